@@ -0,0 +1,257 @@
+// Package autodiscover is the public API for embedding auto-discovery in other Go programs
+//
+// A typical caller creates an AutoDiscovery from its own clients, builds a Plan and executes it:
+//
+//	ad := autodiscover.New(kubeClient, dynamicClient)
+//	plan, err := ad.Plan(ctx, autodiscover.Options{Namespaces: []string{"app"}})
+//	result, err := ad.Execute(ctx, plan, autodiscover.ExecuteOptions{OutputDir: "bundle"})
+//
+// The package depends only on client interfaces, so operators and agents can pass
+// their existing clients or fakes and never import the CLI packages.
+package autodiscover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Options configures discovery, see autodiscovery.DiscoveryOptions
+type Options = autodiscovery.DiscoveryOptions
+
+// Collector is a generated collector specification
+type Collector = autodiscovery.CollectorSpec
+
+// Resource is a Kubernetes resource found during discovery
+type Resource = autodiscovery.Resource
+
+// CollectorLimitReport lists the collectors dropped to honor Options.MaxCollectors
+type CollectorLimitReport = autodiscovery.CollectorLimitReport
+
+// DeprecationReport lists the deprecated APIs the API server warned about during discovery
+type DeprecationReport = autodiscovery.DeprecationReport
+
+// APIUsageReport accounts for the API requests issued during discovery
+type APIUsageReport = autodiscovery.APIUsageReport
+
+// RetryConfig sets how often collectors that fail transiently are rerun, globally and per collector type
+type RetryConfig = autodiscovery.RetryConfig
+
+// RetryPolicy is the retry policy of a collector type, see autodiscovery.RetryPolicy
+type RetryPolicy = autodiscovery.RetryPolicy
+
+// CollectorRetry records the failed attempts of a collector that was retried
+type CollectorRetry = autodiscovery.CollectorRetry
+
+// Errors returned by discovery, match them with errors.Is
+var (
+	ErrRBACForbidden     = autodiscovery.ErrRBACForbidden
+	ErrNamespaceNotFound = autodiscovery.ErrNamespaceNotFound
+	ErrK8sThrottled      = autodiscovery.ErrK8sThrottled
+	ErrSpecInvalid       = autodiscovery.ErrSpecInvalid
+)
+
+// PreFilterHook and PostExpandHook let callers adjust resources and collectors during discovery
+type (
+	PreFilterHook  = autodiscovery.PreFilterHook
+	PostExpandHook = autodiscovery.PostExpandHook
+)
+
+// Discoverer generates collectors for the resources in a cluster
+type Discoverer interface {
+	Discover(ctx context.Context, opts Options) ([]Collector, error)
+}
+
+// Runner runs one collector and returns the files it wrote, relative to outputDir
+type Runner func(ctx context.Context, collector Collector, outputDir string) ([]string, error)
+
+// Plan is the set of collectors that Execute will run
+type Plan struct {
+	Options        Options               `json:"options"`
+	Collectors     []Collector           `json:"collectors"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	CollectorLimit *CollectorLimitReport `json:"collectorLimit,omitempty"` // Set when Options.MaxCollectors dropped collectors
+}
+
+// ExecuteOptions configures Execute
+type ExecuteOptions struct {
+	OutputDir string      // Directory the collectors write to, created when missing
+	Runner    Runner      // Runs each collector, nil writes the collector specs as JSON
+	Retries   RetryConfig // Reruns collectors that fail with a retryable error, the zero value never retries
+}
+
+// CollectorResult is the outcome of running a single collector
+type CollectorResult struct {
+	Name    string   `json:"name"`
+	Outputs []string `json:"outputs,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Result is the outcome of Execute
+type Result struct {
+	OutputDir  string            `json:"outputDir"`
+	Collectors []CollectorResult `json:"collectors"`
+	Failed     int               `json:"failed"`
+	Retries    []CollectorRetry  `json:"retries,omitempty"` // Collectors that were retried, with every failed attempt
+	Duration   time.Duration     `json:"duration"`
+}
+
+// AutoDiscovery discovers collectors and runs them
+type AutoDiscovery struct {
+	discoverer *autodiscovery.Discoverer
+}
+
+// New creates an AutoDiscovery from existing clients
+func New(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface) *AutoDiscovery {
+	return &AutoDiscovery{
+		discoverer: autodiscovery.NewDiscovererForClients(kubeClient, dynamicClient),
+	}
+}
+
+// NewForConfig creates an AutoDiscovery with clients built from a REST config
+func NewForConfig(config *rest.Config) (*AutoDiscovery, error) {
+	discoverer, err := autodiscovery.NewDiscoverer(config)
+	if err != nil {
+		return nil, err
+	}
+	return &AutoDiscovery{discoverer: discoverer}, nil
+}
+
+// AddPreFilterHook registers a hook that runs on discovered resources before collectors are generated
+func (a *AutoDiscovery) AddPreFilterHook(hook PreFilterHook) {
+	a.discoverer.RegisterPreFilterHook(hook)
+}
+
+// AddPostExpandHook registers a hook that runs on the generated collectors
+func (a *AutoDiscovery) AddPostExpandHook(hook PostExpandHook) {
+	a.discoverer.RegisterPostExpandHook(hook)
+}
+
+// Discover returns the collectors for the resources selected by opts, highest priority first
+func (a *AutoDiscovery) Discover(ctx context.Context, opts Options) ([]Collector, error) {
+	return a.discoverer.Discover(ctx, opts)
+}
+
+// CollectorLimitReport returns the collectors dropped by the last Discover to honor Options.MaxCollectors, nil when none were
+func (a *AutoDiscovery) CollectorLimitReport() *CollectorLimitReport {
+	return a.discoverer.CollectorLimitReport()
+}
+
+// DeprecationReport returns the deprecated APIs the server warned about so far, nil unless created by NewForConfig
+func (a *AutoDiscovery) DeprecationReport() *DeprecationReport {
+	return a.discoverer.DeprecationReport()
+}
+
+// APIUsageReport returns the API requests issued so far, nil unless created by NewForConfig
+func (a *AutoDiscovery) APIUsageReport() *APIUsageReport {
+	usage := a.discoverer.APIUsage()
+	if usage == nil {
+		return nil
+	}
+	return usage.Report()
+}
+
+// Plan discovers collectors and returns them as a plan that can be inspected or edited before Execute
+func (a *AutoDiscovery) Plan(ctx context.Context, opts Options) (*Plan, error) {
+	return NewPlan(ctx, a, opts)
+}
+
+// Execute runs the plan, see Execute
+func (a *AutoDiscovery) Execute(ctx context.Context, plan *Plan, opts ExecuteOptions) (*Result, error) {
+	return Execute(ctx, plan, opts)
+}
+
+// NewPlan builds a plan with any Discoverer
+func NewPlan(ctx context.Context, discoverer Discoverer, opts Options) (*Plan, error) {
+	collectors, err := discoverer.Discover(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	plan := &Plan{
+		Options:    opts,
+		Collectors: collectors,
+		CreatedAt:  time.Now(),
+	}
+	// Discoverers that enforce MaxCollectors themselves can report what they dropped
+	if limited, ok := discoverer.(interface{ CollectorLimitReport() *CollectorLimitReport }); ok {
+		plan.CollectorLimit = limited.CollectorLimitReport()
+	}
+	return plan, nil
+}
+
+// Execute runs every collector in the plan in order
+// Collector failures are recorded in the result; Execute stops early only when ctx is cancelled
+// Failures opts.Retries deems transient are retried first, the retries are recorded in the result
+func Execute(ctx context.Context, plan *Plan, opts ExecuteOptions) (*Result, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("plan is required")
+	}
+	if opts.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	runner := opts.Runner
+	if runner == nil {
+		runner = WriteCollectorSpec
+	}
+	retrier, err := autodiscovery.NewCollectorRetrier(opts.Retries)
+	if err != nil {
+		return nil, err
+	}
+	if retrier != nil {
+		run := runner
+		runner = func(ctx context.Context, collector Collector, outputDir string) ([]string, error) {
+			return retrier.Run(ctx, collector, outputDir, run)
+		}
+	}
+
+	startTime := time.Now()
+	result := &Result{OutputDir: opts.OutputDir, Collectors: []CollectorResult{}}
+	for _, collector := range plan.Collectors {
+		if err := ctx.Err(); err != nil {
+			result.Duration = time.Since(startTime)
+			return result, fmt.Errorf("execution interrupted after %d of %d collectors: %w", len(result.Collectors), len(plan.Collectors), err)
+		}
+
+		outputs, err := runner(ctx, collector, opts.OutputDir)
+		collectorResult := CollectorResult{Name: collector.Name, Outputs: outputs}
+		if err != nil {
+			collectorResult.Error = err.Error()
+			result.Failed++
+		}
+		result.Collectors = append(result.Collectors, collectorResult)
+	}
+
+	result.Retries = retrier.Retries()
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// WriteCollectorSpec is the default Runner, it writes the collector spec to collectors/<name>.json
+func WriteCollectorSpec(ctx context.Context, collector Collector, outputDir string) ([]string, error) {
+	output := filepath.Join("collectors", collector.Name+".json")
+	path := filepath.Join(outputDir, output)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for collector %s: %w", collector.Name, err)
+	}
+
+	data, err := json.MarshalIndent(collector, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal collector %s: %w", collector.Name, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write collector %s: %w", collector.Name, err)
+	}
+	return []string{output}, nil
+}
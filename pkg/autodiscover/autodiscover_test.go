@@ -0,0 +1,140 @@
+package autodiscover
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type staticDiscoverer struct {
+	collectors []Collector
+	err        error
+}
+
+func (s *staticDiscoverer) Discover(ctx context.Context, opts Options) ([]Collector, error) {
+	return s.collectors, s.err
+}
+
+func testCollectors() []Collector {
+	return []Collector{
+		{Type: "logs", Name: "logs-app-web", Namespace: "app"},
+		{Type: "cluster-resources", Name: "resources-app-deployments", Namespace: "app"},
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	opts := Options{Namespaces: []string{"app"}}
+	plan, err := NewPlan(context.Background(), &staticDiscoverer{collectors: testCollectors()}, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(plan.Collectors) != 2 {
+		t.Errorf("Expected 2 collectors, got %d", len(plan.Collectors))
+	}
+	if len(plan.Options.Namespaces) != 1 || plan.Options.Namespaces[0] != "app" {
+		t.Errorf("Expected plan options to be recorded, got %v", plan.Options.Namespaces)
+	}
+	if plan.CreatedAt.IsZero() {
+		t.Errorf("Expected plan creation time to be set")
+	}
+
+	if _, err := NewPlan(context.Background(), &staticDiscoverer{err: fmt.Errorf("forbidden")}, opts); err == nil {
+		t.Errorf("Expected discovery error but got none")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "bundle")
+	plan := &Plan{Collectors: testCollectors()}
+
+	result, err := Execute(context.Background(), plan, ExecuteOptions{OutputDir: outputDir})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Collectors) != 2 || result.Failed != 0 {
+		t.Fatalf("Expected 2 successful collectors, got %d with %d failed", len(result.Collectors), result.Failed)
+	}
+	for _, collector := range result.Collectors {
+		if len(collector.Outputs) != 1 {
+			t.Fatalf("Expected 1 output for %s, got %v", collector.Name, collector.Outputs)
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, collector.Outputs[0])); err != nil {
+			t.Errorf("Expected output file for %s: %v", collector.Name, err)
+		}
+	}
+}
+
+func TestExecute_RunnerErrors(t *testing.T) {
+	runner := func(ctx context.Context, collector Collector, outputDir string) ([]string, error) {
+		if collector.Type == "logs" {
+			return nil, fmt.Errorf("pod not found")
+		}
+		return []string{collector.Name + ".json"}, nil
+	}
+
+	result, err := Execute(context.Background(), &Plan{Collectors: testCollectors()}, ExecuteOptions{
+		OutputDir: t.TempDir(),
+		Runner:    runner,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Failed != 1 {
+		t.Errorf("Expected 1 failed collector, got %d", result.Failed)
+	}
+	if result.Collectors[0].Error != "pod not found" {
+		t.Errorf("Expected collector error to be recorded, got %q", result.Collectors[0].Error)
+	}
+}
+
+func TestExecute_Retries(t *testing.T) {
+	failures := 0
+	runner := func(ctx context.Context, collector Collector, outputDir string) ([]string, error) {
+		if collector.Type == "logs" && failures < 2 {
+			failures++
+			return nil, fmt.Errorf("dial tcp 10.0.0.1:443: %w", syscall.ECONNRESET)
+		}
+		return []string{collector.Name + ".json"}, nil
+	}
+
+	result, err := Execute(context.Background(), &Plan{Collectors: testCollectors()}, ExecuteOptions{
+		OutputDir: t.TempDir(),
+		Runner:    runner,
+		Retries:   RetryConfig{Default: RetryPolicy{Attempts: 3, Backoff: time.Millisecond}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Failed != 0 {
+		t.Errorf("Expected the retries to recover the collector, got %d failed", result.Failed)
+	}
+	if len(result.Retries) != 1 || result.Retries[0].Attempts != 3 || !result.Retries[0].Succeeded {
+		t.Errorf("Expected one collector recovered on the third attempt, got %+v", result.Retries)
+	}
+}
+
+func TestExecute_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := Execute(ctx, &Plan{Collectors: testCollectors()}, ExecuteOptions{OutputDir: t.TempDir()})
+	if err == nil {
+		t.Fatalf("Expected error for cancelled context but got none")
+	}
+	if result == nil || len(result.Collectors) != 0 {
+		t.Errorf("Expected partial result with no collectors, got %+v", result)
+	}
+}
+
+func TestExecute_InvalidOptions(t *testing.T) {
+	if _, err := Execute(context.Background(), nil, ExecuteOptions{OutputDir: t.TempDir()}); err == nil {
+		t.Errorf("Expected error for nil plan but got none")
+	}
+	if _, err := Execute(context.Background(), &Plan{}, ExecuteOptions{}); err == nil {
+		t.Errorf("Expected error for missing output directory but got none")
+	}
+}
@@ -0,0 +1,60 @@
+package autodiscover_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/replicatedhq/troubleshoot/pkg/autodiscover"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// An operator that already has clients can plan and run a collection without the CLI
+func Example() {
+	var kubeClient kubernetes.Interface // e.g. from the operator's manager
+	var dynamicClient dynamic.Interface
+	ctx := context.Background()
+
+	ad := autodiscover.New(kubeClient, dynamicClient)
+
+	plan, err := ad.Plan(ctx, autodiscover.Options{
+		Namespaces: []string{"app"},
+		SkipGroups: []string{"images"},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	result, err := ad.Execute(ctx, plan, autodiscover.ExecuteOptions{OutputDir: "/tmp/bundle"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("ran %d collectors, %d failed\n", len(result.Collectors), result.Failed)
+}
+
+// A custom Runner replaces the default, which only records collector specs
+func ExampleExecute() {
+	plan := &autodiscover.Plan{Collectors: []autodiscover.Collector{
+		{Type: "logs", Name: "logs-app-web", Namespace: "app"},
+	}}
+
+	runner := func(ctx context.Context, collector autodiscover.Collector, outputDir string) ([]string, error) {
+		fmt.Printf("collecting %s\n", collector.Name)
+		return nil, nil
+	}
+
+	result, err := autodiscover.Execute(context.Background(), plan, autodiscover.ExecuteOptions{
+		OutputDir: "/tmp/bundle",
+		Runner:    runner,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%d failed\n", result.Failed)
+	// Output:
+	// collecting logs-app-web
+	// 0 failed
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// AnalysisFileName is the bundle file that holds auto-generated analyzer results
+const AnalysisFileName = "analysis.json"
+
+// AnalysisSummary counts analyzer results by outcome
+type AnalysisSummary struct {
+	Total int `json:"total"`
+	Pass  int `json:"pass"`
+	Warn  int `json:"warn"`
+	Fail  int `json:"fail"`
+}
+
+// AnalysisReport is written to the bundle alongside the collected data
+type AnalysisReport struct {
+	Summary   AnalysisSummary                `json:"summary"`
+	Analyzers []autodiscovery.AnalyzerSpec   `json:"analyzers"`
+	Results   []autodiscovery.AnalyzerResult `json:"results"`
+}
+
+// SummarizeAnalysisResults counts pass/warn/fail outcomes
+func SummarizeAnalysisResults(results []autodiscovery.AnalyzerResult) AnalysisSummary {
+	summary := AnalysisSummary{Total: len(results)}
+
+	for _, result := range results {
+		switch result.Result {
+		case autodiscovery.AnalyzerResultPass:
+			summary.Pass++
+		case autodiscovery.AnalyzerResultWarn:
+			summary.Warn++
+		case autodiscovery.AnalyzerResultFail:
+			summary.Fail++
+		}
+	}
+
+	return summary
+}
+
+// writeAnalysisReport writes the analysis report into the bundle output directory
+func writeAnalysisReport(outputDir string, report *AnalysisReport) (string, error) {
+	path := filepath.Join(outputDir, AnalysisFileName)
+	if err := writeJSONFile(path, report); err != nil {
+		return "", fmt.Errorf("failed to write analysis report: %w", err)
+	}
+	return path, nil
+}
+
+// printAnalysisResults prints analyzer outcomes, failures first
+func printAnalysisResults(report *AnalysisReport) {
+	fmt.Printf("\n🔬 Analysis Results:\n")
+	fmt.Printf("   Pass: %d  Warn: %d  Fail: %d\n", report.Summary.Pass, report.Summary.Warn, report.Summary.Fail)
+
+	for _, outcome := range []string{autodiscovery.AnalyzerResultFail, autodiscovery.AnalyzerResultWarn} {
+		for _, result := range report.Results {
+			if result.Result != outcome {
+				continue
+			}
+
+			icon := "❌"
+			if outcome == autodiscovery.AnalyzerResultWarn {
+				icon = "⚠️ "
+			}
+			fmt.Printf("   %s %s", icon, result.Message)
+			if result.Detail != "" {
+				fmt.Printf(" (%s)", result.Detail)
+			}
+			if result.Error != "" {
+				fmt.Printf(": %s", result.Error)
+			}
+			fmt.Printf("\n")
+		}
+	}
+}
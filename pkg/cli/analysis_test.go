@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestSummarizeAnalysisResults(t *testing.T) {
+	results := []autodiscovery.AnalyzerResult{
+		{AnalyzerName: "a", Result: autodiscovery.AnalyzerResultPass},
+		{AnalyzerName: "b", Result: autodiscovery.AnalyzerResultPass},
+		{AnalyzerName: "c", Result: autodiscovery.AnalyzerResultWarn},
+		{AnalyzerName: "d", Result: autodiscovery.AnalyzerResultFail},
+	}
+
+	summary := SummarizeAnalysisResults(results)
+
+	if summary.Total != 4 {
+		t.Errorf("Expected total 4, got %d", summary.Total)
+	}
+	if summary.Pass != 2 || summary.Warn != 1 || summary.Fail != 1 {
+		t.Errorf("Expected 2 pass, 1 warn, 1 fail, got %d/%d/%d", summary.Pass, summary.Warn, summary.Fail)
+	}
+}
+
+func TestWriteAnalysisReport(t *testing.T) {
+	outputDir := t.TempDir()
+	results := []autodiscovery.AnalyzerResult{
+		{AnalyzerName: "auto-node-pressure", Type: autodiscovery.AnalyzerTypeNodeResources, Result: autodiscovery.AnalyzerResultWarn},
+	}
+	report := &AnalysisReport{
+		Summary: SummarizeAnalysisResults(results),
+		Results: results,
+	}
+
+	path, err := writeAnalysisReport(outputDir, report)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != filepath.Join(outputDir, AnalysisFileName) {
+		t.Errorf("Expected report at %s, got %s", filepath.Join(outputDir, AnalysisFileName), path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+
+	var loaded AnalysisReport
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if loaded.Summary.Warn != 1 || len(loaded.Results) != 1 {
+		t.Errorf("Expected report with 1 warning, got %+v", loaded.Summary)
+	}
+}
@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IdentifierKind is the kind of cluster identifier being anonymized
+type IdentifierKind string
+
+const (
+	IdentifierNamespace IdentifierKind = "namespace"
+	IdentifierNode      IdentifierKind = "node"
+	IdentifierRegistry  IdentifierKind = "registry"
+)
+
+// Pseudonymizer maps a cluster identifier to a stable pseudonym
+type Pseudonymizer interface {
+	Pseudonymize(kind IdentifierKind, value string) string
+}
+
+// HMACPseudonymizer derives pseudonyms from a keyed hash so they can't be reversed
+// by hashing well-known names like "default" or "kube-system"
+type HMACPseudonymizer struct {
+	key []byte
+}
+
+// NewHMACPseudonymizer creates a pseudonymizer; the same key always yields the same pseudonyms
+func NewHMACPseudonymizer(key []byte) *HMACPseudonymizer {
+	return &HMACPseudonymizer{key: key}
+}
+
+// NewRandomHMACPseudonymizer creates a pseudonymizer with a random key, consistent within one bundle only
+func NewRandomHMACPseudonymizer() (*HMACPseudonymizer, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate anonymization key: %w", err)
+	}
+	return NewHMACPseudonymizer(key), nil
+}
+
+// Pseudonymize returns the pseudonym for an identifier
+func (hp *HMACPseudonymizer) Pseudonymize(kind IdentifierKind, value string) string {
+	mac := hmac.New(sha256.New, hp.key)
+	mac.Write([]byte(string(kind) + ":" + value))
+	sum := hex.EncodeToString(mac.Sum(nil))[:10]
+
+	switch kind {
+	case IdentifierNamespace:
+		return "ns-" + sum
+	case IdentifierNode:
+		return "node-" + sum
+	case IdentifierRegistry:
+		// Keep a dot so the anonymized hostname is still parsed as a registry in image references
+		return "registry-" + sum + ".invalid"
+	default:
+		return string(kind) + "-" + sum
+	}
+}
+
+// AnonymizationMapping records the pseudonym assigned to each identifier
+// It is kept outside the bundle so the bundle can be shared without it
+type AnonymizationMapping struct {
+	CreatedAt   time.Time                            `json:"createdAt"`
+	BundlePath  string                               `json:"bundlePath,omitempty"`
+	Identifiers map[IdentifierKind]map[string]string `json:"identifiers"`
+}
+
+// BundleAnonymizer rewrites namespace names, node names and registry hostnames across a bundle
+type BundleAnonymizer struct {
+	pseudonymizer Pseudonymizer
+	identifiers   map[IdentifierKind]map[string]string
+	replacements  map[string]string
+}
+
+// NewBundleAnonymizer creates a new BundleAnonymizer using the given pseudonymizer
+func NewBundleAnonymizer(pseudonymizer Pseudonymizer) *BundleAnonymizer {
+	return &BundleAnonymizer{
+		pseudonymizer: pseudonymizer,
+		identifiers:   make(map[IdentifierKind]map[string]string),
+		replacements:  make(map[string]string),
+	}
+}
+
+// AddIdentifier registers an identifier to be anonymized and returns its pseudonym
+func (ba *BundleAnonymizer) AddIdentifier(kind IdentifierKind, value string) string {
+	if value == "" {
+		return ""
+	}
+	if pseudonym, exists := ba.replacements[value]; exists {
+		return pseudonym
+	}
+
+	pseudonym := ba.pseudonymizer.Pseudonymize(kind, value)
+	if ba.identifiers[kind] == nil {
+		ba.identifiers[kind] = make(map[string]string)
+	}
+	ba.identifiers[kind][value] = pseudonym
+	ba.replacements[value] = pseudonym
+
+	return pseudonym
+}
+
+// AddNamespace registers a namespace name
+func (ba *BundleAnonymizer) AddNamespace(namespace string) {
+	ba.AddIdentifier(IdentifierNamespace, namespace)
+}
+
+// AddNode registers a node name
+func (ba *BundleAnonymizer) AddNode(node string) {
+	ba.AddIdentifier(IdentifierNode, node)
+}
+
+// AddImage registers the registry hostname of an image reference
+// Docker Hub images without an explicit registry have no hostname to hide
+func (ba *BundleAnonymizer) AddImage(imageRef string) {
+	if registry := imageRegistryHost(imageRef); registry != "" {
+		ba.AddIdentifier(IdentifierRegistry, registry)
+	}
+}
+
+// DiscoverIdentifiers registers identifiers found under well-known keys in the bundle's JSON files
+func (ba *BundleAnonymizer) DiscoverIdentifiers(bundleDir string) error {
+	return filepath.WalkDir(bundleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var content interface{}
+		if err := json.Unmarshal(data, &content); err != nil {
+			// Not every .json file has to be valid, it is still rewritten textually
+			return nil
+		}
+		ba.discoverInValue(content)
+
+		return nil
+	})
+}
+
+// discoverInValue walks decoded JSON and registers values of identifier keys
+func (ba *BundleAnonymizer) discoverInValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok {
+				switch key {
+				case "namespace":
+					ba.AddNamespace(s)
+				case "nodeName":
+					ba.AddNode(s)
+				case "image":
+					ba.AddImage(s)
+				}
+				continue
+			}
+			ba.discoverInValue(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			ba.discoverInValue(child)
+		}
+	}
+}
+
+// Anonymize replaces every registered identifier in the text with its pseudonym
+func (ba *BundleAnonymizer) Anonymize(text string) string {
+	pattern := ba.pattern()
+	if pattern == nil {
+		return text
+	}
+	return pattern.ReplaceAllStringFunc(text, func(match string) string {
+		return ba.replacements[match]
+	})
+}
+
+// AnonymizeBundle rewrites every file in the bundle and returns the number of files changed
+func (ba *BundleAnonymizer) AnonymizeBundle(bundleDir string) (int, error) {
+	pattern := ba.pattern()
+	if pattern == nil {
+		return 0, nil
+	}
+
+	changed := 0
+	var renames []string
+	err := filepath.WalkDir(bundleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Per-namespace directories and files carry identifiers in their names too
+		if path != bundleDir && pattern.MatchString(d.Name()) {
+			renames = append(renames, path)
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		// Skip binary content
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil
+		}
+
+		rewritten := pattern.ReplaceAllFunc(data, func(match []byte) []byte {
+			return []byte(ba.replacements[string(match)])
+		})
+		if bytes.Equal(rewritten, data) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, rewritten, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		changed++
+
+		return nil
+	})
+	if err != nil {
+		return changed, fmt.Errorf("failed to anonymize bundle: %w", err)
+	}
+
+	// Rename deepest paths first so parent directories still have their original names
+	sort.Slice(renames, func(i, j int) bool {
+		return strings.Count(renames[i], string(filepath.Separator)) > strings.Count(renames[j], string(filepath.Separator))
+	})
+	for _, path := range renames {
+		renamed := filepath.Join(filepath.Dir(path), ba.Anonymize(filepath.Base(path)))
+		if err := os.Rename(path, renamed); err != nil {
+			return changed, fmt.Errorf("failed to rename %s: %w", path, err)
+		}
+	}
+
+	return changed, nil
+}
+
+// Mapping returns a copy of the identifier to pseudonym mapping
+func (ba *BundleAnonymizer) Mapping() AnonymizationMapping {
+	mapping := AnonymizationMapping{
+		CreatedAt:   time.Now(),
+		Identifiers: make(map[IdentifierKind]map[string]string),
+	}
+	for kind, values := range ba.identifiers {
+		mapping.Identifiers[kind] = make(map[string]string, len(values))
+		for original, pseudonym := range values {
+			mapping.Identifiers[kind][original] = pseudonym
+		}
+	}
+	return mapping
+}
+
+// WriteMapping writes the mapping file, refusing to place it inside the bundle
+func (ba *BundleAnonymizer) WriteMapping(path, bundleDir string) error {
+	if isWithinDir(path, bundleDir) {
+		return fmt.Errorf("anonymization mapping %s must be kept outside the bundle directory", path)
+	}
+
+	mapping := ba.Mapping()
+	mapping.BundlePath = bundleDir
+
+	return writeJSONFile(path, mapping)
+}
+
+// pattern builds a regexp matching any registered identifier as a whole word
+func (ba *BundleAnonymizer) pattern() *regexp.Regexp {
+	if len(ba.replacements) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(ba.replacements))
+	for value := range ba.replacements {
+		values = append(values, value)
+	}
+	// Longest first so "app-prod" wins over "app"
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+
+	return regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// DefaultAnonymizationMappingPath returns the mapping file location next to the bundle directory
+func DefaultAnonymizationMappingPath(bundleDir string) string {
+	return filepath.Clean(bundleDir) + "-anonymization-mapping.json"
+}
+
+// imageRegistryHost returns the explicit registry hostname of an image reference
+func imageRegistryHost(imageRef string) string {
+	parts := strings.SplitN(imageRef, "/", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	host := parts[0]
+	if strings.Contains(host, ".") || strings.Contains(host, ":") || host == "localhost" {
+		return host
+	}
+	return ""
+}
+
+// isWithinDir checks whether path is dir or located below it
+func isWithinDir(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
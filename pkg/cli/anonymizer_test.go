@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHMACPseudonymizer_Pseudonymize(t *testing.T) {
+	p := NewHMACPseudonymizer([]byte("key"))
+
+	first := p.Pseudonymize(IdentifierNamespace, "payments")
+	if first != p.Pseudonymize(IdentifierNamespace, "payments") {
+		t.Errorf("Expected pseudonyms to be deterministic for the same key")
+	}
+	if !strings.HasPrefix(first, "ns-") {
+		t.Errorf("Expected namespace pseudonym to start with ns-, got %s", first)
+	}
+	if first == NewHMACPseudonymizer([]byte("other")).Pseudonymize(IdentifierNamespace, "payments") {
+		t.Errorf("Expected different keys to yield different pseudonyms")
+	}
+	if first == p.Pseudonymize(IdentifierNode, "payments") {
+		t.Errorf("Expected the identifier kind to be part of the pseudonym")
+	}
+
+	registry := p.Pseudonymize(IdentifierRegistry, "registry.corp.example.com")
+	if !strings.HasPrefix(registry, "registry-") || !strings.HasSuffix(registry, ".invalid") {
+		t.Errorf("Expected registry pseudonym to look like a hostname, got %s", registry)
+	}
+}
+
+func TestImageRegistryHost(t *testing.T) {
+	tests := []struct {
+		imageRef string
+		expected string
+	}{
+		{"registry.corp.example.com/team/app:1.0", "registry.corp.example.com"},
+		{"localhost:5000/app", "localhost:5000"},
+		{"localhost/app", "localhost"},
+		{"nginx:1.25", ""},
+		{"library/nginx", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.imageRef, func(t *testing.T) {
+			if host := imageRegistryHost(tt.imageRef); host != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, host)
+			}
+		})
+	}
+}
+
+func TestBundleAnonymizer_Anonymize(t *testing.T) {
+	anonymizer := NewBundleAnonymizer(NewHMACPseudonymizer([]byte("key")))
+	app := anonymizer.AddIdentifier(IdentifierNamespace, "app")
+	appProd := anonymizer.AddIdentifier(IdentifierNamespace, "app-prod")
+	node := anonymizer.AddIdentifier(IdentifierNode, "ip-10-0-1-5.ec2.internal")
+	anonymizer.AddImage("registry.corp.example.com/team/web:1.0")
+	registry := anonymizer.Mapping().Identifiers[IdentifierRegistry]["registry.corp.example.com"]
+
+	input := "pod web in app-prod on ip-10-0-1-5.ec2.internal pulled registry.corp.example.com/team/web:1.0; app ok; application untouched"
+	expected := "pod web in " + appProd + " on " + node + " pulled " + registry + "/team/web:1.0; " + app + " ok; application untouched"
+
+	if output := anonymizer.Anonymize(input); output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+func TestBundleAnonymizer_AnonymizeBundle(t *testing.T) {
+	bundleDir := t.TempDir()
+	files := map[string]string{
+		"namespaces/payments/summary.json": `{"namespace": "payments", "pods": [{"nodeName": "worker-1", "image": "registry.corp.example.com/pay:2"}]}`,
+		"namespaces/index.json":            `{"namespaces": [{"namespace": "payments", "path": "namespaces/payments/summary.json"}]}`,
+		"logs/app.log":                     "scheduled on worker-1 in payments\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(bundleDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	anonymizer := NewBundleAnonymizer(NewHMACPseudonymizer([]byte("key")))
+	if err := anonymizer.DiscoverIdentifiers(bundleDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	mapping := anonymizer.Mapping()
+	ns := mapping.Identifiers[IdentifierNamespace]["payments"]
+	node := mapping.Identifiers[IdentifierNode]["worker-1"]
+	if ns == "" || node == "" || mapping.Identifiers[IdentifierRegistry]["registry.corp.example.com"] == "" {
+		t.Fatalf("Expected namespace, node and registry to be discovered, got %v", mapping.Identifiers)
+	}
+
+	changed, err := anonymizer.AnonymizeBundle(bundleDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if changed != 3 {
+		t.Errorf("Expected 3 files changed, got %d", changed)
+	}
+
+	if _, err := os.Stat(filepath.Join(bundleDir, "namespaces", "payments")); !os.IsNotExist(err) {
+		t.Errorf("Expected namespace directory to be renamed")
+	}
+	summary, err := os.ReadFile(filepath.Join(bundleDir, "namespaces", ns, "summary.json"))
+	if err != nil {
+		t.Fatalf("Expected anonymized summary path: %v", err)
+	}
+
+	log, err := os.ReadFile(filepath.Join(bundleDir, "logs", "app.log"))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+
+	for _, content := range []string{string(summary), string(log)} {
+		for _, original := range []string{"payments", "worker-1", "registry.corp.example.com"} {
+			if strings.Contains(content, original) {
+				t.Errorf("Expected %q to be anonymized in %q", original, content)
+			}
+		}
+	}
+	if !strings.Contains(string(log), node) {
+		t.Errorf("Expected log to contain node pseudonym %s, got %q", node, log)
+	}
+}
+
+func TestBundleAnonymizer_WriteMapping(t *testing.T) {
+	dir := t.TempDir()
+	bundleDir := filepath.Join(dir, "bundle")
+
+	anonymizer := NewBundleAnonymizer(NewHMACPseudonymizer([]byte("key")))
+	anonymizer.AddNamespace("payments")
+
+	if err := anonymizer.WriteMapping(filepath.Join(bundleDir, "mapping.json"), bundleDir); err == nil {
+		t.Errorf("Expected error when writing the mapping inside the bundle")
+	}
+
+	mappingPath := DefaultAnonymizationMappingPath(bundleDir)
+	if err := anonymizer.WriteMapping(mappingPath, bundleDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(mappingPath); err != nil {
+		t.Errorf("Expected mapping file at %s: %v", mappingPath, err)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// maxAPIUsageCallsListed limits the request groups printed individually after a run
+const maxAPIUsageCallsListed = 5
+
+// setAPIUsagePhase accounts the API requests that follow to phase, e.g. autodiscovery.APIUsagePhaseCollection
+func (sbc *SupportBundleCollector) setAPIUsagePhase(phase string) {
+	if usage := sbc.discoverer.APIUsage(); usage != nil {
+		usage.SetPhase(phase)
+	}
+}
+
+// apiUsageReport returns the API requests of the run so far, nil when they were not recorded
+func (sbc *SupportBundleCollector) apiUsageReport() *autodiscovery.APIUsageReport {
+	usage := sbc.discoverer.APIUsage()
+	if usage == nil {
+		return nil
+	}
+	return usage.Report()
+}
+
+// printAPIUsageSummary prints the request totals of the run and its most frequent requests
+func printAPIUsageSummary(report *autodiscovery.APIUsageReport) {
+	if report == nil || report.Summary.Requests == 0 {
+		return
+	}
+	writeAPIUsageSummary(os.Stdout, report)
+}
+
+// writeAPIUsageSummary writes the request totals, per phase, and the most frequent requests
+func writeAPIUsageSummary(w io.Writer, report *autodiscovery.APIUsageReport) {
+	summary := report.Summary
+	fmt.Fprintf(w, "📡 API usage: %d requests, %d errors, %v total request time\n",
+		summary.Requests, summary.Errors, summary.TotalDuration.Round(time.Millisecond))
+
+	phases := make([]string, 0, len(summary.ByPhase))
+	for phase := range summary.ByPhase {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	for _, phase := range phases {
+		fmt.Fprintf(w, "   %s: %d requests\n", phase, summary.ByPhase[phase])
+	}
+
+	for i, call := range report.Calls {
+		if i == maxAPIUsageCallsListed {
+			fmt.Fprintf(w, "   ... and %d more, see %s\n", len(report.Calls)-i, autodiscovery.APIUsageFileName)
+			break
+		}
+		fmt.Fprintf(w, "   %dx %s (%v)\n", call.Requests, call, call.TotalDuration.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestWriteAPIUsageSummary(t *testing.T) {
+	recorder := autodiscovery.NewAPIUsageRecorder()
+	for i := 0; i < 7; i++ {
+		u, _ := url.Parse("/api/v1/namespaces/ns-" + string(rune('a'+i)) + "/pods")
+		recorder.Record("GET", u, 10*time.Millisecond, false)
+	}
+	logs, _ := url.Parse("/api/v1/namespaces/ns-a/pods/web-0/log")
+	recorder.SetPhase(autodiscovery.APIUsagePhaseCollection)
+	recorder.Record("GET", logs, 40*time.Millisecond, false)
+	recorder.Record("GET", logs, 40*time.Millisecond, true)
+
+	var buf bytes.Buffer
+	writeAPIUsageSummary(&buf, recorder.Report())
+	output := buf.String()
+
+	expected := []string{
+		"📡 API usage: 9 requests, 1 errors, 150ms total request time",
+		"   collection: 2 requests",
+		"   discovery: 7 requests",
+		"   2x get v1 pods/log in ns-a (80ms)",
+		"   ... and 3 more, see api-usage.json",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// AuditDirName is the bundle directory holding audit notes about how the bundle was collected
+const AuditDirName = "audit"
+
+// SystemNamespaceAuditNote records that the default system namespace excludes were disabled
+type SystemNamespaceAuditNote struct {
+	Timestamp time.Time                           `json:"timestamp"`
+	Note      string                              `json:"note"`
+	Policy    autodiscovery.SystemNamespacePolicy `json:"policy"`
+}
+
+// writeSystemNamespaceAuditNote writes the system namespace audit note into the bundle
+func writeSystemNamespaceAuditNote(outputDir string, policy autodiscovery.SystemNamespacePolicy) (string, error) {
+	note := SystemNamespaceAuditNote{
+		Timestamp: time.Now(),
+		Note:      fmt.Sprintf("Default system namespace excludes were disabled; %v may be included in this bundle", policy.SystemNamespaces),
+		Policy:    policy,
+	}
+
+	path := filepath.Join(outputDir, AuditDirName, "system-namespaces.json")
+	if err := writeJSONFile(path, note); err != nil {
+		return "", fmt.Errorf("failed to write system namespace audit note: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestWriteSystemNamespaceAuditNote(t *testing.T) {
+	outputDir := t.TempDir()
+	configManager := autodiscovery.NewConfigManager()
+	configManager.SetIncludeSystemNamespaces(true)
+
+	path, err := writeSystemNamespaceAuditNote(outputDir, configManager.GetSystemNamespacePolicy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != filepath.Join(outputDir, AuditDirName, "system-namespaces.json") {
+		t.Errorf("Unexpected audit note path %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read audit note: %v", err)
+	}
+
+	var note SystemNamespaceAuditNote
+	if err := json.Unmarshal(data, &note); err != nil {
+		t.Fatalf("Failed to parse audit note: %v", err)
+	}
+	if !note.Policy.IncludeSystemNamespaces {
+		t.Errorf("Expected audit note to record that system namespaces were included")
+	}
+	if note.Note == "" {
+		t.Errorf("Expected audit note message")
+	}
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBundleArchive(t *testing.T) {
+	tests := []struct {
+		compression string
+		extension   string
+	}{
+		{compression: CompressionGzip, extension: ".tar.gz"},
+		{compression: CompressionZstd, extension: ".tar.zst"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			if tt.compression == CompressionZstd {
+				if _, err := exec.LookPath("zstd"); err != nil {
+					t.Skip("zstd is not installed")
+				}
+			}
+
+			bundleDir := filepath.Join(t.TempDir(), "support-bundle-2024-01-01T00-00-00")
+			files := writeInspectBundle(t, bundleDir)
+
+			format, err := ArchiveFormatFor(tt.compression)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			archivePath, err := WriteBundleArchive(bundleDir, format)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if archivePath != bundleDir+tt.extension {
+				t.Errorf("Expected archive %s, got %s", bundleDir+tt.extension, archivePath)
+			}
+			if _, err := os.Stat(bundleDir); !os.IsNotExist(err) {
+				t.Errorf("Expected the bundle directory to be removed, got %v", err)
+			}
+
+			reader, err := OpenBundle(archivePath)
+			if err != nil {
+				t.Fatalf("Failed to open archive: %v", err)
+			}
+			for name, expected := range files {
+				data, err := reader.ReadFile(name)
+				if err != nil {
+					t.Errorf("Failed to read %s from archive: %v", name, err)
+					continue
+				}
+				if !bytes.Equal(data, expected) {
+					t.Errorf("Expected %s to round-trip, got %q", name, data)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyBundle_Archive(t *testing.T) {
+	dir := writeTestBundle(t)
+	if _, err := SignBundle(dir, nil); err != nil {
+		t.Fatalf("Failed to sign bundle: %v", err)
+	}
+
+	archivePath, err := WriteBundleArchive(dir, gzipArchiveFormat{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := VerifyBundle(archivePath, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Valid || result.FilesChecked != 2 {
+		t.Errorf("Expected the archived bundle to verify with 2 files checked, got %+v", result)
+	}
+}
+
+func TestArchiveFormatFor(t *testing.T) {
+	tests := []struct {
+		compression string
+		expected    string
+		expectError bool
+	}{
+		{compression: "", expected: CompressionGzip},
+		{compression: "gzip", expected: CompressionGzip},
+		{compression: "zstd", expected: CompressionZstd},
+		{compression: "none", expected: ""},
+		{compression: "bzip2", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			format, err := ArchiveFormatFor(tt.compression)
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "unknown compression") {
+					t.Errorf("Expected an unknown compression error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			name := ""
+			if format != nil {
+				name = format.Name()
+			}
+			if name != tt.expected {
+				t.Errorf("Expected format %q, got %q", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestZstdReader_CorruptStream(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd is not installed")
+	}
+
+	var compressed bytes.Buffer
+	writer, err := zstdArchiveFormat{}.NewWriter(&compressed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	writer.Write([]byte("bundle data"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	compressed.WriteString("not a zstd frame")
+
+	reader, err := zstdArchiveFormat{}.NewReader(&compressed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	if string(data) != "bundle data" {
+		t.Errorf("Expected the valid frame to be decompressed, got %q", data)
+	}
+	if err := reader.Close(); err == nil || !strings.Contains(err.Error(), "zstd failed") {
+		t.Errorf("Expected the corrupt stream to be reported on Close, got %v", err)
+	}
+}
+
+func TestCollectWithAutoDiscovery_CompressionValidation(t *testing.T) {
+	sbc := &SupportBundleCollector{}
+
+	_, err := sbc.CollectWithAutoDiscovery(context.Background(), SupportBundleCollectOptions{Quiet: true, Compression: "lz4"})
+	if err == nil || !strings.Contains(err.Error(), "invalid --compression") {
+		t.Errorf("Expected invalid --compression error, got %v", err)
+	}
+
+	_, err = sbc.CollectWithAutoDiscovery(context.Background(), SupportBundleCollectOptions{Quiet: true, DryRun: true, Compression: "gzip"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be used with --dry-run") {
+		t.Errorf("Expected --dry-run error, got %v", err)
+	}
+}
+
+// benchmarkBundleArchive archives a bundle of compressible logs and incompressible blobs, 64 MiB in total
+func benchmarkBundleArchive(b *testing.B, compression string) {
+	if compression == CompressionZstd {
+		if _, err := exec.LookPath("zstd"); err != nil {
+			b.Skip("zstd is not installed")
+		}
+	}
+	format, _ := ArchiveFormatFor(compression)
+
+	logLine := []byte("2024-01-01T00:00:00Z INFO request completed status=200 path=/api/v1/items duration=12ms\n")
+	logs := bytes.Repeat(logLine, (4<<20)/len(logLine))
+	blob := make([]byte, 4<<20)
+	rand.Read(blob)
+
+	b.SetBytes(64 << 20)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		bundleDir := filepath.Join(b.TempDir(), "bundle")
+		for j := 0; j < 8; j++ {
+			os.MkdirAll(filepath.Join(bundleDir, "logs"), 0755)
+			os.MkdirAll(filepath.Join(bundleDir, "images"), 0755)
+			os.WriteFile(filepath.Join(bundleDir, "logs", fmt.Sprintf("pod-%d.log", j)), logs, 0644)
+			os.WriteFile(filepath.Join(bundleDir, "images", fmt.Sprintf("blob-%d", j)), blob, 0644)
+		}
+		b.StartTimer()
+
+		if _, err := WriteBundleArchive(bundleDir, format); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteBundleArchive_Gzip(b *testing.B) { benchmarkBundleArchive(b, CompressionGzip) }
+func BenchmarkWriteBundleArchive_Zstd(b *testing.B) { benchmarkBundleArchive(b, CompressionZstd) }
@@ -0,0 +1,462 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/replicatedhq/troubleshoot/pkg/autodiscover"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+// DiscoveryManifestFileName is the file recording the discovery options and generated collectors of a bundle
+const DiscoveryManifestFileName = "discovery.json"
+
+// Inspect subcommands
+const (
+	InspectCollectors = "collectors"
+	InspectManifest   = "manifest"
+	InspectImages     = "images"
+	InspectGrep       = "grep"
+	InspectLayout     = "layout"
+	InspectSearch     = "search"
+)
+
+// imageFactsPaths are the locations image facts are written to, in lookup order
+var imageFactsPaths = []string{"images/facts.json", "facts.json", "images/" + images.FactsStreamFileName, images.FactsStreamFileName}
+
+// bundleDirs are the top-level bundle directories, never mistaken for an archive's root directory
+var bundleDirs = map[string]bool{
+	"collectors":   true,
+	"images":       true,
+	"namespaces":   true,
+	"audit":        true,
+	"cluster-info": true,
+	"rollouts":     true,
+	"storage":      true,
+	"tables":       true,
+	"webhooks":     true,
+}
+
+// InspectBundleOptions configures `support-bundle inspect <bundle>`
+type InspectBundleOptions struct {
+	BundlePath string `json:"bundlePath"`           // Bundle directory or .tgz/.tar.gz/.tar.zst archive
+	Command    string `json:"command"`              // "collectors", "manifest", "images", "grep", "layout" or "search"
+	Pattern    string `json:"pattern,omitempty"`    // Regular expression for grep, text for search
+	IgnoreCase bool   `json:"ignoreCase,omitempty"` // Case-insensitive grep or search
+	Output     string `json:"output,omitempty"`     // "console" or "json"
+}
+
+// LogMatch is a line matched by grep or search
+type LogMatch struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// ImageFactsInspection summarizes the image facts stored in a bundle
+type ImageFactsInspection struct {
+	File    string                        `json:"file"`
+	Summary images.ImageFactsSummary      `json:"summary"`
+	Images  map[string]*images.ImageFacts `json:"images"`
+}
+
+// BundleReader reads files from a bundle directory or archive without extracting it
+type BundleReader struct {
+	path    string
+	archive bool
+	root    string // Top-level directory of the archive, stripped from entry names
+}
+
+// OpenBundle opens a bundle directory or a .tar.gz or .tar.zst archive of one
+func OpenBundle(bundlePath string) (*BundleReader, error) {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	if info.IsDir() {
+		return &BundleReader{path: bundlePath}, nil
+	}
+
+	reader := &BundleReader{path: bundlePath, archive: true}
+	root, err := reader.archiveRoot()
+	if err != nil {
+		return nil, err
+	}
+	reader.root = root
+	return reader, nil
+}
+
+// Walk calls fn for every regular file in the bundle with its slash-separated path relative to the bundle root
+func (b *BundleReader) Walk(fn func(name string, r io.Reader) error) error {
+	if !b.archive {
+		return filepath.WalkDir(b.path, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(b.path, filePath)
+			if err != nil {
+				return err
+			}
+			file, err := os.Open(filePath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return fn(filepath.ToSlash(rel), file)
+		})
+	}
+
+	return b.walkArchive(func(header *tar.Header, r io.Reader) error {
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if b.root != "" {
+			name = strings.TrimPrefix(name, b.root+"/")
+		}
+		return fn(name, r)
+	})
+}
+
+// ReadFile returns the contents of one bundle file, os.ErrNotExist when it is missing
+func (b *BundleReader) ReadFile(name string) ([]byte, error) {
+	var data []byte
+	found := false
+	err := b.Walk(func(fileName string, r io.Reader) error {
+		if found || fileName != name {
+			return nil
+		}
+		found = true
+		var err error
+		data, err = io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	return data, nil
+}
+
+func (b *BundleReader) walkArchive(fn func(header *tar.Header, r io.Reader) error) error {
+	file, err := os.Open(b.path)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle archive: %w", err)
+	}
+	defer file.Close()
+
+	format := archiveFormatForPath(b.path)
+	decompressed, err := format.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("bundle is neither a directory nor a %s tar archive: %w", format.Name(), err)
+	}
+	defer decompressed.Close()
+
+	tr := tar.NewReader(decompressed)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			// A decompressor can fail after the last entry, e.g. on a truncated stream, so it is read to its end
+			if _, err := io.Copy(io.Discard, decompressed); err != nil {
+				return fmt.Errorf("failed to read bundle archive: %w", err)
+			}
+			if err := decompressed.Close(); err != nil {
+				return fmt.Errorf("failed to read bundle archive: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle archive: %w", err)
+		}
+		if err := fn(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// archiveRoot returns the directory every archive entry is under, as when a bundle directory is tarred whole
+func (b *BundleReader) archiveRoot() (string, error) {
+	root := ""
+	shared := true
+	err := b.walkArchive(func(header *tar.Header, r io.Reader) error {
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		first, _, nested := strings.Cut(name, "/")
+		// Only the root directory entry itself may sit at the top level
+		if !nested && header.Typeflag != tar.TypeDir {
+			shared = false
+		}
+		if root == "" {
+			root = first
+		} else if first != root {
+			shared = false
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !shared || bundleDirs[root] {
+		return "", nil
+	}
+	return root, nil
+}
+
+// ListCollectors returns the collector specs recorded under collectors/, sorted by name
+func (b *BundleReader) ListCollectors() ([]autodiscovery.CollectorSpec, error) {
+	var collectors []autodiscovery.CollectorSpec
+	err := b.Walk(func(name string, r io.Reader) error {
+		if !strings.HasPrefix(name, "collectors/") || path.Ext(name) != ".json" {
+			return nil
+		}
+		var collector autodiscovery.CollectorSpec
+		if err := json.NewDecoder(r).Decode(&collector); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		collectors = append(collectors, collector)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].Name < collectors[j].Name
+	})
+	return collectors, nil
+}
+
+// DiscoveryManifest returns the discovery options and collectors recorded in discovery.json
+func (b *BundleReader) DiscoveryManifest() (*autodiscover.Plan, error) {
+	data, err := b.ReadFile(DiscoveryManifestFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discovery manifest: %w", err)
+	}
+	var plan autodiscover.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse discovery manifest: %w", err)
+	}
+	return &plan, nil
+}
+
+// ImageFacts returns the image facts stored in the bundle
+func (b *BundleReader) ImageFacts() (*ImageFactsInspection, error) {
+	for _, name := range imageFactsPaths {
+		data, err := b.ReadFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var output images.ImageFactsOutput
+		if path.Ext(name) == ".jsonl" {
+			stream, err := images.LoadFactsStream(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+			}
+			output = *stream
+		} else if err := json.Unmarshal(data, &output); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		return &ImageFactsInspection{File: name, Summary: output.Summary, Images: output.Facts}, nil
+	}
+	return nil, fmt.Errorf("bundle has no image facts, collect with --include-images")
+}
+
+// GrepLogs returns the log lines matching pattern
+func (b *BundleReader) GrepLogs(pattern *regexp.Regexp) ([]LogMatch, error) {
+	var matches []LogMatch
+	err := b.Walk(func(name string, r io.Reader) error {
+		if !isLogFile(name) {
+			return nil
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if pattern.MatchString(scanner.Text()) {
+				matches = append(matches, LogMatch{File: name, Line: line, Text: scanner.Text()})
+			}
+		}
+		return scanner.Err()
+	})
+	return matches, err
+}
+
+// isLogFile reports whether a bundle file holds logs: a .log file or any file under a logs directory
+func isLogFile(name string) bool {
+	if path.Ext(name) == ".log" {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if dir == "logs" {
+			return true
+		}
+	}
+	return false
+}
+
+// RunInspectBundle implements `support-bundle inspect <bundle> <collectors|manifest|images|grep|layout|search>`
+func RunInspectBundle(options InspectBundleOptions) error {
+	bundle, err := OpenBundle(options.BundlePath)
+	if err != nil {
+		return err
+	}
+	compatibility, err := bundle.CheckCompatibility()
+	if err != nil {
+		return err
+	}
+	if options.Output != "json" && options.Command != InspectLayout {
+		for _, warning := range compatibility.Warnings {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+
+	var result interface{}
+	switch options.Command {
+	case InspectCollectors:
+		collectors, err := bundle.ListCollectors()
+		if err != nil {
+			return err
+		}
+		result = collectors
+		if options.Output != "json" {
+			printInspectedCollectors(collectors)
+		}
+	case InspectManifest:
+		plan, err := bundle.DiscoveryManifest()
+		if err != nil {
+			return err
+		}
+		// The manifest is dumped as JSON in either output format
+		result = plan
+		options.Output = "json"
+	case InspectImages:
+		facts, err := bundle.ImageFacts()
+		if err != nil {
+			return err
+		}
+		result = facts
+		if options.Output != "json" {
+			printInspectedImages(facts)
+		}
+	case InspectGrep:
+		if options.Pattern == "" {
+			return fmt.Errorf("grep requires a pattern")
+		}
+		expr := options.Pattern
+		if options.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid grep pattern: %w", err)
+		}
+		matches, err := bundle.GrepLogs(pattern)
+		if err != nil {
+			return err
+		}
+		result = matches
+		if options.Output != "json" {
+			for _, match := range matches {
+				fmt.Printf("%s:%d: %s\n", match.File, match.Line, match.Text)
+			}
+		}
+	case InspectSearch:
+		search, err := bundle.Search(options.Pattern, options.IgnoreCase)
+		if err != nil {
+			return err
+		}
+		result = search
+		if options.Output != "json" {
+			if !search.Indexed {
+				fmt.Printf("Warning: bundle has no %s, scanning every text file\n", SearchIndexFileName)
+			}
+			for _, match := range search.Matches {
+				fmt.Printf("%s:%d: %s\n", match.File, match.Line, match.Text)
+			}
+		}
+	case InspectLayout:
+		manifest, err := bundle.LayoutManifest()
+		if err != nil {
+			return err
+		}
+		// Like the discovery manifest, the layout is dumped as JSON in either output format
+		result = struct {
+			Compatibility *LayoutCompatibility `json:"compatibility"`
+			Manifest      *LayoutManifest      `json:"manifest,omitempty"`
+		}{compatibility, manifest}
+		options.Output = "json"
+	default:
+		return fmt.Errorf("unknown inspect command %q, must be %s, %s, %s, %s, %s or %s", options.Command, InspectCollectors, InspectManifest, InspectImages, InspectGrep, InspectLayout, InspectSearch)
+	}
+
+	if options.Output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal inspect result: %w", err)
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func printInspectedCollectors(collectors []autodiscovery.CollectorSpec) {
+	fmt.Printf("📋 Collectors: %d\n", len(collectors))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tTYPE\tGROUP\tNAMESPACE\tPRIORITY")
+	for _, collector := range collectors {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%d\n", collector.Name, collector.Type, autodiscovery.CollectorGroupFor(collector), collector.Namespace, collector.Priority)
+	}
+	w.Flush()
+}
+
+func printInspectedImages(facts *ImageFactsInspection) {
+	fmt.Printf("🖼️  Image Facts (%s):\n", facts.File)
+	fmt.Printf("   Images: %d\n", facts.Summary.TotalImages)
+	fmt.Printf("   Total Size: %d bytes\n", facts.Summary.TotalSize)
+	if facts.Summary.LargestImageRef != "" {
+		fmt.Printf("   Largest: %s (%d bytes)\n", facts.Summary.LargestImageRef, facts.Summary.LargestImageSize)
+	}
+	for _, registry := range sortedKeys(facts.Summary.Registries) {
+		fmt.Printf("   Registry %s: %d images\n", registry, facts.Summary.Registries[registry])
+	}
+	for _, platform := range sortedKeys(facts.Summary.Platforms) {
+		fmt.Printf("   Platform %s: %d images\n", platform, facts.Summary.Platforms[platform])
+	}
+
+	refs := make([]string, 0, len(facts.Images))
+	for ref := range facts.Images {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		fmt.Printf("   - %s %s\n", ref, facts.Images[ref].Digest)
+	}
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/autodiscover"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+func writeInspectBundle(t *testing.T, dir string) map[string][]byte {
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: "logs", Name: "auto-logs-app", Namespace: "app"},
+		{Type: "cluster-resources", Name: "auto-resources-pods", Group: autodiscovery.CollectorGroupWorkloads},
+	}
+	facts := map[string]*images.ImageFacts{
+		"nginx:1.25": {Repository: "library/nginx", Tag: "1.25", Registry: "docker.io", Digest: "sha256:abc", Size: 100},
+	}
+	factsData, err := images.NewFactsSerializer(true).SerializeToJSON(facts)
+	if err != nil {
+		t.Fatalf("Failed to serialize facts: %v", err)
+	}
+
+	files := map[string][]byte{
+		"images/facts.json":         factsData,
+		"logs/app/web.log":          []byte("starting\nERROR connection refused\nready\n"),
+		"namespaces/app/events.txt": []byte("ERROR not a log file\n"),
+	}
+	for _, collector := range collectors {
+		data, _ := json.Marshal(collector)
+		files["collectors/"+collector.Name+".json"] = data
+	}
+	manifest, _ := json.Marshal(autodiscover.Plan{Options: autodiscovery.DiscoveryOptions{Namespaces: []string{"app"}}, Collectors: collectors})
+	files[DiscoveryManifestFileName] = manifest
+
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return files
+}
+
+func writeInspectArchive(t *testing.T, archivePath, root string, files map[string][]byte) {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	if root != "" {
+		tw.WriteHeader(&tar.Header{Name: root + "/", Typeflag: tar.TypeDir, Mode: 0755})
+	}
+	for name, data := range files {
+		if root != "" {
+			name = root + "/" + name
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		tw.Write(data)
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func TestBundleReader(t *testing.T) {
+	bundleDir := filepath.Join(t.TempDir(), "support-bundle-2024-01-01T00-00-00")
+	files := writeInspectBundle(t, bundleDir)
+
+	archiveWithRoot := filepath.Join(t.TempDir(), "bundle.tgz")
+	writeInspectArchive(t, archiveWithRoot, "support-bundle-2024-01-01T00-00-00", files)
+	archiveWithoutRoot := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	writeInspectArchive(t, archiveWithoutRoot, "", files)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "directory", path: bundleDir},
+		{name: "archive with root directory", path: archiveWithRoot},
+		{name: "archive without root directory", path: archiveWithoutRoot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := OpenBundle(tt.path)
+			if err != nil {
+				t.Fatalf("Failed to open bundle: %v", err)
+			}
+
+			collectors, err := bundle.ListCollectors()
+			if err != nil {
+				t.Fatalf("Failed to list collectors: %v", err)
+			}
+			if len(collectors) != 2 || collectors[0].Name != "auto-logs-app" {
+				t.Errorf("Expected 2 collectors sorted by name, got %v", collectors)
+			}
+
+			plan, err := bundle.DiscoveryManifest()
+			if err != nil {
+				t.Fatalf("Failed to read discovery manifest: %v", err)
+			}
+			if len(plan.Options.Namespaces) != 1 || len(plan.Collectors) != 2 {
+				t.Errorf("Expected manifest with 1 namespace and 2 collectors, got %+v", plan)
+			}
+
+			facts, err := bundle.ImageFacts()
+			if err != nil {
+				t.Fatalf("Failed to read image facts: %v", err)
+			}
+			if facts.Summary.TotalImages != 1 || facts.Images["nginx:1.25"] == nil {
+				t.Errorf("Expected facts for nginx:1.25, got %+v", facts)
+			}
+
+			matches, err := bundle.GrepLogs(regexp.MustCompile("ERROR"))
+			if err != nil {
+				t.Fatalf("Failed to grep logs: %v", err)
+			}
+			if len(matches) != 1 {
+				t.Fatalf("Expected 1 match in log files only, got %v", matches)
+			}
+			if matches[0].File != "logs/app/web.log" || matches[0].Line != 2 {
+				t.Errorf("Expected match at logs/app/web.log:2, got %s:%d", matches[0].File, matches[0].Line)
+			}
+		})
+	}
+}
+
+func TestBundleReader_MissingFiles(t *testing.T) {
+	bundle, err := OpenBundle(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+
+	if _, err := bundle.DiscoveryManifest(); err == nil {
+		t.Errorf("Expected error for missing discovery manifest")
+	}
+	if _, err := bundle.ImageFacts(); err == nil {
+		t.Errorf("Expected error for missing image facts")
+	}
+	if _, err := OpenBundle(filepath.Join(t.TempDir(), "missing.tgz")); err == nil {
+		t.Errorf("Expected error for missing bundle")
+	}
+}
+
+func TestBundleReader_ImageFactsStream(t *testing.T) {
+	bundleDir := t.TempDir()
+	stream, err := images.OpenFactsStream(filepath.Join(bundleDir, "images", images.FactsStreamFileName))
+	if err != nil {
+		t.Fatalf("Failed to open facts stream: %v", err)
+	}
+	stream.Write("nginx:1.25", &images.ImageFacts{Repository: "library/nginx", Tag: "1.25", Registry: "docker.io", Size: 100})
+	stream.Write("quay.io/app/api:v2", &images.ImageFacts{Repository: "app/api", Tag: "v2", Registry: "quay.io", Size: 300})
+	stream.Close()
+
+	bundle, err := OpenBundle(bundleDir)
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+	facts, err := bundle.ImageFacts()
+	if err != nil {
+		t.Fatalf("Failed to read image facts: %v", err)
+	}
+	if facts.File != "images/facts.jsonl" || facts.Summary.TotalImages != 2 || facts.Summary.LargestImageRef != "app/api:v2" {
+		t.Errorf("Expected 2 images from images/facts.jsonl, got %s with %+v", facts.File, facts.Summary)
+	}
+}
+
+func TestRunInspectBundle_Validation(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeInspectBundle(t, bundleDir)
+
+	tests := []struct {
+		name    string
+		options InspectBundleOptions
+	}{
+		{name: "unknown command", options: InspectBundleOptions{BundlePath: bundleDir, Command: "extract"}},
+		{name: "grep without pattern", options: InspectBundleOptions{BundlePath: bundleDir, Command: InspectGrep}},
+		{name: "invalid grep pattern", options: InspectBundleOptions{BundlePath: bundleDir, Command: InspectGrep, Pattern: "("}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RunInspectBundle(tt.options); err == nil {
+				t.Errorf("Expected error but got none")
+			}
+		})
+	}
+
+	if err := RunInspectBundle(InspectBundleOptions{BundlePath: bundleDir, Command: InspectCollectors}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
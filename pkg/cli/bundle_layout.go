@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LayoutManifestFileName describes the format and directory layout of a bundle, written at the bundle root
+const LayoutManifestFileName = "layout-manifest.json"
+
+// BundleFormatVersion is the layout version this producer writes, as major.minor
+// Bump the minor version for additions readers can ignore, the major version when files move or change meaning
+const BundleFormatVersion = "1.1"
+
+// legacyBundleFormatVersion is assumed for bundles written before layout manifests existed
+const legacyBundleFormatVersion = "1.0"
+
+// bundleProducer names the program that writes bundles in layout manifests
+const bundleProducer = "troubleshoot"
+
+// bundleLayoutEntries describes the top-level files and directories a bundle can contain
+var bundleLayoutEntries = []LayoutEntry{
+	{Path: BundleReadmeFileName, Description: "Human-readable summary of the bundle"},
+	{Path: DiscoveryManifestFileName, Description: "Discovery options and generated collectors"},
+	{Path: ExecutionReportFileName, Description: "Status, duration, bytes written, retries and error class of each collector"},
+	{Path: RunSummaryFileName, Description: "Aggregate summary of the run for support-bundle merge-summaries"},
+	{Path: AnalysisFileName, Description: "Results of the auto-generated analyzers"},
+	{Path: SearchIndexFileName, Description: "Trigram index of the text files for support-bundle inspect search"},
+	{Path: ManifestFileName, Description: "SHA-256 checksums of every bundle file"},
+	{Path: SignatureFileName, Description: "minisign signature of manifest.json"},
+	{Path: "collectors", Directory: true, Description: "One JSON spec per collector that ran"},
+	{Path: namespaceSummaryDir, Directory: true, Description: "Per-namespace resources, logs, timelines and summaries, with index.json"},
+	{Path: "cluster-info", Directory: true, Description: "Cluster-scoped health: control plane, nodes, aggregated APIs"},
+	{Path: "images", Directory: true, Description: "Image facts, node image presence and pull secret audit"},
+	{Path: AuditDirName, Directory: true, Description: "Notes on collection settings that widen what was collected"},
+	{Path: "network", Directory: true, Description: "Network policy reachability"},
+	{Path: "rollouts", Directory: true, Description: "Rollout history of workloads"},
+	{Path: "storage", Directory: true, Description: "Storage diagnostics for PVCs, PVs and CSI drivers"},
+	{Path: "tables", Directory: true, Description: "Server-side printed tables for large resource lists"},
+	{Path: "webhooks", Directory: true, Description: "Admission webhook configurations and backends"},
+}
+
+// LayoutEntry describes one top-level file or directory of a bundle
+type LayoutEntry struct {
+	Path        string `json:"path"`
+	Directory   bool   `json:"directory,omitempty"`
+	Description string `json:"description"`
+}
+
+// LayoutManifest records the bundle format so readers can tell which layout they are looking at
+type LayoutManifest struct {
+	FormatVersion   string        `json:"formatVersion"` // See BundleFormatVersion
+	Producer        string        `json:"producer"`
+	ProducerVersion string        `json:"producerVersion"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	Entries         []LayoutEntry `json:"entries"` // Known top-level files and directories present in the bundle
+}
+
+// LayoutCompatibility is the result of checking a bundle's layout manifest against this reader
+type LayoutCompatibility struct {
+	FormatVersion string   `json:"formatVersion"`
+	Legacy        bool     `json:"legacy,omitempty"` // The bundle has no layout manifest
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// NewLayoutManifest describes the known entries present in bundleDir, plus the pending entries written after the
+// manifest, e.g. manifest.json when the bundle is signed
+func NewLayoutManifest(bundleDir string, createdAt time.Time, pending ...string) *LayoutManifest {
+	manifest := &LayoutManifest{
+		FormatVersion:   BundleFormatVersion,
+		Producer:        bundleProducer,
+		ProducerVersion: producerVersion(),
+		CreatedAt:       createdAt.UTC(),
+		Entries:         []LayoutEntry{},
+	}
+	for _, entry := range bundleLayoutEntries {
+		info, err := os.Stat(filepath.Join(bundleDir, entry.Path))
+		if (err == nil && info.IsDir() == entry.Directory) || containsLayoutPath(pending, entry.Path) {
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+	manifest.Entries = append(manifest.Entries, LayoutEntry{Path: LayoutManifestFileName, Description: "This file"})
+	sort.Slice(manifest.Entries, func(i, j int) bool {
+		return manifest.Entries[i].Path < manifest.Entries[j].Path
+	})
+	return manifest
+}
+
+// containsLayoutPath reports whether paths contains path
+func containsLayoutPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteLayoutManifest writes layout-manifest.json at the bundle root and returns its path
+func WriteLayoutManifest(bundleDir string, createdAt time.Time, pending ...string) (string, error) {
+	path := filepath.Join(bundleDir, LayoutManifestFileName)
+	if err := writeJSONFile(path, NewLayoutManifest(bundleDir, createdAt, pending...)); err != nil {
+		return "", fmt.Errorf("failed to write layout manifest: %w", err)
+	}
+	return path, nil
+}
+
+// CheckLayoutCompatibility checks a bundle's layout manifest, nil for a bundle without one
+// A newer major version is an error since files may have moved; a newer minor version only warns
+func CheckLayoutCompatibility(manifest *LayoutManifest) (*LayoutCompatibility, error) {
+	if manifest == nil {
+		return &LayoutCompatibility{
+			FormatVersion: legacyBundleFormatVersion,
+			Legacy:        true,
+			Warnings:      []string{fmt.Sprintf("bundle has no %s, assuming format %s", LayoutManifestFileName, legacyBundleFormatVersion)},
+		}, nil
+	}
+
+	major, minor, err := parseFormatVersion(manifest.FormatVersion)
+	if err != nil {
+		return nil, err
+	}
+	supportedMajor, supportedMinor, _ := parseFormatVersion(BundleFormatVersion)
+
+	compatibility := &LayoutCompatibility{FormatVersion: manifest.FormatVersion}
+	switch {
+	case major > supportedMajor:
+		return nil, fmt.Errorf("bundle format %s written by %s %s is not supported, this version reads format %d.x", manifest.FormatVersion, manifest.Producer, manifest.ProducerVersion, supportedMajor)
+	case major < supportedMajor:
+		return nil, fmt.Errorf("bundle format %s is no longer supported, this version reads format %d.x", manifest.FormatVersion, supportedMajor)
+	case minor > supportedMinor:
+		compatibility.Warnings = append(compatibility.Warnings, fmt.Sprintf("bundle format %s is newer than %s, files added since are ignored", manifest.FormatVersion, BundleFormatVersion))
+	}
+	return compatibility, nil
+}
+
+// parseFormatVersion splits a major.minor format version
+func parseFormatVersion(version string) (int, int, error) {
+	majorPart, minorPart, found := strings.Cut(version, ".")
+	major, majorErr := strconv.Atoi(majorPart)
+	minor, minorErr := strconv.Atoi(minorPart)
+	if !found || majorErr != nil || minorErr != nil || major < 0 || minor < 0 {
+		return 0, 0, fmt.Errorf("invalid bundle format version %q, expected major.minor", version)
+	}
+	return major, minor, nil
+}
+
+// LayoutManifest returns the bundle's layout manifest, nil when the bundle predates layout manifests
+func (b *BundleReader) LayoutManifest() (*LayoutManifest, error) {
+	data, err := b.ReadFile(LayoutManifestFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layout manifest: %w", err)
+	}
+	var manifest LayoutManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse layout manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// CheckCompatibility reads the layout manifest and checks this version can read the bundle
+func (b *BundleReader) CheckCompatibility() (*LayoutCompatibility, error) {
+	manifest, err := b.LayoutManifest()
+	if err != nil {
+		return nil, err
+	}
+	return CheckLayoutCompatibility(manifest)
+}
+
+// producerVersion returns the troubleshoot module version of the running binary, "(devel)" for local builds
+func producerVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	const module = "github.com/replicatedhq/troubleshoot"
+	if info.Main.Path == module && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == module {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewLayoutManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeInspectBundle(t, dir)
+
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	path, err := WriteLayoutManifest(dir, createdAt, ManifestFileName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != filepath.Join(dir, LayoutManifestFileName) {
+		t.Errorf("Expected the manifest at the bundle root, got %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read layout manifest: %v", err)
+	}
+	var manifest LayoutManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to parse layout manifest: %v", err)
+	}
+	if manifest.FormatVersion != BundleFormatVersion || manifest.Producer != bundleProducer || manifest.ProducerVersion == "" {
+		t.Errorf("Unexpected manifest header %+v", manifest)
+	}
+	if !manifest.CreatedAt.Equal(createdAt) {
+		t.Errorf("Expected created at %s, got %s", createdAt, manifest.CreatedAt)
+	}
+
+	var paths []string
+	for _, entry := range manifest.Entries {
+		paths = append(paths, entry.Path)
+	}
+	expected := []string{"collectors", DiscoveryManifestFileName, "images", LayoutManifestFileName, ManifestFileName, namespaceSummaryDir}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected entries %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Expected entry %d to be %s, got %s", i, expected[i], paths[i])
+		}
+	}
+}
+
+func TestCheckLayoutCompatibility(t *testing.T) {
+	tests := []struct {
+		name           string
+		manifest       *LayoutManifest
+		expectLegacy   bool
+		expectWarnings int
+		expectError    bool
+	}{
+		{name: "legacy bundle", expectLegacy: true, expectWarnings: 1},
+		{name: "current", manifest: &LayoutManifest{FormatVersion: BundleFormatVersion}},
+		{name: "newer minor", manifest: &LayoutManifest{FormatVersion: "1.3"}, expectWarnings: 1},
+		{name: "newer major", manifest: &LayoutManifest{FormatVersion: "2.0"}, expectError: true},
+		{name: "older major", manifest: &LayoutManifest{FormatVersion: "0.9"}, expectError: true},
+		{name: "invalid", manifest: &LayoutManifest{FormatVersion: "abc"}, expectError: true},
+		{name: "missing minor", manifest: &LayoutManifest{FormatVersion: "1"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compatibility, err := CheckLayoutCompatibility(tt.manifest)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			if compatibility.Legacy != tt.expectLegacy {
+				t.Errorf("Expected legacy %v, got %v", tt.expectLegacy, compatibility.Legacy)
+			}
+			if len(compatibility.Warnings) != tt.expectWarnings {
+				t.Errorf("Expected %d warnings, got %v", tt.expectWarnings, compatibility.Warnings)
+			}
+		})
+	}
+}
+
+func TestBundleReader_LayoutManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeInspectBundle(t, dir)
+
+	bundle, err := OpenBundle(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	manifest, err := bundle.LayoutManifest()
+	if err != nil || manifest != nil {
+		t.Fatalf("Expected no layout manifest for a legacy bundle, got %+v, %v", manifest, err)
+	}
+	compatibility, err := bundle.CheckCompatibility()
+	if err != nil || !compatibility.Legacy {
+		t.Errorf("Expected a legacy bundle, got %+v, %v", compatibility, err)
+	}
+
+	if _, err := WriteLayoutManifest(dir, time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	manifest, err = bundle.LayoutManifest()
+	if err != nil || manifest == nil || manifest.FormatVersion != BundleFormatVersion {
+		t.Fatalf("Expected format %s, got %+v, %v", BundleFormatVersion, manifest, err)
+	}
+
+	unsupported, _ := json.Marshal(LayoutManifest{FormatVersion: "2.0", Producer: bundleProducer})
+	if err := os.WriteFile(filepath.Join(dir, LayoutManifestFileName), unsupported, 0644); err != nil {
+		t.Fatalf("Failed to write layout manifest: %v", err)
+	}
+	if err := RunInspectBundle(InspectBundleOptions{BundlePath: dir, Command: InspectCollectors}); err == nil {
+		t.Errorf("Expected inspect to reject an unsupported bundle format")
+	}
+}
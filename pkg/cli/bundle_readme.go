@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+// BundleReadmeFileName is the human-readable summary written at the bundle root
+const BundleReadmeFileName = "README.md"
+
+// defaultBundleReadmeTitle is the README heading when the config does not set one
+const defaultBundleReadmeTitle = "Support Bundle"
+
+// bundleReadmeArtifacts are the key files linked from the README when the bundle contains them
+var bundleReadmeArtifacts = []BundleReadmeLink{
+	{Name: "Analysis results", Path: AnalysisFileName},
+	{Name: "Discovery manifest", Path: DiscoveryManifestFileName},
+	{Name: "Namespace index", Path: namespaceSummaryDir + "/index.json"},
+	{Name: "Control plane health", Path: "cluster-info/control-plane.json"},
+	{Name: "Unavailable aggregated APIs", Path: "cluster-info/unavailable-apiservices.json"},
+	{Name: "Network policy reachability", Path: "network/policy-reachability.json"},
+	{Name: "Node image presence", Path: "images/" + images.NodeImagePresenceFileName},
+	{Name: "Pull secret audit", Path: "images/" + images.PullSecretAuditFileName},
+	{Name: "Registry catalog", Path: "images/" + images.RegistryCatalogFileName},
+	{Name: "Image errors", Path: "images/" + images.ErrorLedgerFileName},
+	{Name: "System namespace audit note", Path: AuditDirName + "/system-namespaces.json"},
+}
+
+// defaultBundleReadmeTemplate renders BundleReadmeData as Markdown
+const defaultBundleReadmeTemplate = `# {{ .Title }}
+
+Collected {{ .GeneratedAt.UTC.Format "2006-01-02 15:04:05 UTC" }} in {{ .Duration }} by {{ .Collectors }} auto-discovered collectors.
+{{- if .Namespaces }}
+
+Namespaces: {{ join .Namespaces ", " }}
+{{- end }}
+{{- if .Notes }}
+
+{{ .Notes }}
+{{- end }}
+
+## Key Artifacts
+{{ range .Artifacts }}
+- [{{ .Name }}]({{ .Path }})
+{{- else }}
+None.
+{{- end }}
+
+## Collectors by Group
+{{ range .Groups }}
+- {{ .Name }}: {{ .Count }}
+{{- else }}
+None.
+{{- end }}
+
+## Contents
+
+| Directory | Files | Size |
+| --- | ---: | ---: |
+{{- range .Directories }}
+| [{{ .Path }}]({{ .Path }}) | {{ .Files }} | {{ size .Bytes }} |
+{{- end }}
+| Total | {{ .TotalFiles }} | {{ size .TotalBytes }} |
+
+## Warnings
+{{ range .Warnings }}
+- {{ . }}
+{{- else }}
+None.
+{{- end }}
+{{- if .ImageErrors }}
+
+## Image Errors
+{{ range .ImageErrors }}
+- {{ . }}
+{{- end }}
+{{- end }}
+
+## Skipped
+{{ range .Skipped }}
+- {{ . }}
+{{- else }}
+Nothing was skipped.
+{{- end }}
+`
+
+// BundleReadmeData is what the README template is rendered with
+type BundleReadmeData struct {
+	Title       string
+	Notes       string
+	GeneratedAt time.Time
+	Duration    time.Duration
+	Collectors  int
+	Groups      []BundleReadmeCount
+	Namespaces  []string
+	Directories []BundleReadmeDirectory
+	TotalFiles  int
+	TotalBytes  int64
+	Artifacts   []BundleReadmeLink
+	Warnings    []string
+	Skipped     []string
+	ImageErrors []string // Most frequent image error classes, the section is left out when empty
+}
+
+// BundleReadmeCount is a named count, e.g. the collectors of one group
+type BundleReadmeCount struct {
+	Name  string
+	Count int
+}
+
+// BundleReadmeDirectory is a top-level bundle directory with its file count and size
+type BundleReadmeDirectory struct {
+	Path  string
+	Files int
+	Bytes int64
+}
+
+// BundleReadmeLink is a key artifact, linked by its path relative to the bundle root
+type BundleReadmeLink struct {
+	Name string
+	Path string
+}
+
+// NewBundleReadmeData summarizes the collectors and the files in the bundle directory
+// Warnings and skipped items are left to the caller
+func NewBundleReadmeData(outputDir string, collectors []autodiscovery.CollectorSpec, duration time.Duration) BundleReadmeData {
+	data := BundleReadmeData{
+		Title:       defaultBundleReadmeTitle,
+		GeneratedAt: time.Now(),
+		Duration:    duration.Round(time.Second),
+		Collectors:  len(collectors),
+	}
+
+	groups := make(map[string]int)
+	for _, collector := range collectors {
+		groups[autodiscovery.CollectorGroupFor(collector)]++
+	}
+	for _, group := range autodiscovery.CollectorGroups {
+		if count := groups[group]; count > 0 {
+			data.Groups = append(data.Groups, BundleReadmeCount{Name: group, Count: count})
+		}
+	}
+	data.Namespaces = collectorNamespaces(collectors)
+
+	data.Directories, data.TotalFiles, data.TotalBytes = bundleDirectorySizes(outputDir)
+	for _, artifact := range bundleReadmeArtifacts {
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(artifact.Path))); err == nil {
+			data.Artifacts = append(data.Artifacts, artifact)
+		}
+	}
+
+	return data
+}
+
+// WriteBundleReadme renders the README into the bundle root with the configured or default template
+// It returns an empty path when the README is disabled
+func WriteBundleReadme(outputDir string, data BundleReadmeData, config autodiscovery.BundleReadmeConfig) (string, error) {
+	if config.Disabled {
+		return "", nil
+	}
+
+	tmpl, err := loadBundleReadmeTemplate(config)
+	if err != nil {
+		return "", err
+	}
+	if config.Title != "" {
+		data.Title = config.Title
+	}
+	if config.Notes != "" {
+		data.Notes = config.Notes
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render bundle README: %w", err)
+	}
+
+	path := filepath.Join(outputDir, BundleReadmeFileName)
+	if err := os.WriteFile(path, []byte(out.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write bundle README: %w", err)
+	}
+	return path, nil
+}
+
+// loadBundleReadmeTemplate parses the template set in the config, or the default template
+func loadBundleReadmeTemplate(config autodiscovery.BundleReadmeConfig) (*template.Template, error) {
+	text := defaultBundleReadmeTemplate
+	switch {
+	case config.TemplateFile != "":
+		data, err := os.ReadFile(config.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle README template: %w", err)
+		}
+		text = string(data)
+	case config.Template != "":
+		text = config.Template
+	}
+
+	tmpl, err := template.New(BundleReadmeFileName).Funcs(template.FuncMap{"size": formatByteSize, "join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle README template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// bundleReadmeSkippedItems describes what discovery left out of the bundle on purpose or because it was unavailable
+func bundleReadmeSkippedItems(opts autodiscovery.DiscoveryOptions, unavailable []autodiscovery.UnavailableAPIService) []string {
+	var skipped []string
+	for _, group := range opts.SkipGroups {
+		skipped = append(skipped, fmt.Sprintf("Collector group %s (--skip-groups)", group))
+	}
+	if len(opts.OnlyGroups) > 0 {
+		skipped = append(skipped, fmt.Sprintf("Collector groups other than %s (--only-groups)", strings.Join(opts.OnlyGroups, ", ")))
+	}
+	if len(opts.Namespaces) == 0 {
+		for _, namespace := range opts.ExcludeNamespaces {
+			skipped = append(skipped, fmt.Sprintf("Namespace %s (excluded by config)", namespace))
+		}
+	}
+	for _, namespace := range opts.ProtectedNamespaces {
+		skipped = append(skipped, fmt.Sprintf("Namespace %s (protected by config)", namespace))
+	}
+	for _, apiService := range unavailable {
+		skipped = append(skipped, fmt.Sprintf("%s resources: aggregated API %s is unavailable (%s)", apiService.GroupVersion(), apiService.Name, apiService.Reason))
+	}
+	return skipped
+}
+
+// bundleDirectorySizes returns the file count and size of each top-level directory, and the bundle totals
+func bundleDirectorySizes(dir string) ([]BundleReadmeDirectory, int, int64) {
+	byDirectory := make(map[string]*BundleReadmeDirectory)
+	totalFiles := 0
+	var totalBytes int64
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		totalFiles++
+		totalBytes += info.Size()
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+		if len(parts) < 2 {
+			return nil // Files at the bundle root only count towards the totals
+		}
+		if byDirectory[parts[0]] == nil {
+			byDirectory[parts[0]] = &BundleReadmeDirectory{Path: parts[0] + "/"}
+		}
+		byDirectory[parts[0]].Files++
+		byDirectory[parts[0]].Bytes += info.Size()
+		return nil
+	})
+
+	directories := make([]BundleReadmeDirectory, 0, len(byDirectory))
+	for _, directory := range byDirectory {
+		directories = append(directories, *directory)
+	}
+	sort.Slice(directories, func(i, j int) bool {
+		return directories[i].Path < directories[j].Path
+	})
+	return directories, totalFiles, totalBytes
+}
+
+// formatByteSize formats a size in bytes with a binary unit, e.g. 1.5 KiB
+func formatByteSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
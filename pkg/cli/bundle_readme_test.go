@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestWriteBundleReadme(t *testing.T) {
+	dir := writeTestBundle(t)
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeLogs, Name: "auto-logs-app", Namespace: "default"},
+		{Type: autodiscovery.CollectorTypeLogs, Name: "auto-logs-db", Namespace: "data"},
+	}
+
+	data := NewBundleReadmeData(dir, collectors, 90*time.Second)
+	data.Warnings = []string{"collector auto-logs-db failed: pod not found"}
+	data.ImageErrors = []string{"auth (not retryable): 2 errors on 2 images from ghcr.io, e.g. unauthorized"}
+
+	path, err := WriteBundleReadme(dir, data, autodiscovery.BundleReadmeConfig{Notes: "Contact support@example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != filepath.Join(dir, BundleReadmeFileName) {
+		t.Errorf("Expected README at the bundle root, got %s", path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read README: %v", err)
+	}
+	readme := string(content)
+
+	expected := []string{
+		"# Support Bundle\n",
+		"in 1m30s by 2 auto-discovered collectors.",
+		"Namespaces: data, default",
+		"Contact support@example.com",
+		"- [Analysis results](analysis.json)",
+		"- logs: 2",
+		"| [namespaces/](namespaces/) | 1 | 23 B |",
+		"| Total | 2 | 37 B |",
+		"- collector auto-logs-db failed: pod not found",
+		"## Image Errors\n\n- auth (not retryable): 2 errors on 2 images from ghcr.io, e.g. unauthorized",
+		"Nothing was skipped.",
+	}
+	for _, line := range expected {
+		if !strings.Contains(readme, line) {
+			t.Errorf("Expected README to contain %q, got:\n%s", line, readme)
+		}
+	}
+	if strings.Contains(readme, "Discovery manifest") {
+		t.Errorf("Expected only artifacts present in the bundle to be linked")
+	}
+}
+
+func TestWriteBundleReadme_Config(t *testing.T) {
+	dir := t.TempDir()
+	data := NewBundleReadmeData(dir, nil, time.Second)
+
+	templateFile := filepath.Join(t.TempDir(), "readme.tmpl")
+	if err := os.WriteFile(templateFile, []byte("{{ .Title }} from file"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   autodiscovery.BundleReadmeConfig
+		expected string
+		wantErr  bool
+	}{
+		{name: "inline template", config: autodiscovery.BundleReadmeConfig{Title: "Acme", Template: "# {{ .Title }} ({{ .Collectors }} collectors)"}, expected: "# Acme (0 collectors)"},
+		{name: "template file", config: autodiscovery.BundleReadmeConfig{TemplateFile: templateFile}, expected: "Support Bundle from file"},
+		{name: "invalid template", config: autodiscovery.BundleReadmeConfig{Template: "{{ .Title"}, wantErr: true},
+		{name: "unknown field", config: autodiscovery.BundleReadmeConfig{Template: "{{ .Missing }}"}, wantErr: true},
+		{name: "missing template file", config: autodiscovery.BundleReadmeConfig{TemplateFile: filepath.Join(dir, "missing.tmpl")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := WriteBundleReadme(dir, data, tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			content, _ := os.ReadFile(path)
+			if string(content) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, content)
+			}
+		})
+	}
+
+	if path, err := WriteBundleReadme(dir, data, autodiscovery.BundleReadmeConfig{Disabled: true}); err != nil || path != "" {
+		t.Errorf("Expected no README when disabled, got %q, %v", path, err)
+	}
+}
+
+func TestBundleReadmeSkippedItems(t *testing.T) {
+	opts := autodiscovery.DiscoveryOptions{
+		SkipGroups:          []string{"images"},
+		ExcludeNamespaces:   []string{"kube-system"},
+		ProtectedNamespaces: []string{"pci-*"},
+	}
+	unavailable := []autodiscovery.UnavailableAPIService{{Name: "v1beta1.metrics.k8s.io", Group: "metrics.k8s.io", Version: "v1beta1", Reason: "FailedDiscoveryCheck"}}
+
+	skipped := bundleReadmeSkippedItems(opts, unavailable)
+	expected := []string{
+		"Collector group images (--skip-groups)",
+		"Namespace kube-system (excluded by config)",
+		"Namespace pci-* (protected by config)",
+		"metrics.k8s.io/v1beta1 resources: aggregated API v1beta1.metrics.k8s.io is unavailable (FailedDiscoveryCheck)",
+	}
+	if strings.Join(skipped, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, skipped)
+	}
+
+	opts.Namespaces = []string{"default"}
+	if skipped := bundleReadmeSkippedItems(opts, nil); len(skipped) != 2 {
+		t.Errorf("Expected only protected namespaces to be kept when namespaces are explicit, got %v", skipped)
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := formatByteSize(tt.bytes); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
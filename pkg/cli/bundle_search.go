@@ -0,0 +1,472 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SearchIndexFileName is the trigram index of the bundle's text files, written at the bundle root
+const SearchIndexFileName = "search-index.gob.gz"
+
+// searchIndexVersion is bumped when the index encoding changes; older indexes are ignored and the bundle scanned
+const searchIndexVersion = 1
+
+// DefaultSearchChunkSize is the number of bytes of a file indexed together, split at line boundaries
+// Smaller chunks make searches read less at the cost of a larger index
+const DefaultSearchChunkSize = 1 << 20
+
+// searchSniffSize is how much of a file is checked for NUL bytes to tell binary files from text
+const searchSniffSize = 512
+
+// searchSkippedExtensions are compressed files, never indexed or searched
+var searchSkippedExtensions = map[string]bool{
+	".gz":  true,
+	".tgz": true,
+	".zst": true,
+	".tar": true,
+	".zip": true,
+}
+
+// SearchIndex maps every trigram of the bundle's text files to the chunks containing it
+// A query is only read from the chunks that contain all of its trigrams, so a search of a multi-GB bundle reads a
+// few chunks instead of every file. Trigrams are ASCII lower-cased and never span lines
+type SearchIndex struct {
+	Version  int
+	Files    []SearchIndexFile
+	Chunks   []SearchIndexChunk
+	Postings map[uint32][]byte // Trigram to ascending chunk IDs, each stored as a uvarint delta from the previous
+}
+
+// SearchIndexFile is one indexed text file
+type SearchIndexFile struct {
+	Name string // Slash-separated path relative to the bundle root
+	Size int64
+}
+
+// SearchIndexChunk is a run of whole lines of one file
+type SearchIndexChunk struct {
+	File   int   // Index into SearchIndex.Files
+	Offset int64 // Byte offset of the first line
+	Line   int   // Number of the first line, from 1
+}
+
+// SearchResult is the outcome of `support-bundle inspect <bundle> search`
+type SearchResult struct {
+	Query          string     `json:"query"`
+	Indexed        bool       `json:"indexed"`        // False when the bundle has no usable index and every text file was scanned
+	ChunksSearched int        `json:"chunksSearched"` // Index chunks read, 0 without an index
+	Matches        []LogMatch `json:"matches"`
+}
+
+// searchSpan is a byte range of a file to scan, End is -1 for the rest of the file
+type searchSpan struct {
+	Start int64
+	End   int64
+	Line  int
+}
+
+// searchIndexBuilder indexes files one chunk at a time
+type searchIndexBuilder struct {
+	index     *SearchIndex
+	chunkSize int64
+	seen      []uint64          // Bitset of the trigrams of the current chunk
+	pending   []uint32          // Trigrams set in seen
+	last      map[uint32]uint32 // Last chunk ID appended to each posting list
+}
+
+func newSearchIndexBuilder(chunkSize int64) *searchIndexBuilder {
+	return &searchIndexBuilder{
+		index:     &SearchIndex{Version: searchIndexVersion, Postings: make(map[uint32][]byte)},
+		chunkSize: chunkSize,
+		seen:      make([]uint64, 1<<24/64),
+		last:      make(map[uint32]uint32),
+	}
+}
+
+// BuildSearchIndex indexes the text files of a bundle directory or archive
+func BuildSearchIndex(bundlePath string) (*SearchIndex, error) {
+	return buildSearchIndex(bundlePath, DefaultSearchChunkSize)
+}
+
+func buildSearchIndex(bundlePath string, chunkSize int64) (*SearchIndex, error) {
+	bundle, err := OpenBundle(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	builder := newSearchIndexBuilder(chunkSize)
+	if err := bundle.Walk(builder.addFile); err != nil {
+		return nil, fmt.Errorf("failed to index bundle: %w", err)
+	}
+	return builder.index, nil
+}
+
+// WriteSearchIndex indexes the text files of a bundle directory and writes the index at its root, returning its path
+func WriteSearchIndex(bundleDir string) (string, error) {
+	return writeSearchIndex(bundleDir, DefaultSearchChunkSize)
+}
+
+func writeSearchIndex(bundleDir string, chunkSize int64) (string, error) {
+	index, err := buildSearchIndex(bundleDir, chunkSize)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(bundleDir, SearchIndexFileName)
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to write search index: %w", err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	if err := gob.NewEncoder(gz).Encode(index); err != nil {
+		return "", fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to write search index: %w", err)
+	}
+	return path, file.Close()
+}
+
+// addFile indexes one bundle file, skipping the index itself, compressed files and binary files
+func (b *searchIndexBuilder) addFile(name string, r io.Reader) error {
+	if !isSearchableName(name) {
+		return nil
+	}
+	br := bufio.NewReaderSize(r, 64*1024)
+	if !isTextContent(br) {
+		return nil
+	}
+
+	fileID := len(b.index.Files)
+	b.index.Files = append(b.index.Files, SearchIndexFile{Name: name})
+	b.index.Chunks = append(b.index.Chunks, SearchIndexChunk{File: fileID, Line: 1})
+
+	var offset, chunkStart int64
+	var trigram uint32
+	line, inLine := 1, 0
+	split := false
+	for {
+		buf, err := br.ReadSlice('\n')
+		if len(buf) > 0 && split {
+			b.flushChunk()
+			b.index.Chunks = append(b.index.Chunks, SearchIndexChunk{File: fileID, Offset: offset, Line: line})
+			chunkStart = offset
+			split = false
+		}
+		for _, c := range buf {
+			if c == '\n' {
+				inLine = 0
+				continue
+			}
+			trigram = (trigram<<8 | uint32(lowerASCII(c))) & 0xFFFFFF
+			inLine++
+			if inLine >= 3 {
+				b.add(trigram)
+			}
+		}
+		offset += int64(len(buf))
+		if len(buf) > 0 && buf[len(buf)-1] == '\n' {
+			line++
+			split = offset-chunkStart >= b.chunkSize
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return fmt.Errorf("failed to index %s: %w", name, err)
+		}
+	}
+	b.flushChunk()
+	b.index.Files[fileID].Size = offset
+	return nil
+}
+
+func (b *searchIndexBuilder) add(trigram uint32) {
+	word, bit := trigram/64, uint64(1)<<(trigram%64)
+	if b.seen[word]&bit == 0 {
+		b.seen[word] |= bit
+		b.pending = append(b.pending, trigram)
+	}
+}
+
+// flushChunk appends the current chunk to the posting list of each of its trigrams
+func (b *searchIndexBuilder) flushChunk() {
+	id := uint32(len(b.index.Chunks) - 1)
+	var buf [binary.MaxVarintLen32]byte
+	for _, trigram := range b.pending {
+		n := binary.PutUvarint(buf[:], uint64(id-b.last[trigram]))
+		b.index.Postings[trigram] = append(b.index.Postings[trigram], buf[:n]...)
+		b.last[trigram] = id
+		b.seen[trigram/64] = 0
+	}
+	b.pending = b.pending[:0]
+}
+
+// candidateChunks returns the IDs of the chunks containing every trigram of query, every chunk for queries
+// shorter than a trigram
+func (idx *SearchIndex) candidateChunks(query string) []uint32 {
+	trigrams := queryTrigrams(query)
+	if len(trigrams) == 0 {
+		all := make([]uint32, len(idx.Chunks))
+		for i := range all {
+			all[i] = uint32(i)
+		}
+		return all
+	}
+
+	// Intersect the shortest posting lists first
+	sort.Slice(trigrams, func(i, j int) bool {
+		return len(idx.Postings[trigrams[i]]) < len(idx.Postings[trigrams[j]])
+	})
+	candidates := decodePostings(idx.Postings[trigrams[0]])
+	for _, trigram := range trigrams[1:] {
+		if len(candidates) == 0 {
+			break
+		}
+		candidates = intersectPostings(candidates, decodePostings(idx.Postings[trigram]))
+	}
+	return candidates
+}
+
+// spans returns the byte ranges to scan for query per file, merging adjacent chunks
+func (idx *SearchIndex) spans(query string) (map[string][]searchSpan, int) {
+	chunks := idx.candidateChunks(query)
+	spans := make(map[string][]searchSpan)
+	for _, id := range chunks {
+		chunk := idx.Chunks[id]
+		name := idx.Files[chunk.File].Name
+		end := int64(-1)
+		if int(id)+1 < len(idx.Chunks) && idx.Chunks[id+1].File == chunk.File {
+			end = idx.Chunks[id+1].Offset
+		}
+		if n := len(spans[name]); n > 0 && spans[name][n-1].End == chunk.Offset {
+			spans[name][n-1].End = end
+			continue
+		}
+		spans[name] = append(spans[name], searchSpan{Start: chunk.Offset, End: end, Line: chunk.Line})
+	}
+	return spans, len(chunks)
+}
+
+func queryTrigrams(query string) []uint32 {
+	seen := make(map[uint32]bool)
+	var trigrams []uint32
+	for i := 0; i+3 <= len(query); i++ {
+		trigram := uint32(lowerASCII(query[i]))<<16 | uint32(lowerASCII(query[i+1]))<<8 | uint32(lowerASCII(query[i+2]))
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
+
+func decodePostings(data []byte) []uint32 {
+	var ids []uint32
+	var id uint64
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		id += delta
+		ids = append(ids, uint32(id))
+		data = data[n:]
+	}
+	return ids
+}
+
+func intersectPostings(a, b []uint32) []uint32 {
+	var out []uint32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// SearchIndex returns the bundle's search index, os.ErrNotExist when it has none
+func (b *BundleReader) SearchIndex() (*SearchIndex, error) {
+	data, err := b.ReadFile(SearchIndexFileName)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+	var index SearchIndex
+	if err := gob.NewDecoder(gz).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to parse search index: %w", err)
+	}
+	return &index, nil
+}
+
+// Search returns the lines of the bundle's text files containing query, reading only the chunks the search index
+// says can match; a bundle without a usable index is scanned in full
+func (b *BundleReader) Search(query string, ignoreCase bool) (*SearchResult, error) {
+	if query == "" {
+		return nil, fmt.Errorf("search requires a query")
+	}
+	result := &SearchResult{Query: query, Matches: []LogMatch{}}
+
+	var spans map[string][]searchSpan
+	index, err := b.SearchIndex()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if index != nil && index.Version == searchIndexVersion {
+		result.Indexed = true
+		spans, result.ChunksSearched = index.spans(query)
+		if len(spans) == 0 {
+			return result, nil
+		}
+	}
+
+	matcher := newLineMatcher(query, ignoreCase)
+	scan := func(name string, r io.Reader) error {
+		fileSpans, ok := spans[name]
+		if !result.Indexed {
+			if !isSearchableName(name) {
+				return nil
+			}
+			fileSpans, ok = []searchSpan{{Start: 0, End: -1, Line: 1}}, true
+		}
+		if !ok {
+			return nil
+		}
+		matches, err := scanSearchSpans(name, r, fileSpans, matcher, !result.Indexed)
+		result.Matches = append(result.Matches, matches...)
+		return err
+	}
+
+	if !b.archive && result.Indexed {
+		// Open only the files with candidate chunks instead of walking the bundle
+		names := make([]string, 0, len(spans))
+		for name := range spans {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			file, err := os.Open(filepath.Join(b.path, filepath.FromSlash(name)))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			err = scan(name, file)
+			file.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	if err := b.Walk(scan); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].File < result.Matches[j].File
+	})
+	return result, nil
+}
+
+// scanSearchSpans returns the lines of the spans of one file that match, seeking between spans when r can seek
+// checkText skips binary files, which an index already left out
+func scanSearchSpans(name string, r io.Reader, spans []searchSpan, matches func(string) bool, checkText bool) ([]LogMatch, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	if checkText && !isTextContent(br) {
+		return nil, nil
+	}
+	seeker, canSeek := r.(io.Seeker)
+
+	var found []LogMatch
+	var pos int64
+	for _, span := range spans {
+		if canSeek {
+			if _, err := seeker.Seek(span.Start, io.SeekStart); err != nil {
+				return found, fmt.Errorf("failed to search %s: %w", name, err)
+			}
+			br.Reset(r)
+		} else if _, err := br.Discard(int(span.Start - pos)); err != nil {
+			return found, fmt.Errorf("failed to search %s: %w", name, err)
+		}
+		pos = span.Start
+
+		line := span.Line
+		for span.End < 0 || pos < span.End {
+			text, err := br.ReadString('\n')
+			pos += int64(len(text))
+			if text != "" {
+				text = strings.TrimRight(text, "\r\n")
+				if matches(text) {
+					found = append(found, LogMatch{File: name, Line: line, Text: text})
+				}
+				line++
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return found, fmt.Errorf("failed to search %s: %w", name, err)
+			}
+		}
+	}
+	return found, nil
+}
+
+// newLineMatcher matches lines containing query, ignoring ASCII case like the index does
+func newLineMatcher(query string, ignoreCase bool) func(string) bool {
+	if !ignoreCase {
+		return func(line string) bool { return strings.Contains(line, query) }
+	}
+	lowered := lowerASCIIString(query)
+	return func(line string) bool { return strings.Contains(lowerASCIIString(line), lowered) }
+}
+
+// isSearchableName reports whether a bundle file may hold searchable text
+func isSearchableName(name string) bool {
+	return name != SearchIndexFileName && !searchSkippedExtensions[path.Ext(name)]
+}
+
+// isTextContent reports whether the start of a file is free of NUL bytes
+func isTextContent(br *bufio.Reader) bool {
+	head, _ := br.Peek(searchSniffSize)
+	return bytes.IndexByte(head, 0) < 0
+}
+
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func lowerASCIIString(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		b[i] = lowerASCII(c)
+	}
+	return string(b)
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSearchBundle(t *testing.T, dir string) map[string][]byte {
+	var log strings.Builder
+	for i := 1; i <= 40; i++ {
+		switch i {
+		case 7:
+			log.WriteString("dial tcp 10.0.0.1:5432: Connection Refused\n")
+		case 33:
+			log.WriteString("retrying after connection refused\n")
+		default:
+			fmt.Fprintf(&log, "request %d served in 12ms\n", i)
+		}
+	}
+
+	files := map[string][]byte{
+		"namespaces/shop/logs/api.log":  []byte(log.String()),
+		"namespaces/shop/events.json":   []byte(`{"message": "Readiness probe failed: connection refused"}`),
+		"namespaces/shop/core.bin":      append([]byte{0x7f, 'E', 'L', 'F', 0}, []byte("connection refused")...),
+		"namespaces/shop/previous.gz":   []byte("connection refused"),
+		"cluster-info/nodes/node-1.txt": []byte("Ready"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return files
+}
+
+func searchHits(matches []LogMatch) []string {
+	hits := []string{}
+	for _, match := range matches {
+		hits = append(hits, fmt.Sprintf("%s:%d", match.File, match.Line))
+	}
+	return hits
+}
+
+func TestBundleReader_Search(t *testing.T) {
+	bundleDir := filepath.Join(t.TempDir(), "support-bundle-2024-01-01T00-00-00")
+	files := writeSearchBundle(t, bundleDir)
+	// Small chunks split api.log so a search reads only some of them
+	if _, err := writeSearchIndex(bundleDir, 256); err != nil {
+		t.Fatalf("Failed to write search index: %v", err)
+	}
+	indexData, err := os.ReadFile(filepath.Join(bundleDir, SearchIndexFileName))
+	if err != nil {
+		t.Fatalf("Failed to read search index: %v", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "bundle.tgz")
+	indexedFiles := map[string][]byte{SearchIndexFileName: indexData}
+	for name, data := range files {
+		indexedFiles[name] = data
+	}
+	writeInspectArchive(t, archivePath, "support-bundle-2024-01-01T00-00-00", indexedFiles)
+	unindexedDir := t.TempDir()
+	writeSearchBundle(t, unindexedDir)
+
+	tests := []struct {
+		name    string
+		path    string
+		indexed bool
+	}{
+		{name: "directory", path: bundleDir, indexed: true},
+		{name: "archive", path: archivePath, indexed: true},
+		{name: "without index", path: unindexedDir, indexed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := OpenBundle(tt.path)
+			if err != nil {
+				t.Fatalf("Failed to open bundle: %v", err)
+			}
+
+			result, err := bundle.Search("connection refused", true)
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+			expected := []string{"namespaces/shop/events.json:1", "namespaces/shop/logs/api.log:7", "namespaces/shop/logs/api.log:33"}
+			if !reflect.DeepEqual(searchHits(result.Matches), expected) {
+				t.Errorf("Expected hits %v, got %v", expected, searchHits(result.Matches))
+			}
+			if result.Indexed != tt.indexed {
+				t.Errorf("Expected indexed %v, got %v", tt.indexed, result.Indexed)
+			}
+			if tt.indexed && result.ChunksSearched != 3 {
+				t.Errorf("Expected only the 3 chunks holding the query to be read, got %d", result.ChunksSearched)
+			}
+			if result.Matches[1].Text != "dial tcp 10.0.0.1:5432: Connection Refused" {
+				t.Errorf("Expected the matched line, got %q", result.Matches[1].Text)
+			}
+
+			result, err = bundle.Search("connection refused", false)
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+			if len(result.Matches) != 2 {
+				t.Errorf("Expected the case-sensitive search to skip line 7, got %v", searchHits(result.Matches))
+			}
+
+			result, err = bundle.Search("no route to host", false)
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+			if len(result.Matches) != 0 || (tt.indexed && result.ChunksSearched != 0) {
+				t.Errorf("Expected no hits and no chunks read, got %v after %d chunks", searchHits(result.Matches), result.ChunksSearched)
+			}
+		})
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeSearchBundle(t, bundleDir)
+	index, err := buildSearchIndex(bundleDir, 256)
+	if err != nil {
+		t.Fatalf("Failed to build search index: %v", err)
+	}
+
+	var names []string
+	for _, file := range index.Files {
+		names = append(names, file.Name)
+	}
+	expected := []string{"cluster-info/nodes/node-1.txt", "namespaces/shop/events.json", "namespaces/shop/logs/api.log"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected binary and compressed files left out, got %v", names)
+	}
+
+	for i, chunk := range index.Chunks[1:] {
+		previous := index.Chunks[i]
+		if chunk.File == previous.File && (chunk.Offset <= previous.Offset || chunk.Line <= previous.Line) {
+			t.Errorf("Expected chunks in file order, got %+v after %+v", chunk, previous)
+		}
+	}
+	if len(index.Chunks) < 5 {
+		t.Errorf("Expected api.log split into several chunks, got %d chunks", len(index.Chunks))
+	}
+
+	// Every chunk holding a two-character query is a candidate
+	if candidates := index.candidateChunks("ms"); len(candidates) != len(index.Chunks) {
+		t.Errorf("Expected every chunk for a short query, got %d of %d", len(candidates), len(index.Chunks))
+	}
+	if candidates := index.candidateChunks("READY"); len(candidates) != 1 || index.Files[index.Chunks[candidates[0]].File].Name != "cluster-info/nodes/node-1.txt" {
+		t.Errorf("Expected only the node chunk for READY, got %v", candidates)
+	}
+}
+
+func TestRunInspectBundle_SearchWithoutQuery(t *testing.T) {
+	if err := RunInspectBundle(InspectBundleOptions{BundlePath: t.TempDir(), Command: InspectSearch}); err == nil {
+		t.Errorf("Expected error for search without a query")
+	}
+}
@@ -0,0 +1,527 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// ManifestFileName is the checksum manifest written at the bundle root
+	ManifestFileName = "manifest.json"
+	// SignatureFileName holds the minisign signature of the manifest
+	SignatureFileName = "manifest.json.minisig"
+
+	manifestVersion   = "v1"
+	manifestAlgorithm = "sha256"
+
+	// minisign algorithm ids: Ed25519 keys, and signatures over the BLAKE2b-512 of the file
+	minisignKeyAlgorithm      = "Ed"
+	minisignHashedAlgorithm   = "ED"
+	minisignUntrustedPrefix   = "untrusted comment: "
+	minisignTrustedPrefix     = "trusted comment: "
+	minisignKeyIDSize         = 8
+	minisignSignatureBlobSize = 2 + minisignKeyIDSize + ed25519.SignatureSize
+	minisignPublicKeyBlobSize = 2 + minisignKeyIDSize + ed25519.PublicKeySize
+)
+
+// BundleManifest lists the checksum of every file in a support bundle
+type BundleManifest struct {
+	Version     string          `json:"version"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Algorithm   string          `json:"algorithm"`
+	Files       []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is the checksum of a single bundle file
+type ManifestEntry struct {
+	Path   string `json:"path"` // Slash separated, relative to the bundle root
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// BundleVerificationResult describes whether a bundle matches its manifest and signature
+type BundleVerificationResult struct {
+	BundlePath       string   `json:"bundlePath"`
+	Signed           bool     `json:"signed"`
+	SignatureChecked bool     `json:"signatureChecked"`
+	SignatureValid   bool     `json:"signatureValid"`
+	FilesChecked     int      `json:"filesChecked"`
+	ModifiedFiles    []string `json:"modifiedFiles,omitempty"`
+	MissingFiles     []string `json:"missingFiles,omitempty"`
+	UnexpectedFiles  []string `json:"unexpectedFiles,omitempty"`
+	Valid            bool     `json:"valid"`
+}
+
+// VerifyBundleOptions represents CLI options for `support-bundle verify <bundle>`
+type VerifyBundleOptions struct {
+	BundlePath string `json:"bundlePath"`
+	PublicKey  string `json:"publicKey,omitempty"` // PEM Ed25519 public key; checksums only when empty
+	Output     string `json:"output,omitempty"`    // "console" or "json"
+}
+
+// BuildBundleManifest computes checksums for every file in the bundle directory
+// The manifest and signature files themselves are skipped
+func BuildBundleManifest(bundleDir string) (*BundleManifest, error) {
+	manifest := &BundleManifest{
+		Version:     manifestVersion,
+		GeneratedAt: time.Now().UTC(),
+		Algorithm:   manifestAlgorithm,
+		Files:       []ManifestEntry{},
+	}
+
+	err := walkBundleFiles(bundleDir, func(path, rel string) error {
+		sum, size, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, ManifestEntry{Path: rel, SHA256: sum, Size: size})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build bundle manifest: %w", err)
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+	return manifest, nil
+}
+
+// SignBundle writes the checksum manifest into the bundle and signs it with the given key
+// A nil key writes the manifest without a signature
+func SignBundle(bundleDir string, key ed25519.PrivateKey) (*BundleManifest, error) {
+	manifest, err := BuildBundleManifest(bundleDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, ManifestFileName), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+
+	if key == nil {
+		// Drop any signature from an earlier run, it no longer matches the manifest
+		if err := os.Remove(filepath.Join(bundleDir, SignatureFileName)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale bundle signature: %w", err)
+		}
+		return manifest, nil
+	}
+
+	signature := minisignSign(key, data, fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), ManifestFileName))
+	if err := os.WriteFile(filepath.Join(bundleDir, SignatureFileName), signature, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write bundle signature: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// VerifyBundle checks the bundle files against its manifest and, when a public key is
+// given, checks the manifest signature. The bundle may be a directory or an archive of one
+// A signed bundle is only valid once its signature has been checked, since anyone can
+// regenerate the manifest after editing a file
+func VerifyBundle(bundlePath string, publicKey ed25519.PublicKey) (*BundleVerificationResult, error) {
+	reader, err := OpenBundle(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash every file in one pass so an archive is only decompressed once
+	var data, signature []byte
+	signed := false
+	actual := make(map[string]ManifestEntry)
+	err = reader.Walk(func(name string, r io.Reader) error {
+		var err error
+		switch name {
+		case ManifestFileName:
+			data, err = io.ReadAll(r)
+		case SignatureFileName:
+			signature, err = io.ReadAll(r)
+			signed = true
+		default:
+			sum, size, hashErr := readerSHA256(r)
+			if hashErr != nil {
+				return fmt.Errorf("failed to hash %s: %w", name, hashErr)
+			}
+			actual[name] = ManifestEntry{Path: name, SHA256: sum, Size: size}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify bundle files: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("failed to read bundle manifest: %s: %w", ManifestFileName, os.ErrNotExist)
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+	if manifest.Algorithm != manifestAlgorithm {
+		return nil, fmt.Errorf("unsupported manifest algorithm %q", manifest.Algorithm)
+	}
+
+	result := &BundleVerificationResult{BundlePath: bundlePath, Signed: signed}
+
+	if publicKey != nil {
+		result.SignatureChecked = true
+		if result.Signed {
+			result.SignatureValid = minisignVerify(publicKey, data, signature) == nil
+		}
+	}
+
+	expected := make(map[string]ManifestEntry, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		expected[entry.Path] = entry
+	}
+
+	for rel, file := range actual {
+		entry, ok := expected[rel]
+		if !ok {
+			result.UnexpectedFiles = append(result.UnexpectedFiles, rel)
+			continue
+		}
+		delete(expected, rel)
+
+		result.FilesChecked++
+		if file.SHA256 != entry.SHA256 || file.Size != entry.Size {
+			result.ModifiedFiles = append(result.ModifiedFiles, rel)
+		}
+	}
+
+	for rel := range expected {
+		result.MissingFiles = append(result.MissingFiles, rel)
+	}
+	sort.Strings(result.MissingFiles)
+	sort.Strings(result.ModifiedFiles)
+	sort.Strings(result.UnexpectedFiles)
+
+	signatureOK := !result.Signed && !result.SignatureChecked
+	if result.SignatureChecked {
+		signatureOK = result.SignatureValid
+	}
+	result.Valid = len(result.ModifiedFiles) == 0 && len(result.MissingFiles) == 0 && len(result.UnexpectedFiles) == 0 && signatureOK
+
+	return result, nil
+}
+
+// signBundle writes the manifest (and signature when a key path is set) and records the paths
+func signBundle(outputDir, signingKeyPath string, result *CollectionResult) error {
+	var key ed25519.PrivateKey
+	if signingKeyPath != "" {
+		loaded, err := LoadSigningKey(signingKeyPath)
+		if err != nil {
+			return err
+		}
+		key = loaded
+	}
+
+	if _, err := SignBundle(outputDir, key); err != nil {
+		return err
+	}
+
+	result.ManifestPath = filepath.Join(outputDir, ManifestFileName)
+	if key != nil {
+		result.SignaturePath = filepath.Join(outputDir, SignatureFileName)
+	}
+	return nil
+}
+
+// RunVerifyBundle implements `support-bundle verify <bundle>`
+func RunVerifyBundle(options VerifyBundleOptions) (*BundleVerificationResult, error) {
+	var publicKey ed25519.PublicKey
+	if options.PublicKey != "" {
+		key, err := LoadVerificationKey(options.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	}
+
+	result, err := VerifyBundle(options.BundlePath, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal verification result: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printVerificationResult(result)
+	}
+
+	if !result.Valid {
+		return result, fmt.Errorf("bundle %s failed verification", options.BundlePath)
+	}
+	return result, nil
+}
+
+// GenerateBundleSigningKey writes a new Ed25519 key pair: the private key as PEM and the
+// public key in minisign format, so recipients can also check a bundle with `minisign -V`
+func GenerateBundleSigningKey(privateKeyPath, publicKeyPath string) error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	if err := os.WriteFile(privateKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := os.WriteFile(publicKeyPath, MinisignPublicKey(publicKey), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+	return nil
+}
+
+// LoadSigningKey reads a PEM encoded PKCS#8 Ed25519 private key,
+// as produced by `openssl genpkey -algorithm ed25519`
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+	return privateKey, nil
+}
+
+// LoadVerificationKey reads an Ed25519 public key, either a minisign public key or PEM encoded PKIX
+func LoadVerificationKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+	if strings.HasPrefix(string(data), minisignUntrustedPrefix) {
+		publicKey, err := parseMinisignPublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+		}
+		return publicKey, nil
+	}
+
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+	return publicKey, nil
+}
+
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s does not contain PEM data", path)
+	}
+	return block, nil
+}
+
+// MinisignPublicKey encodes an Ed25519 public key as a minisign public key file
+func MinisignPublicKey(publicKey ed25519.PublicKey) []byte {
+	keyID := minisignKeyID(publicKey)
+	blob := make([]byte, 0, minisignPublicKeyBlobSize)
+	blob = append(blob, minisignKeyAlgorithm...)
+	blob = append(blob, keyID...)
+	blob = append(blob, publicKey...)
+	return []byte(fmt.Sprintf("%sminisign public key %016X\n%s\n",
+		minisignUntrustedPrefix, binary.LittleEndian.Uint64(keyID), base64.StdEncoding.EncodeToString(blob)))
+}
+
+// minisignKeyID derives the 8 byte minisign key id from the public key, so a key loaded from
+// PEM and the same key in minisign format agree on it
+func minisignKeyID(publicKey ed25519.PublicKey) []byte {
+	sum := sha256.Sum256(publicKey)
+	return sum[:minisignKeyIDSize]
+}
+
+// minisignSign returns a minisign signature file for data: an Ed25519 signature over the
+// BLAKE2b-512 of data, and a global signature binding the trusted comment to it
+func minisignSign(key ed25519.PrivateKey, data []byte, trustedComment string) []byte {
+	publicKey := key.Public().(ed25519.PublicKey)
+	keyID := minisignKeyID(publicKey)
+	digest := blake2b.Sum512(data)
+	signature := ed25519.Sign(key, digest[:])
+
+	blob := make([]byte, 0, minisignSignatureBlobSize)
+	blob = append(blob, minisignHashedAlgorithm...)
+	blob = append(blob, keyID...)
+	blob = append(blob, signature...)
+	globalSignature := ed25519.Sign(key, append(append([]byte{}, signature...), trustedComment...))
+
+	return []byte(fmt.Sprintf("%ssignature from support-bundle secret key %016X\n%s\n%s%s\n%s\n",
+		minisignUntrustedPrefix, binary.LittleEndian.Uint64(keyID), base64.StdEncoding.EncodeToString(blob),
+		minisignTrustedPrefix, trustedComment, base64.StdEncoding.EncodeToString(globalSignature)))
+}
+
+// minisignVerify checks a minisign signature file over data, including its trusted comment
+func minisignVerify(publicKey ed25519.PublicKey, data, signatureFile []byte) error {
+	lines := strings.Split(strings.TrimRight(string(signatureFile), "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], minisignUntrustedPrefix) || !strings.HasPrefix(lines[2], minisignTrustedPrefix) {
+		return fmt.Errorf("malformed minisign signature")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(blob) != minisignSignatureBlobSize {
+		return fmt.Errorf("malformed minisign signature")
+	}
+	signature := blob[2+minisignKeyIDSize:]
+
+	message := data
+	switch string(blob[:2]) {
+	case minisignHashedAlgorithm:
+		digest := blake2b.Sum512(data)
+		message = digest[:]
+	case minisignKeyAlgorithm:
+		// Legacy minisign signature over the data itself
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", blob[:2])
+	}
+	if !ed25519.Verify(publicKey, message, signature) {
+		return fmt.Errorf("signature does not match the manifest")
+	}
+
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), minisignTrustedPrefix)
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(publicKey, append(append([]byte{}, signature...), trustedComment...), globalSignature) {
+		return fmt.Errorf("trusted comment signature is invalid")
+	}
+	return nil
+}
+
+// parseMinisignPublicKey decodes a minisign public key file
+func parseMinisignPublicKey(data []byte) (ed25519.PublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("malformed minisign public key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(blob) != minisignPublicKeyBlobSize || string(blob[:2]) != minisignKeyAlgorithm {
+		return nil, fmt.Errorf("malformed minisign public key")
+	}
+	return ed25519.PublicKey(blob[2+minisignKeyIDSize:]), nil
+}
+
+// walkBundleFiles calls fn for every regular file in the bundle except the manifest and signature
+func walkBundleFiles(bundleDir string, fn func(path, rel string) error) error {
+	return filepath.WalkDir(bundleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(bundleDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFileName || rel == SignatureFileName {
+			return nil
+		}
+		return fn(path, rel)
+	})
+}
+
+func fileSHA256(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	sum, size, err := readerSHA256(f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return sum, size, nil
+}
+
+// readerSHA256 returns the hex SHA-256 and size of everything read from r
+func readerSHA256(r io.Reader) (string, int64, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func printVerificationResult(result *BundleVerificationResult) {
+	fmt.Printf("🔏 Bundle Verification: %s\n", result.BundlePath)
+	fmt.Printf("   Files checked: %d\n", result.FilesChecked)
+
+	switch {
+	case !result.SignatureChecked && result.Signed:
+		fmt.Printf("   ❌ Checksums only, signature not verified (pass --public-key to check it)\n")
+	case !result.SignatureChecked:
+		fmt.Printf("   ⚠️  Checksums only, bundle is not signed\n")
+	case !result.Signed:
+		fmt.Printf("   ❌ Bundle is not signed\n")
+	case result.SignatureValid:
+		fmt.Printf("   ✅ Signature valid\n")
+	default:
+		fmt.Printf("   ❌ Signature invalid\n")
+	}
+
+	for _, path := range result.ModifiedFiles {
+		fmt.Printf("   ❌ Modified: %s\n", path)
+	}
+	for _, path := range result.MissingFiles {
+		fmt.Printf("   ❌ Missing: %s\n", path)
+	}
+	for _, path := range result.UnexpectedFiles {
+		fmt.Printf("   ❌ Unexpected: %s\n", path)
+	}
+
+	if result.Valid {
+		fmt.Printf("✅ Bundle verified\n")
+	} else {
+		fmt.Printf("❌ Bundle failed verification\n")
+	}
+}
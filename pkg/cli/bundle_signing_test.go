@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+func writeTestBundle(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"analysis.json":                 `{"results":[]}`,
+		"namespaces/default/index.json": `{"namespace":"default"}`,
+	}
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	return dir
+}
+
+func writeTestKeys(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "bundle.key")
+	publicPath := filepath.Join(dir, "bundle.pub")
+	if err := GenerateBundleSigningKey(privatePath, publicPath); err != nil {
+		t.Fatalf("Failed to generate keys: %v", err)
+	}
+	return privatePath, publicPath
+}
+
+func TestSignAndVerifyBundle(t *testing.T) {
+	privatePath, publicPath := writeTestKeys(t)
+	_, otherPublicPath := writeTestKeys(t)
+
+	privateKey, err := LoadSigningKey(privatePath)
+	if err != nil {
+		t.Fatalf("Failed to load signing key: %v", err)
+	}
+	publicKey, err := LoadVerificationKey(publicPath)
+	if err != nil {
+		t.Fatalf("Failed to load public key: %v", err)
+	}
+	otherPublicKey, err := LoadVerificationKey(otherPublicPath)
+	if err != nil {
+		t.Fatalf("Failed to load public key: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		tamper          func(t *testing.T, dir string)
+		useOtherKey     bool
+		noKey           bool
+		expectValid     bool
+		expectSignature bool
+		expectModified  int
+		expectMissing   int
+		expectUnexpect  int
+	}{
+		{
+			name:            "untouched bundle",
+			tamper:          func(t *testing.T, dir string) {},
+			expectValid:     true,
+			expectSignature: true,
+		},
+		{
+			name: "modified file",
+			tamper: func(t *testing.T, dir string) {
+				os.WriteFile(filepath.Join(dir, "analysis.json"), []byte(`{"results":["edited"]}`), 0644)
+			},
+			expectValid:     false,
+			expectSignature: true,
+			expectModified:  1,
+		},
+		{
+			name: "removed file",
+			tamper: func(t *testing.T, dir string) {
+				os.Remove(filepath.Join(dir, "namespaces/default/index.json"))
+			},
+			expectValid:     false,
+			expectSignature: true,
+			expectMissing:   1,
+		},
+		{
+			name: "added file",
+			tamper: func(t *testing.T, dir string) {
+				os.WriteFile(filepath.Join(dir, "extra.txt"), []byte("injected"), 0644)
+			},
+			expectValid:     false,
+			expectSignature: true,
+			expectUnexpect:  1,
+		},
+		{
+			name: "edited manifest",
+			tamper: func(t *testing.T, dir string) {
+				data, _ := os.ReadFile(filepath.Join(dir, ManifestFileName))
+				os.WriteFile(filepath.Join(dir, ManifestFileName), append(data, '\n'), 0644)
+			},
+			expectValid:     false,
+			expectSignature: false,
+		},
+		{
+			name: "modified file with regenerated manifest",
+			tamper: func(t *testing.T, dir string) {
+				os.WriteFile(filepath.Join(dir, "analysis.json"), []byte(`{"results":["edited"]}`), 0644)
+				manifest, _ := BuildBundleManifest(dir)
+				data, _ := json.MarshalIndent(manifest, "", "  ")
+				os.WriteFile(filepath.Join(dir, ManifestFileName), data, 0644)
+			},
+			expectValid:     false,
+			expectSignature: false,
+		},
+		{
+			name:            "signed bundle without public key",
+			tamper:          func(t *testing.T, dir string) {},
+			noKey:           true,
+			expectValid:     false,
+			expectSignature: false,
+		},
+		{
+			name:            "wrong public key",
+			tamper:          func(t *testing.T, dir string) {},
+			useOtherKey:     true,
+			expectValid:     false,
+			expectSignature: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestBundle(t)
+			if _, err := SignBundle(dir, privateKey); err != nil {
+				t.Fatalf("Failed to sign bundle: %v", err)
+			}
+			tt.tamper(t, dir)
+
+			key := publicKey
+			if tt.useOtherKey {
+				key = otherPublicKey
+			}
+			if tt.noKey {
+				key = nil
+			}
+			result, err := VerifyBundle(dir, key)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result.Valid != tt.expectValid {
+				t.Errorf("Expected valid %v, got %v", tt.expectValid, result.Valid)
+			}
+			if result.SignatureValid != tt.expectSignature {
+				t.Errorf("Expected signature valid %v, got %v", tt.expectSignature, result.SignatureValid)
+			}
+			if len(result.ModifiedFiles) != tt.expectModified {
+				t.Errorf("Expected %d modified files, got %v", tt.expectModified, result.ModifiedFiles)
+			}
+			if len(result.MissingFiles) != tt.expectMissing {
+				t.Errorf("Expected %d missing files, got %v", tt.expectMissing, result.MissingFiles)
+			}
+			if len(result.UnexpectedFiles) != tt.expectUnexpect {
+				t.Errorf("Expected %d unexpected files, got %v", tt.expectUnexpect, result.UnexpectedFiles)
+			}
+		})
+	}
+}
+
+func TestVerifyBundle_Unsigned(t *testing.T) {
+	_, publicPath := writeTestKeys(t)
+	publicKey, err := LoadVerificationKey(publicPath)
+	if err != nil {
+		t.Fatalf("Failed to load public key: %v", err)
+	}
+
+	dir := writeTestBundle(t)
+	if _, err := SignBundle(dir, nil); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	// Checksums alone pass without a public key
+	result, err := VerifyBundle(dir, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Valid || result.Signed || result.SignatureChecked {
+		t.Errorf("Expected valid unsigned bundle without signature check, got %+v", result)
+	}
+	if result.FilesChecked != 2 {
+		t.Errorf("Expected 2 files checked, got %d", result.FilesChecked)
+	}
+
+	// Requiring a signature fails an unsigned bundle
+	result, err = VerifyBundle(dir, publicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Errorf("Expected unsigned bundle to fail when a public key is given")
+	}
+}
+
+func TestSignBundle_MinisignFormat(t *testing.T) {
+	privatePath, publicPath := writeTestKeys(t)
+	privateKey, err := LoadSigningKey(privatePath)
+	if err != nil {
+		t.Fatalf("Failed to load signing key: %v", err)
+	}
+
+	dir := writeTestBundle(t)
+	if _, err := SignBundle(dir, privateKey); err != nil {
+		t.Fatalf("Failed to sign bundle: %v", err)
+	}
+
+	manifest, _ := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	signatureFile, _ := os.ReadFile(filepath.Join(dir, SignatureFileName))
+	publicKeyFile, _ := os.ReadFile(publicPath)
+
+	// Decode both files as minisign does: algorithm, key id, then the key or signature
+	publicLines := strings.Split(strings.TrimSpace(string(publicKeyFile)), "\n")
+	publicBlob, err := base64.StdEncoding.DecodeString(publicLines[1])
+	if err != nil || len(publicBlob) != 42 || string(publicBlob[:2]) != "Ed" {
+		t.Fatalf("Expected a minisign public key, got %q", publicKeyFile)
+	}
+	lines := strings.Split(strings.TrimSpace(string(signatureFile)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment: ") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		t.Fatalf("Expected a 4 line minisign signature, got %q", signatureFile)
+	}
+	signatureBlob, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(signatureBlob) != 74 || string(signatureBlob[:2]) != "ED" {
+		t.Fatalf("Expected a prehashed minisign signature, got %q", lines[1])
+	}
+	if string(signatureBlob[2:10]) != string(publicBlob[2:10]) {
+		t.Errorf("Expected the signature key id to match the public key")
+	}
+
+	publicKey := ed25519.PublicKey(publicBlob[10:])
+	digest := blake2b.Sum512(manifest)
+	if !ed25519.Verify(publicKey, digest[:], signatureBlob[10:]) {
+		t.Errorf("Expected the signature to cover the BLAKE2b-512 of the manifest")
+	}
+	globalSignature, _ := base64.StdEncoding.DecodeString(lines[3])
+	trusted := append(append([]byte{}, signatureBlob[10:]...), strings.TrimPrefix(lines[2], "trusted comment: ")...)
+	if !ed25519.Verify(publicKey, trusted, globalSignature) {
+		t.Errorf("Expected the global signature to cover the trusted comment")
+	}
+}
+
+func TestVerifyBundle_NoManifest(t *testing.T) {
+	if _, err := VerifyBundle(writeTestBundle(t), nil); err == nil {
+		t.Errorf("Expected error for bundle without manifest")
+	}
+}
@@ -0,0 +1,495 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Upload defaults used when BundleUploadOptions leaves them unset
+const (
+	DefaultUploadChunkSize  = 16 << 20
+	DefaultUploadRetries    = 5
+	DefaultUploadBackoff    = time.Second
+	DefaultUploadMaxBackoff = 30 * time.Second
+)
+
+// UploadStateSuffix is appended to the bundle path for the file that lets an interrupted upload resume
+const UploadStateSuffix = ".upload.json"
+
+// tusVersion is the version of the tus resumable upload protocol spoken, see https://tus.io/protocols/resumable-upload
+const tusVersion = "1.0.0"
+
+// BundleUploadOptions configures a resumable bundle upload to a tus server
+type BundleUploadOptions struct {
+	URL        string            // Creation endpoint, the bundle is uploaded to the location it returns
+	Headers    map[string]string // Added to every request, e.g. Authorization
+	ChunkSize  int64             // Bytes sent per PATCH request, a failed part is retried on its own
+	MaxRetries int               // Retries of one part before the upload fails, 0 uses DefaultUploadRetries and -1 none
+	Backoff    time.Duration     // Wait before the first retry of a part, doubled for each later one
+	MaxBackoff time.Duration     // Caps the doubled wait
+	Client     *http.Client
+	Progress   func(progress UploadProgress) // Called after each part
+}
+
+// UploadProgress reports how much of the bundle the server has received
+type UploadProgress struct {
+	Uploaded int64 `json:"uploaded"`
+	Total    int64 `json:"total"`
+	Retries  int   `json:"retries"`
+}
+
+// UploadResult describes a completed upload
+type UploadResult struct {
+	Location string        `json:"location"`
+	Size     int64         `json:"size"`
+	Resumed  int64         `json:"resumed,omitempty"` // Bytes uploaded by an earlier, interrupted run
+	Parts    int           `json:"parts"`
+	Retries  int           `json:"retries,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// uploadState is saved next to the bundle as soon as the server has created the upload, and removed once it
+// completes. The bundle size and modification time guard against resuming with a different file
+type uploadState struct {
+	Endpoint string    `json:"endpoint"`
+	Location string    `json:"location"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+}
+
+// uploadStatusError is a response the server should not have sent at this point of the protocol
+type uploadStatusError struct {
+	method string
+	status int
+}
+
+func (e *uploadStatusError) Error() string {
+	return fmt.Sprintf("%s returned %d %s", e.method, e.status, http.StatusText(e.status))
+}
+
+// UploadStatePath returns the resume state file of a bundle
+func UploadStatePath(bundlePath string) string {
+	return bundlePath + UploadStateSuffix
+}
+
+// withDefaults fills unset options
+func (o BundleUploadOptions) withDefaults() BundleUploadOptions {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultUploadChunkSize
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = DefaultUploadRetries
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultUploadBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultUploadMaxBackoff
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	return o
+}
+
+// ValidateUploadURL checks an --upload-url value
+func ValidateUploadURL(uploadURL string) error {
+	u, err := url.Parse(uploadURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q is not an http or https URL", uploadURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", uploadURL)
+	}
+	return nil
+}
+
+// UploadBundle uploads a bundle archive in parts with the tus protocol. Each part that fails with a network
+// error, 5xx, 409, 423 or 429 is retried from the offset the server reports. When the upload fails, the state
+// file next to the bundle is kept and calling UploadBundle again on the unchanged archive with the same endpoint,
+// e.g. through RunUploadBundle, resumes it
+func UploadBundle(ctx context.Context, bundlePath string, opts BundleUploadOptions) (*UploadResult, error) {
+	opts = opts.withDefaults()
+	startTime := time.Now()
+
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat bundle: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a bundle archive, directory bundles cannot be uploaded", bundlePath)
+	}
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer file.Close()
+
+	uploader := &tusUploader{opts: opts}
+	state, offset, err := uploader.resume(ctx, bundlePath, info)
+	if err != nil {
+		return nil, err
+	}
+	result := &UploadResult{Size: info.Size(), Resumed: offset}
+	if state == nil {
+		state, err = uploader.create(ctx, bundlePath, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Location = state.Location
+
+	for offset < info.Size() {
+		length := opts.ChunkSize
+		if remaining := info.Size() - offset; remaining < length {
+			length = remaining
+		}
+		offset, err = uploader.sendPart(ctx, state.Location, io.NewSectionReader(file, offset, length), offset, length)
+		if err != nil {
+			return nil, fmt.Errorf("upload interrupted at %d of %d bytes, resume with `support-bundle upload %s`: %w", offset, info.Size(), bundlePath, err)
+		}
+		result.Parts++
+		if opts.Progress != nil {
+			opts.Progress(UploadProgress{Uploaded: offset, Total: info.Size(), Retries: uploader.retries})
+		}
+	}
+
+	if err := os.Remove(UploadStatePath(bundlePath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning: failed to remove upload state: %v\n", err)
+	}
+	result.Retries = uploader.retries
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// tusUploader sends the requests of one upload
+type tusUploader struct {
+	opts    BundleUploadOptions
+	retries int
+}
+
+// resume loads the state of an interrupted upload of the same bundle to the same endpoint and asks the server how
+// much it received. It returns a nil state when there is nothing to resume, e.g. the server expired the upload
+func (u *tusUploader) resume(ctx context.Context, bundlePath string, info os.FileInfo) (*uploadState, int64, error) {
+	data, err := os.ReadFile(UploadStatePath(bundlePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read upload state: %w", err)
+	}
+	var state uploadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		fmt.Printf("Warning: ignoring unreadable upload state: %v\n", err)
+		return nil, 0, nil
+	}
+	if state.Endpoint != u.opts.URL {
+		fmt.Printf("Warning: the interrupted upload went to %s, starting a new upload\n", state.Endpoint)
+		return nil, 0, nil
+	}
+	if state.Size != info.Size() || !state.ModTime.Equal(info.ModTime()) {
+		fmt.Printf("Warning: %s changed since the interrupted upload, starting a new upload\n", bundlePath)
+		return nil, 0, nil
+	}
+
+	offset, err := u.withRetries(ctx, func() (int64, error) {
+		return u.headOffset(ctx, state.Location)
+	})
+	var statusErr *uploadStatusError
+	if errors.As(err, &statusErr) && statusErr.status < 500 {
+		fmt.Printf("Warning: the interrupted upload is no longer available (%v), starting a new upload\n", err)
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to resume upload: %w", err)
+	}
+	if offset > state.Size {
+		fmt.Printf("Warning: the server reports %d bytes of a %d byte upload, starting a new upload\n", offset, state.Size)
+		return nil, 0, nil
+	}
+	return &state, offset, nil
+}
+
+// create asks the server for a new upload and saves its location before any data is sent
+func (u *tusUploader) create(ctx context.Context, bundlePath string, info os.FileInfo) (*uploadState, error) {
+	var state *uploadState
+	_, err := u.withRetries(ctx, func() (int64, error) {
+		req, err := u.newRequest(ctx, http.MethodPost, u.opts.URL, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Upload-Length", strconv.FormatInt(info.Size(), 10))
+		req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filepath.Base(bundlePath))))
+
+		resp, err := u.opts.Client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer drainBody(resp)
+		if resp.StatusCode != http.StatusCreated {
+			return 0, &uploadStatusError{method: http.MethodPost, status: resp.StatusCode}
+		}
+		location, err := resolveUploadLocation(u.opts.URL, resp.Header.Get("Location"))
+		if err != nil {
+			return 0, err
+		}
+		state = &uploadState{Endpoint: u.opts.URL, Location: location, Size: info.Size(), ModTime: info.ModTime()}
+		return 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal upload state: %w", err)
+	}
+	if err := os.WriteFile(UploadStatePath(bundlePath), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write upload state: %w", err)
+	}
+	return state, nil
+}
+
+// sendPart sends one part and returns the new offset. A failed attempt asks the server for its offset before the
+// retry, since it may have stored some of the part, and only the remaining bytes are resent
+func (u *tusUploader) sendPart(ctx context.Context, location string, part *io.SectionReader, offset, length int64) (int64, error) {
+	end := offset + length
+	current := offset
+	_, err := u.withRetries(ctx, func() (int64, error) {
+		if current < offset || current > end {
+			return 0, fmt.Errorf("server reported offset %d outside the part %d-%d", current, offset, end)
+		}
+		if current == end {
+			return current, nil
+		}
+
+		body := io.NewSectionReader(part, current-offset, end-current)
+		req, err := u.newRequest(ctx, http.MethodPatch, location, body)
+		if err != nil {
+			return 0, err
+		}
+		req.ContentLength = end - current
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", strconv.FormatInt(current, 10))
+
+		resp, err := u.opts.Client.Do(req)
+		if err == nil {
+			drainBody(resp)
+			if resp.StatusCode == http.StatusNoContent {
+				current, err = parseUploadOffset(resp)
+				if err == nil && current != end {
+					err = fmt.Errorf("server stored %d of %d bytes", current-offset, length)
+				}
+				if err == nil {
+					return current, nil
+				}
+			} else {
+				err = &uploadStatusError{method: http.MethodPatch, status: resp.StatusCode}
+			}
+		}
+
+		// Resynchronize before the retry; if the server cannot say, resend the part from its start
+		if synced, headErr := u.headOffset(ctx, location); headErr == nil {
+			current = synced
+		} else {
+			current = offset
+		}
+		return 0, err
+	})
+	if err != nil {
+		return current, err
+	}
+	return end, nil
+}
+
+// headOffset asks the server how many bytes of the upload it has stored
+func (u *tusUploader) headOffset(ctx context.Context, location string) (int64, error) {
+	req, err := u.newRequest(ctx, http.MethodHead, location, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := u.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer drainBody(resp)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return 0, &uploadStatusError{method: http.MethodHead, status: resp.StatusCode}
+	}
+	return parseUploadOffset(resp)
+}
+
+// withRetries runs attempt until it succeeds, fails with an error that is not worth retrying or runs out of
+// retries, waiting with a doubling backoff between attempts
+func (u *tusUploader) withRetries(ctx context.Context, attempt func() (int64, error)) (int64, error) {
+	backoff := u.opts.Backoff
+	for retry := 0; ; retry++ {
+		value, err := attempt()
+		if err == nil {
+			return value, nil
+		}
+		if retry >= u.opts.MaxRetries || !isRetryableUploadError(ctx, err) {
+			return value, err
+		}
+
+		u.retries++
+		fmt.Printf("Warning: upload request failed, retrying in %v (%d/%d): %v\n", backoff, retry+1, u.opts.MaxRetries, err)
+		select {
+		case <-ctx.Done():
+			return value, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > u.opts.MaxBackoff {
+			backoff = u.opts.MaxBackoff
+		}
+	}
+}
+
+// newRequest creates a tus request with the configured headers
+func (u *tusUploader) newRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range u.opts.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Tus-Resumable", tusVersion)
+	return req, nil
+}
+
+// isRetryableUploadError reports whether a failed request may succeed when sent again: network errors, server
+// errors, and the conflict, locked and throttled responses of a server still busy with an earlier request
+func isRetryableUploadError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *uploadStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.status {
+		case http.StatusConflict, http.StatusLocked, http.StatusTooManyRequests:
+			return true
+		}
+		return statusErr.status >= 500
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseUploadOffset reads the Upload-Offset header of a response
+func parseUploadOffset(resp *http.Response) (int64, error) {
+	offset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid Upload-Offset %q", resp.Header.Get("Upload-Offset"))
+	}
+	return offset, nil
+}
+
+// resolveUploadLocation resolves the Location of a created upload, which may be relative to the endpoint
+func resolveUploadLocation(endpoint, location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("server did not return the upload location")
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid upload location %q: %w", location, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// drainBody reads the rest of a response so the connection can be reused
+func drainBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// UploadBundleOptions are the options of `support-bundle upload <archive>`
+type UploadBundleOptions struct {
+	BundlePath      string `json:"bundlePath"`
+	UploadURL       string `json:"uploadURL"`
+	UploadToken     string `json:"-"`
+	UploadChunkSize int64  `json:"uploadChunkSize,omitempty"`
+	Output          string `json:"output,omitempty"` // "console" or "json"
+}
+
+// RunUploadBundle implements `support-bundle upload <archive>`. It uploads an existing archive, resuming from the
+// state file an interrupted upload to the same endpoint left next to it
+func RunUploadBundle(ctx context.Context, options UploadBundleOptions) (*UploadResult, error) {
+	if options.BundlePath == "" {
+		return nil, fmt.Errorf("a bundle archive is required")
+	}
+	if err := ValidateUploadURL(options.UploadURL); err != nil {
+		return nil, fmt.Errorf("invalid --upload-url: %w", err)
+	}
+	if options.UploadChunkSize < 0 {
+		return nil, fmt.Errorf("--upload-chunk-size cannot be negative")
+	}
+
+	result, err := uploadBundle(ctx, options.BundlePath, options.UploadURL, options.UploadToken, options.UploadChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal upload result: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("✅ Uploaded %s to %s\n", options.BundlePath, result.Location)
+	}
+	return result, nil
+}
+
+// uploadBundle uploads the bundle archive with the CLI upload options, printing progress
+func uploadBundle(ctx context.Context, bundlePath, uploadURL, uploadToken string, chunkSize int64) (*UploadResult, error) {
+	opts := BundleUploadOptions{
+		URL:       uploadURL,
+		ChunkSize: chunkSize,
+		Progress:  printUploadProgress,
+	}
+	if uploadToken != "" {
+		opts.Headers = map[string]string{"Authorization": "Bearer " + uploadToken}
+	}
+
+	fmt.Printf("📤 Uploading %s...\n", bundlePath)
+	result, err := UploadBundle(ctx, bundlePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	if result.Resumed > 0 {
+		fmt.Printf("   Resumed after %d of %d bytes\n", result.Resumed, result.Size)
+	}
+	return result, nil
+}
+
+// printUploadProgress prints the progress of an upload, one line per part
+func printUploadProgress(progress UploadProgress) {
+	percent := 100.0
+	if progress.Total > 0 {
+		percent = float64(progress.Uploaded) * 100 / float64(progress.Total)
+	}
+	fmt.Printf("   Uploaded %d of %d bytes (%.0f%%)\n", progress.Uploaded, progress.Total, percent)
+}
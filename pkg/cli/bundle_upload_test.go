@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTusServer stores uploads in memory. Each PATCH while failures remain stores half of its body and then
+// fails, the way a dropped connection would
+type fakeTusServer struct {
+	mu        sync.Mutex
+	uploads   map[string][]byte
+	failures  int
+	created   int
+	patched   int64 // Bytes received by PATCH requests, stored or not
+	authFails int
+}
+
+func newFakeTusServer() (*fakeTusServer, *httptest.Server) {
+	fake := &fakeTusServer{uploads: make(map[string][]byte)}
+	return fake, httptest.NewServer(fake)
+}
+
+func (f *fakeTusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Tus-Resumable") != tusVersion || r.Header.Get("Authorization") != "Bearer token" {
+		f.authFails++
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		f.created++
+		id := fmt.Sprintf("upload-%d", f.created)
+		f.uploads[id] = nil
+		w.Header().Set("Location", "/files/"+id)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		data, ok := f.uploads[filepath.Base(r.URL.Path)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPatch:
+		id := filepath.Base(r.URL.Path)
+		data, ok := f.uploads[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if offset, _ := strconv.Atoi(r.Header.Get("Upload-Offset")); offset != len(data) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.patched += int64(len(body))
+		if f.failures > 0 {
+			f.failures--
+			f.uploads[id] = append(data, body[:len(body)/2]...)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		f.uploads[id] = append(data, body...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(f.uploads[id])))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeTusServer) setFailures(failures int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = failures
+}
+
+func (f *fakeTusServer) stored(id string) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.uploads[id]
+}
+
+// counts returns the uploads created, the bytes received by PATCH requests and the rejected requests
+func (f *fakeTusServer) counts() (int, int64, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.created, f.patched, f.authFails
+}
+
+func writeTestArchive(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+	content := bytes.Repeat([]byte("support-bundle-"), size/15+1)[:size]
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return path, content
+}
+
+func testUploadOptions(serverURL string) BundleUploadOptions {
+	return BundleUploadOptions{
+		URL:       serverURL + "/files/",
+		Headers:   map[string]string{"Authorization": "Bearer token"},
+		ChunkSize: 100,
+		Backoff:   time.Millisecond,
+	}
+}
+
+func TestUploadBundle_RetriesFailedParts(t *testing.T) {
+	fake, server := newFakeTusServer()
+	defer server.Close()
+	fake.setFailures(2)
+	path, content := writeTestArchive(t, 450)
+
+	var progress []UploadProgress
+	opts := testUploadOptions(server.URL)
+	opts.Progress = func(p UploadProgress) { progress = append(progress, p) }
+	result, err := UploadBundle(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(fake.stored("upload-1"), content) {
+		t.Errorf("Expected the server to hold the bundle, got %d of %d bytes", len(fake.stored("upload-1")), len(content))
+	}
+	if result.Parts != 5 || result.Retries != 2 || result.Location != server.URL+"/files/upload-1" {
+		t.Errorf("Expected 5 parts with 2 retries at the resolved location, got %+v", result)
+	}
+	// Only what the server did not store is sent again: 50 bytes of the first part, then 25 of those
+	if _, patched, _ := fake.counts(); patched != 450+50+25 {
+		t.Errorf("Expected 525 bytes sent, got %d", patched)
+	}
+	if len(progress) != 5 || progress[4].Uploaded != 450 || progress[4].Total != 450 {
+		t.Errorf("Expected progress after each part, got %+v", progress)
+	}
+	if _, err := os.Stat(UploadStatePath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the upload state to be removed, got %v", err)
+	}
+}
+
+func TestUploadBundle_Resume(t *testing.T) {
+	fake, server := newFakeTusServer()
+	defer server.Close()
+	path, content := writeTestArchive(t, 450)
+
+	// The connection keeps dropping during the third part
+	opts := testUploadOptions(server.URL)
+	opts.MaxRetries = 1
+	calls := 0
+	opts.Progress = func(p UploadProgress) {
+		if calls++; calls == 2 {
+			fake.setFailures(10)
+		}
+	}
+	_, err := UploadBundle(context.Background(), path, opts)
+	if err == nil || !strings.Contains(err.Error(), "support-bundle upload "+path) {
+		t.Fatalf("Expected the upload to be interrupted, got %v", err)
+	}
+	if _, err := os.Stat(UploadStatePath(path)); err != nil {
+		t.Fatalf("Expected the upload state to be kept: %v", err)
+	}
+	stored := int64(len(fake.stored("upload-1")))
+
+	fake.setFailures(0)
+	opts.Progress = nil
+	result, err := UploadBundle(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created, _, _ := fake.counts(); created != 1 || result.Resumed != stored || !bytes.Equal(fake.stored("upload-1"), content) {
+		t.Errorf("Expected the upload to resume after %d bytes, got %d uploads and %+v", stored, created, result)
+	}
+	if _, err := os.Stat(UploadStatePath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the upload state to be removed, got %v", err)
+	}
+}
+
+func TestRunUploadBundle_Resume(t *testing.T) {
+	fake, server := newFakeTusServer()
+	defer server.Close()
+	path, content := writeTestArchive(t, 450)
+
+	// The upload after collection is interrupted during the second part
+	opts := testUploadOptions(server.URL)
+	opts.MaxRetries = -1
+	opts.Progress = func(p UploadProgress) { fake.setFailures(1) }
+	if _, err := UploadBundle(context.Background(), path, opts); err == nil {
+		t.Fatalf("Expected the upload to be interrupted")
+	}
+	stored := int64(len(fake.stored("upload-1")))
+
+	options := UploadBundleOptions{BundlePath: path, UploadURL: server.URL + "/files/", UploadToken: "token", UploadChunkSize: 100}
+	result, err := RunUploadBundle(context.Background(), options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created, _, _ := fake.counts(); created != 1 || result.Resumed != stored || !bytes.Equal(fake.stored("upload-1"), content) {
+		t.Errorf("Expected the upload to resume after %d bytes, got %d uploads and %+v", stored, created, result)
+	}
+	if _, err := os.Stat(UploadStatePath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the upload state to be removed, got %v", err)
+	}
+
+	invalid := []UploadBundleOptions{
+		{UploadURL: server.URL + "/files/"},
+		{BundlePath: path, UploadURL: "ftp://uploads.example.com"},
+		{BundlePath: path, UploadURL: server.URL + "/files/", UploadChunkSize: -1},
+	}
+	for _, options := range invalid {
+		if _, err := RunUploadBundle(context.Background(), options); err == nil {
+			t.Errorf("Expected error for %+v", options)
+		}
+	}
+}
+
+func TestUploadBundle_ExpiredUpload(t *testing.T) {
+	fake, server := newFakeTusServer()
+	defer server.Close()
+	path, content := writeTestArchive(t, 120)
+
+	info, _ := os.Stat(path)
+	state := fmt.Sprintf(`{"endpoint":%q,"location":%q,"size":%d,"modTime":%q}`,
+		server.URL+"/files/", server.URL+"/files/expired", info.Size(), info.ModTime().Format(time.RFC3339Nano))
+	if err := os.WriteFile(UploadStatePath(path), []byte(state), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	result, err := UploadBundle(context.Background(), path, testUploadOptions(server.URL))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Resumed != 0 || !bytes.Equal(fake.stored("upload-1"), content) {
+		t.Errorf("Expected a new upload of the whole bundle, got %+v", result)
+	}
+}
+
+func TestUploadBundle_NotRetried(t *testing.T) {
+	fake, server := newFakeTusServer()
+	defer server.Close()
+	path, _ := writeTestArchive(t, 120)
+
+	opts := testUploadOptions(server.URL)
+	opts.Headers = nil
+	if _, err := UploadBundle(context.Background(), path, opts); err == nil {
+		t.Fatalf("Expected an error without credentials")
+	}
+	if _, _, authFails := fake.counts(); authFails != 1 {
+		t.Errorf("Expected a 412 response not to be retried, got %d requests", authFails)
+	}
+	if _, err := UploadBundle(context.Background(), t.TempDir(), opts); err == nil || !strings.Contains(err.Error(), "directory bundles") {
+		t.Errorf("Expected directory bundles to be rejected, got %v", err)
+	}
+}
+
+func TestValidateUploadURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://uploads.example.com/files/"},
+		{url: "http://localhost:1080/files"},
+		{url: "ftp://uploads.example.com/", wantErr: true},
+		{url: "uploads.example.com/files", wantErr: true},
+		{url: "https:///files", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := ValidateUploadURL(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCollectWithAutoDiscovery_UploadValidation(t *testing.T) {
+	sbc := &SupportBundleCollector{}
+
+	tests := []struct {
+		name    string
+		options SupportBundleCollectOptions
+		wantErr string
+	}{
+		{name: "dry run", options: SupportBundleCollectOptions{DryRun: true, UploadURL: "https://uploads.example.com/files/"}, wantErr: "--upload-url cannot be used with --dry-run"},
+		{name: "directory bundle", options: SupportBundleCollectOptions{Compression: CompressionNone, UploadURL: "https://uploads.example.com/files/"}, wantErr: "requires a bundle archive"},
+		{name: "bad url", options: SupportBundleCollectOptions{UploadURL: "uploads.example.com"}, wantErr: "invalid --upload-url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Quiet = true
+			_, err := sbc.CollectWithAutoDiscovery(context.Background(), tt.options)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
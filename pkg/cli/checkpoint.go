@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/autodiscover"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// CheckpointFileName is written into the output directory while collectors run and removed once they all complete
+const CheckpointFileName = ".collection-checkpoint.json"
+
+// CollectorRunner runs one collector into the output directory and returns the files it wrote, relative to outputDir
+// On error it should still return the files written so far so they can be cleaned up before a resume
+type CollectorRunner = autodiscover.Runner
+
+// CheckpointEntry records a completed collector
+type CheckpointEntry struct {
+	CompletedAt time.Time `json:"completedAt"`
+	Outputs     []string  `json:"outputs,omitempty"`
+}
+
+// CollectionCheckpoint tracks completed collectors so an interrupted collection can be resumed
+type CollectionCheckpoint struct {
+	StartedAt time.Time                  `json:"startedAt"`
+	UpdatedAt time.Time                  `json:"updatedAt"`
+	Completed map[string]CheckpointEntry `json:"completed"`
+	Partial   map[string][]string        `json:"partial,omitempty"` // Outputs of collectors that failed or were interrupted
+
+	outputDir string
+	mu        sync.Mutex
+}
+
+// NewCollectionCheckpoint creates an empty checkpoint for the output directory
+func NewCollectionCheckpoint(outputDir string) *CollectionCheckpoint {
+	now := time.Now()
+	return &CollectionCheckpoint{
+		StartedAt: now,
+		UpdatedAt: now,
+		Completed: make(map[string]CheckpointEntry),
+		Partial:   make(map[string][]string),
+		outputDir: outputDir,
+	}
+}
+
+// LoadCollectionCheckpoint reads the checkpoint from the output directory
+// The returned error wraps os.ErrNotExist when there is no checkpoint
+func LoadCollectionCheckpoint(outputDir string) (*CollectionCheckpoint, error) {
+	data, err := os.ReadFile(CheckpointPath(outputDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	checkpoint := NewCollectionCheckpoint(outputDir)
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if checkpoint.Completed == nil {
+		checkpoint.Completed = make(map[string]CheckpointEntry)
+	}
+	if checkpoint.Partial == nil {
+		checkpoint.Partial = make(map[string][]string)
+	}
+	return checkpoint, nil
+}
+
+// openCollectionCheckpoint loads the existing checkpoint when resuming and starts a new one otherwise
+// Resuming without a checkpoint starts from zero with a warning, e.g. when the crash happened after all collectors finished
+func openCollectionCheckpoint(outputDir string, resume bool) (*CollectionCheckpoint, error) {
+	if !resume {
+		return NewCollectionCheckpoint(outputDir), nil
+	}
+
+	checkpoint, err := LoadCollectionCheckpoint(outputDir)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning: no checkpoint found in %s, starting collection from the beginning\n", outputDir)
+		return NewCollectionCheckpoint(outputDir), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkpoint.CleanPartialOutputs(); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// CheckpointPath returns the checkpoint file path for an output directory
+func CheckpointPath(outputDir string) string {
+	return filepath.Join(outputDir, CheckpointFileName)
+}
+
+// CollectorCheckpointID identifies a collector across runs
+func CollectorCheckpointID(collector autodiscovery.CollectorSpec) string {
+	return fmt.Sprintf("%s/%s/%s", collector.Type, collector.Namespace, collector.Name)
+}
+
+// IsCompleted reports whether the collector finished in a previous run
+func (c *CollectionCheckpoint) IsCompleted(collector autodiscovery.CollectorSpec) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.Completed[CollectorCheckpointID(collector)]
+	return ok
+}
+
+// PendingCollectors returns the collectors that have not completed yet and the number skipped
+func (c *CollectionCheckpoint) PendingCollectors(collectors []autodiscovery.CollectorSpec) ([]autodiscovery.CollectorSpec, int) {
+	var pending []autodiscovery.CollectorSpec
+	skipped := 0
+	for _, collector := range collectors {
+		if c.IsCompleted(collector) {
+			skipped++
+			continue
+		}
+		pending = append(pending, collector)
+	}
+	return pending, skipped
+}
+
+// MarkCompleted records a finished collector and saves the checkpoint
+func (c *CollectionCheckpoint) MarkCompleted(collector autodiscovery.CollectorSpec, outputs []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := CollectorCheckpointID(collector)
+	c.Completed[id] = CheckpointEntry{CompletedAt: time.Now(), Outputs: outputs}
+	delete(c.Partial, id)
+	return c.save()
+}
+
+// MarkPartial records the outputs of a collector that did not finish and saves the checkpoint
+func (c *CollectionCheckpoint) MarkPartial(collector autodiscovery.CollectorSpec, outputs []string) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Partial[CollectorCheckpointID(collector)] = outputs
+	return c.save()
+}
+
+// CleanPartialOutputs removes files left by collectors that did not finish so they are rerun cleanly
+func (c *CollectionCheckpoint) CleanPartialOutputs() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.Partial) == 0 {
+		return nil
+	}
+	for _, outputs := range c.Partial {
+		for _, output := range outputs {
+			path := filepath.Join(c.outputDir, output)
+			if !isWithinDir(path, c.outputDir) {
+				continue
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove partial output %s: %w", output, err)
+			}
+		}
+	}
+	c.Partial = make(map[string][]string)
+	return c.save()
+}
+
+// Remove deletes the checkpoint file so it does not end up in the bundle
+func (c *CollectionCheckpoint) Remove() error {
+	if err := os.Remove(CheckpointPath(c.outputDir)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// save writes the checkpoint through a temporary file so a crash never leaves it truncated
+func (c *CollectionCheckpoint) save() error {
+	c.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(c.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	path := CheckpointPath(c.outputDir)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// writeCollectorSpec is the default CollectorRunner; it records the collector spec under collectors/
+func writeCollectorSpec(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+	return autodiscover.WriteCollectorSpec(ctx, collector, outputDir)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func testCheckpointCollectors() []autodiscovery.CollectorSpec {
+	return []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeLogs, Name: "logs-app", Namespace: "app"},
+		{Type: autodiscovery.CollectorTypeClusterResources, Name: "resources-app", Namespace: "app"},
+		{Type: autodiscovery.CollectorTypeLogs, Name: "logs-db", Namespace: "db"},
+	}
+}
+
+func TestCollectionCheckpoint_SaveAndLoad(t *testing.T) {
+	outputDir := t.TempDir()
+	collectors := testCheckpointCollectors()
+
+	checkpoint := NewCollectionCheckpoint(outputDir)
+	if err := checkpoint.MarkCompleted(collectors[0], []string{"logs/app.log"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loaded, err := LoadCollectionCheckpoint(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !loaded.IsCompleted(collectors[0]) {
+		t.Errorf("Expected %s to be completed", collectors[0].Name)
+	}
+
+	pending, skipped := loaded.PendingCollectors(collectors)
+	if skipped != 1 || len(pending) != 2 {
+		t.Errorf("Expected 1 skipped and 2 pending collectors, got %d and %d", skipped, len(pending))
+	}
+
+	if _, err := LoadCollectionCheckpoint(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error for a missing checkpoint, got %v", err)
+	}
+}
+
+func TestCollectionCheckpoint_CleanPartialOutputs(t *testing.T) {
+	outputDir := t.TempDir()
+	partial := filepath.Join(outputDir, "logs", "db.log")
+	if err := os.MkdirAll(filepath.Dir(partial), 0755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(partial, []byte("half"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	checkpoint := NewCollectionCheckpoint(outputDir)
+	if err := checkpoint.MarkPartial(testCheckpointCollectors()[2], []string{"logs/db.log", "../outside.log"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resumed, err := openCollectionCheckpoint(outputDir, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("Expected partial output to be removed on resume")
+	}
+	if len(resumed.Partial) != 0 {
+		t.Errorf("Expected partial outputs to be cleared, got %v", resumed.Partial)
+	}
+}
+
+func TestRunCollectors_Resume(t *testing.T) {
+	outputDir := t.TempDir()
+	collectors := testCheckpointCollectors()
+
+	// First run is interrupted while the second collector runs
+	ctx, cancel := context.WithCancel(context.Background())
+	var firstRun []string
+	sbc := &SupportBundleCollector{
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			firstRun = append(firstRun, collector.Name)
+			if collector.Name == "resources-app" {
+				cancel()
+				return []string{"partial.json"}, ctx.Err()
+			}
+			return nil, nil
+		},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = sbc.runCollectors(ctx, collectors, outputDir, checkpoint)
+	if err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Fatalf("Expected interrupted collection error mentioning --resume, got %v", err)
+	}
+	if len(firstRun) != 2 {
+		t.Errorf("Expected 2 collectors to start before the interrupt, got %v", firstRun)
+	}
+	if _, err := os.Stat(CheckpointPath(outputDir)); err != nil {
+		t.Fatalf("Expected checkpoint to be kept after an interrupt: %v", err)
+	}
+
+	// Resumed run skips the completed collector and fails one without losing the others
+	var secondRun []string
+	sbc.collectorRunner = func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		secondRun = append(secondRun, collector.Name)
+		if collector.Name == "logs-db" {
+			return nil, fmt.Errorf("pod not found")
+		}
+		return nil, nil
+	}
+
+	checkpoint, err = openCollectionCheckpoint(outputDir, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collectorErrors, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(secondRun) != 2 || secondRun[0] != "resources-app" || secondRun[1] != "logs-db" {
+		t.Errorf("Expected only the remaining collectors to run, got %v", secondRun)
+	}
+	if len(collectorErrors) != 1 || !strings.Contains(collectorErrors[0], "logs-db") {
+		t.Errorf("Expected the logs-db failure to be reported, got %v", collectorErrors)
+	}
+	if _, err := os.Stat(CheckpointPath(outputDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint to be removed after all collectors ran")
+	}
+}
+
+func TestOpenCollectionCheckpoint_ResumeWithoutCheckpoint(t *testing.T) {
+	checkpoint, err := openCollectionCheckpoint(t.TempDir(), true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(checkpoint.Completed) != 0 {
+		t.Errorf("Expected a fresh checkpoint, got %v", checkpoint.Completed)
+	}
+}
+
+func TestWriteCollectorSpec(t *testing.T) {
+	outputDir := t.TempDir()
+	outputs, err := writeCollectorSpec(context.Background(), testCheckpointCollectors()[0], outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0] != filepath.Join("collectors", "logs-app.json") {
+		t.Errorf("Expected collectors/logs-app.json, got %v", outputs)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, outputs[0])); err != nil {
+		t.Errorf("Expected collector spec to be written: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// printClusterScope prints the cluster-scoped types opted in to and those the RBAC check left out
+func printClusterScope(report *autodiscovery.ClusterScopeReport) {
+	if report == nil {
+		return
+	}
+	writeClusterScope(os.Stdout, report)
+}
+
+// writeClusterScope writes the included cluster-scoped types on one line and each denied type with its reason
+func writeClusterScope(w io.Writer, report *autodiscovery.ClusterScopeReport) {
+	included := make([]string, 0, len(report.Included))
+	for _, gvr := range report.Included {
+		included = append(included, clusterScopedName(gvr))
+	}
+	fmt.Fprintf(w, "🌐 Cluster-Scoped Resources: %d included, %d denied\n", len(report.Included), len(report.Denied))
+	if len(included) > 0 {
+		fmt.Fprintf(w, "  Included: %v\n", included)
+	}
+	for _, denial := range report.Denied {
+		fmt.Fprintf(w, "  Denied: %s (%s)\n", clusterScopedName(denial.GVR), denial.Reason)
+	}
+}
+
+// clusterScopeWarnings describes each requested cluster-scoped type that will not be collected
+func clusterScopeWarnings(report *autodiscovery.ClusterScopeReport) []string {
+	if report == nil {
+		return nil
+	}
+	var warnings []string
+	for _, denial := range report.Denied {
+		warnings = append(warnings, fmt.Sprintf("Cluster-scoped %s will not be collected: %s", clusterScopedName(denial.GVR), denial.Reason))
+	}
+	return warnings
+}
+
+// clusterScopedName names a type the way clusterScope.resources does, e.g. nodes or storageclasses.storage.k8s.io
+func clusterScopedName(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Resource
+	}
+	return gvr.Resource + "." + gvr.Group
+}
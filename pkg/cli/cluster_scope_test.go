@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestWriteClusterScope(t *testing.T) {
+	report := &autodiscovery.ClusterScopeReport{
+		Included: []schema.GroupVersionResource{
+			{Version: "v1", Resource: "nodes"},
+			{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"},
+		},
+		Denied: []autodiscovery.ClusterScopeDenial{
+			{GVR: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, Reason: "list is forbidden"},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeClusterScope(&buf, report)
+	output := buf.String()
+
+	expected := []string{
+		"🌐 Cluster-Scoped Resources: 2 included, 1 denied",
+		"  Included: [nodes storageclasses.storage.k8s.io]",
+		"  Denied: clusterroles.rbac.authorization.k8s.io (list is forbidden)",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+
+	warnings := clusterScopeWarnings(report)
+	if len(warnings) != 1 || warnings[0] != "Cluster-scoped clusterroles.rbac.authorization.k8s.io will not be collected: list is forbidden" {
+		t.Errorf("Expected one warning for clusterroles, got %v", warnings)
+	}
+	if warnings := clusterScopeWarnings(nil); warnings != nil {
+		t.Errorf("Expected no warnings without a report, got %v", warnings)
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// printCollectionAnnotations prints what troubleshoot.sh/ annotations on namespaces and workloads changed
+func printCollectionAnnotations(report *autodiscovery.AnnotationReport) {
+	if report == nil {
+		return
+	}
+	writeCollectionAnnotations(os.Stdout, report)
+	for _, warning := range collectionAnnotationWarnings(report) {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+// writeCollectionAnnotations writes the namespaces and resources opted out and in, and the log lines set, by
+// collection annotations
+func writeCollectionAnnotations(w io.Writer, report *autodiscovery.AnnotationReport) {
+	fmt.Fprintf(w, "🏷️  Collection Annotations: %d namespaces and %d resources excluded, %d opted in\n",
+		len(report.ExcludedNamespaces), len(report.ExcludedResources), len(report.OptedIn))
+	if len(report.ExcludedNamespaces) > 0 {
+		fmt.Fprintf(w, "  Excluded namespaces: %s\n", strings.Join(report.ExcludedNamespaces, ", "))
+	}
+	for _, resource := range report.ExcludedResources {
+		fmt.Fprintf(w, "  Excluded: %s\n", resource)
+	}
+	for _, resource := range report.OptedIn {
+		fmt.Fprintf(w, "  Opted in: %s\n", resource)
+	}
+	for _, target := range sortedKeys(report.LogLines) {
+		fmt.Fprintf(w, "  Log lines: %s = %d\n", target, report.LogLines[target])
+	}
+}
+
+// collectionAnnotationWarnings describes invalid annotations and excluded namespaces collected because they were requested
+func collectionAnnotationWarnings(report *autodiscovery.AnnotationReport) []string {
+	if report == nil {
+		return nil
+	}
+	warnings := append([]string{}, report.Warnings...)
+	for _, namespace := range report.Overridden {
+		warnings = append(warnings, fmt.Sprintf("namespace %s is annotated %s, collecting it because it was requested", namespace, autodiscovery.AnnotationExclude))
+	}
+	return warnings
+}
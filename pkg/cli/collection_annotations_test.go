@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestWriteCollectionAnnotations(t *testing.T) {
+	report := &autodiscovery.AnnotationReport{
+		ExcludedNamespaces: []string{"sandbox", "scratch"},
+		ExcludedResources:  []string{"shop/deployments/legacy"},
+		OptedIn:            []string{"sandbox/pods/keeper"},
+		Overridden:         []string{"staging"},
+		LogLines:           map[string]int{"shop/api-1": 200, "billing": 50},
+		Warnings:           []string{`invalid troubleshoot.sh/exclude "yes" on namespace billing, expected true or false`},
+	}
+
+	var buf bytes.Buffer
+	writeCollectionAnnotations(&buf, report)
+	output := buf.String()
+
+	expected := []string{
+		"🏷️  Collection Annotations: 2 namespaces and 1 resources excluded, 1 opted in",
+		"  Excluded namespaces: sandbox, scratch",
+		"  Excluded: shop/deployments/legacy",
+		"  Opted in: sandbox/pods/keeper",
+		"  Log lines: billing = 50\n  Log lines: shop/api-1 = 200",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+
+	warnings := collectionAnnotationWarnings(report)
+	if len(warnings) != 2 || !strings.Contains(warnings[1], "namespace staging is annotated troubleshoot.sh/exclude") {
+		t.Errorf("Expected the invalid annotation and the requested namespace to be warned about, got %v", warnings)
+	}
+	if warnings := collectionAnnotationWarnings(nil); warnings != nil {
+		t.Errorf("Expected no warnings without a report, got %v", warnings)
+	}
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+const (
+	// CollectionLockHeartbeat is how often a running collection refreshes its lock
+	CollectionLockHeartbeat = 30 * time.Second
+	// CollectionLockStaleAfter is how long a lock may go unrefreshed before it is treated as left behind
+	// by a run that crashed or was killed
+	CollectionLockStaleAfter = 2 * time.Minute
+
+	collectionLockSuffix = ".lock"
+)
+
+// ErrCollectionLocked is returned when another collection of the same cluster and namespaces is running
+var ErrCollectionLocked = errors.New("collection already running")
+
+// CollectionLockHolder describes the collection holding a lock
+type CollectionLockHolder struct {
+	ID          string    `json:"id"`
+	Host        string    `json:"host"`
+	PID         int       `json:"pid"`
+	Cluster     string    `json:"cluster"`
+	Namespaces  []string  `json:"namespaces,omitempty"` // Empty when every namespace is collected
+	AcquiredAt  time.Time `json:"acquiredAt"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// String describes the holder for conflict messages
+func (h CollectionLockHolder) String() string {
+	namespaces := "all namespaces"
+	if len(h.Namespaces) > 0 {
+		namespaces = "namespaces " + strings.Join(h.Namespaces, ", ")
+	}
+	return fmt.Sprintf("pid %d on %s, collecting %s since %s", h.PID, h.Host, namespaces, h.AcquiredAt.Format(time.RFC3339))
+}
+
+// CollectionLock keeps other collections of the same cluster and namespaces from starting until it is
+// released. Each collection writes its own file under <workspace>/locks/<cluster>/ and refreshes it while it runs
+type CollectionLock struct {
+	Holder     CollectionLockHolder
+	Overridden []CollectionLockHolder // Running collections ignored because of --force
+
+	path    string
+	stop    chan struct{}
+	done    chan struct{}
+	release sync.Once
+}
+
+// CollectionLockDir returns the directory holding the locks of a cluster
+func CollectionLockDir(workspaceDir, cluster string) string {
+	return filepath.Join(workspaceDir, "locks", sanitizePathValue(cluster))
+}
+
+// AcquireCollectionLock locks the namespaces of a cluster, all of them when namespaces is empty. When a
+// running collection already holds an overlapping lock, ErrCollectionLocked is returned unless force is set
+func AcquireCollectionLock(workspaceDir, cluster string, namespaces []string, force bool) (*CollectionLock, error) {
+	dir := CollectionLockDir(workspaceDir, cluster)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	id, err := newCollectionLockID()
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	now := time.Now()
+	lock := &CollectionLock{
+		Holder: CollectionLockHolder{
+			ID:          id,
+			Host:        host,
+			PID:         os.Getpid(),
+			Cluster:     cluster,
+			Namespaces:  append([]string(nil), namespaces...),
+			AcquiredAt:  now,
+			RefreshedAt: now,
+		},
+		path: filepath.Join(dir, id+collectionLockSuffix),
+	}
+	sort.Strings(lock.Holder.Namespaces)
+
+	// Our lock is written before the others are read, so of two collections starting together at least one
+	// sees the other. The one that started later gives way
+	if err := writeCollectionLock(lock.path, lock.Holder); err != nil {
+		return nil, err
+	}
+	holders, err := readCollectionLocks(dir, now)
+	if err != nil {
+		os.Remove(lock.path)
+		return nil, err
+	}
+	for _, holder := range holders {
+		if holder.ID == id || !holder.startedBefore(lock.Holder) || !namespacesOverlap(holder.Namespaces, lock.Holder.Namespaces) {
+			continue
+		}
+		if !force {
+			os.Remove(lock.path)
+			return nil, fmt.Errorf("%w: cluster %s is already being collected by %s; wait for it to finish or pass --force",
+				ErrCollectionLocked, cluster, holder)
+		}
+		lock.Overridden = append(lock.Overridden, holder)
+	}
+
+	lock.stop = make(chan struct{})
+	lock.done = make(chan struct{})
+	go lock.heartbeat()
+	return lock, nil
+}
+
+// Release stops refreshing the lock and removes it. It is safe to call more than once
+func (l *CollectionLock) Release() error {
+	var err error
+	l.release.Do(func() {
+		close(l.stop)
+		<-l.done
+		if removeErr := os.Remove(l.path); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = fmt.Errorf("failed to remove lock: %w", removeErr)
+		}
+	})
+	return err
+}
+
+// heartbeat refreshes the lock until it is released, so other collections can tell it from a stale one
+func (l *CollectionLock) heartbeat() {
+	defer close(l.done)
+	holder := l.Holder
+	ticker := time.NewTicker(CollectionLockHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			holder.RefreshedAt = now
+			if err := writeCollectionLock(l.path, holder); err != nil {
+				fmt.Printf("Warning: failed to refresh collection lock: %v\n", err)
+			}
+		}
+	}
+}
+
+// startedBefore orders holders by acquisition time, then by ID when two collections started at the same time
+func (h CollectionLockHolder) startedBefore(other CollectionLockHolder) bool {
+	if !h.AcquiredAt.Equal(other.AcquiredAt) {
+		return h.AcquiredAt.Before(other.AcquiredAt)
+	}
+	return h.ID < other.ID
+}
+
+// namespacesOverlap reports whether two namespace lists share a namespace, an empty list covers every namespace.
+// Either list may hold glob patterns, so "app-*" overlaps "app-1"
+func namespacesOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, namespace := range a {
+		for _, other := range b {
+			if autodiscovery.MatchGlob(namespace, other) || autodiscovery.MatchGlob(other, namespace) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readCollectionLocks returns the live locks in dir. Locks not refreshed within CollectionLockStaleAfter
+// are removed
+func readCollectionLocks(dir string, now time.Time) ([]CollectionLockHolder, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock directory: %w", err)
+	}
+
+	var holders []CollectionLockHolder
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), collectionLockSuffix) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue // Released while we were reading
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read lock %s: %w", entry.Name(), err)
+		}
+		var holder CollectionLockHolder
+		if err := json.Unmarshal(data, &holder); err != nil {
+			fmt.Printf("Warning: removing unreadable collection lock %s: %v\n", entry.Name(), err)
+			os.Remove(path)
+			continue
+		}
+		if now.Sub(holder.RefreshedAt) > CollectionLockStaleAfter {
+			fmt.Printf("Warning: removing stale collection lock of %s, not refreshed since %s\n", holder, holder.RefreshedAt.Format(time.RFC3339))
+			os.Remove(path)
+			continue
+		}
+		holders = append(holders, holder)
+	}
+	return holders, nil
+}
+
+// writeCollectionLock writes the lock through a temporary file, readers never see a partial lock
+func writeCollectionLock(path string, holder CollectionLockHolder) error {
+	data, err := json.MarshalIndent(holder, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lock: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lock: %w", err)
+	}
+	return nil
+}
+
+func newCollectionLockID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate lock id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// acquireCollectionLock locks the cluster and namespaces of a collection. Without a workspace the
+// collection runs unlocked with a warning
+func (sbc *SupportBundleCollector) acquireCollectionLock(opts SupportBundleCollectOptions, namespaces []string) (*CollectionLock, error) {
+	workspaceDir := opts.WorkspaceDir
+	if workspaceDir == "" {
+		dir, err := DefaultWorkspaceDir()
+		if err != nil {
+			fmt.Printf("Warning: collection not locked, concurrent runs are not detected: %v\n", err)
+			return nil, nil
+		}
+		workspaceDir = dir
+	}
+
+	lock, err := AcquireCollectionLock(workspaceDir, sbc.clusterName, namespaces, opts.Force)
+	if err != nil {
+		if errors.Is(err, ErrCollectionLocked) {
+			return nil, err
+		}
+		fmt.Printf("Warning: collection not locked, concurrent runs are not detected: %v\n", err)
+		return nil, nil
+	}
+	for _, holder := range lock.Overridden {
+		fmt.Printf("Warning: --force set, running alongside the collection of %s\n", holder)
+	}
+	return lock, nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireCollectionLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		held       []string
+		namespaces []string
+		force      bool
+		wantLocked bool
+	}{
+		{name: "all namespaces held", namespaces: []string{"app"}, wantLocked: true},
+		{name: "overlapping namespaces", held: []string{"app", "db"}, namespaces: []string{"db"}, wantLocked: true},
+		{name: "all namespaces requested", held: []string{"app"}, wantLocked: true},
+		{name: "disjoint namespaces", held: []string{"app"}, namespaces: []string{"db"}},
+		{name: "glob held", held: []string{"app-*"}, namespaces: []string{"app-1"}, wantLocked: true},
+		{name: "glob requested", held: []string{"app-1"}, namespaces: []string{"app-*"}, wantLocked: true},
+		{name: "disjoint glob", held: []string{"app-*"}, namespaces: []string{"db-1"}},
+		{name: "force", namespaces: []string{"app"}, force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := t.TempDir()
+			first, err := AcquireCollectionLock(workspace, "prod", tt.held, false)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer first.Release()
+
+			second, err := AcquireCollectionLock(workspace, "prod", tt.namespaces, tt.force)
+			if tt.wantLocked {
+				if !errors.Is(err, ErrCollectionLocked) || !strings.Contains(err.Error(), "--force") {
+					t.Fatalf("Expected ErrCollectionLocked mentioning --force, got %v", err)
+				}
+				entries, _ := os.ReadDir(CollectionLockDir(workspace, "prod"))
+				if len(entries) != 1 {
+					t.Errorf("Expected only the first lock to remain, got %d files", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer second.Release()
+			if tt.force && len(second.Overridden) != 1 {
+				t.Errorf("Expected the running collection to be reported as overridden, got %+v", second.Overridden)
+			}
+		})
+	}
+}
+
+func TestAcquireCollectionLock_Release(t *testing.T) {
+	workspace := t.TempDir()
+	lock, err := AcquireCollectionLock(workspace, "prod", nil, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := AcquireCollectionLock(workspace, "staging", nil, false); err != nil {
+		t.Errorf("Expected other clusters not to be locked, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Errorf("Expected a second release to be a no-op, got %v", err)
+	}
+	next, err := AcquireCollectionLock(workspace, "prod", nil, false)
+	if err != nil {
+		t.Fatalf("Expected the lock to be free after release, got %v", err)
+	}
+	next.Release()
+}
+
+func TestAcquireCollectionLock_Stale(t *testing.T) {
+	workspace := t.TempDir()
+	dir := CollectionLockDir(workspace, "prod")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create lock directory: %v", err)
+	}
+	crashed := CollectionLockHolder{ID: "0000", Host: "node-1", PID: 42, Cluster: "prod",
+		AcquiredAt: time.Now().Add(-time.Hour), RefreshedAt: time.Now().Add(-CollectionLockStaleAfter - time.Minute)}
+	stalePath := filepath.Join(dir, crashed.ID+collectionLockSuffix)
+	if err := writeCollectionLock(stalePath, crashed); err != nil {
+		t.Fatalf("Failed to write lock: %v", err)
+	}
+
+	lock, err := AcquireCollectionLock(workspace, "prod", nil, false)
+	if err != nil {
+		t.Fatalf("Expected the stale lock to be ignored, got %v", err)
+	}
+	defer lock.Release()
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale lock to be removed, got %v", err)
+	}
+}
+
+func TestCollectionLockHolder_StartedBefore(t *testing.T) {
+	now := time.Now()
+	a := CollectionLockHolder{ID: "a", AcquiredAt: now}
+	b := CollectionLockHolder{ID: "b", AcquiredAt: now}
+	if !a.startedBefore(b) || b.startedBefore(a) {
+		t.Errorf("Expected ties to be broken by ID")
+	}
+	c := CollectionLockHolder{ID: "0", AcquiredAt: now.Add(time.Second)}
+	if c.startedBefore(a) {
+		t.Errorf("Expected the later collection to give way")
+	}
+}
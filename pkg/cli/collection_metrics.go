@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// metricsPrefix is the prefix of every exported metric name
+const metricsPrefix = "troubleshoot_collection_"
+
+// collectionErrorTypes classifies collection error messages by prefix for the errors metric
+var collectionErrorTypes = []struct {
+	prefix    string
+	errorType string
+}{
+	{"auto-discovery failed", "discovery"},
+	{"collector ", "collector"},
+	{"failed to build node image", "images"},
+	{"failed to write node image", "images"},
+	{"failed to generate analyzers", "analysis"},
+	{"failed to anonymize", "anonymization"},
+	{"failed to discover identifiers", "anonymization"},
+	{"failed to generate anonymization", "anonymization"},
+	{"failed to write anonymization", "anonymization"},
+	{"failed to build bundle manifest", "signing"},
+	{"failed to marshal bundle manifest", "signing"},
+	{"failed to write bundle", "signing"},
+	{"failed to remove stale bundle signature", "signing"},
+	{"failed to write", "write"},
+}
+
+// CollectionMetrics records the metrics of one collection run, safe to read while the run updates it
+type CollectionMetrics struct {
+	mu                 sync.Mutex
+	discoveryDuration  time.Duration
+	collectionDuration time.Duration
+	collectorsByGroup  map[string]int
+	apiRequests        int
+	apiThrottled       int
+	errorsByType       map[string]int
+	bytesWritten       int64
+	success            bool
+	finishedAt         time.Time
+}
+
+// NewCollectionMetrics creates empty collection metrics
+func NewCollectionMetrics() *CollectionMetrics {
+	return &CollectionMetrics{
+		collectorsByGroup: make(map[string]int),
+		errorsByType:      make(map[string]int),
+	}
+}
+
+// ObserveDiscovery records the discovery duration and the generated collectors by group
+func (m *CollectionMetrics) ObserveDiscovery(duration time.Duration, collectors []autodiscovery.CollectorSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.discoveryDuration = duration
+	m.collectorsByGroup = make(map[string]int)
+	for _, collector := range collectors {
+		m.collectorsByGroup[autodiscovery.CollectorGroupFor(collector)]++
+	}
+}
+
+// ObserveAPIRequests records the API requests made, from the discoverer's throttle stats
+func (m *CollectionMetrics) ObserveAPIRequests(stats *autodiscovery.ThrottleStats) {
+	if stats == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiRequests = stats.Requests
+	m.apiThrottled = stats.ServerThrottled + stats.ClientThrottled
+}
+
+// ObserveErrors counts collection error messages by type
+func (m *CollectionMetrics) ObserveErrors(errors []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, message := range errors {
+		m.errorsByType[classifyCollectionError(message)]++
+	}
+}
+
+// Finish records the total duration, the bytes in the bundle and whether the run succeeded
+func (m *CollectionMetrics) Finish(duration time.Duration, bytesWritten int64, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectionDuration = duration
+	m.bytesWritten = bytesWritten
+	m.success = success
+	m.finishedAt = time.Now()
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format
+func (m *CollectionMetrics) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out strings.Builder
+	writeMetric := func(name, metricType, help string, samples map[string]float64) {
+		fmt.Fprintf(&out, "# HELP %s%s %s\n", metricsPrefix, name, help)
+		fmt.Fprintf(&out, "# TYPE %s%s %s\n", metricsPrefix, name, metricType)
+		keys := make([]string, 0, len(samples))
+		for labels := range samples {
+			keys = append(keys, labels)
+		}
+		sort.Strings(keys)
+		for _, labels := range keys {
+			fmt.Fprintf(&out, "%s%s%s %g\n", metricsPrefix, name, labels, samples[labels])
+		}
+	}
+
+	success := 0.0
+	if m.success {
+		success = 1
+	}
+	finishedAt := 0.0
+	if !m.finishedAt.IsZero() {
+		finishedAt = float64(m.finishedAt.Unix())
+	}
+
+	writeMetric("discovery_duration_seconds", "gauge", "Time spent discovering resources and generating collectors.",
+		map[string]float64{"": m.discoveryDuration.Seconds()})
+	writeMetric("duration_seconds", "gauge", "Time spent on the whole collection run.",
+		map[string]float64{"": m.collectionDuration.Seconds()})
+	writeMetric("collectors_generated", "gauge", "Collectors generated by auto-discovery, by collector group.",
+		labeledSamples("group", m.collectorsByGroup))
+	writeMetric("api_requests_total", "counter", "Kubernetes API requests made during discovery.",
+		map[string]float64{"": float64(m.apiRequests)})
+	writeMetric("api_throttled_total", "counter", "Kubernetes API requests throttled by the server or the client rate limiter.",
+		map[string]float64{"": float64(m.apiThrottled)})
+	writeMetric("errors_total", "counter", "Collection errors by type.",
+		labeledSamples("type", m.errorsByType))
+	writeMetric("bytes_written_total", "counter", "Bytes written to the support bundle.",
+		map[string]float64{"": float64(m.bytesWritten)})
+	writeMetric("success", "gauge", "Whether the last collection run finished without errors.",
+		map[string]float64{"": success})
+	writeMetric("last_run_timestamp_seconds", "gauge", "Unix time the last collection run finished.",
+		map[string]float64{"": finishedAt})
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// WriteTextFile writes the metrics for the node exporter textfile collector
+// The file is replaced atomically so a scrape never reads a partial file
+func (m *CollectionMetrics) WriteTextFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".metrics-*.prom")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := m.WriteText(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+// Serve exposes the metrics on addr at /metrics until the returned stop function is called
+func (m *CollectionMetrics) Serve(addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.WriteText(w)
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
+
+// classifyCollectionError returns the error type of a collection error message
+func classifyCollectionError(message string) string {
+	for _, t := range collectionErrorTypes {
+		if strings.HasPrefix(message, t.prefix) {
+			return t.errorType
+		}
+	}
+	return "other"
+}
+
+// labeledSamples turns counts into samples with one label, e.g. {group="logs"}
+func labeledSamples(label string, counts map[string]int) map[string]float64 {
+	samples := make(map[string]float64, len(counts))
+	for value, count := range counts {
+		samples[fmt.Sprintf("{%s=%q}", label, value)] = float64(count)
+	}
+	return samples
+}
+
+// bundleSize returns the total size of the files under dir
+func bundleSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// writeCollectionMetrics writes the metrics textfile if one was requested, reporting whether it was written
+// A failed write only warns, metrics must never fail the collection itself
+func writeCollectionMetrics(metrics *CollectionMetrics, path string) bool {
+	if path == "" {
+		return false
+	}
+	if err := metrics.WriteTextFile(path); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return false
+	}
+	return true
+}
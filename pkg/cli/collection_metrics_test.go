@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func testCollectionMetrics() *CollectionMetrics {
+	metrics := NewCollectionMetrics()
+	metrics.ObserveDiscovery(2*time.Second, []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeLogs, Name: "auto-logs-app"},
+		{Type: autodiscovery.CollectorTypeLogs, Name: "auto-logs-db"},
+		{Type: "cluster-info", Name: "auto-cluster-info", Group: autodiscovery.CollectorGroupClusterInfo},
+	})
+	metrics.ObserveAPIRequests(&autodiscovery.ThrottleStats{Requests: 42, ServerThrottled: 2, ClientThrottled: 1})
+	metrics.ObserveErrors([]string{
+		"collector auto-logs-db failed: timeout",
+		"failed to write namespace summaries: disk full",
+		"something unexpected",
+	})
+	metrics.Finish(5*time.Second, 1024, false)
+	return metrics
+}
+
+func TestCollectionMetrics_WriteText(t *testing.T) {
+	var out strings.Builder
+	if err := testCollectionMetrics().WriteText(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := out.String()
+
+	expected := []string{
+		"# TYPE troubleshoot_collection_discovery_duration_seconds gauge",
+		"troubleshoot_collection_discovery_duration_seconds 2\n",
+		"troubleshoot_collection_duration_seconds 5\n",
+		`troubleshoot_collection_collectors_generated{group="logs"} 2`,
+		`troubleshoot_collection_collectors_generated{group="cluster-info"} 1`,
+		"troubleshoot_collection_api_requests_total 42\n",
+		"troubleshoot_collection_api_throttled_total 3\n",
+		`troubleshoot_collection_errors_total{type="collector"} 1`,
+		`troubleshoot_collection_errors_total{type="write"} 1`,
+		`troubleshoot_collection_errors_total{type="other"} 1`,
+		"troubleshoot_collection_bytes_written_total 1024\n",
+		"troubleshoot_collection_success 0\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(text, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "troubleshoot_collection_last_run_timestamp_seconds 0\n") {
+		t.Errorf("Expected last run timestamp to be set")
+	}
+}
+
+func TestClassifyCollectionError(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"auto-discovery failed: forbidden", "discovery"},
+		{"collector auto-logs-app failed: timeout", "collector"},
+		{"failed to build node image presence report: forbidden", "images"},
+		{"failed to generate analyzers: boom", "analysis"},
+		{"failed to write anonymization mapping: denied", "anonymization"},
+		{"failed to write bundle manifest: denied", "signing"},
+		{"failed to write discovery manifest: denied", "write"},
+		{"unexpected", "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if result := classifyCollectionError(tt.message); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCollectionMetrics_WriteTextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "textfile", "troubleshoot.prom")
+	if err := testCollectionMetrics().WriteTextFile(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	if !strings.Contains(string(data), "troubleshoot_collection_bytes_written_total 1024") {
+		t.Errorf("Expected metrics in file, got:\n%s", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the metrics file to remain, got %d entries", len(entries))
+	}
+}
+
+func TestCollectionMetrics_Serve(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	metrics := testCollectionMetrics()
+	stop, err := metrics.Serve(addr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stop()
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "troubleshoot_collection_api_requests_total 42") {
+		t.Errorf("Expected metrics in response, got:\n%s", body)
+	}
+
+	if _, err := metrics.Serve(addr); err == nil {
+		t.Errorf("Expected an error when the address is in use")
+	}
+}
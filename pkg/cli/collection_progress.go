@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// progressBarWidth is the number of cells in the collection progress bar
+const progressBarWidth = 30
+
+// CollectionProgress draws a progress bar with an ETA on one console line while the collectors run
+// The ETA is the dry-run estimate of the remaining collectors, scaled by how the completed ones compared to theirs
+type CollectionProgress struct {
+	w         io.Writer
+	total     int
+	completed int
+	failed    int
+	bytes     int64
+	remaining time.Duration // Dry-run estimate of the collectors not completed yet
+	estimated time.Duration // Dry-run estimate of the completed collectors
+	started   time.Time
+	current   string
+	lineWidth int
+	now       func() time.Time
+}
+
+// NewCollectionProgress creates a progress bar for running the collectors
+func NewCollectionProgress(w io.Writer, collectors []autodiscovery.CollectorSpec) *CollectionProgress {
+	p := &CollectionProgress{w: w, total: len(collectors), now: time.Now}
+	for _, collector := range collectors {
+		p.remaining += estimateCollectorDuration(collector)
+	}
+	p.started = p.now()
+	return p
+}
+
+// Begin shows the collector that is running
+func (p *CollectionProgress) Begin(collector autodiscovery.CollectorSpec) {
+	p.current = collector.Name
+	p.draw()
+}
+
+// Complete records a finished collector and the bytes it wrote
+func (p *CollectionProgress) Complete(collector autodiscovery.CollectorSpec, bytes int64, err error) {
+	p.completed++
+	if err != nil {
+		p.failed++
+	}
+	p.bytes += bytes
+	estimate := estimateCollectorDuration(collector)
+	p.remaining -= estimate
+	p.estimated += estimate
+	p.current = ""
+	p.draw()
+}
+
+// Finish clears the progress line so the summary starts on an empty line
+func (p *CollectionProgress) Finish() {
+	if p.lineWidth > 0 {
+		fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", p.lineWidth))
+		p.lineWidth = 0
+	}
+}
+
+// ETA estimates the time left from the dry-run estimates, calibrated by the collectors completed so far
+func (p *CollectionProgress) ETA() time.Duration {
+	if p.remaining <= 0 {
+		return 0
+	}
+	if p.estimated <= 0 {
+		return p.remaining
+	}
+	elapsed := p.now().Sub(p.started)
+	return time.Duration(float64(p.remaining) * float64(elapsed) / float64(p.estimated))
+}
+
+// Line renders the progress, e.g. "[#####-----]  50% 10/20 collectors | 1.2 MiB | ETA 40s | auto-logs-shop"
+func (p *CollectionProgress) Line() string {
+	percent := 100
+	if p.total > 0 {
+		percent = p.completed * 100 / p.total
+	}
+	filled := percent * progressBarWidth / 100
+	line := fmt.Sprintf("[%s%s] %3d%% %d/%d collectors | %s | ETA %v",
+		strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled),
+		percent, p.completed, p.total, formatByteSize(p.bytes), p.ETA().Round(time.Second))
+	if p.failed > 0 {
+		line += fmt.Sprintf(" | %d failed", p.failed)
+	}
+	if p.current != "" {
+		line += " | " + p.current
+	}
+	return line
+}
+
+// draw rewrites the progress line, padding over the rest of a longer previous line
+func (p *CollectionProgress) draw() {
+	line := p.Line()
+	padding := ""
+	if len(line) < p.lineWidth {
+		padding = strings.Repeat(" ", p.lineWidth-len(line))
+	}
+	fmt.Fprintf(p.w, "\r%s%s", line, padding)
+	p.lineWidth = len(line)
+}
+
+// showCollectionProgress reports whether the progress bar is drawn: not with --quiet, --no-progress or
+// --progress-format none
+func showCollectionProgress(options SupportBundleCollectOptions) bool {
+	return !options.Quiet && !options.NoProgress && options.ProgressFormat != "none"
+}
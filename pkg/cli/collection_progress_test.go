@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestCollectionProgress(t *testing.T) {
+	collectors := []autodiscovery.CollectorSpec{
+		{Name: "auto-cluster-info", Type: autodiscovery.CollectorTypeClusterResources},
+		{Name: "auto-logs-shop", Type: autodiscovery.CollectorTypeLogs},
+		{Name: "auto-dns-lookup-shop", Type: autodiscovery.CollectorTypeRunPod},
+	}
+
+	var out bytes.Buffer
+	progress := NewCollectionProgress(&out, collectors)
+	now := progress.started
+	progress.now = func() time.Time { return now }
+
+	if eta := progress.ETA(); eta != 16*time.Second {
+		t.Errorf("Expected the dry-run estimate of 16s before any collector completes, got %v", eta)
+	}
+
+	progress.Begin(collectors[0])
+	if line := progress.Line(); !strings.Contains(line, "0/3 collectors") || !strings.HasSuffix(line, "| auto-cluster-info") {
+		t.Errorf("Expected the running collector on the line, got %q", line)
+	}
+
+	// The first collector took twice its estimate, so the rest is expected to as well
+	now = now.Add(4 * time.Second)
+	progress.Complete(collectors[0], 2048, nil)
+	if eta := progress.ETA(); eta != 28*time.Second {
+		t.Errorf("Expected the estimate scaled by the observed pace, got %v", eta)
+	}
+
+	progress.Begin(collectors[1])
+	progress.Complete(collectors[1], 1024, fmt.Errorf("forbidden"))
+	line := progress.Line()
+	expected := "[###################-----------]  66% 2/3 collectors | 3.0 KiB | ETA 1s | 1 failed"
+	if line != expected {
+		t.Errorf("Expected %q, got %q", expected, line)
+	}
+
+	progress.Begin(collectors[2])
+	progress.Complete(collectors[2], 0, nil)
+	if line := progress.Line(); !strings.Contains(line, "100% 3/3") || !strings.Contains(line, "ETA 0s") {
+		t.Errorf("Expected a full bar, got %q", line)
+	}
+
+	progress.Finish()
+	if !strings.HasSuffix(out.String(), "\r") || strings.Contains(out.String(), "\n") {
+		t.Errorf("Expected the progress to redraw one line and clear it, got %q", out.String())
+	}
+}
+
+func TestShowCollectionProgress(t *testing.T) {
+	tests := []struct {
+		options  SupportBundleCollectOptions
+		expected bool
+	}{
+		{SupportBundleCollectOptions{}, true},
+		{SupportBundleCollectOptions{NoProgress: true}, false},
+		{SupportBundleCollectOptions{Quiet: true}, false},
+		{SupportBundleCollectOptions{ProgressFormat: "none"}, false},
+	}
+
+	for _, tt := range tests {
+		if shown := showCollectionProgress(tt.options); shown != tt.expected {
+			t.Errorf("Expected %v for %+v, got %v", tt.expected, tt.options, shown)
+		}
+	}
+}
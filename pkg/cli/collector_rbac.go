@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var podsGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+
+// CollectorPermission describes a permission a collector needs at execution time
+type CollectorPermission struct {
+	Verb        string                      `json:"verb"`
+	GVR         schema.GroupVersionResource `json:"gvr"`
+	Subresource string                      `json:"subresource,omitempty"`
+	Namespace   string                      `json:"namespace,omitempty"`
+}
+
+// String returns a kubectl-style description of the permission
+func (cp CollectorPermission) String() string {
+	resource := cp.GVR.Resource
+	if cp.Subresource != "" {
+		resource = resource + "/" + cp.Subresource
+	}
+	if cp.Namespace == "" {
+		return fmt.Sprintf("%s %s", cp.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in %s", cp.Verb, resource, cp.Namespace)
+}
+
+// CollectorRBACResult reports whether a generated collector has the permissions it needs to run
+type CollectorRBACResult struct {
+	CollectorName string                `json:"collectorName"`
+	CollectorType string                `json:"collectorType"`
+	Namespace     string                `json:"namespace,omitempty"`
+	Required      []CollectorPermission `json:"required"`
+	Denied        []CollectorPermission `json:"denied,omitempty"`
+	WillFail      bool                  `json:"willFail"`
+	Errors        []string              `json:"errors,omitempty"`
+}
+
+// ValidateCollectorPermissions simulates the verbs each collector needs and flags collectors that will fail
+func (rv *RBACValidator) ValidateCollectorPermissions(ctx context.Context, collectors []autodiscovery.CollectorSpec) []CollectorRBACResult {
+	results := make([]CollectorRBACResult, 0, len(collectors))
+
+	for _, collector := range collectors {
+		result := CollectorRBACResult{
+			CollectorName: collector.Name,
+			CollectorType: collector.Type,
+			Namespace:     collector.Namespace,
+			Required:      RequiredCollectorPermissions(collector),
+		}
+
+		for _, perm := range result.Required {
+			allowed, err := rv.rbacChecker.CheckVerbAccess(ctx, perm.GVR, perm.Subresource, perm.Namespace, perm.Verb)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			}
+			if !allowed {
+				result.Denied = append(result.Denied, perm)
+			}
+		}
+		result.WillFail = len(result.Denied) > 0
+
+		results = append(results, result)
+	}
+
+	rv.report.CollectorResults = results
+	rv.report.FailingCollectors = countFailingCollectors(results)
+
+	return results
+}
+
+// RequiredCollectorPermissions returns the verbs a collector needs when it is executed
+func RequiredCollectorPermissions(collector autodiscovery.CollectorSpec) []CollectorPermission {
+	namespace := collector.Namespace
+
+	switch collector.Type {
+	case "logs":
+		return []CollectorPermission{
+			{Verb: "list", GVR: podsGVR, Namespace: namespace},
+			{Verb: "get", GVR: podsGVR, Subresource: "log", Namespace: namespace},
+		}
+	case "cluster-resources":
+		var permissions []CollectorPermission
+		for _, gvr := range collectorTargetGVRs(collector) {
+			permissions = append(permissions, CollectorPermission{Verb: "list", GVR: gvr, Namespace: namespace})
+		}
+		return permissions
+	case "exec", "copy":
+		// copy streams files out of the container through exec
+		return []CollectorPermission{
+			{Verb: "get", GVR: podsGVR, Namespace: namespace},
+			{Verb: "create", GVR: podsGVR, Subresource: "exec", Namespace: namespace},
+		}
+	case "run-pod":
+		return []CollectorPermission{
+			{Verb: "create", GVR: podsGVR, Namespace: namespace},
+			{Verb: "get", GVR: podsGVR, Namespace: namespace},
+			{Verb: "get", GVR: podsGVR, Subresource: "log", Namespace: namespace},
+			{Verb: "delete", GVR: podsGVR, Namespace: namespace},
+		}
+	default:
+		return nil
+	}
+}
+
+// collectorTargetGVRs reads the resources a cluster-resources collector gathers from its parameters
+func collectorTargetGVRs(collector autodiscovery.CollectorSpec) []schema.GroupVersionResource {
+	params, err := collector.ClusterResourcesParams()
+	if err != nil {
+		return nil
+	}
+	return params.GVRs()
+}
+
+func countFailingCollectors(results []CollectorRBACResult) int {
+	count := 0
+	for _, result := range results {
+		if result.WillFail {
+			count++
+		}
+	}
+	return count
+}
+
+// describeDeniedPermissions returns a short comma separated list of denied permissions
+func describeDeniedPermissions(denied []CollectorPermission) string {
+	descriptions := make([]string, 0, len(denied))
+	for _, perm := range denied {
+		descriptions = append(descriptions, perm.String())
+	}
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestRequiredCollectorPermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector autodiscovery.CollectorSpec
+		expected  []string
+	}{
+		{
+			name:      "logs collector",
+			collector: autodiscovery.CollectorSpec{Type: "logs", Namespace: "default"},
+			expected:  []string{"list pods in default", "get pods/log in default"},
+		},
+		{
+			name: "cluster-resources collector",
+			collector: autodiscovery.CollectorSpec{
+				Type:       "cluster-resources",
+				Namespace:  "default",
+				Parameters: map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments"},
+			},
+			expected: []string{"list deployments in default"},
+		},
+		{
+			name:      "exec collector",
+			collector: autodiscovery.CollectorSpec{Type: "exec", Namespace: "app"},
+			expected:  []string{"get pods in app", "create pods/exec in app"},
+		},
+		{
+			name:      "run-pod collector",
+			collector: autodiscovery.CollectorSpec{Type: "run-pod", Namespace: "app"},
+			expected:  []string{"create pods in app", "get pods in app", "get pods/log in app", "delete pods in app"},
+		},
+		{
+			name:      "unknown collector",
+			collector: autodiscovery.CollectorSpec{Type: "custom"},
+			expected:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := RequiredCollectorPermissions(tt.collector)
+
+			if len(permissions) != len(tt.expected) {
+				t.Fatalf("Expected %d permissions, got %d: %v", len(tt.expected), len(permissions), permissions)
+			}
+			for i, perm := range permissions {
+				if perm.String() != tt.expected[i] {
+					t.Errorf("Expected permission %q, got %q", tt.expected[i], perm.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRBACValidator_ValidateCollectorPermissions(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		// Allow reads, deny anything that creates pods or execs into them
+		req := action.(ktesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview)
+		attrs := req.Spec.ResourceAttributes
+
+		allowed := attrs.Verb == "get" || attrs.Verb == "list"
+
+		return true, &authv1.SelfSubjectAccessReview{
+			Status: authv1.SubjectAccessReviewStatus{
+				Allowed: allowed,
+			},
+		}, nil
+	})
+
+	validator := NewRBACValidator(client, RBACValidationBasic)
+	collectors := []autodiscovery.CollectorSpec{
+		{Name: "auto-logs-default", Type: "logs", Namespace: "default"},
+		{Name: "auto-exec-web", Type: "exec", Namespace: "default"},
+		{Name: "auto-network-diag-default", Type: "run-pod", Namespace: "default"},
+	}
+
+	results := validator.ValidateCollectorPermissions(context.Background(), collectors)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	expected := map[string]struct {
+		willFail bool
+		denied   int
+	}{
+		"auto-logs-default":         {false, 0},
+		"auto-exec-web":             {true, 1},
+		"auto-network-diag-default": {true, 2},
+	}
+
+	for _, result := range results {
+		exp := expected[result.CollectorName]
+		if result.WillFail != exp.willFail {
+			t.Errorf("Expected %s WillFail=%v, got %v", result.CollectorName, exp.willFail, result.WillFail)
+		}
+		if len(result.Denied) != exp.denied {
+			t.Errorf("Expected %s to have %d denied permissions, got %d", result.CollectorName, exp.denied, len(result.Denied))
+		}
+	}
+
+	if validator.report.FailingCollectors != 2 {
+		t.Errorf("Expected 2 failing collectors in report, got %d", validator.report.FailingCollectors)
+	}
+}
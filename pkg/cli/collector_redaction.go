@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// redactingRunner redacts the output of each collector before it is recorded as completed
+// When redaction fails the outputs are removed, unredacted data is never left in the bundle
+func redactingRunner(runner CollectorRunner, redactor *autodiscovery.CollectorRedactor) CollectorRunner {
+	return func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		outputs, err := runner(ctx, collector, outputDir)
+		if _, redactErr := redactor.Redact(collector, outputDir, outputs); redactErr != nil {
+			for _, output := range outputs {
+				if removeErr := os.RemoveAll(filepath.Join(outputDir, output)); removeErr != nil {
+					fmt.Printf("Warning: failed to remove unredacted output %s: %v\n", output, removeErr)
+				}
+			}
+			return nil, fmt.Errorf("redaction failed, outputs removed: %w", redactErr)
+		}
+		return outputs, err
+	}
+}
+
+// printRedactionSummary prints the files changed by each collector redaction rule
+func printRedactionSummary(redacted map[string]int) {
+	if len(redacted) == 0 {
+		return
+	}
+	names := make([]string, 0, len(redacted))
+	for name := range redacted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("🔒 Redacted collector output:\n")
+	for _, name := range names {
+		fmt.Printf("   %s: %d files\n", name, redacted[name])
+	}
+}
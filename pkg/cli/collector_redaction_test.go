@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestRunCollectors_Redaction(t *testing.T) {
+	redactor, err := autodiscovery.NewCollectorRedactor([]autodiscovery.CollectorRedactionRule{
+		{Name: "tokens", CollectorTypes: []string{autodiscovery.CollectorTypeLogs}, Action: autodiscovery.RedactionActionRegex, Pattern: `token=\S+`},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		redactor: redactor,
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			name := collector.Name + ".log"
+			return []string{name}, os.WriteFile(filepath.Join(outputDir, name), []byte("login token=abc123\n"), 0644)
+		},
+	}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeLogs, Name: "logs-app", Namespace: "app"},
+		{Type: autodiscovery.CollectorTypeExec, Name: "exec-app", Namespace: "app"},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collectorErrors, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint)
+	if err != nil || len(collectorErrors) != 0 {
+		t.Fatalf("Unexpected errors: %v %v", err, collectorErrors)
+	}
+
+	logs, _ := os.ReadFile(filepath.Join(outputDir, "logs-app.log"))
+	if string(logs) != "login "+autodiscovery.DefaultRedactionReplacement+"\n" {
+		t.Errorf("Expected the token to be redacted from logs, got %q", logs)
+	}
+	exec, _ := os.ReadFile(filepath.Join(outputDir, "exec-app.log"))
+	if string(exec) != "login token=abc123\n" {
+		t.Errorf("Expected exec output to be left alone, got %q", exec)
+	}
+}
+
+func TestRedactingRunner_RemovesOutputsOnFailure(t *testing.T) {
+	redactor, err := autodiscovery.NewCollectorRedactor([]autodiscovery.CollectorRedactionRule{
+		{Name: "all", Action: autodiscovery.RedactionActionFull},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	runner := redactingRunner(func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		if err := os.WriteFile(filepath.Join(outputDir, "written.log"), []byte("secret"), 0644); err != nil {
+			return nil, err
+		}
+		return []string{"written.log", "missing.log"}, nil
+	}, redactor)
+
+	outputs, err := runner(context.Background(), autodiscovery.CollectorSpec{Type: autodiscovery.CollectorTypeLogs, Name: "logs"}, outputDir)
+	if err == nil || !strings.Contains(err.Error(), "redaction failed") {
+		t.Fatalf("Expected a redaction failure, got %v", err)
+	}
+	if outputs != nil {
+		t.Errorf("Expected no outputs after a redaction failure, got %v", outputs)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "written.log")); !os.IsNotExist(err) {
+		t.Errorf("Expected unredacted output to be removed")
+	}
+}
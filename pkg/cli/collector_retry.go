@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// retryingRunner reruns collectors that fail transiently, following the retry policy of their type
+func retryingRunner(runner CollectorRunner, retrier *autodiscovery.CollectorRetrier) CollectorRunner {
+	return func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		return retrier.Run(ctx, collector, outputDir, runner)
+	}
+}
+
+// printRetrySummary prints the collectors that were retried and whether a retry recovered them
+func printRetrySummary(retries []autodiscovery.CollectorRetry) {
+	writeRetrySummary(os.Stdout, retries)
+}
+
+func writeRetrySummary(w io.Writer, retries []autodiscovery.CollectorRetry) {
+	if len(retries) == 0 {
+		return
+	}
+	recovered := 0
+	for _, retry := range retries {
+		if retry.Succeeded {
+			recovered++
+		}
+	}
+
+	fmt.Fprintf(w, "🔁 Retried collectors: %d, %d recovered\n", len(retries), recovered)
+	for _, retry := range retries {
+		outcome := "succeeded"
+		if !retry.Succeeded {
+			last := retry.Failures[len(retry.Failures)-1]
+			outcome = "failed: " + last.Error
+		}
+		fmt.Fprintf(w, "   %s: %d attempts, %s\n", retry.Collector, retry.Attempts, outcome)
+	}
+}
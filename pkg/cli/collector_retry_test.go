@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestRunCollectors_Retries(t *testing.T) {
+	retrier, err := autodiscovery.NewCollectorRetrier(autodiscovery.RetryConfig{
+		CollectorTypes: map[string]autodiscovery.RetryPolicy{
+			autodiscovery.CollectorTypeExec: {Attempts: 2, Backoff: time.Millisecond},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	attempts := make(map[string]int)
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		retrier: retrier,
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			attempts[collector.Name]++
+			if attempts[collector.Name] == 1 {
+				return nil, errors.New("container not found (\"db\")")
+			}
+			return writeCollectorSpec(ctx, collector, outputDir)
+		},
+	}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeExec, Name: "exec-db", Namespace: "app"},
+		{Type: autodiscovery.CollectorTypeLogs, Name: "logs-db", Namespace: "app"},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collectorErrors, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(collectorErrors) != 1 || !strings.Contains(collectorErrors[0], "logs-db") {
+		t.Errorf("Expected only the logs collector, which has no retry policy, to fail, got %v", collectorErrors)
+	}
+	if attempts["exec-db"] != 2 || attempts["logs-db"] != 1 {
+		t.Errorf("Expected 2 exec attempts and 1 logs attempt, got %v", attempts)
+	}
+	if retries := retrier.Retries(); len(retries) != 1 || !retries[0].Succeeded {
+		t.Errorf("Expected exec-db to be recorded as recovered, got %+v", retries)
+	}
+}
+
+func TestWriteRetrySummary(t *testing.T) {
+	retries := []autodiscovery.CollectorRetry{
+		{Collector: "auto-exec-db", Type: "exec", Attempts: 2, Succeeded: true, Failures: []autodiscovery.RetryFailure{
+			{Attempt: 1, Class: autodiscovery.RetryClassExec, Error: "container not found"},
+		}},
+		{Collector: "auto-logs-web", Type: "logs", Attempts: 3, Failures: []autodiscovery.RetryFailure{
+			{Attempt: 1, Class: autodiscovery.RetryClassThrottled, Error: "too many requests"},
+			{Attempt: 2, Class: autodiscovery.RetryClassThrottled, Error: "too many requests"},
+			{Attempt: 3, Class: autodiscovery.RetryClassTimeout, Error: "context deadline exceeded"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	writeRetrySummary(&buf, retries)
+	output := buf.String()
+
+	expected := []string{
+		"🔁 Retried collectors: 2, 1 recovered",
+		"   auto-exec-db: 2 attempts, succeeded",
+		"   auto-logs-web: 3 attempts, failed: context deadline exceeded",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+
+	buf.Reset()
+	writeRetrySummary(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output without retries, got %q", buf.String())
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	// A Wednesday
+	after := time.Date(2026, time.January, 14, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 3 * * *", time.Date(2026, time.January, 15, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.January, 14, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2026, time.January, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", time.Date(2026, time.January, 14, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * fri", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"5,35 * * * *", time.Date(2026, time.January, 14, 10, 35, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.January, 14, 11, 0, 0, 0, time.UTC)},
+		{"@DAILY", time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if next := schedule.Next(after); !next.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"0 3 * *",
+		"0 3 * * * *",
+		"60 * * * *",
+		"0 24 * * *",
+		"0 0 0 * *",
+		"0 0 * 13 *",
+		"0 0 * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"0 0 * * funday",
+		"@often",
+	} {
+		if _, err := ParseCronSchedule(expr); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
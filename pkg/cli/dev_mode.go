@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DevModeMaxLogLines caps every logs collector in --dev mode, enough to check a collector mapping works
+const DevModeMaxLogLines = 200
+
+// ApplyDevMode adjusts the options for --dev, the loop of developers iterating on collector mappings against a
+// local kind or minikube cluster: the current kubeconfig context, no RBAC checks and an uncompressed directory bundle
+func ApplyDevMode(options SupportBundleCollectOptions) (SupportBundleCollectOptions, error) {
+	if !options.Dev {
+		return options, nil
+	}
+	if options.Context != "" {
+		return options, fmt.Errorf("--context cannot be used with --dev, which targets the current kubeconfig context")
+	}
+	if options.Compression != "" && options.Compression != CompressionNone {
+		return options, fmt.Errorf("--compression %s cannot be used with --dev, which writes a directory bundle", options.Compression)
+	}
+	if options.Output != "" {
+		if _, compression := splitArchivePath(options.Output); compression != "" {
+			return options, fmt.Errorf("--output must name a directory with --dev, got an archive path %s", options.Output)
+		}
+	}
+
+	options.RBACCheck = false
+	if !options.DryRun {
+		options.Compression = CompressionNone
+	}
+	return options, nil
+}
+
+// applyDevModeDiscovery relaxes discovery options after profiles and the config file were merged, so neither turns
+// RBAC filtering back on or raises the log limits
+func applyDevModeDiscovery(opts autodiscovery.DiscoveryOptions) autodiscovery.DiscoveryOptions {
+	opts.RBACCheck = false
+	if opts.MaxLogLines <= 0 || opts.MaxLogLines > DevModeMaxLogLines {
+		opts.MaxLogLines = DevModeMaxLogLines
+	}
+	return opts
+}
+
+// loadDevKubernetesConfig loads the current kubeconfig context, never the in-cluster config, so --dev cannot reach
+// a real cluster from inside a pod
+func loadDevKubernetesConfig(options SupportBundleCollectOptions) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = options.KubeconfigPath
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestApplyDevMode(t *testing.T) {
+	tests := []struct {
+		name                string
+		options             SupportBundleCollectOptions
+		expectError         bool
+		expectedCompression string
+	}{
+		{
+			name:                "disabled",
+			options:             SupportBundleCollectOptions{RBACCheck: true},
+			expectedCompression: "",
+		},
+		{
+			name:                "collection",
+			options:             SupportBundleCollectOptions{Dev: true, RBACCheck: true},
+			expectedCompression: CompressionNone,
+		},
+		{
+			name:                "dry run",
+			options:             SupportBundleCollectOptions{Dev: true, DryRun: true},
+			expectedCompression: "",
+		},
+		{
+			name:                "directory output",
+			options:             SupportBundleCollectOptions{Dev: true, Output: "bundles/{{.Timestamp}}"},
+			expectedCompression: CompressionNone,
+		},
+		{
+			name:        "explicit context",
+			options:     SupportBundleCollectOptions{Dev: true, Context: "prod"},
+			expectError: true,
+		},
+		{
+			name:        "compressed bundle",
+			options:     SupportBundleCollectOptions{Dev: true, Compression: CompressionGzip},
+			expectError: true,
+		},
+		{
+			name:        "archive output",
+			options:     SupportBundleCollectOptions{Dev: true, Output: "bundles/{{.Timestamp}}.tgz"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := ApplyDevMode(tt.options)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if tt.expectError {
+				return
+			}
+			if options.RBACCheck != (tt.options.RBACCheck && !tt.options.Dev) {
+				t.Errorf("Expected RBAC checks to be off in dev mode, got %v", options.RBACCheck)
+			}
+			if options.Compression != tt.expectedCompression {
+				t.Errorf("Expected compression %q, got %q", tt.expectedCompression, options.Compression)
+			}
+		})
+	}
+}
+
+func TestApplyDevModeDiscovery(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxLogLines int
+		expected    int
+	}{
+		{name: "unset", maxLogLines: 0, expected: DevModeMaxLogLines},
+		{name: "larger", maxLogLines: 5000, expected: DevModeMaxLogLines},
+		{name: "smaller", maxLogLines: 50, expected: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := applyDevModeDiscovery(autodiscovery.DiscoveryOptions{RBACCheck: true, MaxLogLines: tt.maxLogLines})
+			if opts.RBACCheck {
+				t.Errorf("Expected RBAC checks to be off")
+			}
+			if opts.MaxLogLines != tt.expected {
+				t.Errorf("Expected max log lines %d, got %d", tt.expected, opts.MaxLogLines)
+			}
+		})
+	}
+}
@@ -14,8 +14,12 @@ type DiscoveryProfile struct {
 	Description string                      `json:"description"`
 	Options     autodiscovery.DiscoveryOptions `json:"options"`
 	Config      *autodiscovery.Config       `json:"config"`
+	Extends     []string                    `json:"extends,omitempty"` // Profiles merged in order underneath this one, see resolveProfile
 }
 
+// builtinProfileOrder lists the built-in profiles in the order they are presented
+var builtinProfileOrder = []string{"minimal", "logs", "workloads", "standard", "comprehensive", "debug"}
+
 // DiscoveryProfileManager manages discovery profiles
 type DiscoveryProfileManager struct {
 	profiles map[string]*DiscoveryProfile
@@ -30,13 +34,61 @@ func NewDiscoveryProfileManager() *DiscoveryProfileManager {
 	return manager
 }
 
-// GetProfile retrieves a discovery profile by name
+// GetProfile retrieves a discovery profile by name, with the profiles it extends merged in
 func (dpm *DiscoveryProfileManager) GetProfile(name string) (*DiscoveryProfile, error) {
+	return dpm.resolveProfile(name, nil)
+}
+
+// resolveProfile merges the profiles listed in Extends, in order, underneath the named profile
+// The override semantics are those of config extends (see autodiscovery.MergeConfigs):
+//   - options set in a later profile replace earlier ones, booleans can only be turned on
+//   - resource filters and collector mappings with the same name are replaced in place, others are appended
+//   - excludes and includes are appended
+//
+// The name and description are always those of the named profile. stack holds the profiles being resolved
+// so cycles can be reported
+func (dpm *DiscoveryProfileManager) resolveProfile(name string, stack []string) (*DiscoveryProfile, error) {
 	profile, exists := dpm.profiles[name]
 	if !exists {
 		return nil, fmt.Errorf("discovery profile not found: %s", name)
 	}
-	return profile, nil
+	for _, resolving := range stack {
+		if resolving == name {
+			return nil, fmt.Errorf("discovery profile extends cycle: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+	}
+	if len(profile.Extends) == 0 {
+		return profile, nil
+	}
+
+	merged := &DiscoveryProfile{}
+	for _, extends := range profile.Extends {
+		base, err := dpm.resolveProfile(extends, append(stack, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve profile %s: %w", name, err)
+		}
+		merged = mergeProfiles(merged, base)
+	}
+	merged = mergeProfiles(merged, profile)
+	merged.Name = profile.Name
+	merged.Description = profile.Description
+	merged.Extends = profile.Extends
+	return merged, nil
+}
+
+// mergeProfiles layers the options and config of override on top of base
+func mergeProfiles(base, override *DiscoveryProfile) *DiscoveryProfile {
+	merged := &DiscoveryProfile{
+		Options: autodiscovery.MergeDiscoveryOptions(base.Options, override.Options),
+		Config:  base.Config,
+	}
+	if override.Config != nil {
+		if merged.Config == nil {
+			merged.Config = &autodiscovery.Config{}
+		}
+		merged.Config = autodiscovery.MergeConfigs(merged.Config, override.Config)
+	}
+	return merged
 }
 
 // ListProfiles returns all available profile names
@@ -58,8 +110,18 @@ func (dpm *DiscoveryProfileManager) RegisterProfile(profile *DiscoveryProfile) e
 	if err := dpm.validateProfile(profile); err != nil {
 		return fmt.Errorf("invalid profile: %w", err)
 	}
-	
+
+	// The extended profiles must exist and must not extend this one
+	previous, replacing := dpm.profiles[profile.Name]
 	dpm.profiles[profile.Name] = profile
+	if _, err := dpm.resolveProfile(profile.Name, nil); err != nil {
+		if replacing {
+			dpm.profiles[profile.Name] = previous
+		} else {
+			delete(dpm.profiles, profile.Name)
+		}
+		return fmt.Errorf("invalid profile: %w", err)
+	}
 	return nil
 }
 
@@ -72,6 +134,10 @@ func (profile *DiscoveryProfile) ApplyToOptions(baseOptions autodiscovery.Discov
 	if len(baseOptions.Namespaces) > 0 {
 		result.Namespaces = baseOptions.Namespaces
 	}
+	if baseOptions.MaxCollectors > 0 {
+		result.MaxCollectors = baseOptions.MaxCollectors
+	}
+	result.ClusterScope = result.ClusterScope.WithOverrides(baseOptions.ClusterScope)
 	
 	return result
 }
@@ -109,10 +175,33 @@ func (dpm *DiscoveryProfileManager) initializeBuiltinProfiles() {
 		},
 	}
 
-	// Standard Profile - Comprehensive application troubleshooting
-	dpm.profiles["standard"] = &DiscoveryProfile{
-		Name:        "standard",
-		Description: "Standard auto-discovery for comprehensive application troubleshooting",
+	// Logs Profile - Pod logs and events, a building block for other profiles
+	dpm.profiles["logs"] = &DiscoveryProfile{
+		Name:        "logs",
+		Description: "Pod logs and events, combine with other profiles through extends",
+		Options: autodiscovery.DiscoveryOptions{
+			Namespaces: []string{},
+			RBACCheck:  true,
+			MaxDepth:   1,
+		},
+		Config: &autodiscovery.Config{
+			ResourceFilters: []autodiscovery.ResourceFilterRule{
+				{
+					Name:   "logs-resources",
+					Action: "include",
+					MatchGVRs: []schema.GroupVersionResource{
+						{Group: "", Version: "v1", Resource: "pods"},
+						{Group: "", Version: "v1", Resource: "events"},
+					},
+				},
+			},
+		},
+	}
+
+	// Workloads Profile - Controllers and the objects they mount, a building block for other profiles
+	dpm.profiles["workloads"] = &DiscoveryProfile{
+		Name:        "workloads",
+		Description: "Workloads, their configuration, storage and ingresses, combine with other profiles through extends",
 		Options: autodiscovery.DiscoveryOptions{
 			Namespaces:    []string{},
 			IncludeImages: true,
@@ -122,34 +211,27 @@ func (dpm *DiscoveryProfileManager) initializeBuiltinProfiles() {
 		Config: &autodiscovery.Config{
 			ResourceFilters: []autodiscovery.ResourceFilterRule{
 				{
-					Name:   "standard-app-resources",
+					Name:   "workloads-resources",
 					Action: "include",
 					MatchGVRs: []schema.GroupVersionResource{
-						// Core resources
-						{Group: "", Version: "v1", Resource: "pods"},
-						{Group: "", Version: "v1", Resource: "services"},
+						// Configuration and storage
 						{Group: "", Version: "v1", Resource: "configmaps"},
-						{Group: "", Version: "v1", Resource: "events"},
 						{Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
-						
+
 						// Workload resources
 						{Group: "apps", Version: "v1", Resource: "deployments"},
 						{Group: "apps", Version: "v1", Resource: "statefulsets"},
 						{Group: "apps", Version: "v1", Resource: "daemonsets"},
-						
+
 						// Networking resources
 						{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
-						
+
 						// Batch resources
 						{Group: "batch", Version: "v1", Resource: "jobs"},
 					},
 				},
 			},
 			Excludes: []autodiscovery.ResourceExcludeRule{
-				{
-					Namespaces: []string{"kube-node-lease"},
-					Reason:     "Node lease namespace not useful for troubleshooting",
-				},
 				{
 					GVRs: []schema.GroupVersionResource{
 						{Group: "", Version: "v1", Resource: "secrets"},
@@ -161,6 +243,13 @@ func (dpm *DiscoveryProfileManager) initializeBuiltinProfiles() {
 		},
 	}
 
+	// Standard Profile - Comprehensive application troubleshooting, composed of minimal, logs and workloads
+	dpm.profiles["standard"] = &DiscoveryProfile{
+		Name:        "standard",
+		Description: "Standard auto-discovery for comprehensive application troubleshooting",
+		Extends:     []string{"minimal", "logs", "workloads"},
+	}
+
 	// Comprehensive Profile - Deep cluster analysis
 	dpm.profiles["comprehensive"] = &DiscoveryProfile{
 		Name:        "comprehensive",
@@ -242,6 +331,7 @@ func (dpm *DiscoveryProfileManager) initializeBuiltinProfiles() {
 			IncludeImages: true,
 			RBACCheck:     false,
 			MaxDepth:      10, // Maximum depth
+			HotPods:       autodiscovery.HotPods{TopN: 10},
 		},
 		Config: &autodiscovery.Config{
 			// Include everything possible
@@ -283,8 +373,8 @@ func (dpm *DiscoveryProfileManager) validateProfile(profile *DiscoveryProfile) e
 	}
 
 	// Validate discovery options
-	if profile.Options.MaxDepth < 0 || profile.Options.MaxDepth > 20 {
-		return fmt.Errorf("maxDepth must be between 0 and 20")
+	if err := profile.Options.Validate(); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
 	}
 
 	// Validate config if present
@@ -324,6 +414,9 @@ func (dpm *DiscoveryProfileManager) validateProfile(profile *DiscoveryProfile) e
 // GetProfileDescription returns a human-readable description of the profile
 func (profile *DiscoveryProfile) GetProfileDescription() string {
 	description := fmt.Sprintf("%s: %s\n", profile.Name, profile.Description)
+	if len(profile.Extends) > 0 {
+		description += fmt.Sprintf("  Extends: %s\n", strings.Join(profile.Extends, ", "))
+	}
 	description += fmt.Sprintf("  Include Images: %v\n", profile.Options.IncludeImages)
 	description += fmt.Sprintf("  RBAC Check: %v\n", profile.Options.RBACCheck)
 	description += fmt.Sprintf("  Max Depth: %d\n", profile.Options.MaxDepth)
@@ -354,12 +447,15 @@ func (dpm *DiscoveryProfileManager) GetProfilesOverview() string {
 	overview := "📋 Available Discovery Profiles:\n\n"
 	
 	// Specific order for built-in profiles
-	profileOrder := []string{"minimal", "standard", "comprehensive", "debug"}
+	profileOrder := builtinProfileOrder
 	
 	for _, profileName := range profileOrder {
-		if profile, exists := dpm.profiles[profileName]; exists {
+		if profile, err := dpm.GetProfile(profileName); err == nil {
 			overview += fmt.Sprintf("🔸 **%s**\n", profile.Name)
 			overview += fmt.Sprintf("   %s\n", profile.Description)
+			if len(profile.Extends) > 0 {
+				overview += fmt.Sprintf("   Extends: %s\n", strings.Join(profile.Extends, " + "))
+			}
 			overview += fmt.Sprintf("   Images: %v | RBAC: %v | Depth: %d\n",
 				profile.Options.IncludeImages, profile.Options.RBACCheck, profile.Options.MaxDepth)
 			
@@ -391,6 +487,9 @@ func (dpm *DiscoveryProfileManager) GetProfilesOverview() string {
 		if !isBuiltin {
 			overview += fmt.Sprintf("🔹 **%s** (custom)\n", profile.Name)
 			overview += fmt.Sprintf("   %s\n", profile.Description)
+			if len(profile.Extends) > 0 {
+				overview += fmt.Sprintf("   Extends: %s\n", strings.Join(profile.Extends, " + "))
+			}
 			overview += "\n"
 		}
 	}
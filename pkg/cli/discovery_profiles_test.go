@@ -186,6 +186,92 @@ func TestDiscoveryProfileManager_RegisterProfile(t *testing.T) {
 	}
 }
 
+func TestDiscoveryProfileManager_Extends(t *testing.T) {
+	manager := NewDiscoveryProfileManager()
+
+	standard, err := manager.GetProfile("standard")
+	if err != nil {
+		t.Fatalf("Failed to get standard profile: %v", err)
+	}
+	if !standard.Options.IncludeImages || !standard.Options.RBACCheck || standard.Options.MaxDepth != 3 {
+		t.Errorf("Expected standard to take images and depth 3 from workloads, got %+v", standard.Options)
+	}
+	var filters []string
+	for _, filter := range standard.Config.ResourceFilters {
+		filters = append(filters, filter.Name)
+	}
+	expectedFilters := "minimal-core-resources,logs-resources,workloads-resources"
+	if strings.Join(filters, ",") != expectedFilters {
+		t.Errorf("Expected filters %s, got %s", expectedFilters, strings.Join(filters, ","))
+	}
+
+	custom := &DiscoveryProfile{
+		Name:        "team",
+		Description: "Standard with deeper dependencies and our operator",
+		Extends:     []string{"standard"},
+		Options:     autodiscovery.DiscoveryOptions{MaxDepth: 5},
+		Config: &autodiscovery.Config{
+			ResourceFilters: []autodiscovery.ResourceFilterRule{
+				{Name: "logs-resources", Action: "exclude"},
+				{Name: "team-operator", Action: "include"},
+			},
+		},
+	}
+	if err := manager.RegisterProfile(custom); err != nil {
+		t.Fatalf("Failed to register extending profile: %v", err)
+	}
+
+	resolved, err := manager.GetProfile("team")
+	if err != nil {
+		t.Fatalf("Failed to get extending profile: %v", err)
+	}
+	if resolved.Name != "team" || resolved.Description != custom.Description {
+		t.Errorf("Expected the name and description of the extending profile, got %s: %s", resolved.Name, resolved.Description)
+	}
+	if resolved.Options.MaxDepth != 5 || !resolved.Options.IncludeImages {
+		t.Errorf("Expected depth 5 with inherited images, got %+v", resolved.Options)
+	}
+	if len(resolved.Config.ResourceFilters) != 4 {
+		t.Fatalf("Expected 4 resource filters, got %d", len(resolved.Config.ResourceFilters))
+	}
+	if resolved.Config.ResourceFilters[1].Name != "logs-resources" || resolved.Config.ResourceFilters[1].Action != "exclude" {
+		t.Errorf("Expected logs-resources to be replaced in place, got %+v", resolved.Config.ResourceFilters[1])
+	}
+	if len(resolved.Config.Excludes) != len(standard.Config.Excludes) {
+		t.Errorf("Expected %d inherited excludes, got %d", len(standard.Config.Excludes), len(resolved.Config.Excludes))
+	}
+
+	if raw := manager.profiles["team"]; raw.Options.IncludeImages || len(raw.Config.ResourceFilters) != 2 {
+		t.Errorf("Resolving a profile should not modify it")
+	}
+}
+
+func TestDiscoveryProfileManager_ExtendsErrors(t *testing.T) {
+	manager := NewDiscoveryProfileManager()
+
+	err := manager.RegisterProfile(&DiscoveryProfile{Name: "orphan", Description: "Test profile", Extends: []string{"missing"}})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a missing profile error, got %v", err)
+	}
+	if _, err := manager.GetProfile("orphan"); err == nil {
+		t.Errorf("Expected the invalid profile not to be registered")
+	}
+
+	if err := manager.RegisterProfile(&DiscoveryProfile{Name: "a", Description: "Test profile"}); err != nil {
+		t.Fatalf("Failed to register profile: %v", err)
+	}
+	if err := manager.RegisterProfile(&DiscoveryProfile{Name: "b", Description: "Test profile", Extends: []string{"a"}}); err != nil {
+		t.Fatalf("Failed to register profile: %v", err)
+	}
+	err = manager.RegisterProfile(&DiscoveryProfile{Name: "a", Description: "Replaced", Extends: []string{"b"}})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+	if profile, err := manager.GetProfile("a"); err != nil || profile.Description != "Test profile" {
+		t.Errorf("Expected the previous profile to be kept, got %v, %v", profile, err)
+	}
+}
+
 func TestDiscoveryProfile_ApplyToOptions(t *testing.T) {
 	profile := &DiscoveryProfile{
 		Name: "test-profile",
@@ -1,15 +1,20 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
 	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // DryRunExecutor handles dry-run mode execution
@@ -17,8 +22,13 @@ type DryRunExecutor struct {
 	discoverer       *autodiscovery.Discoverer
 	imageCollector   *images.AutoDiscoveryImageCollector
 	rbacValidator    *RBACValidator
+	baseline         *DryRunResult
 	outputFormat     string // "console", "json", "yaml"
+	outputFile       string // Write results here instead of stdout
 	verboseMode      bool
+	quietMode        bool
+	referencedGVRs   []schema.GroupVersionResource // GVRs named by the config and profile, checked against the cluster
+	remediationDir   string // Where rbac-remediation.yaml is written, defaults to the output file's directory
 }
 
 // DryRunResult represents the result of a dry-run execution
@@ -33,12 +43,21 @@ type DryRunResult struct {
 	Recommendations  []string                        `json:"recommendations"`
 	EstimatedSize    string                          `json:"estimatedSize"`
 	EstimatedDuration time.Duration                  `json:"estimatedDuration"`
+	Comparison       *DryRunComparison               `json:"comparison,omitempty"`
+	Throttling       *autodiscovery.ThrottleStats    `json:"throttling,omitempty"`
+	UnservedResources []autodiscovery.UnservedGVR    `json:"unservedResources,omitempty"`
+	Dependencies     *autodiscovery.DependencyReport `json:"dependencies,omitempty"`
+	CollectorLimit   *autodiscovery.CollectorLimitReport `json:"collectorLimit,omitempty"`
+	ClusterScope     *autodiscovery.ClusterScopeReport   `json:"clusterScope,omitempty"`
+	Annotations      *autodiscovery.AnnotationReport     `json:"annotations,omitempty"`
+	NamespaceScope   *NamespaceScope                     `json:"namespaceScope,omitempty"` // Namespaces inferred when none were given, and why
 }
 
 // DryRunSummary provides high-level summary of what would be collected
 type DryRunSummary struct {
 	TotalCollectors       int            `json:"totalCollectors"`
 	CollectorsByType      map[string]int `json:"collectorsByType"`
+	CollectorsByGroup     map[string]int `json:"collectorsByGroup"`
 	CollectorsByNamespace map[string]int `json:"collectorsByNamespace"`
 	CollectorsByPriority  map[string]int `json:"collectorsByPriority"`
 	NamespacesIncluded    []string       `json:"namespacesIncluded"`
@@ -52,6 +71,7 @@ type DryRunImageAnalysis struct {
 	UniqueRegistries  []string `json:"uniqueRegistries"`
 	EstimatedSize     string   `json:"estimatedSize"`
 	AuthRequirements  []string `json:"authRequirements"`
+	RegistryCatalog   *images.RegistryCatalog `json:"registryCatalog,omitempty"` // Registries of the pods in scope, replaces the estimates above
 }
 
 // NewDryRunExecutor creates a new dry-run executor
@@ -81,9 +101,26 @@ func (dre *DryRunExecutor) SetVerboseMode(verbose bool) {
 	dre.verboseMode = verbose
 }
 
-// Execute performs the dry-run analysis
+// SetOutputFile writes results to path instead of stdout; an empty path restores stdout
+func (dre *DryRunExecutor) SetOutputFile(path string) {
+	dre.outputFile = path
+}
+
+// SetQuietMode suppresses progress output so library callers only get return values
+func (dre *DryRunExecutor) SetQuietMode(quiet bool) {
+	dre.quietMode = quiet
+}
+
+// progressf prints a progress line unless quiet mode is enabled
+func (dre *DryRunExecutor) progressf(format string, args ...interface{}) {
+	if !dre.quietMode {
+		fmt.Printf(format, args...)
+	}
+}
+
+// Execute performs the dry-run analysis and returns the result without printing it
 func (dre *DryRunExecutor) Execute(ctx context.Context, options autodiscovery.DiscoveryOptions, rbacMode RBACValidationMode) (*DryRunResult, error) {
-	fmt.Printf("🔍 Executing auto-discovery dry run...\n")
+	dre.progressf("🔍 Executing auto-discovery dry run...\n")
 	
 	result := &DryRunResult{
 		Timestamp:       time.Now(),
@@ -94,7 +131,7 @@ func (dre *DryRunExecutor) Execute(ctx context.Context, options autodiscovery.Di
 
 	// Perform RBAC validation if requested
 	if rbacMode != RBACValidationOff && dre.rbacValidator != nil {
-		fmt.Printf("🔐 Validating RBAC permissions...\n")
+		dre.progressf("🔐 Validating RBAC permissions...\n")
 		rbacReport, err := dre.rbacValidator.ValidateRBACAccess(ctx, options.Namespaces)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("RBAC validation failed: %v", err))
@@ -109,7 +146,7 @@ func (dre *DryRunExecutor) Execute(ctx context.Context, options autodiscovery.Di
 	}
 
 	// Perform discovery simulation
-	fmt.Printf("🔍 Simulating auto-discovery...\n")
+	dre.progressf("🔍 Simulating auto-discovery...\n")
 	collectors, err := dre.discoverer.Discover(ctx, options)
 	if err != nil {
 		return nil, fmt.Errorf("discovery simulation failed: %w", err)
@@ -117,10 +154,46 @@ func (dre *DryRunExecutor) Execute(ctx context.Context, options autodiscovery.Di
 
 	result.Collectors = collectors
 	result.Summary = dre.generateSummary(collectors, options)
+	dre.recordThrottling(result)
+	dre.recordDependencies(result)
+	dre.recordCollectorLimit(result)
+	dre.recordClusterScope(result)
+	dre.recordCollectionAnnotations(result)
+
+	// Warn about config and profile GVRs the cluster does not serve, they would silently collect nothing
+	if len(dre.referencedGVRs) > 0 {
+		unserved, err := dre.discoverer.FindUnservedGVRs(dre.referencedGVRs)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Could not check configured resource types: %v", err))
+		} else {
+			addUnservedGVRWarnings(result, unserved)
+		}
+	}
+
+	// Simulate the verbs each collector needs at execution time
+	if result.RBACReport != nil && result.RBACReport.Mode != "off" {
+		dre.progressf("🔐 Simulating collector execution permissions...\n")
+		for _, collectorResult := range dre.rbacValidator.ValidateCollectorPermissions(ctx, collectors) {
+			if collectorResult.WillFail {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("Collector %s will fail: missing %s",
+					collectorResult.CollectorName, describeDeniedPermissions(collectorResult.Denied)))
+			}
+		}
+		dre.writeRBACRemediation(ctx, result.RBACReport, result)
+	}
 
 	// Analyze image collection if enabled
 	if options.IncludeImages {
 		imageAnalysis := dre.analyzeImageCollection(collectors)
+		if dre.imageCollector != nil {
+			dre.progressf("📦 Cataloging image registries...\n")
+			catalog, err := dre.imageCollector.BuildRegistryCatalog(ctx, options.Namespaces, nil)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("Registry catalog failed: %v", err))
+			} else {
+				applyRegistryCatalog(imageAnalysis, catalog)
+			}
+		}
 		result.ImageAnalysis = imageAnalysis
 	}
 
@@ -131,95 +204,169 @@ func (dre *DryRunExecutor) Execute(ctx context.Context, options autodiscovery.Di
 	result.EstimatedSize = dre.estimateCollectionSize(result)
 	result.EstimatedDuration = dre.estimateCollectionDuration(result)
 
+	// Compare against the previous run if a baseline was provided
+	if dre.baseline != nil {
+		result.Comparison = dre.compareWithBaseline(dre.baseline, result)
+	}
+
 	return result, nil
 }
 
-// PrintResult outputs the dry-run result in the specified format
+// PrintResult outputs the dry-run result in the specified format, to the output file if one is set
 func (dre *DryRunExecutor) PrintResult(result *DryRunResult) error {
+	data, err := dre.RenderResult(result)
+	if err != nil {
+		return err
+	}
+
+	if dre.outputFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(dre.outputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write dry run result to %s: %w", dre.outputFile, err)
+	}
+	dre.progressf("📄 Dry run result written to %s\n", dre.outputFile)
+	return nil
+}
+
+// RenderResult returns the dry-run result in the specified format
+func (dre *DryRunExecutor) RenderResult(result *DryRunResult) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+
 	switch dre.outputFormat {
 	case "console":
-		return dre.printConsoleResult(result)
+		err = dre.writeConsoleResult(&buf, result)
 	case "json":
-		return dre.printJSONResult(result)
+		err = dre.writeJSONResult(&buf, result)
 	case "yaml":
-		return dre.printYAMLResult(result)
+		err = dre.writeYAMLResult(&buf, result)
 	default:
-		return fmt.Errorf("unsupported output format: %s", dre.outputFormat)
+		return nil, fmt.Errorf("unsupported output format: %s", dre.outputFormat)
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	return buf.Bytes(), nil
 }
 
-func (dre *DryRunExecutor) printConsoleResult(result *DryRunResult) error {
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
-	fmt.Printf("🔍 AUTO-DISCOVERY DRY RUN RESULTS\n")
-	fmt.Printf(strings.Repeat("=", 60) + "\n\n")
+func (dre *DryRunExecutor) writeConsoleResult(w io.Writer, result *DryRunResult) error {
+	fmt.Fprintf(w, "\n%s\n", strings.Repeat("=", 60))
+	fmt.Fprintf(w, "🔍 AUTO-DISCOVERY DRY RUN RESULTS\n")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 60))
 
 	// Print configuration summary
-	fmt.Printf("⚙️  Configuration:\n")
-	fmt.Printf("  Namespaces: %v\n", result.Options.Namespaces)
-	fmt.Printf("  Include Images: %v\n", result.Options.IncludeImages)
-	fmt.Printf("  RBAC Check: %v\n", result.Options.RBACCheck)
-	fmt.Printf("  Max Depth: %d\n", result.Options.MaxDepth)
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "⚙️  Configuration:\n")
+	fmt.Fprintf(w, "  Namespaces: %v\n", result.Options.Namespaces)
+	fmt.Fprintf(w, "  Include Images: %v\n", result.Options.IncludeImages)
+	fmt.Fprintf(w, "  RBAC Check: %v\n", result.Options.RBACCheck)
+	fmt.Fprintf(w, "  Max Depth: %d\n", result.Options.MaxDepth)
+	fmt.Fprintf(w, "\n")
 
 	// Print discovery summary
-	fmt.Printf("📊 Discovery Summary:\n")
-	fmt.Printf("  Total Collectors: %d\n", result.Summary.TotalCollectors)
-	fmt.Printf("  Namespaces: %d (%v)\n", len(result.Summary.NamespacesIncluded), result.Summary.NamespacesIncluded)
-	fmt.Printf("  Resource Types: %d\n", len(result.Summary.ResourceTypesIncluded))
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "📊 Discovery Summary:\n")
+	fmt.Fprintf(w, "  Total Collectors: %d\n", result.Summary.TotalCollectors)
+	fmt.Fprintf(w, "  Namespaces: %d (%v)\n", len(result.Summary.NamespacesIncluded), result.Summary.NamespacesIncluded)
+	fmt.Fprintf(w, "  Resource Types: %d\n", len(result.Summary.ResourceTypesIncluded))
+	fmt.Fprintf(w, "\n")
 
 	// Print collectors by type
-	fmt.Printf("📋 Collectors by Type:\n")
+	fmt.Fprintf(w, "📋 Collectors by Type:\n")
 	for collectorType, count := range result.Summary.CollectorsByType {
-		fmt.Printf("  %-20s: %d collectors\n", collectorType, count)
+		fmt.Fprintf(w, "  %-20s: %d collectors\n", collectorType, count)
+	}
+	fmt.Fprintf(w, "\n")
+
+	// Print collectors by group
+	fmt.Fprintf(w, "🏷️  Collectors by Group:\n")
+	for group, count := range result.Summary.CollectorsByGroup {
+		fmt.Fprintf(w, "  %-20s: %d collectors\n", group, count)
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 
 	// Print RBAC report if available
 	if result.RBACReport != nil {
-		fmt.Printf("🔐 RBAC Validation:\n")
-		fmt.Printf("  Access Rate: %.1f%%\n", result.RBACReport.Summary.AccessRate*100)
-		fmt.Printf("  Accessible Namespaces: %d\n", result.RBACReport.Summary.NamespaceAccess)
-		fmt.Printf("  Accessible Resource Types: %d\n", result.RBACReport.Summary.ResourceTypeAccess)
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "🔐 RBAC Validation:\n")
+		fmt.Fprintf(w, "  Access Rate: %.1f%%\n", result.RBACReport.Summary.AccessRate*100)
+		fmt.Fprintf(w, "  Accessible Namespaces: %d\n", result.RBACReport.Summary.NamespaceAccess)
+		fmt.Fprintf(w, "  Accessible Resource Types: %d\n", result.RBACReport.Summary.ResourceTypeAccess)
+		if len(result.RBACReport.CollectorResults) > 0 {
+			fmt.Fprintf(w, "  Collectors Missing Permissions: %d/%d\n", result.RBACReport.FailingCollectors, len(result.RBACReport.CollectorResults))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	// Print the namespaces inferred when none were given
+	if result.NamespaceScope != nil {
+		writeNamespaceScope(w, result.NamespaceScope)
+		fmt.Fprintf(w, "\n")
+	}
+
+	// Print the cluster-scoped types opted in to
+	if result.ClusterScope != nil {
+		writeClusterScope(w, result.ClusterScope)
+		fmt.Fprintf(w, "\n")
+	}
+
+	// Print what collection annotations changed
+	if result.Annotations != nil {
+		writeCollectionAnnotations(w, result.Annotations)
+		fmt.Fprintf(w, "\n")
 	}
 
 	// Print image analysis if available
 	if result.ImageAnalysis != nil {
-		fmt.Printf("🖼️  Image Collection:\n")
-		fmt.Printf("  Expected Images: %d\n", result.ImageAnalysis.ExpectedImages)
-		fmt.Printf("  Unique Registries: %v\n", result.ImageAnalysis.UniqueRegistries)
-		fmt.Printf("  Estimated Size: %s\n", result.ImageAnalysis.EstimatedSize)
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "🖼️  Image Collection:\n")
+		fmt.Fprintf(w, "  Expected Images: %d\n", result.ImageAnalysis.ExpectedImages)
+		fmt.Fprintf(w, "  Unique Registries: %v\n", result.ImageAnalysis.UniqueRegistries)
+		fmt.Fprintf(w, "  Estimated Size: %s\n", result.ImageAnalysis.EstimatedSize)
+		if result.ImageAnalysis.RegistryCatalog != nil {
+			writeRegistryCatalogSummary(w, result.ImageAnalysis.RegistryCatalog)
+		}
+		fmt.Fprintf(w, "\n")
 	}
 
 	// Print estimates
-	fmt.Printf("📏 Estimates:\n")
-	fmt.Printf("  Collection Size: %s\n", result.EstimatedSize)
-	fmt.Printf("  Collection Time: %v\n", result.EstimatedDuration.Round(time.Second))
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "📏 Estimates:\n")
+	fmt.Fprintf(w, "  Collection Size: %s\n", result.EstimatedSize)
+	fmt.Fprintf(w, "  Collection Time: %v\n", result.EstimatedDuration.Round(time.Second))
+	fmt.Fprintf(w, "\n")
+
+	// Print baseline comparison if available
+	if result.Comparison != nil {
+		writeDryRunComparison(w, result.Comparison)
+	}
+
+	// Print API throttling seen during discovery
+	if result.Throttling != nil && result.Throttling.Requests > 0 {
+		writeThrottleStats(w, result.Throttling)
+		fmt.Fprintf(w, "\n")
+	}
 
 	// Print warnings
 	if len(result.Warnings) > 0 {
-		fmt.Printf("⚠️  Warnings:\n")
+		fmt.Fprintf(w, "⚠️  Warnings:\n")
 		for _, warning := range result.Warnings {
-			fmt.Printf("  • %s\n", warning)
+			fmt.Fprintf(w, "  • %s\n", warning)
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "\n")
 	}
 
 	// Print recommendations
 	if len(result.Recommendations) > 0 {
-		fmt.Printf("💡 Recommendations:\n")
+		fmt.Fprintf(w, "💡 Recommendations:\n")
 		for _, rec := range result.Recommendations {
-			fmt.Printf("  • %s\n", rec)
+			fmt.Fprintf(w, "  • %s\n", rec)
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "\n")
 	}
 
 	// Print detailed collectors list if verbose
 	if dre.verboseMode {
-		fmt.Printf("📝 Detailed Collector List:\n")
+		fmt.Fprintf(w, "📝 Detailed Collector List:\n")
 		
 		// Sort collectors by priority for display
 		sortedCollectors := make([]autodiscovery.CollectorSpec, len(result.Collectors))
@@ -229,41 +376,45 @@ func (dre *DryRunExecutor) printConsoleResult(result *DryRunResult) error {
 		})
 
 		for i, collector := range sortedCollectors {
-			fmt.Printf("  [%3d] %-30s (type: %-15s, ns: %-15s, priority: %d)\n",
+			fmt.Fprintf(w, "  [%3d] %-30s (type: %-15s, ns: %-15s, priority: %d)\n",
 				i+1, collector.Name, collector.Type, collector.Namespace, collector.Priority)
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "\n")
 	}
 
-	fmt.Printf("✅ Dry run complete! Use the actual collection command to gather the data.\n\n")
+	fmt.Fprintf(w, "✅ Dry run complete! Use the actual collection command to gather the data.\n\n")
 	return nil
 }
 
-func (dre *DryRunExecutor) printJSONResult(result *DryRunResult) error {
+func (dre *DryRunExecutor) writeJSONResult(w io.Writer, result *DryRunResult) error {
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal dry run result to JSON: %w", err)
 	}
 	
-	fmt.Printf("%s\n", data)
+	fmt.Fprintf(w, "%s\n", data)
 	return nil
 }
 
-func (dre *DryRunExecutor) printYAMLResult(result *DryRunResult) error {
+func (dre *DryRunExecutor) writeYAMLResult(w io.Writer, result *DryRunResult) error {
 	// In a full implementation, this would use a YAML marshaler
-	fmt.Printf("# Auto-Discovery Dry Run Result\n")
-	fmt.Printf("timestamp: %s\n", result.Timestamp.Format(time.RFC3339))
-	fmt.Printf("totalCollectors: %d\n", result.Summary.TotalCollectors)
-	fmt.Printf("estimatedSize: %s\n", result.EstimatedSize)
-	fmt.Printf("estimatedDuration: %s\n", result.EstimatedDuration.String())
+	fmt.Fprintf(w, "# Auto-Discovery Dry Run Result\n")
+	fmt.Fprintf(w, "timestamp: %s\n", result.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(w, "totalCollectors: %d\n", result.Summary.TotalCollectors)
+	fmt.Fprintf(w, "estimatedSize: %s\n", result.EstimatedSize)
+	fmt.Fprintf(w, "estimatedDuration: %s\n", result.EstimatedDuration.String())
+	if result.Throttling != nil {
+		fmt.Fprintf(w, "throttled: %v\n", result.Throttling.Throttled)
+		fmt.Fprintf(w, "effectiveRequestRate: %.1f\n", result.Throttling.EffectiveRate)
+	}
 	
 	if len(result.Collectors) > 0 {
-		fmt.Printf("\ncolletors:\n")
+		fmt.Fprintf(w, "\ncolletors:\n")
 		for _, collector := range result.Collectors {
-			fmt.Printf("  - name: %s\n", collector.Name)
-			fmt.Printf("    type: %s\n", collector.Type)
-			fmt.Printf("    namespace: %s\n", collector.Namespace)
-			fmt.Printf("    priority: %d\n", collector.Priority)
+			fmt.Fprintf(w, "  - name: %s\n", collector.Name)
+			fmt.Fprintf(w, "    type: %s\n", collector.Type)
+			fmt.Fprintf(w, "    namespace: %s\n", collector.Namespace)
+			fmt.Fprintf(w, "    priority: %d\n", collector.Priority)
 		}
 	}
 	
@@ -276,6 +427,7 @@ func (dre *DryRunExecutor) generateSummary(collectors []autodiscovery.CollectorS
 	summary := DryRunSummary{
 		TotalCollectors:       len(collectors),
 		CollectorsByType:      make(map[string]int),
+		CollectorsByGroup:     make(map[string]int),
 		CollectorsByNamespace: make(map[string]int),
 		CollectorsByPriority:  make(map[string]int),
 		NamespacesIncluded:    make([]string, 0),
@@ -289,6 +441,9 @@ func (dre *DryRunExecutor) generateSummary(collectors []autodiscovery.CollectorS
 		// Count by type
 		summary.CollectorsByType[collector.Type]++
 
+		// Count by group
+		summary.CollectorsByGroup[autodiscovery.CollectorGroupFor(collector)]++
+
 		// Count by namespace
 		if collector.Namespace != "" {
 			summary.CollectorsByNamespace[collector.Namespace]++
@@ -350,15 +505,7 @@ func (dre *DryRunExecutor) analyzeImageCollection(collectors []autodiscovery.Col
 	}
 
 	// Estimate size based on expected images
-	if analysis.ExpectedImages == 0 {
-		analysis.EstimatedSize = "None (no pods discovered)"
-	} else if analysis.ExpectedImages < 10 {
-		analysis.EstimatedSize = "Small (< 10MB)"
-	} else if analysis.ExpectedImages < 50 {
-		analysis.EstimatedSize = "Medium (10-50MB)"
-	} else {
-		analysis.EstimatedSize = "Large (> 50MB)"
-	}
+	analysis.EstimatedSize = estimateImageMetadataSize(analysis.ExpectedImages)
 
 	// Determine auth requirements
 	for _, registry := range analysis.UniqueRegistries {
@@ -371,6 +518,37 @@ func (dre *DryRunExecutor) analyzeImageCollection(collectors []autodiscovery.Col
 	return analysis
 }
 
+// applyRegistryCatalog replaces the estimated images and registries with the registries pods actually pull from
+func applyRegistryCatalog(analysis *DryRunImageAnalysis, catalog *images.RegistryCatalog) {
+	analysis.RegistryCatalog = catalog
+	analysis.ExpectedImages = catalog.Summary.TotalImages
+	analysis.EstimatedSize = estimateImageMetadataSize(analysis.ExpectedImages)
+	analysis.UniqueRegistries = make([]string, 0, len(catalog.Registries))
+	analysis.AuthRequirements = make([]string, 0)
+
+	for _, entry := range catalog.Registries {
+		analysis.UniqueRegistries = append(analysis.UniqueRegistries, entry.Registry)
+		if entry.Reachability != nil && entry.Reachability.Status == images.RegistryAuthRequired {
+			analysis.AuthRequirements = append(analysis.AuthRequirements,
+				fmt.Sprintf("Credentials needed for %s (%d images)", entry.Registry, entry.ImageCount))
+		}
+	}
+}
+
+// estimateImageMetadataSize describes the size of the image metadata collected for a number of images
+func estimateImageMetadataSize(expectedImages int) string {
+	switch {
+	case expectedImages == 0:
+		return "None (no pods discovered)"
+	case expectedImages < 10:
+		return "Small (< 10MB)"
+	case expectedImages < 50:
+		return "Medium (10-50MB)"
+	default:
+		return "Large (> 50MB)"
+	}
+}
+
 func (dre *DryRunExecutor) generateRecommendations(result *DryRunResult) []string {
 	var recommendations []string
 
@@ -381,8 +559,8 @@ func (dre *DryRunExecutor) generateRecommendations(result *DryRunResult) []strin
 	}
 
 	// Check for good practices
-	if result.Summary.TotalCollectors > 100 {
-		recommendations = append(recommendations, "Large number of collectors detected - consider using more specific namespace filtering")
+	if result.Summary.TotalCollectors > 100 && result.Options.MaxCollectors == 0 {
+		recommendations = append(recommendations, "Large number of collectors detected - consider using more specific namespace filtering, or cap them with --max-collectors")
 	}
 
 	// Check namespace distribution
@@ -394,6 +572,9 @@ func (dre *DryRunExecutor) generateRecommendations(result *DryRunResult) []strin
 	if result.RBACReport != nil && result.RBACReport.Summary.AccessRate < 0.7 {
 		recommendations = append(recommendations, "Limited RBAC access - consider using a ServiceAccount with broader permissions")
 	}
+	if result.RBACReport != nil && result.RBACReport.RemediationPath != "" {
+		recommendations = append(recommendations, fmt.Sprintf("Grant the missing read permissions with kubectl apply -f %s", result.RBACReport.RemediationPath))
+	}
 
 	// Check image collection
 	if result.ImageAnalysis != nil && len(result.ImageAnalysis.AuthRequirements) > 0 {
@@ -410,6 +591,22 @@ func (dre *DryRunExecutor) generateRecommendations(result *DryRunResult) []strin
 }
 
 func (dre *DryRunExecutor) estimateCollectionSize(result *DryRunResult) string {
+	size := dre.estimateCollectionSizeMB(result)
+
+	switch {
+	case size < 50:
+		return "Small (< 50MB)"
+	case size < 200:
+		return "Medium (50-200MB)"
+	case size < 1000:
+		return "Large (200MB-1GB)"
+	default:
+		return "Very Large (> 1GB)"
+	}
+}
+
+// estimateCollectionSizeMB returns the rough collection size in megabytes
+func (dre *DryRunExecutor) estimateCollectionSizeMB(result *DryRunResult) int {
 	size := 0
 
 	// Base size from collectors
@@ -427,37 +624,43 @@ func (dre *DryRunExecutor) estimateCollectionSize(result *DryRunResult) string {
 	clusterResourcesCollectors := result.Summary.CollectorsByType["cluster-resources"]
 	size += clusterResourcesCollectors * 2 // YAML/JSON resources are smaller
 
-	switch {
-	case size < 50:
-		return "Small (< 50MB)"
-	case size < 200:
-		return "Medium (50-200MB)"
-	case size < 1000:
-		return "Large (200MB-1GB)"
-	default:
-		return "Very Large (> 1GB)"
-	}
+	return size
 }
 
+// Dry-run duration estimates, also the base of the progress bar's ETA during collection
+const (
+	estimatedSetupDuration     = 10 * time.Second
+	estimatedCollectorDuration = 2 * time.Second
+	estimatedLogsExtraDuration = 10 * time.Second // Logs are slower to collect
+	estimatedImageDuration     = 5 * time.Second
+)
+
 func (dre *DryRunExecutor) estimateCollectionDuration(result *DryRunResult) time.Duration {
-	// Base time: 10 seconds setup
-	duration := 10 * time.Second
+	duration := estimatedSetupDuration
 
 	// Add time per collector
-	duration += time.Duration(result.Summary.TotalCollectors) * 2 * time.Second
+	duration += time.Duration(result.Summary.TotalCollectors) * estimatedCollectorDuration
 
 	// Add extra time for image collection
 	if result.ImageAnalysis != nil && result.ImageAnalysis.Enabled {
-		duration += time.Duration(result.ImageAnalysis.ExpectedImages) * 5 * time.Second
+		duration += time.Duration(result.ImageAnalysis.ExpectedImages) * estimatedImageDuration
 	}
 
 	// Add extra time for logs (slower to collect)
 	logsCollectors := result.Summary.CollectorsByType["logs"]
-	duration += time.Duration(logsCollectors) * 10 * time.Second
+	duration += time.Duration(logsCollectors) * estimatedLogsExtraDuration
 
 	return duration
 }
 
+// estimateCollectorDuration is the dry-run estimate of how long one collector takes
+func estimateCollectorDuration(collector autodiscovery.CollectorSpec) time.Duration {
+	if collector.Type == autodiscovery.CollectorTypeLogs {
+		return estimatedCollectorDuration + estimatedLogsExtraDuration
+	}
+	return estimatedCollectorDuration
+}
+
 func (dre *DryRunExecutor) getPriorityName(priority int) string {
 	switch {
 	case priority >= int(autodiscovery.PriorityCritical):
@@ -471,29 +674,33 @@ func (dre *DryRunExecutor) getPriorityName(priority int) string {
 	}
 }
 
+// SetRBACRemediationDir sets where rbac-remediation.yaml is written when RBAC checks find missing read permissions
+func (dre *DryRunExecutor) SetRBACRemediationDir(dir string) {
+	dre.remediationDir = dir
+}
+
+// writeRBACRemediation writes rbac-remediation.yaml for the read permissions the report found missing
+func (dre *DryRunExecutor) writeRBACRemediation(ctx context.Context, report *RBACValidationReport, result *DryRunResult) {
+	dir := dre.remediationDir
+	if dir == "" {
+		dir = filepath.Dir(dre.outputFile) // "." without an output file
+	}
+	path, err := WriteRBACRemediation(dir, report, dre.rbacValidator.CurrentSubject(ctx))
+	if err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+		return
+	}
+	report.RemediationPath = path
+}
+
 // SetRBACValidator sets the RBAC validator for dry-run RBAC checks
 func (dre *DryRunExecutor) SetRBACValidator(validator *RBACValidator) {
 	dre.rbacValidator = validator
 }
 
-// ValidateDryRunOptions validates options for dry-run mode
+// ValidateDryRunOptions validates options for dry-run mode with the rules of DiscoveryOptions.Validate
 func ValidateDryRunOptions(options autodiscovery.DiscoveryOptions) error {
-	// Validate namespaces
-	for _, ns := range options.Namespaces {
-		if ns == "" {
-			return fmt.Errorf("namespace cannot be empty")
-		}
-		if strings.Contains(ns, " ") {
-			return fmt.Errorf("namespace cannot contain spaces: %s", ns)
-		}
-	}
-
-	// Validate max depth
-	if options.MaxDepth < 0 || options.MaxDepth > 20 {
-		return fmt.Errorf("maxDepth must be between 0 and 20")
-	}
-
-	return nil
+	return options.Validate()
 }
 
 // GenerateDryRunExample creates an example dry-run command
@@ -515,5 +722,111 @@ support-bundle collect --auto --profile comprehensive --dry-run --output json
 
 # Dry run with exclusion patterns
 support-bundle collect --auto --exclude "ns:kube-*,secrets" --dry-run --verbose
+
+# Dry run compared against a previous dry run
+support-bundle collect --auto --dry-run --baseline previous-dryrun.json
+
+# Dry run written to a file without progress output
+support-bundle collect --auto --dry-run --output-file dryrun.yaml --output yaml --quiet
 `
 }
+
+// SetReferencedGVRs sets the GVRs named by the config and profile that Execute checks against the cluster
+func (dre *DryRunExecutor) SetReferencedGVRs(gvrs []schema.GroupVersionResource) {
+	dre.referencedGVRs = gvrs
+}
+
+// addUnservedGVRWarnings records GVRs the cluster does not serve and adds a warning for each
+func addUnservedGVRWarnings(result *DryRunResult, unserved []autodiscovery.UnservedGVR) {
+	result.UnservedResources = unserved
+	for _, u := range unserved {
+		result.Warnings = append(result.Warnings, u.String())
+	}
+}
+
+// recordThrottling adds the discoverer's throttle stats to the result and warns when requests were throttled
+func (dre *DryRunExecutor) recordThrottling(result *DryRunResult) {
+	result.Throttling = dre.discoverer.ThrottleStats()
+	if warning := throttleWarning(result.Throttling); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
+}
+
+// recordDependencies adds the dependency resolution report to the result and warns about cycles and truncation
+func (dre *DryRunExecutor) recordDependencies(result *DryRunResult) {
+	result.Dependencies = dre.discoverer.DependencyReport()
+	result.Warnings = append(result.Warnings, dependencyWarnings(result.Dependencies)...)
+}
+
+// recordClusterScope adds the cluster-scoped types opted in to the result and warns about those denied
+func (dre *DryRunExecutor) recordClusterScope(result *DryRunResult) {
+	result.ClusterScope = dre.discoverer.ClusterScopeReport()
+	result.Warnings = append(result.Warnings, clusterScopeWarnings(result.ClusterScope)...)
+}
+
+// recordCollectionAnnotations adds what troubleshoot.sh/ annotations changed to the result and warns about invalid ones
+func (dre *DryRunExecutor) recordCollectionAnnotations(result *DryRunResult) {
+	result.Annotations = dre.discoverer.AnnotationReport()
+	result.Warnings = append(result.Warnings, collectionAnnotationWarnings(result.Annotations)...)
+}
+
+// recordCollectorLimit adds the collectors dropped by --max-collectors to the result and warns about them
+func (dre *DryRunExecutor) recordCollectorLimit(result *DryRunResult) {
+	result.CollectorLimit = dre.discoverer.CollectorLimitReport()
+	if warning := collectorLimitWarning(result.CollectorLimit); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
+}
+
+// collectorLimitWarning describes the collectors dropped to honor the collector limit, or returns "" when none were
+func collectorLimitWarning(report *autodiscovery.CollectorLimitReport) string {
+	if report == nil || len(report.Dropped) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Collector limit of %d reached: %s, the lowest priority ones are listed under collectorLimit in the JSON output", report.Limit, report)
+}
+
+// maxDependencyCycleWarnings limits the cycles listed individually, a service and its pods already form one
+const maxDependencyCycleWarnings = 5
+
+// dependencyWarnings describes the cycles and expansion limit hit during dependency resolution
+func dependencyWarnings(report *autodiscovery.DependencyReport) []string {
+	if report == nil {
+		return nil
+	}
+	var warnings []string
+	for i, cycle := range report.Cycles {
+		if i == maxDependencyCycleWarnings {
+			warnings = append(warnings, fmt.Sprintf("%d more dependency cycles, see the dependencies section of the JSON output", len(report.Cycles)-i))
+			break
+		}
+		warnings = append(warnings, fmt.Sprintf("Dependency cycle: %s (each resource is collected once)", cycle))
+	}
+	if report.Truncated {
+		warnings = append(warnings, fmt.Sprintf("Dependency resolution stopped at %d added resources, remaining dependencies will not be collected", report.Limit))
+	}
+	return warnings
+}
+
+// throttleWarning describes throttling seen during discovery, or returns "" when there was none
+func throttleWarning(stats *autodiscovery.ThrottleStats) string {
+	if stats == nil || !stats.Throttled {
+		return ""
+	}
+	return fmt.Sprintf("API requests were throttled (%d server, %d client-side); the request rate was reduced to %.1f/s, collection on this cluster may be slow",
+		stats.ServerThrottled, stats.ClientThrottled, stats.MinQPS)
+}
+
+// writeThrottleStats prints the request count, effective rate and throttling seen during discovery
+func writeThrottleStats(w io.Writer, stats *autodiscovery.ThrottleStats) {
+	status := "not throttled"
+	if stats.Throttled {
+		status = "throttled"
+	}
+	fmt.Fprintf(w, "🚦 API Requests: %d (%.1f/s, %s)\n", stats.Requests, stats.EffectiveRate, status)
+	if stats.Throttled {
+		fmt.Fprintf(w, "  429 Responses: %d\n", stats.ServerThrottled)
+		fmt.Fprintf(w, "  Client-side Waits: %d (%v)\n", stats.ClientThrottled, stats.ClientWait.Round(time.Millisecond))
+		fmt.Fprintf(w, "  Rate Limit: %.1f/s (min %.1f/s, max %.1f/s)\n", stats.QPS, stats.MinQPS, stats.MaxQPS)
+	}
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// DryRunComparison describes how a dry-run result differs from a previous run
+type DryRunComparison struct {
+	BaselineTimestamp     string                        `json:"baselineTimestamp"`
+	NewCollectors         []autodiscovery.CollectorSpec `json:"newCollectors"`
+	RemovedCollectors     []autodiscovery.CollectorSpec `json:"removedCollectors"`
+	AddedNamespaces       []string                      `json:"addedNamespaces"`
+	RemovedNamespaces     []string                      `json:"removedNamespaces"`
+	CollectorCountDelta   int                           `json:"collectorCountDelta"`
+	BaselineEstimatedSize string                        `json:"baselineEstimatedSize"`
+	CurrentEstimatedSize  string                        `json:"currentEstimatedSize"`
+	EstimatedSizeMBDelta  int                           `json:"estimatedSizeMBDelta"`
+}
+
+// HasChanges returns true if the current run differs from the baseline
+func (c *DryRunComparison) HasChanges() bool {
+	return len(c.NewCollectors) > 0 || len(c.RemovedCollectors) > 0 ||
+		len(c.AddedNamespaces) > 0 || len(c.RemovedNamespaces) > 0 ||
+		c.EstimatedSizeMBDelta != 0
+}
+
+// LoadDryRunBaseline loads a previous dry-run result written with --output json
+func LoadDryRunBaseline(path string) (*DryRunResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file %s: %w", path, err)
+	}
+
+	var baseline DryRunResult
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline file %s: %w", path, err)
+	}
+
+	return &baseline, nil
+}
+
+// SetBaseline sets a previous dry-run result to compare against
+func (dre *DryRunExecutor) SetBaseline(baseline *DryRunResult) {
+	dre.baseline = baseline
+}
+
+// LoadBaseline loads a previous dry-run result from file to compare against
+func (dre *DryRunExecutor) LoadBaseline(path string) error {
+	baseline, err := LoadDryRunBaseline(path)
+	if err != nil {
+		return err
+	}
+	dre.baseline = baseline
+	return nil
+}
+
+// BuildResult builds a dry-run result from already discovered collectors
+func (dre *DryRunExecutor) BuildResult(collectors []autodiscovery.CollectorSpec, options autodiscovery.DiscoveryOptions) *DryRunResult {
+	result := &DryRunResult{
+		Timestamp:  time.Now(),
+		Options:    options,
+		Collectors: collectors,
+		Summary:    dre.generateSummary(collectors, options),
+	}
+	dre.recordThrottling(result)
+	dre.recordDependencies(result)
+	dre.recordCollectorLimit(result)
+	dre.recordClusterScope(result)
+
+	if options.IncludeImages {
+		result.ImageAnalysis = dre.analyzeImageCollection(collectors)
+	}
+
+	result.EstimatedSize = dre.estimateCollectionSize(result)
+	result.EstimatedDuration = dre.estimateCollectionDuration(result)
+
+	if dre.baseline != nil {
+		result.Comparison = dre.compareWithBaseline(dre.baseline, result)
+	}
+
+	return result
+}
+
+// compareWithBaseline computes the delta between a baseline and the current result
+func (dre *DryRunExecutor) compareWithBaseline(baseline, current *DryRunResult) *DryRunComparison {
+	comparison := &DryRunComparison{
+		NewCollectors:         []autodiscovery.CollectorSpec{},
+		RemovedCollectors:     []autodiscovery.CollectorSpec{},
+		CollectorCountDelta:   len(current.Collectors) - len(baseline.Collectors),
+		BaselineEstimatedSize: baseline.EstimatedSize,
+		CurrentEstimatedSize:  current.EstimatedSize,
+	}
+
+	if !baseline.Timestamp.IsZero() {
+		comparison.BaselineTimestamp = baseline.Timestamp.Format(time.RFC3339)
+	}
+
+	baselineCollectors := make(map[string]bool)
+	for _, collector := range baseline.Collectors {
+		baselineCollectors[collectorKey(collector)] = true
+	}
+	currentCollectors := make(map[string]bool)
+	for _, collector := range current.Collectors {
+		key := collectorKey(collector)
+		currentCollectors[key] = true
+		if !baselineCollectors[key] {
+			comparison.NewCollectors = append(comparison.NewCollectors, collector)
+		}
+	}
+	for _, collector := range baseline.Collectors {
+		if !currentCollectors[collectorKey(collector)] {
+			comparison.RemovedCollectors = append(comparison.RemovedCollectors, collector)
+		}
+	}
+
+	comparison.AddedNamespaces = stringSliceDifference(current.Summary.NamespacesIncluded, baseline.Summary.NamespacesIncluded)
+	comparison.RemovedNamespaces = stringSliceDifference(baseline.Summary.NamespacesIncluded, current.Summary.NamespacesIncluded)
+
+	comparison.EstimatedSizeMBDelta = dre.estimateCollectionSizeMB(current) - dre.estimateCollectionSizeMB(baseline)
+
+	return comparison
+}
+
+// collectorKey returns a stable identity for a collector across runs
+func collectorKey(collector autodiscovery.CollectorSpec) string {
+	return fmt.Sprintf("%s/%s/%s", collector.Type, collector.Namespace, collector.Name)
+}
+
+// stringSliceDifference returns the sorted values in a that are not in b
+func stringSliceDifference(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, value := range b {
+		seen[value] = true
+	}
+
+	diff := []string{}
+	for _, value := range a {
+		if !seen[value] {
+			diff = append(diff, value)
+			seen[value] = true
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// printDryRunComparison prints the baseline comparison section of the console output
+func printDryRunComparison(comparison *DryRunComparison) {
+	writeDryRunComparison(os.Stdout, comparison)
+}
+
+// writeDryRunComparison writes the baseline comparison section of the console output
+func writeDryRunComparison(w io.Writer, comparison *DryRunComparison) {
+	fmt.Fprintf(w, "🔄 Comparison with Baseline:\n")
+	if comparison.BaselineTimestamp != "" {
+		fmt.Fprintf(w, "  Baseline Taken: %s\n", comparison.BaselineTimestamp)
+	}
+
+	if !comparison.HasChanges() {
+		fmt.Fprintf(w, "  No changes since baseline\n")
+		fmt.Fprintf(w, "\n")
+		return
+	}
+
+	fmt.Fprintf(w, "  Collector Count Delta: %+d\n", comparison.CollectorCountDelta)
+	fmt.Fprintf(w, "  Estimated Size: %s -> %s (%+dMB)\n",
+		comparison.BaselineEstimatedSize, comparison.CurrentEstimatedSize, comparison.EstimatedSizeMBDelta)
+
+	if len(comparison.AddedNamespaces) > 0 {
+		fmt.Fprintf(w, "  Added Namespaces: %v\n", comparison.AddedNamespaces)
+	}
+	if len(comparison.RemovedNamespaces) > 0 {
+		fmt.Fprintf(w, "  Removed Namespaces: %v\n", comparison.RemovedNamespaces)
+	}
+
+	if len(comparison.NewCollectors) > 0 {
+		fmt.Fprintf(w, "  New Collectors (%d):\n", len(comparison.NewCollectors))
+		for _, collector := range comparison.NewCollectors {
+			fmt.Fprintf(w, "    + %s (type: %s, ns: %s)\n", collector.Name, collector.Type, collector.Namespace)
+		}
+	}
+	if len(comparison.RemovedCollectors) > 0 {
+		fmt.Fprintf(w, "  Removed Collectors (%d):\n", len(comparison.RemovedCollectors))
+		for _, collector := range comparison.RemovedCollectors {
+			fmt.Fprintf(w, "    - %s (type: %s, ns: %s)\n", collector.Name, collector.Type, collector.Namespace)
+		}
+	}
+	fmt.Fprintf(w, "\n")
+}
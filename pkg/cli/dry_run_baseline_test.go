@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestLoadDryRunBaseline(t *testing.T) {
+	executor := NewDryRunExecutor(nil, nil)
+	baseline := executor.BuildResult([]autodiscovery.CollectorSpec{
+		{Type: "logs", Name: "auto-logs-default", Namespace: "default", Priority: 2},
+	}, autodiscovery.DiscoveryOptions{Namespaces: []string{"default"}, MaxDepth: 3})
+
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatalf("Failed to marshal baseline: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	validPath := filepath.Join(tmpDir, "previous-dryrun.json")
+	if err := os.WriteFile(validPath, data, 0644); err != nil {
+		t.Fatalf("Failed to write baseline: %v", err)
+	}
+	invalidPath := filepath.Join(tmpDir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte("not json"), 0644); err != nil {
+		t.Fatalf("Failed to write invalid baseline: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		expectError bool
+	}{
+		{"valid baseline", validPath, false},
+		{"invalid json", invalidPath, true},
+		{"missing file", filepath.Join(tmpDir, "missing.json"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := LoadDryRunBaseline(tt.path)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(loaded.Collectors) != 1 {
+				t.Errorf("Expected 1 collector, got %d", len(loaded.Collectors))
+			}
+			if loaded.Summary.TotalCollectors != 1 {
+				t.Errorf("Expected total collectors 1, got %d", loaded.Summary.TotalCollectors)
+			}
+		})
+	}
+}
+
+func TestDryRunExecutor_CompareWithBaseline(t *testing.T) {
+	options := autodiscovery.DiscoveryOptions{MaxDepth: 3}
+
+	baselineCollectors := []autodiscovery.CollectorSpec{
+		{Type: "logs", Name: "auto-logs-default", Namespace: "default", Priority: 2},
+		{Type: "cluster-resources", Name: "auto-resources-_v1_pods", Namespace: "default", Priority: 1},
+		{Type: "logs", Name: "auto-logs-legacy", Namespace: "legacy", Priority: 2},
+	}
+
+	tests := []struct {
+		name             string
+		current          []autodiscovery.CollectorSpec
+		expectNew        int
+		expectRemoved    int
+		expectAddedNS    []string
+		expectRemovedNS  []string
+		expectCountDelta int
+		expectSizeDelta  int
+		expectHasChanges bool
+	}{
+		{
+			name:             "no changes",
+			current:          baselineCollectors,
+			expectAddedNS:    []string{},
+			expectRemovedNS:  []string{},
+			expectHasChanges: false,
+		},
+		{
+			name: "namespace replaced",
+			current: []autodiscovery.CollectorSpec{
+				{Type: "logs", Name: "auto-logs-default", Namespace: "default", Priority: 2},
+				{Type: "cluster-resources", Name: "auto-resources-_v1_pods", Namespace: "default", Priority: 1},
+				{Type: "logs", Name: "auto-logs-app", Namespace: "app", Priority: 2},
+				{Type: "cluster-resources", Name: "auto-resources-_v1_pods", Namespace: "app", Priority: 1},
+			},
+			expectNew:        2,
+			expectRemoved:    1,
+			expectAddedNS:    []string{"app"},
+			expectRemovedNS:  []string{"legacy"},
+			expectCountDelta: 1,
+			expectSizeDelta:  7, // one cluster-resources collector (5 + 2)
+			expectHasChanges: true,
+		},
+		{
+			name: "collectors removed",
+			current: []autodiscovery.CollectorSpec{
+				{Type: "logs", Name: "auto-logs-default", Namespace: "default", Priority: 2},
+			},
+			expectRemoved:    2,
+			expectAddedNS:    []string{},
+			expectRemovedNS:  []string{"legacy"},
+			expectCountDelta: -2,
+			expectSizeDelta:  -32, // one logs (5 + 20) and one cluster-resources (5 + 2)
+			expectHasChanges: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewDryRunExecutor(nil, nil)
+			executor.SetBaseline(executor.BuildResult(baselineCollectors, options))
+
+			result := executor.BuildResult(tt.current, options)
+			comparison := result.Comparison
+			if comparison == nil {
+				t.Fatalf("Expected comparison to be populated")
+			}
+
+			if len(comparison.NewCollectors) != tt.expectNew {
+				t.Errorf("Expected %d new collectors, got %d", tt.expectNew, len(comparison.NewCollectors))
+			}
+			if len(comparison.RemovedCollectors) != tt.expectRemoved {
+				t.Errorf("Expected %d removed collectors, got %d", tt.expectRemoved, len(comparison.RemovedCollectors))
+			}
+			if !equalStringSlices(comparison.AddedNamespaces, tt.expectAddedNS) {
+				t.Errorf("Expected added namespaces %v, got %v", tt.expectAddedNS, comparison.AddedNamespaces)
+			}
+			if !equalStringSlices(comparison.RemovedNamespaces, tt.expectRemovedNS) {
+				t.Errorf("Expected removed namespaces %v, got %v", tt.expectRemovedNS, comparison.RemovedNamespaces)
+			}
+			if comparison.CollectorCountDelta != tt.expectCountDelta {
+				t.Errorf("Expected collector count delta %d, got %d", tt.expectCountDelta, comparison.CollectorCountDelta)
+			}
+			if comparison.EstimatedSizeMBDelta != tt.expectSizeDelta {
+				t.Errorf("Expected size delta %d, got %d", tt.expectSizeDelta, comparison.EstimatedSizeMBDelta)
+			}
+			if comparison.HasChanges() != tt.expectHasChanges {
+				t.Errorf("Expected HasChanges %v, got %v", tt.expectHasChanges, comparison.HasChanges())
+			}
+		})
+	}
+}
+
+func TestDryRunExecutor_CompareWithBaseline_Timestamp(t *testing.T) {
+	executor := NewDryRunExecutor(nil, nil)
+	taken := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	comparison := executor.compareWithBaseline(&DryRunResult{Timestamp: taken}, &DryRunResult{})
+	if comparison.BaselineTimestamp != "2024-01-02T03:04:05Z" {
+		t.Errorf("Expected baseline timestamp 2024-01-02T03:04:05Z, got %s", comparison.BaselineTimestamp)
+	}
+
+	comparison = executor.compareWithBaseline(&DryRunResult{}, &DryRunResult{})
+	if comparison.BaselineTimestamp != "" {
+		t.Errorf("Expected empty baseline timestamp for zero time, got %s", comparison.BaselineTimestamp)
+	}
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -342,6 +344,96 @@ func TestDryRunExecutor_PrintResult(t *testing.T) {
 	}
 }
 
+func TestDryRunExecutor_OutputFile(t *testing.T) {
+	result := &DryRunResult{
+		Timestamp: time.Now(),
+		Summary: DryRunSummary{
+			TotalCollectors:  1,
+			CollectorsByType: map[string]int{"logs": 1},
+		},
+		Collectors: []autodiscovery.CollectorSpec{
+			{Type: "logs", Name: "test-logs", Namespace: "default", Priority: 2},
+		},
+		EstimatedSize: "Small (< 50MB)",
+	}
+
+	tests := []struct {
+		format   string
+		contains string
+	}{
+		{"json", `"name": "test-logs"`},
+		{"yaml", "- name: test-logs"},
+		{"console", "AUTO-DISCOVERY DRY RUN RESULTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dryrun."+tt.format)
+
+			executor := NewDryRunExecutor(nil, nil)
+			executor.SetQuietMode(true)
+			executor.SetOutputFile(path)
+			if err := executor.SetOutputFormat(tt.format); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := executor.PrintResult(result); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Expected output file to be written: %v", err)
+			}
+			if !strings.Contains(string(data), tt.contains) {
+				t.Errorf("Expected output to contain %q, got %s", tt.contains, data)
+			}
+
+			rendered, err := executor.RenderResult(result)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(rendered) != string(data) {
+				t.Errorf("Expected file contents to match rendered result")
+			}
+		})
+	}
+}
+
+func TestDryRunExecutor_OutputFileJSONRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dryrun.json")
+
+	executor := NewDryRunExecutor(nil, nil)
+	executor.SetQuietMode(true)
+	executor.SetOutputFormat("json")
+	executor.SetOutputFile(path)
+
+	result := executor.BuildResult([]autodiscovery.CollectorSpec{
+		{Type: "logs", Name: "app-logs", Namespace: "app", Priority: 2},
+	}, autodiscovery.DiscoveryOptions{Namespaces: []string{"app"}})
+	if err := executor.PrintResult(result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The written file can be used as a baseline for the next run
+	baseline, err := LoadDryRunBaseline(path)
+	if err != nil {
+		t.Fatalf("Failed to load written result as baseline: %v", err)
+	}
+	if len(baseline.Collectors) != 1 || baseline.Collectors[0].Name != "app-logs" {
+		t.Errorf("Expected 1 collector app-logs in baseline, got %v", baseline.Collectors)
+	}
+}
+
+func TestDryRunExecutor_OutputFileError(t *testing.T) {
+	executor := NewDryRunExecutor(nil, nil)
+	executor.SetQuietMode(true)
+	executor.SetOutputFile(filepath.Join(t.TempDir(), "missing", "dryrun.json"))
+
+	if err := executor.PrintResult(&DryRunResult{}); err == nil {
+		t.Errorf("Expected error writing to a missing directory")
+	}
+}
+
 func TestDryRunExecutor_GenerateRecommendations(t *testing.T) {
 	executor := NewDryRunExecutor(nil, nil)
 
@@ -638,3 +730,125 @@ func BenchmarkDryRunExecutor_GenerateSummary(b *testing.B) {
 		}
 	}
 }
+
+func TestDryRunExecutor_ThrottleOutput(t *testing.T) {
+	executor := NewDryRunExecutor(nil, nil)
+	executor.SetQuietMode(true)
+
+	tests := []struct {
+		name            string
+		stats           *autodiscovery.ThrottleStats
+		expectWarning   bool
+		expectedConsole []string
+	}{
+		{
+			name:  "no stats",
+			stats: nil,
+		},
+		{
+			name:            "not throttled",
+			stats:           &autodiscovery.ThrottleStats{Requests: 40, EffectiveRate: 4.8, MaxQPS: 5, MinQPS: 5, QPS: 5},
+			expectedConsole: []string{"API Requests: 40 (4.8/s, not throttled)"},
+		},
+		{
+			name: "throttled",
+			stats: &autodiscovery.ThrottleStats{
+				Throttled: true, Requests: 40, ServerThrottled: 3, ClientThrottled: 2,
+				ClientWait: 1500 * time.Millisecond, EffectiveRate: 2.1, MaxQPS: 5, MinQPS: 1.25, QPS: 2.25,
+			},
+			expectWarning:   true,
+			expectedConsole: []string{"API Requests: 40 (2.1/s, throttled)", "429 Responses: 3", "Rate Limit: 2.2/s (min 1.2/s, max 5.0/s)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning := throttleWarning(tt.stats)
+			if tt.expectWarning && !strings.Contains(warning, "throttled") {
+				t.Errorf("Expected throttle warning, got %q", warning)
+			}
+			if !tt.expectWarning && warning != "" {
+				t.Errorf("Expected no throttle warning, got %q", warning)
+			}
+
+			result := executor.BuildResult(nil, autodiscovery.DiscoveryOptions{})
+			result.Throttling = tt.stats
+			data, err := executor.RenderResult(result)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, expected := range tt.expectedConsole {
+				if !strings.Contains(string(data), expected) {
+					t.Errorf("Console output should contain %q", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDependencyWarnings(t *testing.T) {
+	cycle := autodiscovery.DependencyCycle{Chain: []string{"pods/default/web", "services/default/web", "pods/default/web"}}
+	manyCycles := make([]autodiscovery.DependencyCycle, maxDependencyCycleWarnings+3)
+	for i := range manyCycles {
+		manyCycles[i] = cycle
+	}
+
+	tests := []struct {
+		name     string
+		report   *autodiscovery.DependencyReport
+		expected []string
+	}{
+		{name: "no report"},
+		{name: "no cycles", report: &autodiscovery.DependencyReport{Added: 3, Limit: 10}},
+		{
+			name:     "cycle",
+			report:   &autodiscovery.DependencyReport{Cycles: []autodiscovery.DependencyCycle{cycle}},
+			expected: []string{"Dependency cycle: pods/default/web -> services/default/web -> pods/default/web (each resource is collected once)"},
+		},
+		{
+			name:     "truncated",
+			report:   &autodiscovery.DependencyReport{Added: 10, Limit: 10, Truncated: true},
+			expected: []string{"Dependency resolution stopped at 10 added resources, remaining dependencies will not be collected"},
+		},
+		{
+			name:   "many cycles",
+			report: &autodiscovery.DependencyReport{Cycles: manyCycles},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := dependencyWarnings(tt.report)
+			if tt.name == "many cycles" {
+				if len(warnings) != maxDependencyCycleWarnings+1 || !strings.HasPrefix(warnings[maxDependencyCycleWarnings], "3 more dependency cycles") {
+					t.Errorf("Expected %d cycles and a summary, got %v", maxDependencyCycleWarnings, warnings)
+				}
+				return
+			}
+			if len(warnings) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, warnings)
+			}
+			for i := range warnings {
+				if warnings[i] != tt.expected[i] {
+					t.Errorf("Expected %q, got %q", tt.expected[i], warnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCollectorLimitWarning(t *testing.T) {
+	if warning := collectorLimitWarning(nil); warning != "" {
+		t.Errorf("Expected no warning without a report, got %q", warning)
+	}
+
+	report := &autodiscovery.CollectorLimitReport{
+		Limit:   2,
+		Total:   3,
+		Dropped: []autodiscovery.DroppedCollector{{Name: "auto-logs-web", Type: "logs", Priority: 1}},
+	}
+	expected := "Collector limit of 2 reached: kept 2 of 3 collectors, dropped 1, the lowest priority ones are listed under collectorLimit in the JSON output"
+	if warning := collectorLimitWarning(report); warning != expected {
+		t.Errorf("Expected %q, got %q", expected, warning)
+	}
+}
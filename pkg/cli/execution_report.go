@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ExecutionReportFileName is the per-collector execution report written at the bundle root
+const ExecutionReportFileName = "execution-report.json"
+
+// executionTableRows caps the console table, failed collectors are always listed
+const executionTableRows = 10
+
+// Collector execution statuses
+const (
+	ExecutionStatusSucceeded = "succeeded"
+	ExecutionStatusFailed    = "failed"
+	ExecutionStatusResumed   = "resumed" // Completed by the run that was resumed, not rerun
+)
+
+// CollectorExecution records how one collector ran
+type CollectorExecution struct {
+	Collector  string        `json:"collector"`
+	Type       string        `json:"type"`
+	Namespace  string        `json:"namespace,omitempty"`
+	Status     string        `json:"status"`
+	Duration   time.Duration `json:"duration"`             // Including retries, trimming and redaction
+	Bytes      int64         `json:"bytes"`                // Size of the outputs left in the bundle
+	Retries    int           `json:"retries,omitempty"`    // Attempts after the first
+	ErrorClass string        `json:"errorClass,omitempty"` // See collectorErrorClass
+	Error      string        `json:"error,omitempty"`
+}
+
+// ExecutionSummary totals the collector executions of a run
+type ExecutionSummary struct {
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Resumed   int           `json:"resumed,omitempty"`
+	Retried   int           `json:"retried,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Bytes     int64         `json:"bytes"`
+}
+
+// ExecutionReport records the status, duration, bytes written and retries of every collector of a run
+type ExecutionReport struct {
+	mu         sync.Mutex
+	collectors []CollectorExecution
+}
+
+// executionReportFile is the JSON layout of execution-report.json
+type executionReportFile struct {
+	Summary    ExecutionSummary     `json:"summary"`
+	Collectors []CollectorExecution `json:"collectors"`
+}
+
+// NewExecutionReport creates an empty execution report
+func NewExecutionReport() *ExecutionReport {
+	return &ExecutionReport{}
+}
+
+// Record adds the execution of a collector; err is nil when it succeeded
+func (r *ExecutionReport) Record(collector autodiscovery.CollectorSpec, duration time.Duration, bytes int64, attempts int, err error) {
+	execution := CollectorExecution{
+		Collector: collector.Name,
+		Type:      collector.Type,
+		Namespace: collector.Namespace,
+		Status:    ExecutionStatusSucceeded,
+		Duration:  duration,
+		Bytes:     bytes,
+	}
+	if attempts > 1 {
+		execution.Retries = attempts - 1
+	}
+	if err != nil {
+		execution.Status = ExecutionStatusFailed
+		execution.ErrorClass = collectorErrorClass(err)
+		execution.Error = err.Error()
+	}
+	r.add(execution)
+}
+
+// RecordResumed adds a collector completed by the run that was resumed
+func (r *ExecutionReport) RecordResumed(collector autodiscovery.CollectorSpec) {
+	r.add(CollectorExecution{
+		Collector: collector.Name,
+		Type:      collector.Type,
+		Namespace: collector.Namespace,
+		Status:    ExecutionStatusResumed,
+	})
+}
+
+func (r *ExecutionReport) add(execution CollectorExecution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, execution)
+}
+
+// Collectors returns the recorded executions in the order the collectors ran
+func (r *ExecutionReport) Collectors() []CollectorExecution {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CollectorExecution(nil), r.collectors...)
+}
+
+// Summary totals the recorded executions
+func (r *ExecutionReport) Summary() ExecutionSummary {
+	var summary ExecutionSummary
+	for _, execution := range r.Collectors() {
+		summary.Total++
+		switch execution.Status {
+		case ExecutionStatusSucceeded:
+			summary.Succeeded++
+		case ExecutionStatusFailed:
+			summary.Failed++
+		case ExecutionStatusResumed:
+			summary.Resumed++
+		}
+		if execution.Retries > 0 {
+			summary.Retried++
+		}
+		summary.Duration += execution.Duration
+		summary.Bytes += execution.Bytes
+	}
+	return summary
+}
+
+// WriteFile writes the report to execution-report.json under outputDir, returning its path
+func (r *ExecutionReport) WriteFile(outputDir string) (string, error) {
+	path := filepath.Join(outputDir, ExecutionReportFileName)
+	report := executionReportFile{Summary: r.Summary(), Collectors: r.Collectors()}
+	if report.Collectors == nil {
+		report.Collectors = []CollectorExecution{}
+	}
+	if err := writeJSONFile(path, report); err != nil {
+		return "", fmt.Errorf("failed to write execution report: %w", err)
+	}
+	return path, nil
+}
+
+// collectorErrorClass groups collector errors for the report: the retry classes, then forbidden, not-found,
+// canceled and other
+func collectorErrorClass(err error) string {
+	if class := autodiscovery.ClassifyRetryableError(err); class != "" {
+		return class
+	}
+	switch {
+	case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+		return "forbidden"
+	case apierrors.IsNotFound(err) || errors.Is(err, os.ErrNotExist):
+		return "not-found"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "other"
+}
+
+// outputsSize returns the total size of the collector outputs, relative to outputDir
+func outputsSize(outputDir string, outputs []string) int64 {
+	var size int64
+	for _, output := range outputs {
+		size += bundleSize(filepath.Join(outputDir, output))
+	}
+	return size
+}
+
+// printExecutionReport prints the execution totals and a table of the failed and slowest collectors
+func printExecutionReport(report *ExecutionReport) {
+	writeExecutionReport(os.Stdout, report)
+}
+
+func writeExecutionReport(w io.Writer, report *ExecutionReport) {
+	summary := report.Summary()
+	if summary.Total == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "⏱️  Collectors: %d succeeded, %d failed", summary.Succeeded, summary.Failed)
+	if summary.Resumed > 0 {
+		fmt.Fprintf(w, ", %d resumed", summary.Resumed)
+	}
+	if summary.Retried > 0 {
+		fmt.Fprintf(w, ", %d retried", summary.Retried)
+	}
+	fmt.Fprintf(w, " in %s, %s written\n", summary.Duration.Round(time.Millisecond), formatByteSize(summary.Bytes))
+
+	rows := executionTableSelection(report.Collectors())
+	if len(rows) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "   COLLECTOR\tSTATUS\tDURATION\tBYTES\tRETRIES\tERROR")
+	for _, execution := range rows {
+		fmt.Fprintf(tw, "   %s\t%s\t%s\t%s\t%d\t%s\n", execution.Collector, execution.Status,
+			execution.Duration.Round(time.Millisecond), formatByteSize(execution.Bytes), execution.Retries, execution.ErrorClass)
+	}
+	tw.Flush()
+	if hidden := summary.Total - summary.Resumed - len(rows); hidden > 0 {
+		fmt.Fprintf(w, "   ... %d more in %s\n", hidden, ExecutionReportFileName)
+	}
+}
+
+// executionTableSelection returns every failed collector, then the slowest others up to executionTableRows
+func executionTableSelection(executions []CollectorExecution) []CollectorExecution {
+	var failed, others []CollectorExecution
+	for _, execution := range executions {
+		switch execution.Status {
+		case ExecutionStatusFailed:
+			failed = append(failed, execution)
+		case ExecutionStatusSucceeded:
+			others = append(others, execution)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].Duration > others[j].Duration
+	})
+	if room := executionTableRows - len(failed); room < len(others) {
+		if room < 0 {
+			room = 0
+		}
+		others = others[:room]
+	}
+	return append(failed, others...)
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRunCollectors_ExecutionReport(t *testing.T) {
+	retrier, err := autodiscovery.NewCollectorRetrier(autodiscovery.RetryConfig{
+		CollectorTypes: map[string]autodiscovery.RetryPolicy{
+			autodiscovery.CollectorTypeExec: {Attempts: 3, Backoff: time.Millisecond},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	attempts := make(map[string]int)
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		retrier: retrier,
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			attempts[collector.Name]++
+			switch {
+			case collector.Name == "secrets-app":
+				return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("denied"))
+			case collector.Name == "exec-db" && attempts[collector.Name] == 1:
+				return nil, errors.New("container not found (\"db\")")
+			}
+			return writeCollectorSpec(ctx, collector, outputDir)
+		},
+	}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeExec, Name: "exec-db", Namespace: "app"},
+		{Type: "secret", Name: "secrets-app", Namespace: "app"},
+		{Type: autodiscovery.CollectorTypeLogs, Name: "logs-db", Namespace: "app"},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	executions := sbc.execution.Collectors()
+	if len(executions) != 3 {
+		t.Fatalf("Expected 3 executions, got %+v", executions)
+	}
+	if exec := executions[0]; exec.Status != ExecutionStatusSucceeded || exec.Retries != 1 || exec.Bytes == 0 {
+		t.Errorf("Expected exec-db to succeed after 1 retry with bytes written, got %+v", exec)
+	}
+	if secrets := executions[1]; secrets.Status != ExecutionStatusFailed || secrets.ErrorClass != "forbidden" || secrets.Bytes != 0 {
+		t.Errorf("Expected secrets-app to fail as forbidden, got %+v", secrets)
+	}
+	summary := sbc.execution.Summary()
+	if summary.Succeeded != 2 || summary.Failed != 1 || summary.Retried != 1 {
+		t.Errorf("Expected 2 succeeded, 1 failed and 1 retried, got %+v", summary)
+	}
+
+	path, err := sbc.execution.WriteFile(outputDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var written executionReportFile
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if written.Summary.Total != 3 || len(written.Collectors) != 3 || filepath.Base(path) != ExecutionReportFileName {
+		t.Errorf("Expected 3 collectors in %s, got %+v", ExecutionReportFileName, written)
+	}
+}
+
+func TestCollectorErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: context.DeadlineExceeded, want: autodiscovery.RetryClassTimeout},
+		{err: apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied")), want: "forbidden"},
+		{err: apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "web"), want: "not-found"},
+		{err: fmt.Errorf("failed to write: %w", os.ErrNotExist), want: "not-found"},
+		{err: context.Canceled, want: "canceled"},
+		{err: errors.New("invalid spec"), want: "other"},
+	}
+
+	for _, tt := range tests {
+		if got := collectorErrorClass(tt.err); got != tt.want {
+			t.Errorf("Expected %q for %v, got %q", tt.want, tt.err, got)
+		}
+	}
+}
+
+func TestWriteExecutionReport(t *testing.T) {
+	report := NewExecutionReport()
+	report.RecordResumed(autodiscovery.CollectorSpec{Name: "logs-web"})
+	report.Record(autodiscovery.CollectorSpec{Name: "secrets-app"}, time.Millisecond, 0, 1, errors.New("invalid spec"))
+	for i := 0; i < executionTableRows+2; i++ {
+		report.Record(autodiscovery.CollectorSpec{Name: fmt.Sprintf("pods-%d", i)}, time.Duration(i)*time.Second, 2048, 1, nil)
+	}
+
+	var out bytes.Buffer
+	writeExecutionReport(&out, report)
+	output := out.String()
+
+	if !strings.Contains(output, "12 succeeded, 1 failed, 1 resumed") || !strings.Contains(output, "24.0 KiB written") {
+		t.Errorf("Expected the totals, got:\n%s", output)
+	}
+	if !strings.Contains(output, "secrets-app") || !strings.Contains(output, "pods-11") {
+		t.Errorf("Expected the failed and the slowest collectors, got:\n%s", output)
+	}
+	if strings.Contains(output, "pods-0 ") || strings.Contains(output, "logs-web") {
+		t.Errorf("Expected the fastest and resumed collectors to be left out, got:\n%s", output)
+	}
+	if !strings.Contains(output, "... 3 more in execution-report.json") {
+		t.Errorf("Expected the hidden collectors to be counted, got:\n%s", output)
+	}
+
+	out.Reset()
+	writeExecutionReport(&out, NewExecutionReport())
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing for an empty report, got:\n%s", out.String())
+	}
+}
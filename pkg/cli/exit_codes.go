@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// Exit codes of support-bundle collection, so scripts can tell a bad spec from missing permissions
+const (
+	ExitCodeOK                = 0
+	ExitCodeError             = 1 // Any error not listed below
+	ExitCodeSpecInvalid       = 2
+	ExitCodeRBACForbidden     = 3
+	ExitCodeNamespaceNotFound = 4
+	ExitCodeK8sThrottled      = 5
+	ExitCodeCollectionLocked  = 6
+)
+
+// exitCodes maps the autodiscovery errors and ErrCollectionLocked to exit codes, checked in order
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{autodiscovery.ErrSpecInvalid, ExitCodeSpecInvalid},
+	{autodiscovery.ErrRBACForbidden, ExitCodeRBACForbidden},
+	{autodiscovery.ErrNamespaceNotFound, ExitCodeNamespaceNotFound},
+	{autodiscovery.ErrK8sThrottled, ExitCodeK8sThrottled},
+	{ErrCollectionLocked, ExitCodeCollectionLocked},
+}
+
+// ExitCode returns the process exit code for the error returned by a collection
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	for _, mapping := range exitCodes {
+		if errors.Is(err, mapping.err) {
+			return mapping.code
+		}
+	}
+	return ExitCodeError
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "success", err: nil, expected: ExitCodeOK},
+		{name: "generic", err: errors.New("boom"), expected: ExitCodeError},
+		{name: "spec", err: fmt.Errorf("failed to load config file: %w", autodiscovery.ErrSpecInvalid), expected: ExitCodeSpecInvalid},
+		{name: "forbidden", err: fmt.Errorf("auto-discovery failed: %w", autodiscovery.ErrRBACForbidden), expected: ExitCodeRBACForbidden},
+		{name: "namespace", err: fmt.Errorf("%w: typo", autodiscovery.ErrNamespaceNotFound), expected: ExitCodeNamespaceNotFound},
+		{name: "throttled", err: fmt.Errorf("dry run discovery failed: %w", autodiscovery.ErrK8sThrottled), expected: ExitCodeK8sThrottled},
+		{name: "locked", err: fmt.Errorf("%w: cluster prod is already being collected", ErrCollectionLocked), expected: ExitCodeCollectionLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(tt.err); code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestSupportBundleSpecLoader_LoadFromFileSpecInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte("{not: [valid"), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	_, err := NewSupportBundleSpecLoader().LoadFromFile(path)
+	if !errors.Is(err, autodiscovery.ErrSpecInvalid) {
+		t.Errorf("Expected ErrSpecInvalid, got %v", err)
+	}
+}
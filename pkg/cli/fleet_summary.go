@@ -0,0 +1,471 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+// RunSummaryFileName is the per-run summary written at the bundle root, the input of merge-summaries
+const RunSummaryFileName = "run-summary.json"
+
+// RunSummaryFormatVersion is the run summary format this release writes; merge-summaries rejects other versions
+const RunSummaryFormatVersion = "1"
+
+// fleetTableRows caps the failing namespaces and skewed images printed to the console
+const fleetTableRows = 10
+
+// RunSummary is the aggregate summary of one collection run, small enough to ship from every cluster of a fleet
+type RunSummary struct {
+	FormatVersion     string                `json:"formatVersion"` // See RunSummaryFormatVersion
+	Cluster           string                `json:"cluster"`
+	Context           string                `json:"context,omitempty"`
+	KubernetesVersion string                `json:"kubernetesVersion,omitempty"`
+	CollectedAt       time.Time             `json:"collectedAt"`
+	Duration          time.Duration         `json:"duration"`
+	Collectors        ExecutionSummary      `json:"collectors"`
+	Analysis          *AnalysisSummary      `json:"analysis,omitempty"`
+	Namespaces        []RunNamespaceSummary `json:"namespaces"`
+	Images            []string              `json:"images,omitempty"` // Normalized references of the images the cluster runs
+	Errors            int                   `json:"errors"`
+}
+
+// RunNamespaceSummary counts the collectors and analyzer results of one namespace
+type RunNamespaceSummary struct {
+	Namespace        string `json:"namespace"`
+	Collectors       int    `json:"collectors"`
+	FailedCollectors int    `json:"failedCollectors,omitempty"`
+	FailedAnalyzers  int    `json:"failedAnalyzers,omitempty"`
+	WarnAnalyzers    int    `json:"warnAnalyzers,omitempty"`
+}
+
+// Failing reports whether a collector or an analyzer failed in the namespace
+func (n RunNamespaceSummary) Failing() bool {
+	return n.FailedCollectors > 0 || n.FailedAnalyzers > 0
+}
+
+// NewRunSummary creates an empty run summary for a cluster
+func NewRunSummary(cluster string, collectedAt time.Time) *RunSummary {
+	return &RunSummary{FormatVersion: RunSummaryFormatVersion, Cluster: cluster, CollectedAt: collectedAt}
+}
+
+// RecordExecution records the collector totals and the collectors run per namespace
+func (s *RunSummary) RecordExecution(report *ExecutionReport) {
+	s.Collectors = report.Summary()
+	for _, execution := range report.Collectors() {
+		if execution.Namespace == "" {
+			continue
+		}
+		namespace := s.namespace(execution.Namespace)
+		namespace.Collectors++
+		if execution.Status == ExecutionStatusFailed {
+			namespace.FailedCollectors++
+		}
+	}
+}
+
+// RecordAnalysis records the analyzer totals and the failed and warning analyzers per namespace
+func (s *RunSummary) RecordAnalysis(report *AnalysisReport) {
+	if report == nil {
+		return
+	}
+	summary := report.Summary
+	s.Analysis = &summary
+	for _, result := range report.Results {
+		if result.Namespace == "" {
+			continue
+		}
+		switch result.Result {
+		case autodiscovery.AnalyzerResultFail:
+			s.namespace(result.Namespace).FailedAnalyzers++
+		case autodiscovery.AnalyzerResultWarn:
+			s.namespace(result.Namespace).WarnAnalyzers++
+		}
+	}
+}
+
+// RecordImages records the images of the registry catalog, normalized so clusters compare equal
+func (s *RunSummary) RecordImages(catalog *images.RegistryCatalog) {
+	if catalog == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, ref := range s.Images {
+		seen[ref] = true
+	}
+	for _, registry := range catalog.Registries {
+		for _, image := range registry.Images {
+			ref := fleetImageReference(image)
+			if !seen[ref] {
+				seen[ref] = true
+				s.Images = append(s.Images, ref)
+			}
+		}
+	}
+	sort.Strings(s.Images)
+}
+
+// fleetImageReference normalizes an image reference with Docker Hub written as docker.io, whichever alias it was
+// pulled through, so "nginx:1.25" and "docker.io/library/nginx:1.25" are the same image
+func fleetImageReference(image string) string {
+	ref, err := images.NormalizeImageReference(image)
+	if err != nil {
+		return image
+	}
+	for _, alias := range []string{"index.docker.io/", "registry-1.docker.io/"} {
+		if strings.HasPrefix(ref, alias) {
+			return "docker.io/" + strings.TrimPrefix(ref, alias)
+		}
+	}
+	return ref
+}
+
+// namespace returns the summary of a namespace, adding it in name order when missing
+func (s *RunSummary) namespace(name string) *RunNamespaceSummary {
+	i := sort.Search(len(s.Namespaces), func(i int) bool {
+		return s.Namespaces[i].Namespace >= name
+	})
+	if i == len(s.Namespaces) || s.Namespaces[i].Namespace != name {
+		s.Namespaces = append(s.Namespaces, RunNamespaceSummary{})
+		copy(s.Namespaces[i+1:], s.Namespaces[i:])
+		s.Namespaces[i] = RunNamespaceSummary{Namespace: name}
+	}
+	return &s.Namespaces[i]
+}
+
+// FailingNamespaces returns the names of the failing namespaces
+func (s *RunSummary) FailingNamespaces() []string {
+	var failing []string
+	for _, namespace := range s.Namespaces {
+		if namespace.Failing() {
+			failing = append(failing, namespace.Namespace)
+		}
+	}
+	return failing
+}
+
+// WriteFile writes the summary to run-summary.json under outputDir, returning its path
+func (s *RunSummary) WriteFile(outputDir string) (string, error) {
+	if s.Namespaces == nil {
+		s.Namespaces = []RunNamespaceSummary{}
+	}
+	path := filepath.Join(outputDir, RunSummaryFileName)
+	if err := writeJSONFile(path, s); err != nil {
+		return "", fmt.Errorf("failed to write run summary: %w", err)
+	}
+	return path, nil
+}
+
+// LoadRunSummary reads a run summary file, or the run summary of a bundle directory or archive
+func LoadRunSummary(path string) (*RunSummary, error) {
+	var data []byte
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run summary: %w", err)
+	}
+	if !info.IsDir() && filepath.Ext(path) == ".json" {
+		data, err = os.ReadFile(path)
+	} else {
+		var bundle *BundleReader
+		if bundle, err = OpenBundle(path); err == nil {
+			data, err = bundle.ReadFile(RunSummaryFileName)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run summary of %s: %w", path, err)
+	}
+
+	var summary RunSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse run summary of %s: %w", path, err)
+	}
+	if summary.FormatVersion != RunSummaryFormatVersion {
+		return nil, fmt.Errorf("run summary of %s has format version %q, this release reads %q", path, summary.FormatVersion, RunSummaryFormatVersion)
+	}
+	if summary.Cluster == "" {
+		return nil, fmt.Errorf("run summary of %s does not name its cluster", path)
+	}
+	return &summary, nil
+}
+
+// buildRunSummary summarizes a finished collection run for the fleet report
+func (sbc *SupportBundleCollector) buildRunSummary(cliOptions SupportBundleCollectOptions, opts autodiscovery.DiscoveryOptions, startTime time.Time, result *CollectionResult, catalog *images.RegistryCatalog) *RunSummary {
+	vars := sbc.outputPathVars(cliOptions, opts, startTime)
+	cluster := vars.ClusterName
+	if cluster == "" {
+		cluster = "unknown"
+	}
+
+	summary := NewRunSummary(cluster, startTime)
+	summary.Context = vars.Context
+	summary.KubernetesVersion = vars.KubernetesVersion
+	summary.Duration = time.Since(startTime)
+	summary.RecordExecution(sbc.execution)
+	summary.RecordAnalysis(result.Analysis)
+	summary.RecordImages(catalog)
+	summary.Errors = len(result.Errors)
+	return summary
+}
+
+// FleetReport combines the latest run summary of many clusters
+type FleetReport struct {
+	GeneratedAt       time.Time          `json:"generatedAt"`
+	Summary           FleetSummary       `json:"summary"`
+	Clusters          []FleetCluster     `json:"clusters"`
+	FailingNamespaces []FleetNamespace   `json:"failingNamespaces"` // Most clusters first
+	ImageSkew         []ImageVersionSkew `json:"imageSkew"`         // Repositories running more than one version across the fleet
+	Warnings          []string           `json:"warnings,omitempty"`
+}
+
+// FleetSummary provides totals for the fleet report
+type FleetSummary struct {
+	Clusters          int `json:"clusters"`
+	FailingClusters   int `json:"failingClusters"` // Clusters with at least one failing namespace
+	FailingNamespaces int `json:"failingNamespaces"`
+	SkewedImages      int `json:"skewedImages"`
+}
+
+// FleetCluster is the run of one cluster that was merged
+type FleetCluster struct {
+	Cluster           string    `json:"cluster"`
+	KubernetesVersion string    `json:"kubernetesVersion,omitempty"`
+	CollectedAt       time.Time `json:"collectedAt"`
+	Collectors        int       `json:"collectors"`
+	FailedCollectors  int       `json:"failedCollectors"`
+	FailingNamespaces []string  `json:"failingNamespaces,omitempty"`
+	Errors            int       `json:"errors"`
+}
+
+// FleetNamespace is a namespace failing in one or more clusters
+type FleetNamespace struct {
+	Namespace string   `json:"namespace"`
+	Clusters  []string `json:"clusters"`
+}
+
+// ImageVersionSkew lists the versions of one image repository and the clusters running each
+type ImageVersionSkew struct {
+	Repository string         `json:"repository"`
+	Versions   []ImageVersion `json:"versions"`
+}
+
+// ImageVersion is a tag or digest of a repository and the clusters running it
+type ImageVersion struct {
+	Version  string   `json:"version"`
+	Clusters []string `json:"clusters"`
+}
+
+// MergeRunSummaries combines run summaries into a fleet report. When a cluster has several runs the latest is kept
+func MergeRunSummaries(summaries []*RunSummary) *FleetReport {
+	report := &FleetReport{
+		GeneratedAt:       time.Now(),
+		Clusters:          []FleetCluster{},
+		FailingNamespaces: []FleetNamespace{},
+		ImageSkew:         []ImageVersionSkew{},
+	}
+
+	latest := make(map[string]*RunSummary)
+	for _, summary := range summaries {
+		if previous, ok := latest[summary.Cluster]; ok {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("cluster %s has several runs, keeping the one collected at %s",
+				summary.Cluster, laterRun(previous, summary).CollectedAt.Format(time.RFC3339)))
+			summary = laterRun(previous, summary)
+		}
+		latest[summary.Cluster] = summary
+	}
+
+	failingNamespaces := make(map[string][]string)
+	versions := make(map[string]map[string][]string) // repository -> version -> clusters
+	for _, cluster := range sortedRunClusters(latest) {
+		summary := latest[cluster]
+		failing := summary.FailingNamespaces()
+		report.Clusters = append(report.Clusters, FleetCluster{
+			Cluster:           cluster,
+			KubernetesVersion: summary.KubernetesVersion,
+			CollectedAt:       summary.CollectedAt,
+			Collectors:        summary.Collectors.Total,
+			FailedCollectors:  summary.Collectors.Failed,
+			FailingNamespaces: failing,
+			Errors:            summary.Errors,
+		})
+		if len(failing) > 0 {
+			report.Summary.FailingClusters++
+		}
+		for _, namespace := range failing {
+			failingNamespaces[namespace] = append(failingNamespaces[namespace], cluster)
+		}
+		for _, ref := range summary.Images {
+			repository, version := splitImageVersion(ref)
+			if versions[repository] == nil {
+				versions[repository] = make(map[string][]string)
+			}
+			clusters := versions[repository][version]
+			if len(clusters) == 0 || clusters[len(clusters)-1] != cluster {
+				versions[repository][version] = append(clusters, cluster)
+			}
+		}
+	}
+
+	for namespace, clusters := range failingNamespaces {
+		report.FailingNamespaces = append(report.FailingNamespaces, FleetNamespace{Namespace: namespace, Clusters: clusters})
+	}
+	sort.Slice(report.FailingNamespaces, func(i, j int) bool {
+		a, b := report.FailingNamespaces[i], report.FailingNamespaces[j]
+		if len(a.Clusters) != len(b.Clusters) {
+			return len(a.Clusters) > len(b.Clusters)
+		}
+		return a.Namespace < b.Namespace
+	})
+
+	for repository, byVersion := range versions {
+		if len(byVersion) < 2 {
+			continue
+		}
+		skew := ImageVersionSkew{Repository: repository}
+		for version, clusters := range byVersion {
+			skew.Versions = append(skew.Versions, ImageVersion{Version: version, Clusters: clusters})
+		}
+		sort.Slice(skew.Versions, func(i, j int) bool {
+			return skew.Versions[i].Version < skew.Versions[j].Version
+		})
+		report.ImageSkew = append(report.ImageSkew, skew)
+	}
+	sort.Slice(report.ImageSkew, func(i, j int) bool {
+		a, b := report.ImageSkew[i], report.ImageSkew[j]
+		if len(a.Versions) != len(b.Versions) {
+			return len(a.Versions) > len(b.Versions)
+		}
+		return a.Repository < b.Repository
+	})
+
+	report.Summary.Clusters = len(report.Clusters)
+	report.Summary.FailingNamespaces = len(report.FailingNamespaces)
+	report.Summary.SkewedImages = len(report.ImageSkew)
+	return report
+}
+
+func laterRun(a, b *RunSummary) *RunSummary {
+	if b.CollectedAt.After(a.CollectedAt) {
+		return b
+	}
+	return a
+}
+
+func sortedRunClusters(summaries map[string]*RunSummary) []string {
+	clusters := make([]string, 0, len(summaries))
+	for cluster := range summaries {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// splitImageVersion splits a normalized image reference into its repository and its digest or tag
+func splitImageVersion(ref string) (string, string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, "latest"
+}
+
+// MergeSummariesOptions configures `support-bundle merge-summaries`
+type MergeSummariesOptions struct {
+	Paths      []string `json:"paths"`                // run-summary.json files, bundle directories or archives
+	OutputFile string   `json:"outputFile,omitempty"` // Fleet report path, defaults to fleet-report.json
+	Output     string   `json:"output,omitempty"`     // "console" or "json"
+}
+
+// DefaultFleetReportPath is where merge-summaries writes the fleet report without --output-file
+const DefaultFleetReportPath = "fleet-report.json"
+
+// RunMergeSummaries implements `support-bundle merge-summaries <summary|bundle>... --output-file fleet-report.json`
+func RunMergeSummaries(options MergeSummariesOptions) (*FleetReport, error) {
+	if len(options.Paths) == 0 {
+		return nil, fmt.Errorf("merge-summaries requires at least one run summary or bundle")
+	}
+
+	summaries := make([]*RunSummary, 0, len(options.Paths))
+	var loadErrors []error
+	for _, path := range options.Paths {
+		summary, err := LoadRunSummary(path)
+		if err != nil {
+			loadErrors = append(loadErrors, err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(summaries) == 0 {
+		return nil, errors.Join(loadErrors...)
+	}
+
+	report := MergeRunSummaries(summaries)
+	for _, err := range loadErrors {
+		report.Warnings = append(report.Warnings, err.Error())
+	}
+
+	outputFile := options.OutputFile
+	if outputFile == "" {
+		outputFile = DefaultFleetReportPath
+	}
+	if err := writeJSONFile(outputFile, report); err != nil {
+		return nil, fmt.Errorf("failed to write fleet report: %w", err)
+	}
+
+	if options.Output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal fleet report: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printFleetReport(report)
+		fmt.Printf("   Report: %s\n", outputFile)
+	}
+	return report, nil
+}
+
+func printFleetReport(report *FleetReport) {
+	for _, warning := range report.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	fmt.Printf("🌐 Fleet: %d clusters, %d with failing namespaces, %d skewed images\n",
+		report.Summary.Clusters, report.Summary.FailingClusters, report.Summary.SkewedImages)
+
+	if len(report.FailingNamespaces) > 0 {
+		fmt.Printf("📋 Failing namespaces:\n")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "   NAMESPACE\tCLUSTERS\t")
+		for i, namespace := range report.FailingNamespaces {
+			if i == fleetTableRows {
+				break
+			}
+			fmt.Fprintf(w, "   %s\t%d\t%s\n", namespace.Namespace, len(namespace.Clusters), strings.Join(namespace.Clusters, ", "))
+		}
+		w.Flush()
+	}
+
+	if len(report.ImageSkew) > 0 {
+		fmt.Printf("🖼️  Image version skew:\n")
+		for i, skew := range report.ImageSkew {
+			if i == fleetTableRows {
+				break
+			}
+			versions := make([]string, 0, len(skew.Versions))
+			for _, version := range skew.Versions {
+				versions = append(versions, fmt.Sprintf("%s (%s)", version.Version, strings.Join(version.Clusters, ", ")))
+			}
+			fmt.Printf("   %s: %s\n", skew.Repository, strings.Join(versions, ", "))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+func TestRunSummary_Record(t *testing.T) {
+	execution := NewExecutionReport()
+	execution.Record(autodiscovery.CollectorSpec{Name: "logs-api", Namespace: "shop"}, time.Second, 10, 1, nil)
+	execution.Record(autodiscovery.CollectorSpec{Name: "secrets-shop", Namespace: "shop"}, time.Second, 0, 1, errors.New("denied"))
+	execution.Record(autodiscovery.CollectorSpec{Name: "logs-billing", Namespace: "billing"}, time.Second, 10, 1, nil)
+	execution.Record(autodiscovery.CollectorSpec{Name: "cluster-info"}, time.Second, 10, 1, nil)
+
+	summary := NewRunSummary("prod-eu", time.Now())
+	summary.RecordExecution(execution)
+	summary.RecordAnalysis(&AnalysisReport{Results: []autodiscovery.AnalyzerResult{
+		{Namespace: "billing", Result: autodiscovery.AnalyzerResultFail},
+		{Namespace: "auth", Result: autodiscovery.AnalyzerResultWarn},
+	}})
+	summary.RecordImages(&images.RegistryCatalog{Registries: []images.RegistryCatalogEntry{
+		{Registry: "docker.io", Images: []string{"nginx:1.25", "docker.io/library/nginx:1.25", "index.docker.io/library/nginx:1.25"}},
+	}})
+
+	if len(summary.Namespaces) != 3 || summary.Namespaces[0].Namespace != "auth" || summary.Namespaces[2].Namespace != "shop" {
+		t.Fatalf("Expected auth, billing and shop in order, got %+v", summary.Namespaces)
+	}
+	if shop := summary.Namespaces[2]; shop.Collectors != 2 || shop.FailedCollectors != 1 {
+		t.Errorf("Expected 2 shop collectors with 1 failure, got %+v", shop)
+	}
+	if failing := strings.Join(summary.FailingNamespaces(), ","); failing != "billing,shop" {
+		t.Errorf("Expected billing and shop to be failing, got %s", failing)
+	}
+	if len(summary.Images) != 1 || summary.Images[0] != "docker.io/library/nginx:1.25" {
+		t.Errorf("Expected nginx to be recorded once, got %v", summary.Images)
+	}
+	if summary.Collectors.Total != 4 || summary.Collectors.Failed != 1 {
+		t.Errorf("Expected the execution totals, got %+v", summary.Collectors)
+	}
+}
+
+func testRunSummary(cluster string, collectedAt time.Time, failing []string, images ...string) *RunSummary {
+	summary := NewRunSummary(cluster, collectedAt)
+	for _, namespace := range failing {
+		summary.namespace(namespace).FailedAnalyzers++
+	}
+	summary.namespace("default").Collectors++
+	summary.Images = images
+	return summary
+}
+
+func TestMergeRunSummaries(t *testing.T) {
+	now := time.Now()
+	report := MergeRunSummaries([]*RunSummary{
+		testRunSummary("prod-eu", now, []string{"shop", "billing"}, "docker.io/library/nginx:1.25", "ghcr.io/acme/api@sha256:aaa"),
+		testRunSummary("prod-us", now, []string{"shop"}, "docker.io/library/nginx:1.24", "ghcr.io/acme/api@sha256:aaa"),
+		testRunSummary("staging", now.Add(-time.Hour), []string{"billing"}, "docker.io/library/nginx:1.25", "registry.local:5000/tools"),
+		testRunSummary("staging", now, nil, "docker.io/library/nginx:1.26"),
+	})
+
+	if report.Summary.Clusters != 3 || report.Summary.FailingClusters != 2 {
+		t.Errorf("Expected 3 clusters, 2 failing, got %+v", report.Summary)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "staging") {
+		t.Errorf("Expected a warning about the duplicate staging run, got %v", report.Warnings)
+	}
+	if len(report.FailingNamespaces) != 2 || report.FailingNamespaces[0].Namespace != "shop" || len(report.FailingNamespaces[0].Clusters) != 2 {
+		t.Errorf("Expected shop to fail in 2 clusters, then billing, got %+v", report.FailingNamespaces)
+	}
+	if len(report.ImageSkew) != 1 {
+		t.Fatalf("Expected only nginx to be skewed, got %+v", report.ImageSkew)
+	}
+	skew := report.ImageSkew[0]
+	if skew.Repository != "docker.io/library/nginx" || len(skew.Versions) != 3 || skew.Versions[0].Version != "1.24" || skew.Versions[2].Clusters[0] != "staging" {
+		t.Errorf("Expected nginx 1.24, 1.25 and 1.26 from the latest staging run, got %+v", skew)
+	}
+}
+
+func TestSplitImageVersion(t *testing.T) {
+	tests := map[string][2]string{
+		"docker.io/library/nginx:1.25":   {"docker.io/library/nginx", "1.25"},
+		"ghcr.io/acme/api@sha256:aaa":    {"ghcr.io/acme/api", "sha256:aaa"},
+		"registry.local:5000/tools":      {"registry.local:5000/tools", "latest"},
+		"registry.local:5000/tools:v1.0": {"registry.local:5000/tools", "v1.0"},
+	}
+	for ref, want := range tests {
+		if repository, version := splitImageVersion(ref); repository != want[0] || version != want[1] {
+			t.Errorf("Expected %v for %s, got %s and %s", want, ref, repository, version)
+		}
+	}
+}
+
+func TestLoadRunSummary(t *testing.T) {
+	bundleDir := t.TempDir()
+	if _, err := testRunSummary("prod-eu", time.Now(), []string{"shop"}).WriteFile(bundleDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, path := range []string{bundleDir, filepath.Join(bundleDir, RunSummaryFileName)} {
+		summary, err := LoadRunSummary(path)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", path, err)
+		}
+		if summary.Cluster != "prod-eu" || len(summary.FailingNamespaces()) != 1 {
+			t.Errorf("Expected the prod-eu summary from %s, got %+v", path, summary)
+		}
+	}
+
+	future := filepath.Join(t.TempDir(), "future.json")
+	os.WriteFile(future, []byte(`{"formatVersion": "2", "cluster": "prod-eu"}`), 0644)
+	if _, err := LoadRunSummary(future); err == nil || !strings.Contains(err.Error(), "format version") {
+		t.Errorf("Expected an unknown format version to be rejected, got %v", err)
+	}
+
+	if _, err := LoadRunSummary(t.TempDir()); err == nil {
+		t.Errorf("Expected a bundle without a run summary to be rejected")
+	}
+}
+
+func TestRunMergeSummaries(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, cluster := range []string{"prod-eu", "prod-us"} {
+		bundleDir := filepath.Join(dir, cluster)
+		if _, err := testRunSummary(cluster, time.Now(), []string{"shop"}).WriteFile(bundleDir); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		paths = append(paths, bundleDir)
+	}
+	paths = append(paths, filepath.Join(dir, "missing"))
+
+	outputFile := filepath.Join(dir, DefaultFleetReportPath)
+	report, err := RunMergeSummaries(MergeSummariesOptions{Paths: paths, OutputFile: outputFile})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Summary.Clusters != 2 || len(report.Warnings) != 1 {
+		t.Errorf("Expected 2 clusters and a warning for the missing bundle, got %+v", report)
+	}
+	if _, err := os.Stat(outputFile); err != nil {
+		t.Errorf("Expected the fleet report to be written: %v", err)
+	}
+
+	if _, err := RunMergeSummaries(MergeSummariesOptions{Paths: []string{filepath.Join(dir, "missing")}, OutputFile: outputFile}); err == nil {
+		t.Errorf("Expected an error when no summary can be read")
+	}
+}
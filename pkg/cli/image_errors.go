@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+// topImageErrorClasses is how many error classes the console summary and the bundle README list
+const topImageErrorClasses = 3
+
+// printImageErrorSummary prints the image error totals and the most frequent error classes
+func printImageErrorSummary(summary *images.ErrorLedgerSummary, classes []images.ErrorClassSummary) {
+	writeImageErrorSummary(os.Stdout, summary, classes)
+}
+
+// writeImageErrorSummary writes the image error totals, then one line per error class
+func writeImageErrorSummary(w io.Writer, summary *images.ErrorLedgerSummary, classes []images.ErrorClassSummary) {
+	if summary == nil {
+		return
+	}
+	fmt.Fprintf(w, "   Image errors: %d on %d images, %d answered with fallback facts\n",
+		summary.TotalErrors, summary.Images, summary.FallbackApplied)
+	for _, class := range classes {
+		fmt.Fprintf(w, "     %s\n", describeImageErrorClass(class))
+	}
+}
+
+// imageErrorReadmeItems describes the most frequent error classes for the bundle README
+func imageErrorReadmeItems(classes []images.ErrorClassSummary) []string {
+	items := make([]string, 0, len(classes))
+	for _, class := range classes {
+		items = append(items, describeImageErrorClass(class)+", e.g. "+class.Example)
+	}
+	return items
+}
+
+// describeImageErrorClass describes a class as e.g. "network (retryable): 4 errors on 3 images from docker.io"
+func describeImageErrorClass(class images.ErrorClassSummary) string {
+	retryable := "not retryable"
+	if class.Retryable {
+		retryable = "retryable"
+	}
+	description := fmt.Sprintf("%s (%s): %d errors on %d images from %s",
+		class.Type, retryable, class.Count, class.Images, strings.Join(class.Registries, ", "))
+	if class.FallbackApplied > 0 {
+		description += fmt.Sprintf(", %d with fallback facts", class.FallbackApplied)
+	}
+	return description
+}
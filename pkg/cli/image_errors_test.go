@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+func TestWriteImageErrorSummary(t *testing.T) {
+	ledger := images.BuildErrorLedger([]images.CollectionError{
+		{ImageRef: "docker.io/library/nginx:1.25", Type: "network", Message: "connection refused", Retryable: true, Fallback: "best-effort"},
+		{ImageRef: "docker.io/library/redis:7", Type: "network", Message: "timeout", Retryable: true},
+		{ImageRef: "ghcr.io/acme/api:v1", Type: "auth", Message: "unauthorized"},
+	})
+
+	var out bytes.Buffer
+	writeImageErrorSummary(&out, &ledger.Summary, ledger.TopClasses(topImageErrorClasses))
+	expected := "   Image errors: 3 on 3 images, 1 answered with fallback facts\n" +
+		"     network (retryable): 2 errors on 2 images from docker.io, 1 with fallback facts\n" +
+		"     auth (not retryable): 1 errors on 1 images from ghcr.io\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	items := imageErrorReadmeItems(ledger.TopClasses(1))
+	if len(items) != 1 || items[0] != "network (retryable): 2 errors on 2 images from docker.io, 1 with fallback facts, e.g. connection refused" {
+		t.Errorf("Expected the most frequent class with an example, got %v", items)
+	}
+
+	out.Reset()
+	writeImageErrorSummary(&out, nil, nil)
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing without image errors, got %q", out.String())
+	}
+}
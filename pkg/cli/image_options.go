@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -52,7 +53,12 @@ func (ich *ImageCollectionHandler) ParseImageOptions(includeImages bool, imageOp
 	}
 
 	// Parse additional image options if provided
-	// Format: "manifests=true,layers=false,cache=true,timeout=60s"
+	// Format: "manifests=true,layers=false,history=true,cache=true,timeout=60s,proxy=http://proxy:3128,ca-bundle=ca.pem,insecure-registry=registry.local"
+	// Per-registry limits: "registry-concurrency=harbor.internal:20,registry-timeout=docker.io:30s"
+	// Registry mirrors, queried in the order given: "mirror=docker.io=mirror.internal:5000,mirror=docker.io=http://cache.local"
+	// Known base image layers: "base-image=sha256:<digest>=acme/golden-base:2024.1"
+	// Labels kept in the facts, added to the default filter: "label-allow=org.opencontainers.*,label-deny=com.acme.*"
+	// Enrichers run on each image's facts, in order: "enricher=vulnerability-labels,enricher=build-info"
 	if imageOpts != "" {
 		return ich.parseImageOptionsString(imageOpts)
 	}
@@ -83,6 +89,8 @@ func (ich *ImageCollectionHandler) parseImageOptionsString(imageOpts string) err
 			ich.options.IncludeLayers = parseBool(value, true)
 		case "config":
 			ich.options.IncludeConfig = parseBool(value, true)
+		case "history":
+			ich.options.IncludeHistory = parseBool(value, false)
 		case "cache":
 			ich.options.CacheEnabled = parseBool(value, true)
 		case "timeout":
@@ -109,6 +117,71 @@ func (ich *ImageCollectionHandler) parseImageOptionsString(imageOpts string) err
 				return fmt.Errorf("retry count must be between 0 and 10")
 			}
 			ich.options.RetryCount = retries
+		case "proxy":
+			ich.transportConfig().Proxy = value
+		case "ca-bundle":
+			ich.transportConfig().CABundle = value
+		case "registry-concurrency":
+			registry, limit, err := splitRegistryValue(key, value)
+			if err != nil {
+				return err
+			}
+			var concurrency int
+			if _, err := fmt.Sscanf(limit, "%d", &concurrency); err != nil {
+				return fmt.Errorf("invalid concurrency for %s: %w", registry, err)
+			}
+			if concurrency < 1 || concurrency > 50 {
+				return fmt.Errorf("concurrency for %s must be between 1 and 50", registry)
+			}
+			limits := ich.options.RegistryLimits[registry]
+			limits.MaxConcurrency = concurrency
+			ich.SetRegistryLimits(registry, limits)
+		case "registry-timeout":
+			registry, limit, err := splitRegistryValue(key, value)
+			if err != nil {
+				return err
+			}
+			timeout, err := time.ParseDuration(limit)
+			if err != nil {
+				return fmt.Errorf("invalid timeout for %s: %w", registry, err)
+			}
+			limits := ich.options.RegistryLimits[registry]
+			limits.Timeout = timeout
+			ich.SetRegistryLimits(registry, limits)
+		case "insecure-registry":
+			if value == "" {
+				return fmt.Errorf("insecure-registry requires a registry host")
+			}
+			tlsConfig := ich.transportConfig().Registries[value]
+			tlsConfig.InsecureSkipVerify = true
+			ich.SetRegistryTLSConfig(value, tlsConfig)
+		case "mirror":
+			registry, endpoint, found := strings.Cut(value, "=")
+			if !found || registry == "" || endpoint == "" {
+				return fmt.Errorf("mirror must be in format registry=endpoint: %s", value)
+			}
+			ich.AddRegistryMirror(registry, endpoint)
+		case "base-image":
+			digest, name, found := strings.Cut(value, "=")
+			if !found || digest == "" || name == "" {
+				return fmt.Errorf("base-image must be in format digest=name: %s", value)
+			}
+			ich.AddBaseImageDigest(digest, name)
+		case "label-allow", "label-deny":
+			if value == "" {
+				return fmt.Errorf("%s requires a label pattern", key)
+			}
+			filter := ich.labelFilter()
+			if key == "label-allow" {
+				filter.Allow = append(filter.Allow, value)
+			} else {
+				filter.Deny = append(filter.Deny, value)
+			}
+		case "enricher":
+			if value == "" {
+				return fmt.Errorf("enricher requires an enricher name")
+			}
+			ich.options.Enrichers = append(ich.options.Enrichers, value)
 		default:
 			return fmt.Errorf("unknown image option: %s", key)
 		}
@@ -122,6 +195,56 @@ func (ich *ImageCollectionHandler) SetRegistryCredentials(registry string, creds
 	ich.registryCredentials[registry] = creds
 }
 
+// SetRegistryTLSConfig configures the CA bundle and TLS verification for one registry host
+func (ich *ImageCollectionHandler) SetRegistryTLSConfig(registry string, tlsConfig images.RegistryTLSConfig) {
+	transport := ich.transportConfig()
+	if transport.Registries == nil {
+		transport.Registries = make(map[string]images.RegistryTLSConfig)
+	}
+	transport.Registries[registry] = tlsConfig
+}
+
+// SetRegistryLimits overrides the concurrency and timeout for one registry host
+func (ich *ImageCollectionHandler) SetRegistryLimits(registry string, limits images.RegistryLimits) {
+	if ich.options.RegistryLimits == nil {
+		ich.options.RegistryLimits = make(map[string]images.RegistryLimits)
+	}
+	ich.options.RegistryLimits[registry] = limits
+}
+
+// AddRegistryMirror appends a mirror endpoint queried before the registry and its earlier mirrors fail
+func (ich *ImageCollectionHandler) AddRegistryMirror(registry, endpoint string) {
+	if ich.options.Mirrors == nil {
+		ich.options.Mirrors = make(map[string][]string)
+	}
+	ich.options.Mirrors[registry] = append(ich.options.Mirrors[registry], endpoint)
+}
+
+// AddBaseImageDigest identifies images with the layer digest as built on the named base image
+func (ich *ImageCollectionHandler) AddBaseImageDigest(digest, name string) {
+	if ich.options.BaseImageDigests == nil {
+		ich.options.BaseImageDigests = make(map[string]string)
+	}
+	ich.options.BaseImageDigests[digest] = name
+}
+
+// labelFilter returns the label filter, starting from images.DefaultLabelFilter on first use
+func (ich *ImageCollectionHandler) labelFilter() *images.LabelFilter {
+	if ich.options.LabelFilter == nil {
+		filter := images.DefaultLabelFilter()
+		ich.options.LabelFilter = &filter
+	}
+	return ich.options.LabelFilter
+}
+
+// transportConfig returns the registry transport config, creating it on first use
+func (ich *ImageCollectionHandler) transportConfig() *images.RegistryTransportConfig {
+	if ich.options.Transport == nil {
+		ich.options.Transport = &images.RegistryTransportConfig{}
+	}
+	return ich.options.Transport
+}
+
 // LoadRegistryCredentialsFromConfig loads registry credentials from configuration
 func (ich *ImageCollectionHandler) LoadRegistryCredentialsFromConfig(configFile string) error {
 	// In a full implementation, this would load registry credentials from:
@@ -175,6 +298,35 @@ func (ich *ImageCollectionHandler) ValidateImageOptions() error {
 		return fmt.Errorf("retry count cannot be negative")
 	}
 
+	if err := images.ValidateRegistryLimits(ich.options.RegistryLimits); err != nil {
+		return fmt.Errorf("invalid registry limits: %w", err)
+	}
+
+	if err := images.ValidateRegistryMirrors(ich.options.Mirrors); err != nil {
+		return fmt.Errorf("invalid registry mirrors: %w", err)
+	}
+
+	if err := images.ValidateBaseImageDigests(ich.options.BaseImageDigests); err != nil {
+		return fmt.Errorf("invalid base images: %w", err)
+	}
+
+	if ich.options.LabelFilter != nil {
+		if err := ich.options.LabelFilter.Validate(); err != nil {
+			return fmt.Errorf("invalid label filter: %w", err)
+		}
+	}
+
+	if err := images.ValidateEnrichers(ich.options.Enrichers); err != nil {
+		return fmt.Errorf("invalid enrichers: %w", err)
+	}
+
+	// Validate proxy URL and CA bundles
+	if ich.options.Transport != nil {
+		if _, err := images.NewRegistryTransport(ich.options.Transport); err != nil {
+			return fmt.Errorf("invalid registry transport: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -189,6 +341,7 @@ func (ich *ImageCollectionHandler) GenerateImageCollectionSummary() string {
 		fmt.Sprintf("  Include manifests: %v", ich.options.IncludeManifests),
 		fmt.Sprintf("  Include layers: %v", ich.options.IncludeLayers),
 		fmt.Sprintf("  Include config: %v", ich.options.IncludeConfig),
+		fmt.Sprintf("  Include history: %v", ich.options.IncludeHistory),
 		fmt.Sprintf("  Cache enabled: %v", ich.options.CacheEnabled),
 		fmt.Sprintf("  Timeout: %v", ich.options.Timeout),
 		fmt.Sprintf("  Max concurrency: %d", ich.options.MaxConcurrency),
@@ -203,11 +356,77 @@ func (ich *ImageCollectionHandler) GenerateImageCollectionSummary() string {
 		summary = append(summary, fmt.Sprintf("  Authenticated registries: %s", strings.Join(registries, ", ")))
 	}
 
+	if len(ich.options.RegistryLimits) > 0 {
+		registries := make([]string, 0, len(ich.options.RegistryLimits))
+		for registry := range ich.options.RegistryLimits {
+			registries = append(registries, registry)
+		}
+		sort.Strings(registries)
+		for _, registry := range registries {
+			limits := ich.options.RegistryLimits[registry]
+			summary = append(summary, fmt.Sprintf("  Registry %s: concurrency %d, timeout %v", registry, limits.MaxConcurrency, limits.Timeout))
+		}
+	}
+
+	if len(ich.options.Mirrors) > 0 {
+		registries := make([]string, 0, len(ich.options.Mirrors))
+		for registry := range ich.options.Mirrors {
+			registries = append(registries, registry)
+		}
+		sort.Strings(registries)
+		for _, registry := range registries {
+			summary = append(summary, fmt.Sprintf("  Mirrors for %s: %s", registry, strings.Join(ich.options.Mirrors[registry], ", ")))
+		}
+	}
+
+	if len(ich.options.BaseImageDigests) > 0 {
+		summary = append(summary, fmt.Sprintf("  Known base image layers: %d", len(ich.options.BaseImageDigests)))
+	}
+
+	if filter := ich.options.LabelFilter; filter != nil {
+		if len(filter.Allow) > 0 {
+			summary = append(summary, fmt.Sprintf("  Labels allowed: %s", strings.Join(filter.Allow, ", ")))
+		}
+		summary = append(summary, fmt.Sprintf("  Labels denied: %s", strings.Join(filter.Deny, ", ")))
+	}
+
+	if len(ich.options.Enrichers) > 0 {
+		summary = append(summary, fmt.Sprintf("  Enrichers: %s", strings.Join(ich.options.Enrichers, ", ")))
+	}
+
+	if transport := ich.options.Transport; transport != nil {
+		if transport.Proxy != "" {
+			summary = append(summary, fmt.Sprintf("  Proxy: %s", transport.Proxy))
+		}
+		if transport.CABundle != "" {
+			summary = append(summary, fmt.Sprintf("  CA bundle: %s", transport.CABundle))
+		}
+		var insecure []string
+		for registry, tlsConfig := range transport.Registries {
+			if tlsConfig.InsecureSkipVerify {
+				insecure = append(insecure, registry)
+			}
+		}
+		if len(insecure) > 0 {
+			sort.Strings(insecure)
+			summary = append(summary, fmt.Sprintf("  TLS verification disabled: %s", strings.Join(insecure, ", ")))
+		}
+	}
+
 	return strings.Join(summary, "\n")
 }
 
 // Helper functions
 
+// splitRegistryValue splits a "host:value" option, the host may carry a port
+func splitRegistryValue(key, value string) (string, string, error) {
+	i := strings.LastIndex(value, ":")
+	if i <= 0 || i == len(value)-1 {
+		return "", "", fmt.Errorf("%s must be in format registry:value: %s", key, value)
+	}
+	return value[:i], value[i+1:], nil
+}
+
 func parseBool(value string, defaultValue bool) bool {
 	value = strings.ToLower(value)
 	switch value {
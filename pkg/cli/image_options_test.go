@@ -60,6 +60,151 @@ func TestImageCollectionHandler_ParseImageOptions(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name:          "history option",
+			includeImages: true,
+			imageOpts:     "history=true",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				if !handler.GetImageCollectionOptions().IncludeHistory {
+					return fmt.Errorf("history should be enabled")
+				}
+				return nil
+			},
+		},
+		{
+			name:          "proxy and TLS options",
+			includeImages: true,
+			imageOpts:     "proxy=http://proxy.corp:3128,ca-bundle=/etc/ssl/corp-ca.pem,insecure-registry=registry.local:5000",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				transport := handler.GetImageCollectionOptions().Transport
+				if transport == nil {
+					return fmt.Errorf("transport config should be set")
+				}
+				if transport.Proxy != "http://proxy.corp:3128" {
+					return fmt.Errorf("proxy should be http://proxy.corp:3128, got %s", transport.Proxy)
+				}
+				if transport.CABundle != "/etc/ssl/corp-ca.pem" {
+					return fmt.Errorf("CA bundle should be /etc/ssl/corp-ca.pem, got %s", transport.CABundle)
+				}
+				if !transport.Registries["registry.local:5000"].InsecureSkipVerify {
+					return fmt.Errorf("TLS verification should be disabled for registry.local:5000")
+				}
+				return nil
+			},
+		},
+		{
+			name:          "per-registry limits",
+			includeImages: true,
+			imageOpts:     "registry-concurrency=harbor.internal:5000:20,registry-concurrency=docker.io:2,registry-timeout=docker.io:30s",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				limits := handler.GetImageCollectionOptions().RegistryLimits
+				if limits["harbor.internal:5000"].MaxConcurrency != 20 {
+					return fmt.Errorf("harbor.internal:5000 concurrency should be 20, got %d", limits["harbor.internal:5000"].MaxConcurrency)
+				}
+				if limits["docker.io"].MaxConcurrency != 2 || limits["docker.io"].Timeout != 30*time.Second {
+					return fmt.Errorf("docker.io limits should be 2 and 30s, got %+v", limits["docker.io"])
+				}
+				return nil
+			},
+		},
+		{
+			name:          "registry mirrors",
+			includeImages: true,
+			imageOpts:     "mirror=docker.io=mirror.gcr.io,mirror=docker.io=http://cache.local:5000",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				mirrors := handler.GetImageCollectionOptions().Mirrors["docker.io"]
+				if strings.Join(mirrors, ",") != "mirror.gcr.io,http://cache.local:5000" {
+					return fmt.Errorf("docker.io mirrors should be kept in order, got %v", mirrors)
+				}
+				return handler.ValidateImageOptions()
+			},
+		},
+		{
+			name:          "base image digest",
+			includeImages: true,
+			imageOpts:     "base-image=sha256:" + strings.Repeat("a", 64) + "=acme/golden-base:2024.1",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				if name := handler.GetImageCollectionOptions().BaseImageDigests["sha256:"+strings.Repeat("a", 64)]; name != "acme/golden-base:2024.1" {
+					return fmt.Errorf("base image should be acme/golden-base:2024.1, got %q", name)
+				}
+				return handler.ValidateImageOptions()
+			},
+		},
+		{
+			name:          "label filter",
+			includeImages: true,
+			imageOpts:     "label-allow=org.opencontainers.*,label-allow=version,label-deny=*.vendor",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				filter := handler.GetImageCollectionOptions().LabelFilter
+				if filter == nil || strings.Join(filter.Allow, ",") != "org.opencontainers.*,version" {
+					return fmt.Errorf("allowed labels should be kept in order, got %+v", filter)
+				}
+				if len(filter.Deny) != len(images.DefaultDeniedLabels)+1 || filter.Deny[len(filter.Deny)-1] != "*.vendor" {
+					return fmt.Errorf("denied labels should extend the defaults, got %v", filter.Deny)
+				}
+				return handler.ValidateImageOptions()
+			},
+		},
+		{
+			name:          "enrichers",
+			includeImages: true,
+			imageOpts:     "enricher=vulnerability-labels,enricher=build-info",
+			expectError:   false,
+			validate: func(handler *ImageCollectionHandler) error {
+				if enrichers := handler.GetImageCollectionOptions().Enrichers; strings.Join(enrichers, ",") != "vulnerability-labels,build-info" {
+					return fmt.Errorf("enrichers should be kept in order, got %v", enrichers)
+				}
+				return handler.ValidateImageOptions()
+			},
+		},
+		{
+			name:          "enricher without name",
+			includeImages: true,
+			imageOpts:     "enricher=",
+			expectError:   true,
+		},
+		{
+			name:          "label filter without pattern",
+			includeImages: true,
+			imageOpts:     "label-deny=",
+			expectError:   true,
+		},
+		{
+			name:          "base image without name",
+			includeImages: true,
+			imageOpts:     "base-image=sha256:abc",
+			expectError:   true,
+		},
+		{
+			name:          "mirror without endpoint",
+			includeImages: true,
+			imageOpts:     "mirror=docker.io",
+			expectError:   true,
+		},
+		{
+			name:          "registry limit without registry",
+			includeImages: true,
+			imageOpts:     "registry-concurrency=20",
+			expectError:   true,
+		},
+		{
+			name:          "invalid registry timeout",
+			includeImages: true,
+			imageOpts:     "registry-timeout=docker.io:soon",
+			expectError:   true,
+		},
+		{
+			name:          "insecure registry without host",
+			includeImages: true,
+			imageOpts:     "insecure-registry=",
+			expectError:   true,
+		},
 		{
 			name:          "invalid timeout",
 			includeImages: true,
@@ -166,6 +311,30 @@ func TestImageCollectionHandler_ValidateImageOptions(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "missing CA bundle",
+			setupHandler: func(handler *ImageCollectionHandler) {
+				handler.SetEnabled(true)
+				handler.options.Transport = &images.RegistryTransportConfig{CABundle: "/nonexistent/ca.pem"}
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown enricher",
+			setupHandler: func(handler *ImageCollectionHandler) {
+				handler.SetEnabled(true)
+				handler.options.Enrichers = []string{"internal-catalog"}
+			},
+			expectError: true,
+		},
+		{
+			name: "insecure registry",
+			setupHandler: func(handler *ImageCollectionHandler) {
+				handler.SetEnabled(true)
+				handler.SetRegistryTLSConfig("registry.local", images.RegistryTLSConfig{InsecureSkipVerify: true})
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -262,6 +431,13 @@ func TestImageCollectionHandler_GenerateImageCollectionSummary(t *testing.T) {
 			},
 			expected: []string{"enabled", "Authenticated registries"},
 		},
+		{
+			name: "enabled with registry mirrors",
+			setup: func(handler *ImageCollectionHandler) {
+				handler.ParseImageOptions(true, "mirror=docker.io=mirror.gcr.io,mirror=docker.io=cache.local")
+			},
+			expected: []string{"Mirrors for docker.io: mirror.gcr.io, cache.local"},
+		},
 	}
 
 	for _, tt := range tests {
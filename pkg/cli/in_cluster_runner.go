@@ -0,0 +1,793 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultRunnerImage runs `support-bundle collect` in the Job of --in-cluster
+const DefaultRunnerImage = "replicated/troubleshoot:latest"
+
+// Paths inside the runner pod
+const (
+	runnerConfigDir     = "/etc/troubleshoot"
+	runnerOptionsFile   = "options.json"
+	runnerConfigFile    = "config.yaml"
+	runnerBundleDir     = "/bundle"
+	runnerWorkspaceDir  = "/tmp/troubleshoot"
+	runnerContainerName = "collect"
+)
+
+// Frames the runner pod writes to its log, every other line is the collection's own console output
+const (
+	runnerResultFrame      = "TROUBLESHOOT-RESULT "
+	runnerErrorFrame       = "TROUBLESHOOT-ERROR "
+	runnerBundleBeginFrame = "TROUBLESHOOT-BUNDLE-BEGIN "
+	runnerBundleFrame      = "TROUBLESHOOT-BUNDLE "
+	runnerBundleEndFrame   = "TROUBLESHOOT-BUNDLE-END "
+)
+
+// runnerChunkSize is the bundle bytes per log line, 4 KiB once base64 encoded, well below the kubelet's line split
+const runnerChunkSize = 3 * 1024
+
+// runnerLogMaxSize is the kubelet's default containerLogMaxSize. The log is rotated past it while the CLI follows
+// it, so a bundle over about 7.5 MiB, once base64 encoded, loses frames and fails its size or checksum check
+const runnerLogMaxSize = 10 * 1024 * 1024
+
+// runnerLogSizeHint is added to the errors of a bundle cut short, which is most often log rotation
+var runnerLogSizeHint = fmt.Sprintf("bundles over %s once encoded can be cut off by the kubelet's log rotation", formatByteSize(runnerLogMaxSize))
+
+// runnerJobTTL lets the cluster remove a finished Job and its pod when the CLI could not, e.g. after it was killed
+const runnerJobTTL int32 = 3600
+
+// runnerStartupGrace is added to --timeout for the Job deadline, to pull the image and stream the bundle back
+const runnerStartupGrace = 5 * time.Minute
+
+// runnerPollInterval is how often the runner pod is checked until it starts
+var runnerPollInterval = 2 * time.Second
+
+// runnerWaitingFailures are container waiting reasons a runner pod does not recover from
+var runnerWaitingFailures = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// InClusterRunner is the Job of `support-bundle collect --in-cluster`: a ServiceAccount bound to read-only roles
+// for the resources of the discovered collectors, a ConfigMap with the collect options and config file, and a Job
+// running the collection with them
+type InClusterRunner struct {
+	Name      string
+	Namespace string
+
+	ServiceAccount     *corev1.ServiceAccount
+	ClusterRole        *rbacv1.ClusterRole        // Cluster-scoped collectors only, nil without them
+	ClusterRoleBinding *rbacv1.ClusterRoleBinding // nil without a ClusterRole
+	Roles              []*rbacv1.Role             // One per collector namespace
+	RoleBindings       []*rbacv1.RoleBinding
+	ConfigMap          *corev1.ConfigMap
+	Job                *batchv1.Job
+
+	SkippedSecrets bool // Secrets were discovered but not granted, no secret policy collects them
+
+	client    kubernetes.Interface
+	bundleDir string // OutputDir of the collection in the pod
+}
+
+// runnerOutput is what the runner pod streamed back
+type runnerOutput struct {
+	Result     *CollectionResult
+	BundlePath string
+	BundleSize int64
+}
+
+// NewInClusterRunner renders the runner objects for options in namespace. The runner is granted read access to what
+// the locally discovered collectors gather, in their namespaces, and collects from those namespaces; clusterName
+// names the cluster in the bundle, since in a pod it would otherwise be named after the API server service address
+func NewInClusterRunner(client kubernetes.Interface, options SupportBundleCollectOptions, collectors []autodiscovery.CollectorSpec, namespace, clusterName string, startTime time.Time) (*InClusterRunner, error) {
+	name := fmt.Sprintf("troubleshoot-runner-%s", startTime.Format("20060102-150405"))
+	labels := runnerLabels(name)
+	meta := metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}
+	clusterMeta := metav1.ObjectMeta{Name: name, Labels: labels}
+
+	image := options.RunnerImage
+	if image == "" {
+		image = DefaultRunnerImage
+	}
+
+	podOptions := runnerPodOptions(options, runnerBundleName(options, startTime), clusterName)
+	// The runner may only read the discovered namespaces, it must not infer or list them on its own
+	if namespaces := collectorNamespaces(collectors); len(podOptions.Namespaces) == 0 && len(namespaces) > 0 {
+		podOptions.Namespaces = namespaces
+		podOptions.AllNamespaces = false
+	}
+	optionsJSON, err := json.MarshalIndent(podOptions, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode runner options: %w", err)
+	}
+	configData := map[string]string{runnerOptionsFile: string(optionsJSON)}
+	if options.ConfigFile != "" {
+		config, err := os.ReadFile(options.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		configData[runnerConfigFile] = string(config)
+	}
+
+	backoffLimit := int32(0)
+	ttl := runnerJobTTL
+	runAsNonRoot := true
+	runAsUser := int64(65532)
+	readOnlyRootFilesystem := true
+	allowPrivilegeEscalation := false
+
+	job := &batchv1.Job{
+		ObjectMeta: meta,
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: name,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot:   &runAsNonRoot,
+						RunAsUser:      &runAsUser,
+						SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+					},
+					Containers: []corev1.Container{{
+						Name:    runnerContainerName,
+						Image:   image,
+						Command: []string{"support-bundle", "collect", "--runner-options", path.Join(runnerConfigDir, runnerOptionsFile)},
+						Env: []corev1.EnvVar{
+							{Name: "HOME", Value: "/tmp"},
+							{Name: WorkspaceDirEnv, Value: runnerWorkspaceDir},
+						},
+						SecurityContext: &corev1.SecurityContext{
+							AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+							ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+							Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "config", MountPath: runnerConfigDir, ReadOnly: true},
+							{Name: "bundle", MountPath: runnerBundleDir},
+							{Name: "tmp", MountPath: "/tmp"},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}}},
+						{Name: "bundle", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+						{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+	if options.Timeout > 0 {
+		deadline := int64((options.Timeout + runnerStartupGrace).Seconds())
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+
+	runner := &InClusterRunner{
+		Name:           name,
+		Namespace:      namespace,
+		ServiceAccount: &corev1.ServiceAccount{ObjectMeta: meta},
+		ConfigMap:      &corev1.ConfigMap{ObjectMeta: meta, Data: configData},
+		Job:            job,
+		client:         client,
+		bundleDir:      podOptions.OutputDir,
+	}
+
+	permissions, skippedSecrets := runnerPermissions(collectors)
+	runner.SkippedSecrets = skippedSecrets
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}}
+	for _, roleNamespace := range permissionNamespaces(permissions) {
+		rules := runnerPolicyRules(permissions, roleNamespace)
+		if roleNamespace == "" {
+			runner.ClusterRole = &rbacv1.ClusterRole{ObjectMeta: clusterMeta, Rules: rules}
+			runner.ClusterRoleBinding = &rbacv1.ClusterRoleBinding{
+				ObjectMeta: clusterMeta,
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+				Subjects:   subjects,
+			}
+			continue
+		}
+		roleMeta := metav1.ObjectMeta{Name: name, Namespace: roleNamespace, Labels: labels}
+		runner.Roles = append(runner.Roles, &rbacv1.Role{ObjectMeta: roleMeta, Rules: rules})
+		runner.RoleBindings = append(runner.RoleBindings, &rbacv1.RoleBinding{
+			ObjectMeta: roleMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+			Subjects:   subjects,
+		})
+	}
+	return runner, nil
+}
+
+// runnerPermissions returns the read permissions the collectors need, as the RBAC check derives them. Exec, debug
+// pods and other writes are never granted, their collectors fail or are filtered out by the RBAC check. Secrets
+// are granted only to collectors a secret policy limits to metadata, skippedSecrets reports the others
+func runnerPermissions(collectors []autodiscovery.CollectorSpec) (permissions []CollectorPermission, skippedSecrets bool) {
+	seen := make(map[CollectorPermission]bool)
+	for _, collector := range collectors {
+		secretPolicy := false
+		if params, err := collector.ClusterResourcesParams(); err == nil && collector.Type == autodiscovery.CollectorTypeClusterResources {
+			secretPolicy = len(params.SecretPolicy) > 0
+		}
+		for _, perm := range RequiredCollectorPermissions(collector) {
+			if !rbacReadVerbs[perm.Verb] || seen[perm] {
+				continue
+			}
+			if perm.GVR.Group == "" && perm.GVR.Resource == "secrets" && !secretPolicy {
+				skippedSecrets = true
+				continue
+			}
+			seen[perm] = true
+			permissions = append(permissions, perm)
+		}
+	}
+	return permissions, skippedSecrets
+}
+
+// permissionNamespaces returns the sorted namespaces of the permissions, "" for cluster-scoped ones
+func permissionNamespaces(permissions []CollectorPermission) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, perm := range permissions {
+		if !seen[perm.Namespace] {
+			seen[perm.Namespace] = true
+			namespaces = append(namespaces, perm.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// runnerPolicyRules groups the permissions in namespace into rules as the RBAC remediation does
+func runnerPolicyRules(permissions []CollectorPermission, namespace string) []rbacv1.PolicyRule {
+	var inNamespace []CollectorPermission
+	for _, perm := range permissions {
+		if perm.Namespace == namespace {
+			inNamespace = append(inNamespace, perm)
+		}
+	}
+	var rules []rbacv1.PolicyRule
+	for _, rule := range remediationRules(inNamespace) {
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: rule.APIGroups, Resources: rule.Resources, Verbs: rule.Verbs})
+	}
+	return rules
+}
+
+func runnerLabels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "troubleshoot-runner",
+		"app.kubernetes.io/instance":   name,
+		"app.kubernetes.io/managed-by": "troubleshoot.sh",
+	}
+}
+
+// runnerBundleName is the bundle directory name in the pod, the base of --output-dir so the archive streamed back
+// is named as a local collection would name it
+func runnerBundleName(options SupportBundleCollectOptions, startTime time.Time) string {
+	if options.OutputDir != "" {
+		return filepath.Base(options.OutputDir)
+	}
+	return fmt.Sprintf("support-bundle-%s", startTime.Format(outputTimestampLayout))
+}
+
+// runnerPodOptions are the collect options the runner pod runs with: the in-cluster config, a bundle in the pod's
+// scratch volume and the config file from the ConfigMap. Uploading and tracking are left to the CLI, and no
+// progress bar is drawn into the pod log the CLI reads the result from
+func runnerPodOptions(options SupportBundleCollectOptions, bundleName, clusterName string) SupportBundleCollectOptions {
+	podOptions := options
+	podOptions.InCluster = false
+	podOptions.RunnerNamespace = ""
+	podOptions.RunnerImage = ""
+	podOptions.KeepRunner = false
+	podOptions.KubeconfigPath = ""
+	podOptions.Context = ""
+	podOptions.Output = ""
+	podOptions.OutputDir = path.Join(runnerBundleDir, bundleName)
+	podOptions.ClusterName = clusterName
+	podOptions.WorkspaceDir = runnerWorkspaceDir
+	podOptions.NoTrack = true
+	podOptions.UploadURL = ""
+	podOptions.UploadChunkSize = 0
+	podOptions.NoProgress = true
+	if options.ConfigFile != "" {
+		podOptions.ConfigFile = path.Join(runnerConfigDir, runnerConfigFile)
+	}
+	return podOptions
+}
+
+// validateInClusterOptions rejects options that need files on the local machine, which the runner pod cannot read
+func validateInClusterOptions(options SupportBundleCollectOptions) error {
+	if !options.InCluster {
+		if options.RunnerNamespace != "" || options.RunnerImage != "" || options.KeepRunner {
+			return fmt.Errorf("--runner-namespace, --runner-image and --keep-runner require --in-cluster")
+		}
+		return nil
+	}
+
+	switch {
+	case options.DryRun:
+		return fmt.Errorf("--in-cluster cannot be used with --dry-run")
+	case options.Resume:
+		return fmt.Errorf("--in-cluster cannot be used with --resume, the runner starts from an empty bundle")
+	case options.Dev:
+		return fmt.Errorf("--in-cluster cannot be used with --dev, which targets the local kubeconfig context")
+	case options.Compression == CompressionNone:
+		return fmt.Errorf("--in-cluster requires a bundle archive, not --compression none")
+	case options.Output != "":
+		return fmt.Errorf("--output cannot be used with --in-cluster, use --output-dir")
+	case options.AuditLog != "":
+		return fmt.Errorf("--audit-log cannot be used with --in-cluster, the runner cannot read local files")
+	case options.SigningKey != "":
+		return fmt.Errorf("--signing-key cannot be used with --in-cluster, the key would have to be sent to the cluster")
+	case options.AnonymizationMapping != "":
+		return fmt.Errorf("--anonymization-mapping cannot be used with --in-cluster, the mapping would stay in the runner pod")
+	case options.MetricsFile != "" || options.MetricsAddr != "":
+		return fmt.Errorf("--metrics-file and --metrics-addr cannot be used with --in-cluster")
+	}
+	return nil
+}
+
+// Create creates the runner objects, the Job last so its pod starts with everything it mounts and is bound to
+func (r *InClusterRunner) Create(ctx context.Context) error {
+	if _, err := r.client.CoreV1().ServiceAccounts(r.Namespace).Create(ctx, r.ServiceAccount, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create runner ServiceAccount: %w", err)
+	}
+	if r.ClusterRole != nil {
+		if _, err := r.client.RbacV1().ClusterRoles().Create(ctx, r.ClusterRole, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create runner ClusterRole: %w", err)
+		}
+		if _, err := r.client.RbacV1().ClusterRoleBindings().Create(ctx, r.ClusterRoleBinding, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create runner ClusterRoleBinding: %w", err)
+		}
+	}
+	for i, role := range r.Roles {
+		if _, err := r.client.RbacV1().Roles(role.Namespace).Create(ctx, role, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create runner Role in %s: %w", role.Namespace, err)
+		}
+		if _, err := r.client.RbacV1().RoleBindings(role.Namespace).Create(ctx, r.RoleBindings[i], metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create runner RoleBinding in %s: %w", role.Namespace, err)
+		}
+	}
+	if _, err := r.client.CoreV1().ConfigMaps(r.Namespace).Create(ctx, r.ConfigMap, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create runner ConfigMap: %w", err)
+	}
+	if _, err := r.client.BatchV1().Jobs(r.Namespace).Create(ctx, r.Job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create runner Job: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the runner objects, ignoring those that were never created, and returns a warning per failure
+// It does not use the collection's context, which may already be canceled
+func (r *InClusterRunner) Delete() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	propagation := metav1.DeletePropagationBackground
+	type runnerDelete struct {
+		kind   string
+		delete func() error
+	}
+	deletes := []runnerDelete{
+		{"Job", func() error {
+			return r.client.BatchV1().Jobs(r.Namespace).Delete(ctx, r.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		}},
+		{"ConfigMap", func() error {
+			return r.client.CoreV1().ConfigMaps(r.Namespace).Delete(ctx, r.Name, metav1.DeleteOptions{})
+		}},
+		{"ClusterRoleBinding", func() error {
+			return r.client.RbacV1().ClusterRoleBindings().Delete(ctx, r.Name, metav1.DeleteOptions{})
+		}},
+		{"ClusterRole", func() error { return r.client.RbacV1().ClusterRoles().Delete(ctx, r.Name, metav1.DeleteOptions{}) }},
+	}
+	for _, role := range r.Roles {
+		roleNamespace := role.Namespace
+		deletes = append(deletes,
+			runnerDelete{"RoleBinding in " + roleNamespace, func() error {
+				return r.client.RbacV1().RoleBindings(roleNamespace).Delete(ctx, r.Name, metav1.DeleteOptions{})
+			}},
+			runnerDelete{"Role in " + roleNamespace, func() error {
+				return r.client.RbacV1().Roles(roleNamespace).Delete(ctx, r.Name, metav1.DeleteOptions{})
+			}},
+		)
+	}
+	deletes = append(deletes, runnerDelete{"ServiceAccount", func() error {
+		return r.client.CoreV1().ServiceAccounts(r.Namespace).Delete(ctx, r.Name, metav1.DeleteOptions{})
+	}})
+
+	var warnings []string
+	for _, d := range deletes {
+		if err := d.delete(); err != nil && !apierrors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("failed to delete runner %s %s: %v", d.kind, r.Name, err))
+		}
+	}
+	return warnings
+}
+
+// Run creates the runner, waits for its pod to start and follows its log until the bundle has been streamed back
+// to bundlePath(name of the archive in the pod). Log lines other than frames are copied to echo unless it is nil
+func (r *InClusterRunner) Run(ctx context.Context, echo io.Writer, bundlePath func(string) string) (*runnerOutput, error) {
+	if err := r.Create(ctx); err != nil {
+		return nil, err
+	}
+
+	pod, err := r.waitForPod(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := r.client.CoreV1().Pods(r.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: runnerContainerName, Follow: true}).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to follow runner pod %s: %w", pod.Name, err)
+	}
+	defer stream.Close()
+
+	return receiveRunnerStream(stream, echo, bundlePath)
+}
+
+// waitForPod waits until the Job's pod runs or has finished, and fails early when it cannot start
+func (r *InClusterRunner) waitForPod(ctx context.Context) (*corev1.Pod, error) {
+	selector := fmt.Sprintf("app.kubernetes.io/instance=%s", r.Name)
+	for {
+		pods, err := r.client.CoreV1().Pods(r.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runner pods: %w", err)
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			switch pod.Status.Phase {
+			case corev1.PodRunning, corev1.PodSucceeded, corev1.PodFailed:
+				// A failed pod's log still carries the error frame
+				return pod, nil
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				if waiting := status.State.Waiting; waiting != nil && runnerWaitingFailures[waiting.Reason] {
+					return nil, fmt.Errorf("runner pod %s cannot start: %s: %s", pod.Name, waiting.Reason, waiting.Message)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("runner pod did not start: %w", ctx.Err())
+		case <-time.After(runnerPollInterval):
+		}
+	}
+}
+
+// receiveRunnerStream reads the runner log: the result frame, and the bundle written to a temporary file next to
+// bundlePath(name) that is renamed once its size and sha256 match the end frame
+func receiveRunnerStream(r io.Reader, echo io.Writer, bundlePath func(string) string) (*runnerOutput, error) {
+	output := &runnerOutput{}
+	reader := bufio.NewReader(r)
+
+	var (
+		file        *os.File
+		digest      hash.Hash
+		tmpPath     string
+		localPath   string
+		expected    int64
+		received    int64
+		runnerError string
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	handle := func(line string) error {
+		switch {
+		case strings.HasPrefix(line, runnerResultFrame):
+			result := &CollectionResult{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, runnerResultFrame)), result); err != nil {
+				return fmt.Errorf("failed to decode runner result: %w", err)
+			}
+			output.Result = result
+
+		case strings.HasPrefix(line, runnerErrorFrame):
+			runnerError = strings.TrimPrefix(line, runnerErrorFrame)
+
+		case strings.HasPrefix(line, runnerBundleBeginFrame):
+			fields := strings.Fields(strings.TrimPrefix(line, runnerBundleBeginFrame))
+			if len(fields) != 2 || file != nil {
+				return fmt.Errorf("invalid bundle begin frame: %q", line)
+			}
+			size, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid bundle size %q: %w", fields[1], err)
+			}
+			localPath = bundlePath(filepath.Base(fields[0]))
+			tmpPath = localPath + ".tmp"
+			if dir := filepath.Dir(localPath); dir != "." {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return fmt.Errorf("failed to create bundle directory: %w", err)
+				}
+			}
+			if file, err = os.Create(tmpPath); err != nil {
+				return fmt.Errorf("failed to create bundle file: %w", err)
+			}
+			digest = sha256.New()
+			expected = size
+
+		case strings.HasPrefix(line, runnerBundleFrame):
+			if file == nil {
+				return fmt.Errorf("bundle data before its begin frame")
+			}
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, runnerBundleFrame))
+			if err != nil {
+				return fmt.Errorf("invalid bundle data after %d bytes: %w", received, err)
+			}
+			if _, err := io.MultiWriter(file, digest).Write(data); err != nil {
+				return fmt.Errorf("failed to write bundle file: %w", err)
+			}
+			received += int64(len(data))
+
+		case strings.HasPrefix(line, runnerBundleEndFrame):
+			if file == nil {
+				return fmt.Errorf("bundle end frame before its begin frame")
+			}
+			if received != expected {
+				return fmt.Errorf("bundle is %d bytes, expected %d; %s", received, expected, runnerLogSizeHint)
+			}
+			if sum := hex.EncodeToString(digest.Sum(nil)); sum != strings.TrimPrefix(line, runnerBundleEndFrame) {
+				return fmt.Errorf("bundle checksum %s does not match the runner's %s; %s", sum, strings.TrimPrefix(line, runnerBundleEndFrame), runnerLogSizeHint)
+			}
+			err := file.Close()
+			file = nil
+			if err != nil {
+				os.Remove(tmpPath)
+				return fmt.Errorf("failed to write bundle file: %w", err)
+			}
+			if err := os.Rename(tmpPath, localPath); err != nil {
+				os.Remove(tmpPath)
+				return fmt.Errorf("failed to rename bundle file: %w", err)
+			}
+			output.BundlePath = localPath
+			output.BundleSize = received
+
+		default:
+			if echo != nil {
+				fmt.Fprintln(echo, line)
+			}
+		}
+		return nil
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if handleErr := handle(strings.TrimRight(line, "\r\n")); handleErr != nil {
+				return nil, handleErr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read runner log: %w", err)
+		}
+	}
+
+	switch {
+	case runnerError != "":
+		return nil, fmt.Errorf("in-cluster collection failed: %s", runnerError)
+	case file != nil:
+		return nil, fmt.Errorf("runner log ended after %d of %d bundle bytes; %s", received, expected, runnerLogSizeHint)
+	case output.BundlePath == "":
+		return nil, fmt.Errorf("runner exited without streaming a bundle")
+	}
+	if output.Result == nil {
+		output.Result = &CollectionResult{}
+	}
+	return output, nil
+}
+
+// writeRunnerBundle writes the bundle archive at bundlePath to w as base64 frames with its size and sha256
+func writeRunnerBundle(w io.Writer, bundlePath string) error {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat bundle: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("bundle %s is a directory, the runner streams archives only", bundlePath)
+	}
+
+	if encoded := int64(base64.StdEncoding.EncodedLen(int(info.Size()))); encoded > runnerLogMaxSize {
+		fmt.Fprintf(w, "Warning: the bundle is %s once encoded, the kubelet may rotate the log before it is read (%s)\n", formatByteSize(encoded), runnerLogSizeHint)
+	}
+	fmt.Fprintf(w, "%s%s %d\n", runnerBundleBeginFrame, filepath.Base(bundlePath), info.Size())
+	digest := sha256.New()
+	chunk := make([]byte, runnerChunkSize)
+	for {
+		n, err := io.ReadFull(file, chunk)
+		if n > 0 {
+			digest.Write(chunk[:n])
+			if _, werr := fmt.Fprintf(w, "%s%s\n", runnerBundleFrame, base64.StdEncoding.EncodeToString(chunk[:n])); werr != nil {
+				return fmt.Errorf("failed to stream bundle: %w", werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", runnerBundleEndFrame, hex.EncodeToString(digest.Sum(nil)))
+	return err
+}
+
+// RunInClusterCollection is the runner pod's side of --in-cluster: it runs the collection with the options the CLI
+// rendered into the ConfigMap and streams the result and bundle archive to w, the pod's log
+func RunInClusterCollection(ctx context.Context, optionsPath string, w io.Writer) error {
+	err := runInClusterCollection(ctx, optionsPath, w)
+	if err != nil {
+		fmt.Fprintf(w, "%s%s\n", runnerErrorFrame, strings.ReplaceAll(err.Error(), "\n", " "))
+	}
+	return err
+}
+
+func runInClusterCollection(ctx context.Context, optionsPath string, w io.Writer) error {
+	data, err := os.ReadFile(optionsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read runner options: %w", err)
+	}
+	var options SupportBundleCollectOptions
+	if err := json.Unmarshal(data, &options); err != nil {
+		return fmt.Errorf("failed to decode runner options: %w", err)
+	}
+	if options.InCluster {
+		return fmt.Errorf("runner options must not start another in-cluster collection")
+	}
+
+	sbc, err := NewSupportBundleCollector(options)
+	if err != nil {
+		return err
+	}
+	result, err := sbc.CollectWithAutoDiscovery(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode collection result: %w", err)
+	}
+	fmt.Fprintf(w, "%s%s\n", runnerResultFrame, resultJSON)
+	return writeRunnerBundle(w, result.OutputPath)
+}
+
+// localizeRunnerResult points the paths of a result collected in the runner pod at the local bundle: the output
+// path at the downloaded archive, and paths inside the bundle relative to the archive root
+func localizeRunnerResult(result *CollectionResult, bundleDir, bundlePath string) {
+	result.OutputPath = bundlePath
+	root := path.Dir(bundleDir) + "/"
+	for _, p := range []*string{
+		&result.NamespaceIndexPath, &result.ReadmePath, &result.AnalysisPath, &result.ManifestPath,
+		&result.SignaturePath, &result.ExecutionReportPath, &result.RunSummaryPath,
+	} {
+		*p = strings.TrimPrefix(*p, root)
+	}
+}
+
+// collectInCluster runs the collection in a runner Job instead of from this machine, so only discovery and creating
+// the runner need the kubeconfig, and downloads the bundle archive it writes. The collectors discovered with opts
+// decide what the runner is granted
+func (sbc *SupportBundleCollector) collectInCluster(ctx context.Context, opts autodiscovery.DiscoveryOptions, options SupportBundleCollectOptions) (*CollectionResult, error) {
+	startTime := time.Now()
+
+	collectors, err := sbc.discoverer.Discover(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the runner's collectors: %w", err)
+	}
+	if len(collectors) == 0 {
+		return nil, fmt.Errorf("auto-discovery found nothing to collect in-cluster")
+	}
+
+	namespace := options.RunnerNamespace
+	if namespace == "" {
+		namespace = sbc.contextNamespace
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	runner, err := NewInClusterRunner(sbc.kubeClient, options, collectors, namespace, sbc.clusterName, startTime)
+	if err != nil {
+		return nil, err
+	}
+	if runner.SkippedSecrets {
+		fmt.Printf("Warning: the runner is not granted Secrets, set a secretPolicy to collect their metadata in-cluster\n")
+	}
+
+	var echo io.Writer
+	if !options.Quiet {
+		echo = os.Stdout
+		fmt.Printf("🚀 Running the collection in-cluster as Job %s/%s (%s)\n", namespace, runner.Name, runner.Job.Spec.Template.Spec.Containers[0].Image)
+	}
+
+	bundlePath := func(name string) string {
+		if options.OutputDir != "" {
+			if format, _ := ArchiveFormatFor(options.Compression); format != nil {
+				return options.OutputDir + format.Extension()
+			}
+		}
+		return name
+	}
+	output, err := runner.Run(ctx, echo, bundlePath)
+
+	if options.KeepRunner {
+		if !options.Quiet {
+			fmt.Printf("   Runner kept, remove it with: kubectl delete job,configmap,serviceaccount -n %s -l app.kubernetes.io/instance=%s && kubectl delete role,rolebinding -A -l app.kubernetes.io/instance=%s && kubectl delete clusterrole,clusterrolebinding -l app.kubernetes.io/instance=%s\n", namespace, runner.Name, runner.Name, runner.Name)
+		}
+	} else {
+		for _, warning := range runner.Delete() {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+	if err != nil {
+		if !options.KeepRunner {
+			return nil, fmt.Errorf("%w (rerun with --keep-runner to inspect the runner Job)", err)
+		}
+		return nil, err
+	}
+
+	result := output.Result
+	localizeRunnerResult(result, runner.bundleDir, output.BundlePath)
+	result.Duration = time.Since(startTime)
+
+	if options.UploadURL != "" {
+		if upload, err := uploadBundle(ctx, result.OutputPath, options.UploadURL, options.UploadToken, options.UploadChunkSize); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to upload bundle: %v", err))
+		} else {
+			result.Upload = upload
+		}
+	}
+	if !options.NoTrack {
+		trackBundle(options.WorkspaceDir, result.OutputPath, startTime)
+	}
+
+	if !options.Quiet {
+		fmt.Printf("✅ In-cluster collection complete!\n")
+		fmt.Printf("   Duration: %v\n", result.Duration.Round(time.Second))
+		fmt.Printf("   Output: %s (%s)\n", result.OutputPath, formatByteSize(output.BundleSize))
+	}
+	return result, nil
+}
@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewInClusterRunner(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(configFile, []byte("maxDepth: 2\n"), 0644)
+
+	options := SupportBundleCollectOptions{
+		InCluster:      true,
+		Namespaces:     []string{"shop"},
+		ConfigFile:     configFile,
+		OutputDir:      "bundles/shop",
+		KubeconfigPath: "/home/dev/.kube/config",
+		Context:        "prod",
+		UploadURL:      "https://uploads.example.com/files/",
+		Timeout:        10 * time.Minute,
+	}
+	startTime := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	runner, err := NewInClusterRunner(kubernetesfake.NewSimpleClientset(), options, testRunnerCollectors(), "support", "prod-eu", startTime)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if runner.Name != "troubleshoot-runner-20240301-093000" || runner.Job.Namespace != "support" || runner.ClusterRole.Namespace != "" {
+		t.Errorf("Expected namespaced objects in support and a cluster-scoped ClusterRole, got %s/%s", runner.Job.Namespace, runner.Name)
+	}
+	if subject := runner.ClusterRoleBinding.Subjects[0]; subject.Name != runner.Name || subject.Namespace != "support" {
+		t.Errorf("Expected the binding to name the runner ServiceAccount, got %+v", subject)
+	}
+	if deadline := runner.Job.Spec.ActiveDeadlineSeconds; deadline == nil || *deadline != int64((15*time.Minute).Seconds()) {
+		t.Errorf("Expected the timeout plus the startup grace as the Job deadline, got %v", deadline)
+	}
+	if container := runner.Job.Spec.Template.Spec.Containers[0]; container.Image != DefaultRunnerImage || !*container.SecurityContext.ReadOnlyRootFilesystem {
+		t.Errorf("Expected the default image with a read-only root filesystem, got %+v", container)
+	}
+
+	if runner.ConfigMap.Data[runnerConfigFile] != "maxDepth: 2\n" {
+		t.Errorf("Expected the config file in the ConfigMap, got %v", runner.ConfigMap.Data)
+	}
+	var podOptions SupportBundleCollectOptions
+	if err := json.Unmarshal([]byte(runner.ConfigMap.Data[runnerOptionsFile]), &podOptions); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if podOptions.InCluster || podOptions.KubeconfigPath != "" || podOptions.Context != "" || podOptions.UploadURL != "" {
+		t.Errorf("Expected the pod to use the in-cluster config and leave uploading to the CLI, got %+v", podOptions)
+	}
+	if podOptions.OutputDir != "/bundle/shop" || podOptions.ConfigFile != "/etc/troubleshoot/config.yaml" || podOptions.ClusterName != "prod-eu" {
+		t.Errorf("Expected pod paths and the local cluster name, got %+v", podOptions)
+	}
+	if strings.Join(podOptions.Namespaces, ",") != "shop" {
+		t.Errorf("Expected the collection options to be passed on, got %v", podOptions.Namespaces)
+	}
+}
+
+// testRunnerCollectors are discovered collectors for nodes, the shop workloads and Secrets with and without a
+// secret policy
+func testRunnerCollectors() []autodiscovery.CollectorSpec {
+	return []autodiscovery.CollectorSpec{
+		{Type: "cluster-resources", Parameters: map[string]interface{}{"version": "v1", "resource": "nodes"}},
+		{Type: "logs", Namespace: "shop"},
+		{Type: "cluster-resources", Namespace: "shop", Parameters: map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments"}},
+		{Type: "exec", Namespace: "shop"},
+		{Type: "cluster-resources", Namespace: "shop", Parameters: map[string]interface{}{"version": "v1", "resource": "secrets"}},
+		{Type: "cluster-resources", Namespace: "tls", Parameters: map[string]interface{}{
+			"version": "v1", "resource": "secrets", "secretPolicy": []interface{}{map[string]interface{}{"type": "kubernetes.io/tls"}},
+		}},
+	}
+}
+
+func TestNewInClusterRunner_RBAC(t *testing.T) {
+	runner, err := NewInClusterRunner(kubernetesfake.NewSimpleClientset(), SupportBundleCollectOptions{InCluster: true}, testRunnerCollectors(), "support", "prod-eu", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	describe := func(rules []rbacv1.PolicyRule) string {
+		var parts []string
+		for _, rule := range rules {
+			parts = append(parts, fmt.Sprintf("%s:%s:%s", strings.Join(rule.APIGroups, ","), strings.Join(rule.Resources, ","), strings.Join(rule.Verbs, ",")))
+		}
+		return strings.Join(parts, " ")
+	}
+	if got := describe(runner.ClusterRole.Rules); got != ":nodes:list" {
+		t.Errorf("Expected the ClusterRole to grant only the cluster-scoped collectors, got %s", got)
+	}
+
+	roles := make(map[string]string)
+	for i, role := range runner.Roles {
+		roles[role.Namespace] = describe(role.Rules)
+		if binding := runner.RoleBindings[i]; binding.Namespace != role.Namespace || binding.RoleRef.Name != runner.Name || binding.Subjects[0].Namespace != "support" {
+			t.Errorf("Expected a binding of the runner ServiceAccount next to each Role, got %+v", binding)
+		}
+	}
+	if len(roles) != 2 || roles["shop"] != ":pods:get,list :pods/log:get apps:deployments:list" {
+		t.Errorf("Expected shop reads without exec or Secrets, got %v", roles)
+	}
+	if roles["tls"] != ":secrets:list" {
+		t.Errorf("Expected Secrets under a secret policy to be granted, got %s", roles["tls"])
+	}
+	if !runner.SkippedSecrets {
+		t.Errorf("Expected the Secrets without a secret policy to be reported")
+	}
+
+	var podOptions SupportBundleCollectOptions
+	if err := json.Unmarshal([]byte(runner.ConfigMap.Data[runnerOptionsFile]), &podOptions); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(podOptions.Namespaces, ",") != "shop,tls" {
+		t.Errorf("Expected the runner to collect the discovered namespaces, got %v", podOptions.Namespaces)
+	}
+}
+
+func TestValidateInClusterOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options SupportBundleCollectOptions
+		wantErr bool
+	}{
+		{name: "not in-cluster", options: SupportBundleCollectOptions{DryRun: true}},
+		{name: "runner image without in-cluster", options: SupportBundleCollectOptions{RunnerImage: "troubleshoot:dev"}, wantErr: true},
+		{name: "in-cluster", options: SupportBundleCollectOptions{InCluster: true, OutputDir: "bundle", Anonymize: true, Sign: true}},
+		{name: "dry run", options: SupportBundleCollectOptions{InCluster: true, DryRun: true}, wantErr: true},
+		{name: "directory bundle", options: SupportBundleCollectOptions{InCluster: true, Compression: CompressionNone}, wantErr: true},
+		{name: "output template", options: SupportBundleCollectOptions{InCluster: true, Output: "bundles/{{.ClusterName}}.tgz"}, wantErr: true},
+		{name: "audit log", options: SupportBundleCollectOptions{InCluster: true, AuditLog: "audit.log"}, wantErr: true},
+		{name: "signing key", options: SupportBundleCollectOptions{InCluster: true, SigningKey: "key.pem"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInClusterOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func testRunnerLog(t *testing.T, bundle []byte) string {
+	bundlePath := filepath.Join(t.TempDir(), "support-bundle.tar.gz")
+	os.WriteFile(bundlePath, bundle, 0644)
+
+	var log bytes.Buffer
+	log.WriteString("Starting auto-discovery support bundle collection...\n")
+	log.WriteString(runnerResultFrame + `{"collectors": [], "readmePath": "/bundle/support-bundle/README.md"}` + "\n")
+	if err := writeRunnerBundle(&log, bundlePath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return log.String()
+}
+
+func TestReceiveRunnerStream(t *testing.T) {
+	bundle := bytes.Repeat([]byte("troubleshoot"), runnerChunkSize) // Several chunks, the last one short
+	dir := t.TempDir()
+	bundlePath := func(name string) string { return filepath.Join(dir, "local", name) }
+
+	var echo bytes.Buffer
+	output, err := receiveRunnerStream(strings.NewReader(testRunnerLog(t, bundle)), &echo, bundlePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if output.BundlePath != filepath.Join(dir, "local", "support-bundle.tar.gz") || output.BundleSize != int64(len(bundle)) {
+		t.Errorf("Expected the bundle under local, got %s (%d bytes)", output.BundlePath, output.BundleSize)
+	}
+	if received, _ := os.ReadFile(output.BundlePath); !bytes.Equal(received, bundle) {
+		t.Errorf("Expected the received bundle to match, got %d bytes", len(received))
+	}
+	if echo.String() != "Starting auto-discovery support bundle collection...\n" {
+		t.Errorf("Expected only the console output to be echoed, got %q", echo.String())
+	}
+
+	localizeRunnerResult(output.Result, "/bundle/support-bundle", output.BundlePath)
+	if output.Result.ReadmePath != "support-bundle/README.md" || output.Result.OutputPath != output.BundlePath {
+		t.Errorf("Expected paths relative to the archive root, got %+v", output.Result)
+	}
+}
+
+func TestReceiveRunnerStream_Failures(t *testing.T) {
+	log := testRunnerLog(t, bytes.Repeat([]byte("troubleshoot"), runnerChunkSize))
+	lines := strings.SplitAfter(log, "\n")
+
+	corrupted := strings.Replace(log, runnerBundleFrame+"dHJv", runnerBundleFrame+"AAAA", 1)
+	truncated := strings.Join(lines[:len(lines)-3], "")
+
+	tests := []struct {
+		name string
+		log  string
+		want string
+	}{
+		{name: "corrupted", log: corrupted, want: "checksum"},
+		{name: "truncated", log: truncated, want: "bundle bytes"},
+		{name: "no bundle", log: lines[0], want: "without streaming a bundle"},
+		{name: "runner error", log: lines[0] + runnerErrorFrame + "failed to create discoverer\n", want: "failed to create discoverer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := receiveRunnerStream(strings.NewReader(tt.log), nil, func(name string) string { return filepath.Join(dir, name) })
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("Expected no bundle to be left behind, got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestInClusterRunner_CreateDelete(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset()
+	runner, err := NewInClusterRunner(client, SupportBundleCollectOptions{InCluster: true}, testRunnerCollectors(), "support", "prod-eu", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := runner.Create(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.BatchV1().Jobs("support").Get(ctx, runner.Name, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the runner Job to be created: %v", err)
+	}
+	if _, err := client.RbacV1().RoleBindings("tls").Get(ctx, runner.Name, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the runner RoleBinding to be created: %v", err)
+	}
+
+	if warnings := runner.Delete(); len(warnings) != 0 {
+		t.Errorf("Expected a clean delete, got %v", warnings)
+	}
+	if roles, _ := client.RbacV1().Roles("shop").List(ctx, metav1.ListOptions{}); len(roles.Items) != 0 {
+		t.Errorf("Expected the runner Roles to be deleted, got %d", len(roles.Items))
+	}
+	if roles, _ := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{}); len(roles.Items) != 0 {
+		t.Errorf("Expected the runner ClusterRole to be deleted, got %d", len(roles.Items))
+	}
+	if warnings := runner.Delete(); len(warnings) != 0 {
+		t.Errorf("Expected objects already gone to be ignored, got %v", warnings)
+	}
+}
+
+func TestInClusterRunner_WaitForPod(t *testing.T) {
+	runner := &InClusterRunner{Name: "troubleshoot-runner-1", Namespace: "support"}
+	pod := func(phase corev1.PodPhase, waitingReason string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "troubleshoot-runner-1-abcde", Namespace: "support", Labels: runnerLabels(runner.Name)},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		if waitingReason != "" {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}}}}
+		}
+		return p
+	}
+
+	runner.client = kubernetesfake.NewSimpleClientset(pod(corev1.PodRunning, ""))
+	if got, err := runner.waitForPod(context.Background()); err != nil || got.Name != "troubleshoot-runner-1-abcde" {
+		t.Errorf("Expected the running pod, got %v, %v", got, err)
+	}
+
+	runner.client = kubernetesfake.NewSimpleClientset(pod(corev1.PodPending, "ImagePullBackOff"))
+	if _, err := runner.waitForPod(context.Background()); err == nil || !strings.Contains(err.Error(), "ImagePullBackOff") {
+		t.Errorf("Expected the image pull failure, got %v", err)
+	}
+
+	runner.client = kubernetesfake.NewSimpleClientset(pod(corev1.PodPending, "ContainerCreating"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := runner.waitForPod(ctx); err == nil {
+		t.Errorf("Expected waiting for a pending pod to stop with the context")
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// maxSkippedObjectsListed limits the trimmed objects printed individually after a collection
+const maxSkippedObjectsListed = 5
+
+// trimmingRunner trims oversized ConfigMaps and Secrets in the output of each collector
+// A failure to trim only prints a warning, the objects are then kept in full
+func trimmingRunner(runner CollectorRunner, trimmer *autodiscovery.LargeObjectTrimmer) CollectorRunner {
+	return func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		outputs, err := runner(ctx, collector, outputDir)
+		if _, trimErr := trimmer.Trim(collector, outputDir, outputs); trimErr != nil {
+			fmt.Printf("Warning: collector %s: %v\n", collector.Name, trimErr)
+		}
+		return outputs, err
+	}
+}
+
+// printSkippedObjectsSummary prints the ConfigMaps and Secrets whose content was left out
+func printSkippedObjectsSummary(skipped []autodiscovery.SkippedObject) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Printf("✂️  Captured %d large ConfigMaps and Secrets as metadata, keys and sizes only:\n", len(skipped))
+	for i, object := range skipped {
+		if i == maxSkippedObjectsListed {
+			fmt.Printf("   ... and %d more, see skippedObjects in the JSON output\n", len(skipped)-i)
+			break
+		}
+		fmt.Printf("   %s/%s/%s: %d bytes, %d keys skipped\n", object.Resource, object.Namespace, object.Name, object.Size, len(object.Keys))
+	}
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestRunCollectors_LargeObjects(t *testing.T) {
+	configMap := `{"kind":"ConfigMap","metadata":{"name":"trust-bundle","namespace":"app"},"data":{"ca-bundle.crt":"` + strings.Repeat("x", 2048) + `"}}`
+
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		trimmer: autodiscovery.NewLargeObjectTrimmer(),
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			name := collector.Name + ".json"
+			return []string{name}, os.WriteFile(filepath.Join(outputDir, name), []byte(configMap), 0644)
+		},
+	}
+	params := autodiscovery.ClusterResourcesParams{Version: "v1", Resource: "configmaps", Namespaces: []string{"app"}, MaxObjectSize: 1024}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeClusterResources, Name: "auto-resources-configmaps", Parameters: params.ToMap()},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collectorErrors, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint)
+	if err != nil || len(collectorErrors) != 0 {
+		t.Fatalf("Unexpected errors: %v %v", err, collectorErrors)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(outputDir, "auto-resources-configmaps.json"))
+	if strings.Contains(string(data), strings.Repeat("x", 2048)) || !strings.Contains(string(data), autodiscovery.LargeObjectSkippedAnnotation) {
+		t.Errorf("Expected the CA bundle to be replaced by its size, got %s", data)
+	}
+	if skipped := sbc.trimmer.Skipped(); len(skipped) != 1 || skipped[0].Keys["ca-bundle.crt"] != 2048 {
+		t.Errorf("Expected trust-bundle to be recorded as skipped, got %+v", skipped)
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// timestampNormalizingRunner rewrites the log lines of each logs collector to start with an RFC3339 UTC timestamp
+// A failure to normalize only prints a warning, the file then keeps the timestamps it was collected with
+func timestampNormalizingRunner(runner CollectorRunner, normalizer *autodiscovery.LogTimestampNormalizer) CollectorRunner {
+	return func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		outputs, err := runner(ctx, collector, outputDir)
+		if _, normalizeErr := normalizer.Normalize(collector, outputDir, outputs); normalizeErr != nil {
+			fmt.Printf("Warning: collector %s: %v\n", collector.Name, normalizeErr)
+		}
+		return outputs, err
+	}
+}
+
+// printLogTimestampSummary prints how many log lines were normalized
+func printLogTimestampSummary(report *autodiscovery.LogTimestampReport) {
+	if report == nil {
+		return
+	}
+
+	fmt.Printf("🕒 Normalized %d of %d log lines in %d files to RFC3339 UTC\n", report.Normalized, report.Lines, report.Files)
+	if report.Unparsed > 0 {
+		fmt.Printf("   %d lines without a recognized timestamp were kept as is\n", report.Unparsed)
+	}
+}
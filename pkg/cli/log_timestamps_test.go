@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestRunCollectors_LogTimestamps(t *testing.T) {
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		timestamps: autodiscovery.NewLogTimestampNormalizer(),
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			name := collector.Name + ".log"
+			return []string{name}, os.WriteFile(filepath.Join(outputDir, name), []byte("2024-03-01T10:30:00+01:00 ready\n"), 0644)
+		},
+	}
+	params := autodiscovery.LogsParams{Namespace: "shop", Name: "api-1", Timestamps: true, NormalizeTimestamps: true}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeLogs, Name: "auto-logs-pod-api-1", Namespace: "shop", Parameters: params.ToMap()},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collectorErrors, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint)
+	if err != nil || len(collectorErrors) != 0 {
+		t.Fatalf("Unexpected errors: %v %v", err, collectorErrors)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(outputDir, "auto-logs-pod-api-1.log"))
+	if string(data) != "2024-03-01T09:30:00.000000000Z ready\n" {
+		t.Errorf("Expected the timestamp in UTC, got %q", data)
+	}
+	if report := sbc.timestamps.Report(); report == nil || report.Normalized != 1 {
+		t.Errorf("Expected 1 line to be normalized, got %+v", report)
+	}
+}
@@ -3,10 +3,10 @@ package cli
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -170,7 +170,7 @@ func (nf *NamespaceFilter) discoverAndFilterNamespaces(ctx context.Context) ([]s
 
 func (nf *NamespaceFilter) isExcluded(namespace string) bool {
 	for _, excludePattern := range nf.excludeList {
-		if matched, _ := filepath.Match(excludePattern, namespace); matched {
+		if autodiscovery.MatchGlob(excludePattern, namespace) {
 			return true
 		}
 	}
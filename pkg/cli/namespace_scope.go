@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Reasons a namespace was inferred
+const (
+	ScopeReasonContext = "kubeconfig context namespace"
+	ScopeReasonApp     = "workloads of --app"
+)
+
+// InferredNamespace is a namespace picked when no namespaces were given, with why it was picked
+type InferredNamespace struct {
+	Namespace string   `json:"namespace"`
+	Reasons   []string `json:"reasons"`
+	Apps      []string `json:"apps,omitempty"` // The --app values with workloads in the namespace
+}
+
+// NamespaceScope is the namespace scope inferred from the kubeconfig context and the --app selector
+type NamespaceScope struct {
+	Namespaces []InferredNamespace `json:"namespaces"`
+	Warnings   []string            `json:"warnings,omitempty"`
+}
+
+// Names returns the inferred namespace names, empty when the scope falls back to every namespace
+func (s *NamespaceScope) Names() []string {
+	names := make([]string, 0, len(s.Namespaces))
+	for _, namespace := range s.Namespaces {
+		names = append(names, namespace.Namespace)
+	}
+	return names
+}
+
+func (s *NamespaceScope) add(namespace, reason, app string) {
+	for i := range s.Namespaces {
+		if s.Namespaces[i].Namespace != namespace {
+			continue
+		}
+		entry := &s.Namespaces[i]
+		entry.Reasons = appendScopeValue(entry.Reasons, reason)
+		if app != "" {
+			entry.Apps = appendScopeValue(entry.Apps, app)
+		}
+		return
+	}
+	entry := InferredNamespace{Namespace: namespace, Reasons: []string{reason}}
+	if app != "" {
+		entry.Apps = []string{app}
+	}
+	s.Namespaces = append(s.Namespaces, entry)
+}
+
+func appendScopeValue(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// appWorkloadLists list the workloads, cluster-wide, that carry a label selector; scaled-down apps still have
+// their Deployments and StatefulSets
+var appWorkloadLists = []struct {
+	resource string
+	list     func(ctx context.Context, client kubernetes.Interface, options metav1.ListOptions) ([]metav1.ObjectMeta, error)
+}{
+	{"pods", func(ctx context.Context, client kubernetes.Interface, options metav1.ListOptions) ([]metav1.ObjectMeta, error) {
+		list, err := client.CoreV1().Pods("").List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+		for _, item := range list.Items {
+			metas = append(metas, item.ObjectMeta)
+		}
+		return metas, nil
+	}},
+	{"deployments", func(ctx context.Context, client kubernetes.Interface, options metav1.ListOptions) ([]metav1.ObjectMeta, error) {
+		list, err := client.AppsV1().Deployments("").List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+		for _, item := range list.Items {
+			metas = append(metas, item.ObjectMeta)
+		}
+		return metas, nil
+	}},
+	{"statefulsets", func(ctx context.Context, client kubernetes.Interface, options metav1.ListOptions) ([]metav1.ObjectMeta, error) {
+		list, err := client.AppsV1().StatefulSets("").List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+		for _, item := range list.Items {
+			metas = append(metas, item.ObjectMeta)
+		}
+		return metas, nil
+	}},
+}
+
+// InferNamespaceScope picks the namespaces to collect when none were given: the kubeconfig context namespace, if
+// set and present, plus the namespaces of workloads labeled app.kubernetes.io/name or part-of with an --app value
+// Each namespace records every reason it was picked for. Lists that fail, e.g. when the user cannot list pods
+// cluster-wide, are reported as warnings
+func InferNamespaceScope(ctx context.Context, client kubernetes.Interface, contextNamespace string, apps []string) *NamespaceScope {
+	scope := &NamespaceScope{}
+
+	if contextNamespace != "" {
+		_, err := client.CoreV1().Namespaces().Get(ctx, contextNamespace, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			scope.Warnings = append(scope.Warnings, fmt.Sprintf("kubeconfig context namespace %s does not exist", contextNamespace))
+		default:
+			// A user who may not get namespaces can still collect their own
+			scope.add(contextNamespace, ScopeReasonContext, "")
+		}
+	}
+
+	if len(apps) > 0 {
+		values := strings.Join(apps, ",")
+		for _, label := range []string{autodiscovery.LabelAppName, autodiscovery.LabelAppPartOf} {
+			selector := fmt.Sprintf("%s in (%s)", label, values)
+			for _, workloads := range appWorkloadLists {
+				metas, err := workloads.list(ctx, client, metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					scope.Warnings = append(scope.Warnings, fmt.Sprintf("failed to list %s labeled %s: %v", workloads.resource, label, err))
+					continue
+				}
+				for _, meta := range metas {
+					scope.add(meta.Namespace, ScopeReasonApp, meta.Labels[label])
+				}
+			}
+		}
+	}
+
+	for i := range scope.Namespaces {
+		sort.Strings(scope.Namespaces[i].Apps)
+	}
+	sort.Slice(scope.Namespaces, func(i, j int) bool {
+		return scope.Namespaces[i].Namespace < scope.Namespaces[j].Namespace
+	})
+	return scope
+}
+
+// resolveContextNamespace returns the namespace of the kubeconfig context, "" in a pod or when the context sets none
+func resolveContextNamespace(options SupportBundleCollectOptions) string {
+	if usesInClusterConfig(options) {
+		return ""
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = options.KubeconfigPath
+	raw, err := rules.Load()
+	if err != nil {
+		return ""
+	}
+	contextName := options.Context
+	if contextName == "" {
+		contextName = raw.CurrentContext
+	}
+	if kubeContext, ok := raw.Contexts[contextName]; ok {
+		return kubeContext.Namespace
+	}
+	return ""
+}
+
+// inferNamespaceScope fills in the namespaces of options that name none, unless --all-namespaces was given,
+// and prints the inferred scope before discovery starts. The scope is nil when nothing was inferred
+func (sbc *SupportBundleCollector) inferNamespaceScope(ctx context.Context, opts autodiscovery.DiscoveryOptions, options SupportBundleCollectOptions) (autodiscovery.DiscoveryOptions, *NamespaceScope) {
+	if len(opts.Namespaces) > 0 || options.AllNamespaces || sbc.kubeClient == nil {
+		return opts, nil
+	}
+
+	scope := InferNamespaceScope(ctx, sbc.kubeClient, sbc.contextNamespace, opts.Apps)
+	opts.Namespaces = scope.Names()
+	if !options.Quiet {
+		printNamespaceScope(scope)
+	}
+	return opts, scope
+}
+
+func printNamespaceScope(scope *NamespaceScope) {
+	writeNamespaceScope(os.Stdout, scope)
+}
+
+// writeNamespaceScope writes the inferred namespaces with why each was picked, and the warnings of the inference
+func writeNamespaceScope(w io.Writer, scope *NamespaceScope) {
+	for _, warning := range scope.Warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+	if len(scope.Namespaces) == 0 {
+		fmt.Fprintf(w, "🌐 No namespaces given or inferred, scanning all namespaces\n")
+		return
+	}
+
+	fmt.Fprintf(w, "🎯 No namespaces given, collecting the inferred scope:\n")
+	for _, namespace := range scope.Namespaces {
+		reasons := make([]string, 0, len(namespace.Reasons))
+		for _, reason := range namespace.Reasons {
+			if reason == ScopeReasonApp {
+				reason = fmt.Sprintf("workloads of --app %s", strings.Join(namespace.Apps, ", "))
+			}
+			reasons = append(reasons, reason)
+		}
+		fmt.Fprintf(w, "   %s: %s\n", namespace.Namespace, strings.Join(reasons, "; "))
+	}
+	fmt.Fprintf(w, "   Pass --namespaces to choose them, or --all-namespaces to scan every namespace\n")
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func testScopeObjects() []runtime.Object {
+	return []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop", Labels: map[string]string{autodiscovery.LabelAppName: "shop"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a", Labels: map[string]string{autodiscovery.LabelAppName: "storefront"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "billing", Labels: map[string]string{autodiscovery.LabelAppName: "billing"}}},
+		// Scaled to zero, only the Deployment tells where the app lives
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop-data", Labels: map[string]string{autodiscovery.LabelAppPartOf: "shop"}}},
+	}
+}
+
+func TestInferNamespaceScope(t *testing.T) {
+	tests := []struct {
+		name             string
+		contextNamespace string
+		apps             []string
+		want             string
+		warnings         int
+	}{
+		{name: "nothing to infer", want: ""},
+		{name: "context namespace", contextNamespace: "team-a", want: "team-a"},
+		{name: "missing context namespace", contextNamespace: "gone", want: "", warnings: 1},
+		{name: "apps", apps: []string{"shop"}, want: "shop,shop-data"},
+		{name: "context namespace and apps", contextNamespace: "team-a", apps: []string{"shop", "storefront"}, want: "shop,shop-data,team-a"},
+		{name: "context namespace plus apps elsewhere", contextNamespace: "team-a", apps: []string{"shop"}, want: "shop,shop-data,team-a"},
+		{name: "apps not found", contextNamespace: "team-a", apps: []string{"unknown"}, want: "team-a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kubernetesfake.NewSimpleClientset(testScopeObjects()...)
+			scope := InferNamespaceScope(context.Background(), client, tt.contextNamespace, tt.apps)
+			if got := strings.Join(scope.Names(), ","); got != tt.want {
+				t.Errorf("Expected namespaces %q, got %q", tt.want, got)
+			}
+			if len(scope.Warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %v", tt.warnings, scope.Warnings)
+			}
+		})
+	}
+}
+
+func TestInferNamespaceScope_Reasons(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset(testScopeObjects()...)
+	scope := InferNamespaceScope(context.Background(), client, "team-a", []string{"storefront"})
+
+	if len(scope.Namespaces) != 1 {
+		t.Fatalf("Expected only team-a, got %+v", scope.Namespaces)
+	}
+	teamA := scope.Namespaces[0]
+	if len(teamA.Reasons) != 2 || teamA.Reasons[0] != ScopeReasonContext || teamA.Reasons[1] != ScopeReasonApp || strings.Join(teamA.Apps, ",") != "storefront" {
+		t.Errorf("Expected team-a to be picked for the context and storefront, got %+v", teamA)
+	}
+
+	scope = InferNamespaceScope(context.Background(), client, "team-a", nil)
+	if len(scope.Namespaces) != 1 || scope.Namespaces[0].Reasons[0] != ScopeReasonContext {
+		t.Errorf("Expected team-a to be picked for the context, got %+v", scope.Namespaces)
+	}
+}
+
+func TestInferNamespaceScope_ForbiddenLists(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset(testScopeObjects()...)
+	client.PrependReactor("list", "pods", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("cannot list pods"))
+	})
+	client.PrependReactor("get", "namespaces", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "team-a", errors.New("cannot get namespaces"))
+	})
+
+	scope := InferNamespaceScope(context.Background(), client, "team-a", []string{"shop"})
+	if got := strings.Join(scope.Names(), ","); got != "shop-data,team-a" {
+		t.Errorf("Expected the context namespace and the Deployment namespace, got %q", got)
+	}
+	if len(scope.Warnings) != 2 || !strings.Contains(scope.Warnings[0], "failed to list pods") {
+		t.Errorf("Expected a warning per forbidden pod list, got %v", scope.Warnings)
+	}
+
+	// A user who may not get namespaces still collects the context namespace
+	scope = InferNamespaceScope(context.Background(), client, "team-a", nil)
+	if got := strings.Join(scope.Names(), ","); got != "team-a" {
+		t.Errorf("Expected the context namespace, got %q", got)
+	}
+}
+
+func TestSupportBundleCollector_InferNamespaceScope(t *testing.T) {
+	sbc := &SupportBundleCollector{kubeClient: kubernetesfake.NewSimpleClientset(testScopeObjects()...), contextNamespace: "team-a"}
+
+	tests := []struct {
+		name    string
+		opts    autodiscovery.DiscoveryOptions
+		options SupportBundleCollectOptions
+		want    string
+	}{
+		{name: "inferred", opts: autodiscovery.DiscoveryOptions{Apps: []string{"shop"}}, want: "shop,shop-data,team-a"},
+		{name: "context namespace", opts: autodiscovery.DiscoveryOptions{}, want: "team-a"},
+		{name: "namespaces given", opts: autodiscovery.DiscoveryOptions{Namespaces: []string{"billing"}, Apps: []string{"shop"}}, want: "billing"},
+		{name: "all namespaces", opts: autodiscovery.DiscoveryOptions{Apps: []string{"shop"}}, options: SupportBundleCollectOptions{AllNamespaces: true}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Quiet = true
+			opts, scope := sbc.inferNamespaceScope(context.Background(), tt.opts, tt.options)
+			if got := strings.Join(opts.Namespaces, ","); got != tt.want {
+				t.Errorf("Expected namespaces %q, got %q", tt.want, got)
+			}
+			inferred := len(tt.opts.Namespaces) == 0 && !tt.options.AllNamespaces
+			if (scope != nil) != inferred {
+				t.Errorf("Expected a scope to be returned only when namespaces were inferred, got %+v", scope)
+			}
+		})
+	}
+}
+
+func TestWriteNamespaceScope(t *testing.T) {
+	scope := &NamespaceScope{
+		Namespaces: []InferredNamespace{
+			{Namespace: "shop", Reasons: []string{ScopeReasonApp}, Apps: []string{"shop"}},
+			{Namespace: "team-a", Reasons: []string{ScopeReasonContext}},
+		},
+		Warnings: []string{"failed to list pods labeled app.kubernetes.io/name: forbidden"},
+	}
+
+	var buf strings.Builder
+	writeNamespaceScope(&buf, scope)
+	for _, expected := range []string{"Warning: failed to list pods", "shop: workloads of --app shop", "team-a: kubeconfig context namespace"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+const (
+	// namespaceSummaryDir is the bundle directory holding per-namespace summaries
+	namespaceSummaryDir = "namespaces"
+	// clusterScopedSummaryName is used for data that does not belong to a namespace
+	clusterScopedSummaryName = "_cluster"
+)
+
+// NamespaceCollectionSummary describes what was collected for a single namespace
+type NamespaceCollectionSummary struct {
+	Namespace      string         `json:"namespace"`
+	ResourceCounts map[string]int `json:"resourceCounts"`
+	TotalBytes     int64          `json:"totalBytes"`
+	Errors         []string       `json:"errors,omitempty"`
+	Duration       time.Duration  `json:"duration"`
+}
+
+// NamespaceSummaryIndex is the aggregate index of all per-namespace summaries
+type NamespaceSummaryIndex struct {
+	Version         string                       `json:"version"`
+	Timestamp       time.Time                    `json:"timestamp"`
+	TotalNamespaces int                          `json:"totalNamespaces"`
+	TotalResources  int                          `json:"totalResources"`
+	TotalBytes      int64                        `json:"totalBytes"`
+	TotalErrors     int                          `json:"totalErrors"`
+	Namespaces      []NamespaceSummaryIndexEntry `json:"namespaces"`
+}
+
+// NamespaceSummaryIndexEntry points to a single namespace summary in the bundle
+type NamespaceSummaryIndexEntry struct {
+	Namespace     string        `json:"namespace"`
+	SummaryPath   string        `json:"summaryPath"`
+	ResourceCount int           `json:"resourceCount"`
+	TotalBytes    int64         `json:"totalBytes"`
+	ErrorCount    int           `json:"errorCount"`
+	Duration      time.Duration `json:"duration"`
+}
+
+// NamespaceSummaryWriter accumulates collection stats per namespace and writes them to the bundle
+type NamespaceSummaryWriter struct {
+	outputDir string
+	summaries map[string]*NamespaceCollectionSummary
+}
+
+// NewNamespaceSummaryWriter creates a new namespace summary writer for the bundle directory
+func NewNamespaceSummaryWriter(outputDir string) *NamespaceSummaryWriter {
+	return &NamespaceSummaryWriter{
+		outputDir: outputDir,
+		summaries: make(map[string]*NamespaceCollectionSummary),
+	}
+}
+
+// RecordResource records collected data of the given kind for a namespace
+func (nsw *NamespaceSummaryWriter) RecordResource(namespace, kind string, bytes int64) {
+	summary := nsw.getSummary(namespace)
+	summary.ResourceCounts[kind]++
+	summary.TotalBytes += bytes
+}
+
+// RecordError records a collection error for a namespace
+func (nsw *NamespaceSummaryWriter) RecordError(namespace string, err error) {
+	if err == nil {
+		return
+	}
+	summary := nsw.getSummary(namespace)
+	summary.Errors = append(summary.Errors, err.Error())
+}
+
+// RecordDuration adds time spent collecting a namespace
+func (nsw *NamespaceSummaryWriter) RecordDuration(namespace string, duration time.Duration) {
+	nsw.getSummary(namespace).Duration += duration
+}
+
+// RecordCollectors records the given collectors using their target resources as the kinds
+func (nsw *NamespaceSummaryWriter) RecordCollectors(collectors []autodiscovery.CollectorSpec) {
+	for _, collector := range collectors {
+		nsw.RecordCollector(collector, 0, 0, nil)
+	}
+}
+
+// RecordCollector records a collector run in its namespace: its target resources as the kinds,
+// the bytes its outputs left in the bundle, its duration and its error
+func (nsw *NamespaceSummaryWriter) RecordCollector(collector autodiscovery.CollectorSpec, bytes int64, duration time.Duration, err error) {
+	summary := nsw.getSummary(collector.Namespace)
+	for _, kind := range collectorResourceKinds(collector) {
+		summary.ResourceCounts[kind]++
+	}
+	summary.TotalBytes += bytes
+	nsw.RecordDuration(collector.Namespace, duration)
+	nsw.RecordError(collector.Namespace, err)
+}
+
+// RecordExecutions records the collectors with the bytes, duration and error of their run in the report
+// Collectors without a recorded run are counted without them
+func (nsw *NamespaceSummaryWriter) RecordExecutions(collectors []autodiscovery.CollectorSpec, report *ExecutionReport) {
+	executions := make(map[string]CollectorExecution)
+	for _, execution := range report.Collectors() {
+		executions[fmt.Sprintf("%s/%s/%s", execution.Type, execution.Namespace, execution.Collector)] = execution
+	}
+
+	for _, collector := range collectors {
+		execution := executions[collectorKey(collector)]
+		var err error
+		if execution.Error != "" {
+			err = errors.New(execution.Error)
+		}
+		nsw.RecordCollector(collector, execution.Bytes, execution.Duration, err)
+	}
+}
+
+// GetSummary returns the summary for a namespace, or nil if nothing was recorded
+func (nsw *NamespaceSummaryWriter) GetSummary(namespace string) *NamespaceCollectionSummary {
+	return nsw.summaries[summaryName(namespace)]
+}
+
+// BuildIndex builds the aggregate index without writing anything
+func (nsw *NamespaceSummaryWriter) BuildIndex() *NamespaceSummaryIndex {
+	index := &NamespaceSummaryIndex{
+		Version:    "v1",
+		Timestamp:  time.Now(),
+		Namespaces: make([]NamespaceSummaryIndexEntry, 0, len(nsw.summaries)),
+	}
+
+	names := make([]string, 0, len(nsw.summaries))
+	for name := range nsw.summaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		summary := nsw.summaries[name]
+
+		resourceCount := 0
+		for _, count := range summary.ResourceCounts {
+			resourceCount += count
+		}
+
+		index.Namespaces = append(index.Namespaces, NamespaceSummaryIndexEntry{
+			Namespace:     summary.Namespace,
+			SummaryPath:   filepath.ToSlash(filepath.Join(namespaceSummaryDir, name, "summary.json")),
+			ResourceCount: resourceCount,
+			TotalBytes:    summary.TotalBytes,
+			ErrorCount:    len(summary.Errors),
+			Duration:      summary.Duration,
+		})
+
+		index.TotalResources += resourceCount
+		index.TotalBytes += summary.TotalBytes
+		index.TotalErrors += len(summary.Errors)
+	}
+	index.TotalNamespaces = len(index.Namespaces)
+
+	return index
+}
+
+// Write writes namespaces/<ns>/summary.json for every namespace plus namespaces/index.json
+func (nsw *NamespaceSummaryWriter) Write() (*NamespaceSummaryIndex, error) {
+	index := nsw.BuildIndex()
+
+	for _, entry := range index.Namespaces {
+		summary := nsw.summaries[summaryName(entry.Namespace)]
+		if err := writeJSONFile(filepath.Join(nsw.outputDir, filepath.FromSlash(entry.SummaryPath)), summary); err != nil {
+			return nil, fmt.Errorf("failed to write summary for namespace %s: %w", entry.Namespace, err)
+		}
+	}
+
+	if err := writeJSONFile(nsw.IndexPath(), index); err != nil {
+		return nil, fmt.Errorf("failed to write namespace summary index: %w", err)
+	}
+
+	return index, nil
+}
+
+// IndexPath returns the location of the aggregate index in the bundle directory
+func (nsw *NamespaceSummaryWriter) IndexPath() string {
+	return filepath.Join(nsw.outputDir, namespaceSummaryDir, "index.json")
+}
+
+func (nsw *NamespaceSummaryWriter) getSummary(namespace string) *NamespaceCollectionSummary {
+	name := summaryName(namespace)
+	summary, exists := nsw.summaries[name]
+	if !exists {
+		summary = &NamespaceCollectionSummary{
+			Namespace:      namespace,
+			ResourceCounts: make(map[string]int),
+		}
+		nsw.summaries[name] = summary
+	}
+	return summary
+}
+
+// summaryName maps a namespace to its directory name in the bundle
+func summaryName(namespace string) string {
+	if namespace == "" {
+		return clusterScopedSummaryName
+	}
+	return namespace
+}
+
+// collectorResourceKinds returns the resource kinds a collector gathers, falling back to its type
+func collectorResourceKinds(collector autodiscovery.CollectorSpec) []string {
+	if collector.Type == autodiscovery.CollectorTypeClusterResources {
+		if params, err := collector.ClusterResourcesParams(); err == nil && len(params.GVRs()) > 0 {
+			var kinds []string
+			for _, gvr := range params.GVRs() {
+				kinds = append(kinds, gvr.Resource)
+			}
+			return kinds
+		}
+	}
+	if resource, ok := collector.Parameters["resource"].(string); ok && resource != "" {
+		return []string{resource}
+	}
+	return []string{collector.Type}
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestNamespaceSummaryWriter_RecordCollectors(t *testing.T) {
+	writer := NewNamespaceSummaryWriter(t.TempDir())
+
+	writer.RecordCollectors([]autodiscovery.CollectorSpec{
+		{Type: "logs", Name: "auto-logs-default", Namespace: "default"},
+		{Type: "cluster-resources", Name: "auto-resources-_v1_pods", Namespace: "default", Parameters: map[string]interface{}{"resource": "pods"}},
+		{Type: "cluster-resources", Name: "auto-resources-_v1_services", Namespace: "default", Parameters: map[string]interface{}{"resource": "services"}},
+		{Type: "cluster-resources", Name: "auto-resources-_v1_nodes", Parameters: map[string]interface{}{"resource": "nodes"}},
+	})
+
+	tests := []struct {
+		namespace      string
+		expectedCounts map[string]int
+	}{
+		{"default", map[string]int{"logs": 1, "pods": 1, "services": 1}},
+		{"", map[string]int{"nodes": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(summaryName(tt.namespace), func(t *testing.T) {
+			summary := writer.GetSummary(tt.namespace)
+			if summary == nil {
+				t.Fatalf("Expected summary for namespace %q", tt.namespace)
+			}
+
+			if len(summary.ResourceCounts) != len(tt.expectedCounts) {
+				t.Errorf("Expected %d resource kinds, got %d", len(tt.expectedCounts), len(summary.ResourceCounts))
+			}
+			for kind, count := range tt.expectedCounts {
+				if summary.ResourceCounts[kind] != count {
+					t.Errorf("Expected %d %s, got %d", count, kind, summary.ResourceCounts[kind])
+				}
+			}
+		})
+	}
+
+	if writer.GetSummary("missing") != nil {
+		t.Errorf("Expected no summary for unrecorded namespace")
+	}
+}
+
+func TestNamespaceSummaryWriter_RecordExecutions(t *testing.T) {
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			if collector.Name == "auto-secrets-app" {
+				return nil, errors.New("secrets is forbidden")
+			}
+			return writeCollectorSpec(ctx, collector, outputDir)
+		},
+	}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeLogs, Name: "auto-logs-app", Namespace: "app"},
+		{Type: "secret", Name: "auto-secrets-app", Namespace: "app"},
+		{Type: autodiscovery.CollectorTypeClusterResources, Name: "auto-resources-_v1_nodes", Parameters: map[string]interface{}{"resource": "nodes"}},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writer := NewNamespaceSummaryWriter(outputDir)
+	writer.RecordExecutions(collectors, sbc.execution)
+
+	app := writer.GetSummary("app")
+	if app == nil {
+		t.Fatalf("Expected summary for namespace app")
+	}
+	if app.TotalBytes == 0 {
+		t.Errorf("Expected bytes written by the logs collector, got 0")
+	}
+	if app.Duration == 0 {
+		t.Errorf("Expected a collection duration, got 0")
+	}
+	if len(app.Errors) != 1 || app.Errors[0] != "secrets is forbidden" {
+		t.Errorf("Expected the secrets collector error, got %v", app.Errors)
+	}
+	if app.ResourceCounts["logs"] != 1 || app.ResourceCounts["secret"] != 1 {
+		t.Errorf("Expected logs and secret to be counted, got %v", app.ResourceCounts)
+	}
+
+	index := writer.BuildIndex()
+	if index.TotalBytes == 0 || index.TotalErrors != 1 || index.TotalResources != 3 {
+		t.Errorf("Expected bytes, 1 error and 3 resources in the index, got %+v", index)
+	}
+}
+
+func TestNamespaceSummaryWriter_BuildIndex(t *testing.T) {
+	writer := NewNamespaceSummaryWriter(t.TempDir())
+
+	writer.RecordResource("zeta", "pods", 100)
+	writer.RecordResource("alpha", "pods", 200)
+	writer.RecordResource("alpha", "services", 50)
+	writer.RecordError("alpha", fmt.Errorf("forbidden"))
+	writer.RecordError("alpha", nil)
+	writer.RecordDuration("alpha", 2*time.Second)
+	writer.RecordDuration("alpha", 3*time.Second)
+
+	index := writer.BuildIndex()
+
+	if index.TotalNamespaces != 2 {
+		t.Errorf("Expected 2 namespaces, got %d", index.TotalNamespaces)
+	}
+	if index.TotalResources != 3 {
+		t.Errorf("Expected 3 resources, got %d", index.TotalResources)
+	}
+	if index.TotalBytes != 350 {
+		t.Errorf("Expected 350 total bytes, got %d", index.TotalBytes)
+	}
+	if index.TotalErrors != 1 {
+		t.Errorf("Expected 1 error, got %d", index.TotalErrors)
+	}
+
+	// Entries are sorted by namespace
+	if index.Namespaces[0].Namespace != "alpha" || index.Namespaces[1].Namespace != "zeta" {
+		t.Errorf("Expected namespaces sorted [alpha zeta], got [%s %s]", index.Namespaces[0].Namespace, index.Namespaces[1].Namespace)
+	}
+
+	alpha := index.Namespaces[0]
+	if alpha.SummaryPath != "namespaces/alpha/summary.json" {
+		t.Errorf("Expected summary path namespaces/alpha/summary.json, got %s", alpha.SummaryPath)
+	}
+	if alpha.Duration != 5*time.Second {
+		t.Errorf("Expected duration 5s, got %v", alpha.Duration)
+	}
+	if alpha.ErrorCount != 1 {
+		t.Errorf("Expected 1 error for alpha, got %d", alpha.ErrorCount)
+	}
+}
+
+func TestNamespaceSummaryWriter_Write(t *testing.T) {
+	outputDir := t.TempDir()
+	writer := NewNamespaceSummaryWriter(outputDir)
+
+	writer.RecordResource("default", "pods", 1024)
+	writer.RecordResource("", "nodes", 512)
+
+	index, err := writer.Write()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, entry := range index.Namespaces {
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(entry.SummaryPath)))
+		if err != nil {
+			t.Fatalf("Expected summary file for %q: %v", entry.Namespace, err)
+		}
+
+		var summary NamespaceCollectionSummary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			t.Fatalf("Failed to parse summary for %q: %v", entry.Namespace, err)
+		}
+		if summary.TotalBytes != entry.TotalBytes {
+			t.Errorf("Expected %d bytes for %q, got %d", entry.TotalBytes, entry.Namespace, summary.TotalBytes)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "namespaces", "_cluster", "summary.json")); err != nil {
+		t.Errorf("Expected cluster-scoped summary to be written: %v", err)
+	}
+
+	data, err := os.ReadFile(writer.IndexPath())
+	if err != nil {
+		t.Fatalf("Expected index file: %v", err)
+	}
+
+	var written NamespaceSummaryIndex
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Failed to parse index: %v", err)
+	}
+	if written.TotalNamespaces != 2 {
+		t.Errorf("Expected 2 namespaces in index, got %d", written.TotalNamespaces)
+	}
+	if written.TotalBytes != 1536 {
+		t.Errorf("Expected 1536 total bytes in index, got %d", written.TotalBytes)
+	}
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// outputTimestampLayout formats {{.Timestamp}}, the same layout as the default bundle name
+const outputTimestampLayout = "2006-01-02T15-04-05"
+
+// OutputPathVars are the variables of an --output path template
+type OutputPathVars struct {
+	ClusterName       string // Cluster of the kubeconfig context, or the API server host
+	Context           string // Kubeconfig context, "in-cluster" when running in a pod
+	KubernetesVersion string // e.g. v1.29.2
+	Profile           string // Discovery profile, "default" without --profile
+	Config            string // Config file name without its extension, "none" without --config
+	Namespace         string // The namespace when exactly one is collected, otherwise "all"
+	Timestamp         string // Collection start, e.g. 2024-03-01T09-30-00
+	Date              string // Collection start date, e.g. 2024-03-01
+}
+
+// outputArchiveSuffixes maps the archive extensions an --output path may end in to their compression
+var outputArchiveSuffixes = []struct {
+	suffix      string
+	compression string
+}{
+	{".tar.gz", CompressionGzip},
+	{".tgz", CompressionGzip},
+	{".tar.zst", CompressionZstd},
+	{".tzst", CompressionZstd},
+}
+
+// RenderOutputPath executes an --output template such as bundles/{{.ClusterName}}/{{.Timestamp}}-{{.Profile}}.tgz
+// Variable values are reduced to letters, digits, '.', '_' and '-' so they never add directories
+func RenderOutputPath(pathTemplate string, vars OutputPathVars) (string, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+
+	sanitized := OutputPathVars{
+		ClusterName:       sanitizePathValue(vars.ClusterName),
+		Context:           sanitizePathValue(vars.Context),
+		KubernetesVersion: sanitizePathValue(vars.KubernetesVersion),
+		Profile:           sanitizePathValue(vars.Profile),
+		Config:            sanitizePathValue(vars.Config),
+		Namespace:         sanitizePathValue(vars.Namespace),
+		Timestamp:         sanitizePathValue(vars.Timestamp),
+		Date:              sanitizePathValue(vars.Date),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, sanitized); err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	path := strings.TrimSpace(buf.String())
+	if path == "" {
+		return "", fmt.Errorf("output template rendered an empty path")
+	}
+	return filepath.Clean(path), nil
+}
+
+// ValidateOutputTemplate checks an --output template renders and that its archive extension agrees with compression
+func ValidateOutputTemplate(pathTemplate, compression string) error {
+	if _, err := RenderOutputPath(pathTemplate, OutputPathVars{}); err != nil {
+		return err
+	}
+	_, implied := splitArchivePath(pathTemplate)
+	if implied != "" && compression != "" && compression != implied {
+		return fmt.Errorf("%s extension conflicts with --compression %s", filepath.Ext(pathTemplate), compression)
+	}
+	if implied != "" {
+		return ValidateCompression(implied)
+	}
+	return nil
+}
+
+// splitArchivePath returns the bundle directory for an output path and the compression its extension implies
+// A path without an archive extension is the bundle directory itself
+func splitArchivePath(path string) (string, string) {
+	for _, archive := range outputArchiveSuffixes {
+		if strings.HasSuffix(path, archive.suffix) && len(path) > len(archive.suffix) {
+			return strings.TrimSuffix(path, archive.suffix), archive.compression
+		}
+	}
+	return path, ""
+}
+
+// sanitizePathValue keeps a variable value within one path element, "unknown" when it is empty
+func sanitizePathValue(value string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '-'
+		}
+	}, value)
+	if strings.Trim(sanitized, ".") == "" {
+		return "unknown"
+	}
+	return sanitized
+}
+
+// outputPathVars collects the template variables for a collection started at startTime
+func (sbc *SupportBundleCollector) outputPathVars(options SupportBundleCollectOptions, opts autodiscovery.DiscoveryOptions, startTime time.Time) OutputPathVars {
+	vars := OutputPathVars{
+		ClusterName: sbc.clusterName,
+		Context:     sbc.kubeContext,
+		Profile:     "default",
+		Config:      "none",
+		Namespace:   "all",
+		Timestamp:   startTime.Format(outputTimestampLayout),
+		Date:        startTime.Format("2006-01-02"),
+	}
+	if options.ProfileName != "" {
+		vars.Profile = options.ProfileName
+	}
+	if options.ConfigFile != "" {
+		base := filepath.Base(options.ConfigFile)
+		vars.Config = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if len(opts.Namespaces) == 1 {
+		vars.Namespace = opts.Namespaces[0]
+	}
+	if sbc.kubeClient != nil {
+		if version, err := sbc.kubeClient.Discovery().ServerVersion(); err == nil {
+			vars.KubernetesVersion = version.GitVersion
+		}
+	}
+	return vars
+}
+
+// usesInClusterConfig mirrors loadKubernetesConfig, which prefers the in-cluster config unless a kubeconfig or --dev is given
+func usesInClusterConfig(options SupportBundleCollectOptions) bool {
+	if options.KubeconfigPath != "" || options.Dev {
+		return false
+	}
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
+
+// resolveClusterIdentity returns the kubeconfig context and its cluster name for the --output variables
+// In a pod, or when the kubeconfig cannot be read, the context is "in-cluster" and the cluster is the API server host
+func resolveClusterIdentity(options SupportBundleCollectOptions, config *rest.Config) (string, string) {
+	if !usesInClusterConfig(options) {
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		rules.ExplicitPath = options.KubeconfigPath
+		if raw, err := rules.Load(); err == nil {
+			contextName := options.Context
+			if contextName == "" {
+				contextName = raw.CurrentContext
+			}
+			if kubeContext, ok := raw.Contexts[contextName]; ok && kubeContext.Cluster != "" {
+				return contextName, kubeContext.Cluster
+			}
+		}
+	}
+
+	host := config.Host
+	if u, err := url.Parse(config.Host); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return "in-cluster", host
+}
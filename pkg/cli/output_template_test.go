@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderOutputPath(t *testing.T) {
+	vars := OutputPathVars{
+		ClusterName:       "prod-eu",
+		Context:           "admin@prod-eu",
+		KubernetesVersion: "v1.29.2+k3s1",
+		Profile:           "standard",
+		Config:            "none",
+		Namespace:         "all",
+		Timestamp:         "2024-03-01T09-30-00",
+		Date:              "2024-03-01",
+	}
+
+	tests := []struct {
+		name        string
+		template    string
+		vars        OutputPathVars
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "cluster, timestamp and profile",
+			template: "bundles/{{.ClusterName}}/{{.Timestamp}}-{{.Profile}}.tgz",
+			vars:     vars,
+			expected: "bundles/prod-eu/2024-03-01T09-30-00-standard.tgz",
+		},
+		{
+			name:     "unsafe characters are replaced",
+			template: "{{.Context}}/{{.KubernetesVersion}}",
+			vars:     vars,
+			expected: "admin-prod-eu/v1.29.2-k3s1",
+		},
+		{
+			name:     "values cannot leave the directory",
+			template: "bundles/{{.ClusterName}}/{{.Date}}",
+			vars:     OutputPathVars{ClusterName: "../../etc", Date: ".."},
+			expected: "bundles/..-..-etc/unknown",
+		},
+		{
+			name:     "empty values",
+			template: "bundles/{{.ClusterName}}",
+			expected: "bundles/unknown",
+		},
+		{
+			name:        "unknown variable",
+			template:    "bundles/{{.Cluster}}",
+			expectError: true,
+		},
+		{
+			name:        "invalid template",
+			template:    "bundles/{{.ClusterName",
+			expectError: true,
+		},
+		{
+			name:        "empty path",
+			template:    "{{if false}}x{{end}}",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := RenderOutputPath(tt.template, tt.vars)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if path != filepath.FromSlash(tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, path)
+			}
+		})
+	}
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	tests := []struct {
+		path                string
+		expectedDir         string
+		expectedCompression string
+	}{
+		{path: "bundles/a.tgz", expectedDir: "bundles/a", expectedCompression: CompressionGzip},
+		{path: "bundles/a.tar.gz", expectedDir: "bundles/a", expectedCompression: CompressionGzip},
+		{path: "bundles/a.tar.zst", expectedDir: "bundles/a", expectedCompression: CompressionZstd},
+		{path: "bundles/a.tzst", expectedDir: "bundles/a", expectedCompression: CompressionZstd},
+		{path: "bundles/a", expectedDir: "bundles/a"},
+		{path: ".tgz", expectedDir: ".tgz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			dir, compression := splitArchivePath(tt.path)
+			if dir != tt.expectedDir || compression != tt.expectedCompression {
+				t.Errorf("Expected %q and %q, got %q and %q", tt.expectedDir, tt.expectedCompression, dir, compression)
+			}
+		})
+	}
+}
+
+func TestValidateOutputTemplate(t *testing.T) {
+	if err := ValidateOutputTemplate("bundles/{{.ClusterName}}.tgz", CompressionGzip); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := ValidateOutputTemplate("bundles/{{.ClusterName}}", CompressionNone); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := ValidateOutputTemplate("bundles/{{.ClusterName}}.tgz", CompressionZstd); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Errorf("Expected a conflict with --compression zstd, got %v", err)
+	}
+	if err := ValidateOutputTemplate("bundles/{{.ClusterName}}.tar.zst", CompressionNone); err == nil {
+		t.Errorf("Expected a conflict with --compression none")
+	}
+	if err := ValidateOutputTemplate("bundles/{{.Bogus}}", ""); err == nil {
+		t.Errorf("Expected an error for an unknown variable")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if err := ValidateOutputTemplate("bundles/{{.ClusterName}}.tar.zst", ""); err == nil || !strings.Contains(err.Error(), "zstd command") {
+		t.Errorf("Expected a .tar.zst output to require the zstd command, got %v", err)
+	}
+}
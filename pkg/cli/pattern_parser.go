@@ -1,18 +1,24 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 )
 
 // PatternParser handles parsing of exclusion/inclusion patterns from CLI flags and config
 type PatternParser struct {
 	exclusionPatterns []string
 	inclusionPatterns []string
+
+	discoveryClient discovery.DiscoveryInterface // Resolves aliases and CRD short names, see SetDiscoveryClient
+	aliases         resourceAliases
+	aliasesErr      error
 }
 
 // NewPatternParser creates a new pattern parser
@@ -104,6 +110,46 @@ func (pp *PatternParser) ConvertToResourceFilterRules() []autodiscovery.Resource
 	return rules
 }
 
+// ResourceFilterRules converts parsed patterns to resource filter rules like ConvertToResourceFilterRules,
+// but returns an error for the first pattern that cannot be converted instead of skipping it
+func (pp *PatternParser) ResourceFilterRules() ([]autodiscovery.ResourceFilterRule, error) {
+	var rules []autodiscovery.ResourceFilterRule
+	for i, pattern := range pp.exclusionPatterns {
+		rule, err := pp.patternToResourceFilter(pattern, "exclude", fmt.Sprintf("cli-exclude-%d", i))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion pattern '%s': %w", pattern, err)
+		}
+		rules = append(rules, rule)
+	}
+	for i, pattern := range pp.inclusionPatterns {
+		rule, err := pp.patternToResourceFilter(pattern, "include", fmt.Sprintf("cli-include-%d", i))
+		if err != nil {
+			return nil, fmt.Errorf("invalid inclusion pattern '%s': %w", pattern, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// NewPatternFilterHook parses --exclude and --include patterns into a discovery pre-filter hook. Resource names,
+// kubectl short names and CRD short names resolve through the cluster's discovery API, so a failed discovery or
+// a type the cluster does not serve is an error
+func NewPatternFilterHook(exclude, include string, client discovery.DiscoveryInterface) (*autodiscovery.ResourceFilterHook, error) {
+	parser := NewPatternParser()
+	parser.SetDiscoveryClient(client)
+	if err := parser.ParseExclusionFlag(exclude); err != nil {
+		return nil, err
+	}
+	if err := parser.ParseInclusionFlag(include); err != nil {
+		return nil, err
+	}
+	rules, err := parser.ResourceFilterRules()
+	if err != nil {
+		return nil, err
+	}
+	return autodiscovery.NewResourceFilterHook("cli-patterns", rules), nil
+}
+
 // validatePattern validates a pattern syntax
 func (pp *PatternParser) validatePattern(pattern string) error {
 	// Support various pattern formats:
@@ -146,8 +192,8 @@ func (pp *PatternParser) validateNamespacePattern(pattern string) error {
 	if pattern == "" {
 		return fmt.Errorf("namespace pattern cannot be empty")
 	}
-	// Allow wildcards in namespace names
-	if strings.Contains(pattern, "*") {
+	// Allow globs in namespace names
+	if autodiscovery.IsGlob(pattern) {
 		// Validate wildcard pattern
 		return pp.validateWildcardPattern(pattern)
 	}
@@ -205,8 +251,8 @@ func (pp *PatternParser) validateRegexPattern(pattern string) error {
 }
 
 func (pp *PatternParser) validateResourcePattern(pattern string) error {
-	// Allow wildcards in resource patterns
-	if strings.Contains(pattern, "*") {
+	// Allow globs in resource patterns
+	if autodiscovery.IsGlob(pattern) {
 		return pp.validateWildcardPattern(pattern)
 	}
 	return pp.validateResourceName(pattern)
@@ -222,6 +268,10 @@ func (pp *PatternParser) validateWildcardPattern(pattern string) error {
 	if pattern == "*" {
 		return fmt.Errorf("pattern cannot be only '*'")
 	}
+
+	if err := autodiscovery.ValidateGlob(pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
 	
 	return nil
 }
@@ -268,7 +318,14 @@ func (pp *PatternParser) patternToResourceFilter(pattern, action, name string) (
 			rule.MatchNamespaces = []string{value}
 		}
 	} else {
-		// Simple resource name pattern
+		// Simple resource name pattern; once discovered, a name the cluster does not serve is an error
+		aliases, err := pp.discoveredAliases()
+		if err != nil {
+			return rule, fmt.Errorf("failed to resolve resource type %q: %w", pattern, err)
+		}
+		if _, ok := pp.resolveResource(pattern); !ok && len(aliases) > 0 && !autodiscovery.IsGlob(pattern) {
+			return rule, fmt.Errorf("the server doesn't have a resource type %q", pattern)
+		}
 		gvr := pp.resourceNameToGVR(pattern)
 		rule.MatchGVRs = []schema.GroupVersionResource{gvr}
 	}
@@ -307,26 +364,8 @@ func (pp *PatternParser) parseGVR(gvrString string) (schema.GroupVersionResource
 }
 
 func (pp *PatternParser) resourceNameToGVR(resource string) schema.GroupVersionResource {
-	// Map common resource names to their proper GVRs
-	resourceMap := map[string]schema.GroupVersionResource{
-		"pods":                  {Group: "", Version: "v1", Resource: "pods"},
-		"services":              {Group: "", Version: "v1", Resource: "services"},
-		"configmaps":            {Group: "", Version: "v1", Resource: "configmaps"},
-		"secrets":               {Group: "", Version: "v1", Resource: "secrets"},
-		"events":                {Group: "", Version: "v1", Resource: "events"},
-		"persistentvolumeclaims": {Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
-		"nodes":                 {Group: "", Version: "v1", Resource: "nodes"},
-		"deployments":           {Group: "apps", Version: "v1", Resource: "deployments"},
-		"replicasets":           {Group: "apps", Version: "v1", Resource: "replicasets"},
-		"statefulsets":          {Group: "apps", Version: "v1", Resource: "statefulsets"},
-		"daemonsets":            {Group: "apps", Version: "v1", Resource: "daemonsets"},
-		"jobs":                  {Group: "batch", Version: "v1", Resource: "jobs"},
-		"cronjobs":              {Group: "batch", Version: "v1", Resource: "cronjobs"},
-		"ingresses":             {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
-		"networkpolicies":       {Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
-	}
-
-	if gvr, exists := resourceMap[resource]; exists {
+	// Resolve names, singular names and short names, e.g. deploy or svc, to their proper GVRs
+	if gvr, ok := pp.resolveResource(resource); ok {
 		return gvr
 	}
 
@@ -345,6 +384,9 @@ Pattern Syntax Help:
 
 Resource Types:
   pods, services, deployments, configmaps, secrets, events, jobs, etc.
+  Singular and short names work as in kubectl: pod, po, svc, cm, deploy, sts, ds, ing
+  Group-qualified names pick one of several types: deploy.apps, certificates.cert-manager.io
+  Connected to a cluster, CRD names and short names resolve too
 
 Namespace Patterns:
   ns:kube-system          - Specific namespace
@@ -365,6 +407,7 @@ Regex Patterns:
 
 Examples:
   --exclude "kube-*,ns:kube-system,secrets"
+  --exclude "deploy,svc"
   --include "label:app=myapp,deployments,services"
   --exclude "regex:.*-test$;ns:test-*"
 `
@@ -400,6 +443,12 @@ func (pp *PatternParser) TestPatternMatching(sampleResources []autodiscovery.Res
 	return results
 }
 
+// PreviewPlan previews what the parsed patterns would do to already discovered resources on top of
+// the rules configured in configManager, see ConfigManager.PlanPreview
+func (pp *PatternParser) PreviewPlan(ctx context.Context, configManager *autodiscovery.ConfigManager, resources []autodiscovery.Resource, opts autodiscovery.DiscoveryOptions) (*autodiscovery.PlanPreview, error) {
+	return configManager.PlanPreview(ctx, resources, pp.ConvertToResourceFilterRules(), opts)
+}
+
 func (pp *PatternParser) testRuleAgainstResource(rule autodiscovery.ResourceFilterRule, resource autodiscovery.Resource) bool {
 	// Test GVR match
 	if len(rule.MatchGVRs) > 0 {
@@ -419,7 +468,7 @@ func (pp *PatternParser) testRuleAgainstResource(rule autodiscovery.ResourceFilt
 	if len(rule.MatchNamespaces) > 0 {
 		found := false
 		for _, ns := range rule.MatchNamespaces {
-			if matchPattern(ns, resource.Namespace) {
+			if autodiscovery.MatchGlob(ns, resource.Namespace) {
 				found = true
 				break
 			}
@@ -491,28 +540,6 @@ func (pp *PatternParser) testLabelSelector(selector string, labels map[string]st
 	return exists
 }
 
-// matchPattern tests if a value matches a pattern (supports wildcards)
-func matchPattern(pattern, value string) bool {
-	if pattern == value {
-		return true
-	}
-
-	// Handle wildcard patterns
-	if strings.Contains(pattern, "*") {
-		// Convert shell-style wildcard to regex
-		regexPattern := strings.ReplaceAll(pattern, "*", ".*")
-		regexPattern = "^" + regexPattern + "$"
-		
-		matched, err := regexp.MatchString(regexPattern, value)
-		if err != nil {
-			return false
-		}
-		return matched
-	}
-
-	return false
-}
-
 // GetExclusionPatterns returns the parsed exclusion patterns
 func (pp *PatternParser) GetExclusionPatterns() []string {
 	return pp.exclusionPatterns
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -300,6 +301,22 @@ func TestPatternParser_ResourceNameToGVR(t *testing.T) {
 			resourceName: "ingresses",
 			expectedGVR:  schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
 		},
+		{
+			resourceName: "deploy",
+			expectedGVR:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		},
+		{
+			resourceName: "svc",
+			expectedGVR:  schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"},
+		},
+		{
+			resourceName: "cm",
+			expectedGVR:  schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"},
+		},
+		{
+			resourceName: "netpol.networking.k8s.io",
+			expectedGVR:  schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+		},
 		{
 			resourceName: "unknown-resource",
 			expectedGVR:  schema.GroupVersionResource{Group: "", Version: "v1", Resource: "unknown-resource"}, // Default to core
@@ -569,11 +586,17 @@ func TestMatchPattern(t *testing.T) {
 		{"*", "anything", true},
 		{"", "", true},
 		{"*test*", "my-test-app", true},
+
+		// Shared glob syntax
+		{"app-?", "app-1", true},
+		{"app-[0-9]", "app-x", false},
+		{"team-[!a]*", "team-b-prod", true},
+		{"app.v1", "app-v1", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s_%s", tt.pattern, tt.value), func(t *testing.T) {
-			result := matchPattern(tt.pattern, tt.value)
+			result := autodiscovery.MatchGlob(tt.pattern, tt.value)
 
 			if result != tt.expected {
 				t.Errorf("Pattern '%s' matching '%s': expected %v, got %v", tt.pattern, tt.value, tt.expected, result)
@@ -582,6 +605,38 @@ func TestMatchPattern(t *testing.T) {
 	}
 }
 
+func TestPatternParser_PreviewPlan(t *testing.T) {
+	parser := NewPatternParser()
+	if err := parser.ParseExclusionFlag("secrets,label:tier=debug"); err != nil {
+		t.Fatalf("Failed to parse patterns: %v", err)
+	}
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resources := []autodiscovery.Resource{
+		{GVR: pods, Namespace: "default", Name: "web", Labels: map[string]string{"tier": "frontend"}},
+		{GVR: pods, Namespace: "default", Name: "debug", Labels: map[string]string{"tier": "debug"}},
+		{GVR: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, Namespace: "default", Name: "token"},
+	}
+
+	preview, err := parser.PreviewPlan(context.Background(), autodiscovery.NewConfigManager(), resources, autodiscovery.DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	removed := make(map[string]int)
+	for _, rule := range preview.Rules {
+		if rule.Candidate {
+			removed[rule.Name] = rule.Removed
+		}
+	}
+	if removed["cli-exclude-0"] != 1 || removed["cli-exclude-1"] != 1 {
+		t.Errorf("Expected each pattern to remove one resource, got %+v", preview.Rules)
+	}
+	if preview.KeptResources != 1 || preview.Resources[0].Name != "web" {
+		t.Errorf("Expected only the web pod to be kept, got %+v", preview.Resources)
+	}
+}
+
 // Benchmark pattern parsing performance
 func BenchmarkPatternParser_ConvertToResourceFilterRules(b *testing.B) {
 	parser := NewPatternParser()
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RBACRemediationFileName is the manifest granting the missing read permissions found by an RBAC check
+const RBACRemediationFileName = "rbac-remediation.yaml"
+
+// rbacRemediationName names the generated roles and bindings
+const rbacRemediationName = "troubleshoot-read"
+
+// rbacReadVerbs are the only verbs a remediation grants, exec and other write access are left to the operator
+var rbacReadVerbs = map[string]bool{"get": true, "list": true, "watch": true}
+
+// RBACSubject is who a remediation binds the missing permissions to
+type RBACSubject struct {
+	Kind      string `json:"kind" yaml:"kind"` // ServiceAccount, User or Group
+	APIGroup  string `json:"apiGroup,omitempty" yaml:"apiGroup,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// defaultRBACSubject is used when the current identity cannot be determined
+var defaultRBACSubject = RBACSubject{Kind: "ServiceAccount", Name: "troubleshoot", Namespace: "default"}
+
+// rbacManifestMetadata, rbacPolicyRule and rbacRoleRef mirror the rbac.authorization.k8s.io/v1 fields the manifest uses
+type rbacManifestMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type rbacPolicyRule struct {
+	APIGroups []string `yaml:"apiGroups"`
+	Resources []string `yaml:"resources"`
+	Verbs     []string `yaml:"verbs"`
+}
+
+type rbacRoleRef struct {
+	APIGroup string `yaml:"apiGroup"`
+	Kind     string `yaml:"kind"`
+	Name     string `yaml:"name"`
+}
+
+type rbacRoleManifest struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   rbacManifestMetadata `yaml:"metadata"`
+	Rules      []rbacPolicyRule     `yaml:"rules"`
+}
+
+type rbacBindingManifest struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   rbacManifestMetadata `yaml:"metadata"`
+	RoleRef    rbacRoleRef          `yaml:"roleRef"`
+	Subjects   []RBACSubject        `yaml:"subjects"`
+}
+
+// MissingReadPermissions returns the denied get, list and watch permissions in a report, sorted and without duplicates
+// Checks that failed with an error are skipped, only permissions known to be denied are included
+func MissingReadPermissions(report *RBACValidationReport) []CollectorPermission {
+	if report == nil {
+		return nil
+	}
+
+	seen := make(map[CollectorPermission]bool)
+	var missing []CollectorPermission
+	add := func(perm CollectorPermission) {
+		if rbacReadVerbs[perm.Verb] && !seen[perm] {
+			seen[perm] = true
+			missing = append(missing, perm)
+		}
+	}
+
+	namespacesGVR := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	for _, result := range report.NamespaceResults {
+		if !result.Allowed && result.Error == "" {
+			add(CollectorPermission{Verb: "get", GVR: namespacesGVR, Namespace: result.Namespace})
+		}
+	}
+	for _, result := range report.ResourceResults {
+		if result.Error != "" {
+			continue
+		}
+		if !result.GetAllowed {
+			add(CollectorPermission{Verb: "get", GVR: result.GVR, Namespace: result.Namespace})
+		}
+		if !result.ListAllowed {
+			add(CollectorPermission{Verb: "list", GVR: result.GVR, Namespace: result.Namespace})
+		}
+	}
+	for _, result := range report.CollectorResults {
+		for _, perm := range result.Denied {
+			add(perm)
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		a, b := missing[i], missing[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.GVR.Group != b.GVR.Group {
+			return a.GVR.Group < b.GVR.Group
+		}
+		if a.GVR.Resource != b.GVR.Resource {
+			return a.GVR.Resource < b.GVR.Resource
+		}
+		if a.Subresource != b.Subresource {
+			return a.Subresource < b.Subresource
+		}
+		return a.Verb < b.Verb
+	})
+	return missing
+}
+
+// GenerateRBACRemediation renders a Role and RoleBinding per namespace, and a ClusterRole and ClusterRoleBinding
+// for cluster-scoped permissions, granting exactly the missing read permissions to subject
+// It returns nil when nothing is missing
+func GenerateRBACRemediation(missing []CollectorPermission, subject RBACSubject) ([]byte, error) {
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	byNamespace := make(map[string][]CollectorPermission)
+	var namespaces []string
+	for _, perm := range missing {
+		if _, ok := byNamespace[perm.Namespace]; !ok {
+			namespaces = append(namespaces, perm.Namespace)
+		}
+		byNamespace[perm.Namespace] = append(byNamespace[perm.Namespace], perm)
+	}
+	sort.Strings(namespaces)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Grants the read permissions a troubleshoot RBAC check found missing, apply with kubectl apply -f %s\n", RBACRemediationFileName)
+	fmt.Fprintf(&buf, "# Bound to %s %s, edit the subjects to grant them to another identity\n", subject.Kind, subjectName(subject))
+
+	for _, namespace := range namespaces {
+		roleKind, bindingKind := "Role", "RoleBinding"
+		if namespace == "" {
+			roleKind, bindingKind = "ClusterRole", "ClusterRoleBinding"
+		}
+		metadata := rbacManifestMetadata{Name: rbacRemediationName, Namespace: namespace}
+
+		role := rbacRoleManifest{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       roleKind,
+			Metadata:   metadata,
+			Rules:      remediationRules(byNamespace[namespace]),
+		}
+		binding := rbacBindingManifest{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       bindingKind,
+			Metadata:   metadata,
+			RoleRef:    rbacRoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: roleKind, Name: rbacRemediationName},
+			Subjects:   []RBACSubject{subject},
+		}
+
+		for _, manifest := range []interface{}{role, binding} {
+			data, err := yaml.Marshal(manifest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal RBAC remediation: %w", err)
+			}
+			buf.WriteString("---\n")
+			buf.Write(data)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// remediationRules groups permissions into one rule per API group and verb set, listing their resources
+func remediationRules(perms []CollectorPermission) []rbacPolicyRule {
+	type resourceKey struct{ group, resource string }
+	verbs := make(map[resourceKey][]string)
+	var keys []resourceKey
+	for _, perm := range perms {
+		resource := perm.GVR.Resource
+		if perm.Subresource != "" {
+			resource += "/" + perm.Subresource
+		}
+		key := resourceKey{perm.GVR.Group, resource}
+		if _, ok := verbs[key]; !ok {
+			keys = append(keys, key)
+		}
+		verbs[key] = append(verbs[key], perm.Verb)
+	}
+
+	var rules []rbacPolicyRule
+	ruleIndex := make(map[string]int)
+	for _, key := range keys {
+		sort.Strings(verbs[key])
+		id := key.group + "|" + strings.Join(verbs[key], ",")
+		if i, ok := ruleIndex[id]; ok {
+			rules[i].Resources = append(rules[i].Resources, key.resource)
+			continue
+		}
+		ruleIndex[id] = len(rules)
+		rules = append(rules, rbacPolicyRule{APIGroups: []string{key.group}, Resources: []string{key.resource}, Verbs: verbs[key]})
+	}
+	return rules
+}
+
+// WriteRBACRemediation writes the remediation for a report into dir, returning "" when nothing is missing
+func WriteRBACRemediation(dir string, report *RBACValidationReport, subject RBACSubject) (string, error) {
+	data, err := GenerateRBACRemediation(MissingReadPermissions(report), subject)
+	if err != nil || data == nil {
+		return "", err
+	}
+	path := filepath.Join(dir, RBACRemediationFileName)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write RBAC remediation: %w", err)
+	}
+	return path, nil
+}
+
+// CurrentSubject returns the identity the RBAC checks ran as, or a troubleshoot ServiceAccount when it cannot be determined
+func (rv *RBACValidator) CurrentSubject(ctx context.Context) RBACSubject {
+	if rv.kubeClient == nil {
+		return defaultRBACSubject
+	}
+	review, err := rv.kubeClient.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return defaultRBACSubject
+	}
+	return subjectForUser(review.Status.UserInfo.Username)
+}
+
+// subjectForUser maps a username to a binding subject, service accounts authenticate as system:serviceaccount:<namespace>:<name>
+func subjectForUser(username string) RBACSubject {
+	if username == "" {
+		return defaultRBACSubject
+	}
+	if rest, ok := strings.CutPrefix(username, "system:serviceaccount:"); ok {
+		if namespace, name, ok := strings.Cut(rest, ":"); ok && namespace != "" && name != "" {
+			return RBACSubject{Kind: "ServiceAccount", Name: name, Namespace: namespace}
+		}
+	}
+	return RBACSubject{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: username}
+}
+
+func subjectName(subject RBACSubject) string {
+	if subject.Namespace != "" {
+		return subject.Namespace + "/" + subject.Name
+	}
+	return subject.Name
+}
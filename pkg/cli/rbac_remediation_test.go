@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testRemediationReport() *RBACValidationReport {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	events := schema.GroupVersionResource{Version: "v1", Resource: "events"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+	return &RBACValidationReport{
+		NamespaceResults: []RBACNamespaceResult{
+			{Namespace: "default", Allowed: true},
+			{Namespace: "locked", Allowed: false},
+		},
+		ResourceResults: []RBACResourceResult{
+			{GVR: pods, Namespace: "default", GetAllowed: true, ListAllowed: true},
+			{GVR: events, Namespace: "default", GetAllowed: false, ListAllowed: false},
+			{GVR: deployments, Namespace: "default", GetAllowed: true, ListAllowed: false},
+			{GVR: pods, Namespace: "other", GetAllowed: false, ListAllowed: false, Error: "timeout"},
+		},
+		CollectorResults: []CollectorRBACResult{
+			{
+				CollectorName: "auto-exec-db",
+				Denied: []CollectorPermission{
+					{Verb: "create", GVR: pods, Subresource: "exec", Namespace: "default"},
+					{Verb: "get", GVR: pods, Subresource: "log", Namespace: "default"},
+				},
+			},
+			{
+				CollectorName: "auto-nodes",
+				Denied:        []CollectorPermission{{Verb: "list", GVR: nodes}},
+			},
+			{
+				CollectorName: "auto-events",
+				Denied:        []CollectorPermission{{Verb: "list", GVR: events, Namespace: "default"}},
+			},
+		},
+	}
+}
+
+func TestMissingReadPermissions(t *testing.T) {
+	missing := MissingReadPermissions(testRemediationReport())
+
+	var described []string
+	for _, perm := range missing {
+		described = append(described, perm.String())
+	}
+	expected := []string{
+		"list nodes",
+		"get events in default",
+		"list events in default",
+		"get pods/log in default",
+		"list deployments in default",
+		"get namespaces in locked",
+	}
+	if strings.Join(described, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("Expected %v, got %v", expected, described)
+	}
+
+	if MissingReadPermissions(nil) != nil {
+		t.Errorf("Expected no permissions for a nil report")
+	}
+}
+
+func TestGenerateRBACRemediation(t *testing.T) {
+	subject := RBACSubject{Kind: "ServiceAccount", Name: "support", Namespace: "tools"}
+	data, err := GenerateRBACRemediation(MissingReadPermissions(testRemediationReport()), subject)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var roles []rbacRoleManifest
+	var bindings []rbacBindingManifest
+	for _, doc := range strings.Split(string(data), "---\n")[1:] {
+		var kind struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &kind); err != nil {
+			t.Fatalf("Failed to parse manifest: %v", err)
+		}
+		if strings.HasSuffix(kind.Kind, "Binding") {
+			var binding rbacBindingManifest
+			yaml.Unmarshal([]byte(doc), &binding)
+			bindings = append(bindings, binding)
+		} else {
+			var role rbacRoleManifest
+			yaml.Unmarshal([]byte(doc), &role)
+			roles = append(roles, role)
+		}
+	}
+
+	if len(roles) != 3 || len(bindings) != 3 {
+		t.Fatalf("Expected 3 roles and 3 bindings, got %d and %d", len(roles), len(bindings))
+	}
+	if roles[0].Kind != "ClusterRole" || roles[0].Metadata.Namespace != "" || bindings[0].Kind != "ClusterRoleBinding" {
+		t.Errorf("Expected cluster-scoped permissions first in a ClusterRole, got %+v", roles[0])
+	}
+
+	defaultRole := roles[1]
+	if defaultRole.Kind != "Role" || defaultRole.Metadata.Namespace != "default" {
+		t.Fatalf("Expected a Role in default, got %+v", defaultRole)
+	}
+	expectedRules := []string{
+		" events: get,list",
+		" pods/log: get",
+		"apps deployments: list",
+	}
+	var rules []string
+	for _, rule := range defaultRole.Rules {
+		rules = append(rules, strings.Join(rule.APIGroups, ",")+" "+strings.Join(rule.Resources, ",")+": "+strings.Join(rule.Verbs, ","))
+	}
+	if strings.Join(rules, "; ") != strings.Join(expectedRules, "; ") {
+		t.Errorf("Expected rules %v, got %v", expectedRules, rules)
+	}
+	for _, rule := range defaultRole.Rules {
+		for _, verb := range rule.Verbs {
+			if !rbacReadVerbs[verb] {
+				t.Errorf("Expected only read verbs, got %s", verb)
+			}
+		}
+	}
+
+	binding := bindings[1]
+	if binding.RoleRef.Kind != "Role" || binding.RoleRef.Name != rbacRemediationName || len(binding.Subjects) != 1 || binding.Subjects[0] != subject {
+		t.Errorf("Expected the Role bound to %+v, got %+v", subject, binding)
+	}
+
+	empty, err := GenerateRBACRemediation(nil, subject)
+	if err != nil || empty != nil {
+		t.Errorf("Expected no manifest without missing permissions, got %q, %v", empty, err)
+	}
+}
+
+func TestWriteRBACRemediation(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := WriteRBACRemediation(dir, &RBACValidationReport{}, defaultRBACSubject)
+	if err != nil || path != "" {
+		t.Errorf("Expected nothing written for a report without denials, got %q, %v", path, err)
+	}
+
+	path, err = WriteRBACRemediation(dir, testRemediationReport(), defaultRBACSubject)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != filepath.Join(dir, RBACRemediationFileName) {
+		t.Errorf("Expected %s, got %s", filepath.Join(dir, RBACRemediationFileName), path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the remediation to be written: %v", err)
+	}
+}
+
+func TestSubjectForUser(t *testing.T) {
+	tests := []struct {
+		username string
+		expected RBACSubject
+	}{
+		{username: "system:serviceaccount:tools:support", expected: RBACSubject{Kind: "ServiceAccount", Name: "support", Namespace: "tools"}},
+		{username: "jane@example.com", expected: RBACSubject{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "jane@example.com"}},
+		{username: "system:serviceaccount:broken", expected: RBACSubject{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "system:serviceaccount:broken"}},
+		{username: "", expected: defaultRBACSubject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			if subject := subjectForUser(tt.username); subject != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, subject)
+			}
+		})
+	}
+}
@@ -39,7 +39,10 @@ type RBACValidationReport struct {
 	DeniedResources   int                         `json:"deniedResources"`
 	ResourceResults   []RBACResourceResult        `json:"resourceResults"`
 	NamespaceResults  []RBACNamespaceResult       `json:"namespaceResults"`
+	CollectorResults  []CollectorRBACResult       `json:"collectorResults,omitempty"`
+	FailingCollectors int                         `json:"failingCollectors"`
 	Summary           RBACValidationSummary       `json:"summary"`
+	RemediationPath   string                      `json:"remediationPath,omitempty"` // rbac-remediation.yaml granting the missing read permissions
 }
 
 // RBACResourceResult represents RBAC check result for a specific resource type
@@ -415,6 +418,15 @@ func PrintRBACValidationReport(report *RBACValidationReport) {
 		}
 	}
 
+	if report.FailingCollectors > 0 {
+		fmt.Printf("\n🚫 Collectors Missing Execution Permissions: %d\n", report.FailingCollectors)
+		for _, result := range report.CollectorResults {
+			if result.WillFail {
+				fmt.Printf("  ❌ %s (%s): %s\n", result.CollectorName, result.CollectorType, describeDeniedPermissions(result.Denied))
+			}
+		}
+	}
+
 	if len(report.Summary.Recommendations) > 0 {
 		fmt.Printf("\n💡 Recommendations:\n")
 		for _, rec := range report.Summary.Recommendations {
@@ -422,6 +434,10 @@ func PrintRBACValidationReport(report *RBACValidationReport) {
 		}
 	}
 
+	if report.RemediationPath != "" {
+		fmt.Printf("\n🛠️ Missing read permissions: kubectl apply -f %s\n", report.RemediationPath)
+	}
+
 	fmt.Printf("\n")
 }
 
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+// printRegistryCatalogSummary prints the registries pods pull from, with their auth and reachability
+func printRegistryCatalogSummary(catalog *images.RegistryCatalog) {
+	writeRegistryCatalogSummary(os.Stdout, catalog)
+}
+
+// writeRegistryCatalogSummary writes one line per registry, and warnings for unreachable registries and unusable credentials
+func writeRegistryCatalogSummary(w io.Writer, catalog *images.RegistryCatalog) {
+	summary := catalog.Summary
+	fmt.Fprintf(w, "📦 Registries: %d images from %d registries\n", summary.TotalImages, summary.TotalRegistries)
+	for _, entry := range catalog.Registries {
+		fmt.Fprintf(w, "   %s: %d images, %d pods, auth %s, %s\n",
+			entry.Registry, entry.ImageCount, entry.Pods, entry.Auth, describeRegistryReachability(entry))
+	}
+	if summary.UnreachableRegistries > 0 {
+		fmt.Fprintf(w, "   ⚠️  %d registries are unreachable from this environment\n", summary.UnreachableRegistries)
+	}
+	if summary.UnusableCredentials > 0 {
+		fmt.Fprintf(w, "   ⚠️  %d registries have pull secrets without a usable credential\n", summary.UnusableCredentials)
+	}
+}
+
+// describeRegistryReachability names the mirror that answered when it is not the registry itself
+func describeRegistryReachability(entry images.RegistryCatalogEntry) string {
+	reachability := entry.Reachability
+	switch {
+	case reachability == nil:
+		return "not probed"
+	case reachability.Status == images.RegistryUnreachable:
+		return fmt.Sprintf("unreachable (%s)", reachability.Error)
+	case reachability.Endpoint != "" && reachability.Endpoint != entry.Registry:
+		return fmt.Sprintf("%s via %s in %v", reachability.Status, reachability.Endpoint, reachability.Latency.Round(time.Millisecond))
+	default:
+		return fmt.Sprintf("%s in %v", reachability.Status, reachability.Latency.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+)
+
+func testRegistryCatalog() *images.RegistryCatalog {
+	return &images.RegistryCatalog{
+		Registries: []images.RegistryCatalogEntry{
+			{Registry: "registry.example.com", ImageCount: 3, Pods: 4, Auth: images.RegistryAuthUnverified,
+				Reachability: &images.RegistryReachability{Status: images.RegistryAuthRequired, Endpoint: "registry.example.com", Latency: 20 * time.Millisecond}},
+			{Registry: "index.docker.io", ImageCount: 2, Pods: 2, Auth: images.RegistryAuthAnonymous,
+				Reachability: &images.RegistryReachability{Status: images.RegistryReachable, Endpoint: "mirror.internal", Latency: 5 * time.Millisecond}},
+			{Registry: "gcr.io", ImageCount: 1, Pods: 1, Auth: images.RegistryAuthPullSecretUnusable,
+				Reachability: &images.RegistryReachability{Status: images.RegistryUnreachable, Endpoint: "gcr.io", Error: "i/o timeout"}},
+		},
+		Summary: images.RegistryCatalogSummary{TotalRegistries: 3, TotalImages: 6, UnreachableRegistries: 1, UnusableCredentials: 1},
+	}
+}
+
+func TestWriteRegistryCatalogSummary(t *testing.T) {
+	var buf bytes.Buffer
+	writeRegistryCatalogSummary(&buf, testRegistryCatalog())
+	output := buf.String()
+
+	expected := []string{
+		"📦 Registries: 6 images from 3 registries",
+		"   registry.example.com: 3 images, 4 pods, auth unverified, auth-required in 20ms",
+		"   index.docker.io: 2 images, 2 pods, auth anonymous, reachable via mirror.internal in 5ms",
+		"   gcr.io: 1 images, 1 pods, auth pull-secret-unusable, unreachable (i/o timeout)",
+		"   ⚠️  1 registries are unreachable from this environment",
+		"   ⚠️  1 registries have pull secrets without a usable credential",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestApplyRegistryCatalog(t *testing.T) {
+	executor := NewDryRunExecutor(nil, nil)
+	analysis := executor.analyzeImageCollection(nil)
+
+	applyRegistryCatalog(analysis, testRegistryCatalog())
+
+	if analysis.ExpectedImages != 6 || analysis.EstimatedSize != "Small (< 10MB)" {
+		t.Errorf("Expected 6 images and a small size, got %d and %s", analysis.ExpectedImages, analysis.EstimatedSize)
+	}
+	expectedRegistries := []string{"registry.example.com", "index.docker.io", "gcr.io"}
+	if !reflect.DeepEqual(analysis.UniqueRegistries, expectedRegistries) {
+		t.Errorf("Expected %v, got %v", expectedRegistries, analysis.UniqueRegistries)
+	}
+	if len(analysis.AuthRequirements) != 1 || !strings.Contains(analysis.AuthRequirements[0], "registry.example.com") {
+		t.Errorf("Expected credentials to be needed for registry.example.com only, got %v", analysis.AuthRequirements)
+	}
+	if analysis.RegistryCatalog == nil {
+		t.Errorf("Expected the catalog to be kept in the analysis")
+	}
+}
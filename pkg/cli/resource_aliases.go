@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+)
+
+// builtinResourceAliases resolves the names, singular names and kubectl short names of built-in types when no
+// discovery client is set; plural names that are not listed default to core v1
+var builtinResourceAliases = map[string]schema.GroupVersionResource{}
+
+func init() {
+	types := []struct {
+		gvr     schema.GroupVersionResource
+		aliases []string
+	}{
+		{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, []string{"pod", "po"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "services"}, []string{"service", "svc"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, []string{"configmap", "cm"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, []string{"secret"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "events"}, []string{"event", "ev"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}, []string{"ep"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, []string{"serviceaccount", "sa"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, []string{"persistentvolumeclaim", "pvc"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}, []string{"persistentvolume", "pv"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, []string{"node", "no"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, []string{"namespace", "ns"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "resourcequotas"}, []string{"resourcequota", "quota"}},
+		{schema.GroupVersionResource{Version: "v1", Resource: "limitranges"}, []string{"limitrange", "limits"}},
+		{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, []string{"deployment", "deploy"}},
+		{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, []string{"replicaset", "rs"}},
+		{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, []string{"statefulset", "sts"}},
+		{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, []string{"daemonset", "ds"}},
+		{schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, []string{"job"}},
+		{schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, []string{"cronjob", "cj"}},
+		{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, []string{"ingress", "ing"}},
+		{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, []string{"networkpolicy", "netpol"}},
+		{schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, []string{"horizontalpodautoscaler", "hpa"}},
+		{schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}, []string{"poddisruptionbudget", "pdb"}},
+		{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, []string{"storageclass", "sc"}},
+		{schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, []string{"customresourcedefinition", "crd", "crds"}},
+	}
+	for _, t := range types {
+		for _, alias := range append([]string{t.gvr.Resource}, t.aliases...) {
+			builtinResourceAliases[alias] = t.gvr
+			if t.gvr.Group != "" {
+				builtinResourceAliases[alias+"."+t.gvr.Group] = t.gvr
+			}
+		}
+	}
+}
+
+// resourceAliases maps the names kubectl accepts for a type to the GVR at the server's preferred version: the
+// plural and singular names, short names and lowercase kind, alone or qualified by group, e.g. deploy.apps
+type resourceAliases map[string]schema.GroupVersionResource
+
+// discoverResourceAliases reads the aliases of every type the cluster serves, CRDs included
+// Groups are visited in the server's priority order, so a short name claimed by two groups resolves as in kubectl
+// Groups that fail discovery are skipped; an error is returned only when no resources could be read
+func discoverResourceAliases(client discovery.DiscoveryInterface) (resourceAliases, error) {
+	groups, lists, err := client.ServerGroupsAndResources()
+	if err != nil && len(lists) == 0 {
+		return nil, fmt.Errorf("failed to discover resource types: %w", err)
+	}
+
+	resourcesByGroupVersion := make(map[string][]string)
+	aliasesByGroupVersion := make(map[string]map[string]string)
+	for _, list := range lists {
+		names := make(map[string]string)
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") {
+				continue // Subresources, e.g. pods/log
+			}
+			resourcesByGroupVersion[list.GroupVersion] = append(resourcesByGroupVersion[list.GroupVersion], resource.Name)
+			names[resource.Name] = resource.Name
+			if resource.SingularName != "" {
+				names[resource.SingularName] = resource.Name
+			}
+			if resource.Kind != "" {
+				names[strings.ToLower(resource.Kind)] = resource.Name
+			}
+			for _, shortName := range resource.ShortNames {
+				names[shortName] = resource.Name
+			}
+		}
+		aliasesByGroupVersion[list.GroupVersion] = names
+	}
+
+	aliases := make(resourceAliases)
+	add := func(alias string, gvr schema.GroupVersionResource) {
+		if _, exists := aliases[alias]; !exists {
+			aliases[alias] = gvr
+		}
+		if gvr.Group == "" {
+			return
+		}
+		if _, exists := aliases[alias+"."+gvr.Group]; !exists {
+			aliases[alias+"."+gvr.Group] = gvr
+		}
+	}
+	for _, group := range groups {
+		gv, err := schema.ParseGroupVersion(group.PreferredVersion.GroupVersion)
+		if err != nil {
+			continue
+		}
+		// Plural names first, so a plural always wins over another type's singular or short name
+		for _, resource := range resourcesByGroupVersion[gv.String()] {
+			gvr := gv.WithResource(resource)
+			add(resource, gvr)
+		}
+		for alias, resource := range aliasesByGroupVersion[gv.String()] {
+			gvr := gv.WithResource(resource)
+			add(alias, gvr)
+		}
+	}
+	return aliases, nil
+}
+
+// SetDiscoveryClient resolves resource names in patterns with the types the cluster serves, so kubectl short
+// names such as deploy and svc, and the short names of CRDs, match the right type. The client is wrapped in a
+// memory cache unless it already caches discovery; unknown names are then rejected instead of assumed core v1
+func (pp *PatternParser) SetDiscoveryClient(client discovery.DiscoveryInterface) {
+	if _, cached := client.(discovery.CachedDiscoveryInterface); !cached {
+		client = memory.NewMemCacheClient(client)
+	}
+	pp.discoveryClient = client
+	pp.aliases = nil
+	pp.aliasesErr = nil
+}
+
+// discoveredAliases returns the cluster's aliases, discovering them on first use
+// It returns nil without a discovery client; a failed discovery is returned on every call
+func (pp *PatternParser) discoveredAliases() (resourceAliases, error) {
+	if pp.discoveryClient == nil {
+		return nil, nil
+	}
+	if pp.aliases == nil && pp.aliasesErr == nil {
+		pp.aliases, pp.aliasesErr = discoverResourceAliases(pp.discoveryClient)
+	}
+	return pp.aliases, pp.aliasesErr
+}
+
+// resolveResource resolves a resource name, alias or group-qualified alias to its GVR
+// The built-in aliases are used when discovery is not set or failed
+func (pp *PatternParser) resolveResource(name string) (schema.GroupVersionResource, bool) {
+	name = strings.ToLower(name)
+	if aliases, err := pp.discoveredAliases(); err == nil {
+		if gvr, ok := aliases[name]; ok {
+			return gvr, true
+		}
+	}
+	gvr, ok := builtinResourceAliases[name]
+	return gvr, ok
+}
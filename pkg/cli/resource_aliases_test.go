@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func testDiscoveryClient() *discoveryfake.FakeDiscovery {
+	return &discoveryfake.FakeDiscovery{Fake: &ktesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "services", SingularName: "service", Kind: "Service", ShortNames: []string{"svc"}},
+			{Name: "pods", SingularName: "pod", Kind: "Pod", ShortNames: []string{"po"}},
+			{Name: "pods/log", Kind: "Pod"},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", SingularName: "deployment", Kind: "Deployment", ShortNames: []string{"deploy"}},
+		}},
+		{GroupVersion: "cert-manager.io/v1", APIResources: []metav1.APIResource{
+			{Name: "certificates", SingularName: "certificate", Kind: "Certificate", ShortNames: []string{"cert", "certs"}},
+		}},
+		{GroupVersion: "networking.gke.io/v1", APIResources: []metav1.APIResource{
+			{Name: "managedcertificates", SingularName: "managedcertificate", Kind: "ManagedCertificate", ShortNames: []string{"mcrt", "cert"}},
+		}},
+	}}}
+}
+
+// prioritizedDiscovery returns the groups in the order of the fake's resource lists, as the API server returns
+// them by priority; the fake itself returns them in random order
+type prioritizedDiscovery struct {
+	*discoveryfake.FakeDiscovery
+}
+
+func (d prioritizedDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	var groups []*metav1.APIGroup
+	for _, list := range d.Resources {
+		gv, _ := schema.ParseGroupVersion(list.GroupVersion)
+		groups = append(groups, &metav1.APIGroup{
+			Name:             gv.Group,
+			PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: list.GroupVersion, Version: gv.Version},
+		})
+	}
+	return groups, d.Resources, nil
+}
+
+func TestDiscoverResourceAliases(t *testing.T) {
+	aliases, err := discoverResourceAliases(prioritizedDiscovery{testDiscoveryClient()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := map[string]schema.GroupVersionResource{
+		"deploy":                 {Group: "apps", Version: "v1", Resource: "deployments"},
+		"deploy.apps":            {Group: "apps", Version: "v1", Resource: "deployments"},
+		"svc":                    {Version: "v1", Resource: "services"},
+		"certificate":            {Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+		"cert":                   {Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+		"cert.networking.gke.io": {Group: "networking.gke.io", Version: "v1", Resource: "managedcertificates"},
+		"managedcertificate":     {Group: "networking.gke.io", Version: "v1", Resource: "managedcertificates"},
+	}
+	for alias, want := range tests {
+		if got := aliases[alias]; got != want {
+			t.Errorf("Expected %s to resolve to %v, got %v", alias, want, got)
+		}
+	}
+	if _, ok := aliases["pods/log"]; ok {
+		t.Errorf("Expected subresources to be left out")
+	}
+}
+
+func TestPatternParser_DiscoveredAliases(t *testing.T) {
+	parser := NewPatternParser()
+	parser.SetDiscoveryClient(testDiscoveryClient())
+
+	if err := parser.ParseExclusionFlag("deploy,svc,certs,widgets,kube-*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules := parser.ConvertToResourceFilterRules()
+
+	var resources []string
+	for _, rule := range rules {
+		resources = append(resources, rule.MatchGVRs[0].Resource)
+	}
+	if got := strings.Join(resources, ","); got != "deployments,services,certificates,kube-*" {
+		t.Errorf("Expected aliases resolved and the unknown widgets skipped, got %s", got)
+	}
+	if rules[0].MatchGVRs[0].Group != "apps" {
+		t.Errorf("Expected deploy to match apps deployments, got %v", rules[0].MatchGVRs[0])
+	}
+}
+
+// failingDiscovery fails every discovery request, like an unreachable API server
+type failingDiscovery struct {
+	*discoveryfake.FakeDiscovery
+}
+
+func (d failingDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (d failingDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	return nil, nil, errors.New("connection refused")
+}
+
+func TestNewPatternFilterHook(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resources := []autodiscovery.Resource{
+		{GVR: deployments, Namespace: "shop", Name: "web"},
+		{GVR: services, Namespace: "shop", Name: "web"},
+		{GVR: pods, Namespace: "shop", Name: "web-1"},
+		{GVR: pods, Namespace: "test-1", Name: "web-2"},
+	}
+
+	tests := []struct {
+		name    string
+		exclude string
+		include string
+		want    string
+	}{
+		{name: "exclude short names", exclude: "deploy,svc", want: "pods/web-1,pods/web-2"},
+		{name: "exclude namespaces", exclude: "ns:test-*", want: "deployments/web,services/web,pods/web-1"},
+		{name: "include any of the patterns", include: "deploy,svc", want: "deployments/web,services/web"},
+		{name: "include and exclude", exclude: "svc", include: "deploy,svc", want: "deployments/web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook, err := NewPatternFilterHook(tt.exclude, tt.include, testDiscoveryClient())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			filtered, err := hook.PreFilter(context.Background(), resources)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			for _, resource := range filtered {
+				got = append(got, resource.GVR.Resource+"/"+resource.Name)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, strings.Join(got, ","))
+			}
+		})
+	}
+}
+
+func TestNewPatternFilterHook_Errors(t *testing.T) {
+	if _, err := NewPatternFilterHook("widgets", "", testDiscoveryClient()); err == nil || !strings.Contains(err.Error(), "widgets") {
+		t.Errorf("Expected an error for a type the cluster does not serve, got %v", err)
+	}
+	if _, err := NewPatternFilterHook("deploy", "", failingDiscovery{testDiscoveryClient()}); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected the discovery error, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultScheduleBundleDir is where scheduled bundles and their index are written without --bundle-dir
+	DefaultScheduleBundleDir = "bundles"
+	// DefaultScheduleKeep is how many of the newest bundles rotation keeps without --keep
+	DefaultScheduleKeep = 7
+)
+
+// ScheduleOptions configures `support-bundle schedule`
+type ScheduleOptions struct {
+	Cron       string `json:"cron"`                 // When to collect, e.g. "0 3 * * *" or "@daily", in the process's local time
+	SpecFile   string `json:"specFile,omitempty"`   // Support bundle spec read again before every run, so edits apply without a restart
+	BundleDir  string `json:"bundleDir,omitempty"`  // Bundles and their bundles.json index, defaults to DefaultScheduleBundleDir
+	Keep       int    `json:"keep,omitempty"`       // Newest bundles kept by rotation, defaults to DefaultScheduleKeep
+	OlderThan  string `json:"olderThan,omitempty"`  // Keep bundles younger than this beyond Keep, e.g. 14d, see ParseRetentionAge
+	RunOnStart bool   `json:"runOnStart,omitempty"` // Collect once at startup instead of waiting for the first scheduled time
+
+	// Options of every run; UploadURL uploads each bundle once it is written
+	Collect SupportBundleCollectOptions `json:"collect"`
+}
+
+// ScheduledRun records one collection started by the scheduler
+type ScheduledRun struct {
+	ScheduledAt time.Time     `json:"scheduledAt"`
+	StartedAt   time.Time     `json:"startedAt"`
+	Duration    time.Duration `json:"duration"`
+	BundlePath  string        `json:"bundlePath,omitempty"`
+	Upload      *UploadResult `json:"upload,omitempty"`
+	Warnings    int           `json:"warnings,omitempty"` // Errors the collection recorded while still writing a bundle
+	Rotated     int           `json:"rotated,omitempty"`  // Old bundles removed after the run
+	Error       string        `json:"error,omitempty"`
+}
+
+// Scheduler runs auto-discovery collections on a cron schedule and rotates old bundles, in-process so it can
+// run as a plain Deployment
+type Scheduler struct {
+	options   ScheduleOptions
+	schedule  *CronSchedule
+	retention CleanBundlesPolicy
+	collect   func(ctx context.Context, options SupportBundleCollectOptions) (*CollectionResult, error)
+	now       func() time.Time
+	wait      func(ctx context.Context, d time.Duration) bool
+}
+
+// NewScheduler validates the options and creates a scheduler
+func NewScheduler(options ScheduleOptions) (*Scheduler, error) {
+	schedule, err := ParseCronSchedule(options.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --cron: %w", err)
+	}
+	olderThan, err := ParseRetentionAge(options.OlderThan)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --older-than: %w", err)
+	}
+	if options.Keep < 0 {
+		return nil, fmt.Errorf("--keep cannot be negative")
+	}
+	if options.Keep == 0 {
+		options.Keep = DefaultScheduleKeep
+	}
+	if options.BundleDir == "" {
+		options.BundleDir = DefaultScheduleBundleDir
+	}
+
+	collect := options.Collect
+	if collect.DryRun {
+		return nil, fmt.Errorf("--dry-run cannot be used with schedule")
+	}
+	if collect.Resume {
+		return nil, fmt.Errorf("--resume cannot be used with schedule, every run writes a new bundle")
+	}
+	if collect.Output != "" || collect.OutputDir != "" {
+		return nil, fmt.Errorf("--output and --output-dir cannot be used with schedule, bundles are written to --bundle-dir")
+	}
+	if collect.NoTrack {
+		return nil, fmt.Errorf("--no-track cannot be used with schedule, rotation needs the bundle index")
+	}
+
+	// Fail at startup rather than at the first run, hours later
+	if options.SpecFile != "" {
+		if _, err := NewSupportBundleSpecLoader().LoadFromFile(options.SpecFile); err != nil {
+			return nil, fmt.Errorf("failed to load spec: %w", err)
+		}
+	}
+
+	return &Scheduler{
+		options:   options,
+		schedule:  schedule,
+		retention: CleanBundlesPolicy{OlderThan: olderThan, Keep: options.Keep},
+		collect:   collectScheduledBundle,
+		now:       time.Now,
+		wait:      waitFor,
+	}, nil
+}
+
+// Run collects at every scheduled time until ctx is done; runs never overlap, scheduled times passed while a
+// run was still going are skipped
+func (s *Scheduler) Run(ctx context.Context) error {
+	fmt.Printf("⏰ Scheduling support bundle collection: %s\n", s.schedule)
+	fmt.Printf("   Bundles: %s (keeping the newest %d)\n", s.options.BundleDir, s.retention.Keep)
+
+	if s.options.RunOnStart {
+		s.RunOnce(ctx, s.now())
+	}
+	for {
+		next := s.schedule.Next(s.now())
+		if next.IsZero() {
+			return fmt.Errorf("cron schedule %q never fires", s.schedule)
+		}
+		fmt.Printf("⏰ Next collection at %s\n", next.Format(time.RFC3339))
+		if !s.wait(ctx, next.Sub(s.now())) {
+			fmt.Printf("Scheduler stopped\n")
+			return nil
+		}
+		s.RunOnce(ctx, next)
+	}
+}
+
+// RunOnce collects one bundle and rotates old ones; failures are recorded in the run instead of stopping the
+// scheduler
+func (s *Scheduler) RunOnce(ctx context.Context, scheduledAt time.Time) ScheduledRun {
+	run := ScheduledRun{ScheduledAt: scheduledAt, StartedAt: s.now()}
+
+	options, err := s.collectOptions(run.StartedAt)
+	if err == nil {
+		var result *CollectionResult
+		if result, err = s.collect(ctx, options); err == nil {
+			run.BundlePath = result.OutputPath
+			run.Upload = result.Upload
+			run.Warnings = len(result.Errors)
+		}
+	}
+	run.Duration = s.now().Sub(run.StartedAt)
+	if err != nil {
+		run.Error = err.Error()
+		fmt.Printf("Warning: scheduled collection failed: %v\n", err)
+	}
+
+	// Rotate after failed runs too, so a cluster that keeps failing does not stop the cleanup
+	cleaned, err := NewWorkspaceManager(s.options.BundleDir).Clean(s.retention, s.now())
+	if err != nil {
+		fmt.Printf("Warning: failed to rotate bundles: %v\n", err)
+	} else {
+		run.Rotated = len(cleaned.Removed)
+		if run.Rotated > 0 || len(cleaned.Errors) > 0 {
+			printCleanBundlesResult(cleaned)
+		}
+	}
+	return run
+}
+
+// collectOptions returns the options of a run starting at startedAt, with the spec read again
+func (s *Scheduler) collectOptions(startedAt time.Time) (SupportBundleCollectOptions, error) {
+	options := s.options.Collect
+	options.Auto = true
+	options.OutputDir = filepath.Join(s.options.BundleDir, fmt.Sprintf("support-bundle-%s", startedAt.Format(outputTimestampLayout)))
+	options.WorkspaceDir = s.options.BundleDir
+	options.NoProgress = true // Scheduled runs log to a file or the pod log, not a terminal
+
+	if s.options.SpecFile != "" {
+		spec, err := NewSupportBundleSpecLoader().LoadFromFile(s.options.SpecFile)
+		if err != nil {
+			return options, fmt.Errorf("failed to load spec: %w", err)
+		}
+		applyScheduleSpec(&options, spec)
+	}
+	return options, nil
+}
+
+// applyScheduleSpec fills the options the command line left unset from the spec's auto-discovery section
+func applyScheduleSpec(options *SupportBundleCollectOptions, spec *SupportBundleSpec) {
+	config := spec.Spec.AutoDiscovery
+	if config == nil {
+		return
+	}
+	if len(options.Namespaces) == 0 {
+		options.Namespaces = config.Namespaces
+	}
+	if options.ProfileName == "" {
+		options.ProfileName = config.Profile
+	}
+	options.IncludeImages = options.IncludeImages || config.IncludeImages
+	options.RBACCheck = options.RBACCheck || config.RBACCheck
+	options.IncludeSystemNamespaces = options.IncludeSystemNamespaces || config.IncludeSystemNamespaces
+}
+
+// collectScheduledBundle creates a collector for every run so kubeconfig and in-cluster token changes apply
+func collectScheduledBundle(ctx context.Context, options SupportBundleCollectOptions) (*CollectionResult, error) {
+	sbc, err := NewSupportBundleCollector(options)
+	if err != nil {
+		return nil, err
+	}
+	return sbc.CollectWithAutoDiscovery(ctx, options)
+}
+
+// waitFor sleeps for d, returning false if ctx is done first
+func waitFor(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// RunSchedule implements `support-bundle schedule`: it collects on the cron schedule until ctx is cancelled,
+// e.g. on SIGTERM when the Deployment running it is scaled down
+func RunSchedule(ctx context.Context, options ScheduleOptions) error {
+	scheduler, err := NewScheduler(options)
+	if err != nil {
+		return err
+	}
+	return scheduler.Run(ctx)
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewScheduler_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ScheduleOptions
+		errText string
+	}{
+		{"invalid cron", ScheduleOptions{Cron: "every night"}, "invalid --cron"},
+		{"invalid older-than", ScheduleOptions{Cron: "@daily", OlderThan: "a week"}, "invalid --older-than"},
+		{"negative keep", ScheduleOptions{Cron: "@daily", Keep: -1}, "--keep"},
+		{"dry run", ScheduleOptions{Cron: "@daily", Collect: SupportBundleCollectOptions{DryRun: true}}, "--dry-run"},
+		{"output dir", ScheduleOptions{Cron: "@daily", Collect: SupportBundleCollectOptions{OutputDir: "out"}}, "--bundle-dir"},
+		{"no track", ScheduleOptions{Cron: "@daily", Collect: SupportBundleCollectOptions{NoTrack: true}}, "--no-track"},
+		{"missing spec", ScheduleOptions{Cron: "@daily", SpecFile: "/nonexistent/spec.yaml"}, "failed to load spec"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewScheduler(tt.options)
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("Expected an error containing %q, got %v", tt.errText, err)
+			}
+		})
+	}
+
+	scheduler, err := NewScheduler(ScheduleOptions{Cron: "0 3 * * *"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scheduler.retention.Keep != DefaultScheduleKeep || scheduler.options.BundleDir != DefaultScheduleBundleDir {
+		t.Errorf("Expected default keep and bundle dir, got %d and %s", scheduler.retention.Keep, scheduler.options.BundleDir)
+	}
+}
+
+func TestScheduler_RunRotatesBundles(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "spec.yaml")
+	spec := `
+apiVersion: troubleshoot.sh/v1beta3
+kind: SupportBundle
+metadata:
+  name: nightly
+spec:
+  autoDiscovery:
+    enabled: true
+    namespaces: ["shop"]
+    includeImages: true
+`
+	if err := os.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	scheduler, err := NewScheduler(ScheduleOptions{
+		Cron:      "0 3 * * *",
+		SpecFile:  specPath,
+		BundleDir: filepath.Join(dir, "bundles"),
+		Keep:      2,
+		Collect:   SupportBundleCollectOptions{UploadURL: "https://uploads.example.com/files/"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	now := time.Date(2026, time.January, 14, 10, 30, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var waits []time.Duration
+	scheduler.wait = func(ctx context.Context, d time.Duration) bool {
+		waits = append(waits, d)
+		if len(waits) > 3 {
+			cancel()
+			return false
+		}
+		now = now.Add(d)
+		return true
+	}
+
+	var collected []SupportBundleCollectOptions
+	scheduler.collect = func(ctx context.Context, options SupportBundleCollectOptions) (*CollectionResult, error) {
+		collected = append(collected, options)
+		if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+			return nil, err
+		}
+		trackBundle(options.WorkspaceDir, options.OutputDir, now)
+		return &CollectionResult{OutputPath: options.OutputDir}, nil
+	}
+
+	if err := scheduler.Run(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(collected) != 3 {
+		t.Fatalf("Expected 3 runs, got %d", len(collected))
+	}
+	if waits[0] != 16*time.Hour+30*time.Minute || waits[1] != 24*time.Hour {
+		t.Errorf("Expected to wait until 03:00 every day, got %v", waits)
+	}
+	first := collected[0]
+	if !first.Auto || !first.IncludeImages || len(first.Namespaces) != 1 || first.Namespaces[0] != "shop" {
+		t.Errorf("Expected the spec's auto-discovery options, got %+v", first)
+	}
+	if first.UploadURL == "" || first.WorkspaceDir != filepath.Join(dir, "bundles") {
+		t.Errorf("Expected the upload URL and the bundle dir as workspace, got %+v", first)
+	}
+	if filepath.Base(first.OutputDir) != "support-bundle-2026-01-15T03-00-00" {
+		t.Errorf("Expected a timestamped bundle dir, got %s", first.OutputDir)
+	}
+
+	if _, err := os.Stat(first.OutputDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the oldest bundle to be rotated out, got %v", err)
+	}
+	for _, options := range collected[1:] {
+		if _, err := os.Stat(options.OutputDir); err != nil {
+			t.Errorf("Expected %s to be kept: %v", options.OutputDir, err)
+		}
+	}
+}
+
+func TestScheduler_RunOnceRecordsFailures(t *testing.T) {
+	scheduler, err := NewScheduler(ScheduleOptions{Cron: "@hourly", BundleDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scheduler.collect = func(ctx context.Context, options SupportBundleCollectOptions) (*CollectionResult, error) {
+		return nil, os.ErrPermission
+	}
+
+	run := scheduler.RunOnce(context.Background(), time.Now())
+	if run.Error == "" || run.BundlePath != "" {
+		t.Errorf("Expected the failure to be recorded, got %+v", run)
+	}
+}
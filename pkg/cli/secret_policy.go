@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// secretFilteringRunner applies the secret policy to the output of each collector before it is recorded as
+// completed. When filtering fails the outputs are removed, Secret values are never left in the bundle
+func secretFilteringRunner(runner CollectorRunner, filter *autodiscovery.SecretFilter) CollectorRunner {
+	return func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		outputs, err := runner(ctx, collector, outputDir)
+		if _, _, filterErr := filter.Filter(collector, outputDir, outputs); filterErr != nil {
+			for _, output := range outputs {
+				if removeErr := os.RemoveAll(filepath.Join(outputDir, output)); removeErr != nil {
+					fmt.Printf("Warning: failed to remove unfiltered output %s: %v\n", output, removeErr)
+				}
+			}
+			return nil, fmt.Errorf("secret policy failed, outputs removed: %w", filterErr)
+		}
+		return outputs, err
+	}
+}
+
+// printSecretPolicySummary prints the Secrets kept and left out by the secret policy, and the certificates
+// that have expired or expire soon
+func printSecretPolicySummary(report *autodiscovery.SecretPolicyReport) {
+	dropped := 0
+	types := make([]string, 0, len(report.Dropped))
+	for secretType, count := range report.Dropped {
+		dropped += count
+		types = append(types, secretType)
+	}
+	sort.Strings(types)
+
+	fmt.Printf("🔐 Secret policy: %d Secrets collected without values, %d left out\n", len(report.Collected), dropped)
+	for _, secretType := range types {
+		fmt.Printf("   %s: %d left out\n", secretType, report.Dropped[secretType])
+	}
+	for _, secret := range report.Expiring {
+		for _, certificate := range secret.Certificates {
+			state := "expires"
+			if certificate.NotAfter.Before(time.Now()) {
+				state = "expired"
+			}
+			fmt.Printf("Warning: certificate %s in secret %s/%s (%s) %s %s\n", certificate.Subject, secret.Namespace, secret.Name,
+				certificate.Key, state, certificate.NotAfter.Format(time.RFC3339))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+func TestRunCollectors_SecretPolicy(t *testing.T) {
+	secrets := `{"kind":"SecretList","items":[` +
+		`{"kind":"Secret","type":"Opaque","metadata":{"name":"db","namespace":"app"},"data":{"password":"aHVudGVyMg=="}},` +
+		`{"kind":"Secret","type":"kubernetes.io/basic-auth","metadata":{"name":"admin","namespace":"app"},"data":{"username":"YWRtaW4="}}]}`
+
+	outputDir := t.TempDir()
+	sbc := &SupportBundleCollector{
+		secretFilter: autodiscovery.NewSecretFilter(),
+		trimmer:      autodiscovery.NewLargeObjectTrimmer(),
+		collectorRunner: func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+			name := collector.Name + ".json"
+			return []string{name}, os.WriteFile(filepath.Join(outputDir, name), []byte(secrets), 0644)
+		},
+	}
+	params := autodiscovery.ClusterResourcesParams{Version: "v1", Resource: "secrets", Namespaces: []string{"app"}, MaxObjectSize: 1024,
+		SecretPolicy: []autodiscovery.SecretTypePolicy{{Type: "Opaque"}}}
+	collectors := []autodiscovery.CollectorSpec{
+		{Type: autodiscovery.CollectorTypeClusterResources, Name: "auto-resources-secrets", Parameters: params.ToMap()},
+	}
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collectorErrors, err := sbc.runCollectors(context.Background(), collectors, outputDir, checkpoint)
+	if err != nil || len(collectorErrors) != 0 {
+		t.Fatalf("Unexpected errors: %v %v", err, collectorErrors)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(outputDir, "auto-resources-secrets.json"))
+	if strings.Contains(string(data), "aHVudGVyMg==") || strings.Contains(string(data), "admin") || !strings.Contains(string(data), autodiscovery.SecretPolicyAnnotation) {
+		t.Errorf("Expected only the db secret, without its value, got %s", data)
+	}
+	report := sbc.secretFilter.Report()
+	if report == nil || len(report.Collected) != 1 || report.Dropped["kubernetes.io/basic-auth"] != 1 {
+		t.Errorf("Expected db to be collected and admin left out, got %+v", report)
+	}
+}
+
+func TestSecretFilteringRunner_RemovesOutputsOnFailure(t *testing.T) {
+	outputDir := t.TempDir()
+	runner := secretFilteringRunner(func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		// The second output is reported but never written, so filtering fails
+		return []string{"secrets.json", "missing.json"}, os.WriteFile(filepath.Join(outputDir, "secrets.json"), []byte(`{"kind":"Secret"}`), 0644)
+	}, autodiscovery.NewSecretFilter())
+
+	params := autodiscovery.ClusterResourcesParams{Version: "v1", Resource: "secrets", SecretPolicy: []autodiscovery.SecretTypePolicy{{Type: "Opaque"}}}
+	collector := autodiscovery.CollectorSpec{Type: autodiscovery.CollectorTypeClusterResources, Name: "auto-resources-secrets", Parameters: params.ToMap()}
+	outputs, err := runner(context.Background(), collector, outputDir)
+	if err == nil || outputs != nil {
+		t.Fatalf("Expected the failure to be returned without outputs, got %v %v", outputs, err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "secrets.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected the unfiltered output to be removed, got %v", err)
+	}
+}
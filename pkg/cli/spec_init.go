@@ -0,0 +1,530 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultInitSpecPath is where `support-bundle init` writes the spec without --output
+const DefaultInitSpecPath = "support-bundle.yaml"
+
+// initSpecApps are the common apps recognized by image name, without registry, path or tag
+var initSpecApps = []struct {
+	name   string
+	images []string
+}{
+	{"postgres", []string{"postgres", "postgresql", "postgis"}},
+	{"mysql", []string{"mysql", "mariadb", "percona-server"}},
+	{"mongodb", []string{"mongo", "mongodb"}},
+	{"redis", []string{"redis", "valkey"}},
+	{"kafka", []string{"kafka", "cp-kafka"}},
+	{"rabbitmq", []string{"rabbitmq"}},
+	{"elasticsearch", []string{"elasticsearch", "opensearch"}},
+	{"minio", []string{"minio"}},
+	{"nginx", []string{"nginx", "nginx-unprivileged"}},
+	{"ingress-nginx", []string{"ingress-nginx/controller", "nginx-ingress-controller"}},
+	{"prometheus", []string{"prometheus"}},
+	{"cert-manager", []string{"cert-manager-controller"}},
+}
+
+// InitSpecOptions configures `support-bundle init`
+type InitSpecOptions struct {
+	KubeconfigPath string   `json:"kubeconfigPath,omitempty"`
+	OutputPath     string   `json:"outputPath,omitempty"` // Defaults to DefaultInitSpecPath
+	Name           string   `json:"name,omitempty"`       // Preselected metadata.name
+	Namespaces     []string `json:"namespaces,omitempty"` // Preselected namespaces, skips the namespace question
+	Yes            bool     `json:"yes,omitempty"`        // Accept every default without prompting
+	Force          bool     `json:"force,omitempty"`      // Overwrite an existing file
+
+	In  io.Reader `json:"-"` // Answers, defaults to os.Stdin
+	Out io.Writer `json:"-"` // Questions and summary, defaults to os.Stdout
+}
+
+// ClusterSurvey is what `support-bundle init` learned about the cluster to prefill the spec
+type ClusterSurvey struct {
+	Namespaces []SurveyedNamespace `json:"namespaces"`
+	Registries []SurveyedRegistry  `json:"registries,omitempty"` // Sorted by image count, most used first
+	Problems   []string            `json:"problems,omitempty"`
+}
+
+// SurveyedNamespace is a namespace with its pod count and the common apps found running in it
+type SurveyedNamespace struct {
+	Name   string   `json:"name"`
+	Pods   int      `json:"pods"`
+	Apps   []string `json:"apps,omitempty"`
+	System bool     `json:"system,omitempty"`
+}
+
+// SurveyedRegistry is a registry and the number of distinct images pulled from it
+type SurveyedRegistry struct {
+	Registry string `json:"registry"`
+	Images   int    `json:"images"`
+}
+
+// InitSpecAnswers are the choices the generated spec is built from
+type InitSpecAnswers struct {
+	Name              string   `json:"name"`
+	Namespaces        []string `json:"namespaces"` // Empty scans every namespace the user can list
+	Profile           string   `json:"profile"`
+	IncludeImages     bool     `json:"includeImages"`
+	IncludeSecrets    bool     `json:"includeSecrets"`
+	SkipCompletedPods bool     `json:"skipCompletedPods"`
+}
+
+// SurveyCluster lists namespaces and pods to find the application namespaces, common apps and image registries
+// Failing to list either is recorded as a problem so the wizard can still run on its defaults
+func SurveyCluster(ctx context.Context, kubeClient kubernetes.Interface) (*ClusterSurvey, error) {
+	survey := &ClusterSurvey{}
+	byName := make(map[string]*SurveyedNamespace)
+
+	namespaces, err := kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		survey.Problems = append(survey.Problems, fmt.Sprintf("failed to list namespaces: %v", err))
+	} else {
+		for _, namespace := range namespaces.Items {
+			byName[namespace.Name] = &SurveyedNamespace{Name: namespace.Name}
+		}
+	}
+
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		survey.Problems = append(survey.Problems, fmt.Sprintf("failed to list pods: %v", err))
+	} else {
+		apps := make(map[string]map[string]bool)
+		registryImages := make(map[string]map[string]bool)
+		for _, pod := range pods.Items {
+			namespace := byName[pod.Namespace]
+			if namespace == nil {
+				namespace = &SurveyedNamespace{Name: pod.Namespace}
+				byName[pod.Namespace] = namespace
+			}
+			namespace.Pods++
+
+			for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+				registry := images.GetRegistryFromImageRef(container.Image)
+				if registryImages[registry] == nil {
+					registryImages[registry] = make(map[string]bool)
+				}
+				registryImages[registry][container.Image] = true
+
+				if app := initSpecApp(container.Image); app != "" {
+					if apps[pod.Namespace] == nil {
+						apps[pod.Namespace] = make(map[string]bool)
+					}
+					apps[pod.Namespace][app] = true
+				}
+			}
+		}
+		for namespace, found := range apps {
+			for app := range found {
+				byName[namespace].Apps = append(byName[namespace].Apps, app)
+			}
+			sort.Strings(byName[namespace].Apps)
+		}
+		for registry, refs := range registryImages {
+			survey.Registries = append(survey.Registries, SurveyedRegistry{Registry: registry, Images: len(refs)})
+		}
+		sort.Slice(survey.Registries, func(i, j int) bool {
+			if survey.Registries[i].Images != survey.Registries[j].Images {
+				return survey.Registries[i].Images > survey.Registries[j].Images
+			}
+			return survey.Registries[i].Registry < survey.Registries[j].Registry
+		})
+	}
+
+	if len(survey.Problems) == 2 {
+		return nil, fmt.Errorf("failed to survey the cluster: %s", strings.Join(survey.Problems, "; "))
+	}
+
+	for _, namespace := range byName {
+		namespace.System = isSystemNamespace(namespace.Name)
+		survey.Namespaces = append(survey.Namespaces, *namespace)
+	}
+	sort.Slice(survey.Namespaces, func(i, j int) bool { return survey.Namespaces[i].Name < survey.Namespaces[j].Name })
+	return survey, nil
+}
+
+// initSpecApp returns the common app an image runs, "" when it is not one of initSpecApps
+func initSpecApp(image string) string {
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name = name[:colon]
+	}
+	for _, app := range initSpecApps {
+		for _, candidate := range app.images {
+			if name == candidate || strings.HasSuffix(name, "/"+candidate) || path.Base(name) == candidate {
+				return app.name
+			}
+		}
+	}
+	return ""
+}
+
+func isSystemNamespace(name string) bool {
+	for _, system := range autodiscovery.SystemNamespaces {
+		if name == system {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultInitSpecAnswers proposes the non-system namespaces running pods, the standard profile, image collection
+// when pods were found, no Secrets and no completed pods
+func DefaultInitSpecAnswers(survey *ClusterSurvey, options InitSpecOptions) InitSpecAnswers {
+	answers := InitSpecAnswers{
+		Name:              options.Name,
+		Namespaces:        options.Namespaces,
+		Profile:           "standard",
+		IncludeImages:     len(survey.Registries) > 0,
+		SkipCompletedPods: true,
+	}
+	if answers.Namespaces == nil {
+		for _, namespace := range survey.Namespaces {
+			if !namespace.System && namespace.Pods > 0 && namespace.Name != "default" {
+				answers.Namespaces = append(answers.Namespaces, namespace.Name)
+			}
+		}
+	}
+	if answers.Name == "" {
+		answers.Name = "support-bundle"
+		if len(answers.Namespaces) == 1 && !strings.ContainsAny(answers.Namespaces[0], "*?[") {
+			answers.Name = answers.Namespaces[0] + "-support-bundle"
+		}
+	}
+	return answers
+}
+
+// initSpecPrompter asks questions on out and reads one answer per line from in, an empty line keeps the default
+type initSpecPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// read asks the question showing hint and returns the trimmed answer, "" when the line is empty or input ended
+func (p *initSpecPrompter) read(question, hint string) (string, error) {
+	fmt.Fprintf(p.out, "%s [%s]: ", question, hint)
+	line, err := p.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if err == io.EOF && line == "" {
+		fmt.Fprintln(p.out)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (p *initSpecPrompter) ask(question, defaultValue string) (string, error) {
+	answer, err := p.read(question, defaultValue)
+	if err != nil || answer == "" {
+		return defaultValue, err
+	}
+	return answer, nil
+}
+
+// confirm asks a yes/no question until the answer is y, yes, n, no or empty, the case is ignored
+func (p *initSpecPrompter) confirm(question string, defaultValue bool) (bool, error) {
+	hint := "y/N"
+	if defaultValue {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := p.read(question, hint)
+		if err != nil || answer == "" {
+			return defaultValue, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintf(p.out, "Please answer y or n\n")
+	}
+}
+
+// askInitSpecAnswers asks for each answer the options did not preselect, proposing the defaults
+func askInitSpecAnswers(prompter *initSpecPrompter, survey *ClusterSurvey, options InitSpecOptions, defaults InitSpecAnswers) (InitSpecAnswers, error) {
+	answers := defaults
+	var err error
+
+	if options.Namespaces == nil {
+		answer, err := prompter.ask("Namespaces to collect, comma-separated, globs allowed, \"*\" for all", strings.Join(defaults.Namespaces, ","))
+		if err != nil {
+			return answers, err
+		}
+		answers.Namespaces = splitCommaList(answer)
+		if len(answers.Namespaces) == 1 && answers.Namespaces[0] == "*" {
+			answers.Namespaces = nil
+		}
+		if err := (autodiscovery.DiscoveryOptions{Namespaces: answers.Namespaces}).Validate(); err != nil {
+			return answers, fmt.Errorf("invalid namespaces: %w", err)
+		}
+	}
+	if options.Name == "" {
+		if answers.Name, err = prompter.ask("Spec name", defaults.Name); err != nil {
+			return answers, err
+		}
+	}
+	if answers.Profile, err = prompter.ask("Discovery profile: minimal, standard or comprehensive", defaults.Profile); err != nil {
+		return answers, err
+	}
+	if answers.Profile != "minimal" && answers.Profile != "standard" && answers.Profile != "comprehensive" {
+		return answers, fmt.Errorf("invalid profile: %s (valid: minimal, standard, comprehensive)", answers.Profile)
+	}
+	if answers.IncludeImages, err = prompter.confirm(fmt.Sprintf("Collect image metadata from %d registries", len(survey.Registries)), defaults.IncludeImages); err != nil {
+		return answers, err
+	}
+	if answers.IncludeSecrets, err = prompter.confirm("Collect Secrets", defaults.IncludeSecrets); err != nil {
+		return answers, err
+	}
+	if answers.SkipCompletedPods, err = prompter.confirm("Skip pods that completed successfully", defaults.SkipCompletedPods); err != nil {
+		return answers, err
+	}
+	return answers, nil
+}
+
+func splitCommaList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// RenderInitSpec writes a commented v1beta3 SupportBundle spec with autoDiscovery configured from the answers
+// The result is checked with the spec loader's validation so it always loads
+func RenderInitSpec(survey *ClusterSurvey, answers InitSpecAnswers) ([]byte, error) {
+	var b strings.Builder
+	line := func(indent int, format string, args ...interface{}) {
+		b.WriteString(strings.Repeat("  ", indent))
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\n")
+	}
+
+	line(0, "# Generated by `support-bundle init`. Collect with: support-bundle --spec <this file>")
+	line(0, "apiVersion: troubleshoot.sh/v1beta3")
+	line(0, "kind: SupportBundle")
+	line(0, "metadata:")
+	line(1, "# Names the bundle and its archive")
+	line(1, "name: %s", yamlString(answers.Name))
+	line(0, "spec:")
+	line(1, "autoDiscovery:")
+	line(2, "# Discover the resources in the namespaces below and generate collectors for them")
+	line(2, "enabled: true")
+	line(2, "# Namespaces to scan, globs such as app-* allowed. Without any, every namespace you can list is scanned")
+	if len(answers.Namespaces) == 0 {
+		line(2, "namespaces: []")
+	} else {
+		line(2, "namespaces:")
+		for _, namespace := range answers.Namespaces {
+			comment := ""
+			if apps := surveyedApps(survey, namespace); len(apps) > 0 {
+				comment = "  # runs " + strings.Join(apps, ", ")
+			}
+			line(3, "- %s%s", yamlString(namespace), comment)
+		}
+	}
+	line(2, "# minimal: pods, services and events; standard: application troubleshooting; comprehensive: deep cluster analysis")
+	line(2, "profile: %s", answers.Profile)
+	line(2, "# Levels of dependencies (Services, ConfigMaps, volumes...) followed from each workload, 0 to %d", autodiscovery.MaxMaxDepth)
+	line(2, "maxDepth: 3")
+	line(2, "# Check permissions first and skip what you cannot read instead of failing")
+	line(2, "rbacCheck: true")
+	line(2, "# Resources never collected")
+	line(2, "excludes:")
+	line(3, "- namespaces: [kube-node-lease, kube-public]")
+	line(4, "reason: Node heartbeats and public cluster info do not help troubleshooting")
+	line(2, "# Filters applied to discovered resources, in order")
+	if answers.IncludeSecrets && !answers.SkipCompletedPods {
+		line(2, "resourceFilters: []")
+	} else {
+		line(2, "resourceFilters:")
+		if !answers.IncludeSecrets {
+			line(3, "- name: exclude-secrets")
+			line(4, "action: exclude")
+			line(4, "matchGVRs:")
+			line(5, "- {group: \"\", version: v1, resource: secrets}")
+		}
+		if answers.SkipCompletedPods {
+			line(3, "- name: exclude-completed-pods")
+			line(4, "action: exclude")
+			line(4, "matchGVRs:")
+			line(5, "- {group: \"\", version: v1, resource: pods}")
+			line(4, "fieldSelectors: [status.phase=Succeeded]")
+		}
+	}
+	line(2, "# Image metadata (digests, base images, labels) looked up in the registries the pods pull from")
+	line(2, "includeImages: %t", answers.IncludeImages)
+	if answers.IncludeImages {
+		line(2, "imageOptions:")
+		if len(survey.Registries) > 0 {
+			names := make([]string, 0, len(survey.Registries))
+			for _, registry := range survey.Registries {
+				names = append(names, fmt.Sprintf("%s (%d)", registry.Registry, registry.Images))
+			}
+			line(3, "# Registries in use: %s", strings.Join(names, ", "))
+		}
+		line(3, "includeManifests: true")
+		line(3, "includeConfig: true")
+		line(3, "# Layers make facts large, enable them to compare images layer by layer")
+		line(3, "includeLayers: false")
+		line(3, "cacheEnabled: true")
+		line(3, "timeout: 60s")
+		line(3, "maxConcurrency: 5")
+		line(3, "retryCount: 2")
+		if usesDockerHub(survey) {
+			line(3, "# Docker Hub rate limits anonymous pulls, keep lookups against it slow")
+			line(3, "registryLimits:")
+			line(4, "docker.io:")
+			line(5, "maxConcurrency: 2")
+		}
+	}
+
+	data := []byte(b.String())
+	spec := &SupportBundleSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse generated spec: %w", err)
+	}
+	if err := NewSupportBundleSpecLoader().ValidateSpec(spec); err != nil {
+		return nil, fmt.Errorf("generated spec is invalid: %w", err)
+	}
+	return data, nil
+}
+
+// yamlString quotes values YAML would read as another type, an alias or a flow collection, e.g. app-*, 123 or on
+func yamlString(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil || strings.ContainsAny(value, "*?[]{}:#,&!|>'\"%@`") {
+		return strconv.Quote(value)
+	}
+	switch strings.ToLower(value) {
+	case "", "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func surveyedApps(survey *ClusterSurvey, namespace string) []string {
+	for _, surveyed := range survey.Namespaces {
+		if surveyed.Name == namespace {
+			return surveyed.Apps
+		}
+	}
+	return nil
+}
+
+// usesDockerHub reports whether any pod pulls from Docker Hub, named docker.io or, when implied, index.docker.io
+func usesDockerHub(survey *ClusterSurvey) bool {
+	for _, surveyed := range survey.Registries {
+		if surveyed.Registry == "docker.io" || surveyed.Registry == "index.docker.io" {
+			return true
+		}
+	}
+	return false
+}
+
+// RunInitSpec implements `support-bundle init`: it surveys the cluster, asks which namespaces and data to collect,
+// and writes a commented spec to options.OutputPath
+func RunInitSpec(ctx context.Context, options InitSpecOptions) (string, error) {
+	outputPath := options.OutputPath
+	if outputPath == "" {
+		outputPath = DefaultInitSpecPath
+	}
+	if _, err := os.Stat(outputPath); err == nil && !options.Force {
+		return "", fmt.Errorf("%s already exists, pass --force to overwrite it", outputPath)
+	}
+	if options.In == nil {
+		options.In = os.Stdin
+	}
+	if options.Out == nil {
+		options.Out = os.Stdout
+	}
+
+	config, err := loadKubernetesConfig(SupportBundleCollectOptions{KubeconfigPath: options.KubeconfigPath})
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	fmt.Fprintf(options.Out, "🔍 Surveying the cluster...\n")
+	survey, err := SurveyCluster(ctx, kubeClient)
+	if err != nil {
+		return "", err
+	}
+	return writeInitSpec(survey, options, outputPath)
+}
+
+// writeInitSpec prints the survey, asks the questions unless options.Yes is set, and writes the spec
+func writeInitSpec(survey *ClusterSurvey, options InitSpecOptions, outputPath string) (string, error) {
+	for _, problem := range survey.Problems {
+		fmt.Fprintf(options.Out, "Warning: %s\n", problem)
+	}
+	printClusterSurvey(options.Out, survey)
+
+	answers := DefaultInitSpecAnswers(survey, options)
+	if !options.Yes {
+		prompter := &initSpecPrompter{in: bufio.NewReader(options.In), out: options.Out}
+		var err error
+		if answers, err = askInitSpecAnswers(prompter, survey, options, answers); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := RenderInitSpec(survey, answers)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write spec: %w", err)
+	}
+	fmt.Fprintf(options.Out, "✅ Wrote %s\n", outputPath)
+	fmt.Fprintf(options.Out, "   Review it, then preview with: support-bundle --spec %s --dry-run\n", outputPath)
+	return outputPath, nil
+}
+
+func printClusterSurvey(w io.Writer, survey *ClusterSurvey) {
+	if len(survey.Namespaces) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n📋 Namespaces:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  NAMESPACE\tPODS\tAPPS")
+	for _, namespace := range survey.Namespaces {
+		apps := strings.Join(namespace.Apps, ", ")
+		if namespace.System {
+			apps = strings.TrimPrefix(apps+" (system)", " ")
+		}
+		fmt.Fprintf(tw, "  %s\t%d\t%s\n", namespace.Name, namespace.Pods, apps)
+	}
+	tw.Flush()
+
+	if len(survey.Registries) > 0 {
+		fmt.Fprintf(w, "\n📦 Registries:\n")
+		for _, registry := range survey.Registries {
+			fmt.Fprintf(w, "  %s: %d images\n", registry.Registry, registry.Images)
+		}
+	}
+	fmt.Fprintln(w)
+}
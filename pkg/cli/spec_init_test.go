@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testSurveyPod(namespace, name string, images ...string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	for _, image := range images {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: name, Image: image})
+	}
+	return pod
+}
+
+func TestSurveyCluster(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "empty"}},
+		testSurveyPod("shop", "api", "ghcr.io/acme/shop-api:1.4.0"),
+		testSurveyPod("shop", "db", "bitnami/postgresql:16.2.0"),
+		testSurveyPod("shop", "cache", "redis:7"),
+		testSurveyPod("kube-system", "coredns", "registry.k8s.io/coredns/coredns:v1.11.1"),
+	)
+
+	survey, err := SurveyCluster(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(survey.Namespaces) != 4 {
+		t.Fatalf("Expected 4 namespaces, got %+v", survey.Namespaces)
+	}
+	shop := survey.Namespaces[3]
+	if shop.Name != "shop" || shop.Pods != 3 || strings.Join(shop.Apps, ",") != "postgres,redis" {
+		t.Errorf("Expected shop to run postgres and redis in 3 pods, got %+v", shop)
+	}
+	if !survey.Namespaces[2].System {
+		t.Errorf("Expected kube-system to be a system namespace, got %+v", survey.Namespaces[2])
+	}
+	if len(survey.Registries) != 3 || survey.Registries[0].Registry != "index.docker.io" || survey.Registries[0].Images != 2 {
+		t.Errorf("Expected Docker Hub first with 2 images, got %+v", survey.Registries)
+	}
+
+	answers := DefaultInitSpecAnswers(survey, InitSpecOptions{})
+	if strings.Join(answers.Namespaces, ",") != "shop" || answers.Name != "shop-support-bundle" || !answers.IncludeImages {
+		t.Errorf("Expected shop to be proposed with images, got %+v", answers)
+	}
+}
+
+func TestInitSpecApp(t *testing.T) {
+	tests := map[string]string{
+		"postgres:16":                                      "postgres",
+		"docker.io/bitnami/postgresql:16":                  "postgres",
+		"mongo@sha256:abc":                                 "mongodb",
+		"registry.local:5000/redis":                        "redis",
+		"registry.k8s.io/ingress-nginx/controller:v1.10.0": "ingress-nginx",
+		"ghcr.io/acme/api:1.0":                             "",
+	}
+	for image, want := range tests {
+		if got := initSpecApp(image); got != want {
+			t.Errorf("Expected %q for %s, got %q", want, image, got)
+		}
+	}
+}
+
+func TestRenderInitSpec(t *testing.T) {
+	survey := &ClusterSurvey{
+		Namespaces: []SurveyedNamespace{{Name: "shop", Pods: 3, Apps: []string{"postgres"}}},
+		Registries: []SurveyedRegistry{{Registry: "index.docker.io", Images: 2}, {Registry: "ghcr.io", Images: 1}},
+	}
+
+	tests := []struct {
+		name     string
+		answers  InitSpecAnswers
+		contains []string
+		excludes []string
+	}{
+		{
+			name:    "defaults",
+			answers: InitSpecAnswers{Name: "shop-support-bundle", Namespaces: []string{"shop", "shop-*"}, Profile: "standard", IncludeImages: true, SkipCompletedPods: true},
+			contains: []string{
+				"- shop  # runs postgres",
+				`- "shop-*"`,
+				"name: exclude-secrets",
+				"fieldSelectors: [status.phase=Succeeded]",
+				"# Registries in use: index.docker.io (2), ghcr.io (1)",
+				"docker.io:",
+			},
+		},
+		{
+			name:     "all namespaces with secrets and no images",
+			answers:  InitSpecAnswers{Name: "true", Profile: "minimal", IncludeSecrets: true},
+			contains: []string{"namespaces: []", `name: "true"`, "resourceFilters: []", "includeImages: false"},
+			excludes: []string{"exclude-secrets", "imageOptions"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderInitSpec(survey, tt.answers)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			spec := string(data)
+			for _, want := range tt.contains {
+				if !strings.Contains(spec, want) {
+					t.Errorf("Expected spec to contain %q:\n%s", want, spec)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(spec, unwanted) {
+					t.Errorf("Expected spec not to contain %q:\n%s", unwanted, spec)
+				}
+			}
+
+			path := filepath.Join(t.TempDir(), "spec.yaml")
+			os.WriteFile(path, data, 0644)
+			loaded, err := NewSupportBundleSpecLoader().LoadFromFile(path)
+			if err != nil {
+				t.Fatalf("Expected the generated spec to load, got %v", err)
+			}
+			if loaded.Metadata.Name != tt.answers.Name || loaded.Spec.AutoDiscovery.Profile != tt.answers.Profile {
+				t.Errorf("Expected the answers to round-trip, got %+v", loaded)
+			}
+		})
+	}
+}
+
+func TestWriteInitSpec(t *testing.T) {
+	survey := &ClusterSurvey{
+		Namespaces: []SurveyedNamespace{{Name: "shop", Pods: 3}, {Name: "billing", Pods: 1}},
+		Registries: []SurveyedRegistry{{Registry: "ghcr.io", Images: 1}},
+		Problems:   []string{"failed to list namespaces: forbidden"},
+	}
+	outputPath := filepath.Join(t.TempDir(), DefaultInitSpecPath)
+
+	// Namespaces, name and profile are answered, the confirmations keep their defaults
+	var out bytes.Buffer
+	options := InitSpecOptions{In: strings.NewReader("billing, shop\n\ncomprehensive\n\ny\n"), Out: &out}
+	if _, err := writeInitSpec(survey, options, outputPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	spec, err := NewSupportBundleSpecLoader().LoadFromFile(outputPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	autoDiscovery := spec.Spec.AutoDiscovery
+	if strings.Join(autoDiscovery.Namespaces, ",") != "billing,shop" || autoDiscovery.Profile != "comprehensive" {
+		t.Errorf("Expected the answered namespaces and profile, got %+v", autoDiscovery)
+	}
+	if spec.Metadata.Name != "support-bundle" || !autoDiscovery.IncludeImages {
+		t.Errorf("Expected the default name and images, got %s and %v", spec.Metadata.Name, autoDiscovery.IncludeImages)
+	}
+	for _, filter := range autoDiscovery.ResourceFilters {
+		if filter.Name == "exclude-secrets" {
+			t.Errorf("Expected Secrets to be collected after answering yes")
+		}
+	}
+	if !strings.Contains(out.String(), "Warning: failed to list namespaces") || !strings.Contains(out.String(), "✅ Wrote") {
+		t.Errorf("Expected the warning and the written path, got:\n%s", out.String())
+	}
+
+	if _, err := writeInitSpec(survey, InitSpecOptions{In: strings.NewReader("bad namespace\n"), Out: &out}, outputPath); err == nil {
+		t.Errorf("Expected an invalid namespace answer to be rejected")
+	}
+}
+
+func TestInitSpecPrompter_Confirm(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		defaultValue bool
+		expected     bool
+		prompts      int
+	}{
+		{name: "y", input: "y\n", defaultValue: false, expected: true, prompts: 1},
+		{name: "yes in capitals", input: "YES\n", defaultValue: false, expected: true, prompts: 1},
+		{name: "n", input: "n\n", defaultValue: true, expected: false, prompts: 1},
+		{name: "No", input: "No\n", defaultValue: true, expected: false, prompts: 1},
+		{name: "empty keeps the default", input: "\n", defaultValue: true, expected: true, prompts: 1},
+		{name: "end of input keeps the default", input: "", defaultValue: true, expected: true, prompts: 1},
+		{name: "other answers ask again", input: "maybe\ntrue\ny\n", defaultValue: false, expected: true, prompts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			prompter := &initSpecPrompter{in: bufio.NewReader(strings.NewReader(tt.input)), out: &out}
+			confirmed, err := prompter.confirm("Collect Secrets", tt.defaultValue)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if confirmed != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, confirmed)
+			}
+			if asked := strings.Count(out.String(), "Collect Secrets"); asked != tt.prompts {
+				t.Errorf("Expected the question to be asked %d times, got %d", tt.prompts, asked)
+			}
+		})
+	}
+}
@@ -3,10 +3,16 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/replicatedhq/troubleshoot/pkg/autodiscover"
 	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
 	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -18,18 +24,74 @@ type SupportBundleCollectOptions struct {
 	// Auto-discovery options
 	Auto            bool     `json:"auto"`
 	Namespaces      []string `json:"namespaces,omitempty"`
+	AllNamespaces   bool     `json:"allNamespaces,omitempty"` // Scan every namespace instead of inferring them when Namespaces is empty
+	Apps            []string `json:"apps,omitempty"` // --app: collect everything labeled app.kubernetes.io/name or part-of with these values
 	IncludeImages   bool     `json:"includeImages,omitempty"`
+	AuditPullSecrets bool    `json:"auditPullSecrets,omitempty"` // Report which registries the pods' imagePullSecrets cover, without the credentials
 	RBACCheck       bool     `json:"rbacCheck,omitempty"`
+	IncludeSystemNamespaces bool `json:"includeSystemNamespaces,omitempty"` // Disable the default kube-system/kube-public/kube-node-lease excludes
+	CandidateNamespaces []string `json:"candidateNamespaces,omitempty"` // Probed when listing namespaces is forbidden
+	Analyze         bool     `json:"analyze,omitempty"` // Generate and run default analyzers for discovered resources
+	DebugLogFallback bool    `json:"debugLogFallback,omitempty"` // Read ephemeral container logs from the node with a debug pod
+	OnlyGroups      []string `json:"onlyGroups,omitempty"` // Collect only these collector groups (logs, workloads, networking, storage, images, cluster-info)
+	SkipGroups      []string `json:"skipGroups,omitempty"` // Skip these collector groups
+	ResourceFormat  string   `json:"resourceFormat,omitempty"` // "full", "table" or "both": server-side printed tables for large resource lists
+	TableThreshold  int      `json:"tableThreshold,omitempty"` // Objects per type and namespace before a table is used
+	MaxCollectors   int      `json:"maxCollectors,omitempty"`  // Keep at most this many collectors, dropping the lowest priority; 0 is unlimited
+	ClusterScope    []string `json:"clusterScope,omitempty"`   // Cluster-scoped types to collect, e.g. persistentvolumes or "*", see autodiscovery.ClusterScope
+	Exclude         string   `json:"exclude,omitempty"`        // --exclude patterns, e.g. "deploy,svc,ns:test-*", see GetPatternsHelp
+	Include         string   `json:"include,omitempty"`        // --include patterns; only resources matching one of them are collected
+	Since           string   `json:"since,omitempty"` // Start of the incident window: RFC3339 or a duration before now, e.g. 2h
+	Until           string   `json:"until,omitempty"` // End of the incident window, same formats as Since
+	AuditLog        string   `json:"auditLog,omitempty"` // API server audit log (JSON lines) searched for admission denials
+	NormalizeLogTimestamps bool `json:"normalizeLogTimestamps,omitempty"` // Collect logs with timestamps and rewrite them to RFC3339 UTC
+	Dev             bool     `json:"dev,omitempty"`      // Local kind/minikube mode: current kubeconfig context, no RBAC checks, short logs, directory bundle
 	
 	// Discovery configuration
 	ConfigFile      string `json:"configFile,omitempty"`
 	ProfileName     string `json:"profileName,omitempty"`
 	DryRun          bool   `json:"dryRun,omitempty"`
+	Baseline        string `json:"baseline,omitempty"` // Previous dry-run JSON result to compare against
 	
 	// Output options
 	OutputDir       string `json:"outputDir,omitempty"`
-	OutputFile      string `json:"outputFile,omitempty"`
+	ClusterName     string `json:"clusterName,omitempty"`  // Names the cluster in run-summary.json, --output templates and locks, defaults to the kubeconfig cluster or API server host
+	Output          string `json:"output,omitempty"`       // Bundle path template, e.g. bundles/{{.ClusterName}}/{{.Timestamp}}-{{.Profile}}.tgz, see OutputPathVars
+	OutputFile      string `json:"outputFile,omitempty"`   // Write the dry-run result here in OutputFormat
+	OutputFormat    string `json:"outputFormat,omitempty"` // Dry-run result format: "console", "json", "yaml"
 	ProgressFormat  string `json:"progressFormat,omitempty"` // "console", "json", "none"
+	Quiet           bool   `json:"quiet,omitempty"`        // Suppress progress and summary output
+	NoProgress      bool   `json:"noProgress,omitempty"`   // Do not draw the collection progress bar, e.g. for CI logs
+	Resume          bool   `json:"resume,omitempty"`       // Skip collectors completed by an interrupted run into OutputDir
+	Compression     string `json:"compression,omitempty"`  // "gzip" (default), "zstd" or "none" to leave the bundle as a directory
+	WorkspaceDir    string `json:"workspaceDir,omitempty"` // Index of created bundles for `support-bundle clean`, defaults to DefaultWorkspaceDir
+	NoTrack         bool   `json:"noTrack,omitempty"`      // Do not record the bundle in the workspace index
+	NoSearchIndex   bool   `json:"noSearchIndex,omitempty"` // Do not index the bundle's text files for `support-bundle inspect search`
+	Force           bool   `json:"force,omitempty"`        // Run even when another collection of the same cluster and namespaces holds the lock
+
+	// Anonymization options
+	Anonymize            bool   `json:"anonymize,omitempty"`
+	AnonymizationKey     string `json:"-"`                              // Reusing a key keeps pseudonyms stable across bundles
+	AnonymizationMapping string `json:"anonymizationMapping,omitempty"` // Mapping file path, must be outside the bundle
+
+	// Signing options
+	Sign       bool   `json:"sign,omitempty"`       // Write a checksum manifest into the bundle
+	SigningKey string `json:"signingKey,omitempty"` // PEM Ed25519 private key; implies --sign and signs the manifest
+
+	// Metrics options
+	MetricsFile string `json:"metricsFile,omitempty"` // Prometheus textfile written when the run finishes
+	MetricsAddr string `json:"metricsAddr,omitempty"` // Serve /metrics on this address while the run is in progress
+
+	// Upload options
+	UploadURL       string `json:"uploadURL,omitempty"`       // tus endpoint the bundle archive is uploaded to, resumed by `support-bundle upload`
+	UploadToken     string `json:"-"`                         // Sent as a bearer token with every upload request
+	UploadChunkSize int64  `json:"uploadChunkSize,omitempty"` // Bytes per upload part, defaults to DefaultUploadChunkSize
+
+	// In-cluster runner options
+	InCluster       bool   `json:"inCluster,omitempty"`       // Collect from a Job in the cluster and download its bundle, see InClusterRunner
+	RunnerNamespace string `json:"runnerNamespace,omitempty"` // Namespace of the runner Job, defaults to the kubeconfig context namespace or default
+	RunnerImage     string `json:"runnerImage,omitempty"`     // Image of the runner Job, defaults to DefaultRunnerImage
+	KeepRunner      bool   `json:"keepRunner,omitempty"`      // Leave the runner Job, ConfigMap and RBAC in place for inspection
 	
 	// Kubernetes connection
 	KubeconfigPath  string        `json:"kubeconfigPath,omitempty"`
@@ -45,6 +107,18 @@ type SupportBundleCollector struct {
 	imageCollector     *images.AutoDiscoveryImageCollector
 	configManager      *autodiscovery.ConfigManager
 	profileManager     *DiscoveryProfileManager
+	collectorRunner    CollectorRunner
+	redactor           *autodiscovery.CollectorRedactor // Redacts collector output as each collector runs, nil without rules
+	trimmer            *autodiscovery.LargeObjectTrimmer // Trims oversized ConfigMaps and Secrets as each collector runs
+	timestamps         *autodiscovery.LogTimestampNormalizer // Normalizes log timestamps as each logs collector runs
+	secretFilter       *autodiscovery.SecretFilter       // Applies the secret policy as each collector runs
+	retrier            *autodiscovery.CollectorRetrier   // Reruns collectors that fail transiently, nil without retry policies
+	execution          *ExecutionReport                  // Status, timings and bytes of each collector of the last runCollectors
+	showProgress       bool                              // Draw a progress bar while runCollectors runs
+	kubeContext        string // For --output templates
+	contextNamespace   string // Namespace of the kubeconfig context, inferred as the scope when no namespaces are given
+	namespaceScope     *NamespaceScope // Namespaces inferred for the last collection, and why, nil when they were given
+	clusterName        string
 }
 
 // NewSupportBundleCollector creates a new support bundle collector
@@ -55,21 +129,25 @@ func NewSupportBundleCollector(options SupportBundleCollectOptions) (*SupportBun
 		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
 	}
 
-	// Create Kubernetes clients
-	kubeClient, err := kubernetes.NewForConfig(config)
+	// Create auto-discovery components
+	discoverer, err := autodiscovery.NewDiscoverer(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		return nil, fmt.Errorf("failed to create discoverer: %w", err)
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
+	// Create Kubernetes clients, their requests are accounted for in the discoverer's API usage report
+	clientConfig := rest.CopyConfig(config)
+	if usage := discoverer.APIUsage(); usage != nil {
+		usage.Install(clientConfig)
+	}
+	kubeClient, err := kubernetes.NewForConfig(clientConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	// Create auto-discovery components
-	discoverer, err := autodiscovery.NewDiscoverer(config)
+	dynamicClient, err := dynamic.NewForConfig(clientConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create discoverer: %w", err)
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
 	imageCollector := images.NewAutoDiscoveryImageCollector(dynamicClient)
@@ -81,6 +159,30 @@ func NewSupportBundleCollector(options SupportBundleCollectOptions) (*SupportBun
 		if err := configManager.LoadFromFile(options.ConfigFile); err != nil {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
 		}
+		if err := discoverer.RegisterHooks(configManager.GetHooks()); err != nil {
+			return nil, fmt.Errorf("failed to register discovery hooks: %w", err)
+		}
+		discoverer.SetExecCatalog(configManager.GetExecCatalog())
+	}
+	if options.Exclude != "" || options.Include != "" {
+		patterns, err := NewPatternFilterHook(options.Exclude, options.Include, kubeClient.Discovery())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource patterns: %w", err)
+		}
+		discoverer.RegisterPreFilterHook(patterns)
+	}
+	redactor, err := autodiscovery.NewCollectorRedactor(configManager.GetRedactionRules())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load redaction rules: %w", err)
+	}
+	retrier, err := autodiscovery.NewCollectorRetrier(configManager.GetRetryConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retry policies: %w", err)
+	}
+
+	kubeContext, clusterName := resolveClusterIdentity(options, config)
+	if options.ClusterName != "" {
+		clusterName = options.ClusterName
 	}
 
 	return &SupportBundleCollector{
@@ -88,21 +190,121 @@ func NewSupportBundleCollector(options SupportBundleCollectOptions) (*SupportBun
 		dynamicClient:  dynamicClient,
 		discoverer:     discoverer,
 		imageCollector: imageCollector,
-		configManager:  configManager,
-		profileManager: profileManager,
+		configManager:   configManager,
+		profileManager:  profileManager,
+		collectorRunner: writeCollectorSpec,
+		redactor:        redactor,
+		trimmer:         autodiscovery.NewLargeObjectTrimmer(),
+		timestamps:      autodiscovery.NewLogTimestampNormalizer(),
+		secretFilter:    autodiscovery.NewSecretFilter(),
+		retrier:         retrier,
+		kubeContext:     kubeContext,
+		clusterName:     clusterName,
+		contextNamespace: resolveContextNamespace(options),
 	}, nil
 }
 
 // CollectWithAutoDiscovery performs support bundle collection with auto-discovery
 func (sbc *SupportBundleCollector) CollectWithAutoDiscovery(ctx context.Context, options SupportBundleCollectOptions) (*CollectionResult, error) {
-	fmt.Printf("Starting auto-discovery support bundle collection...\n")
-	
+	if !options.Quiet {
+		fmt.Printf("Starting auto-discovery support bundle collection...\n")
+	}
+
+	options, err := ApplyDevMode(options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --dev: %w", err)
+	}
+	if err := validateInClusterOptions(options); err != nil {
+		return nil, err
+	}
+
+	if options.Baseline != "" && !options.DryRun {
+		return nil, fmt.Errorf("--baseline can only be used with --dry-run")
+	}
+	if options.OutputFile != "" && !options.DryRun {
+		return nil, fmt.Errorf("--output-file can only be used with --dry-run")
+	}
+	if options.AllNamespaces && len(options.Namespaces) > 0 {
+		return nil, fmt.Errorf("--all-namespaces and --namespaces cannot be used together")
+	}
+	timeWindow, err := autodiscovery.ParseTimeWindow(options.Since, options.Until, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid --since/--until: %w", err)
+	}
+	if options.AuditLog != "" {
+		if _, err := os.Stat(options.AuditLog); err != nil {
+			return nil, fmt.Errorf("invalid --audit-log: %w", err)
+		}
+	}
+	if (options.MetricsFile != "" || options.MetricsAddr != "") && options.DryRun {
+		return nil, fmt.Errorf("--metrics-file and --metrics-addr cannot be used with --dry-run")
+	}
+	if options.Compression != "" && options.DryRun {
+		return nil, fmt.Errorf("--compression cannot be used with --dry-run")
+	}
+	if options.UploadURL != "" {
+		if options.DryRun {
+			return nil, fmt.Errorf("--upload-url cannot be used with --dry-run")
+		}
+		if options.Compression == CompressionNone {
+			return nil, fmt.Errorf("--upload-url requires a bundle archive, not --compression none")
+		}
+		if err := ValidateUploadURL(options.UploadURL); err != nil {
+			return nil, fmt.Errorf("invalid --upload-url: %w", err)
+		}
+	}
+	if err := ValidateCompression(options.Compression); err != nil {
+		return nil, fmt.Errorf("invalid --compression: %w", err)
+	}
+	if options.Resume && options.DryRun {
+		return nil, fmt.Errorf("--resume cannot be used with --dry-run")
+	}
+	if options.Output != "" {
+		if options.OutputDir != "" {
+			return nil, fmt.Errorf("--output and --output-dir cannot be used together")
+		}
+		if options.DryRun {
+			return nil, fmt.Errorf("--output cannot be used with --dry-run, use --output-file")
+		}
+		if err := ValidateOutputTemplate(options.Output, options.Compression); err != nil {
+			return nil, fmt.Errorf("invalid --output: %w", err)
+		}
+	}
+	if options.Resume && options.OutputDir == "" {
+		return nil, fmt.Errorf("--resume requires --output-dir pointing at the interrupted collection")
+	}
+	if options.AnonymizationMapping != "" && options.OutputDir != "" && isWithinDir(options.AnonymizationMapping, options.OutputDir) {
+		return nil, fmt.Errorf("--anonymization-mapping must be outside the bundle output directory")
+	}
+	if options.SigningKey != "" {
+		if _, err := LoadSigningKey(options.SigningKey); err != nil {
+			return nil, fmt.Errorf("invalid --signing-key: %w", err)
+		}
+	}
+	if readme := sbc.configManager.GetBundleReadmeConfig(); !options.DryRun && !readme.Disabled {
+		if _, err := loadBundleReadmeTemplate(readme); err != nil {
+			return nil, fmt.Errorf("invalid bundleReadme config: %w", err)
+		}
+	}
+
 	// Setup discovery options from CLI flags
 	discoveryOpts := autodiscovery.DiscoveryOptions{
 		Namespaces:    options.Namespaces,
 		IncludeImages: options.IncludeImages,
 		RBACCheck:     options.RBACCheck,
 		MaxDepth:      3, // Default
+		DebugLogFallback: options.DebugLogFallback,
+		OnlyGroups:       options.OnlyGroups,
+		SkipGroups:       options.SkipGroups,
+		ResourceFormat:   options.ResourceFormat,
+		CandidateNamespaces: options.CandidateNamespaces,
+		TableThreshold:   options.TableThreshold,
+		Apps:             options.Apps,
+		TimeWindow:       timeWindow,
+		AuditLogPath:     options.AuditLog,
+		MaxCollectors:    options.MaxCollectors,
+		ClusterScope:     autodiscovery.ClusterScope{Resources: options.ClusterScope},
+		NormalizeLogTimestamps: options.NormalizeLogTimestamps,
 	}
 
 	// Apply profile if specified
@@ -114,9 +316,47 @@ func (sbc *SupportBundleCollector) CollectWithAutoDiscovery(ctx context.Context,
 		discoveryOpts = profile.ApplyToOptions(discoveryOpts)
 	}
 
+	if options.IncludeSystemNamespaces {
+		sbc.configManager.SetIncludeSystemNamespaces(true)
+	}
+
 	// Merge with configuration file settings
 	finalOpts := sbc.configManager.GetDiscoveryOptions(&discoveryOpts)
 
+	if options.Dev {
+		finalOpts = applyDevModeDiscovery(finalOpts)
+	}
+
+	// Image collection lists pods on its own, it must skip the protected namespaces too
+	if sbc.imageCollector != nil {
+		sbc.imageCollector.SetProtectedNamespaces(finalOpts.ProtectedNamespaces)
+	}
+
+	// Image metadata is the images collector group
+	if !autodiscovery.CollectorGroupSelected(autodiscovery.CollectorGroupImages, finalOpts) {
+		finalOpts.IncludeImages = false
+	}
+
+	// Flags, profile and config file are checked merged, against the one rule set of DiscoveryOptions.Validate
+	if err := finalOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid discovery options: %w", err)
+	}
+
+	// Without namespaces from flags, profile or config file, scope to the context namespace and the --app workloads
+	finalOpts, sbc.namespaceScope = sbc.inferNamespaceScope(ctx, finalOpts, options)
+
+	// A mistyped namespace would otherwise produce an empty bundle
+	if sbc.kubeClient != nil && len(finalOpts.Namespaces) > 0 {
+		if err := autodiscovery.CheckNamespacesExist(ctx, sbc.kubeClient, finalOpts.Namespaces); err != nil {
+			return nil, err
+		}
+	}
+
+	// The runner pod collects with the same options, granted only what discovery finds here; only the bundle comes back
+	if options.InCluster {
+		return sbc.collectInCluster(ctx, finalOpts, options)
+	}
+
 	// Handle dry-run mode
 	if options.DryRun {
 		return sbc.performDryRun(ctx, finalOpts, options)
@@ -128,7 +368,9 @@ func (sbc *SupportBundleCollector) CollectWithAutoDiscovery(ctx context.Context,
 
 // performDryRun shows what would be collected without actually collecting
 func (sbc *SupportBundleCollector) performDryRun(ctx context.Context, opts autodiscovery.DiscoveryOptions, cliOptions SupportBundleCollectOptions) (*CollectionResult, error) {
-	fmt.Printf("🔍 DRY RUN: Auto-discovery analysis\n")
+	if !cliOptions.Quiet {
+		fmt.Printf("🔍 DRY RUN: Auto-discovery analysis\n")
+	}
 	
 	// Discover what collectors would be generated
 	collectors, err := sbc.discoverer.Discover(ctx, opts)
@@ -136,17 +378,136 @@ func (sbc *SupportBundleCollector) performDryRun(ctx context.Context, opts autod
 		return nil, fmt.Errorf("dry run discovery failed: %w", err)
 	}
 
-	// Count collectors by type
+	throttling := sbc.discoverer.ThrottleStats()
+	unserved := sbc.findUnservedGVRs(cliOptions.ProfileName)
+	if !cliOptions.Quiet {
+		sbc.printDryRunSummary(collectors, opts)
+		printThrottleSummary(throttling)
+		printDependencyWarnings(sbc.discoverer.DependencyReport())
+		printCollectorLimit(sbc.discoverer.CollectorLimitReport())
+		printClusterScope(sbc.discoverer.ClusterScopeReport())
+		printCollectionAnnotations(sbc.discoverer.AnnotationReport())
+		printUnservedGVRs(unserved)
+		printDeprecatedAPIs(sbc.discoverer.DeprecationReport())
+		printAPIUsageSummary(sbc.apiUsageReport())
+	}
+
+	result := &CollectionResult{
+		Collectors:    collectors,
+		DryRun:        true,
+		Summary:       generateDryRunSummary(collectors, opts),
+		Duration:      time.Since(time.Now()), // Minimal duration for dry run
+	}
+	result.Summary.Throttling = throttling
+	result.UnservedResources = unserved
+	result.CollectorLimit = sbc.discoverer.CollectorLimitReport()
+	result.ClusterScope = sbc.discoverer.ClusterScopeReport()
+	result.Annotations = sbc.discoverer.AnnotationReport()
+	result.NamespaceScope = sbc.namespaceScope
+	if deprecations := sbc.discoverer.DeprecationReport(); deprecations != nil {
+		result.DeprecatedAPIs = deprecations.Deprecations
+	}
+	if apiUsage := sbc.apiUsageReport(); apiUsage != nil {
+		result.APIUsage = &apiUsage.Summary
+	}
+
+	if cliOptions.Baseline == "" && cliOptions.OutputFile == "" {
+		return result, nil
+	}
+
+	executor := NewDryRunExecutor(sbc.discoverer, sbc.imageCollector)
+	executor.SetQuietMode(cliOptions.Quiet)
+
+	// Compare against a previous dry run if requested
+	if cliOptions.Baseline != "" {
+		if err := executor.LoadBaseline(cliOptions.Baseline); err != nil {
+			return nil, fmt.Errorf("failed to load dry run baseline: %w", err)
+		}
+	}
+
+	dryRunResult := executor.BuildResult(collectors, opts)
+	dryRunResult.NamespaceScope = sbc.namespaceScope
+	addUnservedGVRWarnings(dryRunResult, unserved)
+	result.Comparison = dryRunResult.Comparison
+	if result.Comparison != nil && !cliOptions.Quiet {
+		fmt.Printf("\n")
+		printDryRunComparison(result.Comparison)
+	}
+
+	// Write the full dry-run result in the selected format
+	if cliOptions.OutputFile != "" {
+		format := cliOptions.OutputFormat
+		if format == "" {
+			format = "json"
+		}
+		if err := executor.SetOutputFormat(format); err != nil {
+			return nil, err
+		}
+		executor.SetOutputFile(cliOptions.OutputFile)
+		if err := executor.PrintResult(dryRunResult); err != nil {
+			return nil, err
+		}
+		result.OutputPath = cliOptions.OutputFile
+	}
+
+	return result, nil
+}
+
+// printDryRunSummary prints the collectors a dry run would generate
+func (sbc *SupportBundleCollector) printDryRunSummary(collectors []autodiscovery.CollectorSpec, opts autodiscovery.DiscoveryOptions) {
+	// Count collectors by type and group
 	collectorStats := make(map[string]int)
+	groupStats := make(map[string]int)
 	for _, collector := range collectors {
 		collectorStats[collector.Type]++
+		groupStats[autodiscovery.CollectorGroupFor(collector)]++
 	}
 
-	// Print dry-run summary
 	fmt.Printf("\n📊 Discovery Summary:\n")
-	fmt.Printf("  Namespaces: %v\n", opts.Namespaces)
+	if sbc.namespaceScope != nil && len(sbc.namespaceScope.Namespaces) > 0 {
+		fmt.Printf("  Namespaces: %v (inferred, none given)\n", opts.Namespaces)
+	} else {
+		fmt.Printf("  Namespaces: %v\n", opts.Namespaces)
+	}
+	if len(opts.Apps) > 0 {
+		fmt.Printf("  Apps: %v (namespaces: %v)\n", opts.Apps, collectorNamespaces(collectors))
+	}
 	fmt.Printf("  Include Images: %v\n", opts.IncludeImages)
 	fmt.Printf("  RBAC Check: %v\n", opts.RBACCheck)
+	if policy := sbc.configManager.GetSystemNamespacePolicy(); policy.IncludeSystemNamespaces {
+		fmt.Printf("  System Namespaces: included (default excludes disabled)\n")
+	}
+	if len(opts.ProtectedNamespaces) > 0 {
+		fmt.Printf("  Protected Namespaces: %v (never collected)\n", opts.ProtectedNamespaces)
+	}
+	if len(opts.ClusterScope.Resources) > 0 {
+		fmt.Printf("  Cluster Scope: %v\n", opts.ClusterScope.Resources)
+	}
+	if len(opts.OnlyGroups) > 0 {
+		fmt.Printf("  Only Groups: %v\n", opts.OnlyGroups)
+	}
+	if len(opts.SkipGroups) > 0 {
+		fmt.Printf("  Skipped Groups: %v\n", opts.SkipGroups)
+	}
+	if opts.ResourceFormat == autodiscovery.ResourceFormatTable || opts.ResourceFormat == autodiscovery.ResourceFormatBoth {
+		threshold := opts.TableThreshold
+		if threshold <= 0 {
+			threshold = autodiscovery.DefaultTableThreshold
+		}
+		fmt.Printf("  Resource Format: %s (lists of %d+ objects)\n", opts.ResourceFormat, threshold)
+	}
+	if !opts.TimeWindow.IsZero() {
+		fmt.Printf("  Time Window: %s\n", opts.TimeWindow)
+	}
+	if opts.AuditLogPath != "" {
+		fmt.Printf("  Audit Log: %s\n", opts.AuditLogPath)
+	}
+	if opts.MaxLogLines > 0 {
+		fmt.Printf("  Max Log Lines: %d\n", opts.MaxLogLines)
+	}
+	if opts.BatchByNamespace {
+		fmt.Printf("  Resource Batching: by namespace\n")
+	}
 	fmt.Printf("  Total Collectors: %d\n", len(collectors))
 	
 	fmt.Printf("\n📋 Collectors by Type:\n")
@@ -154,18 +515,32 @@ func (sbc *SupportBundleCollector) performDryRun(ctx context.Context, opts autod
 		fmt.Printf("  - %s: %d collectors\n", collectorType, count)
 	}
 
+	fmt.Printf("\n🏷️  Collectors by Group:\n")
+	for _, group := range autodiscovery.CollectorGroups {
+		if count := groupStats[group]; count > 0 {
+			fmt.Printf("  - %s: %d collectors\n", group, count)
+		}
+	}
+
 	fmt.Printf("\n📝 Generated Collectors:\n")
 	for i, collector := range collectors {
-		fmt.Printf("  [%d] %s (type: %s, namespace: %s, priority: %d)\n", 
-			i+1, collector.Name, collector.Type, collector.Namespace, collector.Priority)
+		fmt.Printf("  [%d] %s (type: %s, group: %s, namespace: %s, priority: %d)\n", 
+			i+1, collector.Name, collector.Type, autodiscovery.CollectorGroupFor(collector), collector.Namespace, collector.Priority)
 	}
+}
 
-	return &CollectionResult{
-		Collectors:    collectors,
-		DryRun:        true,
-		Summary:       generateDryRunSummary(collectors, opts),
-		Duration:      time.Since(time.Now()), // Minimal duration for dry run
-	}, nil
+// collectorNamespaces returns the sorted namespaces the collectors gather from
+func collectorNamespaces(collectors []autodiscovery.CollectorSpec) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, collector := range collectors {
+		if collector.Namespace != "" && !seen[collector.Namespace] {
+			seen[collector.Namespace] = true
+			namespaces = append(namespaces, collector.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
 }
 
 // performCollection executes the actual support bundle collection
@@ -174,28 +549,120 @@ func (sbc *SupportBundleCollector) performCollection(ctx context.Context, opts a
 	
 	fmt.Printf("🚀 Starting auto-discovery collection...\n")
 
+	// Two collections of the same namespaces would double the API load and race on --resume checkpoints
+	lock, err := sbc.acquireCollectionLock(cliOptions, opts.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	if lock != nil {
+		defer func() {
+			if err := lock.Release(); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}()
+	}
+
+	metrics := NewCollectionMetrics()
+	if cliOptions.MetricsAddr != "" {
+		stop, err := metrics.Serve(cliOptions.MetricsAddr)
+		if err != nil {
+			fmt.Printf("Warning: failed to serve metrics: %v\n", err)
+		} else {
+			defer stop()
+		}
+	}
+
 	// Perform discovery
 	result, err := sbc.discoverer.DiscoverWithImageCollection(ctx, opts, opts.IncludeImages)
 	if err != nil {
+		metrics.ObserveErrors([]string{fmt.Sprintf("auto-discovery failed: %v", err)})
+		metrics.Finish(time.Since(startTime), 0, false)
+		writeCollectionMetrics(metrics, cliOptions.MetricsFile)
 		return nil, fmt.Errorf("auto-discovery failed: %w", err)
 	}
+	metrics.ObserveDiscovery(time.Since(startTime), result.Collectors)
+	collectorLimit := sbc.discoverer.CollectorLimitReport()
+	printCollectorLimit(collectorLimit)
+	annotations := sbc.discoverer.AnnotationReport()
+	if !cliOptions.Quiet {
+		printCollectionAnnotations(annotations)
+	}
 
 	// Create output directory
 	outputDir := cliOptions.OutputDir
+	archivePath := "" // Set when --output names the archive
+	if cliOptions.Output != "" {
+		path, err := RenderOutputPath(cliOptions.Output, sbc.outputPathVars(cliOptions, opts, startTime))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --output: %w", err)
+		}
+		var compression string
+		outputDir, compression = splitArchivePath(path)
+		if compression != "" {
+			cliOptions.Compression = compression
+			archivePath = path
+		}
+	}
 	if outputDir == "" {
-		outputDir = fmt.Sprintf("support-bundle-%s", time.Now().Format("2006-01-02T15-04-05"))
+		outputDir = fmt.Sprintf("support-bundle-%s", startTime.Format(outputTimestampLayout))
 	}
 
 	// In a real implementation, this would integrate with the existing
 	// troubleshoot.sh support bundle collection system
 	fmt.Printf("📦 Generating support bundle with %d auto-discovered collectors...\n", len(result.Collectors))
-	
+
+	checkpoint, err := openCollectionCheckpoint(outputDir, cliOptions.Resume)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume collection: %w", err)
+	}
+	sbc.setAPIUsagePhase(autodiscovery.APIUsagePhaseCollection)
+	sbc.showProgress = showCollectionProgress(cliOptions)
+	collectorErrors, err := sbc.runCollectors(ctx, result.Collectors, outputDir, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	executionPath, err := sbc.execution.WriteFile(outputDir)
+	if err != nil {
+		collectorErrors = append(collectorErrors, err.Error())
+	}
+
+	// Record what was discovered so `support-bundle inspect` can show it without the cluster
+	discoveryManifest := autodiscover.Plan{Options: opts, Collectors: result.Collectors, CreatedAt: startTime, CollectorLimit: collectorLimit}
+	if err := writeJSONFile(filepath.Join(outputDir, DiscoveryManifestFileName), discoveryManifest); err != nil {
+		collectorErrors = append(collectorErrors, fmt.Sprintf("failed to write discovery manifest: %v", err))
+	}
+
+	// Record the deprecated APIs the server warned about, to prepare for cluster upgrades
+	deprecations := sbc.discoverer.DeprecationReport()
+	if deprecations != nil {
+		if err := writeJSONFile(filepath.Join(outputDir, autodiscovery.DeprecationsReportFileName), deprecations); err != nil {
+			collectorErrors = append(collectorErrors, fmt.Sprintf("failed to write deprecations report: %v", err))
+		}
+		printDeprecatedAPIs(deprecations)
+	}
+
 	// Collect image metadata if requested
 	var imageResult *images.ImageCollectionResult
+	var nodeImageReport *images.NodeImagePresenceReport
+	var nodeImageErr error
+	var pullSecretAudit *images.PullSecretAuditReport
+	var pullSecretAuditErr error
+	var registryCatalog *images.RegistryCatalog
+	var registryCatalogErr error
 	if opts.IncludeImages {
 		// This would extract resources from the discovery result and collect image facts
 		fmt.Printf("🖼️  Collecting image metadata...\n")
 		// In full implementation, this would use actual discovered resources
+
+		// Cross-reference pod images with node image caches to spot ImagePullBackOff risks
+		nodeImageReport, nodeImageErr = sbc.imageCollector.BuildNodeImagePresenceReport(ctx, opts.Namespaces)
+
+		if cliOptions.AuditPullSecrets {
+			pullSecretAudit, pullSecretAuditErr = sbc.imageCollector.BuildPullSecretAudit(ctx, opts.Namespaces)
+		}
+
+		// The audit, when run, tells whether pull secrets actually cover each registry
+		registryCatalog, registryCatalogErr = sbc.imageCollector.BuildRegistryCatalog(ctx, opts.Namespaces, pullSecretAudit)
 	}
 
 	collectionResult := &CollectionResult{
@@ -205,19 +672,565 @@ func (sbc *SupportBundleCollector) performCollection(ctx context.Context, opts a
 		Summary:        generateCollectionSummary(result.Collectors, imageResult),
 		Duration:       time.Since(startTime),
 		DryRun:         false,
+		Errors:         collectorErrors,
+		CollectorLimit: collectorLimit,
+		ClusterScope:   sbc.discoverer.ClusterScopeReport(),
+		Annotations:    annotations,
+		NamespaceScope: sbc.namespaceScope,
+		ExecutionReportPath: executionPath,
+	}
+	executionSummary := sbc.execution.Summary()
+	collectionResult.Execution = &executionSummary
+	collectionResult.Summary.Throttling = sbc.discoverer.ThrottleStats()
+	if deprecations != nil {
+		collectionResult.DeprecatedAPIs = deprecations.Deprecations
+	}
+	if sbc.redactor != nil {
+		collectionResult.RedactedFiles = sbc.redactor.RedactedFiles()
+		printRedactionSummary(collectionResult.RedactedFiles)
+	}
+	if sbc.trimmer != nil {
+		collectionResult.SkippedObjects = sbc.trimmer.Skipped()
+		printSkippedObjectsSummary(collectionResult.SkippedObjects)
+	}
+	if sbc.timestamps != nil {
+		collectionResult.LogTimestamps = sbc.timestamps.Report()
+		printLogTimestampSummary(collectionResult.LogTimestamps)
+	}
+	if sbc.retrier != nil {
+		collectionResult.Retries = sbc.retrier.Retries()
+		printRetrySummary(collectionResult.Retries)
+	}
+	if sbc.secretFilter != nil {
+		if report := sbc.secretFilter.Report(); report != nil {
+			if err := writeJSONFile(filepath.Join(outputDir, autodiscovery.SecretPolicyReportPath), report); err != nil {
+				collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write secret policy report: %v", err))
+			}
+			collectionResult.Secrets = report
+			printSecretPolicySummary(report)
+		}
 	}
 
+	if nodeImageErr != nil {
+		collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to build node image presence report: %v", nodeImageErr))
+	} else if nodeImageReport != nil {
+		path := filepath.Join(outputDir, "images", images.NodeImagePresenceFileName)
+		if err := writeJSONFile(path, nodeImageReport); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write node image presence report: %v", err))
+		}
+		collectionResult.NodeImagePresence = &nodeImageReport.Summary
+		printNodeImagePresenceSummary(nodeImageReport)
+	}
+
+	if pullSecretAuditErr != nil {
+		collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to audit image pull secrets: %v", pullSecretAuditErr))
+	} else if pullSecretAudit != nil {
+		path := filepath.Join(outputDir, "images", images.PullSecretAuditFileName)
+		if err := writeJSONFile(path, pullSecretAudit); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write pull secret audit: %v", err))
+		}
+		collectionResult.PullSecretAudit = &pullSecretAudit.Summary
+		printPullSecretAuditSummary(pullSecretAudit)
+	}
+
+	if registryCatalogErr != nil {
+		collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to build registry catalog: %v", registryCatalogErr))
+	} else if registryCatalog != nil {
+		path := filepath.Join(outputDir, "images", images.RegistryCatalogFileName)
+		if err := writeJSONFile(path, registryCatalog); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write registry catalog: %v", err))
+		}
+		collectionResult.RegistryCatalog = &registryCatalog.Summary
+		printRegistryCatalogSummary(registryCatalog)
+	}
+
+	// Keep the classified image errors, which otherwise only live as long as the image collector
+	var imageErrors *images.ErrorLedger
+	if opts.IncludeImages {
+		imageErrors = sbc.imageCollector.ErrorLedger()
+	}
+	if imageErrors != nil {
+		path := filepath.Join(outputDir, "images", images.ErrorLedgerFileName)
+		if err := writeJSONFile(path, imageErrors); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write image error ledger: %v", err))
+		}
+		collectionResult.ImageErrors = &imageErrors.Summary
+		collectionResult.TopImageErrors = imageErrors.TopClasses(topImageErrorClasses)
+	}
+
+	// Write per-namespace summaries and the aggregate index
+	summaryWriter := NewNamespaceSummaryWriter(outputDir)
+	summaryWriter.RecordExecutions(result.Collectors, sbc.execution)
+	if _, err := summaryWriter.Write(); err != nil {
+		collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write namespace summaries: %v", err))
+	} else {
+		collectionResult.NamespaceIndexPath = summaryWriter.IndexPath()
+	}
+
+	// Leave an audit note when the default system namespace excludes were disabled
+	if policy := sbc.configManager.GetSystemNamespacePolicy(); policy.IncludeSystemNamespaces {
+		if path, err := writeSystemNamespaceAuditNote(outputDir, policy); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, err.Error())
+		} else {
+			collectionResult.AuditNotes = append(collectionResult.AuditNotes, path)
+		}
+	}
+
+	// Run auto-generated analyzers so the bundle has pass/warn/fail results, not just raw data
+	if cliOptions.Analyze {
+		report, err := sbc.runAnalysis(ctx, opts)
+		if err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, err.Error())
+		} else {
+			collectionResult.Analysis = report
+			if path, err := writeAnalysisReport(outputDir, report); err != nil {
+				collectionResult.Errors = append(collectionResult.Errors, err.Error())
+			} else {
+				collectionResult.AnalysisPath = path
+			}
+			printAnalysisResults(report)
+		}
+	}
+
+	// Account for every API request of the run, nothing below talks to the cluster
+	if apiUsage := sbc.apiUsageReport(); apiUsage != nil {
+		if err := writeJSONFile(filepath.Join(outputDir, autodiscovery.APIUsageFileName), apiUsage); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to write API usage: %v", err))
+		}
+		collectionResult.APIUsage = &apiUsage.Summary
+		printAPIUsageSummary(apiUsage)
+	}
+
+	// Summarize the run for `support-bundle merge-summaries`, before anonymization so it matches the rest of the bundle
+	runSummary := sbc.buildRunSummary(cliOptions, opts, startTime, collectionResult, registryCatalog)
+	if path, err := runSummary.WriteFile(outputDir); err != nil {
+		collectionResult.Errors = append(collectionResult.Errors, err.Error())
+	} else {
+		collectionResult.RunSummaryPath = path
+	}
+
+	// Summarize the bundle for humans before anonymization, which rewrites the README like any other file
+	readmeData := NewBundleReadmeData(outputDir, result.Collectors, time.Since(startTime))
+	readmeData.Warnings = collectionResult.Errors
+	readmeData.Skipped = bundleReadmeSkippedItems(opts, sbc.discoverer.UnavailableAPIServices())
+	readmeData.ImageErrors = imageErrorReadmeItems(collectionResult.TopImageErrors)
+	if path, err := WriteBundleReadme(outputDir, readmeData, sbc.configManager.GetBundleReadmeConfig()); err != nil {
+		collectionResult.Errors = append(collectionResult.Errors, err.Error())
+	} else {
+		collectionResult.ReadmePath = path
+	}
+
+	// Anonymize last so every file written above is covered
+	if cliOptions.Anonymize {
+		mappingPath, err := sbc.anonymizeBundle(ctx, outputDir, result, cliOptions)
+		if err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, err.Error())
+		} else {
+			collectionResult.AnonymizationMappingPath = mappingPath
+		}
+	}
+
+	// Index the text files once they are final, after anonymization, so searches find what the bundle holds
+	if !cliOptions.NoSearchIndex {
+		if path, err := WriteSearchIndex(outputDir); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, err.Error())
+		} else {
+			collectionResult.SearchIndexPath = path
+		}
+	}
+
+	// Describe the layout before signing so the checksum manifest covers it
+	var pending []string
+	if cliOptions.Sign || cliOptions.SigningKey != "" {
+		pending = append(pending, ManifestFileName)
+	}
+	if cliOptions.SigningKey != "" {
+		pending = append(pending, SignatureFileName)
+	}
+	if _, err := WriteLayoutManifest(outputDir, startTime, pending...); err != nil {
+		collectionResult.Errors = append(collectionResult.Errors, err.Error())
+	}
+
+	// Sign after every other step has written to the bundle
+	if cliOptions.Sign || cliOptions.SigningKey != "" {
+		if err := signBundle(outputDir, cliOptions.SigningKey, collectionResult); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, err.Error())
+		}
+	}
+
+	// Archive once nothing else writes to the bundle directory
+	if format, _ := ArchiveFormatFor(cliOptions.Compression); format != nil {
+		written, err := WriteBundleArchive(outputDir, format)
+		if err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, err.Error())
+		}
+		if written != "" && archivePath != "" && written != archivePath {
+			// e.g. .tgz, WriteBundleArchive always uses the format's own extension
+			if err := os.Rename(written, archivePath); err != nil {
+				collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to rename bundle archive: %v", err))
+			} else {
+				written = archivePath
+			}
+		}
+		if written != "" {
+			collectionResult.OutputPath = written
+		}
+	}
+
+	// Upload the finished archive; a failed upload keeps its state next to the bundle for `support-bundle upload` to resume
+	if cliOptions.UploadURL != "" {
+		if upload, err := uploadBundle(ctx, collectionResult.OutputPath, cliOptions.UploadURL, cliOptions.UploadToken, cliOptions.UploadChunkSize); err != nil {
+			collectionResult.Errors = append(collectionResult.Errors, fmt.Sprintf("failed to upload bundle: %v", err))
+		} else {
+			collectionResult.Upload = upload
+		}
+	}
+
+	// Record the bundle so `support-bundle clean` can remove it later
+	if !cliOptions.NoTrack {
+		trackBundle(cliOptions.WorkspaceDir, collectionResult.OutputPath, startTime)
+	}
+
+	metrics.ObserveAPIRequests(collectionResult.Summary.Throttling)
+	metrics.ObserveErrors(collectionResult.Errors)
+	metrics.Finish(time.Since(startTime), bundleSize(collectionResult.OutputPath), len(collectionResult.Errors) == 0)
+	if writeCollectionMetrics(metrics, cliOptions.MetricsFile) {
+		collectionResult.MetricsPath = cliOptions.MetricsFile
+	}
+
+	printExecutionReport(sbc.execution)
 	fmt.Printf("✅ Support bundle collection complete!\n")
 	fmt.Printf("   Collectors: %d\n", len(result.Collectors))
 	fmt.Printf("   Duration: %v\n", collectionResult.Duration.Round(time.Second))
-	fmt.Printf("   Output: %s\n", outputDir)
+	fmt.Printf("   Output: %s\n", collectionResult.OutputPath)
+	if collectionResult.ReadmePath != "" {
+		fmt.Printf("   README: %s\n", collectionResult.ReadmePath)
+	}
+	if collectionResult.NamespaceIndexPath != "" {
+		fmt.Printf("   Namespace Index: %s\n", collectionResult.NamespaceIndexPath)
+	}
+	if collectionResult.AnalysisPath != "" {
+		fmt.Printf("   Analysis: %s\n", collectionResult.AnalysisPath)
+	}
+	if collectionResult.SearchIndexPath != "" {
+		fmt.Printf("   Search Index: %s\n", collectionResult.SearchIndexPath)
+	}
+	if collectionResult.AnonymizationMappingPath != "" {
+		fmt.Printf("   Anonymization Mapping: %s (do not share)\n", collectionResult.AnonymizationMappingPath)
+	}
+	if collectionResult.ManifestPath != "" {
+		fmt.Printf("   Manifest: %s\n", collectionResult.ManifestPath)
+	}
+	if collectionResult.SignaturePath != "" {
+		fmt.Printf("   Signature: %s\n", collectionResult.SignaturePath)
+	}
+	if collectionResult.ExecutionReportPath != "" {
+		fmt.Printf("   Execution Report: %s\n", collectionResult.ExecutionReportPath)
+	}
+	if collectionResult.RunSummaryPath != "" {
+		fmt.Printf("   Run Summary: %s\n", collectionResult.RunSummaryPath)
+	}
+	if collectionResult.MetricsPath != "" {
+		fmt.Printf("   Metrics: %s\n", collectionResult.MetricsPath)
+	}
+	if collectionResult.Upload != nil {
+		fmt.Printf("   Uploaded: %s\n", collectionResult.Upload.Location)
+	}
+	printImageErrorSummary(collectionResult.ImageErrors, collectionResult.TopImageErrors)
+	printThrottleSummary(collectionResult.Summary.Throttling)
 
 	return collectionResult, nil
 }
 
+// runCollectors runs the collectors that are not completed in the checkpoint, saving it after each one
+// An interrupted run returns an error and leaves the checkpoint so the collection can be resumed
+// Collector failures are returned as messages; the checkpoint is removed once every collector has run
+// Each collector's execution is recorded in sbc.execution
+func (sbc *SupportBundleCollector) runCollectors(ctx context.Context, collectors []autodiscovery.CollectorSpec, outputDir string, checkpoint *CollectionCheckpoint) ([]string, error) {
+	sbc.execution = NewExecutionReport()
+	pending, skipped := checkpoint.PendingCollectors(collectors)
+	if skipped > 0 {
+		fmt.Printf("⏭️  Resuming: skipping %d collectors completed by the previous run\n", skipped)
+		for _, collector := range collectors {
+			if checkpoint.IsCompleted(collector) {
+				sbc.execution.RecordResumed(collector)
+			}
+		}
+	}
+
+	runner := sbc.collectorRunner
+	if runner == nil {
+		runner = writeCollectorSpec
+	}
+	// Count attempts below the retrier so the execution report sees every retry
+	attempts := 0
+	counted := runner
+	runner = func(ctx context.Context, collector autodiscovery.CollectorSpec, outputDir string) ([]string, error) {
+		attempts++
+		return counted(ctx, collector, outputDir)
+	}
+	// Retry only the collector itself, trimming and redaction run once on the outputs of the last attempt
+	if sbc.retrier != nil {
+		runner = retryingRunner(runner, sbc.retrier)
+	}
+	// Filter Secrets first so their values never reach trimming or redaction
+	if sbc.secretFilter != nil {
+		runner = secretFilteringRunner(runner, sbc.secretFilter)
+	}
+	// Trim before redacting so redaction rules see the content that is kept
+	if sbc.trimmer != nil {
+		runner = trimmingRunner(runner, sbc.trimmer)
+	}
+	// Normalize before redacting so redaction rules see the lines as they are kept
+	if sbc.timestamps != nil {
+		runner = timestampNormalizingRunner(runner, sbc.timestamps)
+	}
+	if sbc.redactor != nil {
+		runner = redactingRunner(runner, sbc.redactor)
+	}
+
+	var progress *CollectionProgress
+	if sbc.showProgress {
+		progress = NewCollectionProgress(os.Stdout, pending)
+		defer progress.Finish()
+	}
+
+	var collectorErrors []string
+	for i, collector := range pending {
+		if ctx.Err() != nil {
+			return nil, interruptedCollectionError(ctx, skipped+i, len(collectors), outputDir)
+		}
+
+		if progress != nil {
+			progress.Begin(collector)
+		}
+		attempts = 0
+		started := time.Now()
+		outputs, err := runner(ctx, collector, outputDir)
+		size := outputsSize(outputDir, outputs)
+		sbc.execution.Record(collector, time.Since(started), size, attempts, err)
+		if progress != nil {
+			progress.Complete(collector, size, err)
+		}
+		if err != nil {
+			if saveErr := checkpoint.MarkPartial(collector, outputs); saveErr != nil {
+				fmt.Printf("Warning: %v\n", saveErr)
+			}
+			if ctx.Err() != nil {
+				return nil, interruptedCollectionError(ctx, skipped+i, len(collectors), outputDir)
+			}
+			collectorErrors = append(collectorErrors, fmt.Sprintf("collector %s failed: %v", collector.Name, err))
+			continue
+		}
+
+		if err := checkpoint.MarkCompleted(collector, outputs); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	// Partial outputs of failed collectors stay in the bundle once the collection finishes
+	if err := checkpoint.Remove(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return collectorErrors, nil
+}
+
+func interruptedCollectionError(ctx context.Context, completed, total int, outputDir string) error {
+	return fmt.Errorf("collection interrupted after %d of %d collectors, rerun with --resume --output-dir %s: %w", completed, total, outputDir, ctx.Err())
+}
+
+// runAnalysis generates default analyzers for the discovered resources and evaluates them
+func (sbc *SupportBundleCollector) runAnalysis(ctx context.Context, opts autodiscovery.DiscoveryOptions) (*AnalysisReport, error) {
+	analyzers, err := sbc.discoverer.DiscoverAnalyzers(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate analyzers: %w", err)
+	}
+
+	results := sbc.discoverer.RunAnalyzers(ctx, analyzers)
+
+	return &AnalysisReport{
+		Summary:   SummarizeAnalysisResults(results),
+		Analyzers: analyzers,
+		Results:   results,
+	}, nil
+}
+
+// anonymizeBundle pseudonymizes namespace, node and registry names in the bundle and writes the mapping file
+func (sbc *SupportBundleCollector) anonymizeBundle(ctx context.Context, outputDir string, result *autodiscovery.DiscoveryResultWithImages, cliOptions SupportBundleCollectOptions) (string, error) {
+	var pseudonymizer Pseudonymizer
+	if cliOptions.AnonymizationKey != "" {
+		pseudonymizer = NewHMACPseudonymizer([]byte(cliOptions.AnonymizationKey))
+	} else {
+		random, err := NewRandomHMACPseudonymizer()
+		if err != nil {
+			return "", err
+		}
+		pseudonymizer = random
+	}
+
+	anonymizer := NewBundleAnonymizer(pseudonymizer)
+
+	for _, collector := range result.Collectors {
+		anonymizer.AddNamespace(collector.Namespace)
+	}
+	for imageRef := range result.ImageFacts {
+		anonymizer.AddImage(imageRef)
+	}
+
+	nodes, err := sbc.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list nodes for anonymization: %v\n", err)
+	} else {
+		for _, node := range nodes.Items {
+			anonymizer.AddNode(node.Name)
+		}
+	}
+
+	if err := anonymizer.DiscoverIdentifiers(outputDir); err != nil {
+		return "", fmt.Errorf("failed to discover identifiers: %w", err)
+	}
+
+	changed, err := anonymizer.AnonymizeBundle(outputDir)
+	if err != nil {
+		return "", err
+	}
+
+	mappingPath := cliOptions.AnonymizationMapping
+	if mappingPath == "" {
+		mappingPath = DefaultAnonymizationMappingPath(outputDir)
+	}
+	if err := anonymizer.WriteMapping(mappingPath, outputDir); err != nil {
+		return "", fmt.Errorf("failed to write anonymization mapping: %w", err)
+	}
+
+	fmt.Printf("🕶️  Anonymized %d bundle files\n", changed)
+
+	return mappingPath, nil
+}
+
+// referencedGVRs returns the GVRs named by the config file and the selected profile, including their cluster scope, without duplicates
+func (sbc *SupportBundleCollector) referencedGVRs(profileName string) []schema.GroupVersionResource {
+	gvrs := sbc.configManager.GetConfig().ReferencedGVRs()
+	if profileName != "" {
+		if profile, err := sbc.profileManager.GetProfile(profileName); err == nil {
+			seen := make(map[schema.GroupVersionResource]bool)
+			for _, gvr := range gvrs {
+				seen[gvr] = true
+			}
+			profileGVRs, _ := profile.Options.ClusterScope.GVRs()
+			if profile.Config != nil {
+				profileGVRs = append(profileGVRs, profile.Config.ReferencedGVRs()...)
+			}
+			for _, gvr := range profileGVRs {
+				if !seen[gvr] {
+					seen[gvr] = true
+					gvrs = append(gvrs, gvr)
+				}
+			}
+		}
+	}
+	return gvrs
+}
+
+// findUnservedGVRs checks the config and profile GVRs against the discovery API
+// A failed check is reported as a warning so the dry run still completes
+func (sbc *SupportBundleCollector) findUnservedGVRs(profileName string) []autodiscovery.UnservedGVR {
+	unserved, err := sbc.discoverer.FindUnservedGVRs(sbc.referencedGVRs(profileName))
+	if err != nil {
+		fmt.Printf("Warning: failed to check configured resource types against the cluster: %v\n", err)
+		return nil
+	}
+	return unserved
+}
+
+// printUnservedGVRs prints a warning for each configured GVR the cluster does not serve
+func printUnservedGVRs(unserved []autodiscovery.UnservedGVR) {
+	if len(unserved) == 0 {
+		return
+	}
+	fmt.Printf("\n⚠️  Configured resource types not served by the cluster (nothing will be collected for them):\n")
+	for _, u := range unserved {
+		fmt.Printf("Warning: %s\n", u)
+	}
+}
+
+// printThrottleSummary prints the API request rate and a warning when discovery was throttled
+func printThrottleSummary(stats *autodiscovery.ThrottleStats) {
+	if stats == nil || stats.Requests == 0 {
+		return
+	}
+	fmt.Printf("\n")
+	writeThrottleStats(os.Stdout, stats)
+	if warning := throttleWarning(stats); warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+// printDeprecatedAPIs warns about the deprecated APIs used during discovery, and the release that removes them
+func printDeprecatedAPIs(report *autodiscovery.DeprecationReport) {
+	if report == nil || len(report.Deprecations) == 0 {
+		return
+	}
+	fmt.Printf("Warning: the API server reported %d deprecated APIs, see %s\n", len(report.Deprecations), autodiscovery.DeprecationsReportFileName)
+	for _, deprecated := range report.Deprecations {
+		fmt.Printf("  - %s\n", deprecated)
+	}
+}
+
+// printCollectorLimit prints how many collectors --max-collectors dropped and the first few of them
+func printCollectorLimit(report *autodiscovery.CollectorLimitReport) {
+	warning := collectorLimitWarning(report)
+	if warning == "" {
+		return
+	}
+	fmt.Printf("Warning: %s\n", warning)
+	for i, dropped := range report.Dropped {
+		if i == maxDroppedCollectorsShown {
+			fmt.Printf("  ... and %d more\n", len(report.Dropped)-i)
+			break
+		}
+		fmt.Printf("  - %s (%s, priority %d)\n", dropped.Name, dropped.Type, dropped.Priority)
+	}
+}
+
+// maxDroppedCollectorsShown limits the dropped collectors printed by name
+const maxDroppedCollectorsShown = 10
+
+// printDependencyWarnings prints the dependency cycles and expansion limit hit during discovery
+func printDependencyWarnings(report *autodiscovery.DependencyReport) {
+	for _, warning := range dependencyWarnings(report) {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+// printNodeImagePresenceSummary prints node image cache coverage and pods with missing images
+func printNodeImagePresenceSummary(report *images.NodeImagePresenceReport) {
+	fmt.Printf("   Node image cache: %d/%d images present on %d nodes\n",
+		report.Summary.PresentImages, report.Summary.TotalImages, len(report.Nodes))
+	if report.Summary.PodsWithMissingImages > 0 {
+		fmt.Printf("   ⚠️  %d pods reference images not present on any node\n", report.Summary.PodsWithMissingImages)
+	}
+}
+
+// printPullSecretAuditSummary prints pull secret health and registries pulled from without a usable credential
+func printPullSecretAuditSummary(report *images.PullSecretAuditReport) {
+	summary := report.Summary
+	fmt.Printf("   Pull secrets: %d/%d valid\n", summary.ValidSecrets, summary.TotalSecrets)
+	if problems := summary.MissingSecrets + summary.MalformedSecrets + summary.ExpiredSecrets + summary.UnreadableSecrets; problems > 0 {
+		fmt.Printf("   ⚠️  %d pull secrets are missing, malformed, expired or unreadable\n", problems)
+	}
+	if summary.UncoveredRegistries > 0 {
+		fmt.Printf("   ⚠️  %d registries have no usable pull credential (fine if they are public)\n", summary.UncoveredRegistries)
+	}
+}
+
 // Helper functions
 
 func loadKubernetesConfig(options SupportBundleCollectOptions) (*rest.Config, error) {
+	if options.Dev {
+		return loadDevKubernetesConfig(options)
+	}
+
 	if options.KubeconfigPath != "" {
 		return clientcmd.BuildConfigFromFlags("", options.KubeconfigPath)
 	}
@@ -282,9 +1295,41 @@ type CollectionResult struct {
 	Collectors  []autodiscovery.CollectorSpec `json:"collectors"`
 	ImageFacts  map[string]interface{}        `json:"imageFacts,omitempty"`
 	OutputPath  string                        `json:"outputPath,omitempty"`
+	NamespaceIndexPath string                 `json:"namespaceIndexPath,omitempty"`
+	ReadmePath  string                        `json:"readmePath,omitempty"`
+	AnalysisPath string                       `json:"analysisPath,omitempty"`
+	AnonymizationMappingPath string           `json:"anonymizationMappingPath,omitempty"`
+	ManifestPath string                       `json:"manifestPath,omitempty"`
+	SignaturePath string                      `json:"signaturePath,omitempty"`
+	MetricsPath string                        `json:"metricsPath,omitempty"`
+	ExecutionReportPath string                `json:"executionReportPath,omitempty"`
+	RunSummaryPath string                     `json:"runSummaryPath,omitempty"`
+	SearchIndexPath string                    `json:"searchIndexPath,omitempty"`
+	Upload      *UploadResult                 `json:"upload,omitempty"`
+	AuditNotes  []string                      `json:"auditNotes,omitempty"`
+	NodeImagePresence *images.NodeImagePresenceSummary `json:"nodeImagePresence,omitempty"`
+	PullSecretAudit *images.PullSecretAuditSummary `json:"pullSecretAudit,omitempty"`
+	RegistryCatalog *images.RegistryCatalogSummary `json:"registryCatalog,omitempty"`
+	ImageErrors     *images.ErrorLedgerSummary     `json:"imageErrors,omitempty"`    // Totals of images/errors.json
+	TopImageErrors  []images.ErrorClassSummary     `json:"topImageErrors,omitempty"` // Most frequent image error classes
+	Analysis    *AnalysisReport               `json:"analysis,omitempty"`
 	Summary     CollectionSummary             `json:"summary"`
 	Duration    time.Duration                 `json:"duration"`
 	DryRun      bool                         `json:"dryRun"`
+	Comparison  *DryRunComparison            `json:"comparison,omitempty"`
+	UnservedResources []autodiscovery.UnservedGVR `json:"unservedResources,omitempty"`
+	CollectorLimit *autodiscovery.CollectorLimitReport `json:"collectorLimit,omitempty"` // Collectors dropped by --max-collectors
+	ClusterScope *autodiscovery.ClusterScopeReport `json:"clusterScope,omitempty"` // Cluster-scoped types included and denied by the RBAC check
+	Annotations  *autodiscovery.AnnotationReport   `json:"annotations,omitempty"`  // Namespaces and workloads opted out or in, and log lines set, by troubleshoot.sh/ annotations
+	NamespaceScope *NamespaceScope                 `json:"namespaceScope,omitempty"` // Namespaces inferred when none were given, and why
+	RedactedFiles  map[string]int                      `json:"redactedFiles,omitempty"`  // Files changed per collector redaction rule
+	SkippedObjects []autodiscovery.SkippedObject       `json:"skippedObjects,omitempty"` // ConfigMaps and Secrets captured as metadata, keys and sizes
+	LogTimestamps  *autodiscovery.LogTimestampReport   `json:"logTimestamps,omitempty"`  // Log lines rewritten to RFC3339 UTC by --normalize-log-timestamps
+	Retries        []autodiscovery.CollectorRetry      `json:"retries,omitempty"`        // Collectors rerun after a transient failure, with every failed attempt
+	Secrets        *autodiscovery.SecretPolicyReport   `json:"secrets,omitempty"`        // Secrets kept and left out by the secret policy
+	DeprecatedAPIs []autodiscovery.DeprecatedAPI       `json:"deprecatedAPIs,omitempty"` // APIs the server returned deprecation warnings for
+	APIUsage       *autodiscovery.APIUsageSummary      `json:"apiUsage,omitempty"`       // API requests issued by the run, see api-usage.json
+	Execution      *ExecutionSummary                   `json:"execution,omitempty"`      // Collector totals, see execution-report.json
 	Errors      []string                     `json:"errors,omitempty"`
 }
 
@@ -295,6 +1340,7 @@ type CollectionSummary struct {
 	Namespaces      map[string]int             `json:"namespaces"`
 	Options         autodiscovery.DiscoveryOptions `json:"options"`
 	ImageStats      *ImageCollectionStats      `json:"imageStats,omitempty"`
+	Throttling      *autodiscovery.ThrottleStats `json:"throttling,omitempty"`
 }
 
 // ImageCollectionStats summarizes image collection results
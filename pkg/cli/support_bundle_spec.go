@@ -3,11 +3,12 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+	"github.com/replicatedhq/troubleshoot/pkg/collect/images"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"gopkg.in/yaml.v2"
 )
@@ -61,6 +62,7 @@ type AutoDiscoveryConfig struct {
 	// Exclusions and inclusions
 	Excludes []autodiscovery.ResourceExcludeRule `json:"excludes,omitempty" yaml:"excludes,omitempty"`
 	Includes []autodiscovery.ResourceIncludeRule `json:"includes,omitempty" yaml:"includes,omitempty"`
+	IncludeSystemNamespaces bool                 `json:"includeSystemNamespaces,omitempty" yaml:"includeSystemNamespaces,omitempty"`
 	
 	// Image collection configuration
 	ImageOptions *ImageCollectionConfig `json:"imageOptions,omitempty" yaml:"imageOptions,omitempty"`
@@ -71,11 +73,17 @@ type ImageCollectionConfig struct {
 	IncludeManifests bool                                     `json:"includeManifests" yaml:"includeManifests"`
 	IncludeLayers    bool                                     `json:"includeLayers" yaml:"includeLayers"`
 	IncludeConfig    bool                                     `json:"includeConfig" yaml:"includeConfig"`
+	IncludeHistory   bool                                     `json:"includeHistory,omitempty" yaml:"includeHistory,omitempty"`
 	CacheEnabled     bool                                     `json:"cacheEnabled" yaml:"cacheEnabled"`
 	Timeout          string                                   `json:"timeout" yaml:"timeout"`
 	MaxConcurrency   int                                      `json:"maxConcurrency" yaml:"maxConcurrency"`
 	RetryCount       int                                      `json:"retryCount" yaml:"retryCount"`
 	RegistryAuth     map[string]*RegistryAuthConfig           `json:"registryAuth,omitempty" yaml:"registryAuth,omitempty"`
+	RegistryLimits   map[string]*RegistryLimitsConfig         `json:"registryLimits,omitempty" yaml:"registryLimits,omitempty"`
+	Mirrors          map[string][]string                      `json:"mirrors,omitempty" yaml:"mirrors,omitempty"` // Registry -> mirror endpoints, tried in order
+	BaseImageDigests map[string]string                        `json:"baseImageDigests,omitempty" yaml:"baseImageDigests,omitempty"` // Layer digest -> base image name
+	LabelFilter      *images.LabelFilter                      `json:"labelFilter,omitempty" yaml:"labelFilter,omitempty"` // Replaces the default label filter, {} keeps every label
+	Enrichers        []string                                 `json:"enrichers,omitempty" yaml:"enrichers,omitempty"` // Registered enrichers run on each image's facts, in order
 }
 
 // RegistryAuthConfig configures registry authentication
@@ -84,6 +92,37 @@ type RegistryAuthConfig struct {
 	Password string `json:"password,omitempty" yaml:"password,omitempty"`
 	Token    string `json:"token,omitempty" yaml:"token,omitempty"`
 	SecretRef string `json:"secretRef,omitempty" yaml:"secretRef,omitempty"`
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"` // "static", "ecr", "gcr", "acr" or "auto"
+}
+
+// RegistryLimitsConfig overrides maxConcurrency and timeout for one registry
+type RegistryLimitsConfig struct {
+	MaxConcurrency int    `json:"maxConcurrency,omitempty" yaml:"maxConcurrency,omitempty"`
+	Timeout        string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// ImageRegistryLimits converts the registryLimits section to image collection options
+func (c *ImageCollectionConfig) ImageRegistryLimits() (map[string]images.RegistryLimits, error) {
+	if len(c.RegistryLimits) == 0 {
+		return nil, nil
+	}
+
+	limits := make(map[string]images.RegistryLimits, len(c.RegistryLimits))
+	for registry, config := range c.RegistryLimits {
+		if config == nil {
+			continue
+		}
+		limit := images.RegistryLimits{MaxConcurrency: config.MaxConcurrency}
+		if config.Timeout != "" {
+			timeout, err := time.ParseDuration(config.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for %s: %w", registry, err)
+			}
+			limit.Timeout = timeout
+		}
+		limits[registry] = limit
+	}
+	return limits, images.ValidateRegistryLimits(limits)
 }
 
 // RedactionConfig configures redaction behavior (placeholder for future implementation)
@@ -116,13 +155,13 @@ func (sbsl *SupportBundleSpecLoader) LoadFromFile(filePath string) (*SupportBund
 	if err := yaml.Unmarshal(data, spec); err != nil {
 		// Try JSON fallback
 		if jsonErr := json.Unmarshal(data, spec); jsonErr != nil {
-			return nil, fmt.Errorf("failed to parse spec as YAML or JSON: yaml=%v, json=%v", err, jsonErr)
+			return nil, fmt.Errorf("%w: failed to parse as YAML or JSON: yaml=%v, json=%v", autodiscovery.ErrSpecInvalid, err, jsonErr)
 		}
 	}
 
 	// Validate the spec
 	if err := sbsl.ValidateSpec(spec); err != nil {
-		return nil, fmt.Errorf("invalid spec: %w", err)
+		return nil, fmt.Errorf("%w: %w", autodiscovery.ErrSpecInvalid, err)
 	}
 
 	return spec, nil
@@ -178,19 +217,10 @@ func (sbsl *SupportBundleSpecLoader) ValidateSpec(spec *SupportBundleSpec) error
 }
 
 func (sbsl *SupportBundleSpecLoader) validateAutoDiscoveryConfig(config *AutoDiscoveryConfig) error {
-	// Validate namespace list
-	for _, ns := range config.Namespaces {
-		if ns == "" {
-			return fmt.Errorf("namespace cannot be empty")
-		}
-		if strings.Contains(ns, " ") {
-			return fmt.Errorf("namespace cannot contain spaces: %s", ns)
-		}
-	}
-
-	// Validate max depth
-	if config.MaxDepth < 0 || config.MaxDepth > 10 {
-		return fmt.Errorf("maxDepth must be between 0 and 10")
+	// Namespaces and maxDepth follow the same rules as config files and profiles
+	opts := autodiscovery.DiscoveryOptions{Namespaces: config.Namespaces, MaxDepth: config.MaxDepth}
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
 	// Validate profile name
@@ -236,6 +266,44 @@ func (sbsl *SupportBundleSpecLoader) validateImageConfig(config *ImageCollection
 		return fmt.Errorf("retryCount must be between 0 and 10")
 	}
 
+	// Validate per-registry limits
+	for registry, limits := range config.RegistryLimits {
+		if limits != nil && limits.MaxConcurrency > 50 {
+			return fmt.Errorf("maxConcurrency for %s must be between 1 and 50", registry)
+		}
+	}
+	if _, err := config.ImageRegistryLimits(); err != nil {
+		return fmt.Errorf("invalid registryLimits: %w", err)
+	}
+
+	if err := images.ValidateRegistryMirrors(config.Mirrors); err != nil {
+		return fmt.Errorf("invalid mirrors: %w", err)
+	}
+
+	if err := images.ValidateBaseImageDigests(config.BaseImageDigests); err != nil {
+		return fmt.Errorf("invalid baseImageDigests: %w", err)
+	}
+
+	if config.LabelFilter != nil {
+		if err := config.LabelFilter.Validate(); err != nil {
+			return fmt.Errorf("invalid labelFilter: %w", err)
+		}
+	}
+
+	if err := images.ValidateEnrichers(config.Enrichers); err != nil {
+		return fmt.Errorf("invalid enrichers: %w", err)
+	}
+
+	// Validate registry auth providers
+	for registry, auth := range config.RegistryAuth {
+		if auth == nil {
+			continue
+		}
+		if !isValidRegistryAuthProvider(auth.Provider) {
+			return fmt.Errorf("invalid registryAuth provider for %s: %s (valid: %v)", registry, auth.Provider, validRegistryAuthProviders)
+		}
+	}
+
 	return nil
 }
 
@@ -379,6 +447,7 @@ func ConvertSpecToAutoDiscoveryConfig(autoDiscoverySpec *AutoDiscoveryConfig) *a
 		CollectorMappings: autoDiscoverySpec.CollectorMappings,
 		Excludes:          autoDiscoverySpec.Excludes,
 		Includes:          autoDiscoverySpec.Includes,
+		IncludeSystemNamespaces: autoDiscoverySpec.IncludeSystemNamespaces,
 	}
 
 	// Set defaults if not specified
@@ -389,6 +458,69 @@ func ConvertSpecToAutoDiscoveryConfig(autoDiscoverySpec *AutoDiscoveryConfig) *a
 	return config
 }
 
+// validRegistryAuthProviders lists the supported registry auth providers
+var validRegistryAuthProviders = []string{"static", "ecr", "gcr", "acr", "auto"}
+
+func isValidRegistryAuthProvider(provider string) bool {
+	if provider == "" {
+		return true
+	}
+	for _, valid := range validRegistryAuthProviders {
+		if provider == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildRegistryKeychain builds a keychain from spec registry auth. Static credentials
+// are consulted first, followed by the cloud-native providers that were requested.
+func BuildRegistryKeychain(registryAuth map[string]*RegistryAuthConfig, timeout time.Duration) (images.Keychain, error) {
+	staticCreds := make(map[string]*images.RegistryCredentials)
+	providers := make(map[string]bool)
+
+	for registry, auth := range registryAuth {
+		if auth == nil {
+			continue
+		}
+		if !isValidRegistryAuthProvider(auth.Provider) {
+			return nil, fmt.Errorf("invalid registryAuth provider for %s: %s", registry, auth.Provider)
+		}
+
+		if auth.Username != "" || auth.Password != "" || auth.Token != "" {
+			staticCreds[registry] = &images.RegistryCredentials{
+				Username: auth.Username,
+				Password: auth.Password,
+				Token:    auth.Token,
+			}
+		}
+
+		switch auth.Provider {
+		case "ecr", "gcr", "acr":
+			providers[auth.Provider] = true
+		case "auto":
+			providers["ecr"] = true
+			providers["gcr"] = true
+			providers["acr"] = true
+		}
+	}
+
+	keychains := []images.Keychain{images.NewStaticKeychain(staticCreds)}
+
+	httpClient := &http.Client{Timeout: timeout}
+	if providers["ecr"] {
+		keychains = append(keychains, images.NewECRKeychain(httpClient))
+	}
+	if providers["gcr"] {
+		keychains = append(keychains, images.NewGCRKeychain(httpClient))
+	}
+	if providers["acr"] {
+		keychains = append(keychains, images.NewACRKeychain(httpClient))
+	}
+
+	return images.NewChainedKeychain(keychains...), nil
+}
+
 // CompatibilityChecker validates backwards compatibility
 type CompatibilityChecker struct {
 	supportedVersions map[string]bool
@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
 )
@@ -780,6 +782,59 @@ func TestImageCollectionConfig_Validation(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "cloud registry auth providers",
+			config: &ImageCollectionConfig{
+				MaxConcurrency: 5,
+				RegistryAuth: map[string]*RegistryAuthConfig{
+					"123456789012.dkr.ecr.us-east-1.amazonaws.com": {Provider: "ecr"},
+					"myregistry.azurecr.io":                        {Provider: "acr"},
+					"registry.example.com":                         {Username: "user", Password: "pass"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "per-registry limits",
+			config: &ImageCollectionConfig{
+				MaxConcurrency: 5,
+				RegistryLimits: map[string]*RegistryLimitsConfig{
+					"harbor.internal": {MaxConcurrency: 20},
+					"docker.io":       {MaxConcurrency: 2, Timeout: "30s"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid registry limit timeout",
+			config: &ImageCollectionConfig{
+				MaxConcurrency: 5,
+				RegistryLimits: map[string]*RegistryLimitsConfig{
+					"docker.io": {Timeout: "soon"},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "registry limit concurrency too high",
+			config: &ImageCollectionConfig{
+				MaxConcurrency: 5,
+				RegistryLimits: map[string]*RegistryLimitsConfig{
+					"harbor.internal": {MaxConcurrency: 100},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown registry auth provider",
+			config: &ImageCollectionConfig{
+				MaxConcurrency: 5,
+				RegistryAuth: map[string]*RegistryAuthConfig{
+					"registry.example.com": {Provider: "vault"},
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -796,6 +851,71 @@ func TestImageCollectionConfig_Validation(t *testing.T) {
 	}
 }
 
+func TestBuildRegistryKeychain(t *testing.T) {
+	tests := []struct {
+		name          string
+		registryAuth  map[string]*RegistryAuthConfig
+		expectedChain string
+		expectError   bool
+	}{
+		{
+			name:          "static credentials only",
+			registryAuth:  map[string]*RegistryAuthConfig{"registry.example.com": {Username: "user", Password: "pass"}},
+			expectedChain: "chain(static)",
+		},
+		{
+			name:          "static credentials chained before provider",
+			registryAuth:  map[string]*RegistryAuthConfig{"gcr.io": {Provider: "gcr"}, "registry.example.com": {Token: "abc"}},
+			expectedChain: "chain(static,gcr)",
+		},
+		{
+			name:          "auto enables all cloud providers",
+			registryAuth:  map[string]*RegistryAuthConfig{"*": {Provider: "auto"}},
+			expectedChain: "chain(static,ecr,gcr,acr)",
+		},
+		{
+			name:         "unknown provider",
+			registryAuth: map[string]*RegistryAuthConfig{"registry.example.com": {Provider: "vault"}},
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keychain, err := BuildRegistryKeychain(tt.registryAuth, 10*time.Second)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if keychain.Name() != tt.expectedChain {
+				t.Errorf("Expected keychain %s, got %s", tt.expectedChain, keychain.Name())
+			}
+		})
+	}
+
+	keychain, err := BuildRegistryKeychain(map[string]*RegistryAuthConfig{
+		"registry.example.com": {Username: "user", Password: "pass"},
+	}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	creds, err := keychain.Resolve(context.Background(), "registry.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds == nil || creds.Username != "user" || creds.Password != "pass" {
+		t.Errorf("Expected static credentials, got %v", creds)
+	}
+}
+
 // Error handling tests for CLI integration
 func TestCLI_ErrorHandlingAndValidation(t *testing.T) {
 	tests := []struct {
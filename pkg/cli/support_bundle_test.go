@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -566,3 +567,157 @@ func TestParseNamespaceList(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectWithAutoDiscovery_GroupValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       SupportBundleCollectOptions
+		expectedError string
+	}{
+		{
+			name: "only and skip together",
+			options: SupportBundleCollectOptions{
+				Auto:       true,
+				OnlyGroups: []string{"logs"},
+				SkipGroups: []string{"images"},
+			},
+			expectedError: "cannot be used together",
+		},
+		{
+			name: "unknown only group",
+			options: SupportBundleCollectOptions{
+				Auto:       true,
+				OnlyGroups: []string{"metrics"},
+			},
+			expectedError: "invalid discovery options: onlyGroups",
+		},
+		{
+			name: "unknown skip group",
+			options: SupportBundleCollectOptions{
+				Auto:       true,
+				SkipGroups: []string{"metrics"},
+			},
+			expectedError: "invalid discovery options: skipGroups",
+		},
+		{
+			name: "unparseable since",
+			options: SupportBundleCollectOptions{
+				Auto:  true,
+				Since: "last tuesday",
+			},
+			expectedError: "invalid --since/--until",
+		},
+		{
+			name: "since after until",
+			options: SupportBundleCollectOptions{
+				Auto:  true,
+				Since: "1h",
+				Until: "2h",
+			},
+			expectedError: "is after until",
+		},
+		{
+			name: "missing audit log",
+			options: SupportBundleCollectOptions{
+				Auto:     true,
+				AuditLog: "/nonexistent/audit.log",
+			},
+			expectedError: "invalid --audit-log",
+		},
+		{
+			name: "negative table threshold",
+			options: SupportBundleCollectOptions{
+				Auto:           true,
+				TableThreshold: -1,
+			},
+			expectedError: "invalid discovery options: tableThreshold",
+		},
+		{
+			name: "negative max collectors",
+			options: SupportBundleCollectOptions{
+				Auto:          true,
+				MaxCollectors: -1,
+			},
+			expectedError: "invalid discovery options: maxCollectors",
+		},
+		{
+			name: "unknown cluster scope resource",
+			options: SupportBundleCollectOptions{
+				Auto:         true,
+				ClusterScope: []string{"widgets"},
+			},
+			expectedError: "invalid discovery options: clusterScope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Discovery options are only checked once flags, profile and config file are merged
+			sbc := &SupportBundleCollector{
+				configManager:  autodiscovery.NewConfigManager(),
+				profileManager: NewDiscoveryProfileManager(),
+			}
+			tt.options.Quiet = true
+
+			_, err := sbc.CollectWithAutoDiscovery(context.Background(), tt.options)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSupportBundleCollector_ReferencedGVRs(t *testing.T) {
+	configManager := autodiscovery.NewConfigManager()
+	err := configManager.LoadFromJSON([]byte(`{
+		"resourceFilters": [{
+			"name": "gateway",
+			"action": "include",
+			"matchGVRs": [
+				{"Group": "gateway.networking.k8s.io", "Version": "v1", "Resource": "httproutes"},
+				{"Group": "", "Version": "v1", "Resource": "pods"}
+			]
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	sbc := &SupportBundleCollector{
+		configManager:  configManager,
+		profileManager: NewDiscoveryProfileManager(),
+	}
+
+	configOnly := sbc.referencedGVRs("")
+	if len(configOnly) != 2 {
+		t.Fatalf("Expected 2 GVRs from the config, got %v", configOnly)
+	}
+
+	withProfile := sbc.referencedGVRs("comprehensive")
+	if len(withProfile) <= len(configOnly) {
+		t.Errorf("Expected profile GVRs to be added, got %v", withProfile)
+	}
+	seen := make(map[string]int)
+	for _, gvr := range withProfile {
+		seen[gvr.String()]++
+	}
+	for gvr, count := range seen {
+		if count > 1 {
+			t.Errorf("Expected %s once, got %d times", gvr, count)
+		}
+	}
+}
+
+func TestAddUnservedGVRWarnings(t *testing.T) {
+	result := &DryRunResult{}
+	addUnservedGVRWarnings(result, []autodiscovery.UnservedGVR{
+		{Reason: "API group gateway.networking.k8s.io is not installed", Suggestion: "install the Gateway API CRDs or remove it from the config"},
+	})
+
+	if len(result.UnservedResources) != 1 {
+		t.Errorf("Expected 1 unserved resource, got %d", len(result.UnservedResources))
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "Gateway API") {
+		t.Errorf("Expected a warning with the suggestion, got %v", result.Warnings)
+	}
+}
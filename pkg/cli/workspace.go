@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkspaceIndexFileName is the file in the workspace directory listing the bundles this CLI created
+const WorkspaceIndexFileName = "bundles.json"
+
+// WorkspaceDirEnv overrides the default workspace directory
+const WorkspaceDirEnv = "TROUBLESHOOT_WORKSPACE"
+
+// BundleRecord is one bundle tracked in the workspace index
+type BundleRecord struct {
+	Path      string    `json:"path"` // Absolute path of the bundle directory or archive
+	SizeBytes int64     `json:"sizeBytes"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// workspaceIndex is the on-disk format of the workspace index
+type workspaceIndex struct {
+	Bundles []BundleRecord `json:"bundles"`
+}
+
+// WorkspaceManager tracks the bundles created by this CLI so old ones can be cleaned up
+// Only tracked bundles are ever removed, paths the CLI did not create are never touched
+type WorkspaceManager struct {
+	dir string
+}
+
+// NewWorkspaceManager creates a WorkspaceManager keeping its index in dir, see DefaultWorkspaceDir
+func NewWorkspaceManager(dir string) *WorkspaceManager {
+	return &WorkspaceManager{
+		dir: dir,
+	}
+}
+
+// DefaultWorkspaceDir returns $TROUBLESHOOT_WORKSPACE, or ~/.troubleshoot when it is not set
+func DefaultWorkspaceDir() (string, error) {
+	if dir := os.Getenv(WorkspaceDirEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the workspace directory, set %s: %w", WorkspaceDirEnv, err)
+	}
+	return filepath.Join(home, ".troubleshoot"), nil
+}
+
+// IndexPath returns the path of the workspace index
+func (w *WorkspaceManager) IndexPath() string {
+	return filepath.Join(w.dir, WorkspaceIndexFileName)
+}
+
+// List returns the tracked bundles, newest first
+func (w *WorkspaceManager) List() ([]BundleRecord, error) {
+	index, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	sortBundleRecords(index.Bundles)
+	return index.Bundles, nil
+}
+
+// Track records a bundle created at createdAt, replacing any earlier record of the same path
+func (w *WorkspaceManager) Track(bundlePath string, createdAt time.Time) (BundleRecord, error) {
+	absPath, err := filepath.Abs(bundlePath)
+	if err != nil {
+		return BundleRecord{}, fmt.Errorf("failed to resolve bundle path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return BundleRecord{}, fmt.Errorf("failed to track bundle: %w", err)
+	}
+
+	index, err := w.load()
+	if err != nil {
+		return BundleRecord{}, err
+	}
+
+	record := BundleRecord{Path: absPath, SizeBytes: bundleSize(absPath), CreatedAt: createdAt.UTC()}
+	bundles := []BundleRecord{record}
+	for _, existing := range index.Bundles {
+		if existing.Path != absPath {
+			bundles = append(bundles, existing)
+		}
+	}
+	index.Bundles = bundles
+
+	if err := w.save(index); err != nil {
+		return BundleRecord{}, err
+	}
+	return record, nil
+}
+
+// CleanBundlesPolicy selects the tracked bundles to remove
+type CleanBundlesPolicy struct {
+	OlderThan time.Duration // Remove bundles created longer ago than this, 0 for any age
+	Keep      int           // Always keep this many of the newest bundles
+	DryRun    bool          // Report what would be removed without removing anything
+}
+
+// CleanBundlesResult reports what a clean removed, or would remove in a dry run
+type CleanBundlesResult struct {
+	Removed    []BundleRecord `json:"removed"`
+	Kept       []BundleRecord `json:"kept"`
+	Missing    []BundleRecord `json:"missing,omitempty"` // Already gone from disk, dropped from the index
+	FreedBytes int64          `json:"freedBytes"`
+	DryRun     bool           `json:"dryRun,omitempty"`
+	Errors     []string       `json:"errors,omitempty"`
+}
+
+// Clean removes the tracked bundles selected by policy as of now and drops them from the index
+// Bundles that fail to be removed stay in the index so a later clean retries them
+func (w *WorkspaceManager) Clean(policy CleanBundlesPolicy, now time.Time) (*CleanBundlesResult, error) {
+	if policy.OlderThan < 0 || policy.Keep < 0 {
+		return nil, fmt.Errorf("older-than and keep cannot be negative")
+	}
+	if policy.OlderThan == 0 && policy.Keep == 0 {
+		return nil, fmt.Errorf("set older-than, keep or both, cleaning without either would remove every bundle")
+	}
+
+	index, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	sortBundleRecords(index.Bundles)
+
+	result := &CleanBundlesResult{Removed: []BundleRecord{}, Kept: []BundleRecord{}, DryRun: policy.DryRun}
+	var remaining []BundleRecord
+	kept := 0
+	for _, record := range index.Bundles {
+		if _, err := os.Stat(record.Path); errors.Is(err, os.ErrNotExist) {
+			result.Missing = append(result.Missing, record)
+			continue
+		}
+
+		if kept < policy.Keep || (policy.OlderThan > 0 && now.Sub(record.CreatedAt) <= policy.OlderThan) {
+			kept++
+			result.Kept = append(result.Kept, record)
+			remaining = append(remaining, record)
+			continue
+		}
+
+		if !policy.DryRun {
+			if err := os.RemoveAll(record.Path); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("failed to remove %s: %v", record.Path, err))
+				remaining = append(remaining, record)
+				continue
+			}
+		}
+		result.Removed = append(result.Removed, record)
+		result.FreedBytes += record.SizeBytes
+	}
+
+	if policy.DryRun {
+		return result, nil
+	}
+	index.Bundles = remaining
+	if err := w.save(index); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (w *WorkspaceManager) load() (*workspaceIndex, error) {
+	index := &workspaceIndex{}
+	data, err := os.ReadFile(w.IndexPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace index %s: %w", w.IndexPath(), err)
+	}
+	return index, nil
+}
+
+// save writes the index through a temporary file so an interrupted write never truncates it
+func (w *WorkspaceManager) save(index *workspaceIndex) error {
+	if index.Bundles == nil {
+		index.Bundles = []BundleRecord{}
+	}
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal workspace index: %w", err)
+	}
+	tmpPath := w.IndexPath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write workspace index: %w", err)
+	}
+	if err := os.Rename(tmpPath, w.IndexPath()); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write workspace index: %w", err)
+	}
+	return nil
+}
+
+// sortBundleRecords orders records newest first
+func sortBundleRecords(records []BundleRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+}
+
+// ParseRetentionAge parses an age such as "30d", "12h" or "90m"; days are not supported by time.ParseDuration
+func ParseRetentionAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, expected a number of days like 30d or a duration like 12h", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected a number of days like 30d or a duration like 12h", value)
+	}
+	return d, nil
+}
+
+// trackBundle records a finished bundle in the workspace index
+// A failure only warns, tracking must never fail the collection itself
+func trackBundle(workspaceDir, bundlePath string, createdAt time.Time) {
+	if workspaceDir == "" {
+		dir, err := DefaultWorkspaceDir()
+		if err != nil {
+			fmt.Printf("Warning: bundle not tracked for cleanup: %v\n", err)
+			return
+		}
+		workspaceDir = dir
+	}
+	if _, err := NewWorkspaceManager(workspaceDir).Track(bundlePath, createdAt); err != nil {
+		fmt.Printf("Warning: bundle not tracked for cleanup: %v\n", err)
+	}
+}
+
+// CleanBundlesOptions configures `support-bundle clean`
+type CleanBundlesOptions struct {
+	WorkspaceDir string `json:"workspaceDir,omitempty"` // Defaults to DefaultWorkspaceDir
+	OlderThan    string `json:"olderThan,omitempty"`    // e.g. 30d, see ParseRetentionAge
+	Keep         int    `json:"keep,omitempty"`         // Newest bundles always kept
+	DryRun       bool   `json:"dryRun,omitempty"`
+	Output       string `json:"output,omitempty"` // "console" or "json"
+}
+
+// RunCleanBundles implements `support-bundle clean --older-than 30d --keep 5`
+func RunCleanBundles(options CleanBundlesOptions) (*CleanBundlesResult, error) {
+	olderThan, err := ParseRetentionAge(options.OlderThan)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --older-than: %w", err)
+	}
+	dir := options.WorkspaceDir
+	if dir == "" {
+		if dir, err = DefaultWorkspaceDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := NewWorkspaceManager(dir).Clean(CleanBundlesPolicy{OlderThan: olderThan, Keep: options.Keep, DryRun: options.DryRun}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal clean result: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printCleanBundlesResult(result)
+	}
+
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("failed to remove %d bundles", len(result.Errors))
+	}
+	return result, nil
+}
+
+func printCleanBundlesResult(result *CleanBundlesResult) {
+	verb := "Removed"
+	if result.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("🧹 %s %d bundles (%s), keeping %d\n", verb, len(result.Removed), formatByteSize(result.FreedBytes), len(result.Kept))
+	for _, record := range result.Removed {
+		fmt.Printf("   - %s (%s, created %s)\n", record.Path, formatByteSize(record.SizeBytes), record.CreatedAt.Format(time.RFC3339))
+	}
+	if len(result.Missing) > 0 {
+		fmt.Printf("   %d bundles were already deleted and are no longer tracked\n", len(result.Missing))
+	}
+	for _, e := range result.Errors {
+		fmt.Printf("Warning: %s\n", e)
+	}
+}
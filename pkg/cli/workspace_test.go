@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTrackedTestBundle creates a bundle directory holding one file of size bytes
+func writeTrackedTestBundle(t *testing.T, dir, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "README.md"), make([]byte, size), 0644); err != nil {
+		t.Fatalf("Failed to write bundle file: %v", err)
+	}
+	return path
+}
+
+func TestWorkspaceManager_Track(t *testing.T) {
+	dir := t.TempDir()
+	workspace := NewWorkspaceManager(filepath.Join(dir, "workspace"))
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	bundle := writeTrackedTestBundle(t, dir, "bundle-a", 100)
+	if _, err := workspace.Track(bundle, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	record, err := workspace.Track(bundle, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if record.SizeBytes != 100 || !record.CreatedAt.Equal(now) {
+		t.Errorf("Expected a 100 byte bundle created at %s, got %+v", now, record)
+	}
+
+	records, err := workspace.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("Expected tracking the same path twice to keep one record, got %+v", records)
+	}
+
+	if _, err := workspace.Track(filepath.Join(dir, "missing"), now); err == nil {
+		t.Errorf("Expected an error tracking a missing bundle")
+	}
+}
+
+func TestWorkspaceManager_Clean(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	ages := []int{1, 10, 20, 40, 50, 60} // Days before now
+
+	tests := []struct {
+		name            string
+		policy          CleanBundlesPolicy
+		expectedRemoved int
+	}{
+		{name: "older than 30 days", policy: CleanBundlesPolicy{OlderThan: 30 * 24 * time.Hour}, expectedRemoved: 3},
+		{name: "keep newest 5", policy: CleanBundlesPolicy{Keep: 5}, expectedRemoved: 1},
+		{name: "keep wins over age", policy: CleanBundlesPolicy{OlderThan: 30 * 24 * time.Hour, Keep: 5}, expectedRemoved: 1},
+		{name: "age wins over keep", policy: CleanBundlesPolicy{OlderThan: 15 * 24 * time.Hour, Keep: 1}, expectedRemoved: 4},
+		{name: "dry run", policy: CleanBundlesPolicy{Keep: 2, DryRun: true}, expectedRemoved: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			workspace := NewWorkspaceManager(filepath.Join(dir, "workspace"))
+			for _, age := range ages {
+				path := writeTrackedTestBundle(t, dir, fmt.Sprintf("bundle-%d", age), 10)
+				if _, err := workspace.Track(path, now.Add(-time.Duration(age)*24*time.Hour)); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			result, err := workspace.Clean(tt.policy, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(result.Removed) != tt.expectedRemoved || result.FreedBytes != int64(10*tt.expectedRemoved) {
+				t.Errorf("Expected %d bundles removed, got %d freeing %d bytes", tt.expectedRemoved, len(result.Removed), result.FreedBytes)
+			}
+			if len(result.Kept)+len(result.Removed) != len(ages) {
+				t.Errorf("Expected every bundle to be kept or removed, got %d kept and %d removed", len(result.Kept), len(result.Removed))
+			}
+
+			for _, record := range result.Removed {
+				if record.CreatedAt.After(result.Kept[len(result.Kept)-1].CreatedAt) {
+					t.Errorf("Expected removed bundles to be older than kept ones, removed %s", record.Path)
+				}
+				_, err := os.Stat(record.Path)
+				if tt.policy.DryRun && err != nil {
+					t.Errorf("Expected a dry run to leave %s in place", record.Path)
+				}
+				if !tt.policy.DryRun && !os.IsNotExist(err) {
+					t.Errorf("Expected %s to be removed", record.Path)
+				}
+			}
+
+			records, err := workspace.List()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expectedTracked := len(ages) - tt.expectedRemoved
+			if tt.policy.DryRun {
+				expectedTracked = len(ages)
+			}
+			if len(records) != expectedTracked {
+				t.Errorf("Expected %d tracked bundles, got %d", expectedTracked, len(records))
+			}
+		})
+	}
+}
+
+func TestWorkspaceManager_CleanMissingAndUntracked(t *testing.T) {
+	dir := t.TempDir()
+	workspace := NewWorkspaceManager(filepath.Join(dir, "workspace"))
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	deleted := writeTrackedTestBundle(t, dir, "deleted", 10)
+	if _, err := workspace.Track(deleted, now.Add(-90*24*time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	os.RemoveAll(deleted)
+	untracked := writeTrackedTestBundle(t, dir, "untracked", 10)
+
+	result, err := workspace.Clean(CleanBundlesPolicy{OlderThan: time.Hour}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Missing) != 1 || len(result.Removed) != 0 {
+		t.Errorf("Expected the deleted bundle to be reported missing, got %+v", result)
+	}
+	if _, err := os.Stat(untracked); err != nil {
+		t.Errorf("Expected an untracked bundle to be left alone: %v", err)
+	}
+	if records, _ := workspace.List(); len(records) != 0 {
+		t.Errorf("Expected the missing bundle to be dropped from the index, got %+v", records)
+	}
+
+	if _, err := workspace.Clean(CleanBundlesPolicy{}, now); err == nil {
+		t.Errorf("Expected an error without older-than or keep")
+	}
+}
+
+func TestParseRetentionAge(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{value: "", expected: 0},
+		{value: "30d", expected: 30 * 24 * time.Hour},
+		{value: "12h", expected: 12 * time.Hour},
+		{value: "90m", expected: 90 * time.Minute},
+		{value: "-1d", expectError: true},
+		{value: "1.5d", expectError: true},
+		{value: "month", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			age, err := ParseRetentionAge(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if age != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, age)
+			}
+		})
+	}
+}
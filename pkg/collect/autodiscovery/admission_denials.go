@@ -0,0 +1,341 @@
+package autodiscovery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultAdmissionDenialLimit is the number of most recent denials kept per namespace
+const DefaultAdmissionDenialLimit = 200
+
+// maxAuditLineSize bounds a single audit log line, request and response bodies can make them large
+const maxAuditLineSize = 4 * 1024 * 1024
+
+// Admission controller types a denial is attributed to
+const (
+	AdmissionControllerWebhook        = "AdmissionWebhook"
+	AdmissionControllerPolicy         = "ValidatingAdmissionPolicy"
+	AdmissionControllerPodSecurity    = "PodSecurity"
+	AdmissionControllerResourceQuota  = "ResourceQuota"
+	AdmissionControllerLimitRange     = "LimitRange"
+	AdmissionControllerServiceAccount = "ServiceAccount"
+)
+
+// Where a denial was found
+const (
+	AdmissionDenialSourceEvent = "event"
+	AdmissionDenialSourceAudit = "audit"
+)
+
+// admissionDenialPatterns recognize the messages admission controllers reject requests with, the first group names the controller
+var admissionDenialPatterns = []struct {
+	controller string
+	pattern    *regexp.Regexp
+}{
+	{AdmissionControllerWebhook, regexp.MustCompile(`admission webhook "([^"]+)" denied the request`)},
+	{AdmissionControllerPolicy, regexp.MustCompile(`ValidatingAdmissionPolicy '([^']+)'(?: with binding '[^']+')? denied request`)},
+	{AdmissionControllerPodSecurity, regexp.MustCompile(`violates PodSecurity "([^"]+)"`)},
+	{AdmissionControllerResourceQuota, regexp.MustCompile(`exceeded quota: ([^,\s]+)`)},
+	{AdmissionControllerLimitRange, regexp.MustCompile(`forbidden: ((?:maximum|minimum) \S+ usage per (?:Container|Pod))`)},
+	{AdmissionControllerServiceAccount, regexp.MustCompile(`error looking up service account ([^:\s]+)`)},
+}
+
+// admissionAuditVerbs are the audited verbs admission control applies to
+var admissionAuditVerbs = map[string]bool{"create": true, "update": true, "patch": true, "delete": true}
+
+// AdmissionDenial is one request rejected by an admission controller, repeats of the same rejection are counted
+type AdmissionDenial struct {
+	Time       string `json:"time"` // Most recent occurrence
+	Source     string `json:"source"`
+	Controller string `json:"controller"`     // e.g. AdmissionWebhook or PodSecurity
+	Name       string `json:"name,omitempty"` // Webhook, policy, quota or PodSecurity level
+	Object     string `json:"object"`         // Kind/name from events, resource/name from the audit log
+	Operation  string `json:"operation,omitempty"`
+	User       string `json:"user,omitempty"`
+	Message    string `json:"message"`
+	Count      int64  `json:"count"`
+}
+
+// NamespaceAdmissionDenials are the admission denials affecting one namespace
+type NamespaceAdmissionDenials struct {
+	Namespace string            `json:"namespace"`
+	Denials   []AdmissionDenial `json:"denials"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Problems  []string          `json:"problems,omitempty"`
+}
+
+// AdmissionDenials finds requests rejected by admission control, the usual reason a rollout silently creates nothing
+type AdmissionDenials struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewAdmissionDenials creates a new AdmissionDenials
+func NewAdmissionDenials(dynamicClient dynamic.Interface) *AdmissionDenials {
+	return &AdmissionDenials{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateAdmissionDenialCollectors returns one data collector per discovered namespace with admission denials inside
+// the time window, found in its events and, when opts.AuditLogPath is set, in the API server audit log
+func (a *AdmissionDenials) GenerateAdmissionDenialCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions) []CollectorSpec {
+	namespaces := make(map[string]bool)
+	for _, resource := range resources {
+		if resource.Namespace != "" {
+			namespaces[resource.Namespace] = true
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	var audit []auditDenial
+	if opts.AuditLogPath != "" {
+		var err error
+		audit, err = readAuditLogDenials(opts.AuditLogPath, namespaces)
+		if err != nil {
+			fmt.Printf("Warning: failed to read admission denials from the audit log: %v\n", err)
+		}
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	var collectors []CollectorSpec
+	for _, namespace := range names {
+		report := a.namespaceDenials(ctx, namespace, audit, opts.TimeWindow)
+		if len(report.Denials) == 0 && len(report.Problems) == 0 {
+			continue
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			continue
+		}
+		collectors = append(collectors, CollectorSpec{
+			Type:      "data",
+			Name:      fmt.Sprintf("auto-admission-denials-%s", namespace),
+			Namespace: namespace,
+			Group:     CollectorGroupWorkloads,
+			Priority:  int(PriorityHigh),
+			Parameters: map[string]interface{}{
+				"name": fmt.Sprintf("namespaces/%s/admission-denials.json", namespace),
+				"data": string(data),
+			},
+		})
+	}
+	return collectors
+}
+
+// namespaceDenials merges the event and audit denials of one namespace, listing failures as problems rather than failing
+func (a *AdmissionDenials) namespaceDenials(ctx context.Context, namespace string, audit []auditDenial, window TimeWindow) NamespaceAdmissionDenials {
+	report := NamespaceAdmissionDenials{Namespace: namespace, Denials: []AdmissionDenial{}}
+	merged := make(map[string]int)
+	add := func(denial AdmissionDenial) {
+		if t, err := time.Parse(time.RFC3339, denial.Time); err == nil && !window.IsZero() && !window.Contains(t) {
+			return
+		}
+		key := strings.Join([]string{denial.Source, denial.Controller, denial.Name, denial.Object, denial.Message}, "|")
+		if i, ok := merged[key]; ok {
+			report.Denials[i].Count += denial.Count
+			if denial.Time > report.Denials[i].Time {
+				report.Denials[i].Time = denial.Time
+			}
+			return
+		}
+		merged[key] = len(report.Denials)
+		report.Denials = append(report.Denials, denial)
+	}
+
+	events, err := a.dynamicClient.Resource(eventsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to list events: %v", err))
+	} else {
+		for _, event := range events.Items {
+			if denial, ok := eventAdmissionDenial(event); ok {
+				add(denial)
+			}
+		}
+	}
+
+	for _, denial := range audit {
+		if denial.namespace == namespace {
+			add(denial.AdmissionDenial)
+		}
+	}
+
+	sort.SliceStable(report.Denials, func(i, j int) bool {
+		if report.Denials[i].Time != report.Denials[j].Time {
+			return report.Denials[i].Time < report.Denials[j].Time
+		}
+		return report.Denials[i].Object < report.Denials[j].Object
+	})
+	if len(report.Denials) > DefaultAdmissionDenialLimit {
+		report.Denials = report.Denials[len(report.Denials)-DefaultAdmissionDenialLimit:]
+		report.Truncated = true
+	}
+	return report
+}
+
+// ClassifyAdmissionDenial returns the controller type and name that rejected a request with message
+func ClassifyAdmissionDenial(message string) (string, string, bool) {
+	for _, p := range admissionDenialPatterns {
+		if match := p.pattern.FindStringSubmatch(message); match != nil {
+			return p.controller, match[1], true
+		}
+	}
+	return "", "", false
+}
+
+// eventAdmissionDenial converts a Warning event reporting a rejected request, e.g. a ReplicaSet's FailedCreate
+func eventAdmissionDenial(event unstructured.Unstructured) (AdmissionDenial, bool) {
+	if eventType, _, _ := unstructured.NestedString(event.Object, "type"); eventType != "Warning" {
+		return AdmissionDenial{}, false
+	}
+	message, _, _ := unstructured.NestedString(event.Object, "message")
+	controller, name, ok := ClassifyAdmissionDenial(message)
+	if !ok {
+		return AdmissionDenial{}, false
+	}
+
+	kind, _, _ := unstructured.NestedString(event.Object, "involvedObject", "kind")
+	objectName, _, _ := unstructured.NestedString(event.Object, "involvedObject", "name")
+	denial := AdmissionDenial{
+		Source:     AdmissionDenialSourceEvent,
+		Controller: controller,
+		Name:       name,
+		Object:     kind + "/" + objectName,
+		Message:    message,
+		Count:      1,
+	}
+	denial.Operation, _, _ = unstructured.NestedString(event.Object, "reason")
+	for _, field := range []string{"lastTimestamp", "eventTime", "firstTimestamp"} {
+		if value, ok, _ := unstructured.NestedString(event.Object, field); ok && value != "" {
+			denial.Time = value
+			break
+		}
+	}
+	if count, ok, _ := unstructured.NestedInt64(event.Object, "count"); ok && count > 0 {
+		denial.Count = count
+	}
+	return denial, true
+}
+
+// auditDenial is an audit log denial together with the namespace of its object
+type auditDenial struct {
+	AdmissionDenial
+	namespace string
+}
+
+// auditEvent holds the audit.k8s.io/v1 Event fields a denial is built from
+type auditEvent struct {
+	Verb string `json:"verb"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Subresource string `json:"subresource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"responseStatus"`
+	StageTimestamp string `json:"stageTimestamp"`
+}
+
+// readAuditLogDenials reads admission denials for namespaces from an audit log, one JSON Event or webhook EventList
+// batch per line as written by the log backend or a webhook sink. Unparseable lines are skipped
+func readAuditLogDenials(path string, namespaces map[string]bool) ([]auditDenial, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var denials []auditDenial
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var batch struct {
+			Kind  string       `json:"kind"`
+			Items []auditEvent `json:"items"`
+		}
+		if err := json.Unmarshal(line, &batch); err != nil {
+			continue
+		}
+		events := batch.Items
+		if batch.Kind != "EventList" {
+			var event auditEvent
+			if err := json.Unmarshal(line, &event); err != nil {
+				continue
+			}
+			events = []auditEvent{event}
+		}
+		for _, event := range events {
+			if denial, ok := auditAdmissionDenial(event); ok && namespaces[denial.namespace] {
+				denials = append(denials, denial)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return denials, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return denials, nil
+}
+
+// auditAdmissionDenial converts a completed write whose failure response names an admission controller
+func auditAdmissionDenial(event auditEvent) (auditDenial, bool) {
+	if !admissionAuditVerbs[event.Verb] || event.ObjectRef == nil || event.ResponseStatus == nil || event.ResponseStatus.Code < 400 {
+		return auditDenial{}, false
+	}
+	controller, name, ok := ClassifyAdmissionDenial(event.ResponseStatus.Message)
+	if !ok {
+		return auditDenial{}, false
+	}
+
+	object := event.ObjectRef.Resource
+	if event.ObjectRef.Subresource != "" {
+		object += "/" + event.ObjectRef.Subresource
+	}
+	if event.ObjectRef.Name != "" {
+		object += "/" + event.ObjectRef.Name
+	}
+	timestamp := event.StageTimestamp
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		timestamp = t.UTC().Format(time.RFC3339)
+	}
+	return auditDenial{
+		AdmissionDenial: AdmissionDenial{
+			Time:       timestamp,
+			Source:     AdmissionDenialSourceAudit,
+			Controller: controller,
+			Name:       name,
+			Object:     object,
+			Operation:  event.Verb,
+			User:       event.User.Username,
+			Message:    event.ResponseStatus.Message,
+			Count:      1,
+		},
+		namespace: event.ObjectRef.Namespace,
+	}, true
+}
@@ -0,0 +1,171 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassifyAdmissionDenial(t *testing.T) {
+	tests := []struct {
+		name               string
+		message            string
+		expectedController string
+		expectedName       string
+	}{
+		{
+			name:               "webhook",
+			message:            `Error creating: admission webhook "validation.gatekeeper.sh" denied the request: [must-have-owner] missing label owner`,
+			expectedController: AdmissionControllerWebhook,
+			expectedName:       "validation.gatekeeper.sh",
+		},
+		{
+			name:               "validating admission policy",
+			message:            `deployments.apps "web" is forbidden: ValidatingAdmissionPolicy 'replica-limit' with binding 'replica-limit-binding' denied request: too many replicas`,
+			expectedController: AdmissionControllerPolicy,
+			expectedName:       "replica-limit",
+		},
+		{
+			name:               "pod security",
+			message:            `Error creating: pods "web-abc" is forbidden: violates PodSecurity "restricted:latest": allowPrivilegeEscalation != false`,
+			expectedController: AdmissionControllerPodSecurity,
+			expectedName:       "restricted:latest",
+		},
+		{
+			name:               "quota",
+			message:            `Error creating: pods "web-abc" is forbidden: exceeded quota: compute-quota, requested: cpu=2, used: cpu=8, limited: cpu=8`,
+			expectedController: AdmissionControllerResourceQuota,
+			expectedName:       "compute-quota",
+		},
+		{
+			name:               "limit range",
+			message:            `Error creating: pods "web-abc" is forbidden: maximum memory usage per Container is 1Gi, but limit is 2Gi`,
+			expectedController: AdmissionControllerLimitRange,
+			expectedName:       "maximum memory usage per Container",
+		},
+		{
+			name:               "service account",
+			message:            `Error creating: pods "web-abc" is forbidden: error looking up service account app/web: serviceaccount "web" not found`,
+			expectedController: AdmissionControllerServiceAccount,
+			expectedName:       "app/web",
+		},
+		{
+			name:    "scheduling failure",
+			message: `0/3 nodes are available: 3 Insufficient cpu.`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, name, ok := ClassifyAdmissionDenial(tt.message)
+			if ok != (tt.expectedController != "") {
+				t.Fatalf("Expected match %v, got %v", tt.expectedController != "", ok)
+			}
+			if controller != tt.expectedController || name != tt.expectedName {
+				t.Errorf("Expected %s %q, got %s %q", tt.expectedController, tt.expectedName, controller, name)
+			}
+		})
+	}
+}
+
+func TestReadAuditLogDenials(t *testing.T) {
+	denied := `{"kind":"Event","verb":"create","user":{"username":"system:serviceaccount:kube-system:replicaset-controller"},` +
+		`"objectRef":{"resource":"pods","namespace":"app"},"responseStatus":{"code":403,"message":"pods \"web-abc\" is forbidden: violates PodSecurity \"baseline:latest\": hostPath volumes"},` +
+		`"stageTimestamp":"2024-01-01T10:05:00.123456Z"}`
+	rbac := `{"kind":"Event","verb":"create","objectRef":{"resource":"pods","namespace":"app"},"responseStatus":{"code":403,"message":"pods is forbidden: User \"jane\" cannot create resource \"pods\""}}`
+	batch := `{"kind":"EventList","items":[` +
+		`{"verb":"update","objectRef":{"resource":"deployments","namespace":"app","name":"web"},"responseStatus":{"code":400,"message":"admission webhook \"policy.example.com\" denied the request: no"},"stageTimestamp":"2024-01-01T10:06:00Z"},` +
+		`{"verb":"create","objectRef":{"resource":"pods","namespace":"other"},"responseStatus":{"code":403,"message":"exceeded quota: q1"}},` +
+		`{"verb":"get","objectRef":{"resource":"pods","namespace":"app"},"responseStatus":{"code":404,"message":"admission webhook \"x\" denied the request"}}]}`
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte(strings.Join([]string{denied, "not json", rbac, "", batch}, "\n")), 0644); err != nil {
+		t.Fatalf("Failed to write audit log: %v", err)
+	}
+
+	denials, err := readAuditLogDenials(path, map[string]bool{"app": true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(denials) != 2 {
+		t.Fatalf("Expected 2 denials in app, got %+v", denials)
+	}
+	if d := denials[0]; d.Controller != AdmissionControllerPodSecurity || d.Time != "2024-01-01T10:05:00Z" || d.Operation != "create" || d.Object != "pods" || d.User == "" {
+		t.Errorf("Unexpected PodSecurity denial %+v", d)
+	}
+	if d := denials[1]; d.Controller != AdmissionControllerWebhook || d.Name != "policy.example.com" || d.Object != "deployments/web" {
+		t.Errorf("Unexpected webhook denial %+v", d)
+	}
+
+	if _, err := readAuditLogDenials(filepath.Join(t.TempDir(), "missing.log"), nil); err == nil {
+		t.Errorf("Expected an error for a missing audit log")
+	}
+}
+
+func TestAdmissionDenials_GenerateAdmissionDenialCollectors(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	denied := func(name string, at time.Time, count int32) *corev1.Event {
+		event := testTimelineEvent("app", name, "ReplicaSet", "web-rs", "FailedCreate", at)
+		event.Message = `Error creating: admission webhook "policy.example.com" denied the request: missing label`
+		event.Count = count
+		return event
+	}
+	client := createTestDynamicClient(
+		denied("web-rs.1", base.Add(5*time.Minute), 4),
+		denied("web-rs.2", base.Add(9*time.Minute), 2),
+		denied("web-rs.old", base.Add(-3*time.Hour), 1),
+		testTimelineEvent("app", "web-abc.1", "Pod", "web-abc", "BackOff", base.Add(6*time.Minute)),
+		testTimelineEvent("quiet", "db.1", "Pod", "db", "BackOff", base.Add(6*time.Minute)),
+	)
+
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	line := `{"verb":"create","objectRef":{"resource":"pods","namespace":"app"},"responseStatus":{"code":403,"message":"exceeded quota: compute, requested: cpu=1"},"stageTimestamp":"2024-01-01T10:07:00Z"}`
+	if err := os.WriteFile(auditLog, []byte(line+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write audit log: %v", err)
+	}
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resources := []Resource{
+		{GVR: pods, Namespace: "app", Name: "web-abc"},
+		{GVR: pods, Namespace: "quiet", Name: "db"},
+	}
+	since := base
+	opts := DiscoveryOptions{TimeWindow: TimeWindow{Since: &since}, AuditLogPath: auditLog}
+
+	collectors := NewAdmissionDenials(client).GenerateAdmissionDenialCollectors(context.Background(), resources, opts)
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 collector for the namespace with denials, got %d", len(collectors))
+	}
+	if collectors[0].Name != "auto-admission-denials-app" || collectors[0].Parameters["name"] != "namespaces/app/admission-denials.json" {
+		t.Errorf("Unexpected collector %s writing %v", collectors[0].Name, collectors[0].Parameters["name"])
+	}
+
+	var report NamespaceAdmissionDenials
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse denials: %v", err)
+	}
+	if len(report.Denials) != 2 {
+		t.Fatalf("Expected the webhook and quota denials, got %+v", report.Denials)
+	}
+	quota, webhook := report.Denials[0], report.Denials[1]
+	if quota.Source != AdmissionDenialSourceAudit || quota.Controller != AdmissionControllerResourceQuota || quota.Name != "compute" {
+		t.Errorf("Expected the quota denial from the audit log first, got %+v", quota)
+	}
+	if webhook.Source != AdmissionDenialSourceEvent || webhook.Object != "ReplicaSet/web-rs" || webhook.Count != 6 {
+		t.Errorf("Expected 6 webhook denials of ReplicaSet/web-rs merged from events, got %+v", webhook)
+	}
+	if webhook.Time != base.Add(9*time.Minute).Format(time.RFC3339) {
+		t.Errorf("Expected the most recent occurrence, got %s", webhook.Time)
+	}
+
+	if collectors := NewAdmissionDenials(client).GenerateAdmissionDenialCollectors(context.Background(), nil, DiscoveryOptions{}); collectors != nil {
+		t.Errorf("Expected no collectors without discovered namespaces, got %d", len(collectors))
+	}
+}
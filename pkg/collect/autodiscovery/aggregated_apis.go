@@ -0,0 +1,145 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var apiServicesGVR = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// UnavailableAPIService is an aggregated API whose APIService is not Available
+// Requests to its group version fail or hang until the backing service recovers
+type UnavailableAPIService struct {
+	Name             string `json:"name"`
+	Group            string `json:"group"`
+	Version          string `json:"version"`
+	ServiceNamespace string `json:"serviceNamespace"`
+	ServiceName      string `json:"serviceName"`
+	Reason           string `json:"reason,omitempty"`
+	Message          string `json:"message,omitempty"`
+}
+
+// GroupVersion returns the group version served by the APIService
+func (u UnavailableAPIService) GroupVersion() schema.GroupVersion {
+	return schema.GroupVersion{Group: u.Group, Version: u.Version}
+}
+
+// AggregatedAPIReport is the finding recorded in the bundle when aggregated APIs are down
+type AggregatedAPIReport struct {
+	Finding     string                  `json:"finding"`
+	Unavailable []UnavailableAPIService `json:"unavailable"`
+}
+
+// AggregatedAPIChecker detects aggregated APIs that are down before resources are scanned
+type AggregatedAPIChecker struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewAggregatedAPIChecker creates a new AggregatedAPIChecker
+func NewAggregatedAPIChecker(dynamicClient dynamic.Interface) *AggregatedAPIChecker {
+	return &AggregatedAPIChecker{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// FindUnavailable lists APIService objects and returns the aggregated ones that are not Available
+// APIServices without a service are served by the API server itself and are never reported
+func (a *AggregatedAPIChecker) FindUnavailable(ctx context.Context) ([]UnavailableAPIService, error) {
+	list, err := a.dynamicClient.Resource(apiServicesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apiservices: %w", err)
+	}
+
+	var unavailable []UnavailableAPIService
+	for _, item := range list.Items {
+		service, found, _ := unstructured.NestedMap(item.Object, "spec", "service")
+		if !found || service == nil {
+			continue
+		}
+
+		available, reason, message := apiServiceAvailability(item)
+		if available {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(item.Object, "spec", "group")
+		version, _, _ := unstructured.NestedString(item.Object, "spec", "version")
+		namespace, _, _ := unstructured.NestedString(service, "namespace")
+		name, _, _ := unstructured.NestedString(service, "name")
+		unavailable = append(unavailable, UnavailableAPIService{
+			Name:             item.GetName(),
+			Group:            group,
+			Version:          version,
+			ServiceNamespace: namespace,
+			ServiceName:      name,
+			Reason:           reason,
+			Message:          message,
+		})
+	}
+
+	sort.Slice(unavailable, func(i, j int) bool {
+		return unavailable[i].Name < unavailable[j].Name
+	})
+	return unavailable, nil
+}
+
+// GenerateAggregatedAPICollectors records unavailable aggregated APIs as a critical cluster-info finding
+func (a *AggregatedAPIChecker) GenerateAggregatedAPICollectors(unavailable []UnavailableAPIService) []CollectorSpec {
+	if len(unavailable) == 0 {
+		return nil
+	}
+
+	report := AggregatedAPIReport{
+		Finding: fmt.Sprintf("%d aggregated API(s) are unavailable. Requests to their groups fail or hang, which commonly "+
+			"breaks namespace deletion, garbage collection, HPA and kubectl commands that discover all APIs. "+
+			"This is a likely root cause of other failures in this bundle.", len(unavailable)),
+		Unavailable: unavailable,
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return []CollectorSpec{{
+		Type:     "data",
+		Name:     "auto-cluster-info-unavailable-apiservices",
+		Group:    CollectorGroupClusterInfo,
+		Priority: int(PriorityCritical),
+		Parameters: map[string]interface{}{
+			"name": "cluster-info/unavailable-apiservices.json",
+			"data": string(data),
+		},
+	}}
+}
+
+// apiServiceAvailability reads the Available condition of an APIService
+// An APIService without the condition yet is treated as available
+func apiServiceAvailability(apiService unstructured.Unstructured) (bool, string, string) {
+	conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Available" {
+			continue
+		}
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return condition["status"] == "True", reason, message
+	}
+	return true, "", ""
+}
+
+// unavailableGroupVersions indexes the group versions of unavailable aggregated APIs
+func unavailableGroupVersions(unavailable []UnavailableAPIService) map[schema.GroupVersion]UnavailableAPIService {
+	groupVersions := make(map[schema.GroupVersion]UnavailableAPIService, len(unavailable))
+	for _, apiService := range unavailable {
+		groupVersions[apiService.GroupVersion()] = apiService
+	}
+	return groupVersions
+}
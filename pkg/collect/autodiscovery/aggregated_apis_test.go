@@ -0,0 +1,148 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testAPIService(name, group, version string, service map[string]interface{}, available string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiregistration.k8s.io/v1",
+		"kind":       "APIService",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       map[string]interface{}{"group": group, "version": version},
+	}}
+	if service != nil {
+		obj.Object["spec"].(map[string]interface{})["service"] = service
+	}
+	if available != "" {
+		obj.Object["status"] = map[string]interface{}{"conditions": []interface{}{map[string]interface{}{
+			"type":    "Available",
+			"status":  available,
+			"reason":  "FailedDiscoveryCheck",
+			"message": "failing or missing response from https://10.96.0.10:443",
+		}}}
+	}
+	return obj
+}
+
+func createTestAggregatedAPIClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	// The scheme registers no types so the unstructured objects are listed as they are, not converted to typed ones
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		apiServicesGVR: "APIServiceList",
+		podsGVR:        "PodList",
+		podMetricsGVR:  "PodMetricsList",
+	}, objects...)
+}
+
+func TestAggregatedAPIChecker_FindUnavailable(t *testing.T) {
+	metricsServer := map[string]interface{}{"namespace": "kube-system", "name": "metrics-server"}
+	client := createTestAggregatedAPIClient(
+		testAPIService("v1.apps", "apps", "v1", nil, "True"),
+		testAPIService("v1beta1.metrics.k8s.io", "metrics.k8s.io", "v1beta1", metricsServer, "False"),
+		testAPIService("v1.custom.example.com", "custom.example.com", "v1", map[string]interface{}{"namespace": "custom", "name": "api"}, "True"),
+		testAPIService("v1.pending.example.com", "pending.example.com", "v1", map[string]interface{}{"namespace": "custom", "name": "api"}, ""),
+	)
+
+	unavailable, err := NewAggregatedAPIChecker(client).FindUnavailable(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unavailable) != 1 {
+		t.Fatalf("Expected 1 unavailable API service, got %+v", unavailable)
+	}
+
+	apiService := unavailable[0]
+	if apiService.Name != "v1beta1.metrics.k8s.io" || apiService.ServiceName != "metrics-server" || apiService.ServiceNamespace != "kube-system" {
+		t.Errorf("Expected metrics-server API service, got %+v", apiService)
+	}
+	if apiService.Reason != "FailedDiscoveryCheck" {
+		t.Errorf("Expected reason FailedDiscoveryCheck, got %s", apiService.Reason)
+	}
+	if apiService.GroupVersion() != podMetricsGVR.GroupVersion() {
+		t.Errorf("Expected group version %s, got %s", podMetricsGVR.GroupVersion(), apiService.GroupVersion())
+	}
+}
+
+func TestAggregatedAPIChecker_GenerateAggregatedAPICollectors(t *testing.T) {
+	checker := NewAggregatedAPIChecker(createTestAggregatedAPIClient())
+
+	if collectors := checker.GenerateAggregatedAPICollectors(nil); len(collectors) != 0 {
+		t.Errorf("Expected no collectors without unavailable APIs, got %d", len(collectors))
+	}
+
+	collectors := checker.GenerateAggregatedAPICollectors([]UnavailableAPIService{{Name: "v1beta1.metrics.k8s.io", Group: "metrics.k8s.io", Version: "v1beta1"}})
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 collector, got %d", len(collectors))
+	}
+	if collectors[0].Group != CollectorGroupClusterInfo || collectors[0].Priority != int(PriorityCritical) {
+		t.Errorf("Expected a critical cluster-info collector, got %+v", collectors[0])
+	}
+
+	var report AggregatedAPIReport
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if report.Finding == "" || len(report.Unavailable) != 1 {
+		t.Errorf("Expected a finding with 1 unavailable API, got %+v", report)
+	}
+}
+
+func TestDiscoverer_SkipsUnavailableAggregatedAPIs(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	dynamicClient := createTestAggregatedAPIClient(
+		testAPIService("v1beta1.metrics.k8s.io", "metrics.k8s.io", "v1beta1", map[string]interface{}{"namespace": "kube-system", "name": "metrics-server"}, "False"),
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": "web-1", "namespace": "default"},
+		}},
+	)
+	// Created through its resource, the tracker would otherwise guess the plural of PodMetrics wrong and never list it
+	podMetrics := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata":   map[string]interface{}{"name": "web-1", "namespace": "default"},
+	}}
+	if _, err := dynamicClient.Resource(podMetricsGVR).Namespace("default").Create(context.Background(), podMetrics, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create pod metrics: %v", err)
+	}
+
+	discoverer := &Discoverer{
+		kubeClient:     kubeClient,
+		dynamicClient:  dynamicClient,
+		rbacChecker:    NewRBACChecker(kubeClient),
+		nsScanner:      NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:       NewResourceExpander(),
+		aggregatedAPIs: NewAggregatedAPIChecker(dynamicClient),
+	}
+
+	filter := ResourceFilter{IncludeGVRs: []schema.GroupVersionResource{podsGVR, podMetricsGVR}}
+	resources, err := discoverer.scanResources(context.Background(), DiscoveryOptions{Namespaces: []string{"default"}}, filter)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resources) != 1 || resources[0].GVR != podsGVR {
+		t.Errorf("Expected only the pod to be scanned, got %+v", resources)
+	}
+
+	if len(discoverer.UnavailableAPIServices()) != 1 {
+		t.Fatalf("Expected the metrics API to be recorded as unavailable, got %+v", discoverer.UnavailableAPIServices())
+	}
+
+	unserved, err := discoverer.FindUnservedGVRs([]schema.GroupVersionResource{podMetricsGVR})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unserved) != 1 || unserved[0].Suggestion != "check the kube-system/metrics-server service that backs it" {
+		t.Errorf("Expected the unavailable aggregated API to be reported as unserved, got %+v", unserved)
+	}
+}
@@ -0,0 +1,453 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Analyzer outcome results
+const (
+	AnalyzerResultPass = "pass"
+	AnalyzerResultWarn = "warn"
+	AnalyzerResultFail = "fail"
+)
+
+// Default analyzer types generated from discovered resources
+const (
+	AnalyzerTypeDeploymentStatus         = "deploymentStatus"
+	AnalyzerTypePVCStatus                = "pvcStatus"
+	AnalyzerTypeNodeResources            = "nodeResources"
+	AnalyzerTypeClusterPodStatuses       = "clusterPodStatuses"
+	AnalyzerTypeCustomResourceConditions = "customResourceConditions"
+)
+
+// Default CrashLoopBackOff thresholds for the clusterPodStatuses analyzer
+const (
+	DefaultCrashLoopWarnThreshold = 1
+	DefaultCrashLoopFailThreshold = 3
+)
+
+var (
+	podsGVR  = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	nodesGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}
+)
+
+// AnalyzerSpec represents a generated analyzer specification
+// This will be converted to the appropriate troubleshoot.sh/v1beta2 analyzer type
+type AnalyzerSpec struct {
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Namespace  string                 `json:"namespace,omitempty"`
+	Target     string                 `json:"target,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Outcomes   []AnalyzerOutcome      `json:"outcomes"`
+}
+
+// AnalyzerOutcome describes a single pass/warn/fail outcome of an analyzer
+type AnalyzerOutcome struct {
+	Result  string `json:"result"`
+	When    string `json:"when,omitempty"`
+	Message string `json:"message"`
+}
+
+// AnalyzerResult is the outcome of evaluating an analyzer against the cluster
+type AnalyzerResult struct {
+	AnalyzerName string `json:"analyzerName"`
+	Type         string `json:"type"`
+	Namespace    string `json:"namespace,omitempty"`
+	Target       string `json:"target,omitempty"`
+	Result       string `json:"result"`
+	Message      string `json:"message"`
+	Detail       string `json:"detail,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// AnalyzerGenerator pairs discovered resources with default analyzers and evaluates them
+type AnalyzerGenerator struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewAnalyzerGenerator creates a new AnalyzerGenerator
+func NewAnalyzerGenerator(dynamicClient dynamic.Interface) *AnalyzerGenerator {
+	return &AnalyzerGenerator{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateAnalyzers creates default analyzer specs for the discovered resources
+func (g *AnalyzerGenerator) GenerateAnalyzers(resources []Resource) []AnalyzerSpec {
+	var analyzers []AnalyzerSpec
+	podNamespaces := make(map[string]bool)
+	customResources := make(map[string]Resource) // One resource per namespace and type
+
+	for _, resource := range resources {
+		switch {
+		case isCustomResourceGroup(resource.GVR.Group) && resource.GVR != crdsGVR:
+			key := resource.Namespace + "/" + resource.GVR.Resource + "." + resource.GVR.Group
+			customResources[key] = resource
+		case resource.GVR.Group == "apps" && resource.GVR.Resource == "deployments":
+			analyzers = append(analyzers, deploymentStatusAnalyzer(resource))
+		case resource.GVR.Group == "" && resource.GVR.Resource == "persistentvolumeclaims":
+			analyzers = append(analyzers, pvcStatusAnalyzer(resource))
+		case resource.GVR.Group == "" && resource.GVR.Resource == "pods":
+			podNamespaces[resource.Namespace] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(podNamespaces))
+	for namespace := range podNamespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		analyzers = append(analyzers, clusterPodStatusesAnalyzer(namespace))
+	}
+
+	keys := make([]string, 0, len(customResources))
+	for key := range customResources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		analyzers = append(analyzers, customResourceConditionsAnalyzer(customResources[key]))
+	}
+
+	// Node pressure applies to the whole cluster, so only one analyzer is generated
+	if len(resources) > 0 {
+		analyzers = append(analyzers, nodeResourcesAnalyzer())
+	}
+
+	return analyzers
+}
+
+// RunAnalyzers evaluates the analyzers against the live cluster state
+func (g *AnalyzerGenerator) RunAnalyzers(ctx context.Context, analyzers []AnalyzerSpec) []AnalyzerResult {
+	results := make([]AnalyzerResult, 0, len(analyzers))
+
+	for _, analyzer := range analyzers {
+		result := AnalyzerResult{
+			AnalyzerName: analyzer.Name,
+			Type:         analyzer.Type,
+			Namespace:    analyzer.Namespace,
+			Target:       analyzer.Target,
+		}
+
+		outcome, detail, err := g.evaluate(ctx, analyzer)
+		if err != nil {
+			// An analyzer that can't read its inputs is reported as a warning rather than dropped
+			result.Result = AnalyzerResultWarn
+			result.Message = fmt.Sprintf("Unable to evaluate %s", analyzer.Name)
+			result.Error = err.Error()
+		} else {
+			result.Result = outcome
+			result.Message = analyzer.outcomeMessage(outcome)
+			result.Detail = detail
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// evaluate returns the outcome of a single analyzer and a short description of what was observed
+func (g *AnalyzerGenerator) evaluate(ctx context.Context, analyzer AnalyzerSpec) (string, string, error) {
+	switch analyzer.Type {
+	case AnalyzerTypeDeploymentStatus:
+		return g.evaluateDeploymentStatus(ctx, analyzer)
+	case AnalyzerTypePVCStatus:
+		return g.evaluatePVCStatus(ctx, analyzer)
+	case AnalyzerTypeClusterPodStatuses:
+		return g.evaluateClusterPodStatuses(ctx, analyzer)
+	case AnalyzerTypeNodeResources:
+		return g.evaluateNodeResources(ctx)
+	case AnalyzerTypeCustomResourceConditions:
+		return g.evaluateCustomResourceConditions(ctx, analyzer)
+	default:
+		return "", "", fmt.Errorf("unsupported analyzer type %s", analyzer.Type)
+	}
+}
+
+func (g *AnalyzerGenerator) evaluateDeploymentStatus(ctx context.Context, analyzer AnalyzerSpec) (string, string, error) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	obj, err := g.dynamicClient.Resource(gvr).Namespace(analyzer.Namespace).Get(ctx, analyzer.Target, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get deployment %s/%s: %w", analyzer.Namespace, analyzer.Target, err)
+	}
+
+	// spec.replicas defaults to 1 when unset
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	detail := fmt.Sprintf("%d/%d replicas ready", ready, replicas)
+
+	switch {
+	case replicas > 0 && ready < 1:
+		return AnalyzerResultFail, detail, nil
+	case ready < replicas:
+		return AnalyzerResultWarn, detail, nil
+	default:
+		return AnalyzerResultPass, detail, nil
+	}
+}
+
+func (g *AnalyzerGenerator) evaluatePVCStatus(ctx context.Context, analyzer AnalyzerSpec) (string, string, error) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}
+	obj, err := g.dynamicClient.Resource(gvr).Namespace(analyzer.Namespace).Get(ctx, analyzer.Target, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get persistentvolumeclaim %s/%s: %w", analyzer.Namespace, analyzer.Target, err)
+	}
+
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	detail := fmt.Sprintf("phase %s", phase)
+
+	switch phase {
+	case "Bound":
+		return AnalyzerResultPass, detail, nil
+	case "Pending":
+		return AnalyzerResultFail, detail, nil
+	default:
+		return AnalyzerResultWarn, detail, nil
+	}
+}
+
+func (g *AnalyzerGenerator) evaluateClusterPodStatuses(ctx context.Context, analyzer AnalyzerSpec) (string, string, error) {
+	list, err := g.dynamicClient.Resource(podsGVR).Namespace(analyzer.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list pods in %s: %w", analyzer.Namespace, err)
+	}
+
+	var crashing []string
+	for _, pod := range list.Items {
+		if podIsCrashLooping(pod) {
+			crashing = append(crashing, pod.GetName())
+		}
+	}
+
+	detail := fmt.Sprintf("%d pods in CrashLoopBackOff", len(crashing))
+	if len(crashing) > 0 {
+		detail = fmt.Sprintf("%s: %s", detail, strings.Join(crashing, ", "))
+	}
+
+	warnThreshold := analyzerIntParameter(analyzer, "warnThreshold", DefaultCrashLoopWarnThreshold)
+	failThreshold := analyzerIntParameter(analyzer, "failThreshold", DefaultCrashLoopFailThreshold)
+
+	switch {
+	case len(crashing) >= failThreshold:
+		return AnalyzerResultFail, detail, nil
+	case len(crashing) >= warnThreshold:
+		return AnalyzerResultWarn, detail, nil
+	default:
+		return AnalyzerResultPass, detail, nil
+	}
+}
+
+func (g *AnalyzerGenerator) evaluateNodeResources(ctx context.Context) (string, string, error) {
+	list, err := g.dynamicClient.Resource(nodesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var notReady, pressured []string
+	for _, node := range list.Items {
+		conditions, _, _ := unstructured.NestedSlice(node.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditionType, _ := condition["type"].(string)
+			status, _ := condition["status"].(string)
+
+			switch {
+			case conditionType == "Ready" && status != "True":
+				notReady = append(notReady, node.GetName())
+			case strings.HasSuffix(conditionType, "Pressure") && status == "True":
+				pressured = append(pressured, fmt.Sprintf("%s (%s)", node.GetName(), conditionType))
+			}
+		}
+	}
+
+	switch {
+	case len(notReady) > 0:
+		return AnalyzerResultFail, fmt.Sprintf("not ready: %s", strings.Join(notReady, ", ")), nil
+	case len(pressured) > 0:
+		return AnalyzerResultWarn, fmt.Sprintf("under pressure: %s", strings.Join(pressured, ", ")), nil
+	default:
+		return AnalyzerResultPass, fmt.Sprintf("%d nodes ready without pressure", len(list.Items)), nil
+	}
+}
+
+func (g *AnalyzerGenerator) evaluateCustomResourceConditions(ctx context.Context, analyzer AnalyzerSpec) (string, string, error) {
+	resource, group, _ := strings.Cut(analyzer.Target, ".")
+	version, _ := analyzer.Parameters["version"].(string)
+	gvr := schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
+
+	resources, err := listCustomResourceConditions(ctx, g.dynamicClient, gvr, analyzer.Target, analyzer.Namespace)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read conditions of %s: %w", analyzer.Target, err)
+	}
+
+	var unhealthy []CustomResourceConditions
+	var unknown []string
+	for _, r := range resources {
+		if !r.Healthy {
+			unhealthy = append(unhealthy, r)
+			continue
+		}
+		for _, condition := range r.Conditions {
+			if isConditionUnknown(condition) {
+				unknown = append(unknown, fmt.Sprintf("%s %s=Unknown", r.Name, condition.Type))
+			}
+		}
+	}
+
+	switch {
+	case len(unhealthy) > 0:
+		return AnalyzerResultFail, describeConditionProblems(unhealthy), nil
+	case len(unknown) > 0:
+		return AnalyzerResultWarn, strings.Join(unknown, ", "), nil
+	default:
+		return AnalyzerResultPass, fmt.Sprintf("%d resources reporting conditions, all healthy", len(resources)), nil
+	}
+}
+
+// outcomeMessage returns the message of the outcome matching the result
+func (a AnalyzerSpec) outcomeMessage(result string) string {
+	for _, outcome := range a.Outcomes {
+		if outcome.Result == result {
+			return outcome.Message
+		}
+	}
+	return ""
+}
+
+// podIsCrashLooping checks whether any container in the pod is waiting in CrashLoopBackOff
+func podIsCrashLooping(pod unstructured.Unstructured) bool {
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, s := range statuses {
+			status, ok := s.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			reason, _, _ := unstructured.NestedString(status, "state", "waiting", "reason")
+			if reason == "CrashLoopBackOff" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func analyzerIntParameter(analyzer AnalyzerSpec, key string, defaultValue int) int {
+	// Parameters loaded from JSON carry numbers as float64
+	switch value := analyzer.Parameters[key].(type) {
+	case int:
+		if value > 0 {
+			return value
+		}
+	case float64:
+		if value > 0 {
+			return int(value)
+		}
+	}
+	return defaultValue
+}
+
+func deploymentStatusAnalyzer(resource Resource) AnalyzerSpec {
+	return AnalyzerSpec{
+		Type:      AnalyzerTypeDeploymentStatus,
+		Name:      fmt.Sprintf("auto-deployment-status-%s-%s", resource.Namespace, resource.Name),
+		Namespace: resource.Namespace,
+		Target:    resource.Name,
+		Outcomes: []AnalyzerOutcome{
+			{Result: AnalyzerResultFail, When: "readyReplicas < 1", Message: fmt.Sprintf("Deployment %s has no ready replicas", resource.Name)},
+			{Result: AnalyzerResultWarn, When: "readyReplicas < replicas", Message: fmt.Sprintf("Deployment %s is not fully ready", resource.Name)},
+			{Result: AnalyzerResultPass, Message: fmt.Sprintf("Deployment %s has all replicas ready", resource.Name)},
+		},
+	}
+}
+
+func pvcStatusAnalyzer(resource Resource) AnalyzerSpec {
+	return AnalyzerSpec{
+		Type:      AnalyzerTypePVCStatus,
+		Name:      fmt.Sprintf("auto-pvc-status-%s-%s", resource.Namespace, resource.Name),
+		Namespace: resource.Namespace,
+		Target:    resource.Name,
+		Outcomes: []AnalyzerOutcome{
+			{Result: AnalyzerResultFail, When: "phase == Pending", Message: fmt.Sprintf("PersistentVolumeClaim %s is pending", resource.Name)},
+			{Result: AnalyzerResultWarn, When: "phase != Bound", Message: fmt.Sprintf("PersistentVolumeClaim %s is not bound", resource.Name)},
+			{Result: AnalyzerResultPass, Message: fmt.Sprintf("PersistentVolumeClaim %s is bound", resource.Name)},
+		},
+	}
+}
+
+func clusterPodStatusesAnalyzer(namespace string) AnalyzerSpec {
+	return AnalyzerSpec{
+		Type:      AnalyzerTypeClusterPodStatuses,
+		Name:      fmt.Sprintf("auto-crashloop-%s", namespace),
+		Namespace: namespace,
+		Parameters: map[string]interface{}{
+			"warnThreshold": DefaultCrashLoopWarnThreshold,
+			"failThreshold": DefaultCrashLoopFailThreshold,
+		},
+		Outcomes: []AnalyzerOutcome{
+			{Result: AnalyzerResultFail, When: fmt.Sprintf("crashLoopBackOff >= %d", DefaultCrashLoopFailThreshold), Message: fmt.Sprintf("Multiple pods in %s are in CrashLoopBackOff", namespace)},
+			{Result: AnalyzerResultWarn, When: fmt.Sprintf("crashLoopBackOff >= %d", DefaultCrashLoopWarnThreshold), Message: fmt.Sprintf("Pods in %s are in CrashLoopBackOff", namespace)},
+			{Result: AnalyzerResultPass, Message: fmt.Sprintf("No pods in %s are in CrashLoopBackOff", namespace)},
+		},
+	}
+}
+
+// customResourceConditionsAnalyzer checks the status conditions of every resource of one custom type in a
+// namespace, targeting the type by its CRD name, <resource>.<group>
+func customResourceConditionsAnalyzer(resource Resource) AnalyzerSpec {
+	crd := resource.GVR.Resource + "." + resource.GVR.Group
+	name := fmt.Sprintf("auto-cr-conditions-%s", crd)
+	scope := "the cluster"
+	if resource.Namespace != "" {
+		name = fmt.Sprintf("auto-cr-conditions-%s-%s", resource.Namespace, crd)
+		scope = resource.Namespace
+	}
+	return AnalyzerSpec{
+		Type:       AnalyzerTypeCustomResourceConditions,
+		Name:       name,
+		Namespace:  resource.Namespace,
+		Target:     crd,
+		Parameters: map[string]interface{}{"version": resource.GVR.Version},
+		Outcomes: []AnalyzerOutcome{
+			{Result: AnalyzerResultFail, When: "Ready-like condition False || Degraded-like condition True", Message: fmt.Sprintf("%s in %s report unhealthy conditions", crd, scope)},
+			{Result: AnalyzerResultWarn, When: "Ready-like or Degraded-like condition Unknown", Message: fmt.Sprintf("%s in %s report unknown conditions", crd, scope)},
+			{Result: AnalyzerResultPass, Message: fmt.Sprintf("%s in %s report healthy conditions", crd, scope)},
+		},
+	}
+}
+
+// isCustomResourceGroup reports whether group can belong to a CRD: custom groups contain a dot, and the dotted
+// built-in groups all end in k8s.io. A CRD in a k8s.io group needs API review approval, so those are skipped too
+func isCustomResourceGroup(group string) bool {
+	return strings.Contains(group, ".") && !strings.HasSuffix(group, ".k8s.io")
+}
+
+func nodeResourcesAnalyzer() AnalyzerSpec {
+	return AnalyzerSpec{
+		Type: AnalyzerTypeNodeResources,
+		Name: "auto-node-pressure",
+		Outcomes: []AnalyzerOutcome{
+			{Result: AnalyzerResultFail, When: "Ready != True", Message: "One or more nodes are not ready"},
+			{Result: AnalyzerResultWarn, When: "MemoryPressure || DiskPressure || PIDPressure", Message: "One or more nodes report resource pressure"},
+			{Result: AnalyzerResultPass, Message: "All nodes are ready without resource pressure"},
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package autodiscovery
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestAnalyzerGenerator_GenerateAnalyzers(t *testing.T) {
+	generator := NewAnalyzerGenerator(createTestDynamicClient())
+
+	resources := []Resource{
+		{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, Namespace: "app", Name: "web"},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}, Namespace: "app", Name: "data"},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}, Namespace: "app", Name: "web-1"},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}, Namespace: "app", Name: "web-2"},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}, Namespace: "default", Name: "debug"},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}, Namespace: "app", Name: "config"},
+	}
+
+	analyzers := generator.GenerateAnalyzers(resources)
+
+	expected := []string{
+		"auto-deployment-status-app-web",
+		"auto-pvc-status-app-data",
+		"auto-crashloop-app",
+		"auto-crashloop-default",
+		"auto-node-pressure",
+	}
+	if len(analyzers) != len(expected) {
+		t.Fatalf("Expected %d analyzers, got %d: %v", len(expected), len(analyzers), analyzers)
+	}
+	for i, analyzer := range analyzers {
+		if analyzer.Name != expected[i] {
+			t.Errorf("Expected analyzer %s, got %s", expected[i], analyzer.Name)
+		}
+		if len(analyzer.Outcomes) != 3 {
+			t.Errorf("Expected pass/warn/fail outcomes for %s, got %d", analyzer.Name, len(analyzer.Outcomes))
+		}
+	}
+
+	if len(generator.GenerateAnalyzers(nil)) != 0 {
+		t.Errorf("Expected no analyzers without discovered resources")
+	}
+}
+
+func TestAnalyzerGenerator_RunAnalyzers(t *testing.T) {
+	client := createTestDynamicClient(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "app"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "degraded", Namespace: "app"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "down", Namespace: "app"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "app"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "crashing", Namespace: "app"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "main", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+					{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+	)
+	generator := NewAnalyzerGenerator(client)
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	analyzers := generator.GenerateAnalyzers([]Resource{
+		{GVR: deployments, Namespace: "app", Name: "ready"},
+		{GVR: deployments, Namespace: "app", Name: "degraded"},
+		{GVR: deployments, Namespace: "app", Name: "down"},
+		{GVR: deployments, Namespace: "app", Name: "missing"},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}, Namespace: "app", Name: "pending"},
+		{GVR: podsGVR, Namespace: "app", Name: "crashing"},
+	})
+
+	results := generator.RunAnalyzers(context.Background(), analyzers)
+
+	expected := map[string]string{
+		"auto-deployment-status-app-ready":    AnalyzerResultPass,
+		"auto-deployment-status-app-degraded": AnalyzerResultWarn,
+		"auto-deployment-status-app-down":     AnalyzerResultFail,
+		"auto-deployment-status-app-missing":  AnalyzerResultWarn,
+		"auto-pvc-status-app-pending":         AnalyzerResultFail,
+		"auto-crashloop-app":                  AnalyzerResultWarn,
+		"auto-node-pressure":                  AnalyzerResultWarn,
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for _, result := range results {
+		if result.Result != expected[result.AnalyzerName] {
+			t.Errorf("Expected %s to %s, got %s (%s)", result.AnalyzerName, expected[result.AnalyzerName], result.Result, result.Detail)
+		}
+		if result.Message == "" {
+			t.Errorf("Expected a message for %s", result.AnalyzerName)
+		}
+	}
+
+	// Analyzers whose target can't be read carry the error
+	for _, result := range results {
+		if result.AnalyzerName == "auto-deployment-status-app-missing" && result.Error == "" {
+			t.Errorf("Expected an error for the missing deployment")
+		}
+	}
+}
+
+func TestAnalyzerGenerator_CrashLoopThresholds(t *testing.T) {
+	var objects []runtime.Object
+	for _, name := range []string{"a", "b", "c"} {
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app"},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "init", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				},
+			},
+		})
+	}
+	generator := NewAnalyzerGenerator(createTestDynamicClient(objects...))
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected string
+	}{
+		{"default thresholds", nil, AnalyzerResultFail},
+		{"raised fail threshold", map[string]interface{}{"failThreshold": 5}, AnalyzerResultWarn},
+		{"raised warn threshold", map[string]interface{}{"warnThreshold": 4, "failThreshold": 5}, AnalyzerResultPass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := clusterPodStatusesAnalyzer("app")
+			analyzer.Parameters = tt.params
+
+			results := generator.RunAnalyzers(context.Background(), []AnalyzerSpec{analyzer})
+			if results[0].Result != tt.expected {
+				t.Errorf("Expected %s, got %s (%s)", tt.expected, results[0].Result, results[0].Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package autodiscovery
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// APIUsageFileName is the bundle file accounting for every API request issued during the run
+const APIUsageFileName = "api-usage.json"
+
+// Phases API requests are accounted to, see APIUsageRecorder.SetPhase
+const (
+	APIUsagePhaseDiscovery  = "discovery"
+	APIUsagePhaseCollection = "collection"
+)
+
+// APIUsageEntry aggregates the requests of one phase with the same verb, resource and namespace
+type APIUsageEntry struct {
+	Phase         string        `json:"phase"`
+	Verb          string        `json:"verb"` // get, list, watch, create, update, patch, delete or deletecollection
+	Group         string        `json:"group,omitempty"`
+	Version       string        `json:"version,omitempty"`
+	Resource      string        `json:"resource,omitempty"`
+	Subresource   string        `json:"subresource,omitempty"` // e.g. log
+	Namespace     string        `json:"namespace,omitempty"`
+	Path          string        `json:"path,omitempty"` // Requests that do not name a resource, e.g. /apis/apps/v1 discovery
+	Requests      int           `json:"requests"`
+	Errors        int           `json:"errors"`        // Transport errors and responses with a 4xx or 5xx status
+	TotalDuration time.Duration `json:"totalDuration"` // Time to response headers, summed
+	MaxDuration   time.Duration `json:"maxDuration"`
+}
+
+// APIUsageSummary totals the requests of a run
+type APIUsageSummary struct {
+	Requests      int            `json:"requests"`
+	Errors        int            `json:"errors"`
+	TotalDuration time.Duration  `json:"totalDuration"`
+	ByVerb        map[string]int `json:"byVerb"`
+	ByPhase       map[string]int `json:"byPhase"`
+}
+
+// APIUsageReport lists the API requests of a run, the most frequent first
+type APIUsageReport struct {
+	Summary APIUsageSummary `json:"summary"`
+	Calls   []APIUsageEntry `json:"calls"`
+}
+
+// APIUsageRecorder accounts for every request sent through the transports it wraps
+type APIUsageRecorder struct {
+	mu      sync.Mutex
+	phase   string
+	entries map[APIUsageEntry]*APIUsageEntry // Keyed by the entry without its counters
+}
+
+// NewAPIUsageRecorder creates an APIUsageRecorder accounting requests to the discovery phase
+func NewAPIUsageRecorder() *APIUsageRecorder {
+	return &APIUsageRecorder{
+		phase:   APIUsagePhaseDiscovery,
+		entries: make(map[APIUsageEntry]*APIUsageEntry),
+	}
+}
+
+// Install wraps the config's transport so every client built from it is accounted for
+func (r *APIUsageRecorder) Install(config *rest.Config) {
+	config.Wrap(r.WrapTransport)
+}
+
+// WrapTransport returns a RoundTripper that records the verb, resource, namespace and duration of each request
+func (r *APIUsageRecorder) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return &apiUsageTransport{next: next, recorder: r}
+}
+
+// SetPhase accounts the requests that follow to phase, e.g. APIUsagePhaseCollection
+func (r *APIUsageRecorder) SetPhase(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = phase
+}
+
+// Record accounts for one request, failed is true for transport errors and 4xx or 5xx responses
+func (r *APIUsageRecorder) Record(method string, u *url.URL, duration time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := apiUsageKey(method, u)
+	key.Phase = r.phase
+	entry, exists := r.entries[key]
+	if !exists {
+		entry = &APIUsageEntry{}
+		*entry = key
+		r.entries[key] = entry
+	}
+	entry.Requests++
+	if failed {
+		entry.Errors++
+	}
+	entry.TotalDuration += duration
+	if duration > entry.MaxDuration {
+		entry.MaxDuration = duration
+	}
+}
+
+// Report returns the requests recorded so far, sorted by request count, then phase, verb, resource and namespace
+func (r *APIUsageRecorder) Report() *APIUsageReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &APIUsageReport{
+		Summary: APIUsageSummary{ByVerb: map[string]int{}, ByPhase: map[string]int{}},
+		Calls:   []APIUsageEntry{},
+	}
+	for _, entry := range r.entries {
+		report.Calls = append(report.Calls, *entry)
+		report.Summary.Requests += entry.Requests
+		report.Summary.Errors += entry.Errors
+		report.Summary.TotalDuration += entry.TotalDuration
+		report.Summary.ByVerb[entry.Verb] += entry.Requests
+		report.Summary.ByPhase[entry.Phase] += entry.Requests
+	}
+	sort.Slice(report.Calls, func(i, j int) bool {
+		a, b := report.Calls[i], report.Calls[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return apiUsageSortKey(a) < apiUsageSortKey(b)
+	})
+	return report
+}
+
+// String describes the requests of an entry, e.g. "list apps/v1 deployments in default"
+func (e APIUsageEntry) String() string {
+	if e.Resource == "" {
+		return e.Verb + " " + e.Path
+	}
+	target := schema.GroupVersion{Group: e.Group, Version: e.Version}.String() + " " + e.Resource
+	if e.Subresource != "" {
+		target += "/" + e.Subresource
+	}
+	if e.Namespace != "" {
+		target += " in " + e.Namespace
+	}
+	return e.Verb + " " + target
+}
+
+func apiUsageSortKey(e APIUsageEntry) string {
+	return strings.Join([]string{e.Phase, e.Verb, e.Group, e.Version, e.Resource, e.Subresource, e.Namespace, e.Path}, "|")
+}
+
+// apiUsageKey describes a request without its counters, requests to the same resource type and namespace share a key
+func apiUsageKey(method string, u *url.URL) APIUsageEntry {
+	request, ok := parseAPIRequestPath(u.Path)
+	if !ok {
+		return APIUsageEntry{Verb: strings.ToLower(method), Path: u.Path}
+	}
+
+	entry := APIUsageEntry{
+		Group:       request.gvr.Group,
+		Version:     request.gvr.Version,
+		Resource:    request.gvr.Resource,
+		Subresource: request.subresource,
+		Namespace:   request.namespace,
+	}
+	switch method {
+	case http.MethodGet:
+		switch {
+		case request.watch || u.Query().Get("watch") == "true" || u.Query().Get("watch") == "1":
+			entry.Verb = "watch"
+		case request.name != "":
+			entry.Verb = "get"
+		default:
+			entry.Verb = "list"
+		}
+	case http.MethodPost:
+		entry.Verb = "create"
+	case http.MethodPut:
+		entry.Verb = "update"
+	case http.MethodPatch:
+		entry.Verb = "patch"
+	case http.MethodDelete:
+		entry.Verb = "delete"
+		if request.name == "" {
+			entry.Verb = "deletecollection"
+		}
+	default:
+		entry.Verb = strings.ToLower(method)
+	}
+	return entry
+}
+
+// apiRequestPath is a request path naming a resource, e.g. /apis/apps/v1/namespaces/default/deployments/web/scale
+type apiRequestPath struct {
+	gvr         schema.GroupVersionResource
+	namespace   string
+	name        string
+	subresource string
+	watch       bool // The legacy /watch/ path prefix
+}
+
+// parseAPIRequestPath parses a request path naming a resource, prefixes before /api or /apis, e.g. from a proxied
+// cluster URL, are ignored. Paths that do not name a resource, e.g. discovery, are not parsed
+func parseAPIRequestPath(path string) (apiRequestPath, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		var request apiRequestPath
+		var remaining []string
+		switch {
+		case segment == "api" && len(segments) > i+2:
+			request.gvr.Version = segments[i+1]
+			remaining = segments[i+2:]
+		case segment == "apis" && len(segments) > i+3:
+			request.gvr.Group, request.gvr.Version = segments[i+1], segments[i+2]
+			remaining = segments[i+3:]
+		default:
+			continue
+		}
+
+		if remaining[0] == "watch" && len(remaining) > 1 {
+			request.watch = true
+			remaining = remaining[1:]
+		}
+		// namespaces/<namespace>/<resource> is a namespaced resource, namespaces and namespaces/<name> are not
+		if remaining[0] == "namespaces" && len(remaining) > 2 {
+			request.namespace = remaining[1]
+			remaining = remaining[2:]
+		}
+		request.gvr.Resource = remaining[0]
+		if len(remaining) > 1 {
+			request.name = remaining[1]
+		}
+		if len(remaining) > 2 {
+			request.subresource = remaining[2]
+		}
+		return request, request.gvr.Resource != ""
+	}
+	return apiRequestPath{}, false
+}
+
+// apiUsageTransport records every request sent through it
+type apiUsageTransport struct {
+	next     http.RoundTripper
+	recorder *APIUsageRecorder
+}
+
+func (at *apiUsageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := at.next.RoundTrip(req)
+	at.recorder.Record(req.Method, req.URL, time.Since(start), err != nil || resp.StatusCode >= http.StatusBadRequest)
+	return resp, err
+}
@@ -0,0 +1,101 @@
+package autodiscovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAPIUsageKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		expected APIUsageEntry
+	}{
+		{name: "list", method: "GET", url: "/api/v1/namespaces/default/pods?limit=500", expected: APIUsageEntry{Verb: "list", Version: "v1", Resource: "pods", Namespace: "default"}},
+		{name: "list all namespaces", method: "GET", url: "/apis/apps/v1/deployments", expected: APIUsageEntry{Verb: "list", Group: "apps", Version: "v1", Resource: "deployments"}},
+		{name: "get", method: "GET", url: "/apis/apps/v1/namespaces/default/deployments/web", expected: APIUsageEntry{Verb: "get", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default"}},
+		{name: "subresource", method: "GET", url: "/api/v1/namespaces/default/pods/web-0/log", expected: APIUsageEntry{Verb: "get", Version: "v1", Resource: "pods", Subresource: "log", Namespace: "default"}},
+		{name: "namespace", method: "GET", url: "/api/v1/namespaces/default", expected: APIUsageEntry{Verb: "get", Version: "v1", Resource: "namespaces"}},
+		{name: "watch parameter", method: "GET", url: "/api/v1/pods?watch=true", expected: APIUsageEntry{Verb: "watch", Version: "v1", Resource: "pods"}},
+		{name: "watch path", method: "GET", url: "/api/v1/watch/namespaces/default/pods", expected: APIUsageEntry{Verb: "watch", Version: "v1", Resource: "pods", Namespace: "default"}},
+		{name: "create", method: "POST", url: "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", expected: APIUsageEntry{Verb: "create", Group: "authorization.k8s.io", Version: "v1", Resource: "selfsubjectaccessreviews"}},
+		{name: "delete", method: "DELETE", url: "/api/v1/namespaces/default/pods/debug", expected: APIUsageEntry{Verb: "delete", Version: "v1", Resource: "pods", Namespace: "default"}},
+		{name: "discovery", method: "GET", url: "/apis/apps/v1", expected: APIUsageEntry{Verb: "get", Path: "/apis/apps/v1"}},
+		{name: "version", method: "GET", url: "/version", expected: APIUsageEntry{Verb: "get", Path: "/version"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("Invalid URL: %v", err)
+			}
+			if key := apiUsageKey(tt.method, u); key != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, key)
+			}
+		})
+	}
+}
+
+func TestAPIUsageRecorder_Report(t *testing.T) {
+	recorder := NewAPIUsageRecorder()
+	pods, _ := url.Parse("/api/v1/namespaces/default/pods")
+	version, _ := url.Parse("/version")
+
+	recorder.Record("GET", pods, 10*time.Millisecond, false)
+	recorder.Record("GET", pods, 30*time.Millisecond, true)
+	recorder.Record("GET", version, 5*time.Millisecond, false)
+	recorder.SetPhase(APIUsagePhaseCollection)
+	recorder.Record("GET", pods, 20*time.Millisecond, false)
+
+	report := recorder.Report()
+	if report.Summary.Requests != 4 || report.Summary.Errors != 1 || report.Summary.TotalDuration != 65*time.Millisecond {
+		t.Errorf("Expected 4 requests, 1 error and 65ms, got %+v", report.Summary)
+	}
+	if report.Summary.ByPhase[APIUsagePhaseDiscovery] != 3 || report.Summary.ByPhase[APIUsagePhaseCollection] != 1 {
+		t.Errorf("Expected 3 discovery and 1 collection requests, got %v", report.Summary.ByPhase)
+	}
+	if report.Summary.ByVerb["list"] != 3 || report.Summary.ByVerb["get"] != 1 {
+		t.Errorf("Expected 3 lists and 1 get, got %v", report.Summary.ByVerb)
+	}
+	if len(report.Calls) != 3 {
+		t.Fatalf("Expected 3 request groups, got %d: %+v", len(report.Calls), report.Calls)
+	}
+
+	first := report.Calls[0]
+	if first.Phase != APIUsagePhaseDiscovery || first.Requests != 2 || first.Errors != 1 || first.MaxDuration != 30*time.Millisecond {
+		t.Errorf("Expected the discovery pod lists first, got %+v", first)
+	}
+	if first.String() != "list v1 pods in default" {
+		t.Errorf("Expected %q, got %q", "list v1 pods in default", first.String())
+	}
+}
+
+func TestAPIUsageRecorder_WrapTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/namespaces/default/secrets/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	recorder := NewAPIUsageRecorder()
+	client := &http.Client{Transport: recorder.WrapTransport(http.DefaultTransport)}
+	for _, path := range []string{"/api/v1/namespaces/default/secrets/missing", "/api/v1/namespaces/default/secrets"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	report := recorder.Report()
+	if report.Summary.Requests != 2 || report.Summary.Errors != 1 {
+		t.Errorf("Expected 2 requests and 1 error, got %+v", report.Summary)
+	}
+}
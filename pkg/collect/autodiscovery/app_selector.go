@@ -0,0 +1,43 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Recommended Kubernetes labels used to select the resources of an application
+const (
+	LabelAppName   = "app.kubernetes.io/name"
+	LabelAppPartOf = "app.kubernetes.io/part-of"
+)
+
+// ResourceBelongsToApps reports whether a resource carries an app.kubernetes.io/name or
+// app.kubernetes.io/part-of label with one of the given values
+func ResourceBelongsToApps(resource Resource, apps []string) bool {
+	for _, app := range apps {
+		if resource.Labels[LabelAppName] == app || resource.Labels[LabelAppPartOf] == app {
+			return true
+		}
+	}
+	return false
+}
+
+// selectAppResources keeps the resources belonging to opts.Apps, which seed discovery
+// Dependencies of the selected resources are added later by the dependency resolver
+func selectAppResources(resources []Resource, opts DiscoveryOptions) ([]Resource, error) {
+	if len(opts.Apps) == 0 {
+		return resources, nil
+	}
+
+	var selected []Resource
+	for _, resource := range resources {
+		if ResourceBelongsToApps(resource, opts.Apps) {
+			selected = append(selected, resource)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no resources labeled %s or %s with value %s", LabelAppName, LabelAppPartOf, strings.Join(opts.Apps, ", "))
+	}
+	return selected, nil
+}
@@ -0,0 +1,96 @@
+package autodiscovery
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResourceBelongsToApps(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected bool
+	}{
+		{"name label", map[string]string{LabelAppName: "checkout"}, true},
+		{"part-of label", map[string]string{LabelAppName: "cart-db", LabelAppPartOf: "checkout"}, true},
+		{"other app", map[string]string{LabelAppName: "payments"}, false},
+		{"plain app label", map[string]string{"app": "checkout"}, false},
+		{"no labels", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := Resource{GVR: podsGVR, Namespace: "shop", Name: "pod", Labels: tt.labels}
+			if result := ResourceBelongsToApps(resource, []string{"checkout"}); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSelectAppResources(t *testing.T) {
+	resources := []Resource{
+		{GVR: podsGVR, Namespace: "shop", Name: "checkout-1", Labels: map[string]string{LabelAppName: "checkout"}},
+		{GVR: podsGVR, Namespace: "data", Name: "cart-db-0", Labels: map[string]string{LabelAppPartOf: "checkout"}},
+		{GVR: podsGVR, Namespace: "shop", Name: "payments-1", Labels: map[string]string{LabelAppName: "payments"}},
+	}
+
+	selected, err := selectAppResources(resources, DiscoveryOptions{Apps: []string{"checkout"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(selected) != 2 {
+		t.Errorf("Expected 2 resources across namespaces, got %d", len(selected))
+	}
+
+	if all, _ := selectAppResources(resources, DiscoveryOptions{}); len(all) != len(resources) {
+		t.Errorf("Expected all resources without apps, got %d", len(all))
+	}
+
+	if _, err := selectAppResources(resources, DiscoveryOptions{Apps: []string{"missing"}}); err == nil {
+		t.Errorf("Expected an error when no resources belong to the app")
+	}
+}
+
+func TestDiscoverer_DiscoverApps(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "data"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+	)
+	dynamicClient := createTestDynamicClient(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkout-1", Namespace: "shop", Labels: map[string]string{LabelAppName: "checkout"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cart-db-0", Namespace: "data", Labels: map[string]string{LabelAppPartOf: "checkout"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "payments-1", Namespace: "payments", Labels: map[string]string{LabelAppName: "payments"}}},
+	)
+
+	discoverer := &Discoverer{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		rbacChecker:   NewRBACChecker(kubeClient),
+		nsScanner:     NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:      NewResourceExpander(),
+	}
+
+	collectors, err := discoverer.Discover(context.Background(), DiscoveryOptions{Apps: []string{"checkout"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	namespaces := make(map[string]bool)
+	for _, collector := range collectors {
+		if collector.Namespace != "" {
+			namespaces[collector.Namespace] = true
+		}
+	}
+	if !namespaces["shop"] || !namespaces["data"] {
+		t.Errorf("Expected collectors in shop and data, got %v", namespaces)
+	}
+	if namespaces["payments"] {
+		t.Errorf("Expected no collectors for other apps, got %v", namespaces)
+	}
+}
@@ -0,0 +1,203 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClusterScopeAll in ClusterScope.Resources includes every type in KnownClusterScopedResources
+const ClusterScopeAll = "*"
+
+// KnownClusterScopedResources are the cluster-scoped types ClusterScope.Resources can name by resource, or by
+// resource.group; other types are named group/version/resource, e.g. cert-manager.io/v1/clusterissuers
+var KnownClusterScopedResources = []schema.GroupVersionResource{
+	{Version: "v1", Resource: "nodes"},
+	{Version: "v1", Resource: "persistentvolumes"},
+	{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"},
+	{Group: "storage.k8s.io", Version: "v1", Resource: "csidrivers"},
+	{Group: "storage.k8s.io", Version: "v1", Resource: "csinodes"},
+	{Group: "storage.k8s.io", Version: "v1", Resource: "volumeattachments"},
+	{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+	{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"},
+	{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"},
+	{Group: "node.k8s.io", Version: "v1", Resource: "runtimeclasses"},
+}
+
+// ClusterScope opts cluster-scoped resource types in to collection. Without it discovery reads namespaced
+// resources, plus the nodes, webhooks and CRDs its own collectors need, whatever the namespace scope
+type ClusterScope struct {
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"` // e.g. persistentvolumes, storageclasses.storage.k8s.io or "*"
+}
+
+// ClusterScopeReport records the cluster-scoped types collected and those left out after the RBAC check
+type ClusterScopeReport struct {
+	Included []schema.GroupVersionResource `json:"included"`
+	Denied   []ClusterScopeDenial          `json:"denied,omitempty"`
+}
+
+// ClusterScopeDenial is a cluster-scoped type requested by ClusterScope that is not collected
+type ClusterScopeDenial struct {
+	GVR    schema.GroupVersionResource `json:"gvr"`
+	Reason string                      `json:"reason"`
+}
+
+// WithOverrides returns the scope with every set override applied, overriding resources replace the base ones
+func (s ClusterScope) WithOverrides(overrides ClusterScope) ClusterScope {
+	if len(overrides.Resources) > 0 {
+		s.Resources = overrides.Resources
+	}
+	return s
+}
+
+// Validate checks that every resource names a known cluster-scoped type or a group/version/resource
+func (s ClusterScope) Validate() error {
+	_, err := s.GVRs()
+	return err
+}
+
+// GVRs resolves the resources to GVRs, without duplicates, in the order they are listed
+func (s ClusterScope) GVRs() ([]schema.GroupVersionResource, error) {
+	var gvrs []schema.GroupVersionResource
+	seen := make(map[schema.GroupVersionResource]bool)
+	add := func(gvr schema.GroupVersionResource) {
+		if !seen[gvr] {
+			seen[gvr] = true
+			gvrs = append(gvrs, gvr)
+		}
+	}
+
+	for _, name := range s.Resources {
+		if name == ClusterScopeAll {
+			for _, gvr := range KnownClusterScopedResources {
+				add(gvr)
+			}
+			continue
+		}
+		gvr, err := ParseClusterScopedResource(name)
+		if err != nil {
+			return nil, err
+		}
+		add(gvr)
+	}
+	return gvrs, nil
+}
+
+// ParseClusterScopedResource resolves a known type, e.g. nodes or storageclasses.storage.k8s.io, or parses a
+// version/resource or group/version/resource for other types
+func ParseClusterScopedResource(name string) (schema.GroupVersionResource, error) {
+	if name == "" {
+		return schema.GroupVersionResource{}, fmt.Errorf("cluster-scoped resource cannot be empty")
+	}
+
+	if strings.Contains(name, "/") {
+		parts := strings.Split(name, "/")
+		for _, part := range parts {
+			if part == "" {
+				return schema.GroupVersionResource{}, fmt.Errorf("invalid cluster-scoped resource %q: empty segment", name)
+			}
+		}
+		switch len(parts) {
+		case 2:
+			return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+		case 3:
+			return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+		}
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid cluster-scoped resource %q: expected group/version/resource", name)
+	}
+
+	resource, group, _ := strings.Cut(name, ".")
+	for _, gvr := range KnownClusterScopedResources {
+		if gvr.Resource == resource && (group == "" || gvr.Group == group) {
+			return gvr, nil
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("unknown cluster-scoped resource %q, name it as group/version/resource", name)
+}
+
+// generateClusterScopeCollectors adds a cluster-resources collector for each type in scope the user may list
+// Types of unavailable aggregated APIs and types already collected whole are skipped
+func (d *Discoverer) generateClusterScopeCollectors(ctx context.Context, collectors []CollectorSpec, scope ClusterScope) []CollectorSpec {
+	d.clusterScope = nil
+	gvrs, err := scope.GVRs()
+	if err != nil || len(gvrs) == 0 {
+		return nil // Rejected when the config is loaded
+	}
+
+	collected := make(map[schema.GroupVersionResource]bool)
+	for _, collector := range collectors {
+		if collector.Type != CollectorTypeClusterResources {
+			continue
+		}
+		if params, err := collector.ClusterResourcesParams(); err == nil && len(params.Namespaces) == 0 {
+			collected[schema.GroupVersionResource{Group: params.Group, Version: params.Version, Resource: params.Resource}] = true
+		}
+	}
+
+	report := &ClusterScopeReport{Included: []schema.GroupVersionResource{}}
+	unavailable := unavailableGroupVersions(d.unavailableAPIs)
+	var generated []CollectorSpec
+	for _, gvr := range gvrs {
+		if apiService, down := unavailable[gvr.GroupVersion()]; down {
+			report.Denied = append(report.Denied, ClusterScopeDenial{GVR: gvr, Reason: fmt.Sprintf("aggregated API %s is unavailable", apiService.Name)})
+			continue
+		}
+		allowed, err := d.rbacChecker.CheckVerbAccess(ctx, gvr, "", "", "list")
+		switch {
+		case err != nil:
+			report.Denied = append(report.Denied, ClusterScopeDenial{GVR: gvr, Reason: err.Error()})
+			continue
+		case !allowed:
+			report.Denied = append(report.Denied, ClusterScopeDenial{GVR: gvr, Reason: "list is forbidden"})
+			continue
+		}
+
+		report.Included = append(report.Included, gvr)
+		if collected[gvr] {
+			continue
+		}
+		generated = append(generated, clusterScopeCollector(gvr))
+	}
+
+	for _, denial := range report.Denied {
+		fmt.Printf("Warning: skipping cluster-scoped %s: %s\n", formatGVR(denial.GVR), denial.Reason)
+	}
+	d.clusterScope = report
+	return generated
+}
+
+// clusterScopeCollector collects every object of a cluster-scoped type
+func clusterScopeCollector(gvr schema.GroupVersionResource) CollectorSpec {
+	name := "auto-cluster-scope-" + gvr.Resource
+	if gvr.Group != "" {
+		name += "-" + strings.ReplaceAll(gvr.Group, ".", "-")
+	}
+
+	group := CollectorGroupClusterInfo
+	if storageResources[gvr.Resource] {
+		group = CollectorGroupStorage
+	}
+
+	return CollectorSpec{
+		Type:     CollectorTypeClusterResources,
+		Name:     name,
+		Group:    group,
+		Priority: int(PriorityNormal),
+		Parameters: ClusterResourcesParams{
+			Group:    gvr.Group,
+			Version:  gvr.Version,
+			Resource: gvr.Resource,
+		}.ToMap(),
+	}
+}
+
+// String summarizes the report, e.g. "3 cluster-scoped types included, 1 denied"
+func (r *ClusterScopeReport) String() string {
+	return fmt.Sprintf("%d cluster-scoped types included, %d denied", len(r.Included), len(r.Denied))
+}
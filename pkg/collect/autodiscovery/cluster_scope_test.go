@@ -0,0 +1,116 @@
+package autodiscovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestParseClusterScopedResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected schema.GroupVersionResource
+		wantErr  bool
+	}{
+		{name: "nodes", expected: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}},
+		{name: "storageclasses", expected: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}},
+		{name: "clusterroles.rbac.authorization.k8s.io", expected: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}},
+		{name: "cert-manager.io/v1/clusterissuers", expected: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}},
+		{name: "v1/persistentvolumes", expected: schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}},
+		{name: "storageclasses.example.com", wantErr: true},
+		{name: "clusterissuers", wantErr: true},
+		{name: "cert-manager.io//clusterissuers", wantErr: true},
+		{name: "a/b/c/d", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gvr, err := ParseClusterScopedResource(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if gvr != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, gvr)
+			}
+		})
+	}
+}
+
+func TestClusterScope_GVRs(t *testing.T) {
+	gvrs, err := ClusterScope{Resources: []string{"nodes", "*", "cert-manager.io/v1/clusterissuers"}}.GVRs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(gvrs) != len(KnownClusterScopedResources)+1 {
+		t.Errorf("Expected every known type once plus clusterissuers, got %v", gvrs)
+	}
+	if gvrs[0].Resource != "nodes" || gvrs[len(gvrs)-1].Resource != "clusterissuers" {
+		t.Errorf("Expected the listed order to be kept, got %v", gvrs)
+	}
+
+	if err := (ClusterScope{Resources: []string{"widgets"}}).Validate(); err == nil {
+		t.Errorf("Expected an unknown type without a group and version to be rejected")
+	}
+}
+
+func TestMergeDiscoveryOptions_ClusterScope(t *testing.T) {
+	base := DiscoveryOptions{ClusterScope: ClusterScope{Resources: []string{"nodes"}}}
+
+	merged := MergeDiscoveryOptions(base, DiscoveryOptions{})
+	if !reflect.DeepEqual(merged.ClusterScope.Resources, []string{"nodes"}) {
+		t.Errorf("Expected the base cluster scope to be kept, got %v", merged.ClusterScope.Resources)
+	}
+
+	merged = MergeDiscoveryOptions(base, DiscoveryOptions{ClusterScope: ClusterScope{Resources: []string{"persistentvolumes"}}})
+	if !reflect.DeepEqual(merged.ClusterScope.Resources, []string{"persistentvolumes"}) {
+		t.Errorf("Expected the override to replace the cluster scope, got %v", merged.ClusterScope.Resources)
+	}
+}
+
+func TestDiscoverer_GenerateClusterScopeCollectors(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		req := action.(ktesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview)
+		allowed := req.Spec.ResourceAttributes.Resource != "clusterroles"
+		return true, &authv1.SelfSubjectAccessReview{Status: authv1.SubjectAccessReviewStatus{Allowed: allowed}}, nil
+	})
+
+	discoverer := &Discoverer{
+		kubeClient:  kubeClient,
+		rbacChecker: NewRBACChecker(kubeClient),
+		unavailableAPIs: []UnavailableAPIService{
+			{Name: "v1.cert-manager.io", Group: "cert-manager.io", Version: "v1"},
+		},
+	}
+	existing := []CollectorSpec{
+		{Type: CollectorTypeClusterResources, Name: "auto-cluster-info-nodes", Parameters: ClusterResourcesParams{Version: "v1", Resource: "nodes"}.ToMap()},
+	}
+	scope := ClusterScope{Resources: []string{"nodes", "persistentvolumes", "clusterroles", "cert-manager.io/v1/clusterissuers"}}
+
+	collectors := discoverer.generateClusterScopeCollectors(context.Background(), existing, scope)
+	if len(collectors) != 1 || collectors[0].Name != "auto-cluster-scope-persistentvolumes" || collectors[0].Group != CollectorGroupStorage {
+		t.Fatalf("Expected only a storage collector for persistentvolumes, got %+v", collectors)
+	}
+
+	report := discoverer.ClusterScopeReport()
+	if report == nil || len(report.Included) != 2 || len(report.Denied) != 2 {
+		t.Fatalf("Expected nodes and persistentvolumes included, 2 denied, got %+v", report)
+	}
+	if report.Denied[0].GVR.Resource != "clusterroles" || report.Denied[0].Reason != "list is forbidden" {
+		t.Errorf("Expected clusterroles to be forbidden, got %+v", report.Denied[0])
+	}
+	if report.Denied[1].GVR.Resource != "clusterissuers" {
+		t.Errorf("Expected clusterissuers to be skipped with its API down, got %+v", report.Denied[1])
+	}
+
+	if collectors := discoverer.generateClusterScopeCollectors(context.Background(), nil, ClusterScope{}); collectors != nil || discoverer.ClusterScopeReport() != nil {
+		t.Errorf("Expected no collectors and no report without a cluster scope, got %v", collectors)
+	}
+}
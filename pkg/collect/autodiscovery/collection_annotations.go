@@ -0,0 +1,402 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotations application owners set on namespaces and workloads to control collection without touching the
+// central config. A workload's annotations apply to the pods it owns and take precedence over the namespace's
+const (
+	AnnotationExclude  = "troubleshoot.sh/exclude"   // "true" skips the namespace or workload, "false" opts a workload in an excluded namespace back in
+	AnnotationLogLines = "troubleshoot.sh/log-lines" // Log lines collected per container, e.g. "200"
+)
+
+// collectionAnnotationPrefix is the prefix of the annotations kept on scanned resources
+const collectionAnnotationPrefix = "troubleshoot.sh/"
+
+// maxOwnerDepth bounds the owner chain followed to inherit annotations, e.g. pod -> ReplicaSet -> Deployment
+const maxOwnerDepth = 4
+
+// AnnotationReport records what collection annotations changed in the last discovery
+type AnnotationReport struct {
+	ExcludedNamespaces []string       `json:"excludedNamespaces,omitempty"`
+	ExcludedResources  []string       `json:"excludedResources,omitempty"` // namespace/resource/name of workloads and pods opted out
+	OptedIn            []string       `json:"optedIn,omitempty"`           // Resources annotated exclude: "false" in an excluded namespace
+	Overridden         []string       `json:"overridden,omitempty"`        // Excluded namespaces collected because they were requested explicitly
+	LogLines           map[string]int `json:"logLines,omitempty"`          // Log lines by namespace, or namespace/pod for pods and their workloads
+	Warnings           []string       `json:"warnings,omitempty"`          // Annotations with invalid values, which are ignored
+}
+
+// IsEmpty reports whether no annotation changed the collection
+func (r *AnnotationReport) IsEmpty() bool {
+	return r == nil || (len(r.ExcludedNamespaces) == 0 && len(r.ExcludedResources) == 0 && len(r.OptedIn) == 0 &&
+		len(r.Overridden) == 0 && len(r.LogLines) == 0 && len(r.Warnings) == 0)
+}
+
+// collectionAnnotations keeps the troubleshoot.sh/ annotations of an object, nil when it has none
+func collectionAnnotations(annotations map[string]string) map[string]string {
+	var kept map[string]string
+	for key, value := range annotations {
+		if !strings.HasPrefix(key, collectionAnnotationPrefix) {
+			continue
+		}
+		if kept == nil {
+			kept = make(map[string]string)
+		}
+		kept[key] = value
+	}
+	return kept
+}
+
+// annotationSettings are the parsed collection annotations of one object, unset fields are inherited
+type annotationSettings struct {
+	exclude  *bool
+	logLines int
+}
+
+// inherit fills the fields not set on s from parent
+func (s annotationSettings) inherit(parent annotationSettings) annotationSettings {
+	if s.exclude == nil {
+		s.exclude = parent.exclude
+	}
+	if s.logLines == 0 {
+		s.logLines = parent.logLines
+	}
+	return s
+}
+
+// parseAnnotationSettings parses the collection annotations of the object described by target, invalid values
+// are reported as warnings and ignored
+func parseAnnotationSettings(target string, annotations map[string]string, report *AnnotationReport) annotationSettings {
+	var settings annotationSettings
+	if value, ok := annotations[AnnotationExclude]; ok {
+		exclude, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("invalid %s %q on %s, expected true or false", AnnotationExclude, value, target))
+		} else {
+			settings.exclude = &exclude
+		}
+	}
+	if value, ok := annotations[AnnotationLogLines]; ok {
+		lines, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || lines <= 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("invalid %s %q on %s, expected a positive number", AnnotationLogLines, value, target))
+		} else {
+			settings.logLines = lines
+		}
+	}
+	return settings
+}
+
+// annotationScope holds the collection annotations found while scanning, applied again to the generated collectors
+type annotationScope struct {
+	report            *AnnotationReport
+	namespaceLogLines map[string]int
+	podLogLines       map[string]int      // namespace/pod -> lines, from the pod or its workloads
+	splitNamespaces   map[string][]string // Namespaces whose logs are collected pod by pod, with the pods kept
+}
+
+// applyCollectionAnnotations drops the resources opted out by annotations on them, their owners or their namespace,
+// and records the log line settings to apply to the generated collectors
+// Namespaces requested explicitly in opts.Namespaces are collected even when annotated as excluded
+func applyCollectionAnnotations(resources []Resource, namespaceAnnotations map[string]map[string]string, opts DiscoveryOptions) ([]Resource, *annotationScope) {
+	scope := &annotationScope{
+		report:            &AnnotationReport{},
+		namespaceLogLines: make(map[string]int),
+		podLogLines:       make(map[string]int),
+		splitNamespaces:   make(map[string][]string),
+	}
+
+	requested := make(map[string]bool, len(opts.Namespaces))
+	for _, namespace := range opts.Namespaces {
+		requested[namespace] = true
+	}
+
+	namespaceNames := make([]string, 0, len(namespaceAnnotations))
+	for namespace := range namespaceAnnotations {
+		namespaceNames = append(namespaceNames, namespace)
+	}
+	sort.Strings(namespaceNames)
+
+	excludedNamespaces := make(map[string]bool)
+	for _, namespace := range namespaceNames {
+		settings := parseAnnotationSettings("namespace "+namespace, namespaceAnnotations[namespace], scope.report)
+		if settings.logLines > 0 {
+			scope.namespaceLogLines[namespace] = settings.logLines
+		}
+		if settings.exclude == nil || !*settings.exclude {
+			continue
+		}
+		if requested[namespace] {
+			scope.report.Overridden = append(scope.report.Overridden, namespace)
+			continue
+		}
+		excludedNamespaces[namespace] = true
+		scope.report.ExcludedNamespaces = append(scope.report.ExcludedNamespaces, namespace)
+	}
+
+	resolver := newAnnotationResolver(resources, scope.report)
+	kept := make([]Resource, 0, len(resources))
+	keptPods := make(map[string][]string)
+	splitReasons := make(map[string]bool)
+	for _, resource := range resources {
+		settings := resolver.resolve(resource)
+		key := fmt.Sprintf("%s/%s/%s", resource.Namespace, resource.GVR.Resource, resource.Name)
+
+		excluded := excludedNamespaces[resource.Namespace]
+		if settings.exclude != nil {
+			if *settings.exclude {
+				scope.report.ExcludedResources = append(scope.report.ExcludedResources, key)
+			} else if excluded {
+				scope.report.OptedIn = append(scope.report.OptedIn, key)
+			}
+			excluded = *settings.exclude
+		}
+		if excluded {
+			if resource.GVR.Resource == "pods" {
+				splitReasons[resource.Namespace] = true
+			}
+			continue
+		}
+
+		kept = append(kept, resource)
+		if resource.GVR.Resource != "pods" {
+			continue
+		}
+		keptPods[resource.Namespace] = append(keptPods[resource.Namespace], resource.Name)
+		if settings.logLines > 0 && settings.logLines != scope.namespaceLogLines[resource.Namespace] {
+			scope.podLogLines[resource.Namespace+"/"+resource.Name] = settings.logLines
+			splitReasons[resource.Namespace] = true
+		}
+	}
+
+	sort.Strings(scope.report.ExcludedResources)
+	sort.Strings(scope.report.OptedIn)
+
+	// A namespace-wide logs collector would still read the logs of excluded pods, or apply one line limit to all
+	for namespace := range splitReasons {
+		scope.splitNamespaces[namespace] = keptPods[namespace]
+	}
+
+	if len(scope.namespaceLogLines) > 0 || len(scope.podLogLines) > 0 {
+		scope.report.LogLines = make(map[string]int, len(scope.namespaceLogLines)+len(scope.podLogLines))
+		for namespace, lines := range scope.namespaceLogLines {
+			scope.report.LogLines[namespace] = lines
+		}
+		for pod, lines := range scope.podLogLines {
+			scope.report.LogLines[pod] = lines
+		}
+	}
+	return kept, scope
+}
+
+// annotationResolver resolves the settings of a resource from its own annotations and those of its owners
+type annotationResolver struct {
+	owners   map[string]Resource // namespace/Kind/name
+	settings map[string]annotationSettings
+	report   *AnnotationReport
+}
+
+func newAnnotationResolver(resources []Resource, report *AnnotationReport) *annotationResolver {
+	resolver := &annotationResolver{
+		owners:   make(map[string]Resource),
+		settings: make(map[string]annotationSettings),
+		report:   report,
+	}
+	for _, resource := range resources {
+		if kind, ok := timelineKinds[resource.GVR.Resource]; ok {
+			resolver.owners[resource.Namespace+"/"+kind+"/"+resource.Name] = resource
+		}
+	}
+	return resolver
+}
+
+// resolve returns the settings of a resource, inheriting unset fields from its controller owners
+func (r *annotationResolver) resolve(resource Resource) annotationSettings {
+	settings := r.settingsOf(resource)
+	current := resource
+	for depth := 0; depth < maxOwnerDepth && (settings.exclude == nil || settings.logLines == 0); depth++ {
+		owner, ok := r.owner(current)
+		if !ok {
+			break
+		}
+		settings = settings.inherit(r.settingsOf(owner))
+		current = owner
+	}
+	return settings
+}
+
+// settingsOf parses the annotations of a resource once, so invalid values are reported once
+func (r *annotationResolver) settingsOf(resource Resource) annotationSettings {
+	key := fmt.Sprintf("%s/%s/%s", resource.Namespace, resource.GVR.Resource, resource.Name)
+	if settings, ok := r.settings[key]; ok {
+		return settings
+	}
+	settings := parseAnnotationSettings(key, resource.Annotations, r.report)
+	r.settings[key] = settings
+	return settings
+}
+
+// owner returns the scanned controller of a resource, or its first owner when none is marked as controller
+func (r *annotationResolver) owner(resource Resource) (Resource, bool) {
+	if len(resource.OwnerRefs) == 0 {
+		return Resource{}, false
+	}
+	ref := resource.OwnerRefs[0]
+	for _, ownerRef := range resource.OwnerRefs {
+		if ownerRef.Controller != nil && *ownerRef.Controller {
+			ref = ownerRef
+			break
+		}
+	}
+	owner, ok := r.owners[resource.Namespace+"/"+ref.Kind+"/"+ref.Name]
+	return owner, ok
+}
+
+// excludeNamespaces adds the namespaces excluded by annotation to opts.ExcludeNamespaces, so dependencies are
+// not followed into them
+func (s *annotationScope) excludeNamespaces(opts DiscoveryOptions) DiscoveryOptions {
+	if s == nil || len(s.report.ExcludedNamespaces) == 0 {
+		return opts
+	}
+	excluded := append([]string{}, opts.ExcludeNamespaces...)
+	opts.ExcludeNamespaces = appendUniqueStrings(excluded, s.report.ExcludedNamespaces...)
+	return opts
+}
+
+// applyLogLines sets the log lines of the logs collectors from the namespace and pod annotations
+// In namespaces with excluded pods, or pods with their own line count, the namespace-wide logs collector is replaced
+// by one collector per kept pod
+func (s *annotationScope) applyLogLines(collectors []CollectorSpec) []CollectorSpec {
+	if s == nil || (len(s.namespaceLogLines) == 0 && len(s.podLogLines) == 0 && len(s.splitNamespaces) == 0) {
+		return collectors
+	}
+
+	targeted := make(map[string]bool)
+	for _, collector := range collectors {
+		if collector.Type != CollectorTypeLogs {
+			continue
+		}
+		if params, err := collector.LogsParams(); err == nil && params.Name != "" {
+			targeted[collector.Namespace+"/"+params.Name] = true
+		}
+	}
+
+	result := make([]CollectorSpec, 0, len(collectors))
+	for _, collector := range collectors {
+		if collector.Type != CollectorTypeLogs {
+			result = append(result, collector)
+			continue
+		}
+		params, err := collector.LogsParams()
+		if err != nil {
+			result = append(result, collector)
+			continue
+		}
+
+		pods, split := s.splitNamespaces[collector.Namespace]
+		if params.Name == "" && split {
+			for _, pod := range pods {
+				if targeted[collector.Namespace+"/"+pod] {
+					continue
+				}
+				podParams := *params
+				podParams.Name = pod
+				podParams.Selector = nil
+				if params.Limits != nil {
+					limits := *params.Limits
+					podParams.Limits = &limits
+				}
+				s.setLogLines(&podParams, collector.Namespace)
+				result = append(result, CollectorSpec{
+					Type:       collector.Type,
+					Name:       fmt.Sprintf("auto-logs-pod-%s", pod),
+					Namespace:  collector.Namespace,
+					Priority:   collector.Priority,
+					Parameters: podParams.ToMap(),
+					Group:      collector.Group,
+				})
+			}
+			continue
+		}
+
+		if s.setLogLines(params, collector.Namespace) {
+			collector.Parameters = params.ToMap()
+		}
+		result = append(result, collector)
+	}
+	return result
+}
+
+// setLogLines sets the maxLines of logs params from the annotations of their pod or namespace, reporting a change
+func (s *annotationScope) setLogLines(params *LogsParams, namespace string) bool {
+	lines := s.namespaceLogLines[namespace]
+	if params.Name != "" {
+		if podLines, ok := s.podLogLines[namespace+"/"+params.Name]; ok {
+			lines = podLines
+		}
+	}
+	if lines == 0 {
+		return false
+	}
+	if params.Limits == nil {
+		params.Limits = &LogsLimits{}
+	}
+	params.Limits.MaxLines = lines
+	return true
+}
+
+// namespaceAnnotations returns the collection annotations of the namespaces the resources live in
+// Namespaces are listed once; when that is forbidden each one is read on its own, and those that cannot be read
+// are treated as not annotated
+func (d *Discoverer) namespaceAnnotations(ctx context.Context, resources []Resource) map[string]map[string]string {
+	if d.kubeClient == nil {
+		return nil
+	}
+
+	namespaces := make(map[string]bool)
+	for _, resource := range resources {
+		if resource.Namespace != "" {
+			namespaces[resource.Namespace] = true
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	annotations := make(map[string]map[string]string)
+	list, err := d.kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, namespace := range list.Items {
+			if kept := collectionAnnotations(namespace.Annotations); kept != nil && namespaces[namespace.Name] {
+				annotations[namespace.Name] = kept
+			}
+		}
+		return annotations
+	}
+
+	for name := range namespaces {
+		namespace, err := d.kubeClient.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if kept := collectionAnnotations(namespace.Annotations); kept != nil {
+			annotations[name] = kept
+		}
+	}
+	return annotations
+}
+
+// AnnotationReport returns what collection annotations changed in the last discovery, nil when none did
+func (d *Discoverer) AnnotationReport() *AnnotationReport {
+	if d == nil || d.annotations == nil || d.annotations.report.IsEmpty() {
+		return nil
+	}
+	return d.annotations.report
+}
@@ -0,0 +1,155 @@
+package autodiscovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func ownedBy(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func testAnnotatedResources() []Resource {
+	return []Resource{
+		{GVR: deploymentsGVR, Namespace: "shop", Name: "legacy", Annotations: map[string]string{AnnotationExclude: "true"}},
+		{GVR: replicaSetsGVR, Namespace: "shop", Name: "legacy-abc", OwnerRefs: ownedBy("Deployment", "legacy")},
+		{GVR: podsGVR, Namespace: "shop", Name: "legacy-abc-1", OwnerRefs: ownedBy("ReplicaSet", "legacy-abc")},
+		{GVR: deploymentsGVR, Namespace: "shop", Name: "api", Annotations: map[string]string{AnnotationLogLines: "200"}},
+		{GVR: replicaSetsGVR, Namespace: "shop", Name: "api-abc", OwnerRefs: ownedBy("Deployment", "api")},
+		{GVR: podsGVR, Namespace: "shop", Name: "api-abc-1", OwnerRefs: ownedBy("ReplicaSet", "api-abc")},
+		{GVR: podsGVR, Namespace: "shop", Name: "web-1"},
+		{GVR: podsGVR, Namespace: "sandbox", Name: "scratch"},
+		{GVR: podsGVR, Namespace: "sandbox", Name: "keeper", Annotations: map[string]string{AnnotationExclude: "false"}},
+		{GVR: podsGVR, Namespace: "billing", Name: "invoices", Annotations: map[string]string{AnnotationLogLines: "lots"}},
+	}
+}
+
+func TestApplyCollectionAnnotations(t *testing.T) {
+	namespaceAnnotations := map[string]map[string]string{
+		"sandbox": {AnnotationExclude: "true"},
+		"billing": {AnnotationExclude: "yes", AnnotationLogLines: "50"},
+	}
+
+	kept, scope := applyCollectionAnnotations(testAnnotatedResources(), namespaceAnnotations, DiscoveryOptions{})
+
+	var names []string
+	for _, resource := range kept {
+		names = append(names, resource.Name)
+	}
+	if got := strings.Join(names, ","); got != "api,api-abc,api-abc-1,web-1,keeper,invoices" {
+		t.Errorf("Expected legacy and scratch to be excluded, got %s", got)
+	}
+
+	report := scope.report
+	if strings.Join(report.ExcludedNamespaces, ",") != "sandbox" || len(report.ExcludedResources) != 3 {
+		t.Errorf("Expected sandbox and the legacy Deployment, ReplicaSet and pod to be excluded, got %+v", report)
+	}
+	if strings.Join(report.OptedIn, ",") != "sandbox/pods/keeper" {
+		t.Errorf("Expected keeper to be opted in, got %v", report.OptedIn)
+	}
+	if len(report.Warnings) != 2 {
+		t.Errorf("Expected warnings for the invalid exclude and log-lines values, got %v", report.Warnings)
+	}
+	if report.LogLines["billing"] != 50 || report.LogLines["shop/api-abc-1"] != 200 {
+		t.Errorf("Expected log lines for billing and the api pod, got %v", report.LogLines)
+	}
+	if pods := scope.splitNamespaces["shop"]; strings.Join(pods, ",") != "api-abc-1,web-1" {
+		t.Errorf("Expected shop logs to be collected pod by pod, got %v", scope.splitNamespaces)
+	}
+	if _, split := scope.splitNamespaces["billing"]; split {
+		t.Errorf("Expected billing to keep its namespace-wide logs collector")
+	}
+}
+
+func TestApplyCollectionAnnotations_RequestedNamespace(t *testing.T) {
+	namespaceAnnotations := map[string]map[string]string{"sandbox": {AnnotationExclude: "true"}}
+
+	kept, scope := applyCollectionAnnotations(testAnnotatedResources(), namespaceAnnotations, DiscoveryOptions{Namespaces: []string{"sandbox"}})
+
+	if len(kept) != 7 {
+		t.Errorf("Expected only the legacy workload to be excluded, got %d resources", len(kept))
+	}
+	if len(scope.report.ExcludedNamespaces) != 0 || strings.Join(scope.report.Overridden, ",") != "sandbox" {
+		t.Errorf("Expected the requested namespace to be collected, got %+v", scope.report)
+	}
+	if opts := scope.excludeNamespaces(DiscoveryOptions{}); len(opts.ExcludeNamespaces) != 0 {
+		t.Errorf("Expected no namespace to be excluded from dependency resolution, got %v", opts.ExcludeNamespaces)
+	}
+}
+
+func TestAnnotationScope_ApplyLogLines(t *testing.T) {
+	_, scope := applyCollectionAnnotations(testAnnotatedResources(), map[string]map[string]string{
+		"billing": {AnnotationLogLines: "50"},
+	}, DiscoveryOptions{})
+
+	logs := func(namespace, name string, params LogsParams) CollectorSpec {
+		params.Namespace = namespace
+		return CollectorSpec{Type: CollectorTypeLogs, Name: name, Namespace: namespace, Parameters: params.ToMap()}
+	}
+	collectors := []CollectorSpec{
+		logs("shop", "auto-logs-shop", LogsParams{Selector: []string{"namespace=shop"}, Limits: &LogsLimits{MaxAge: "72h", MaxLines: 10000}}),
+		logs("shop", "auto-logs-pod-web-1", LogsParams{Name: "web-1", Limits: &LogsLimits{MaxAge: "24h", MaxLines: 1000}}),
+		logs("billing", "auto-logs-billing", LogsParams{Selector: []string{"namespace=billing"}, Limits: &LogsLimits{MaxLines: 10000}}),
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-pods", Namespace: "shop"},
+	}
+
+	collectors = scope.applyLogLines(collectors)
+
+	maxLines := make(map[string]int)
+	for _, collector := range collectors {
+		if params, err := collector.LogsParams(); err == nil && params.Limits != nil {
+			maxLines[collector.Name] = params.Limits.MaxLines
+		}
+	}
+	if len(collectors) != 4 {
+		t.Fatalf("Expected the shop logs collector to be split into api-abc-1, got %v", maxLines)
+	}
+	if _, ok := maxLines["auto-logs-shop"]; ok {
+		t.Errorf("Expected the namespace-wide shop collector to be replaced, got %v", maxLines)
+	}
+	if maxLines["auto-logs-pod-api-abc-1"] != 200 || maxLines["auto-logs-pod-web-1"] != 1000 || maxLines["auto-logs-billing"] != 50 {
+		t.Errorf("Expected 200 lines for api, 1000 for web and 50 for billing, got %v", maxLines)
+	}
+}
+
+func TestDiscoverer_CollectionAnnotations(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox", Annotations: map[string]string{AnnotationExclude: "true"}}},
+	)
+	dynamicClient := createTestDynamicClient(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "scratch", Namespace: "sandbox"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "shop", Annotations: map[string]string{AnnotationExclude: "true"}}},
+	)
+
+	discoverer := &Discoverer{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		rbacChecker:   NewRBACChecker(kubeClient),
+		nsScanner:     NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:      NewResourceExpander(),
+	}
+
+	collectors, err := discoverer.Discover(context.Background(), DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, collector := range collectors {
+		if collector.Namespace == "sandbox" {
+			t.Errorf("Expected no collectors for the excluded namespace, got %s", collector.Name)
+		}
+	}
+
+	report := discoverer.AnnotationReport()
+	if report == nil || strings.Join(report.ExcludedNamespaces, ",") != "sandbox" || strings.Join(report.ExcludedResources, ",") != "shop/deployments/legacy" {
+		t.Errorf("Expected sandbox and the legacy Deployment to be excluded, got %+v", report)
+	}
+}
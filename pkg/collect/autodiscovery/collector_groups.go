@@ -0,0 +1,135 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Collector groups
+const (
+	CollectorGroupLogs       = "logs"
+	CollectorGroupWorkloads  = "workloads"
+	CollectorGroupNetworking = "networking"
+	CollectorGroupImages     = "images"
+)
+
+// CollectorGroups lists the groups that can be passed to --only-groups and --skip-groups
+var CollectorGroups = []string{
+	CollectorGroupLogs,
+	CollectorGroupWorkloads,
+	CollectorGroupNetworking,
+	CollectorGroupStorage,
+	CollectorGroupImages,
+	CollectorGroupClusterInfo,
+}
+
+// networkingResources and storageResources are the cluster-resources types outside the workloads group
+var networkingResources = map[string]bool{
+	"services":        true,
+	"endpoints":       true,
+	"endpointslices":  true,
+	"ingresses":       true,
+	"ingressclasses":  true,
+	"networkpolicies": true,
+}
+
+var storageResources = map[string]bool{
+	"persistentvolumeclaims": true,
+	"persistentvolumes":      true,
+	"storageclasses":         true,
+	"csidrivers":             true,
+	"csinodes":               true,
+	"volumeattachments":      true,
+}
+
+// CollectorGroupFor returns the group of a collector, classifying it by type and target resource when untagged
+func CollectorGroupFor(collector CollectorSpec) string {
+	if collector.Group != "" {
+		return collector.Group
+	}
+
+	switch collector.Type {
+	case CollectorTypeLogs:
+		return CollectorGroupLogs
+	case CollectorTypeRunPod:
+		if strings.HasPrefix(collector.Name, "auto-debug-logs-") {
+			return CollectorGroupLogs
+		}
+		if strings.HasPrefix(collector.Name, "auto-network-diag-") {
+			return CollectorGroupNetworking
+		}
+	case CollectorTypeClusterResources:
+		resource, _ := collector.Parameters["resource"].(string)
+		return groupForResource(resource)
+	}
+	return CollectorGroupWorkloads
+}
+
+// groupForResource returns the group of a collector gathering the given resource type
+func groupForResource(resource string) string {
+	if networkingResources[resource] {
+		return CollectorGroupNetworking
+	}
+	if storageResources[resource] {
+		return CollectorGroupStorage
+	}
+	return CollectorGroupWorkloads
+}
+
+// assignCollectorGroups tags every untagged collector with its group
+func assignCollectorGroups(collectors []CollectorSpec) {
+	for i := range collectors {
+		collectors[i].Group = CollectorGroupFor(collectors[i])
+	}
+}
+
+// ValidateCollectorGroups checks that every name is a known collector group
+func ValidateCollectorGroups(groups []string) error {
+	for _, group := range groups {
+		if !isKnownCollectorGroup(group) {
+			return fmt.Errorf("unknown collector group %q (valid: %s)", group, strings.Join(CollectorGroups, ", "))
+		}
+	}
+	return nil
+}
+
+func isKnownCollectorGroup(group string) bool {
+	for _, known := range CollectorGroups {
+		if group == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectorGroupSelected reports whether a group passes the OnlyGroups and SkipGroups options
+func CollectorGroupSelected(group string, opts DiscoveryOptions) bool {
+	if len(opts.OnlyGroups) > 0 && !containsString(opts.OnlyGroups, group) {
+		return false
+	}
+	return !containsString(opts.SkipGroups, group)
+}
+
+// filterCollectorGroups drops collectors whose group is not selected
+func filterCollectorGroups(collectors []CollectorSpec, opts DiscoveryOptions) []CollectorSpec {
+	if len(opts.OnlyGroups) == 0 && len(opts.SkipGroups) == 0 {
+		return collectors
+	}
+
+	filtered := make([]CollectorSpec, 0, len(collectors))
+	for _, collector := range collectors {
+		if CollectorGroupSelected(CollectorGroupFor(collector), opts) {
+			filtered = append(filtered, collector)
+		}
+	}
+	return filtered
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
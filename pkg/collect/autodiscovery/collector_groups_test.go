@@ -0,0 +1,135 @@
+package autodiscovery
+
+import (
+	"testing"
+)
+
+func TestCollectorGroupFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector CollectorSpec
+		expected  string
+	}{
+		{
+			name:      "pod logs",
+			collector: CollectorSpec{Type: CollectorTypeLogs, Name: "logs-default-app"},
+			expected:  CollectorGroupLogs,
+		},
+		{
+			name:      "debug logs run-pod",
+			collector: CollectorSpec{Type: CollectorTypeRunPod, Name: "auto-debug-logs-default"},
+			expected:  CollectorGroupLogs,
+		},
+		{
+			name:      "network diagnostics run-pod",
+			collector: CollectorSpec{Type: CollectorTypeRunPod, Name: "auto-network-diag-default"},
+			expected:  CollectorGroupNetworking,
+		},
+		{
+			name:      "other run-pod",
+			collector: CollectorSpec{Type: CollectorTypeRunPod, Name: "custom-check"},
+			expected:  CollectorGroupWorkloads,
+		},
+		{
+			name: "services",
+			collector: CollectorSpec{Type: CollectorTypeClusterResources, Parameters: map[string]interface{}{
+				"resource": "services",
+			}},
+			expected: CollectorGroupNetworking,
+		},
+		{
+			name: "persistent volume claims",
+			collector: CollectorSpec{Type: CollectorTypeClusterResources, Parameters: map[string]interface{}{
+				"resource": "persistentvolumeclaims",
+			}},
+			expected: CollectorGroupStorage,
+		},
+		{
+			name: "deployments",
+			collector: CollectorSpec{Type: CollectorTypeClusterResources, Parameters: map[string]interface{}{
+				"resource": "deployments",
+			}},
+			expected: CollectorGroupWorkloads,
+		},
+		{
+			name:      "exec",
+			collector: CollectorSpec{Type: "exec", Name: "exec-check"},
+			expected:  CollectorGroupWorkloads,
+		},
+		{
+			name:      "preset group is kept",
+			collector: CollectorSpec{Type: CollectorTypeLogs, Name: "csi-logs", Group: CollectorGroupStorage},
+			expected:  CollectorGroupStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if group := CollectorGroupFor(tt.collector); group != tt.expected {
+				t.Errorf("Expected group %s, got %s", tt.expected, group)
+			}
+		})
+	}
+}
+
+func TestValidateCollectorGroups(t *testing.T) {
+	if err := ValidateCollectorGroups(CollectorGroups); err != nil {
+		t.Errorf("Unexpected error for known groups: %v", err)
+	}
+	if err := ValidateCollectorGroups(nil); err != nil {
+		t.Errorf("Unexpected error for no groups: %v", err)
+	}
+	if err := ValidateCollectorGroups([]string{"logs", "metrics"}); err == nil {
+		t.Errorf("Expected error for unknown group but got none")
+	}
+}
+
+func TestCollectorGroupSelected(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    string
+		opts     DiscoveryOptions
+		expected bool
+	}{
+		{"no selection", CollectorGroupLogs, DiscoveryOptions{}, true},
+		{"in only groups", CollectorGroupLogs, DiscoveryOptions{OnlyGroups: []string{"logs", "networking"}}, true},
+		{"not in only groups", CollectorGroupImages, DiscoveryOptions{OnlyGroups: []string{"logs"}}, false},
+		{"in skip groups", CollectorGroupImages, DiscoveryOptions{SkipGroups: []string{"images"}}, false},
+		{"not in skip groups", CollectorGroupLogs, DiscoveryOptions{SkipGroups: []string{"images"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if selected := CollectorGroupSelected(tt.group, tt.opts); selected != tt.expected {
+				t.Errorf("Expected selected=%v, got %v", tt.expected, selected)
+			}
+		})
+	}
+}
+
+func TestFilterCollectorGroups(t *testing.T) {
+	collectors := []CollectorSpec{
+		{Type: CollectorTypeLogs, Name: "logs-default-app"},
+		{Type: CollectorTypeRunPod, Name: "auto-network-diag-default"},
+		{Type: CollectorTypeClusterResources, Name: "resources-default-deployments", Parameters: map[string]interface{}{"resource": "deployments"}},
+	}
+
+	only := filterCollectorGroups(collectors, DiscoveryOptions{OnlyGroups: []string{CollectorGroupLogs}})
+	if len(only) != 1 || only[0].Name != "logs-default-app" {
+		t.Errorf("Expected only the logs collector, got %v", only)
+	}
+
+	skipped := filterCollectorGroups(collectors, DiscoveryOptions{SkipGroups: []string{CollectorGroupNetworking}})
+	if len(skipped) != 2 {
+		t.Errorf("Expected 2 collectors after skipping networking, got %d", len(skipped))
+	}
+	for _, collector := range skipped {
+		if collector.Name == "auto-network-diag-default" {
+			t.Errorf("Networking collector should have been skipped")
+		}
+	}
+
+	if all := filterCollectorGroups(collectors, DiscoveryOptions{}); len(all) != len(collectors) {
+		t.Errorf("Expected %d collectors without a selection, got %d", len(collectors), len(all))
+	}
+}
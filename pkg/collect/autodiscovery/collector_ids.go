@@ -0,0 +1,66 @@
+package autodiscovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// collectorIDLength is the number of hex characters kept from the content hash
+const collectorIDLength = 12
+
+// CollectorID returns a content-derived ID for a collector: a hash of its type, namespace and parameters
+// Parameters are hashed as JSON, which orders map keys, so equal collectors get equal IDs across runs
+func CollectorID(collector CollectorSpec) string {
+	data, err := json.Marshal(struct {
+		Type       string                 `json:"type"`
+		Namespace  string                 `json:"namespace"`
+		Parameters map[string]interface{} `json:"parameters"`
+	}{collector.Type, collector.Namespace, collector.Parameters})
+	if err != nil {
+		data = []byte(collector.Type + "/" + collector.Namespace + "/" + collector.Name)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:collectorIDLength]
+}
+
+// finalizeCollectors assigns IDs, makes names unique and sorts collectors so the same cluster state
+// always produces the same list: identical collectors are dropped, collectors sharing a name get
+// their ID appended, and the list is ordered by priority, then name
+func finalizeCollectors(collectors []CollectorSpec) []CollectorSpec {
+	byName := make(map[string]map[string]bool)
+	for i := range collectors {
+		collectors[i].ID = CollectorID(collectors[i])
+		if byName[collectors[i].Name] == nil {
+			byName[collectors[i].Name] = make(map[string]bool)
+		}
+		byName[collectors[i].Name][collectors[i].ID] = true
+	}
+
+	seen := make(map[string]bool)
+	result := make([]CollectorSpec, 0, len(collectors))
+	for _, collector := range collectors {
+		if len(byName[collector.Name]) > 1 {
+			collector.Name = collector.Name + "-" + collector.ID[:8]
+		}
+		key := collector.Name + "/" + collector.ID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, collector)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority > result[j].Priority
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
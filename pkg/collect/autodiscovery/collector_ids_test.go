@@ -0,0 +1,87 @@
+package autodiscovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCollectorID(t *testing.T) {
+	a := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-app", Namespace: "app", Parameters: map[string]interface{}{"namespace": "app", "selector": []string{"app=web"}}}
+	b := CollectorSpec{Type: CollectorTypeLogs, Name: "renamed", Namespace: "app", Parameters: map[string]interface{}{"selector": []string{"app=web"}, "namespace": "app"}}
+	c := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-app", Namespace: "other", Parameters: map[string]interface{}{"namespace": "other", "selector": []string{"app=web"}}}
+
+	if CollectorID(a) != CollectorID(b) {
+		t.Errorf("Expected equal IDs for equal content, got %s and %s", CollectorID(a), CollectorID(b))
+	}
+	if CollectorID(a) == CollectorID(c) {
+		t.Errorf("Expected different IDs for different namespaces")
+	}
+	if len(CollectorID(a)) != collectorIDLength {
+		t.Errorf("Expected ID of length %d, got %s", collectorIDLength, CollectorID(a))
+	}
+}
+
+func TestFinalizeCollectors(t *testing.T) {
+	collectors := []CollectorSpec{
+		{Type: CollectorTypeLogs, Name: "auto-logs-pod-web", Namespace: "b", Priority: int(PriorityCritical), Parameters: map[string]interface{}{"namespace": "b"}},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-pods", Priority: int(PriorityNormal)},
+		{Type: CollectorTypeLogs, Name: "auto-logs-pod-web", Namespace: "a", Priority: int(PriorityCritical), Parameters: map[string]interface{}{"namespace": "a"}},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-configmaps", Priority: int(PriorityNormal)},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-pods", Priority: int(PriorityNormal)},
+	}
+
+	result := finalizeCollectors(collectors)
+	if len(result) != 4 {
+		t.Fatalf("Expected identical collectors to be dropped, got %d collectors", len(result))
+	}
+
+	for _, collector := range result {
+		if collector.ID == "" {
+			t.Errorf("Expected %s to have an ID", collector.Name)
+		}
+	}
+	if result[0].Name == result[1].Name {
+		t.Errorf("Expected collectors sharing a name to be made unique, got %s twice", result[0].Name)
+	}
+	if result[0].Name != "auto-logs-pod-web-"+result[0].ID[:8] {
+		t.Errorf("Expected ID suffix on a shared name, got %s", result[0].Name)
+	}
+	if result[2].Name != "auto-resources-configmaps" || result[3].Name != "auto-resources-pods" {
+		t.Errorf("Expected equal priorities to be sorted by name, got %s, %s", result[2].Name, result[3].Name)
+	}
+}
+
+func TestExpandToCollectors_Deterministic(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	resources := []Resource{
+		{GVR: podsGVR, Namespace: "c", Name: "web-1"},
+		{GVR: deployments, Namespace: "b", Name: "web"},
+		{GVR: podsGVR, Namespace: "a", Name: "api-1"},
+		{GVR: servicesGVR, Namespace: "b", Name: "web"},
+		{GVR: deployments, Namespace: "a", Name: "api"},
+		{GVR: servicesGVR, Namespace: "c", Name: "web"},
+	}
+
+	var first []CollectorSpec
+	for i := 0; i < 10; i++ {
+		shuffled := append([]Resource{}, resources[i%len(resources):]...)
+		shuffled = append(shuffled, resources[:i%len(resources)]...)
+
+		collectors, err := NewResourceExpander().ExpandToCollectors(context.Background(), shuffled, DiscoveryOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		collectors = finalizeCollectors(collectors)
+
+		if first == nil {
+			first = collectors
+			continue
+		}
+		if !reflect.DeepEqual(first, collectors) {
+			t.Fatalf("Expected identical collectors on every run, got %+v and %+v", first, collectors)
+		}
+	}
+}
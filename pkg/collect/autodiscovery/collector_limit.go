@@ -0,0 +1,55 @@
+package autodiscovery
+
+import (
+	"fmt"
+)
+
+// DroppedCollector identifies a collector removed by the collector limit
+type DroppedCollector struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Namespace string `json:"namespace,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Priority  int    `json:"priority"`
+}
+
+// CollectorLimitReport records the collectors dropped to honor DiscoveryOptions.MaxCollectors
+type CollectorLimitReport struct {
+	Limit   int                `json:"limit"`
+	Total   int                `json:"total"` // Collectors generated before the limit was applied
+	Dropped []DroppedCollector `json:"dropped"`
+}
+
+// String summarizes the report, e.g. "kept 100 of 240 collectors, dropped 140"
+func (r *CollectorLimitReport) String() string {
+	return fmt.Sprintf("kept %d of %d collectors, dropped %d", r.Total-len(r.Dropped), r.Total, len(r.Dropped))
+}
+
+// ValidateMaxCollectors rejects negative limits, 0 means unlimited
+func ValidateMaxCollectors(max int) error {
+	if max < 0 {
+		return fmt.Errorf("must not be negative, got %d", max)
+	}
+	return nil
+}
+
+// limitCollectors keeps the first max collectors of a finalized list and reports the rest
+// finalizeCollectors sorts by priority, then name and ID, so the lowest priority collectors are dropped and
+// the same cluster state always drops the same collectors. It returns nil when nothing was dropped
+func limitCollectors(collectors []CollectorSpec, max int) ([]CollectorSpec, *CollectorLimitReport) {
+	if max <= 0 || len(collectors) <= max {
+		return collectors, nil
+	}
+
+	report := &CollectorLimitReport{Limit: max, Total: len(collectors)}
+	for _, collector := range collectors[max:] {
+		report.Dropped = append(report.Dropped, DroppedCollector{
+			Name:      collector.Name,
+			Type:      collector.Type,
+			Namespace: collector.Namespace,
+			Group:     collector.Group,
+			Priority:  collector.Priority,
+		})
+	}
+	return collectors[:max], report
+}
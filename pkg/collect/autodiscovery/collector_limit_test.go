@@ -0,0 +1,93 @@
+package autodiscovery
+
+import (
+	"testing"
+)
+
+func TestLimitCollectors(t *testing.T) {
+	generated := func() []CollectorSpec {
+		return finalizeCollectors([]CollectorSpec{
+			{Type: CollectorTypeLogs, Name: "auto-logs-web", Namespace: "app", Priority: int(PriorityNormal)},
+			{Type: CollectorTypeClusterResources, Name: "auto-cluster-resources", Priority: int(PriorityHigh)},
+			{Type: CollectorTypeLogs, Name: "auto-logs-api", Namespace: "app", Priority: int(PriorityNormal)},
+			{Type: CollectorTypeExec, Name: "auto-exec-db", Namespace: "app", Priority: int(PriorityLow)},
+		})
+	}
+
+	tests := []struct {
+		name            string
+		max             int
+		expectedKept    []string
+		expectedDropped []string
+	}{
+		{
+			name:         "unlimited",
+			max:          0,
+			expectedKept: []string{"auto-cluster-resources", "auto-logs-api", "auto-logs-web", "auto-exec-db"},
+		},
+		{
+			name:         "under the limit",
+			max:          10,
+			expectedKept: []string{"auto-cluster-resources", "auto-logs-api", "auto-logs-web", "auto-exec-db"},
+		},
+		{
+			name:            "lowest priority dropped first",
+			max:             3,
+			expectedKept:    []string{"auto-cluster-resources", "auto-logs-api", "auto-logs-web"},
+			expectedDropped: []string{"auto-exec-db"},
+		},
+		{
+			name:            "ties broken by name",
+			max:             2,
+			expectedKept:    []string{"auto-cluster-resources", "auto-logs-api"},
+			expectedDropped: []string{"auto-logs-web", "auto-exec-db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, report := limitCollectors(generated(), tt.max)
+
+			var names []string
+			for _, collector := range kept {
+				names = append(names, collector.Name)
+			}
+			if len(names) != len(tt.expectedKept) {
+				t.Fatalf("Expected %v, got %v", tt.expectedKept, names)
+			}
+			for i := range names {
+				if names[i] != tt.expectedKept[i] {
+					t.Errorf("Expected %v, got %v", tt.expectedKept, names)
+					break
+				}
+			}
+
+			if len(tt.expectedDropped) == 0 {
+				if report != nil {
+					t.Errorf("Expected no report, got %+v", report)
+				}
+				return
+			}
+			if report == nil || report.Limit != tt.max || report.Total != 4 || len(report.Dropped) != len(tt.expectedDropped) {
+				t.Fatalf("Expected %d dropped collectors, got %+v", len(tt.expectedDropped), report)
+			}
+			for i, dropped := range report.Dropped {
+				if dropped.Name != tt.expectedDropped[i] {
+					t.Errorf("Expected dropped %v, got %+v", tt.expectedDropped, report.Dropped)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMaxCollectors(t *testing.T) {
+	for _, max := range []int{0, 1, 500} {
+		if err := ValidateMaxCollectors(max); err != nil {
+			t.Errorf("Expected %d to be valid, got %v", max, err)
+		}
+	}
+	if err := ValidateMaxCollectors(-1); err == nil {
+		t.Errorf("Expected an error for a negative limit")
+	}
+}
@@ -0,0 +1,252 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Collector types without typed parameters that can still be used in collector mappings
+const (
+	CollectorTypeExec = "exec"
+	CollectorTypeCopy = "copy"
+)
+
+// parameterKind is the expected type of a collector mapping parameter value
+type parameterKind string
+
+const (
+	parameterString     parameterKind = "a string"
+	parameterInt        parameterKind = "an integer"
+	parameterBool       parameterKind = "a boolean"
+	parameterStringList parameterKind = "a list of strings"
+	parameterDuration   parameterKind = "a duration"
+	parameterObject     parameterKind = "an object"
+)
+
+// parameterSpec describes one supported parameter, Fields lists the keys of object parameters
+// and is nil when any key is accepted
+type parameterSpec struct {
+	Kind   parameterKind
+	Fields map[string]parameterSpec
+}
+
+// targetParameters are set from the matched resource by GetCollectorMappings, so every type accepts them
+var targetParameters = map[string]parameterSpec{
+	"name":      {Kind: parameterString},
+	"namespace": {Kind: parameterString},
+}
+
+// collectorParameterSchemas lists the parameters each collector type accepts in a collector mapping
+var collectorParameterSchemas = map[string]map[string]parameterSpec{
+	CollectorTypeLogs: {
+		"selector":       {Kind: parameterStringList},
+		"containerNames": {Kind: parameterStringList},
+		"previous":       {Kind: parameterBool},
+		"maxAge":         {Kind: parameterString},
+		"maxLines":       {Kind: parameterInt},
+		"sinceTime":      {Kind: parameterString},
+		"limits": {Kind: parameterObject, Fields: map[string]parameterSpec{
+			"maxAge":    {Kind: parameterString},
+			"maxLines":  {Kind: parameterInt},
+			"sinceTime": {Kind: parameterString},
+		}},
+	},
+	CollectorTypeClusterResources: {
+		"group":          {Kind: parameterString},
+		"version":        {Kind: parameterString},
+		"resource":       {Kind: parameterString},
+		"namespaces":     {Kind: parameterStringList},
+		"fieldSelectors": {Kind: parameterStringList},
+	},
+	CollectorTypeExec: {
+		"selector":      {Kind: parameterStringList},
+		"containerName": {Kind: parameterString},
+		"command":       {Kind: parameterStringList},
+		"args":          {Kind: parameterStringList},
+		"timeout":       {Kind: parameterDuration},
+	},
+	CollectorTypeCopy: {
+		"selector":      {Kind: parameterStringList},
+		"containerName": {Kind: parameterString},
+		"containerPath": {Kind: parameterString},
+	},
+	CollectorTypeRunPod: {
+		"podSpec": {Kind: parameterObject},
+		"timeout": {Kind: parameterDuration},
+	},
+}
+
+// SupportedCollectorTypes returns the collector types accepted by collector mappings, sorted
+func SupportedCollectorTypes() []string {
+	types := make([]string, 0, len(collectorParameterSchemas))
+	for collectorType := range collectorParameterSchemas {
+		types = append(types, collectorType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidateParameters checks the collector type and that every parameter is supported by it
+// and has the expected type, so typos such as "maxline" are reported instead of ignored
+// An empty collector type falls back to cluster-resources like the resource expander does
+func (r CollectorMappingRule) ValidateParameters() error {
+	collectorType := r.CollectorType
+	if collectorType == "" {
+		collectorType = CollectorTypeClusterResources
+	}
+	schema, ok := collectorParameterSchemas[collectorType]
+	if !ok {
+		return fmt.Errorf("unknown collector type %q, expected one of %s", r.CollectorType, strings.Join(SupportedCollectorTypes(), ", "))
+	}
+
+	fields := make(map[string]parameterSpec, len(schema)+len(targetParameters))
+	for key, spec := range targetParameters {
+		fields[key] = spec
+	}
+	for key, spec := range schema {
+		fields[key] = spec
+	}
+	return validateParameterFields(collectorType+" collector", "", r.Parameters, fields)
+}
+
+// validateParameterFields checks params against fields, prefix is the dotted path of nested objects
+func validateParameterFields(collector, prefix string, params map[string]interface{}, fields map[string]parameterSpec) error {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := prefix + key
+		spec, ok := fields[key]
+		if !ok {
+			message := fmt.Sprintf("parameter %q is not supported by the %s", path, collector)
+			if suggestion := suggestParameterName(key, fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", prefix+suggestion)
+			}
+			return fmt.Errorf("%s", message)
+		}
+		if err := validateParameterValue(collector, path, params[key], spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateParameterValue checks a single value against its spec, accepting the types produced by
+// JSON and YAML decoding as well as Go values set programmatically
+func validateParameterValue(collector, path string, value interface{}, spec parameterSpec) error {
+	invalid := func() error {
+		return fmt.Errorf("parameter %q of the %s must be %s, got %T", path, collector, spec.Kind, value)
+	}
+
+	switch spec.Kind {
+	case parameterString:
+		if _, ok := value.(string); !ok {
+			return invalid()
+		}
+	case parameterDuration:
+		s, ok := value.(string)
+		if !ok {
+			return invalid()
+		}
+		if _, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("parameter %q of the %s must be a duration like 30s: %w", path, collector, err)
+		}
+	case parameterBool:
+		if _, ok := value.(bool); !ok {
+			return invalid()
+		}
+	case parameterInt:
+		switch v := value.(type) {
+		case int, int32, int64, uint, uint32, uint64:
+		case float64:
+			if v != math.Trunc(v) {
+				return invalid()
+			}
+		default:
+			return invalid()
+		}
+	case parameterStringList:
+		switch v := value.(type) {
+		case []string:
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					return invalid()
+				}
+			}
+		default:
+			return invalid()
+		}
+	case parameterObject:
+		object, ok := toStringMap(value)
+		if !ok {
+			return invalid()
+		}
+		if spec.Fields != nil {
+			return validateParameterFields(collector, path+".", object, spec.Fields)
+		}
+	}
+	return nil
+}
+
+// toStringMap converts JSON objects and the map[interface{}]interface{} produced by yaml.v2
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			s, ok := key.(string)
+			if !ok {
+				return nil, false
+			}
+			converted[s] = item
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// suggestParameterName returns the supported parameter closest to key when it differs only in case
+// or by at most two edits, or "" when nothing is close
+func suggestParameterName(key string, fields map[string]parameterSpec) string {
+	best, bestDistance := "", 3
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+		distance := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance || (distance == bestDistance && best != "" && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
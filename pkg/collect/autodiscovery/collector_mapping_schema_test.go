@@ -0,0 +1,135 @@
+package autodiscovery
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestCollectorMappingRule_ValidateParameters(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          CollectorMappingRule
+		expectedError string
+	}{
+		{
+			name: "valid logs parameters",
+			rule: CollectorMappingRule{CollectorType: "logs", Parameters: map[string]interface{}{
+				"maxAge":   "7d",
+				"maxLines": 50000,
+				"selector": []interface{}{"app=database"},
+				"previous": true,
+			}},
+		},
+		{
+			name: "nested limits",
+			rule: CollectorMappingRule{CollectorType: "logs", Parameters: map[string]interface{}{
+				"limits": map[string]interface{}{"maxLines": float64(100)},
+			}},
+		},
+		{
+			name: "valid exec parameters",
+			rule: CollectorMappingRule{CollectorType: "exec", Parameters: map[string]interface{}{
+				"command": []string{"pg_dump", "--schema-only"},
+				"timeout": "60s",
+			}},
+		},
+		{
+			name: "no parameters",
+			rule: CollectorMappingRule{CollectorType: "cluster-resources"},
+		},
+		{
+			name:          "misspelled parameter",
+			rule:          CollectorMappingRule{CollectorType: "logs", Parameters: map[string]interface{}{"maxline": 100}},
+			expectedError: `parameter "maxline" is not supported by the logs collector, did you mean "maxLines"?`,
+		},
+		{
+			name:          "wrong case",
+			rule:          CollectorMappingRule{CollectorType: "copy", Parameters: map[string]interface{}{"containerpath": "/var/log"}},
+			expectedError: `did you mean "containerPath"?`,
+		},
+		{
+			name:          "misspelled nested parameter",
+			rule:          CollectorMappingRule{CollectorType: "logs", Parameters: map[string]interface{}{"limits": map[string]interface{}{"maxAges": "1h"}}},
+			expectedError: `did you mean "limits.maxAge"?`,
+		},
+		{
+			name:          "parameter of another collector type",
+			rule:          CollectorMappingRule{CollectorType: "cluster-resources", Parameters: map[string]interface{}{"command": []string{"ls"}}},
+			expectedError: `parameter "command" is not supported by the cluster-resources collector`,
+		},
+		{
+			name:          "wrong value type",
+			rule:          CollectorMappingRule{CollectorType: "logs", Parameters: map[string]interface{}{"maxLines": "lots"}},
+			expectedError: `parameter "maxLines" of the logs collector must be an integer, got string`,
+		},
+		{
+			name:          "fractional integer",
+			rule:          CollectorMappingRule{CollectorType: "logs", Parameters: map[string]interface{}{"maxLines": 1.5}},
+			expectedError: "must be an integer",
+		},
+		{
+			name:          "list with a non-string item",
+			rule:          CollectorMappingRule{CollectorType: "exec", Parameters: map[string]interface{}{"command": []interface{}{"ls", 1}}},
+			expectedError: "must be a list of strings",
+		},
+		{
+			name:          "invalid duration",
+			rule:          CollectorMappingRule{CollectorType: "run-pod", Parameters: map[string]interface{}{"timeout": "soon"}},
+			expectedError: "must be a duration",
+		},
+		{
+			name:          "unknown collector type",
+			rule:          CollectorMappingRule{CollectorType: "log"},
+			expectedError: `unknown collector type "log", expected one of cluster-resources, copy, exec, logs, run-pod`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.ValidateParameters()
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestCollectorMappingRule_ValidateParameters_YAML(t *testing.T) {
+	var config Config
+	err := yaml.Unmarshal([]byte(`collectorMappings:
+  - name: db-logs
+    collectorType: logs
+    parameters:
+      selector: ["app=database"]
+      limits:
+        maxAge: 24h
+        maxLines: 5000
+`), &config)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	if err := config.CollectorMappings[0].ValidateParameters(); err != nil {
+		t.Errorf("Expected YAML decoded parameters to be valid, got %v", err)
+	}
+}
+
+func TestValidateConfig_CollectorMappings(t *testing.T) {
+	config := &Config{CollectorMappings: []CollectorMappingRule{
+		{Name: "pod-logs", CollectorType: "logs"},
+		{Name: "db-exec", CollectorType: "exec", Parameters: map[string]interface{}{"comand": []string{"ls"}}},
+	}}
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), `collector mapping "db-exec" (collectorMappings[1])`) {
+		t.Errorf("Expected the error to point at db-exec, got %v", err)
+	}
+}
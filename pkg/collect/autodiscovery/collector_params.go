@@ -0,0 +1,310 @@
+package autodiscovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Collector types with typed parameters
+const (
+	CollectorTypeLogs             = "logs"
+	CollectorTypeClusterResources = "cluster-resources"
+	CollectorTypeRunPod           = "run-pod"
+)
+
+// LogsParams are the parameters of a logs collector
+type LogsParams struct {
+	Name                string      `json:"name,omitempty"`
+	Namespace           string      `json:"namespace,omitempty"`
+	Selector            []string    `json:"selector,omitempty"`
+	ContainerNames      []string    `json:"containerNames,omitempty"`
+	Limits              *LogsLimits `json:"limits,omitempty"`
+	Timestamps          bool        `json:"timestamps,omitempty"`          // Prefix each line with the kubelet's timestamp, as kubectl logs --timestamps
+	NormalizeTimestamps bool        `json:"normalizeTimestamps,omitempty"` // Rewrite the lines to start with an RFC3339 UTC timestamp, see LogTimestampNormalizer
+}
+
+// LogsLimits bounds how much log data a logs collector gathers
+type LogsLimits struct {
+	MaxAge    string `json:"maxAge,omitempty"`
+	MaxLines  int    `json:"maxLines,omitempty"`
+	SinceTime string `json:"sinceTime,omitempty"` // RFC3339, replaces maxAge when set
+}
+
+// ClusterResourcesParams are the parameters of a cluster-resources collector
+type ClusterResourcesParams struct {
+	Group          string   `json:"group"`
+	Version        string   `json:"version"`
+	Resource       string   `json:"resource"`
+	Namespaces     []string `json:"namespaces,omitempty"`
+	FieldSelectors []string `json:"fieldSelectors,omitempty"` // Objects are kept when all match, see ParseFieldSelector
+	// ConfigMaps and Secrets only, objects above MaxObjectSize bytes keep metadata, keys and sizes, see LargeObjects
+	MaxObjectSize     int      `json:"maxObjectSize,omitempty"`
+	AlwaysCaptureKeys []string `json:"alwaysCaptureKeys,omitempty"`
+	// Secrets only, the Secret types kept and what they keep instead of their values, see SecretPolicy
+	SecretPolicy []SecretTypePolicy `json:"secretPolicy,omitempty"`
+	// Batched collectors only, the resource types gathered from every namespace instead of Group, Version and Resource
+	Resources []ResourceRef `json:"resources,omitempty"`
+	// Namespace globs skipped when Namespaces is empty and every namespace is listed, e.g. protected namespaces
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+}
+
+// ResourceRef names one resource type of a batched cluster-resources collector
+type ResourceRef struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+}
+
+// GVRs returns the resource types the collector gathers, Resources for batched collectors
+func (p ClusterResourcesParams) GVRs() []schema.GroupVersionResource {
+	if len(p.Resources) == 0 {
+		if p.Resource == "" {
+			return nil
+		}
+		return []schema.GroupVersionResource{{Group: p.Group, Version: p.Version, Resource: p.Resource}}
+	}
+	gvrs := make([]schema.GroupVersionResource, 0, len(p.Resources))
+	for _, ref := range p.Resources {
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource})
+	}
+	return gvrs
+}
+
+// RunPodParams are the parameters of a run-pod collector
+type RunPodParams struct {
+	Name      string                 `json:"name,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	PodSpec   map[string]interface{} `json:"podSpec,omitempty"`
+	Timeout   string                 `json:"timeout,omitempty"`
+}
+
+// ToMap converts the parameters into the generic CollectorSpec parameter map
+func (p LogsParams) ToMap() map[string]interface{} {
+	params := map[string]interface{}{}
+	if p.Name != "" {
+		params["name"] = p.Name
+	}
+	if p.Namespace != "" {
+		params["namespace"] = p.Namespace
+	}
+	if len(p.Selector) > 0 {
+		params["selector"] = p.Selector
+	}
+	if len(p.ContainerNames) > 0 {
+		params["containerNames"] = p.ContainerNames
+	}
+	if p.Limits != nil {
+		limits := map[string]interface{}{}
+		if p.Limits.MaxAge != "" {
+			limits["maxAge"] = p.Limits.MaxAge
+		}
+		if p.Limits.MaxLines > 0 {
+			limits["maxLines"] = p.Limits.MaxLines
+		}
+		if p.Limits.SinceTime != "" {
+			limits["sinceTime"] = p.Limits.SinceTime
+		}
+		params["limits"] = limits
+	}
+	if p.Timestamps {
+		params["timestamps"] = true
+	}
+	if p.NormalizeTimestamps {
+		params["normalizeTimestamps"] = true
+	}
+	return params
+}
+
+// Validate checks that the logs collector targets a namespace and either a pod name or selector
+func (p LogsParams) Validate() error {
+	if p.Namespace == "" {
+		return fmt.Errorf("logs collector requires a namespace")
+	}
+	if p.Name == "" && len(p.Selector) == 0 {
+		return fmt.Errorf("logs collector requires a pod name or selector")
+	}
+	if p.Limits != nil && p.Limits.MaxLines < 0 {
+		return fmt.Errorf("logs collector maxLines cannot be negative")
+	}
+	if p.Limits != nil && p.Limits.SinceTime != "" {
+		if _, err := time.Parse(time.RFC3339, p.Limits.SinceTime); err != nil {
+			return fmt.Errorf("logs collector sinceTime must be an RFC3339 timestamp: %w", err)
+		}
+	}
+	return nil
+}
+
+// ToMap converts the parameters into the generic CollectorSpec parameter map
+func (p ClusterResourcesParams) ToMap() map[string]interface{} {
+	params := map[string]interface{}{
+		"group":    p.Group,
+		"version":  p.Version,
+		"resource": p.Resource,
+	}
+	if len(p.Namespaces) > 0 {
+		params["namespaces"] = p.Namespaces
+	}
+	if len(p.FieldSelectors) > 0 {
+		params["fieldSelectors"] = p.FieldSelectors
+	}
+	if p.MaxObjectSize > 0 {
+		params["maxObjectSize"] = p.MaxObjectSize
+	}
+	if len(p.AlwaysCaptureKeys) > 0 {
+		params["alwaysCaptureKeys"] = p.AlwaysCaptureKeys
+	}
+	if len(p.SecretPolicy) > 0 {
+		params["secretPolicy"] = p.SecretPolicy
+	}
+	if len(p.Resources) > 0 {
+		params["resources"] = p.Resources
+	}
+	if len(p.ExcludeNamespaces) > 0 {
+		params["excludeNamespaces"] = p.ExcludeNamespaces
+	}
+	return params
+}
+
+// Validate checks that the cluster-resources collector names a resource and version, or a list of them
+func (p ClusterResourcesParams) Validate() error {
+	if len(p.Resources) > 0 {
+		for i, ref := range p.Resources {
+			if ref.Resource == "" || ref.Version == "" {
+				return fmt.Errorf("cluster-resources collector resources[%d] requires a resource and version", i)
+			}
+		}
+	} else if p.Resource == "" {
+		return fmt.Errorf("cluster-resources collector requires a resource")
+	} else if p.Version == "" {
+		return fmt.Errorf("cluster-resources collector requires a version")
+	}
+	if _, err := ParseFieldSelectors(p.FieldSelectors); err != nil {
+		return fmt.Errorf("cluster-resources collector: %w", err)
+	}
+	if err := (LargeObjects{MaxSize: p.MaxObjectSize, AlwaysCaptureKeys: p.AlwaysCaptureKeys}).Validate(); err != nil {
+		return fmt.Errorf("cluster-resources collector: %w", err)
+	}
+	if err := (SecretPolicy{Types: p.SecretPolicy}).Validate(); err != nil {
+		return fmt.Errorf("cluster-resources collector secretPolicy: %w", err)
+	}
+	if err := ValidateProtectedNamespaces(p.ExcludeNamespaces); err != nil {
+		return fmt.Errorf("cluster-resources collector excludeNamespaces: %w", err)
+	}
+	return nil
+}
+
+// ToMap converts the parameters into the generic CollectorSpec parameter map
+func (p RunPodParams) ToMap() map[string]interface{} {
+	params := map[string]interface{}{}
+	if p.Name != "" {
+		params["name"] = p.Name
+	}
+	if p.Namespace != "" {
+		params["namespace"] = p.Namespace
+	}
+	if p.PodSpec != nil {
+		params["podSpec"] = p.PodSpec
+	}
+	if p.Timeout != "" {
+		params["timeout"] = p.Timeout
+	}
+	return params
+}
+
+// Validate checks that the run-pod collector has a name, namespace and containers to run
+func (p RunPodParams) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("run-pod collector requires a name")
+	}
+	if p.Namespace == "" {
+		return fmt.Errorf("run-pod collector requires a namespace")
+	}
+	if p.PodSpec == nil {
+		return fmt.Errorf("run-pod collector requires a podSpec")
+	}
+	switch containers := p.PodSpec["containers"].(type) {
+	case []map[string]interface{}:
+		if len(containers) > 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(containers) > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("run-pod collector podSpec requires at least one container")
+}
+
+// LogsParams decodes the parameters of a logs collector
+func (c CollectorSpec) LogsParams() (*LogsParams, error) {
+	params := &LogsParams{}
+	if err := c.decodeParameters(CollectorTypeLogs, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// ClusterResourcesParams decodes the parameters of a cluster-resources collector
+func (c CollectorSpec) ClusterResourcesParams() (*ClusterResourcesParams, error) {
+	params := &ClusterResourcesParams{}
+	if err := c.decodeParameters(CollectorTypeClusterResources, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// RunPodParams decodes the parameters of a run-pod collector
+func (c CollectorSpec) RunPodParams() (*RunPodParams, error) {
+	params := &RunPodParams{}
+	if err := c.decodeParameters(CollectorTypeRunPod, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// ValidateParameters validates the parameters of collector types that have typed parameters
+// Parameters of other collector types are left unchecked
+func (c CollectorSpec) ValidateParameters() error {
+	var err error
+	switch c.Type {
+	case CollectorTypeLogs:
+		var params *LogsParams
+		if params, err = c.LogsParams(); err == nil {
+			err = params.Validate()
+		}
+	case CollectorTypeClusterResources:
+		var params *ClusterResourcesParams
+		if params, err = c.ClusterResourcesParams(); err == nil {
+			err = params.Validate()
+		}
+	case CollectorTypeRunPod:
+		var params *RunPodParams
+		if params, err = c.RunPodParams(); err == nil {
+			err = params.Validate()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid collector %s: %w", c.Name, withKind(ErrSpecInvalid, err))
+	}
+	return nil
+}
+
+// decodeParameters round-trips the generic parameter map through JSON into a typed struct
+// so values decoded from JSON or YAML (float64 numbers, []interface{} lists) convert cleanly
+func (c CollectorSpec) decodeParameters(collectorType string, out interface{}) error {
+	if c.Type != collectorType {
+		return fmt.Errorf("collector %s has type %q, not %q", c.Name, c.Type, collectorType)
+	}
+
+	data, err := json.Marshal(c.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s parameters: %w", collectorType, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s parameters: %w", collectorType, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCollectorSpec_LogsParamsRoundTrip(t *testing.T) {
+	original := LogsParams{
+		Namespace: "default",
+		Selector:  []string{"app=web"},
+		Limits:    &LogsLimits{MaxAge: "72h", MaxLines: 10000},
+	}
+	spec := CollectorSpec{Type: "logs", Name: "auto-logs-default", Parameters: original.ToMap()}
+
+	// Round-trip through JSON so numbers and lists come back as float64 and []interface{}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("Failed to marshal spec: %v", err)
+	}
+	var decoded CollectorSpec
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal spec: %v", err)
+	}
+
+	params, err := decoded.LogsParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Namespace != "default" || len(params.Selector) != 1 || params.Selector[0] != "app=web" {
+		t.Errorf("Expected namespace default with selector app=web, got %+v", params)
+	}
+	if params.Limits == nil || params.Limits.MaxAge != "72h" || params.Limits.MaxLines != 10000 {
+		t.Errorf("Expected limits 72h/10000, got %+v", params.Limits)
+	}
+}
+
+func TestCollectorSpec_ClusterResourcesParams(t *testing.T) {
+	spec := CollectorSpec{
+		Type: "cluster-resources",
+		Name: "auto-resources-apps-deployments",
+		Parameters: map[string]interface{}{
+			"group":      "apps",
+			"version":    "v1",
+			"resource":   "deployments",
+			"namespaces": []interface{}{"default", "app"},
+			"custom":     "ignored",
+		},
+	}
+
+	params, err := spec.ClusterResourcesParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Group != "apps" || params.Version != "v1" || params.Resource != "deployments" {
+		t.Errorf("Expected apps/v1/deployments, got %+v", params)
+	}
+	if len(params.Namespaces) != 2 {
+		t.Errorf("Expected 2 namespaces, got %v", params.Namespaces)
+	}
+
+	if _, err := spec.LogsParams(); err == nil {
+		t.Errorf("Expected error decoding cluster-resources parameters as logs parameters")
+	}
+}
+
+func TestCollectorSpec_RunPodParams(t *testing.T) {
+	original := RunPodParams{
+		Name:      "network-diagnostic-default",
+		Namespace: "default",
+		PodSpec: map[string]interface{}{
+			"containers": []map[string]interface{}{
+				{"name": "diagnostic", "image": "nicolaka/netshoot:latest"},
+			},
+		},
+		Timeout: "60s",
+	}
+	spec := CollectorSpec{Type: "run-pod", Name: "auto-network-diag-default", Parameters: original.ToMap()}
+
+	params, err := spec.RunPodParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Name != original.Name || params.Timeout != "60s" {
+		t.Errorf("Expected name %s and timeout 60s, got %+v", original.Name, params)
+	}
+	if err := params.Validate(); err != nil {
+		t.Errorf("Expected decoded run-pod parameters to be valid, got %v", err)
+	}
+}
+
+func TestCollectorSpec_ValidateParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    CollectorSpec
+		wantErr bool
+	}{
+		{
+			name:    "valid logs",
+			spec:    CollectorSpec{Type: "logs", Parameters: LogsParams{Name: "web", Namespace: "default"}.ToMap()},
+			wantErr: false,
+		},
+		{
+			name:    "logs without target",
+			spec:    CollectorSpec{Type: "logs", Parameters: LogsParams{Namespace: "default"}.ToMap()},
+			wantErr: true,
+		},
+		{
+			name:    "logs with malformed limits",
+			spec:    CollectorSpec{Type: "logs", Parameters: map[string]interface{}{"name": "web", "namespace": "default", "limits": "none"}},
+			wantErr: true,
+		},
+		{
+			name:    "cluster-resources without resource",
+			spec:    CollectorSpec{Type: "cluster-resources", Parameters: ClusterResourcesParams{Version: "v1"}.ToMap()},
+			wantErr: true,
+		},
+		{
+			name:    "batched cluster-resources",
+			spec:    CollectorSpec{Type: "cluster-resources", Parameters: ClusterResourcesParams{Namespaces: []string{"default"}, Resources: []ResourceRef{{Version: "v1", Resource: "services"}, {Group: "apps", Version: "v1", Resource: "deployments"}}}.ToMap()},
+			wantErr: false,
+		},
+		{
+			name:    "batched cluster-resources without version",
+			spec:    CollectorSpec{Type: "cluster-resources", Parameters: ClusterResourcesParams{Resources: []ResourceRef{{Resource: "services"}}}.ToMap()},
+			wantErr: true,
+		},
+		{
+			name:    "run-pod without containers",
+			spec:    CollectorSpec{Type: "run-pod", Parameters: RunPodParams{Name: "diag", Namespace: "default", PodSpec: map[string]interface{}{}}.ToMap()},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type keeps generic map",
+			spec:    CollectorSpec{Type: "custom", Parameters: map[string]interface{}{"anything": 1}},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.ValidateParameters()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResourceExpander_GeneratedParametersValidate(t *testing.T) {
+	expander := NewResourceExpander()
+	resources := []Resource{
+		{GVR: podsGVR, Namespace: "default", Name: "web-1"},
+		{GVR: podsGVR, Namespace: "default", Name: "web-2", Labels: map[string]string{"status": "error"}},
+		{GVR: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}, Namespace: "default", Name: "web"},
+	}
+
+	collectors, err := expander.ExpandToCollectors(context.Background(), resources, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(collectors) == 0 {
+		t.Fatalf("Expected generated collectors")
+	}
+	for _, collector := range collectors {
+		if err := collector.ValidateParameters(); err != nil {
+			t.Errorf("Expected generated collector %s to be valid, got %v", collector.Name, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Actions of a CollectorRedactionRule
+const (
+	RedactionActionRegex = "regex" // Replace the matches of Pattern
+	RedactionActionFull  = "full"  // Replace the whole content of every output file
+)
+
+// DefaultRedactionReplacement replaces redacted text when a rule sets no replacement
+const DefaultRedactionReplacement = "***HIDDEN***"
+
+// CollectorRedactionRule redacts the output of the collectors it matches right after each one runs, so
+// unredacted data is never kept in the bundle. Empty match lists match everything
+type CollectorRedactionRule struct {
+	Name           string   `json:"name" yaml:"name"`
+	CollectorTypes []string `json:"collectorTypes,omitempty" yaml:"collectorTypes,omitempty"` // e.g. logs, exec
+	Namespaces     []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`         // Cluster-scoped collectors only match rules without namespaces
+	Resources      []string `json:"resources,omitempty" yaml:"resources,omitempty"`           // Resource of cluster-resources collectors, e.g. secrets
+	Collectors     []string `json:"collectors,omitempty" yaml:"collectors,omitempty"`         // Collector name globs, e.g. auto-exec-*
+	Action         string   `json:"action" yaml:"action"`                                     // "regex" or "full"
+	Pattern        string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`               // Go regexp, required by the regex action
+	Replacement    string   `json:"replacement,omitempty" yaml:"replacement,omitempty"`       // Defaults to DefaultRedactionReplacement
+}
+
+// Validate checks the rule has a name, a known action and valid patterns
+func (r CollectorRedactionRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.Action {
+	case RedactionActionRegex:
+		if r.Pattern == "" {
+			return fmt.Errorf("pattern is required by the %s action", RedactionActionRegex)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case RedactionActionFull:
+		if r.Pattern != "" {
+			return fmt.Errorf("pattern cannot be used with the %s action", RedactionActionFull)
+		}
+	default:
+		return fmt.Errorf("invalid action %q (valid: %s, %s)", r.Action, RedactionActionRegex, RedactionActionFull)
+	}
+	for _, glob := range r.Collectors {
+		if err := ValidateGlob(glob); err != nil {
+			return fmt.Errorf("invalid collector glob: %w", err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to the collector
+func (r CollectorRedactionRule) Matches(collector CollectorSpec) bool {
+	if len(r.CollectorTypes) > 0 && !containsString(r.CollectorTypes, collector.Type) {
+		return false
+	}
+	if len(r.Namespaces) > 0 && !MatchesAnyGlob(r.Namespaces, collectorRedactionNamespace(collector)) {
+		return false
+	}
+	if len(r.Resources) > 0 {
+		params, err := collector.ClusterResourcesParams()
+		if collector.Type != CollectorTypeClusterResources || err != nil {
+			return false
+		}
+		matched := false
+		for _, gvr := range params.GVRs() {
+			if containsString(r.Resources, gvr.Resource) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.Collectors) > 0 {
+		if !MatchesAnyGlob(r.Collectors, collector.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectorRedactionNamespace returns the namespace a collector reads from, cluster-resources collectors
+// scoped to a single namespace count as that namespace
+func collectorRedactionNamespace(collector CollectorSpec) string {
+	if collector.Namespace != "" {
+		return collector.Namespace
+	}
+	if collector.Type == CollectorTypeClusterResources {
+		if params, err := collector.ClusterResourcesParams(); err == nil && len(params.Namespaces) == 1 {
+			return params.Namespaces[0]
+		}
+	}
+	return ""
+}
+
+// compiledRedactionRule is a validated rule with its pattern compiled
+type compiledRedactionRule struct {
+	CollectorRedactionRule
+	pattern     *regexp.Regexp
+	replacement []byte
+}
+
+// CollectorRedactor applies redaction rules to the files each collector writes
+type CollectorRedactor struct {
+	rules []compiledRedactionRule
+
+	mu       sync.Mutex
+	redacted map[string]int // Files changed per rule name
+}
+
+// NewCollectorRedactor validates and compiles the rules, it returns nil when there are none
+func NewCollectorRedactor(rules []CollectorRedactionRule) (*CollectorRedactor, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	redactor := &CollectorRedactor{redacted: make(map[string]int)}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, withKind(ErrSpecInvalid, fmt.Errorf("redaction rule %q: %w", rule.Name, err))
+		}
+		compiled := compiledRedactionRule{CollectorRedactionRule: rule, replacement: []byte(rule.Replacement)}
+		if rule.Replacement == "" {
+			compiled.replacement = []byte(DefaultRedactionReplacement)
+		}
+		if rule.Action == RedactionActionRegex {
+			compiled.pattern = regexp.MustCompile(rule.Pattern)
+		}
+		redactor.rules = append(redactor.rules, compiled)
+	}
+	return redactor, nil
+}
+
+// Redact applies the matching rules, in order, to the collector's output files, relative to outputDir
+// It returns the number of files that changed
+func (r *CollectorRedactor) Redact(collector CollectorSpec, outputDir string, outputs []string) (int, error) {
+	var rules []compiledRedactionRule
+	for _, rule := range r.rules {
+		if rule.Matches(collector) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	changed := 0
+	for _, output := range outputs {
+		file := filepath.Join(outputDir, output)
+		info, err := os.Stat(file)
+		if err != nil {
+			return changed, fmt.Errorf("failed to redact %s: %w", output, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return changed, fmt.Errorf("failed to redact %s: %w", output, err)
+		}
+
+		redacted := data
+		var applied []string
+		for _, rule := range rules {
+			var next []byte
+			if rule.Action == RedactionActionFull {
+				next = rule.replacement
+			} else {
+				next = rule.pattern.ReplaceAllLiteral(redacted, rule.replacement)
+			}
+			if string(next) != string(redacted) {
+				applied = append(applied, rule.Name)
+			}
+			redacted = next
+		}
+		if len(applied) == 0 {
+			continue
+		}
+
+		if err := os.WriteFile(file, redacted, info.Mode().Perm()); err != nil {
+			return changed, fmt.Errorf("failed to redact %s: %w", output, err)
+		}
+		changed++
+		r.mu.Lock()
+		for _, name := range applied {
+			r.redacted[name]++
+		}
+		r.mu.Unlock()
+	}
+	return changed, nil
+}
+
+// RedactedFiles returns the number of files each rule changed, nil when nothing was redacted
+func (r *CollectorRedactor) RedactedFiles() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.redacted) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(r.redacted))
+	for name, count := range r.redacted {
+		counts[name] = count
+	}
+	return counts
+}
@@ -0,0 +1,145 @@
+package autodiscovery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectorRedactionRule_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        CollectorRedactionRule
+		expectError bool
+	}{
+		{name: "regex", rule: CollectorRedactionRule{Name: "cards", Action: RedactionActionRegex, Pattern: `\d{4}-\d{4}`}},
+		{name: "full", rule: CollectorRedactionRule{Name: "secrets", Action: RedactionActionFull, Resources: []string{"secrets"}}},
+		{name: "missing name", rule: CollectorRedactionRule{Action: RedactionActionFull}, expectError: true},
+		{name: "unknown action", rule: CollectorRedactionRule{Name: "x", Action: "mask"}, expectError: true},
+		{name: "regex without pattern", rule: CollectorRedactionRule{Name: "x", Action: RedactionActionRegex}, expectError: true},
+		{name: "invalid pattern", rule: CollectorRedactionRule{Name: "x", Action: RedactionActionRegex, Pattern: "("}, expectError: true},
+		{name: "full with pattern", rule: CollectorRedactionRule{Name: "x", Action: RedactionActionFull, Pattern: "a"}, expectError: true},
+		{name: "invalid glob", rule: CollectorRedactionRule{Name: "x", Action: RedactionActionFull, Collectors: []string{"auto-["}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestCollectorRedactionRule_Matches(t *testing.T) {
+	paymentsLogs := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-payments", Namespace: "payments"}
+	otherLogs := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-web", Namespace: "web"}
+	secrets := CollectorSpec{
+		Type:       CollectorTypeClusterResources,
+		Name:       "auto-resources-_v1_secrets",
+		Parameters: ClusterResourcesParams{Version: "v1", Resource: "secrets", Namespaces: []string{"payments"}}.ToMap(),
+	}
+
+	tests := []struct {
+		name      string
+		rule      CollectorRedactionRule
+		collector CollectorSpec
+		expected  bool
+	}{
+		{name: "type and namespace", rule: CollectorRedactionRule{CollectorTypes: []string{CollectorTypeLogs}, Namespaces: []string{"payments"}}, collector: paymentsLogs, expected: true},
+		{name: "other namespace", rule: CollectorRedactionRule{CollectorTypes: []string{CollectorTypeLogs}, Namespaces: []string{"payments"}}, collector: otherLogs, expected: false},
+		{name: "other type", rule: CollectorRedactionRule{CollectorTypes: []string{CollectorTypeExec}}, collector: paymentsLogs, expected: false},
+		{name: "resource", rule: CollectorRedactionRule{Resources: []string{"secrets"}}, collector: secrets, expected: true},
+		{name: "resource on logs", rule: CollectorRedactionRule{Resources: []string{"secrets"}}, collector: paymentsLogs, expected: false},
+		{name: "cluster-resources namespace", rule: CollectorRedactionRule{Namespaces: []string{"payments"}}, collector: secrets, expected: true},
+		{name: "name glob", rule: CollectorRedactionRule{Collectors: []string{"auto-logs-*"}}, collector: otherLogs, expected: true},
+		{name: "name glob mismatch", rule: CollectorRedactionRule{Collectors: []string{"auto-exec-*"}}, collector: otherLogs, expected: false},
+		{name: "empty rule", rule: CollectorRedactionRule{}, collector: secrets, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matched := tt.rule.Matches(tt.collector); matched != tt.expected {
+				t.Errorf("Expected match %v, got %v", tt.expected, matched)
+			}
+		})
+	}
+}
+
+func TestCollectorRedactor_Redact(t *testing.T) {
+	redactor, err := NewCollectorRedactor([]CollectorRedactionRule{
+		{Name: "payments-cards", CollectorTypes: []string{CollectorTypeLogs}, Namespaces: []string{"payments"}, Action: RedactionActionRegex, Pattern: `card=\d+`, Replacement: "card=REDACTED"},
+		{Name: "secrets", Resources: []string{"secrets"}, Action: RedactionActionFull},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(outputDir, name)), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+	write("payments/api.log", "charged card=4111111111111111 ok\nno card here\n")
+	write("web/api.log", "charged card=4111111111111111 ok\n")
+	write("resources/secrets.json", `{"data":{"password":"aHVudGVyMg=="}}`)
+
+	payments := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-payments", Namespace: "payments"}
+	if changed, err := redactor.Redact(payments, outputDir, []string{"payments/api.log", "payments"}); err != nil || changed != 1 {
+		t.Fatalf("Expected 1 changed file, got %d (%v)", changed, err)
+	}
+	if got := read("payments/api.log"); got != "charged card=REDACTED ok\nno card here\n" {
+		t.Errorf("Expected the card number to be redacted, got %q", got)
+	}
+
+	web := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-web", Namespace: "web"}
+	if changed, err := redactor.Redact(web, outputDir, []string{"web/api.log"}); err != nil || changed != 0 {
+		t.Fatalf("Expected logs of other namespaces to be left alone, got %d (%v)", changed, err)
+	}
+
+	secrets := CollectorSpec{
+		Type:       CollectorTypeClusterResources,
+		Name:       "auto-resources-_v1_secrets",
+		Parameters: ClusterResourcesParams{Version: "v1", Resource: "secrets"}.ToMap(),
+	}
+	if _, err := redactor.Redact(secrets, outputDir, []string{"resources/secrets.json"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := read("resources/secrets.json"); got != DefaultRedactionReplacement {
+		t.Errorf("Expected the secrets output to be fully redacted, got %q", got)
+	}
+
+	counts := redactor.RedactedFiles()
+	if counts["payments-cards"] != 1 || counts["secrets"] != 1 {
+		t.Errorf("Expected one file per rule, got %v", counts)
+	}
+
+	if _, err := redactor.Redact(payments, outputDir, []string{"payments/missing.log"}); err == nil {
+		t.Errorf("Expected an error for a missing output file")
+	}
+}
+
+func TestNewCollectorRedactor(t *testing.T) {
+	redactor, err := NewCollectorRedactor(nil)
+	if err != nil || redactor != nil {
+		t.Errorf("Expected no redactor without rules, got %v (%v)", redactor, err)
+	}
+
+	_, err = NewCollectorRedactor([]CollectorRedactionRule{{Name: "bad", Action: RedactionActionRegex}})
+	if !errors.Is(err, ErrSpecInvalid) {
+		t.Errorf("Expected ErrSpecInvalid for an invalid rule, got %v", err)
+	}
+}
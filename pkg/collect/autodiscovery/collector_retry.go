@@ -0,0 +1,300 @@
+package autodiscovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Error classes a RetryPolicy can retry, errors outside these classes fail the collector on the first attempt
+const (
+	RetryClassTimeout     = "timeout"      // Deadlines and API server timeouts
+	RetryClassThrottled   = "throttled"    // 429 responses from the API server
+	RetryClassServerError = "server-error" // 5xx responses from the API server
+	RetryClassNetwork     = "network"      // Refused or reset connections
+	RetryClassExec        = "exec"         // Exec into a container that is restarting or not running yet
+)
+
+// RetryClasses are all the error classes, a policy without RetryOn retries every one of them
+var RetryClasses = []string{RetryClassTimeout, RetryClassThrottled, RetryClassServerError, RetryClassNetwork, RetryClassExec}
+
+// Backoff defaults of a RetryPolicy that sets attempts but no backoff
+const (
+	DefaultRetryBackoff    = time.Second
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// execRetryMessages are the messages of exec failures caused by a container that is restarting
+var execRetryMessages = []string{
+	"container not found",
+	"is not running",
+	"unable to upgrade connection",
+	"error dialing backend",
+}
+
+// RetryPolicy controls how often a failed collector is rerun. The zero value does not retry
+type RetryPolicy struct {
+	Attempts   int           `json:"attempts,omitempty" yaml:"attempts,omitempty"`     // Total attempts including the first, 1 disables retries
+	Backoff    time.Duration `json:"backoff,omitempty" yaml:"backoff,omitempty"`       // Wait before the first retry, doubled for each later one
+	MaxBackoff time.Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"` // Caps the doubled wait
+	RetryOn    []string      `json:"retryOn,omitempty" yaml:"retryOn,omitempty"`       // Error classes to retry, defaults to RetryClasses
+}
+
+// RetryConfig sets the retry policy of every collector and overrides it per collector type
+type RetryConfig struct {
+	Default        RetryPolicy            `json:"default,omitempty" yaml:"default,omitempty"`
+	CollectorTypes map[string]RetryPolicy `json:"collectorTypes,omitempty" yaml:"collectorTypes,omitempty"` // e.g. exec, the fields set replace the default ones
+}
+
+// CollectorRetry records the attempts of a collector that was retried
+type CollectorRetry struct {
+	Collector string         `json:"collector"`
+	Type      string         `json:"type"`
+	Attempts  int            `json:"attempts"` // Including the first
+	Succeeded bool           `json:"succeeded"`
+	Failures  []RetryFailure `json:"failures"`
+}
+
+// RetryFailure is a failed attempt of a retried collector
+type RetryFailure struct {
+	Attempt int           `json:"attempt"`
+	Class   string        `json:"class,omitempty"` // Empty when the error is not retryable
+	Error   string        `json:"error"`
+	Backoff time.Duration `json:"backoff,omitempty"` // Wait before the next attempt, 0 when none followed
+}
+
+// WithOverrides returns the policy with every set override applied
+func (p RetryPolicy) WithOverrides(overrides RetryPolicy) RetryPolicy {
+	if overrides.Attempts > 0 {
+		p.Attempts = overrides.Attempts
+	}
+	if overrides.Backoff > 0 {
+		p.Backoff = overrides.Backoff
+	}
+	if overrides.MaxBackoff > 0 {
+		p.MaxBackoff = overrides.MaxBackoff
+	}
+	if len(overrides.RetryOn) > 0 {
+		p.RetryOn = overrides.RetryOn
+	}
+	return p
+}
+
+// Validate checks that attempts and backoffs are not negative and that every error class is known
+func (p RetryPolicy) Validate() error {
+	if p.Attempts < 0 {
+		return fmt.Errorf("attempts cannot be negative")
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("backoff cannot be negative")
+	}
+	if p.Backoff > 0 && p.MaxBackoff > 0 && p.MaxBackoff < p.Backoff {
+		return fmt.Errorf("maxBackoff %s is shorter than backoff %s", p.MaxBackoff, p.Backoff)
+	}
+	for _, class := range p.RetryOn {
+		if !containsString(RetryClasses, class) {
+			return fmt.Errorf("unknown error class %q (valid: %s)", class, strings.Join(RetryClasses, ", "))
+		}
+	}
+	return nil
+}
+
+// Retries reports whether an error of class is retried by the policy
+func (p RetryPolicy) Retries(class string) bool {
+	if class == "" || p.Attempts <= 1 {
+		return false
+	}
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+	return containsString(p.RetryOn, class)
+}
+
+// backoff returns the wait before the given retry, the first retry is 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait, limit := p.Backoff, p.MaxBackoff
+	if wait == 0 {
+		wait = DefaultRetryBackoff
+	}
+	if limit == 0 {
+		limit = DefaultRetryMaxBackoff
+	}
+	if limit < wait {
+		limit = wait
+	}
+	for i := 1; i < retry && wait < limit; i++ {
+		wait *= 2
+	}
+	if wait > limit {
+		wait = limit
+	}
+	return wait
+}
+
+// Validate checks the default policy and the policy of every collector type
+func (c RetryConfig) Validate() error {
+	if err := c.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for collectorType, policy := range c.CollectorTypes {
+		if collectorType == "" {
+			return fmt.Errorf("collector type cannot be empty")
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("collector type %s: %w", collectorType, err)
+		}
+	}
+	return nil
+}
+
+// PolicyFor returns the policy of a collector type, the default with the type's overrides applied
+func (c RetryConfig) PolicyFor(collectorType string) RetryPolicy {
+	return c.Default.WithOverrides(c.CollectorTypes[collectorType])
+}
+
+// IsZero reports whether no policy retries
+func (c RetryConfig) IsZero() bool {
+	if c.Default.Attempts > 1 {
+		return false
+	}
+	for _, policy := range c.CollectorTypes {
+		if policy.Attempts > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// ClassifyRetryableError returns the retry class of a collector error, or "" when retrying cannot help
+func ClassifyRetryableError(err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return ""
+	case errors.Is(err, context.DeadlineExceeded), apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return RetryClassTimeout
+	case errors.Is(err, ErrK8sThrottled), apierrors.IsTooManyRequests(err):
+		return RetryClassThrottled
+	case apierrors.IsInternalError(err), apierrors.IsServiceUnavailable(err), apierrors.IsUnexpectedServerError(err):
+		return RetryClassServerError
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return RetryClassTimeout
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code >= 500 {
+		return RetryClassServerError
+	}
+
+	message := err.Error()
+	for _, fragment := range execRetryMessages {
+		if strings.Contains(message, fragment) {
+			return RetryClassExec
+		}
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr) {
+		return RetryClassNetwork
+	}
+	return ""
+}
+
+// CollectorRetrier reruns collectors that fail with a retryable error, following the policy of their type
+type CollectorRetrier struct {
+	config  RetryConfig
+	sleep   func(ctx context.Context, d time.Duration) error
+	mu      sync.Mutex
+	retries []CollectorRetry
+}
+
+// NewCollectorRetrier validates the config and returns a retrier, nil when no policy retries
+func NewCollectorRetrier(config RetryConfig) (*CollectorRetrier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, withKind(ErrSpecInvalid, fmt.Errorf("retries: %w", err))
+	}
+	if config.IsZero() {
+		return nil, nil
+	}
+	return &CollectorRetrier{config: config, sleep: sleepContext}, nil
+}
+
+// Run runs the collector until it succeeds, fails with an error its policy does not retry or runs out of attempts
+// The outputs of a failed attempt, relative to outputDir, are removed before the next one
+func (r *CollectorRetrier) Run(ctx context.Context, collector CollectorSpec, outputDir string, run func(ctx context.Context, collector CollectorSpec, outputDir string) ([]string, error)) ([]string, error) {
+	policy := r.config.PolicyFor(collector.Type)
+	record := CollectorRetry{Collector: collector.Name, Type: collector.Type}
+
+	for attempt := 1; ; attempt++ {
+		outputs, err := run(ctx, collector, outputDir)
+		record.Attempts = attempt
+		if err == nil {
+			record.Succeeded = true
+			r.record(record)
+			return outputs, nil
+		}
+
+		class := ClassifyRetryableError(err)
+		failure := RetryFailure{Attempt: attempt, Class: class, Error: err.Error()}
+		if ctx.Err() != nil || attempt >= policy.Attempts || !policy.Retries(class) {
+			record.Failures = append(record.Failures, failure)
+			r.record(record)
+			return outputs, err
+		}
+
+		failure.Backoff = policy.backoff(attempt)
+		record.Failures = append(record.Failures, failure)
+		fmt.Printf("Warning: collector %s failed with a %s error (attempt %d/%d), retrying in %s: %v\n",
+			collector.Name, class, attempt, policy.Attempts, failure.Backoff, err)
+		for _, output := range outputs {
+			if removeErr := os.RemoveAll(filepath.Join(outputDir, output)); removeErr != nil {
+				fmt.Printf("Warning: failed to remove output %s of the failed attempt: %v\n", output, removeErr)
+			}
+		}
+		if err := r.sleep(ctx, failure.Backoff); err != nil {
+			r.record(record)
+			return nil, err
+		}
+	}
+}
+
+// Retries returns the collectors that were retried, in the order they finished
+func (r *CollectorRetrier) Retries() []CollectorRetry {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CollectorRetry(nil), r.retries...)
+}
+
+// record keeps the attempts of collectors that were retried, a single attempt is not a retry
+func (r *CollectorRetrier) record(retry CollectorRetry) {
+	if retry.Attempts < 2 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries = append(r.retries, retry)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package autodiscovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassifyRetryableError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "deadline", err: fmt.Errorf("collector timed out: %w", context.DeadlineExceeded), expected: RetryClassTimeout},
+		{name: "server timeout", err: apierrors.NewServerTimeout(pods, "list", 1), expected: RetryClassTimeout},
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 1), expected: RetryClassThrottled},
+		{name: "throttled sentinel", err: withKind(ErrK8sThrottled, errors.New("rate limited")), expected: RetryClassThrottled},
+		{name: "internal error", err: apierrors.NewInternalError(errors.New("etcd leader changed")), expected: RetryClassServerError},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("apiserver restarting"), expected: RetryClassServerError},
+		{name: "connection reset", err: fmt.Errorf("read tcp: %w", syscall.ECONNRESET), expected: RetryClassNetwork},
+		{name: "container restarting", err: errors.New(`unable to upgrade connection: container not found ("postgres")`), expected: RetryClassExec},
+		{name: "forbidden", err: apierrors.NewForbidden(pods, "web", errors.New("denied")), expected: ""},
+		{name: "not found", err: apierrors.NewNotFound(pods, "web"), expected: ""},
+		{name: "cancelled", err: fmt.Errorf("interrupted: %w", context.Canceled), expected: ""},
+		{name: "nil", err: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if class := ClassifyRetryableError(tt.err); class != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, class)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{Attempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, wait := range expected {
+		if backoff := policy.backoff(i + 1); backoff != wait {
+			t.Errorf("Expected retry %d to wait %s, got %s", i+1, wait, backoff)
+		}
+	}
+
+	if backoff := (RetryPolicy{Attempts: 2}).backoff(1); backoff != DefaultRetryBackoff {
+		t.Errorf("Expected the default backoff %s, got %s", DefaultRetryBackoff, backoff)
+	}
+}
+
+func TestRetryConfig_PolicyFor(t *testing.T) {
+	config := RetryConfig{
+		Default: RetryPolicy{Attempts: 2, Backoff: time.Second},
+		CollectorTypes: map[string]RetryPolicy{
+			CollectorTypeExec: {Attempts: 4, RetryOn: []string{RetryClassExec}},
+		},
+	}
+
+	exec := config.PolicyFor(CollectorTypeExec)
+	if exec.Attempts != 4 || exec.Backoff != time.Second {
+		t.Errorf("Expected 4 attempts with the default backoff, got %+v", exec)
+	}
+	if exec.Retries(RetryClassThrottled) || !exec.Retries(RetryClassExec) {
+		t.Errorf("Expected exec collectors to retry only exec errors, got %v", exec.RetryOn)
+	}
+	if logs := config.PolicyFor(CollectorTypeLogs); logs.Attempts != 2 || !logs.Retries(RetryClassThrottled) {
+		t.Errorf("Expected logs collectors to use the default policy, got %+v", logs)
+	}
+}
+
+func TestRetryConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RetryConfig
+		wantErr bool
+	}{
+		{name: "empty", config: RetryConfig{}},
+		{name: "valid", config: RetryConfig{Default: RetryPolicy{Attempts: 3, Backoff: time.Second, MaxBackoff: 10 * time.Second}}},
+		{name: "negative attempts", config: RetryConfig{Default: RetryPolicy{Attempts: -1}}, wantErr: true},
+		{name: "max below backoff", config: RetryConfig{Default: RetryPolicy{Backoff: 10 * time.Second, MaxBackoff: time.Second}}, wantErr: true},
+		{name: "unknown class", config: RetryConfig{CollectorTypes: map[string]RetryPolicy{CollectorTypeExec: {RetryOn: []string{"oom"}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCollectorRetrier_Run(t *testing.T) {
+	retrier, err := NewCollectorRetrier(RetryConfig{
+		Default: RetryPolicy{Attempts: 3, Backoff: time.Second},
+		CollectorTypes: map[string]RetryPolicy{
+			CollectorTypeLogs: {Attempts: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var waits []time.Duration
+	retrier.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	outputDir := t.TempDir()
+	attempts := 0
+	restarting := func(ctx context.Context, collector CollectorSpec, outputDir string) ([]string, error) {
+		attempts++
+		output := fmt.Sprintf("exec/attempt-%d.txt", attempts)
+		os.MkdirAll(filepath.Join(outputDir, "exec"), 0755)
+		os.WriteFile(filepath.Join(outputDir, output), []byte("partial"), 0644)
+		if attempts < 3 {
+			return []string{output}, errors.New("container not found (\"db\")")
+		}
+		return []string{output}, nil
+	}
+
+	outputs, err := retrier.Run(context.Background(), CollectorSpec{Type: CollectorTypeExec, Name: "auto-exec-db"}, outputDir, restarting)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0] != "exec/attempt-3.txt" {
+		t.Errorf("Expected the outputs of the last attempt, got %v", outputs)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "exec/attempt-1.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected the outputs of failed attempts to be removed, got %v", err)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("Expected waits of 1s and 2s, got %v", waits)
+	}
+
+	failing := func(ctx context.Context, collector CollectorSpec, outputDir string) ([]string, error) {
+		return nil, apierrors.NewTooManyRequests("slow down", 1)
+	}
+	if _, err := retrier.Run(context.Background(), CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-web"}, outputDir, failing); err == nil {
+		t.Errorf("Expected logs collectors to fail without retries")
+	}
+	if _, err := retrier.Run(context.Background(), CollectorSpec{Type: CollectorTypeClusterResources, Name: "auto-pods"}, outputDir, failing); err == nil {
+		t.Errorf("Expected the error once the attempts run out")
+	}
+
+	retries := retrier.Retries()
+	if len(retries) != 2 {
+		t.Fatalf("Expected 2 retried collectors, got %+v", retries)
+	}
+	if !retries[0].Succeeded || retries[0].Attempts != 3 || len(retries[0].Failures) != 2 || retries[0].Failures[0].Class != RetryClassExec {
+		t.Errorf("Expected auto-exec-db to recover after 2 exec failures, got %+v", retries[0])
+	}
+	if retries[1].Succeeded || retries[1].Attempts != 3 || retries[1].Failures[2].Backoff != 0 {
+		t.Errorf("Expected auto-pods to fail after 3 attempts with no wait after the last, got %+v", retries[1])
+	}
+}
+
+func TestNewCollectorRetrier_NoRetries(t *testing.T) {
+	retrier, err := NewCollectorRetrier(RetryConfig{CollectorTypes: map[string]RetryPolicy{CollectorTypeExec: {Attempts: 1}}})
+	if err != nil || retrier != nil {
+		t.Errorf("Expected no retrier when no policy retries, got %v, %v", retrier, err)
+	}
+	if _, err := NewCollectorRetrier(RetryConfig{Default: RetryPolicy{Attempts: -1}}); !errors.Is(err, ErrSpecInvalid) {
+		t.Errorf("Expected ErrSpecInvalid, got %v", err)
+	}
+}
+
+func TestMergeConfigs_Retries(t *testing.T) {
+	base := &Config{Retries: RetryConfig{
+		Default:        RetryPolicy{Attempts: 3, Backoff: time.Second},
+		CollectorTypes: map[string]RetryPolicy{CollectorTypeExec: {Attempts: 5}},
+	}}
+	override := &Config{Retries: RetryConfig{
+		Default:        RetryPolicy{Backoff: 2 * time.Second},
+		CollectorTypes: map[string]RetryPolicy{CollectorTypeExec: {RetryOn: []string{RetryClassExec}}},
+	}}
+
+	merged := MergeConfigs(base, override).Retries
+	if merged.Default.Attempts != 3 || merged.Default.Backoff != 2*time.Second {
+		t.Errorf("Expected 3 attempts with a 2s backoff, got %+v", merged.Default)
+	}
+	if exec := merged.CollectorTypes[CollectorTypeExec]; exec.Attempts != 5 || len(exec.RetryOn) != 1 {
+		t.Errorf("Expected the exec policies to be merged, got %+v", exec)
+	}
+}
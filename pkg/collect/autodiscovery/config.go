@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"gopkg.in/yaml.v2"
 )
@@ -26,6 +27,48 @@ type Config struct {
 	
 	// Includes define additional resources to always include
 	Includes []ResourceIncludeRule `json:"includes,omitempty" yaml:"includes,omitempty"`
+
+	// IncludeSystemNamespaces disables the default system namespace excludes
+	IncludeSystemNamespaces bool `json:"includeSystemNamespaces,omitempty" yaml:"includeSystemNamespaces,omitempty"`
+
+	// Extends lists base config files merged in order before this file, paths are relative to this file
+	Extends []string `json:"extends,omitempty" yaml:"extends,omitempty"`
+
+	// Hooks declare exec or Go plugin hooks that adjust resources and collectors during discovery
+	Hooks []HookConfig `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+
+	// BundleReadme customizes the README.md written at the bundle root
+	BundleReadme BundleReadmeConfig `json:"bundleReadme,omitempty" yaml:"bundleReadme,omitempty"`
+
+	// ExecCatalog adds or replaces the read-only commands run in database and cache pods
+	ExecCatalog ExecCatalogConfig `json:"execCatalog,omitempty" yaml:"execCatalog,omitempty"`
+
+	// Redactions redact the output of matching collectors as they run
+	Redactions []CollectorRedactionRule `json:"redactions,omitempty" yaml:"redactions,omitempty"`
+
+	// Retries rerun collectors that fail transiently, globally and per collector type
+	Retries RetryConfig `json:"retries,omitempty" yaml:"retries,omitempty"`
+}
+
+// BundleReadmeConfig customizes the README.md written at the bundle root
+type BundleReadmeConfig struct {
+	Disabled     bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`         // Do not write a README
+	Title        string `json:"title,omitempty" yaml:"title,omitempty"`               // Heading of the default template
+	Notes        string `json:"notes,omitempty" yaml:"notes,omitempty"`               // Free text shown near the top, e.g. who to contact
+	Template     string `json:"template,omitempty" yaml:"template,omitempty"`         // Go text/template replacing the default template
+	TemplateFile string `json:"templateFile,omitempty" yaml:"templateFile,omitempty"` // File holding the template, relative to the config file
+}
+
+// SystemNamespaces are excluded from auto-discovery unless IncludeSystemNamespaces is set
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+const systemNamespaceExcludeReason = "System namespaces excluded by default"
+
+// SystemNamespacePolicy records whether system namespaces were collected, for auditing
+type SystemNamespacePolicy struct {
+	IncludeSystemNamespaces bool     `json:"includeSystemNamespaces"`
+	SystemNamespaces        []string `json:"systemNamespaces"`
+	ExcludedNamespaces      []string `json:"excludedNamespaces,omitempty"`
 }
 
 // ResourceFilterRule defines filtering criteria for resources
@@ -35,6 +78,7 @@ type ResourceFilterRule struct {
 	MatchNamespaces   []string                      `json:"matchNamespaces" yaml:"matchNamespaces"`
 	MatchLabels       map[string]string             `json:"matchLabels" yaml:"matchLabels"`
 	LabelSelector     string                        `json:"labelSelector" yaml:"labelSelector"`
+	FieldSelectors    []string                      `json:"fieldSelectors,omitempty" yaml:"fieldSelectors,omitempty"` // e.g. "status.phase=Failed", "spec.replicas>10"
 	Action            string                        `json:"action" yaml:"action"` // "include" or "exclude"
 }
 
@@ -77,28 +121,35 @@ func NewConfigManager() *ConfigManager {
 }
 
 // LoadFromFile loads configuration from a file (supports JSON and YAML)
+// Files listed under extends are loaded first and the file is layered on top
 func (c *ConfigManager) LoadFromFile(filePath string) error {
-	data, err := os.ReadFile(filePath)
+	config, err := loadConfigFile(filePath, nil)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return err
 	}
-
-	ext := filepath.Ext(filePath)
-	switch ext {
-	case ".json":
-		return c.LoadFromJSON(data)
-	case ".yaml", ".yml":
-		return c.LoadFromYAML(data)
-	default:
-		return fmt.Errorf("unsupported config file format: %s", ext)
+	if err := validateConfig(config); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", filePath, withKind(ErrSpecInvalid, err))
 	}
+
+	// Merge with defaults
+	c.config = mergeWithDefaults(config)
+	return nil
 }
 
 // LoadFromJSON loads configuration from JSON data
+// Relative extends paths are resolved against the working directory
 func (c *ConfigManager) LoadFromJSON(data []byte) error {
 	config := &Config{}
 	if err := json.Unmarshal(data, config); err != nil {
-		return fmt.Errorf("failed to parse JSON config: %w", err)
+		return fmt.Errorf("failed to parse JSON config: %w", withKind(ErrSpecInvalid, err))
+	}
+
+	config, err := resolveExtends(config, ".", nil)
+	if err != nil {
+		return err
+	}
+	if err := validateConfig(config); err != nil {
+		return fmt.Errorf("invalid config: %w", withKind(ErrSpecInvalid, err))
 	}
 
 	// Merge with defaults
@@ -107,10 +158,19 @@ func (c *ConfigManager) LoadFromJSON(data []byte) error {
 }
 
 // LoadFromYAML loads configuration from YAML data
+// Relative extends paths are resolved against the working directory
 func (c *ConfigManager) LoadFromYAML(data []byte) error {
 	config := &Config{}
 	if err := yaml.Unmarshal(data, config); err != nil {
-		return fmt.Errorf("failed to parse YAML config: %w", err)
+		return fmt.Errorf("failed to parse YAML config: %w", withKind(ErrSpecInvalid, err))
+	}
+
+	config, err := resolveExtends(config, ".", nil)
+	if err != nil {
+		return err
+	}
+	if err := validateConfig(config); err != nil {
+		return fmt.Errorf("invalid config: %w", withKind(ErrSpecInvalid, err))
 	}
 
 	// Merge with defaults
@@ -118,6 +178,31 @@ func (c *ConfigManager) LoadFromYAML(data []byte) error {
 	return nil
 }
 
+// GetHooks returns the hooks declared in the configuration
+func (c *ConfigManager) GetHooks() []HookConfig {
+	return c.config.Hooks
+}
+
+// GetExecCatalog returns the default exec command catalog with the configured entries applied
+func (c *ConfigManager) GetExecCatalog() *ExecCatalog {
+	return NewExecCatalogFromConfig(c.config.ExecCatalog)
+}
+
+// GetRedactionRules returns the collector redaction rules in evaluation order
+func (c *ConfigManager) GetRedactionRules() []CollectorRedactionRule {
+	return c.config.Redactions
+}
+
+// GetRetryConfig returns the collector retry policies
+func (c *ConfigManager) GetRetryConfig() RetryConfig {
+	return c.config.Retries
+}
+
+// GetBundleReadmeConfig returns the bundle README settings
+func (c *ConfigManager) GetBundleReadmeConfig() BundleReadmeConfig {
+	return c.config.BundleReadme
+}
+
 // GetConfig returns the current configuration
 func (c *ConfigManager) GetConfig() *Config {
 	return c.config
@@ -128,46 +213,136 @@ func (c *ConfigManager) GetDiscoveryOptions(overrides *DiscoveryOptions) Discove
 	options := c.config.DefaultOptions
 
 	if overrides != nil {
-		if len(overrides.Namespaces) > 0 {
-			options.Namespaces = overrides.Namespaces
+		options = MergeDiscoveryOptions(options, *overrides)
+	}
+
+	// Namespaces excluded by the rules are added to the merged excludes rather than replacing them
+	excludes := append([]string(nil), options.ExcludeNamespaces...)
+	seen := make(map[string]bool, len(excludes))
+	for _, ns := range excludes {
+		seen[ns] = true
+	}
+	for _, ns := range c.ExcludedNamespaces() {
+		if !seen[ns] {
+			seen[ns] = true
+			excludes = append(excludes, ns)
 		}
-		if overrides.IncludeImages {
-			options.IncludeImages = overrides.IncludeImages
+	}
+	options.ExcludeNamespaces = excludes
+
+	return options
+}
+
+// SetIncludeSystemNamespaces enables or disables the default system namespace excludes
+func (c *ConfigManager) SetIncludeSystemNamespaces(include bool) {
+	c.config.IncludeSystemNamespaces = include
+
+	var excludes []ResourceExcludeRule
+	for _, rule := range c.config.Excludes {
+		if !isSystemNamespaceExcludeRule(rule) {
+			excludes = append(excludes, rule)
 		}
-		if overrides.RBACCheck {
-			options.RBACCheck = overrides.RBACCheck
+	}
+	if !include {
+		excludes = append([]ResourceExcludeRule{systemNamespaceExcludeRule()}, excludes...)
+	}
+	c.config.Excludes = excludes
+}
+
+// ExcludedNamespaces returns namespaces excluded as a whole by the configured exclude rules
+func (c *ConfigManager) ExcludedNamespaces() []string {
+	var namespaces []string
+	seen := make(map[string]bool)
+
+	for _, rule := range c.config.Excludes {
+		// Rules that also match GVRs or names only exclude part of a namespace
+		if len(rule.GVRs) > 0 || len(rule.Names) > 0 {
+			continue
 		}
-		if overrides.MaxDepth > 0 {
-			options.MaxDepth = overrides.MaxDepth
+		for _, ns := range rule.Namespaces {
+			if !seen[ns] {
+				seen[ns] = true
+				namespaces = append(namespaces, ns)
+			}
 		}
 	}
 
-	return options
+	return namespaces
+}
+
+// GetSystemNamespacePolicy returns the effective system namespace policy
+func (c *ConfigManager) GetSystemNamespacePolicy() SystemNamespacePolicy {
+	return SystemNamespacePolicy{
+		IncludeSystemNamespaces: c.config.IncludeSystemNamespaces,
+		SystemNamespaces:        append([]string{}, SystemNamespaces...),
+		ExcludedNamespaces:      c.ExcludedNamespaces(),
+	}
 }
 
 // ApplyResourceFilters applies configured resource filters to a list of resources
 func (c *ConfigManager) ApplyResourceFilters(resources []Resource) []Resource {
 	filteredResources := resources
-
-	// Apply exclusion rules first
-	for _, exclude := range c.config.Excludes {
-		filteredResources = c.applyExcludeRule(filteredResources, exclude)
+	for _, step := range c.resourceFilterSteps(nil) {
+		filteredResources = step.apply(filteredResources)
 	}
+	return filteredResources
+}
 
-	// Apply filter rules
-	for _, filter := range c.config.ResourceFilters {
-		if filter.Action == "exclude" {
-			filteredResources = c.applyFilterRule(filteredResources, filter, true)
+// resourceFilterStep is one exclude or filter rule in the order ApplyResourceFilters applies them
+type resourceFilterStep struct {
+	name      string
+	action    string
+	candidate bool
+	matches   func(Resource) bool
+	apply     func([]Resource) []Resource
+}
+
+// resourceFilterSteps orders the configured rules followed by candidates: exclusion rules first,
+// then exclude filters, then include filters
+func (c *ConfigManager) resourceFilterSteps(candidates []ResourceFilterRule) []resourceFilterStep {
+	var steps []resourceFilterStep
+
+	for i, exclude := range c.config.Excludes {
+		exclude := exclude
+		name := exclude.Reason
+		if name == "" {
+			name = fmt.Sprintf("excludes[%d]", i)
 		}
+		steps = append(steps, resourceFilterStep{
+			name:    name,
+			action:  "exclude",
+			matches: func(resource Resource) bool { return excludeRuleMatches(resource, exclude) },
+			apply:   func(resources []Resource) []Resource { return c.applyExcludeRule(resources, exclude) },
+		})
 	}
 
+	filters := make([]resourceFilterStep, 0, len(c.config.ResourceFilters)+len(candidates))
+	addFilter := func(filter ResourceFilterRule, candidate bool) {
+		filters = append(filters, resourceFilterStep{
+			name:      filter.Name,
+			action:    filter.Action,
+			candidate: candidate,
+			matches:   func(resource Resource) bool { return c.resourceMatchesFilter(resource, filter) },
+			apply: func(resources []Resource) []Resource {
+				return c.applyFilterRule(resources, filter, filter.Action == "exclude")
+			},
+		})
+	}
 	for _, filter := range c.config.ResourceFilters {
-		if filter.Action == "include" {
-			filteredResources = c.applyFilterRule(filteredResources, filter, false)
-		}
+		addFilter(filter, false)
+	}
+	for _, filter := range candidates {
+		addFilter(filter, true)
 	}
 
-	return filteredResources
+	for _, action := range []string{"exclude", "include"} {
+		for _, filter := range filters {
+			if filter.action == action {
+				steps = append(steps, filter)
+			}
+		}
+	}
+	return steps
 }
 
 // GetCollectorMappings returns custom collector mappings that override defaults
@@ -209,42 +384,25 @@ func (c *ConfigManager) applyExcludeRule(resources []Resource, rule ResourceExcl
 	var filtered []Resource
 
 	for _, resource := range resources {
-		excluded := false
-
-		// Check GVR match
-		for _, gvr := range rule.GVRs {
-			if resource.GVR == gvr {
-				excluded = true
-				break
-			}
-		}
-
-		// Check namespace match
-		if !excluded && len(rule.Namespaces) > 0 {
-			for _, ns := range rule.Namespaces {
-				if resource.Namespace == ns {
-					excluded = true
-					break
-				}
-			}
+		if !excludeRuleMatches(resource, rule) {
+			filtered = append(filtered, resource)
 		}
+	}
 
-		// Check name match
-		if !excluded && len(rule.Names) > 0 {
-			for _, name := range rule.Names {
-				if resource.Name == name {
-					excluded = true
-					break
-				}
-			}
-		}
+	return filtered
+}
 
-		if !excluded {
-			filtered = append(filtered, resource)
+// excludeRuleMatches reports whether the resource matches any GVR, namespace glob or name glob of the rule
+func excludeRuleMatches(resource Resource, rule ResourceExcludeRule) bool {
+	// Check GVR match
+	for _, gvr := range rule.GVRs {
+		if resource.GVR == gvr {
+			return true
 		}
 	}
 
-	return filtered
+	// Check namespace and name match
+	return MatchesAnyGlob(rule.Namespaces, resource.Namespace) || MatchesAnyGlob(rule.Names, resource.Name)
 }
 
 // applyFilterRule applies a filter rule to include or exclude resources
@@ -285,17 +443,8 @@ func (c *ConfigManager) resourceMatchesFilter(resource Resource, rule ResourceFi
 	}
 
 	// Check namespace match
-	if len(rule.MatchNamespaces) > 0 {
-		found := false
-		for _, ns := range rule.MatchNamespaces {
-			if resource.Namespace == ns {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
+	if len(rule.MatchNamespaces) > 0 && !MatchesAnyGlob(rule.MatchNamespaces, resource.Namespace) {
+		return false
 	}
 
 	// Check label match
@@ -307,11 +456,132 @@ func (c *ConfigManager) resourceMatchesFilter(resource Resource, rule ResourceFi
 		}
 	}
 
-	// TODO: Implement label selector matching using k8s.io/apimachinery/pkg/labels
+	// Check label selector match
+	if rule.LabelSelector != "" {
+		selector, err := labels.Parse(rule.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(resource.Labels)) {
+			return false
+		}
+	}
+
+	// Check field selectors against the values kept by the scanner
+	if len(rule.FieldSelectors) > 0 {
+		selectors, err := ParseFieldSelectors(rule.FieldSelectors)
+		if err != nil {
+			return false
+		}
+		if !MatchFieldSelectors(selectors, resource) {
+			return false
+		}
+	}
 
 	return true
 }
 
+// FieldPaths returns the JSONPaths referenced by field selectors in the resource filters
+// Pass them as ResourceFilter.FieldPaths so the scanner keeps the values the filters need
+func (c *ConfigManager) FieldPaths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, rule := range c.config.ResourceFilters {
+		selectors, err := ParseFieldSelectors(rule.FieldSelectors)
+		if err != nil {
+			continue
+		}
+		for _, selector := range selectors {
+			if !seen[selector.Path] {
+				seen[selector.Path] = true
+				paths = append(paths, selector.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// ReferencedGVRs returns every GVR named by the filter, mapping, exclude and include rules and the cluster scope,
+// without duplicates
+func (c *Config) ReferencedGVRs() []schema.GroupVersionResource {
+	var lists [][]schema.GroupVersionResource
+	if clusterScope, err := c.DefaultOptions.ClusterScope.GVRs(); err == nil {
+		lists = append(lists, clusterScope)
+	}
+	for _, rule := range c.ResourceFilters {
+		lists = append(lists, rule.MatchGVRs)
+	}
+	for _, rule := range c.CollectorMappings {
+		lists = append(lists, rule.MatchGVRs)
+	}
+	for _, rule := range c.Excludes {
+		lists = append(lists, rule.GVRs)
+	}
+	for _, rule := range c.Includes {
+		lists = append(lists, rule.GVRs)
+	}
+
+	seen := make(map[schema.GroupVersionResource]bool)
+	var gvrs []schema.GroupVersionResource
+	for _, list := range lists {
+		for _, gvr := range list {
+			if gvr.Resource == "" || seen[gvr] {
+				continue
+			}
+			seen[gvr] = true
+			gvrs = append(gvrs, gvr)
+		}
+	}
+	return gvrs
+}
+
+// validateConfig checks the parts of a loaded config that cannot be validated by parsing alone
+func validateConfig(config *Config) error {
+	if err := config.DefaultOptions.Validate(); err != nil {
+		return err
+	}
+	if config.BundleReadme.Template != "" && config.BundleReadme.TemplateFile != "" {
+		return fmt.Errorf("bundleReadme: template and templateFile cannot both be set")
+	}
+	for _, rule := range config.ResourceFilters {
+		if _, err := ParseFieldSelectors(rule.FieldSelectors); err != nil {
+			return fmt.Errorf("resource filter %s: %w", rule.Name, err)
+		}
+		if err := validateGlobs(rule.MatchNamespaces); err != nil {
+			return fmt.Errorf("resource filter %s: matchNamespaces: %w", rule.Name, err)
+		}
+	}
+	for i, rule := range config.Excludes {
+		if err := validateGlobs(rule.Namespaces); err != nil {
+			return fmt.Errorf("excludes[%d]: namespaces: %w", i, err)
+		}
+		if err := validateGlobs(rule.Names); err != nil {
+			return fmt.Errorf("excludes[%d]: names: %w", i, err)
+		}
+	}
+	for i, rule := range config.CollectorMappings {
+		if err := rule.ValidateParameters(); err != nil {
+			return fmt.Errorf("collector mapping %q (collectorMappings[%d]): %w", rule.Name, i, err)
+		}
+	}
+	for i, entry := range config.ExecCatalog.Entries {
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("exec catalog entry %q (execCatalog.entries[%d]): %w", entry.Name, i, err)
+		}
+	}
+	redactions := make(map[string]bool)
+	for i, rule := range config.Redactions {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("redaction rule %q (redactions[%d]): %w", rule.Name, i, err)
+		}
+		if redactions[rule.Name] {
+			return fmt.Errorf("redaction rule %q (redactions[%d]): declared twice", rule.Name, i)
+		}
+		redactions[rule.Name] = true
+	}
+	if err := config.Retries.Validate(); err != nil {
+		return fmt.Errorf("retries: %w", err)
+	}
+	return nil
+}
+
 // getDefaultConfig returns the default auto-discovery configuration
 func getDefaultConfig() *Config {
 	return &Config{
@@ -324,16 +594,24 @@ func getDefaultConfig() *Config {
 		ResourceFilters:   []ResourceFilterRule{},
 		CollectorMappings: []CollectorMappingRule{},
 		Excludes: []ResourceExcludeRule{
-			{
-				// Exclude system namespaces by default
-				Namespaces: []string{"kube-system", "kube-public", "kube-node-lease"},
-				Reason:     "System namespaces excluded by default",
-			},
+			// Exclude system namespaces by default
+			systemNamespaceExcludeRule(),
 		},
 		Includes: []ResourceIncludeRule{},
 	}
 }
 
+func systemNamespaceExcludeRule() ResourceExcludeRule {
+	return ResourceExcludeRule{
+		Namespaces: append([]string{}, SystemNamespaces...),
+		Reason:     systemNamespaceExcludeReason,
+	}
+}
+
+func isSystemNamespaceExcludeRule(rule ResourceExcludeRule) bool {
+	return rule.Reason == systemNamespaceExcludeReason && len(rule.GVRs) == 0 && len(rule.Names) == 0
+}
+
 // mergeWithDefaults merges user configuration with defaults
 func mergeWithDefaults(userConfig *Config) *Config {
 	defaultConfig := getDefaultConfig()
@@ -362,6 +640,15 @@ func mergeWithDefaults(userConfig *Config) *Config {
 	}
 
 	// Merge excludes (user excludes are added to defaults)
+	if userConfig.IncludeSystemNamespaces {
+		var defaults []ResourceExcludeRule
+		for _, rule := range defaultConfig.Excludes {
+			if !isSystemNamespaceExcludeRule(rule) {
+				defaults = append(defaults, rule)
+			}
+		}
+		defaultConfig.Excludes = defaults
+	}
 	userConfig.Excludes = append(defaultConfig.Excludes, userConfig.Excludes...)
 
 	return userConfig
@@ -0,0 +1,281 @@
+package autodiscovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadConfigFile reads a config file and resolves its extends chain
+// stack holds the files currently being loaded so cycles can be reported
+func loadConfigFile(filePath string, stack []string) (*Config, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path %s: %w", filePath, err)
+	}
+	for _, loading := range stack {
+		if loading == absPath {
+			return nil, withKind(ErrSpecInvalid, fmt.Errorf("config extends cycle: %s -> %s", strings.Join(stack, " -> "), absPath))
+		}
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := &Config{}
+	ext := filepath.Ext(absPath)
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON config: %w", withKind(ErrSpecInvalid, err))
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML config: %w", withKind(ErrSpecInvalid, err))
+		}
+	default:
+		return nil, withKind(ErrSpecInvalid, fmt.Errorf("unsupported config file format: %s", ext))
+	}
+
+	// A relative README template file is relative to the config file that names it
+	if config.BundleReadme.TemplateFile != "" && !filepath.IsAbs(config.BundleReadme.TemplateFile) {
+		config.BundleReadme.TemplateFile = filepath.Join(filepath.Dir(absPath), config.BundleReadme.TemplateFile)
+	}
+
+	return resolveExtends(config, filepath.Dir(absPath), append(stack, absPath))
+}
+
+// resolveExtends merges the configs listed in config.Extends, in order, underneath config
+func resolveExtends(config *Config, baseDir string, stack []string) (*Config, error) {
+	if len(config.Extends) == 0 {
+		return config, nil
+	}
+
+	merged := &Config{}
+	for _, extends := range config.Extends {
+		path := extends
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+
+		base, err := loadConfigFile(path, stack)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load extended config %s: %w", extends, err)
+		}
+		merged = MergeConfigs(merged, base)
+	}
+
+	return MergeConfigs(merged, config), nil
+}
+
+// MergeConfigs layers override on top of base, it is used for config extends and discovery profile extends:
+//   - default options set in override replace those in base (booleans can only be turned on)
+//   - resource filters and collector mappings with the same name are replaced in place, others are appended
+//   - excludes and includes are appended after those of base
+//   - hooks with the same name are replaced in place, others are appended
+//   - bundle README settings set in override replace those in base
+//   - exec catalog entries with the same name are replaced in place, others are appended
+//   - retry policy fields set in override replace those in base, per collector type
+func MergeConfigs(base, override *Config) *Config {
+	return &Config{
+		DefaultOptions:          MergeDiscoveryOptions(base.DefaultOptions, override.DefaultOptions),
+		ResourceFilters:         mergeResourceFilterRules(base.ResourceFilters, override.ResourceFilters),
+		CollectorMappings:       mergeCollectorMappingRules(base.CollectorMappings, override.CollectorMappings),
+		Excludes:                append(append([]ResourceExcludeRule{}, base.Excludes...), override.Excludes...),
+		Includes:                append(append([]ResourceIncludeRule{}, base.Includes...), override.Includes...),
+		IncludeSystemNamespaces: base.IncludeSystemNamespaces || override.IncludeSystemNamespaces,
+		Hooks:                   mergeHookConfigs(base.Hooks, override.Hooks),
+		BundleReadme:            mergeBundleReadmeConfigs(base.BundleReadme, override.BundleReadme),
+		ExecCatalog: ExecCatalogConfig{
+			DisableDefaults: base.ExecCatalog.DisableDefaults || override.ExecCatalog.DisableDefaults,
+			Entries:         mergeExecCatalogEntries(base.ExecCatalog.Entries, override.ExecCatalog.Entries),
+		},
+		Redactions: mergeRedactionRules(base.Redactions, override.Redactions),
+		Retries:    mergeRetryConfigs(base.Retries, override.Retries),
+	}
+}
+
+// mergeRetryConfigs applies the retry policies set in override on top of base
+func mergeRetryConfigs(base, override RetryConfig) RetryConfig {
+	merged := RetryConfig{Default: base.Default.WithOverrides(override.Default)}
+	for _, policies := range []map[string]RetryPolicy{base.CollectorTypes, override.CollectorTypes} {
+		for collectorType, policy := range policies {
+			if merged.CollectorTypes == nil {
+				merged.CollectorTypes = make(map[string]RetryPolicy)
+			}
+			merged.CollectorTypes[collectorType] = merged.CollectorTypes[collectorType].WithOverrides(policy)
+		}
+	}
+	return merged
+}
+
+// mergeBundleReadmeConfigs applies the README settings set in override on top of base
+// A template in override replaces a template file in base and the other way around
+func mergeBundleReadmeConfigs(base, override BundleReadmeConfig) BundleReadmeConfig {
+	if override.Disabled {
+		base.Disabled = true
+	}
+	if override.Title != "" {
+		base.Title = override.Title
+	}
+	if override.Notes != "" {
+		base.Notes = override.Notes
+	}
+	if override.Template != "" || override.TemplateFile != "" {
+		base.Template = override.Template
+		base.TemplateFile = override.TemplateFile
+	}
+	return base
+}
+
+// MergeDiscoveryOptions applies the options set in overrides on top of base, booleans can only be turned on
+// and protected namespaces can only be added to
+func MergeDiscoveryOptions(base, overrides DiscoveryOptions) DiscoveryOptions {
+	if len(overrides.Namespaces) > 0 {
+		base.Namespaces = overrides.Namespaces
+	}
+	if overrides.IncludeImages {
+		base.IncludeImages = overrides.IncludeImages
+	}
+	if overrides.RBACCheck {
+		base.RBACCheck = overrides.RBACCheck
+	}
+	if overrides.MaxDepth > 0 {
+		base.MaxDepth = overrides.MaxDepth
+	}
+	if overrides.PageSize > 0 {
+		base.PageSize = overrides.PageSize
+	}
+	if len(overrides.ExcludeNamespaces) > 0 {
+		base.ExcludeNamespaces = overrides.ExcludeNamespaces
+	}
+	if len(overrides.ProtectedNamespaces) > 0 {
+		base.ProtectedNamespaces = appendUniqueStrings(append([]string{}, base.ProtectedNamespaces...), overrides.ProtectedNamespaces...)
+	}
+	if len(overrides.CandidateNamespaces) > 0 {
+		base.CandidateNamespaces = overrides.CandidateNamespaces
+	}
+	base.PhaseTimeouts = base.PhaseTimeouts.WithOverrides(overrides.PhaseTimeouts)
+	if overrides.DebugLogFallback {
+		base.DebugLogFallback = overrides.DebugLogFallback
+	}
+	if overrides.StoragePriority > 0 {
+		base.StoragePriority = overrides.StoragePriority
+	}
+	if len(overrides.OnlyGroups) > 0 {
+		base.OnlyGroups = overrides.OnlyGroups
+	}
+	if len(overrides.SkipGroups) > 0 {
+		base.SkipGroups = overrides.SkipGroups
+	}
+	if overrides.ResourceFormat != "" {
+		base.ResourceFormat = overrides.ResourceFormat
+	}
+	if overrides.TableThreshold != 0 {
+		base.TableThreshold = overrides.TableThreshold
+	}
+	if len(overrides.Apps) > 0 {
+		base.Apps = overrides.Apps
+	}
+	base.NodeSampling = base.NodeSampling.WithOverrides(overrides.NodeSampling)
+	base.HotPods = base.HotPods.WithOverrides(overrides.HotPods)
+	base.TimeWindow = base.TimeWindow.WithOverrides(overrides.TimeWindow)
+	if overrides.AuditLogPath != "" {
+		base.AuditLogPath = overrides.AuditLogPath
+	}
+	if overrides.MaxCollectors != 0 {
+		base.MaxCollectors = overrides.MaxCollectors
+	}
+	if overrides.MaxLogLines > 0 {
+		base.MaxLogLines = overrides.MaxLogLines
+	}
+	if overrides.NormalizeLogTimestamps {
+		base.NormalizeLogTimestamps = overrides.NormalizeLogTimestamps
+	}
+	base.LargeObjects = base.LargeObjects.WithOverrides(overrides.LargeObjects)
+	base.SecretPolicy = base.SecretPolicy.WithOverrides(overrides.SecretPolicy)
+	base.ClusterScope = base.ClusterScope.WithOverrides(overrides.ClusterScope)
+	if overrides.BatchByNamespace {
+		base.BatchByNamespace = overrides.BatchByNamespace
+	}
+	return base
+}
+
+func mergeResourceFilterRules(base, overrides []ResourceFilterRule) []ResourceFilterRule {
+	merged := append([]ResourceFilterRule{}, base...)
+	for _, rule := range overrides {
+		replaced := false
+		for i := range merged {
+			if rule.Name != "" && merged[i].Name == rule.Name {
+				merged[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, rule)
+		}
+	}
+	return merged
+}
+
+func mergeCollectorMappingRules(base, overrides []CollectorMappingRule) []CollectorMappingRule {
+	merged := append([]CollectorMappingRule{}, base...)
+	for _, rule := range overrides {
+		replaced := false
+		for i := range merged {
+			if rule.Name != "" && merged[i].Name == rule.Name {
+				merged[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, rule)
+		}
+	}
+	return merged
+}
+
+func mergeHookConfigs(base, overrides []HookConfig) []HookConfig {
+	merged := append([]HookConfig{}, base...)
+	for _, hook := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == hook.Name {
+				merged[i] = hook
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, hook)
+		}
+	}
+	return merged
+}
+
+// mergeRedactionRules replaces base rules with override rules of the same name and appends the others
+func mergeRedactionRules(base, overrides []CollectorRedactionRule) []CollectorRedactionRule {
+	merged := append([]CollectorRedactionRule{}, base...)
+	for _, rule := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == rule.Name {
+				merged[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, rule)
+		}
+	}
+	return merged
+}
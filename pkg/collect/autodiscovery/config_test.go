@@ -112,6 +112,38 @@ invalid: yaml: content
 			content:  `{"invalid": json, "missing": quotes}`,
 			expectError: true,
 		},
+		{
+			name:     "misspelled collector mapping parameter",
+			filename: "typo.yaml",
+			content: `collectorMappings:
+  - name: db-logs
+    collectorType: logs
+    parameters:
+      maxline: 50000
+`,
+			expectError: true,
+		},
+		{
+			name:     "exec catalog entry with a mutating command",
+			filename: "exec.yaml",
+			content: `execCatalog:
+  entries:
+    - name: redis
+      labels: [redis]
+      commands:
+        - name: reset
+          command: [redis-cli, FLUSHALL]
+`,
+			expectError: true,
+		},
+		{
+			name:     "negative collector limit",
+			filename: "limit.yaml",
+			content: `defaultOptions:
+  maxCollectors: -1
+`,
+			expectError: true,
+		},
 		{
 			name:        "unsupported file extension",
 			filename:    "config.txt",
@@ -659,6 +691,31 @@ func TestConfigManager_resourceMatchesFilter(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "field selector match",
+			resource: Resource{
+				GVR:       schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+				Namespace: "default",
+				Name:      "web",
+				Fields:    map[string][]interface{}{"spec.replicas": {int64(20)}},
+			},
+			filter: ResourceFilterRule{
+				FieldSelectors: []string{"spec.replicas>10"},
+			},
+			expected: true,
+		},
+		{
+			name: "field selector without extracted field",
+			resource: Resource{
+				GVR:       schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"},
+				Namespace: "default",
+				Name:      "web-pod",
+			},
+			filter: ResourceFilterRule{
+				FieldSelectors: []string{"status.phase=Failed"},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -756,3 +813,287 @@ func TestMergeWithDefaults(t *testing.T) {
 		t.Errorf("Default excludes not merged with user excludes")
 	}
 }
+
+func TestConfigManager_SetIncludeSystemNamespaces(t *testing.T) {
+	configManager := NewConfigManager()
+	configManager.config.Excludes = append(configManager.config.Excludes, ResourceExcludeRule{
+		Namespaces: []string{"sandbox"},
+		Reason:     "User specified",
+	})
+
+	excluded := configManager.GetDiscoveryOptions(nil).ExcludeNamespaces
+	if len(excluded) != 4 {
+		t.Errorf("Expected system namespaces and sandbox to be excluded, got %v", excluded)
+	}
+
+	configManager.SetIncludeSystemNamespaces(true)
+
+	excluded = configManager.GetDiscoveryOptions(nil).ExcludeNamespaces
+	if len(excluded) != 1 || excluded[0] != "sandbox" {
+		t.Errorf("Expected only sandbox to stay excluded, got %v", excluded)
+	}
+	if !configManager.GetSystemNamespacePolicy().IncludeSystemNamespaces {
+		t.Errorf("Expected policy to report system namespaces as included")
+	}
+
+	// Turning it back off restores the default excludes exactly once
+	configManager.SetIncludeSystemNamespaces(false)
+	configManager.SetIncludeSystemNamespaces(false)
+
+	excluded = configManager.GetDiscoveryOptions(nil).ExcludeNamespaces
+	if len(excluded) != 4 {
+		t.Errorf("Expected system namespaces to be excluded again, got %v", excluded)
+	}
+	if len(configManager.config.Excludes) != 2 {
+		t.Errorf("Expected 2 exclude rules, got %d", len(configManager.config.Excludes))
+	}
+}
+
+func TestConfigManager_ExcludedNamespacesSkipsPartialRules(t *testing.T) {
+	configManager := &ConfigManager{config: &Config{
+		Excludes: []ResourceExcludeRule{
+			{Namespaces: []string{"team-a"}, Names: []string{"noisy-pod"}},
+			{Namespaces: []string{"team-b"}, GVRs: []schema.GroupVersionResource{{Version: "v1", Resource: "secrets"}}},
+			{Namespaces: []string{"team-c"}},
+		},
+	}}
+
+	excluded := configManager.ExcludedNamespaces()
+	if len(excluded) != 1 || excluded[0] != "team-c" {
+		t.Errorf("Expected only team-c to be excluded as a whole, got %v", excluded)
+	}
+}
+
+func TestConfigManager_GetDiscoveryOptionsKeepsOverrideExcludes(t *testing.T) {
+	configManager := &ConfigManager{config: &Config{
+		DefaultOptions: DiscoveryOptions{ExcludeNamespaces: []string{"team-default"}},
+		Excludes: []ResourceExcludeRule{
+			{Namespaces: []string{"kube-system"}},
+			{Namespaces: []string{"team-override"}},
+		},
+	}}
+
+	options := configManager.GetDiscoveryOptions(&DiscoveryOptions{ExcludeNamespaces: []string{"team-override", "scratch"}})
+
+	expected := []string{"team-override", "scratch", "kube-system"}
+	if len(options.ExcludeNamespaces) != len(expected) {
+		t.Fatalf("Expected excludes %v, got %v", expected, options.ExcludeNamespaces)
+	}
+	for i, ns := range expected {
+		if options.ExcludeNamespaces[i] != ns {
+			t.Errorf("Expected exclude[%d]=%s, got %s", i, ns, options.ExcludeNamespaces[i])
+		}
+	}
+
+	defaults := configManager.GetDiscoveryOptions(nil)
+	if len(defaults.ExcludeNamespaces) != 3 || defaults.ExcludeNamespaces[0] != "team-default" {
+		t.Errorf("Expected default excludes to be kept alongside the rules, got %v", defaults.ExcludeNamespaces)
+	}
+}
+
+func TestConfig_ReferencedGVRs(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	httpRoutes := schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	monitors := schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+
+	config := &Config{
+		ResourceFilters:   []ResourceFilterRule{{Name: "routes", MatchGVRs: []schema.GroupVersionResource{pods, httpRoutes}}},
+		CollectorMappings: []CollectorMappingRule{{Name: "pods", MatchGVRs: []schema.GroupVersionResource{pods}}},
+		Excludes:          []ResourceExcludeRule{{GVRs: []schema.GroupVersionResource{secrets}}, systemNamespaceExcludeRule()},
+		Includes:          []ResourceIncludeRule{{GVRs: []schema.GroupVersionResource{monitors}}},
+	}
+
+	gvrs := config.ReferencedGVRs()
+	expected := []schema.GroupVersionResource{pods, httpRoutes, secrets, monitors}
+	if len(gvrs) != len(expected) {
+		t.Fatalf("Expected %d GVRs, got %v", len(expected), gvrs)
+	}
+	for i, gvr := range expected {
+		if gvrs[i] != gvr {
+			t.Errorf("Expected GVR[%d]=%v, got %v", i, gvr, gvrs[i])
+		}
+	}
+}
+
+func TestMergeWithDefaults_IncludeSystemNamespaces(t *testing.T) {
+	configManager := NewConfigManager()
+	err := configManager.LoadFromYAML([]byte("includeSystemNamespaces: true\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, rule := range configManager.GetConfig().Excludes {
+		for _, ns := range rule.Namespaces {
+			if ns == "kube-system" {
+				t.Errorf("Expected kube-system not to be excluded when includeSystemNamespaces is set")
+			}
+		}
+	}
+}
+
+func TestConfigManager_LoadFromFile_Extends(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml": `defaultOptions:
+  maxDepth: 2
+  rbacCheck: true
+collectorMappings:
+  - name: pod-logs
+    collectorType: logs
+    priority: 5
+  - name: events
+    collectorType: cluster-resources
+    priority: 1
+excludes:
+  - names: ["base-secret"]
+    reason: base policy
+`,
+		"team/overrides.yaml": `defaultOptions:
+  namespaces: ["team-a"]
+collectorMappings:
+  - name: pod-logs
+    collectorType: logs
+    priority: 20
+excludes:
+  - names: ["team-secret"]
+    reason: team policy
+`,
+		"team/app.yaml": `extends: ["../base.yaml", "overrides.yaml"]
+defaultOptions:
+  maxDepth: 4
+collectorMappings:
+  - name: app-exec
+    collectorType: exec
+    priority: 10
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	configManager := NewConfigManager()
+	if err := configManager.LoadFromFile(filepath.Join(dir, "team/app.yaml")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config := configManager.GetConfig()
+
+	if config.DefaultOptions.MaxDepth != 4 {
+		t.Errorf("Expected maxDepth 4 from the extending file, got %d", config.DefaultOptions.MaxDepth)
+	}
+	if !config.DefaultOptions.RBACCheck {
+		t.Errorf("Expected rbacCheck from the base file")
+	}
+	if len(config.DefaultOptions.Namespaces) != 1 || config.DefaultOptions.Namespaces[0] != "team-a" {
+		t.Errorf("Expected namespaces [team-a] from the overrides file, got %v", config.DefaultOptions.Namespaces)
+	}
+
+	// Same-name mappings are replaced in place, new ones appended
+	expectedMappings := []struct {
+		name     string
+		priority int
+	}{{"pod-logs", 20}, {"events", 1}, {"app-exec", 10}}
+	if len(config.CollectorMappings) != len(expectedMappings) {
+		t.Fatalf("Expected %d collector mappings, got %d", len(expectedMappings), len(config.CollectorMappings))
+	}
+	for i, expected := range expectedMappings {
+		if config.CollectorMappings[i].Name != expected.name || config.CollectorMappings[i].Priority != expected.priority {
+			t.Errorf("Expected mapping %d to be %s/%d, got %s/%d", i, expected.name, expected.priority,
+				config.CollectorMappings[i].Name, config.CollectorMappings[i].Priority)
+		}
+	}
+
+	var reasons []string
+	for _, rule := range config.Excludes {
+		reasons = append(reasons, rule.Reason)
+	}
+	expectedReasons := []string{systemNamespaceExcludeReason, "base policy", "team policy"}
+	if fmt.Sprint(reasons) != fmt.Sprint(expectedReasons) {
+		t.Errorf("Expected excludes %v, got %v", expectedReasons, reasons)
+	}
+
+	if len(config.Extends) != 0 {
+		t.Errorf("Expected extends to be resolved, got %v", config.Extends)
+	}
+}
+
+func TestConfigManager_LoadFromFile_ExtendsErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml":       "extends: [b.yaml]\n",
+		"b.yaml":       "extends: [a.yaml]\n",
+		"missing.yaml": "extends: [does-not-exist.yaml]\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "cycle", file: "a.yaml"},
+		{name: "missing base", file: "missing.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configManager := NewConfigManager()
+			if err := configManager.LoadFromFile(filepath.Join(dir, tt.file)); err == nil {
+				t.Errorf("Expected error loading %s", tt.file)
+			}
+		})
+	}
+}
+
+func TestConfigManager_LoadFromFile_BundleReadme(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml": `bundleReadme:
+  title: Acme Support Bundle
+  notes: Contact support@example.com
+`,
+		"team/app.yaml": `extends: ["../base.yaml"]
+bundleReadme:
+  templateFile: readme.tmpl
+`,
+		"invalid.yaml": `bundleReadme:
+  template: "# {{ .Title }}"
+  templateFile: readme.tmpl
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	configManager := NewConfigManager()
+	if err := configManager.LoadFromFile(filepath.Join(dir, "team/app.yaml")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	readme := configManager.GetBundleReadmeConfig()
+
+	if readme.Title != "Acme Support Bundle" || readme.Notes != "Contact support@example.com" {
+		t.Errorf("Expected title and notes from the base file, got %+v", readme)
+	}
+	if expected := filepath.Join(dir, "team", "readme.tmpl"); readme.TemplateFile != expected {
+		t.Errorf("Expected template file relative to the config file %s, got %s", expected, readme.TemplateFile)
+	}
+
+	if err := NewConfigManager().LoadFromFile(filepath.Join(dir, "invalid.yaml")); err == nil {
+		t.Errorf("Expected an error when template and templateFile are both set")
+	}
+}
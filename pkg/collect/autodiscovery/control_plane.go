@@ -0,0 +1,293 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CollectorGroupClusterInfo groups the cluster-info and control-plane health collectors
+const CollectorGroupClusterInfo = "cluster-info"
+
+// controlPlaneComponents are the kube-system components whose flags are captured
+var controlPlaneComponents = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+
+// HealthCheck is the result of a single API server readiness check or component status
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// ControlPlaneComponent is the configuration of one control plane pod, read from its command line flags
+type ControlPlaneComponent struct {
+	Component                string          `json:"component"`
+	Pod                      string          `json:"pod"`
+	Image                    string          `json:"image,omitempty"`
+	FeatureGates             map[string]bool `json:"featureGates,omitempty"`
+	EnabledAdmissionPlugins  []string        `json:"enabledAdmissionPlugins,omitempty"`
+	DisabledAdmissionPlugins []string        `json:"disabledAdmissionPlugins,omitempty"`
+	AdmissionConfigFile      string          `json:"admissionConfigFile,omitempty"`
+}
+
+// ControlPlaneReport is the cluster-info report: server version, control plane health and component configuration
+type ControlPlaneReport struct {
+	Version      *version.Info           `json:"version,omitempty"`
+	HealthSource string                  `json:"healthSource,omitempty"` // "readyz" or "componentstatuses"
+	Healthy      bool                    `json:"healthy"`
+	Checks       []HealthCheck           `json:"checks"`
+	Components   []ControlPlaneComponent `json:"components"` // Empty on managed control planes, whose pods are not visible
+	Problems     []string                `json:"problems,omitempty"`
+}
+
+// ControlPlaneHealth generates the cluster-info collector group
+type ControlPlaneHealth struct {
+	kubeClient kubernetes.Interface
+}
+
+// NewControlPlaneHealth creates a new ControlPlaneHealth
+func NewControlPlaneHealth(kubeClient kubernetes.Interface) *ControlPlaneHealth {
+	return &ControlPlaneHealth{
+		kubeClient: kubeClient,
+	}
+}
+
+// GenerateClusterInfoCollectors returns the cluster-info collector group at critical priority:
+// the control plane report and the admission webhook configurations
+// It does not depend on the discovered resources, so it is generated for every auto-discovery run
+func (c *ControlPlaneHealth) GenerateClusterInfoCollectors(ctx context.Context) []CollectorSpec {
+	var collectors []CollectorSpec
+
+	report := c.BuildReport(ctx)
+	if data, err := json.MarshalIndent(report, "", "  "); err == nil {
+		collectors = append(collectors, CollectorSpec{
+			Type:     "data",
+			Name:     "auto-cluster-info-control-plane",
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(PriorityCritical),
+			Parameters: map[string]interface{}{
+				"name": "cluster-info/control-plane.json",
+				"data": string(data),
+			},
+		})
+	}
+
+	for _, gvr := range []schema.GroupVersionResource{validatingWebhooksGVR, mutatingWebhooksGVR} {
+		collectors = append(collectors, CollectorSpec{
+			Type:     CollectorTypeClusterResources,
+			Name:     fmt.Sprintf("auto-cluster-info-%s", gvr.Resource),
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(PriorityCritical),
+			Parameters: ClusterResourcesParams{
+				Group:    gvr.Group,
+				Version:  gvr.Version,
+				Resource: gvr.Resource,
+			}.ToMap(),
+		})
+	}
+
+	return collectors
+}
+
+// BuildReport reads the server version, control plane health and kube-system component flags
+// Failures are recorded as problems so a partially readable cluster still produces a report
+func (c *ControlPlaneHealth) BuildReport(ctx context.Context) ControlPlaneReport {
+	report := ControlPlaneReport{Checks: []HealthCheck{}, Components: []ControlPlaneComponent{}}
+
+	serverVersion, err := c.kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to get server version: %v", err))
+	} else {
+		report.Version = serverVersion
+	}
+
+	c.checkHealth(ctx, &report)
+
+	components, err := c.findControlPlaneComponents(ctx)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to list kube-system pods: %v", err))
+	} else {
+		report.Components = components
+	}
+
+	return report
+}
+
+// checkHealth uses /readyz?verbose and falls back to the deprecated componentstatuses API
+func (c *ControlPlaneHealth) checkHealth(ctx context.Context, report *ControlPlaneReport) {
+	if restClient := c.kubeClient.Discovery().RESTClient(); restClient != nil {
+		body, err := restClient.Get().AbsPath("/readyz").Param("verbose", "").DoRaw(ctx)
+		// A failing check returns 500 with the verbose output, which is still worth parsing
+		if checks := parseReadyzOutput(string(body)); len(checks) > 0 {
+			report.HealthSource = "readyz"
+			report.Checks = checks
+			report.Healthy = allHealthy(checks)
+			return
+		}
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to get /readyz: %v", err))
+		}
+	}
+
+	statuses, err := c.kubeClient.CoreV1().ComponentStatuses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to list component statuses: %v", err))
+		return
+	}
+
+	report.HealthSource = "componentstatuses"
+	for _, status := range statuses.Items {
+		check := HealthCheck{Name: status.Name}
+		for _, condition := range status.Conditions {
+			if condition.Type != corev1.ComponentHealthy {
+				continue
+			}
+			check.Healthy = condition.Status == corev1.ConditionTrue
+			check.Message = condition.Message
+			if condition.Error != "" {
+				check.Message = condition.Error
+			}
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.Healthy = allHealthy(report.Checks)
+}
+
+// parseReadyzOutput parses verbose readyz lines such as "[+]ping ok" and "[-]etcd failed: reason withheld"
+func parseReadyzOutput(output string) []HealthCheck {
+	var checks []HealthCheck
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		var healthy bool
+		switch {
+		case strings.HasPrefix(line, "[+]"):
+			healthy = true
+		case strings.HasPrefix(line, "[-]"):
+			healthy = false
+		default:
+			continue
+		}
+
+		name, message, _ := strings.Cut(line[3:], " ")
+		checks = append(checks, HealthCheck{Name: name, Healthy: healthy, Message: message})
+	}
+	return checks
+}
+
+func allHealthy(checks []HealthCheck) bool {
+	for _, check := range checks {
+		if !check.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// findControlPlaneComponents reads feature gates and admission settings from the control plane pods in kube-system
+func (c *ControlPlaneHealth) findControlPlaneComponents(ctx context.Context) ([]ControlPlaneComponent, error) {
+	pods, err := c.kubeClient.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	components := []ControlPlaneComponent{}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			name := controlPlaneComponentName(container)
+			if name == "" {
+				continue
+			}
+			component := ControlPlaneComponent{Component: name, Pod: pod.Name, Image: container.Image}
+			applyControlPlaneFlags(&component, append(append([]string{}, container.Command...), container.Args...))
+			components = append(components, component)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Component != components[j].Component {
+			return components[i].Component < components[j].Component
+		}
+		return components[i].Pod < components[j].Pod
+	})
+	return components, nil
+}
+
+// controlPlaneComponentName identifies a control plane container by its name or executable
+func controlPlaneComponentName(container corev1.Container) string {
+	candidates := []string{container.Name}
+	if len(container.Command) > 0 {
+		candidates = append(candidates, path.Base(container.Command[0]))
+	}
+	for _, candidate := range candidates {
+		for _, component := range controlPlaneComponents {
+			if candidate == component {
+				return component
+			}
+		}
+	}
+	return ""
+}
+
+// applyControlPlaneFlags records feature gate and admission flags, in "--flag=value" or "--flag value" form
+func applyControlPlaneFlags(component *ControlPlaneComponent, args []string) {
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			value = args[i+1]
+			i++
+		}
+
+		switch strings.TrimLeft(flag, "-") {
+		case "feature-gates":
+			if component.FeatureGates == nil {
+				component.FeatureGates = make(map[string]bool)
+			}
+			for gate, enabled := range parseFeatureGates(value) {
+				component.FeatureGates[gate] = enabled
+			}
+		case "enable-admission-plugins":
+			component.EnabledAdmissionPlugins = append(component.EnabledAdmissionPlugins, splitFlagList(value)...)
+		case "disable-admission-plugins":
+			component.DisabledAdmissionPlugins = append(component.DisabledAdmissionPlugins, splitFlagList(value)...)
+		case "admission-control-config-file":
+			component.AdmissionConfigFile = value
+		}
+	}
+}
+
+// parseFeatureGates parses "Gate1=true,Gate2=false", skipping malformed entries
+func parseFeatureGates(value string) map[string]bool {
+	gates := make(map[string]bool)
+	for _, entry := range splitFlagList(value) {
+		gate, setting, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(setting))
+		if err != nil {
+			continue
+		}
+		gates[strings.TrimSpace(gate)] = enabled
+	}
+	return gates
+}
+
+func splitFlagList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,138 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controlPlanePod(name, container string, command []string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: container, Image: "registry.k8s.io/" + container + ":v1.28.4", Command: command}},
+		},
+	}
+}
+
+func TestControlPlaneHealth_BuildReport(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		controlPlanePod("kube-apiserver-node1", "kube-apiserver", []string{
+			"kube-apiserver",
+			"--feature-gates=InPlacePodVerticalScaling=true,SidecarContainers=false",
+			"--enable-admission-plugins=NodeRestriction,PodSecurity",
+			"--admission-control-config-file", "/etc/kubernetes/admission.yaml",
+		}),
+		controlPlanePod("kube-scheduler-node1", "kube-scheduler", []string{"/usr/local/bin/kube-scheduler", "--leader-elect=true"}),
+		controlPlanePod("coredns-abc", "coredns", []string{"/coredns"}),
+		&corev1.ComponentStatus{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd-0"},
+			Conditions: []corev1.ComponentCondition{{Type: corev1.ComponentHealthy, Status: corev1.ConditionFalse, Error: "connection refused"}},
+		},
+	)
+
+	report := NewControlPlaneHealth(kubeClient).BuildReport(context.Background())
+
+	if report.Version == nil {
+		t.Errorf("Expected server version to be set")
+	}
+	if report.HealthSource != "componentstatuses" {
+		t.Errorf("Expected componentstatuses fallback without a REST client, got %s", report.HealthSource)
+	}
+	if report.Healthy || len(report.Checks) != 1 || report.Checks[0].Message != "connection refused" {
+		t.Errorf("Expected one unhealthy etcd check, got %+v", report.Checks)
+	}
+	if len(report.Components) != 2 {
+		t.Fatalf("Expected 2 control plane components, got %d", len(report.Components))
+	}
+
+	apiserver := report.Components[0]
+	if apiserver.Component != "kube-apiserver" {
+		t.Fatalf("Expected kube-apiserver first, got %s", apiserver.Component)
+	}
+	if !apiserver.FeatureGates["InPlacePodVerticalScaling"] || apiserver.FeatureGates["SidecarContainers"] {
+		t.Errorf("Expected feature gates to be parsed, got %v", apiserver.FeatureGates)
+	}
+	if len(apiserver.EnabledAdmissionPlugins) != 2 {
+		t.Errorf("Expected 2 admission plugins, got %v", apiserver.EnabledAdmissionPlugins)
+	}
+	if apiserver.AdmissionConfigFile != "/etc/kubernetes/admission.yaml" {
+		t.Errorf("Expected admission config file from separate flag value, got %s", apiserver.AdmissionConfigFile)
+	}
+	if report.Components[1].Component != "kube-scheduler" {
+		t.Errorf("Expected kube-scheduler identified by its executable, got %s", report.Components[1].Component)
+	}
+}
+
+func TestControlPlaneHealth_GenerateClusterInfoCollectors(t *testing.T) {
+	collectors := NewControlPlaneHealth(fake.NewSimpleClientset()).GenerateClusterInfoCollectors(context.Background())
+
+	if len(collectors) != 3 {
+		t.Fatalf("Expected 3 collectors, got %d", len(collectors))
+	}
+	for _, collector := range collectors {
+		if collector.Group != CollectorGroupClusterInfo {
+			t.Errorf("Expected %s in group %s, got %s", collector.Name, CollectorGroupClusterInfo, collector.Group)
+		}
+		if collector.Priority != int(PriorityCritical) {
+			t.Errorf("Expected %s at critical priority, got %d", collector.Name, collector.Priority)
+		}
+	}
+
+	var report ControlPlaneReport
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse control plane report: %v", err)
+	}
+	if len(report.Components) != 0 {
+		t.Errorf("Expected no components without control plane pods, got %d", len(report.Components))
+	}
+}
+
+func TestParseReadyzOutput(t *testing.T) {
+	output := "[+]ping ok\n[+]log ok\n[-]etcd failed: reason withheld\nreadyz check failed\n"
+
+	checks := parseReadyzOutput(output)
+	if len(checks) != 3 {
+		t.Fatalf("Expected 3 checks, got %d", len(checks))
+	}
+	if !checks[0].Healthy || checks[0].Name != "ping" {
+		t.Errorf("Expected healthy ping check, got %+v", checks[0])
+	}
+	if checks[2].Healthy || checks[2].Name != "etcd" || checks[2].Message != "failed: reason withheld" {
+		t.Errorf("Expected failed etcd check, got %+v", checks[2])
+	}
+	if allHealthy(checks) {
+		t.Errorf("Expected checks to be unhealthy")
+	}
+}
+
+func TestParseFeatureGates(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]bool
+	}{
+		{name: "empty", value: "", expected: map[string]bool{}},
+		{name: "single gate", value: "Foo=true", expected: map[string]bool{"Foo": true}},
+		{name: "multiple gates", value: "Foo=true, Bar=false", expected: map[string]bool{"Foo": true, "Bar": false}},
+		{name: "malformed entries skipped", value: "Foo,Bar=maybe,Baz=false", expected: map[string]bool{"Baz": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gates := parseFeatureGates(tt.value)
+			if len(gates) != len(tt.expected) {
+				t.Fatalf("Expected %d gates, got %v", len(tt.expected), gates)
+			}
+			for gate, enabled := range tt.expected {
+				if got, ok := gates[gate]; !ok || got != enabled {
+					t.Errorf("Expected %s=%v, got %v", gate, enabled, gates)
+				}
+			}
+		})
+	}
+}
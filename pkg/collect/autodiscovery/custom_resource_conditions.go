@@ -0,0 +1,226 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// CustomResourceConditionsPath is where the status conditions of discovered custom resources are written
+const CustomResourceConditionsPath = "custom-resources/conditions-summary.json"
+
+// Condition type suffixes whose polarity is known: a healthy resource reports the first as True and the second
+// as False, e.g. Ready, DatabaseReady, Degraded or ReconcileError
+var (
+	positiveConditionSuffixes = []string{"Ready", "Available", "Healthy", "Synced", "Reconciled", "Succeeded", "Established"}
+	negativeConditionSuffixes = []string{"Degraded", "Failed", "Failure", "Error", "Stalled"}
+)
+
+// CustomResourceCondition is one entry of a custom resource's status.conditions
+type CustomResourceCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+	Problem            bool   `json:"problem,omitempty"` // A Ready-like condition that is False or a Degraded-like one that is True
+}
+
+// CustomResourceConditions are the status conditions of one custom resource
+type CustomResourceConditions struct {
+	CRD        string                    `json:"crd"`
+	Kind       string                    `json:"kind"`
+	Namespace  string                    `json:"namespace,omitempty"`
+	Name       string                    `json:"name"`
+	Healthy    bool                      `json:"healthy"`
+	Conditions []CustomResourceCondition `json:"conditions"`
+}
+
+// CustomResourceConditionsSummary is written to conditions-summary.json
+type CustomResourceConditionsSummary struct {
+	Resources []CustomResourceConditions `json:"resources"`
+	Unhealthy int                        `json:"unhealthy"`
+	Counts    map[string]map[string]int  `json:"counts"` // condition type -> status -> resources
+}
+
+// listCustomResourceConditions lists the resources of gvr in namespace, cluster-wide when empty, and returns the
+// status conditions of each by name. Resources that report no conditions are left out
+func listCustomResourceConditions(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, crd, namespace string) ([]CustomResourceConditions, error) {
+	var resourceClient dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+	if namespace != "" {
+		resourceClient = dynamicClient.Resource(gvr).Namespace(namespace)
+	}
+	list, err := resourceClient.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+
+	var results []CustomResourceConditions
+	for _, item := range list.Items {
+		conditions := extractConditions(item)
+		if len(conditions) == 0 {
+			continue
+		}
+		results = append(results, CustomResourceConditions{
+			CRD:        crd,
+			Kind:       item.GetKind(),
+			Namespace:  item.GetNamespace(),
+			Name:       item.GetName(),
+			Healthy:    !hasConditionProblem(conditions),
+			Conditions: conditions,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// discoveredConditions returns the status conditions of the discovered resources of one CRD, by namespace and name
+// A namespace that cannot be listed is skipped with a warning
+func (c *CustomResources) discoveredConditions(ctx context.Context, crd string, resources []Resource) []CustomResourceConditions {
+	discovered := make(map[string]map[string]bool)
+	namespaces := make(map[string]bool)
+	for _, resource := range resources {
+		namespaces[resource.Namespace] = true
+		if discovered[resource.Namespace] == nil {
+			discovered[resource.Namespace] = make(map[string]bool)
+		}
+		discovered[resource.Namespace][resource.Name] = true
+	}
+
+	var results []CustomResourceConditions
+	for _, namespace := range sortedKeys(namespaces) {
+		listed, err := listCustomResourceConditions(ctx, c.dynamicClient, resources[0].GVR, crd, namespace)
+		if err != nil {
+			fmt.Printf("Warning: failed to read conditions of %s in namespace %q: %v\n", crd, namespace, err)
+			continue
+		}
+		for _, resource := range listed {
+			if discovered[namespace][resource.Name] {
+				results = append(results, resource)
+			}
+		}
+	}
+	return results
+}
+
+// extractConditions reads status.conditions in the shape shared by metav1.Condition and most operator APIs
+func extractConditions(obj unstructured.Unstructured) []CustomResourceCondition {
+	items, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	var conditions []CustomResourceCondition
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condition := CustomResourceCondition{}
+		condition.Type, _, _ = unstructured.NestedString(entry, "type")
+		condition.Status, _, _ = unstructured.NestedString(entry, "status")
+		condition.Reason, _, _ = unstructured.NestedString(entry, "reason")
+		condition.Message, _, _ = unstructured.NestedString(entry, "message")
+		condition.LastTransitionTime, _, _ = unstructured.NestedString(entry, "lastTransitionTime")
+		if condition.Type == "" {
+			continue
+		}
+		condition.Problem = isConditionProblem(condition)
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// isConditionProblem reports whether a condition of known polarity is in its unhealthy state
+// Conditions of other types, such as Progressing or Paused, say nothing on their own
+func isConditionProblem(condition CustomResourceCondition) bool {
+	switch conditionPolarity(condition.Type) {
+	case conditionPositive:
+		return condition.Status == string(metav1.ConditionFalse)
+	case conditionNegative:
+		return condition.Status == string(metav1.ConditionTrue)
+	default:
+		return false
+	}
+}
+
+// isConditionUnknown reports whether a condition of known polarity is Unknown, usually a controller still
+// reconciling or no longer reporting
+func isConditionUnknown(condition CustomResourceCondition) bool {
+	return conditionPolarity(condition.Type) != conditionNeutral && condition.Status == string(metav1.ConditionUnknown)
+}
+
+// Condition polarities, see conditionPolarity
+const (
+	conditionNeutral = iota
+	conditionPositive
+	conditionNegative
+)
+
+// conditionPolarity matches a condition type against the known suffixes, negative ones first so that
+// ReadyFailed is negative
+func conditionPolarity(conditionType string) int {
+	for _, suffix := range negativeConditionSuffixes {
+		if strings.HasSuffix(conditionType, suffix) {
+			return conditionNegative
+		}
+	}
+	for _, suffix := range positiveConditionSuffixes {
+		if strings.HasSuffix(conditionType, suffix) {
+			return conditionPositive
+		}
+	}
+	return conditionNeutral
+}
+
+func hasConditionProblem(conditions []CustomResourceCondition) bool {
+	for _, condition := range conditions {
+		if condition.Problem {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeConditions counts the unhealthy resources and the resources reporting each condition type and status
+func summarizeConditions(resources []CustomResourceConditions) CustomResourceConditionsSummary {
+	summary := CustomResourceConditionsSummary{Resources: resources, Counts: make(map[string]map[string]int)}
+	for _, resource := range resources {
+		if !resource.Healthy {
+			summary.Unhealthy++
+		}
+		for _, condition := range resource.Conditions {
+			if summary.Counts[condition.Type] == nil {
+				summary.Counts[condition.Type] = make(map[string]int)
+			}
+			summary.Counts[condition.Type][condition.Status]++
+		}
+	}
+	return summary
+}
+
+// describeConditionProblems lists the problem conditions of unhealthy resources, e.g. app/db Ready=False (Failover)
+func describeConditionProblems(resources []CustomResourceConditions) string {
+	var problems []string
+	for _, resource := range resources {
+		name := resource.Name
+		if resource.Namespace != "" {
+			name = resource.Namespace + "/" + resource.Name
+		}
+		for _, condition := range resource.Conditions {
+			if !condition.Problem {
+				continue
+			}
+			problem := fmt.Sprintf("%s %s=%s", name, condition.Type, condition.Status)
+			if condition.Reason != "" {
+				problem += fmt.Sprintf(" (%s)", condition.Reason)
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return strings.Join(problems, ", ")
+}
@@ -0,0 +1,138 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testWidgetWithConditions(namespace, name string, conditions ...map[string]interface{}) *unstructured.Unstructured {
+	widget := testWidget(name)
+	widget.SetNamespace(namespace)
+	items := make([]interface{}, 0, len(conditions))
+	for _, condition := range conditions {
+		items = append(items, condition)
+	}
+	if len(items) > 0 {
+		unstructured.SetNestedSlice(widget.Object, items, "status", "conditions")
+	}
+	return widget
+}
+
+func testCondition(conditionType, status, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               conditionType,
+		"status":             status,
+		"reason":             reason,
+		"lastTransitionTime": "2024-05-01T10:00:00Z",
+	}
+}
+
+func TestCustomResources_ConditionsSummary(t *testing.T) {
+	client := createTestCustomResourceClient(
+		testWidgetCRD(),
+		testWidgetWithConditions("default", "healthy", testCondition("Ready", "True", "Reconciled"), testCondition("Progressing", "False", "")),
+		testWidgetWithConditions("default", "broken", testCondition("Ready", "False", "ReconcileFailed")),
+		testWidgetWithConditions("default", "no-status"),
+		testWidgetWithConditions("other", "undiscovered", testCondition("Degraded", "True", "")),
+	)
+	resources := []Resource{
+		{GVR: widgetsGVR, Namespace: "default", Name: "healthy"},
+		{GVR: widgetsGVR, Namespace: "default", Name: "broken"},
+		{GVR: widgetsGVR, Namespace: "default", Name: "no-status"},
+	}
+
+	collectors := NewCustomResources(client).GenerateCustomResourceCollectors(context.Background(), resources)
+	if len(collectors) != 3 {
+		t.Fatalf("Expected definition, samples and conditions collectors, got %d", len(collectors))
+	}
+	summaryCollector := collectors[2]
+	if summaryCollector.Name != "auto-cr-conditions" || summaryCollector.Parameters["name"] != CustomResourceConditionsPath {
+		t.Errorf("Expected the conditions summary at %s, got %s at %v", CustomResourceConditionsPath, summaryCollector.Name, summaryCollector.Parameters["name"])
+	}
+	if summaryCollector.Priority != int(PriorityHigh) {
+		t.Errorf("Expected high priority with an unhealthy resource, got %d", summaryCollector.Priority)
+	}
+
+	var summary CustomResourceConditionsSummary
+	if err := json.Unmarshal([]byte(summaryCollector.Parameters["data"].(string)), &summary); err != nil {
+		t.Fatalf("Failed to parse summary: %v", err)
+	}
+	if len(summary.Resources) != 2 || summary.Unhealthy != 1 {
+		t.Fatalf("Expected 2 discovered widgets with conditions, 1 unhealthy, got %+v", summary)
+	}
+	broken := summary.Resources[0]
+	if broken.Name != "broken" || broken.Healthy || broken.Kind != "Widget" || broken.CRD != "widgets.example.com" {
+		t.Errorf("Expected the broken widget first and unhealthy, got %+v", broken)
+	}
+	if c := broken.Conditions[0]; !c.Problem || c.Reason != "ReconcileFailed" || c.LastTransitionTime != "2024-05-01T10:00:00Z" {
+		t.Errorf("Expected the Ready=False condition with its reason, got %+v", c)
+	}
+	if summary.Counts["Ready"]["True"] != 1 || summary.Counts["Ready"]["False"] != 1 || summary.Counts["Progressing"]["False"] != 1 {
+		t.Errorf("Expected counts per condition type and status, got %v", summary.Counts)
+	}
+}
+
+func TestIsConditionProblem(t *testing.T) {
+	tests := []struct {
+		conditionType string
+		status        string
+		expected      bool
+	}{
+		{conditionType: "Ready", status: "False", expected: true},
+		{conditionType: "Ready", status: "True", expected: false},
+		{conditionType: "Ready", status: "Unknown", expected: false},
+		{conditionType: "DatabaseAvailable", status: "False", expected: true},
+		{conditionType: "Degraded", status: "True", expected: true},
+		{conditionType: "Degraded", status: "False", expected: false},
+		{conditionType: "ReconcileError", status: "True", expected: true},
+		{conditionType: "Progressing", status: "False", expected: false},
+		{conditionType: "Paused", status: "True", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.conditionType+"="+tt.status, func(t *testing.T) {
+			condition := CustomResourceCondition{Type: tt.conditionType, Status: tt.status}
+			if problem := isConditionProblem(condition); problem != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, problem)
+			}
+		})
+	}
+}
+
+func TestAnalyzerGenerator_CustomResourceConditions(t *testing.T) {
+	client := createTestCustomResourceClient(
+		testWidgetWithConditions("default", "broken", testCondition("Ready", "False", "ReconcileFailed")),
+		testWidgetWithConditions("staging", "starting", testCondition("Ready", "Unknown", "")),
+	)
+	generator := NewAnalyzerGenerator(client)
+
+	analyzers := generator.GenerateAnalyzers([]Resource{
+		{GVR: widgetsGVR, Namespace: "default", Name: "broken"},
+		{GVR: widgetsGVR, Namespace: "staging", Name: "starting"},
+		{GVR: networkPoliciesGVR, Namespace: "default", Name: "deny-all"},
+		{GVR: schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, Name: "widgets.example.com"},
+	})
+
+	var conditionAnalyzers []AnalyzerSpec
+	for _, analyzer := range analyzers {
+		if analyzer.Type == AnalyzerTypeCustomResourceConditions {
+			conditionAnalyzers = append(conditionAnalyzers, analyzer)
+		}
+	}
+	if len(conditionAnalyzers) != 2 || conditionAnalyzers[0].Name != "auto-cr-conditions-default-widgets.example.com" {
+		t.Fatalf("Expected one analyzer per namespace for widgets only, got %v", conditionAnalyzers)
+	}
+
+	results := generator.RunAnalyzers(context.Background(), conditionAnalyzers)
+	if results[0].Result != AnalyzerResultFail || !strings.Contains(results[0].Detail, "default/broken Ready=False (ReconcileFailed)") {
+		t.Errorf("Expected the broken widget to fail, got %+v", results[0])
+	}
+	if results[1].Result != AnalyzerResultWarn || results[1].Detail != "starting Ready=Unknown" {
+		t.Errorf("Expected the starting widget to warn, got %+v", results[1])
+	}
+}
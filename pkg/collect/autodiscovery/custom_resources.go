@@ -0,0 +1,215 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultCustomResourceSampleSize is the number of custom resources sampled per type
+const DefaultCustomResourceSampleSize = 5
+
+var crdsGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// CustomResourceVersion is one version of a CRD with its OpenAPI schema
+type CustomResourceVersion struct {
+	Name       string                 `json:"name"`
+	Served     bool                   `json:"served"`
+	Storage    bool                   `json:"storage"`
+	Deprecated bool                   `json:"deprecated,omitempty"`
+	Schema     map[string]interface{} `json:"schema,omitempty"` // openAPIV3Schema
+}
+
+// CustomResourceDefinitionSummary is the part of a CRD needed to understand an operator API
+type CustomResourceDefinitionSummary struct {
+	Name       string                  `json:"name"`
+	Group      string                  `json:"group"`
+	Kind       string                  `json:"kind"`
+	Plural     string                  `json:"plural"`
+	Scope      string                  `json:"scope"`
+	Versions   []CustomResourceVersion `json:"versions"`
+	Conversion map[string]interface{}  `json:"conversion,omitempty"` // Strategy and conversion webhook config, without the CA bundle
+}
+
+// CustomResourceSamples is a bounded sample of the discovered custom resources of one type
+type CustomResourceSamples struct {
+	CRD        string                   `json:"crd"`
+	Discovered int                      `json:"discovered"`
+	Samples    []map[string]interface{} `json:"samples"`
+}
+
+// CustomResources generates collectors for the CRDs of discovered custom resources
+type CustomResources struct {
+	dynamicClient dynamic.Interface
+	sampleSize    int
+}
+
+// NewCustomResources creates a new CustomResources
+func NewCustomResources(dynamicClient dynamic.Interface) *CustomResources {
+	return &CustomResources{
+		dynamicClient: dynamicClient,
+		sampleSize:    DefaultCustomResourceSampleSize,
+	}
+}
+
+// GenerateCustomResourceCollectors returns two data collectors per CRD with discovered resources:
+// the CRD definition and a sample of at most sampleSize of its discovered resources
+// When any discovered resource reports status.conditions, a conditions summary is added for all CRDs
+// CRDs are read by name, <resource>.<group>, so built-in types in dotted groups are skipped on not found
+func (c *CustomResources) GenerateCustomResourceCollectors(ctx context.Context, resources []Resource) []CollectorSpec {
+	byCRD := make(map[string][]Resource)
+	for _, resource := range resources {
+		// CRD groups always contain a dot, so core, apps, batch and similar groups are built in
+		if !strings.Contains(resource.GVR.Group, ".") || resource.GVR == crdsGVR {
+			continue
+		}
+		key := resource.GVR.Resource + "." + resource.GVR.Group
+		byCRD[key] = append(byCRD[key], resource)
+	}
+
+	names := make([]string, 0, len(byCRD))
+	for name := range byCRD {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var collectors []CollectorSpec
+	var conditions []CustomResourceConditions
+	for _, name := range names {
+		crd, err := c.dynamicClient.Resource(crdsGVR).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to get customresourcedefinition %s: %v\n", name, err)
+			continue
+		}
+		discovered := byCRD[name]
+
+		definition, err := json.MarshalIndent(summarizeCRD(*crd), "", "  ")
+		if err != nil {
+			continue
+		}
+		samples, err := json.MarshalIndent(c.sampleResources(ctx, crd.GetName(), discovered), "", "  ")
+		if err != nil {
+			continue
+		}
+
+		collectors = append(collectors,
+			CollectorSpec{
+				Type:     "data",
+				Name:     fmt.Sprintf("auto-crd-%s", crd.GetName()),
+				Group:    CollectorGroupWorkloads,
+				Priority: int(PriorityNormal),
+				Parameters: map[string]interface{}{
+					"name": fmt.Sprintf("custom-resources/%s/definition.json", crd.GetName()),
+					"data": string(definition),
+				},
+			},
+			CollectorSpec{
+				Type:     "data",
+				Name:     fmt.Sprintf("auto-cr-samples-%s", crd.GetName()),
+				Group:    CollectorGroupWorkloads,
+				Priority: int(PriorityNormal),
+				Parameters: map[string]interface{}{
+					"name": fmt.Sprintf("custom-resources/%s/samples.json", crd.GetName()),
+					"data": string(samples),
+				},
+			},
+		)
+		conditions = append(conditions, c.discoveredConditions(ctx, crd.GetName(), discovered)...)
+	}
+
+	if len(conditions) > 0 {
+		summary := summarizeConditions(conditions)
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return collectors
+		}
+		priority := PriorityNormal
+		if summary.Unhealthy > 0 {
+			priority = PriorityHigh
+		}
+		collectors = append(collectors, CollectorSpec{
+			Type:     "data",
+			Name:     "auto-cr-conditions",
+			Group:    CollectorGroupWorkloads,
+			Priority: int(priority),
+			Parameters: map[string]interface{}{
+				"name": CustomResourceConditionsPath,
+				"data": string(data),
+			},
+		})
+	}
+	return collectors
+}
+
+// sampleResources reads the first sampleSize resources, by namespace and name, without managed fields
+// Resources that cannot be read are left out of the sample
+func (c *CustomResources) sampleResources(ctx context.Context, crdName string, resources []Resource) CustomResourceSamples {
+	sorted := append([]Resource{}, resources...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	samples := CustomResourceSamples{CRD: crdName, Discovered: len(resources), Samples: []map[string]interface{}{}}
+	for _, resource := range sorted {
+		if len(samples.Samples) >= c.sampleSize {
+			break
+		}
+
+		var resourceClient dynamic.ResourceInterface = c.dynamicClient.Resource(resource.GVR)
+		if resource.Namespace != "" {
+			resourceClient = c.dynamicClient.Resource(resource.GVR).Namespace(resource.Namespace)
+		}
+		obj, err := resourceClient.Get(ctx, resource.Name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+		samples.Samples = append(samples.Samples, obj.Object)
+	}
+	return samples
+}
+
+// summarizeCRD keeps the names, scope, versions with their schemas and the conversion config of a CRD
+func summarizeCRD(crd unstructured.Unstructured) CustomResourceDefinitionSummary {
+	summary := CustomResourceDefinitionSummary{Name: crd.GetName(), Versions: []CustomResourceVersion{}}
+	summary.Group, _, _ = unstructured.NestedString(crd.Object, "spec", "group")
+	summary.Kind, _, _ = unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	summary.Plural, _, _ = unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	summary.Scope, _, _ = unstructured.NestedString(crd.Object, "spec", "scope")
+
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entry := CustomResourceVersion{}
+		entry.Name, _, _ = unstructured.NestedString(version, "name")
+		entry.Served, _, _ = unstructured.NestedBool(version, "served")
+		entry.Storage, _, _ = unstructured.NestedBool(version, "storage")
+		entry.Deprecated, _, _ = unstructured.NestedBool(version, "deprecated")
+		entry.Schema, _, _ = unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		summary.Versions = append(summary.Versions, entry)
+	}
+
+	if conversion, found, _ := unstructured.NestedMap(crd.Object, "spec", "conversion"); found {
+		// The CA bundle is long and says nothing about why conversion fails
+		unstructured.RemoveNestedField(conversion, "webhook", "clientConfig", "caBundle")
+		summary.Conversion = conversion
+	}
+	return summary
+}
@@ -0,0 +1,127 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var widgetsGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func testWidgetCRD() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "widgets.example.com"},
+		"spec": map[string]interface{}{
+			"group": "example.com",
+			"scope": "Namespaced",
+			"names": map[string]interface{}{"kind": "Widget", "plural": "widgets"},
+			"versions": []interface{}{map[string]interface{}{
+				"name":    "v1",
+				"served":  true,
+				"storage": true,
+				"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{
+					"type": "object",
+				}},
+			}},
+			"conversion": map[string]interface{}{
+				"strategy": "Webhook",
+				"webhook": map[string]interface{}{"clientConfig": map[string]interface{}{
+					"caBundle": "LS0tLS1CRUdJTi...",
+					"service":  map[string]interface{}{"namespace": "widgets", "name": "widget-webhook"},
+				}},
+			},
+		},
+	}}
+}
+
+func testWidget(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":          name,
+			"namespace":     "default",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec": map[string]interface{}{"size": int64(3)},
+	}}
+}
+
+func createTestCustomResourceClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(createTestScheme(), map[schema.GroupVersionResource]string{
+		crdsGVR:    "CustomResourceDefinitionList",
+		widgetsGVR: "WidgetList",
+	}, objects...)
+}
+
+func TestCustomResources_GenerateCustomResourceCollectors(t *testing.T) {
+	objects := []runtime.Object{testWidgetCRD()}
+	var resources []Resource
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("widget-%d", i)
+		objects = append(objects, testWidget(name))
+		resources = append(resources, Resource{GVR: widgetsGVR, Namespace: "default", Name: name})
+	}
+	resources = append(resources,
+		Resource{GVR: deploymentsGVR, Namespace: "default", Name: "app"},
+		Resource{GVR: networkPoliciesGVR, Namespace: "default", Name: "deny-all"},
+	)
+
+	collectors := NewCustomResources(createTestCustomResourceClient(objects...)).GenerateCustomResourceCollectors(context.Background(), resources)
+	if len(collectors) != 2 {
+		t.Fatalf("Expected 2 collectors, got %d", len(collectors))
+	}
+	if collectors[0].Name != "auto-crd-widgets.example.com" || collectors[1].Name != "auto-cr-samples-widgets.example.com" {
+		t.Errorf("Expected definition and samples collectors, got %s and %s", collectors[0].Name, collectors[1].Name)
+	}
+	if collectors[1].Parameters["name"] != "custom-resources/widgets.example.com/samples.json" {
+		t.Errorf("Expected samples under custom-resources/, got %v", collectors[1].Parameters["name"])
+	}
+
+	var definition CustomResourceDefinitionSummary
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &definition); err != nil {
+		t.Fatalf("Failed to parse definition: %v", err)
+	}
+	if definition.Kind != "Widget" || definition.Scope != "Namespaced" || len(definition.Versions) != 1 {
+		t.Errorf("Expected the Widget CRD summary, got %+v", definition)
+	}
+	if definition.Versions[0].Schema["type"] != "object" {
+		t.Errorf("Expected the openAPIV3Schema to be kept, got %v", definition.Versions[0].Schema)
+	}
+	if strings.Contains(collectors[0].Parameters["data"].(string), "caBundle") {
+		t.Errorf("Expected the conversion webhook CA bundle to be stripped")
+	}
+
+	var samples CustomResourceSamples
+	if err := json.Unmarshal([]byte(collectors[1].Parameters["data"].(string)), &samples); err != nil {
+		t.Fatalf("Failed to parse samples: %v", err)
+	}
+	if samples.Discovered != 7 || len(samples.Samples) != DefaultCustomResourceSampleSize {
+		t.Errorf("Expected %d of 7 widgets to be sampled, got %d of %d", DefaultCustomResourceSampleSize, len(samples.Samples), samples.Discovered)
+	}
+	if _, found, _ := unstructured.NestedSlice(samples.Samples[0], "metadata", "managedFields"); found {
+		t.Errorf("Expected managedFields to be stripped from samples")
+	}
+}
+
+func TestCustomResources_GenerateCustomResourceCollectors_NoCRD(t *testing.T) {
+	resources := []Resource{
+		{GVR: podsGVR, Namespace: "default", Name: "web-1"},
+		{GVR: widgetsGVR, Namespace: "default", Name: "widget-0"},
+	}
+
+	// The widgets CRD is not installed, so widgets are treated like a built-in type
+	collectors := NewCustomResources(createTestCustomResourceClient()).GenerateCustomResourceCollectors(context.Background(), resources)
+	if len(collectors) != 0 {
+		t.Errorf("Expected no collectors without a matching CRD, got %d", len(collectors))
+	}
+}
@@ -3,6 +3,7 @@ package autodiscovery
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,10 +12,39 @@ import (
 	"k8s.io/client-go/dynamic"
 )
 
+// DefaultMaxExpandedResources caps how many resources dependency resolution may add to a discovery
+const DefaultMaxExpandedResources = 5000
+
 // DependencyResolver identifies and resolves resource dependencies
 type DependencyResolver struct {
 	dynamicClient dynamic.Interface
 	maxDepth      int
+	maxResources  int
+	excluded      []string // Namespace globs dependencies are never followed into
+	protected     []string // Namespace globs never read, even when a dependency points into them
+	lastReport    *DependencyReport
+}
+
+// DependencyCycle is a chain of resources that resolve back to the first one, e.g. a pod and the service selecting it
+type DependencyCycle struct {
+	Chain []string `json:"chain"` // resource/namespace/name, the first entry is repeated at the end
+}
+
+// String returns the chain joined with arrows
+func (c DependencyCycle) String() string {
+	return strings.Join(c.Chain, " -> ")
+}
+
+// DependencyReport describes the last dependency resolution
+type DependencyReport struct {
+	Added     int               `json:"added"` // Resources added on top of the discovered ones
+	Limit     int               `json:"limit"`
+	Truncated bool              `json:"truncated,omitempty"` // The limit was reached, remaining dependencies were skipped
+	Cycles    []DependencyCycle `json:"cycles,omitempty"`
+	// CrossNamespace counts resources added from a namespace other than the resource they were found from
+	CrossNamespace int `json:"crossNamespace,omitempty"`
+	// Protected counts dependencies skipped because they live in a protected namespace
+	Protected int `json:"protected,omitempty"`
 }
 
 // NewDependencyResolver creates a new DependencyResolver
@@ -22,52 +52,163 @@ func NewDependencyResolver(dynamicClient dynamic.Interface, maxDepth int) *Depen
 	return &DependencyResolver{
 		dynamicClient: dynamicClient,
 		maxDepth:      maxDepth,
+		maxResources:  DefaultMaxExpandedResources,
+	}
+}
+
+// SetMaxResources caps how many resources ResolveDependencies may add, 0 disables the cap
+func (dr *DependencyResolver) SetMaxResources(maxResources int) {
+	dr.maxResources = maxResources
+}
+
+// SetExcludedNamespaces stops dependencies from being followed into the given namespaces, e.g. through an
+// ExternalName service or a service FQDN in a ConfigMap
+func (dr *DependencyResolver) SetExcludedNamespaces(namespaces []string) {
+	dr.excluded = namespaces
+}
+
+// SetProtectedNamespaces stops dependencies from being read or followed into namespaces matching the globs,
+// unlike excluded namespaces this also applies to services a ConfigMap refers to by FQDN
+func (dr *DependencyResolver) SetProtectedNamespaces(patterns []string) {
+	dr.protected = patterns
+}
+
+// Report returns the report of the last ResolveDependencies call, nil before the first one
+func (dr *DependencyResolver) Report() *DependencyReport {
+	if dr == nil {
+		return nil
 	}
+	return dr.lastReport
 }
 
 // ResolveDependencies finds related resources and returns expanded resource list
+// Each depth only expands the resources added by the previous one, and every resource remembers the
+// resource it was found from so dependencies pointing back up that chain are reported as cycles
 func (dr *DependencyResolver) ResolveDependencies(ctx context.Context, resources []Resource) ([]Resource, error) {
+	report := &DependencyReport{Limit: dr.maxResources}
+	dr.lastReport = report
+
 	visited := make(map[string]bool)
+	parents := make(map[string]string) // Provenance: resource key to the key of the resource it was found from
+	labels := make(map[string]string)
+	seenCycles := make(map[string]bool)
 	result := make([]Resource, len(resources))
 	copy(result, resources)
-	
+
 	// Mark initial resources as visited
 	for _, resource := range resources {
 		key := dr.resourceKey(resource)
 		visited[key] = true
+		labels[key] = dependencyLabel(resource)
 	}
 
 	// Resolve dependencies up to maxDepth
+	frontier := resources
 	for depth := 0; depth < dr.maxDepth; depth++ {
 		newResources := []Resource{}
-		
-		for _, resource := range result {
+		expired := false
+
+		for _, resource := range frontier {
+			if phaseExpired(ctx, PhaseDependencyResolve) {
+				expired = true
+				break
+			}
+			if report.Truncated {
+				break
+			}
+
 			dependencies, err := dr.findResourceDependencies(ctx, resource)
 			if err != nil {
 				// Log warning but continue
 				fmt.Printf("Warning: failed to resolve dependencies for %s/%s: %v\n", resource.Namespace, resource.Name, err)
 				continue
 			}
-			
+
+			from := dr.resourceKey(resource)
 			for _, dep := range dependencies {
+				if dep.Namespace != "" && MatchesAnyGlob(dr.excluded, dep.Namespace) {
+					continue
+				}
+				if IsProtectedNamespace(dr.protected, dep.Namespace) {
+					report.Protected++
+					continue
+				}
 				key := dr.resourceKey(dep)
-				if !visited[key] {
-					visited[key] = true
-					newResources = append(newResources, dep)
+				if visited[key] {
+					if cycle, ok := dependencyCycle(from, key, parents, labels); ok {
+						if id := cycleID(cycle); !seenCycles[id] {
+							seenCycles[id] = true
+							report.Cycles = append(report.Cycles, cycle)
+						}
+					}
+					continue
+				}
+				if dr.maxResources > 0 && report.Added >= dr.maxResources {
+					report.Truncated = true
+					break
+				}
+				visited[key] = true
+				parents[key] = from
+				labels[key] = dependencyLabel(dep)
+				newResources = append(newResources, dep)
+				report.Added++
+				if dep.Namespace != "" && resource.Namespace != "" && dep.Namespace != resource.Namespace {
+					report.CrossNamespace++
 				}
 			}
 		}
-		
-		if len(newResources) == 0 {
-			break // No more dependencies found
-		}
-		
+
+		// Keep dependencies found before the deadline
 		result = append(result, newResources...)
+
+		if expired || report.Truncated || len(newResources) == 0 {
+			break // Deadline reached, limit reached or no more dependencies found
+		}
+		frontier = newResources
+	}
+
+	if report.Truncated {
+		fmt.Printf("Warning: dependency resolution stopped after adding %d resources, remaining dependencies were not collected\n", report.Added)
 	}
 
 	return result, nil
 }
 
+// dependencyCycle returns the cycle closed by from depending on key, when key is from itself or one of its ancestors
+func dependencyCycle(from, key string, parents, labels map[string]string) (DependencyCycle, bool) {
+	ancestors := []string{from}
+	for current := from; current != key; {
+		parent, ok := parents[current]
+		if !ok {
+			return DependencyCycle{}, false // Reached a discovered resource without meeting key
+		}
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+
+	chain := make([]string, 0, len(ancestors)+1)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		chain = append(chain, labels[ancestors[i]])
+	}
+	chain = append(chain, labels[key])
+	return DependencyCycle{Chain: chain}, true
+}
+
+// cycleID identifies a cycle by its members so the same loop entered from different resources is reported once
+func cycleID(cycle DependencyCycle) string {
+	members := append([]string{}, cycle.Chain[:len(cycle.Chain)-1]...)
+	sort.Strings(members)
+	return strings.Join(members, ",")
+}
+
+// dependencyLabel returns resource/namespace/name, or resource/name for cluster-scoped resources
+func dependencyLabel(resource Resource) string {
+	if resource.Namespace == "" {
+		return resource.GVR.Resource + "/" + resource.Name
+	}
+	return resource.GVR.Resource + "/" + resource.Namespace + "/" + resource.Name
+}
+
 // findResourceDependencies identifies dependencies for a specific resource
 func (dr *DependencyResolver) findResourceDependencies(ctx context.Context, resource Resource) ([]Resource, error) {
 	switch resource.GVR.Resource {
@@ -79,8 +220,16 @@ func (dr *DependencyResolver) findResourceDependencies(ctx context.Context, reso
 		return dr.resolveStatefulSetDependencies(ctx, resource)
 	case "services":
 		return dr.resolveServiceDependencies(ctx, resource)
+	case "configmaps":
+		return dr.resolveConfigMapDependencies(ctx, resource)
 	case "ingresses":
 		return dr.resolveIngressDependencies(ctx, resource)
+	case "daemonsets", "jobs":
+		return dr.findPodsOwnedBy(ctx, resource)
+	case "cronjobs":
+		return dr.resolveCronJobDependencies(ctx, resource)
+	case "horizontalpodautoscalers":
+		return dr.resolveHPADependencies(ctx, resource)
 	default:
 		return []Resource{}, nil // No known dependencies
 	}
@@ -295,6 +444,7 @@ func (dr *DependencyResolver) resolveStatefulSetDependencies(ctx context.Context
 }
 
 // resolveServiceDependencies finds endpoints and pods targeted by a Service
+// Besides the selected pods, it follows ExternalName services and endpoints to services and pods in other namespaces
 func (dr *DependencyResolver) resolveServiceDependencies(ctx context.Context, resource Resource) ([]Resource, error) {
 	var dependencies []Resource
 
@@ -306,6 +456,7 @@ func (dr *DependencyResolver) resolveServiceDependencies(ctx context.Context, re
 			Namespace: endpoints.GetNamespace(),
 			Name:      endpoints.GetName(),
 		})
+		dependencies = append(dependencies, dr.resolveEndpointTargets(endpoints)...)
 	}
 
 	// Get service to find selector
@@ -314,6 +465,9 @@ func (dr *DependencyResolver) resolveServiceDependencies(ctx context.Context, re
 	if err != nil {
 		return dependencies, nil
 	}
+	if serviceType, _, _ := unstructured.NestedString(service.Object, "spec", "type"); serviceType == "ExternalName" {
+		dependencies = append(dependencies, dr.resolveExternalNameService(ctx, service)...)
+	}
 
 	// Find pods matching the service selector
 	if spec, found, err := unstructured.NestedMap(service.Object, "spec"); err == nil && found {
@@ -353,6 +507,67 @@ func (dr *DependencyResolver) resolveIngressDependencies(ctx context.Context, re
 	return dependencies, nil
 }
 
+// resolveCronJobDependencies finds Jobs spawned by a CronJob and the pods they run
+func (dr *DependencyResolver) resolveCronJobDependencies(ctx context.Context, resource Resource) ([]Resource, error) {
+	var dependencies []Resource
+
+	jobGVR := schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	jobList, err := dr.dynamicClient.Resource(jobGVR).Namespace(resource.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return dependencies, nil
+	}
+
+	for _, job := range jobList.Items {
+		if !dr.isOwnedBy(job, resource) {
+			continue
+		}
+
+		jobResource := Resource{
+			GVR:       jobGVR,
+			Namespace: job.GetNamespace(),
+			Name:      job.GetName(),
+		}
+		dependencies = append(dependencies, jobResource)
+
+		// Find pods owned by this Job
+		podDeps, err := dr.findPodsOwnedBy(ctx, jobResource)
+		if err == nil {
+			dependencies = append(dependencies, podDeps...)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// resolveHPADependencies finds the workload scaled by a HorizontalPodAutoscaler
+func (dr *DependencyResolver) resolveHPADependencies(ctx context.Context, resource Resource) ([]Resource, error) {
+	var dependencies []Resource
+
+	hpa, err := dr.dynamicClient.Resource(resource.GVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+	if err != nil {
+		return dependencies, nil
+	}
+
+	targetRef, found, err := unstructured.NestedStringMap(hpa.Object, "spec", "scaleTargetRef")
+	if err != nil || !found || targetRef["kind"] == "" || targetRef["name"] == "" {
+		return dependencies, nil
+	}
+
+	gv, err := schema.ParseGroupVersion(targetRef["apiVersion"])
+	if err != nil {
+		return dependencies, nil
+	}
+
+	// The scale target itself is expanded on the next depth iteration
+	dependencies = append(dependencies, Resource{
+		GVR:       gv.WithResource(dr.kindToResource(targetRef["kind"])),
+		Namespace: resource.Namespace,
+		Name:      targetRef["name"],
+	})
+
+	return dependencies, nil
+}
+
 // Helper functions
 
 func (dr *DependencyResolver) resourceKey(resource Resource) string {
@@ -376,12 +591,20 @@ func (dr *DependencyResolver) gvrToKind(gvr schema.GroupVersionResource) string
 		"replicasets":  "ReplicaSet", 
 		"statefulsets": "StatefulSet",
 		"daemonsets":   "DaemonSet",
+		"jobs":         "Job",
+		"cronjobs":     "CronJob",
 		"pods":         "Pod",
 		"services":     "Service",
 	}
 	return kindMap[gvr.Resource]
 }
 
+// kindToResource maps a kind to its plural resource name
+func (dr *DependencyResolver) kindToResource(kind string) string {
+	// Simple mapping - in production this would use discovery API
+	return strings.ToLower(kind) + "s"
+}
+
 func (dr *DependencyResolver) findPodsOwnedBy(ctx context.Context, owner Resource) ([]Resource, error) {
 	var pods []Resource
 	
@@ -0,0 +1,228 @@
+package autodiscovery
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func ownedPod(name, namespace, ownerKind, ownerName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{{Kind: ownerKind, Name: ownerName}},
+		},
+	}
+}
+
+func TestDependencyResolver_findResourceDependencies(t *testing.T) {
+	client := createTestDynamicClient(
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"}},
+		ownedPod("agent-abc", "default", "DaemonSet", "agent"),
+		ownedPod("agent-def", "default", "DaemonSet", "agent"),
+		ownedPod("other", "default", "DaemonSet", "other-agent"),
+		&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"}},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "backup-28000000",
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "backup"}},
+			},
+		},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"}},
+		ownedPod("backup-28000000-xyz", "default", "Job", "backup-28000000"),
+		ownedPod("migrate-xyz", "default", "Job", "migrate"),
+		&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "autoscaling/v2",
+				"kind":       "HorizontalPodAutoscaler",
+				"metadata": map[string]interface{}{
+					"name":      "web",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{
+					"scaleTargetRef": map[string]interface{}{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"name":       "web",
+					},
+				},
+			},
+		},
+	)
+	resolver := NewDependencyResolver(client, 3)
+
+	tests := []struct {
+		name     string
+		resource Resource
+		expected []string
+	}{
+		{
+			name:     "daemonset to pods",
+			resource: Resource{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, Namespace: "default", Name: "agent"},
+			expected: []string{"pods/agent-abc", "pods/agent-def"},
+		},
+		{
+			name:     "cronjob to jobs to pods",
+			resource: Resource{GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, Namespace: "default", Name: "backup"},
+			expected: []string{"jobs/backup-28000000", "pods/backup-28000000-xyz"},
+		},
+		{
+			name:     "job to pods",
+			resource: Resource{GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, Namespace: "default", Name: "migrate"},
+			expected: []string{"pods/migrate-xyz"},
+		},
+		{
+			name:     "hpa to scale target",
+			resource: Resource{GVR: schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, Namespace: "default", Name: "web"},
+			expected: []string{"deployments/web"},
+		},
+		{
+			name:     "missing hpa",
+			resource: Resource{GVR: schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, Namespace: "default", Name: "missing"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependencies, err := resolver.findResourceDependencies(context.Background(), tt.resource)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			names := make([]string, 0, len(dependencies))
+			for _, dep := range dependencies {
+				names = append(names, dep.GVR.Resource+"/"+dep.Name)
+			}
+			sort.Strings(names)
+
+			if len(names) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, names)
+			}
+			for i := range names {
+				if names[i] != tt.expected[i] {
+					t.Errorf("Expected %s, got %s", tt.expected[i], names[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDependencyResolver_HPAScaleTargetGVR(t *testing.T) {
+	client := createTestDynamicClient(&unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "autoscaling/v2",
+			"kind":       "HorizontalPodAutoscaler",
+			"metadata": map[string]interface{}{
+				"name":      "db",
+				"namespace": "data",
+			},
+			"spec": map[string]interface{}{
+				"scaleTargetRef": map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       "StatefulSet",
+					"name":       "postgres",
+				},
+			},
+		},
+	})
+	resolver := NewDependencyResolver(client, 1)
+
+	hpaGVR := schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+	dependencies, err := resolver.findResourceDependencies(context.Background(), Resource{GVR: hpaGVR, Namespace: "data", Name: "db"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dependencies) != 1 {
+		t.Fatalf("Expected 1 dependency, got %d", len(dependencies))
+	}
+
+	expected := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	if dependencies[0].GVR != expected {
+		t.Errorf("Expected GVR %v, got %v", expected, dependencies[0].GVR)
+	}
+	if dependencies[0].Namespace != "data" || dependencies[0].Name != "postgres" {
+		t.Errorf("Expected data/postgres, got %s/%s", dependencies[0].Namespace, dependencies[0].Name)
+	}
+}
+
+func selectedPod(name string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"}}}
+}
+
+func TestDependencyResolver_ResolveDependencies_Cycles(t *testing.T) {
+	client := createTestDynamicClient(
+		selectedPod("web-1"),
+		selectedPod("web-2"),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		},
+	)
+	resolver := NewDependencyResolver(client, 5)
+	if resolver.Report() != nil {
+		t.Errorf("Expected no report before resolving")
+	}
+
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	result, err := resolver.ResolveDependencies(context.Background(), []Resource{{GVR: podGVR, Namespace: "default", Name: "web-1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// web-1 finds the service, the service finds web-1 again and web-2
+	if len(result) != 3 {
+		t.Errorf("Expected 3 resources, got %+v", result)
+	}
+
+	report := resolver.Report()
+	if report == nil || report.Added != 2 || report.Truncated {
+		t.Fatalf("Expected 2 added resources without truncation, got %+v", report)
+	}
+	expected := []string{
+		"pods/default/web-1 -> services/default/web -> pods/default/web-1",
+		"services/default/web -> pods/default/web-2 -> services/default/web",
+	}
+	if len(report.Cycles) != len(expected) {
+		t.Fatalf("Expected %d cycles, got %v", len(expected), report.Cycles)
+	}
+	for i, cycle := range report.Cycles {
+		if cycle.String() != expected[i] {
+			t.Errorf("Expected cycle %q, got %q", expected[i], cycle.String())
+		}
+	}
+}
+
+func TestDependencyResolver_ResolveDependencies_MaxResources(t *testing.T) {
+	client := createTestDynamicClient(
+		selectedPod("web-1"),
+		selectedPod("web-2"),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		},
+	)
+	resolver := NewDependencyResolver(client, 5)
+	resolver.SetMaxResources(1)
+
+	serviceGVR := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	result, err := resolver.ResolveDependencies(context.Background(), []Resource{{GVR: serviceGVR, Namespace: "default", Name: "web"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("Expected the service and one pod, got %+v", result)
+	}
+	if report := resolver.Report(); !report.Truncated || report.Added != 1 || report.Limit != 1 {
+		t.Errorf("Expected a truncated report at 1 resource, got %+v", report)
+	}
+}
@@ -0,0 +1,179 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+)
+
+// DeprecationsReportFileName is the bundle file listing the deprecated APIs discovery was warned about
+const DeprecationsReportFileName = "deprecations-report.json"
+
+var (
+	deprecatedInPattern = regexp.MustCompile(`deprecated in (v\d+\.\d+)`)
+	removedInPattern    = regexp.MustCompile(`unavailable in (v\d+\.\d+)`)
+	replacementPattern  = regexp.MustCompile(`; use (\S+ \S+)`)
+)
+
+// DeprecatedAPI is a GVR the API server returned deprecation warnings for, e.g.
+// "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+, unavailable in v1.25+; use policy/v1 PodDisruptionBudget"
+type DeprecatedAPI struct {
+	Group        string   `json:"group"`
+	Version      string   `json:"version"`
+	Resource     string   `json:"resource"`
+	Warnings     []string `json:"warnings"`
+	Requests     int      `json:"requests"`               // Requests answered with a deprecation warning
+	DeprecatedIn string   `json:"deprecatedIn,omitempty"` // e.g. v1.21
+	RemovedIn    string   `json:"removedIn,omitempty"`    // The release an upgrade to will break clients, e.g. v1.25
+	Replacement  string   `json:"replacement,omitempty"`  // e.g. policy/v1 PodDisruptionBudget
+}
+
+// GroupVersion returns the API version of the deprecated resource, e.g. policy/v1beta1
+func (d DeprecatedAPI) GroupVersion() string {
+	return schema.GroupVersion{Group: d.Group, Version: d.Version}.String()
+}
+
+// String summarizes a deprecated API, e.g. "policy/v1beta1 poddisruptionbudgets (removed in v1.25, use policy/v1 PodDisruptionBudget)"
+func (d DeprecatedAPI) String() string {
+	var details []string
+	if d.RemovedIn != "" {
+		details = append(details, "removed in "+d.RemovedIn)
+	}
+	if d.Replacement != "" {
+		details = append(details, "use "+d.Replacement)
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("%s %s", d.GroupVersion(), d.Resource)
+	}
+	return fmt.Sprintf("%s %s (%s)", d.GroupVersion(), d.Resource, strings.Join(details, ", "))
+}
+
+// DeprecationReport lists the deprecated APIs used during discovery, sorted by group, version and resource
+type DeprecationReport struct {
+	Deprecations []DeprecatedAPI `json:"deprecations"`
+}
+
+// DeprecationRecorder records the deprecation warnings the API server returns in Warning headers, per GVR
+type DeprecationRecorder struct {
+	mu   sync.Mutex
+	apis map[schema.GroupVersionResource]*DeprecatedAPI
+}
+
+// NewDeprecationRecorder creates a DeprecationRecorder
+func NewDeprecationRecorder() *DeprecationRecorder {
+	return &DeprecationRecorder{apis: make(map[schema.GroupVersionResource]*DeprecatedAPI)}
+}
+
+// Install wraps the config's transport so every client built from it records deprecation warnings
+func (r *DeprecationRecorder) Install(config *rest.Config) {
+	config.Wrap(r.WrapTransport)
+}
+
+// WrapTransport returns a RoundTripper that records the deprecation warnings of each response
+func (r *DeprecationRecorder) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return &deprecationTransport{next: next, recorder: r}
+}
+
+// Record adds the deprecation warnings in the Warning header values of a response to the request path's GVR
+// Paths that do not name a resource, e.g. discovery, and warnings that are not deprecations are ignored
+func (r *DeprecationRecorder) Record(path string, headers []string) {
+	if len(headers) == 0 {
+		return
+	}
+	gvr, ok := gvrFromRequestPath(path)
+	if !ok {
+		return
+	}
+
+	warnings, _ := utilnet.ParseWarningHeaders(headers)
+	var texts []string
+	for _, warning := range warnings {
+		if strings.Contains(strings.ToLower(warning.Text), "deprecated") {
+			texts = append(texts, warning.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	api, exists := r.apis[gvr]
+	if !exists {
+		api = &DeprecatedAPI{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource}
+		r.apis[gvr] = api
+	}
+	api.Requests++
+	for _, text := range texts {
+		if !containsString(api.Warnings, text) {
+			api.Warnings = append(api.Warnings, text)
+		}
+		parseDeprecationWarning(api, text)
+	}
+}
+
+// Report returns the deprecated APIs recorded so far
+func (r *DeprecationRecorder) Report() *DeprecationReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &DeprecationReport{Deprecations: []DeprecatedAPI{}}
+	for _, api := range r.apis {
+		deprecated := *api
+		deprecated.Warnings = append([]string{}, api.Warnings...)
+		report.Deprecations = append(report.Deprecations, deprecated)
+	}
+	sort.Slice(report.Deprecations, func(i, j int) bool {
+		a, b := report.Deprecations[i], report.Deprecations[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Resource < b.Resource
+	})
+	return report
+}
+
+// parseDeprecationWarning fills the releases and replacement of api from the standard deprecation warning text
+func parseDeprecationWarning(api *DeprecatedAPI, text string) {
+	if match := deprecatedInPattern.FindStringSubmatch(text); match != nil && api.DeprecatedIn == "" {
+		api.DeprecatedIn = match[1]
+	}
+	if match := removedInPattern.FindStringSubmatch(text); match != nil && api.RemovedIn == "" {
+		api.RemovedIn = match[1]
+	}
+	if match := replacementPattern.FindStringSubmatch(text); match != nil && api.Replacement == "" {
+		api.Replacement = match[1]
+	}
+}
+
+// gvrFromRequestPath returns the GVR of an API request path, e.g. /apis/apps/v1/namespaces/default/deployments/web
+// Prefixes before /api or /apis, e.g. from a proxied cluster URL, are ignored
+func gvrFromRequestPath(path string) (schema.GroupVersionResource, bool) {
+	request, ok := parseAPIRequestPath(path)
+	return request.gvr, ok
+}
+
+// deprecationTransport records the deprecation warnings of every response
+type deprecationTransport struct {
+	next     http.RoundTripper
+	recorder *DeprecationRecorder
+}
+
+func (dt *deprecationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := dt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	dt.recorder.Record(req.URL.Path, resp.Header.Values("Warning"))
+	return resp, nil
+}
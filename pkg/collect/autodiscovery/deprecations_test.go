@@ -0,0 +1,99 @@
+package autodiscovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const pdbDeprecationWarning = `299 - "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+, unavailable in v1.25+; use policy/v1 PodDisruptionBudget"`
+
+func TestGVRFromRequestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected schema.GroupVersionResource
+		ok       bool
+	}{
+		{name: "core list", path: "/api/v1/pods", expected: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, ok: true},
+		{name: "core namespaced", path: "/api/v1/namespaces/default/pods/web/log", expected: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, ok: true},
+		{name: "namespace", path: "/api/v1/namespaces/default", expected: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, ok: true},
+		{name: "group namespaced", path: "/apis/policy/v1beta1/namespaces/default/poddisruptionbudgets", expected: schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"}, ok: true},
+		{name: "proxied cluster", path: "/k8s/clusters/c-abc/apis/apps/v1/deployments", expected: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, ok: true},
+		{name: "core discovery", path: "/api/v1"},
+		{name: "group discovery", path: "/apis/apps/v1"},
+		{name: "version", path: "/version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gvr, ok := gvrFromRequestPath(tt.path)
+			if ok != tt.ok || gvr != tt.expected {
+				t.Errorf("Expected %v (%v), got %v (%v)", tt.expected, tt.ok, gvr, ok)
+			}
+		})
+	}
+}
+
+func TestDeprecationRecorder_Record(t *testing.T) {
+	recorder := NewDeprecationRecorder()
+	recorder.Record("/apis/policy/v1beta1/poddisruptionbudgets", []string{pdbDeprecationWarning})
+	recorder.Record("/apis/policy/v1beta1/namespaces/default/poddisruptionbudgets", []string{pdbDeprecationWarning})
+	recorder.Record("/api/v1/componentstatuses", []string{`299 - "v1 ComponentStatus is deprecated in v1.19+"`})
+	recorder.Record("/api/v1/pods", []string{`299 - "unknown field \"spec.foo\""`})
+	recorder.Record("/api/v1/configmaps", nil)
+	recorder.Record("/apis", []string{pdbDeprecationWarning})
+
+	report := recorder.Report()
+	if len(report.Deprecations) != 2 {
+		t.Fatalf("Expected 2 deprecated APIs, got %d: %+v", len(report.Deprecations), report.Deprecations)
+	}
+
+	components := report.Deprecations[0]
+	if components.Resource != "componentstatuses" || components.DeprecatedIn != "v1.19" || components.RemovedIn != "" {
+		t.Errorf("Expected componentstatuses deprecated in v1.19 first, got %+v", components)
+	}
+
+	pdbs := report.Deprecations[1]
+	if pdbs.GroupVersion() != "policy/v1beta1" || pdbs.Resource != "poddisruptionbudgets" {
+		t.Errorf("Expected policy/v1beta1 poddisruptionbudgets, got %s %s", pdbs.GroupVersion(), pdbs.Resource)
+	}
+	if pdbs.Requests != 2 || len(pdbs.Warnings) != 1 {
+		t.Errorf("Expected 2 requests with 1 distinct warning, got %d and %d", pdbs.Requests, len(pdbs.Warnings))
+	}
+	if pdbs.DeprecatedIn != "v1.21" || pdbs.RemovedIn != "v1.25" || pdbs.Replacement != "policy/v1 PodDisruptionBudget" {
+		t.Errorf("Expected the releases and replacement to be parsed, got %+v", pdbs)
+	}
+
+	expected := "policy/v1beta1 poddisruptionbudgets (removed in v1.25, use policy/v1 PodDisruptionBudget)"
+	if pdbs.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, pdbs.String())
+	}
+}
+
+func TestDeprecationRecorder_WrapTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/apis/policy/v1beta1/poddisruptionbudgets" {
+			w.Header().Add("Warning", pdbDeprecationWarning)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	recorder := NewDeprecationRecorder()
+	client := &http.Client{Transport: recorder.WrapTransport(http.DefaultTransport)}
+	for _, path := range []string{"/apis/policy/v1beta1/poddisruptionbudgets", "/apis/policy/v1/poddisruptionbudgets"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	report := recorder.Report()
+	if len(report.Deprecations) != 1 || report.Deprecations[0].Version != "v1beta1" {
+		t.Errorf("Expected only policy/v1beta1 to be reported, got %+v", report.Deprecations)
+	}
+}
@@ -3,26 +3,64 @@ package autodiscovery
 import (
 	"context"
 	"fmt"
-	"sort"
 
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // Discoverer implements the AutoCollector interface
 type Discoverer struct {
-	kubeClient    kubernetes.Interface
-	dynamicClient dynamic.Interface
-	restConfig    *rest.Config
-	rbacChecker   *RBACChecker
-	nsScanner     *NamespaceScanner
-	expander      *ResourceExpander
+	kubeClient      kubernetes.Interface
+	dynamicClient   dynamic.Interface
+	restConfig      *rest.Config
+	rbacChecker     *RBACChecker
+	nsScanner       *NamespaceScanner
+	expander        *ResourceExpander
+	analyzers       *AnalyzerGenerator
+	webhooks        *WebhookDetector
+	storage         *StorageDiagnostics
+	networking      *NetworkingDiagnostics
+	rollouts        *RolloutHistory
+	timelines       *PodTimeline
+	hotPods         *HotPodRanker
+	jobs            *FinishedJobs
+	admission       *AdmissionDenials
+	scheduling      *SchedulingInsights
+	podSecurity     *PodSecurityAnalyzer
+	netPolicies     *NetworkPolicyAnalyzer
+	customResources *CustomResources
+	controlPlane    *ControlPlaneHealth
+	nodes           *NodeSampler
+	aggregatedAPIs  *AggregatedAPIChecker
+	tables          *TableSummarizer
+	throttle        *AdaptiveThrottle
+	deprecations    *DeprecationRecorder
+	apiUsage        *APIUsageRecorder
+
+	unavailableAPIs []UnavailableAPIService // Found by the last scan
+	openShift       *OpenShiftPlatform      // Detected by the last scan, nil when the cluster is not OpenShift
+	collectorLimit  *CollectorLimitReport   // Collectors dropped by the last Discover, nil when none were
+	clusterScope    *ClusterScopeReport     // Cluster-scoped types of the last Discover, nil without a cluster scope
+	annotations     *annotationScope        // Collection annotations found by the last scan
+
+	preFilterHooks  []PreFilterHook
+	postExpandHooks []PostExpandHook
 }
 
 // NewDiscoverer creates a new Discoverer instance
 func NewDiscoverer(config *rest.Config) (*Discoverer, error) {
+	// Throttle, account for requests and record deprecation warnings on a copy so the caller's clients are unaffected
+	// The usage recorder is installed first, innermost, so request durations leave out throttling waits
+	config = rest.CopyConfig(config)
+	apiUsage := NewAPIUsageRecorder()
+	apiUsage.Install(config)
+	throttle := NewAdaptiveThrottle(config.QPS, config.Burst)
+	throttle.Install(config)
+	deprecations := NewDeprecationRecorder()
+	deprecations.Install(config)
+
 	kubeClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
@@ -33,52 +71,359 @@ func NewDiscoverer(config *rest.Config) (*Discoverer, error) {
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	rbacChecker := NewRBACChecker(kubeClient)
-	nsScanner := NewNamespaceScanner(kubeClient, dynamicClient)
-	expander := NewResourceExpanderWithDependencies(dynamicClient, 3) // Default max depth of 3
+	discoverer := NewDiscovererForClients(kubeClient, dynamicClient)
+	discoverer.restConfig = config
+	discoverer.throttle = throttle
+	discoverer.deprecations = deprecations
+	discoverer.apiUsage = apiUsage
+	return discoverer, nil
+}
+
+// ThrottleStats returns the API throttling seen so far, or nil when the clients were not built by NewDiscoverer
+func (d *Discoverer) ThrottleStats() *ThrottleStats {
+	if d == nil || d.throttle == nil {
+		return nil
+	}
+	stats := d.throttle.Stats()
+	return &stats
+}
+
+// DeprecationReport returns the deprecated APIs the server warned about so far, or nil when the clients were not
+// built by NewDiscoverer
+func (d *Discoverer) DeprecationReport() *DeprecationReport {
+	if d == nil || d.deprecations == nil {
+		return nil
+	}
+	return d.deprecations.Report()
+}
+
+// APIUsage returns the recorder accounting for the discoverer's API requests, or nil when the clients were not built by
+// NewDiscoverer. Install it on other clients of the run to account for their requests in the same report
+func (d *Discoverer) APIUsage() *APIUsageRecorder {
+	if d == nil {
+		return nil
+	}
+	return d.apiUsage
+}
+
+// SetExecCatalog replaces the catalog of read-only commands run in matching pods, see NewExecCatalogFromConfig
+func (d *Discoverer) SetExecCatalog(catalog *ExecCatalog) {
+	if d.expander != nil {
+		d.expander.SetExecCatalog(catalog)
+	}
+}
+
+// DependencyReport returns the cycles and expansion limit hit by the last dependency resolution
+func (d *Discoverer) DependencyReport() *DependencyReport {
+	if d == nil {
+		return nil
+	}
+	return d.expander.DependencyReport()
+}
+
+// CollectorLimitReport returns the collectors dropped by the last Discover to honor MaxCollectors, nil when none were
+func (d *Discoverer) CollectorLimitReport() *CollectorLimitReport {
+	if d == nil {
+		return nil
+	}
+	return d.collectorLimit
+}
+
+// ClusterScopeReport returns the cluster-scoped types included and denied by the last Discover, nil without a cluster scope
+func (d *Discoverer) ClusterScopeReport() *ClusterScopeReport {
+	if d == nil {
+		return nil
+	}
+	return d.clusterScope
+}
 
+// NewDiscovererForClients creates a Discoverer from existing clients, e.g. fakes or clients shared with an operator
+func NewDiscovererForClients(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface) *Discoverer {
 	return &Discoverer{
-		kubeClient:    kubeClient,
-		dynamicClient: dynamicClient,
-		restConfig:    config,
-		rbacChecker:   rbacChecker,
-		nsScanner:     nsScanner,
-		expander:      expander,
-	}, nil
+		kubeClient:      kubeClient,
+		dynamicClient:   dynamicClient,
+		rbacChecker:     NewRBACChecker(kubeClient),
+		nsScanner:       NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:        NewResourceExpanderWithDependencies(dynamicClient, 3), // Default max depth of 3
+		analyzers:       NewAnalyzerGenerator(dynamicClient),
+		webhooks:        NewWebhookDetector(dynamicClient),
+		storage:         NewStorageDiagnostics(dynamicClient),
+		networking:      NewNetworkingDiagnostics(dynamicClient),
+		rollouts:        NewRolloutHistory(dynamicClient),
+		timelines:       NewPodTimeline(dynamicClient),
+		hotPods:         NewHotPodRanker(dynamicClient),
+		jobs:            NewFinishedJobs(dynamicClient),
+		admission:       NewAdmissionDenials(dynamicClient),
+		scheduling:      NewSchedulingInsights(dynamicClient),
+		podSecurity:     NewPodSecurityAnalyzer(dynamicClient),
+		netPolicies:     NewNetworkPolicyAnalyzer(dynamicClient),
+		customResources: NewCustomResources(dynamicClient),
+		controlPlane:    NewControlPlaneHealth(kubeClient),
+		nodes:           NewNodeSampler(dynamicClient),
+		aggregatedAPIs:  NewAggregatedAPIChecker(dynamicClient),
+		tables:          NewTableSummarizer(kubeClient.Discovery().RESTClient()),
+	}
 }
 
 // Discover performs auto-discovery of resources and generates collector specifications
 func (d *Discoverer) Discover(ctx context.Context, opts DiscoveryOptions) ([]CollectorSpec, error) {
-	// Step 1: Scan for resources in specified namespaces
-	resources, err := d.nsScanner.ScanNamespaces(ctx, opts.Namespaces, ResourceFilter{})
+	// Steps 1-2: Scan for resources and validate RBAC permissions if requested
+	resources, err := d.scanResources(ctx, opts, ResourceFilter{})
+	if err != nil {
+		return nil, err
+	}
+	opts = d.annotations.excludeNamespaces(opts)
+
+	// Step 3: Expand resources into collector specifications
+	collectors, err := d.expander.ExpandToCollectors(ctx, resources, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand resources to collectors: %w", err)
+	}
+
+	collectors = d.summarizeLargeLists(ctx, collectors, resources, opts)
+
+	// Step 4: Add collectors for admission webhooks whose backends look unhealthy
+	collectors = append(collectors, d.discoverWebhookCollectors(ctx)...)
+
+	// Add the storage collector group when PVCs or StorageClasses were discovered
+	if d.storage != nil {
+		collectors = append(collectors, d.storage.GenerateStorageCollectors(ctx, resources, opts)...)
+	}
+
+	// Add ingress controller logs, CoreDNS and DNS lookups when Ingresses or Services without ready endpoints were discovered
+	if d.networking != nil {
+		collectors = append(collectors, d.networking.GenerateNetworkingCollectors(ctx, resources, opts)...)
+	}
+
+	// Add rollout history for discovered Deployments and StatefulSets
+	if d.rollouts != nil {
+		collectors = append(collectors, d.rollouts.GenerateRolloutCollectors(ctx, resources)...)
+	}
+
+	// Add a restart and event timeline per namespace with discovered pods
+	if d.timelines != nil {
+		collectors = append(collectors, d.timelines.GenerateTimelineCollectors(ctx, resources, opts.TimeWindow)...)
+	}
+
+	// Target logs and process listings at the pods using the most CPU and memory or restarting most
+	if d.hotPods != nil {
+		collectors = append(collectors, d.hotPods.GenerateHotPodCollectors(ctx, resources, opts, d.podMetricsServed())...)
+	}
+
+	// Capture Job status and fetch the logs of finished Job pods first, before a TTL deletes them
+	if d.jobs != nil {
+		collectors = append(collectors, d.jobs.GenerateJobCollectors(ctx, resources)...)
+	}
+
+	// Add admission denials from events and the audit log, why a deploy may silently create nothing
+	if d.admission != nil {
+		collectors = append(collectors, d.admission.GenerateAdmissionDenialCollectors(ctx, resources, opts)...)
+	}
+
+	// Explain Pending and evicted pods: node requests, untolerated taints, PriorityClasses and FailedScheduling events
+	if d.scheduling != nil {
+		collectors = append(collectors, d.scheduling.GenerateSchedulingCollectors(ctx, resources, opts)...)
+	}
+
+	// Check workloads against the Pod Security Admission labels of their namespaces, plus SCCs and PSPs when served
+	if d.podSecurity != nil {
+		collectors = append(collectors, d.podSecurity.GeneratePodSecurityCollectors(ctx, resources)...)
+	}
+
+	// Add the reachability analysis when NetworkPolicies were discovered
+	if d.netPolicies != nil {
+		collectors = append(collectors, d.netPolicies.GenerateNetworkPolicyCollectors(ctx, resources)...)
+	}
+
+	// Add the CRD definitions and a bounded sample of discovered custom resources
+	if d.customResources != nil {
+		collectors = append(collectors, d.customResources.GenerateCustomResourceCollectors(ctx, resources)...)
+	}
+
+	// Always add cluster-info and control plane health, whatever the namespace scope
+	if d.controlPlane != nil {
+		collectors = append(collectors, d.controlPlane.GenerateClusterInfoCollectors(ctx)...)
+	}
+
+	// Add a failure-domain aware sample of the nodes, bounded on large clusters
+	if d.nodes != nil {
+		collectors = append(collectors, d.nodes.GenerateNodeCollectors(ctx, opts.NodeSampling)...)
+	}
+
+	// Record aggregated API outages, a likely root cause of other failures
+	if d.aggregatedAPIs != nil {
+		collectors = append(collectors, d.aggregatedAPIs.GenerateAggregatedAPICollectors(d.unavailableAPIs)...)
+	}
+
+	// Add the OpenShift version and ClusterOperator health on OpenShift clusters
+	collectors = append(collectors, d.generateOpenShiftCollectors(ctx)...)
+
+	// Add the cluster-scoped types opted in to, plus ClusterOperators on OpenShift, once the user may list them
+	collectors = append(collectors, d.generateClusterScopeCollectors(ctx, collectors, d.openShift.extendClusterScope(opts.ClusterScope))...)
+
+	// Scope logs and events to the incident window, if one was given
+	collectors = applyTimeWindow(collectors, opts.TimeWindow)
+
+	// Step 5: Tag collectors with their group and let registered hooks adjust them
+	assignCollectorGroups(collectors)
+	collectors, err = d.runPostExpandHooks(ctx, collectors)
+	if err != nil {
+		return nil, err
+	}
+	assignCollectorGroups(collectors)
+	collectors = filterCollectorGroups(collectors, opts)
+	collectors = d.annotations.applyLogLines(collectors)
+	collectors = capLogLines(collectors, opts.MaxLogLines)
+	collectors = applyLogTimestamps(collectors, opts.NormalizeLogTimestamps)
+	collectors = applyLargeObjectLimits(collectors, opts.LargeObjects)
+	collectors = applySecretPolicy(collectors, opts.SecretPolicy)
+	collectors = batchByNamespace(collectors, opts.BatchByNamespace)
+	collectors = enforceProtectedNamespaces(collectors, opts.ProtectedNamespaces)
+
+	// Step 6: Assign IDs and sort collectors by priority, then name, so runs are reproducible
+	collectors = finalizeCollectors(collectors)
+
+	// Enforce the collector cap on the sorted list, dropping the lowest priority collectors
+	collectors, d.collectorLimit = limitCollectors(collectors, opts.MaxCollectors)
+
+	// Log discovery statistics (in a real implementation, this might be returned or stored)
+	// TODO: Add metadata collection and logging
+
+	return collectors, nil
+}
+
+// summarizeLargeLists adds table summaries for large resource lists when opts.ResourceFormat asks for them,
+// replacing the full objects of the summarized lists in "table" format
+func (d *Discoverer) summarizeLargeLists(ctx context.Context, collectors []CollectorSpec, resources []Resource, opts DiscoveryOptions) []CollectorSpec {
+	if d.tables == nil || (opts.ResourceFormat != ResourceFormatTable && opts.ResourceFormat != ResourceFormatBoth) {
+		return collectors
+	}
+
+	tableCollectors, summarized := d.tables.GenerateTableCollectors(ctx, resources, opts)
+	if opts.ResourceFormat == ResourceFormatTable {
+		collectors = dropSummarizedResources(collectors, summarized)
+	}
+	return append(collectors, tableCollectors...)
+}
+
+// DiscoverAnalyzers scans for resources and pairs them with default analyzers
+func (d *Discoverer) DiscoverAnalyzers(ctx context.Context, opts DiscoveryOptions) ([]AnalyzerSpec, error) {
+	resources, err := d.scanResources(ctx, opts, ResourceFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	return d.analyzers.GenerateAnalyzers(resources), nil
+}
+
+// discoverWebhookCollectors checks admission webhooks and returns collectors for unhealthy ones
+// Failing webhooks break API requests cluster-wide, so this runs regardless of namespace scope
+func (d *Discoverer) discoverWebhookCollectors(ctx context.Context) []CollectorSpec {
+	if d.webhooks == nil {
+		return nil
+	}
+
+	statuses, err := d.webhooks.DetectWebhooks(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to check admission webhooks: %v\n", err)
+		return nil
+	}
+	return d.webhooks.GenerateWebhookCollectors(statuses)
+}
+
+// RunAnalyzers evaluates analyzers against the cluster and returns pass/warn/fail results
+func (d *Discoverer) RunAnalyzers(ctx context.Context, analyzers []AnalyzerSpec) []AnalyzerResult {
+	return d.analyzers.RunAnalyzers(ctx, analyzers)
+}
+
+// scanResources scans the requested namespaces, runs pre-filter hooks and filters resources by RBAC when enabled
+// Each phase runs under its own timeout from opts.PhaseTimeouts and keeps partial results on expiry
+func (d *Discoverer) scanResources(ctx context.Context, opts DiscoveryOptions, filter ResourceFilter) ([]Resource, error) {
+	d.nsScanner.SetPageSize(opts.PageSize)
+	d.nsScanner.SetCandidateNamespaces(opts.CandidateNamespaces)
+	d.nsScanner.SetProtectedNamespaces(opts.ProtectedNamespaces)
+	d.nsScanner.SetUnavailableAPIServices(d.detectUnavailableAPIs(ctx))
+	d.nsScanner.SetOpenShift(d.detectOpenShift())
+
+	scanCtx, cancel := withPhaseTimeout(ctx, opts.PhaseTimeouts.NamespaceScan)
+	resources, err := d.nsScanner.ScanNamespaces(scanCtx, opts.Namespaces, filter)
+	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan namespaces: %w", err)
 	}
+	resources = filterExcludedNamespaces(resources, opts)
+	resources, err = selectAppResources(resources, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Namespace and workload owners opt out of collection, or set their log lines, with troubleshoot.sh/ annotations
+	resources, d.annotations = applyCollectionAnnotations(resources, d.namespaceAnnotations(ctx, resources), opts)
+
+	resources, err = d.runPreFilterHooks(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+	// Hooks may add resources, protected namespaces are filtered once more afterwards
+	resources = filterProtectedResources(resources, opts.ProtectedNamespaces)
 
-	// Step 2: Validate RBAC permissions if requested
 	if opts.RBACCheck {
-		allowedResources, err := d.ValidatePermissions(ctx, resources)
+		rbacCtx, cancel := withPhaseTimeout(ctx, opts.PhaseTimeouts.RBACCheck)
+		allowedResources, err := d.ValidatePermissions(rbacCtx, resources)
+		cancel()
 		if err != nil {
 			return nil, fmt.Errorf("failed to validate permissions: %w", err)
 		}
 		resources = allowedResources
 	}
 
-	// Step 3: Expand resources into collector specifications
-	collectors, err := d.expander.ExpandToCollectors(ctx, resources, opts)
+	return resources, nil
+}
+
+// detectUnavailableAPIs finds aggregated APIs that are down so their group versions can be skipped
+// Failing to list APIServices is only a warning, the scan then runs as before
+func (d *Discoverer) detectUnavailableAPIs(ctx context.Context) []UnavailableAPIService {
+	d.unavailableAPIs = nil
+	if d.aggregatedAPIs == nil {
+		return nil
+	}
+
+	unavailable, err := d.aggregatedAPIs.FindUnavailable(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to expand resources to collectors: %w", err)
+		fmt.Printf("Warning: failed to check aggregated APIs: %v\n", err)
+		return nil
+	}
+	for _, apiService := range unavailable {
+		fmt.Printf("Warning: aggregated API %s is unavailable (%s), skipping %s resources\n",
+			apiService.Name, apiService.Reason, apiService.GroupVersion())
 	}
 
-	// Step 4: Sort collectors by priority
-	sort.Slice(collectors, func(i, j int) bool {
-		return collectors[i].Priority > collectors[j].Priority
-	})
+	d.unavailableAPIs = unavailable
+	return unavailable
+}
 
-	// Log discovery statistics (in a real implementation, this might be returned or stored)
-	// TODO: Add metadata collection and logging
+// UnavailableAPIServices returns the aggregated APIs found unavailable by the last discovery
+func (d *Discoverer) UnavailableAPIServices() []UnavailableAPIService {
+	if d == nil {
+		return nil
+	}
+	return d.unavailableAPIs
+}
 
-	return collectors, nil
+// filterExcludedNamespaces drops resources in excluded namespaces when all namespaces are scanned
+// Namespaces requested explicitly are always kept
+func filterExcludedNamespaces(resources []Resource, opts DiscoveryOptions) []Resource {
+	if len(opts.Namespaces) > 0 || len(opts.ExcludeNamespaces) == 0 {
+		return resources
+	}
+
+	filtered := make([]Resource, 0, len(resources))
+	for _, resource := range resources {
+		if !MatchesAnyGlob(opts.ExcludeNamespaces, resource.Namespace) {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered
 }
 
 // ValidatePermissions checks if the user has permissions to access the specified resources
@@ -105,24 +450,24 @@ func (d *Discoverer) GetSupportedResourceTypes() []schema.GroupVersionResource {
 		{Group: "", Version: "v1", Resource: "secrets"},
 		{Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
 		{Group: "", Version: "v1", Resource: "events"},
-		
+
 		// Apps resources
 		{Group: "apps", Version: "v1", Resource: "deployments"},
 		{Group: "apps", Version: "v1", Resource: "replicasets"},
 		{Group: "apps", Version: "v1", Resource: "statefulsets"},
 		{Group: "apps", Version: "v1", Resource: "daemonsets"},
-		
+
 		// Networking resources
 		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
 		{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
-		
+
 		// Storage resources
 		{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"},
-		
+
 		// Batch resources
 		{Group: "batch", Version: "v1", Resource: "jobs"},
 		{Group: "batch", Version: "v1", Resource: "cronjobs"},
-		
+
 		// Custom resources (these would be discovered dynamically)
 		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
 	}
@@ -130,17 +475,9 @@ func (d *Discoverer) GetSupportedResourceTypes() []schema.GroupVersionResource {
 
 // DiscoverWithFilter performs discovery with custom resource filtering
 func (d *Discoverer) DiscoverWithFilter(ctx context.Context, opts DiscoveryOptions, filter ResourceFilter) ([]CollectorSpec, error) {
-	resources, err := d.nsScanner.ScanNamespaces(ctx, opts.Namespaces, filter)
+	resources, err := d.scanResources(ctx, opts, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan namespaces with filter: %w", err)
-	}
-
-	if opts.RBACCheck {
-		allowedResources, err := d.ValidatePermissions(ctx, resources)
-		if err != nil {
-			return nil, fmt.Errorf("failed to validate permissions: %w", err)
-		}
-		resources = allowedResources
+		return nil, err
 	}
 
 	collectors, err := d.expander.ExpandToCollectors(ctx, resources, opts)
@@ -148,11 +485,15 @@ func (d *Discoverer) DiscoverWithFilter(ctx context.Context, opts DiscoveryOptio
 		return nil, fmt.Errorf("failed to expand resources to collectors: %w", err)
 	}
 
-	sort.Slice(collectors, func(i, j int) bool {
-		return collectors[i].Priority > collectors[j].Priority
-	})
+	assignCollectorGroups(collectors)
+	collectors, err = d.runPostExpandHooks(ctx, collectors)
+	if err != nil {
+		return nil, err
+	}
+	assignCollectorGroups(collectors)
+	collectors = filterCollectorGroups(collectors, opts)
 
-	return collectors, nil
+	return finalizeCollectors(collectors), nil
 }
 
 // DiscoverWithImageCollection performs discovery and optionally collects image metadata
@@ -169,10 +510,10 @@ func (d *Discoverer) DiscoverWithImageCollection(ctx context.Context, opts Disco
 	}
 
 	// Collect image metadata if requested
-	if collectImages && opts.IncludeImages {
+	if collectImages && opts.IncludeImages && CollectorGroupSelected(CollectorGroupImages, opts) {
 		// This integration point would use the image collection system
 		fmt.Printf("Image collection would be triggered here for discovered pods\n")
-		
+
 		// In a real implementation, this would:
 		// 1. Create AutoDiscoveryImageCollector
 		// 2. Extract image refs from discovered pod resources
@@ -185,6 +526,6 @@ func (d *Discoverer) DiscoverWithImageCollection(ctx context.Context, opts Disco
 
 // DiscoveryResultWithImages extends normal discovery result with image metadata
 type DiscoveryResultWithImages struct {
-	Collectors []CollectorSpec            `json:"collectors"`
-	ImageFacts map[string]interface{}     `json:"imageFacts,omitempty"`
+	Collectors []CollectorSpec        `json:"collectors"`
+	ImageFacts map[string]interface{} `json:"imageFacts,omitempty"`
 }
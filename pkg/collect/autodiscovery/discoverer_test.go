@@ -409,3 +409,30 @@ func TestDiscoverer_ErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterExcludedNamespaces(t *testing.T) {
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resources := []Resource{
+		{GVR: podGVR, Namespace: "default", Name: "app"},
+		{GVR: podGVR, Namespace: "kube-system", Name: "coredns"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     DiscoveryOptions
+		expected int
+	}{
+		{"all namespaces with excludes", DiscoveryOptions{ExcludeNamespaces: []string{"kube-system"}}, 1},
+		{"no excludes", DiscoveryOptions{}, 2},
+		{"explicit namespaces are kept", DiscoveryOptions{Namespaces: []string{"kube-system"}, ExcludeNamespaces: []string{"kube-system"}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := filterExcludedNamespaces(resources, tt.opts)
+			if len(filtered) != tt.expected {
+				t.Errorf("Expected %d resources, got %d", tt.expected, len(filtered))
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package autodiscovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Errors returned by the autodiscovery APIs, match them with errors.Is instead of the message
+// The underlying Kubernetes API error stays wrapped, so apierrors.IsForbidden and errors.As keep working too
+var (
+	ErrRBACForbidden     = errors.New("forbidden by RBAC")
+	ErrNamespaceNotFound = errors.New("namespace not found")
+	ErrK8sThrottled      = errors.New("throttled by the Kubernetes API")
+	ErrSpecInvalid       = errors.New("invalid spec")
+)
+
+// kindError tags an error with one of the sentinel errors without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns both the sentinel and the original error for errors.Is and errors.As
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// withKind tags err with kind, nil and errors already matching kind are returned unchanged
+func withKind(kind, err error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// classifyAPIError tags Kubernetes API errors that callers act on: forbidden requests and throttling
+func classifyAPIError(err error) error {
+	switch {
+	case apierrors.IsForbidden(err):
+		return withKind(ErrRBACForbidden, err)
+	case apierrors.IsTooManyRequests(err):
+		return withKind(ErrK8sThrottled, err)
+	}
+	return err
+}
+
+// CheckNamespacesExist returns an error wrapping ErrNamespaceNotFound that names every namespace that does not exist
+// Namespaces the caller may not get are assumed to exist, discovery then collects what it can read from them
+func CheckNamespacesExist(ctx context.Context, client kubernetes.Interface, namespaces []string) error {
+	var missing []string
+	for _, namespace := range namespaces {
+		_, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			missing = append(missing, namespace)
+		case err != nil && !apierrors.IsForbidden(err):
+			return fmt.Errorf("failed to get namespace %s: %w", namespace, classifyAPIError(err))
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: %s", ErrNamespaceNotFound, strings.Join(missing, ", "))
+	}
+	return nil
+}
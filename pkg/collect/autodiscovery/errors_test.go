@@ -0,0 +1,105 @@
+package autodiscovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestClassifyAPIError(t *testing.T) {
+	namespaces := schema.GroupResource{Resource: "namespaces"}
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "forbidden", err: apierrors.NewForbidden(namespaces, "", errors.New("no access")), expected: ErrRBACForbidden},
+		{name: "throttled", err: apierrors.NewTooManyRequests("slow down", 1), expected: ErrK8sThrottled},
+		{name: "not found", err: apierrors.NewNotFound(namespaces, "app")},
+		{name: "other", err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("failed to list namespaces: %w", classifyAPIError(tt.err))
+			for _, kind := range []error{ErrRBACForbidden, ErrK8sThrottled} {
+				if errors.Is(err, kind) != (kind == tt.expected) {
+					t.Errorf("Expected errors.Is(%v) to be %v", kind, kind == tt.expected)
+				}
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected the API error to stay wrapped")
+			}
+			if err.Error() != "failed to list namespaces: "+tt.err.Error() {
+				t.Errorf("Expected the message to be unchanged, got %s", err)
+			}
+		})
+	}
+
+	forbidden := classifyAPIError(apierrors.NewForbidden(namespaces, "", errors.New("no access")))
+	if !apierrors.IsForbidden(forbidden) {
+		t.Errorf("Expected apierrors.IsForbidden to match through the sentinel")
+	}
+}
+
+func TestCheckNamespacesExist(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+	client.PrependReactor("get", "namespaces", func(action ktesting.Action) (bool, runtime.Object, error) {
+		if action.(ktesting.GetAction).GetName() == "locked" {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "locked", errors.New("no access"))
+		}
+		return false, nil, nil
+	})
+
+	if err := CheckNamespacesExist(context.Background(), client, []string{"app", "locked"}); err != nil {
+		t.Errorf("Expected existing and forbidden namespaces to pass, got %v", err)
+	}
+
+	err := CheckNamespacesExist(context.Background(), client, []string{"typo", "app", "another"})
+	if !errors.Is(err, ErrNamespaceNotFound) {
+		t.Fatalf("Expected ErrNamespaceNotFound, got %v", err)
+	}
+	if err.Error() != "namespace not found: another, typo" {
+		t.Errorf("Expected both missing namespaces in the message, got %s", err)
+	}
+}
+
+func TestConfigManager_LoadSpecInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "unparseable", file: "config.yaml", content: "defaultOptions: [unclosed"},
+		{name: "invalid option", file: "config.yaml", content: "defaultOptions:\n  maxCollectors: -1\n"},
+		{name: "unsupported format", file: "config.toml", content: "maxCollectors = 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			if err := NewConfigManager().LoadFromFile(path); !errors.Is(err, ErrSpecInvalid) {
+				t.Errorf("Expected ErrSpecInvalid, got %v", err)
+			}
+		})
+	}
+
+	if err := NewConfigManager().LoadFromFile(filepath.Join(dir, "missing.yaml")); errors.Is(err, ErrSpecInvalid) {
+		t.Errorf("Expected a missing file not to be reported as an invalid spec")
+	}
+}
@@ -0,0 +1,260 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// defaultExecTimeout bounds catalog commands that do not set a timeout
+const defaultExecTimeout = "30s"
+
+// execCatalogLabels are the pod labels whose values are matched against ExecCatalogEntry.Labels
+var execCatalogLabels = []string{"app", "app.kubernetes.io/name", "app.kubernetes.io/component"}
+
+// execShells are rejected as commands, catalog commands run without a shell so their arguments are never interpreted
+var execShells = map[string]bool{"sh": true, "bash": true, "ash": true, "dash": true, "zsh": true, "ksh": true}
+
+// execMutatingWords are rejected anywhere in a command, they delete files, stop processes or change data
+var execMutatingWords = map[string]bool{
+	"rm": true, "mv": true, "dd": true, "kill": true, "killall": true, "shutdown": true, "reboot": true,
+	"flushall": true, "flushdb": true, "del": true, "set": true,
+	"drop": true, "delete": true, "truncate": true, "insert": true, "update": true, "alter": true,
+}
+
+// ExecCatalogConfig adds to or replaces the default exec command catalog
+type ExecCatalogConfig struct {
+	DisableDefaults bool               `json:"disableDefaults,omitempty" yaml:"disableDefaults,omitempty"` // Only use the entries below
+	Entries         []ExecCatalogEntry `json:"entries,omitempty" yaml:"entries,omitempty"`                 // Entries named like a default replace it
+}
+
+// ExecCatalogEntry defines the read-only commands run in pods of one workload type, e.g. postgres
+type ExecCatalogEntry struct {
+	Name     string        `json:"name" yaml:"name"`
+	Labels   []string      `json:"labels,omitempty" yaml:"labels,omitempty"` // Values of the app, app.kubernetes.io/name or app.kubernetes.io/component label
+	Images   []string      `json:"images,omitempty" yaml:"images,omitempty"` // Image names without registry, path or tag, e.g. postgresql matches bitnami/postgresql:16
+	Commands []ExecCommand `json:"commands" yaml:"commands"`
+}
+
+// ExecCommand is one command of a catalog entry
+type ExecCommand struct {
+	Name    string   `json:"name" yaml:"name"`
+	Command []string `json:"command" yaml:"command"`                     // Run without a shell
+	Timeout string   `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Defaults to 30s
+}
+
+// DefaultExecCatalogEntries returns the built-in catalog
+func DefaultExecCatalogEntries() []ExecCatalogEntry {
+	return []ExecCatalogEntry{
+		{
+			Name:     "postgres",
+			Labels:   []string{"postgres", "postgresql"},
+			Images:   []string{"postgres", "postgresql"},
+			Commands: []ExecCommand{{Name: "ready", Command: []string{"pg_isready"}}},
+		},
+		{
+			Name:     "redis",
+			Labels:   []string{"redis"},
+			Images:   []string{"redis"},
+			Commands: []ExecCommand{{Name: "info", Command: []string{"redis-cli", "INFO"}}},
+		},
+		{
+			Name:     "mysql",
+			Labels:   []string{"mysql", "mariadb"},
+			Images:   []string{"mysql", "mariadb"},
+			Commands: []ExecCommand{{Name: "status", Command: []string{"mysqladmin", "status"}}},
+		},
+	}
+}
+
+// Validate checks the entry matches something and that its commands are read-only
+func (e ExecCatalogEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(e.Labels) == 0 && len(e.Images) == 0 {
+		return fmt.Errorf("labels or images are required")
+	}
+	if len(e.Commands) == 0 {
+		return fmt.Errorf("at least one command is required")
+	}
+
+	names := make(map[string]bool)
+	for i, command := range e.Commands {
+		if command.Name == "" {
+			return fmt.Errorf("commands[%d]: name is required", i)
+		}
+		if names[command.Name] {
+			return fmt.Errorf("commands[%d]: duplicate name %q", i, command.Name)
+		}
+		names[command.Name] = true
+		if err := validateExecCommand(command); err != nil {
+			return fmt.Errorf("command %q: %w", command.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateExecCommand rejects shells, mutating commands and invalid timeouts
+func validateExecCommand(command ExecCommand) error {
+	if len(command.Command) == 0 || command.Command[0] == "" {
+		return fmt.Errorf("command is required")
+	}
+	if execShells[path.Base(command.Command[0])] {
+		return fmt.Errorf("%s is a shell, commands run without one", command.Command[0])
+	}
+	for _, arg := range command.Command {
+		words := strings.FieldsFunc(strings.ToLower(arg), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		})
+		for _, word := range words {
+			if execMutatingWords[word] {
+				return fmt.Errorf("%q is not read-only", word)
+			}
+		}
+	}
+	if command.Timeout != "" {
+		if d, err := time.ParseDuration(command.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", command.Timeout)
+		}
+	}
+	return nil
+}
+
+// ExecCatalog maps pods to the read-only diagnostic commands run in them
+type ExecCatalog struct {
+	entries []ExecCatalogEntry
+}
+
+// NewExecCatalog creates an ExecCatalog, the first entry matching a pod wins
+func NewExecCatalog(entries []ExecCatalogEntry) *ExecCatalog {
+	return &ExecCatalog{
+		entries: entries,
+	}
+}
+
+// NewExecCatalogFromConfig creates the catalog of the defaults with config applied on top
+func NewExecCatalogFromConfig(config ExecCatalogConfig) *ExecCatalog {
+	var entries []ExecCatalogEntry
+	if !config.DisableDefaults {
+		entries = DefaultExecCatalogEntries()
+	}
+	return NewExecCatalog(mergeExecCatalogEntries(entries, config.Entries))
+}
+
+// Entries returns the catalog entries in match order
+func (c *ExecCatalog) Entries() []ExecCatalogEntry {
+	if c == nil {
+		return nil
+	}
+	return c.entries
+}
+
+// Match returns the entry for a pod and the container to run it in
+// An image match names its container, a label match leaves the container empty for the pod's default container
+func (c *ExecCatalog) Match(resource Resource) (ExecCatalogEntry, string, bool) {
+	if c == nil || resource.GVR.Resource != "pods" {
+		return ExecCatalogEntry{}, "", false
+	}
+
+	containers := make([]string, 0, len(resource.ContainerImages))
+	for container := range resource.ContainerImages {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	for _, entry := range c.entries {
+		for _, container := range containers {
+			name := imageBaseName(resource.ContainerImages[container])
+			for _, image := range entry.Images {
+				if strings.EqualFold(name, image) {
+					return entry, container, true
+				}
+			}
+		}
+		for _, key := range execCatalogLabels {
+			value, ok := resource.Labels[key]
+			if !ok {
+				continue
+			}
+			for _, label := range entry.Labels {
+				if strings.EqualFold(value, label) {
+					return entry, "", true
+				}
+			}
+		}
+	}
+	return ExecCatalogEntry{}, "", false
+}
+
+// GenerateExecCollectors creates one exec collector per catalog command for each matching pod
+func (c *ExecCatalog) GenerateExecCollectors(resources []Resource, priority int) []CollectorSpec {
+	var collectors []CollectorSpec
+	for _, resource := range resources {
+		collectors = append(collectors, c.collectorsFor(resource, priority)...)
+	}
+	return collectors
+}
+
+func (c *ExecCatalog) collectorsFor(resource Resource, priority int) []CollectorSpec {
+	entry, container, ok := c.Match(resource)
+	if !ok {
+		return nil
+	}
+
+	collectors := make([]CollectorSpec, 0, len(entry.Commands))
+	for _, command := range entry.Commands {
+		timeout := command.Timeout
+		if timeout == "" {
+			timeout = defaultExecTimeout
+		}
+		collectors = append(collectors, CollectorSpec{
+			Type:      CollectorTypeExec,
+			Name:      fmt.Sprintf("auto-exec-%s-%s-%s", resource.Name, entry.Name, command.Name),
+			Namespace: resource.Namespace,
+			Priority:  priority,
+			Parameters: map[string]interface{}{
+				"name":      resource.Name,
+				"namespace": resource.Namespace,
+				"container": container,
+				"command":   append([]string{}, command.Command...),
+				"timeout":   timeout,
+			},
+		})
+	}
+	return collectors
+}
+
+// imageBaseName returns the last path element of an image without its tag or digest, e.g. postgresql for docker.io/bitnami/postgresql:16
+func imageBaseName(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	name := path.Base(image)
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// mergeExecCatalogEntries replaces entries of base named like one in overrides and appends the others
+func mergeExecCatalogEntries(base, overrides []ExecCatalogEntry) []ExecCatalogEntry {
+	merged := append([]ExecCatalogEntry{}, base...)
+	for _, entry := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == entry.Name {
+				merged[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
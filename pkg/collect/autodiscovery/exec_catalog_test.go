@@ -0,0 +1,157 @@
+package autodiscovery
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestExecCatalog_Match(t *testing.T) {
+	catalog := NewExecCatalog(DefaultExecCatalogEntries())
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	tests := []struct {
+		name              string
+		resource          Resource
+		expectedEntry     string
+		expectedContainer string
+	}{
+		{
+			name:              "postgres image",
+			resource:          Resource{GVR: pods, Name: "db-0", ContainerImages: map[string]string{"metrics": "prom/postgres-exporter:v0.15", "db": "docker.io/bitnami/postgresql:16@sha256:abc"}},
+			expectedEntry:     "postgres",
+			expectedContainer: "db",
+		},
+		{
+			name:          "redis label",
+			resource:      Resource{GVR: pods, Name: "cache-0", Labels: map[string]string{"app.kubernetes.io/name": "Redis"}},
+			expectedEntry: "redis",
+		},
+		{
+			name:              "mariadb image",
+			resource:          Resource{GVR: pods, Name: "mysql-0", ContainerImages: map[string]string{"mariadb": "mariadb:11"}},
+			expectedEntry:     "mysql",
+			expectedContainer: "mariadb",
+		},
+		{
+			name:     "unknown workload",
+			resource: Resource{GVR: pods, Name: "web", Labels: map[string]string{"app": "web"}, ContainerImages: map[string]string{"web": "nginx:1.25"}},
+		},
+		{
+			name:     "not a pod",
+			resource: Resource{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, Name: "db", Labels: map[string]string{"app": "postgres"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, container, ok := catalog.Match(tt.resource)
+			if ok != (tt.expectedEntry != "") {
+				t.Fatalf("Expected match %v, got %v", tt.expectedEntry != "", ok)
+			}
+			if entry.Name != tt.expectedEntry {
+				t.Errorf("Expected entry %q, got %q", tt.expectedEntry, entry.Name)
+			}
+			if container != tt.expectedContainer {
+				t.Errorf("Expected container %q, got %q", tt.expectedContainer, container)
+			}
+		})
+	}
+}
+
+func TestExecCatalog_GenerateExecCollectors(t *testing.T) {
+	catalog := NewExecCatalogFromConfig(ExecCatalogConfig{
+		Entries: []ExecCatalogEntry{
+			{
+				Name:   "redis",
+				Labels: []string{"redis"},
+				Commands: []ExecCommand{
+					{Name: "info", Command: []string{"redis-cli", "INFO"}},
+					{Name: "slowlog", Command: []string{"redis-cli", "SLOWLOG", "GET", "20"}, Timeout: "10s"},
+				},
+			},
+		},
+	})
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resources := []Resource{
+		{GVR: pods, Namespace: "cache", Name: "redis-0", Labels: map[string]string{"app": "redis"}},
+		{GVR: pods, Namespace: "cache", Name: "web", Labels: map[string]string{"app": "web"}},
+	}
+
+	collectors := catalog.GenerateExecCollectors(resources, int(PriorityNormal))
+	if len(collectors) != 2 {
+		t.Fatalf("Expected 2 collectors, got %d", len(collectors))
+	}
+
+	slowlog := collectors[1]
+	if slowlog.Type != CollectorTypeExec || slowlog.Name != "auto-exec-redis-0-redis-slowlog" || slowlog.Namespace != "cache" {
+		t.Errorf("Unexpected collector %+v", slowlog)
+	}
+	if slowlog.Parameters["timeout"] != "10s" || collectors[0].Parameters["timeout"] != defaultExecTimeout {
+		t.Errorf("Expected timeouts 30s and 10s, got %v and %v", collectors[0].Parameters["timeout"], slowlog.Parameters["timeout"])
+	}
+	if command := slowlog.Parameters["command"].([]string); strings.Join(command, " ") != "redis-cli SLOWLOG GET 20" {
+		t.Errorf("Expected the configured command, got %v", command)
+	}
+
+	if len(catalog.Entries()) != len(DefaultExecCatalogEntries()) {
+		t.Errorf("Expected the redis entry to replace the default one, got %d entries", len(catalog.Entries()))
+	}
+	if len(NewExecCatalogFromConfig(ExecCatalogConfig{DisableDefaults: true}).Entries()) != 0 {
+		t.Errorf("Expected no entries with defaults disabled")
+	}
+}
+
+func TestExecCatalogEntry_Validate(t *testing.T) {
+	valid := func(command ...string) ExecCatalogEntry {
+		return ExecCatalogEntry{Name: "app", Labels: []string{"app"}, Commands: []ExecCommand{{Name: "check", Command: command}}}
+	}
+
+	tests := []struct {
+		name        string
+		entry       ExecCatalogEntry
+		expectError string
+	}{
+		{name: "valid", entry: valid("pg_isready", "-h", "localhost")},
+		{name: "missing name", entry: ExecCatalogEntry{Labels: []string{"app"}}, expectError: "name is required"},
+		{name: "nothing to match", entry: ExecCatalogEntry{Name: "app", Commands: []ExecCommand{{Name: "check", Command: []string{"true"}}}}, expectError: "labels or images"},
+		{name: "no commands", entry: ExecCatalogEntry{Name: "app", Images: []string{"app"}}, expectError: "at least one command"},
+		{name: "empty command", entry: valid(), expectError: "command is required"},
+		{name: "shell", entry: valid("/bin/sh", "-c", "cat /etc/hosts"), expectError: "is a shell"},
+		{name: "redis flush", entry: valid("redis-cli", "FLUSHALL"), expectError: `"flushall" is not read-only`},
+		{name: "sql drop", entry: valid("psql", "-c", "DROP TABLE users;"), expectError: `"drop" is not read-only`},
+		{name: "remove files", entry: valid("rm", "-rf", "/data"), expectError: `"rm" is not read-only`},
+		{
+			name:        "invalid timeout",
+			entry:       ExecCatalogEntry{Name: "app", Labels: []string{"app"}, Commands: []ExecCommand{{Name: "check", Command: []string{"true"}, Timeout: "soon"}}},
+			expectError: "invalid timeout",
+		},
+		{
+			name:        "duplicate command",
+			entry:       ExecCatalogEntry{Name: "app", Labels: []string{"app"}, Commands: []ExecCommand{{Name: "check", Command: []string{"true"}}, {Name: "check", Command: []string{"true"}}}},
+			expectError: "duplicate name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.entry.Validate()
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+
+	for _, entry := range DefaultExecCatalogEntries() {
+		if err := entry.Validate(); err != nil {
+			t.Errorf("Expected default entry %s to be valid: %v", entry.Name, err)
+		}
+	}
+}
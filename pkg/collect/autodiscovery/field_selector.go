@@ -0,0 +1,223 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// fieldSelectorOperators are checked longest first so ">=" is not read as ">"
+var fieldSelectorOperators = []string{"!=", ">=", "<=", "==", "=", ">", "<"}
+
+// FieldSelector matches a resource on the value found at a JSONPath, e.g. status.phase=Failed or spec.replicas>10
+// Ordered operators compare numbers, or RFC3339 timestamps such as lastTimestamp>=2024-01-01T00:00:00Z
+type FieldSelector struct {
+	Path     string `json:"path"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// ParseFieldSelector parses "<path><op><value>" where op is one of =, ==, !=, >, >=, <, <=
+// The path is a JSONPath with or without braces and the leading dot, e.g. {.status.phase} or status.phase
+// Operators inside brackets, such as JSONPath filters, are part of the path
+func ParseFieldSelector(expr string) (FieldSelector, error) {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '[', '(', '{':
+			depth++
+			continue
+		case ']', ')', '}':
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+
+		for _, op := range fieldSelectorOperators {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+			selector := FieldSelector{
+				Path:     normalizeFieldPath(expr[:i]),
+				Operator: op,
+				Value:    strings.TrimSpace(expr[i+len(op):]),
+			}
+			if selector.Operator == "==" {
+				selector.Operator = "="
+			}
+			if selector.Path == "" {
+				return FieldSelector{}, fmt.Errorf("field selector %q has no path", expr)
+			}
+			if _, err := compileFieldPath(selector.Path); err != nil {
+				return FieldSelector{}, fmt.Errorf("field selector %q: %w", expr, err)
+			}
+			if selector.isNumeric() {
+				_, numErr := strconv.ParseFloat(selector.Value, 64)
+				_, timeErr := time.Parse(time.RFC3339, selector.Value)
+				if numErr != nil && timeErr != nil {
+					return FieldSelector{}, fmt.Errorf("field selector %q: %s requires a numeric or RFC3339 timestamp value", expr, selector.Operator)
+				}
+			}
+			return selector, nil
+		}
+	}
+
+	return FieldSelector{}, fmt.Errorf("field selector %q has no operator (valid: %s)", expr, strings.Join(fieldSelectorOperators, ", "))
+}
+
+// Matches reports whether the extracted fields satisfy the selector
+// A path with several results (e.g. over a list) matches when any result matches, and "!=" when none is equal
+// A missing field only matches "!="
+func (s FieldSelector) Matches(fields map[string][]interface{}) bool {
+	values := fields[s.Path]
+
+	if s.Operator == "!=" {
+		for _, value := range values {
+			if fmt.Sprint(value) == s.Value {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, value := range values {
+		if s.matchesValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s FieldSelector) matchesValue(value interface{}) bool {
+	if !s.isNumeric() {
+		return fmt.Sprint(value) == s.Value
+	}
+
+	var actual, expected float64
+	if expectedTime, err := time.Parse(time.RFC3339, s.Value); err == nil {
+		actualTime, err := time.Parse(time.RFC3339, fmt.Sprint(value))
+		if err != nil {
+			return false
+		}
+		actual, expected = float64(actualTime.Unix()), float64(expectedTime.Unix())
+	} else {
+		if actual, err = strconv.ParseFloat(fmt.Sprint(value), 64); err != nil {
+			return false
+		}
+		if expected, err = strconv.ParseFloat(s.Value, 64); err != nil {
+			return false
+		}
+	}
+
+	switch s.Operator {
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	}
+	return false
+}
+
+func (s FieldSelector) isNumeric() bool {
+	return s.Operator == ">" || s.Operator == ">=" || s.Operator == "<" || s.Operator == "<="
+}
+
+// ParseFieldSelectors parses every expression, failing on the first invalid one
+func ParseFieldSelectors(exprs []string) ([]FieldSelector, error) {
+	selectors := make([]FieldSelector, 0, len(exprs))
+	for _, expr := range exprs {
+		selector, err := ParseFieldSelector(expr)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+// MatchFieldSelectors reports whether the resource satisfies all selectors
+func MatchFieldSelectors(selectors []FieldSelector, resource Resource) bool {
+	for _, selector := range selectors {
+		if !selector.Matches(resource.Fields) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeFieldPath strips braces and the leading dot so {.status.phase} and status.phase are the same path
+func normalizeFieldPath(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") && strings.HasSuffix(path, "}") {
+		path = path[1 : len(path)-1]
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+func compileFieldPath(path string) (*jsonpath.JSONPath, error) {
+	parser := jsonpath.New(path).AllowMissingKeys(true)
+	if err := parser.Parse("{." + path + "}"); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", path, err)
+	}
+	return parser, nil
+}
+
+// fieldExtractor reads the values at a fixed set of paths from listed objects
+// The scanner keeps only these values so full objects can still be released page by page
+type fieldExtractor struct {
+	paths map[string]*jsonpath.JSONPath
+}
+
+// newFieldExtractor compiles the paths, returning nil when there is nothing to extract
+func newFieldExtractor(paths []string) (*fieldExtractor, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	extractor := &fieldExtractor{paths: make(map[string]*jsonpath.JSONPath)}
+	for _, path := range paths {
+		path = normalizeFieldPath(path)
+		if _, exists := extractor.paths[path]; exists {
+			continue
+		}
+		parser, err := compileFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		extractor.paths[path] = parser
+	}
+	return extractor, nil
+}
+
+// extract returns the values found at each path, paths without results are omitted
+func (e *fieldExtractor) extract(obj unstructured.Unstructured) map[string][]interface{} {
+	if e == nil {
+		return nil
+	}
+
+	fields := make(map[string][]interface{})
+	for path, parser := range e.paths {
+		results, err := parser.FindResults(obj.Object)
+		if err != nil {
+			continue
+		}
+		for _, result := range results {
+			for _, value := range result {
+				if value.IsValid() && value.CanInterface() {
+					fields[path] = append(fields[path], value.Interface())
+				}
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,199 @@
+package autodiscovery
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseFieldSelector(t *testing.T) {
+	tests := []struct {
+		expr        string
+		expected    FieldSelector
+		expectError bool
+	}{
+		{expr: "status.phase=Failed", expected: FieldSelector{Path: "status.phase", Operator: "=", Value: "Failed"}},
+		{expr: "{.status.phase}==Failed", expected: FieldSelector{Path: "status.phase", Operator: "=", Value: "Failed"}},
+		{expr: "status.phase!=Running", expected: FieldSelector{Path: "status.phase", Operator: "!=", Value: "Running"}},
+		{expr: "spec.replicas>10", expected: FieldSelector{Path: "spec.replicas", Operator: ">", Value: "10"}},
+		{expr: "spec.replicas >= 3", expected: FieldSelector{Path: "spec.replicas", Operator: ">=", Value: "3"}},
+		{expr: "lastTimestamp>=2024-01-01T10:00:00Z", expected: FieldSelector{Path: "lastTimestamp", Operator: ">=", Value: "2024-01-01T10:00:00Z"}},
+		{
+			expr:     `status.conditions[?(@.type=="Ready")].status=False`,
+			expected: FieldSelector{Path: `status.conditions[?(@.type=="Ready")].status`, Operator: "=", Value: "False"},
+		},
+		{expr: "status.phase", expectError: true},
+		{expr: "=Failed", expectError: true},
+		{expr: "spec.replicas>many", expectError: true},
+		{expr: "spec.containers[=x", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := ParseFieldSelector(tt.expr)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got %+v", selector)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if selector != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, selector)
+			}
+		})
+	}
+}
+
+func TestFieldSelector_Matches(t *testing.T) {
+	fields := map[string][]interface{}{
+		"status.phase":  {"Failed"},
+		"spec.replicas": {int64(12)},
+		"status.containerStatuses[*].restartCount": {int64(0), int64(7)},
+		"lastTimestamp": {"2024-01-01T10:30:00Z"},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"status.phase=Failed", true},
+		{"status.phase=Running", false},
+		{"status.phase!=Running", true},
+		{"spec.replicas>10", true},
+		{"spec.replicas<=10", false},
+		{"status.containerStatuses[*].restartCount>5", true},
+		{"status.containerStatuses[*].restartCount!=0", false},
+		{"spec.nodeName=worker-1", false},
+		{"spec.nodeName!=worker-1", true},
+		{"lastTimestamp>=2024-01-01T10:00:00Z", true},
+		{"lastTimestamp<=2024-01-01T11:00:00+02:00", false},
+		{"spec.replicas>2024-01-01T10:00:00Z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := ParseFieldSelector(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := selector.Matches(fields); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFieldExtractor(t *testing.T) {
+	extractor, err := newFieldExtractor([]string{"{.status.phase}", "status.phase", "spec.containers[*].name", "spec.nodeName"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fields := extractor.extract(unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app"},
+				map[string]interface{}{"name": "sidecar"},
+			},
+		},
+		"status": map[string]interface{}{"phase": "Failed"},
+	}})
+
+	if len(fields["status.phase"]) != 1 || fields["status.phase"][0] != "Failed" {
+		t.Errorf("Expected status.phase Failed, got %v", fields["status.phase"])
+	}
+	if len(fields["spec.containers[*].name"]) != 2 {
+		t.Errorf("Expected 2 container names, got %v", fields["spec.containers[*].name"])
+	}
+	if _, exists := fields["spec.nodeName"]; exists {
+		t.Errorf("Expected missing field to be omitted, got %v", fields["spec.nodeName"])
+	}
+
+	if extractor, err := newFieldExtractor(nil); extractor != nil || err != nil {
+		t.Errorf("Expected nil extractor without paths, got %v, %v", extractor, err)
+	}
+}
+
+func TestNamespaceScanner_FieldPaths(t *testing.T) {
+	replicas := int32(12)
+	dynamicClient := createTestDynamicClient(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "crashed", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "big", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+	)
+	scanner := NewNamespaceScanner(kubernetesfake.NewSimpleClientset(), dynamicClient)
+
+	resources, err := scanner.ScanNamespaces(context.Background(), []string{"default"}, ResourceFilter{
+		FieldPaths: []string{"status.phase", "spec.replicas"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	configManager := NewConfigManager()
+	if err := configManager.LoadFromYAML([]byte(`
+resourceFilters:
+  - name: failed-pods
+    action: exclude
+    fieldSelectors:
+      - status.phase=Failed
+  - name: small-deployments
+    action: exclude
+    matchGVRs:
+      - group: apps
+        version: v1
+        resource: deployments
+    fieldSelectors:
+      - "{.spec.replicas}<=10"
+`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	paths := configManager.FieldPaths()
+	if len(paths) != 2 || paths[0] != "status.phase" || paths[1] != "spec.replicas" {
+		t.Errorf("Expected field paths from the filters, got %v", paths)
+	}
+
+	names := make(map[string]bool)
+	for _, resource := range configManager.ApplyResourceFilters(resources) {
+		names[resource.Name] = true
+	}
+	if !names["healthy"] {
+		t.Errorf("Expected running pod to be kept, got %v", names)
+	}
+	if names["crashed"] {
+		t.Errorf("Expected failed pod to be excluded, got %v", names)
+	}
+	if !names["big"] {
+		t.Errorf("Expected deployment with 12 replicas to be kept, got %v", names)
+	}
+
+	if _, err := scanner.ScanNamespaces(context.Background(), []string{"default"}, ResourceFilter{FieldPaths: []string{"spec.containers[="}}); err == nil {
+		t.Errorf("Expected error for an invalid field path")
+	}
+}
+
+func TestConfigManager_InvalidFieldSelector(t *testing.T) {
+	configManager := NewConfigManager()
+	err := configManager.LoadFromJSON([]byte(`{"resourceFilters": [{"name": "bad", "action": "exclude", "fieldSelectors": ["status.phase"]}]}`))
+	if err == nil {
+		t.Errorf("Expected error for a field selector without an operator")
+	}
+}
@@ -0,0 +1,343 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultJobPodLogLimit is the number of finished Job pods per namespace whose logs are fetched ahead of
+// other collectors, those of Jobs expiring soonest first
+const DefaultJobPodLogLimit = 50
+
+// Job states in a JobStatusReport
+const (
+	JobStateComplete = "Complete"
+	JobStateFailed   = "Failed"
+	JobStateRunning  = "Running"
+)
+
+var jobsGVR = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+
+// JobPodStatus is a pod of a Job as it was at discovery, kept in the report in case the pod is deleted
+// before its logs are collected
+type JobPodStatus struct {
+	Name       string               `json:"name"`
+	Phase      string               `json:"phase,omitempty"`
+	Containers []JobContainerStatus `json:"containers,omitempty"`
+	Logs       string               `json:"logs,omitempty"` // Collector fetching the pod's logs, empty when over the limit
+}
+
+// JobContainerStatus is how a container of a Job pod terminated
+type JobContainerStatus struct {
+	Name     string `json:"name"`
+	ExitCode *int64 `json:"exitCode,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// JobCondition is a condition of a Job's status
+type JobCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// JobStatusSummary is the status of one Job, with the pods that still exist and a note on those that do not
+type JobStatusSummary struct {
+	Name        string         `json:"name"`
+	State       string         `json:"state"`
+	Active      int64          `json:"active"`
+	Succeeded   int64          `json:"succeeded"`
+	Failed      int64          `json:"failed"`
+	StartTime   string         `json:"startTime,omitempty"`
+	FinishedAt  string         `json:"finishedAt,omitempty"`
+	TTLSeconds  *int64         `json:"ttlSecondsAfterFinished,omitempty"`
+	ExpiresAt   string         `json:"expiresAt,omitempty"` // When the TTL controller deletes the Job and its pods
+	Conditions  []JobCondition `json:"conditions,omitempty"`
+	Pods        []JobPodStatus `json:"pods"`
+	MissingPods int64          `json:"missingPods,omitempty"` // Pods counted by the Job status that no longer exist
+	Gap         string         `json:"gap,omitempty"`
+
+	expiresAt time.Time
+}
+
+// JobStatusReport is the status of the discovered Jobs in one namespace, written to namespaces/<ns>/jobs.json
+type JobStatusReport struct {
+	Namespace string             `json:"namespace"`
+	Jobs      []JobStatusSummary `json:"jobs"`
+	Problems  []string           `json:"problems,omitempty"`
+}
+
+// FinishedJobs captures the status of discovered Jobs and fetches the logs of finished Job pods first.
+// Jobs with ttlSecondsAfterFinished are deleted with their pods soon after they finish, often before a
+// collection that runs collectors in the usual order reaches them
+type FinishedJobs struct {
+	dynamicClient dynamic.Interface
+	now           func() time.Time
+}
+
+// NewFinishedJobs creates a new FinishedJobs
+func NewFinishedJobs(dynamicClient dynamic.Interface) *FinishedJobs {
+	return &FinishedJobs{
+		dynamicClient: dynamicClient,
+		now:           time.Now,
+	}
+}
+
+// GenerateJobCollectors returns, per namespace with discovered Jobs or Job pods, a data collector holding the
+// status of the Jobs and a PriorityExpiring logs collector for each pod of a finished Job
+func (f *FinishedJobs) GenerateJobCollectors(ctx context.Context, resources []Resource) []CollectorSpec {
+	jobs := make(map[string]map[string]bool)
+	addJob := func(namespace, name string) {
+		if jobs[namespace] == nil {
+			jobs[namespace] = make(map[string]bool)
+		}
+		jobs[namespace][name] = true
+	}
+	for _, resource := range resources {
+		if resource.Namespace == "" {
+			continue
+		}
+		if resource.GVR.Group == jobsGVR.Group && resource.GVR.Resource == jobsGVR.Resource {
+			addJob(resource.Namespace, resource.Name)
+		}
+		if resource.GVR.Resource == "pods" && resource.GVR.Group == "" {
+			for _, owner := range resource.OwnerRefs {
+				if owner.Kind == "Job" {
+					addJob(resource.Namespace, owner.Name)
+				}
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(jobs))
+	for namespace := range jobs {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var collectors []CollectorSpec
+	for _, namespace := range namespaces {
+		report, logCollectors := f.namespaceJobs(ctx, namespace, jobs[namespace])
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			continue
+		}
+		collectors = append(collectors, logCollectors...)
+		collectors = append(collectors, CollectorSpec{
+			Type:      "data",
+			Name:      fmt.Sprintf("auto-jobs-%s", namespace),
+			Namespace: namespace,
+			Group:     CollectorGroupWorkloads,
+			Priority:  int(PriorityHigh),
+			Parameters: map[string]interface{}{
+				"name": fmt.Sprintf("namespaces/%s/jobs.json", namespace),
+				"data": string(data),
+			},
+		})
+	}
+	return collectors
+}
+
+// namespaceJobs builds the report of one namespace and the logs collectors of its finished Job pods,
+// listing failures as problems rather than failing
+func (f *FinishedJobs) namespaceJobs(ctx context.Context, namespace string, names map[string]bool) (JobStatusReport, []CollectorSpec) {
+	report := JobStatusReport{Namespace: namespace, Jobs: []JobStatusSummary{}}
+
+	jobList, err := f.dynamicClient.Resource(jobsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to list jobs: %v", err))
+		return report, nil
+	}
+	pods := make(map[string][]unstructured.Unstructured)
+	podList, err := f.dynamicClient.Resource(podsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	podsListed := err == nil
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to list pods: %v", err))
+	} else {
+		for _, pod := range podList.Items {
+			for _, owner := range pod.GetOwnerReferences() {
+				if owner.Kind == "Job" {
+					pods[owner.Name] = append(pods[owner.Name], pod)
+				}
+			}
+		}
+	}
+
+	now := f.now()
+	for _, job := range jobList.Items {
+		if !names[job.GetName()] {
+			continue
+		}
+		summary := jobStatusSummary(job, pods[job.GetName()], podsListed)
+		if !summary.expiresAt.IsZero() && summary.expiresAt.Before(now) {
+			summary.Gap = appendGap(summary.Gap, fmt.Sprintf("TTL expired at %s, the Job and its pods are being deleted", summary.ExpiresAt))
+		}
+		report.Jobs = append(report.Jobs, summary)
+	}
+	for name := range names {
+		if !containsJob(report.Jobs, name) {
+			report.Problems = append(report.Problems, fmt.Sprintf("job %s was deleted before its status could be read", name))
+		}
+	}
+	sort.Strings(report.Problems)
+
+	// Finished Jobs expiring soonest go first, then the most recently finished
+	sort.SliceStable(report.Jobs, func(i, j int) bool {
+		a, b := report.Jobs[i], report.Jobs[j]
+		if a.expiresAt.IsZero() != b.expiresAt.IsZero() {
+			return !a.expiresAt.IsZero()
+		}
+		if !a.expiresAt.Equal(b.expiresAt) {
+			return a.expiresAt.Before(b.expiresAt)
+		}
+		if a.FinishedAt != b.FinishedAt {
+			return a.FinishedAt > b.FinishedAt
+		}
+		return a.Name < b.Name
+	})
+
+	var collectors []CollectorSpec
+	skipped := 0
+	for i := range report.Jobs {
+		job := &report.Jobs[i]
+		if job.State == JobStateRunning {
+			continue
+		}
+		for p := range job.Pods {
+			if len(collectors) >= DefaultJobPodLogLimit {
+				skipped++
+				continue
+			}
+			pod := &job.Pods[p]
+			pod.Logs = fmt.Sprintf("auto-logs-job-%s", pod.Name)
+			collectors = append(collectors, CollectorSpec{
+				Type:      CollectorTypeLogs,
+				Name:      pod.Logs,
+				Namespace: namespace,
+				Priority:  int(PriorityExpiring),
+				Parameters: LogsParams{
+					Name:      pod.Name,
+					Namespace: namespace,
+					Limits:    &LogsLimits{MaxLines: 10000},
+				}.ToMap(),
+			})
+		}
+	}
+	if skipped > 0 {
+		report.Problems = append(report.Problems, fmt.Sprintf("logs of %d finished Job pods are left to the namespace logs collector, over the limit of %d", skipped, DefaultJobPodLogLimit))
+	}
+	return report, collectors
+}
+
+// jobStatusSummary reads the status of a Job and its remaining pods. listed is false when the pods could not
+// be listed, so missing pods are not reported
+func jobStatusSummary(job unstructured.Unstructured, pods []unstructured.Unstructured, listed bool) JobStatusSummary {
+	summary := JobStatusSummary{Name: job.GetName(), State: JobStateRunning, Pods: []JobPodStatus{}}
+	summary.Active, _, _ = unstructured.NestedInt64(job.Object, "status", "active")
+	summary.Succeeded, _, _ = unstructured.NestedInt64(job.Object, "status", "succeeded")
+	summary.Failed, _, _ = unstructured.NestedInt64(job.Object, "status", "failed")
+	summary.StartTime, _, _ = unstructured.NestedString(job.Object, "status", "startTime")
+	summary.FinishedAt, _, _ = unstructured.NestedString(job.Object, "status", "completionTime")
+	if ttl, ok, _ := unstructured.NestedInt64(job.Object, "spec", "ttlSecondsAfterFinished"); ok {
+		summary.TTLSeconds = &ttl
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(job.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var jc JobCondition
+		jc.Type, _, _ = unstructured.NestedString(condition, "type")
+		jc.Status, _, _ = unstructured.NestedString(condition, "status")
+		jc.Reason, _, _ = unstructured.NestedString(condition, "reason")
+		jc.Message, _, _ = unstructured.NestedString(condition, "message")
+		jc.LastTransitionTime, _, _ = unstructured.NestedString(condition, "lastTransitionTime")
+		summary.Conditions = append(summary.Conditions, jc)
+
+		// Failed Jobs have no completionTime, they finish when the Failed condition is added
+		if jc.Status == "True" && (jc.Type == JobStateComplete || jc.Type == JobStateFailed) {
+			summary.State = jc.Type
+			if summary.FinishedAt == "" {
+				summary.FinishedAt = jc.LastTransitionTime
+			}
+		}
+	}
+
+	if summary.State != JobStateRunning && summary.TTLSeconds != nil {
+		if finishedAt, err := time.Parse(time.RFC3339, summary.FinishedAt); err == nil {
+			summary.expiresAt = finishedAt.Add(time.Duration(*summary.TTLSeconds) * time.Second)
+			summary.ExpiresAt = summary.expiresAt.Format(time.RFC3339)
+		}
+	}
+
+	sort.Slice(pods, func(i, j int) bool { return pods[i].GetName() < pods[j].GetName() })
+	for _, pod := range pods {
+		summary.Pods = append(summary.Pods, jobPodStatus(pod))
+	}
+
+	if listed {
+		if expected := summary.Active + summary.Succeeded + summary.Failed; expected > int64(len(pods)) {
+			summary.MissingPods = expected - int64(len(pods))
+			summary.Gap = fmt.Sprintf("%d of %d pods no longer exist, their logs cannot be collected; the counts and conditions above are all that remains of them",
+				summary.MissingPods, expected)
+		}
+	}
+	return summary
+}
+
+// jobPodStatus keeps the phase and container terminations of a Job pod
+func jobPodStatus(pod unstructured.Unstructured) JobPodStatus {
+	status := JobPodStatus{Name: pod.GetName()}
+	status.Phase, _, _ = unstructured.NestedString(pod.Object, "status", "phase")
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, s := range statuses {
+			container, ok := s.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			terminated, ok, _ := unstructured.NestedMap(container, "state", "terminated")
+			if !ok {
+				continue
+			}
+			cs := JobContainerStatus{}
+			cs.Name, _, _ = unstructured.NestedString(container, "name")
+			cs.Reason, _, _ = unstructured.NestedString(terminated, "reason")
+			cs.Message, _, _ = unstructured.NestedString(terminated, "message")
+			if exitCode, ok, _ := unstructured.NestedInt64(terminated, "exitCode"); ok {
+				cs.ExitCode = &exitCode
+			}
+			status.Containers = append(status.Containers, cs)
+		}
+	}
+	return status
+}
+
+func containsJob(jobs []JobStatusSummary, name string) bool {
+	for _, job := range jobs {
+		if job.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func appendGap(gap, note string) string {
+	if gap == "" {
+		return note
+	}
+	return gap + "; " + note
+}
@@ -0,0 +1,145 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testJob(name string, ttl int64, status map[string]interface{}) *unstructured.Unstructured {
+	job := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "batch"},
+		"spec":       map[string]interface{}{},
+		"status":     status,
+	}}
+	if ttl > 0 {
+		job.Object["spec"] = map[string]interface{}{"ttlSecondsAfterFinished": ttl}
+	}
+	return job
+}
+
+func testJobPod(name, job, phase string, exitCode int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       "batch",
+			"ownerReferences": []interface{}{map[string]interface{}{"apiVersion": "batch/v1", "kind": "Job", "name": job, "uid": job}},
+		},
+		"status": map[string]interface{}{
+			"phase": phase,
+			"containerStatuses": []interface{}{map[string]interface{}{
+				"name":  "main",
+				"state": map[string]interface{}{"terminated": map[string]interface{}{"exitCode": exitCode, "reason": "Error"}},
+			}},
+		},
+	}}
+}
+
+func jobCondition(conditionType, at string) map[string]interface{} {
+	return map[string]interface{}{"type": conditionType, "status": "True", "lastTransitionTime": at}
+}
+
+func TestFinishedJobs_GenerateJobCollectors(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	client := createTestDynamicClient(
+		// Failed 2 minutes ago with a 5 minute TTL, one of its 3 pods was already garbage collected
+		testJob("migrate", 300, map[string]interface{}{
+			"failed": int64(3), "startTime": "2024-03-01T09:50:00Z",
+			"conditions": []interface{}{jobCondition("Failed", "2024-03-01T09:58:00Z")},
+		}),
+		testJobPod("migrate-a", "migrate", "Failed", 1),
+		testJobPod("migrate-b", "migrate", "Failed", 1),
+		// Completed long ago without a TTL
+		testJob("backup", 0, map[string]interface{}{
+			"succeeded": int64(1), "completionTime": "2024-03-01T08:00:00Z",
+			"conditions": []interface{}{jobCondition("Complete", "2024-03-01T08:00:00Z")},
+		}),
+		testJobPod("backup-a", "backup", "Succeeded", 0),
+		// Still running, its logs are left to the namespace logs collector
+		testJob("report", 0, map[string]interface{}{"active": int64(1)}),
+		testJobPod("report-a", "report", "Running", 0),
+	)
+
+	jobsResource := func(name string) Resource {
+		return Resource{GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, Namespace: "batch", Name: name}
+	}
+	resources := []Resource{
+		jobsResource("backup"),
+		jobsResource("report"),
+		jobsResource("cleanup"), // Deleted by its TTL since it was discovered
+		{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Namespace: "batch", Name: "migrate-a",
+			OwnerRefs: []metav1.OwnerReference{{Kind: "Job", Name: "migrate"}}},
+	}
+
+	finished := NewFinishedJobs(client)
+	finished.now = func() time.Time { return now }
+	collectors := finished.GenerateJobCollectors(context.Background(), resources)
+
+	var logs []string
+	var report JobStatusReport
+	for _, collector := range collectors {
+		if collector.Type == CollectorTypeLogs {
+			if collector.Priority != int(PriorityExpiring) {
+				t.Errorf("Expected %s to be collected first, got priority %d", collector.Name, collector.Priority)
+			}
+			logs = append(logs, collector.Name)
+			continue
+		}
+		if collector.Name != "auto-jobs-batch" || collector.Parameters["name"] != "namespaces/batch/jobs.json" {
+			t.Fatalf("Unexpected collector %+v", collector)
+		}
+		if err := json.Unmarshal([]byte(collector.Parameters["data"].(string)), &report); err != nil {
+			t.Fatalf("Failed to parse report: %v", err)
+		}
+	}
+
+	// The Job expiring soonest comes first
+	if strings.Join(logs, ",") != "auto-logs-job-migrate-a,auto-logs-job-migrate-b,auto-logs-job-backup-a" {
+		t.Errorf("Expected logs of finished Job pods only, expiring first, got %v", logs)
+	}
+	if len(report.Jobs) != 3 || report.Jobs[0].Name != "migrate" || report.Jobs[2].State != JobStateRunning {
+		t.Fatalf("Expected migrate, backup and report, got %+v", report.Jobs)
+	}
+
+	migrate := report.Jobs[0]
+	if migrate.State != JobStateFailed || migrate.FinishedAt != "2024-03-01T09:58:00Z" || migrate.ExpiresAt != "2024-03-01T10:03:00Z" {
+		t.Errorf("Expected a failed Job expiring 5 minutes after it failed, got %+v", migrate)
+	}
+	if migrate.MissingPods != 1 || !strings.Contains(migrate.Gap, "1 of 3 pods no longer exist") {
+		t.Errorf("Expected the garbage collected pod to be noted, got %d and %q", migrate.MissingPods, migrate.Gap)
+	}
+	if pod := migrate.Pods[0]; pod.Logs != "auto-logs-job-migrate-a" || *pod.Containers[0].ExitCode != 1 {
+		t.Errorf("Expected the pod's exit code and logs collector, got %+v", pod)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "job cleanup was deleted") {
+		t.Errorf("Expected the deleted Job to be reported, got %v", report.Problems)
+	}
+}
+
+func TestJobStatusSummary_ExpiredTTL(t *testing.T) {
+	job := testJob("nightly", 60, map[string]interface{}{
+		"succeeded": int64(1), "completionTime": "2024-03-01T09:00:00Z",
+		"conditions": []interface{}{jobCondition("Complete", "2024-03-01T09:00:00Z")},
+	})
+	finished := NewFinishedJobs(createTestDynamicClient(job))
+	finished.now = func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	report, collectors := finished.namespaceJobs(context.Background(), "batch", map[string]bool{"nightly": true})
+	if len(collectors) != 0 {
+		t.Errorf("Expected no logs collectors without pods, got %d", len(collectors))
+	}
+	gap := report.Jobs[0].Gap
+	if !strings.Contains(gap, "1 of 1 pods no longer exist") || !strings.Contains(gap, "TTL expired at 2024-03-01T09:01:00Z") {
+		t.Errorf("Expected the missing pod and the expired TTL to be noted, got %q", gap)
+	}
+}
@@ -0,0 +1,231 @@
+package autodiscovery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrBadGlob is returned by ValidateGlob for a malformed pattern
+var ErrBadGlob = errors.New("malformed glob")
+
+// globMeta are the characters with a meaning in a glob, see MatchGlob
+const globMeta = `*?[\`
+
+// globToken is one element of a parsed glob: a literal rune, ?, * or a character class
+type globToken struct {
+	kind    globTokenKind
+	literal rune
+	negate  bool
+	ranges  []globRange
+}
+
+type globTokenKind int
+
+const (
+	globLiteral globTokenKind = iota
+	globAnyRune
+	globAnyString
+	globClass
+)
+
+type globRange struct {
+	lo, hi rune
+}
+
+// MatchGlob reports whether value matches the shell-style glob pattern shared by namespace filters, exclude rules,
+// protected namespaces and redaction globs. A star matches any run of characters, / included, a question mark any
+// single character, [abc] one of the characters, [a-z] one in the range and [!a-z] or [^a-z] one outside it.
+// A backslash escapes the next character, e.g. \* or \[. A pattern without any of these matches only the identical
+// value, and a malformed pattern matches nothing, use ValidateGlob to report it
+func MatchGlob(pattern, value string) bool {
+	if !strings.ContainsAny(pattern, globMeta) {
+		return pattern == value
+	}
+	tokens, err := parseGlob(pattern)
+	if err != nil {
+		return false
+	}
+	return matchGlobTokens(tokens, value)
+}
+
+// MatchesAnyGlob reports whether value matches one of the globs
+func MatchesAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateGlob checks the syntax of a glob: every character class is closed and every \ escapes a character
+func ValidateGlob(pattern string) error {
+	if _, err := parseGlob(pattern); err != nil {
+		return fmt.Errorf("%q: %w", pattern, err)
+	}
+	return nil
+}
+
+// validateGlobs validates each glob, see ValidateGlob
+func validateGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if err := ValidateGlob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsGlob reports whether pattern uses any wildcard, class or escape rather than naming a value exactly
+func IsGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, globMeta)
+}
+
+// EscapeGlob returns a glob matching exactly value
+func EscapeGlob(value string) string {
+	if !strings.ContainsAny(value, globMeta) {
+		return value
+	}
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(globMeta, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// parseGlob splits a glob into tokens, merging consecutive stars
+func parseGlob(pattern string) ([]globToken, error) {
+	var tokens []globToken
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+
+		switch r {
+		case '*':
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != globAnyString {
+				tokens = append(tokens, globToken{kind: globAnyString})
+			}
+		case '?':
+			tokens = append(tokens, globToken{kind: globAnyRune})
+		case '\\':
+			if i >= len(pattern) {
+				return nil, fmt.Errorf("%w: trailing \\", ErrBadGlob)
+			}
+			escaped, size := utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			tokens = append(tokens, globToken{kind: globLiteral, literal: escaped})
+		case '[':
+			class, next, err := parseGlobClass(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, class)
+			i = next
+		default:
+			tokens = append(tokens, globToken{kind: globLiteral, literal: r})
+		}
+	}
+	return tokens, nil
+}
+
+// parseGlobClass parses a character class starting just after its [, returning the index after its ]
+// A ] right after [ or [! is part of the class, as is a - at either end
+func parseGlobClass(pattern string, i int) (globToken, int, error) {
+	class := globToken{kind: globClass}
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		class.negate = true
+		i++
+	}
+
+	// readChar reads one possibly escaped character of the class
+	readChar := func() (rune, error) {
+		if i >= len(pattern) {
+			return 0, fmt.Errorf("%w: unterminated character class", ErrBadGlob)
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		if r == '\\' {
+			if i >= len(pattern) {
+				return 0, fmt.Errorf("%w: trailing \\", ErrBadGlob)
+			}
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+		}
+		return r, nil
+	}
+
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return class, i, fmt.Errorf("%w: unterminated character class", ErrBadGlob)
+		}
+		if pattern[i] == ']' && !first {
+			return class, i + 1, nil
+		}
+
+		lo, err := readChar()
+		if err != nil {
+			return class, i, err
+		}
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			i++
+			if hi, err = readChar(); err != nil {
+				return class, i, err
+			}
+		}
+		class.ranges = append(class.ranges, globRange{lo: lo, hi: hi})
+	}
+}
+
+// matches reports whether a single-character token matches r
+func (t globToken) matches(r rune) bool {
+	switch t.kind {
+	case globLiteral:
+		return t.literal == r
+	case globAnyRune:
+		return true
+	case globClass:
+		in := false
+		for _, rng := range t.ranges {
+			if rng.lo <= r && r <= rng.hi {
+				in = true
+				break
+			}
+		}
+		return in != t.negate
+	default:
+		return false
+	}
+}
+
+// matchGlobTokens matches greedily, backtracking to the last star on a mismatch. Every other token matches
+// exactly one character, so only the last star ever needs to give characters back
+func matchGlobTokens(tokens []globToken, value string) bool {
+	runes := []rune(value)
+	t, v := 0, 0
+	star, starV := -1, 0
+	for v < len(runes) {
+		switch {
+		case t < len(tokens) && tokens[t].kind == globAnyString:
+			star, starV = t, v
+			t++
+		case t < len(tokens) && tokens[t].matches(runes[v]):
+			t++
+			v++
+		case star >= 0:
+			starV++
+			t, v = star+1, starV
+		default:
+			return false
+		}
+	}
+	for t < len(tokens) && tokens[t].kind == globAnyString {
+		t++
+	}
+	return t == len(tokens)
+}
@@ -0,0 +1,175 @@
+package autodiscovery
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{pattern: "", value: "", expected: true},
+		{pattern: "default", value: "default", expected: true},
+		{pattern: "default", value: "default-2", expected: false},
+		{pattern: "kube-*", value: "kube-system", expected: true},
+		{pattern: "kube-*", value: "kube-", expected: true},
+		{pattern: "*-system", value: "system", expected: false},
+		{pattern: "*", value: "", expected: true},
+		{pattern: "a*b*c", value: "abxbxc", expected: true},
+		{pattern: "a*b*c", value: "abxbx", expected: false},
+		{pattern: "auto-*", value: "auto-logs/app", expected: true},
+		{pattern: "app-?", value: "app-1", expected: true},
+		{pattern: "app-?", value: "app-10", expected: false},
+		{pattern: "app-?", value: "app-é", expected: true},
+		{pattern: "team-[abc]", value: "team-b", expected: true},
+		{pattern: "team-[abc]", value: "team-d", expected: false},
+		{pattern: "ns-[0-9][0-9]", value: "ns-42", expected: true},
+		{pattern: "ns-[0-9]", value: "ns-x", expected: false},
+		{pattern: "ns-[!0-9]", value: "ns-x", expected: true},
+		{pattern: "ns-[^0-9]", value: "ns-4", expected: false},
+		{pattern: "[]a]", value: "]", expected: true},
+		{pattern: "[a-]", value: "-", expected: true},
+		{pattern: `app\*`, value: "app*", expected: true},
+		{pattern: `app\*`, value: "apps", expected: false},
+		{pattern: `\[x\]`, value: "[x]", expected: true},
+		{pattern: `[\]]`, value: "]", expected: true},
+		{pattern: "app.v1", value: "app-v1", expected: false},
+		{pattern: "ns-[0-9", value: "ns-[0-9", expected: false},
+		{pattern: `app\`, value: `app\`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.value, func(t *testing.T) {
+			if result := MatchGlob(tt.pattern, tt.value); result != tt.expected {
+				t.Errorf("Pattern %q matching %q: expected %v, got %v", tt.pattern, tt.value, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: "kube-*"},
+		{pattern: "team-[a-c]?"},
+		{pattern: `literal\*`},
+		{pattern: "[]]"},
+		{pattern: "ns-[0-9", wantErr: true},
+		{pattern: "[", wantErr: true},
+		{pattern: "[!", wantErr: true},
+		{pattern: `app\`, wantErr: true},
+		{pattern: `[a\`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			err := ValidateGlob(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrBadGlob) {
+				t.Errorf("Expected ErrBadGlob, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	for _, value := range []string{"default", "a*b", "[x]", `back\slash`, "what?"} {
+		escaped := EscapeGlob(value)
+		if !MatchGlob(escaped, value) {
+			t.Errorf("Expected %q to match %q", escaped, value)
+		}
+		if IsGlob(value) && MatchGlob(escaped, value+"x") {
+			t.Errorf("Expected %q to match only %q", escaped, value)
+		}
+	}
+}
+
+func TestValidateConfig_Globs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "valid globs",
+			config: &Config{Excludes: []ResourceExcludeRule{{Namespaces: []string{"team-[a-c]*"}, Names: []string{`literal\*`}}}},
+		},
+		{
+			name:    "bad exclude namespace",
+			config:  &Config{Excludes: []ResourceExcludeRule{{Namespaces: []string{"team-[a-c"}}}},
+			wantErr: "excludes[0]: namespaces",
+		},
+		{
+			name:    "bad exclude name",
+			config:  &Config{Excludes: []ResourceExcludeRule{{Names: []string{`app\`}}}},
+			wantErr: "excludes[0]: names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func FuzzMatchGlob(f *testing.F) {
+	seeds := [][2]string{
+		{"kube-*", "kube-system"},
+		{"app-?", "app-1"},
+		{"ns-[!0-9]*", "ns-x1"},
+		{"[a-z]*[0-9]", "web01"},
+		{`\*lit\[eral`, "*lit[eral"},
+		{"ns-[0-9", "ns-1"},
+		{"*a*a*a*a*b", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+	}
+	for _, seed := range seeds {
+		f.Add(seed[0], seed[1])
+	}
+
+	f.Fuzz(func(t *testing.T, pattern, value string) {
+		matched := MatchGlob(pattern, value)
+
+		if !MatchGlob(EscapeGlob(value), value) {
+			t.Fatalf("Escaped %q does not match itself", value)
+		}
+		if !IsGlob(pattern) && matched != (pattern == value) {
+			t.Fatalf("Literal pattern %q matching %q: got %v", pattern, value, matched)
+		}
+		if ValidateGlob(pattern) != nil && matched {
+			t.Fatalf("Malformed pattern %q matched %q", pattern, value)
+		}
+
+		// Without / and the ! negation, which path.Match spells ^, both agree on what they both accept. Invalid
+		// UTF-8 is left out, names never contain it and path.Match compares it byte by byte
+		if strings.ContainsAny(value, "/") || strings.ContainsAny(pattern, "/!^") || !utf8.ValidString(pattern) || !utf8.ValidString(value) {
+			return
+		}
+		expected, err := path.Match(pattern, value)
+		if err != nil || ValidateGlob(pattern) != nil {
+			return
+		}
+		if matched != expected {
+			t.Fatalf("Pattern %q matching %q: expected %v like path.Match, got %v", pattern, value, expected, matched)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// knownCRDProviders names the project that installs well-known API groups, used in suggestions
+var knownCRDProviders = map[string]string{
+	"gateway.networking.k8s.io": "the Gateway API CRDs",
+	"monitoring.coreos.com":     "the Prometheus Operator",
+	"cert-manager.io":           "cert-manager",
+	"snapshot.storage.k8s.io":   "the CSI external-snapshotter CRDs",
+	"networking.istio.io":       "Istio",
+	"argoproj.io":               "Argo",
+	"velero.io":                 "Velero",
+	"metrics.k8s.io":            "metrics-server",
+}
+
+// UnservedGVR is a GVR referenced by a config or profile that the cluster does not serve
+type UnservedGVR struct {
+	GVR        schema.GroupVersionResource `json:"gvr"`
+	Reason     string                      `json:"reason"`
+	Suggestion string                      `json:"suggestion,omitempty"`
+}
+
+// String formats the GVR, reason and suggestion as a single warning line
+func (u UnservedGVR) String() string {
+	message := fmt.Sprintf("%s is not served by the cluster: %s", formatGVR(u.GVR), u.Reason)
+	if u.Suggestion != "" {
+		message += " (" + u.Suggestion + ")"
+	}
+	return message
+}
+
+// FindUnservedGVRs asks the discovery API which of the GVRs the cluster does not serve
+func FindUnservedGVRs(discoveryClient discovery.DiscoveryInterface, gvrs []schema.GroupVersionResource) ([]UnservedGVR, error) {
+	var unserved []UnservedGVR
+	servedVersions := map[string][]string(nil)
+
+	for _, gvr := range gvrs {
+		gv := gvr.GroupVersion().String()
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(gv)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to discover resources for %s: %w", gv, err)
+		}
+
+		if err == nil {
+			names := make([]string, 0, len(resources.APIResources))
+			found := false
+			for _, resource := range resources.APIResources {
+				if resource.Name == gvr.Resource {
+					found = true
+					break
+				}
+				names = append(names, resource.Name)
+			}
+			if !found {
+				unserved = append(unserved, UnservedGVR{
+					GVR:        gvr,
+					Reason:     fmt.Sprintf("%s has no resource %q", gv, gvr.Resource),
+					Suggestion: suggestResourceName(gvr.Resource, names),
+				})
+			}
+			continue
+		}
+
+		// The group version is not served; find out whether the group is served at another version
+		if servedVersions == nil {
+			servedVersions, err = serverGroupVersions(discoveryClient)
+			if err != nil {
+				return nil, err
+			}
+		}
+		unserved = append(unserved, unservedGroupVersion(gvr, servedVersions[gvr.Group]))
+	}
+
+	return unserved, nil
+}
+
+// FindUnservedGVRs checks the GVRs against the discovery API of the discoverer's cluster
+// GVRs of aggregated APIs found unavailable are reported without asking the discovery API, which would hang
+func (d *Discoverer) FindUnservedGVRs(gvrs []schema.GroupVersionResource) ([]UnservedGVR, error) {
+	if len(gvrs) == 0 {
+		return nil, nil
+	}
+
+	var unserved []UnservedGVR
+	var served []schema.GroupVersionResource
+	unavailable := unavailableGroupVersions(d.unavailableAPIs)
+	for _, gvr := range gvrs {
+		apiService, down := unavailable[gvr.GroupVersion()]
+		if !down {
+			served = append(served, gvr)
+			continue
+		}
+		unserved = append(unserved, UnservedGVR{
+			GVR:        gvr,
+			Reason:     fmt.Sprintf("aggregated API %s is unavailable (%s)", apiService.Name, apiService.Reason),
+			Suggestion: fmt.Sprintf("check the %s/%s service that backs it", apiService.ServiceNamespace, apiService.ServiceName),
+		})
+	}
+	if len(served) == 0 {
+		return unserved, nil
+	}
+
+	found, err := FindUnservedGVRs(d.kubeClient.Discovery(), served)
+	if err != nil {
+		return nil, err
+	}
+	return append(unserved, found...), nil
+}
+
+func serverGroupVersions(discoveryClient discovery.DiscoveryInterface) (map[string][]string, error) {
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API groups: %w", err)
+	}
+
+	versions := make(map[string][]string)
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			versions[group.Name] = append(versions[group.Name], version.Version)
+		}
+	}
+	return versions, nil
+}
+
+func unservedGroupVersion(gvr schema.GroupVersionResource, servedVersions []string) UnservedGVR {
+	if len(servedVersions) > 0 {
+		sort.Strings(servedVersions)
+		return UnservedGVR{
+			GVR:        gvr,
+			Reason:     fmt.Sprintf("version %s of %s is not served", gvr.Version, groupName(gvr.Group)),
+			Suggestion: fmt.Sprintf("the cluster serves %s, update the config to one of these versions", strings.Join(servedVersions, ", ")),
+		}
+	}
+
+	suggestion := "install the CRDs for this group or remove it from the config"
+	if provider, ok := knownCRDProviders[gvr.Group]; ok {
+		suggestion = fmt.Sprintf("install %s or remove it from the config", provider)
+	}
+	return UnservedGVR{
+		GVR:        gvr,
+		Reason:     fmt.Sprintf("API group %s is not installed", groupName(gvr.Group)),
+		Suggestion: suggestion,
+	}
+}
+
+// suggestResourceName returns a hint when a served resource differs only in case or plural form
+func suggestResourceName(resource string, served []string) string {
+	lower := strings.ToLower(resource)
+	for _, name := range served {
+		if strings.Contains(name, "/") {
+			continue
+		}
+		if name == lower || name == lower+"s" || name == lower+"es" || name+"s" == lower {
+			return fmt.Sprintf("did you mean %q?", name)
+		}
+	}
+	return "check the resource name, it must be the lowercase plural form"
+}
+
+func groupName(group string) string {
+	if group == "" {
+		return "core"
+	}
+	return group
+}
+
+func formatGVR(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return fmt.Sprintf("%s/%s", gvr.Version, gvr.Resource)
+	}
+	return fmt.Sprintf("%s/%s/%s", gvr.Group, gvr.Version, gvr.Resource)
+}
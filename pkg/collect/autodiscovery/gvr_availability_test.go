@@ -0,0 +1,129 @@
+package autodiscovery
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestDiscoveryClient() *fakediscovery.FakeDiscovery {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	discoveryClient := kubeClient.Discovery().(*fakediscovery.FakeDiscovery)
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods"}, {Name: "pods/log"}, {Name: "services"}}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments"}}},
+		{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "ingresses"}, {Name: "networkpolicies"}}},
+	}
+	return discoveryClient
+}
+
+func TestFindUnservedGVRs(t *testing.T) {
+	tests := []struct {
+		name               string
+		gvr                schema.GroupVersionResource
+		expectUnserved     bool
+		expectedSuggestion string
+	}{
+		{
+			name: "served core resource",
+			gvr:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		},
+		{
+			name: "served group resource",
+			gvr:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		},
+		{
+			name:               "singular resource name",
+			gvr:                schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingress"},
+			expectUnserved:     true,
+			expectedSuggestion: `did you mean "ingresses"?`,
+		},
+		{
+			name:               "unknown resource in served group",
+			gvr:                schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "rollouts"},
+			expectUnserved:     true,
+			expectedSuggestion: "lowercase plural form",
+		},
+		{
+			name:               "old version of a served group",
+			gvr:                schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"},
+			expectUnserved:     true,
+			expectedSuggestion: "the cluster serves v1",
+		},
+		{
+			name:               "gateway API not installed",
+			gvr:                schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"},
+			expectUnserved:     true,
+			expectedSuggestion: "install the Gateway API CRDs",
+		},
+		{
+			name:               "unknown CRD group",
+			gvr:                schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"},
+			expectUnserved:     true,
+			expectedSuggestion: "install the CRDs for this group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unserved, err := FindUnservedGVRs(newTestDiscoveryClient(), []schema.GroupVersionResource{tt.gvr})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !tt.expectUnserved {
+				if len(unserved) != 0 {
+					t.Errorf("Expected GVR to be served, got %v", unserved)
+				}
+				return
+			}
+			if len(unserved) != 1 {
+				t.Fatalf("Expected 1 unserved GVR, got %d", len(unserved))
+			}
+			if unserved[0].GVR != tt.gvr {
+				t.Errorf("Expected GVR %v, got %v", tt.gvr, unserved[0].GVR)
+			}
+			if !strings.Contains(unserved[0].Suggestion, tt.expectedSuggestion) {
+				t.Errorf("Expected suggestion containing %q, got %q", tt.expectedSuggestion, unserved[0].Suggestion)
+			}
+		})
+	}
+}
+
+func TestUnservedGVR_String(t *testing.T) {
+	unserved := UnservedGVR{
+		GVR:        schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"},
+		Reason:     "API group gateway.networking.k8s.io is not installed",
+		Suggestion: "install the Gateway API CRDs or remove it from the config",
+	}
+
+	expected := "gateway.networking.k8s.io/v1/gateways is not served by the cluster: API group gateway.networking.k8s.io is not installed (install the Gateway API CRDs or remove it from the config)"
+	if unserved.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, unserved.String())
+	}
+}
+
+func TestDiscoverer_FindUnservedGVRs(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = newTestDiscoveryClient().Resources
+	discoverer := NewDiscovererForClients(kubeClient, createTestDynamicClient())
+
+	unserved, err := discoverer.FindUnservedGVRs([]schema.GroupVersionResource{
+		{Version: "v1", Resource: "pods"},
+		{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(unserved) != 1 || unserved[0].GVR.Resource != "servicemonitors" {
+		t.Errorf("Expected only servicemonitors to be unserved, got %v", unserved)
+	}
+
+	if unserved, err := discoverer.FindUnservedGVRs(nil); err != nil || unserved != nil {
+		t.Errorf("Expected no check without GVRs, got %v, %v", unserved, err)
+	}
+}
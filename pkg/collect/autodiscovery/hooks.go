@@ -0,0 +1,338 @@
+package autodiscovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"plugin"
+	"time"
+)
+
+// Hook stages
+const (
+	HookStagePreFilter  = "preFilter"
+	HookStagePostExpand = "postExpand"
+)
+
+// DefaultExecHookTimeout bounds an exec hook that has no timeout configured
+const DefaultExecHookTimeout = 30 * time.Second
+
+// PreFilterHook adjusts the scanned resources before RBAC filtering and expansion
+// Returning a shorter slice drops resources; a hook error fails discovery
+type PreFilterHook interface {
+	Name() string
+	PreFilter(ctx context.Context, resources []Resource) ([]Resource, error)
+}
+
+// PostExpandHook adjusts the generated collectors before they are sorted and returned
+type PostExpandHook interface {
+	Name() string
+	PostExpand(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error)
+}
+
+// HookConfig declares an exec or Go plugin hook in the config file
+type HookConfig struct {
+	Name    string        `json:"name" yaml:"name"`
+	Stage   string        `json:"stage" yaml:"stage"`                         // "preFilter" or "postExpand"
+	Command []string      `json:"command,omitempty" yaml:"command,omitempty"` // Reads JSON from stdin and writes the adjusted JSON to stdout
+	Plugin  string        `json:"plugin,omitempty" yaml:"plugin,omitempty"`   // Go plugin exporting PreFilterHook or PostExpandHook
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Exec hooks only, 0 uses DefaultExecHookTimeout
+}
+
+// Validate checks that the hook has a known stage and exactly one implementation
+func (h HookConfig) Validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("hook name is required")
+	}
+	if h.Stage != HookStagePreFilter && h.Stage != HookStagePostExpand {
+		return fmt.Errorf("hook %s: invalid stage %q (valid: %s, %s)", h.Name, h.Stage, HookStagePreFilter, HookStagePostExpand)
+	}
+	if (len(h.Command) == 0) == (h.Plugin == "") {
+		return fmt.Errorf("hook %s: exactly one of command or plugin must be set", h.Name)
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("hook %s: timeout cannot be negative", h.Name)
+	}
+	return nil
+}
+
+// preFilterFunc adapts a function to PreFilterHook
+type preFilterFunc struct {
+	name string
+	fn   func(ctx context.Context, resources []Resource) ([]Resource, error)
+}
+
+// NewPreFilterHook creates a PreFilterHook from a function
+func NewPreFilterHook(name string, fn func(ctx context.Context, resources []Resource) ([]Resource, error)) PreFilterHook {
+	return &preFilterFunc{name: name, fn: fn}
+}
+
+func (h *preFilterFunc) Name() string { return h.name }
+
+func (h *preFilterFunc) PreFilter(ctx context.Context, resources []Resource) ([]Resource, error) {
+	return h.fn(ctx, resources)
+}
+
+// postExpandFunc adapts a function to PostExpandHook
+type postExpandFunc struct {
+	name string
+	fn   func(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error)
+}
+
+// NewPostExpandHook creates a PostExpandHook from a function
+func NewPostExpandHook(name string, fn func(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error)) PostExpandHook {
+	return &postExpandFunc{name: name, fn: fn}
+}
+
+func (h *postExpandFunc) Name() string { return h.name }
+
+func (h *postExpandFunc) PostExpand(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error) {
+	return h.fn(ctx, collectors)
+}
+
+// ExecHook runs an external command as a hook
+// The command receives the resources or collectors as a JSON array on stdin and must print
+// the adjusted JSON array on stdout; a non-zero exit fails the hook
+type ExecHook struct {
+	name    string
+	command []string
+	timeout time.Duration
+}
+
+// NewExecHook creates an exec hook, a zero timeout uses DefaultExecHookTimeout
+func NewExecHook(name string, command []string, timeout time.Duration) *ExecHook {
+	if timeout == 0 {
+		timeout = DefaultExecHookTimeout
+	}
+	return &ExecHook{
+		name:    name,
+		command: command,
+		timeout: timeout,
+	}
+}
+
+// Name returns the hook name
+func (h *ExecHook) Name() string { return h.name }
+
+// PreFilter runs the command on the scanned resources
+func (h *ExecHook) PreFilter(ctx context.Context, resources []Resource) ([]Resource, error) {
+	var result []Resource
+	if err := h.run(ctx, resources, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PostExpand runs the command on the generated collectors
+func (h *ExecHook) PostExpand(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error) {
+	var result []CollectorSpec
+	if err := h.run(ctx, collectors, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (h *ExecHook) run(ctx context.Context, input interface{}, output interface{}) error {
+	if len(h.command) == 0 {
+		return fmt.Errorf("no command configured")
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook input: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.command[0], h.command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("command failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return fmt.Errorf("command failed: %w", err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		return fmt.Errorf("failed to decode hook output: %w", err)
+	}
+	return nil
+}
+
+// LoadPluginHook opens a Go plugin and looks up the hook for the given stage
+// The plugin must export a variable named PreFilterHook or PostExpandHook implementing the matching interface
+func LoadPluginHook(path, stage string) (interface{}, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin %s: %w", path, err)
+	}
+
+	switch stage {
+	case HookStagePreFilter:
+		symbol, err := p.Lookup("PreFilterHook")
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", path, err)
+		}
+		if hook, ok := symbol.(*PreFilterHook); ok {
+			return *hook, nil
+		}
+		if hook, ok := symbol.(PreFilterHook); ok {
+			return hook, nil
+		}
+		return nil, fmt.Errorf("plugin %s: PreFilterHook does not implement PreFilterHook", path)
+	case HookStagePostExpand:
+		symbol, err := p.Lookup("PostExpandHook")
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", path, err)
+		}
+		if hook, ok := symbol.(*PostExpandHook); ok {
+			return *hook, nil
+		}
+		if hook, ok := symbol.(PostExpandHook); ok {
+			return hook, nil
+		}
+		return nil, fmt.Errorf("plugin %s: PostExpandHook does not implement PostExpandHook", path)
+	default:
+		return nil, fmt.Errorf("invalid hook stage %q", stage)
+	}
+}
+
+// BuildHooks creates the hooks declared in the config, in declaration order
+func BuildHooks(configs []HookConfig) ([]PreFilterHook, []PostExpandHook, error) {
+	var preFilter []PreFilterHook
+	var postExpand []PostExpandHook
+
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			return nil, nil, withKind(ErrSpecInvalid, err)
+		}
+
+		var hook interface{}
+		if config.Plugin != "" {
+			loaded, err := LoadPluginHook(config.Plugin, config.Stage)
+			if err != nil {
+				return nil, nil, fmt.Errorf("hook %s: %w", config.Name, err)
+			}
+			hook = loaded
+		} else {
+			hook = NewExecHook(config.Name, config.Command, config.Timeout)
+		}
+
+		switch config.Stage {
+		case HookStagePreFilter:
+			preFilter = append(preFilter, hook.(PreFilterHook))
+		case HookStagePostExpand:
+			postExpand = append(postExpand, hook.(PostExpandHook))
+		}
+	}
+
+	return preFilter, postExpand, nil
+}
+
+// RegisterPreFilterHook adds a hook that runs on scanned resources, in registration order
+func (d *Discoverer) RegisterPreFilterHook(hook PreFilterHook) {
+	d.preFilterHooks = append(d.preFilterHooks, hook)
+}
+
+// RegisterPostExpandHook adds a hook that runs on generated collectors, in registration order
+func (d *Discoverer) RegisterPostExpandHook(hook PostExpandHook) {
+	d.postExpandHooks = append(d.postExpandHooks, hook)
+}
+
+// RegisterHooks builds the configured hooks and registers them
+func (d *Discoverer) RegisterHooks(configs []HookConfig) error {
+	preFilter, postExpand, err := BuildHooks(configs)
+	if err != nil {
+		return err
+	}
+	for _, hook := range preFilter {
+		d.RegisterPreFilterHook(hook)
+	}
+	for _, hook := range postExpand {
+		d.RegisterPostExpandHook(hook)
+	}
+	return nil
+}
+
+// ResourceFilterHook is a built-in PreFilterHook applying include and exclude filter rules, e.g. from the
+// --include and --exclude patterns. Resources matching any exclude rule are dropped; when there are include
+// rules, only resources matching at least one of them are kept
+type ResourceFilterHook struct {
+	name     string
+	includes []ResourceFilterRule
+	excludes []ResourceFilterRule
+	matcher  *ConfigManager
+}
+
+// NewResourceFilterHook creates a ResourceFilterHook for the rules, their Action picks include or exclude
+func NewResourceFilterHook(name string, rules []ResourceFilterRule) *ResourceFilterHook {
+	hook := &ResourceFilterHook{name: name, matcher: NewConfigManager()}
+	for _, rule := range rules {
+		if rule.Action == "include" {
+			hook.includes = append(hook.includes, rule)
+		} else {
+			hook.excludes = append(hook.excludes, rule)
+		}
+	}
+	return hook
+}
+
+// Name returns the hook name used in errors
+func (h *ResourceFilterHook) Name() string {
+	return h.name
+}
+
+// PreFilter drops the excluded resources and, with include rules, those none of them match
+func (h *ResourceFilterHook) PreFilter(ctx context.Context, resources []Resource) ([]Resource, error) {
+	filtered := make([]Resource, 0, len(resources))
+	for _, resource := range resources {
+		if h.matchesAny(resource, h.excludes) {
+			continue
+		}
+		if len(h.includes) > 0 && !h.matchesAny(resource, h.includes) {
+			continue
+		}
+		filtered = append(filtered, resource)
+	}
+	return filtered, nil
+}
+
+func (h *ResourceFilterHook) matchesAny(resource Resource, rules []ResourceFilterRule) bool {
+	for _, rule := range rules {
+		if h.matcher.resourceMatchesFilter(resource, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// runPreFilterHooks passes the resources through every pre-filter hook
+func (d *Discoverer) runPreFilterHooks(ctx context.Context, resources []Resource) ([]Resource, error) {
+	for _, hook := range d.preFilterHooks {
+		filtered, err := hook.PreFilter(ctx, resources)
+		if err != nil {
+			return nil, fmt.Errorf("pre-filter hook %s failed: %w", hook.Name(), err)
+		}
+		resources = filtered
+	}
+	return resources, nil
+}
+
+// runPostExpandHooks passes the collectors through every post-expand hook
+func (d *Discoverer) runPostExpandHooks(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error) {
+	for _, hook := range d.postExpandHooks {
+		adjusted, err := hook.PostExpand(ctx, collectors)
+		if err != nil {
+			return nil, fmt.Errorf("post-expand hook %s failed: %w", hook.Name(), err)
+		}
+		collectors = adjusted
+	}
+	return collectors, nil
+}
@@ -0,0 +1,217 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testService(name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "default",
+				"labels":    labels,
+			},
+		},
+	}
+}
+
+func TestDiscoverer_Hooks(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	dynamicClient := createTestDynamicClient(
+		testService("payments", map[string]interface{}{"pci": "true"}),
+		testService("frontend", map[string]interface{}{"app": "frontend"}),
+	)
+
+	discoverer := &Discoverer{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		rbacChecker:   NewRBACChecker(kubeClient),
+		nsScanner:     NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:      NewResourceExpanderWithDependencies(dynamicClient, 1),
+	}
+
+	var seen []string
+	discoverer.RegisterPreFilterHook(NewPreFilterHook("drop-pci", func(ctx context.Context, resources []Resource) ([]Resource, error) {
+		var kept []Resource
+		for _, resource := range resources {
+			if resource.Labels["pci"] != "true" {
+				kept = append(kept, resource)
+			}
+		}
+		return kept, nil
+	}))
+	discoverer.RegisterPreFilterHook(NewPreFilterHook("record", func(ctx context.Context, resources []Resource) ([]Resource, error) {
+		for _, resource := range resources {
+			seen = append(seen, resource.Name)
+		}
+		return resources, nil
+	}))
+	discoverer.RegisterPostExpandHook(NewPostExpandHook("add-vendor", func(ctx context.Context, collectors []CollectorSpec) ([]CollectorSpec, error) {
+		return append(collectors, CollectorSpec{Type: "cluster-info", Name: "vendor-info", Priority: int(PriorityLow)}), nil
+	}))
+
+	collectors, err := discoverer.Discover(context.Background(), DiscoveryOptions{Namespaces: []string{"default"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, name := range seen {
+		if name == "payments" {
+			t.Errorf("Expected pci resources to be dropped before later hooks, got %v", seen)
+		}
+	}
+	if len(seen) == 0 {
+		t.Errorf("Expected the second pre-filter hook to see the remaining resources")
+	}
+
+	found := false
+	for _, collector := range collectors {
+		if collector.Name == "vendor-info" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected collector added by post-expand hook, got %v", collectors)
+	}
+}
+
+func TestDiscoverer_HookError(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	dynamicClient := createTestDynamicClient(testService("frontend", nil))
+
+	discoverer := &Discoverer{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		rbacChecker:   NewRBACChecker(kubeClient),
+		nsScanner:     NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:      NewResourceExpanderWithDependencies(dynamicClient, 1),
+	}
+	discoverer.RegisterPreFilterHook(NewPreFilterHook("broken", func(ctx context.Context, resources []Resource) ([]Resource, error) {
+		return nil, fmt.Errorf("policy service unavailable")
+	}))
+
+	_, err := discoverer.Discover(context.Background(), DiscoveryOptions{Namespaces: []string{"default"}})
+	if err == nil || !strings.Contains(err.Error(), "pre-filter hook broken failed") {
+		t.Errorf("Expected pre-filter hook error, got %v", err)
+	}
+}
+
+func TestExecHook(t *testing.T) {
+	resources := []Resource{
+		{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Namespace: "default", Name: "app"},
+	}
+
+	tests := []struct {
+		name        string
+		command     []string
+		timeout     time.Duration
+		expectCount int
+		expectError string
+	}{
+		{
+			name:        "passthrough",
+			command:     []string{"cat"},
+			expectCount: 1,
+		},
+		{
+			name:        "drop all",
+			command:     []string{"sh", "-c", "cat >/dev/null; echo '[]'"},
+			expectCount: 0,
+		},
+		{
+			name:        "non-zero exit",
+			command:     []string{"sh", "-c", "echo denied >&2; exit 1"},
+			expectError: "denied",
+		},
+		{
+			name:        "invalid output",
+			command:     []string{"sh", "-c", "echo not-json"},
+			expectError: "failed to decode hook output",
+		},
+		{
+			name:        "timeout",
+			command:     []string{"sleep", "5"},
+			timeout:     100 * time.Millisecond,
+			expectError: "command failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := NewExecHook(tt.name, tt.command, tt.timeout)
+			result, err := hook.PreFilter(context.Background(), resources)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(result) != tt.expectCount {
+				t.Errorf("Expected %d resources, got %d", tt.expectCount, len(result))
+			}
+		})
+	}
+}
+
+func TestHookConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      HookConfig
+		expectError bool
+	}{
+		{"valid exec hook", HookConfig{Name: "a", Stage: HookStagePreFilter, Command: []string{"true"}}, false},
+		{"valid plugin hook", HookConfig{Name: "a", Stage: HookStagePostExpand, Plugin: "hook.so"}, false},
+		{"missing name", HookConfig{Stage: HookStagePreFilter, Command: []string{"true"}}, true},
+		{"invalid stage", HookConfig{Name: "a", Stage: "postFilter", Command: []string{"true"}}, true},
+		{"command and plugin", HookConfig{Name: "a", Stage: HookStagePreFilter, Command: []string{"true"}, Plugin: "hook.so"}, true},
+		{"neither command nor plugin", HookConfig{Name: "a", Stage: HookStagePreFilter}, true},
+		{"negative timeout", HookConfig{Name: "a", Stage: HookStagePreFilter, Command: []string{"true"}, Timeout: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildHooks(t *testing.T) {
+	preFilter, postExpand, err := BuildHooks([]HookConfig{
+		{Name: "filter", Stage: HookStagePreFilter, Command: []string{"cat"}},
+		{Name: "expand", Stage: HookStagePostExpand, Command: []string{"cat"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(preFilter) != 1 || preFilter[0].Name() != "filter" {
+		t.Errorf("Expected pre-filter hook filter, got %v", preFilter)
+	}
+	if len(postExpand) != 1 || postExpand[0].Name() != "expand" {
+		t.Errorf("Expected post-expand hook expand, got %v", postExpand)
+	}
+
+	if _, _, err := BuildHooks([]HookConfig{{Name: "missing", Stage: HookStagePreFilter, Plugin: "/nonexistent/hook.so"}}); err == nil {
+		t.Errorf("Expected error for a missing plugin")
+	}
+}
@@ -0,0 +1,319 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultHotPodsPerNamespace is how many of the hottest pods per namespace get targeted collectors
+const DefaultHotPodsPerNamespace = 3
+
+var podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// HotPods targets the pods most likely to be the culprit: ranked per namespace by CPU and memory use from the
+// metrics API, when it is served, and by restart count
+type HotPods struct {
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"` // Add no hot pod collectors
+	TopN     int  `json:"topN,omitempty" yaml:"topN,omitempty"`         // Pods targeted per namespace, 0 uses DefaultHotPodsPerNamespace
+	SkipExec bool `json:"skipExec,omitempty" yaml:"skipExec,omitempty"` // Collect only logs, no process listing
+}
+
+// WithOverrides returns the hot pod settings with every set override applied
+func (h HotPods) WithOverrides(overrides HotPods) HotPods {
+	if overrides.Disabled {
+		h.Disabled = overrides.Disabled
+	}
+	if overrides.TopN > 0 {
+		h.TopN = overrides.TopN
+	}
+	if overrides.SkipExec {
+		h.SkipExec = overrides.SkipExec
+	}
+	return h
+}
+
+// Validate checks that the number of pods per namespace is not negative
+func (h HotPods) Validate() error {
+	if h.TopN < 0 {
+		return fmt.Errorf("topN cannot be negative")
+	}
+	return nil
+}
+
+func (h HotPods) topN() int {
+	if h.TopN > 0 {
+		return h.TopN
+	}
+	return DefaultHotPodsPerNamespace
+}
+
+// HotPod is one pod ranked among the hottest of its namespace
+type HotPod struct {
+	Namespace     string   `json:"namespace"`
+	Name          string   `json:"name"`
+	CPUMillicores int64    `json:"cpuMillicores,omitempty"`
+	MemoryBytes   int64    `json:"memoryBytes,omitempty"`
+	Restarts      int64    `json:"restarts,omitempty"`
+	Score         float64  `json:"score"`             // Sum of CPU, memory and restarts, each relative to the namespace's highest
+	Reasons       []string `json:"reasons,omitempty"` // Measures the pod leads its namespace on
+}
+
+// HotPodsReport is the ranking behind the hot pod collectors, written to hot-pods/summary.json
+type HotPodsReport struct {
+	MetricsAvailable bool                `json:"metricsAvailable"` // False ranks by restarts alone
+	TopN             int                 `json:"topN"`
+	Namespaces       map[string][]HotPod `json:"namespaces"`
+	Problems         []string            `json:"problems,omitempty"`
+}
+
+// HotPodRanker ranks the discovered pods of each namespace and adds targeted collectors for the hottest
+type HotPodRanker struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewHotPodRanker creates a new HotPodRanker
+func NewHotPodRanker(dynamicClient dynamic.Interface) *HotPodRanker {
+	return &HotPodRanker{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateHotPodCollectors returns high priority logs and exec collectors for the top pods of each namespace,
+// plus the ranking; without served metrics pods are ranked by restarts and pods that never restarted are left out
+func (h *HotPodRanker) GenerateHotPodCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions, metricsServed bool) []CollectorSpec {
+	settings := opts.HotPods
+	if settings.Disabled {
+		return nil
+	}
+
+	pods := make(map[string]map[string]bool)
+	for _, res := range resources {
+		if res.GVR != podsGVR || res.Namespace == "" || IsProtectedNamespace(opts.ProtectedNamespaces, res.Namespace) {
+			continue
+		}
+		if pods[res.Namespace] == nil {
+			pods[res.Namespace] = make(map[string]bool)
+		}
+		pods[res.Namespace][res.Name] = true
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+
+	report := h.BuildReport(ctx, pods, settings.topN(), metricsServed)
+	var collectors []CollectorSpec
+	namespaces := make([]string, 0, len(report.Namespaces))
+	for namespace := range report.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		for _, pod := range report.Namespaces[namespace] {
+			collectors = append(collectors, hotPodCollectors(pod, settings)...)
+		}
+	}
+	if len(collectors) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return collectors
+	}
+	return append(collectors, CollectorSpec{
+		Type:     "data",
+		Name:     "auto-hot-pods-summary",
+		Group:    CollectorGroupWorkloads,
+		Priority: int(PriorityHigh),
+		Parameters: map[string]interface{}{
+			"name": "hot-pods/summary.json",
+			"data": string(data),
+		},
+	})
+}
+
+// hotPodCollectors targets one pod's logs and, unless skipped, its process list
+func hotPodCollectors(pod HotPod, settings HotPods) []CollectorSpec {
+	collectors := []CollectorSpec{{
+		Type:      CollectorTypeLogs,
+		Name:      fmt.Sprintf("auto-logs-hot-%s", pod.Name),
+		Namespace: pod.Namespace,
+		Priority:  int(PriorityHigh),
+		Parameters: LogsParams{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Limits:    &LogsLimits{MaxAge: "24h", MaxLines: 5000},
+		}.ToMap(),
+	}}
+	if settings.SkipExec {
+		return collectors
+	}
+	return append(collectors, CollectorSpec{
+		Type:      CollectorTypeExec,
+		Name:      fmt.Sprintf("auto-exec-hot-%s", pod.Name),
+		Namespace: pod.Namespace,
+		Priority:  int(PriorityHigh),
+		Parameters: map[string]interface{}{
+			"name":      pod.Name,
+			"namespace": pod.Namespace,
+			"container": "",
+			"command":   []string{"ps", "aux"},
+			"timeout":   defaultExecTimeout,
+		},
+	})
+}
+
+// BuildReport ranks the given pods of each namespace and keeps the top n, listing failures as problems
+func (h *HotPodRanker) BuildReport(ctx context.Context, pods map[string]map[string]bool, n int, metricsServed bool) *HotPodsReport {
+	report := &HotPodsReport{MetricsAvailable: metricsServed, TopN: n, Namespaces: make(map[string][]HotPod)}
+	if !metricsServed {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is not served, ranking by restarts only", podMetricsGVR.GroupVersion()))
+	}
+
+	namespaces := make([]string, 0, len(pods))
+	for namespace := range pods {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		candidates, err := h.podRestarts(ctx, namespace, pods[namespace])
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to list pods in %s: %v", namespace, err))
+			continue
+		}
+		if report.MetricsAvailable {
+			if err := h.addPodMetrics(ctx, namespace, candidates); err != nil {
+				// metrics-server is missing or down for the whole cluster, not just this namespace
+				report.MetricsAvailable = false
+				report.Problems = append(report.Problems, fmt.Sprintf("pod metrics unavailable, ranking by restarts only: %v", err))
+			}
+		}
+		if hot := rankHotPods(candidates, n); len(hot) > 0 {
+			report.Namespaces[namespace] = hot
+		}
+	}
+	return report
+}
+
+// podRestarts returns the discovered pods of a namespace with their total container restarts
+func (h *HotPodRanker) podRestarts(ctx context.Context, namespace string, discovered map[string]bool) (map[string]*HotPod, error) {
+	list, err := h.dynamicClient.Resource(podsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[string]*HotPod)
+	for _, item := range list.Items {
+		if !discovered[item.GetName()] {
+			continue
+		}
+		pod := &HotPod{Namespace: namespace, Name: item.GetName()}
+		statuses, _, _ := unstructured.NestedSlice(item.Object, "status", "containerStatuses")
+		for _, status := range statuses {
+			if s, ok := status.(map[string]interface{}); ok {
+				restarts, _, _ := unstructured.NestedInt64(s, "restartCount")
+				pod.Restarts += restarts
+			}
+		}
+		candidates[pod.Name] = pod
+	}
+	return candidates, nil
+}
+
+// addPodMetrics sums the CPU and memory usage of the containers of each candidate
+func (h *HotPodRanker) addPodMetrics(ctx context.Context, namespace string, candidates map[string]*HotPod) error {
+	list, err := h.dynamicClient.Resource(podMetricsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, item := range list.Items {
+		pod, ok := candidates[item.GetName()]
+		if !ok {
+			continue
+		}
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		for _, container := range containers {
+			c, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if cpu, ok, _ := unstructured.NestedString(c, "usage", "cpu"); ok {
+				if q, err := resource.ParseQuantity(cpu); err == nil {
+					pod.CPUMillicores += q.MilliValue()
+				}
+			}
+			if memory, ok, _ := unstructured.NestedString(c, "usage", "memory"); ok {
+				if q, err := resource.ParseQuantity(memory); err == nil {
+					pod.MemoryBytes += q.Value()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// rankHotPods scores each pod by its CPU, memory and restarts relative to the highest in the namespace and
+// returns the top n with a score above zero, highest first
+func rankHotPods(candidates map[string]*HotPod, n int) []HotPod {
+	var maxCPU, maxMemory, maxRestarts int64
+	for _, pod := range candidates {
+		maxCPU = max(maxCPU, pod.CPUMillicores)
+		maxMemory = max(maxMemory, pod.MemoryBytes)
+		maxRestarts = max(maxRestarts, pod.Restarts)
+	}
+
+	ranked := make([]HotPod, 0, len(candidates))
+	for _, pod := range candidates {
+		hot := *pod
+		hot.Reasons = nil
+		if maxCPU > 0 {
+			hot.Score += float64(pod.CPUMillicores) / float64(maxCPU)
+			if pod.CPUMillicores == maxCPU {
+				hot.Reasons = append(hot.Reasons, fmt.Sprintf("highest CPU (%dm)", pod.CPUMillicores))
+			}
+		}
+		if maxMemory > 0 {
+			hot.Score += float64(pod.MemoryBytes) / float64(maxMemory)
+			if pod.MemoryBytes == maxMemory {
+				hot.Reasons = append(hot.Reasons, fmt.Sprintf("highest memory (%s)", resource.NewQuantity(pod.MemoryBytes, resource.BinarySI)))
+			}
+		}
+		if maxRestarts > 0 {
+			hot.Score += float64(pod.Restarts) / float64(maxRestarts)
+			if pod.Restarts == maxRestarts {
+				hot.Reasons = append(hot.Reasons, fmt.Sprintf("most restarts (%d)", pod.Restarts))
+			}
+		}
+		if hot.Score > 0 {
+			ranked = append(ranked, hot)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// podMetricsServed reports whether the cluster serves pod metrics, an unavailable metrics-server is not queried
+func (d *Discoverer) podMetricsServed() bool {
+	if d.kubeClient == nil {
+		return false
+	}
+	unserved, err := d.FindUnservedGVRs([]schema.GroupVersionResource{podMetricsGVR})
+	return err == nil && len(unserved) == 0
+}
@@ -0,0 +1,162 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func testHotPod(namespace, name string, restarts ...int64) *unstructured.Unstructured {
+	statuses := []interface{}{}
+	for i, count := range restarts {
+		statuses = append(statuses, map[string]interface{}{"name": fmt.Sprintf("c%d", i), "restartCount": count})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"status":     map[string]interface{}{"containerStatuses": statuses},
+	}}
+}
+
+func testPodMetrics(namespace, name, cpu, memory string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "usage": map[string]interface{}{"cpu": cpu, "memory": memory}},
+		},
+	}}
+}
+
+// newHotPodsTestClient creates a client serving the pods, and the metrics under metrics.k8s.io/pods rather than
+// the podmetrics resource the fake tracker would guess from their kind
+// The scheme registers no types so the unstructured pods are listed as they are, not converted to v1.Pod
+func newHotPodsTestClient(t *testing.T, pods []runtime.Object, metrics ...*unstructured.Unstructured) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		podsGVR:       "PodList",
+		podMetricsGVR: "PodMetricsList",
+	}, pods...)
+	for _, m := range metrics {
+		if _, err := client.Resource(podMetricsGVR).Namespace(m.GetNamespace()).Create(context.Background(), m, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create pod metrics: %v", err)
+		}
+	}
+	return client
+}
+
+func hotPodResources(namespace string, names ...string) []Resource {
+	var resources []Resource
+	for _, name := range names {
+		resources = append(resources, Resource{GVR: podsGVR, Namespace: namespace, Name: name})
+	}
+	return resources
+}
+
+func TestHotPodRanker_GenerateHotPodCollectors(t *testing.T) {
+	client := newHotPodsTestClient(t,
+		[]runtime.Object{
+			testHotPod("shop", "api-1", 0),
+			testHotPod("shop", "api-2", 7, 1),
+			testHotPod("shop", "web-1", 0),
+			testHotPod("shop", "idle-1", 0),
+			testHotPod("shop", "undiscovered", 50),
+		},
+		testPodMetrics("shop", "api-1", "900m", "256Mi"),
+		testPodMetrics("shop", "api-2", "100m", "1Gi"),
+		testPodMetrics("shop", "web-1", "50m", "64Mi"),
+		testPodMetrics("shop", "idle-1", "0", "0"),
+	)
+	ranker := NewHotPodRanker(client)
+
+	resources := hotPodResources("shop", "api-1", "api-2", "web-1", "idle-1")
+	collectors := ranker.GenerateHotPodCollectors(context.Background(), resources, DiscoveryOptions{HotPods: HotPods{TopN: 2}}, true)
+
+	var names []string
+	var summary CollectorSpec
+	for _, collector := range collectors {
+		names = append(names, collector.Name)
+		if collector.Priority != int(PriorityHigh) {
+			t.Errorf("Expected %s at high priority, got %d", collector.Name, collector.Priority)
+		}
+		if collector.Name == "auto-hot-pods-summary" {
+			summary = collector
+		}
+	}
+	expected := []string{"auto-logs-hot-api-2", "auto-exec-hot-api-2", "auto-logs-hot-api-1", "auto-exec-hot-api-1", "auto-hot-pods-summary"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected collectors %v, got %v", expected, names)
+	}
+
+	params, err := collectors[0].LogsParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Name != "api-2" || params.Namespace != "shop" {
+		t.Errorf("Expected logs targeted at shop/api-2, got %+v", params)
+	}
+
+	var report HotPodsReport
+	if err := json.Unmarshal([]byte(summary.Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse hot pods summary: %v", err)
+	}
+	hot := report.Namespaces["shop"]
+	if !report.MetricsAvailable || len(hot) != 2 {
+		t.Fatalf("Expected 2 hot pods ranked with metrics, got %+v", report)
+	}
+	if hot[0].Restarts != 8 || hot[0].MemoryBytes != 1<<30 || !reflect.DeepEqual(hot[0].Reasons, []string{"highest memory (1Gi)", "most restarts (8)"}) {
+		t.Errorf("Expected api-2 first for its memory and restarts, got %+v", hot[0])
+	}
+	if hot[1].CPUMillicores != 900 || !reflect.DeepEqual(hot[1].Reasons, []string{"highest CPU (900m)"}) {
+		t.Errorf("Expected api-1 second for its CPU, got %+v", hot[1])
+	}
+}
+
+func TestHotPodRanker_WithoutMetrics(t *testing.T) {
+	client := newHotPodsTestClient(t, []runtime.Object{
+		testHotPod("shop", "api-1", 0),
+		testHotPod("shop", "api-2", 3),
+	})
+	client.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Group == podMetricsGVR.Group {
+			return true, nil, fmt.Errorf("the server could not find the requested resource")
+		}
+		return false, nil, nil
+	})
+	ranker := NewHotPodRanker(client)
+
+	pods := map[string]map[string]bool{"shop": {"api-1": true, "api-2": true}}
+	for _, served := range []bool{true, false} {
+		report := ranker.BuildReport(context.Background(), pods, 3, served)
+		if report.MetricsAvailable || len(report.Problems) != 1 {
+			t.Errorf("Expected the metrics API to be reported unavailable, got %+v", report)
+		}
+		if hot := report.Namespaces["shop"]; len(hot) != 1 || hot[0].Name != "api-2" {
+			t.Errorf("Expected only the restarted pod, got %+v", hot)
+		}
+	}
+
+	resources := hotPodResources("shop", "api-1", "api-2")
+	if collectors := ranker.GenerateHotPodCollectors(context.Background(), resources, DiscoveryOptions{HotPods: HotPods{Disabled: true}}, false); len(collectors) != 0 {
+		t.Errorf("Expected no collectors when disabled, got %d", len(collectors))
+	}
+	collectors := ranker.GenerateHotPodCollectors(context.Background(), resources, DiscoveryOptions{HotPods: HotPods{SkipExec: true}}, false)
+	for _, collector := range collectors {
+		if collector.Type == CollectorTypeExec {
+			t.Errorf("Expected no exec collectors with skipExec, got %s", collector.Name)
+		}
+	}
+	if collectors := ranker.GenerateHotPodCollectors(context.Background(), resources, DiscoveryOptions{ProtectedNamespaces: []string{"shop"}}, false); len(collectors) != 0 {
+		t.Errorf("Expected protected namespaces to be skipped, got %d collectors", len(collectors))
+	}
+}
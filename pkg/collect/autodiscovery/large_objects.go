@@ -0,0 +1,275 @@
+package autodiscovery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLargeObjectMaxSize is the content size, in bytes, above which ConfigMaps and Secrets are captured as
+// metadata, keys and sizes only
+const DefaultLargeObjectMaxSize = 256 * 1024
+
+// LargeObjectSkippedAnnotation is added to trimmed objects, its value is the JSON encoded SkippedContent
+const LargeObjectSkippedAnnotation = "troubleshoot.sh/skipped-content"
+
+// largeObjectResources are the resources whose content is trimmed, by the encoding of their data field
+var largeObjectResources = map[string]bool{
+	"configmaps": false,
+	"secrets":    true, // data values are base64 encoded
+}
+
+// LargeObjects bounds the ConfigMap and Secret content captured in bundles, e.g. CA bundles or jar dumps
+// stored in a ConfigMap. Objects above MaxSize keep their metadata and the keys in AlwaysCaptureKeys, the
+// other keys are replaced by their sizes
+type LargeObjects struct {
+	Disabled          bool     `json:"disabled,omitempty" yaml:"disabled,omitempty"`                   // Capture every object in full
+	MaxSize           int      `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`                     // Bytes of data and binaryData, 0 uses DefaultLargeObjectMaxSize
+	AlwaysCaptureKeys []string `json:"alwaysCaptureKeys,omitempty" yaml:"alwaysCaptureKeys,omitempty"` // Key globs captured in full regardless of size, e.g. *.properties
+}
+
+// WithOverrides returns the limits with every set override applied
+func (l LargeObjects) WithOverrides(overrides LargeObjects) LargeObjects {
+	if overrides.Disabled {
+		l.Disabled = overrides.Disabled
+	}
+	if overrides.MaxSize > 0 {
+		l.MaxSize = overrides.MaxSize
+	}
+	if len(overrides.AlwaysCaptureKeys) > 0 {
+		l.AlwaysCaptureKeys = overrides.AlwaysCaptureKeys
+	}
+	return l
+}
+
+// Validate checks that the size is not negative and the key globs are valid
+func (l LargeObjects) Validate() error {
+	if l.MaxSize < 0 {
+		return fmt.Errorf("maxSize cannot be negative")
+	}
+	for _, glob := range l.AlwaysCaptureKeys {
+		if glob == "" {
+			return fmt.Errorf("alwaysCaptureKeys cannot contain an empty key")
+		}
+		if err := ValidateGlob(glob); err != nil {
+			return fmt.Errorf("invalid alwaysCaptureKeys glob: %w", err)
+		}
+	}
+	return nil
+}
+
+func (l LargeObjects) maxSize() int {
+	if l.MaxSize > 0 {
+		return l.MaxSize
+	}
+	return DefaultLargeObjectMaxSize
+}
+
+// applyLargeObjectLimits sets the size limit and always captured keys on every ConfigMap and Secret
+// cluster-resources collector, runners trim the objects they write with TrimLargeObjects
+func applyLargeObjectLimits(collectors []CollectorSpec, limits LargeObjects) []CollectorSpec {
+	if limits.Disabled {
+		return collectors
+	}
+
+	for i, collector := range collectors {
+		if collector.Type != CollectorTypeClusterResources {
+			continue
+		}
+		params, err := collector.ClusterResourcesParams()
+		if err != nil {
+			continue
+		}
+		if _, ok := largeObjectResources[params.Resource]; !ok || params.Group != "" {
+			continue
+		}
+		params.MaxObjectSize = limits.maxSize()
+		params.AlwaysCaptureKeys = limits.AlwaysCaptureKeys
+		collectors[i].Parameters = params.ToMap()
+	}
+	return collectors
+}
+
+// SkippedContent describes the content left out of a trimmed object
+type SkippedContent struct {
+	Size      int            `json:"size"`      // Bytes of data and binaryData before trimming
+	Threshold int            `json:"threshold"` // The limit the object exceeded
+	Keys      map[string]int `json:"keys"`      // Size of each key left out
+}
+
+// SkippedObject is a ConfigMap or Secret captured as metadata, keys and sizes only
+type SkippedObject struct {
+	Collector string `json:"collector"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	SkippedContent
+}
+
+// LargeObjectTrimmer trims oversized ConfigMaps and Secrets in the files each collector writes
+type LargeObjectTrimmer struct {
+	mu      sync.Mutex
+	skipped []SkippedObject
+}
+
+// NewLargeObjectTrimmer creates a LargeObjectTrimmer
+func NewLargeObjectTrimmer() *LargeObjectTrimmer {
+	return &LargeObjectTrimmer{}
+}
+
+// Trim rewrites the JSON output files of a ConfigMap or Secret collector, relative to outputDir, replacing the
+// content of objects above the collector's maxObjectSize. It returns the number of objects trimmed
+// Collectors without a size limit and files that are not JSON objects or lists are left untouched
+func (t *LargeObjectTrimmer) Trim(collector CollectorSpec, outputDir string, outputs []string) (int, error) {
+	if collector.Type != CollectorTypeClusterResources {
+		return 0, nil
+	}
+	params, err := collector.ClusterResourcesParams()
+	if err != nil || params.MaxObjectSize <= 0 {
+		return 0, nil
+	}
+	encoded, ok := largeObjectResources[params.Resource]
+	if !ok {
+		return 0, nil
+	}
+
+	trimmed := 0
+	for _, output := range outputs {
+		file := filepath.Join(outputDir, output)
+		info, err := os.Stat(file)
+		if err != nil {
+			return trimmed, fmt.Errorf("failed to trim %s: %w", output, err)
+		}
+		if info.IsDir() || !strings.HasSuffix(output, ".json") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return trimmed, fmt.Errorf("failed to trim %s: %w", output, err)
+		}
+		var document map[string]interface{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			continue // Not an object or list, e.g. a collector error file
+		}
+
+		objects := []map[string]interface{}{document}
+		if items, ok := document["items"].([]interface{}); ok {
+			objects = objects[:0]
+			for _, item := range items {
+				if object, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, object)
+				}
+			}
+		}
+
+		var skipped []SkippedObject
+		for _, object := range objects {
+			content, ok := trimLargeObject(object, encoded, params.MaxObjectSize, params.AlwaysCaptureKeys)
+			if !ok {
+				continue
+			}
+			metadata, _ := object["metadata"].(map[string]interface{})
+			namespace, _ := metadata["namespace"].(string)
+			name, _ := metadata["name"].(string)
+			skipped = append(skipped, SkippedObject{
+				Collector:      collector.Name,
+				Resource:       params.Resource,
+				Namespace:      namespace,
+				Name:           name,
+				SkippedContent: *content,
+			})
+		}
+		if len(skipped) == 0 {
+			continue
+		}
+
+		rewritten, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return trimmed, fmt.Errorf("failed to trim %s: %w", output, err)
+		}
+		if err := os.WriteFile(file, rewritten, info.Mode().Perm()); err != nil {
+			return trimmed, fmt.Errorf("failed to trim %s: %w", output, err)
+		}
+		trimmed += len(skipped)
+		t.mu.Lock()
+		t.skipped = append(t.skipped, skipped...)
+		t.mu.Unlock()
+	}
+	return trimmed, nil
+}
+
+// Skipped returns the objects trimmed so far, sorted by resource, namespace and name
+func (t *LargeObjectTrimmer) Skipped() []SkippedObject {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	skipped := append([]SkippedObject{}, t.skipped...)
+	sort.Slice(skipped, func(i, j int) bool {
+		if skipped[i].Resource != skipped[j].Resource {
+			return skipped[i].Resource < skipped[j].Resource
+		}
+		if skipped[i].Namespace != skipped[j].Namespace {
+			return skipped[i].Namespace < skipped[j].Namespace
+		}
+		return skipped[i].Name < skipped[j].Name
+	})
+	return skipped
+}
+
+// trimLargeObject drops the keys not in alwaysCapture from an object whose data and binaryData exceed maxSize,
+// and records what was dropped in LargeObjectSkippedAnnotation. encoded is true when data values are base64
+func trimLargeObject(object map[string]interface{}, encoded bool, maxSize int, alwaysCapture []string) (*SkippedContent, bool) {
+	fields := map[string]bool{"data": encoded, "binaryData": true}
+
+	size := 0
+	for field, base64Encoded := range fields {
+		values, _ := object[field].(map[string]interface{})
+		for _, value := range values {
+			size += contentSize(value, base64Encoded)
+		}
+	}
+	if size <= maxSize {
+		return nil, false
+	}
+
+	content := &SkippedContent{Size: size, Threshold: maxSize, Keys: map[string]int{}}
+	for field, base64Encoded := range fields {
+		values, _ := object[field].(map[string]interface{})
+		for key, value := range values {
+			if MatchesAnyGlob(alwaysCapture, key) {
+				continue
+			}
+			content.Keys[key] = contentSize(value, base64Encoded)
+			delete(values, key)
+		}
+	}
+
+	annotation, _ := json.Marshal(content)
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		object["metadata"] = metadata
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	annotations[LargeObjectSkippedAnnotation] = string(annotation)
+	return content, true
+}
+
+// contentSize returns the size in bytes of a data value, decoding base64 values
+func contentSize(value interface{}, base64Encoded bool) int {
+	s, _ := value.(string)
+	if base64Encoded {
+		if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return len(decoded)
+		}
+	}
+	return len(s)
+}
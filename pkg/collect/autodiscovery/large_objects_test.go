@@ -0,0 +1,164 @@
+package autodiscovery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLargeObjects_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      LargeObjects
+		expectError bool
+	}{
+		{name: "defaults"},
+		{name: "valid", limits: LargeObjects{MaxSize: 1024, AlwaysCaptureKeys: []string{"config.yaml", "*.properties"}}},
+		{name: "negative size", limits: LargeObjects{MaxSize: -1}, expectError: true},
+		{name: "empty key", limits: LargeObjects{AlwaysCaptureKeys: []string{""}}, expectError: true},
+		{name: "invalid glob", limits: LargeObjects{AlwaysCaptureKeys: []string{"[a-"}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Validate()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestLargeObjects_WithOverrides(t *testing.T) {
+	base := LargeObjects{MaxSize: 1024, AlwaysCaptureKeys: []string{"ca.crt"}}
+
+	merged := base.WithOverrides(LargeObjects{MaxSize: 4096})
+	if merged.MaxSize != 4096 || len(merged.AlwaysCaptureKeys) != 1 {
+		t.Errorf("Expected the size to be overridden and the keys kept, got %+v", merged)
+	}
+	if merged := base.WithOverrides(LargeObjects{Disabled: true}); !merged.Disabled || merged.MaxSize != 1024 {
+		t.Errorf("Expected only disabled to be overridden, got %+v", merged)
+	}
+}
+
+func TestApplyLargeObjectLimits(t *testing.T) {
+	newCollectors := func() []CollectorSpec {
+		return []CollectorSpec{
+			{Type: CollectorTypeClusterResources, Name: "auto-resources-configmaps", Parameters: ClusterResourcesParams{Version: "v1", Resource: "configmaps"}.ToMap()},
+			{Type: CollectorTypeClusterResources, Name: "auto-resources-secrets", Parameters: ClusterResourcesParams{Version: "v1", Resource: "secrets"}.ToMap()},
+			{Type: CollectorTypeClusterResources, Name: "auto-resources-pods", Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods"}.ToMap()},
+			{Type: CollectorTypeLogs, Name: "auto-logs-app", Parameters: LogsParams{Namespace: "app"}.ToMap()},
+		}
+	}
+
+	collectors := applyLargeObjectLimits(newCollectors(), LargeObjects{AlwaysCaptureKeys: []string{"*.yaml"}})
+	for i, expected := range []int{DefaultLargeObjectMaxSize, DefaultLargeObjectMaxSize} {
+		params, err := collectors[i].ClusterResourcesParams()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if params.MaxObjectSize != expected || len(params.AlwaysCaptureKeys) != 1 {
+			t.Errorf("Expected %s limited to %d bytes keeping *.yaml, got %+v", collectors[i].Name, expected, params)
+		}
+	}
+	if _, ok := collectors[2].Parameters["maxObjectSize"]; ok {
+		t.Errorf("Expected pods to be left unlimited, got %v", collectors[2].Parameters)
+	}
+
+	collectors = applyLargeObjectLimits(newCollectors(), LargeObjects{Disabled: true})
+	if _, ok := collectors[0].Parameters["maxObjectSize"]; ok {
+		t.Errorf("Expected no limit when disabled, got %v", collectors[0].Parameters)
+	}
+}
+
+func TestLargeObjectTrimmer_Trim(t *testing.T) {
+	dir := t.TempDir()
+	bundle := strings.Repeat("-----BEGIN CERTIFICATE-----\n", 100)
+	configMaps := map[string]interface{}{
+		"kind": "ConfigMapList",
+		"items": []interface{}{
+			map[string]interface{}{
+				"kind":     "ConfigMap",
+				"metadata": map[string]interface{}{"name": "trust-bundle", "namespace": "app"},
+				"data":     map[string]interface{}{"ca-bundle.crt": bundle, "config.yaml": "level: debug"},
+			},
+			map[string]interface{}{
+				"kind":     "ConfigMap",
+				"metadata": map[string]interface{}{"name": "small", "namespace": "app"},
+				"data":     map[string]interface{}{"key": "value"},
+			},
+		},
+	}
+	secret := map[string]interface{}{
+		"kind":     "Secret",
+		"metadata": map[string]interface{}{"name": "jar", "namespace": "app"},
+		"data":     map[string]interface{}{"app.jar": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 2000)))},
+	}
+	for name, document := range map[string]interface{}{"configmaps.json": configMaps, "secret.json": secret} {
+		data, _ := json.Marshal(document)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "errors.txt"), []byte("forbidden"), 0644); err != nil {
+		t.Fatalf("Failed to write errors.txt: %v", err)
+	}
+
+	params := ClusterResourcesParams{Version: "v1", Resource: "configmaps", MaxObjectSize: 1024, AlwaysCaptureKeys: []string{"*.yaml"}}
+	collector := CollectorSpec{Type: CollectorTypeClusterResources, Name: "auto-resources-configmaps", Parameters: params.ToMap()}
+	trimmer := NewLargeObjectTrimmer()
+
+	trimmed, err := trimmer.Trim(collector, dir, []string{"configmaps.json", "errors.txt"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if trimmed != 1 {
+		t.Fatalf("Expected 1 trimmed ConfigMap, got %d", trimmed)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "configmaps.json"))
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatalf("Failed to parse trimmed output: %v", err)
+	}
+	if _, ok := list.Items[0].Data["ca-bundle.crt"]; ok || list.Items[0].Data["config.yaml"] != "level: debug" {
+		t.Errorf("Expected only config.yaml to be kept, got %v", list.Items[0].Data)
+	}
+	var content SkippedContent
+	if err := json.Unmarshal([]byte(list.Items[0].Metadata.Annotations[LargeObjectSkippedAnnotation]), &content); err != nil {
+		t.Fatalf("Failed to parse %s: %v", LargeObjectSkippedAnnotation, err)
+	}
+	if content.Keys["ca-bundle.crt"] != len(bundle) || content.Threshold != 1024 || content.Size != len(bundle)+len("level: debug") {
+		t.Errorf("Unexpected skipped content %+v", content)
+	}
+	if list.Items[1].Data["key"] != "value" {
+		t.Errorf("Expected the small ConfigMap to be kept, got %v", list.Items[1].Data)
+	}
+
+	params.Resource = "secrets"
+	params.AlwaysCaptureKeys = nil
+	collector = CollectorSpec{Type: CollectorTypeClusterResources, Name: "auto-resources-secrets", Parameters: params.ToMap()}
+	if trimmed, err := trimmer.Trim(collector, dir, []string{"secret.json"}); err != nil || trimmed != 1 {
+		t.Fatalf("Expected the decoded 2000 byte secret to be trimmed, got %d, %v", trimmed, err)
+	}
+
+	skipped := trimmer.Skipped()
+	if len(skipped) != 2 || skipped[0].Name != "trust-bundle" || skipped[1].Name != "jar" || skipped[1].Keys["app.jar"] != 2000 {
+		t.Errorf("Unexpected skipped objects %+v", skipped)
+	}
+
+	unlimited := CollectorSpec{Type: CollectorTypeClusterResources, Name: "auto-resources-pods", Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods"}.ToMap()}
+	if trimmed, err := trimmer.Trim(unlimited, dir, []string{"configmaps.json"}); err != nil || trimmed != 0 {
+		t.Errorf("Expected collectors without a limit to be skipped, got %d, %v", trimmed, err)
+	}
+}
@@ -0,0 +1,39 @@
+package autodiscovery
+
+import (
+	"fmt"
+)
+
+// ValidateMaxLogLines rejects negative log line caps, 0 keeps the limits of each collector
+func ValidateMaxLogLines(max int) error {
+	if max < 0 {
+		return fmt.Errorf("must not be negative, got %d", max)
+	}
+	return nil
+}
+
+// capLogLines lowers the maxLines of every logs collector to max, setting it where a collector had no line limit
+func capLogLines(collectors []CollectorSpec, max int) []CollectorSpec {
+	if max <= 0 {
+		return collectors
+	}
+
+	for i, collector := range collectors {
+		if collector.Type != CollectorTypeLogs {
+			continue
+		}
+		params, err := collector.LogsParams()
+		if err != nil {
+			continue
+		}
+		if params.Limits == nil {
+			params.Limits = &LogsLimits{}
+		}
+		if params.Limits.MaxLines > 0 && params.Limits.MaxLines <= max {
+			continue
+		}
+		params.Limits.MaxLines = max
+		collectors[i].Parameters = params.ToMap()
+	}
+	return collectors
+}
@@ -0,0 +1,74 @@
+package autodiscovery
+
+import (
+	"testing"
+)
+
+func TestCapLogLines(t *testing.T) {
+	logs := func(name string, limits *LogsLimits) CollectorSpec {
+		return CollectorSpec{
+			Type:       CollectorTypeLogs,
+			Name:       name,
+			Parameters: LogsParams{Namespace: "default", Name: name, Limits: limits}.ToMap(),
+		}
+	}
+	collectors := []CollectorSpec{
+		logs("large", &LogsLimits{MaxAge: "72h", MaxLines: 10000}),
+		logs("small", &LogsLimits{MaxLines: 100}),
+		logs("unlimited", nil),
+		{
+			Type:       CollectorTypeClusterResources,
+			Name:       "auto-resources-pods",
+			Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods"}.ToMap(),
+		},
+	}
+
+	collectors = capLogLines(collectors, 500)
+
+	tests := []struct {
+		index       int
+		expectedMax int
+		expectedAge string
+	}{
+		{index: 0, expectedMax: 500, expectedAge: "72h"},
+		{index: 1, expectedMax: 100},
+		{index: 2, expectedMax: 500},
+	}
+	for _, tt := range tests {
+		t.Run(collectors[tt.index].Name, func(t *testing.T) {
+			params, err := collectors[tt.index].LogsParams()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if params.Limits == nil || params.Limits.MaxLines != tt.expectedMax || params.Limits.MaxAge != tt.expectedAge {
+				t.Errorf("Expected maxLines %d and maxAge %q, got %+v", tt.expectedMax, tt.expectedAge, params.Limits)
+			}
+		})
+	}
+
+	if _, ok := collectors[3].Parameters["limits"]; ok {
+		t.Errorf("Expected non-logs collectors to be left alone")
+	}
+}
+
+func TestCapLogLines_Disabled(t *testing.T) {
+	collectors := []CollectorSpec{{
+		Type:       CollectorTypeLogs,
+		Name:       "large",
+		Parameters: LogsParams{Namespace: "default", Name: "large", Limits: &LogsLimits{MaxLines: 10000}}.ToMap(),
+	}}
+
+	params, _ := capLogLines(collectors, 0)[0].LogsParams()
+	if params.Limits.MaxLines != 10000 {
+		t.Errorf("Expected maxLines to be kept without a cap, got %d", params.Limits.MaxLines)
+	}
+}
+
+func TestValidateMaxLogLines(t *testing.T) {
+	if err := ValidateMaxLogLines(0); err != nil {
+		t.Errorf("Expected 0 to be valid, got %v", err)
+	}
+	if err := ValidateMaxLogLines(-1); err == nil {
+		t.Errorf("Expected an error for a negative cap")
+	}
+}
@@ -0,0 +1,241 @@
+package autodiscovery
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NormalizedTimestampLayout is RFC3339 in UTC with a fixed nine digit fraction, so normalized lines of every
+// container sort by time as plain text
+const NormalizedTimestampLayout = "2006-01-02T15:04:05.000000000Z"
+
+// logTimestampSearchWidth is how far into a line without a kubelet timestamp the application's own is looked for
+const logTimestampSearchWidth = 120
+
+// applyLogTimestamps has every logs collector collect with timestamps and normalize them when normalize is set
+func applyLogTimestamps(collectors []CollectorSpec, normalize bool) []CollectorSpec {
+	if !normalize {
+		return collectors
+	}
+
+	for i, collector := range collectors {
+		if collector.Type != CollectorTypeLogs {
+			continue
+		}
+		params, err := collector.LogsParams()
+		if err != nil {
+			continue
+		}
+		params.Timestamps = true
+		params.NormalizeTimestamps = true
+		collectors[i].Parameters = params.ToMap()
+	}
+	return collectors
+}
+
+// appTimestampFormats are the application timestamp formats recognized in lines without a kubelet timestamp
+var appTimestampFormats = []struct {
+	pattern *regexp.Regexp
+	parse   func(match []string) (time.Time, error)
+}{
+	// ISO 8601 as most loggers write it, with a T or a space, a dot or comma fraction and an optional zone
+	{regexp.MustCompile(`(\d{4}-\d{2}-\d{2})[T ](\d{2}:\d{2}:\d{2})(?:[.,](\d{1,9}))?(Z| ?[+-]\d{2}:?\d{2})?`), func(match []string) (time.Time, error) {
+		value := match[1] + "T" + match[2]
+		if match[3] != "" {
+			value += "." + match[3]
+		}
+		zone := strings.Replace(strings.TrimSpace(match[4]), ":", "", 1)
+		if zone == "" || zone == "Z" {
+			// Without a zone the time is taken as UTC, the zone most container images run in
+			return time.Parse("2006-01-02T15:04:05.999999999", value)
+		}
+		return time.Parse("2006-01-02T15:04:05.999999999-0700", value+zone)
+	}},
+	// Common and combined access logs, e.g. [01/Mar/2024:09:30:00 +0100]
+	{regexp.MustCompile(`(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})`), func(match []string) (time.Time, error) {
+		return time.Parse("02/Jan/2006:15:04:05 -0700", match[1])
+	}},
+	// Epoch seconds in JSON logs, e.g. zap's "ts":1709285400.123
+	{regexp.MustCompile(`"(?:ts|time|timestamp)":\s*(\d{10})(?:\.(\d{1,9}))?[,}\s]`), func(match []string) (time.Time, error) {
+		seconds, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		var nanos int64
+		if match[2] != "" {
+			fraction := match[2] + strings.Repeat("0", 9-len(match[2]))
+			if nanos, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Unix(seconds, nanos), nil
+	}},
+}
+
+// NormalizeLogLine returns line starting with its timestamp as NormalizedTimestampLayout, and whether it had one
+// The kubelet's timestamp, written first on every line by --timestamps, is replaced. Otherwise a timestamp found
+// near the start of the line is put in front of it and the line is kept whole
+func NormalizeLogLine(line string) (string, bool) {
+	if prefix, rest, ok := strings.Cut(line, " "); ok {
+		if timestamp, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+			return timestamp.UTC().Format(NormalizedTimestampLayout) + " " + rest, true
+		}
+	}
+
+	head := line
+	if len(head) > logTimestampSearchWidth {
+		head = head[:logTimestampSearchWidth]
+	}
+	for _, format := range appTimestampFormats {
+		match := format.pattern.FindStringSubmatch(head)
+		if match == nil {
+			continue
+		}
+		if timestamp, err := format.parse(match); err == nil {
+			return timestamp.UTC().Format(NormalizedTimestampLayout) + " " + line, true
+		}
+	}
+	return line, false
+}
+
+// LogTimestampReport counts the log lines rewritten by a LogTimestampNormalizer
+type LogTimestampReport struct {
+	Files      int `json:"files"`
+	Lines      int `json:"lines"`
+	Normalized int `json:"normalized"`
+	Unparsed   int `json:"unparsed"` // Lines without a recognized timestamp, e.g. stack trace continuations, kept as is
+}
+
+// LogTimestampNormalizer rewrites the log files of each logs collector that asks for normalizeTimestamps
+type LogTimestampNormalizer struct {
+	mu     sync.Mutex
+	report LogTimestampReport
+}
+
+// NewLogTimestampNormalizer creates a LogTimestampNormalizer
+func NewLogTimestampNormalizer() *LogTimestampNormalizer {
+	return &LogTimestampNormalizer{}
+}
+
+// Normalize rewrites the .log files among the outputs of a logs collector, relative to outputDir, with
+// NormalizeLogLine. It returns the number of lines normalized; other collectors are left untouched
+func (n *LogTimestampNormalizer) Normalize(collector CollectorSpec, outputDir string, outputs []string) (int, error) {
+	if collector.Type != CollectorTypeLogs {
+		return 0, nil
+	}
+	params, err := collector.LogsParams()
+	if err != nil || !params.NormalizeTimestamps {
+		return 0, nil
+	}
+
+	var report LogTimestampReport
+	for _, output := range outputs {
+		err := filepath.Walk(filepath.Join(outputDir, output), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".log") {
+				return nil
+			}
+			return normalizeLogFile(path, info.Mode().Perm(), &report)
+		})
+		if err != nil {
+			n.add(report)
+			return report.Normalized, fmt.Errorf("failed to normalize timestamps in %s: %w", output, err)
+		}
+	}
+	n.add(report)
+	return report.Normalized, nil
+}
+
+func (n *LogTimestampNormalizer) add(report LogTimestampReport) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.report.Files += report.Files
+	n.report.Lines += report.Lines
+	n.report.Normalized += report.Normalized
+	n.report.Unparsed += report.Unparsed
+}
+
+// Report returns the lines normalized so far, nil when no log file was rewritten
+func (n *LogTimestampNormalizer) Report() *LogTimestampReport {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.report.Files == 0 {
+		return nil
+	}
+	report := n.report
+	return &report
+}
+
+// normalizeLogFile rewrites a log file through a temporary file next to it, so a failure leaves the original
+func normalizeLogFile(path string, perm os.FileMode, report *LogTimestampReport) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := out.Name()
+	defer os.Remove(tmpPath)
+
+	var counts LogTimestampReport
+	reader := bufio.NewReader(in)
+	writer := bufio.NewWriter(out)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			text := strings.TrimSuffix(line, "\n")
+			normalized, ok := NormalizeLogLine(text)
+			counts.Lines++
+			if ok {
+				counts.Normalized++
+			} else {
+				counts.Unparsed++
+			}
+			writer.WriteString(normalized)
+			if strings.HasSuffix(line, "\n") {
+				writer.WriteString("\n")
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			out.Close()
+			return readErr
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	counts.Files = 1
+	report.Files += counts.Files
+	report.Lines += counts.Lines
+	report.Normalized += counts.Normalized
+	report.Unparsed += counts.Unparsed
+	return nil
+}
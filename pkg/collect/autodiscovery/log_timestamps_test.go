@@ -0,0 +1,124 @@
+package autodiscovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeLogLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   string
+		parsed bool
+	}{
+		{
+			name:   "kubelet timestamp",
+			line:   "2024-03-01T10:30:00.5+01:00 GET /healthz 200",
+			want:   "2024-03-01T09:30:00.500000000Z GET /healthz 200",
+			parsed: true,
+		},
+		{
+			name:   "already normalized",
+			line:   "2024-03-01T09:30:00.500000000Z GET /healthz 200",
+			want:   "2024-03-01T09:30:00.500000000Z GET /healthz 200",
+			parsed: true,
+		},
+		{
+			name:   "iso with space and comma fraction",
+			line:   "2024-03-01 04:30:00,123 -0500 INFO started",
+			want:   "2024-03-01T09:30:00.123000000Z 2024-03-01 04:30:00,123 -0500 INFO started",
+			parsed: true,
+		},
+		{
+			name:   "iso without zone",
+			line:   "level=info time=2024-03-01T09:30:00 msg=ready",
+			want:   "2024-03-01T09:30:00.000000000Z level=info time=2024-03-01T09:30:00 msg=ready",
+			parsed: true,
+		},
+		{
+			name:   "access log",
+			line:   `10.0.0.1 - - [01/Mar/2024:10:30:00 +0100] "GET / HTTP/1.1" 200`,
+			want:   `2024-03-01T09:30:00.000000000Z 10.0.0.1 - - [01/Mar/2024:10:30:00 +0100] "GET / HTTP/1.1" 200`,
+			parsed: true,
+		},
+		{
+			name:   "epoch seconds",
+			line:   `{"level":"info","ts":1709285400.25,"msg":"ready"}`,
+			want:   `2024-03-01T09:30:00.250000000Z {"level":"info","ts":1709285400.25,"msg":"ready"}`,
+			parsed: true,
+		},
+		{
+			name: "continuation",
+			line: "    at com.example.Main.run(Main.java:42)",
+			want: "    at com.example.Main.run(Main.java:42)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, parsed := NormalizeLogLine(tt.line)
+			if got != tt.want || parsed != tt.parsed {
+				t.Errorf("Expected %q (%v), got %q (%v)", tt.want, tt.parsed, got, parsed)
+			}
+		})
+	}
+}
+
+func TestApplyLogTimestamps(t *testing.T) {
+	collectors := []CollectorSpec{
+		{Type: CollectorTypeLogs, Name: "auto-logs-shop", Parameters: LogsParams{Namespace: "shop", Selector: []string{"app=api"}}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-pods"},
+	}
+
+	if params, _ := applyLogTimestamps(collectors, false)[0].LogsParams(); params.Timestamps {
+		t.Errorf("Expected timestamps to stay off unless normalization is enabled")
+	}
+
+	collectors = applyLogTimestamps(collectors, true)
+	params, err := collectors[0].LogsParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !params.Timestamps || !params.NormalizeTimestamps {
+		t.Errorf("Expected the logs collector to collect and normalize timestamps, got %+v", params)
+	}
+	if collectors[1].Parameters != nil {
+		t.Errorf("Expected other collectors to be left untouched, got %v", collectors[1].Parameters)
+	}
+}
+
+func TestLogTimestampNormalizer_Normalize(t *testing.T) {
+	outputDir := t.TempDir()
+	logDir := filepath.Join(outputDir, "auto-logs-shop")
+	os.MkdirAll(logDir, 0755)
+	os.WriteFile(filepath.Join(logDir, "api-1-app.log"), []byte("2024-03-01T09:30:00Z ready\npanic: boom\n"), 0644)
+	os.WriteFile(filepath.Join(logDir, "api-1-app.json"), []byte(`{"ts": "2024-03-01T09:30:00Z"}`), 0644)
+
+	normalizer := NewLogTimestampNormalizer()
+	plain := CollectorSpec{Type: CollectorTypeLogs, Name: "auto-logs-shop", Parameters: LogsParams{Namespace: "shop"}.ToMap()}
+	if n, err := normalizer.Normalize(plain, outputDir, []string{"auto-logs-shop"}); err != nil || n != 0 || normalizer.Report() != nil {
+		t.Errorf("Expected collectors without normalizeTimestamps to be skipped, got %d, %v", n, err)
+	}
+
+	collector := applyLogTimestamps([]CollectorSpec{plain}, true)[0]
+	n, err := normalizer.Normalize(collector, outputDir, []string{"auto-logs-shop"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 line normalized, got %d", n)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(logDir, "api-1-app.log"))
+	if string(data) != "2024-03-01T09:30:00.000000000Z ready\npanic: boom\n" {
+		t.Errorf("Expected the kubelet timestamp to be normalized and the continuation kept, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(logDir, "api-1-app.json")); string(data) != `{"ts": "2024-03-01T09:30:00Z"}` {
+		t.Errorf("Expected files other than .log to be left untouched, got %s", data)
+	}
+	if report := normalizer.Report(); report == nil || report.Files != 1 || report.Lines != 2 || report.Unparsed != 1 {
+		t.Errorf("Expected 1 file with 2 lines, 1 unparsed, got %+v", report)
+	}
+}
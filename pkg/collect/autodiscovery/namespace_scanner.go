@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -13,10 +14,19 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// DefaultListPageSize is the number of objects requested per list call
+const DefaultListPageSize int64 = 500
+
 // NamespaceScanner handles namespace-aware resource enumeration
 type NamespaceScanner struct {
 	kubeClient    kubernetes.Interface
 	dynamicClient dynamic.Interface
+	pageSize      int64
+	rbacChecker   *RBACChecker
+	candidates    []string // Probed when listing namespaces is forbidden
+	unavailable   map[schema.GroupVersion]UnavailableAPIService // Aggregated APIs that are down, never listed
+	protected     []string // Namespace globs never listed, even when requested explicitly
+	openShift     *OpenShiftPlatform // Adds Routes, DeploymentConfigs and ImageStreams, and projects as a fallback
 }
 
 // NewNamespaceScanner creates a new NamespaceScanner instance
@@ -24,27 +34,90 @@ func NewNamespaceScanner(kubeClient kubernetes.Interface, dynamicClient dynamic.
 	return &NamespaceScanner{
 		kubeClient:    kubeClient,
 		dynamicClient: dynamicClient,
+		pageSize:      DefaultListPageSize,
+		rbacChecker:   NewRBACChecker(kubeClient),
 	}
 }
 
+// SetCandidateNamespaces sets the namespaces probed with access reviews when listing namespaces is forbidden
+func (n *NamespaceScanner) SetCandidateNamespaces(namespaces []string) {
+	n.candidates = namespaces
+}
+
+// SetOpenShift sets the OpenShift API groups served by the cluster, nil when it is not OpenShift
+func (n *NamespaceScanner) SetOpenShift(platform *OpenShiftPlatform) {
+	n.openShift = platform
+}
+
+// SetProtectedNamespaces sets the namespace globs that are never scanned, see IsProtectedNamespace
+func (n *NamespaceScanner) SetProtectedNamespaces(patterns []string) {
+	n.protected = patterns
+}
+
+// SetUnavailableAPIServices sets the aggregated APIs whose group versions are skipped while scanning
+func (n *NamespaceScanner) SetUnavailableAPIServices(unavailable []UnavailableAPIService) {
+	n.unavailable = unavailableGroupVersions(unavailable)
+}
+
+// SetPageSize sets the number of objects requested per list call; values <= 0 restore the default
+func (n *NamespaceScanner) SetPageSize(pageSize int64) {
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	n.pageSize = pageSize
+}
+
 // ScanNamespaces scans the specified namespaces for resources matching the filter
 func (n *NamespaceScanner) ScanNamespaces(ctx context.Context, namespaces []string, filter ResourceFilter) ([]Resource, error) {
 	var allResources []Resource
 
+	if _, err := newFieldExtractor(filter.FieldPaths); err != nil {
+		return nil, fmt.Errorf("invalid field path: %w", err)
+	}
+
 	// Get the list of supported resource types
 	supportedGVRs := n.getSupportedGVRs(filter)
 
+	// Protected namespaces are never listed, a request naming only protected namespaces scans nothing
+	if len(namespaces) > 0 && len(n.protected) > 0 {
+		allowed, protected := splitProtectedNamespaces(namespaces, n.protected)
+		for _, namespace := range protected {
+			fmt.Printf("Warning: namespace %s is protected, skipping\n", namespace)
+		}
+		if len(allowed) == 0 {
+			return allResources, nil
+		}
+		namespaces = allowed
+	}
+
 	// If no namespaces specified, scan all accessible namespaces
 	if len(namespaces) == 0 {
 		discoveredNamespaces, err := n.discoverAccessibleNamespaces(ctx)
+		if apierrors.IsForbidden(err) && n.openShift.ServesProjects() {
+			fmt.Printf("Warning: listing namespaces is forbidden, listing OpenShift projects instead\n")
+			if projects, projectErr := n.listProjects(ctx); projectErr == nil {
+				discoveredNamespaces, err = projects, nil
+			}
+		}
+		if apierrors.IsForbidden(err) && len(n.candidates) > 0 && n.rbacChecker != nil {
+			fmt.Printf("Warning: listing namespaces is forbidden, probing %d candidate namespaces\n", len(n.candidates))
+			discoveredNamespaces, err = n.probeCandidateNamespaces(ctx)
+		}
 		if err != nil {
+			if phaseExpired(ctx, PhaseNamespaceScan) {
+				return allResources, nil
+			}
 			return nil, fmt.Errorf("failed to discover accessible namespaces: %w", err)
 		}
-		namespaces = discoveredNamespaces
+		namespaces, _ = splitProtectedNamespaces(discoveredNamespaces, n.protected)
 	}
 
 	// Scan each namespace for resources
 	for _, namespace := range namespaces {
+		if phaseExpired(ctx, PhaseNamespaceScan) {
+			break
+		}
+
 		resources, err := n.scanNamespace(ctx, namespace, supportedGVRs, filter)
 		if err != nil {
 			// Log warning but continue with other namespaces
@@ -62,59 +135,135 @@ func (n *NamespaceScanner) scanNamespace(ctx context.Context, namespace string,
 	var resources []Resource
 
 	for _, gvr := range gvrs {
+		// Stop on deadline and keep what was listed so far
+		if ctx.Err() != nil {
+			break
+		}
+
 		// Skip cluster-scoped resources when scanning specific namespaces
 		if n.isClusterScoped(gvr) && namespace != "" {
 			continue
 		}
 
-		resourceList, err := n.listResources(ctx, gvr, namespace)
+		// Listing an aggregated API that is down fails or hangs until the request times out
+		if _, down := n.unavailable[gvr.GroupVersion()]; down {
+			continue
+		}
+
+		listed, err := n.listResources(ctx, gvr, namespace, filter)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			// Some resources might not exist or might not be accessible - continue with others
 			fmt.Printf("Debug: failed to list %s in namespace %s: %v\n", gvr.Resource, namespace, err)
 			continue
 		}
 
-		// Convert to our Resource type and apply filtering
-		for _, item := range resourceList {
-			resource := n.convertToResource(item, gvr)
-			if n.matchesFilter(resource, filter) {
-				resources = append(resources, resource)
-			}
-		}
+		resources = append(resources, listed...)
 	}
 
 	return resources, nil
 }
 
-// listResources lists resources of a specific GVR in a namespace
-func (n *NamespaceScanner) listResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
-	resourceClient := n.dynamicClient.Resource(gvr)
-	
-	var list *unstructured.UnstructuredList
-	var err error
-	
-	if namespace == "" || n.isClusterScoped(gvr) {
-		list, err = resourceClient.List(ctx, metav1.ListOptions{})
-	} else {
-		list, err = resourceClient.Namespace(namespace).List(ctx, metav1.ListOptions{})
-	}
-	
+// listResources lists resources of a specific GVR in a namespace page by page,
+// keeping only the metadata and filter.FieldPaths values of objects that match the filter
+func (n *NamespaceScanner) listResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, filter ResourceFilter) ([]Resource, error) {
+	fields, err := newFieldExtractor(filter.FieldPaths)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list resources: %w", err)
+		return nil, err
 	}
 
-	return list.Items, nil
+	var resourceClient dynamic.ResourceInterface = n.dynamicClient.Resource(gvr)
+	if namespace != "" && !n.isClusterScoped(gvr) {
+		resourceClient = n.dynamicClient.Resource(gvr).Namespace(namespace)
+	}
+
+	var resources []Resource
+	listOptions := metav1.ListOptions{Limit: n.pageSize}
+
+	for {
+		list, err := resourceClient.List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+
+		// Convert each page right away so the full objects can be released
+		for _, item := range list.Items {
+			resource := n.convertToResource(item, gvr)
+			resource.Fields = fields.extract(item)
+			if n.matchesFilter(resource, filter) {
+				resources = append(resources, resource)
+			}
+		}
+
+		listOptions.Continue = list.GetContinue()
+		if listOptions.Continue == "" {
+			break
+		}
+	}
+
+	return resources, nil
 }
 
 // convertToResource converts an unstructured object to our Resource type
 func (n *NamespaceScanner) convertToResource(obj unstructured.Unstructured, gvr schema.GroupVersionResource) Resource {
-	return Resource{
+	resource := Resource{
 		GVR:       gvr,
 		Namespace: obj.GetNamespace(),
 		Name:      obj.GetName(),
 		Labels:    obj.GetLabels(),
+		Annotations: collectionAnnotations(obj.GetAnnotations()),
 		OwnerRefs: obj.GetOwnerReferences(),
 	}
+
+	// Keep the pod details needed to collect ephemeral container logs
+	if gvr.Resource == "pods" {
+		resource.NodeName, _, _ = unstructured.NestedString(obj.Object, "spec", "nodeName")
+		resource.EphemeralContainers = ephemeralContainerNames(obj)
+		resource.ContainerImages = containerImages(obj)
+	}
+
+	return resource
+}
+
+// ephemeralContainerNames returns the names of a pod's ephemeral (debug) containers
+func ephemeralContainerNames(pod unstructured.Unstructured) []string {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "ephemeralContainers")
+
+	var names []string
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := container["name"].(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// containerImages returns the image of each of a pod's containers, keyed by container name
+func containerImages(pod unstructured.Unstructured) map[string]string {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+
+	images := make(map[string]string)
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		if name != "" && image != "" {
+			images[name] = image
+		}
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	return images
 }
 
 // matchesFilter checks if a resource matches the provided filter criteria
@@ -194,43 +343,81 @@ func (n *NamespaceScanner) getSupportedGVRs(filter ResourceFilter) []schema.Grou
 		return filter.IncludeGVRs
 	}
 
-	return defaultGVRs
+	return append(defaultGVRs, n.openShift.NamespacedGVRs()...)
 }
 
 // discoverAccessibleNamespaces discovers all namespaces the user has access to
 func (n *NamespaceScanner) discoverAccessibleNamespaces(ctx context.Context) ([]string, error) {
-	namespaceList, err := n.kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	var namespaces []string
+	listOptions := metav1.ListOptions{Limit: n.pageSize}
+
+	for {
+		namespaceList, err := n.kubeClient.CoreV1().Namespaces().List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", classifyAPIError(err))
+		}
+
+		for _, ns := range namespaceList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+
+		listOptions.Continue = namespaceList.Continue
+		if listOptions.Continue == "" {
+			break
+		}
 	}
 
+	return namespaces, nil
+}
+
+// probeCandidateNamespaces returns the candidate namespaces the user can list pods in or get
+// Access reviews take a namespace name, so a glob candidate would always be denied and is skipped
+func (n *NamespaceScanner) probeCandidateNamespaces(ctx context.Context) ([]string, error) {
 	var namespaces []string
-	for _, ns := range namespaceList.Items {
-		namespaces = append(namespaces, ns.Name)
+	for _, namespace := range n.candidates {
+		if strings.ContainsAny(namespace, "*?[") {
+			fmt.Printf("Warning: candidate namespace %s is a glob, skipping; candidates must be namespace names\n", namespace)
+			continue
+		}
+		allowed, err := n.rbacChecker.CheckVerbAccess(ctx, podsGVR, "", namespace, "list")
+		if err == nil && !allowed {
+			allowed, err = n.rbacChecker.CheckNamespaceAccess(ctx, namespace)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to check access for namespace %s: %v\n", namespace, err)
+			continue
+		}
+		if allowed {
+			namespaces = append(namespaces, namespace)
+		}
 	}
 
+	if len(namespaces) == 0 {
+		return nil, withKind(ErrRBACForbidden, fmt.Errorf("listing namespaces is forbidden and none of the %d candidate namespaces are accessible", len(n.candidates)))
+	}
 	return namespaces, nil
 }
 
+// clusterScopedResources are the supported resources that do not live in a namespace
+var clusterScopedResources = map[string]bool{
+	"nodes":                           true,
+	"persistentvolumes":               true,
+	"storageclasses":                  true,
+	"clusterroles":                    true,
+	"clusterrolebindings":             true,
+	"customresourcedefinitions":       true,
+	"apiservices":                     true,
+	"mutatingwebhookconfigurations":   true,
+	"validatingwebhookconfigurations": true,
+	"priorityclasses":                 true,
+	"runtimeclasses":                  true,
+	"podsecuritypolicies":             true,
+	"volumeattachments":               true,
+	"csidrivers":                      true,
+	"csinodes":                        true,
+}
+
 // isClusterScoped returns true if the resource is cluster-scoped
 func (n *NamespaceScanner) isClusterScoped(gvr schema.GroupVersionResource) bool {
-	clusterScopedResources := map[string]bool{
-		"nodes":                      true,
-		"persistentvolumes":          true,
-		"storageclasses":             true,
-		"clusterroles":               true,
-		"clusterrolebindings":        true,
-		"customresourcedefinitions":  true,
-		"apiservices":                true,
-		"mutatingwebhookconfigurations":   true,
-		"validatingwebhookconfigurations": true,
-		"priorityclasses":            true,
-		"runtimeclasses":             true,
-		"podsecuritypolicies":        true,
-		"volumeattachments":          true,
-		"csidrivers":                 true,
-		"csinodes":                   true,
-	}
-
 	return clusterScopedResources[gvr.Resource]
 }
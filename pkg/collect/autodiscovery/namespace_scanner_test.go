@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"testing"
 
+	authv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -640,6 +642,106 @@ func TestNamespaceScanner_discoverAccessibleNamespaces(t *testing.T) {
 	}
 }
 
+func TestNamespaceScanner_listResourcesPaginated(t *testing.T) {
+	pages := []*corev1.ConfigMapList{
+		{
+			ListMeta: metav1.ListMeta{Continue: "page-2"},
+			Items: []corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "cm-1", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "cm-2", Namespace: "default", Labels: map[string]string{"app": "db"}}},
+			},
+		},
+		{
+			ListMeta: metav1.ListMeta{Continue: "page-3"},
+			Items: []corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "cm-3", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+			},
+		},
+		{
+			Items: []corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "cm-4", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		filter        ResourceFilter
+		expectedCount int
+	}{
+		{
+			name:          "all pages are listed",
+			filter:        ResourceFilter{},
+			expectedCount: 4,
+		},
+		{
+			name:          "filter is applied to every page",
+			filter:        ResourceFilter{LabelSelector: "app=web"},
+			expectedCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := createTestDynamicClient()
+			calls := 0
+			dynamicClient.PrependReactor("list", "configmaps", func(action ktesting.Action) (bool, runtime.Object, error) {
+				if calls >= len(pages) {
+					return true, nil, fmt.Errorf("unexpected list call %d", calls+1)
+				}
+				// The fake dynamic client only passes unstructured lists through as they are
+				content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pages[calls])
+				if err != nil {
+					return true, nil, err
+				}
+				calls++
+				page := &unstructured.UnstructuredList{}
+				page.SetUnstructuredContent(content)
+				return true, page, nil
+			})
+
+			scanner := NewNamespaceScanner(nil, dynamicClient)
+			scanner.SetPageSize(2)
+
+			gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+			resources, err := scanner.listResources(context.Background(), gvr, "default", tt.filter)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if calls != len(pages) {
+				t.Errorf("Expected %d list calls, got %d", len(pages), calls)
+			}
+			if len(resources) != tt.expectedCount {
+				t.Errorf("Expected %d resources, got %d", tt.expectedCount, len(resources))
+			}
+		})
+	}
+}
+
+func TestNamespaceScanner_SetPageSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int64
+		expected int64
+	}{
+		{"custom page size", 100, 100},
+		{"zero restores default", 0, DefaultListPageSize},
+		{"negative restores default", -5, DefaultListPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewNamespaceScanner(nil, nil)
+			scanner.SetPageSize(tt.pageSize)
+
+			if scanner.pageSize != tt.expected {
+				t.Errorf("Expected page size %d, got %d", tt.expected, scanner.pageSize)
+			}
+		})
+	}
+}
+
 // Benchmark tests for performance validation
 func BenchmarkNamespaceScanner_ScanNamespaces(b *testing.B) {
 	// Setup with many resources
@@ -675,3 +777,105 @@ func BenchmarkNamespaceScanner_ScanNamespaces(b *testing.B) {
 		}
 	}
 }
+
+func TestNamespaceScanner_convertToResource_EphemeralContainers(t *testing.T) {
+	scanner := NewNamespaceScanner(kubernetesfake.NewSimpleClientset(), createTestDynamicClient())
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "web"}},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-abc"}},
+			},
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		t.Fatalf("Failed to convert pod: %v", err)
+	}
+
+	resource := scanner.convertToResource(unstructured.Unstructured{Object: content}, schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+
+	if resource.NodeName != "node-1" {
+		t.Errorf("Expected node name node-1, got %s", resource.NodeName)
+	}
+	if len(resource.EphemeralContainers) != 1 || resource.EphemeralContainers[0] != "debugger-abc" {
+		t.Errorf("Expected ephemeral containers [debugger-abc], got %v", resource.EphemeralContainers)
+	}
+}
+
+func TestNamespaceScanner_CandidateNamespaceFallback(t *testing.T) {
+	newClient := func() *kubernetesfake.Clientset {
+		client := kubernetesfake.NewSimpleClientset()
+		client.PrependReactor("list", "namespaces", func(action ktesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", fmt.Errorf("cannot list namespaces"))
+		})
+		client.PrependReactor("create", "selfsubjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+			attrs := action.(ktesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview).Spec.ResourceAttributes
+			// team-a can list pods, team-b can only get its namespace, team-c has no access
+			allowed := (attrs.Namespace == "team-a" && attrs.Resource == "pods" && attrs.Verb == "list") ||
+				(attrs.Namespace == "team-b" && attrs.Resource == "namespaces" && attrs.Verb == "get")
+			return true, &authv1.SelfSubjectAccessReview{Status: authv1.SubjectAccessReviewStatus{Allowed: allowed}}, nil
+		})
+		return client
+	}
+	dynamicClient := createTestDynamicClient(
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "team-a"},
+		}},
+	)
+	filter := ResourceFilter{IncludeGVRs: []schema.GroupVersionResource{podsGVR}}
+
+	t.Run("without candidates", func(t *testing.T) {
+		scanner := NewNamespaceScanner(newClient(), dynamicClient)
+		if _, err := scanner.ScanNamespaces(context.Background(), nil, filter); err == nil {
+			t.Errorf("Expected error when listing namespaces is forbidden")
+		}
+	})
+
+	t.Run("with candidates", func(t *testing.T) {
+		scanner := NewNamespaceScanner(newClient(), dynamicClient)
+		scanner.SetCandidateNamespaces([]string{"team-a", "team-b", "team-c"})
+
+		namespaces, err := scanner.probeCandidateNamespaces(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(namespaces) != 2 || namespaces[0] != "team-a" || namespaces[1] != "team-b" {
+			t.Errorf("Expected team-a and team-b to be accessible, got %v", namespaces)
+		}
+
+		resources, err := scanner.ScanNamespaces(context.Background(), nil, filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resources) != 1 || resources[0].Name != "web" {
+			t.Errorf("Expected pod web from team-a, got %v", resources)
+		}
+	})
+
+	t.Run("glob candidates", func(t *testing.T) {
+		scanner := NewNamespaceScanner(newClient(), dynamicClient)
+		scanner.SetCandidateNamespaces([]string{"team-*", "team-a"})
+
+		namespaces, err := scanner.probeCandidateNamespaces(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(namespaces) != 1 || namespaces[0] != "team-a" {
+			t.Errorf("Expected the glob to be skipped and team-a kept, got %v", namespaces)
+		}
+	})
+
+	t.Run("no accessible candidates", func(t *testing.T) {
+		scanner := NewNamespaceScanner(newClient(), dynamicClient)
+		scanner.SetCandidateNamespaces([]string{"team-c"})
+		if _, err := scanner.ScanNamespaces(context.Background(), nil, filter); err == nil {
+			t.Errorf("Expected error when no candidate namespace is accessible")
+		}
+	})
+}
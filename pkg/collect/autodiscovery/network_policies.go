@@ -0,0 +1,538 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	networkPoliciesGVR = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+	namespacesGVR      = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+)
+
+// namespaceNameLabel is set on every namespace by the API server and is what namespaceSelectors usually match
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// NetworkPolicySummary is a discovered NetworkPolicy with the parts that decide reachability
+type NetworkPolicySummary struct {
+	Namespace    string                 `json:"namespace"`
+	Name         string                 `json:"name"`
+	PodSelector  string                 `json:"podSelector"` // "" selects every pod in the namespace
+	PolicyTypes  []string               `json:"policyTypes"`
+	Spec         map[string]interface{} `json:"spec"`
+	SelectedPods []string               `json:"selectedPods"`
+	Problem      string                 `json:"problem,omitempty"`
+}
+
+// PodIsolation reports whether a discovered pod is isolated for ingress or egress, and by which policies
+type PodIsolation struct {
+	Namespace       string   `json:"namespace"`
+	Name            string   `json:"name"`
+	IngressIsolated bool     `json:"ingressIsolated"`
+	EgressIsolated  bool     `json:"egressIsolated"`
+	IngressPolicies []string `json:"ingressPolicies,omitempty"`
+	EgressPolicies  []string `json:"egressPolicies,omitempty"`
+}
+
+// ServiceReachability lists the ports of one service that the pods behind another service can and cannot reach
+type ServiceReachability struct {
+	From         string   `json:"from"` // namespace/service
+	To           string   `json:"to"`   // namespace/service
+	OpenPorts    []string `json:"openPorts,omitempty"`
+	BlockedPorts []string `json:"blockedPorts,omitempty"`
+}
+
+// NetworkPolicyReport is the reachability analysis written to the bundle
+type NetworkPolicyReport struct {
+	Policies []NetworkPolicySummary `json:"policies"`
+	Pods     []PodIsolation         `json:"pods"`
+	Matrix   []ServiceReachability  `json:"matrix"`
+}
+
+// networkPolicySpec mirrors the fields of networking.k8s.io/v1 NetworkPolicySpec used for the analysis
+type networkPolicySpec struct {
+	PodSelector metav1.LabelSelector `json:"podSelector"`
+	PolicyTypes []string             `json:"policyTypes,omitempty"`
+	Ingress     []networkPolicyRule  `json:"ingress,omitempty"`
+	Egress      []networkPolicyRule  `json:"egress,omitempty"`
+}
+
+type networkPolicyRule struct {
+	From  []networkPolicyPeer `json:"from,omitempty"`
+	To    []networkPolicyPeer `json:"to,omitempty"`
+	Ports []networkPolicyPort `json:"ports,omitempty"`
+}
+
+type networkPolicyPeer struct {
+	PodSelector       *metav1.LabelSelector  `json:"podSelector,omitempty"`
+	NamespaceSelector *metav1.LabelSelector  `json:"namespaceSelector,omitempty"`
+	IPBlock           map[string]interface{} `json:"ipBlock,omitempty"`
+}
+
+type networkPolicyPort struct {
+	Protocol string              `json:"protocol,omitempty"`
+	Port     *intstr.IntOrString `json:"port,omitempty"`
+	EndPort  *int32              `json:"endPort,omitempty"`
+}
+
+// parsedPolicy is a NetworkPolicy ready for matching
+type parsedPolicy struct {
+	namespace string
+	name      string
+	selector  labels.Selector
+	ingress   bool
+	egress    bool
+	spec      networkPolicySpec
+}
+
+// analysisPod is a discovered pod with the labels of its namespace
+type analysisPod struct {
+	namespace       string
+	name            string
+	labels          labels.Set
+	namespaceLabels labels.Set
+}
+
+// servicePort is a port a service forwards to its pods, named target ports are kept by name
+type servicePort struct {
+	protocol   string
+	targetPort intstr.IntOrString
+}
+
+// analysisService is a discovered service with the pods it selects
+type analysisService struct {
+	namespace string
+	name      string
+	pods      []analysisPod
+	ports     []servicePort
+}
+
+// NetworkPolicyAnalyzer computes pod isolation and service-to-service reachability from discovered NetworkPolicies
+type NetworkPolicyAnalyzer struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewNetworkPolicyAnalyzer creates a new NetworkPolicyAnalyzer
+func NewNetworkPolicyAnalyzer(dynamicClient dynamic.Interface) *NetworkPolicyAnalyzer {
+	return &NetworkPolicyAnalyzer{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// HasNetworkPolicies reports whether NetworkPolicies were discovered
+func HasNetworkPolicies(resources []Resource) bool {
+	for _, resource := range resources {
+		if resource.GVR == networkPoliciesGVR {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateNetworkPolicyCollectors returns a data collector with the reachability analysis of the discovered
+// NetworkPolicies, pods and services. Nothing is generated unless NetworkPolicies were discovered
+func (a *NetworkPolicyAnalyzer) GenerateNetworkPolicyCollectors(ctx context.Context, resources []Resource) []CollectorSpec {
+	if !HasNetworkPolicies(resources) {
+		return nil
+	}
+
+	report := a.BuildReport(ctx, resources)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Printf("Warning: failed to serialize network policy analysis: %v\n", err)
+		return nil
+	}
+
+	return []CollectorSpec{
+		{
+			Type:     "data",
+			Name:     "auto-network-policy-reachability",
+			Group:    CollectorGroupNetworking,
+			Priority: int(PriorityNormal),
+			Parameters: map[string]interface{}{
+				"name": "network/policy-reachability.json",
+				"data": string(data),
+			},
+		},
+	}
+}
+
+// BuildReport reads the discovered policies and services and computes isolation and the reachability matrix
+// ipBlock peers are kept in the policy spec but never match pods, so traffic they allow is reported as blocked
+func (a *NetworkPolicyAnalyzer) BuildReport(ctx context.Context, resources []Resource) NetworkPolicyReport {
+	report := NetworkPolicyReport{
+		Policies: []NetworkPolicySummary{},
+		Pods:     []PodIsolation{},
+		Matrix:   []ServiceReachability{},
+	}
+
+	namespaceLabels := a.namespaceLabels(ctx, resources)
+	var pods []analysisPod
+	for _, resource := range resources {
+		if resource.GVR == podsGVR {
+			pods = append(pods, analysisPod{
+				namespace:       resource.Namespace,
+				name:            resource.Name,
+				labels:          labels.Set(resource.Labels),
+				namespaceLabels: namespaceLabels[resource.Namespace],
+			})
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].namespace != pods[j].namespace {
+			return pods[i].namespace < pods[j].namespace
+		}
+		return pods[i].name < pods[j].name
+	})
+
+	var policies []parsedPolicy
+	for _, resource := range resources {
+		if resource.GVR != networkPoliciesGVR {
+			continue
+		}
+
+		summary := NetworkPolicySummary{Namespace: resource.Namespace, Name: resource.Name, SelectedPods: []string{}}
+		policy, err := a.getPolicy(ctx, resource.Namespace, resource.Name)
+		if err != nil {
+			summary.Problem = err.Error()
+			report.Policies = append(report.Policies, summary)
+			continue
+		}
+
+		summary.PodSelector = policy.selector.String()
+		summary.PolicyTypes = policyTypes(policy)
+		summary.Spec = policy.rawSpec
+		for _, pod := range pods {
+			if policy.selects(pod) {
+				summary.SelectedPods = append(summary.SelectedPods, pod.name)
+			}
+		}
+		report.Policies = append(report.Policies, summary)
+		policies = append(policies, policy.parsedPolicy)
+	}
+	sort.Slice(report.Policies, func(i, j int) bool {
+		if report.Policies[i].Namespace != report.Policies[j].Namespace {
+			return report.Policies[i].Namespace < report.Policies[j].Namespace
+		}
+		return report.Policies[i].Name < report.Policies[j].Name
+	})
+
+	for _, pod := range pods {
+		isolation := PodIsolation{Namespace: pod.namespace, Name: pod.name}
+		for _, policy := range policies {
+			if !policy.selects(pod) {
+				continue
+			}
+			if policy.ingress {
+				isolation.IngressIsolated = true
+				isolation.IngressPolicies = append(isolation.IngressPolicies, policy.name)
+			}
+			if policy.egress {
+				isolation.EgressIsolated = true
+				isolation.EgressPolicies = append(isolation.EgressPolicies, policy.name)
+			}
+		}
+		report.Pods = append(report.Pods, isolation)
+	}
+
+	services := a.services(ctx, resources, pods)
+	for _, from := range services {
+		for _, to := range services {
+			if from.namespace == to.namespace && from.name == to.name {
+				continue
+			}
+
+			entry := ServiceReachability{
+				From: from.namespace + "/" + from.name,
+				To:   to.namespace + "/" + to.name,
+			}
+			for _, port := range to.ports {
+				label := port.protocol + "/" + port.targetPort.String()
+				if servicesReachable(policies, from.pods, to.pods, port) {
+					entry.OpenPorts = append(entry.OpenPorts, label)
+				} else {
+					entry.BlockedPorts = append(entry.BlockedPorts, label)
+				}
+			}
+			report.Matrix = append(report.Matrix, entry)
+		}
+	}
+
+	return report
+}
+
+// fetchedPolicy is a parsed policy with its raw spec for the report
+type fetchedPolicy struct {
+	parsedPolicy
+	rawSpec map[string]interface{}
+}
+
+// getPolicy reads and parses one NetworkPolicy
+func (a *NetworkPolicyAnalyzer) getPolicy(ctx context.Context, namespace, name string) (fetchedPolicy, error) {
+	obj, err := a.dynamicClient.Resource(networkPoliciesGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fetchedPolicy{}, fmt.Errorf("failed to get network policy: %w", err)
+	}
+
+	rawSpec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	data, err := json.Marshal(rawSpec)
+	if err != nil {
+		return fetchedPolicy{}, fmt.Errorf("failed to read network policy spec: %w", err)
+	}
+	var spec networkPolicySpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fetchedPolicy{}, fmt.Errorf("failed to parse network policy spec: %w", err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&spec.PodSelector)
+	if err != nil {
+		return fetchedPolicy{}, fmt.Errorf("invalid pod selector: %w", err)
+	}
+
+	policy := parsedPolicy{namespace: namespace, name: name, selector: selector, spec: spec}
+	if len(spec.PolicyTypes) == 0 {
+		// Without policyTypes every policy isolates ingress, and egress only when it has egress rules
+		policy.ingress = true
+		policy.egress = len(spec.Egress) > 0
+	}
+	for _, policyType := range spec.PolicyTypes {
+		switch policyType {
+		case "Ingress":
+			policy.ingress = true
+		case "Egress":
+			policy.egress = true
+		}
+	}
+	return fetchedPolicy{parsedPolicy: policy, rawSpec: rawSpec}, nil
+}
+
+// namespaceLabels returns the labels of every namespace with discovered resources
+// Namespaces that cannot be read only get the kubernetes.io/metadata.name label
+func (a *NetworkPolicyAnalyzer) namespaceLabels(ctx context.Context, resources []Resource) map[string]labels.Set {
+	result := make(map[string]labels.Set)
+	for _, resource := range resources {
+		if resource.Namespace == "" || result[resource.Namespace] != nil {
+			continue
+		}
+
+		set := labels.Set{}
+		if ns, err := a.dynamicClient.Resource(namespacesGVR).Get(ctx, resource.Namespace, metav1.GetOptions{}); err == nil {
+			for key, value := range ns.GetLabels() {
+				set[key] = value
+			}
+		}
+		if _, ok := set[namespaceNameLabel]; !ok {
+			set[namespaceNameLabel] = resource.Namespace
+		}
+		result[resource.Namespace] = set
+	}
+	return result
+}
+
+// services reads the selector and ports of every discovered service and matches it to discovered pods
+// Services without a selector are skipped since their backends are not pods
+func (a *NetworkPolicyAnalyzer) services(ctx context.Context, resources []Resource, pods []analysisPod) []analysisService {
+	var services []analysisService
+	for _, resource := range resources {
+		if resource.GVR != servicesGVR {
+			continue
+		}
+
+		obj, err := a.dynamicClient.Resource(servicesGVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+		if err != nil {
+			fmt.Printf("Warning: failed to get service %s/%s: %v\n", resource.Namespace, resource.Name, err)
+			continue
+		}
+
+		selector, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector")
+		if len(selector) == 0 {
+			continue
+		}
+
+		service := analysisService{namespace: resource.Namespace, name: resource.Name}
+		for _, pod := range pods {
+			if pod.namespace == resource.Namespace && labels.SelectorFromSet(selector).Matches(pod.labels) {
+				service.pods = append(service.pods, pod)
+			}
+		}
+
+		ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+		for _, p := range ports {
+			port, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			service.ports = append(service.ports, parseServicePort(port))
+		}
+		services = append(services, service)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].namespace != services[j].namespace {
+			return services[i].namespace < services[j].namespace
+		}
+		return services[i].name < services[j].name
+	})
+	return services
+}
+
+// parseServicePort returns the protocol and target port of a service port, the target port defaults to the port
+func parseServicePort(port map[string]interface{}) servicePort {
+	result := servicePort{protocol: "TCP"}
+	if protocol, ok := port["protocol"].(string); ok && protocol != "" {
+		result.protocol = protocol
+	}
+
+	target := port["targetPort"]
+	if target == nil {
+		target = port["port"]
+	}
+	switch value := target.(type) {
+	case string:
+		result.targetPort = intstr.Parse(value)
+	case int64:
+		result.targetPort = intstr.FromInt(int(value))
+	case float64:
+		result.targetPort = intstr.FromInt(int(value))
+	}
+	return result
+}
+
+// servicesReachable reports whether any pod behind one service can reach any pod behind another on the port
+func servicesReachable(policies []parsedPolicy, fromPods, toPods []analysisPod, port servicePort) bool {
+	for _, src := range fromPods {
+		for _, dst := range toPods {
+			if podReachable(policies, src, dst, port) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// podReachable applies the NetworkPolicy semantics: traffic must be allowed by the egress side of the
+// source and the ingress side of the destination, and a pod not selected by any policy of a type allows all
+func podReachable(policies []parsedPolicy, src, dst analysisPod, port servicePort) bool {
+	egressIsolated, egressAllowed := false, false
+	ingressIsolated, ingressAllowed := false, false
+
+	for _, policy := range policies {
+		if policy.egress && policy.selects(src) {
+			egressIsolated = true
+			for _, rule := range policy.spec.Egress {
+				if policy.peersMatch(rule.To, dst) && portsMatch(rule.Ports, port) {
+					egressAllowed = true
+				}
+			}
+		}
+		if policy.ingress && policy.selects(dst) {
+			ingressIsolated = true
+			for _, rule := range policy.spec.Ingress {
+				if policy.peersMatch(rule.From, src) && portsMatch(rule.Ports, port) {
+					ingressAllowed = true
+				}
+			}
+		}
+	}
+
+	return (!egressIsolated || egressAllowed) && (!ingressIsolated || ingressAllowed)
+}
+
+// selects reports whether the policy applies to the pod
+func (p parsedPolicy) selects(pod analysisPod) bool {
+	return pod.namespace == p.namespace && p.selector.Matches(pod.labels)
+}
+
+// peersMatch reports whether a rule's peers include the pod, an empty peer list matches every pod
+func (p parsedPolicy) peersMatch(peers []networkPolicyPeer, pod analysisPod) bool {
+	if len(peers) == 0 {
+		return true
+	}
+
+	for _, peer := range peers {
+		if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+			continue // ipBlock
+		}
+
+		if peer.NamespaceSelector == nil {
+			if pod.namespace != p.namespace {
+				continue
+			}
+		} else if !labelSelectorMatches(peer.NamespaceSelector, pod.namespaceLabels) {
+			continue
+		}
+
+		if peer.PodSelector == nil || labelSelectorMatches(peer.PodSelector, pod.labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// labelSelectorMatches reports whether the selector matches the labels, invalid selectors match nothing
+func labelSelectorMatches(selector *metav1.LabelSelector, set labels.Set) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(set)
+}
+
+// portsMatch reports whether a rule's ports include the target port, an empty port list matches every port
+// Named ports only match by name since container port names are not resolved
+func portsMatch(ports []networkPolicyPort, port servicePort) bool {
+	if len(ports) == 0 {
+		return true
+	}
+
+	for _, p := range ports {
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		if !strings.EqualFold(protocol, port.protocol) {
+			continue
+		}
+		if p.Port == nil {
+			return true
+		}
+
+		if p.Port.Type == intstr.String || port.targetPort.Type == intstr.String {
+			if p.Port.String() == port.targetPort.String() {
+				return true
+			}
+			continue
+		}
+
+		end := p.Port.IntVal
+		if p.EndPort != nil {
+			end = *p.EndPort
+		}
+		if port.targetPort.IntVal >= p.Port.IntVal && port.targetPort.IntVal <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// policyTypes returns the effective policy types of a policy
+func policyTypes(policy fetchedPolicy) []string {
+	var types []string
+	if policy.ingress {
+		types = append(types, "Ingress")
+	}
+	if policy.egress {
+		types = append(types, "Egress")
+	}
+	return types
+}
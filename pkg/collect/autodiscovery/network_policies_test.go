@@ -0,0 +1,152 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func testSelectorService(namespace, name string, port int32, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports:    []corev1.ServicePort{{Port: port, TargetPort: intstr.FromInt(int(port))}},
+		},
+	}
+}
+
+func testIngressPolicy(name string, podSelector map[string]string, from networkingv1.NetworkPolicyPeer, port int) *networkingv1.NetworkPolicy {
+	target := intstr.FromInt(port)
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podSelector},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From:  []networkingv1.NetworkPolicyPeer{from},
+				Ports: []networkingv1.NetworkPolicyPort{{Port: &target}},
+			}},
+		},
+	}
+}
+
+func TestNetworkPolicyAnalyzer_GenerateNetworkPolicyCollectors(t *testing.T) {
+	client := createTestDynamicClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring", Labels: map[string]string{"team": "ops"}}},
+		testSelectorService("app", "web", 8080, map[string]string{"app": "web"}),
+		testSelectorService("app", "db", 5432, map[string]string{"app": "db"}),
+		testSelectorService("monitoring", "prom", 9090, map[string]string{"app": "prom"}),
+		testIngressPolicy("db-from-web", map[string]string{"app": "db"},
+			networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}, 5432),
+		testIngressPolicy("web-from-ops", map[string]string{"app": "web"},
+			networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "ops"}}}, 8080),
+	)
+
+	resources := []Resource{
+		{GVR: podsGVR, Namespace: "app", Name: "web-1", Labels: map[string]string{"app": "web"}},
+		{GVR: podsGVR, Namespace: "app", Name: "db-1", Labels: map[string]string{"app": "db"}},
+		{GVR: podsGVR, Namespace: "monitoring", Name: "prom-1", Labels: map[string]string{"app": "prom"}},
+		{GVR: servicesGVR, Namespace: "app", Name: "web"},
+		{GVR: servicesGVR, Namespace: "app", Name: "db"},
+		{GVR: servicesGVR, Namespace: "monitoring", Name: "prom"},
+		{GVR: networkPoliciesGVR, Namespace: "app", Name: "db-from-web"},
+		{GVR: networkPoliciesGVR, Namespace: "app", Name: "web-from-ops"},
+	}
+
+	collectors := NewNetworkPolicyAnalyzer(client).GenerateNetworkPolicyCollectors(context.Background(), resources)
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 collector, got %d", len(collectors))
+	}
+	if collectors[0].Parameters["name"] != "network/policy-reachability.json" {
+		t.Errorf("Expected output network/policy-reachability.json, got %v", collectors[0].Parameters["name"])
+	}
+
+	var report NetworkPolicyReport
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	if len(report.Policies) != 2 || report.Policies[0].Name != "db-from-web" {
+		t.Fatalf("Expected 2 policies sorted by name, got %+v", report.Policies)
+	}
+	if len(report.Policies[0].SelectedPods) != 1 || report.Policies[0].SelectedPods[0] != "db-1" {
+		t.Errorf("Expected db-from-web to select db-1, got %v", report.Policies[0].SelectedPods)
+	}
+
+	isolated := make(map[string]bool)
+	for _, pod := range report.Pods {
+		isolated[pod.Name] = pod.IngressIsolated
+		if pod.EgressIsolated {
+			t.Errorf("Expected %s not to be egress isolated", pod.Name)
+		}
+	}
+	if !isolated["web-1"] || !isolated["db-1"] || isolated["prom-1"] {
+		t.Errorf("Expected web-1 and db-1 to be ingress isolated, got %v", isolated)
+	}
+
+	tests := []struct {
+		from, to string
+		open     bool
+	}{
+		{from: "app/web", to: "app/db", open: true},
+		{from: "monitoring/prom", to: "app/db", open: false},
+		{from: "monitoring/prom", to: "app/web", open: true},
+		{from: "app/db", to: "app/web", open: false},
+		{from: "app/db", to: "monitoring/prom", open: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			for _, entry := range report.Matrix {
+				if entry.From != tt.from || entry.To != tt.to {
+					continue
+				}
+				if open := len(entry.OpenPorts) > 0; open != tt.open {
+					t.Errorf("Expected open=%v, got open ports %v and blocked ports %v", tt.open, entry.OpenPorts, entry.BlockedPorts)
+				}
+				return
+			}
+			t.Errorf("Expected a matrix entry from %s to %s", tt.from, tt.to)
+		})
+	}
+}
+
+func TestNetworkPolicyAnalyzer_NoPolicies(t *testing.T) {
+	resources := []Resource{{GVR: podsGVR, Namespace: "app", Name: "web-1"}}
+
+	collectors := NewNetworkPolicyAnalyzer(createTestDynamicClient()).GenerateNetworkPolicyCollectors(context.Background(), resources)
+	if len(collectors) != 0 {
+		t.Errorf("Expected no collectors without network policies, got %d", len(collectors))
+	}
+}
+
+func TestPortsMatch(t *testing.T) {
+	http := intstr.FromString("http")
+	low := intstr.FromInt(8000)
+	end := int32(8100)
+
+	tests := []struct {
+		name     string
+		ports    []networkPolicyPort
+		port     servicePort
+		expected bool
+	}{
+		{name: "no ports allows all", port: servicePort{protocol: "TCP", targetPort: intstr.FromInt(80)}, expected: true},
+		{name: "port range", ports: []networkPolicyPort{{Port: &low, EndPort: &end}}, port: servicePort{protocol: "TCP", targetPort: intstr.FromInt(8080)}, expected: true},
+		{name: "outside range", ports: []networkPolicyPort{{Port: &low, EndPort: &end}}, port: servicePort{protocol: "TCP", targetPort: intstr.FromInt(9090)}, expected: false},
+		{name: "protocol mismatch", ports: []networkPolicyPort{{Protocol: "UDP", Port: &low}}, port: servicePort{protocol: "TCP", targetPort: intstr.FromInt(8000)}, expected: false},
+		{name: "named port", ports: []networkPolicyPort{{Port: &http}}, port: servicePort{protocol: "TCP", targetPort: intstr.FromString("http")}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := portsMatch(tt.ports, tt.port); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
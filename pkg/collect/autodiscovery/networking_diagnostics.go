@@ -0,0 +1,411 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	ingressesGVR  = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	configMapsGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+)
+
+// CoreDNS runs in kube-system under the kube-dns label, kept from the kube-dns add-on it replaced
+const (
+	coreDNSNamespace  = "kube-system"
+	coreDNSConfigMap  = "coredns"
+	coreDNSLabel      = "k8s-app"
+	coreDNSLabelValue = "kube-dns"
+)
+
+// DNSLookupImage is the image of the pod running the in-cluster DNS lookup diagnostic
+const DNSLookupImage = "busybox:1.36"
+
+// ingressControllerLabels identify ingress controller pods by the labels their charts and manifests set
+var ingressControllerLabels = []struct {
+	controller string
+	label      string
+	value      string
+}{
+	{"nginx", "app.kubernetes.io/name", "ingress-nginx"},
+	{"nginx", "app.kubernetes.io/name", "rke2-ingress-nginx"},
+	{"nginx", "app.kubernetes.io/name", "nginx-ingress"},
+	{"nginx", "app", "ingress-nginx"},
+	{"nginx", "app", "nginx-ingress"},
+	{"traefik", "app.kubernetes.io/name", "traefik"},
+	{"traefik", "app", "traefik"},
+	{"haproxy", "app.kubernetes.io/name", "haproxy-ingress"},
+	{"haproxy", "app.kubernetes.io/name", "kubernetes-ingress"},
+	{"haproxy", "run", "haproxy-ingress"},
+}
+
+// IngressController is a group of ingress controller pods found by their labels
+type IngressController struct {
+	Controller string `json:"controller"` // nginx, traefik or haproxy
+	Namespace  string `json:"namespace"`
+	Selector   string `json:"selector"`
+	Pods       int    `json:"pods"`
+}
+
+// ServiceEndpointHealth describes a discovered service without ready endpoints
+type ServiceEndpointHealth struct {
+	Namespace         string `json:"namespace"`
+	Name              string `json:"name"`
+	ReadyEndpoints    int    `json:"readyEndpoints"`
+	NotReadyEndpoints int    `json:"notReadyEndpoints"`
+	Problem           string `json:"problem"`
+}
+
+// NetworkingReport is the networking summary written to the bundle
+type NetworkingReport struct {
+	Ingresses          []string                `json:"ingresses"` // namespace/name
+	UnhealthyServices  []ServiceEndpointHealth `json:"unhealthyServices"`
+	IngressControllers []IngressController     `json:"ingressControllers"`
+	DNSLookups         map[string][]string     `json:"dnsLookups"` // Host names looked up from each namespace
+}
+
+// NetworkingDiagnostics generates ingress controller and DNS collectors when Ingresses or unhealthy Services are discovered
+type NetworkingDiagnostics struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewNetworkingDiagnostics creates a new NetworkingDiagnostics
+func NewNetworkingDiagnostics(dynamicClient dynamic.Interface) *NetworkingDiagnostics {
+	return &NetworkingDiagnostics{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// HasIngresses reports whether Ingresses were discovered
+func HasIngresses(resources []Resource) bool {
+	for _, resource := range resources {
+		if resource.GVR == ingressesGVR {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateNetworkingCollectors returns the networking collectors: a summary of Ingresses and unhealthy Services,
+// ingress controller logs, the CoreDNS configuration and logs, and a DNS lookup pod per affected namespace
+// Nothing is generated unless Ingresses, or Services without ready endpoints, are among the discovered resources
+func (n *NetworkingDiagnostics) GenerateNetworkingCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions) []CollectorSpec {
+	report := n.BuildReport(ctx, resources, opts)
+	if len(report.Ingresses) == 0 && len(report.UnhealthyServices) == 0 {
+		return nil
+	}
+
+	priority := int(PriorityNormal)
+	if len(report.UnhealthyServices) > 0 {
+		priority = int(PriorityHigh)
+	}
+
+	var collectors []CollectorSpec
+	if data, err := json.MarshalIndent(report, "", "  "); err == nil {
+		collectors = append(collectors, CollectorSpec{
+			Type:     "data",
+			Name:     "auto-networking-summary",
+			Group:    CollectorGroupNetworking,
+			Priority: priority,
+			Parameters: map[string]interface{}{
+				"name": "networking/summary.json",
+				"data": string(data),
+			},
+		})
+	}
+
+	for _, controller := range report.IngressControllers {
+		collectors = append(collectors, CollectorSpec{
+			Type:      CollectorTypeLogs,
+			Name:      fmt.Sprintf("auto-networking-ingress-logs-%s-%s", controller.Controller, controller.Namespace),
+			Namespace: controller.Namespace,
+			Group:     CollectorGroupNetworking,
+			Priority:  priority,
+			Parameters: LogsParams{
+				Namespace: controller.Namespace,
+				Selector:  []string{controller.Selector},
+				Limits:    &LogsLimits{MaxAge: "24h", MaxLines: 1000},
+			}.ToMap(),
+		})
+	}
+
+	collectors = append(collectors, n.generateCoreDNSCollectors(ctx, priority, opts)...)
+
+	namespaces := make([]string, 0, len(report.DNSLookups))
+	for namespace := range report.DNSLookups {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		collectors = append(collectors, dnsLookupCollector(namespace, report.DNSLookups[namespace], priority))
+	}
+
+	return collectors
+}
+
+// BuildReport reads the discovered Ingresses and Services, and finds the ingress controllers of the cluster
+// Pods in protected namespaces are never read, so their ingress controllers are not reported
+func (n *NetworkingDiagnostics) BuildReport(ctx context.Context, resources []Resource, opts DiscoveryOptions) NetworkingReport {
+	report := NetworkingReport{
+		Ingresses:          []string{},
+		UnhealthyServices:  []ServiceEndpointHealth{},
+		IngressControllers: []IngressController{},
+		DNSLookups:         map[string][]string{},
+	}
+
+	// Every namespace with an Ingress or an unhealthy service gets a lookup pod, resolving at least kubernetes.default
+	lookups := make(map[string]map[string]bool)
+	addLookup := func(namespace, service string) {
+		if lookups[namespace] == nil {
+			lookups[namespace] = map[string]bool{}
+		}
+		if service != "" {
+			lookups[namespace][fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace)] = true
+		}
+	}
+
+	for _, resource := range resources {
+		switch resource.GVR {
+		case ingressesGVR:
+			report.Ingresses = append(report.Ingresses, resource.Namespace+"/"+resource.Name)
+			addLookup(resource.Namespace, "")
+			ingress, err := n.dynamicClient.Resource(ingressesGVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			for _, service := range ingressBackendServices(ingress) {
+				addLookup(resource.Namespace, service)
+			}
+		case servicesGVR:
+			if health, ok := n.checkServiceEndpoints(ctx, resource); !ok {
+				report.UnhealthyServices = append(report.UnhealthyServices, health)
+				addLookup(resource.Namespace, resource.Name)
+			}
+		}
+	}
+	sort.Strings(report.Ingresses)
+
+	if len(report.Ingresses) == 0 && len(report.UnhealthyServices) == 0 {
+		return report
+	}
+
+	report.IngressControllers = n.findIngressControllers(ctx, opts.ProtectedNamespaces)
+	for namespace, hosts := range lookups {
+		names := []string{"kubernetes.default.svc.cluster.local"}
+		for host := range hosts {
+			names = append(names, host)
+		}
+		sort.Strings(names[1:])
+		report.DNSLookups[namespace] = names
+	}
+	return report
+}
+
+// checkServiceEndpoints counts the endpoints of a service, reporting whether it has any ready
+// ExternalName services have no endpoints and are always healthy
+func (n *NetworkingDiagnostics) checkServiceEndpoints(ctx context.Context, resource Resource) (ServiceEndpointHealth, bool) {
+	health := ServiceEndpointHealth{Namespace: resource.Namespace, Name: resource.Name}
+
+	service, err := n.dynamicClient.Resource(servicesGVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+	if err != nil {
+		return health, true // Services that cannot be read are not reported as unhealthy
+	}
+	if serviceType, _, _ := unstructured.NestedString(service.Object, "spec", "type"); serviceType == "ExternalName" {
+		return health, true
+	}
+
+	endpoints, err := n.dynamicClient.Resource(endpointsGVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return health, true
+		}
+		health.Problem = "endpoints not found"
+		return health, false
+	}
+
+	subsets, _, _ := unstructured.NestedSlice(endpoints.Object, "subsets")
+	for _, s := range subsets {
+		subset, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addresses, _, _ := unstructured.NestedSlice(subset, "addresses")
+		health.ReadyEndpoints += len(addresses)
+		notReady, _, _ := unstructured.NestedSlice(subset, "notReadyAddresses")
+		health.NotReadyEndpoints += len(notReady)
+	}
+	if health.ReadyEndpoints > 0 {
+		return health, true
+	}
+	if health.NotReadyEndpoints > 0 {
+		health.Problem = fmt.Sprintf("%d endpoints, none ready", health.NotReadyEndpoints)
+	} else {
+		health.Problem = "no endpoints"
+	}
+	return health, false
+}
+
+// ingressBackendServices returns the names of the services an Ingress routes to, sorted and deduplicated
+func ingressBackendServices(ingress *unstructured.Unstructured) []string {
+	seen := make(map[string]bool)
+	if name, found, _ := unstructured.NestedString(ingress.Object, "spec", "defaultBackend", "service", "name"); found && name != "" {
+		seen[name] = true
+	}
+
+	rules, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		for _, p := range paths {
+			path, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, found, _ := unstructured.NestedString(path, "backend", "service", "name"); found && name != "" {
+				seen[name] = true
+			}
+		}
+	}
+
+	services := make([]string, 0, len(seen))
+	for name := range seen {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// findIngressControllers lists pods in all namespaces and groups those carrying a known ingress controller label
+// Ingress controllers usually run in their own namespace, so this is not limited to the discovery scope
+func (n *NetworkingDiagnostics) findIngressControllers(ctx context.Context, protected []string) []IngressController {
+	pods, err := n.dynamicClient.Resource(podsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list pods for ingress controllers: %v\n", err)
+		return []IngressController{}
+	}
+
+	found := make(map[string]*IngressController)
+	for _, pod := range pods.Items {
+		if IsProtectedNamespace(protected, pod.GetNamespace()) {
+			continue
+		}
+		labels := pod.GetLabels()
+		for _, known := range ingressControllerLabels {
+			if labels[known.label] != known.value {
+				continue
+			}
+			selector := known.label + "=" + known.value
+			key := pod.GetNamespace() + "/" + selector
+			if found[key] == nil {
+				found[key] = &IngressController{Controller: known.controller, Namespace: pod.GetNamespace(), Selector: selector}
+			}
+			found[key].Pods++
+			break
+		}
+	}
+
+	controllers := make([]IngressController, 0, len(found))
+	for _, controller := range found {
+		controllers = append(controllers, *controller)
+	}
+	sort.Slice(controllers, func(i, j int) bool {
+		if controllers[i].Namespace != controllers[j].Namespace {
+			return controllers[i].Namespace < controllers[j].Namespace
+		}
+		return controllers[i].Selector < controllers[j].Selector
+	})
+	return controllers
+}
+
+// generateCoreDNSCollectors returns the CoreDNS ConfigMap, one file per key under networking/coredns/, and CoreDNS logs
+// Nothing is read or collected when kube-system is protected
+func (n *NetworkingDiagnostics) generateCoreDNSCollectors(ctx context.Context, priority int, opts DiscoveryOptions) []CollectorSpec {
+	if IsProtectedNamespace(opts.ProtectedNamespaces, coreDNSNamespace) {
+		return nil
+	}
+
+	var collectors []CollectorSpec
+	configMap, err := n.dynamicClient.Resource(configMapsGVR).Namespace(coreDNSNamespace).Get(ctx, coreDNSConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		fmt.Printf("Warning: failed to get the CoreDNS configuration: %v\n", err)
+	}
+	if err == nil {
+		data, _, _ := unstructured.NestedStringMap(configMap.Object, "data")
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectors = append(collectors, CollectorSpec{
+				Type:     "data",
+				Name:     fmt.Sprintf("auto-networking-coredns-%s", strings.ToLower(key)),
+				Group:    CollectorGroupNetworking,
+				Priority: priority,
+				Parameters: map[string]interface{}{
+					"name": "networking/coredns/" + key,
+					"data": data[key],
+				},
+			})
+		}
+	}
+
+	collectors = append(collectors, CollectorSpec{
+		Type:      CollectorTypeLogs,
+		Name:      "auto-networking-coredns-logs",
+		Namespace: coreDNSNamespace,
+		Group:     CollectorGroupNetworking,
+		Priority:  priority,
+		Parameters: LogsParams{
+			Namespace: coreDNSNamespace,
+			Selector:  []string{coreDNSLabel + "=" + coreDNSLabelValue},
+			Limits:    &LogsLimits{MaxAge: "24h", MaxLines: 1000},
+		}.ToMap(),
+	})
+	return collectors
+}
+
+// dnsLookupCollector returns a run-pod collector that prints the pod's resolv.conf and looks up each host from
+// namespace, so search domains and ndots apply as they do for the namespace's workloads
+func dnsLookupCollector(namespace string, hosts []string, priority int) CollectorSpec {
+	script := []string{"cat /etc/resolv.conf"}
+	for _, host := range hosts {
+		script = append(script, fmt.Sprintf("echo '--- %s'; nslookup %s", host, host))
+	}
+
+	return CollectorSpec{
+		Type:      CollectorTypeRunPod,
+		Name:      fmt.Sprintf("auto-networking-dns-lookup-%s", namespace),
+		Namespace: namespace,
+		Group:     CollectorGroupNetworking,
+		Priority:  priority,
+		Parameters: RunPodParams{
+			Name:      "dns-lookup",
+			Namespace: namespace,
+			PodSpec: map[string]interface{}{
+				"containers": []map[string]interface{}{
+					{
+						"name":    "dns-lookup",
+						"image":   DNSLookupImage,
+						"command": []string{"sh", "-c"},
+						"args":    []string{strings.Join(script, "; ")},
+					},
+				},
+				"restartPolicy": "Never",
+			},
+			Timeout: "60s",
+		}.ToMap(),
+	}
+}
@@ -0,0 +1,150 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newNetworkingTestClient() *NetworkingDiagnostics {
+	return NewNetworkingDiagnostics(createTestDynamicClient(
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "shop"},
+			Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+				Host: "shop.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{
+					{Path: "/", Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "web"}}},
+					{Path: "/api", Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "api"}}},
+				}}},
+			}}},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"}},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+			Subsets:    []corev1.EndpointSubset{{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "payments.example.com"},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress-nginx-controller-abcde",
+			Namespace: "ingress-nginx",
+			Labels:    map[string]string{"app.kubernetes.io/name": "ingress-nginx", "pod-template-hash": "abc"},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "traefik-fghij",
+			Namespace: "vault",
+			Labels:    map[string]string{"app.kubernetes.io/name": "traefik"},
+		}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Data:       map[string]string{"Corefile": ".:53 {\n    forward . /etc/resolv.conf\n}\n"},
+		},
+	))
+}
+
+func TestNetworkingDiagnostics_GenerateNetworkingCollectors(t *testing.T) {
+	resources := []Resource{
+		{GVR: ingressesGVR, Namespace: "shop", Name: "storefront"},
+		{GVR: servicesGVR, Namespace: "shop", Name: "web"},
+		{GVR: servicesGVR, Namespace: "shop", Name: "api"},
+		{GVR: servicesGVR, Namespace: "shop", Name: "payments"},
+	}
+
+	diagnostics := newNetworkingTestClient()
+	collectors := diagnostics.GenerateNetworkingCollectors(context.Background(), resources, DiscoveryOptions{ProtectedNamespaces: []string{"vault"}})
+
+	byName := make(map[string]CollectorSpec)
+	for _, collector := range collectors {
+		if collector.Group != CollectorGroupNetworking {
+			t.Errorf("Expected collector %s in the networking group, got %q", collector.Name, collector.Group)
+		}
+		byName[collector.Name] = collector
+	}
+
+	for _, expected := range []string{
+		"auto-networking-summary",
+		"auto-networking-ingress-logs-nginx-ingress-nginx",
+		"auto-networking-coredns-corefile",
+		"auto-networking-coredns-logs",
+		"auto-networking-dns-lookup-shop",
+	} {
+		if _, ok := byName[expected]; !ok {
+			t.Errorf("Expected collector %s, got %v", expected, collectorNames(collectors))
+		}
+	}
+	if _, ok := byName["auto-networking-ingress-logs-traefik-vault"]; ok {
+		t.Errorf("Expected ingress controllers in protected namespaces to be skipped")
+	}
+
+	var report NetworkingReport
+	if err := json.Unmarshal([]byte(byName["auto-networking-summary"].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse networking summary: %v", err)
+	}
+	if len(report.UnhealthyServices) != 1 || report.UnhealthyServices[0].Name != "api" || report.UnhealthyServices[0].NotReadyEndpoints != 1 {
+		t.Errorf("Expected only api to be unhealthy, got %+v", report.UnhealthyServices)
+	}
+	expectedLookups := []string{
+		"kubernetes.default.svc.cluster.local",
+		"api.shop.svc.cluster.local",
+		"web.shop.svc.cluster.local",
+	}
+	if !reflect.DeepEqual(report.DNSLookups["shop"], expectedLookups) {
+		t.Errorf("Expected lookups %v, got %v", expectedLookups, report.DNSLookups["shop"])
+	}
+
+	if name := byName["auto-networking-coredns-corefile"].Parameters["name"]; name != "networking/coredns/Corefile" {
+		t.Errorf("Expected the Corefile under networking/coredns/, got %v", name)
+	}
+	params, err := byName["auto-networking-ingress-logs-nginx-ingress-nginx"].LogsParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Namespace != "ingress-nginx" || !reflect.DeepEqual(params.Selector, []string{"app.kubernetes.io/name=ingress-nginx"}) {
+		t.Errorf("Expected ingress-nginx logs by label, got %+v", params)
+	}
+
+	lookup := byName["auto-networking-dns-lookup-shop"]
+	if lookup.Type != CollectorTypeRunPod || lookup.Priority != int(PriorityHigh) {
+		t.Errorf("Expected a high priority run-pod collector, got %s at %d", lookup.Type, lookup.Priority)
+	}
+}
+
+func TestNetworkingDiagnostics_NothingToDiagnose(t *testing.T) {
+	diagnostics := newNetworkingTestClient()
+
+	healthy := []Resource{{GVR: servicesGVR, Namespace: "shop", Name: "web"}}
+	if collectors := diagnostics.GenerateNetworkingCollectors(context.Background(), healthy, DiscoveryOptions{}); len(collectors) != 0 {
+		t.Errorf("Expected no collectors for healthy services, got %v", collectorNames(collectors))
+	}
+
+	ingress := []Resource{{GVR: ingressesGVR, Namespace: "shop", Name: "storefront"}}
+	collectors := diagnostics.GenerateNetworkingCollectors(context.Background(), ingress, DiscoveryOptions{ProtectedNamespaces: []string{"kube-*"}})
+	for _, collector := range collectors {
+		if collector.Namespace == "kube-system" || collector.Name == "auto-networking-coredns-corefile" {
+			t.Errorf("Expected CoreDNS to be skipped when kube-system is protected, got %s", collector.Name)
+		}
+	}
+	if len(collectors) == 0 || collectors[0].Priority != int(PriorityNormal) {
+		t.Errorf("Expected normal priority collectors for Ingresses alone, got %v", collectorNames(collectors))
+	}
+}
+
+func collectorNames(collectors []CollectorSpec) []string {
+	names := make([]string, 0, len(collectors))
+	for _, collector := range collectors {
+		names = append(names, collector.Name)
+	}
+	return names
+}
@@ -0,0 +1,276 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// Node sampling defaults
+const (
+	DefaultNodesPerDomain        = 2
+	DefaultNodeSamplingThreshold = 20 // Clusters with at most this many nodes are collected whole
+)
+
+// Well-known node labels that identify a failure domain
+const (
+	LabelTopologyZone = "topology.kubernetes.io/zone"
+	LabelInstanceType = "node.kubernetes.io/instance-type"
+)
+
+// Reasons a node is included in the sample
+const (
+	NodeSampleReasonAll            = "all"            // The cluster is at or below the sampling threshold
+	NodeSampleReasonUnhealthy      = "unhealthy"      // NotReady, under pressure or network unavailable
+	NodeSampleReasonRepresentative = "representative" // One of the first PerDomain healthy nodes of its domain
+)
+
+// DefaultNodeDomainLabels are the labels whose values together form a node's failure domain
+var DefaultNodeDomainLabels = []string{LabelTopologyZone, LabelInstanceType}
+
+// NodeSampling bounds the node data collected from large clusters: every unhealthy node is kept,
+// plus PerDomain healthy nodes for each combination of DomainLabels values
+type NodeSampling struct {
+	Disabled     bool     `json:"disabled,omitempty" yaml:"disabled,omitempty"`         // Collect every node
+	Threshold    int      `json:"threshold,omitempty" yaml:"threshold,omitempty"`       // 0 uses DefaultNodeSamplingThreshold
+	PerDomain    int      `json:"perDomain,omitempty" yaml:"perDomain,omitempty"`       // 0 uses DefaultNodesPerDomain
+	DomainLabels []string `json:"domainLabels,omitempty" yaml:"domainLabels,omitempty"` // Empty uses DefaultNodeDomainLabels
+}
+
+// WithOverrides returns the sampling rules with every set override applied
+func (s NodeSampling) WithOverrides(overrides NodeSampling) NodeSampling {
+	if overrides.Disabled {
+		s.Disabled = overrides.Disabled
+	}
+	if overrides.Threshold > 0 {
+		s.Threshold = overrides.Threshold
+	}
+	if overrides.PerDomain > 0 {
+		s.PerDomain = overrides.PerDomain
+	}
+	if len(overrides.DomainLabels) > 0 {
+		s.DomainLabels = overrides.DomainLabels
+	}
+	return s
+}
+
+// Validate checks that the sampling bounds are not negative and the domain labels are not empty
+func (s NodeSampling) Validate() error {
+	if s.Threshold < 0 {
+		return fmt.Errorf("threshold cannot be negative")
+	}
+	if s.PerDomain < 0 {
+		return fmt.Errorf("perDomain cannot be negative")
+	}
+	for _, label := range s.DomainLabels {
+		if label == "" {
+			return fmt.Errorf("domainLabels cannot contain an empty label")
+		}
+	}
+	return nil
+}
+
+func (s NodeSampling) threshold() int {
+	if s.Threshold > 0 {
+		return s.Threshold
+	}
+	return DefaultNodeSamplingThreshold
+}
+
+func (s NodeSampling) perDomain() int {
+	if s.PerDomain > 0 {
+		return s.PerDomain
+	}
+	return DefaultNodesPerDomain
+}
+
+func (s NodeSampling) domainLabels() []string {
+	if len(s.DomainLabels) > 0 {
+		return s.DomainLabels
+	}
+	return DefaultNodeDomainLabels
+}
+
+// SampledNode is a node kept in the sample, with why it was kept
+type SampledNode struct {
+	Name     string   `json:"name"`
+	Domain   string   `json:"domain"`
+	Reason   string   `json:"reason"`
+	Problems []string `json:"problems,omitempty"` // Unhealthy conditions, e.g. "Ready=Unknown" or "MemoryPressure"
+}
+
+// NodeSamplingReport records how the collected nodes were chosen
+type NodeSamplingReport struct {
+	TotalNodes   int            `json:"totalNodes"`
+	SampledNodes int            `json:"sampledNodes"`
+	Sampled      bool           `json:"sampled"` // False when every node was collected
+	PerDomain    int            `json:"perDomain,omitempty"`
+	DomainLabels []string       `json:"domainLabels,omitempty"`
+	Domains      map[string]int `json:"domains,omitempty"` // Total nodes per failure domain
+	Nodes        []SampledNode  `json:"nodes"`
+}
+
+// NodeSampler generates the node collectors of the cluster-info group
+type NodeSampler struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewNodeSampler creates a new NodeSampler
+func NewNodeSampler(dynamicClient dynamic.Interface) *NodeSampler {
+	return &NodeSampler{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateNodeCollectors returns the sampled nodes and the sampling report as two cluster-info data collectors
+// Nothing is returned when nodes cannot be listed, e.g. without cluster-scoped RBAC
+func (n *NodeSampler) GenerateNodeCollectors(ctx context.Context, opts NodeSampling) []CollectorSpec {
+	list, err := n.dynamicClient.Resource(nodesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list nodes for sampling: %v\n", err)
+		return nil
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	nodes, report := SampleNodes(list.Items, opts)
+	for i := range nodes {
+		unstructured.RemoveNestedField(nodes[i].Object, "metadata", "managedFields")
+	}
+
+	nodeData, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		return nil
+	}
+	reportData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return []CollectorSpec{
+		{
+			Type:     "data",
+			Name:     "auto-cluster-info-nodes",
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(PriorityHigh),
+			Parameters: map[string]interface{}{
+				"name": "cluster-info/nodes.json",
+				"data": string(nodeData),
+			},
+		},
+		{
+			Type:     "data",
+			Name:     "auto-cluster-info-node-sampling",
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(PriorityHigh),
+			Parameters: map[string]interface{}{
+				"name": "cluster-info/node-sampling.json",
+				"data": string(reportData),
+			},
+		},
+	}
+}
+
+// SampleNodes picks the nodes to collect: every node at or below the threshold, otherwise every unhealthy node
+// plus the first PerDomain healthy nodes by name of each failure domain
+func SampleNodes(nodes []unstructured.Unstructured, opts NodeSampling) ([]unstructured.Unstructured, NodeSamplingReport) {
+	sorted := append([]unstructured.Unstructured{}, nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+
+	labels := opts.domainLabels()
+	report := NodeSamplingReport{
+		TotalNodes: len(sorted),
+		Domains:    make(map[string]int),
+	}
+
+	sampleAll := opts.Disabled || len(sorted) <= opts.threshold()
+	if !sampleAll {
+		report.Sampled = true
+		report.PerDomain = opts.perDomain()
+		report.DomainLabels = labels
+	}
+
+	var sampled []unstructured.Unstructured
+	perDomain := make(map[string]int)
+	for _, node := range sorted {
+		domain := nodeDomain(node, labels)
+		report.Domains[domain]++
+		problems := nodeProblems(node)
+
+		var reason string
+		switch {
+		case sampleAll:
+			reason = NodeSampleReasonAll
+		case len(problems) > 0:
+			reason = NodeSampleReasonUnhealthy
+		case perDomain[domain] < opts.perDomain():
+			perDomain[domain]++
+			reason = NodeSampleReasonRepresentative
+		default:
+			continue
+		}
+
+		sampled = append(sampled, node)
+		report.Nodes = append(report.Nodes, SampledNode{
+			Name:     node.GetName(),
+			Domain:   domain,
+			Reason:   reason,
+			Problems: problems,
+		})
+	}
+
+	report.SampledNodes = len(sampled)
+	return sampled, report
+}
+
+// nodeDomain joins the node's domain label values, e.g. "us-east-1a/m5.large", with "unknown" for missing labels
+func nodeDomain(node unstructured.Unstructured, labels []string) string {
+	nodeLabels := node.GetLabels()
+	values := make([]string, 0, len(labels))
+	for _, label := range labels {
+		value := nodeLabels[label]
+		if value == "" {
+			value = "unknown"
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, "/")
+}
+
+// nodeProblems returns the conditions that make a node unhealthy: not Ready, under pressure or network unavailable
+func nodeProblems(node unstructured.Unstructured) []string {
+	var problems []string
+	ready := false
+
+	conditions, _, _ := unstructured.NestedSlice(node.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		status, _ := condition["status"].(string)
+
+		switch {
+		case conditionType == "Ready":
+			ready = status == "True"
+			if !ready {
+				problems = append(problems, fmt.Sprintf("Ready=%s", status))
+			}
+		case (strings.HasSuffix(conditionType, "Pressure") || conditionType == "NetworkUnavailable") && status == "True":
+			problems = append(problems, conditionType)
+		}
+	}
+
+	if len(conditions) > 0 && !ready && len(problems) == 0 {
+		problems = append(problems, "Ready=Missing")
+	}
+	return problems
+}
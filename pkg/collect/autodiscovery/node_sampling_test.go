@@ -0,0 +1,188 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testNode(name, zone, instanceType string, conditions ...corev1.NodeCondition) *corev1.Node {
+	if len(conditions) == 0 {
+		conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				LabelTopologyZone: zone,
+				LabelInstanceType: instanceType,
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubelet"}},
+		},
+		Status: corev1.NodeStatus{Conditions: conditions},
+	}
+}
+
+// testNodeFleet returns perZone healthy nodes in each of three zones, all of one instance type
+func testNodeFleet(perZone int) []runtime.Object {
+	var nodes []runtime.Object
+	for _, zone := range []string{"us-east-1a", "us-east-1b", "us-east-1c"} {
+		for i := 0; i < perZone; i++ {
+			nodes = append(nodes, testNode(fmt.Sprintf("node-%s-%02d", zone, i), zone, "m5.large"))
+		}
+	}
+	return nodes
+}
+
+func TestNodeSampler_GenerateNodeCollectors(t *testing.T) {
+	nodes := append(testNodeFleet(10),
+		testNode("node-not-ready", "us-east-1a", "m5.large", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}),
+		testNode("node-pressure", "us-east-1b", "m5.large",
+			corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			corev1.NodeCondition{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+		),
+	)
+
+	collectors := NewNodeSampler(createTestDynamicClient(nodes...)).GenerateNodeCollectors(context.Background(), NodeSampling{})
+	if len(collectors) != 2 {
+		t.Fatalf("Expected 2 collectors, got %d", len(collectors))
+	}
+	if collectors[0].Parameters["name"] != "cluster-info/nodes.json" || collectors[0].Group != CollectorGroupClusterInfo {
+		t.Errorf("Expected nodes in the cluster-info group, got %v in %s", collectors[0].Parameters["name"], collectors[0].Group)
+	}
+
+	var report NodeSamplingReport
+	if err := json.Unmarshal([]byte(collectors[1].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse sampling report: %v", err)
+	}
+	// 2 representatives in each of 3 zones, plus the 2 unhealthy nodes
+	if !report.Sampled || report.TotalNodes != 32 || report.SampledNodes != 8 {
+		t.Errorf("Expected 8 of 32 nodes to be sampled, got %d of %d", report.SampledNodes, report.TotalNodes)
+	}
+	if report.Domains["us-east-1a/m5.large"] != 11 {
+		t.Errorf("Expected 11 nodes in us-east-1a/m5.large, got %d", report.Domains["us-east-1a/m5.large"])
+	}
+
+	reasons := make(map[string]SampledNode)
+	for _, node := range report.Nodes {
+		reasons[node.Name] = node
+	}
+	if node := reasons["node-not-ready"]; node.Reason != NodeSampleReasonUnhealthy || len(node.Problems) != 1 || node.Problems[0] != "Ready=Unknown" {
+		t.Errorf("Expected node-not-ready to be sampled as unhealthy, got %+v", node)
+	}
+	if node := reasons["node-pressure"]; node.Reason != NodeSampleReasonUnhealthy || node.Problems[0] != "MemoryPressure" {
+		t.Errorf("Expected node-pressure to be sampled as unhealthy, got %+v", node)
+	}
+	if node := reasons["node-us-east-1c-01"]; node.Reason != NodeSampleReasonRepresentative {
+		t.Errorf("Expected node-us-east-1c-01 to be a representative, got %+v", node)
+	}
+	if _, ok := reasons["node-us-east-1c-02"]; ok {
+		t.Errorf("Expected only 2 healthy nodes per domain")
+	}
+
+	var sampled []map[string]interface{}
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &sampled); err != nil {
+		t.Fatalf("Failed to parse nodes: %v", err)
+	}
+	if len(sampled) != 8 {
+		t.Errorf("Expected 8 nodes, got %d", len(sampled))
+	}
+	if _, ok := sampled[0]["metadata"].(map[string]interface{})["managedFields"]; ok {
+		t.Errorf("Expected managedFields to be stripped from nodes")
+	}
+}
+
+func TestSampleNodes(t *testing.T) {
+	tests := []struct {
+		name            string
+		nodes           []runtime.Object
+		opts            NodeSampling
+		expectedSampled int
+		expectedReason  string
+	}{
+		{
+			name:            "small cluster is collected whole",
+			nodes:           testNodeFleet(5),
+			expectedSampled: 15,
+			expectedReason:  NodeSampleReasonAll,
+		},
+		{
+			name:            "sampling disabled",
+			nodes:           testNodeFleet(10),
+			opts:            NodeSampling{Disabled: true},
+			expectedSampled: 30,
+			expectedReason:  NodeSampleReasonAll,
+		},
+		{
+			name:            "custom per domain",
+			nodes:           testNodeFleet(10),
+			opts:            NodeSampling{PerDomain: 3},
+			expectedSampled: 9,
+			expectedReason:  NodeSampleReasonRepresentative,
+		},
+		{
+			name:            "instance type only domains",
+			nodes:           testNodeFleet(10),
+			opts:            NodeSampling{Threshold: 10, DomainLabels: []string{LabelInstanceType}},
+			expectedSampled: 2,
+			expectedReason:  NodeSampleReasonRepresentative,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := createTestDynamicClient(tt.nodes...).Resource(nodesGVR).List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			sampled, report := SampleNodes(list.Items, tt.opts)
+			if len(sampled) != tt.expectedSampled || report.SampledNodes != tt.expectedSampled {
+				t.Errorf("Expected %d sampled nodes, got %d", tt.expectedSampled, len(sampled))
+			}
+			if report.Nodes[0].Reason != tt.expectedReason {
+				t.Errorf("Expected reason %s, got %s", tt.expectedReason, report.Nodes[0].Reason)
+			}
+		})
+	}
+}
+
+func TestNodeSampling_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		sampling    NodeSampling
+		expectError bool
+	}{
+		{name: "defaults", sampling: NodeSampling{}},
+		{name: "custom", sampling: NodeSampling{Threshold: 50, PerDomain: 1, DomainLabels: []string{LabelTopologyZone}}},
+		{name: "negative threshold", sampling: NodeSampling{Threshold: -1}, expectError: true},
+		{name: "negative per domain", sampling: NodeSampling{PerDomain: -1}, expectError: true},
+		{name: "empty label", sampling: NodeSampling{DomainLabels: []string{""}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sampling.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNodeSampling_WithOverrides(t *testing.T) {
+	base := NodeSampling{Threshold: 50, PerDomain: 1}
+	merged := base.WithOverrides(NodeSampling{PerDomain: 3, DomainLabels: []string{LabelTopologyZone}})
+
+	if merged.Threshold != 50 || merged.PerDomain != 3 || len(merged.DomainLabels) != 1 {
+		t.Errorf("Expected threshold 50, perDomain 3 and one domain label, got %+v", merged)
+	}
+}
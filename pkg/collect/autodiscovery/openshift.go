@@ -0,0 +1,254 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// OpenShiftReportPath is where the OpenShift version and ClusterOperator health are written
+const OpenShiftReportPath = "cluster-info/openshift.json"
+
+// A cluster serving either group is OpenShift
+const (
+	openShiftRouteGroup   = "route.openshift.io"
+	openShiftProjectGroup = "project.openshift.io"
+)
+
+var (
+	routesGVR            = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+	deploymentConfigsGVR = schema.GroupVersionResource{Group: "apps.openshift.io", Version: "v1", Resource: "deploymentconfigs"}
+	imageStreamsGVR      = schema.GroupVersionResource{Group: "image.openshift.io", Version: "v1", Resource: "imagestreams"}
+	projectsGVR          = schema.GroupVersionResource{Group: "project.openshift.io", Version: "v1", Resource: "projects"}
+	clusterOperatorsGVR  = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusteroperators"}
+	clusterVersionsGVR   = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusterversions"}
+)
+
+// OpenShiftNamespacedResources are scanned in every namespace of an OpenShift cluster that serves their group
+var OpenShiftNamespacedResources = []schema.GroupVersionResource{routesGVR, deploymentConfigsGVR, imageStreamsGVR}
+
+// OpenShiftClusterResources are added to the cluster scope of an OpenShift cluster that serves their group
+var OpenShiftClusterResources = []schema.GroupVersionResource{clusterOperatorsGVR, clusterVersionsGVR}
+
+// OpenShiftPlatform records the OpenShift API groups a cluster serves
+type OpenShiftPlatform struct {
+	Groups map[string]bool `json:"groups"`
+}
+
+// DetectOpenShift returns the OpenShift API groups served by the cluster, nil when it is not OpenShift
+func DetectOpenShift(client discovery.ServerGroupsInterface) (*OpenShiftPlatform, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API groups: %w", err)
+	}
+
+	platform := &OpenShiftPlatform{Groups: make(map[string]bool)}
+	for _, group := range groups.Groups {
+		if strings.HasSuffix(group.Name, ".openshift.io") {
+			platform.Groups[group.Name] = true
+		}
+	}
+	if !platform.Groups[openShiftRouteGroup] && !platform.Groups[openShiftProjectGroup] {
+		return nil, nil
+	}
+	return platform, nil
+}
+
+// NamespacedGVRs returns the OpenShiftNamespacedResources whose group is served, none on other clusters
+func (p *OpenShiftPlatform) NamespacedGVRs() []schema.GroupVersionResource {
+	return p.served(OpenShiftNamespacedResources)
+}
+
+// ClusterGVRs returns the OpenShiftClusterResources whose group is served, none on other clusters
+func (p *OpenShiftPlatform) ClusterGVRs() []schema.GroupVersionResource {
+	return p.served(OpenShiftClusterResources)
+}
+
+// ServesProjects reports whether users can list their projects when they may not list namespaces
+func (p *OpenShiftPlatform) ServesProjects() bool {
+	return p != nil && p.Groups[openShiftProjectGroup]
+}
+
+func (p *OpenShiftPlatform) served(gvrs []schema.GroupVersionResource) []schema.GroupVersionResource {
+	if p == nil {
+		return nil
+	}
+	var served []schema.GroupVersionResource
+	for _, gvr := range gvrs {
+		if p.Groups[gvr.Group] {
+			served = append(served, gvr)
+		}
+	}
+	return served
+}
+
+// extendClusterScope adds the served OpenShift cluster-scoped types to scope, so they go through the same
+// RBAC check and report as the types the user opted in to
+func (p *OpenShiftPlatform) extendClusterScope(scope ClusterScope) ClusterScope {
+	gvrs := p.ClusterGVRs()
+	if len(gvrs) == 0 {
+		return scope
+	}
+	resources := append([]string(nil), scope.Resources...)
+	for _, gvr := range gvrs {
+		resources = append(resources, fmt.Sprintf("%s/%s/%s", gvr.Group, gvr.Version, gvr.Resource))
+	}
+	scope.Resources = resources
+	return scope
+}
+
+// ClusterOperatorStatus is the health of one OpenShift ClusterOperator
+type ClusterOperatorStatus struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Available   bool   `json:"available"`
+	Progressing bool   `json:"progressing"`
+	Degraded    bool   `json:"degraded"`
+	Reason      string `json:"reason,omitempty"`
+	Message     string `json:"message,omitempty"` // Of the Degraded condition, or the Available one when unavailable
+}
+
+// OpenShiftReport is the OpenShift version and the health of its ClusterOperators
+type OpenShiftReport struct {
+	Groups    []string                `json:"groups"`
+	Version   string                  `json:"version,omitempty"` // Desired version of the ClusterVersion
+	Channel   string                  `json:"channel,omitempty"`
+	Operators []ClusterOperatorStatus `json:"operators"`
+	Unhealthy []string                `json:"unhealthy,omitempty"` // ClusterOperators Degraded or not Available
+	Problems  []string                `json:"problems,omitempty"`
+}
+
+// BuildOpenShiftReport reads the ClusterVersion and ClusterOperators, recording failures as problems
+func BuildOpenShiftReport(ctx context.Context, dynamicClient dynamic.Interface, platform *OpenShiftPlatform) OpenShiftReport {
+	report := OpenShiftReport{Groups: sortedKeys(platform.Groups), Operators: []ClusterOperatorStatus{}}
+	if !platform.Groups[clusterOperatorsGVR.Group] {
+		return report // e.g. MicroShift, which has no cluster version operator
+	}
+
+	if version, err := dynamicClient.Resource(clusterVersionsGVR).Get(ctx, "version", metav1.GetOptions{}); err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to get cluster version: %v", err))
+	} else {
+		report.Version, _, _ = unstructured.NestedString(version.Object, "status", "desired", "version")
+		report.Channel, _, _ = unstructured.NestedString(version.Object, "spec", "channel")
+	}
+
+	operators, err := dynamicClient.Resource(clusterOperatorsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to list cluster operators: %v", err))
+		return report
+	}
+	for _, item := range operators.Items {
+		operator := clusterOperatorStatus(item)
+		report.Operators = append(report.Operators, operator)
+		if operator.Degraded || !operator.Available {
+			report.Unhealthy = append(report.Unhealthy, operator.Name)
+		}
+	}
+	sort.Slice(report.Operators, func(i, j int) bool { return report.Operators[i].Name < report.Operators[j].Name })
+	sort.Strings(report.Unhealthy)
+	return report
+}
+
+// clusterOperatorStatus reads the conditions and operator version of a ClusterOperator
+func clusterOperatorStatus(item unstructured.Unstructured) ClusterOperatorStatus {
+	operator := ClusterOperatorStatus{Name: item.GetName()}
+	versions, _, _ := unstructured.NestedSlice(item.Object, "status", "versions")
+	for _, v := range versions {
+		if version, ok := v.(map[string]interface{}); ok && version["name"] == "operator" {
+			operator.Version, _ = version["version"].(string)
+		}
+	}
+
+	var availableReason, availableMessage string
+	conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		switch condition["type"] {
+		case "Available":
+			operator.Available = status == "True"
+			availableReason, availableMessage = reason, message
+		case "Progressing":
+			operator.Progressing = status == "True"
+		case "Degraded":
+			operator.Degraded = status == "True"
+			if operator.Degraded {
+				operator.Reason, operator.Message = reason, message
+			}
+		}
+	}
+	if !operator.Degraded && !operator.Available {
+		operator.Reason, operator.Message = availableReason, availableMessage
+	}
+	return operator
+}
+
+// generateOpenShiftCollectors adds the OpenShift report on OpenShift clusters, critical when an operator is unhealthy
+func (d *Discoverer) generateOpenShiftCollectors(ctx context.Context) []CollectorSpec {
+	if d.openShift == nil || d.dynamicClient == nil {
+		return nil
+	}
+
+	report := BuildOpenShiftReport(ctx, d.dynamicClient, d.openShift)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil
+	}
+	priority := PriorityHigh
+	if len(report.Unhealthy) > 0 {
+		priority = PriorityCritical
+	}
+	return []CollectorSpec{{
+		Type:     "data",
+		Name:     "auto-cluster-info-openshift",
+		Group:    CollectorGroupClusterInfo,
+		Priority: int(priority),
+		Parameters: map[string]interface{}{
+			"name": OpenShiftReportPath,
+			"data": string(data),
+		},
+	}}
+}
+
+// detectOpenShift looks for the OpenShift API groups. A failure is a warning, discovery then runs as on
+// any other cluster
+func (d *Discoverer) detectOpenShift() *OpenShiftPlatform {
+	d.openShift = nil
+	if d.kubeClient == nil {
+		return nil
+	}
+	platform, err := DetectOpenShift(d.kubeClient.Discovery())
+	if err != nil {
+		fmt.Printf("Warning: failed to detect OpenShift: %v\n", err)
+		return nil
+	}
+	d.openShift = platform
+	return platform
+}
+
+// listProjects lists the OpenShift projects of the user, the namespaces they may access
+func (n *NamespaceScanner) listProjects(ctx context.Context) ([]string, error) {
+	list, err := n.dynamicClient.Resource(projectsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", classifyAPIError(err))
+	}
+	namespaces := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		namespaces = append(namespaces, item.GetName())
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
@@ -0,0 +1,198 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+type fakeServerGroups struct {
+	groups []string
+	err    error
+}
+
+func (f fakeServerGroups) ServerGroups() (*metav1.APIGroupList, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	list := &metav1.APIGroupList{}
+	for _, group := range f.groups {
+		list.Groups = append(list.Groups, metav1.APIGroup{Name: group})
+	}
+	return list, nil
+}
+
+func TestDetectOpenShift(t *testing.T) {
+	tests := []struct {
+		name       string
+		groups     []string
+		wantNil    bool
+		namespaced int
+		cluster    int
+		projects   bool
+	}{
+		{name: "vanilla kubernetes", groups: []string{"apps", "batch"}, wantNil: true},
+		{name: "openshift groups without routes or projects", groups: []string{"image.openshift.io"}, wantNil: true},
+		{
+			name:       "openshift",
+			groups:     []string{"apps", "route.openshift.io", "project.openshift.io", "apps.openshift.io", "image.openshift.io", "config.openshift.io"},
+			namespaced: 3,
+			cluster:    2,
+			projects:   true,
+		},
+		{name: "microshift", groups: []string{"route.openshift.io", "security.openshift.io"}, namespaced: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platform, err := DetectOpenShift(fakeServerGroups{groups: tt.groups})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if platform != nil {
+					t.Errorf("Expected no OpenShift platform, got %+v", platform)
+				}
+				return
+			}
+			if platform == nil {
+				t.Fatalf("Expected an OpenShift platform")
+			}
+			if got := len(platform.NamespacedGVRs()); got != tt.namespaced {
+				t.Errorf("Expected %d namespaced types, got %d", tt.namespaced, got)
+			}
+			if got := len(platform.ClusterGVRs()); got != tt.cluster {
+				t.Errorf("Expected %d cluster types, got %d", tt.cluster, got)
+			}
+			if platform.ServesProjects() != tt.projects {
+				t.Errorf("Expected ServesProjects %v, got %v", tt.projects, platform.ServesProjects())
+			}
+		})
+	}
+
+	if _, err := DetectOpenShift(fakeServerGroups{err: fmt.Errorf("connection refused")}); err == nil {
+		t.Errorf("Expected discovery errors to be returned")
+	}
+}
+
+func TestOpenShiftPlatform_ExtendClusterScope(t *testing.T) {
+	var vanilla *OpenShiftPlatform
+	scope := ClusterScope{Resources: []string{"nodes"}}
+	if got := vanilla.extendClusterScope(scope); len(got.Resources) != 1 {
+		t.Errorf("Expected the scope unchanged without OpenShift, got %v", got.Resources)
+	}
+
+	platform := &OpenShiftPlatform{Groups: map[string]bool{"route.openshift.io": true, "config.openshift.io": true}}
+	extended := platform.extendClusterScope(scope)
+	gvrs, err := extended.GVRs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(gvrs) != 3 || gvrs[1] != clusterOperatorsGVR || gvrs[2] != clusterVersionsGVR {
+		t.Errorf("Expected nodes, clusteroperators and clusterversions, got %v", gvrs)
+	}
+	if len(scope.Resources) != 1 {
+		t.Errorf("Expected the original scope to be left alone, got %v", scope.Resources)
+	}
+}
+
+func testClusterOperator(name string, conditions ...map[string]interface{}) *unstructured.Unstructured {
+	items := make([]interface{}, 0, len(conditions))
+	for _, condition := range conditions {
+		items = append(items, condition)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "ClusterOperator",
+		"metadata":   map[string]interface{}{"name": name},
+		"status": map[string]interface{}{
+			"conditions": items,
+			"versions":   []interface{}{map[string]interface{}{"name": "operator", "version": "4.14.3"}},
+		},
+	}}
+}
+
+func operatorCondition(conditionType, status, message string) map[string]interface{} {
+	return map[string]interface{}{"type": conditionType, "status": status, "reason": conditionType + "Reason", "message": message}
+}
+
+func TestBuildOpenShiftReport(t *testing.T) {
+	client := createTestDynamicClient(
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "config.openshift.io/v1",
+			"kind":       "ClusterVersion",
+			"metadata":   map[string]interface{}{"name": "version"},
+			"spec":       map[string]interface{}{"channel": "stable-4.14"},
+			"status":     map[string]interface{}{"desired": map[string]interface{}{"version": "4.14.3"}},
+		}},
+		testClusterOperator("ingress", operatorCondition("Available", "True", ""), operatorCondition("Degraded", "False", "")),
+		testClusterOperator("dns", operatorCondition("Available", "True", ""), operatorCondition("Degraded", "True", "dns pods crashing")),
+		testClusterOperator("authentication", operatorCondition("Available", "False", "oauth server unreachable")),
+	)
+	platform := &OpenShiftPlatform{Groups: map[string]bool{"route.openshift.io": true, "config.openshift.io": true}}
+
+	report := BuildOpenShiftReport(context.Background(), client, platform)
+	if report.Version != "4.14.3" || report.Channel != "stable-4.14" {
+		t.Errorf("Expected version 4.14.3 on stable-4.14, got %q on %q", report.Version, report.Channel)
+	}
+	if len(report.Operators) != 3 || report.Operators[0].Name != "authentication" {
+		t.Fatalf("Expected 3 operators sorted by name, got %+v", report.Operators)
+	}
+	if strings.Join(report.Unhealthy, ",") != "authentication,dns" {
+		t.Errorf("Expected authentication and dns to be unhealthy, got %v", report.Unhealthy)
+	}
+	if dns := report.Operators[1]; dns.Message != "dns pods crashing" || dns.Version != "4.14.3" {
+		t.Errorf("Expected the Degraded message and operator version, got %+v", dns)
+	}
+	if auth := report.Operators[0]; auth.Message != "oauth server unreachable" {
+		t.Errorf("Expected the Available message of an unavailable operator, got %+v", auth)
+	}
+	if len(report.Problems) != 0 {
+		t.Errorf("Expected no problems, got %v", report.Problems)
+	}
+}
+
+func TestNamespaceScanner_OpenShiftProjectsFallback(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	kubeClient.PrependReactor("list", "namespaces", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", fmt.Errorf("cannot list namespaces"))
+	})
+	dynamicClient := createTestDynamicClient(
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "project.openshift.io/v1",
+			"kind":       "Project",
+			"metadata":   map[string]interface{}{"name": "team-a"},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "route.openshift.io/v1",
+			"kind":       "Route",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "team-a"},
+		}},
+	)
+
+	scanner := NewNamespaceScanner(kubeClient, dynamicClient)
+	scanner.SetOpenShift(&OpenShiftPlatform{Groups: map[string]bool{"route.openshift.io": true, "project.openshift.io": true}})
+	resources, err := scanner.ScanNamespaces(context.Background(), nil, ResourceFilter{IncludeGVRs: []schema.GroupVersionResource{routesGVR}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	found := false
+	for _, resource := range resources {
+		if resource.GVR == routesGVR && resource.Namespace == "team-a" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the Route in project team-a to be discovered, got %+v", resources)
+	}
+}
@@ -0,0 +1,104 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Ranges accepted by DiscoveryOptions.Validate
+const (
+	MinMaxDepth = 0
+	MaxMaxDepth = 10 // The comprehensive profile resolves dependencies this deep
+
+	MinPageSize int64 = 0 // 0 uses DefaultListPageSize
+	MaxPageSize int64 = 10000
+)
+
+// Validate checks the options against the one rule set shared by config files, spec files, profiles, dry
+// runs and CLI flags. Zero values are valid and select the defaults
+func (o DiscoveryOptions) Validate() error {
+	if err := validateNamespaceNames("namespaces", o.Namespaces); err != nil {
+		return err
+	}
+	if err := validateNamespaceNames("candidateNamespaces", o.CandidateNamespaces); err != nil {
+		return err
+	}
+	for _, namespace := range o.CandidateNamespaces {
+		// Candidates are probed with access reviews, which take a namespace name
+		if IsGlob(namespace) {
+			return fmt.Errorf("candidateNamespaces: %q is a glob, candidates must be namespace names", namespace)
+		}
+	}
+	if err := validateGlobs(o.ExcludeNamespaces); err != nil {
+		return fmt.Errorf("excludeNamespaces: %w", err)
+	}
+	if o.MaxDepth < MinMaxDepth || o.MaxDepth > MaxMaxDepth {
+		return fmt.Errorf("maxDepth must be between %d and %d, got %d", MinMaxDepth, MaxMaxDepth, o.MaxDepth)
+	}
+	if o.PageSize < MinPageSize || o.PageSize > MaxPageSize {
+		return fmt.Errorf("pageSize must be between %d and %d, got %d", MinPageSize, MaxPageSize, o.PageSize)
+	}
+	if o.TableThreshold < 0 {
+		return fmt.Errorf("tableThreshold must not be negative, got %d", o.TableThreshold)
+	}
+	if len(o.OnlyGroups) > 0 && len(o.SkipGroups) > 0 {
+		return fmt.Errorf("onlyGroups and skipGroups cannot be used together")
+	}
+	if err := ValidateCollectorGroups(o.OnlyGroups); err != nil {
+		return fmt.Errorf("onlyGroups: %w", err)
+	}
+	if err := ValidateCollectorGroups(o.SkipGroups); err != nil {
+		return fmt.Errorf("skipGroups: %w", err)
+	}
+	if err := ValidateResourceFormat(o.ResourceFormat); err != nil {
+		return fmt.Errorf("resourceFormat: %w", err)
+	}
+	if err := o.PhaseTimeouts.Validate(); err != nil {
+		return fmt.Errorf("phaseTimeouts: %w", err)
+	}
+	if err := o.NodeSampling.Validate(); err != nil {
+		return fmt.Errorf("nodeSampling: %w", err)
+	}
+	if err := o.HotPods.Validate(); err != nil {
+		return fmt.Errorf("hotPods: %w", err)
+	}
+	if err := o.TimeWindow.Validate(); err != nil {
+		return fmt.Errorf("timeWindow: %w", err)
+	}
+	if err := ValidateMaxCollectors(o.MaxCollectors); err != nil {
+		return fmt.Errorf("maxCollectors: %w", err)
+	}
+	if err := ValidateMaxLogLines(o.MaxLogLines); err != nil {
+		return fmt.Errorf("maxLogLines: %w", err)
+	}
+	if err := ValidateProtectedNamespaces(o.ProtectedNamespaces); err != nil {
+		return err
+	}
+	if err := o.LargeObjects.Validate(); err != nil {
+		return fmt.Errorf("largeObjects: %w", err)
+	}
+	if err := o.SecretPolicy.Validate(); err != nil {
+		return fmt.Errorf("secretPolicy: %w", err)
+	}
+	if err := o.ClusterScope.Validate(); err != nil {
+		return fmt.Errorf("clusterScope: %w", err)
+	}
+	return nil
+}
+
+// validateNamespaceNames rejects empty names and names containing whitespace. Names may be globs, e.g. app-*
+func validateNamespaceNames(field string, namespaces []string) error {
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			return fmt.Errorf("%s: namespace cannot be empty", field)
+		}
+		if strings.IndexFunc(namespace, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("%s: namespace cannot contain spaces: %q", field, namespace)
+		}
+		if err := ValidateGlob(namespace); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package autodiscovery
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscoveryOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options DiscoveryOptions
+		wantErr string
+	}{
+		{name: "zero values"},
+		{name: "valid", options: DiscoveryOptions{Namespaces: []string{"default", "app-*"}, MaxDepth: MaxMaxDepth, PageSize: 250, ExcludeNamespaces: []string{"kube-*"}}},
+		{name: "empty namespace", options: DiscoveryOptions{Namespaces: []string{""}}, wantErr: "namespaces: namespace cannot be empty"},
+		{name: "namespace with spaces", options: DiscoveryOptions{Namespaces: []string{"default app"}}, wantErr: "cannot contain spaces"},
+		{name: "namespace with tab", options: DiscoveryOptions{CandidateNamespaces: []string{"default\tapp"}}, wantErr: "candidateNamespaces"},
+		{name: "candidate namespace glob", options: DiscoveryOptions{CandidateNamespaces: []string{"team-*"}}, wantErr: "candidateNamespaces: \"team-*\" is a glob"},
+		{name: "bad namespace glob", options: DiscoveryOptions{Namespaces: []string{"app-["}}, wantErr: "namespaces:"},
+		{name: "bad exclude glob", options: DiscoveryOptions{ExcludeNamespaces: []string{"kube-["}}, wantErr: "excludeNamespaces"},
+		{name: "negative max depth", options: DiscoveryOptions{MaxDepth: -1}, wantErr: "maxDepth must be between 0 and 10"},
+		{name: "excessive max depth", options: DiscoveryOptions{MaxDepth: 15}, wantErr: "maxDepth must be between 0 and 10"},
+		{name: "excessive page size", options: DiscoveryOptions{PageSize: MaxPageSize + 1}, wantErr: "pageSize"},
+		{name: "negative table threshold", options: DiscoveryOptions{TableThreshold: -1}, wantErr: "tableThreshold"},
+		{name: "only and skip groups", options: DiscoveryOptions{OnlyGroups: []string{"logs"}, SkipGroups: []string{"images"}}, wantErr: "cannot be used together"},
+		{name: "unknown group", options: DiscoveryOptions{OnlyGroups: []string{"nope"}}, wantErr: "onlyGroups"},
+		{name: "negative phase timeout", options: DiscoveryOptions{PhaseTimeouts: PhaseTimeouts{Expand: -time.Second}}, wantErr: "phaseTimeouts"},
+		{name: "negative max collectors", options: DiscoveryOptions{MaxCollectors: -1}, wantErr: "maxCollectors"},
+		{name: "negative max log lines", options: DiscoveryOptions{MaxLogLines: -1}, wantErr: "maxLogLines"},
+		{name: "empty protected namespace", options: DiscoveryOptions{ProtectedNamespaces: []string{""}}, wantErr: "protectedNamespaces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_UsesDiscoveryOptionsRules(t *testing.T) {
+	config := getDefaultConfig()
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("Expected the default config to be valid, got %v", err)
+	}
+	config.DefaultOptions.MaxDepth = MaxMaxDepth + 1
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "maxDepth") {
+		t.Errorf("Expected the maxDepth range to be enforced, got %v", err)
+	}
+}
@@ -25,10 +25,11 @@ type CacheEntry struct {
 
 // PermissionKey uniquely identifies a permission check
 type PermissionKey struct {
-	Namespace string
-	Verb      string
-	GVR       schema.GroupVersionResource
-	Name      string
+	Namespace   string
+	Verb        string
+	GVR         schema.GroupVersionResource
+	Subresource string
+	Name        string
 }
 
 // NewPermissionCache creates a new permission cache with the specified TTL
@@ -121,8 +122,8 @@ func (pc *PermissionCache) StartCleanupTimer(ctx context.Context, interval time.
 
 // keyToString converts a permission key to a string for use as a map key
 func (pc *PermissionCache) keyToString(key PermissionKey) string {
-	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", 
-		key.Namespace, key.Verb, key.GVR.Group, key.GVR.Version, key.GVR.Resource, key.Name)
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s", 
+		key.Namespace, key.Verb, key.GVR.Group, key.GVR.Version, key.GVR.Resource, key.Subresource, key.Name)
 }
 
 // GetStats returns cache statistics
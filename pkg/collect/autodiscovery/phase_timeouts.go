@@ -0,0 +1,77 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Discovery phases that can be bounded by a timeout
+const (
+	PhaseNamespaceScan     = "namespace scan"
+	PhaseRBACCheck         = "RBAC check"
+	PhaseDependencyResolve = "dependency resolve"
+	PhaseExpand            = "expand"
+)
+
+// PhaseTimeouts bounds how long each discovery phase may run
+// A phase that reaches its deadline stops early and hands its partial results to the next phase
+// Zero values leave the phase bounded only by the caller's context
+type PhaseTimeouts struct {
+	NamespaceScan     time.Duration `json:"namespaceScan,omitempty" yaml:"namespaceScan,omitempty"`
+	RBACCheck         time.Duration `json:"rbacCheck,omitempty" yaml:"rbacCheck,omitempty"`
+	DependencyResolve time.Duration `json:"dependencyResolve,omitempty" yaml:"dependencyResolve,omitempty"`
+	Expand            time.Duration `json:"expand,omitempty" yaml:"expand,omitempty"`
+}
+
+// WithOverrides returns the timeouts with every positive override applied
+func (p PhaseTimeouts) WithOverrides(overrides PhaseTimeouts) PhaseTimeouts {
+	if overrides.NamespaceScan > 0 {
+		p.NamespaceScan = overrides.NamespaceScan
+	}
+	if overrides.RBACCheck > 0 {
+		p.RBACCheck = overrides.RBACCheck
+	}
+	if overrides.DependencyResolve > 0 {
+		p.DependencyResolve = overrides.DependencyResolve
+	}
+	if overrides.Expand > 0 {
+		p.Expand = overrides.Expand
+	}
+	return p
+}
+
+// Validate checks that no phase timeout is negative, reporting the first in phase order
+func (p PhaseTimeouts) Validate() error {
+	for _, phase := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{PhaseNamespaceScan, p.NamespaceScan},
+		{PhaseRBACCheck, p.RBACCheck},
+		{PhaseDependencyResolve, p.DependencyResolve},
+		{PhaseExpand, p.Expand},
+	} {
+		if phase.timeout < 0 {
+			return fmt.Errorf("%s timeout cannot be negative", phase.name)
+		}
+	}
+	return nil
+}
+
+// withPhaseTimeout derives the context for a phase, adding a deadline when a timeout is set
+func withPhaseTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// phaseExpired reports whether a phase's context is done, logging that the phase returns partial results
+func phaseExpired(ctx context.Context, phase string) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	fmt.Printf("Warning: %s phase stopped early (%v), continuing with partial results\n", phase, ctx.Err())
+	return true
+}
@@ -0,0 +1,147 @@
+package autodiscovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestPhaseTimeouts_WithOverrides(t *testing.T) {
+	base := PhaseTimeouts{NamespaceScan: time.Minute, RBACCheck: time.Minute}
+	merged := base.WithOverrides(PhaseTimeouts{RBACCheck: 10 * time.Second, Expand: 5 * time.Second})
+
+	if merged.NamespaceScan != time.Minute {
+		t.Errorf("Expected namespace scan timeout to be kept, got %v", merged.NamespaceScan)
+	}
+	if merged.RBACCheck != 10*time.Second {
+		t.Errorf("Expected RBAC check timeout override, got %v", merged.RBACCheck)
+	}
+	if merged.DependencyResolve != 0 {
+		t.Errorf("Expected no dependency resolve timeout, got %v", merged.DependencyResolve)
+	}
+	if merged.Expand != 5*time.Second {
+		t.Errorf("Expected expand timeout override, got %v", merged.Expand)
+	}
+}
+
+func TestPhaseTimeouts_Validate(t *testing.T) {
+	if err := (PhaseTimeouts{NamespaceScan: time.Second}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := (PhaseTimeouts{Expand: -time.Second}).Validate(); err == nil {
+		t.Errorf("Expected error for negative timeout")
+	}
+	for i := 0; i < 10; i++ {
+		err := (PhaseTimeouts{RBACCheck: -time.Second, Expand: -time.Second}).Validate()
+		if err == nil || err.Error() != "RBAC check timeout cannot be negative" {
+			t.Fatalf("Expected the first invalid phase to be reported every time, got %v", err)
+		}
+	}
+}
+
+func TestNamespaceScanner_ScanNamespaces_PartialOnDeadline(t *testing.T) {
+	dynamicClient := createTestDynamicClient(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	scanner := NewNamespaceScanner(kubernetesfake.NewSimpleClientset(), dynamicClient)
+
+	// Expire the phase right after pods are listed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dynamicClient.PrependReactor("list", "pods", func(action ktesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return false, nil, nil
+	})
+
+	resources, err := scanner.ScanNamespaces(ctx, []string{"default"}, ResourceFilter{})
+	if err != nil {
+		t.Fatalf("Expected partial results without error, got %v", err)
+	}
+	if len(resources) != 1 || resources[0].GVR.Resource != "pods" {
+		t.Errorf("Expected only the pod listed before the deadline, got %v", resources)
+	}
+}
+
+func TestRBACChecker_FilterByPermissions_PartialOnDeadline(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	checker := NewRBACChecker(kubeClient)
+
+	// Expire the phase once the first resource has been checked
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return true, &authv1.SelfSubjectAccessReview{Status: authv1.SubjectAccessReviewStatus{Allowed: true}}, nil
+	})
+
+	configMapsGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	resources := []Resource{
+		{GVR: configMapsGVR, Namespace: "default", Name: "first"},
+		{GVR: configMapsGVR, Namespace: "default", Name: "second"},
+	}
+
+	allowed, err := checker.FilterByPermissions(ctx, resources)
+	if err != nil {
+		t.Fatalf("Expected partial results without error, got %v", err)
+	}
+	if len(allowed) != 1 || allowed[0].Name != "first" {
+		t.Errorf("Expected only the resource checked before the deadline, got %v", allowed)
+	}
+}
+
+func TestResourceExpander_ExpandToCollectors_ExpiredContext(t *testing.T) {
+	expander := NewResourceExpander()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	collectors, err := expander.ExpandToCollectors(ctx, []Resource{
+		{GVR: podsGVR, Namespace: "default", Name: "web"},
+	}, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error on expired expand phase, got %v", err)
+	}
+	if len(collectors) != 0 {
+		t.Errorf("Expected no collectors after the deadline, got %d", len(collectors))
+	}
+}
+
+func TestDiscoverer_Discover_PhaseTimeouts(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset()
+	dynamicClient := createTestDynamicClient(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	discoverer := &Discoverer{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		rbacChecker:   NewRBACChecker(kubeClient),
+		nsScanner:     NewNamespaceScanner(kubeClient, dynamicClient),
+		expander:      NewResourceExpander(),
+	}
+
+	// Generous timeouts must not change the outcome
+	collectors, err := discoverer.Discover(context.Background(), DiscoveryOptions{
+		Namespaces: []string{"default"},
+		PhaseTimeouts: PhaseTimeouts{
+			NamespaceScan:     time.Minute,
+			RBACCheck:         time.Minute,
+			DependencyResolve: time.Minute,
+			Expand:            time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(collectors) == 0 {
+		t.Errorf("Expected collectors for the discovered pod")
+	}
+}
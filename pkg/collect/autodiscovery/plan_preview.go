@@ -0,0 +1,109 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// RuleMatchCount reports what one exclude or filter rule does to the previewed resources
+type RuleMatchCount struct {
+	Name      string `json:"name"`
+	Action    string `json:"action"`              // "include" or "exclude"
+	Candidate bool   `json:"candidate,omitempty"` // Passed to the preview rather than configured
+	Matched   int    `json:"matched"`             // Resources the rule matches on its own
+	Removed   int    `json:"removed"`             // Resources the rule removed, applied in order after the rules before it
+}
+
+// PlanPreview is the result of applying the configured and candidate rules to discovered resources
+type PlanPreview struct {
+	TotalResources int              `json:"totalResources"`
+	KeptResources  int              `json:"keptResources"`
+	Rules          []RuleMatchCount `json:"rules"`
+	Resources      []Resource       `json:"resources"`
+	Collectors     []CollectorSpec  `json:"collectors"`
+	Groups         map[string]int   `json:"groups"` // Collectors per group
+}
+
+// PlanPreview applies the configured rules plus candidate filter rules to already discovered resources
+// and returns per-rule match counts and the collector plan, without changing the configuration or
+// contacting the cluster. It lets UIs answer "what would this exclusion do?" interactively.
+// The plan covers the collectors expanded from the resources; collectors that query the cluster,
+// such as webhooks, storage or node sampling, are not previewed
+func (c *ConfigManager) PlanPreview(ctx context.Context, resources []Resource, candidates []ResourceFilterRule, opts DiscoveryOptions) (*PlanPreview, error) {
+	candidates = append([]ResourceFilterRule{}, candidates...)
+	for i := range candidates {
+		if candidates[i].Name == "" {
+			candidates[i].Name = fmt.Sprintf("candidate-%d", i)
+		}
+		if err := validateCandidateRule(candidates[i]); err != nil {
+			return nil, fmt.Errorf("candidate rule %s: %w", candidates[i].Name, err)
+		}
+	}
+
+	preview := &PlanPreview{
+		TotalResources: len(resources),
+		Rules:          []RuleMatchCount{},
+		Groups:         map[string]int{},
+	}
+
+	kept := resources
+	for _, step := range c.resourceFilterSteps(candidates) {
+		count := RuleMatchCount{Name: step.name, Action: step.action, Candidate: step.candidate}
+		for _, resource := range resources {
+			if step.matches(resource) {
+				count.Matched++
+			}
+		}
+		before := len(kept)
+		kept = step.apply(kept)
+		count.Removed = before - len(kept)
+		preview.Rules = append(preview.Rules, count)
+	}
+	kept = filterProtectedResources(kept, opts.ProtectedNamespaces)
+	if kept == nil {
+		kept = []Resource{}
+	}
+	preview.Resources = kept
+	preview.KeptResources = len(kept)
+
+	collectors, err := NewResourceExpander().ExpandToCollectors(ctx, kept, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand resources to collectors: %w", err)
+	}
+	collectors = applyTimeWindow(collectors, opts.TimeWindow)
+	assignCollectorGroups(collectors)
+	collectors = capLogLines(collectors, opts.MaxLogLines)
+	collectors = applyLogTimestamps(collectors, opts.NormalizeLogTimestamps)
+	collectors = applyLargeObjectLimits(collectors, opts.LargeObjects)
+	collectors = applySecretPolicy(collectors, opts.SecretPolicy)
+	collectors = batchByNamespace(collectors, opts.BatchByNamespace)
+	collectors = enforceProtectedNamespaces(collectors, opts.ProtectedNamespaces)
+	collectors = finalizeCollectors(filterCollectorGroups(collectors, opts))
+	if collectors == nil {
+		collectors = []CollectorSpec{}
+	}
+	preview.Collectors = collectors
+	for _, collector := range collectors {
+		preview.Groups[collector.Group]++
+	}
+
+	return preview, nil
+}
+
+// validateCandidateRule checks a candidate rule the way validateConfig checks configured ones
+func validateCandidateRule(rule ResourceFilterRule) error {
+	if rule.Action != "include" && rule.Action != "exclude" {
+		return fmt.Errorf("action must be include or exclude, got %q", rule.Action)
+	}
+	if rule.LabelSelector != "" {
+		if _, err := labels.Parse(rule.LabelSelector); err != nil {
+			return fmt.Errorf("invalid label selector: %w", err)
+		}
+	}
+	if _, err := ParseFieldSelectors(rule.FieldSelectors); err != nil {
+		return err
+	}
+	return nil
+}
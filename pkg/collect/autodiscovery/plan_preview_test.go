@@ -0,0 +1,113 @@
+package autodiscovery
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+func testPreviewResources() []Resource {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	return []Resource{
+		{GVR: pods, Namespace: "default", Name: "web", Labels: map[string]string{"app": "web"}},
+		{GVR: pods, Namespace: "default", Name: "db", Labels: map[string]string{"app": "db"}},
+		{GVR: pods, Namespace: "kube-system", Name: "coredns"},
+		{GVR: secretsResource, Namespace: "default", Name: "db-password"},
+		{GVR: deploymentsGVR, Namespace: "default", Name: "web"},
+	}
+}
+
+func TestConfigManager_PlanPreview(t *testing.T) {
+	configManager := NewConfigManager()
+	candidates := []ResourceFilterRule{
+		{Name: "no-secrets", MatchGVRs: []schema.GroupVersionResource{secretsResource}, Action: "exclude"},
+		{LabelSelector: "app=db", Action: "exclude"},
+	}
+
+	preview, err := configManager.PlanPreview(context.Background(), testPreviewResources(), candidates, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if preview.TotalResources != 5 || preview.KeptResources != 2 {
+		t.Errorf("Expected 2 of 5 resources to be kept, got %d of %d", preview.KeptResources, preview.TotalResources)
+	}
+
+	expected := []RuleMatchCount{
+		{Name: systemNamespaceExcludeReason, Action: "exclude", Matched: 1, Removed: 1},
+		{Name: "no-secrets", Action: "exclude", Candidate: true, Matched: 1, Removed: 1},
+		{Name: "candidate-1", Action: "exclude", Candidate: true, Matched: 1, Removed: 1},
+	}
+	if len(preview.Rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %+v", len(expected), preview.Rules)
+	}
+	for i, rule := range expected {
+		if preview.Rules[i] != rule {
+			t.Errorf("Expected rule %d to be %+v, got %+v", i, rule, preview.Rules[i])
+		}
+	}
+
+	if len(preview.Collectors) == 0 {
+		t.Fatalf("Expected a collector plan")
+	}
+	total := 0
+	for _, collector := range preview.Collectors {
+		if collector.ID == "" {
+			t.Errorf("Expected collector %s to have an ID", collector.Name)
+		}
+		if collector.Parameters["resource"] == "secrets" {
+			t.Errorf("Expected secrets to be left out of the plan")
+		}
+		total += preview.Groups[collector.Group]
+		preview.Groups[collector.Group] = 0
+	}
+	if total != len(preview.Collectors) {
+		t.Errorf("Expected group counts to add up to %d collectors, got %d", len(preview.Collectors), total)
+	}
+
+	if len(configManager.GetConfig().ResourceFilters) != 0 {
+		t.Errorf("Expected the preview to leave the configuration unchanged")
+	}
+}
+
+func TestConfigManager_PlanPreview_IncludeRules(t *testing.T) {
+	configManager := NewConfigManager()
+	candidates := []ResourceFilterRule{
+		{Name: "only-web", MatchLabels: map[string]string{"app": "web"}, Action: "include"},
+	}
+
+	preview, err := configManager.PlanPreview(context.Background(), testPreviewResources(), candidates, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The include rule matches one resource and removes the three left after the system namespace exclude
+	if rule := preview.Rules[1]; rule.Matched != 1 || rule.Removed != 3 {
+		t.Errorf("Expected only-web to match 1 and remove 3, got %+v", rule)
+	}
+	if preview.KeptResources != 1 || preview.Resources[0].Name != "web" {
+		t.Errorf("Expected only the web pod to be kept, got %+v", preview.Resources)
+	}
+}
+
+func TestConfigManager_PlanPreview_InvalidCandidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ResourceFilterRule
+	}{
+		{name: "missing action", rule: ResourceFilterRule{Name: "bad"}},
+		{name: "invalid label selector", rule: ResourceFilterRule{LabelSelector: "app in (", Action: "exclude"}},
+		{name: "invalid field selector", rule: ResourceFilterRule{FieldSelectors: []string{"status.phase"}, Action: "exclude"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfigManager().PlanPreview(context.Background(), testPreviewResources(), []ResourceFilterRule{tt.rule}, DiscoveryOptions{}); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,588 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// PodSecurityAnalysisPath is where the pod security analysis is written in the bundle
+const PodSecurityAnalysisPath = "cluster-info/pod-security.json"
+
+var (
+	securityContextConstraintsGVR = schema.GroupVersionResource{Group: "security.openshift.io", Version: "v1", Resource: "securitycontextconstraints"}
+	podSecurityPoliciesGVR        = schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies"}
+)
+
+// Pod Security Standard levels, from the least to the most restrictive
+const (
+	PodSecurityLevelPrivileged = "privileged"
+	PodSecurityLevelBaseline   = "baseline"
+	PodSecurityLevelRestricted = "restricted"
+)
+
+// Pod Security Admission modes, only enforce rejects pods
+const (
+	PodSecurityModeEnforce = "enforce"
+	PodSecurityModeWarn    = "warn"
+	PodSecurityModeAudit   = "audit"
+)
+
+// podSecurityLabelPrefix prefixes the Pod Security Admission namespace labels, e.g. pod-security.kubernetes.io/enforce
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+// sccAnnotation is set by OpenShift on admitted pods to the SecurityContextConstraints that admitted them
+const sccAnnotation = "openshift.io/scc"
+
+// podSecurityWorkloads are the discovered types whose pod templates are checked, with the path to the pod spec
+var podSecurityWorkloads = []struct {
+	gvr      schema.GroupVersionResource
+	kind     string
+	specPath []string
+}{
+	{gvr: deploymentsGVR, kind: "Deployment", specPath: []string{"spec", "template", "spec"}},
+	{gvr: statefulSetsGVR, kind: "StatefulSet", specPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, kind: "DaemonSet", specPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, kind: "Job", specPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, kind: "CronJob", specPath: []string{"spec", "jobTemplate", "spec", "template", "spec"}},
+	{gvr: podsGVR, kind: "Pod", specPath: []string{"spec"}},
+}
+
+// baselineCapabilities are the capabilities the baseline level allows containers to add
+var baselineCapabilities = map[string]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true, "MKNOD": true,
+	"NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// NamespacePodSecurity is the Pod Security Admission configuration of a namespace, from its labels
+// Levels left unset fall back to the API server's admission configuration, privileged unless changed
+type NamespacePodSecurity struct {
+	Namespace      string `json:"namespace"`
+	Enforce        string `json:"enforce,omitempty"`
+	EnforceVersion string `json:"enforceVersion,omitempty"`
+	Warn           string `json:"warn,omitempty"`
+	Audit          string `json:"audit,omitempty"`
+}
+
+// ContainerSecurityContext is the effective security context of a container, pod-level fields applied
+type ContainerSecurityContext struct {
+	Name                     string   `json:"name"`
+	Init                     bool     `json:"init,omitempty"`
+	Privileged               bool     `json:"privileged,omitempty"`
+	AllowPrivilegeEscalation *bool    `json:"allowPrivilegeEscalation,omitempty"`
+	RunAsNonRoot             *bool    `json:"runAsNonRoot,omitempty"`
+	RunAsUser                *int64   `json:"runAsUser,omitempty"`
+	ReadOnlyRootFilesystem   bool     `json:"readOnlyRootFilesystem,omitempty"`
+	SeccompProfile           string   `json:"seccompProfile,omitempty"` // RuntimeDefault, Localhost or Unconfined
+	CapabilitiesAdd          []string `json:"capabilitiesAdd,omitempty"`
+	CapabilitiesDrop         []string `json:"capabilitiesDrop,omitempty"`
+	HostPorts                []int32  `json:"hostPorts,omitempty"`
+}
+
+// PodSecurityViolation is a Pod Security Standard check a workload fails
+type PodSecurityViolation struct {
+	Check     string `json:"check"`          // e.g. privileged, runAsNonRoot
+	Level     string `json:"level"`          // The lowest level that includes the check
+	Mode      string `json:"mode,omitempty"` // The strongest namespace mode at that level or above, empty when no label covers it
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+}
+
+// WorkloadPodSecurity is the effective security context of a discovered workload's pods and the checks it fails
+type WorkloadPodSecurity struct {
+	Namespace   string                     `json:"namespace"`
+	Kind        string                     `json:"kind"`
+	Name        string                     `json:"name"`
+	HostNetwork bool                       `json:"hostNetwork,omitempty"`
+	HostPID     bool                       `json:"hostPID,omitempty"`
+	HostIPC     bool                       `json:"hostIPC,omitempty"`
+	Containers  []ContainerSecurityContext `json:"containers"`
+	SCC         string                     `json:"scc,omitempty"`     // Pods only, the SecurityContextConstraints that admitted the pod
+	Blocked     bool                       `json:"blocked,omitempty"` // Fails a check the namespace enforces, new pods are rejected
+	Violations  []PodSecurityViolation     `json:"violations,omitempty"`
+}
+
+// SecurityContextConstraintsSummary is an OpenShift SecurityContextConstraints
+type SecurityContextConstraintsSummary struct {
+	Name                     string   `json:"name"`
+	Priority                 *int64   `json:"priority,omitempty"`
+	AllowPrivilegedContainer bool     `json:"allowPrivilegedContainer"`
+	AllowHostNetwork         bool     `json:"allowHostNetwork"`
+	RunAsUser                string   `json:"runAsUser,omitempty"` // Strategy type, e.g. MustRunAsRange
+	SELinuxContext           string   `json:"seLinuxContext,omitempty"`
+	Users                    []string `json:"users,omitempty"`
+	Groups                   []string `json:"groups,omitempty"`
+}
+
+// PodSecurityPolicySummary is a PodSecurityPolicy, served by clusters older than 1.25
+type PodSecurityPolicySummary struct {
+	Name        string `json:"name"`
+	Privileged  bool   `json:"privileged"`
+	HostNetwork bool   `json:"hostNetwork"`
+	RunAsUser   string `json:"runAsUser,omitempty"` // Rule, e.g. MustRunAsNonRoot
+}
+
+// PodSecuritySummary provides totals for the pod security analysis
+type PodSecuritySummary struct {
+	Workloads        int `json:"workloads"`
+	BlockedWorkloads int `json:"blockedWorkloads"`
+	WarnedWorkloads  int `json:"warnedWorkloads"` // Fail a check the namespace warns or audits on only
+	Violations       int `json:"violations"`
+}
+
+// PodSecurityReport is the pod security analysis written to the bundle
+type PodSecurityReport struct {
+	Namespaces                 []NamespacePodSecurity              `json:"namespaces"`
+	Workloads                  []WorkloadPodSecurity               `json:"workloads"`
+	SecurityContextConstraints []SecurityContextConstraintsSummary `json:"securityContextConstraints,omitempty"`
+	PodSecurityPolicies        []PodSecurityPolicySummary          `json:"podSecurityPolicies,omitempty"`
+	Summary                    PodSecuritySummary                  `json:"summary"`
+	Problems                   []string                            `json:"problems,omitempty"`
+}
+
+// PodSecurityAnalyzer checks discovered workloads against the Pod Security Admission labels of their namespaces
+type PodSecurityAnalyzer struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewPodSecurityAnalyzer creates a new PodSecurityAnalyzer
+func NewPodSecurityAnalyzer(dynamicClient dynamic.Interface) *PodSecurityAnalyzer {
+	return &PodSecurityAnalyzer{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GeneratePodSecurityCollectors returns the pod security analysis, and the SecurityContextConstraints and
+// PodSecurityPolicies when the cluster serves them. Nothing is generated unless workloads were discovered
+func (a *PodSecurityAnalyzer) GeneratePodSecurityCollectors(ctx context.Context, resources []Resource) []CollectorSpec {
+	report := a.BuildReport(ctx, resources)
+	if len(report.Workloads) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Printf("Warning: failed to serialize pod security analysis: %v\n", err)
+		return nil
+	}
+
+	// Pods rejected by admission never show up as Pending, so the analysis is often the only trace
+	priority := PriorityNormal
+	if report.Summary.BlockedWorkloads > 0 {
+		priority = PriorityHigh
+	}
+	collectors := []CollectorSpec{
+		{
+			Type:     "data",
+			Name:     "auto-pod-security-analysis",
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(priority),
+			Parameters: map[string]interface{}{
+				"name": PodSecurityAnalysisPath,
+				"data": string(data),
+			},
+		},
+	}
+	if len(report.SecurityContextConstraints) > 0 {
+		collectors = append(collectors, podSecurityPolicyCollector(securityContextConstraintsGVR))
+	}
+	if len(report.PodSecurityPolicies) > 0 {
+		collectors = append(collectors, podSecurityPolicyCollector(podSecurityPoliciesGVR))
+	}
+	return collectors
+}
+
+// BuildReport reads the namespace labels and the pod specs of the discovered workloads, listing failures as problems
+// Pods owned by a discovered workload are checked through their owner's template
+func (a *PodSecurityAnalyzer) BuildReport(ctx context.Context, resources []Resource) PodSecurityReport {
+	report := PodSecurityReport{Namespaces: []NamespacePodSecurity{}, Workloads: []WorkloadPodSecurity{}}
+
+	discovered := make(map[schema.GroupVersionResource]map[string]map[string]bool) // GVR, namespace, name
+	namespaces := make(map[string]bool)
+	for _, resource := range resources {
+		if resource.Namespace == "" || !isPodSecurityWorkload(resource.GVR) || (resource.GVR == podsGVR && len(resource.OwnerRefs) > 0) {
+			continue
+		}
+		if discovered[resource.GVR] == nil {
+			discovered[resource.GVR] = make(map[string]map[string]bool)
+		}
+		if discovered[resource.GVR][resource.Namespace] == nil {
+			discovered[resource.GVR][resource.Namespace] = make(map[string]bool)
+		}
+		discovered[resource.GVR][resource.Namespace][resource.Name] = true
+		namespaces[resource.Namespace] = true
+	}
+	if len(namespaces) == 0 {
+		return report
+	}
+
+	policies := make(map[string]NamespacePodSecurity)
+	for _, namespace := range sortedKeys(namespaces) {
+		policy := NamespacePodSecurity{Namespace: namespace}
+		if ns, err := a.dynamicClient.Resource(namespacesGVR).Get(ctx, namespace, metav1.GetOptions{}); err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to get namespace %s, its pod security labels are unknown: %v", namespace, err))
+		} else {
+			policy = namespacePodSecurity(namespace, ns.GetLabels())
+		}
+		policies[namespace] = policy
+		report.Namespaces = append(report.Namespaces, policy)
+	}
+
+	for _, workload := range podSecurityWorkloads {
+		for _, namespace := range sortedKeys(namespacesOf(discovered[workload.gvr])) {
+			list, err := a.dynamicClient.Resource(workload.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				report.Problems = append(report.Problems, fmt.Sprintf("failed to list %s in %s: %v", workload.gvr.Resource, namespace, err))
+				continue
+			}
+			for _, item := range list.Items {
+				if !discovered[workload.gvr][namespace][item.GetName()] {
+					continue
+				}
+				result, err := workloadPodSecurity(item, workload.kind, workload.specPath, policies[namespace])
+				if err != nil {
+					report.Problems = append(report.Problems, fmt.Sprintf("failed to read the pod spec of %s %s/%s: %v", workload.kind, namespace, item.GetName(), err))
+					continue
+				}
+				report.Workloads = append(report.Workloads, result)
+			}
+		}
+	}
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		x, y := report.Workloads[i], report.Workloads[j]
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		if x.Kind != y.Kind {
+			return x.Kind < y.Kind
+		}
+		return x.Name < y.Name
+	})
+
+	report.SecurityContextConstraints = a.securityContextConstraints(ctx, &report)
+	report.PodSecurityPolicies = a.podSecurityPolicies(ctx, &report)
+	report.Summary = summarizePodSecurity(report.Workloads)
+	return report
+}
+
+// securityContextConstraints lists the OpenShift SCCs, nil on clusters that do not serve them
+func (a *PodSecurityAnalyzer) securityContextConstraints(ctx context.Context, report *PodSecurityReport) []SecurityContextConstraintsSummary {
+	list, err := a.dynamicClient.Resource(securityContextConstraintsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if !isNotServed(err) {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to list security context constraints: %v", err))
+		}
+		return nil
+	}
+
+	var sccs []SecurityContextConstraintsSummary
+	for _, item := range list.Items {
+		scc := SecurityContextConstraintsSummary{Name: item.GetName()}
+		if priority, found, _ := unstructured.NestedInt64(item.Object, "priority"); found {
+			scc.Priority = &priority
+		}
+		scc.AllowPrivilegedContainer, _, _ = unstructured.NestedBool(item.Object, "allowPrivilegedContainer")
+		scc.AllowHostNetwork, _, _ = unstructured.NestedBool(item.Object, "allowHostNetwork")
+		scc.RunAsUser, _, _ = unstructured.NestedString(item.Object, "runAsUser", "type")
+		scc.SELinuxContext, _, _ = unstructured.NestedString(item.Object, "seLinuxContext", "type")
+		scc.Users, _, _ = unstructured.NestedStringSlice(item.Object, "users")
+		scc.Groups, _, _ = unstructured.NestedStringSlice(item.Object, "groups")
+		sccs = append(sccs, scc)
+	}
+	sort.Slice(sccs, func(i, j int) bool { return sccs[i].Name < sccs[j].Name })
+	return sccs
+}
+
+// podSecurityPolicies lists the PodSecurityPolicies, nil on clusters that no longer serve them
+func (a *PodSecurityAnalyzer) podSecurityPolicies(ctx context.Context, report *PodSecurityReport) []PodSecurityPolicySummary {
+	list, err := a.dynamicClient.Resource(podSecurityPoliciesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if !isNotServed(err) {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to list pod security policies: %v", err))
+		}
+		return nil
+	}
+
+	var psps []PodSecurityPolicySummary
+	for _, item := range list.Items {
+		psp := PodSecurityPolicySummary{Name: item.GetName()}
+		psp.Privileged, _, _ = unstructured.NestedBool(item.Object, "spec", "privileged")
+		psp.HostNetwork, _, _ = unstructured.NestedBool(item.Object, "spec", "hostNetwork")
+		psp.RunAsUser, _, _ = unstructured.NestedString(item.Object, "spec", "runAsUser", "rule")
+		psps = append(psps, psp)
+	}
+	sort.Slice(psps, func(i, j int) bool { return psps[i].Name < psps[j].Name })
+	return psps
+}
+
+// namespacePodSecurity reads the Pod Security Admission labels of a namespace
+func namespacePodSecurity(namespace string, labels map[string]string) NamespacePodSecurity {
+	return NamespacePodSecurity{
+		Namespace:      namespace,
+		Enforce:        labels[podSecurityLabelPrefix+PodSecurityModeEnforce],
+		EnforceVersion: labels[podSecurityLabelPrefix+PodSecurityModeEnforce+"-version"],
+		Warn:           labels[podSecurityLabelPrefix+PodSecurityModeWarn],
+		Audit:          labels[podSecurityLabelPrefix+PodSecurityModeAudit],
+	}
+}
+
+// workloadPodSecurity reads the pod spec of a workload and checks it against the Pod Security Standards
+func workloadPodSecurity(item unstructured.Unstructured, kind string, specPath []string, policy NamespacePodSecurity) (WorkloadPodSecurity, error) {
+	result := WorkloadPodSecurity{Namespace: item.GetNamespace(), Kind: kind, Name: item.GetName(), Containers: []ContainerSecurityContext{}}
+	rawSpec, found, err := unstructured.NestedMap(item.Object, specPath...)
+	if err != nil || !found {
+		return result, fmt.Errorf("no pod spec at %s", strings.Join(specPath, "."))
+	}
+	var spec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return result, err
+	}
+
+	result.HostNetwork, result.HostPID, result.HostIPC = spec.HostNetwork, spec.HostPID, spec.HostIPC
+	if kind == "Pod" {
+		result.SCC = item.GetAnnotations()[sccAnnotation]
+	}
+	for _, container := range spec.InitContainers {
+		result.Containers = append(result.Containers, effectiveSecurityContext(container, spec.SecurityContext, true))
+	}
+	for _, container := range spec.Containers {
+		result.Containers = append(result.Containers, effectiveSecurityContext(container, spec.SecurityContext, false))
+	}
+
+	for _, violation := range podSecurityViolations(spec, result) {
+		violation.Mode = podSecurityMode(policy, violation.Level)
+		if violation.Mode == PodSecurityModeEnforce {
+			result.Blocked = true
+		}
+		result.Violations = append(result.Violations, violation)
+	}
+	return result, nil
+}
+
+// effectiveSecurityContext applies the pod security context under the container's own
+func effectiveSecurityContext(container corev1.Container, pod *corev1.PodSecurityContext, init bool) ContainerSecurityContext {
+	result := ContainerSecurityContext{Name: container.Name, Init: init}
+	if pod != nil {
+		result.RunAsNonRoot = pod.RunAsNonRoot
+		result.RunAsUser = pod.RunAsUser
+		if pod.SeccompProfile != nil {
+			result.SeccompProfile = string(pod.SeccompProfile.Type)
+		}
+	}
+	for _, port := range container.Ports {
+		if port.HostPort != 0 {
+			result.HostPorts = append(result.HostPorts, port.HostPort)
+		}
+	}
+
+	sc := container.SecurityContext
+	if sc == nil {
+		return result
+	}
+	result.Privileged = sc.Privileged != nil && *sc.Privileged
+	result.AllowPrivilegeEscalation = sc.AllowPrivilegeEscalation
+	result.ReadOnlyRootFilesystem = sc.ReadOnlyRootFilesystem != nil && *sc.ReadOnlyRootFilesystem
+	if sc.RunAsNonRoot != nil {
+		result.RunAsNonRoot = sc.RunAsNonRoot
+	}
+	if sc.RunAsUser != nil {
+		result.RunAsUser = sc.RunAsUser
+	}
+	if sc.SeccompProfile != nil {
+		result.SeccompProfile = string(sc.SeccompProfile.Type)
+	}
+	if sc.Capabilities != nil {
+		for _, capability := range sc.Capabilities.Add {
+			result.CapabilitiesAdd = append(result.CapabilitiesAdd, string(capability))
+		}
+		for _, capability := range sc.Capabilities.Drop {
+			result.CapabilitiesDrop = append(result.CapabilitiesDrop, string(capability))
+		}
+	}
+	return result
+}
+
+// podSecurityViolations runs the baseline and restricted checks of the Pod Security Standards that the pod spec
+// decides. AppArmor, SELinux, /proc mount and sysctl checks are left to the API server's warnings
+func podSecurityViolations(spec corev1.PodSpec, workload WorkloadPodSecurity) []PodSecurityViolation {
+	var violations []PodSecurityViolation
+	add := func(check, level, container, message string) {
+		violations = append(violations, PodSecurityViolation{Check: check, Level: level, Container: container, Message: message})
+	}
+
+	var hostNamespaces []string
+	for name, shared := range map[string]bool{"hostNetwork": spec.HostNetwork, "hostPID": spec.HostPID, "hostIPC": spec.HostIPC} {
+		if shared {
+			hostNamespaces = append(hostNamespaces, name)
+		}
+	}
+	if len(hostNamespaces) > 0 {
+		sort.Strings(hostNamespaces)
+		add("hostNamespaces", PodSecurityLevelBaseline, "", fmt.Sprintf("%s must not be set", strings.Join(hostNamespaces, ", ")))
+	}
+
+	var restrictedVolumes []string
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			add("hostPathVolumes", PodSecurityLevelBaseline, "", fmt.Sprintf("volume %s uses hostPath %s", volume.Name, volume.HostPath.Path))
+		}
+		if !isRestrictedVolumeSource(volume.VolumeSource) {
+			restrictedVolumes = append(restrictedVolumes, volume.Name)
+		}
+	}
+	if len(restrictedVolumes) > 0 {
+		add("restrictedVolumes", PodSecurityLevelRestricted, "", fmt.Sprintf("volumes %s use types other than configMap, csi, downwardAPI, emptyDir, ephemeral, persistentVolumeClaim, projected or secret", strings.Join(restrictedVolumes, ", ")))
+	}
+
+	for _, container := range workload.Containers {
+		if container.Privileged {
+			add("privileged", PodSecurityLevelBaseline, container.Name, "privileged must not be true")
+		}
+		if len(container.HostPorts) > 0 {
+			add("hostPorts", PodSecurityLevelBaseline, container.Name, fmt.Sprintf("host ports %v must not be used", container.HostPorts))
+		}
+		if container.SeccompProfile == string(corev1.SeccompProfileTypeUnconfined) {
+			add("seccompProfile", PodSecurityLevelBaseline, container.Name, "seccompProfile must not be Unconfined")
+		} else if container.SeccompProfile == "" {
+			add("seccompProfile", PodSecurityLevelRestricted, container.Name, "seccompProfile must be RuntimeDefault or Localhost")
+		}
+
+		var beyondBaseline, beyondRestricted []string
+		for _, capability := range container.CapabilitiesAdd {
+			if !baselineCapabilities[capability] {
+				beyondBaseline = append(beyondBaseline, capability)
+			}
+			if capability != "NET_BIND_SERVICE" {
+				beyondRestricted = append(beyondRestricted, capability)
+			}
+		}
+		if len(beyondBaseline) > 0 {
+			add("capabilities", PodSecurityLevelBaseline, container.Name, fmt.Sprintf("capabilities %s must not be added", strings.Join(beyondBaseline, ", ")))
+		}
+		if !containsString(container.CapabilitiesDrop, "ALL") {
+			add("capabilities", PodSecurityLevelRestricted, container.Name, "capabilities must drop ALL")
+		}
+		if len(beyondRestricted) > len(beyondBaseline) { // The baseline check already reports the others
+			add("capabilities", PodSecurityLevelRestricted, container.Name, "only NET_BIND_SERVICE may be added")
+		}
+
+		if container.AllowPrivilegeEscalation == nil || *container.AllowPrivilegeEscalation {
+			add("allowPrivilegeEscalation", PodSecurityLevelRestricted, container.Name, "allowPrivilegeEscalation must be false")
+		}
+		if container.RunAsNonRoot == nil || !*container.RunAsNonRoot {
+			add("runAsNonRoot", PodSecurityLevelRestricted, container.Name, "runAsNonRoot must be true")
+		}
+		if container.RunAsUser != nil && *container.RunAsUser == 0 {
+			add("runAsUser", PodSecurityLevelRestricted, container.Name, "runAsUser must not be 0")
+		}
+	}
+	return violations
+}
+
+// isRestrictedVolumeSource reports whether the restricted level allows the volume type
+func isRestrictedVolumeSource(source corev1.VolumeSource) bool {
+	return source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil || source.EmptyDir != nil ||
+		source.Ephemeral != nil || source.PersistentVolumeClaim != nil || source.Projected != nil || source.Secret != nil
+}
+
+// podSecurityMode returns the strongest mode of the namespace whose level includes checks of the given level
+func podSecurityMode(policy NamespacePodSecurity, level string) string {
+	for _, mode := range []struct {
+		name  string
+		level string
+	}{
+		{PodSecurityModeEnforce, policy.Enforce},
+		{PodSecurityModeWarn, policy.Warn},
+		{PodSecurityModeAudit, policy.Audit},
+	} {
+		if podSecurityLevelRank(mode.level) >= podSecurityLevelRank(level) {
+			return mode.name
+		}
+	}
+	return ""
+}
+
+// podSecurityLevelRank orders the levels, unset and unknown levels rank as privileged
+func podSecurityLevelRank(level string) int {
+	switch level {
+	case PodSecurityLevelRestricted:
+		return 2
+	case PodSecurityLevelBaseline:
+		return 1
+	}
+	return 0
+}
+
+func summarizePodSecurity(workloads []WorkloadPodSecurity) PodSecuritySummary {
+	summary := PodSecuritySummary{Workloads: len(workloads)}
+	for _, workload := range workloads {
+		summary.Violations += len(workload.Violations)
+		if workload.Blocked {
+			summary.BlockedWorkloads++
+			continue
+		}
+		for _, violation := range workload.Violations {
+			if violation.Mode != "" {
+				summary.WarnedWorkloads++
+				break
+			}
+		}
+	}
+	return summary
+}
+
+// podSecurityPolicyCollector collects every object of a cluster-scoped policy type
+func podSecurityPolicyCollector(gvr schema.GroupVersionResource) CollectorSpec {
+	return CollectorSpec{
+		Type:     CollectorTypeClusterResources,
+		Name:     "auto-resources-" + gvr.Resource,
+		Group:    CollectorGroupClusterInfo,
+		Priority: int(PriorityNormal),
+		Parameters: ClusterResourcesParams{
+			Group:    gvr.Group,
+			Version:  gvr.Version,
+			Resource: gvr.Resource,
+		}.ToMap(),
+	}
+}
+
+func isPodSecurityWorkload(gvr schema.GroupVersionResource) bool {
+	for _, workload := range podSecurityWorkloads {
+		if workload.gvr == gvr {
+			return true
+		}
+	}
+	return false
+}
+
+// isNotServed reports whether a list failed because the cluster does not serve the type
+func isNotServed(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+func namespacesOf(names map[string]map[string]bool) map[string]bool {
+	namespaces := make(map[string]bool, len(names))
+	for namespace := range names {
+		namespaces[namespace] = true
+	}
+	return namespaces
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
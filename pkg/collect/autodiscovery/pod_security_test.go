@@ -0,0 +1,204 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func testPodSecurityNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestPodSecurityAnalyzer_GeneratePodSecurityCollectors(t *testing.T) {
+	privileged := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "app"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			HostNetwork: true,
+			Containers: []corev1.Container{{
+				Name:            "agent",
+				SecurityContext: &corev1.SecurityContext{Privileged: boolPtr(true)},
+			}},
+		}}},
+	}
+	hardened := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   boolPtr(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name: "web",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: boolPtr(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+		}}},
+	}
+	rootPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "tools", Annotations: map[string]string{sccAnnotation: "anyuid"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "shell"}}},
+	}
+	scc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":               "security.openshift.io/v1",
+		"kind":                     "SecurityContextConstraints",
+		"metadata":                 map[string]interface{}{"name": "anyuid"},
+		"priority":                 int64(10),
+		"allowPrivilegedContainer": false,
+		"runAsUser":                map[string]interface{}{"type": "RunAsAny"},
+		"groups":                   []interface{}{"system:cluster-admins"},
+	}}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(createTestScheme(), map[schema.GroupVersionResource]string{
+		securityContextConstraintsGVR: "SecurityContextConstraintsList",
+		podSecurityPoliciesGVR:        "PodSecurityPolicyList",
+	},
+		testPodSecurityNamespace("app", map[string]string{
+			podSecurityLabelPrefix + "enforce": PodSecurityLevelBaseline,
+			podSecurityLabelPrefix + "warn":    PodSecurityLevelRestricted,
+		}),
+		testPodSecurityNamespace("tools", nil),
+		privileged, hardened, rootPod,
+	)
+	// Created by GVR, the fake client cannot guess the resource of the SecurityContextConstraints kind
+	if _, err := client.Resource(securityContextConstraintsGVR).Create(context.Background(), scc, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client.PrependReactor("list", "podsecuritypolicies", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "policy", Resource: "podsecuritypolicies"}, "")
+	})
+
+	resources := []Resource{
+		{GVR: deploymentsGVR, Namespace: "app", Name: "agent"},
+		{GVR: deploymentsGVR, Namespace: "app", Name: "web"},
+		{GVR: podsGVR, Namespace: "app", Name: "web-5d9c", OwnerRefs: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d9c"}}},
+		{GVR: podsGVR, Namespace: "tools", Name: "debug"},
+	}
+	collectors := NewPodSecurityAnalyzer(client).GeneratePodSecurityCollectors(context.Background(), resources)
+	if len(collectors) != 2 {
+		t.Fatalf("Expected the analysis and SCC collectors, got %d", len(collectors))
+	}
+	if collectors[0].Priority != int(PriorityHigh) || collectors[1].Name != "auto-resources-securitycontextconstraints" {
+		t.Errorf("Expected a high priority analysis and the SCCs, got %+v", collectors)
+	}
+
+	var report PodSecurityReport
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse analysis: %v", err)
+	}
+	if len(report.Problems) != 0 {
+		t.Errorf("Expected PSPs not served to be skipped silently, got %v", report.Problems)
+	}
+	if len(report.Namespaces) != 2 || report.Namespaces[0].Enforce != PodSecurityLevelBaseline || report.Namespaces[0].Warn != PodSecurityLevelRestricted {
+		t.Errorf("Expected the app namespace labels, got %+v", report.Namespaces)
+	}
+	if len(report.SecurityContextConstraints) != 1 || report.SecurityContextConstraints[0].RunAsUser != "RunAsAny" {
+		t.Errorf("Expected the anyuid SCC, got %+v", report.SecurityContextConstraints)
+	}
+	if report.Summary.Workloads != 3 || report.Summary.BlockedWorkloads != 1 || report.Summary.WarnedWorkloads != 0 {
+		t.Fatalf("Expected 3 workloads, 1 blocked, got %+v", report.Summary)
+	}
+
+	agent, web, debug := report.Workloads[0], report.Workloads[1], report.Workloads[2]
+	if agent.Name != "agent" || !agent.Blocked || !agent.HostNetwork {
+		t.Errorf("Expected the privileged agent to be blocked, got %+v", agent)
+	}
+	enforced := map[string]bool{}
+	for _, violation := range agent.Violations {
+		if violation.Mode == PodSecurityModeEnforce {
+			enforced[violation.Check] = true
+		}
+	}
+	if !enforced["privileged"] || !enforced["hostNamespaces"] || enforced["runAsNonRoot"] {
+		t.Errorf("Expected privileged and hostNamespaces to be enforced, got %+v", agent.Violations)
+	}
+	if web.Blocked || len(web.Violations) != 0 {
+		t.Errorf("Expected the hardened web deployment to pass restricted, got %+v", web.Violations)
+	}
+	if c := web.Containers[0]; c.RunAsNonRoot == nil || !*c.RunAsNonRoot || c.SeccompProfile != "RuntimeDefault" {
+		t.Errorf("Expected the pod security context to apply to the container, got %+v", c)
+	}
+	if debug.Kind != "Pod" || debug.SCC != "anyuid" || debug.Blocked || len(debug.Violations) == 0 || debug.Violations[0].Mode != "" {
+		t.Errorf("Expected the unlabeled namespace to report violations without a mode, got %+v", debug)
+	}
+
+	if collectors := NewPodSecurityAnalyzer(client).GeneratePodSecurityCollectors(context.Background(), []Resource{{GVR: servicesGVR, Namespace: "app", Name: "web"}}); collectors != nil {
+		t.Errorf("Expected no collectors without workloads, got %d", len(collectors))
+	}
+}
+
+func TestPodSecurityViolations(t *testing.T) {
+	tests := []struct {
+		name      string
+		container corev1.Container
+		spec      corev1.PodSpec
+		expected  map[string]string // check to level
+	}{
+		{
+			name:      "capabilities beyond baseline",
+			container: corev1.Container{Name: "c", SecurityContext: &corev1.SecurityContext{Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN"}, Drop: []corev1.Capability{"ALL"}}}},
+			expected:  map[string]string{"capabilities": PodSecurityLevelBaseline},
+		},
+		{
+			name:      "unconfined seccomp",
+			container: corev1.Container{Name: "c", SecurityContext: &corev1.SecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}}},
+			expected:  map[string]string{"seccompProfile": PodSecurityLevelBaseline},
+		},
+		{
+			name:      "host path and port",
+			container: corev1.Container{Name: "c", Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}}},
+			spec:      corev1.PodSpec{Volumes: []corev1.Volume{{Name: "docker", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}}}},
+			expected:  map[string]string{"hostPathVolumes": PodSecurityLevelBaseline, "hostPorts": PodSecurityLevelBaseline, "restrictedVolumes": PodSecurityLevelRestricted},
+		},
+		{
+			name:      "root user",
+			container: corev1.Container{Name: "c", SecurityContext: &corev1.SecurityContext{RunAsUser: new(int64)}},
+			expected:  map[string]string{"runAsUser": PodSecurityLevelRestricted, "runAsNonRoot": PodSecurityLevelRestricted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Containers = []corev1.Container{tt.container}
+			workload := WorkloadPodSecurity{Containers: []ContainerSecurityContext{effectiveSecurityContext(tt.container, nil, false)}}
+			found := map[string]string{}
+			for _, violation := range podSecurityViolations(tt.spec, workload) {
+				if level, seen := found[violation.Check]; !seen || podSecurityLevelRank(violation.Level) < podSecurityLevelRank(level) {
+					found[violation.Check] = violation.Level
+				}
+			}
+			for check, level := range tt.expected {
+				if found[check] != level {
+					t.Errorf("Expected %s at %s, got %v", check, level, found)
+				}
+			}
+		})
+	}
+}
+
+func TestPodSecurityMode(t *testing.T) {
+	policy := NamespacePodSecurity{Enforce: PodSecurityLevelBaseline, Audit: PodSecurityLevelRestricted}
+	if mode := podSecurityMode(policy, PodSecurityLevelBaseline); mode != PodSecurityModeEnforce {
+		t.Errorf("Expected baseline checks to be enforced, got %q", mode)
+	}
+	if mode := podSecurityMode(policy, PodSecurityLevelRestricted); mode != PodSecurityModeAudit {
+		t.Errorf("Expected restricted checks to be audited, got %q", mode)
+	}
+	if mode := podSecurityMode(NamespacePodSecurity{}, PodSecurityLevelBaseline); mode != "" {
+		t.Errorf("Expected no mode without labels, got %q", mode)
+	}
+}
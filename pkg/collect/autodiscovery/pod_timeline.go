@@ -0,0 +1,301 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultTimelineEntryLimit is the number of most recent entries kept per namespace timeline
+const DefaultTimelineEntryLimit = 500
+
+// Timeline entry kinds
+const (
+	TimelineEntryPodStarted          = "PodStarted"
+	TimelineEntryContainerTerminated = "ContainerTerminated"
+	TimelineEntryContainerRestarted  = "ContainerRestarted"
+	TimelineEntryEvent               = "Event"
+)
+
+var eventsGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}
+
+// timelineKinds maps the discovered resources whose events belong on a timeline to their kind
+var timelineKinds = map[string]string{
+	"pods":                   "Pod",
+	"deployments":            "Deployment",
+	"statefulsets":           "StatefulSet",
+	"daemonsets":             "DaemonSet",
+	"replicasets":            "ReplicaSet",
+	"jobs":                   "Job",
+	"cronjobs":               "CronJob",
+	"persistentvolumeclaims": "PersistentVolumeClaim",
+}
+
+// TimelineEntry is a single point on a namespace timeline
+type TimelineEntry struct {
+	Time      string `json:"time"`
+	Kind      string `json:"kind"`
+	Object    string `json:"object"` // Kind/name of the object the entry is about
+	Container string `json:"container,omitempty"`
+	Type      string `json:"type,omitempty"` // Event type, Normal or Warning
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Count     int64  `json:"count,omitempty"`
+	ExitCode  *int64 `json:"exitCode,omitempty"`
+}
+
+// PodRestartSummary is the restart count of one container together with its last termination
+type PodRestartSummary struct {
+	Pod             string `json:"pod"`
+	Container       string `json:"container"`
+	RestartCount    int64  `json:"restartCount"`
+	LastTermination string `json:"lastTermination,omitempty"`
+	LastExitCode    *int64 `json:"lastExitCode,omitempty"`
+}
+
+// NamespaceTimeline is the chronological story of the discovered workloads in one namespace
+type NamespaceTimeline struct {
+	Namespace string              `json:"namespace"`
+	Restarts  []PodRestartSummary `json:"restarts"`
+	Entries   []TimelineEntry     `json:"entries"`
+	Truncated bool                `json:"truncated,omitempty"`
+	Problems  []string            `json:"problems,omitempty"`
+}
+
+// PodTimeline generates per-namespace restart and event timelines for discovered workloads
+type PodTimeline struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewPodTimeline creates a new PodTimeline
+func NewPodTimeline(dynamicClient dynamic.Interface) *PodTimeline {
+	return &PodTimeline{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateTimelineCollectors returns one data collector per namespace with discovered pods, holding
+// pod restarts, last terminations and events of the discovered workloads in chronological order
+func (p *PodTimeline) GenerateTimelineCollectors(ctx context.Context, resources []Resource, window TimeWindow) []CollectorSpec {
+	pods := make(map[string]map[string]bool)
+	objects := make(map[string]map[string]bool)
+	for _, resource := range resources {
+		kind, ok := timelineKinds[resource.GVR.Resource]
+		if !ok || resource.GVR.Group == "metrics.k8s.io" || resource.Namespace == "" {
+			continue
+		}
+		if objects[resource.Namespace] == nil {
+			objects[resource.Namespace] = make(map[string]bool)
+		}
+		objects[resource.Namespace][kind+"/"+resource.Name] = true
+		if kind != "Pod" {
+			continue
+		}
+		if pods[resource.Namespace] == nil {
+			pods[resource.Namespace] = make(map[string]bool)
+		}
+		pods[resource.Namespace][resource.Name] = true
+		// Events of the owning ReplicaSet or Job explain pod churn even when the owner was not discovered
+		for _, owner := range resource.OwnerRefs {
+			objects[resource.Namespace][owner.Kind+"/"+owner.Name] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(pods))
+	for namespace := range pods {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var collectors []CollectorSpec
+	for _, namespace := range namespaces {
+		timeline := p.namespaceTimeline(ctx, namespace, pods[namespace], objects[namespace], window)
+		data, err := json.MarshalIndent(timeline, "", "  ")
+		if err != nil {
+			continue
+		}
+		collectors = append(collectors, CollectorSpec{
+			Type:      "data",
+			Name:      fmt.Sprintf("auto-timeline-%s", namespace),
+			Namespace: namespace,
+			Group:     CollectorGroupWorkloads,
+			Priority:  int(PriorityHigh),
+			Parameters: map[string]interface{}{
+				"name": fmt.Sprintf("namespaces/%s/timeline.json", namespace),
+				"data": string(data),
+			},
+		})
+	}
+	return collectors
+}
+
+// namespaceTimeline builds the timeline of one namespace, listing failures as problems rather than failing
+func (p *PodTimeline) namespaceTimeline(ctx context.Context, namespace string, pods, objects map[string]bool, window TimeWindow) NamespaceTimeline {
+	timeline := NamespaceTimeline{Namespace: namespace, Restarts: []PodRestartSummary{}, Entries: []TimelineEntry{}}
+
+	podList, err := p.dynamicClient.Resource(podsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		timeline.Problems = append(timeline.Problems, fmt.Sprintf("failed to list pods: %v", err))
+	} else {
+		for _, pod := range podList.Items {
+			if !pods[pod.GetName()] {
+				continue
+			}
+			restarts, entries := podTimelineEntries(pod)
+			timeline.Restarts = append(timeline.Restarts, restarts...)
+			timeline.Entries = append(timeline.Entries, entries...)
+		}
+	}
+
+	events, err := p.dynamicClient.Resource(eventsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		timeline.Problems = append(timeline.Problems, fmt.Sprintf("failed to list events: %v", err))
+	} else {
+		for _, event := range events.Items {
+			kind, _, _ := unstructured.NestedString(event.Object, "involvedObject", "kind")
+			name, _, _ := unstructured.NestedString(event.Object, "involvedObject", "name")
+			if !objects[kind+"/"+name] {
+				continue
+			}
+			if entry, ok := eventTimelineEntry(event, kind, name); ok {
+				timeline.Entries = append(timeline.Entries, entry)
+			}
+		}
+	}
+
+	timeline.Entries = filterTimelineEntries(timeline.Entries, window)
+	sortTimelineEntries(timeline.Entries)
+	if len(timeline.Entries) > DefaultTimelineEntryLimit {
+		timeline.Entries = timeline.Entries[len(timeline.Entries)-DefaultTimelineEntryLimit:]
+		timeline.Truncated = true
+	}
+
+	sort.SliceStable(timeline.Restarts, func(i, j int) bool {
+		if timeline.Restarts[i].RestartCount != timeline.Restarts[j].RestartCount {
+			return timeline.Restarts[i].RestartCount > timeline.Restarts[j].RestartCount
+		}
+		return timeline.Restarts[i].Pod+"/"+timeline.Restarts[i].Container < timeline.Restarts[j].Pod+"/"+timeline.Restarts[j].Container
+	})
+	return timeline
+}
+
+// podTimelineEntries returns the restart summaries and timeline entries found in a pod's status
+func podTimelineEntries(pod unstructured.Unstructured) ([]PodRestartSummary, []TimelineEntry) {
+	var restarts []PodRestartSummary
+	var entries []TimelineEntry
+	object := "Pod/" + pod.GetName()
+
+	if startTime, ok, _ := unstructured.NestedString(pod.Object, "status", "startTime"); ok && startTime != "" {
+		entries = append(entries, TimelineEntry{Time: startTime, Kind: TimelineEntryPodStarted, Object: object})
+	}
+
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, s := range statuses {
+			status, ok := s.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			container, _, _ := unstructured.NestedString(status, "name")
+			restartCount, _, _ := unstructured.NestedInt64(status, "restartCount")
+
+			var summary *PodRestartSummary
+			if restartCount > 0 {
+				summary = &PodRestartSummary{Pod: pod.GetName(), Container: container, RestartCount: restartCount}
+			}
+
+			if terminated, ok, _ := unstructured.NestedMap(status, "lastState", "terminated"); ok {
+				entry := TimelineEntry{Kind: TimelineEntryContainerTerminated, Object: object, Container: container}
+				entry.Time, _, _ = unstructured.NestedString(terminated, "finishedAt")
+				entry.Reason, _, _ = unstructured.NestedString(terminated, "reason")
+				entry.Message, _, _ = unstructured.NestedString(terminated, "message")
+				if exitCode, ok, _ := unstructured.NestedInt64(terminated, "exitCode"); ok {
+					entry.ExitCode = &exitCode
+				}
+				if entry.Time != "" {
+					entries = append(entries, entry)
+				}
+				if summary != nil {
+					summary.LastTermination = entry.Reason
+					summary.LastExitCode = entry.ExitCode
+				}
+			}
+
+			if startedAt, ok, _ := unstructured.NestedString(status, "state", "running", "startedAt"); ok && startedAt != "" && restartCount > 0 {
+				entries = append(entries, TimelineEntry{
+					Time:      startedAt,
+					Kind:      TimelineEntryContainerRestarted,
+					Object:    object,
+					Container: container,
+					Count:     restartCount,
+				})
+			}
+
+			if summary != nil {
+				restarts = append(restarts, *summary)
+			}
+		}
+	}
+	return restarts, entries
+}
+
+// eventTimelineEntry converts an event, preferring its last occurrence, then eventTime, then first occurrence
+func eventTimelineEntry(event unstructured.Unstructured, kind, name string) (TimelineEntry, bool) {
+	var timestamp string
+	for _, field := range []string{"lastTimestamp", "eventTime", "firstTimestamp"} {
+		if value, ok, _ := unstructured.NestedString(event.Object, field); ok && value != "" {
+			timestamp = value
+			break
+		}
+	}
+	if timestamp == "" {
+		return TimelineEntry{}, false
+	}
+
+	entry := TimelineEntry{Time: timestamp, Kind: TimelineEntryEvent, Object: kind + "/" + name}
+	entry.Type, _, _ = unstructured.NestedString(event.Object, "type")
+	entry.Reason, _, _ = unstructured.NestedString(event.Object, "reason")
+	entry.Message, _, _ = unstructured.NestedString(event.Object, "message")
+	entry.Count, _, _ = unstructured.NestedInt64(event.Object, "count")
+	return entry, true
+}
+
+// filterTimelineEntries keeps the entries inside the window, entries with unparseable times are kept
+func filterTimelineEntries(entries []TimelineEntry, window TimeWindow) []TimelineEntry {
+	if window.IsZero() {
+		return entries
+	}
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if t, err := time.Parse(time.RFC3339, entry.Time); err == nil && !window.Contains(t) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// sortTimelineEntries orders entries chronologically, ties broken by object and container
+func sortTimelineEntries(entries []TimelineEntry) {
+	timeOf := func(entry TimelineEntry) time.Time {
+		t, _ := time.Parse(time.RFC3339, entry.Time)
+		return t
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, tj := timeOf(entries[i]), timeOf(entries[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		if entries[i].Object != entries[j].Object {
+			return entries[i].Object < entries[j].Object
+		}
+		return entries[i].Container < entries[j].Container
+	})
+}
@@ -0,0 +1,126 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testTimelineEvent(namespace, name, kind, object, reason string, at time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object, Namespace: namespace},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Message:        reason + " " + object,
+		Count:          1,
+		LastTimestamp:  metav1.NewTime(at),
+	}
+}
+
+func TestPodTimeline_GenerateTimelineCollectors(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "app"},
+		Status: corev1.PodStatus{
+			StartTime: &metav1.Time{Time: base},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "web",
+					RestartCount: 3,
+					State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(base.Add(20 * time.Minute))}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode:   137,
+						Reason:     "OOMKilled",
+						FinishedAt: metav1.NewTime(base.Add(19 * time.Minute)),
+					}},
+				},
+				{Name: "sidecar"},
+			},
+		},
+	}
+
+	client := createTestDynamicClient(
+		pod,
+		testTimelineEvent("app", "web-abc.1", "Pod", "web-abc", "BackOff", base.Add(18*time.Minute)),
+		testTimelineEvent("app", "web-rs.1", "ReplicaSet", "web-rs", "FailedCreate", base.Add(5*time.Minute)),
+		testTimelineEvent("app", "other.1", "Pod", "other", "BackOff", base.Add(6*time.Minute)),
+	)
+	resources := []Resource{{
+		GVR:       schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Namespace: "app",
+		Name:      "web-abc",
+		OwnerRefs: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-rs"}},
+	}}
+
+	collectors := NewPodTimeline(client).GenerateTimelineCollectors(context.Background(), resources, TimeWindow{})
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 collector, got %d", len(collectors))
+	}
+	if collectors[0].Parameters["name"] != "namespaces/app/timeline.json" || collectors[0].Group != CollectorGroupWorkloads {
+		t.Errorf("Expected namespaces/app/timeline.json in the workloads group, got %v in %s", collectors[0].Parameters["name"], collectors[0].Group)
+	}
+
+	var timeline NamespaceTimeline
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &timeline); err != nil {
+		t.Fatalf("Failed to parse timeline: %v", err)
+	}
+
+	if len(timeline.Restarts) != 1 {
+		t.Fatalf("Expected 1 restarted container, got %+v", timeline.Restarts)
+	}
+	if restart := timeline.Restarts[0]; restart.RestartCount != 3 || restart.LastTermination != "OOMKilled" || restart.LastExitCode == nil || *restart.LastExitCode != 137 {
+		t.Errorf("Expected 3 restarts after an OOMKilled exit 137, got %+v", restart)
+	}
+
+	expected := []struct{ kind, object, reason string }{
+		{TimelineEntryPodStarted, "Pod/web-abc", ""},
+		{TimelineEntryEvent, "ReplicaSet/web-rs", "FailedCreate"},
+		{TimelineEntryEvent, "Pod/web-abc", "BackOff"},
+		{TimelineEntryContainerTerminated, "Pod/web-abc", "OOMKilled"},
+		{TimelineEntryContainerRestarted, "Pod/web-abc", ""},
+	}
+	if len(timeline.Entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %+v", len(expected), timeline.Entries)
+	}
+	for i, e := range expected {
+		entry := timeline.Entries[i]
+		if entry.Kind != e.kind || entry.Object != e.object || entry.Reason != e.reason {
+			t.Errorf("Expected entry %d to be %s %s %s, got %+v", i, e.kind, e.object, e.reason, entry)
+		}
+	}
+}
+
+func TestPodTimeline_TimeWindow(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	client := createTestDynamicClient(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app"}},
+		testTimelineEvent("app", "web.1", "Pod", "web", "BackOff", base),
+		testTimelineEvent("app", "web.2", "Pod", "web", "Unhealthy", base.Add(2*time.Hour)),
+	)
+	resources := []Resource{{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Namespace: "app", Name: "web"}}
+
+	since := base.Add(time.Hour)
+	collectors := NewPodTimeline(client).GenerateTimelineCollectors(context.Background(), resources, TimeWindow{Since: &since})
+
+	var timeline NamespaceTimeline
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &timeline); err != nil {
+		t.Fatalf("Failed to parse timeline: %v", err)
+	}
+	if len(timeline.Entries) != 1 || timeline.Entries[0].Reason != "Unhealthy" {
+		t.Errorf("Expected only the event inside the window, got %+v", timeline.Entries)
+	}
+}
+
+func TestPodTimeline_NoPods(t *testing.T) {
+	resources := []Resource{{GVR: deploymentsGVR, Namespace: "app", Name: "web"}}
+
+	if collectors := NewPodTimeline(createTestDynamicClient()).GenerateTimelineCollectors(context.Background(), resources, TimeWindow{}); len(collectors) != 0 {
+		t.Errorf("Expected no collectors without discovered pods, got %d", len(collectors))
+	}
+}
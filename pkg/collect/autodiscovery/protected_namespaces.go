@@ -0,0 +1,153 @@
+package autodiscovery
+
+import "fmt"
+
+// ValidateProtectedNamespaces checks that every protected namespace is a valid glob, e.g. pci-*, see MatchGlob
+func ValidateProtectedNamespaces(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("protectedNamespaces cannot contain an empty namespace")
+		}
+		if err := ValidateGlob(pattern); err != nil {
+			return fmt.Errorf("invalid protectedNamespaces glob: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsProtectedNamespace reports whether namespace matches one of the protected namespace globs
+// Cluster-scoped objects, with an empty namespace, are never protected
+func IsProtectedNamespace(patterns []string, namespace string) bool {
+	return namespace != "" && MatchesAnyGlob(patterns, namespace)
+}
+
+// splitProtectedNamespaces returns the namespaces that may be read and the protected ones
+func splitProtectedNamespaces(namespaces []string, patterns []string) (allowed []string, protected []string) {
+	for _, namespace := range namespaces {
+		if IsProtectedNamespace(patterns, namespace) {
+			protected = append(protected, namespace)
+		} else {
+			allowed = append(allowed, namespace)
+		}
+	}
+	return allowed, protected
+}
+
+// filterProtectedResources drops resources in protected namespaces, unlike filterExcludedNamespaces this
+// also applies to namespaces that were requested explicitly
+func filterProtectedResources(resources []Resource, patterns []string) []Resource {
+	if len(patterns) == 0 {
+		return resources
+	}
+
+	filtered := make([]Resource, 0, len(resources))
+	for _, resource := range resources {
+		if !IsProtectedNamespace(patterns, resource.Namespace) {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered
+}
+
+// enforceProtectedNamespaces removes protected namespaces from the final collectors, whichever generator or hook
+// added them. Collectors targeting a protected namespace are dropped, protected namespaces are removed from
+// namespace lists, and cluster-resources collectors listing a namespaced type across all namespaces are told to
+// skip the protected ones
+func enforceProtectedNamespaces(collectors []CollectorSpec, patterns []string) []CollectorSpec {
+	if len(patterns) == 0 {
+		return collectors
+	}
+
+	kept := make([]CollectorSpec, 0, len(collectors))
+	for _, collector := range collectors {
+		if IsProtectedNamespace(patterns, collector.Namespace) {
+			continue
+		}
+		if namespace, ok := collector.Parameters["namespace"].(string); ok && IsProtectedNamespace(patterns, namespace) {
+			continue
+		}
+
+		if collector.Type == CollectorTypeClusterResources {
+			params, err := collector.ClusterResourcesParams()
+			if err != nil {
+				kept = append(kept, collector)
+				continue
+			}
+			if len(params.Namespaces) > 0 {
+				allowed, protected := splitProtectedNamespaces(params.Namespaces, patterns)
+				if len(allowed) == 0 {
+					continue
+				}
+				if len(protected) > 0 {
+					params.Namespaces = allowed
+					collector.Parameters = params.ToMap()
+				}
+			} else if listsNamespacedResources(params) {
+				params.ExcludeNamespaces = appendUniqueStrings(params.ExcludeNamespaces, patterns...)
+				collector.Parameters = params.ToMap()
+			}
+			kept = append(kept, collector)
+			continue
+		}
+
+		if namespaces, ok := stringListParameter(collector.Parameters["namespaces"]); ok && len(namespaces) > 0 {
+			allowed, protected := splitProtectedNamespaces(namespaces, patterns)
+			if len(allowed) == 0 {
+				continue
+			}
+			if len(protected) > 0 {
+				collector.Parameters = copyParameters(collector.Parameters)
+				collector.Parameters["namespaces"] = allowed
+			}
+		}
+		kept = append(kept, collector)
+	}
+	return kept
+}
+
+// listsNamespacedResources reports whether a cluster-resources collector gathers a type that is not cluster-scoped
+func listsNamespacedResources(params *ClusterResourcesParams) bool {
+	for _, gvr := range params.GVRs() {
+		if !clusterScopedResources[gvr.Resource] && gvr.Resource != "namespaces" {
+			return true
+		}
+	}
+	return false
+}
+
+// stringListParameter returns a list parameter as strings, whether it was built as []string or decoded from JSON
+func stringListParameter(value interface{}) ([]string, bool) {
+	switch list := value.(type) {
+	case []string:
+		return list, true
+	case []interface{}:
+		strs := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, s)
+		}
+		return strs, true
+	}
+	return nil, false
+}
+
+func copyParameters(params map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		copied[key] = value
+	}
+	return copied
+}
+
+// appendUniqueStrings appends the values not already in list
+func appendUniqueStrings(list []string, values ...string) []string {
+	for _, value := range values {
+		if !containsString(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
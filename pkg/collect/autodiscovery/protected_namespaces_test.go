@@ -0,0 +1,144 @@
+package autodiscovery
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsProtectedNamespace(t *testing.T) {
+	patterns := []string{"pci-*", "vault"}
+	tests := []struct {
+		namespace string
+		expected  bool
+	}{
+		{namespace: "pci-payments", expected: true},
+		{namespace: "vault", expected: true},
+		{namespace: "vault-agent", expected: false},
+		{namespace: "default", expected: false},
+		{namespace: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			if result := IsProtectedNamespace(patterns, tt.namespace); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateProtectedNamespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  bool
+	}{
+		{name: "none"},
+		{name: "names and globs", patterns: []string{"vault", "pci-*"}},
+		{name: "empty", patterns: []string{""}, wantErr: true},
+		{name: "invalid glob", patterns: []string{"pci-["}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProtectedNamespaces(tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFilterProtectedResources(t *testing.T) {
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	resources := []Resource{
+		{GVR: podGVR, Namespace: "app", Name: "web"},
+		{GVR: podGVR, Namespace: "pci-payments", Name: "vault"},
+		{GVR: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, Name: "node-1"},
+	}
+
+	filtered := filterProtectedResources(resources, []string{"pci-*"})
+	if len(filtered) != 2 || filtered[0].Name != "web" || filtered[1].Name != "node-1" {
+		t.Errorf("Expected web and node-1 to be kept, got %v", filtered)
+	}
+	if unfiltered := filterProtectedResources(resources, nil); len(unfiltered) != len(resources) {
+		t.Errorf("Expected every resource without protected namespaces, got %d", len(unfiltered))
+	}
+}
+
+func TestEnforceProtectedNamespaces(t *testing.T) {
+	collectors := []CollectorSpec{
+		{Type: CollectorTypeLogs, Name: "logs-app", Namespace: "app"},
+		{Type: CollectorTypeLogs, Name: "logs-pci", Namespace: "pci-payments"},
+		{Type: CollectorTypeExec, Name: "exec-pci", Parameters: map[string]interface{}{"namespace": "pci-payments"}},
+		{Type: CollectorTypeClusterResources, Name: "pods-mixed", Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods", Namespaces: []string{"app", "pci-payments"}}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "pods-pci", Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods", Namespaces: []string{"pci-payments"}}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "events-all", Parameters: ClusterResourcesParams{Version: "v1", Resource: "events"}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "nodes", Parameters: ClusterResourcesParams{Version: "v1", Resource: "nodes"}.ToMap()},
+		{Type: "timeline", Name: "timeline", Parameters: map[string]interface{}{"namespaces": []interface{}{"app", "pci-payments"}}},
+	}
+
+	enforced := enforceProtectedNamespaces(collectors, []string{"pci-*"})
+
+	var names []string
+	byName := make(map[string]CollectorSpec)
+	for _, collector := range enforced {
+		names = append(names, collector.Name)
+		byName[collector.Name] = collector
+	}
+	expected := []string{"logs-app", "pods-mixed", "events-all", "nodes", "timeline"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+
+	if params, _ := byName["pods-mixed"].ClusterResourcesParams(); !reflect.DeepEqual(params.Namespaces, []string{"app"}) {
+		t.Errorf("Expected pods-mixed to keep only app, got %v", params.Namespaces)
+	}
+	if params, _ := byName["events-all"].ClusterResourcesParams(); !reflect.DeepEqual(params.ExcludeNamespaces, []string{"pci-*"}) {
+		t.Errorf("Expected events across all namespaces to exclude pci-*, got %v", params.ExcludeNamespaces)
+	}
+	if params, _ := byName["nodes"].ClusterResourcesParams(); len(params.ExcludeNamespaces) != 0 {
+		t.Errorf("Expected cluster-scoped nodes to be left alone, got %v", params.ExcludeNamespaces)
+	}
+	if namespaces := byName["timeline"].Parameters["namespaces"]; !reflect.DeepEqual(namespaces, []string{"app"}) {
+		t.Errorf("Expected timeline to keep only app, got %v", namespaces)
+	}
+	if namespaces := collectors[7].Parameters["namespaces"]; len(namespaces.([]interface{})) != 2 {
+		t.Errorf("Expected the original parameters not to be modified, got %v", namespaces)
+	}
+}
+
+func TestMergeDiscoveryOptions_ProtectedNamespaces(t *testing.T) {
+	base := DiscoveryOptions{ProtectedNamespaces: []string{"pci-*"}}
+
+	merged := MergeDiscoveryOptions(base, DiscoveryOptions{Namespaces: []string{"app"}})
+	if !reflect.DeepEqual(merged.ProtectedNamespaces, []string{"pci-*"}) {
+		t.Errorf("Expected overrides without protected namespaces to keep pci-*, got %v", merged.ProtectedNamespaces)
+	}
+
+	merged = MergeDiscoveryOptions(base, DiscoveryOptions{ProtectedNamespaces: []string{"vault", "pci-*"}})
+	if !reflect.DeepEqual(merged.ProtectedNamespaces, []string{"pci-*", "vault"}) {
+		t.Errorf("Expected overrides to add to protected namespaces, got %v", merged.ProtectedNamespaces)
+	}
+	if len(base.ProtectedNamespaces) != 1 {
+		t.Errorf("Expected base to be unchanged, got %v", base.ProtectedNamespaces)
+	}
+}
+
+func TestConfigManager_ProtectedNamespaces(t *testing.T) {
+	cm := NewConfigManager()
+	if err := cm.LoadFromYAML([]byte("defaultOptions:\n  protectedNamespaces: [\"pci-*\"]\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	opts := cm.GetDiscoveryOptions(&DiscoveryOptions{Namespaces: []string{"pci-payments"}})
+	if !reflect.DeepEqual(opts.ProtectedNamespaces, []string{"pci-*"}) {
+		t.Errorf("Expected explicit namespaces not to lift the protection, got %v", opts.ProtectedNamespaces)
+	}
+
+	if err := NewConfigManager().LoadFromYAML([]byte("defaultOptions:\n  protectedNamespaces: [\"pci-[\"]\n")); err == nil {
+		t.Errorf("Expected an invalid protected namespace glob to be rejected")
+	}
+}
@@ -38,8 +38,16 @@ func (r *RBACChecker) FilterByPermissions(ctx context.Context, resources []Resou
 	var allowedResources []Resource
 
 	for _, resource := range resources {
+		// Resources not checked before the deadline are left out rather than assumed allowed
+		if phaseExpired(ctx, PhaseRBACCheck) {
+			break
+		}
+
 		allowed, err := r.checkResourceAccess(ctx, resource)
 		if err != nil {
+			if phaseExpired(ctx, PhaseRBACCheck) {
+				break
+			}
 			// Log warning but continue processing other resources
 			fmt.Printf("Warning: failed to check permissions for %s/%s: %v\n", resource.Namespace, resource.Name, err)
 			continue
@@ -239,6 +247,43 @@ func (r *RBACChecker) CheckResourceTypeAccess(ctx context.Context, gvr schema.Gr
 	return result.Status.Allowed, nil
 }
 
+// CheckVerbAccess checks whether the current user can perform a verb on a resource type or subresource in a namespace
+func (r *RBACChecker) CheckVerbAccess(ctx context.Context, gvr schema.GroupVersionResource, subresource, namespace, verb string) (bool, error) {
+	key := PermissionKey{
+		Namespace:   namespace,
+		Verb:        verb,
+		GVR:         gvr,
+		Subresource: subresource,
+	}
+
+	if result, found, err := r.cache.Get(key); found {
+		return result, err
+	}
+
+	review := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       gvr.Group,
+				Version:     gvr.Version,
+				Resource:    gvr.Resource,
+				Subresource: subresource,
+			},
+		},
+	}
+
+	result, err := r.kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed to check %s permission: %w", verb, err)
+		r.cache.Set(key, false, err)
+		return false, err
+	}
+
+	r.cache.Set(key, result.Status.Allowed, nil)
+	return result.Status.Allowed, nil
+}
+
 // GetCacheStats returns statistics about the permission cache
 func (r *RBACChecker) GetCacheStats() CacheStats {
 	return r.cache.GetStats()
@@ -0,0 +1,136 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// batchableCollectorPrefix names the per-type collectors of discovered resources, see generateClusterResourceCollectors
+const batchableCollectorPrefix = "auto-resources-"
+
+// batchByNamespace replaces the per-type cluster-resources collectors of discovered resources with one collector per
+// collector group and set of resource types, listing the namespaces that hold exactly that set. Each namespace is
+// then listed once per batch rather than once per type, and a type found in many namespaces is not listed cluster-wide
+// Cluster-scoped collectors and collectors with field selectors or size limits are kept as they are
+func batchByNamespace(collectors []CollectorSpec, enabled bool) []CollectorSpec {
+	if !enabled {
+		return collectors
+	}
+
+	type batch struct {
+		group      string
+		priority   int
+		refs       []ResourceRef
+		namespaces []string
+	}
+
+	// The resource types of each namespace, per collector group
+	types := make(map[string]map[string]map[ResourceRef]bool)
+	priorities := make(map[string]int)
+	kept := make([]CollectorSpec, 0, len(collectors))
+	for _, collector := range collectors {
+		params, ok := batchableParams(collector)
+		if !ok {
+			kept = append(kept, collector)
+			continue
+		}
+		if types[collector.Group] == nil {
+			types[collector.Group] = make(map[string]map[ResourceRef]bool)
+		}
+		if collector.Priority > priorities[collector.Group] {
+			priorities[collector.Group] = collector.Priority
+		}
+		ref := ResourceRef{Group: params.Group, Version: params.Version, Resource: params.Resource}
+		for _, namespace := range params.Namespaces {
+			if types[collector.Group][namespace] == nil {
+				types[collector.Group][namespace] = make(map[ResourceRef]bool)
+			}
+			types[collector.Group][namespace][ref] = true
+		}
+	}
+
+	// Namespaces holding the same set of types share a collector
+	batches := make(map[string]*batch)
+	for group, namespaces := range types {
+		for namespace, refSet := range namespaces {
+			refs := make([]ResourceRef, 0, len(refSet))
+			for ref := range refSet {
+				refs = append(refs, ref)
+			}
+			sortResourceRefs(refs)
+
+			key := group + "|" + resourceRefsKey(refs)
+			if batches[key] == nil {
+				batches[key] = &batch{group: group, priority: priorities[group], refs: refs}
+			}
+			batches[key].namespaces = append(batches[key].namespaces, namespace)
+		}
+	}
+
+	keys := make([]string, 0, len(batches))
+	for key := range batches {
+		sort.Strings(batches[key].namespaces)
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		b := batches[key]
+		params := ClusterResourcesParams{Namespaces: b.namespaces}
+		if len(b.refs) == 1 {
+			params.Group, params.Version, params.Resource = b.refs[0].Group, b.refs[0].Version, b.refs[0].Resource
+		} else {
+			params.Resources = b.refs
+		}
+
+		name := fmt.Sprintf("%sns-%s", batchableCollectorPrefix, b.namespaces[0])
+		if b.group != "" {
+			name = fmt.Sprintf("%s%s-ns-%s", batchableCollectorPrefix, b.group, b.namespaces[0])
+		}
+		kept = append(kept, CollectorSpec{
+			Type:       CollectorTypeClusterResources,
+			Name:       name,
+			Group:      b.group,
+			Priority:   b.priority,
+			Parameters: params.ToMap(),
+		})
+	}
+	return kept
+}
+
+// batchableParams returns the parameters of a namespaced, single type collector of discovered resources
+func batchableParams(collector CollectorSpec) (*ClusterResourcesParams, bool) {
+	if collector.Type != CollectorTypeClusterResources || !strings.HasPrefix(collector.Name, batchableCollectorPrefix) {
+		return nil, false
+	}
+	params, err := collector.ClusterResourcesParams()
+	if err != nil || params.Resource == "" || len(params.Resources) > 0 || len(params.Namespaces) == 0 {
+		return nil, false
+	}
+	if len(params.FieldSelectors) > 0 || params.MaxObjectSize > 0 || len(params.SecretPolicy) > 0 {
+		return nil, false
+	}
+	return params, true
+}
+
+func sortResourceRefs(refs []ResourceRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Group != refs[j].Group {
+			return refs[i].Group < refs[j].Group
+		}
+		if refs[i].Resource != refs[j].Resource {
+			return refs[i].Resource < refs[j].Resource
+		}
+		return refs[i].Version < refs[j].Version
+	})
+}
+
+// resourceRefsKey identifies a sorted set of resource types
+func resourceRefsKey(refs []ResourceRef) string {
+	parts := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		parts = append(parts, ref.Group+"/"+ref.Version+"/"+ref.Resource)
+	}
+	return strings.Join(parts, ",")
+}
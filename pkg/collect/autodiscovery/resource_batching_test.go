@@ -0,0 +1,109 @@
+package autodiscovery
+
+import (
+	"testing"
+)
+
+func TestBatchByNamespace(t *testing.T) {
+	resources := func(resource string, namespaces ...string) CollectorSpec {
+		return CollectorSpec{
+			Type:       CollectorTypeClusterResources,
+			Name:       "auto-resources-" + resource,
+			Group:      CollectorGroupWorkloads,
+			Priority:   int(PriorityNormal),
+			Parameters: ClusterResourcesParams{Version: "v1", Resource: resource, Namespaces: namespaces}.ToMap(),
+		}
+	}
+	deployments := CollectorSpec{
+		Type:       CollectorTypeClusterResources,
+		Name:       "auto-resources-apps-deployments",
+		Group:      CollectorGroupWorkloads,
+		Priority:   int(PriorityHigh),
+		Parameters: ClusterResourcesParams{Group: "apps", Version: "v1", Resource: "deployments", Namespaces: []string{"app"}}.ToMap(),
+	}
+	ingresses := CollectorSpec{
+		Type:       CollectorTypeClusterResources,
+		Name:       "auto-resources-networking.k8s.io-ingresses",
+		Group:      CollectorGroupNetworking,
+		Parameters: ClusterResourcesParams{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses", Namespaces: []string{"app"}}.ToMap(),
+	}
+	configMaps := resources("configmaps", "app", "db")
+	configMaps.Parameters["maxObjectSize"] = DefaultLargeObjectMaxSize
+	nodes := resources("nodes")
+	webhooks := CollectorSpec{Type: CollectorTypeClusterResources, Name: "auto-webhook-configs", Parameters: ClusterResourcesParams{Version: "v1", Resource: "services", Namespaces: []string{"app"}}.ToMap()}
+
+	collectors := []CollectorSpec{resources("services", "app", "db", "cache"), deployments, ingresses, configMaps, nodes, webhooks}
+
+	unbatched := batchByNamespace(append([]CollectorSpec{}, collectors...), false)
+	if len(unbatched) != len(collectors) {
+		t.Errorf("Expected batching to be off by default, got %d collectors", len(unbatched))
+	}
+
+	batched := batchByNamespace(collectors, true)
+	byName := make(map[string]CollectorSpec)
+	for _, collector := range batched {
+		byName[collector.Name] = collector
+	}
+	for _, name := range []string{"auto-resources-configmaps", "auto-resources-nodes", "auto-webhook-configs"} {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("Expected %s to be kept as is", name)
+		}
+	}
+	if len(batched) != 6 {
+		t.Errorf("Expected 6 collectors, got %d: %v", len(batched), byName)
+	}
+
+	app, ok := byName["auto-resources-workloads-ns-app"]
+	if !ok {
+		t.Fatalf("Expected a workloads batch for namespace app, got %v", byName)
+	}
+	params, err := app.ClusterResourcesParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(params.Resources) != 2 || params.Resources[0].Resource != "services" || params.Resources[1].Resource != "deployments" {
+		t.Errorf("Expected services and deployments, got %+v", params.Resources)
+	}
+	if len(params.Namespaces) != 1 || params.Namespaces[0] != "app" {
+		t.Errorf("Expected namespace app, got %v", params.Namespaces)
+	}
+	if app.Priority != int(PriorityHigh) {
+		t.Errorf("Expected the highest priority of the group, got %d", app.Priority)
+	}
+	if err := app.ValidateParameters(); err != nil {
+		t.Errorf("Expected a valid batched collector, got %v", err)
+	}
+
+	shared, ok := byName["auto-resources-workloads-ns-cache"]
+	if !ok {
+		t.Fatalf("Expected cache and db to share a batch, got %v", byName)
+	}
+	params, _ = shared.ClusterResourcesParams()
+	if params.Resource != "services" || len(params.Resources) != 0 {
+		t.Errorf("Expected a single type batch to use resource, got %+v", params)
+	}
+	if len(params.Namespaces) != 2 || params.Namespaces[0] != "cache" || params.Namespaces[1] != "db" {
+		t.Errorf("Expected namespaces cache and db, got %v", params.Namespaces)
+	}
+
+	networking, ok := byName["auto-resources-networking-ns-app"]
+	if !ok || networking.Group != CollectorGroupNetworking {
+		t.Errorf("Expected ingresses to stay in the networking group, got %v", byName)
+	}
+}
+
+func TestClusterResourcesParams_GVRs(t *testing.T) {
+	single := ClusterResourcesParams{Group: "apps", Version: "v1", Resource: "deployments"}
+	if gvrs := single.GVRs(); len(gvrs) != 1 || gvrs[0].Resource != "deployments" {
+		t.Errorf("Expected deployments, got %v", gvrs)
+	}
+
+	batched := ClusterResourcesParams{Resources: []ResourceRef{{Version: "v1", Resource: "services"}, {Group: "apps", Version: "v1", Resource: "deployments"}}}
+	if gvrs := batched.GVRs(); len(gvrs) != 2 || gvrs[1].Group != "apps" {
+		t.Errorf("Expected services and deployments, got %v", gvrs)
+	}
+
+	if gvrs := (ClusterResourcesParams{}).GVRs(); gvrs != nil {
+		t.Errorf("Expected no GVRs, got %v", gvrs)
+	}
+}
@@ -3,6 +3,7 @@ package autodiscovery
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	
 	"k8s.io/client-go/dynamic"
@@ -12,6 +13,7 @@ import (
 type ResourceExpander struct {
 	collectorMappings map[string]CollectorMapping
 	dependencyResolver *DependencyResolver
+	execCatalog       *ExecCatalog
 }
 
 // CollectorMapping defines how to convert a resource type to collector specs
@@ -25,6 +27,7 @@ type CollectorMapping struct {
 func NewResourceExpander() *ResourceExpander {
 	expander := &ResourceExpander{
 		collectorMappings: make(map[string]CollectorMapping),
+		execCatalog:       NewExecCatalog(DefaultExecCatalogEntries()),
 	}
 	expander.initializeDefaultMappings()
 	return expander
@@ -35,18 +38,36 @@ func NewResourceExpanderWithDependencies(dynamicClient dynamic.Interface, maxDep
 	expander := &ResourceExpander{
 		collectorMappings: make(map[string]CollectorMapping),
 		dependencyResolver: NewDependencyResolver(dynamicClient, maxDepth),
+		execCatalog:        NewExecCatalog(DefaultExecCatalogEntries()),
 	}
 	expander.initializeDefaultMappings()
 	return expander
 }
 
+// SetExecCatalog replaces the catalog of read-only commands run in matching pods, nil disables catalog exec collectors
+func (r *ResourceExpander) SetExecCatalog(catalog *ExecCatalog) {
+	r.execCatalog = catalog
+}
+
+// DependencyReport returns the report of the last dependency resolution, nil without a dependency resolver
+func (r *ResourceExpander) DependencyReport() *DependencyReport {
+	if r == nil {
+		return nil
+	}
+	return r.dependencyResolver.Report()
+}
+
 // ExpandToCollectors converts resources to collector specifications
 func (r *ResourceExpander) ExpandToCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions) ([]CollectorSpec, error) {
 	// Resolve dependencies if dependency resolver is available
 	expandedResources := resources
 	if r.dependencyResolver != nil && opts.MaxDepth > 0 {
+		r.dependencyResolver.SetExcludedNamespaces(opts.ExcludeNamespaces)
+		r.dependencyResolver.SetProtectedNamespaces(opts.ProtectedNamespaces)
+		resolveCtx, cancel := withPhaseTimeout(ctx, opts.PhaseTimeouts.DependencyResolve)
 		var err error
-		expandedResources, err = r.dependencyResolver.ResolveDependencies(ctx, resources)
+		expandedResources, err = r.dependencyResolver.ResolveDependencies(resolveCtx, resources)
+		cancel()
 		if err != nil {
 			// Log warning but continue with original resources
 			fmt.Printf("Warning: failed to resolve dependencies: %v\n", err)
@@ -54,10 +75,25 @@ func (r *ResourceExpander) ExpandToCollectors(ctx context.Context, resources []R
 		}
 	}
 
+	expandCtx, cancel := withPhaseTimeout(ctx, opts.PhaseTimeouts.Expand)
+	defer cancel()
+
 	var collectors []CollectorSpec
 	resourceGroups := r.groupResourcesByType(expandedResources)
 
-	for resourceKey, resourceList := range resourceGroups {
+	// Expand in a stable order so a deadline always keeps the same groups
+	resourceKeys := make([]string, 0, len(resourceGroups))
+	for resourceKey := range resourceGroups {
+		resourceKeys = append(resourceKeys, resourceKey)
+	}
+	sort.Strings(resourceKeys)
+
+	for _, resourceKey := range resourceKeys {
+		if phaseExpired(expandCtx, PhaseExpand) {
+			break
+		}
+
+		resourceList := resourceGroups[resourceKey]
 		mapping, exists := r.collectorMappings[resourceKey]
 		if !exists {
 			// Create a generic cluster-resources collector for unknown types
@@ -69,6 +105,9 @@ func (r *ResourceExpander) ExpandToCollectors(ctx context.Context, resources []R
 		collectors = append(collectors, newCollectors...)
 	}
 
+	// Pods are mapped to log collectors, the catalog adds its commands for the database and cache pods it knows
+	collectors = append(collectors, r.execCatalog.GenerateExecCollectors(expandedResources, int(PriorityNormal))...)
+
 	return collectors, nil
 }
 
@@ -133,21 +172,29 @@ func (r *ResourceExpander) generateLogCollectors(resources []Resource, mapping C
 		}
 	}
 
-	for namespace, pods := range namespaceGroups {
+	namespaces := make([]string, 0, len(namespaceGroups))
+	for namespace := range namespaceGroups {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		pods := namespaceGroups[namespace]
+		sort.Slice(pods, func(i, j int) bool {
+			return pods[i].Name < pods[j].Name
+		})
+
 		// Create a logs collector for each namespace
 		collectorSpec := CollectorSpec{
 			Type:      "logs",
 			Name:      fmt.Sprintf("auto-logs-%s", namespace),
 			Namespace: namespace,
 			Priority:  mapping.Priority,
-			Parameters: map[string]interface{}{
-				"selector":  []string{fmt.Sprintf("namespace=%s", namespace)},
-				"namespace": namespace,
-				"limits": map[string]interface{}{
-					"maxAge": "72h",
-					"maxLines": 10000,
-				},
-			},
+			Parameters: LogsParams{
+				Selector:  []string{fmt.Sprintf("namespace=%s", namespace)},
+				Namespace: namespace,
+				Limits:    &LogsLimits{MaxAge: "72h", MaxLines: 10000},
+			}.ToMap(),
 		}
 		collectors = append(collectors, collectorSpec)
 
@@ -159,23 +206,83 @@ func (r *ResourceExpander) generateLogCollectors(resources []Resource, mapping C
 					Name:      fmt.Sprintf("auto-logs-pod-%s", pod.Name),
 					Namespace: namespace,
 					Priority:  int(PriorityCritical), // Highest priority for targeted collection
-					Parameters: map[string]interface{}{
-						"name": pod.Name,
-						"namespace": namespace,
-						"limits": map[string]interface{}{
-							"maxAge": "24h",
-							"maxLines": 1000,
-						},
-					},
+					Parameters: LogsParams{
+						Name:      pod.Name,
+						Namespace: namespace,
+						Limits:    &LogsLimits{MaxAge: "24h", MaxLines: 1000},
+					}.ToMap(),
 				}
 				collectors = append(collectors, targetedSpec)
 			}
+
+			// Ephemeral (debug) containers are not covered by the namespace-wide collector
+			if len(pod.EphemeralContainers) > 0 {
+				collectors = append(collectors, CollectorSpec{
+					Type:      "logs",
+					Name:      fmt.Sprintf("auto-logs-ephemeral-%s", pod.Name),
+					Namespace: namespace,
+					Priority:  int(PriorityHigh),
+					Parameters: LogsParams{
+						Name:           pod.Name,
+						Namespace:      namespace,
+						ContainerNames: pod.EphemeralContainers,
+						Limits:         &LogsLimits{MaxAge: "24h", MaxLines: 1000},
+					}.ToMap(),
+				})
+
+				if opts.DebugLogFallback && pod.NodeName != "" {
+					collectors = append(collectors, r.generateDebugLogCollector(pod))
+				}
+			}
 		}
 	}
 
 	return collectors
 }
 
+// generateDebugLogCollector creates a run-pod collector that reads a pod's ephemeral container
+// logs straight from /var/log/pods on its node, for nodes where the log API is not reachable
+func (r *ResourceExpander) generateDebugLogCollector(pod Resource) CollectorSpec {
+	var script strings.Builder
+	for _, container := range pod.EphemeralContainers {
+		fmt.Fprintf(&script, "for f in /var/log/pods/%s_%s_*/%s/*.log; do echo \"==> $f\"; tail -n 1000 \"$f\"; done; ",
+			pod.Namespace, pod.Name, container)
+	}
+
+	return CollectorSpec{
+		Type:      "run-pod",
+		Name:      fmt.Sprintf("auto-debug-logs-%s", pod.Name),
+		Namespace: pod.Namespace,
+		Priority:  int(PriorityNormal),
+		Parameters: RunPodParams{
+			Name:      fmt.Sprintf("debug-logs-%s", pod.Name),
+			Namespace: pod.Namespace,
+			PodSpec: map[string]interface{}{
+				"nodeName": pod.NodeName,
+				"containers": []map[string]interface{}{
+					{
+						"name":    "debug-logs",
+						"image":   "busybox:1.36",
+						"command": []string{"sh", "-c"},
+						"args":    []string{strings.TrimSpace(script.String())},
+						"volumeMounts": []map[string]interface{}{
+							{"name": "pod-logs", "mountPath": "/var/log/pods", "readOnly": true},
+						},
+					},
+				},
+				"volumes": []map[string]interface{}{
+					{"name": "pod-logs", "hostPath": map[string]interface{}{"path": "/var/log/pods"}},
+				},
+				"tolerations": []map[string]interface{}{
+					{"operator": "Exists"},
+				},
+				"restartPolicy": "Never",
+			},
+			Timeout: "60s",
+		}.ToMap(),
+	}
+}
+
 // generateClusterResourceCollectors creates cluster-resources collectors
 func (r *ResourceExpander) generateClusterResourceCollectors(resources []Resource, mapping CollectorMapping, opts DiscoveryOptions) []CollectorSpec {
 	if len(resources) == 0 {
@@ -192,27 +299,33 @@ func (r *ResourceExpander) generateClusterResourceCollectors(resources []Resourc
 		gvrGroups[gvrKey] = append(gvrGroups[gvrKey], resource)
 	}
 
+	gvrKeys := make([]string, 0, len(gvrGroups))
+	for gvrKey := range gvrGroups {
+		gvrKeys = append(gvrKeys, gvrKey)
+	}
+	sort.Strings(gvrKeys)
+
 	var collectors []CollectorSpec
-	for gvrKey, resourceList := range gvrGroups {
+	for _, gvrKey := range gvrKeys {
+		resourceList := gvrGroups[gvrKey]
 		resource := resourceList[0] // Use first resource as template
 		
-		collectorSpec := CollectorSpec{
-			Type:     "cluster-resources",
-			Name:     fmt.Sprintf("auto-resources-%s", strings.ReplaceAll(gvrKey, "/", "-")),
-			Priority: mapping.Priority,
-			Parameters: map[string]interface{}{
-				"group":    resource.GVR.Group,
-				"version":  resource.GVR.Version,
-				"resource": resource.GVR.Resource,
-			},
+		params := ClusterResourcesParams{
+			Group:    resource.GVR.Group,
+			Version:  resource.GVR.Version,
+			Resource: resource.GVR.Resource,
 		}
 
 		// Add namespace filtering if resources are namespaced
 		if resource.Namespace != "" {
-			namespaces := r.getUniqueNamespaces(resourceList)
-			if len(namespaces) > 0 {
-				collectorSpec.Parameters["namespaces"] = namespaces
-			}
+			params.Namespaces = r.getUniqueNamespaces(resourceList)
+		}
+
+		collectorSpec := CollectorSpec{
+			Type:       "cluster-resources",
+			Name:       fmt.Sprintf("auto-resources-%s", strings.ReplaceAll(gvrKey, "/", "-")),
+			Priority:   mapping.Priority,
+			Parameters: params.ToMap(),
 		}
 
 		collectors = append(collectors, collectorSpec)
@@ -227,6 +340,10 @@ func (r *ResourceExpander) generateExecCollectors(resources []Resource, mapping
 
 	// Generate exec collectors for pods that might need diagnostic commands
 	for _, resource := range resources {
+		if catalogCollectors := r.execCatalog.collectorsFor(resource, mapping.Priority); len(catalogCollectors) > 0 {
+			collectors = append(collectors, catalogCollectors...)
+			continue
+		}
 		if resource.GVR.Resource == "pods" && r.shouldCreateExecCollector(resource) {
 			collectorSpec := CollectorSpec{
 				Type:      "exec",
@@ -287,10 +404,10 @@ func (r *ResourceExpander) generateRunPodCollectors(resources []Resource, mappin
 			Name:      fmt.Sprintf("auto-network-diag-%s", namespace),
 			Namespace: namespace,
 			Priority:  mapping.Priority,
-			Parameters: map[string]interface{}{
-				"name":      fmt.Sprintf("network-diagnostic-%s", namespace),
-				"namespace": namespace,
-				"podSpec": map[string]interface{}{
+			Parameters: RunPodParams{
+				Name:      fmt.Sprintf("network-diagnostic-%s", namespace),
+				Namespace: namespace,
+				PodSpec: map[string]interface{}{
 					"containers": []map[string]interface{}{
 						{
 							"name":  "diagnostic",
@@ -301,8 +418,8 @@ func (r *ResourceExpander) generateRunPodCollectors(resources []Resource, mappin
 					},
 					"restartPolicy": "Never",
 				},
-				"timeout": "60s",
-			},
+				Timeout: "60s",
+			}.ToMap(),
 		}
 		collectors = append(collectors, collectorSpec)
 	}
@@ -378,6 +495,7 @@ func (r *ResourceExpander) getUniqueNamespaces(resources []Resource) []string {
 	for ns := range namespaceSet {
 		namespaces = append(namespaces, ns)
 	}
+	sort.Strings(namespaces)
 	
 	return namespaces
 }
@@ -396,6 +514,7 @@ func (r *ResourceExpander) getNamespacesWithNetworkingResources(resources []Reso
 	for ns := range namespaceSet {
 		namespaces = append(namespaces, ns)
 	}
+	sort.Strings(namespaces)
 	
 	return namespaces
 }
@@ -419,10 +538,10 @@ func (r *ResourceExpander) generateNetworkDiagnosticCollectors(resources []Resou
 			Name:      fmt.Sprintf("auto-network-diag-%s", namespace),
 			Namespace: namespace,
 			Priority:  int(PriorityNormal),
-			Parameters: map[string]interface{}{
-				"name":      fmt.Sprintf("network-diagnostic-%s", namespace),
-				"namespace": namespace,
-				"podSpec": map[string]interface{}{
+			Parameters: RunPodParams{
+				Name:      fmt.Sprintf("network-diagnostic-%s", namespace),
+				Namespace: namespace,
+				PodSpec: map[string]interface{}{
 					"containers": []map[string]interface{}{
 						{
 							"name":  "diagnostic",
@@ -433,8 +552,8 @@ func (r *ResourceExpander) generateNetworkDiagnosticCollectors(resources []Resou
 					},
 					"restartPolicy": "Never",
 				},
-				"timeout": "60s",
-			},
+				Timeout: "60s",
+			}.ToMap(),
 		}
 		collectors = append(collectors, collectorSpec)
 	}
@@ -489,6 +608,19 @@ func (r *ResourceExpander) initializeDefaultMappings() {
 			CollectorType: "cluster-resources",
 			Priority:      int(PriorityNormal),
 		},
+		// OpenShift, scanned once DetectOpenShift finds the groups served
+		"route.openshift.io_v1_routes": {
+			CollectorType: "cluster-resources",
+			Priority:      int(PriorityHigh),
+		},
+		"apps.openshift.io_v1_deploymentconfigs": {
+			CollectorType: "cluster-resources",
+			Priority:      int(PriorityHigh),
+		},
+		"image.openshift.io_v1_imagestreams": {
+			CollectorType: "cluster-resources",
+			Priority:      int(PriorityNormal),
+		},
 	}
 }
 
@@ -577,6 +577,72 @@ func TestResourceExpander_initializeDefaultMappings(t *testing.T) {
 	}
 }
 
+func TestResourceExpander_EphemeralContainerLogs(t *testing.T) {
+	podGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	resources := []Resource{
+		{GVR: podGVR, Namespace: "default", Name: "web", NodeName: "node-1", EphemeralContainers: []string{"debugger"}},
+		{GVR: podGVR, Namespace: "default", Name: "plain", NodeName: "node-1"},
+	}
+
+	tests := []struct {
+		name             string
+		debugLogFallback bool
+		expectDebugPod   bool
+	}{
+		{name: "logs only", debugLogFallback: false, expectDebugPod: false},
+		{name: "with debug pod fallback", debugLogFallback: true, expectDebugPod: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expander := NewResourceExpander()
+			collectors, err := expander.ExpandToCollectors(context.Background(), resources, DiscoveryOptions{DebugLogFallback: tt.debugLogFallback})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var ephemeral, debugPod *CollectorSpec
+			for i := range collectors {
+				switch collectors[i].Name {
+				case "auto-logs-ephemeral-web":
+					ephemeral = &collectors[i]
+				case "auto-debug-logs-web":
+					debugPod = &collectors[i]
+				case "auto-logs-ephemeral-plain", "auto-debug-logs-plain":
+					t.Errorf("Unexpected collector %s for pod without ephemeral containers", collectors[i].Name)
+				}
+			}
+
+			if ephemeral == nil {
+				t.Fatalf("Expected ephemeral container logs collector")
+			}
+			params, err := ephemeral.LogsParams()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(params.ContainerNames) != 1 || params.ContainerNames[0] != "debugger" {
+				t.Errorf("Expected container names [debugger], got %v", params.ContainerNames)
+			}
+
+			if (debugPod != nil) != tt.expectDebugPod {
+				t.Fatalf("Expected debug pod collector %v, got %v", tt.expectDebugPod, debugPod != nil)
+			}
+			if debugPod != nil {
+				runPod, err := debugPod.RunPodParams()
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if runPod.PodSpec["nodeName"] != "node-1" {
+					t.Errorf("Expected debug pod on node-1, got %v", runPod.PodSpec["nodeName"])
+				}
+				if err := debugPod.ValidateParameters(); err != nil {
+					t.Errorf("Expected valid debug pod collector, got %v", err)
+				}
+			}
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkResourceExpander_ExpandToCollectors(b *testing.B) {
 	expander := NewResourceExpander()
@@ -0,0 +1,247 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// Resource formats for cluster-resources collection
+const (
+	ResourceFormatFull  = "full"  // Full objects only, the default
+	ResourceFormatTable = "table" // Server-side printed tables replace the full objects of large lists
+	ResourceFormatBoth  = "both"  // Tables are collected in addition to the full objects
+)
+
+// DefaultTableThreshold is the number of objects of one type in a namespace before a table summary is collected
+const DefaultTableThreshold = 200
+
+// tablePageSize is the number of rows requested per table call
+const tablePageSize = 500
+
+// tableAcceptHeader asks the API server for server-side printing, falling back to plain JSON
+const tableAcceptHeader = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// TableSummarizer fetches server-side printed tables, the same columns kubectl get shows
+type TableSummarizer struct {
+	restClient rest.Interface
+}
+
+// NewTableSummarizer creates a TableSummarizer; restClient must be rooted at the API server, like the discovery client
+func NewTableSummarizer(restClient rest.Interface) *TableSummarizer {
+	return &TableSummarizer{
+		restClient: restClient,
+	}
+}
+
+// ValidateResourceFormat checks that format is empty or one of the resource formats
+func ValidateResourceFormat(format string) error {
+	switch format {
+	case "", ResourceFormatFull, ResourceFormatTable, ResourceFormatBoth:
+		return nil
+	}
+	return fmt.Errorf("unknown resource format %q, must be %s, %s or %s", format, ResourceFormatFull, ResourceFormatTable, ResourceFormatBoth)
+}
+
+// tableKey identifies the objects of one type in one namespace, "" for cluster-scoped types
+type tableKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+// GenerateTableCollectors returns a data collector with a table summary for every resource type with at least
+// opts.TableThreshold objects in a namespace, and the lists that were summarized
+// Lists whose table cannot be fetched are left out so their full objects are still collected
+func (s *TableSummarizer) GenerateTableCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions) ([]CollectorSpec, map[tableKey]bool) {
+	summarized := make(map[tableKey]bool)
+	if s.restClient == nil {
+		return nil, summarized
+	}
+
+	threshold := opts.TableThreshold
+	if threshold <= 0 {
+		threshold = DefaultTableThreshold
+	}
+
+	counts := make(map[tableKey]int)
+	for _, resource := range resources {
+		counts[tableKey{gvr: resource.GVR, namespace: resource.Namespace}]++
+	}
+
+	keys := make([]tableKey, 0, len(counts))
+	for key, count := range counts {
+		if count >= threshold {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace != keys[j].namespace {
+			return keys[i].namespace < keys[j].namespace
+		}
+		return keys[i].gvr.String() < keys[j].gvr.String()
+	})
+
+	var collectors []CollectorSpec
+	for _, key := range keys {
+		table, err := s.FetchTable(ctx, key.gvr, key.namespace)
+		if err != nil {
+			fmt.Printf("Warning: failed to get table for %s: %v\n", key.gvr.Resource, err)
+			continue
+		}
+		summarized[key] = true
+
+		scope := key.namespace
+		if scope == "" {
+			scope = "cluster"
+		}
+		collectors = append(collectors, CollectorSpec{
+			Type:      "data",
+			Name:      fmt.Sprintf("auto-table-%s-%s", scope, key.gvr.Resource),
+			Namespace: key.namespace,
+			Group:     groupForResource(key.gvr.Resource),
+			Priority:  int(PriorityNormal),
+			Parameters: map[string]interface{}{
+				"name": fmt.Sprintf("tables/%s/%s.txt", scope, key.gvr.Resource),
+				"data": FormatTable(table),
+			},
+		})
+	}
+	return collectors, summarized
+}
+
+// FetchTable lists one resource type as a server-side printed table, following continue tokens
+func (s *TableSummarizer) FetchTable(ctx context.Context, gvr schema.GroupVersionResource, namespace string) (*metav1.Table, error) {
+	result := &metav1.Table{}
+	continueToken := ""
+	for {
+		request := s.restClient.Get().
+			AbsPath(resourcePath(gvr, namespace)).
+			SetHeader("Accept", tableAcceptHeader).
+			Param("includeObject", "None").
+			Param("limit", strconv.Itoa(tablePageSize))
+		if continueToken != "" {
+			request = request.Param("continue", continueToken)
+		}
+
+		data, err := request.DoRaw(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var page metav1.Table
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse table: %w", err)
+		}
+		if page.Kind != "Table" {
+			return nil, fmt.Errorf("server-side printing is not supported for %s", gvr.Resource)
+		}
+
+		if len(result.ColumnDefinitions) == 0 {
+			result.ColumnDefinitions = page.ColumnDefinitions
+		}
+		result.Rows = append(result.Rows, page.Rows...)
+
+		continueToken = page.Continue
+		if continueToken == "" {
+			return result, nil
+		}
+	}
+}
+
+// FormatTable renders the default (priority 0) columns of a table like kubectl get
+func FormatTable(table *metav1.Table) string {
+	var columns []int
+	for i, column := range table.ColumnDefinitions {
+		if column.Priority == 0 {
+			columns = append(columns, i)
+		}
+	}
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 3, ' ', 0)
+
+	headers := make([]string, 0, len(columns))
+	for _, i := range columns {
+		headers = append(headers, strings.ToUpper(table.ColumnDefinitions[i].Name))
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, row := range table.Rows {
+		cells := make([]string, 0, len(columns))
+		for _, i := range columns {
+			cell := ""
+			if i < len(row.Cells) && row.Cells[i] != nil {
+				cell = fmt.Sprintf("%v", row.Cells[i])
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	w.Flush()
+	return out.String()
+}
+
+// dropSummarizedResources removes summarized namespaces from cluster-resources collectors,
+// dropping collectors with nothing left to collect
+func dropSummarizedResources(collectors []CollectorSpec, summarized map[tableKey]bool) []CollectorSpec {
+	if len(summarized) == 0 {
+		return collectors
+	}
+
+	kept := make([]CollectorSpec, 0, len(collectors))
+	for _, collector := range collectors {
+		if collector.Type != CollectorTypeClusterResources {
+			kept = append(kept, collector)
+			continue
+		}
+
+		group, _ := collector.Parameters["group"].(string)
+		version, _ := collector.Parameters["version"].(string)
+		resource, _ := collector.Parameters["resource"].(string)
+		gvr := schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
+
+		namespaces, _ := collector.Parameters["namespaces"].([]string)
+		if len(namespaces) == 0 {
+			if !summarized[tableKey{gvr: gvr}] {
+				kept = append(kept, collector)
+			}
+			continue
+		}
+
+		var remaining []string
+		for _, namespace := range namespaces {
+			if !summarized[tableKey{gvr: gvr, namespace: namespace}] {
+				remaining = append(remaining, namespace)
+			}
+		}
+		if len(remaining) == 0 {
+			continue
+		}
+		if len(remaining) < len(namespaces) {
+			collector.Parameters = ClusterResourcesParams{Group: group, Version: version, Resource: resource, Namespaces: remaining}.ToMap()
+		}
+		kept = append(kept, collector)
+	}
+	return kept
+}
+
+// resourcePath returns the API path listing a resource type, in one namespace or cluster-wide
+func resourcePath(gvr schema.GroupVersionResource, namespace string) string {
+	path := "/apis/" + gvr.Group + "/" + gvr.Version
+	if gvr.Group == "" {
+		path = "/api/" + gvr.Version
+	}
+	if namespace != "" {
+		path += "/namespaces/" + namespace
+	}
+	return path + "/" + gvr.Resource
+}
@@ -0,0 +1,167 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+func testTable(continueToken string, names ...string) metav1.Table {
+	table := metav1.Table{
+		TypeMeta: metav1.TypeMeta{Kind: "Table", APIVersion: "meta.k8s.io/v1"},
+		ListMeta: metav1.ListMeta{Continue: continueToken},
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string"},
+			{Name: "Status", Type: "string"},
+			{Name: "IP", Type: "string", Priority: 1},
+		},
+	}
+	for _, name := range names {
+		table.Rows = append(table.Rows, metav1.TableRow{Cells: []interface{}{name, "Running", "10.0.0.1"}})
+	}
+	return table
+}
+
+func newTableServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/big/pods" {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept"), "as=Table") {
+			t.Errorf("Expected table Accept header, got %s", r.Header.Get("Accept"))
+		}
+
+		table := testTable("page-2", "web-1", "web-2")
+		if r.URL.Query().Get("continue") == "page-2" {
+			table = testTable("", "web-3")
+		}
+		json.NewEncoder(w).Encode(table)
+	}))
+}
+
+func TestTableSummarizer_GenerateTableCollectors(t *testing.T) {
+	server := newTableServer(t)
+	defer server.Close()
+
+	restClient := discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: server.URL}).RESTClient()
+	summarizer := NewTableSummarizer(restClient)
+
+	resources := []Resource{
+		{GVR: podsGVR, Namespace: "big", Name: "web-1"},
+		{GVR: podsGVR, Namespace: "big", Name: "web-2"},
+		{GVR: podsGVR, Namespace: "big", Name: "web-3"},
+		{GVR: podsGVR, Namespace: "small", Name: "api-1"},
+	}
+
+	collectors, summarized := summarizer.GenerateTableCollectors(context.Background(), resources, DiscoveryOptions{TableThreshold: 2})
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 table collector, got %d", len(collectors))
+	}
+	if collectors[0].Name != "auto-table-big-pods" {
+		t.Errorf("Expected collector auto-table-big-pods, got %s", collectors[0].Name)
+	}
+	if collectors[0].Parameters["name"] != "tables/big/pods.txt" {
+		t.Errorf("Expected output tables/big/pods.txt, got %v", collectors[0].Parameters["name"])
+	}
+
+	data := collectors[0].Parameters["data"].(string)
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		if !strings.Contains(data, name) {
+			t.Errorf("Expected table to contain %s from every page, got:\n%s", name, data)
+		}
+	}
+	if !summarized[tableKey{gvr: podsGVR, namespace: "big"}] {
+		t.Errorf("Expected big/pods to be summarized")
+	}
+	if summarized[tableKey{gvr: podsGVR, namespace: "small"}] {
+		t.Errorf("Expected small/pods below the threshold to be left alone")
+	}
+}
+
+func TestTableSummarizer_NoRESTClient(t *testing.T) {
+	resources := []Resource{{GVR: podsGVR, Namespace: "big", Name: "web-1"}}
+
+	collectors, summarized := NewTableSummarizer(nil).GenerateTableCollectors(context.Background(), resources, DiscoveryOptions{TableThreshold: 1})
+	if len(collectors) != 0 || len(summarized) != 0 {
+		t.Errorf("Expected no tables without a REST client, got %d collectors", len(collectors))
+	}
+}
+
+func TestFormatTable(t *testing.T) {
+	table := testTable("", "web-1")
+
+	output := FormatTable(&table)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected header and 1 row, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[0], "STATUS") {
+		t.Errorf("Expected NAME and STATUS headers, got %s", lines[0])
+	}
+	if strings.Contains(output, "IP") || strings.Contains(output, "10.0.0.1") {
+		t.Errorf("Expected wide columns to be omitted, got:\n%s", output)
+	}
+}
+
+func TestDropSummarizedResources(t *testing.T) {
+	collectors := []CollectorSpec{
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-pods", Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods", Namespaces: []string{"big", "small"}}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-configmaps", Parameters: ClusterResourcesParams{Version: "v1", Resource: "configmaps", Namespaces: []string{"big"}}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-nodes", Parameters: ClusterResourcesParams{Version: "v1", Resource: "nodes"}.ToMap()},
+		{Type: CollectorTypeLogs, Name: "auto-logs-big", Namespace: "big"},
+	}
+	summarized := map[tableKey]bool{
+		{gvr: podsGVR, namespace: "big"}: true,
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, namespace: "big"}: true,
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}}:                        true,
+	}
+
+	kept := dropSummarizedResources(collectors, summarized)
+	if len(kept) != 2 {
+		t.Fatalf("Expected 2 collectors, got %d", len(kept))
+	}
+	if kept[0].Name != "auto-resources-pods" {
+		t.Fatalf("Expected pods collector to be kept, got %s", kept[0].Name)
+	}
+	namespaces := kept[0].Parameters["namespaces"].([]string)
+	if len(namespaces) != 1 || namespaces[0] != "small" {
+		t.Errorf("Expected only namespace small left, got %v", namespaces)
+	}
+	if kept[1].Type != CollectorTypeLogs {
+		t.Errorf("Expected logs collector to be kept, got %s", kept[1].Type)
+	}
+}
+
+func TestValidateResourceFormat(t *testing.T) {
+	for _, format := range []string{"", ResourceFormatFull, ResourceFormatTable, ResourceFormatBoth} {
+		if err := ValidateResourceFormat(format); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", format, err)
+		}
+	}
+	if err := ValidateResourceFormat("yaml"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestConfigManager_GetDiscoveryOptions_ResourceFormat(t *testing.T) {
+	configManager := &ConfigManager{config: &Config{
+		DefaultOptions: DiscoveryOptions{ResourceFormat: ResourceFormatBoth, TableThreshold: 1000},
+	}}
+
+	options := configManager.GetDiscoveryOptions(&DiscoveryOptions{ResourceFormat: ResourceFormatTable})
+	if options.ResourceFormat != ResourceFormatTable {
+		t.Errorf("Expected CLI resource format to override the config, got %s", options.ResourceFormat)
+	}
+	if options.TableThreshold != 1000 {
+		t.Errorf("Expected config table threshold to be kept, got %d", options.TableThreshold)
+	}
+}
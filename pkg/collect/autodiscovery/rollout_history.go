@@ -0,0 +1,261 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultRolloutRevisionLimit is the number of most recent revisions kept per workload
+const DefaultRolloutRevisionLimit = 5
+
+// deploymentRevisionAnnotation holds the rollout revision of a Deployment's ReplicaSet
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+var (
+	deploymentsGVR         = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	statefulSetsGVR        = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	replicaSetsGVR         = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	controllerRevisionsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "controllerrevisions"}
+)
+
+// RolloutRevision is one revision of a workload with the pod template it rolled out
+type RolloutRevision struct {
+	Revision    int64                  `json:"revision"`
+	Name        string                 `json:"name"`
+	CreatedAt   string                 `json:"createdAt,omitempty"`
+	PodTemplate map[string]interface{} `json:"podTemplate,omitempty"`
+}
+
+// TemplateChange is a single field that differs between two pod templates
+type TemplateChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// RevisionDiff summarizes what changed between the previous and the current revision
+type RevisionDiff struct {
+	FromRevision int64            `json:"fromRevision"`
+	ToRevision   int64            `json:"toRevision"`
+	Changes      []TemplateChange `json:"changes"`
+}
+
+// RolloutHistoryReport is the rollout history of a single Deployment or StatefulSet
+type RolloutHistoryReport struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Revisions []RolloutRevision `json:"revisions"`
+	Diff      *RevisionDiff     `json:"diff,omitempty"`
+	Problem   string            `json:"problem,omitempty"`
+}
+
+// RolloutHistory generates rollout history collectors for discovered Deployments and StatefulSets
+type RolloutHistory struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewRolloutHistory creates a new RolloutHistory
+func NewRolloutHistory(dynamicClient dynamic.Interface) *RolloutHistory {
+	return &RolloutHistory{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateRolloutCollectors returns one data collector per discovered Deployment or StatefulSet
+// holding its owned ReplicaSets or ControllerRevisions and a diff of the most recent rollout
+func (r *RolloutHistory) GenerateRolloutCollectors(ctx context.Context, resources []Resource) []CollectorSpec {
+	var collectors []CollectorSpec
+	for _, resource := range resources {
+		var report RolloutHistoryReport
+		switch resource.GVR {
+		case deploymentsGVR:
+			report = r.deploymentHistory(ctx, resource)
+		case statefulSetsGVR:
+			report = r.statefulSetHistory(ctx, resource)
+		default:
+			continue
+		}
+
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			continue
+		}
+		collectors = append(collectors, CollectorSpec{
+			Type:      "data",
+			Name:      fmt.Sprintf("auto-rollout-%s-%s-%s", resource.Namespace, resource.GVR.Resource, resource.Name),
+			Namespace: resource.Namespace,
+			Group:     CollectorGroupWorkloads,
+			Priority:  int(PriorityNormal),
+			Parameters: map[string]interface{}{
+				"name": fmt.Sprintf("rollouts/%s/%s-%s.json", resource.Namespace, resource.GVR.Resource, resource.Name),
+				"data": string(data),
+			},
+		})
+	}
+	return collectors
+}
+
+// deploymentHistory builds the report from the ReplicaSets controlled by the Deployment
+func (r *RolloutHistory) deploymentHistory(ctx context.Context, resource Resource) RolloutHistoryReport {
+	report := RolloutHistoryReport{Kind: "Deployment", Namespace: resource.Namespace, Name: resource.Name, Revisions: []RolloutRevision{}}
+
+	replicaSets, err := r.dynamicClient.Resource(replicaSetsGVR).Namespace(resource.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.Problem = fmt.Sprintf("failed to list replicasets: %v", err)
+		return report
+	}
+
+	for _, rs := range replicaSets.Items {
+		if !hasOwner(rs, "Deployment", resource.Name) {
+			continue
+		}
+		revision, _ := strconv.ParseInt(rs.GetAnnotations()[deploymentRevisionAnnotation], 10, 64)
+		template, _, _ := unstructured.NestedMap(rs.Object, "spec", "template")
+		report.Revisions = append(report.Revisions, newRolloutRevision(rs, revision, template))
+	}
+
+	finishRolloutReport(&report)
+	return report
+}
+
+// statefulSetHistory builds the report from the ControllerRevisions controlled by the StatefulSet
+func (r *RolloutHistory) statefulSetHistory(ctx context.Context, resource Resource) RolloutHistoryReport {
+	report := RolloutHistoryReport{Kind: "StatefulSet", Namespace: resource.Namespace, Name: resource.Name, Revisions: []RolloutRevision{}}
+
+	revisions, err := r.dynamicClient.Resource(controllerRevisionsGVR).Namespace(resource.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.Problem = fmt.Sprintf("failed to list controllerrevisions: %v", err)
+		return report
+	}
+
+	for _, cr := range revisions.Items {
+		if !hasOwner(cr, "StatefulSet", resource.Name) {
+			continue
+		}
+		revision, _, _ := unstructured.NestedInt64(cr.Object, "revision")
+		// StatefulSet revisions store the template as a replace patch under data.spec.template
+		template, _, _ := unstructured.NestedMap(cr.Object, "data", "spec", "template")
+		delete(template, "$patch")
+		report.Revisions = append(report.Revisions, newRolloutRevision(cr, revision, template))
+	}
+
+	finishRolloutReport(&report)
+	return report
+}
+
+func newRolloutRevision(obj unstructured.Unstructured, revision int64, template map[string]interface{}) RolloutRevision {
+	rolloutRevision := RolloutRevision{
+		Revision:    revision,
+		Name:        obj.GetName(),
+		PodTemplate: template,
+	}
+	if created := obj.GetCreationTimestamp(); !created.IsZero() {
+		rolloutRevision.CreatedAt = created.UTC().Format(time.RFC3339)
+	}
+	return rolloutRevision
+}
+
+// finishRolloutReport sorts revisions newest first, keeps the most recent ones and diffs the last rollout
+func finishRolloutReport(report *RolloutHistoryReport) {
+	sort.Slice(report.Revisions, func(i, j int) bool {
+		return report.Revisions[i].Revision > report.Revisions[j].Revision
+	})
+	if len(report.Revisions) > DefaultRolloutRevisionLimit {
+		report.Revisions = report.Revisions[:DefaultRolloutRevisionLimit]
+	}
+	if len(report.Revisions) < 2 {
+		return
+	}
+
+	current, previous := report.Revisions[0], report.Revisions[1]
+	report.Diff = &RevisionDiff{
+		FromRevision: previous.Revision,
+		ToRevision:   current.Revision,
+		Changes:      diffPodTemplates(previous.PodTemplate, current.PodTemplate),
+	}
+}
+
+// hasOwner reports whether obj has an owner reference to the named object of the given kind
+func hasOwner(obj unstructured.Unstructured, kind, name string) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == kind && owner.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// diffPodTemplates returns the fields that differ between two pod templates, sorted by path
+// Labels added per revision by the controller are ignored
+func diffPodTemplates(from, to map[string]interface{}) []TemplateChange {
+	fromFields := make(map[string]interface{})
+	toFields := make(map[string]interface{})
+	flattenTemplate("", from, fromFields)
+	flattenTemplate("", to, toFields)
+
+	changes := []TemplateChange{}
+	for path, fromValue := range fromFields {
+		toValue, ok := toFields[path]
+		if !ok {
+			changes = append(changes, TemplateChange{Path: path, From: fromValue})
+		} else if !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, TemplateChange{Path: path, From: fromValue, To: toValue})
+		}
+	}
+	for path, toValue := range toFields {
+		if _, ok := fromFields[path]; !ok {
+			changes = append(changes, TemplateChange{Path: path, To: toValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// flattenTemplate records every leaf value of a pod template keyed by its dotted path
+// List items with a name, such as containers and volumes, are keyed by name instead of index
+func flattenTemplate(prefix string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if podTemplateLabels[key] && prefix == "metadata.labels" {
+				continue
+			}
+			flattenTemplate(joinFieldPath(prefix, key), child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			key := strconv.Itoa(i)
+			if item, ok := child.(map[string]interface{}); ok {
+				if name, ok := item["name"].(string); ok && name != "" {
+					key = name
+				}
+			}
+			flattenTemplate(fmt.Sprintf("%s[%s]", prefix, key), child, fields)
+		}
+	default:
+		if prefix != "" {
+			fields[prefix] = v
+		}
+	}
+}
+
+func joinFieldPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,174 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testReplicaSet(name, deployment, revision, image string) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "app",
+			Annotations:     map[string]string{deploymentRevisionAnnotation: revision},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: deployment}},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": deployment, "pod-template-hash": name}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+			},
+		},
+	}
+}
+
+func rolloutReport(t *testing.T, collector CollectorSpec) RolloutHistoryReport {
+	var report RolloutHistoryReport
+	data, _ := collector.Parameters["data"].(string)
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		t.Fatalf("Failed to parse rollout report: %v", err)
+	}
+	return report
+}
+
+func TestRolloutHistory_Deployment(t *testing.T) {
+	client := createTestDynamicClient(
+		testReplicaSet("web-1", "web", "1", "nginx:1.24"),
+		testReplicaSet("web-2", "web", "2", "nginx:1.25"),
+		testReplicaSet("web-3", "web", "3", "nginx:1.26"),
+		testReplicaSet("api-1", "api", "1", "api:1.0"),
+	)
+
+	resources := []Resource{
+		{GVR: deploymentsGVR, Namespace: "app", Name: "web"},
+		{GVR: podsGVR, Namespace: "app", Name: "web-abcde"},
+	}
+
+	collectors := NewRolloutHistory(client).GenerateRolloutCollectors(context.Background(), resources)
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 rollout collector, got %d", len(collectors))
+	}
+
+	collector := collectors[0]
+	if collector.Name != "auto-rollout-app-deployments-web" {
+		t.Errorf("Expected collector name auto-rollout-app-deployments-web, got %s", collector.Name)
+	}
+	if collector.Parameters["name"] != "rollouts/app/deployments-web.json" {
+		t.Errorf("Expected output rollouts/app/deployments-web.json, got %v", collector.Parameters["name"])
+	}
+
+	report := rolloutReport(t, collector)
+	if len(report.Revisions) != 3 {
+		t.Fatalf("Expected 3 revisions, got %d", len(report.Revisions))
+	}
+	if report.Revisions[0].Revision != 3 || report.Revisions[0].Name != "web-3" {
+		t.Errorf("Expected newest revision first, got %d (%s)", report.Revisions[0].Revision, report.Revisions[0].Name)
+	}
+
+	if report.Diff == nil {
+		t.Fatalf("Expected a revision diff")
+	}
+	if report.Diff.FromRevision != 2 || report.Diff.ToRevision != 3 {
+		t.Errorf("Expected diff from revision 2 to 3, got %d to %d", report.Diff.FromRevision, report.Diff.ToRevision)
+	}
+	if len(report.Diff.Changes) != 1 {
+		t.Fatalf("Expected 1 change (pod-template-hash ignored), got %v", report.Diff.Changes)
+	}
+	change := report.Diff.Changes[0]
+	if change.Path != "spec.containers[web].image" || change.From != "nginx:1.25" || change.To != "nginx:1.26" {
+		t.Errorf("Unexpected change: %+v", change)
+	}
+}
+
+func TestRolloutHistory_StatefulSet(t *testing.T) {
+	revision := func(name string, number int64, image string) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "app",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db"}},
+			},
+			Revision: number,
+			Data:     runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"db","image":"` + image + `"}]}}}}`)},
+		}
+	}
+
+	client := createTestDynamicClient(
+		revision("db-7d9f", 1, "postgres:15"),
+		revision("db-8c2a", 2, "postgres:16"),
+	)
+
+	collectors := NewRolloutHistory(client).GenerateRolloutCollectors(context.Background(), []Resource{
+		{GVR: statefulSetsGVR, Namespace: "app", Name: "db"},
+	})
+	if len(collectors) != 1 {
+		t.Fatalf("Expected 1 rollout collector, got %d", len(collectors))
+	}
+
+	report := rolloutReport(t, collectors[0])
+	if report.Kind != "StatefulSet" || len(report.Revisions) != 2 {
+		t.Fatalf("Expected 2 StatefulSet revisions, got %s with %d", report.Kind, len(report.Revisions))
+	}
+	if _, ok := report.Revisions[0].PodTemplate["$patch"]; ok {
+		t.Errorf("Expected $patch to be removed from the pod template")
+	}
+	if report.Diff == nil || len(report.Diff.Changes) != 1 || report.Diff.Changes[0].Path != "spec.containers[db].image" {
+		t.Errorf("Expected a single image change, got %+v", report.Diff)
+	}
+}
+
+func TestRolloutHistory_RevisionLimit(t *testing.T) {
+	var objects []runtime.Object
+	for i := 1; i <= DefaultRolloutRevisionLimit+3; i++ {
+		objects = append(objects, testReplicaSet(fmt.Sprintf("web-%d", i), "web", strconv.Itoa(i), "nginx"))
+	}
+	client := createTestDynamicClient(objects...)
+
+	collectors := NewRolloutHistory(client).GenerateRolloutCollectors(context.Background(), []Resource{
+		{GVR: deploymentsGVR, Namespace: "app", Name: "web"},
+	})
+	report := rolloutReport(t, collectors[0])
+	if len(report.Revisions) != DefaultRolloutRevisionLimit {
+		t.Errorf("Expected %d revisions, got %d", DefaultRolloutRevisionLimit, len(report.Revisions))
+	}
+	if report.Diff == nil || len(report.Diff.Changes) != 0 {
+		t.Errorf("Expected an empty diff for identical templates, got %+v", report.Diff)
+	}
+}
+
+func TestDiffPodTemplates(t *testing.T) {
+	from := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1", "args": []interface{}{"--debug"}},
+			},
+		},
+	}
+	to := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{"restartedAt": "now"}},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1"},
+			},
+		},
+	}
+
+	changes := diffPodTemplates(from, to)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %v", changes)
+	}
+	if changes[0].Path != "metadata.annotations.restartedAt" || changes[0].To != "now" || changes[0].From != nil {
+		t.Errorf("Unexpected added field: %+v", changes[0])
+	}
+	if changes[1].Path != "spec.containers[app].args[0]" || changes[1].From != "--debug" || changes[1].To != nil {
+		t.Errorf("Unexpected removed field: %+v", changes[1])
+	}
+}
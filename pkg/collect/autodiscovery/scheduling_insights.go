@@ -0,0 +1,562 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultSchedulingPodLimit is the number of Pending and of evicted pods analyzed
+const DefaultSchedulingPodLimit = 100
+
+// SchedulingAnalysisPath is where the scheduling analysis is written in the bundle
+const SchedulingAnalysisPath = "cluster-info/scheduling-analysis.json"
+
+var priorityClassesGVR = schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+
+// Event reasons the scheduling analysis reads
+const (
+	eventReasonFailedScheduling = "FailedScheduling"
+	eventReasonEvicted          = "Evicted"
+)
+
+// ResourceAmounts are the CPU and memory amounts the scheduler compares
+type ResourceAmounts struct {
+	CPUMillis   int64 `json:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes"`
+}
+
+// NodeAllocation compares a node's allocatable resources with the requests of the pods bound to it
+type NodeAllocation struct {
+	Name          string          `json:"name"`
+	Unschedulable bool            `json:"unschedulable,omitempty"`
+	Taints        []string        `json:"taints,omitempty"` // key=value:Effect
+	Allocatable   ResourceAmounts `json:"allocatable"`
+	Requested     ResourceAmounts `json:"requested"`
+	Pods          int             `json:"pods"`
+	MaxPods       int64           `json:"maxPods"`
+
+	labels map[string]string
+	taints []nodeTaint
+}
+
+// free returns the allocatable resources not requested yet
+func (n NodeAllocation) free() ResourceAmounts {
+	return ResourceAmounts{
+		CPUMillis:   n.Allocatable.CPUMillis - n.Requested.CPUMillis,
+		MemoryBytes: n.Allocatable.MemoryBytes - n.Requested.MemoryBytes,
+	}
+}
+
+// SchedulingEvent is a FailedScheduling or Evicted event of a pod
+type SchedulingEvent struct {
+	Time    string `json:"time"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Count   int64  `json:"count,omitempty"`
+}
+
+// SchedulingPod is a Pending or evicted pod with what the scheduler saw of it
+type SchedulingPod struct {
+	Namespace     string            `json:"namespace"`
+	Name          string            `json:"name"`
+	PriorityClass string            `json:"priorityClass,omitempty"`
+	Priority      int64             `json:"priority"`
+	Requests      ResourceAmounts   `json:"requests"`
+	NodeSelector  map[string]string `json:"nodeSelector,omitempty"`
+	Reason        string            `json:"reason,omitempty"` // Eviction message of evicted pods
+	// Pending pods only: nodes that could fit the pod, and how many nodes each untolerated taint or the selector rules out
+	FittingNodes      []string          `json:"fittingNodes,omitempty"`
+	UntoleratedTaints map[string]int    `json:"untoleratedTaints,omitempty"`
+	SelectorMismatch  int               `json:"selectorMismatch,omitempty"`
+	InsufficientNodes map[string]int    `json:"insufficientNodes,omitempty"` // "cpu" or "memory" to nodes without enough free
+	Events            []SchedulingEvent `json:"events,omitempty"`
+}
+
+// PriorityClassInfo is a PriorityClass pods can preempt with
+type PriorityClassInfo struct {
+	Name             string `json:"name"`
+	Value            int64  `json:"value"`
+	GlobalDefault    bool   `json:"globalDefault,omitempty"`
+	PreemptionPolicy string `json:"preemptionPolicy,omitempty"`
+}
+
+// SchedulingAnalysis explains why pods are Pending or were evicted
+type SchedulingAnalysis struct {
+	PendingPods     []SchedulingPod     `json:"pendingPods"`
+	EvictedPods     []SchedulingPod     `json:"evictedPods"`
+	Nodes           []NodeAllocation    `json:"nodes"`
+	PriorityClasses []PriorityClassInfo `json:"priorityClasses"`
+	Truncated       bool                `json:"truncated,omitempty"` // More than DefaultSchedulingPodLimit pods were Pending or evicted
+	Problems        []string            `json:"problems,omitempty"`
+}
+
+// SchedulingInsights generates the scheduler and eviction collectors when discovered pods are Pending or evicted
+type SchedulingInsights struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewSchedulingInsights creates a new SchedulingInsights
+func NewSchedulingInsights(dynamicClient dynamic.Interface) *SchedulingInsights {
+	return &SchedulingInsights{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// GenerateSchedulingCollectors returns the scheduling analysis and the PriorityClasses when a pod in a discovered
+// namespace is Pending or was evicted, evictions are found from evicted pods not yet cleaned up and from Evicted
+// events inside the time window. It returns nil when scheduling looks healthy
+func (s *SchedulingInsights) GenerateSchedulingCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions) []CollectorSpec {
+	namespaces := make(map[string]bool)
+	for _, resource := range resources {
+		if resource.Namespace != "" {
+			namespaces[resource.Namespace] = true
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	analysis := s.Analyze(ctx, namespaces, opts.TimeWindow)
+	if len(analysis.PendingPods) == 0 && len(analysis.EvictedPods) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return []CollectorSpec{
+		{
+			Type:     "data",
+			Name:     "auto-scheduling-analysis",
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(PriorityHigh),
+			Parameters: map[string]interface{}{
+				"name": SchedulingAnalysisPath,
+				"data": string(data),
+			},
+		},
+		{
+			Type:     CollectorTypeClusterResources,
+			Name:     "auto-resources-priorityclasses",
+			Group:    CollectorGroupClusterInfo,
+			Priority: int(PriorityNormal),
+			Parameters: ClusterResourcesParams{
+				Group:    priorityClassesGVR.Group,
+				Version:  priorityClassesGVR.Version,
+				Resource: priorityClassesGVR.Resource,
+			}.ToMap(),
+		},
+	}
+}
+
+// Analyze finds the Pending and evicted pods of namespaces and compares them with the nodes, listing failures as
+// problems rather than failing
+func (s *SchedulingInsights) Analyze(ctx context.Context, namespaces map[string]bool, window TimeWindow) *SchedulingAnalysis {
+	analysis := &SchedulingAnalysis{
+		PendingPods:     []SchedulingPod{},
+		EvictedPods:     []SchedulingPod{},
+		Nodes:           []NodeAllocation{},
+		PriorityClasses: []PriorityClassInfo{},
+	}
+
+	pods := s.listPods(ctx, namespaces, analysis)
+	var pending []unstructured.Unstructured
+	evicted := make(map[string]SchedulingPod)
+	for _, pod := range pods {
+		if !namespaces[pod.GetNamespace()] {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		nodeName, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName")
+		reason, _, _ := unstructured.NestedString(pod.Object, "status", "reason")
+		switch {
+		case phase == "Pending" && nodeName == "":
+			pending = append(pending, pod)
+		case phase == "Failed" && reason == eventReasonEvicted:
+			evictedPod := schedulingPod(pod)
+			evictedPod.Reason, _, _ = unstructured.NestedString(pod.Object, "status", "message")
+			evicted[pod.GetNamespace()+"/"+pod.GetName()] = evictedPod
+		}
+	}
+
+	events := s.schedulingEvents(ctx, namespaces, window, analysis)
+	for key, podEvents := range events {
+		for _, event := range podEvents {
+			if event.Reason != eventReasonEvicted {
+				continue
+			}
+			evictedPod, ok := evicted[key]
+			if !ok {
+				namespace, name, _ := strings.Cut(key, "/")
+				evictedPod = SchedulingPod{Namespace: namespace, Name: name, Reason: event.Message}
+			}
+			evictedPod.Events = append(evictedPod.Events, event)
+			evicted[key] = evictedPod
+		}
+	}
+	if len(pending) == 0 && len(evicted) == 0 {
+		return analysis
+	}
+
+	analysis.Nodes = s.nodeAllocations(ctx, pods, analysis)
+	analysis.PriorityClasses = s.priorityClasses(ctx, analysis)
+
+	for _, pod := range pending {
+		pendingPod := schedulingPod(pod)
+		explainPending(&pendingPod, pod, analysis.Nodes)
+		for _, event := range events[pod.GetNamespace()+"/"+pod.GetName()] {
+			if event.Reason == eventReasonFailedScheduling {
+				pendingPod.Events = append(pendingPod.Events, event)
+			}
+		}
+		analysis.PendingPods = append(analysis.PendingPods, pendingPod)
+	}
+	for _, evictedPod := range evicted {
+		analysis.EvictedPods = append(analysis.EvictedPods, evictedPod)
+	}
+
+	for _, list := range []*[]SchedulingPod{&analysis.PendingPods, &analysis.EvictedPods} {
+		sort.Slice(*list, func(i, j int) bool {
+			a, b := (*list)[i], (*list)[j]
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+		if len(*list) > DefaultSchedulingPodLimit {
+			*list = (*list)[:DefaultSchedulingPodLimit]
+			analysis.Truncated = true
+		}
+	}
+	return analysis
+}
+
+// listPods lists the pods of every namespace, node requests need them all, falling back to the discovered
+// namespaces for users that cannot list pods cluster-wide
+func (s *SchedulingInsights) listPods(ctx context.Context, namespaces map[string]bool, analysis *SchedulingAnalysis) []unstructured.Unstructured {
+	list, err := s.dynamicClient.Resource(podsGVR).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		return list.Items
+	}
+	analysis.Problems = append(analysis.Problems, fmt.Sprintf("failed to list pods in all namespaces, node requests only include discovered namespaces: %v", err))
+
+	names := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	var pods []unstructured.Unstructured
+	for _, namespace := range names {
+		list, err := s.dynamicClient.Resource(podsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			analysis.Problems = append(analysis.Problems, fmt.Sprintf("failed to list pods in %s: %v", namespace, err))
+			continue
+		}
+		pods = append(pods, list.Items...)
+	}
+	return pods
+}
+
+// schedulingEvents returns the FailedScheduling and Evicted events of pods inside the window, by namespace/name
+func (s *SchedulingInsights) schedulingEvents(ctx context.Context, namespaces map[string]bool, window TimeWindow, analysis *SchedulingAnalysis) map[string][]SchedulingEvent {
+	names := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	events := make(map[string][]SchedulingEvent)
+	for _, namespace := range names {
+		list, err := s.dynamicClient.Resource(eventsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			analysis.Problems = append(analysis.Problems, fmt.Sprintf("failed to list events in %s: %v", namespace, err))
+			continue
+		}
+		for _, event := range list.Items {
+			kind, _, _ := unstructured.NestedString(event.Object, "involvedObject", "kind")
+			name, _, _ := unstructured.NestedString(event.Object, "involvedObject", "name")
+			entry, ok := eventTimelineEntry(event, kind, name)
+			if !ok || kind != "Pod" || (entry.Reason != eventReasonFailedScheduling && entry.Reason != eventReasonEvicted) {
+				continue
+			}
+			if t, err := time.Parse(time.RFC3339, entry.Time); err == nil && !window.IsZero() && !window.Contains(t) {
+				continue
+			}
+			key := namespace + "/" + name
+			events[key] = append(events[key], SchedulingEvent{Time: entry.Time, Reason: entry.Reason, Message: entry.Message, Count: entry.Count})
+		}
+	}
+	for _, podEvents := range events {
+		sort.Slice(podEvents, func(i, j int) bool { return podEvents[i].Time < podEvents[j].Time })
+	}
+	return events
+}
+
+// nodeAllocations sums the requests of the running pods bound to each node
+func (s *SchedulingInsights) nodeAllocations(ctx context.Context, pods []unstructured.Unstructured, analysis *SchedulingAnalysis) []NodeAllocation {
+	nodes, err := s.dynamicClient.Resource(nodesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		analysis.Problems = append(analysis.Problems, fmt.Sprintf("failed to list nodes: %v", err))
+		return []NodeAllocation{}
+	}
+
+	allocations := make([]NodeAllocation, 0, len(nodes.Items))
+	index := make(map[string]int, len(nodes.Items))
+	for _, node := range nodes.Items {
+		allocation := NodeAllocation{Name: node.GetName(), labels: node.GetLabels(), taints: nodeTaints(node)}
+		allocation.Unschedulable, _, _ = unstructured.NestedBool(node.Object, "spec", "unschedulable")
+		for _, taint := range allocation.taints {
+			allocation.Taints = append(allocation.Taints, taint.String())
+		}
+		allocatable, _, _ := unstructured.NestedStringMap(node.Object, "status", "allocatable")
+		allocation.Allocatable = resourceAmounts(allocatable)
+		if maxPods, err := resource.ParseQuantity(allocatable["pods"]); err == nil {
+			allocation.MaxPods = maxPods.Value()
+		}
+		index[allocation.Name] = len(allocations)
+		allocations = append(allocations, allocation)
+	}
+
+	for _, pod := range pods {
+		nodeName, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName")
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		i, ok := index[nodeName]
+		if !ok || phase == "Succeeded" || phase == "Failed" {
+			continue
+		}
+		requests := podRequests(pod)
+		allocations[i].Requested.CPUMillis += requests.CPUMillis
+		allocations[i].Requested.MemoryBytes += requests.MemoryBytes
+		allocations[i].Pods++
+	}
+
+	sort.Slice(allocations, func(i, j int) bool { return allocations[i].Name < allocations[j].Name })
+	return allocations
+}
+
+// priorityClasses lists the PriorityClasses, highest value first
+func (s *SchedulingInsights) priorityClasses(ctx context.Context, analysis *SchedulingAnalysis) []PriorityClassInfo {
+	list, err := s.dynamicClient.Resource(priorityClassesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		analysis.Problems = append(analysis.Problems, fmt.Sprintf("failed to list priority classes: %v", err))
+		return []PriorityClassInfo{}
+	}
+
+	classes := make([]PriorityClassInfo, 0, len(list.Items))
+	for _, item := range list.Items {
+		class := PriorityClassInfo{Name: item.GetName()}
+		class.Value, _, _ = unstructured.NestedInt64(item.Object, "value")
+		class.GlobalDefault, _, _ = unstructured.NestedBool(item.Object, "globalDefault")
+		class.PreemptionPolicy, _, _ = unstructured.NestedString(item.Object, "preemptionPolicy")
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if classes[i].Value != classes[j].Value {
+			return classes[i].Value > classes[j].Value
+		}
+		return classes[i].Name < classes[j].Name
+	})
+	return classes
+}
+
+// schedulingPod reads the fields the scheduler matches a pod on
+func schedulingPod(pod unstructured.Unstructured) SchedulingPod {
+	result := SchedulingPod{Namespace: pod.GetNamespace(), Name: pod.GetName(), Requests: podRequests(pod)}
+	result.PriorityClass, _, _ = unstructured.NestedString(pod.Object, "spec", "priorityClassName")
+	result.Priority, _, _ = unstructured.NestedInt64(pod.Object, "spec", "priority")
+	result.NodeSelector, _, _ = unstructured.NestedStringMap(pod.Object, "spec", "nodeSelector")
+	return result
+}
+
+// explainPending records the nodes that fit a Pending pod and why the others do not
+// Node affinity, topology spread and volume constraints are not evaluated, the FailedScheduling events cover them
+func explainPending(result *SchedulingPod, pod unstructured.Unstructured, nodes []NodeAllocation) {
+	tolerations := podTolerations(pod)
+	result.UntoleratedTaints = map[string]int{}
+	result.InsufficientNodes = map[string]int{}
+
+	for _, node := range nodes {
+		fits := !node.Unschedulable
+		for _, taint := range node.taints {
+			if taint.Effect == "PreferNoSchedule" || toleratesTaint(tolerations, taint) {
+				continue
+			}
+			result.UntoleratedTaints[taint.String()]++
+			fits = false
+		}
+		if !matchesNodeSelector(result.NodeSelector, node.labels) {
+			result.SelectorMismatch++
+			fits = false
+		}
+		free := node.free()
+		if result.Requests.CPUMillis > free.CPUMillis {
+			result.InsufficientNodes["cpu"]++
+			fits = false
+		}
+		if result.Requests.MemoryBytes > free.MemoryBytes {
+			result.InsufficientNodes["memory"]++
+			fits = false
+		}
+		if node.MaxPods > 0 && int64(node.Pods) >= node.MaxPods {
+			result.InsufficientNodes["pods"]++
+			fits = false
+		}
+		if fits {
+			result.FittingNodes = append(result.FittingNodes, node.Name)
+		}
+	}
+	if len(result.UntoleratedTaints) == 0 {
+		result.UntoleratedTaints = nil
+	}
+	if len(result.InsufficientNodes) == 0 {
+		result.InsufficientNodes = nil
+	}
+}
+
+// nodeTaint is a taint of a node
+type nodeTaint struct {
+	Key    string
+	Value  string
+	Effect string
+}
+
+// String formats the taint like kubectl, key=value:Effect
+func (t nodeTaint) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + t.Effect
+	}
+	return t.Key + "=" + t.Value + ":" + t.Effect
+}
+
+func nodeTaints(node unstructured.Unstructured) []nodeTaint {
+	items, _, _ := unstructured.NestedSlice(node.Object, "spec", "taints")
+	var taints []nodeTaint
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		taint := nodeTaint{}
+		taint.Key, _, _ = unstructured.NestedString(fields, "key")
+		taint.Value, _, _ = unstructured.NestedString(fields, "value")
+		taint.Effect, _, _ = unstructured.NestedString(fields, "effect")
+		taints = append(taints, taint)
+	}
+	return taints
+}
+
+// podToleration is a toleration of a pod
+type podToleration struct {
+	Key      string
+	Operator string
+	Value    string
+	Effect   string
+}
+
+func podTolerations(pod unstructured.Unstructured) []podToleration {
+	items, _, _ := unstructured.NestedSlice(pod.Object, "spec", "tolerations")
+	var tolerations []podToleration
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		toleration := podToleration{}
+		toleration.Key, _, _ = unstructured.NestedString(fields, "key")
+		toleration.Operator, _, _ = unstructured.NestedString(fields, "operator")
+		toleration.Value, _, _ = unstructured.NestedString(fields, "value")
+		toleration.Effect, _, _ = unstructured.NestedString(fields, "effect")
+		tolerations = append(tolerations, toleration)
+	}
+	return tolerations
+}
+
+// toleratesTaint matches tolerations against a taint the way the scheduler does
+func toleratesTaint(tolerations []podToleration, taint nodeTaint) bool {
+	for _, toleration := range tolerations {
+		if toleration.Effect != "" && toleration.Effect != taint.Effect {
+			continue
+		}
+		if toleration.Key == "" && toleration.Operator == "Exists" {
+			return true // An empty key with Exists tolerates everything
+		}
+		if toleration.Key != taint.Key {
+			continue
+		}
+		if toleration.Operator == "Exists" || toleration.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeSelector reports whether the node's labels satisfy the pod's nodeSelector
+func matchesNodeSelector(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// podRequests returns the effective requests of a pod: the larger of its containers' total and its largest init
+// container, plus the pod overhead
+func podRequests(pod unstructured.Unstructured) ResourceAmounts {
+	containerRequests := func(field string) []ResourceAmounts {
+		containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", field)
+		var amounts []ResourceAmounts
+		for _, item := range containers {
+			container, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			requests, _, _ := unstructured.NestedStringMap(container, "resources", "requests")
+			amounts = append(amounts, resourceAmounts(requests))
+		}
+		return amounts
+	}
+
+	var total ResourceAmounts
+	for _, amounts := range containerRequests("containers") {
+		total.CPUMillis += amounts.CPUMillis
+		total.MemoryBytes += amounts.MemoryBytes
+	}
+	for _, amounts := range containerRequests("initContainers") {
+		if amounts.CPUMillis > total.CPUMillis {
+			total.CPUMillis = amounts.CPUMillis
+		}
+		if amounts.MemoryBytes > total.MemoryBytes {
+			total.MemoryBytes = amounts.MemoryBytes
+		}
+	}
+	overhead, _, _ := unstructured.NestedStringMap(pod.Object, "spec", "overhead")
+	extra := resourceAmounts(overhead)
+	total.CPUMillis += extra.CPUMillis
+	total.MemoryBytes += extra.MemoryBytes
+	return total
+}
+
+// resourceAmounts parses the cpu and memory quantities of a resource list, unparseable quantities count as 0
+func resourceAmounts(list map[string]string) ResourceAmounts {
+	var amounts ResourceAmounts
+	if cpu, err := resource.ParseQuantity(list["cpu"]); err == nil {
+		amounts.CPUMillis = cpu.MilliValue()
+	}
+	if memory, err := resource.ParseQuantity(list["memory"]); err == nil {
+		amounts.MemoryBytes = memory.Value()
+	}
+	return amounts
+}
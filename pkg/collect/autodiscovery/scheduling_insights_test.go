@@ -0,0 +1,174 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testSchedulingNode(name, cpu, memory string, labels map[string]string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}},
+	}
+}
+
+func testSchedulingPod(namespace, name, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+func TestSchedulingInsights_GenerateSchedulingCollectors(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	pending := testSchedulingPod("app", "web-pending", "1", "1Gi")
+	pending.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	pending.Spec.PriorityClassName = "high"
+	tolerant := testSchedulingPod("app", "tolerant", "500m", "256Mi")
+	tolerant.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+	busy := testSchedulingPod("batch", "busy", "1500m", "1Gi")
+	busy.Spec.NodeName = "node-a"
+	busy.Status.Phase = corev1.PodRunning
+	evicted := testSchedulingPod("app", "web-evicted", "100m", "64Mi")
+	evicted.Spec.NodeName = "node-c"
+	evicted.Status = corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory."}
+
+	failed := testTimelineEvent("app", "web-pending.1", "Pod", "web-pending", "FailedScheduling", base.Add(5*time.Minute))
+	failed.Message = "0/3 nodes are available: 1 Insufficient cpu, 1 node(s) had untolerated taint {dedicated: gpu}"
+	gone := testTimelineEvent("app", "gone.1", "Pod", "gone", "Evicted", base.Add(6*time.Minute))
+	gone.Message = "Pod ephemeral local storage usage exceeds the total limit of containers 1Gi."
+	old := testTimelineEvent("app", "web-pending.0", "Pod", "web-pending", "FailedScheduling", base.Add(-3*time.Hour))
+
+	client := createTestDynamicClient(
+		testSchedulingNode("node-a", "2", "4Gi", map[string]string{"disk": "ssd"}),
+		testSchedulingNode("node-b", "4", "8Gi", map[string]string{"disk": "ssd"}, corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}),
+		testSchedulingNode("node-c", "4", "8Gi", map[string]string{"disk": "hdd"}, corev1.Taint{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule}),
+		pending, tolerant, busy, evicted,
+		failed, gone, old,
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "low"}, Value: 10},
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}, Value: 1000},
+	)
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	since := base
+	opts := DiscoveryOptions{TimeWindow: TimeWindow{Since: &since}}
+	collectors := NewSchedulingInsights(client).GenerateSchedulingCollectors(context.Background(), []Resource{{GVR: pods, Namespace: "app", Name: "web-pending"}}, opts)
+	if len(collectors) != 2 {
+		t.Fatalf("Expected the analysis and PriorityClasses collectors, got %d", len(collectors))
+	}
+	if collectors[0].Parameters["name"] != SchedulingAnalysisPath || collectors[1].Name != "auto-resources-priorityclasses" {
+		t.Errorf("Unexpected collectors %s and %s", collectors[0].Parameters["name"], collectors[1].Name)
+	}
+
+	var analysis SchedulingAnalysis
+	if err := json.Unmarshal([]byte(collectors[0].Parameters["data"].(string)), &analysis); err != nil {
+		t.Fatalf("Failed to parse analysis: %v", err)
+	}
+
+	if len(analysis.Nodes) != 3 || analysis.Nodes[0].Requested.CPUMillis != 1500 || analysis.Nodes[0].Pods != 1 {
+		t.Fatalf("Expected node-a to carry the 1500m request of batch/busy, got %+v", analysis.Nodes)
+	}
+	if analysis.Nodes[2].Requested.CPUMillis != 0 {
+		t.Errorf("Expected the evicted pod not to count towards node-c, got %+v", analysis.Nodes[2])
+	}
+	if len(analysis.PriorityClasses) != 2 || analysis.PriorityClasses[0].Name != "high" {
+		t.Errorf("Expected PriorityClasses highest first, got %+v", analysis.PriorityClasses)
+	}
+
+	if len(analysis.PendingPods) != 2 {
+		t.Fatalf("Expected 2 Pending pods, got %+v", analysis.PendingPods)
+	}
+	tolerantPod, webPod := analysis.PendingPods[0], analysis.PendingPods[1]
+	if len(tolerantPod.FittingNodes) != 3 {
+		t.Errorf("Expected the tolerant pod to fit every node, got %v", tolerantPod.FittingNodes)
+	}
+	if len(webPod.FittingNodes) != 0 || webPod.InsufficientNodes["cpu"] != 1 || webPod.SelectorMismatch != 1 || webPod.UntoleratedTaints["dedicated=gpu:NoSchedule"] != 1 {
+		t.Errorf("Expected web-pending to be ruled out by cpu, selector and taint, got %+v", webPod)
+	}
+	if webPod.PriorityClass != "high" || len(webPod.Events) != 1 || webPod.Events[0].Reason != "FailedScheduling" {
+		t.Errorf("Expected the FailedScheduling event inside the window, got %+v", webPod.Events)
+	}
+
+	if len(analysis.EvictedPods) != 2 {
+		t.Fatalf("Expected the evicted pod and the eviction event, got %+v", analysis.EvictedPods)
+	}
+	if analysis.EvictedPods[0].Name != "gone" || analysis.EvictedPods[0].Reason != gone.Message {
+		t.Errorf("Expected the deleted pod from its Evicted event, got %+v", analysis.EvictedPods[0])
+	}
+	if analysis.EvictedPods[1].Name != "web-evicted" || analysis.EvictedPods[1].Reason != "The node was low on resource: memory." {
+		t.Errorf("Expected the evicted pod with its eviction message, got %+v", analysis.EvictedPods[1])
+	}
+
+	quiet := []Resource{{GVR: pods, Namespace: "batch", Name: "busy"}}
+	if collectors := NewSchedulingInsights(client).GenerateSchedulingCollectors(context.Background(), quiet, opts); collectors != nil {
+		t.Errorf("Expected no collectors without Pending or evicted pods, got %d", len(collectors))
+	}
+}
+
+func TestToleratesTaint(t *testing.T) {
+	taint := nodeTaint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}
+
+	tests := []struct {
+		name       string
+		toleration podToleration
+		expected   bool
+	}{
+		{name: "equal", toleration: podToleration{Key: "dedicated", Value: "gpu"}, expected: true},
+		{name: "exists", toleration: podToleration{Key: "dedicated", Operator: "Exists"}, expected: true},
+		{name: "exists everything", toleration: podToleration{Operator: "Exists"}, expected: true},
+		{name: "other value", toleration: podToleration{Key: "dedicated", Value: "db"}},
+		{name: "other effect", toleration: podToleration{Key: "dedicated", Operator: "Exists", Effect: "NoExecute"}},
+		{name: "other key", toleration: podToleration{Key: "spot", Operator: "Exists"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toleratesTaint([]podToleration{tt.toleration}, taint); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}}}},
+		Containers: []corev1.Container{
+			{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("128Mi")}}},
+			{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")}}},
+		},
+		Overhead: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	requests := podRequests(unstructured.Unstructured{Object: object})
+	if requests.CPUMillis != 2100 || requests.MemoryBytes != 256*1024*1024 {
+		t.Errorf("Expected 2100m and 256Mi, got %+v", requests)
+	}
+}
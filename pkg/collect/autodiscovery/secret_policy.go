@@ -0,0 +1,430 @@
+package autodiscovery
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret capture modes of a SecretTypePolicy. Neither ever writes a Secret value to the bundle
+const (
+	SecretCaptureKeys        = "keys"        // Key names and sizes
+	SecretCaptureCertificate = "certificate" // Key names and sizes, plus subject, issuer, SANs and validity of PEM certificates
+)
+
+// SecretPolicyAnnotation is added to every collected Secret, its value is the JSON encoded SecretSummary
+const SecretPolicyAnnotation = "troubleshoot.sh/secret-policy"
+
+// SecretCertificateExpiryWarning is how close to expiry a certificate is reported as expiring
+const SecretCertificateExpiryWarning = 30 * 24 * time.Hour
+
+// lastAppliedAnnotation holds the full manifest applied with kubectl apply, Secret values included
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// SecretTypePolicy collects the Secrets of one type, e.g. kubernetes.io/tls
+type SecretTypePolicy struct {
+	Type    string `json:"type" yaml:"type"`                           // Secret type, e.g. kubernetes.io/tls or Opaque
+	Capture string `json:"capture,omitempty" yaml:"capture,omitempty"` // SecretCaptureKeys (default) or SecretCaptureCertificate
+}
+
+// SecretPolicy limits Secret collection to the listed types. Collected Secrets keep their metadata, never their
+// values, and Secrets of other types are left out of the bundle. Without types, Secrets are collected as the
+// other rules decide
+type SecretPolicy struct {
+	Types []SecretTypePolicy `json:"types,omitempty" yaml:"types,omitempty"`
+}
+
+// Enabled reports whether the policy filters Secrets
+func (p SecretPolicy) Enabled() bool {
+	return len(p.Types) > 0
+}
+
+// WithOverrides returns the policy with the override types replacing its own, when set
+func (p SecretPolicy) WithOverrides(overrides SecretPolicy) SecretPolicy {
+	if overrides.Enabled() {
+		p.Types = overrides.Types
+	}
+	return p
+}
+
+// Validate checks that every type is named once with a known capture mode
+func (p SecretPolicy) Validate() error {
+	seen := make(map[string]bool)
+	for i, policy := range p.Types {
+		if policy.Type == "" {
+			return fmt.Errorf("types[%d]: type is required", i)
+		}
+		if seen[policy.Type] {
+			return fmt.Errorf("types[%d]: duplicate type %q", i, policy.Type)
+		}
+		seen[policy.Type] = true
+		switch policy.Capture {
+		case "", SecretCaptureKeys, SecretCaptureCertificate:
+		default:
+			return fmt.Errorf("types[%d]: unknown capture %q (valid: %s, %s)", i, policy.Capture, SecretCaptureKeys, SecretCaptureCertificate)
+		}
+	}
+	return nil
+}
+
+// capture returns the capture mode for a Secret type, and false when the type is not collected
+func (p SecretPolicy) capture(secretType string) (string, bool) {
+	if secretType == "" {
+		secretType = "Opaque" // The API server defaults an empty type to Opaque
+	}
+	for _, policy := range p.Types {
+		if policy.Type == secretType {
+			if policy.Capture == "" {
+				return SecretCaptureKeys, true
+			}
+			return policy.Capture, true
+		}
+	}
+	return "", false
+}
+
+// applySecretPolicy sets the policy on every cluster-resources collector that gathers Secrets, runners filter the
+// Secrets they write with SecretFilter
+func applySecretPolicy(collectors []CollectorSpec, policy SecretPolicy) []CollectorSpec {
+	if !policy.Enabled() {
+		return collectors
+	}
+
+	for i, collector := range collectors {
+		if collector.Type != CollectorTypeClusterResources {
+			continue
+		}
+		params, err := collector.ClusterResourcesParams()
+		if err != nil || !gathersSecrets(*params) {
+			continue
+		}
+		params.SecretPolicy = policy.Types
+		collectors[i].Parameters = params.ToMap()
+	}
+	return collectors
+}
+
+// gathersSecrets reports whether a cluster-resources collector lists core Secrets
+func gathersSecrets(params ClusterResourcesParams) bool {
+	for _, gvr := range params.GVRs() {
+		if gvr.Group == "" && gvr.Resource == "secrets" {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretCertificate describes one certificate found in a collected Secret
+type SecretCertificate struct {
+	Key          string    `json:"key"`
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	DNSNames     []string  `json:"dnsNames,omitempty"`
+	IPAddresses  []string  `json:"ipAddresses,omitempty"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	IsCA         bool      `json:"isCA,omitempty"`
+	Error        string    `json:"error,omitempty"` // Set when a PEM certificate block cannot be parsed
+}
+
+// SecretSummary is what a collected Secret keeps in place of its values
+type SecretSummary struct {
+	Capture      string              `json:"capture"`
+	Keys         map[string]int      `json:"keys"` // Size of each value in bytes
+	Certificates []SecretCertificate `json:"certificates,omitempty"`
+}
+
+// CollectedSecret is a Secret kept by the policy, without its values
+type CollectedSecret struct {
+	Collector string `json:"collector"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	SecretSummary
+}
+
+// SecretFilter applies the secret policy to the files each collector writes
+type SecretFilter struct {
+	now       func() time.Time
+	mu        sync.Mutex
+	collected []CollectedSecret
+	dropped   map[string]int // Secrets left out, by type
+}
+
+// NewSecretFilter creates a SecretFilter
+func NewSecretFilter() *SecretFilter {
+	return &SecretFilter{now: time.Now, dropped: make(map[string]int)}
+}
+
+// Filter rewrites the JSON output files of a collector gathering Secrets under a policy, relative to outputDir.
+// Secrets of types outside the policy are removed from lists, and emptied down to their metadata when a file
+// holds a single one; the others lose their values. It returns the number of Secrets kept and left out
+// Collectors without a policy and files that are not JSON objects or lists are left untouched
+func (f *SecretFilter) Filter(collector CollectorSpec, outputDir string, outputs []string) (int, int, error) {
+	if collector.Type != CollectorTypeClusterResources {
+		return 0, 0, nil
+	}
+	params, err := collector.ClusterResourcesParams()
+	if err != nil || len(params.SecretPolicy) == 0 {
+		return 0, 0, nil
+	}
+	policy := SecretPolicy{Types: params.SecretPolicy}
+	onlySecrets := len(params.Resources) == 0 // Batched collectors mix Secrets with other kinds
+
+	kept, dropped := 0, 0
+	for _, output := range outputs {
+		file := filepath.Join(outputDir, output)
+		info, err := os.Stat(file)
+		if err != nil {
+			return kept, dropped, fmt.Errorf("failed to filter secrets in %s: %w", output, err)
+		}
+		if info.IsDir() || !strings.HasSuffix(output, ".json") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return kept, dropped, fmt.Errorf("failed to filter secrets in %s: %w", output, err)
+		}
+		var document map[string]interface{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			continue // Not an object or list, e.g. a collector error file
+		}
+
+		var collected []CollectedSecret
+		droppedTypes := make(map[string]int)
+		isSecret := func(object map[string]interface{}) bool {
+			kind, _ := object["kind"].(string)
+			return kind == "Secret" || (onlySecrets && kind == "")
+		}
+		filterOne := func(object map[string]interface{}) bool {
+			secret, ok := filterSecret(collector.Name, object, policy)
+			if ok {
+				collected = append(collected, secret)
+			} else {
+				droppedTypes[secretType(object)]++
+			}
+			return ok
+		}
+
+		if items, ok := document["items"].([]interface{}); ok {
+			remaining := items[:0]
+			for _, item := range items {
+				object, isObject := item.(map[string]interface{})
+				if isObject && isSecret(object) && !filterOne(object) {
+					continue
+				}
+				remaining = append(remaining, item)
+			}
+			document["items"] = remaining
+		} else if isSecret(document) && !filterOne(document) {
+			stripSecretValues(document)
+		}
+		if len(collected) == 0 && len(droppedTypes) == 0 {
+			continue
+		}
+
+		rewritten, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return kept, dropped, fmt.Errorf("failed to filter secrets in %s: %w", output, err)
+		}
+		if err := os.WriteFile(file, rewritten, info.Mode().Perm()); err != nil {
+			return kept, dropped, fmt.Errorf("failed to filter secrets in %s: %w", output, err)
+		}
+		kept += len(collected)
+		f.mu.Lock()
+		f.collected = append(f.collected, collected...)
+		for secretType, count := range droppedTypes {
+			f.dropped[secretType] += count
+			dropped += count
+		}
+		f.mu.Unlock()
+	}
+	return kept, dropped, nil
+}
+
+// Collected returns the Secrets kept so far, sorted by namespace and name
+func (f *SecretFilter) Collected() []CollectedSecret {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	collected := append([]CollectedSecret{}, f.collected...)
+	sort.Slice(collected, func(i, j int) bool {
+		if collected[i].Namespace != collected[j].Namespace {
+			return collected[i].Namespace < collected[j].Namespace
+		}
+		return collected[i].Name < collected[j].Name
+	})
+	return collected
+}
+
+// Dropped returns the number of Secrets left out so far, by type
+func (f *SecretFilter) Dropped() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dropped := make(map[string]int, len(f.dropped))
+	for secretType, count := range f.dropped {
+		dropped[secretType] = count
+	}
+	return dropped
+}
+
+// ExpiringCertificates returns the certificates of collected Secrets that have expired or expire within
+// SecretCertificateExpiryWarning, soonest first
+func (f *SecretFilter) ExpiringCertificates() []CollectedSecret {
+	deadline := f.now().Add(SecretCertificateExpiryWarning)
+	var expiring []CollectedSecret
+	for _, secret := range f.Collected() {
+		var certificates []SecretCertificate
+		for _, certificate := range secret.Certificates {
+			if certificate.Error == "" && certificate.NotAfter.Before(deadline) {
+				certificates = append(certificates, certificate)
+			}
+		}
+		if len(certificates) > 0 {
+			secret.Certificates = certificates
+			expiring = append(expiring, secret)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].Certificates[0].NotAfter.Before(expiring[j].Certificates[0].NotAfter)
+	})
+	return expiring
+}
+
+// filterSecret replaces the values of a Secret allowed by the policy with a SecretSummary annotation, and
+// reports false for a Secret the policy leaves out
+func filterSecret(collector string, object map[string]interface{}, policy SecretPolicy) (CollectedSecret, bool) {
+	capture, ok := policy.capture(secretType(object))
+	if !ok {
+		return CollectedSecret{}, false
+	}
+
+	summary := SecretSummary{Capture: capture, Keys: make(map[string]int)}
+	data, _ := object["data"].(map[string]interface{})
+	for key, value := range data {
+		summary.Keys[key] = contentSize(value, true)
+		if capture == SecretCaptureCertificate {
+			if encoded, ok := value.(string); ok {
+				if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+					summary.Certificates = append(summary.Certificates, parseSecretCertificates(key, decoded)...)
+				}
+			}
+		}
+	}
+	stringData, _ := object["stringData"].(map[string]interface{})
+	for key, value := range stringData {
+		summary.Keys[key] = contentSize(value, false)
+	}
+	sort.Slice(summary.Certificates, func(i, j int) bool {
+		return summary.Certificates[i].Key < summary.Certificates[j].Key
+	})
+	stripSecretValues(object)
+
+	annotation, _ := json.Marshal(summary)
+	metadata, _ := object["metadata"].(map[string]interface{})
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	annotations[SecretPolicyAnnotation] = string(annotation)
+
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	return CollectedSecret{
+		Collector:     collector,
+		Namespace:     namespace,
+		Name:          name,
+		Type:          secretType(object),
+		SecretSummary: summary,
+	}, true
+}
+
+// stripSecretValues removes every field that can hold a Secret value, the last applied manifest included
+func stripSecretValues(object map[string]interface{}) {
+	delete(object, "data")
+	delete(object, "stringData")
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		object["metadata"] = metadata
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		delete(annotations, lastAppliedAnnotation)
+	}
+}
+
+// secretType returns the type of a Secret object, Opaque when unset
+func secretType(object map[string]interface{}) string {
+	if t, _ := object["type"].(string); t != "" {
+		return t
+	}
+	return "Opaque"
+}
+
+// parseSecretCertificates describes the PEM certificates in a Secret value. Other PEM blocks, private keys
+// included, are skipped without being decoded
+func parseSecretCertificates(key string, data []byte) []SecretCertificate {
+	var certificates []SecretCertificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certificates
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			certificates = append(certificates, SecretCertificate{Key: key, Error: err.Error()})
+			continue
+		}
+		certificate := SecretCertificate{
+			Key:          key,
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			DNSNames:     cert.DNSNames,
+			SerialNumber: hex.EncodeToString(cert.SerialNumber.Bytes()),
+			NotBefore:    cert.NotBefore.UTC(),
+			NotAfter:     cert.NotAfter.UTC(),
+			IsCA:         cert.IsCA,
+		}
+		for _, ip := range cert.IPAddresses {
+			certificate.IPAddresses = append(certificate.IPAddresses, ip.String())
+		}
+		certificates = append(certificates, certificate)
+	}
+}
+
+// SecretPolicyReportPath is where the Secrets kept and left out by the policy are listed in the bundle
+const SecretPolicyReportPath = "secrets/policy-report.json"
+
+// SecretPolicyReport lists the Secrets kept by the policy, with their certificates, and counts those left out
+type SecretPolicyReport struct {
+	Collected []CollectedSecret `json:"collected"`
+	Dropped   map[string]int    `json:"dropped,omitempty"`  // Secrets left out, by type
+	Expiring  []CollectedSecret `json:"expiring,omitempty"` // Collected Secrets with certificates expired or expiring soon
+}
+
+// Report returns the Secrets filtered so far, nil when the policy has not filtered any
+func (f *SecretFilter) Report() *SecretPolicyReport {
+	report := &SecretPolicyReport{
+		Collected: f.Collected(),
+		Dropped:   f.Dropped(),
+		Expiring:  f.ExpiringCertificates(),
+	}
+	if len(report.Collected) == 0 && len(report.Dropped) == 0 {
+		return nil
+	}
+	return report
+}
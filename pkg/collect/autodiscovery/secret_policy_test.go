@@ -0,0 +1,181 @@
+package autodiscovery
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTLSKeyPair returns a PEM certificate for app.example.com expiring at notAfter and its PEM private key
+func testTLSKeyPair(t *testing.T, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(4242),
+		Subject:      pkix.Name{CommonName: "app.example.com"},
+		Issuer:       pkix.Name{CommonName: "app.example.com"},
+		DNSNames:     []string{"app.example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func testSecret(name, secretType string, data map[string][]byte) map[string]interface{} {
+	encoded := map[string]interface{}{}
+	for key, value := range data {
+		encoded[key] = base64.StdEncoding.EncodeToString(value)
+	}
+	return map[string]interface{}{
+		"kind": "Secret",
+		"type": secretType,
+		"metadata": map[string]interface{}{
+			"namespace": "app",
+			"name":      name,
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"aHVudGVyMg=="}}`,
+			},
+		},
+		"data": encoded,
+	}
+}
+
+func TestSecretPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      SecretPolicy
+		expectError bool
+	}{
+		{name: "disabled"},
+		{name: "valid", policy: SecretPolicy{Types: []SecretTypePolicy{{Type: "kubernetes.io/tls", Capture: SecretCaptureCertificate}, {Type: "Opaque"}}}},
+		{name: "missing type", policy: SecretPolicy{Types: []SecretTypePolicy{{Capture: SecretCaptureKeys}}}, expectError: true},
+		{name: "duplicate type", policy: SecretPolicy{Types: []SecretTypePolicy{{Type: "Opaque"}, {Type: "Opaque"}}}, expectError: true},
+		{name: "unknown capture", policy: SecretPolicy{Types: []SecretTypePolicy{{Type: "Opaque", Capture: "full"}}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestApplySecretPolicy(t *testing.T) {
+	policy := SecretPolicy{Types: []SecretTypePolicy{{Type: "kubernetes.io/tls", Capture: SecretCaptureCertificate}}}
+	collectors := applySecretPolicy([]CollectorSpec{
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-secrets", Parameters: ClusterResourcesParams{Version: "v1", Resource: "secrets"}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-batch", Parameters: ClusterResourcesParams{Resources: []ResourceRef{{Version: "v1", Resource: "configmaps"}, {Version: "v1", Resource: "secrets"}}}.ToMap()},
+		{Type: CollectorTypeClusterResources, Name: "auto-resources-configmaps", Parameters: ClusterResourcesParams{Version: "v1", Resource: "configmaps"}.ToMap()},
+	}, policy)
+
+	for i, expected := range []bool{true, true, false} {
+		params, err := collectors[i].ClusterResourcesParams()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if (len(params.SecretPolicy) == 1) != expected {
+			t.Errorf("Expected %s to carry the policy: %v, got %+v", collectors[i].Name, expected, params.SecretPolicy)
+		}
+	}
+}
+
+func TestSecretFilter_Filter(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	certPEM, keyPEM := testTLSKeyPair(t, now.Add(10*24*time.Hour))
+	secrets := map[string]interface{}{
+		"kind": "SecretList",
+		"items": []interface{}{
+			testSecret("app-tls", "kubernetes.io/tls", map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM}),
+			testSecret("db-password", "Opaque", map[string][]byte{"password": []byte("hunter2")}),
+			testSecret("registry", "kubernetes.io/dockerconfigjson", map[string][]byte{".dockerconfigjson": []byte(`{"auths":{}}`)}),
+		},
+	}
+	data, _ := json.Marshal(secrets)
+	if err := os.WriteFile(filepath.Join(dir, "secrets.json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write secrets: %v", err)
+	}
+
+	collector := CollectorSpec{
+		Type: CollectorTypeClusterResources,
+		Name: "auto-resources-secrets",
+		Parameters: ClusterResourcesParams{Version: "v1", Resource: "secrets", SecretPolicy: []SecretTypePolicy{
+			{Type: "kubernetes.io/tls", Capture: SecretCaptureCertificate},
+			{Type: "Opaque"},
+		}}.ToMap(),
+	}
+	filter := NewSecretFilter()
+	filter.now = func() time.Time { return now }
+	kept, dropped, err := filter.Filter(collector, dir, []string{"secrets.json"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if kept != 2 || dropped != 1 {
+		t.Errorf("Expected 2 secrets kept and 1 left out, got %d and %d", kept, dropped)
+	}
+
+	written, _ := os.ReadFile(filepath.Join(dir, "secrets.json"))
+	for _, value := range []string{"PRIVATE KEY", base64.StdEncoding.EncodeToString(keyPEM)[:40], base64.StdEncoding.EncodeToString([]byte("hunter2")), "aHVudGVyMg==", "dockerconfigjson"} {
+		if strings.Contains(string(written), value) {
+			t.Errorf("Expected %q not to be written, got:\n%s", value, written)
+		}
+	}
+
+	collected := filter.Collected()
+	if len(collected) != 2 || collected[0].Name != "app-tls" || collected[1].Capture != SecretCaptureKeys || collected[1].Keys["password"] != 7 {
+		t.Fatalf("Expected app-tls and db-password with their key sizes, got %+v", collected)
+	}
+	certificates := collected[0].Certificates
+	if len(certificates) != 1 || certificates[0].Key != "tls.crt" || certificates[0].Subject != "CN=app.example.com" {
+		t.Fatalf("Expected the tls.crt certificate only, got %+v", certificates)
+	}
+	if strings.Join(certificates[0].DNSNames, ",") != "app.example.com,www.example.com" || certificates[0].IPAddresses[0] != "10.0.0.1" {
+		t.Errorf("Expected the SANs, got %+v", certificates[0])
+	}
+	if filter.Dropped()["kubernetes.io/dockerconfigjson"] != 1 {
+		t.Errorf("Expected the dockerconfigjson secret to be counted as left out, got %v", filter.Dropped())
+	}
+
+	report := filter.Report()
+	if report == nil || len(report.Expiring) != 1 || report.Expiring[0].Name != "app-tls" {
+		t.Errorf("Expected the certificate expiring in 10 days to be reported, got %+v", report)
+	}
+}
+
+func TestSecretFilter_NoPolicy(t *testing.T) {
+	filter := NewSecretFilter()
+	collector := CollectorSpec{Type: CollectorTypeClusterResources, Name: "auto-resources-secrets", Parameters: ClusterResourcesParams{Version: "v1", Resource: "secrets"}.ToMap()}
+	if kept, dropped, err := filter.Filter(collector, t.TempDir(), []string{"missing.json"}); kept != 0 || dropped != 0 || err != nil {
+		t.Errorf("Expected collectors without a policy to be left untouched, got %d, %d, %v", kept, dropped, err)
+	}
+	if filter.Report() != nil {
+		t.Errorf("Expected no report without filtered secrets")
+	}
+}
@@ -0,0 +1,149 @@
+package autodiscovery
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// serviceHostPattern matches a whole in-cluster service host, e.g. db.data.svc or db.data.svc.cluster.local
+var serviceHostPattern = regexp.MustCompile(`^([a-z0-9](?:[-a-z0-9]*[a-z0-9])?)\.([a-z0-9](?:[-a-z0-9]*[a-z0-9])?)\.svc(?:\.[a-z0-9.-]+)?$`)
+
+// serviceFQDNPattern finds service hosts inside free text such as connection strings in ConfigMaps
+var serviceFQDNPattern = regexp.MustCompile(`\b([a-z0-9](?:[-a-z0-9]*[a-z0-9])?)\.([a-z0-9](?:[-a-z0-9]*[a-z0-9])?)\.svc\b`)
+
+// ParseServiceHost returns the namespace and name of the service an in-cluster host name resolves to
+// Hosts outside the cluster, e.g. api.example.com, and short names without a namespace are not matched
+func ParseServiceHost(host string) (string, string, bool) {
+	match := serviceHostPattern.FindStringSubmatch(strings.TrimSuffix(strings.ToLower(host), "."))
+	if match == nil {
+		return "", "", false
+	}
+	return match[2], match[1], true
+}
+
+// FindServiceReferences returns the namespace/name of every service referenced by FQDN in text, sorted and deduplicated
+func FindServiceReferences(text string) []string {
+	seen := make(map[string]bool)
+	var references []string
+	for _, match := range serviceFQDNPattern.FindAllStringSubmatch(strings.ToLower(text), -1) {
+		reference := match[2] + "/" + match[1]
+		if !seen[reference] {
+			seen[reference] = true
+			references = append(references, reference)
+		}
+	}
+	sort.Strings(references)
+	return references
+}
+
+// resolveExternalNameService follows an ExternalName service to the in-cluster service it aliases, often in another namespace
+func (dr *DependencyResolver) resolveExternalNameService(ctx context.Context, service *unstructured.Unstructured) []Resource {
+	externalName, found, err := unstructured.NestedString(service.Object, "spec", "externalName")
+	if err != nil || !found {
+		return nil
+	}
+	namespace, name, ok := ParseServiceHost(externalName)
+	if !ok {
+		return nil // Points outside the cluster
+	}
+	if target, ok := dr.existingService(ctx, namespace, name); ok {
+		return []Resource{target}
+	}
+	return nil
+}
+
+// resolveEndpointTargets returns the pods an Endpoints object points at in other namespaces
+// Selectorless services, e.g. ones fronting a shared database, are backed by manually managed endpoints whose
+// targetRefs may live anywhere, pods in the service's own namespace are already found through its selector
+func (dr *DependencyResolver) resolveEndpointTargets(endpoints *unstructured.Unstructured) []Resource {
+	subsets, found, err := unstructured.NestedSlice(endpoints.Object, "subsets")
+	if err != nil || !found {
+		return nil
+	}
+
+	podGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	seen := make(map[string]bool)
+	var targets []Resource
+	for _, s := range subsets {
+		subset, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"addresses", "notReadyAddresses"} {
+			addresses, _, _ := unstructured.NestedSlice(subset, field)
+			for _, a := range addresses {
+				address, ok := a.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				ref, found, err := unstructured.NestedStringMap(address, "targetRef")
+				if err != nil || !found || ref["kind"] != "Pod" || ref["name"] == "" {
+					continue
+				}
+				if ref["namespace"] == "" || ref["namespace"] == endpoints.GetNamespace() {
+					continue
+				}
+				key := ref["namespace"] + "/" + ref["name"]
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				targets = append(targets, Resource{GVR: podGVR, Namespace: ref["namespace"], Name: ref["name"]})
+			}
+		}
+	}
+	return targets
+}
+
+// resolveConfigMapDependencies finds services referenced by FQDN in a ConfigMap's data, e.g. a connection string
+// pointing at db.data.svc.cluster.local, only services that exist are returned
+func (dr *DependencyResolver) resolveConfigMapDependencies(ctx context.Context, resource Resource) ([]Resource, error) {
+	var dependencies []Resource
+
+	configMap, err := dr.dynamicClient.Resource(resource.GVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+	if err != nil {
+		return dependencies, nil
+	}
+	data, found, err := unstructured.NestedStringMap(configMap.Object, "data")
+	if err != nil || !found {
+		return dependencies, nil
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var text strings.Builder
+	for _, key := range keys {
+		text.WriteString(data[key])
+		text.WriteString("\n")
+	}
+
+	for _, reference := range FindServiceReferences(text.String()) {
+		namespace, name, _ := strings.Cut(reference, "/")
+		if service, ok := dr.existingService(ctx, namespace, name); ok {
+			dependencies = append(dependencies, service)
+		}
+	}
+	return dependencies, nil
+}
+
+// existingService returns the service namespace/name when it exists, text references and external names are not trusted
+// Services in protected namespaces are returned unchecked so they are counted, but never read
+func (dr *DependencyResolver) existingService(ctx context.Context, namespace, name string) (Resource, bool) {
+	serviceGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+	if IsProtectedNamespace(dr.protected, namespace) {
+		return Resource{GVR: serviceGVR, Namespace: namespace, Name: name}, true
+	}
+	if _, err := dr.dynamicClient.Resource(serviceGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		return Resource{}, false
+	}
+	return Resource{GVR: serviceGVR, Namespace: namespace, Name: name}, true
+}
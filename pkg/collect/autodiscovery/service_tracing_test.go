@@ -0,0 +1,166 @@
+package autodiscovery
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseServiceHost(t *testing.T) {
+	tests := []struct {
+		name              string
+		host              string
+		expectedNamespace string
+		expectedName      string
+		expectMatch       bool
+	}{
+		{name: "svc suffix", host: "db.data.svc", expectedNamespace: "data", expectedName: "db", expectMatch: true},
+		{name: "cluster domain", host: "db.data.svc.cluster.local", expectedNamespace: "data", expectedName: "db", expectMatch: true},
+		{name: "trailing dot", host: "DB.Data.svc.cluster.local.", expectedNamespace: "data", expectedName: "db", expectMatch: true},
+		{name: "external host", host: "api.example.com"},
+		{name: "short name", host: "db"},
+		{name: "namespace only", host: "db.data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, name, ok := ParseServiceHost(tt.host)
+			if ok != tt.expectMatch {
+				t.Fatalf("Expected match %v, got %v", tt.expectMatch, ok)
+			}
+			if namespace != tt.expectedNamespace || name != tt.expectedName {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.expectedNamespace, tt.expectedName, namespace, name)
+			}
+		})
+	}
+}
+
+func TestFindServiceReferences(t *testing.T) {
+	text := `DATABASE_URL=postgres://app@db.data.svc.cluster.local:5432/app
+CACHE=redis-0.redis.infra.svc:6379
+QUEUE: amqp://mq.messaging.svc
+REPLICA=postgres://db.data.svc:5433/app
+WEB=http://web:8080`
+
+	references := FindServiceReferences(text)
+	expected := []string{"data/db", "infra/redis", "messaging/mq"}
+	if len(references) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, references)
+	}
+	for i := range expected {
+		if references[i] != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], references[i])
+		}
+	}
+}
+
+func TestDependencyResolver_CrossNamespace(t *testing.T) {
+	newResolver := func() *DependencyResolver {
+		client := createTestDynamicClient(
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db.data.svc.cluster.local"},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "app"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "payments.example.com"},
+			},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "app"}},
+			&corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "app"},
+				Subsets: []corev1.EndpointSubset{{
+					Addresses: []corev1.EndpointAddress{
+						{IP: "10.0.0.5", TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "infra", Name: "redis-0"}},
+						{IP: "10.0.0.6", TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "app", Name: "local-0"}},
+					},
+				}},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "app"},
+				Data: map[string]string{
+					"DATABASE_URL": "postgres://db.data.svc:5432/app",
+					"QUEUE_URL":    "amqp://mq.messaging.svc.cluster.local",
+					"LEGACY_URL":   "http://gone.nowhere.svc",
+				},
+			},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "data"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "mq", Namespace: "messaging"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redis-0", Namespace: "infra"}},
+		)
+		return NewDependencyResolver(client, 1)
+	}
+
+	serviceGVR := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	configMapGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	discovered := []Resource{
+		{GVR: serviceGVR, Namespace: "app", Name: "db"},
+		{GVR: serviceGVR, Namespace: "app", Name: "payments"},
+		{GVR: serviceGVR, Namespace: "app", Name: "cache"},
+		{GVR: configMapGVR, Namespace: "app", Name: "app-config"},
+	}
+
+	tests := []struct {
+		name               string
+		excluded           []string
+		protected          []string
+		expected           []string
+		expectedCrossCount int
+		expectedProtected  int
+	}{
+		{
+			name:               "all namespaces",
+			expected:           []string{"endpoints/app/cache", "pods/infra/redis-0", "services/data/db", "services/messaging/mq"},
+			expectedCrossCount: 3,
+		},
+		{
+			name:               "excluded namespace",
+			excluded:           []string{"messaging"},
+			expected:           []string{"endpoints/app/cache", "pods/infra/redis-0", "services/data/db"},
+			expectedCrossCount: 2,
+		},
+		{
+			name:               "protected namespaces",
+			protected:          []string{"data", "mess*"},
+			expected:           []string{"endpoints/app/cache", "pods/infra/redis-0"},
+			expectedCrossCount: 1,
+			expectedProtected:  3, // db through the ExternalName service and the ConfigMap, and mq
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newResolver()
+			resolver.SetExcludedNamespaces(tt.excluded)
+			resolver.SetProtectedNamespaces(tt.protected)
+
+			result, err := resolver.ResolveDependencies(context.Background(), discovered)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var added []string
+			for _, resource := range result[len(discovered):] {
+				added = append(added, dependencyLabel(resource))
+			}
+			sort.Strings(added)
+			if len(added) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, added)
+			}
+			for i := range added {
+				if added[i] != tt.expected[i] {
+					t.Errorf("Expected %s, got %s", tt.expected[i], added[i])
+				}
+			}
+			if report := resolver.Report(); report.CrossNamespace != tt.expectedCrossCount {
+				t.Errorf("Expected %d cross-namespace resources, got %d", tt.expectedCrossCount, report.CrossNamespace)
+			}
+			if report := resolver.Report(); report.Protected != tt.expectedProtected {
+				t.Errorf("Expected %d protected dependencies, got %d", tt.expectedProtected, report.Protected)
+			}
+		})
+	}
+}
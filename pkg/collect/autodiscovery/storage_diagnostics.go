@@ -0,0 +1,276 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// CollectorGroupStorage groups the collectors generated for storage diagnostics
+const CollectorGroupStorage = "storage"
+
+// DefaultStoragePriority is the priority of the storage collector group when none is configured
+const DefaultStoragePriority = PriorityHigh
+
+var (
+	persistentVolumesGVR      = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}
+	persistentVolumeClaimsGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}
+	storageClassesGVR         = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+	csiDriversGVR             = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csidrivers"}
+	csiNodesGVR               = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csinodes"}
+	volumeAttachmentsGVR      = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "volumeattachments"}
+)
+
+// csiRegistrarContainers are the sidecar names that identify a CSI node plugin pod
+var csiRegistrarContainers = map[string]bool{
+	"node-driver-registrar":     true,
+	"csi-node-driver-registrar": true,
+	"driver-registrar":          true,
+}
+
+// podTemplateLabels are added per pod by controllers and are dropped when building selectors
+var podTemplateLabels = map[string]bool{
+	"controller-revision-hash": true,
+	"pod-template-generation":  true,
+	"pod-template-hash":        true,
+}
+
+// PVCBindingStatus describes whether a claim is bound to a volume
+type PVCBindingStatus struct {
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	Phase        string `json:"phase"`
+	VolumeName   string `json:"volumeName,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Bound        bool   `json:"bound"`
+	Problem      string `json:"problem,omitempty"`
+}
+
+// StorageBindingReport summarizes PVC and PV binding for the storage collector group
+type StorageBindingReport struct {
+	Claims          []PVCBindingStatus `json:"claims"`
+	UnboundClaims   int                `json:"unboundClaims"`
+	ReleasedVolumes []string           `json:"releasedVolumes,omitempty"`
+	FailedVolumes   []string           `json:"failedVolumes,omitempty"`
+}
+
+// csiNodePlugin is a group of CSI node plugin pods sharing a selector
+type csiNodePlugin struct {
+	namespace string
+	name      string
+	selector  map[string]string
+}
+
+// StorageDiagnostics generates storage-focused collectors when PVCs or StorageClasses are discovered
+type StorageDiagnostics struct {
+	dynamicClient dynamic.Interface
+}
+
+// NewStorageDiagnostics creates a new StorageDiagnostics
+func NewStorageDiagnostics(dynamicClient dynamic.Interface) *StorageDiagnostics {
+	return &StorageDiagnostics{
+		dynamicClient: dynamicClient,
+	}
+}
+
+// HasStorageResources reports whether PVCs or StorageClasses were discovered
+func HasStorageResources(resources []Resource) bool {
+	for _, resource := range resources {
+		if resource.GVR == persistentVolumeClaimsGVR || resource.GVR == storageClassesGVR {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateStorageCollectors returns the storage collector group: PV/PVC binding status,
+// StorageClass, CSI driver, CSI node and VolumeAttachment objects, and CSI node plugin logs
+// Nothing is generated unless PVCs or StorageClasses are among the discovered resources
+func (s *StorageDiagnostics) GenerateStorageCollectors(ctx context.Context, resources []Resource, opts DiscoveryOptions) []CollectorSpec {
+	if !HasStorageResources(resources) {
+		return nil
+	}
+
+	priority := opts.StoragePriority
+	if priority <= 0 {
+		priority = int(DefaultStoragePriority)
+	}
+
+	var collectors []CollectorSpec
+	for _, gvr := range []schema.GroupVersionResource{
+		persistentVolumesGVR,
+		storageClassesGVR,
+		csiDriversGVR,
+		csiNodesGVR,
+		volumeAttachmentsGVR,
+	} {
+		collectors = append(collectors, CollectorSpec{
+			Type:     CollectorTypeClusterResources,
+			Name:     fmt.Sprintf("auto-storage-%s", gvr.Resource),
+			Group:    CollectorGroupStorage,
+			Priority: priority,
+			Parameters: ClusterResourcesParams{
+				Group:    gvr.Group,
+				Version:  gvr.Version,
+				Resource: gvr.Resource,
+			}.ToMap(),
+		})
+	}
+
+	report := s.buildBindingReport(ctx, resources)
+	if data, err := json.MarshalIndent(report, "", "  "); err == nil {
+		collectors = append(collectors, CollectorSpec{
+			Type:     "data",
+			Name:     "auto-storage-binding-status",
+			Group:    CollectorGroupStorage,
+			Priority: priority,
+			Parameters: map[string]interface{}{
+				"name": "storage/binding-status.json",
+				"data": string(data),
+			},
+		})
+	}
+
+	for _, plugin := range s.findCSINodePlugins(ctx) {
+		collectors = append(collectors, CollectorSpec{
+			Type:      CollectorTypeLogs,
+			Name:      fmt.Sprintf("auto-storage-csi-logs-%s-%s", plugin.namespace, plugin.name),
+			Namespace: plugin.namespace,
+			Group:     CollectorGroupStorage,
+			Priority:  priority,
+			Parameters: LogsParams{
+				Namespace: plugin.namespace,
+				Selector:  selectorStrings(plugin.selector),
+				Limits:    &LogsLimits{MaxAge: "24h", MaxLines: 1000},
+			}.ToMap(),
+		})
+	}
+
+	return collectors
+}
+
+// buildBindingReport reads the phase of every discovered PVC and of all PVs
+// Objects that cannot be read are reported with a problem instead of failing discovery
+func (s *StorageDiagnostics) buildBindingReport(ctx context.Context, resources []Resource) StorageBindingReport {
+	report := StorageBindingReport{Claims: []PVCBindingStatus{}}
+
+	for _, resource := range resources {
+		if resource.GVR != persistentVolumeClaimsGVR {
+			continue
+		}
+
+		status := PVCBindingStatus{Namespace: resource.Namespace, Name: resource.Name}
+		pvc, err := s.dynamicClient.Resource(persistentVolumeClaimsGVR).Namespace(resource.Namespace).Get(ctx, resource.Name, metav1.GetOptions{})
+		if err != nil {
+			status.Problem = fmt.Sprintf("failed to get claim: %v", err)
+		} else {
+			status.Phase, _, _ = unstructured.NestedString(pvc.Object, "status", "phase")
+			status.VolumeName, _, _ = unstructured.NestedString(pvc.Object, "spec", "volumeName")
+			status.StorageClass, _, _ = unstructured.NestedString(pvc.Object, "spec", "storageClassName")
+			status.Bound = status.Phase == "Bound"
+		}
+
+		if !status.Bound {
+			report.UnboundClaims++
+		}
+		report.Claims = append(report.Claims, status)
+	}
+
+	sort.Slice(report.Claims, func(i, j int) bool {
+		if report.Claims[i].Namespace != report.Claims[j].Namespace {
+			return report.Claims[i].Namespace < report.Claims[j].Namespace
+		}
+		return report.Claims[i].Name < report.Claims[j].Name
+	})
+
+	pvs, err := s.dynamicClient.Resource(persistentVolumesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list persistent volumes: %v\n", err)
+		return report
+	}
+	for _, pv := range pvs.Items {
+		phase, _, _ := unstructured.NestedString(pv.Object, "status", "phase")
+		switch phase {
+		case "Released":
+			report.ReleasedVolumes = append(report.ReleasedVolumes, pv.GetName())
+		case "Failed":
+			report.FailedVolumes = append(report.FailedVolumes, pv.GetName())
+		}
+	}
+	sort.Strings(report.ReleasedVolumes)
+	sort.Strings(report.FailedVolumes)
+
+	return report
+}
+
+// findCSINodePlugins lists pods in all namespaces and returns those running a node driver registrar
+// CSI node plugins usually run in system namespaces, so this is not limited to the discovery scope
+func (s *StorageDiagnostics) findCSINodePlugins(ctx context.Context) []csiNodePlugin {
+	pods, err := s.dynamicClient.Resource(podsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list pods for CSI node plugins: %v\n", err)
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var plugins []csiNodePlugin
+	for _, pod := range pods.Items {
+		if !isCSINodePluginPod(pod) {
+			continue
+		}
+
+		selector := make(map[string]string)
+		for key, value := range pod.GetLabels() {
+			if !podTemplateLabels[key] {
+				selector[key] = value
+			}
+		}
+		if len(selector) == 0 {
+			continue
+		}
+
+		name := pod.GetName()
+		for _, owner := range pod.GetOwnerReferences() {
+			if owner.Kind == "DaemonSet" {
+				name = owner.Name
+				break
+			}
+		}
+
+		key := pod.GetNamespace() + "/" + selectorStrings(selector)[0]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		plugins = append(plugins, csiNodePlugin{namespace: pod.GetNamespace(), name: name, selector: selector})
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		if plugins[i].namespace != plugins[j].namespace {
+			return plugins[i].namespace < plugins[j].namespace
+		}
+		return plugins[i].name < plugins[j].name
+	})
+	return plugins
+}
+
+// isCSINodePluginPod reports whether the pod runs a CSI node driver registrar sidecar
+func isCSINodePluginPod(pod unstructured.Unstructured) bool {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := container["name"].(string); csiRegistrarContainers[name] {
+			return true
+		}
+	}
+	return false
+}
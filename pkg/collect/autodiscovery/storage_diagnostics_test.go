@@ -0,0 +1,146 @@
+package autodiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStorageDiagnostics_GenerateStorageCollectors(t *testing.T) {
+	client := createTestDynamicClient(
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "app"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "uploads", Namespace: "app"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-old"},
+			Status:     corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "ebs-csi-node-abcde",
+				Namespace:       "kube-system",
+				Labels:          map[string]string{"app": "ebs-csi-node", "controller-revision-hash": "123"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ebs-csi-node"}},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "ebs-plugin", Image: "ebs-csi-driver"},
+				{Name: "node-driver-registrar", Image: "csi-node-driver-registrar"},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "ebs-csi-node-fghij",
+				Namespace:       "kube-system",
+				Labels:          map[string]string{"app": "ebs-csi-node", "controller-revision-hash": "456"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ebs-csi-node"}},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "node-driver-registrar", Image: "csi-node-driver-registrar"},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app", Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx"}}},
+		},
+	)
+
+	resources := []Resource{
+		{GVR: persistentVolumeClaimsGVR, Namespace: "app", Name: "data-db-0"},
+		{GVR: persistentVolumeClaimsGVR, Namespace: "app", Name: "uploads"},
+	}
+
+	diagnostics := NewStorageDiagnostics(client)
+	collectors := diagnostics.GenerateStorageCollectors(context.Background(), resources, DiscoveryOptions{})
+
+	byName := make(map[string]CollectorSpec)
+	for _, collector := range collectors {
+		if collector.Group != CollectorGroupStorage {
+			t.Errorf("Expected collector %s in the storage group, got %q", collector.Name, collector.Group)
+		}
+		if collector.Priority != int(DefaultStoragePriority) {
+			t.Errorf("Expected default storage priority for %s, got %d", collector.Name, collector.Priority)
+		}
+		byName[collector.Name] = collector
+	}
+
+	for _, expected := range []string{
+		"auto-storage-persistentvolumes",
+		"auto-storage-storageclasses",
+		"auto-storage-csidrivers",
+		"auto-storage-csinodes",
+		"auto-storage-volumeattachments",
+		"auto-storage-binding-status",
+		"auto-storage-csi-logs-kube-system-ebs-csi-node",
+	} {
+		if _, ok := byName[expected]; !ok {
+			t.Errorf("Expected collector %s, got %v", expected, collectors)
+		}
+	}
+
+	// Both CSI pods share a selector once per-pod labels are dropped
+	csiLogs := 0
+	for _, collector := range collectors {
+		if collector.Type == CollectorTypeLogs {
+			csiLogs++
+			params, err := collector.LogsParams()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(params.Selector) != 1 || params.Selector[0] != "app=ebs-csi-node" {
+				t.Errorf("Expected selector app=ebs-csi-node, got %v", params.Selector)
+			}
+		}
+	}
+	if csiLogs != 1 {
+		t.Errorf("Expected 1 CSI log collector, got %d", csiLogs)
+	}
+
+	var report StorageBindingReport
+	if err := json.Unmarshal([]byte(byName["auto-storage-binding-status"].Parameters["data"].(string)), &report); err != nil {
+		t.Fatalf("Failed to parse binding report: %v", err)
+	}
+	if len(report.Claims) != 2 || report.UnboundClaims != 1 {
+		t.Errorf("Expected 2 claims with 1 unbound, got %+v", report)
+	}
+	if len(report.ReleasedVolumes) != 1 || report.ReleasedVolumes[0] != "pv-old" {
+		t.Errorf("Expected released volume pv-old, got %v", report.ReleasedVolumes)
+	}
+}
+
+func TestStorageDiagnostics_NoStorageResources(t *testing.T) {
+	diagnostics := NewStorageDiagnostics(createTestDynamicClient())
+	collectors := diagnostics.GenerateStorageCollectors(context.Background(), []Resource{
+		{GVR: podsGVR, Namespace: "app", Name: "web"},
+		{GVR: appsv1.SchemeGroupVersion.WithResource("deployments"), Namespace: "app", Name: "web"},
+	}, DiscoveryOptions{})
+
+	if len(collectors) != 0 {
+		t.Errorf("Expected no storage collectors without PVCs or StorageClasses, got %d", len(collectors))
+	}
+}
+
+func TestStorageDiagnostics_Priority(t *testing.T) {
+	diagnostics := NewStorageDiagnostics(createTestDynamicClient())
+	collectors := diagnostics.GenerateStorageCollectors(context.Background(), []Resource{
+		{GVR: storageClassesGVR, Name: "gp3"},
+	}, DiscoveryOptions{StoragePriority: int(PriorityCritical)})
+
+	if len(collectors) == 0 {
+		t.Fatalf("Expected storage collectors for a discovered StorageClass")
+	}
+	for _, collector := range collectors {
+		if collector.Priority != int(PriorityCritical) {
+			t.Errorf("Expected configured priority for %s, got %d", collector.Name, collector.Priority)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package autodiscovery
 
 import (
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 )
@@ -24,6 +26,12 @@ func createTestScheme() *runtime.Scheme {
 	
 	// Register networking types
 	networkingv1.AddToScheme(scheme)
+
+	// Register admission webhook types
+	admissionregistrationv1.AddToScheme(scheme)
+
+	// Register PriorityClasses
+	schedulingv1.AddToScheme(scheme)
 	
 	return scheme
 }
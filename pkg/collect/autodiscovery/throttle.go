@@ -0,0 +1,224 @@
+package autodiscovery
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+
+const (
+	// clientThrottleThreshold is how long a rate limiter wait must take to count as client-side throttling
+	clientThrottleThreshold = 50 * time.Millisecond
+
+	// throttleRecoveryRequests is the number of unthrottled requests before the rate grows by one request per second
+	throttleRecoveryRequests = 20
+
+	// minClientQPS is the lowest request rate a backoff goes down to
+	minClientQPS = 1
+
+	// defaultClientQPS and defaultClientBurst match the client-go defaults used when the config sets none
+	defaultClientQPS   = 5
+	defaultClientBurst = 10
+)
+
+// ThrottleStats reports API throttling seen during discovery and the resulting request rate
+type ThrottleStats struct {
+	Throttled       bool          `json:"throttled"`
+	Requests        int           `json:"requests"`
+	ServerThrottled int           `json:"serverThrottled"` // 429 responses, e.g. from API priority and fairness
+	ClientThrottled int           `json:"clientThrottled"` // Requests delayed by the client-side rate limiter
+	ClientWait      time.Duration `json:"clientWait"`
+	MaxQPS          float64       `json:"maxQPS"`        // Configured client rate limit
+	MinQPS          float64       `json:"minQPS"`        // Lowest rate limit reached
+	QPS             float64       `json:"qps"`           // Current rate limit
+	EffectiveRate   float64       `json:"effectiveRate"` // Requests per second
+}
+
+// AdaptiveThrottle is the client-side rate limiter of the discovery clients. It halves the allowed
+// request rate when the API server throttles with 429 and grows it again after a run of
+// unthrottled requests. Discovery requests run one at a time, so the rate, not concurrency, is
+// what reaches the server
+type AdaptiveThrottle struct {
+	mu        sync.Mutex
+	limiter   *rate.Limiter
+	qps       float64
+	maxQPS    float64
+	minQPS    float64
+	maxBurst  int
+	successes int
+
+	requests        int
+	serverThrottled int
+	clientThrottled int
+	clientWait      time.Duration
+	firstRequest    time.Time
+	lastRequest     time.Time
+}
+
+// NewAdaptiveThrottle creates an AdaptiveThrottle allowing qps requests per second with the given burst
+// Zero values fall back to the client-go defaults
+func NewAdaptiveThrottle(qps float32, burst int) *AdaptiveThrottle {
+	if qps <= 0 {
+		qps = defaultClientQPS
+	}
+	if burst <= 0 {
+		burst = defaultClientBurst
+	}
+	return &AdaptiveThrottle{
+		limiter:  rate.NewLimiter(rate.Limit(qps), burst),
+		qps:      float64(qps),
+		maxQPS:   float64(qps),
+		minQPS:   float64(qps),
+		maxBurst: burst,
+	}
+}
+
+// Install wraps the config's transport and replaces its rate limiter so every client built from it is throttled
+func (t *AdaptiveThrottle) Install(config *rest.Config) {
+	config.Wrap(t.WrapTransport)
+	config.RateLimiter = &throttledRateLimiter{throttle: t}
+}
+
+// WrapTransport returns a RoundTripper that counts requests and records 429s
+func (t *AdaptiveThrottle) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return &throttledTransport{next: next, throttle: t}
+}
+
+// Stats returns the throttling seen so far
+func (t *AdaptiveThrottle) Stats() ThrottleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := ThrottleStats{
+		Throttled:       t.serverThrottled > 0 || t.clientThrottled > 0,
+		Requests:        t.requests,
+		ServerThrottled: t.serverThrottled,
+		ClientThrottled: t.clientThrottled,
+		ClientWait:      t.clientWait,
+		MaxQPS:          t.maxQPS,
+		MinQPS:          t.minQPS,
+		QPS:             t.qps,
+	}
+	if elapsed := t.lastRequest.Sub(t.firstRequest); elapsed > 0 {
+		stats.EffectiveRate = float64(t.requests) / elapsed.Seconds()
+	}
+	return stats
+}
+
+// recordResponse counts a finished request and backs off when the server throttled it
+func (t *AdaptiveThrottle) recordResponse(statusCode int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.requests == 0 {
+		t.firstRequest = now
+	}
+	t.requests++
+	t.lastRequest = now
+
+	if statusCode == http.StatusTooManyRequests {
+		t.serverThrottled++
+		t.backOff()
+		return
+	}
+
+	t.successes++
+	if t.successes >= throttleRecoveryRequests && t.qps < t.maxQPS {
+		t.setRate(math.Min(t.qps+1, t.maxQPS))
+		t.successes = 0
+	}
+}
+
+// recordClientWait counts a rate limiter wait long enough to be throttling
+// The wait comes from the adaptive limit itself, so it does not lower the rate any further
+func (t *AdaptiveThrottle) recordClientWait(wait time.Duration) {
+	if wait < clientThrottleThreshold {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clientThrottled++
+	t.clientWait += wait
+}
+
+// backOff halves the request rate; the caller holds t.mu
+func (t *AdaptiveThrottle) backOff() {
+	t.successes = 0
+	if t.qps <= minClientQPS {
+		return
+	}
+	t.setRate(math.Max(t.qps/2, minClientQPS))
+	if t.qps < t.minQPS {
+		t.minQPS = t.qps
+	}
+	fmt.Printf("Warning: API server returned 429 Too Many Requests, reducing the API request rate to %.1f/s\n", t.qps)
+}
+
+// setRate applies a new request rate, scaling the burst with it; the caller holds t.mu
+func (t *AdaptiveThrottle) setRate(qps float64) {
+	t.qps = qps
+	burst := int(float64(t.maxBurst) * qps / t.maxQPS)
+	if burst < 1 {
+		burst = 1
+	}
+	t.limiter.SetLimit(rate.Limit(qps))
+	t.limiter.SetBurst(burst)
+}
+
+// throttledTransport applies the AdaptiveThrottle to every request
+type throttledTransport struct {
+	next     http.RoundTripper
+	throttle *AdaptiveThrottle
+}
+
+func (tt *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := tt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// client-go retries 429s itself, honoring Retry-After
+	tt.throttle.recordResponse(resp.StatusCode)
+	return resp, nil
+}
+
+// throttledRateLimiter is the flowcontrol.RateLimiter of the clients, backed by the adaptive limiter
+// It measures how long requests wait on it
+type throttledRateLimiter struct {
+	throttle *AdaptiveThrottle
+}
+
+var _ flowcontrol.RateLimiter = &throttledRateLimiter{}
+
+func (r *throttledRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := r.throttle.limiter.Wait(ctx)
+	r.throttle.recordClientWait(time.Since(start))
+	return err
+}
+
+func (r *throttledRateLimiter) Accept() {
+	_ = r.Wait(context.Background())
+}
+
+func (r *throttledRateLimiter) TryAccept() bool {
+	return r.throttle.limiter.Allow()
+}
+
+func (r *throttledRateLimiter) QPS() float32 {
+	r.throttle.mu.Lock()
+	defer r.throttle.mu.Unlock()
+	return float32(r.throttle.qps)
+}
+
+func (r *throttledRateLimiter) Stop() {}
@@ -0,0 +1,168 @@
+package autodiscovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestAdaptiveThrottle_BacksOffOn429(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	throttle := NewAdaptiveThrottle(8, 16)
+	client := &http.Client{Transport: throttle.WrapTransport(http.DefaultTransport)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	stats := throttle.Stats()
+	if !stats.Throttled {
+		t.Errorf("Expected throttled stats")
+	}
+	if stats.Requests != 3 || stats.ServerThrottled != 2 {
+		t.Errorf("Expected 3 requests with 2 throttled, got %d with %d", stats.Requests, stats.ServerThrottled)
+	}
+	if stats.QPS != 2 || stats.MinQPS != 2 || stats.MaxQPS != 8 {
+		t.Errorf("Expected rate halved twice from 8 to 2, got %v (min %v, max %v)", stats.QPS, stats.MinQPS, stats.MaxQPS)
+	}
+	if limit := throttle.limiter.Limit(); limit != 2 {
+		t.Errorf("Expected the rate limiter to allow 2/s, got %v", limit)
+	}
+	if burst := throttle.limiter.Burst(); burst != 4 {
+		t.Errorf("Expected the burst to scale down to 4, got %d", burst)
+	}
+}
+
+func TestAdaptiveThrottle_Recovers(t *testing.T) {
+	throttle := NewAdaptiveThrottle(4, 4)
+	throttle.recordResponse(http.StatusTooManyRequests)
+	if throttle.Stats().QPS != 2 {
+		t.Fatalf("Expected rate 2 after a 429, got %v", throttle.Stats().QPS)
+	}
+
+	for i := 0; i < throttleRecoveryRequests; i++ {
+		throttle.recordResponse(http.StatusOK)
+	}
+	if qps := throttle.Stats().QPS; qps != 3 {
+		t.Errorf("Expected rate 3 after %d successful requests, got %v", throttleRecoveryRequests, qps)
+	}
+
+	for i := 0; i < throttleRecoveryRequests*5; i++ {
+		throttle.recordResponse(http.StatusOK)
+	}
+	stats := throttle.Stats()
+	if stats.QPS != 4 || throttle.limiter.Burst() != 4 {
+		t.Errorf("Expected rate to stop at the maximum 4 with the full burst, got %v (burst %d)", stats.QPS, throttle.limiter.Burst())
+	}
+	if stats.MinQPS != 2 {
+		t.Errorf("Expected minimum rate 2 to be kept, got %v", stats.MinQPS)
+	}
+}
+
+func TestAdaptiveThrottle_MinimumRate(t *testing.T) {
+	throttle := NewAdaptiveThrottle(3, 6)
+	for i := 0; i < 5; i++ {
+		throttle.recordResponse(http.StatusTooManyRequests)
+	}
+
+	stats := throttle.Stats()
+	if stats.QPS != minClientQPS || stats.MinQPS != minClientQPS {
+		t.Errorf("Expected rate to stop at %d, got %v (min %v)", minClientQPS, stats.QPS, stats.MinQPS)
+	}
+	if burst := throttle.limiter.Burst(); burst != 2 {
+		t.Errorf("Expected burst 2 at the minimum rate, got %d", burst)
+	}
+}
+
+func TestAdaptiveThrottle_ClientWait(t *testing.T) {
+	throttle := NewAdaptiveThrottle(4, 4)
+
+	throttle.recordClientWait(time.Millisecond)
+	if throttle.Stats().Throttled {
+		t.Errorf("Expected short rate limiter waits to be ignored")
+	}
+
+	throttle.recordClientWait(200 * time.Millisecond)
+	stats := throttle.Stats()
+	if !stats.Throttled || stats.ClientThrottled != 1 {
+		t.Errorf("Expected 1 client-side throttle, got %d", stats.ClientThrottled)
+	}
+	if stats.ClientWait != 200*time.Millisecond {
+		t.Errorf("Expected client wait 200ms, got %v", stats.ClientWait)
+	}
+	if stats.QPS != 4 {
+		t.Errorf("Expected waits on the adaptive limit itself to keep the rate at 4, got %v", stats.QPS)
+	}
+}
+
+func TestAdaptiveThrottle_LimitsRate(t *testing.T) {
+	throttle := NewAdaptiveThrottle(10, 1)
+	limiter := &throttledRateLimiter{throttle: throttle}
+
+	if !limiter.TryAccept() {
+		t.Fatalf("Expected the first request to use the burst")
+	}
+	if limiter.TryAccept() {
+		t.Errorf("Expected a second immediate request to be limited")
+	}
+
+	throttle.recordResponse(http.StatusTooManyRequests)
+	if qps := limiter.QPS(); qps != 5 {
+		t.Errorf("Expected the clients to see the reduced rate 5, got %v", qps)
+	}
+
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("Expected the next request to wait for the reduced rate, waited %v", waited)
+	}
+}
+
+func TestAdaptiveThrottle_Install(t *testing.T) {
+	config := &rest.Config{Host: "https://example.invalid"}
+	throttle := NewAdaptiveThrottle(config.QPS, config.Burst)
+	throttle.Install(config)
+
+	if config.WrapTransport == nil {
+		t.Errorf("Expected transport wrapper to be installed")
+	}
+	limiter, ok := config.RateLimiter.(*throttledRateLimiter)
+	if !ok {
+		t.Fatalf("Expected throttled rate limiter, got %T", config.RateLimiter)
+	}
+	if limiter.QPS() != defaultClientQPS {
+		t.Errorf("Expected default QPS %d, got %v", defaultClientQPS, limiter.QPS())
+	}
+	if throttle.limiter.Burst() != defaultClientBurst {
+		t.Errorf("Expected default burst %d, got %d", defaultClientBurst, throttle.limiter.Burst())
+	}
+}
+
+func TestDiscoverer_ThrottleStatsWithoutThrottle(t *testing.T) {
+	var d *Discoverer
+	if d.ThrottleStats() != nil {
+		t.Errorf("Expected nil stats for a nil discoverer")
+	}
+	if (&Discoverer{}).ThrottleStats() != nil {
+		t.Errorf("Expected nil stats without a throttle")
+	}
+}
@@ -0,0 +1,139 @@
+package autodiscovery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow scopes collection to an incident window, either bound may be open
+type TimeWindow struct {
+	Since *time.Time `json:"since,omitempty" yaml:"since,omitempty"`
+	Until *time.Time `json:"until,omitempty" yaml:"until,omitempty"`
+}
+
+// ParseTimeWindow parses --since and --until values relative to now, see ParseTimeBound
+func ParseTimeWindow(since, until string, now time.Time) (TimeWindow, error) {
+	var window TimeWindow
+	var err error
+	if window.Since, err = ParseTimeBound(since, now); err != nil {
+		return TimeWindow{}, fmt.Errorf("since: %w", err)
+	}
+	if window.Until, err = ParseTimeBound(until, now); err != nil {
+		return TimeWindow{}, fmt.Errorf("until: %w", err)
+	}
+	return window, window.Validate()
+}
+
+// ParseTimeBound parses an RFC3339 timestamp, or a duration such as "2h" meaning that long before now
+// An empty value is an open bound
+func ParseTimeBound(value string, now time.Time) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("invalid time %q, expected an RFC3339 timestamp or a duration like 2h", value)
+	}
+	t := now.Add(-d).UTC().Truncate(time.Second)
+	return &t, nil
+}
+
+// IsZero reports whether the window has no bounds
+func (w TimeWindow) IsZero() bool {
+	return w.Since == nil && w.Until == nil
+}
+
+// Contains reports whether t falls inside the window, bounds included
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.Since != nil && t.Before(*w.Since) {
+		return false
+	}
+	if w.Until != nil && t.After(*w.Until) {
+		return false
+	}
+	return true
+}
+
+// WithOverrides returns the window with every set bound of overrides applied
+func (w TimeWindow) WithOverrides(overrides TimeWindow) TimeWindow {
+	if overrides.Since != nil {
+		w.Since = overrides.Since
+	}
+	if overrides.Until != nil {
+		w.Until = overrides.Until
+	}
+	return w
+}
+
+// Validate checks that since is not after until
+func (w TimeWindow) Validate() error {
+	if w.Since != nil && w.Until != nil && w.Since.After(*w.Until) {
+		return fmt.Errorf("since (%s) is after until (%s)", w.Since.Format(time.RFC3339), w.Until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// String formats the window for summaries, e.g. "2024-01-01T10:00:00Z to now"
+func (w TimeWindow) String() string {
+	since, until := "the beginning", "now"
+	if w.Since != nil {
+		since = w.Since.Format(time.RFC3339)
+	}
+	if w.Until != nil {
+		until = w.Until.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s to %s", since, until)
+}
+
+// eventFieldSelectors returns the lastTimestamp selectors that keep events inside the window
+func (w TimeWindow) eventFieldSelectors() []string {
+	var selectors []string
+	if w.Since != nil {
+		selectors = append(selectors, "lastTimestamp>="+w.Since.Format(time.RFC3339))
+	}
+	if w.Until != nil {
+		selectors = append(selectors, "lastTimestamp<="+w.Until.Format(time.RFC3339))
+	}
+	return selectors
+}
+
+// applyTimeWindow scopes time-series collectors to the window: logs start at sinceTime instead of their
+// maxAge, and events are filtered on lastTimestamp. The log API has no end bound, so logs run up to collection
+func applyTimeWindow(collectors []CollectorSpec, window TimeWindow) []CollectorSpec {
+	if window.IsZero() {
+		return collectors
+	}
+
+	for i, collector := range collectors {
+		switch collector.Type {
+		case CollectorTypeLogs:
+			if window.Since == nil {
+				continue
+			}
+			params, err := collector.LogsParams()
+			if err != nil {
+				continue
+			}
+			if params.Limits == nil {
+				params.Limits = &LogsLimits{}
+			}
+			params.Limits.MaxAge = ""
+			params.Limits.SinceTime = window.Since.Format(time.RFC3339)
+			collectors[i].Parameters = params.ToMap()
+		case CollectorTypeClusterResources:
+			params, err := collector.ClusterResourcesParams()
+			if err != nil || params.Group != "" || params.Resource != "events" {
+				continue
+			}
+			params.FieldSelectors = append(params.FieldSelectors, window.eventFieldSelectors()...)
+			collectors[i].Parameters = params.ToMap()
+		}
+	}
+	return collectors
+}
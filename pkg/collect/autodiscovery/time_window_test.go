@@ -0,0 +1,141 @@
+package autodiscovery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{value: "", expected: ""},
+		{value: "2h", expected: "2024-01-01T10:00:00Z"},
+		{value: "90m", expected: "2024-01-01T10:30:00Z"},
+		{value: "2024-01-01T08:00:00Z", expected: "2024-01-01T08:00:00Z"},
+		{value: "2024-01-01T08:00:00+02:00", expected: "2024-01-01T06:00:00Z"},
+		{value: "-2h", expectError: true},
+		{value: "yesterday", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			bound, err := ParseTimeBound(tt.value, now)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := ""
+			if bound != nil {
+				got = bound.Format(time.RFC3339)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	window, err := ParseTimeWindow("3h", "1h", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !window.Contains(now.Add(-2*time.Hour)) || window.Contains(now.Add(-30*time.Minute)) || window.Contains(now.Add(-4*time.Hour)) {
+		t.Errorf("Expected the window to cover 09:00 to 11:00, got %s", window)
+	}
+
+	if _, err := ParseTimeWindow("1h", "3h", now); err == nil {
+		t.Errorf("Expected an error when since is after until")
+	}
+	if window, err := ParseTimeWindow("", "", now); err != nil || !window.IsZero() {
+		t.Errorf("Expected an empty window, got %s, %v", window, err)
+	}
+}
+
+func TestApplyTimeWindow(t *testing.T) {
+	since := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+
+	collectors := []CollectorSpec{
+		{
+			Type: CollectorTypeLogs,
+			Name: "auto-logs-default",
+			Parameters: LogsParams{
+				Namespace: "default",
+				Selector:  []string{"namespace=default"},
+				Limits:    &LogsLimits{MaxAge: "72h", MaxLines: 10000},
+			}.ToMap(),
+		},
+		{
+			Type:       CollectorTypeClusterResources,
+			Name:       "auto-resources-events",
+			Parameters: ClusterResourcesParams{Version: "v1", Resource: "events", Namespaces: []string{"default"}}.ToMap(),
+		},
+		{
+			Type:       CollectorTypeClusterResources,
+			Name:       "auto-resources-pods",
+			Parameters: ClusterResourcesParams{Version: "v1", Resource: "pods"}.ToMap(),
+		},
+	}
+
+	collectors = applyTimeWindow(collectors, TimeWindow{Since: &since, Until: &until})
+
+	logs, err := collectors[0].LogsParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if logs.Limits.SinceTime != "2024-01-01T10:00:00Z" || logs.Limits.MaxAge != "" || logs.Limits.MaxLines != 10000 {
+		t.Errorf("Expected sinceTime to replace maxAge, got %+v", logs.Limits)
+	}
+
+	events, err := collectors[1].ClusterResourcesParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events.FieldSelectors) != 2 || events.FieldSelectors[0] != "lastTimestamp>=2024-01-01T10:00:00Z" || events.FieldSelectors[1] != "lastTimestamp<=2024-01-01T11:00:00Z" {
+		t.Errorf("Expected lastTimestamp selectors on events, got %v", events.FieldSelectors)
+	}
+	if err := events.Validate(); err != nil {
+		t.Errorf("Expected the event selectors to be valid, got %v", err)
+	}
+
+	if _, ok := collectors[2].Parameters["fieldSelectors"]; ok {
+		t.Errorf("Expected pods to be left unfiltered")
+	}
+}
+
+func TestApplyTimeWindow_UntilOnly(t *testing.T) {
+	until := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	collectors := []CollectorSpec{{
+		Type: CollectorTypeLogs,
+		Name: "auto-logs-default",
+		Parameters: LogsParams{
+			Namespace: "default",
+			Selector:  []string{"namespace=default"},
+			Limits:    &LogsLimits{MaxAge: "72h"},
+		}.ToMap(),
+	}}
+
+	// The log API has no end bound, so logs keep their maxAge
+	collectors = applyTimeWindow(collectors, TimeWindow{Until: &until})
+	logs, err := collectors[0].LogsParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if logs.Limits.MaxAge != "72h" || logs.Limits.SinceTime != "" {
+		t.Errorf("Expected logs to be unchanged, got %+v", logs.Limits)
+	}
+}
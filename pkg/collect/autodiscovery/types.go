@@ -20,6 +20,29 @@ type DiscoveryOptions struct {
 	IncludeImages bool     `json:"includeImages,omitempty" yaml:"includeImages,omitempty"`
 	RBACCheck     bool     `json:"rbacCheck,omitempty" yaml:"rbacCheck,omitempty"`
 	MaxDepth      int      `json:"maxDepth,omitempty" yaml:"maxDepth,omitempty"`
+	PageSize      int64    `json:"pageSize,omitempty" yaml:"pageSize,omitempty"` // Objects per list call, 0 uses the default
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty" yaml:"excludeNamespaces,omitempty"` // Skipped when scanning all namespaces
+	CandidateNamespaces []string `json:"candidateNamespaces,omitempty" yaml:"candidateNamespaces,omitempty"` // Probed with access reviews when listing namespaces is forbidden
+	PhaseTimeouts PhaseTimeouts `json:"phaseTimeouts,omitempty" yaml:"phaseTimeouts,omitempty"` // Per-phase deadlines, partial results are kept on expiry
+	DebugLogFallback bool `json:"debugLogFallback,omitempty" yaml:"debugLogFallback,omitempty"` // Read ephemeral container logs from the node with a debug pod
+	StoragePriority int `json:"storagePriority,omitempty" yaml:"storagePriority,omitempty"` // Priority of the storage collector group, 0 uses DefaultStoragePriority
+	OnlyGroups []string `json:"onlyGroups,omitempty" yaml:"onlyGroups,omitempty"` // Keep only collectors in these groups, see CollectorGroups
+	SkipGroups []string `json:"skipGroups,omitempty" yaml:"skipGroups,omitempty"` // Drop collectors in these groups
+	ResourceFormat string `json:"resourceFormat,omitempty" yaml:"resourceFormat,omitempty"` // "full" (default), "table" or "both", see ResourceFormatTable
+	TableThreshold int `json:"tableThreshold,omitempty" yaml:"tableThreshold,omitempty"` // Objects per type and namespace before a table is used, 0 uses DefaultTableThreshold
+	Apps []string `json:"apps,omitempty" yaml:"apps,omitempty"` // Seed discovery from resources with these app.kubernetes.io/name or part-of values
+	NodeSampling NodeSampling `json:"nodeSampling,omitempty" yaml:"nodeSampling,omitempty"` // Bounds the nodes collected from large clusters
+	HotPods HotPods `json:"hotPods,omitempty" yaml:"hotPods,omitempty"` // Targets logs and exec collectors at the pods using the most CPU and memory or restarting most
+	TimeWindow TimeWindow `json:"timeWindow,omitempty" yaml:"timeWindow,omitempty"` // Scopes logs and events to an incident window
+	AuditLogPath string `json:"auditLogPath,omitempty" yaml:"auditLogPath,omitempty"` // API server audit log searched for admission denials
+	MaxCollectors int `json:"maxCollectors,omitempty" yaml:"maxCollectors,omitempty"` // Cap on generated collectors, the lowest priority are dropped; 0 is unlimited
+	MaxLogLines int `json:"maxLogLines,omitempty" yaml:"maxLogLines,omitempty"` // Caps maxLines of every logs collector; 0 keeps their own limits
+	NormalizeLogTimestamps bool `json:"normalizeLogTimestamps,omitempty" yaml:"normalizeLogTimestamps,omitempty"` // Collect logs with timestamps and rewrite them to RFC3339 UTC, see LogTimestampNormalizer
+	LargeObjects LargeObjects `json:"largeObjects,omitempty" yaml:"largeObjects,omitempty"` // Captures oversized ConfigMaps and Secrets as metadata, keys and sizes
+	SecretPolicy SecretPolicy `json:"secretPolicy,omitempty" yaml:"secretPolicy,omitempty"` // Secret types collected, as metadata and certificate details only
+	BatchByNamespace bool `json:"batchByNamespace,omitempty" yaml:"batchByNamespace,omitempty"` // One cluster-resources collector per set of resource types and the namespaces sharing it
+	ProtectedNamespaces []string `json:"protectedNamespaces,omitempty" yaml:"protectedNamespaces,omitempty"` // Namespace globs never read, even when requested; overrides can only add to them
+	ClusterScope ClusterScope `json:"clusterScope,omitempty" yaml:"clusterScope,omitempty"` // Cluster-scoped types collected whole, those the user may not list are skipped
 }
 
 // CollectorSpec represents a generated collector specification
@@ -30,6 +53,8 @@ type CollectorSpec struct {
 	Namespace  string                 `json:"namespace,omitempty"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 	Priority   int                    `json:"priority,omitempty"`
+	Group      string                 `json:"group,omitempty"` // Diagnostic group the collector belongs to, e.g. "storage"
+	ID         string                 `json:"id,omitempty"`    // Content-derived ID, see CollectorID
 }
 
 // Resource represents a Kubernetes resource discovered during auto-discovery
@@ -38,7 +63,12 @@ type Resource struct {
 	Namespace string                      `json:"namespace"`
 	Name      string                      `json:"name"`
 	Labels    map[string]string           `json:"labels,omitempty"`
+	Annotations map[string]string         `json:"annotations,omitempty"`         // troubleshoot.sh/ annotations only, see AnnotationExclude
 	OwnerRefs []metav1.OwnerReference     `json:"ownerRefs,omitempty"`
+	NodeName  string                      `json:"nodeName,omitempty"`            // Pods only
+	EphemeralContainers []string          `json:"ephemeralContainers,omitempty"` // Pods only
+	ContainerImages map[string]string     `json:"containerImages,omitempty"`     // Pods only, image by container name
+	Fields    map[string][]interface{}    `json:"fields,omitempty"`              // Values at ResourceFilter.FieldPaths, keyed by path
 }
 
 // DiscoveryResult encapsulates the results of the discovery process
@@ -71,6 +101,7 @@ type ResourceFilter struct {
 	ExcludeGVRs []schema.GroupVersionResource `json:"excludeGVRs,omitempty"`
 	LabelSelector string                      `json:"labelSelector,omitempty"`
 	NamespaceSelector string                  `json:"namespaceSelector,omitempty"`
+	FieldPaths []string                       `json:"fieldPaths,omitempty"` // JSONPaths whose values are kept on each Resource for field selectors
 }
 
 // CollectorPriority defines priority levels for different collector types
@@ -81,4 +112,5 @@ const (
 	PriorityNormal
 	PriorityHigh
 	PriorityCritical
+	PriorityExpiring // Data about to be deleted by the cluster, e.g. pods of finished Jobs with a TTL, collected first
 )
@@ -0,0 +1,301 @@
+package autodiscovery
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultWebhookCertExpiryWarning is how close to expiry a webhook CA bundle is reported
+const DefaultWebhookCertExpiryWarning = 30 * 24 * time.Hour
+
+var (
+	validatingWebhooksGVR = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}
+	mutatingWebhooksGVR   = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}
+	servicesGVR           = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+	endpointsGVR          = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}
+)
+
+// WebhookStatus describes the health of a single admission webhook and its backend
+type WebhookStatus struct {
+	Configuration    string            `json:"configuration"`
+	Kind             string            `json:"kind"`
+	Webhook          string            `json:"webhook"`
+	FailurePolicy    string            `json:"failurePolicy,omitempty"`
+	ServiceNamespace string            `json:"serviceNamespace,omitempty"`
+	ServiceName      string            `json:"serviceName,omitempty"`
+	ServiceSelector  map[string]string `json:"serviceSelector,omitempty"`
+	URL              string            `json:"url,omitempty"`
+	ReadyEndpoints   int               `json:"readyEndpoints"`
+	CertNotAfter     *time.Time        `json:"certNotAfter,omitempty"`
+	Healthy          bool              `json:"healthy"`
+	Problems         []string          `json:"problems,omitempty"`
+
+	resource string
+}
+
+// WebhookDetector inspects admission webhook configurations and their service backends
+type WebhookDetector struct {
+	dynamicClient dynamic.Interface
+	expiryWarning time.Duration
+	now           func() time.Time
+}
+
+// NewWebhookDetector creates a new WebhookDetector
+func NewWebhookDetector(dynamicClient dynamic.Interface) *WebhookDetector {
+	return &WebhookDetector{
+		dynamicClient: dynamicClient,
+		expiryWarning: DefaultWebhookCertExpiryWarning,
+		now:           time.Now,
+	}
+}
+
+// DetectWebhooks checks every validating and mutating webhook
+// A webhook configuration type that cannot be listed is skipped with a warning
+func (w *WebhookDetector) DetectWebhooks(ctx context.Context) ([]WebhookStatus, error) {
+	var statuses []WebhookStatus
+
+	for _, source := range []struct {
+		gvr  schema.GroupVersionResource
+		kind string
+	}{
+		{validatingWebhooksGVR, "ValidatingWebhookConfiguration"},
+		{mutatingWebhooksGVR, "MutatingWebhookConfiguration"},
+	} {
+		list, err := w.dynamicClient.Resource(source.gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			fmt.Printf("Warning: failed to list %s: %v\n", source.gvr.Resource, err)
+			continue
+		}
+
+		for _, config := range list.Items {
+			webhooks, _, _ := unstructured.NestedSlice(config.Object, "webhooks")
+			for _, wh := range webhooks {
+				webhook, ok := wh.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				status := w.checkWebhook(ctx, webhook)
+				status.Configuration = config.GetName()
+				status.Kind = source.kind
+				status.resource = source.gvr.Resource
+				statuses = append(statuses, status)
+			}
+		}
+	}
+
+	return statuses, nil
+}
+
+// checkWebhook evaluates one webhook entry of a configuration
+func (w *WebhookDetector) checkWebhook(ctx context.Context, webhook map[string]interface{}) WebhookStatus {
+	status := WebhookStatus{}
+	status.Webhook, _, _ = unstructured.NestedString(webhook, "name")
+	status.FailurePolicy, _, _ = unstructured.NestedString(webhook, "failurePolicy")
+	status.URL, _, _ = unstructured.NestedString(webhook, "clientConfig", "url")
+	status.ServiceNamespace, _, _ = unstructured.NestedString(webhook, "clientConfig", "service", "namespace")
+	status.ServiceName, _, _ = unstructured.NestedString(webhook, "clientConfig", "service", "name")
+
+	if caBundle, _, _ := unstructured.NestedString(webhook, "clientConfig", "caBundle"); caBundle != "" {
+		notAfter, err := earliestCertExpiry(caBundle)
+		if err != nil {
+			status.Problems = append(status.Problems, fmt.Sprintf("caBundle could not be parsed: %v", err))
+		} else {
+			status.CertNotAfter = &notAfter
+			now := w.now()
+			if notAfter.Before(now) {
+				status.Problems = append(status.Problems, fmt.Sprintf("caBundle expired at %s", notAfter.Format(time.RFC3339)))
+			} else if notAfter.Sub(now) < w.expiryWarning {
+				status.Problems = append(status.Problems, fmt.Sprintf("caBundle expires at %s", notAfter.Format(time.RFC3339)))
+			}
+		}
+	}
+
+	if status.ServiceName != "" {
+		w.checkServiceBackend(ctx, &status)
+	}
+
+	status.Healthy = len(status.Problems) == 0
+	return status
+}
+
+// checkServiceBackend verifies that the webhook service exists and has ready endpoints
+func (w *WebhookDetector) checkServiceBackend(ctx context.Context, status *WebhookStatus) {
+	service, err := w.dynamicClient.Resource(servicesGVR).Namespace(status.ServiceNamespace).Get(ctx, status.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			status.Problems = append(status.Problems, fmt.Sprintf("service %s/%s not found", status.ServiceNamespace, status.ServiceName))
+		} else {
+			status.Problems = append(status.Problems, fmt.Sprintf("failed to get service %s/%s: %v", status.ServiceNamespace, status.ServiceName, err))
+		}
+		return
+	}
+	status.ServiceSelector, _, _ = unstructured.NestedStringMap(service.Object, "spec", "selector")
+
+	endpoints, err := w.dynamicClient.Resource(endpointsGVR).Namespace(status.ServiceNamespace).Get(ctx, status.ServiceName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		status.Problems = append(status.Problems, fmt.Sprintf("failed to get endpoints %s/%s: %v", status.ServiceNamespace, status.ServiceName, err))
+		return
+	}
+	if endpoints != nil && err == nil {
+		subsets, _, _ := unstructured.NestedSlice(endpoints.Object, "subsets")
+		for _, s := range subsets {
+			if subset, ok := s.(map[string]interface{}); ok {
+				addresses, _, _ := unstructured.NestedSlice(subset, "addresses")
+				status.ReadyEndpoints += len(addresses)
+			}
+		}
+	}
+
+	if status.ReadyEndpoints == 0 {
+		status.Problems = append(status.Problems, fmt.Sprintf("service %s/%s has no ready endpoints", status.ServiceNamespace, status.ServiceName))
+	}
+}
+
+// GenerateWebhookCollectors creates collectors for unhealthy webhooks: their configurations,
+// the backing services and endpoints, backend pod logs and a status report with cert expiry
+func (w *WebhookDetector) GenerateWebhookCollectors(statuses []WebhookStatus) []CollectorSpec {
+	var unhealthy []WebhookStatus
+	for _, status := range statuses {
+		if !status.Healthy {
+			unhealthy = append(unhealthy, status)
+		}
+	}
+	if len(unhealthy) == 0 {
+		return nil
+	}
+
+	// Collectors shared by several webhooks are added once, at the highest priority among them
+	var collectors []CollectorSpec
+	seen := make(map[string]int)
+	add := func(spec CollectorSpec) {
+		if i, ok := seen[spec.Name]; ok {
+			if spec.Priority > collectors[i].Priority {
+				collectors[i].Priority = spec.Priority
+			}
+			return
+		}
+		seen[spec.Name] = len(collectors)
+		collectors = append(collectors, spec)
+	}
+
+	for _, status := range unhealthy {
+		// A failing webhook with failurePolicy Fail blocks API requests
+		priority := int(PriorityHigh)
+		if status.FailurePolicy == "" || status.FailurePolicy == "Fail" {
+			priority = int(PriorityCritical)
+		}
+
+		add(CollectorSpec{
+			Type:     "cluster-resources",
+			Name:     fmt.Sprintf("auto-webhook-%s", status.resource),
+			Priority: priority,
+			Parameters: ClusterResourcesParams{
+				Group:    validatingWebhooksGVR.Group,
+				Version:  validatingWebhooksGVR.Version,
+				Resource: status.resource,
+			}.ToMap(),
+		})
+
+		if status.ServiceName == "" {
+			continue
+		}
+		for _, gvr := range []schema.GroupVersionResource{servicesGVR, endpointsGVR} {
+			add(CollectorSpec{
+				Type:      "cluster-resources",
+				Name:      fmt.Sprintf("auto-webhook-%s-%s", gvr.Resource, status.ServiceNamespace),
+				Namespace: status.ServiceNamespace,
+				Priority:  priority,
+				Parameters: ClusterResourcesParams{
+					Group:      gvr.Group,
+					Version:    gvr.Version,
+					Resource:   gvr.Resource,
+					Namespaces: []string{status.ServiceNamespace},
+				}.ToMap(),
+			})
+		}
+
+		if len(status.ServiceSelector) > 0 {
+			add(CollectorSpec{
+				Type:      "logs",
+				Name:      fmt.Sprintf("auto-webhook-logs-%s-%s", status.ServiceNamespace, status.ServiceName),
+				Namespace: status.ServiceNamespace,
+				Priority:  priority,
+				Parameters: LogsParams{
+					Namespace: status.ServiceNamespace,
+					Selector:  selectorStrings(status.ServiceSelector),
+					Limits:    &LogsLimits{MaxAge: "24h", MaxLines: 1000},
+				}.ToMap(),
+			})
+		}
+	}
+
+	report, err := json.MarshalIndent(unhealthy, "", "  ")
+	if err == nil {
+		collectors = append(collectors, CollectorSpec{
+			Type:     "data",
+			Name:     "auto-webhook-status",
+			Priority: int(PriorityCritical),
+			Parameters: map[string]interface{}{
+				"name": "webhooks/status.json",
+				"data": string(report),
+			},
+		})
+	}
+
+	return collectors
+}
+
+// earliestCertExpiry returns the earliest NotAfter of the certificates in a base64 PEM bundle
+func earliestCertExpiry(caBundle string) (time.Time, error) {
+	data, err := base64.StdEncoding.DecodeString(caBundle)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid base64: %w", err)
+	}
+
+	var earliest time.Time
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+
+	if earliest.IsZero() {
+		return time.Time{}, fmt.Errorf("no certificates found")
+	}
+	return earliest, nil
+}
+
+// selectorStrings converts a label map into sorted key=value selectors
+func selectorStrings(selector map[string]string) []string {
+	var selectors []string
+	for key, value := range selector {
+		selectors = append(selectors, key+"="+value)
+	}
+	sort.Strings(selectors)
+	return []string{strings.Join(selectors, ",")}
+}
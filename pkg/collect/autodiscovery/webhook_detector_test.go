@@ -0,0 +1,170 @@
+package autodiscovery
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testCABundle(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "webhook-ca"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestWebhookDetector_DetectWebhooks(t *testing.T) {
+	fail := admissionregistrationv1.Fail
+	ignore := admissionregistrationv1.Ignore
+	soon := time.Now().Add(10 * 24 * time.Hour)
+	later := time.Now().Add(365 * 24 * time.Hour)
+
+	client := createTestDynamicClient(
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+				Name:          "validate.policy.io",
+				FailurePolicy: &fail,
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "policy", Name: "policy-webhook"},
+					CABundle: testCABundle(t, later),
+				},
+			}},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name:          "inject.mesh.io",
+				FailurePolicy: &ignore,
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "mesh", Name: "injector"},
+					CABundle: testCABundle(t, soon),
+				},
+			}},
+		},
+		// policy-webhook exists but has no ready endpoints
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy-webhook", Namespace: "policy"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "policy"}},
+		},
+		&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "policy-webhook", Namespace: "policy"}},
+		// injector is served but its CA expires soon
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector", Namespace: "mesh"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "injector"}},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector", Namespace: "mesh"},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+			}},
+		},
+	)
+
+	detector := NewWebhookDetector(client)
+	statuses, err := detector.DetectWebhooks(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 webhook statuses, got %d", len(statuses))
+	}
+
+	byName := make(map[string]WebhookStatus)
+	for _, status := range statuses {
+		byName[status.Webhook] = status
+	}
+
+	policy := byName["validate.policy.io"]
+	if policy.Healthy || policy.ReadyEndpoints != 0 {
+		t.Errorf("Expected policy webhook to be unhealthy with no endpoints, got %+v", policy)
+	}
+	if policy.Kind != "ValidatingWebhookConfiguration" || policy.Configuration != "policy" {
+		t.Errorf("Expected policy webhook from ValidatingWebhookConfiguration policy, got %s %s", policy.Kind, policy.Configuration)
+	}
+
+	injector := byName["inject.mesh.io"]
+	if injector.Healthy || injector.ReadyEndpoints != 2 {
+		t.Errorf("Expected injector webhook to be unhealthy with 2 endpoints, got %+v", injector)
+	}
+	if injector.CertNotAfter == nil || len(injector.Problems) != 1 {
+		t.Errorf("Expected a single cert expiry problem for injector, got %v", injector.Problems)
+	}
+
+	collectors := detector.GenerateWebhookCollectors(statuses)
+	names := make(map[string]CollectorSpec)
+	for _, collector := range collectors {
+		names[collector.Name] = collector
+	}
+	for _, expected := range []string{
+		"auto-webhook-validatingwebhookconfigurations",
+		"auto-webhook-mutatingwebhookconfigurations",
+		"auto-webhook-services-policy",
+		"auto-webhook-endpoints-policy",
+		"auto-webhook-logs-policy-policy-webhook",
+		"auto-webhook-status",
+	} {
+		if _, ok := names[expected]; !ok {
+			t.Errorf("Expected collector %s, got %v", expected, collectors)
+		}
+	}
+
+	if names["auto-webhook-validatingwebhookconfigurations"].Priority != int(PriorityCritical) {
+		t.Errorf("Expected critical priority for a failing webhook with failurePolicy Fail")
+	}
+	if names["auto-webhook-mutatingwebhookconfigurations"].Priority != int(PriorityHigh) {
+		t.Errorf("Expected high priority for a webhook with failurePolicy Ignore")
+	}
+}
+
+func TestWebhookDetector_GenerateWebhookCollectors_Healthy(t *testing.T) {
+	detector := NewWebhookDetector(createTestDynamicClient())
+	collectors := detector.GenerateWebhookCollectors([]WebhookStatus{
+		{Configuration: "ok", Webhook: "ok.example.io", Healthy: true, resource: "validatingwebhookconfigurations"},
+	})
+	if len(collectors) != 0 {
+		t.Errorf("Expected no collectors for healthy webhooks, got %d", len(collectors))
+	}
+}
+
+func TestEarliestCertExpiry(t *testing.T) {
+	first := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	second := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	bundle := append(testCABundle(t, second), testCABundle(t, first)...)
+
+	notAfter, err := earliestCertExpiry(base64.StdEncoding.EncodeToString(bundle))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !notAfter.Equal(first.UTC()) {
+		t.Errorf("Expected earliest expiry %v, got %v", first.UTC(), notAfter)
+	}
+
+	if _, err := earliestCertExpiry("not-base64!"); err == nil {
+		t.Errorf("Expected error for invalid caBundle")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,11 +15,14 @@ import (
 // AutoDiscoveryImageCollector integrates image collection with auto-discovery
 type AutoDiscoveryImageCollector struct {
 	registryClient   RegistryClient
+	digestResolver   *DefaultDigestResolver
 	factsBuilder     FactsBuilder
 	factsSerializer  *FactsSerializer
 	errorHandler     *ErrorHandler
 	dynamicClient    dynamic.Interface
 	progressReporter ProgressReporter
+	protected        []string // Namespace globs whose pods and workloads are never read
+	factsStream      *FactsStream
 }
 
 // NewAutoDiscoveryImageCollector creates a new auto-discovery image collector
@@ -31,6 +35,7 @@ func NewAutoDiscoveryImageCollector(dynamicClient dynamic.Interface) *AutoDiscov
 
 	return &AutoDiscoveryImageCollector{
 		registryClient:  registryClient,
+		digestResolver:  digestResolver,
 		factsBuilder:    factsBuilder,
 		factsSerializer: factsSerializer,
 		errorHandler:    errorHandler,
@@ -53,8 +58,87 @@ func (adic *AutoDiscoveryImageCollector) SetRegistryCredentials(registryCredenti
 	}
 }
 
+// SetKeychain configures dynamic registry credential resolution
+func (adic *AutoDiscoveryImageCollector) SetKeychain(keychain Keychain) {
+	if defaultClient, ok := adic.registryClient.(*DefaultRegistryClient); ok {
+		defaultClient.SetKeychain(keychain)
+	}
+}
+
+// SetTransportConfig configures the proxy and TLS settings used for registry calls
+func (adic *AutoDiscoveryImageCollector) SetTransportConfig(config *RegistryTransportConfig) error {
+	transport, err := NewRegistryTransport(config)
+	if err != nil {
+		return fmt.Errorf("failed to configure registry transport: %w", err)
+	}
+	if defaultClient, ok := adic.registryClient.(*DefaultRegistryClient); ok {
+		defaultClient.SetTransport(transport)
+	}
+	return nil
+}
+
+// SetMirrors configures the mirror endpoints queried before each registry
+func (adic *AutoDiscoveryImageCollector) SetMirrors(mirrors map[string][]string) error {
+	if defaultClient, ok := adic.registryClient.(*DefaultRegistryClient); ok {
+		if err := defaultClient.SetMirrors(mirrors); err != nil {
+			return fmt.Errorf("failed to configure registry mirrors: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetCaptureHistory enables capturing config.history and the build instruction behind each layer
+func (adic *AutoDiscoveryImageCollector) SetCaptureHistory(enabled bool) {
+	if defaultClient, ok := adic.registryClient.(*DefaultRegistryClient); ok {
+		defaultClient.SetCaptureHistory(enabled)
+	}
+	if defaultBuilder, ok := adic.factsBuilder.(*DefaultFactsBuilder); ok {
+		defaultBuilder.SetCaptureHistory(enabled)
+	}
+}
+
+// SetBaseImageDigests sets the layer digests identifying known base images, see IdentifyBaseImage
+func (adic *AutoDiscoveryImageCollector) SetBaseImageDigests(digests map[string]string) {
+	if defaultClient, ok := adic.registryClient.(*DefaultRegistryClient); ok {
+		defaultClient.SetBaseImageDigests(digests)
+	}
+	if defaultBuilder, ok := adic.factsBuilder.(*DefaultFactsBuilder); ok {
+		defaultBuilder.SetBaseImageDigests(digests)
+	}
+}
+
+// ResolveDigests resolves the tags of many images to digests in one batch, see DefaultDigestResolver.ResolveBatch
+// The digests are cached, so facts built for the same images afterwards skip the lookups
+func (adic *AutoDiscoveryImageCollector) ResolveDigests(ctx context.Context, imageRefs []string) *DigestBatchResult {
+	return adic.digestResolver.ResolveBatch(ctx, adic.deduplicateImageRefs(imageRefs))
+}
+
+// SetProtectedNamespaces sets the namespace globs, e.g. pci-*, whose pods and workloads are never read, even when
+// a namespace list or discovered resource names them
+func (adic *AutoDiscoveryImageCollector) SetProtectedNamespaces(patterns []string) {
+	adic.protected = patterns
+}
+
+// SetFactsStream sets the facts.jsonl stream image facts are appended to as each image completes, see FactsStream
+func (adic *AutoDiscoveryImageCollector) SetFactsStream(stream *FactsStream) {
+	adic.factsStream = stream
+}
+
 // CollectImageFactsFromPods discovers pods and collects image facts
 func (adic *AutoDiscoveryImageCollector) CollectImageFactsFromPods(ctx context.Context, namespaces []string, options ImageCollectionOptions) (*ImageCollectionResult, error) {
+	if options.Transport != nil {
+		if err := adic.SetTransportConfig(options.Transport); err != nil {
+			return nil, err
+		}
+	}
+	if len(options.Mirrors) > 0 {
+		if err := adic.SetMirrors(options.Mirrors); err != nil {
+			return nil, err
+		}
+	}
+	adic.SetCaptureHistory(options.IncludeHistory)
+	adic.SetBaseImageDigests(options.BaseImageDigests)
+
 	// Discover pods in the specified namespaces
 	pods, err := adic.discoverPods(ctx, namespaces)
 	if err != nil {
@@ -70,6 +154,8 @@ func (adic *AutoDiscoveryImageCollector) CollectImageFactsFromPods(ctx context.C
 
 	// Collect facts for all unique images
 	resilientCollector := NewResilientImageCollector(adic.registryClient, adic.errorHandler, 1*time.Hour)
+	resilientCollector.SetFactsStream(adic.factsStream)
+	resilientCollector.SetRuntimeIndex(adic.buildImageRuntimeIndex(ctx, pods))
 	result, err := resilientCollector.CollectImageFacts(ctx, imageRefs, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect image facts: %w", err)
@@ -84,9 +170,23 @@ func (adic *AutoDiscoveryImageCollector) CollectImageFactsFromPods(ctx context.C
 
 // CollectImageFactsFromResources collects image facts from discovered Kubernetes resources
 func (adic *AutoDiscoveryImageCollector) CollectImageFactsFromResources(ctx context.Context, resources []AutoDiscoveryResource, options ImageCollectionOptions) (*ImageCollectionResult, error) {
+	if options.Transport != nil {
+		if err := adic.SetTransportConfig(options.Transport); err != nil {
+			return nil, err
+		}
+	}
+	if len(options.Mirrors) > 0 {
+		if err := adic.SetMirrors(options.Mirrors); err != nil {
+			return nil, err
+		}
+	}
+	adic.SetCaptureHistory(options.IncludeHistory)
+	adic.SetBaseImageDigests(options.BaseImageDigests)
+
 	var allImageRefs []string
 
 	// Extract image references from different resource types
+	resources = adic.filterProtectedResources(resources)
 	for _, resource := range resources {
 		imageRefs, err := adic.extractImageRefsFromResource(ctx, resource)
 		if err != nil {
@@ -105,6 +205,10 @@ func (adic *AutoDiscoveryImageCollector) CollectImageFactsFromResources(ctx cont
 
 	// Collect facts
 	resilientCollector := NewResilientImageCollector(adic.registryClient, adic.errorHandler, 1*time.Hour)
+	resilientCollector.SetFactsStream(adic.factsStream)
+	if namespaces := resourceNamespaces(resources); len(namespaces) > 0 {
+		resilientCollector.SetRuntimeIndex(adic.BuildImageRuntimeIndex(ctx, namespaces))
+	}
 	result, err := resilientCollector.CollectImageFacts(ctx, uniqueImageRefs, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect image facts: %w", err)
@@ -117,6 +221,11 @@ func (adic *AutoDiscoveryImageCollector) CollectImageFactsFromResources(ctx cont
 	return result, nil
 }
 
+// ErrorLedger returns every image collection error classified so far, see ErrorCollector.Ledger
+func (adic *AutoDiscoveryImageCollector) ErrorLedger() *ErrorLedger {
+	return adic.errorHandler.GetErrorCollector().Ledger()
+}
+
 // GenerateFactsJSON generates the facts.json output
 func (adic *AutoDiscoveryImageCollector) GenerateFactsJSON(facts map[string]*ImageFacts) ([]byte, error) {
 	return adic.factsSerializer.SerializeToJSON(facts)
@@ -135,18 +244,53 @@ func (adic *AutoDiscoveryImageCollector) discoverPods(ctx context.Context, names
 	podGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
 
 	for _, namespace := range namespaces {
+		if adic.isProtectedNamespace(namespace) {
+			continue
+		}
 		podList, err := adic.dynamicClient.Resource(podGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
 			fmt.Printf("Warning: failed to list pods in namespace %s: %v\n", namespace, err)
 			continue
 		}
 
-		allPods = append(allPods, podList.Items...)
+		// Listing all namespaces returns the pods of protected namespaces too
+		for _, pod := range podList.Items {
+			if !adic.isProtectedNamespace(pod.GetNamespace()) {
+				allPods = append(allPods, pod)
+			}
+		}
 	}
 
 	return allPods, nil
 }
 
+// isProtectedNamespace reports whether namespace matches one of the protected namespace globs
+func (adic *AutoDiscoveryImageCollector) isProtectedNamespace(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	for _, pattern := range adic.protected {
+		if autodiscovery.MatchGlob(pattern, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterProtectedResources drops resources in protected namespaces
+func (adic *AutoDiscoveryImageCollector) filterProtectedResources(resources []AutoDiscoveryResource) []AutoDiscoveryResource {
+	if len(adic.protected) == 0 {
+		return resources
+	}
+	filtered := make([]AutoDiscoveryResource, 0, len(resources))
+	for _, resource := range resources {
+		if !adic.isProtectedNamespace(resource.Namespace) {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered
+}
+
 func (adic *AutoDiscoveryImageCollector) extractImageRefsFromPods(pods []unstructured.Unstructured) []string {
 	var imageRefs []string
 
@@ -269,6 +413,17 @@ func (adic *AutoDiscoveryImageCollector) extractImageRefsFromCronJob(ctx context
 	return []string{}, nil
 }
 
+// resourceNamespaces returns the distinct namespaces of the given resources
+func resourceNamespaces(resources []AutoDiscoveryResource) []string {
+	var namespaces []string
+	for _, resource := range resources {
+		if resource.Namespace != "" {
+			namespaces = appendUnique(namespaces, resource.Namespace)
+		}
+	}
+	return namespaces
+}
+
 func (adic *AutoDiscoveryImageCollector) deduplicateImageRefs(imageRefs []string) []string {
 	seen := make(map[string]bool)
 	var unique []string
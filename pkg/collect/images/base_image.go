@@ -0,0 +1,110 @@
+package images
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Sources a base image was identified from
+const (
+	BaseImageSourceAnnotation  = "annotation"   // base.name manifest annotation or label, or a UBI component label
+	BaseImageSourceLayerDigest = "layer-digest" // A layer matched a configured base image digest
+	BaseImageSourceHistory     = "history"      // A build step in config.history
+)
+
+// baseImageNameKey is the OCI annotation, also used as a label, naming the image a build started from
+const baseImageNameKey = "org.opencontainers.image.base.name"
+
+// baseImageHistoryPatterns recognize the build steps of well-known base images in config.history, the first
+// group is the version appended to the name
+var baseImageHistoryPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"alpine", regexp.MustCompile(`alpine-minirootfs-(\d+\.\d+)`)},
+	{"debian", regexp.MustCompile(`debian\.sh --arch '[^']+' out/ '([a-z]+)'`)},
+	{"ubuntu", regexp.MustCompile(`org\.opencontainers\.image\.ref\.name=ubuntu.*org\.opencontainers\.image\.version=(\S+)`)},
+}
+
+// ubiComponentPattern matches the com.redhat.component label of Red Hat Universal Base Images, e.g. ubi9-minimal-container
+var ubiComponentPattern = regexp.MustCompile(`^(ubi\d+(?:-minimal|-micro|-init)?)-container$`)
+
+// IdentifyBaseImage names the base image of an image, e.g. alpine:3.19 or distroless, for CVE triage
+// It prefers an explicit base.name annotation, then the deepest layer matching knownDigests (layer digest -> base
+// image), then build steps of well-known base images in config.history. It returns "" when nothing matched
+func IdentifyBaseImage(facts *ImageFacts, annotations map[string]string, knownDigests map[string]string) (string, string) {
+	if facts == nil {
+		return "", ""
+	}
+
+	for _, labels := range []map[string]string{annotations, facts.Config.Labels} {
+		if name := labels[baseImageNameKey]; name != "" {
+			return shortBaseImageName(name), BaseImageSourceAnnotation
+		}
+	}
+	if match := ubiComponentPattern.FindStringSubmatch(facts.Config.Labels["com.redhat.component"]); match != nil {
+		return match[1], BaseImageSourceAnnotation
+	}
+
+	for i := len(facts.Layers) - 1; i >= 0; i-- {
+		if name, ok := knownDigests[facts.Layers[i].Digest]; ok {
+			return name, BaseImageSourceLayerDigest
+		}
+	}
+
+	if name := baseImageFromHistory(facts.Config.History); name != "" {
+		return name, BaseImageSourceHistory
+	}
+	return "", ""
+}
+
+// baseImageFromHistory matches the build steps against well-known base images
+// Distroless images are built with Bazel and record it as the step author or instruction
+func baseImageFromHistory(history []HistoryEntry) string {
+	var steps []string
+	for _, entry := range history {
+		steps = append(steps, entry.CreatedBy+" "+entry.Comment)
+		if entry.Author == "Bazel" || strings.HasPrefix(entry.CreatedBy, "bazel build") {
+			return "distroless"
+		}
+	}
+
+	// Ubuntu declares its name and version in separate LABEL steps
+	joined := strings.Join(steps, " ")
+	for _, base := range baseImageHistoryPatterns {
+		if match := base.pattern.FindStringSubmatch(joined); match != nil {
+			return base.name + ":" + match[1]
+		}
+	}
+	return ""
+}
+
+// layerDigestPattern matches the sha256 layer digests base images are identified by
+var layerDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ValidateBaseImageDigests checks every key is a sha256 layer digest and names a base image
+func ValidateBaseImageDigests(digests map[string]string) error {
+	for digest, name := range digests {
+		if !layerDigestPattern.MatchString(digest) {
+			return fmt.Errorf("invalid layer digest %q, expected sha256:<64 hex characters>", digest)
+		}
+		if name == "" {
+			return fmt.Errorf("base image name for %s cannot be empty", digest)
+		}
+	}
+	return nil
+}
+
+// shortBaseImageName drops the Docker Hub registry and library/ prefix, e.g. docker.io/library/alpine:3.19 -> alpine:3.19
+func shortBaseImageName(name string) string {
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return strings.TrimPrefix(name, "library/")
+}
+
+// applyBaseImage records the base image on facts, it must run before applyImageHistory drops the history
+func applyBaseImage(facts *ImageFacts, annotations map[string]string, knownDigests map[string]string) {
+	facts.BaseImage, facts.BaseImageSource = IdentifyBaseImage(facts, annotations, knownDigests)
+}
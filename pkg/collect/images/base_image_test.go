@@ -0,0 +1,144 @@
+package images
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIdentifyBaseImage(t *testing.T) {
+	goldenDigest := "sha256:" + strings.Repeat("b", 64)
+	known := map[string]string{
+		"sha256:" + strings.Repeat("a", 64): "alpine:3.18",
+		goldenDigest:                        "acme/golden-base:2024.1",
+	}
+
+	tests := []struct {
+		name           string
+		facts          *ImageFacts
+		annotations    map[string]string
+		expectedName   string
+		expectedSource string
+	}{
+		{
+			name:           "manifest annotation",
+			facts:          &ImageFacts{},
+			annotations:    map[string]string{"org.opencontainers.image.base.name": "docker.io/library/alpine:3.19"},
+			expectedName:   "alpine:3.19",
+			expectedSource: BaseImageSourceAnnotation,
+		},
+		{
+			name:           "config label",
+			facts:          &ImageFacts{Config: ImageConfig{Labels: map[string]string{"org.opencontainers.image.base.name": "gcr.io/distroless/static:nonroot"}}},
+			expectedName:   "gcr.io/distroless/static:nonroot",
+			expectedSource: BaseImageSourceAnnotation,
+		},
+		{
+			name:           "ubi component label",
+			facts:          &ImageFacts{Config: ImageConfig{Labels: map[string]string{"com.redhat.component": "ubi9-minimal-container"}}},
+			expectedName:   "ubi9-minimal",
+			expectedSource: BaseImageSourceAnnotation,
+		},
+		{
+			name: "deepest known layer wins",
+			facts: &ImageFacts{Layers: []LayerInfo{
+				{Digest: "sha256:" + strings.Repeat("a", 64)},
+				{Digest: goldenDigest},
+				{Digest: "sha256:" + strings.Repeat("c", 64)},
+			}},
+			expectedName:   "acme/golden-base:2024.1",
+			expectedSource: BaseImageSourceLayerDigest,
+		},
+		{
+			name: "alpine history",
+			facts: &ImageFacts{Config: ImageConfig{History: []HistoryEntry{
+				{CreatedBy: "ADD alpine-minirootfs-3.19.1-x86_64.tar.gz / # buildkit"},
+				{CreatedBy: `CMD ["/bin/sh"]`, EmptyLayer: true},
+				{CreatedBy: "COPY app /app # buildkit"},
+			}}},
+			expectedName:   "alpine:3.19",
+			expectedSource: BaseImageSourceHistory,
+		},
+		{
+			name: "debian history",
+			facts: &ImageFacts{Config: ImageConfig{History: []HistoryEntry{
+				{CreatedBy: "# debian.sh --arch 'amd64' out/ 'bookworm' '@1710201600'"},
+			}}},
+			expectedName:   "debian:bookworm",
+			expectedSource: BaseImageSourceHistory,
+		},
+		{
+			name: "ubuntu history",
+			facts: &ImageFacts{Config: ImageConfig{History: []HistoryEntry{
+				{CreatedBy: "/bin/sh -c #(nop)  ARG RELEASE", EmptyLayer: true},
+				{CreatedBy: "/bin/sh -c #(nop)  LABEL org.opencontainers.image.ref.name=ubuntu", EmptyLayer: true},
+				{CreatedBy: "/bin/sh -c #(nop)  LABEL org.opencontainers.image.version=22.04", EmptyLayer: true},
+				{CreatedBy: "/bin/sh -c #(nop) ADD file:1b2c3d in / "},
+			}}},
+			expectedName:   "ubuntu:22.04",
+			expectedSource: BaseImageSourceHistory,
+		},
+		{
+			name:           "distroless history",
+			facts:          &ImageFacts{Config: ImageConfig{History: []HistoryEntry{{CreatedBy: "bazel build ...", Author: "Bazel"}}}},
+			expectedName:   "distroless",
+			expectedSource: BaseImageSourceHistory,
+		},
+		{
+			name:  "unknown",
+			facts: &ImageFacts{Config: ImageConfig{History: []HistoryEntry{{CreatedBy: "/bin/sh -c #(nop) ADD file:1b2c3d in / "}}}},
+		},
+		{
+			name: "nil facts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, source := IdentifyBaseImage(tt.facts, tt.annotations, known)
+			if name != tt.expectedName || source != tt.expectedSource {
+				t.Errorf("Expected %q from %q, got %q from %q", tt.expectedName, tt.expectedSource, name, source)
+			}
+		})
+	}
+}
+
+func TestDefaultFactsBuilder_BuildFactsBaseImage(t *testing.T) {
+	builder := NewFactsBuilder(nil, nil)
+	manifest := &ManifestInfo{Config: ManifestConfig{Digest: "sha256:" + strings.Repeat("d", 64)}}
+	config := &ImageConfig{History: []HistoryEntry{{CreatedBy: "ADD alpine-minirootfs-3.20.0-x86_64.tar.gz / # buildkit"}}}
+
+	facts, err := builder.BuildFacts(context.Background(), "nginx:1.25-alpine", manifest, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if facts.BaseImage != "alpine:3.20" || facts.BaseImageSource != BaseImageSourceHistory {
+		t.Errorf("Expected alpine:3.20 from history, got %q from %q", facts.BaseImage, facts.BaseImageSource)
+	}
+	if len(facts.Config.History) != 0 {
+		t.Errorf("Expected the history to be dropped without history capture, got %d entries", len(facts.Config.History))
+	}
+}
+
+func TestValidateBaseImageDigests(t *testing.T) {
+	tests := []struct {
+		name        string
+		digests     map[string]string
+		expectError bool
+	}{
+		{name: "valid", digests: map[string]string{"sha256:" + strings.Repeat("a", 64): "alpine:3.19"}},
+		{name: "empty"},
+		{name: "short digest", digests: map[string]string{"sha256:abc": "alpine:3.19"}, expectError: true},
+		{name: "missing algorithm", digests: map[string]string{strings.Repeat("a", 64): "alpine:3.19"}, expectError: true},
+		{name: "empty name", digests: map[string]string{"sha256:" + strings.Repeat("a", 64): ""}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBaseImageDigests(tt.digests)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
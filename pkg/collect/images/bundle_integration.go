@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -33,19 +34,28 @@ func (bic *BundleImageCollector) SetProgressReporter(reporter ProgressReporter)
 }
 
 // CollectAndSerialize collects image facts and adds them to the support bundle
+// Facts are appended to facts.jsonl as each image completes, so an interrupted run keeps the images it finished
+// and running again with the same output path resumes from them
 func (bic *BundleImageCollector) CollectAndSerialize(ctx context.Context, resources []AutoDiscoveryResource, options ImageCollectionOptions) (*BundleImageResult, error) {
+	factsPath := filepath.Join(bic.outputPath, FactsStreamFileName)
+	stream, err := OpenFactsStream(factsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", FactsStreamFileName, err)
+	}
+	defer stream.Close()
+	if stream.Resumed() > 0 {
+		fmt.Printf("Resuming image collection, %d images already in %s\n", stream.Resumed(), factsPath)
+	}
+
+	bic.imageCollector.SetFactsStream(stream)
+	defer bic.imageCollector.SetFactsStream(nil)
+
 	// Collect image facts from resources
 	result, err := bic.imageCollector.CollectImageFactsFromResources(ctx, resources, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect image facts: %w", err)
 	}
 
-	// Generate facts.json
-	factsPath := filepath.Join(bic.outputPath, "facts.json")
-	if err := bic.factsSerializer.SerializeToFile(result.Facts, factsPath); err != nil {
-		return nil, fmt.Errorf("failed to write facts.json: %w", err)
-	}
-
 	// Generate image-collection-stats.json with detailed statistics
 	statsPath := filepath.Join(bic.outputPath, "image-collection-stats.json")
 	if err := bic.writeCollectionStats(result, statsPath); err != nil {
@@ -60,13 +70,28 @@ func (bic *BundleImageCollector) CollectAndSerialize(ctx context.Context, resour
 		}
 	}
 
+	// Generate errors.json with the classified error ledger, kept after the run ends
+	var ledgerPath string
+	if ledger := bic.imageCollector.ErrorLedger(); ledger != nil {
+		ledgerPath = filepath.Join(bic.outputPath, ErrorLedgerFileName)
+		data, err := json.MarshalIndent(ledger, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal image error ledger: %w", err)
+		}
+		if err := bic.writeFile(ledgerPath, data); err != nil {
+			return nil, fmt.Errorf("failed to write image error ledger: %w", err)
+		}
+	}
+
 	bundleResult := &BundleImageResult{
 		FactsPath:       factsPath,
 		StatsPath:       statsPath,
-		FactsCount:      len(result.Facts),
+		LedgerPath:      ledgerPath,
+		FactsCount:      stream.Count(),
 		ErrorsCount:     len(result.Errors),
+		ResumedCount:    stream.Resumed(),
 		CollectionTime:  result.Duration,
-		TotalSize:       bic.calculateTotalImageSize(result.Facts),
+		TotalSize:       stream.TotalSize(),
 	}
 
 	if len(result.Errors) > 0 {
@@ -131,7 +156,14 @@ func (bic *BundleImageCollector) writeImageErrors(errors map[string]error, fileP
 		"errors":    make([]map[string]interface{}, 0),
 	}
 
-	for imageRef, err := range errors {
+	imageRefs := make([]string, 0, len(errors))
+	for imageRef := range errors {
+		imageRefs = append(imageRefs, imageRef)
+	}
+	sort.Strings(imageRefs)
+
+	for _, imageRef := range imageRefs {
+		err := errors[imageRef]
 		errorEntry := map[string]interface{}{
 			"imageRef": imageRef,
 			"error":    err.Error(),
@@ -149,14 +181,6 @@ func (bic *BundleImageCollector) writeImageErrors(errors map[string]error, fileP
 	return bic.writeFile(filePath, data)
 }
 
-func (bic *BundleImageCollector) calculateTotalImageSize(facts map[string]*ImageFacts) int64 {
-	var totalSize int64
-	for _, imageFacts := range facts {
-		totalSize += imageFacts.Size
-	}
-	return totalSize
-}
-
 func (bic *BundleImageCollector) generateImageSummary(facts map[string]*ImageFacts) map[string]interface{} {
 	summary := map[string]interface{}{
 		"totalImages": len(facts),
@@ -166,7 +190,9 @@ func (bic *BundleImageCollector) generateImageSummary(facts map[string]*ImageFac
 		"sizeByRegistry": make(map[string]int64),
 	}
 
-	for imageRef, imageFacts := range facts {
+	for _, imageRef := range SortedImageRefs(facts) {
+		imageFacts := facts[imageRef]
+
 		// Count registries
 		registries := summary["registries"].(map[string]int)
 		registries[imageFacts.Registry]++
@@ -224,8 +250,10 @@ type BundleImageResult struct {
 	FactsPath      string        `json:"factsPath"`
 	StatsPath      string        `json:"statsPath"`
 	ErrorsPath     string        `json:"errorsPath,omitempty"`
+	LedgerPath     string        `json:"ledgerPath,omitempty"` // errors.json, see ErrorLedger
 	FactsCount     int           `json:"factsCount"`
 	ErrorsCount    int           `json:"errorsCount"`
+	ResumedCount   int           `json:"resumedCount,omitempty"` // Facts kept from an interrupted run
 	CollectionTime time.Duration `json:"collectionTime"`
 	TotalSize      int64         `json:"totalSize"`
 }
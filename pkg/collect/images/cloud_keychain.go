@@ -0,0 +1,493 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ecrRegistryPattern = regexp.MustCompile(`^\d{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+	gcrRegistryPattern = regexp.MustCompile(`^([a-z]+\.)?gcr\.io$|^[a-z0-9-]+-docker\.pkg\.dev$`)
+	acrRegistryPattern = regexp.MustCompile(`^[a-z0-9]+\.azurecr\.(io|cn|us)$`)
+)
+
+const (
+	// acrTokenUsername is the fixed username ACR expects alongside a refresh token
+	acrTokenUsername = "00000000-0000-0000-0000-000000000000"
+	// gcrTokenUsername is the fixed username GCR expects alongside an access token
+	gcrTokenUsername = "oauth2accesstoken"
+)
+
+// IsECRRegistry returns true if the registry is an AWS ECR private registry
+func IsECRRegistry(registry string) bool {
+	return ecrRegistryPattern.MatchString(registry)
+}
+
+// IsGCRRegistry returns true if the registry is Google Container Registry or Artifact Registry
+func IsGCRRegistry(registry string) bool {
+	return gcrRegistryPattern.MatchString(registry)
+}
+
+// IsACRRegistry returns true if the registry is an Azure Container Registry
+func IsACRRegistry(registry string) bool {
+	return acrRegistryPattern.MatchString(registry)
+}
+
+// awsCredentials holds the credentials used to sign AWS API requests
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// ECRKeychain resolves ECR credentials from environment credentials or IRSA
+type ECRKeychain struct {
+	httpClient  *http.Client
+	ecrEndpoint string // Overrides https://api.ecr.<region>.amazonaws.com when set
+	stsEndpoint string // Overrides https://sts.<region>.amazonaws.com when set
+	now         func() time.Time
+}
+
+// NewECRKeychain creates a new ECR keychain
+func NewECRKeychain(httpClient *http.Client) *ECRKeychain {
+	return &ECRKeychain{
+		httpClient: httpClient,
+		now:        time.Now,
+	}
+}
+
+// Name returns the keychain name
+func (ek *ECRKeychain) Name() string {
+	return "ecr"
+}
+
+// Resolve exchanges AWS credentials for an ECR authorization token
+func (ek *ECRKeychain) Resolve(ctx context.Context, registry string) (*RegistryCredentials, error) {
+	matches := ecrRegistryPattern.FindStringSubmatch(registry)
+	if matches == nil {
+		return nil, nil
+	}
+	region := matches[2]
+
+	creds, err := ek.loadCredentials(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		return nil, nil
+	}
+
+	return ek.getAuthorizationToken(ctx, region, creds)
+}
+
+// loadCredentials reads static credentials from the environment, falling back to IRSA
+func (ek *ECRKeychain) loadCredentials(ctx context.Context, region string) (*awsCredentials, error) {
+	if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		return &awsCredentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	roleARN := os.Getenv("AWS_ROLE_ARN")
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleARN == "" || tokenFile == "" {
+		return nil, nil
+	}
+
+	return ek.assumeRoleWithWebIdentity(ctx, region, roleARN, tokenFile)
+}
+
+// assumeRoleWithWebIdentity exchanges the projected service account token for role credentials
+func (ek *ECRKeychain) assumeRoleWithWebIdentity(ctx context.Context, region, roleARN, tokenFile string) (*awsCredentials, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+
+	endpoint := ek.stsEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+	}
+
+	query := url.Values{}
+	query.Set("Action", "AssumeRoleWithWebIdentity")
+	query.Set("Version", "2011-06-15")
+	query.Set("RoleArn", roleARN)
+	query.Set("RoleSessionName", "troubleshoot-image-collector")
+	query.Set("WebIdentityToken", strings.TrimSpace(string(token)))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var stsResp struct {
+		AssumeRoleWithWebIdentityResponse struct {
+			AssumeRoleWithWebIdentityResult struct {
+				Credentials struct {
+					AccessKeyID     string `json:"AccessKeyId"`
+					SecretAccessKey string `json:"SecretAccessKey"`
+					SessionToken    string `json:"SessionToken"`
+				} `json:"Credentials"`
+			} `json:"AssumeRoleWithWebIdentityResult"`
+		} `json:"AssumeRoleWithWebIdentityResponse"`
+	}
+	if err := doJSONRequest(ek.httpClient, req, &stsResp); err != nil {
+		return nil, fmt.Errorf("failed to assume role with web identity: %w", err)
+	}
+
+	stsCreds := stsResp.AssumeRoleWithWebIdentityResponse.AssumeRoleWithWebIdentityResult.Credentials
+	return &awsCredentials{
+		AccessKeyID:     stsCreds.AccessKeyID,
+		SecretAccessKey: stsCreds.SecretAccessKey,
+		SessionToken:    stsCreds.SessionToken,
+	}, nil
+}
+
+// getAuthorizationToken calls ecr:GetAuthorizationToken and decodes the returned credentials
+func (ek *ECRKeychain) getAuthorizationToken(ctx context.Context, region string, creds *awsCredentials) (*RegistryCredentials, error) {
+	endpoint := ek.ecrEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://api.ecr.%s.amazonaws.com/", region)
+	}
+
+	body := []byte("{}")
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+	signAWSRequest(req, body, creds, region, "ecr", ek.now())
+
+	var ecrResp struct {
+		AuthorizationData []struct {
+			AuthorizationToken string `json:"authorizationToken"`
+		} `json:"authorizationData"`
+	}
+	if err := doJSONRequest(ek.httpClient, req, &ecrResp); err != nil {
+		return nil, fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(ecrResp.AuthorizationData) == 0 {
+		return nil, fmt.Errorf("ECR returned no authorization data")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(ecrResp.AuthorizationData[0].AuthorizationToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ECR authorization token: %w", err)
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid ECR authorization token format")
+	}
+
+	return &RegistryCredentials{
+		Username: parts[0],
+		Password: parts[1],
+	}, nil
+}
+
+// signAWSRequest signs the request in place using AWS Signature Version 4
+func signAWSRequest(req *http.Request, body []byte, creds *awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := now.UTC().Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// GCRKeychain resolves GCR and Artifact Registry credentials from the GCE/GKE metadata server
+type GCRKeychain struct {
+	httpClient  *http.Client
+	metadataURL string
+}
+
+// NewGCRKeychain creates a new GCR keychain
+func NewGCRKeychain(httpClient *http.Client) *GCRKeychain {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+
+	return &GCRKeychain{
+		httpClient:  httpClient,
+		metadataURL: fmt.Sprintf("http://%s/computeMetadata/v1/instance/service-accounts/default/token", host),
+	}
+}
+
+// Name returns the keychain name
+func (gk *GCRKeychain) Name() string {
+	return "gcr"
+}
+
+// Resolve fetches an access token for the workload's service account
+func (gk *GCRKeychain) Resolve(ctx context.Context, registry string) (*RegistryCredentials, error) {
+	if !IsGCRRegistry(registry) {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", gk.metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSONRequest(gk.httpClient, req, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to get token from metadata server: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("metadata server returned an empty access token")
+	}
+
+	return &RegistryCredentials{
+		Username: gcrTokenUsername,
+		Password: tokenResp.AccessToken,
+	}, nil
+}
+
+// ACRKeychain resolves ACR credentials using Azure workload identity or managed identity
+type ACRKeychain struct {
+	httpClient       *http.Client
+	imdsURL          string
+	exchangeEndpoint string // Overrides https://<registry>/oauth2/exchange when set
+}
+
+// NewACRKeychain creates a new ACR keychain
+func NewACRKeychain(httpClient *http.Client) *ACRKeychain {
+	return &ACRKeychain{
+		httpClient: httpClient,
+		imdsURL:    "http://169.254.169.254/metadata/identity/oauth2/token",
+	}
+}
+
+// Name returns the keychain name
+func (ak *ACRKeychain) Name() string {
+	return "acr"
+}
+
+// Resolve obtains an AAD token and exchanges it for an ACR refresh token
+func (ak *ACRKeychain) Resolve(ctx context.Context, registry string) (*RegistryCredentials, error) {
+	if !IsACRRegistry(registry) {
+		return nil, nil
+	}
+
+	var aadToken string
+	var err error
+	if os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "" {
+		aadToken, err = ak.getWorkloadIdentityToken(ctx)
+	} else {
+		aadToken, err = ak.getManagedIdentityToken(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := ak.exchangeForRefreshToken(ctx, registry, aadToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryCredentials{
+		Username:      acrTokenUsername,
+		Password:      refreshToken,
+		IdentityToken: refreshToken,
+	}, nil
+}
+
+// getWorkloadIdentityToken exchanges the federated service account token for an AAD token
+func (ak *ACRKeychain) getWorkloadIdentityToken(ctx context.Context) (string, error) {
+	assertion, err := os.ReadFile(os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read federated token: %w", err)
+	}
+
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = "https://login.microsoftonline.com/"
+	}
+	tokenURL := strings.TrimSuffix(authorityHost, "/") + "/" + os.Getenv("AZURE_TENANT_ID") + "/oauth2/v2.0/token"
+
+	form := url.Values{}
+	form.Set("client_id", os.Getenv("AZURE_CLIENT_ID"))
+	form.Set("scope", "https://management.azure.com/.default")
+	form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	form.Set("grant_type", "client_credentials")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSONRequest(ak.httpClient, req, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to get workload identity token: %w", err)
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+// getManagedIdentityToken requests an AAD token from the instance metadata service
+func (ak *ACRKeychain) getManagedIdentityToken(ctx context.Context) (string, error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", "https://management.azure.com/")
+	if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+		query.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ak.imdsURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSONRequest(ak.httpClient, req, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to get managed identity token: %w", err)
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+// exchangeForRefreshToken trades an AAD access token for an ACR refresh token
+func (ak *ACRKeychain) exchangeForRefreshToken(ctx context.Context, registry, aadToken string) (string, error) {
+	endpoint := ak.exchangeEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s/oauth2/exchange", registry)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "access_token")
+	form.Set("service", registry)
+	form.Set("access_token", aadToken)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var exchangeResp struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := doJSONRequest(ak.httpClient, req, &exchangeResp); err != nil {
+		return "", fmt.Errorf("failed to exchange token with %s: %w", registry, err)
+	}
+	if exchangeResp.RefreshToken == "" {
+		return "", fmt.Errorf("%s returned an empty refresh token", registry)
+	}
+
+	return exchangeResp.RefreshToken, nil
+}
+
+// doJSONRequest executes the request and decodes a successful JSON response into out
+func doJSONRequest(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
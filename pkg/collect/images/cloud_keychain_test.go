@@ -0,0 +1,206 @@
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloudRegistryPatterns(t *testing.T) {
+	tests := []struct {
+		registry  string
+		expectECR bool
+		expectGCR bool
+		expectACR bool
+	}{
+		{"123456789012.dkr.ecr.us-east-1.amazonaws.com", true, false, false},
+		{"123456789012.dkr.ecr-fips.us-gov-west-1.amazonaws.com", true, false, false},
+		{"123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", true, false, false},
+		{"public.ecr.aws", false, false, false},
+		{"gcr.io", false, true, false},
+		{"eu.gcr.io", false, true, false},
+		{"us-central1-docker.pkg.dev", false, true, false},
+		{"myregistry.azurecr.io", false, false, true},
+		{"docker.io", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.registry, func(t *testing.T) {
+			if IsECRRegistry(tt.registry) != tt.expectECR {
+				t.Errorf("Expected IsECRRegistry %v for %s", tt.expectECR, tt.registry)
+			}
+			if IsGCRRegistry(tt.registry) != tt.expectGCR {
+				t.Errorf("Expected IsGCRRegistry %v for %s", tt.expectGCR, tt.registry)
+			}
+			if IsACRRegistry(tt.registry) != tt.expectACR {
+				t.Errorf("Expected IsACRRegistry %v for %s", tt.expectACR, tt.registry)
+			}
+		})
+	}
+}
+
+func TestECRKeychain_Resolve(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/collector")
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("projected-sa-token\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Action") != "AssumeRoleWithWebIdentity" {
+			t.Errorf("Expected AssumeRoleWithWebIdentity action, got %s", r.URL.Query().Get("Action"))
+		}
+		if r.URL.Query().Get("WebIdentityToken") != "projected-sa-token" {
+			t.Errorf("Expected projected token, got %s", r.URL.Query().Get("WebIdentityToken"))
+		}
+		w.Write([]byte(`{"AssumeRoleWithWebIdentityResponse":{"AssumeRoleWithWebIdentityResult":{"Credentials":{"AccessKeyId":"ASIATEST","SecretAccessKey":"secret","SessionToken":"session"}}}}`))
+	}))
+	defer sts.Close()
+
+	ecr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ASIATEST/20240102/us-west-2/ecr/aws4_request") {
+			t.Errorf("Unexpected authorization header: %s", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("Expected session token header, got %s", r.Header.Get("X-Amz-Security-Token"))
+		}
+		token := base64.StdEncoding.EncodeToString([]byte("AWS:ecr-password"))
+		w.Write([]byte(`{"authorizationData":[{"authorizationToken":"` + token + `"}]}`))
+	}))
+	defer ecr.Close()
+
+	keychain := NewECRKeychain(http.DefaultClient)
+	keychain.stsEndpoint = sts.URL + "/"
+	keychain.ecrEndpoint = ecr.URL + "/"
+	keychain.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	creds, err := keychain.Resolve(context.Background(), "123456789012.dkr.ecr.us-west-2.amazonaws.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds == nil || creds.Username != "AWS" || creds.Password != "ecr-password" {
+		t.Errorf("Expected AWS/ecr-password credentials, got %v", creds)
+	}
+
+	// Non-ECR registries are skipped
+	creds, err = keychain.Resolve(context.Background(), "docker.io")
+	if err != nil || creds != nil {
+		t.Errorf("Expected no credentials and no error for docker.io, got %v, %v", creds, err)
+	}
+}
+
+func TestECRKeychain_ResolveWithoutCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_ROLE_ARN", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+
+	creds, err := NewECRKeychain(http.DefaultClient).Resolve(context.Background(), "123456789012.dkr.ecr.us-west-2.amazonaws.com")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if creds != nil {
+		t.Errorf("Expected no credentials without AWS configuration, got %v", creds)
+	}
+}
+
+func TestGCRKeychain_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"gcp-access-token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	keychain := NewGCRKeychain(http.DefaultClient)
+	keychain.metadataURL = server.URL
+
+	creds, err := keychain.Resolve(context.Background(), "us-docker.pkg.dev")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds == nil || creds.Username != gcrTokenUsername || creds.Password != "gcp-access-token" {
+		t.Errorf("Expected oauth2accesstoken credentials, got %v", creds)
+	}
+}
+
+func TestACRKeychain_ResolveWithManagedIdentity(t *testing.T) {
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	t.Setenv("AZURE_CLIENT_ID", "client-id")
+
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("client_id") != "client-id" {
+			t.Errorf("Expected client_id to be passed, got %s", r.URL.Query().Get("client_id"))
+		}
+		w.Write([]byte(`{"access_token":"aad-token"}`))
+	}))
+	defer imds.Close()
+
+	exchange := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+			return
+		}
+		if r.Form.Get("access_token") != "aad-token" || r.Form.Get("service") != "myregistry.azurecr.io" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"refresh_token":"acr-refresh-token"}`))
+	}))
+	defer exchange.Close()
+
+	keychain := NewACRKeychain(http.DefaultClient)
+	keychain.imdsURL = imds.URL
+	keychain.exchangeEndpoint = exchange.URL
+
+	creds, err := keychain.Resolve(context.Background(), "myregistry.azurecr.io")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds == nil || creds.Username != acrTokenUsername || creds.Password != "acr-refresh-token" {
+		t.Errorf("Expected ACR refresh token credentials, got %v", creds)
+	}
+}
+
+func TestSignAWSRequest(t *testing.T) {
+	req := httptest.NewRequest("POST", "https://api.ecr.us-east-1.amazonaws.com/", nil)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+
+	creds := &awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	signAWSRequest(req, []byte("{}"), creds, "us-east-1", "ecr", now)
+
+	auth := req.Header.Get("Authorization")
+	if !strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date") {
+		t.Errorf("Expected signed headers to be sorted, got %s", auth)
+	}
+	if req.Header.Get("X-Amz-Date") != "20240102T030405Z" {
+		t.Errorf("Expected X-Amz-Date 20240102T030405Z, got %s", req.Header.Get("X-Amz-Date"))
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "" {
+		t.Errorf("Expected no security token header without a session token")
+	}
+
+	// Signing is deterministic for the same input
+	again := httptest.NewRequest("POST", "https://api.ecr.us-east-1.amazonaws.com/", nil)
+	again.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	signAWSRequest(again, []byte("{}"), creds, "us-east-1", "ecr", now)
+	if again.Header.Get("Authorization") != auth {
+		t.Errorf("Expected deterministic signature")
+	}
+}
@@ -2,27 +2,70 @@ package images
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	// DefaultDigestBatchConcurrency is the number of concurrent HEAD requests ResolveBatch sends to one registry
+	DefaultDigestBatchConcurrency = 8
+	// DefaultNegativeCacheTTL is how long a failed resolution is remembered, capped at the resolver's cache TTL
+	DefaultNegativeCacheTTL = 5 * time.Minute
+)
+
 // DefaultDigestResolver implements DigestResolver interface
+// It is safe for concurrent use; failed resolutions are cached for a shorter TTL than digests
 type DefaultDigestResolver struct {
-	registryClient RegistryClient
-	cache          map[string]CacheEntry
-	cacheTTL       time.Duration
+	registryClient   RegistryClient
+	cache            map[string]CacheEntry
+	cacheTTL         time.Duration
+	failures         map[string]failedResolution
+	negativeCacheTTL time.Duration
+	batchConcurrency int
+	mu               sync.Mutex // guards cache and failures
+}
+
+// failedResolution is a cached failure to resolve a tag
+type failedResolution struct {
+	err       error
+	timestamp time.Time
 }
 
 // NewDigestResolver creates a new digest resolver
 func NewDigestResolver(registryClient RegistryClient, cacheTTL time.Duration) *DefaultDigestResolver {
+	negativeCacheTTL := DefaultNegativeCacheTTL
+	if cacheTTL < negativeCacheTTL {
+		negativeCacheTTL = cacheTTL
+	}
 	return &DefaultDigestResolver{
-		registryClient: registryClient,
-		cache:          make(map[string]CacheEntry),
-		cacheTTL:       cacheTTL,
+		registryClient:   registryClient,
+		cache:            make(map[string]CacheEntry),
+		cacheTTL:         cacheTTL,
+		failures:         make(map[string]failedResolution),
+		negativeCacheTTL: negativeCacheTTL,
+		batchConcurrency: DefaultDigestBatchConcurrency,
 	}
 }
 
+// SetNegativeCacheTTL sets how long failed resolutions are cached, 0 disables negative caching
+func (dr *DefaultDigestResolver) SetNegativeCacheTTL(ttl time.Duration) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.negativeCacheTTL = ttl
+}
+
+// SetBatchConcurrency sets the number of concurrent HEAD requests ResolveBatch sends to one registry
+func (dr *DefaultDigestResolver) SetBatchConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	dr.batchConcurrency = concurrency
+}
+
 // ResolveTagToDigest resolves an image tag to its digest
 func (dr *DefaultDigestResolver) ResolveTagToDigest(ctx context.Context, imageRef string) (string, error) {
 	// Check if already a digest
@@ -35,10 +78,14 @@ func (dr *DefaultDigestResolver) ResolveTagToDigest(ctx context.Context, imageRe
 	if cachedDigest, found := dr.getCachedDigest(imageRef); found {
 		return cachedDigest, nil
 	}
+	if err, found := dr.getCachedFailure(imageRef); found {
+		return "", fmt.Errorf("failed to get digest from registry (cached): %w", err)
+	}
 
 	// Get digest from registry
 	digest, err := dr.getDigestFromRegistry(ctx, imageRef)
 	if err != nil {
+		dr.cacheFailure(imageRef, err)
 		return "", fmt.Errorf("failed to get digest from registry: %w", err)
 	}
 
@@ -53,6 +100,8 @@ func (dr *DefaultDigestResolver) getDigestFromRegistry(ctx context.Context, imag
 }
 
 func (dr *DefaultDigestResolver) getCachedDigest(imageRef string) (string, bool) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
 	entry, exists := dr.cache[imageRef]
 	if !exists {
 		return "", false
@@ -73,12 +122,172 @@ func (dr *DefaultDigestResolver) getCachedDigest(imageRef string) (string, bool)
 }
 
 func (dr *DefaultDigestResolver) cacheDigest(imageRef, digest string) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	delete(dr.failures, imageRef)
 	dr.cache[imageRef] = CacheEntry{
 		Facts: &ImageFacts{Digest: digest},
 		Timestamp: time.Now(),
 	}
 }
 
+func (dr *DefaultDigestResolver) getCachedFailure(imageRef string) (error, bool) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	failure, exists := dr.failures[imageRef]
+	if !exists {
+		return nil, false
+	}
+	if time.Since(failure.timestamp) > dr.negativeCacheTTL {
+		delete(dr.failures, imageRef)
+		return nil, false
+	}
+	return failure.err, true
+}
+
+// cacheFailure remembers a failed resolution; cancellations and timeouts say nothing about the image, so
+// they are not cached
+func (dr *DefaultDigestResolver) cacheFailure(imageRef string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	if dr.negativeCacheTTL <= 0 {
+		return
+	}
+	dr.failures[imageRef] = failedResolution{err: err, timestamp: time.Now()}
+}
+
+// DigestBatchResult is the outcome of ResolveBatch
+type DigestBatchResult struct {
+	Digests map[string]string // image ref -> digest
+	Errors  map[string]error  // image ref -> resolution error
+	Stats   DigestBatchStats
+}
+
+// DigestBatchStats counts how the refs of a batch were resolved
+type DigestBatchStats struct {
+	Images            int           `json:"images"`
+	Registries        int           `json:"registries"`
+	Lookups           int           `json:"lookups"`           // HEAD requests sent to registries
+	CacheHits         int           `json:"cacheHits"`         // Refs already digests or in the cache
+	NegativeCacheHits int           `json:"negativeCacheHits"` // Refs that failed recently and were not looked up again
+	Duration          time.Duration `json:"duration"`
+}
+
+// ResolveBatch resolves many tags to digests at once, grouped by registry
+// Registries are resolved in parallel. Within a registry, the first lookup runs alone so the auth token it obtains
+// is shared by the rest, which then run as up to SetBatchConcurrency concurrent HEAD requests
+// Refs already cached, or that failed within the negative cache TTL, are not looked up again
+func (dr *DefaultDigestResolver) ResolveBatch(ctx context.Context, imageRefs []string) *DigestBatchResult {
+	startTime := time.Now()
+	result := &DigestBatchResult{
+		Digests: make(map[string]string),
+		Errors:  make(map[string]error),
+	}
+
+	byRegistry := make(map[string][]string)
+	for _, imageRef := range imageRefs {
+		if _, seen := result.Digests[imageRef]; seen {
+			continue
+		}
+		if _, seen := result.Errors[imageRef]; seen {
+			continue
+		}
+		result.Stats.Images++
+
+		if strings.Contains(imageRef, "@sha256:") {
+			result.Digests[imageRef] = strings.SplitN(imageRef, "@", 2)[1]
+			result.Stats.CacheHits++
+			continue
+		}
+		if digest, found := dr.getCachedDigest(imageRef); found {
+			result.Digests[imageRef] = digest
+			result.Stats.CacheHits++
+			continue
+		}
+		if err, found := dr.getCachedFailure(imageRef); found {
+			result.Errors[imageRef] = fmt.Errorf("failed to get digest from registry (cached): %w", err)
+			result.Stats.NegativeCacheHits++
+			continue
+		}
+
+		registry := GetRegistryFromImageRef(imageRef)
+		// Mark the ref as seen so a duplicate is not queued twice
+		result.Digests[imageRef] = ""
+		byRegistry[registry] = append(byRegistry[registry], imageRef)
+	}
+	result.Stats.Registries = len(byRegistry)
+
+	registries := make([]string, 0, len(byRegistry))
+	for registry := range byRegistry {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	var mu sync.Mutex
+	record := func(imageRef, digest string, err error, lookedUp bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if lookedUp {
+			result.Stats.Lookups++
+		}
+		if err != nil {
+			delete(result.Digests, imageRef)
+			result.Errors[imageRef] = fmt.Errorf("failed to get digest from registry: %w", err)
+			return
+		}
+		result.Digests[imageRef] = digest
+	}
+
+	var wg sync.WaitGroup
+	for _, registry := range registries {
+		wg.Add(1)
+		go func(refs []string) {
+			defer wg.Done()
+			dr.resolveRegistryBatch(ctx, refs, record)
+		}(byRegistry[registry])
+	}
+	wg.Wait()
+
+	result.Stats.Duration = time.Since(startTime)
+	return result
+}
+
+// resolveRegistryBatch resolves the refs of one registry, the first alone and the rest concurrently
+func (dr *DefaultDigestResolver) resolveRegistryBatch(ctx context.Context, refs []string, record func(imageRef, digest string, err error, lookedUp bool)) {
+	resolve := func(imageRef string) {
+		digest, err := dr.getDigestFromRegistry(ctx, imageRef)
+		if err != nil {
+			dr.cacheFailure(imageRef, err)
+		} else {
+			dr.cacheDigest(imageRef, digest)
+		}
+		record(imageRef, digest, err, true)
+	}
+
+	resolve(refs[0])
+
+	slots := make(chan struct{}, dr.batchConcurrency)
+	var wg sync.WaitGroup
+	for _, imageRef := range refs[1:] {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			record(imageRef, "", ctx.Err(), false)
+			continue
+		}
+		wg.Add(1)
+		go func(imageRef string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			resolve(imageRef)
+		}(imageRef)
+	}
+	wg.Wait()
+}
+
 // ResolvePlatformDigest resolves a multi-platform image to a platform-specific digest
 func (dr *DefaultDigestResolver) ResolvePlatformDigest(ctx context.Context, imageRef string, platform Platform) (string, error) {
 	// For now, use regular digest resolution (platform-specific logic would need more complex implementation)
@@ -151,6 +151,138 @@ func TestDefaultDigestResolver_CacheManagement(t *testing.T) {
 	t.Skip("Skipping cache management test - cache stats methods not implemented in current version")
 }
 
+// batchRegistryClient records the concurrency of digest lookups per registry
+type batchRegistryClient struct {
+	*MockRegistryClient
+	latency      time.Duration
+	lookups      int
+	inFlight     map[string]int
+	maxInFlight  map[string]int
+	authed       map[string]bool
+	unauthorized int // Lookups started while another lookup of the registry was still authenticating
+	lock         sync.Mutex
+}
+
+func newBatchRegistryClient(digests map[string]string) *batchRegistryClient {
+	return &batchRegistryClient{
+		MockRegistryClient: &MockRegistryClient{digests: digests},
+		latency:            5 * time.Millisecond,
+		inFlight:           make(map[string]int),
+		maxInFlight:        make(map[string]int),
+		authed:             make(map[string]bool),
+	}
+}
+
+func (c *batchRegistryClient) ResolveDigest(ctx context.Context, imageRef string) (string, error) {
+	registry := GetRegistryFromImageRef(imageRef)
+	c.lock.Lock()
+	c.lookups++
+	c.inFlight[registry]++
+	if c.inFlight[registry] > c.maxInFlight[registry] {
+		c.maxInFlight[registry] = c.inFlight[registry]
+	}
+	if !c.authed[registry] && c.inFlight[registry] > 1 {
+		c.unauthorized++
+	}
+	c.lock.Unlock()
+
+	time.Sleep(c.latency)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.inFlight[registry]--
+	c.authed[registry] = true
+	if digest, exists := c.digests[imageRef]; exists {
+		return digest, nil
+	}
+	return "", fmt.Errorf("digest not found for %s", imageRef)
+}
+
+func TestDefaultDigestResolver_ResolveBatch(t *testing.T) {
+	digests := make(map[string]string)
+	var refs []string
+	for i := 0; i < 20; i++ {
+		for _, registry := range []string{"ghcr.io/acme", "quay.io/acme"} {
+			ref := fmt.Sprintf("%s/app-%d:v1", registry, i)
+			digests[ref] = fmt.Sprintf("sha256:%d", i)
+			refs = append(refs, ref)
+		}
+	}
+	refs = append(refs, "ghcr.io/acme/missing:v1", "ghcr.io/acme/app-0:v1", "nginx@sha256:abc")
+
+	client := newBatchRegistryClient(digests)
+	resolver := NewDigestResolver(client, time.Hour)
+	resolver.SetBatchConcurrency(4)
+
+	result := resolver.ResolveBatch(context.Background(), refs)
+
+	if len(result.Digests) != 41 || len(result.Errors) != 1 {
+		t.Fatalf("Expected 41 digests and 1 error, got %d and %v", len(result.Digests), result.Errors)
+	}
+	if result.Digests["quay.io/acme/app-7:v1"] != "sha256:7" || result.Digests["nginx@sha256:abc"] != "sha256:abc" {
+		t.Errorf("Expected resolved digests, got %v", result.Digests)
+	}
+	if result.Stats.Images != 42 || result.Stats.Registries != 2 || result.Stats.Lookups != 41 || result.Stats.CacheHits != 1 {
+		t.Errorf("Expected 42 images over 2 registries with 41 lookups, got %+v", result.Stats)
+	}
+	for registry, inFlight := range client.maxInFlight {
+		if inFlight > 4 {
+			t.Errorf("Expected at most 4 concurrent lookups against %s, got %d", registry, inFlight)
+		}
+		if inFlight < 2 {
+			t.Errorf("Expected concurrent lookups against %s, got %d", registry, inFlight)
+		}
+	}
+	if client.unauthorized != 0 {
+		t.Errorf("Expected the first lookup of each registry to run alone, got %d lookups alongside it", client.unauthorized)
+	}
+
+	// A second batch is served from the positive and negative caches
+	again := resolver.ResolveBatch(context.Background(), refs)
+	if client.lookups != 41 || again.Stats.CacheHits != 41 || again.Stats.NegativeCacheHits != 1 {
+		t.Errorf("Expected the second batch to be served from cache, got %d lookups and %+v", client.lookups, again.Stats)
+	}
+	if digest, err := resolver.ResolveTagToDigest(context.Background(), "ghcr.io/acme/app-3:v1"); err != nil || digest != "sha256:3" {
+		t.Errorf("Expected the batch to fill the cache, got %s, %v", digest, err)
+	}
+}
+
+func TestDefaultDigestResolver_NegativeCache(t *testing.T) {
+	client := newBatchRegistryClient(map[string]string{})
+	client.latency = 0
+	resolver := NewDigestResolver(client, time.Hour)
+	resolver.SetNegativeCacheTTL(50 * time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.ResolveTagToDigest(ctx, "ghcr.io/acme/missing:v1"); err == nil {
+			t.Fatalf("Expected an error for a missing image")
+		}
+	}
+	if client.lookups != 1 {
+		t.Errorf("Expected the failure to be cached, got %d lookups", client.lookups)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	client.digests["ghcr.io/acme/missing:v1"] = "sha256:pushed"
+	if digest, err := resolver.ResolveTagToDigest(ctx, "ghcr.io/acme/missing:v1"); err != nil || digest != "sha256:pushed" {
+		t.Errorf("Expected the failure to expire, got %s, %v", digest, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	resolver.cacheFailure("ghcr.io/acme/other:v1", canceled.Err())
+	if _, found := resolver.getCachedFailure("ghcr.io/acme/other:v1"); found {
+		t.Errorf("Expected cancellations not to be cached")
+	}
+
+	resolver.SetNegativeCacheTTL(0)
+	resolver.cacheFailure("ghcr.io/acme/other:v1", fmt.Errorf("not found"))
+	if _, found := resolver.getCachedFailure("ghcr.io/acme/other:v1"); found {
+		t.Errorf("Expected negative caching to be disabled")
+	}
+}
+
 // Mock client implementation
 func (m *MockRegistryClient) ResolveDigest(ctx context.Context, imageRef string) (string, error) {
 	m.callCount++
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -25,11 +26,28 @@ const (
 	FallbackCached
 )
 
+// String returns the name a fallback is recorded under, e.g. best-effort
+func (m FallbackMode) String() string {
+	switch m {
+	case FallbackNone:
+		return "none"
+	case FallbackPartial:
+		return "partial"
+	case FallbackBestEffort:
+		return "best-effort"
+	case FallbackCached:
+		return "cached"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(m))
+	}
+}
+
 // ErrorCollector collects and categorizes errors during image collection
 type ErrorCollector struct {
 	errors    []CollectionError
 	stats     ErrorStatistics
 	threshold ErrorThreshold
+	mu        sync.Mutex
 }
 
 // ErrorStatistics tracks error patterns and frequencies
@@ -112,7 +130,7 @@ func (eh *ErrorHandler) handleRetry(ctx context.Context, imageRef string, origin
 		fmt.Printf("Retrying image collection for %s (attempt %d/%d)\n", imageRef, attempt, eh.retryCount)
 		
 		// For now, just track that we attempted retry
-		eh.errorCollector.stats.TotalErrors++
+		eh.errorCollector.recordRetry(imageRef)
 		
 		// Exponential backoff
 		delay *= 2
@@ -125,7 +143,16 @@ func (eh *ErrorHandler) handleRetry(ctx context.Context, imageRef string, origin
 }
 
 // handleFallback applies appropriate fallback strategy
+// Fallback facts that are returned are recorded against the image's error
 func (eh *ErrorHandler) handleFallback(ctx context.Context, imageRef string, collectionErr CollectionError) (*ImageFacts, error) {
+	facts, err := eh.applyFallback(ctx, imageRef, collectionErr)
+	if err == nil {
+		eh.errorCollector.recordFallback(imageRef, eh.fallbackMode)
+	}
+	return facts, err
+}
+
+func (eh *ErrorHandler) applyFallback(ctx context.Context, imageRef string, collectionErr CollectionError) (*ImageFacts, error) {
 	switch eh.fallbackMode {
 	case FallbackNone:
 		return nil, fmt.Errorf("image collection failed: %s", collectionErr.Message)
@@ -296,11 +323,19 @@ func (eh *ErrorHandler) inferMetadataFromReference(facts *ImageFacts, imageRef s
 
 // RecordError records an error in the error collector
 func (ec *ErrorCollector) RecordError(err CollectionError) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if err.Registry == "" {
+		err.Registry = GetRegistryFromImageRef(err.ImageRef)
+	}
+	if err.Time.IsZero() {
+		err.Time = time.Now()
+	}
 	ec.errors = append(ec.errors, err)
 	ec.stats.TotalErrors++
 	ec.stats.ErrorsByType[err.Type]++
-	ec.stats.ErrorsByRegistry[GetRegistryFromImageRef(err.ImageRef)]++
-	ec.stats.LastErrorTime = time.Now()
+	ec.stats.ErrorsByRegistry[err.Registry]++
+	ec.stats.LastErrorTime = err.Time
 	
 	if err.Retryable {
 		ec.stats.RetryableErrors++
@@ -309,8 +344,38 @@ func (ec *ErrorCollector) RecordError(err CollectionError) {
 	}
 }
 
+// recordRetry counts a retry against the latest error recorded for the image
+func (ec *ErrorCollector) recordRetry(imageRef string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if i := ec.latestError(imageRef); i >= 0 {
+		ec.errors[i].Retries++
+	}
+}
+
+// recordFallback marks the latest error recorded for the image as answered with fallback facts
+func (ec *ErrorCollector) recordFallback(imageRef string, mode FallbackMode) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if i := ec.latestError(imageRef); i >= 0 {
+		ec.errors[i].Fallback = mode.String()
+	}
+}
+
+func (ec *ErrorCollector) latestError(imageRef string) int {
+	for i := len(ec.errors) - 1; i >= 0; i-- {
+		if ec.errors[i].ImageRef == imageRef {
+			return i
+		}
+	}
+	return -1
+}
+
 // ShouldApplyFallback determines if fallback should be applied based on error patterns
 func (ec *ErrorCollector) ShouldApplyFallback() bool {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
 	// If no errors, no fallback needed
 	if ec.stats.TotalErrors == 0 {
 		return false
@@ -326,6 +391,8 @@ func (ec *ErrorCollector) ShouldApplyFallback() bool {
 
 // GetErrorSummary returns a summary of collected errors
 func (ec *ErrorCollector) GetErrorSummary() ErrorStatistics {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
 	return ec.stats
 }
 
@@ -370,12 +437,20 @@ func (ec *ErrorCollector) GetThreshold() ErrorThreshold {
 	return ec.threshold
 }
 
+// GetErrorCollector returns the collector the handler records classified errors in
+func (eh *ErrorHandler) GetErrorCollector() *ErrorCollector {
+	return eh.errorCollector
+}
+
 // ResilientImageCollector wraps a registry client with error handling
 type ResilientImageCollector struct {
 	client       RegistryClient
 	errorHandler *ErrorHandler
 	cache        map[string]*CacheEntry
 	cacheTTL     time.Duration
+	cacheMu      sync.Mutex
+	runtimeIndex ImageRuntimeIndex
+	factsStream  *FactsStream // When set, facts are appended here instead of kept in the result
 }
 
 // NewResilientImageCollector creates a resilient image collector
@@ -389,6 +464,11 @@ func NewResilientImageCollector(client RegistryClient, errorHandler *ErrorHandle
 }
 
 // CollectImageFacts collects image facts with error handling and fallback
+// Lookups run in parallel up to options.MaxConcurrency, with per-registry overrides from options.RegistryLimits
+// The enrichers named in options.Enrichers run on each image's facts, then labels are filtered by
+// options.LabelFilter, before facts are kept, cached or streamed
+// With a facts stream set, images it already holds are skipped, each image's facts are appended to it as the
+// lookup completes rather than kept in result.Facts, and lookups are started in chunks
 func (ric *ResilientImageCollector) CollectImageFacts(ctx context.Context, imageRefs []string, options ImageCollectionOptions) (*ImageCollectionResult, error) {
 	startTime := time.Now()
 	result := &ImageCollectionResult{
@@ -400,11 +480,32 @@ func (ric *ResilientImageCollector) CollectImageFacts(ctx context.Context, image
 	// Initialize statistics
 	result.Statistics.TotalImages = len(imageRefs)
 
+	enrichers, err := ResolveEnrichers(options.Enrichers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrichers: %w", err)
+	}
+	limiter := newRegistryLimiter(options)
+	labelFilter := options.labelFilter()
+	registries := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// The cache would hold every image's facts in memory, so it is bypassed while streaming
+	cacheEnabled := options.CacheEnabled && ric.factsStream == nil
+
+	started := 0
 	for _, imageRef := range imageRefs {
+		if ric.factsStream != nil && ric.factsStream.Completed(imageRef) {
+			result.Statistics.SuccessfulImages++
+			result.Statistics.ResumedImages++
+			continue
+		}
+
 		// Check cache first if enabled
-		if options.CacheEnabled {
+		if cacheEnabled {
 			if cachedFacts, found := ric.getCachedFacts(imageRef); found {
 				result.Facts[imageRef] = cachedFacts
+				registries[cachedFacts.Registry] = true
 				result.Statistics.SuccessfulImages++
 				result.Statistics.CacheHits++
 				continue
@@ -412,50 +513,115 @@ func (ric *ResilientImageCollector) CollectImageFacts(ctx context.Context, image
 			result.Statistics.CacheMisses++
 		}
 
-		// Try to collect facts
-		facts, err := ric.client.GetImageFacts(ctx, imageRef)
-		if err != nil {
-			// Check if this is a non-retryable error that should just fail
-			collectionErr := ric.errorHandler.classifyError(imageRef, err)
-			
-			if !collectionErr.Retryable {
-				// For non-retryable errors (like image not found), record as failure
-				result.Errors[imageRef] = err
-				result.Statistics.FailedImages++
-				continue
+		if ric.factsStream != nil && started > 0 && started%factsStreamChunkSize == 0 {
+			wg.Wait()
+		}
+		started++
+
+		wg.Add(1)
+		go func(imageRef string) {
+			defer wg.Done()
+
+			facts, err := ric.collectImage(ctx, limiter, imageRef)
+			if err == nil {
+				applyEnrichers(ctx, enrichers, imageRef, facts)
+				labelFilter.Apply(facts)
+			}
+			if err == nil && ric.factsStream != nil {
+				err = ric.factsStream.Write(imageRef, facts)
 			}
-			
-			// For retryable errors, try error handling and fallback
-			facts, err = ric.errorHandler.HandleError(ctx, imageRef, err)
+
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
 				result.Errors[imageRef] = err
 				result.Statistics.FailedImages++
-				continue
+				return
 			}
-		}
 
-		// Success - store facts and cache if enabled
-		result.Facts[imageRef] = facts
-		result.Statistics.SuccessfulImages++
-		
-		if options.CacheEnabled {
-			ric.cacheFacts(imageRef, facts)
-		}
+			// Success - store facts and cache if enabled
+			registries[facts.Registry] = true
+			result.Statistics.SuccessfulImages++
+			if ric.factsStream != nil {
+				return
+			}
+			result.Facts[imageRef] = facts
+			if cacheEnabled {
+				ric.cacheFacts(imageRef, facts)
+			}
+		}(imageRef)
 	}
+	wg.Wait()
 
 	result.Duration = time.Since(startTime)
-	
-	// Count unique registries accessed
-	registries := make(map[string]bool)
-	for _, facts := range result.Facts {
-		registries[facts.Registry] = true
+
+	// Count unique registries accessed, including those of images resumed from the stream
+	if ric.factsStream != nil {
+		result.Statistics.RegistriesAccessed = ric.factsStream.Registries()
+	} else {
+		result.Statistics.RegistriesAccessed = len(registries)
 	}
-	result.Statistics.RegistriesAccessed = len(registries)
 
 	return result, nil
 }
 
+// collectImage looks up one image once a slot for its registry is free, under the registry's timeout
+func (ric *ResilientImageCollector) collectImage(ctx context.Context, limiter *registryLimiter, imageRef string) (*ImageFacts, error) {
+	registry := GetRegistryFromImageRef(imageRef)
+	release, err := limiter.Acquire(ctx, registry)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for %s: %w", registry, err)
+	}
+	defer release()
+
+	lookupCtx := ctx
+	if timeout := limiter.Timeout(registry); timeout > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	facts, err := ric.client.GetImageFacts(lookupCtx, imageRef)
+	if err != nil {
+		return ric.handleCollectionError(ctx, imageRef, err)
+	}
+	return facts, nil
+}
+
+// handleCollectionError applies retry and fallback handling to a failed registry lookup
+// Images seen running in the cluster always get fallback facts, enriched from pod and node status
+func (ric *ResilientImageCollector) handleCollectionError(ctx context.Context, imageRef string, err error) (*ImageFacts, error) {
+	collectionErr := ric.errorHandler.classifyError(imageRef, err)
+	runtimeInfo := ric.runtimeIndex.Lookup(imageRef)
+	if !collectionErr.Retryable {
+		ric.errorHandler.errorCollector.RecordError(collectionErr) // Retryable errors are recorded by HandleError
+	}
+
+	if collectionErr.Retryable {
+		facts, handleErr := ric.errorHandler.HandleError(ctx, imageRef, err)
+		if handleErr == nil {
+			runtimeInfo.EnrichFacts(facts)
+			return facts, nil
+		}
+		if runtimeInfo == nil {
+			return nil, handleErr
+		}
+	} else if runtimeInfo == nil {
+		// For non-retryable errors (like image not found), record as failure
+		return nil, err
+	}
+
+	facts, fallbackErr := ric.errorHandler.handleFallback(ctx, imageRef, collectionErr)
+	if fallbackErr != nil {
+		return nil, err
+	}
+	runtimeInfo.EnrichFacts(facts)
+	return facts, nil
+}
+
 func (ric *ResilientImageCollector) getCachedFacts(imageRef string) (*ImageFacts, bool) {
+	ric.cacheMu.Lock()
+	defer ric.cacheMu.Unlock()
 	entry, exists := ric.cache[imageRef]
 	if !exists {
 		return nil, false
@@ -471,6 +637,8 @@ func (ric *ResilientImageCollector) getCachedFacts(imageRef string) (*ImageFacts
 }
 
 func (ric *ResilientImageCollector) cacheFacts(imageRef string, facts *ImageFacts) {
+	ric.cacheMu.Lock()
+	defer ric.cacheMu.Unlock()
 	ric.cache[imageRef] = &CacheEntry{
 		Facts:     facts,
 		Timestamp: time.Now(),
@@ -480,6 +648,8 @@ func (ric *ResilientImageCollector) cacheFacts(imageRef string, facts *ImageFact
 
 // CleanupCache removes expired cache entries
 func (ric *ResilientImageCollector) CleanupCache() {
+	ric.cacheMu.Lock()
+	defer ric.cacheMu.Unlock()
 	now := time.Now()
 	for key, entry := range ric.cache {
 		if now.Sub(entry.Timestamp) > entry.TTL {
@@ -488,8 +658,20 @@ func (ric *ResilientImageCollector) CleanupCache() {
 	}
 }
 
+// SetFactsStream sets the stream facts are appended to as each image completes, nil keeps them in the result
+func (ric *ResilientImageCollector) SetFactsStream(stream *FactsStream) {
+	ric.factsStream = stream
+}
+
+// SetRuntimeIndex sets the in-cluster image info used to enrich fallback facts
+func (ric *ResilientImageCollector) SetRuntimeIndex(index ImageRuntimeIndex) {
+	ric.runtimeIndex = index
+}
+
 // GetCacheSize returns the number of cached entries
 func (ric *ResilientImageCollector) GetCacheSize() int {
+	ric.cacheMu.Lock()
+	defer ric.cacheMu.Unlock()
 	return len(ric.cache)
 }
 
@@ -0,0 +1,140 @@
+package images
+
+import (
+	"sort"
+	"time"
+)
+
+// ErrorLedgerFileName is the bundle file, under images/, holding every classified image collection error
+const ErrorLedgerFileName = "errors.json"
+
+// ErrorLedger is the full record of the errors an ErrorCollector classified during a run
+type ErrorLedger struct {
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Errors      []CollectionError   `json:"errors"`
+	Classes     []ErrorClassSummary `json:"classes"` // Most frequent first
+	Summary     ErrorLedgerSummary  `json:"summary"`
+}
+
+// ErrorClassSummary groups the errors of one type and retryability
+type ErrorClassSummary struct {
+	Type            string   `json:"type"`
+	Retryable       bool     `json:"retryable"`
+	Count           int      `json:"count"`
+	Images          int      `json:"images"`
+	Registries      []string `json:"registries"`
+	FallbackApplied int      `json:"fallbackApplied"` // Errors answered with fallback facts
+	Example         string   `json:"example"`         // Message of the first error of the class
+}
+
+// ErrorLedgerSummary provides totals for the error ledger
+type ErrorLedgerSummary struct {
+	TotalErrors       int            `json:"totalErrors"`
+	Images            int            `json:"images"`
+	RetryableErrors   int            `json:"retryableErrors"`
+	UnretryableErrors int            `json:"unretryableErrors"`
+	Retries           int            `json:"retries"`
+	FallbackApplied   int            `json:"fallbackApplied"`
+	ErrorsByType      map[string]int `json:"errorsByType"`
+	ErrorsByRegistry  map[string]int `json:"errorsByRegistry"`
+}
+
+// Ledger returns the errors recorded so far with their classes and totals, nil when none were recorded
+func (ec *ErrorCollector) Ledger() *ErrorLedger {
+	ec.mu.Lock()
+	errors := append([]CollectionError{}, ec.errors...)
+	ec.mu.Unlock()
+	return BuildErrorLedger(errors)
+}
+
+// BuildErrorLedger groups errors into classes by type and retryability, nil when there are no errors
+func BuildErrorLedger(errors []CollectionError) *ErrorLedger {
+	if len(errors) == 0 {
+		return nil
+	}
+
+	ledger := &ErrorLedger{
+		GeneratedAt: time.Now(),
+		Errors:      errors,
+		Summary: ErrorLedgerSummary{
+			ErrorsByType:     make(map[string]int),
+			ErrorsByRegistry: make(map[string]int),
+		},
+	}
+
+	type classKey struct {
+		errorType string
+		retryable bool
+	}
+	classes := make(map[classKey]*ErrorClassSummary)
+	classImages := make(map[classKey]map[string]bool)
+	classRegistries := make(map[classKey]map[string]bool)
+	images := make(map[string]bool)
+	for _, err := range errors {
+		registry := err.Registry
+		if registry == "" {
+			registry = GetRegistryFromImageRef(err.ImageRef)
+		}
+
+		summary := &ledger.Summary
+		summary.TotalErrors++
+		summary.Retries += err.Retries
+		summary.ErrorsByType[err.Type]++
+		summary.ErrorsByRegistry[registry]++
+		if err.Retryable {
+			summary.RetryableErrors++
+		} else {
+			summary.UnretryableErrors++
+		}
+		if err.Fallback != "" {
+			summary.FallbackApplied++
+		}
+		images[err.ImageRef] = true
+
+		key := classKey{errorType: err.Type, retryable: err.Retryable}
+		class, ok := classes[key]
+		if !ok {
+			class = &ErrorClassSummary{Type: err.Type, Retryable: err.Retryable, Example: err.Message}
+			classes[key] = class
+			classImages[key] = make(map[string]bool)
+			classRegistries[key] = make(map[string]bool)
+		}
+		class.Count++
+		if err.Fallback != "" {
+			class.FallbackApplied++
+		}
+		classImages[key][err.ImageRef] = true
+		classRegistries[key][registry] = true
+	}
+	ledger.Summary.Images = len(images)
+
+	for key, class := range classes {
+		class.Images = len(classImages[key])
+		for registry := range classRegistries[key] {
+			class.Registries = append(class.Registries, registry)
+		}
+		sort.Strings(class.Registries)
+		ledger.Classes = append(ledger.Classes, *class)
+	}
+	sort.Slice(ledger.Classes, func(i, j int) bool {
+		if ledger.Classes[i].Count != ledger.Classes[j].Count {
+			return ledger.Classes[i].Count > ledger.Classes[j].Count
+		}
+		if ledger.Classes[i].Type != ledger.Classes[j].Type {
+			return ledger.Classes[i].Type < ledger.Classes[j].Type
+		}
+		return !ledger.Classes[i].Retryable
+	})
+	return ledger
+}
+
+// TopClasses returns up to n of the most frequent error classes
+func (l *ErrorLedger) TopClasses(n int) []ErrorClassSummary {
+	if l == nil {
+		return nil
+	}
+	if len(l.Classes) <= n {
+		return l.Classes
+	}
+	return l.Classes[:n]
+}
@@ -0,0 +1,78 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildErrorLedger(t *testing.T) {
+	if ledger := BuildErrorLedger(nil); ledger != nil {
+		t.Errorf("Expected no ledger without errors, got %+v", ledger)
+	}
+
+	ledger := BuildErrorLedger([]CollectionError{
+		{ImageRef: "ghcr.io/acme/api:v1", Type: "auth", Message: "unauthorized"},
+		{ImageRef: "nginx:1.25", Type: "network", Message: "connection refused", Retryable: true, Retries: 3, Fallback: "best-effort"},
+		{ImageRef: "quay.io/acme/worker:v2", Type: "network", Message: "timeout", Retryable: true, Retries: 3},
+		{ImageRef: "nginx:1.25", Type: "network", Message: "timeout", Retryable: true},
+	})
+
+	summary := ledger.Summary
+	if summary.TotalErrors != 4 || summary.Images != 3 || summary.RetryableErrors != 3 || summary.UnretryableErrors != 1 {
+		t.Errorf("Expected 4 errors on 3 images, 3 retryable, got %+v", summary)
+	}
+	if summary.Retries != 6 || summary.FallbackApplied != 1 {
+		t.Errorf("Expected 6 retries and 1 fallback, got %d and %d", summary.Retries, summary.FallbackApplied)
+	}
+	if summary.ErrorsByRegistry["index.docker.io"] != 2 {
+		t.Errorf("Expected registries to be derived from image refs, got %v", summary.ErrorsByRegistry)
+	}
+
+	if len(ledger.Classes) != 2 {
+		t.Fatalf("Expected 2 classes, got %+v", ledger.Classes)
+	}
+	network := ledger.Classes[0]
+	if network.Type != "network" || network.Count != 3 || network.Images != 2 || network.FallbackApplied != 1 {
+		t.Errorf("Expected the network class first with 3 errors on 2 images, got %+v", network)
+	}
+	if !reflect.DeepEqual(network.Registries, []string{"index.docker.io", "quay.io"}) || network.Example != "connection refused" {
+		t.Errorf("Expected sorted registries and the first message as example, got %+v", network)
+	}
+
+	if top := ledger.TopClasses(1); len(top) != 1 || top[0].Type != "network" {
+		t.Errorf("Expected only the most frequent class, got %+v", top)
+	}
+	if top := ledger.TopClasses(5); len(top) != 2 {
+		t.Errorf("Expected every class when asking for more, got %+v", top)
+	}
+}
+
+func TestErrorCollector_LedgerRecordsRetriesAndFallbacks(t *testing.T) {
+	handler := NewErrorHandler(2, time.Millisecond, FallbackPartial)
+	if ledger := handler.GetErrorCollector().Ledger(); ledger != nil {
+		t.Errorf("Expected no ledger before any error, got %+v", ledger)
+	}
+
+	if _, err := handler.HandleError(context.Background(), "nginx:1.25", fmt.Errorf("connection refused")); err == nil {
+		t.Errorf("Expected the retries to fail")
+	}
+	collectionErr := handler.classifyError("nginx:1.25", fmt.Errorf("connection refused"))
+	if _, err := handler.handleFallback(context.Background(), "nginx:1.25", collectionErr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ledger := handler.GetErrorCollector().Ledger()
+	if ledger == nil || len(ledger.Errors) != 1 {
+		t.Fatalf("Expected 1 recorded error, got %+v", ledger)
+	}
+	recorded := ledger.Errors[0]
+	if recorded.Retries != 2 || recorded.Fallback != "partial" || recorded.Registry != "index.docker.io" || recorded.Time.IsZero() {
+		t.Errorf("Expected 2 retries, a partial fallback, the registry and time, got %+v", recorded)
+	}
+	if stats := handler.GetErrorCollector().GetErrorSummary(); stats.TotalErrors != 1 {
+		t.Errorf("Expected retries not to count as errors, got %d", stats.TotalErrors)
+	}
+}
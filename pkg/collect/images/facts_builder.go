@@ -13,6 +13,8 @@ type DefaultFactsBuilder struct {
 	registryClient   RegistryClient
 	digestResolver   DigestResolver
 	progressReporter ProgressReporter
+	captureHistory   bool
+	baseImageDigests map[string]string
 }
 
 // NewFactsBuilder creates a new facts builder
@@ -28,6 +30,16 @@ func (fb *DefaultFactsBuilder) SetProgressReporter(reporter ProgressReporter) {
 	fb.progressReporter = reporter
 }
 
+// SetCaptureHistory enables keeping config.history and mapping build instructions to layers
+func (fb *DefaultFactsBuilder) SetCaptureHistory(enabled bool) {
+	fb.captureHistory = enabled
+}
+
+// SetBaseImageDigests sets the layer digests identifying known base images, see IdentifyBaseImage
+func (fb *DefaultFactsBuilder) SetBaseImageDigests(digests map[string]string) {
+	fb.baseImageDigests = digests
+}
+
 // BuildFacts creates comprehensive ImageFacts from registry data
 func (fb *DefaultFactsBuilder) BuildFacts(ctx context.Context, imageRef string, manifest *ManifestInfo, config *ImageConfig) (*ImageFacts, error) {
 	registry, repository, tag, err := fb.ExtractImageReference(imageRef)
@@ -80,6 +92,12 @@ func (fb *DefaultFactsBuilder) BuildFacts(ctx context.Context, imageRef string,
 	// Extract configuration information
 	if config != nil {
 		facts.Config = *config
+		var annotations map[string]string
+		if manifest != nil {
+			annotations = manifest.Annotations
+		}
+		applyBaseImage(facts, annotations, fb.baseImageDigests)
+		applyImageHistory(facts, fb.captureHistory)
 		
 		// Extract creation time from environment or labels
 		facts.Created = fb.extractCreationTime(config)
@@ -90,6 +108,8 @@ func (fb *DefaultFactsBuilder) BuildFacts(ctx context.Context, imageRef string,
 				facts.Labels[label] = value
 			}
 		}
+	} else if manifest != nil {
+		applyBaseImage(facts, manifest.Annotations, fb.baseImageDigests)
 	}
 
 	// Add derived metadata
@@ -101,6 +121,28 @@ func (fb *DefaultFactsBuilder) BuildFacts(ctx context.Context, imageRef string,
 	return facts, nil
 }
 
+// applyImageHistory maps config.history onto the layers when capture is enabled and drops it otherwise
+// Entries marked empty_layer (ENV, CMD, LABEL...) produce no layer, the rest match the layers in order
+func applyImageHistory(facts *ImageFacts, capture bool) {
+	if !capture {
+		facts.Config.History = nil
+		return
+	}
+
+	layer := 0
+	for _, entry := range facts.Config.History {
+		if entry.EmptyLayer {
+			continue
+		}
+		if layer >= len(facts.Layers) {
+			break
+		}
+		facts.Layers[layer].CreatedBy = entry.CreatedBy
+		facts.Layers[layer].Created = entry.Created
+		layer++
+	}
+}
+
 // ExtractImageReference extracts components from an image reference string
 func (fb *DefaultFactsBuilder) ExtractImageReference(imageRef string) (registry, repository, tag string, err error) {
 	// Since parseImageReference is not exported, let's implement the parsing here
@@ -321,48 +363,14 @@ func (fb *DefaultFactsBuilder) deduplicateImageRefs(imageRefs []string) []string
 	return unique
 }
 
-// ExtractVulnerabilityInfo extracts vulnerability information from image labels (if available)
+// ExtractVulnerabilityInfo extracts vulnerability information from image labels (if available), see VulnerabilityLabelEnricher
 func (fb *DefaultFactsBuilder) ExtractVulnerabilityInfo(facts *ImageFacts) {
-	// Look for common vulnerability scanning labels
-	vulnPatterns := map[string]string{
-		"security.scan.date":      "last-scan-date",
-		"security.scan.result":    "scan-result",
-		"security.vulnerabilities": "vulnerability-count",
-		"security.severity":       "max-severity",
-	}
-	
-	for labelKey, vulnKey := range vulnPatterns {
-		if value, exists := facts.Labels[labelKey]; exists {
-			facts.Labels["vulnerability."+vulnKey] = value
-		}
-	}
+	extractVulnerabilityInfo(facts)
 }
 
-// ExtractBuildInfo extracts build information from image metadata
+// ExtractBuildInfo extracts build information from image metadata, see BuildInfoEnricher
 func (fb *DefaultFactsBuilder) ExtractBuildInfo(facts *ImageFacts) {
-	// Extract build information from various sources
-	buildInfo := make(map[string]string)
-	
-	// From labels
-	for key, value := range facts.Labels {
-		if strings.Contains(key, "build") || strings.Contains(key, "commit") || 
-		   strings.Contains(key, "version") || strings.Contains(key, "source") {
-			buildInfo[key] = value
-		}
-	}
-	
-	// From environment variables
-	for _, env := range facts.Config.Env {
-		if strings.Contains(env, "BUILD") || strings.Contains(env, "COMMIT") || 
-		   strings.Contains(env, "VERSION") || strings.Contains(env, "GIT") {
-			buildInfo["env."+env] = env
-		}
-	}
-	
-	// Add build info to facts labels
-	for key, value := range buildInfo {
-		facts.Labels["build."+key] = value
-	}
+	extractBuildInfo(facts)
 }
 
 // ValidateImageReference validates that an image reference is well-formed
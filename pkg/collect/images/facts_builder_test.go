@@ -786,3 +786,61 @@ func BenchmarkFactsBuilder_BuildFacts(b *testing.B) {
 		}
 	}
 }
+
+func TestDefaultFactsBuilder_CaptureHistory(t *testing.T) {
+	mockClient := &MockRegistryClient{}
+	builder := NewFactsBuilder(mockClient, NewDigestResolver(mockClient, 5*time.Minute))
+
+	manifest := &ManifestInfo{
+		Config: ManifestConfig{Digest: "sha256:config123"},
+		Layers: []ManifestLayer{
+			{Digest: "sha256:layer1", Size: 100},
+			{Digest: "sha256:layer2", Size: 200},
+		},
+	}
+	newConfig := func() *ImageConfig {
+		return &ImageConfig{
+			History: []HistoryEntry{
+				{Created: "2024-01-01T00:00:00Z", CreatedBy: "/bin/sh -c #(nop) ADD file:abc in /"},
+				{CreatedBy: "/bin/sh -c #(nop) ENV PATH=/usr/bin", EmptyLayer: true},
+				{Created: "2024-01-02T00:00:00Z", CreatedBy: "RUN apt-get install -y curl"},
+				{CreatedBy: "/bin/sh -c #(nop) CMD [\"nginx\"]", EmptyLayer: true},
+			},
+		}
+	}
+
+	t.Run("history disabled", func(t *testing.T) {
+		facts, err := builder.BuildFacts(context.Background(), "nginx:latest", manifest, newConfig())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if facts.Config.History != nil {
+			t.Errorf("Expected history to be dropped, got %d entries", len(facts.Config.History))
+		}
+		if facts.Layers[0].CreatedBy != "" {
+			t.Errorf("Expected no layer provenance, got %s", facts.Layers[0].CreatedBy)
+		}
+	})
+
+	t.Run("history enabled", func(t *testing.T) {
+		builder.SetCaptureHistory(true)
+		defer builder.SetCaptureHistory(false)
+
+		facts, err := builder.BuildFacts(context.Background(), "nginx:latest", manifest, newConfig())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(facts.Config.History) != 4 {
+			t.Errorf("Expected 4 history entries, got %d", len(facts.Config.History))
+		}
+		if facts.Layers[0].CreatedBy != "/bin/sh -c #(nop) ADD file:abc in /" {
+			t.Errorf("Expected first layer from ADD, got %s", facts.Layers[0].CreatedBy)
+		}
+		if facts.Layers[1].CreatedBy != "RUN apt-get install -y curl" {
+			t.Errorf("Expected second layer from RUN, skipping empty layers, got %s", facts.Layers[1].CreatedBy)
+		}
+		if facts.Layers[1].Created != "2024-01-02T00:00:00Z" {
+			t.Errorf("Expected second layer created 2024-01-02T00:00:00Z, got %s", facts.Layers[1].Created)
+		}
+	})
+}
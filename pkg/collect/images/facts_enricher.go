@@ -0,0 +1,200 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in enricher names
+const (
+	EnricherVulnerabilityLabels = "vulnerability-labels"
+	EnricherBuildInfo           = "build-info"
+)
+
+// Enricher adds to the facts of each image after they are built and before labels are filtered and the facts are
+// cached, streamed or serialized
+type Enricher interface {
+	// Name returns the identifier the enricher is registered and enabled under
+	Name() string
+	// Enrich adds to facts in place; a failure is reported as a warning and the image keeps its facts
+	Enrich(ctx context.Context, imageRef string, facts *ImageFacts) error
+}
+
+var (
+	enrichersMu sync.RWMutex
+	enrichers   = map[string]Enricher{
+		EnricherVulnerabilityLabels: NewVulnerabilityLabelEnricher(),
+		EnricherBuildInfo:           NewBuildInfoEnricher(),
+	}
+)
+
+// RegisterEnricher makes an enricher available to ImageCollectionOptions.Enrichers under its name, e.g. one looking
+// up images in an internal catalog by digest
+func RegisterEnricher(enricher Enricher) error {
+	name := enricher.Name()
+	if name == "" {
+		return fmt.Errorf("enricher name cannot be empty")
+	}
+	enrichersMu.Lock()
+	defer enrichersMu.Unlock()
+	if _, exists := enrichers[name]; exists {
+		return fmt.Errorf("enricher %s is already registered", name)
+	}
+	enrichers[name] = enricher
+	return nil
+}
+
+// RegisteredEnrichers returns the names of the built-in and registered enrichers, sorted
+func RegisteredEnrichers() []string {
+	enrichersMu.RLock()
+	defer enrichersMu.RUnlock()
+	names := make([]string, 0, len(enrichers))
+	for name := range enrichers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveEnrichers returns the registered enrichers with the given names, in order
+func ResolveEnrichers(names []string) ([]Enricher, error) {
+	enrichersMu.RLock()
+	defer enrichersMu.RUnlock()
+	resolved := make([]Enricher, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		enricher, exists := enrichers[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown enricher %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("enricher %s is listed more than once", name)
+		}
+		seen[name] = true
+		resolved = append(resolved, enricher)
+	}
+	return resolved, nil
+}
+
+// ValidateEnrichers checks that every enricher name is registered and listed once
+func ValidateEnrichers(names []string) error {
+	_, err := ResolveEnrichers(names)
+	return err
+}
+
+// applyEnrichers runs each enricher on the facts of one image, warning about the ones that fail
+func applyEnrichers(ctx context.Context, enrichers []Enricher, imageRef string, facts *ImageFacts) {
+	if len(enrichers) == 0 {
+		return
+	}
+	if facts.Labels == nil {
+		facts.Labels = make(map[string]string)
+	}
+	for _, enricher := range enrichers {
+		if err := enricher.Enrich(ctx, imageRef, facts); err != nil {
+			fmt.Printf("Warning: enricher %s failed for %s: %v\n", enricher.Name(), imageRef, err)
+		}
+	}
+}
+
+// funcEnricher adapts a function to the Enricher interface
+type funcEnricher struct {
+	name string
+	fn   func(ctx context.Context, imageRef string, facts *ImageFacts) error
+}
+
+// NewEnricherFunc creates an enricher from a function, for enrichers that need no state of their own
+func NewEnricherFunc(name string, fn func(ctx context.Context, imageRef string, facts *ImageFacts) error) Enricher {
+	return &funcEnricher{name: name, fn: fn}
+}
+
+// Name returns the enricher name
+func (fe *funcEnricher) Name() string {
+	return fe.name
+}
+
+// Enrich calls the function
+func (fe *funcEnricher) Enrich(ctx context.Context, imageRef string, facts *ImageFacts) error {
+	return fe.fn(ctx, imageRef, facts)
+}
+
+// vulnerabilityLabels maps common vulnerability scanning labels to the vulnerability.* labels they are copied to
+var vulnerabilityLabels = map[string]string{
+	"security.scan.date":       "last-scan-date",
+	"security.scan.result":     "scan-result",
+	"security.vulnerabilities": "vulnerability-count",
+	"security.severity":        "max-severity",
+}
+
+// VulnerabilityLabelEnricher copies the labels of vulnerability scanners to vulnerability.* labels
+type VulnerabilityLabelEnricher struct{}
+
+// NewVulnerabilityLabelEnricher creates a new VulnerabilityLabelEnricher
+func NewVulnerabilityLabelEnricher() *VulnerabilityLabelEnricher {
+	return &VulnerabilityLabelEnricher{}
+}
+
+// Name returns the enricher name
+func (ve *VulnerabilityLabelEnricher) Name() string {
+	return EnricherVulnerabilityLabels
+}
+
+// Enrich adds the vulnerability.* labels
+func (ve *VulnerabilityLabelEnricher) Enrich(ctx context.Context, imageRef string, facts *ImageFacts) error {
+	extractVulnerabilityInfo(facts)
+	return nil
+}
+
+// BuildInfoEnricher copies build, commit, version and source labels and env vars to build.* labels
+type BuildInfoEnricher struct{}
+
+// NewBuildInfoEnricher creates a new BuildInfoEnricher
+func NewBuildInfoEnricher() *BuildInfoEnricher {
+	return &BuildInfoEnricher{}
+}
+
+// Name returns the enricher name
+func (be *BuildInfoEnricher) Name() string {
+	return EnricherBuildInfo
+}
+
+// Enrich adds the build.* labels
+func (be *BuildInfoEnricher) Enrich(ctx context.Context, imageRef string, facts *ImageFacts) error {
+	extractBuildInfo(facts)
+	return nil
+}
+
+func extractVulnerabilityInfo(facts *ImageFacts) {
+	for labelKey, vulnKey := range vulnerabilityLabels {
+		if value, exists := facts.Labels[labelKey]; exists {
+			facts.Labels["vulnerability."+vulnKey] = value
+		}
+	}
+}
+
+func extractBuildInfo(facts *ImageFacts) {
+	buildInfo := make(map[string]string)
+
+	// From labels
+	for key, value := range facts.Labels {
+		if strings.Contains(key, "build") || strings.Contains(key, "commit") ||
+			strings.Contains(key, "version") || strings.Contains(key, "source") {
+			buildInfo[key] = value
+		}
+	}
+
+	// From environment variables
+	for _, env := range facts.Config.Env {
+		if strings.Contains(env, "BUILD") || strings.Contains(env, "COMMIT") ||
+			strings.Contains(env, "VERSION") || strings.Contains(env, "GIT") {
+			buildInfo["env."+env] = env
+		}
+	}
+
+	for key, value := range buildInfo {
+		facts.Labels["build."+key] = value
+	}
+}
@@ -0,0 +1,160 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRegisterEnricher(t *testing.T) {
+	catalog := NewEnricherFunc("test-registry-catalog", func(ctx context.Context, imageRef string, facts *ImageFacts) error {
+		return nil
+	})
+	if err := RegisterEnricher(catalog); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		enricher Enricher
+	}{
+		{name: "duplicate name", enricher: catalog},
+		{name: "built-in name", enricher: NewEnricherFunc(EnricherBuildInfo, nil)},
+		{name: "empty name", enricher: NewEnricherFunc("", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterEnricher(tt.enricher); err == nil {
+				t.Errorf("Expected error but got none")
+			}
+		})
+	}
+
+	registered := RegisteredEnrichers()
+	for _, name := range []string{EnricherBuildInfo, EnricherVulnerabilityLabels, "test-registry-catalog"} {
+		found := false
+		for _, r := range registered {
+			found = found || r == name
+		}
+		if !found {
+			t.Errorf("Expected %s among the registered enrichers, got %v", name, registered)
+		}
+	}
+}
+
+func TestResolveEnrichers(t *testing.T) {
+	tests := []struct {
+		name        string
+		names       []string
+		expected    []string
+		expectError bool
+	}{
+		{name: "none", names: nil, expected: []string{}},
+		{name: "in order", names: []string{EnricherBuildInfo, EnricherVulnerabilityLabels}, expected: []string{EnricherBuildInfo, EnricherVulnerabilityLabels}},
+		{name: "unknown", names: []string{"catalog"}, expectError: true},
+		{name: "listed twice", names: []string{EnricherBuildInfo, EnricherBuildInfo}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enrichers, err := ResolveEnrichers(tt.names)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			names := []string{}
+			for _, enricher := range enrichers {
+				names = append(names, enricher.Name())
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestApplyEnrichers(t *testing.T) {
+	var calls []string
+	failing := NewEnricherFunc("failing", func(ctx context.Context, imageRef string, facts *ImageFacts) error {
+		calls = append(calls, "failing")
+		return fmt.Errorf("catalog unavailable")
+	})
+	owner := NewEnricherFunc("owner", func(ctx context.Context, imageRef string, facts *ImageFacts) error {
+		calls = append(calls, "owner")
+		facts.Labels["catalog.owner"] = "payments"
+		return nil
+	})
+
+	// Fallback facts may have no labels yet
+	facts := &ImageFacts{Repository: "library/nginx"}
+	applyEnrichers(context.Background(), []Enricher{failing, owner}, "nginx:1.25", facts)
+	if !reflect.DeepEqual(calls, []string{"failing", "owner"}) {
+		t.Errorf("Expected every enricher to run in order after a failure, got %v", calls)
+	}
+	if facts.Labels["catalog.owner"] != "payments" {
+		t.Errorf("Expected the owner label, got %v", facts.Labels)
+	}
+}
+
+func TestResilientImageCollector_Enrichers(t *testing.T) {
+	err := RegisterEnricher(NewEnricherFunc("test-collector-catalog", func(ctx context.Context, imageRef string, facts *ImageFacts) error {
+		facts.Labels["catalog.owner"] = "payments"
+		facts.Labels["catalog.token"] = "abc"
+		facts.Labels["security.severity"] = "HIGH"
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Failed to register enricher: %v", err)
+	}
+
+	client := &labeledRegistryClient{MockRegistryClient: &MockRegistryClient{}}
+	collector := NewResilientImageCollector(client, NewErrorHandler(0, time.Millisecond, FallbackNone), time.Minute)
+
+	options := ImageCollectionOptions{MaxConcurrency: 1, Enrichers: []string{"test-collector-catalog", EnricherVulnerabilityLabels}}
+	result, err := collector.CollectImageFacts(context.Background(), []string{"nginx:1.25"}, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"version":                    "1.2.3",
+		"catalog.owner":              "payments",
+		"security.severity":          "HIGH",
+		"vulnerability.max-severity": "HIGH",
+	}
+	if labels := result.Facts["nginx:1.25"].Labels; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected enriched labels filtered by the default filter %v, got %v", expected, labels)
+	}
+
+	options.Enrichers = []string{"catalog"}
+	if _, err := collector.CollectImageFacts(context.Background(), []string{"nginx:1.25"}, options); err == nil {
+		t.Errorf("Expected error for an unknown enricher")
+	}
+}
+
+func TestBuiltinEnrichers(t *testing.T) {
+	facts := &ImageFacts{
+		Labels: map[string]string{"security.scan.result": "PASSED", "commit.sha": "abc123"},
+		Config: ImageConfig{Env: []string{"GIT_COMMIT=def456", "PATH=/usr/bin"}},
+	}
+	enrichers, err := ResolveEnrichers([]string{EnricherVulnerabilityLabels, EnricherBuildInfo})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	applyEnrichers(context.Background(), enrichers, "app:v1", facts)
+
+	for _, key := range []string{"vulnerability.scan-result", "build.commit.sha", "build.env.GIT_COMMIT=def456"} {
+		if _, exists := facts.Labels[key]; !exists {
+			t.Errorf("Expected label %s, got %v", key, facts.Labels)
+		}
+	}
+	if _, exists := facts.Labels["build.env.PATH=/usr/bin"]; exists {
+		t.Errorf("Expected unrelated env vars to be left out")
+	}
+}
@@ -1,10 +1,12 @@
 package images
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"time"
 )
 
@@ -12,6 +14,7 @@ import (
 type FactsSerializer struct {
 	prettyPrint bool
 	includeEmpty bool
+	timestamp    time.Time // Zero uses the serialization time
 }
 
 // NewFactsSerializer creates a new facts serializer
@@ -27,20 +30,93 @@ func (fs *FactsSerializer) SetIncludeEmpty(include bool) {
 	fs.includeEmpty = include
 }
 
-// SerializeToJSON serializes image facts to JSON
+// SetTimestamp pins the timestamp written with the facts, so the same facts always serialize to the same bytes
+func (fs *FactsSerializer) SetTimestamp(timestamp time.Time) {
+	fs.timestamp = timestamp
+}
+
+// SerializeToJSON serializes image facts to canonical JSON, see CanonicalJSON
 func (fs *FactsSerializer) SerializeToJSON(facts map[string]*ImageFacts) ([]byte, error) {
+	timestamp := fs.timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
 	// Create the facts JSON structure
 	factsOutput := &ImageFactsOutput{
 		Version:   "v1",
-		Timestamp: time.Now(),
+		Timestamp: timestamp.UTC(),
 		Facts:     facts,
 		Summary:   fs.generateSummary(facts),
 	}
 
-	if fs.prettyPrint {
-		return json.MarshalIndent(factsOutput, "", "  ")
+	data, err := json.Marshal(factsOutput)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalJSON(data, fs.prettyPrint)
+}
+
+// CanonicalJSON re-encodes JSON with the keys of every object sorted and numbers kept as written, indented by
+// two spaces when indent is true. Arrays keep their order, layers, env and history entries are ordered by the image
+func CanonicalJSON(data []byte, indent bool) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+
+	if indent {
+		return json.MarshalIndent(value, "", "  ")
 	}
-	return json.Marshal(factsOutput)
+	return json.Marshal(value)
+}
+
+// ValidateCanonical checks that data is canonical JSON, compact or indented, as written by SerializeToJSON
+func ValidateCanonical(data []byte) error {
+	indented := bytes.Contains(data, []byte("\n"))
+	canonical, err := CanonicalJSON(data, indented)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, canonical) {
+		return nil
+	}
+
+	offset := 0
+	for offset < len(data) && offset < len(canonical) && data[offset] == canonical[offset] {
+		offset++
+	}
+	return fmt.Errorf("JSON is not canonical, first difference at byte %d", offset)
+}
+
+// SortedImageRefs returns the image references of facts ordered by repository, tag and digest, then by reference
+func SortedImageRefs(facts map[string]*ImageFacts) []string {
+	refs := make([]string, 0, len(facts))
+	for imageRef := range facts {
+		refs = append(refs, imageRef)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := facts[refs[i]], facts[refs[j]]
+		if a != nil && b != nil {
+			if a.Repository != b.Repository {
+				return a.Repository < b.Repository
+			}
+			if a.Tag != b.Tag {
+				return a.Tag < b.Tag
+			}
+			if a.Digest != b.Digest {
+				return a.Digest < b.Digest
+			}
+		}
+		return refs[i] < refs[j]
+	})
+	return refs
 }
 
 // SerializeToFile serializes image facts to a JSON file
@@ -169,9 +245,28 @@ func (fs *FactsSerializer) generateSummary(facts map[string]*ImageFacts) ImageFa
 		TotalSize:   0,
 	}
 
-	for _, imageFacts := range facts {
+	// Sorted so the largest image is the same one whenever sizes tie
+	for _, imageRef := range SortedImageRefs(facts) {
+		imageFacts := facts[imageRef]
+		if imageFacts == nil {
+			continue
+		}
+
 		// Count registries
 		summary.Registries[imageFacts.Registry]++
+		if imageFacts.ResolvedRegistry != "" {
+			if summary.Mirrors == nil {
+				summary.Mirrors = make(map[string]int)
+			}
+			summary.Mirrors[imageFacts.ResolvedRegistry]++
+		}
+
+		if imageFacts.BaseImage != "" {
+			if summary.BaseImages == nil {
+				summary.BaseImages = make(map[string]int)
+			}
+			summary.BaseImages[imageFacts.BaseImage]++
+		}
 
 		// Count platforms
 		platformKey := fmt.Sprintf("%s/%s", imageFacts.Platform.OS, imageFacts.Platform.Architecture)
@@ -194,6 +289,7 @@ func (fs *FactsSerializer) generateSummary(facts map[string]*ImageFacts) ImageFa
 }
 
 // ImageFactsOutput represents the complete facts output structure
+// It is written as canonical JSON: keys sorted and facts keyed by image reference, see CanonicalJSON
 type ImageFactsOutput struct {
 	Version   string                  `json:"version"`
 	Timestamp time.Time               `json:"timestamp"`
@@ -205,6 +301,8 @@ type ImageFactsOutput struct {
 type ImageFactsSummary struct {
 	TotalImages      int            `json:"totalImages"`
 	Registries       map[string]int `json:"registries"`       // registry -> count
+	Mirrors          map[string]int `json:"mirrors,omitempty"` // mirror endpoint -> count of images it served
+	BaseImages       map[string]int `json:"baseImages,omitempty"` // base image -> count of images built on it
 	Platforms        map[string]int `json:"platforms"`        // platform -> count
 	TotalSize        int64          `json:"totalSize"`        // bytes
 	LargestImageSize int64          `json:"largestImageSize"` // bytes
@@ -264,6 +362,19 @@ func CreateFactsJSONSpec() map[string]interface{} {
 						"type":        "string",
 						"description": "Registry hostname",
 					},
+					"resolvedRegistry": map[string]interface{}{
+						"type":        "string",
+						"description": "Mirror endpoint that served the image, absent when the registry itself did",
+					},
+					"baseImage": map[string]interface{}{
+						"type":        "string",
+						"description": "Base image the image was built on, e.g. alpine:3.19, absent when it could not be identified",
+					},
+					"baseImageSource": map[string]interface{}{
+						"type":        "string",
+						"description": "How the base image was identified",
+						"enum":        []string{BaseImageSourceAnnotation, BaseImageSourceLayerDigest, BaseImageSourceHistory},
+					},
 					"size": map[string]interface{}{
 						"type":        "integer",
 						"description": "Image size in bytes",
@@ -380,6 +491,14 @@ func CreateFactsJSONSpec() map[string]interface{} {
 						"type":        "object",
 						"description": "Registry usage counts",
 					},
+					"mirrors": map[string]interface{}{
+						"type":        "object",
+						"description": "Mirror endpoint usage counts",
+					},
+					"baseImages": map[string]interface{}{
+						"type":        "object",
+						"description": "Base image usage counts",
+					},
 					"platforms": map[string]interface{}{
 						"type":        "object",
 						"description": "Platform usage counts",
@@ -409,3 +409,105 @@ func TestFactsSerializer_GenerateSummary(t *testing.T) {
 		t.Errorf("Expected largest image size 100000000, got %d", summary.LargestImageSize)
 	}
 }
+
+func TestFactsSerializer_SerializeToJSONCanonical(t *testing.T) {
+	newFacts := func() map[string]*ImageFacts {
+		facts := make(map[string]*ImageFacts)
+		for _, name := range []string{"redis", "nginx", "alpine", "busybox", "postgres", "memcached"} {
+			facts[name+":latest"] = &ImageFacts{
+				Repository: "library/" + name,
+				Tag:        "latest",
+				Registry:   "index.docker.io",
+				Size:       1000,
+				Labels:     map[string]string{"z": "1", "a": "2", "m": "3"},
+				Platform:   Platform{Architecture: "amd64", OS: "linux"},
+			}
+		}
+		return facts
+	}
+
+	for _, prettyPrint := range []bool{true, false} {
+		serializer := NewFactsSerializer(prettyPrint)
+		serializer.SetTimestamp(time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)))
+
+		first, err := serializer.SerializeToJSON(newFacts())
+		if err != nil {
+			t.Fatalf("Serialization failed: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			data, err := serializer.SerializeToJSON(newFacts())
+			if err != nil {
+				t.Fatalf("Serialization failed: %v", err)
+			}
+			if !bytes.Equal(first, data) {
+				t.Fatalf("Expected identical output for the same facts, pretty print %v", prettyPrint)
+			}
+		}
+
+		if err := ValidateCanonical(first); err != nil {
+			t.Errorf("Expected canonical output, pretty print %v: %v", prettyPrint, err)
+		}
+		if !bytes.Contains(first, []byte(`"2024-03-01T11:00:00Z"`)) {
+			t.Errorf("Expected the pinned timestamp in UTC, got %s", first)
+		}
+
+		var parsed ImageFactsOutput
+		if err := json.Unmarshal(first, &parsed); err != nil {
+			t.Fatalf("Generated JSON is invalid: %v", err)
+		}
+		if parsed.Summary.LargestImageRef != "library/alpine:latest" {
+			t.Errorf("Expected the first image by repository to win the size tie, got %s", parsed.Summary.LargestImageRef)
+		}
+	}
+}
+
+func TestValidateCanonical(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectError bool
+	}{
+		{name: "compact", data: `{"a":1,"b":{"c":[3,1,2],"d":"x"}}`},
+		{name: "indented", data: "{\n  \"a\": 1,\n  \"b\": [\n    \"y\",\n    \"x\"\n  ]\n}"},
+		{name: "large number", data: `{"size":9007199254740993}`},
+		{name: "unsorted keys", data: `{"b":1,"a":2}`, expectError: true},
+		{name: "unsorted nested keys", data: `{"a":{"z":1,"y":2}}`, expectError: true},
+		{name: "extra whitespace", data: `{"a": 1}`, expectError: true},
+		{name: "trailing newline", data: "{\n  \"a\": 1\n}\n", expectError: true},
+		{name: "invalid", data: `{"a":`, expectError: true},
+		{name: "trailing data", data: `{"a":1}{"b":2}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCanonical([]byte(tt.data))
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestSortedImageRefs(t *testing.T) {
+	facts := map[string]*ImageFacts{
+		"nginx@sha256:bbb":             {Repository: "library/nginx", Digest: "sha256:bbb"},
+		"nginx:1.25":                   {Repository: "library/nginx", Tag: "1.25"},
+		"mirror.local/nginx:1.25":      {Repository: "library/nginx", Tag: "1.25"},
+		"nginx@sha256:aaa":             {Repository: "library/nginx", Digest: "sha256:aaa"},
+		"alpine:3.19":                  {Repository: "library/alpine", Tag: "3.19"},
+		"quay.io/prometheus/node:v1.7": {Repository: "prometheus/node", Tag: "v1.7"},
+	}
+
+	expected := []string{
+		"alpine:3.19",
+		"nginx@sha256:aaa",
+		"nginx@sha256:bbb",
+		"mirror.local/nginx:1.25",
+		"nginx:1.25",
+		"quay.io/prometheus/node:v1.7",
+	}
+	refs := SortedImageRefs(facts)
+	if strings.Join(refs, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, refs)
+	}
+}
@@ -0,0 +1,207 @@
+package images
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FactsStreamFileName is the file image facts are appended to as each image completes
+const FactsStreamFileName = "facts.jsonl"
+
+// factsStreamChunkSize bounds how many image lookups are started at once while streaming, so goroutines and
+// pending facts stay flat regardless of image count
+const factsStreamChunkSize = 64
+
+// FactsStreamRecord is one line of facts.jsonl: the facts of one image
+type FactsStreamRecord struct {
+	ImageRef string      `json:"imageRef"`
+	Facts    *ImageFacts `json:"facts"`
+}
+
+// FactsStream appends image facts to a JSONL file as each image completes. Reopening the file of an interrupted
+// run resumes it: images already written are reported by Completed and not looked up again
+type FactsStream struct {
+	mu         sync.Mutex
+	file       *os.File
+	path       string
+	completed  map[string]bool
+	resumed    int
+	totalSize  int64
+	registries map[string]bool
+}
+
+// OpenFactsStream opens filePath for appending, loading the images already written by an earlier run. A partial
+// last line, left by a run interrupted mid-write, is truncated away
+func OpenFactsStream(filePath string) (*FactsStream, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open facts stream: %w", err)
+	}
+
+	fs := &FactsStream{
+		file:       file,
+		path:       filePath,
+		completed:  make(map[string]bool),
+		registries: make(map[string]bool),
+	}
+	valid, err := readFactsStream(file, func(record FactsStreamRecord) error {
+		fs.add(record)
+		return nil
+	})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	fs.resumed = len(fs.completed)
+
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate partial record: %w", err)
+	}
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek facts stream: %w", err)
+	}
+	return fs, nil
+}
+
+// Path returns the file the facts are written to
+func (fs *FactsStream) Path() string {
+	return fs.path
+}
+
+// Completed reports whether the facts of imageRef have been written, in this run or an earlier one
+func (fs *FactsStream) Completed(imageRef string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.completed[imageRef]
+}
+
+// Write appends the facts of one image as a single canonical JSON line
+func (fs *FactsStream) Write(imageRef string, facts *ImageFacts) error {
+	data, err := json.Marshal(FactsStreamRecord{ImageRef: imageRef, Facts: facts})
+	if err != nil {
+		return fmt.Errorf("failed to marshal facts for %s: %w", imageRef, err)
+	}
+	line, err := CanonicalJSON(data, false)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, err := fs.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write facts for %s: %w", imageRef, err)
+	}
+	fs.add(FactsStreamRecord{ImageRef: imageRef, Facts: facts})
+	return nil
+}
+
+// Count returns the number of images written, including those resumed from an earlier run
+func (fs *FactsStream) Count() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return len(fs.completed)
+}
+
+// Resumed returns the number of images loaded from an earlier run when the stream was opened
+func (fs *FactsStream) Resumed() int {
+	return fs.resumed
+}
+
+// TotalSize returns the summed size of the images written
+func (fs *FactsStream) TotalSize() int64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.totalSize
+}
+
+// Registries returns the number of distinct registries of the images written
+func (fs *FactsStream) Registries() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return len(fs.registries)
+}
+
+// Close closes the underlying file
+func (fs *FactsStream) Close() error {
+	return fs.file.Close()
+}
+
+// add tracks a written record; only counters are kept, never the facts themselves
+func (fs *FactsStream) add(record FactsStreamRecord) {
+	if fs.completed[record.ImageRef] {
+		return
+	}
+	fs.completed[record.ImageRef] = true
+	if record.Facts != nil {
+		fs.totalSize += record.Facts.Size
+		fs.registries[record.Facts.Registry] = true
+	}
+}
+
+// ReadFactsStream calls fn with each record of a facts.jsonl stream, in the order written. A partial last line
+// is ignored
+func ReadFactsStream(r io.Reader, fn func(record FactsStreamRecord) error) error {
+	_, err := readFactsStream(r, fn)
+	return err
+}
+
+// LoadFactsStream reads a facts.jsonl stream into the same structure as facts.json. When an image was written
+// more than once, the last record wins
+func LoadFactsStream(r io.Reader) (*ImageFactsOutput, error) {
+	facts := make(map[string]*ImageFacts)
+	err := ReadFactsStream(r, func(record FactsStreamRecord) error {
+		facts[record.ImageRef] = record.Facts
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ImageFactsOutput{
+		Version: "v1",
+		Facts:   facts,
+		Summary: NewFactsSerializer(false).generateSummary(facts),
+	}, nil
+}
+
+// readFactsStream returns the offset just past the last complete record
+func readFactsStream(r io.Reader, fn func(record FactsStreamRecord) error) (int64, error) {
+	reader := bufio.NewReader(r)
+	var offset int64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return offset, err
+		}
+		if errors.Is(err, io.EOF) {
+			// Without a trailing newline the line was cut short by an interrupted write
+			return offset, nil
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var record FactsStreamRecord
+			if err := json.Unmarshal(trimmed, &record); err != nil {
+				return offset, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if record.ImageRef == "" {
+				return offset, fmt.Errorf("line %d: missing imageRef", lineNumber)
+			}
+			if err := fn(record); err != nil {
+				return offset, err
+			}
+		}
+		offset += int64(len(line))
+	}
+}
@@ -0,0 +1,157 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingRegistryClient counts the images looked up
+type countingRegistryClient struct {
+	*MockRegistryClient
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func (c *countingRegistryClient) GetImageFacts(ctx context.Context, imageRef string) (*ImageFacts, error) {
+	c.mu.Lock()
+	c.lookups[imageRef]++
+	c.mu.Unlock()
+	return &ImageFacts{Registry: GetRegistryFromImageRef(imageRef), Digest: "sha256:" + imageRef, Size: 10}, nil
+}
+
+func TestFactsStream_Resume(t *testing.T) {
+	factsPath := filepath.Join(t.TempDir(), "images", FactsStreamFileName)
+	stream, err := OpenFactsStream(factsPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, imageRef := range []string{"nginx:1.25", "quay.io/app/api:v2"} {
+		if err := stream.Write(imageRef, &ImageFacts{Registry: GetRegistryFromImageRef(imageRef), Size: 100}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	stream.Close()
+
+	// An interrupted write leaves a partial line behind
+	file, _ := os.OpenFile(factsPath, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"facts":{"registry":"gh`)
+	file.Close()
+
+	resumed, err := OpenFactsStream(factsPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resumed.Resumed() != 2 || !resumed.Completed("nginx:1.25") || resumed.Completed("ghcr.io/app/worker:v1") {
+		t.Errorf("Expected the 2 complete records to be resumed, got %d", resumed.Resumed())
+	}
+	if resumed.TotalSize() != 200 || resumed.Registries() != 2 {
+		t.Errorf("Expected 200 bytes from 2 registries, got %d from %d", resumed.TotalSize(), resumed.Registries())
+	}
+	if err := resumed.Write("ghcr.io/app/worker:v1", &ImageFacts{Registry: "ghcr.io", Size: 50}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resumed.Close()
+
+	data, err := os.ReadFile(factsPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); len(lines) != 3 {
+		t.Fatalf("Expected the partial line to be replaced, got:\n%s", data)
+	}
+	output, err := LoadFactsStream(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output.Summary.TotalImages != 3 || output.Summary.TotalSize != 250 || output.Facts["ghcr.io/app/worker:v1"] == nil {
+		t.Errorf("Expected 3 images totalling 250 bytes, got %+v", output.Summary)
+	}
+}
+
+func TestReadFactsStream_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		records int
+		wantErr bool
+	}{
+		{name: "empty", data: ""},
+		{name: "blank lines", data: "{\"imageRef\":\"a:1\",\"facts\":{}}\n\n{\"imageRef\":\"b:1\",\"facts\":{}}\n", records: 2},
+		{name: "partial last line", data: "{\"imageRef\":\"a:1\",\"facts\":{}}\n{\"imageRef\":\"b", records: 1},
+		{name: "corrupt line", data: "{\"imageRef\":\"a:1\",\"facts\":{}}\nnot json\n{\"imageRef\":\"b:1\",\"facts\":{}}\n", records: 1, wantErr: true},
+		{name: "missing imageRef", data: "{\"facts\":{}}\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := 0
+			err := ReadFactsStream(strings.NewReader(tt.data), func(record FactsStreamRecord) error {
+				records++
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if records != tt.records {
+				t.Errorf("Expected %d records, got %d", tt.records, records)
+			}
+		})
+	}
+}
+
+func TestResilientImageCollector_FactsStream(t *testing.T) {
+	client := &countingRegistryClient{MockRegistryClient: &MockRegistryClient{}, lookups: make(map[string]int)}
+	factsPath := filepath.Join(t.TempDir(), FactsStreamFileName)
+
+	var imageRefs []string
+	for i := 0; i < factsStreamChunkSize*2+10; i++ {
+		imageRefs = append(imageRefs, fmt.Sprintf("harbor.internal/app/svc-%d:v1", i))
+	}
+
+	// The first run is interrupted after the first 100 images
+	stream, err := OpenFactsStream(factsPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collector := NewResilientImageCollector(client, NewErrorHandler(0, 0, FallbackNone), time.Minute)
+	collector.SetFactsStream(stream)
+	options := ImageCollectionOptions{MaxConcurrency: 8, CacheEnabled: true}
+	result, err := collector.CollectImageFacts(context.Background(), imageRefs[:100], options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stream.Close()
+	if len(result.Facts) != 0 || result.Statistics.SuccessfulImages != 100 {
+		t.Errorf("Expected 100 images streamed rather than kept in memory, got %d kept and %d successful", len(result.Facts), result.Statistics.SuccessfulImages)
+	}
+
+	stream, err = OpenFactsStream(factsPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stream.Close()
+	collector = NewResilientImageCollector(client, NewErrorHandler(0, 0, FallbackNone), time.Minute)
+	collector.SetFactsStream(stream)
+	result, err = collector.CollectImageFacts(context.Background(), imageRefs, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Statistics.ResumedImages != 100 || result.Statistics.SuccessfulImages != len(imageRefs) {
+		t.Errorf("Expected 100 resumed of %d successful images, got %+v", len(imageRefs), result.Statistics)
+	}
+	if result.Statistics.RegistriesAccessed != 1 || stream.Count() != len(imageRefs) {
+		t.Errorf("Expected %d images from 1 registry, got %d from %d", len(imageRefs), stream.Count(), result.Statistics.RegistriesAccessed)
+	}
+	for _, imageRef := range imageRefs {
+		if client.lookups[imageRef] != 1 {
+			t.Errorf("Expected %s to be looked up once, got %d", imageRef, client.lookups[imageRef])
+		}
+	}
+}
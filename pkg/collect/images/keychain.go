@@ -0,0 +1,98 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Keychain resolves registry credentials on demand
+type Keychain interface {
+	// Name returns a short identifier used in error messages
+	Name() string
+	// Resolve returns credentials for the registry, or nil if the keychain does not apply
+	Resolve(ctx context.Context, registry string) (*RegistryCredentials, error)
+}
+
+// StaticKeychain serves fixed credentials configured per registry
+type StaticKeychain struct {
+	credentials map[string]*RegistryCredentials
+}
+
+// NewStaticKeychain creates a keychain backed by a fixed set of credentials
+func NewStaticKeychain(credentials map[string]*RegistryCredentials) *StaticKeychain {
+	keychain := &StaticKeychain{
+		credentials: make(map[string]*RegistryCredentials),
+	}
+	for registry, creds := range credentials {
+		keychain.credentials[registry] = creds
+	}
+	return keychain
+}
+
+// Name returns the keychain name
+func (sk *StaticKeychain) Name() string {
+	return "static"
+}
+
+// Resolve returns the configured credentials for the registry
+func (sk *StaticKeychain) Resolve(ctx context.Context, registry string) (*RegistryCredentials, error) {
+	return sk.credentials[registry], nil
+}
+
+// ChainedKeychain tries each keychain in order and returns the first credentials found
+type ChainedKeychain struct {
+	keychains []Keychain
+}
+
+// NewChainedKeychain creates a keychain that consults the given keychains in order
+func NewChainedKeychain(keychains ...Keychain) *ChainedKeychain {
+	return &ChainedKeychain{
+		keychains: keychains,
+	}
+}
+
+// Name returns the keychain name
+func (ck *ChainedKeychain) Name() string {
+	names := make([]string, 0, len(ck.keychains))
+	for _, keychain := range ck.keychains {
+		names = append(names, keychain.Name())
+	}
+	return fmt.Sprintf("chain(%s)", strings.Join(names, ","))
+}
+
+// Resolve returns credentials from the first keychain that can provide them.
+// Errors are only returned if no keychain succeeded and at least one failed.
+func (ck *ChainedKeychain) Resolve(ctx context.Context, registry string) (*RegistryCredentials, error) {
+	var errs []string
+
+	for _, keychain := range ck.keychains {
+		creds, err := keychain.Resolve(ctx, registry)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", keychain.Name(), err))
+			continue
+		}
+		if creds != nil {
+			return creds, nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to resolve credentials for %s: %s", registry, strings.Join(errs, "; "))
+	}
+
+	return nil, nil
+}
+
+// NewCloudKeychain creates a keychain for ECR, GCR/Artifact Registry and ACR using
+// workload identity (IRSA, GKE workload identity, Azure workload identity or MSI)
+func NewCloudKeychain(timeout time.Duration) *ChainedKeychain {
+	httpClient := &http.Client{Timeout: timeout}
+	return NewChainedKeychain(
+		NewECRKeychain(httpClient),
+		NewGCRKeychain(httpClient),
+		NewACRKeychain(httpClient),
+	)
+}
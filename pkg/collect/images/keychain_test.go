@@ -0,0 +1,175 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeKeychain struct {
+	name  string
+	creds map[string]*RegistryCredentials
+	err   error
+	calls int
+}
+
+func (fk *fakeKeychain) Name() string {
+	return fk.name
+}
+
+func (fk *fakeKeychain) Resolve(ctx context.Context, registry string) (*RegistryCredentials, error) {
+	fk.calls++
+	if fk.err != nil {
+		return nil, fk.err
+	}
+	return fk.creds[registry], nil
+}
+
+func TestStaticKeychain_Resolve(t *testing.T) {
+	keychain := NewStaticKeychain(map[string]*RegistryCredentials{
+		"registry.example.com": {Username: "user", Password: "pass"},
+	})
+
+	creds, err := keychain.Resolve(context.Background(), "registry.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds == nil || creds.Username != "user" {
+		t.Errorf("Expected credentials for registry.example.com, got %v", creds)
+	}
+
+	creds, err = keychain.Resolve(context.Background(), "other.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds != nil {
+		t.Errorf("Expected no credentials for other.example.com, got %v", creds)
+	}
+}
+
+func TestChainedKeychain_Resolve(t *testing.T) {
+	tests := []struct {
+		name             string
+		keychains        func() []*fakeKeychain
+		expectedUsername string
+		expectError      bool
+		expectedCalls    []int
+	}{
+		{
+			name: "first keychain wins",
+			keychains: func() []*fakeKeychain {
+				return []*fakeKeychain{
+					{name: "first", creds: map[string]*RegistryCredentials{"reg.io": {Username: "first"}}},
+					{name: "second", creds: map[string]*RegistryCredentials{"reg.io": {Username: "second"}}},
+				}
+			},
+			expectedUsername: "first",
+			expectedCalls:    []int{1, 0},
+		},
+		{
+			name: "falls through to next keychain",
+			keychains: func() []*fakeKeychain {
+				return []*fakeKeychain{
+					{name: "empty"},
+					{name: "second", creds: map[string]*RegistryCredentials{"reg.io": {Username: "second"}}},
+				}
+			},
+			expectedUsername: "second",
+			expectedCalls:    []int{1, 1},
+		},
+		{
+			name: "error is ignored when a later keychain succeeds",
+			keychains: func() []*fakeKeychain {
+				return []*fakeKeychain{
+					{name: "broken", err: fmt.Errorf("metadata server unreachable")},
+					{name: "second", creds: map[string]*RegistryCredentials{"reg.io": {Username: "second"}}},
+				}
+			},
+			expectedUsername: "second",
+			expectedCalls:    []int{1, 1},
+		},
+		{
+			name: "error is returned when nothing resolves",
+			keychains: func() []*fakeKeychain {
+				return []*fakeKeychain{
+					{name: "broken", err: fmt.Errorf("metadata server unreachable")},
+					{name: "empty"},
+				}
+			},
+			expectError:   true,
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name: "anonymous when no keychain applies",
+			keychains: func() []*fakeKeychain {
+				return []*fakeKeychain{{name: "empty"}}
+			},
+			expectedCalls: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakes := tt.keychains()
+			keychains := make([]Keychain, 0, len(fakes))
+			for _, fake := range fakes {
+				keychains = append(keychains, fake)
+			}
+
+			creds, err := NewChainedKeychain(keychains...).Resolve(context.Background(), "reg.io")
+
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			username := ""
+			if creds != nil {
+				username = creds.Username
+			}
+			if username != tt.expectedUsername {
+				t.Errorf("Expected username %q, got %q", tt.expectedUsername, username)
+			}
+
+			for i, fake := range fakes {
+				if fake.calls != tt.expectedCalls[i] {
+					t.Errorf("Expected keychain %s to be called %d times, got %d", fake.name, tt.expectedCalls[i], fake.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestChainedKeychain_Name(t *testing.T) {
+	keychain := NewChainedKeychain(&fakeKeychain{name: "static"}, &fakeKeychain{name: "ecr"})
+
+	if !strings.Contains(keychain.Name(), "static,ecr") {
+		t.Errorf("Expected chain name to list keychains, got %s", keychain.Name())
+	}
+}
+
+func TestDefaultRegistryClient_EnsureAuthenticatedWithKeychain(t *testing.T) {
+	client := NewRegistryClient(0)
+	client.SetKeychain(&fakeKeychain{
+		name:  "fake",
+		creds: map[string]*RegistryCredentials{"reg.io": {Token: "keychain-token"}},
+	})
+
+	if err := client.ensureAuthenticated(context.Background(), "reg.io"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.authTokens["reg.io"] != "keychain-token" {
+		t.Errorf("Expected token from keychain, got %q", client.authTokens["reg.io"])
+	}
+
+	// Registries the keychain doesn't know about stay anonymous
+	if err := client.ensureAuthenticated(context.Background(), "public.io"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, exists := client.authTokens["public.io"]; exists {
+		t.Errorf("Expected no token for public.io")
+	}
+}
@@ -0,0 +1,100 @@
+package images
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/replicatedhq/troubleshoot/pkg/collect/autodiscovery"
+)
+
+// DefaultDeniedLabels are the label globs removed from image facts unless a label filter is configured. They catch
+// credentials and internal metadata while keeping version, build and commit keys such as
+// org.opencontainers.image.version, org.opencontainers.image.revision or build-date
+var DefaultDeniedLabels = []string{
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*token*",
+	"*credential*",
+	"*apikey*",
+	"*api.key*",
+	"*api-key*",
+	"*api_key*",
+	"*private*",
+	"*internal*",
+}
+
+// LabelFilter selects the image and env-derived labels written to facts.json. A label is kept when it matches an
+// Allow glob, or Allow is empty, and matches no Deny glob. Globs are matched case-insensitively
+type LabelFilter struct {
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// DefaultLabelFilter keeps every label except those matching DefaultDeniedLabels
+func DefaultLabelFilter() LabelFilter {
+	return LabelFilter{Deny: append([]string(nil), DefaultDeniedLabels...)}
+}
+
+// Validate checks every pattern is a well-formed glob
+func (f LabelFilter) Validate() error {
+	if err := validateLabelGlobs(f.Allow); err != nil {
+		return fmt.Errorf("allow: %w", err)
+	}
+	if err := validateLabelGlobs(f.Deny); err != nil {
+		return fmt.Errorf("deny: %w", err)
+	}
+	return nil
+}
+
+func validateLabelGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("label pattern cannot be empty")
+		}
+		if err := autodiscovery.ValidateGlob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Keeps reports whether the label key passes the filter
+func (f LabelFilter) Keeps(key string) bool {
+	key = strings.ToLower(key)
+	if len(f.Allow) > 0 && !matchesLabelGlob(f.Allow, key) {
+		return false
+	}
+	return !matchesLabelGlob(f.Deny, key)
+}
+
+// Apply replaces facts.Labels and facts.Config.Labels with copies holding only the labels the filter keeps
+func (f LabelFilter) Apply(facts *ImageFacts) {
+	if facts == nil {
+		return
+	}
+	facts.Labels = f.filter(facts.Labels)
+	facts.Config.Labels = f.filter(facts.Config.Labels)
+}
+
+func (f LabelFilter) filter(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	kept := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if f.Keeps(key) {
+			kept[key] = value
+		}
+	}
+	return kept
+}
+
+func matchesLabelGlob(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if autodiscovery.MatchGlob(strings.ToLower(pattern), key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package images
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLabelFilter_Keeps(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter LabelFilter
+		key    string
+		want   bool
+	}{
+		{name: "default keeps version", filter: DefaultLabelFilter(), key: "org.opencontainers.image.version", want: true},
+		{name: "default keeps revision", filter: DefaultLabelFilter(), key: "org.opencontainers.image.revision", want: true},
+		{name: "default keeps build", filter: DefaultLabelFilter(), key: "build-date", want: true},
+		{name: "default keeps commit", filter: DefaultLabelFilter(), key: "commit", want: true},
+		{name: "default drops tokens", filter: DefaultLabelFilter(), key: "ci.job.token", want: false},
+		{name: "default drops internal metadata", filter: DefaultLabelFilter(), key: "com.example.internal.owner", want: false},
+		{name: "matching ignores case", filter: DefaultLabelFilter(), key: "REGISTRY_PASSWORD", want: false},
+		{name: "empty filter keeps everything", filter: LabelFilter{}, key: "api.key", want: true},
+		{name: "allowlist keeps matches", filter: LabelFilter{Allow: []string{"org.opencontainers.*"}}, key: "org.opencontainers.image.source", want: true},
+		{name: "allowlist drops the rest", filter: LabelFilter{Allow: []string{"org.opencontainers.*"}}, key: "maintainer", want: false},
+		{name: "deny wins over allow", filter: LabelFilter{Allow: []string{"*"}, Deny: []string{"vendor"}}, key: "vendor", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Keeps(tt.key); got != tt.want {
+				t.Errorf("Expected Keeps(%q) %v, got %v", tt.key, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLabelFilter_Validate(t *testing.T) {
+	if err := DefaultLabelFilter().Validate(); err != nil {
+		t.Errorf("Expected the default filter to be valid, got %v", err)
+	}
+	if err := (LabelFilter{Allow: []string{""}}).Validate(); err == nil || !strings.Contains(err.Error(), "allow") {
+		t.Errorf("Expected an empty allow pattern to be rejected, got %v", err)
+	}
+	if err := (LabelFilter{Deny: []string{"secret-["}}).Validate(); err == nil || !strings.Contains(err.Error(), "deny") {
+		t.Errorf("Expected a malformed deny glob to be rejected, got %v", err)
+	}
+}
+
+// labeledRegistryClient returns facts carrying image and env-derived labels
+type labeledRegistryClient struct {
+	*MockRegistryClient
+}
+
+func (c *labeledRegistryClient) GetImageFacts(ctx context.Context, imageRef string) (*ImageFacts, error) {
+	return &ImageFacts{
+		Registry: GetRegistryFromImageRef(imageRef),
+		Digest:   "sha256:" + imageRef,
+		Labels:   map[string]string{"version": "1.2.3", "deploy.token": "abc"},
+		Config: ImageConfig{Labels: map[string]string{
+			"org.opencontainers.image.revision": "4f2e1c",
+			"com.example.internal.team":         "payments",
+		}},
+	}, nil
+}
+
+func TestResilientImageCollector_LabelFilter(t *testing.T) {
+	client := &labeledRegistryClient{MockRegistryClient: &MockRegistryClient{}}
+	collector := NewResilientImageCollector(client, NewErrorHandler(0, time.Millisecond, FallbackNone), time.Minute)
+
+	result, err := collector.CollectImageFacts(context.Background(), []string{"nginx:1.25"}, ImageCollectionOptions{MaxConcurrency: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	facts := result.Facts["nginx:1.25"]
+	if len(facts.Labels) != 1 || facts.Labels["version"] != "1.2.3" {
+		t.Errorf("Expected only the version label by default, got %v", facts.Labels)
+	}
+	if len(facts.Config.Labels) != 1 || facts.Config.Labels["org.opencontainers.image.revision"] != "4f2e1c" {
+		t.Errorf("Expected only the revision config label by default, got %v", facts.Config.Labels)
+	}
+
+	options := ImageCollectionOptions{MaxConcurrency: 1, LabelFilter: &LabelFilter{}}
+	result, err = collector.CollectImageFacts(context.Background(), []string{"nginx:1.25"}, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if facts := result.Facts["nginx:1.25"]; len(facts.Labels) != 2 || len(facts.Config.Labels) != 2 {
+		t.Errorf("Expected an empty filter to keep every label, got %v and %v", facts.Labels, facts.Config.Labels)
+	}
+}
@@ -0,0 +1,229 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NodeImagePresenceFileName is the bundle file holding the node image presence report
+const NodeImagePresenceFileName = "node-image-presence.json"
+
+// dockerHubAliases are registry hostnames that all refer to Docker Hub
+var dockerHubAliases = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+// NodeImagePresenceReport cross-references pod images against the images cached on nodes
+type NodeImagePresenceReport struct {
+	GeneratedAt time.Time                `json:"generatedAt"`
+	Nodes       []string                 `json:"nodes"`
+	Images      []ImagePresence          `json:"images"`
+	MissingPods []PodImageStatus         `json:"missingPods,omitempty"`
+	Summary     NodeImagePresenceSummary `json:"summary"`
+}
+
+// NodeImagePresenceSummary provides totals for the node image presence report
+type NodeImagePresenceSummary struct {
+	TotalImages           int   `json:"totalImages"`
+	PresentImages         int   `json:"presentImages"`
+	MissingImages         int   `json:"missingImages"`
+	PodsWithMissingImages int   `json:"podsWithMissingImages"`
+	TotalCachedBytes      int64 `json:"totalCachedBytes"`
+}
+
+// ImagePresence describes where a referenced image is already present
+type ImagePresence struct {
+	Image        string   `json:"image"`
+	Nodes        []string `json:"nodes,omitempty"`
+	SizeBytes    int64    `json:"sizeBytes,omitempty"`
+	PullPolicies []string `json:"pullPolicies,omitempty"`
+	Pods         []string `json:"pods"`
+	Present      bool     `json:"present"`
+}
+
+// PodImageStatus describes a pod container whose image is not present on any node
+type PodImageStatus struct {
+	Namespace  string `json:"namespace"`
+	Pod        string `json:"pod"`
+	NodeName   string `json:"nodeName,omitempty"`
+	Container  string `json:"container"`
+	Image      string `json:"image"`
+	PullPolicy string `json:"pullPolicy,omitempty"`
+}
+
+// nodeImage is an entry from a node's .status.images
+type nodeImage struct {
+	names     []string
+	sizeBytes int64
+}
+
+// BuildNodeImagePresenceReport collects node .status.images and cross-references them with pod images
+// An empty namespace list covers all namespaces
+func (adic *AutoDiscoveryImageCollector) BuildNodeImagePresenceReport(ctx context.Context, namespaces []string) (*NodeImagePresenceReport, error) {
+	nodeGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}
+	nodeList, err := adic.dynamicClient.Resource(nodeGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	pods, err := adic.discoverPods(ctx, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover pods: %w", err)
+	}
+
+	return NewNodeImagePresenceReport(nodeList.Items, pods), nil
+}
+
+// NewNodeImagePresenceReport builds the report from node and pod objects
+func NewNodeImagePresenceReport(nodes []unstructured.Unstructured, pods []unstructured.Unstructured) *NodeImagePresenceReport {
+	report := &NodeImagePresenceReport{
+		GeneratedAt: time.Now(),
+	}
+
+	// Index node images by canonical name
+	imageNodes := make(map[string][]string)
+	imageSizes := make(map[string]int64)
+	for _, node := range nodes {
+		report.Nodes = append(report.Nodes, node.GetName())
+		for _, image := range extractNodeImages(node) {
+			report.Summary.TotalCachedBytes += image.sizeBytes
+			for _, name := range image.names {
+				canonical := canonicalImageName(name)
+				imageNodes[canonical] = appendUnique(imageNodes[canonical], node.GetName())
+				imageSizes[canonical] = image.sizeBytes
+			}
+		}
+	}
+	sort.Strings(report.Nodes)
+
+	presence := make(map[string]*ImagePresence)
+	missingPods := make(map[string]bool)
+
+	for _, pod := range pods {
+		podName := pod.GetNamespace() + "/" + pod.GetName()
+		nodeName, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName")
+
+		for _, container := range podContainers(pod) {
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+			name, _ := container["name"].(string)
+			pullPolicy, _ := container["imagePullPolicy"].(string)
+			canonical := canonicalImageName(image)
+
+			entry, exists := presence[image]
+			if !exists {
+				entry = &ImagePresence{
+					Image:     image,
+					Nodes:     imageNodes[canonical],
+					SizeBytes: imageSizes[canonical],
+					Present:   len(imageNodes[canonical]) > 0,
+				}
+				presence[image] = entry
+			}
+			entry.Pods = appendUnique(entry.Pods, podName)
+			if pullPolicy != "" {
+				entry.PullPolicies = appendUnique(entry.PullPolicies, pullPolicy)
+			}
+
+			if !entry.Present {
+				report.MissingPods = append(report.MissingPods, PodImageStatus{
+					Namespace:  pod.GetNamespace(),
+					Pod:        pod.GetName(),
+					NodeName:   nodeName,
+					Container:  name,
+					Image:      image,
+					PullPolicy: pullPolicy,
+				})
+				missingPods[podName] = true
+			}
+		}
+	}
+
+	for _, entry := range presence {
+		report.Images = append(report.Images, *entry)
+		if entry.Present {
+			report.Summary.PresentImages++
+		} else {
+			report.Summary.MissingImages++
+		}
+	}
+	sort.Slice(report.Images, func(i, j int) bool {
+		return report.Images[i].Image < report.Images[j].Image
+	})
+
+	report.Summary.TotalImages = len(report.Images)
+	report.Summary.PodsWithMissingImages = len(missingPods)
+
+	return report
+}
+
+// extractNodeImages reads .status.images from a node
+func extractNodeImages(node unstructured.Unstructured) []nodeImage {
+	var images []nodeImage
+
+	entries, _, _ := unstructured.NestedSlice(node.Object, "status", "images")
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		names, _, _ := unstructured.NestedStringSlice(entry, "names")
+		size, _, _ := unstructured.NestedInt64(entry, "sizeBytes")
+		images = append(images, nodeImage{names: names, sizeBytes: size})
+	}
+
+	return images
+}
+
+// podContainers returns init, regular and ephemeral containers of a pod
+func podContainers(pod unstructured.Unstructured) []map[string]interface{} {
+	var containers []map[string]interface{}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		list, _, _ := unstructured.NestedSlice(pod.Object, "spec", field)
+		for _, c := range list {
+			if container, ok := c.(map[string]interface{}); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+
+	return containers
+}
+
+// canonicalImageName normalizes an image reference so pod and node names can be compared
+func canonicalImageName(imageRef string) string {
+	normalized, err := NormalizeImageReference(imageRef)
+	if err != nil {
+		return imageRef
+	}
+
+	registry, rest, found := strings.Cut(normalized, "/")
+	if found && dockerHubAliases[registry] {
+		return "docker.io/" + rest
+	}
+	return normalized
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
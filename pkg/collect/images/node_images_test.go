@@ -0,0 +1,182 @@
+package images
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testNode(name string, images ...corev1.ContainerImage) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Images: images},
+	}
+}
+
+func testPod(namespace, name, nodeName string, containers ...corev1.Container) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.PodSpec{NodeName: nodeName, Containers: containers},
+	}
+}
+
+func toUnstructured(t *testing.T, obj runtime.Object) unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatalf("Failed to convert object: %v", err)
+	}
+	return unstructured.Unstructured{Object: content}
+}
+
+func TestCanonicalImageName(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"nginx:1.25", "docker.io/library/nginx:1.25"},
+		{"nginx", "index.docker.io/library/nginx:latest"},
+		{"bitnami/redis:7", "docker.io/bitnami/redis:7"},
+		{"nginx@sha256:abc123", "docker.io/library/nginx@sha256:abc123"},
+		{"gcr.io/project/app:v1", "gcr.io/project/app:v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a, func(t *testing.T) {
+			if canonicalImageName(tt.a) != canonicalImageName(tt.b) {
+				t.Errorf("Expected %s and %s to match, got %s and %s", tt.a, tt.b, canonicalImageName(tt.a), canonicalImageName(tt.b))
+			}
+		})
+	}
+}
+
+func TestNewNodeImagePresenceReport(t *testing.T) {
+	nodes := []unstructured.Unstructured{
+		toUnstructured(t, testNode("node-1",
+			corev1.ContainerImage{Names: []string{"docker.io/library/nginx@sha256:abc", "docker.io/library/nginx:1.25"}, SizeBytes: 1000},
+		)),
+		toUnstructured(t, testNode("node-2",
+			corev1.ContainerImage{Names: []string{"docker.io/library/nginx:1.25"}, SizeBytes: 1000},
+			corev1.ContainerImage{Names: []string{"gcr.io/project/worker:v2"}, SizeBytes: 500},
+		)),
+	}
+	pods := []unstructured.Unstructured{
+		toUnstructured(t, testPod("app", "web-1", "node-1",
+			corev1.Container{Name: "web", Image: "nginx:1.25", ImagePullPolicy: corev1.PullIfNotPresent},
+		)),
+		toUnstructured(t, testPod("app", "web-2", "node-2",
+			corev1.Container{Name: "web", Image: "nginx:1.25", ImagePullPolicy: corev1.PullAlways},
+			corev1.Container{Name: "sidecar", Image: "registry.example.com/sidecar:1.0", ImagePullPolicy: corev1.PullIfNotPresent},
+		)),
+		toUnstructured(t, testPod("jobs", "worker", "",
+			corev1.Container{Name: "worker", Image: "gcr.io/project/worker:v3"},
+		)),
+	}
+
+	report := NewNodeImagePresenceReport(nodes, pods)
+
+	if len(report.Nodes) != 2 {
+		t.Errorf("Expected 2 nodes, got %d", len(report.Nodes))
+	}
+	if report.Summary.TotalImages != 3 {
+		t.Errorf("Expected 3 images, got %d", report.Summary.TotalImages)
+	}
+	if report.Summary.PresentImages != 1 || report.Summary.MissingImages != 2 {
+		t.Errorf("Expected 1 present and 2 missing images, got %d and %d", report.Summary.PresentImages, report.Summary.MissingImages)
+	}
+	if report.Summary.PodsWithMissingImages != 2 {
+		t.Errorf("Expected 2 pods with missing images, got %d", report.Summary.PodsWithMissingImages)
+	}
+	if report.Summary.TotalCachedBytes != 2500 {
+		t.Errorf("Expected 2500 cached bytes, got %d", report.Summary.TotalCachedBytes)
+	}
+
+	var nginx *ImagePresence
+	for i := range report.Images {
+		if report.Images[i].Image == "nginx:1.25" {
+			nginx = &report.Images[i]
+		}
+	}
+	if nginx == nil {
+		t.Fatalf("Expected nginx:1.25 in report")
+	}
+	if !nginx.Present || len(nginx.Nodes) != 2 || nginx.SizeBytes != 1000 {
+		t.Errorf("Expected nginx:1.25 present on 2 nodes with size 1000, got %+v", nginx)
+	}
+	if len(nginx.Pods) != 2 || len(nginx.PullPolicies) != 2 {
+		t.Errorf("Expected 2 pods and 2 pull policies for nginx, got %v and %v", nginx.Pods, nginx.PullPolicies)
+	}
+
+	for _, missing := range report.MissingPods {
+		if missing.Image == "nginx:1.25" {
+			t.Errorf("Expected nginx:1.25 not to be reported missing")
+		}
+	}
+}
+
+func TestAutoDiscoveryImageCollector_BuildNodeImagePresenceReport(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+
+	client := dynamicfake.NewSimpleDynamicClient(scheme,
+		testNode("node-1", corev1.ContainerImage{Names: []string{"docker.io/library/redis:7"}, SizeBytes: 100}),
+		testPod("cache", "redis-0", "node-1", corev1.Container{Name: "redis", Image: "redis:7"}),
+		testPod("other", "app", "node-1", corev1.Container{Name: "app", Image: "app:latest"}),
+	)
+
+	collector := NewAutoDiscoveryImageCollector(client)
+
+	report, err := collector.BuildNodeImagePresenceReport(context.Background(), []string{"cache"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Summary.TotalImages != 1 || report.Summary.PresentImages != 1 {
+		t.Errorf("Expected only the cache namespace image to be present, got %+v", report.Summary)
+	}
+
+	report, err = collector.BuildNodeImagePresenceReport(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Summary.TotalImages != 2 || report.Summary.PodsWithMissingImages != 1 {
+		t.Errorf("Expected 2 images across all namespaces with 1 pod missing, got %+v", report.Summary)
+	}
+}
+
+func TestAutoDiscoveryImageCollector_ProtectedNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+
+	client := dynamicfake.NewSimpleDynamicClient(scheme,
+		testNode("node-1", corev1.ContainerImage{Names: []string{"docker.io/library/redis:7"}, SizeBytes: 100}),
+		testPod("cache", "redis-0", "node-1", corev1.Container{Name: "redis", Image: "redis:7"}),
+		testPod("pci-payments", "vault", "node-1", corev1.Container{Name: "vault", Image: "vault:1.15"}),
+	)
+
+	collector := NewAutoDiscoveryImageCollector(client)
+	collector.SetProtectedNamespaces([]string{"pci-*"})
+
+	report, err := collector.BuildNodeImagePresenceReport(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Summary.TotalImages != 1 || report.Summary.PodsWithMissingImages != 0 {
+		t.Errorf("Expected only the cache namespace image across all namespaces, got %+v", report.Summary)
+	}
+
+	report, err = collector.BuildNodeImagePresenceReport(context.Background(), []string{"pci-payments"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Summary.TotalImages != 0 {
+		t.Errorf("Expected a protected namespace to be skipped when requested, got %+v", report.Summary)
+	}
+
+	resources := []AutoDiscoveryResource{{Namespace: "pci-payments", Name: "vault"}}
+	if filtered := collector.filterProtectedResources(resources); len(filtered) != 0 {
+		t.Errorf("Expected resources in protected namespaces to be dropped, got %v", filtered)
+	}
+}
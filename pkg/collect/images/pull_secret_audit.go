@@ -0,0 +1,399 @@
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PullSecretAuditFileName is the bundle file holding the pull secret audit
+const PullSecretAuditFileName = "pull-secret-audit.json"
+
+// Pull secret statuses
+const (
+	PullSecretValid      = "valid"
+	PullSecretMissing    = "missing"    // Referenced by a pod but not found
+	PullSecretUnreadable = "unreadable" // Could not be read, e.g. without RBAC on secrets
+	PullSecretWrongType  = "wrong-type" // Not a kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg secret
+	PullSecretMalformed  = "malformed"  // The docker config or one of its entries cannot be parsed
+	PullSecretExpired    = "expired"    // Every credential in it has expired
+)
+
+// Pull secret types and the data key holding their docker config
+const (
+	secretTypeDockerConfigJSON = "kubernetes.io/dockerconfigjson"
+	secretTypeDockercfg        = "kubernetes.io/dockercfg"
+)
+
+var secretsGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+
+// PullSecretAuditReport reports which registries have pull credentials, without any credential values
+type PullSecretAuditReport struct {
+	GeneratedAt time.Time                    `json:"generatedAt"`
+	Secrets     []PullSecretStatus           `json:"secrets"`
+	Registries  []RegistryCredentialCoverage `json:"registries"`
+	Summary     PullSecretAuditSummary       `json:"summary"`
+}
+
+// PullSecretAuditSummary provides totals for the pull secret audit
+type PullSecretAuditSummary struct {
+	TotalSecrets        int `json:"totalSecrets"`
+	ValidSecrets        int `json:"validSecrets"`
+	MissingSecrets      int `json:"missingSecrets"`
+	MalformedSecrets    int `json:"malformedSecrets"` // Malformed or of the wrong type
+	ExpiredSecrets      int `json:"expiredSecrets"`
+	UnreadableSecrets   int `json:"unreadableSecrets"`
+	UncoveredRegistries int `json:"uncoveredRegistries"` // Registries pulled from by pods with pull secrets, without a valid credential
+}
+
+// PullSecretStatus is the audit result of one imagePullSecret referenced by discovered pods
+type PullSecretStatus struct {
+	Namespace  string                     `json:"namespace"`
+	Name       string                     `json:"name"`
+	Type       string                     `json:"type,omitempty"`
+	Status     string                     `json:"status"`
+	Problems   []string                   `json:"problems,omitempty"`
+	Registries []RegistryCredentialStatus `json:"registries,omitempty"`
+	Pods       []string                   `json:"pods"`
+}
+
+// RegistryCredentialStatus describes one registry entry of a pull secret
+type RegistryCredentialStatus struct {
+	Registry  string     `json:"registry"`
+	AuthType  string     `json:"authType,omitempty"`  // "basic", "identity-token" or "registry-token"
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // Read from JWT or ECR tokens
+	Expired   bool       `json:"expired,omitempty"`
+	Problems  []string   `json:"problems,omitempty"`
+}
+
+// usable reports whether the entry holds a well-formed credential that has not expired
+func (s RegistryCredentialStatus) usable() bool {
+	return len(s.Problems) == 0 && !s.Expired
+}
+
+// RegistryCredentialCoverage lists the pull secrets with credentials for a registry
+// Registries without credentials may simply be public
+type RegistryCredentialCoverage struct {
+	Registry string   `json:"registry"`
+	Images   []string `json:"images,omitempty"`  // Pulled by pods that have pull secrets
+	Secrets  []string `json:"secrets,omitempty"` // namespace/name of the secrets with an entry for the registry
+	Covered  bool     `json:"covered"`           // At least one of the secrets has a usable credential
+}
+
+// BuildPullSecretAudit audits the imagePullSecrets of the pods in the namespaces
+// An empty namespace list covers all namespaces
+func (adic *AutoDiscoveryImageCollector) BuildPullSecretAudit(ctx context.Context, namespaces []string) (*PullSecretAuditReport, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	pods, err := adic.discoverPods(ctx, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover pods: %w", err)
+	}
+
+	getSecret := func(namespace, name string) (*unstructured.Unstructured, error) {
+		return adic.dynamicClient.Resource(secretsGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return NewPullSecretAuditReport(pods, getSecret, time.Now()), nil
+}
+
+// NewPullSecretAuditReport audits every pull secret the pods reference, reading each once with getSecret
+func NewPullSecretAuditReport(pods []unstructured.Unstructured, getSecret func(namespace, name string) (*unstructured.Unstructured, error), now time.Time) *PullSecretAuditReport {
+	report := &PullSecretAuditReport{GeneratedAt: now}
+
+	secrets := make(map[string]*PullSecretStatus)
+	podSecrets := make(map[string][]string) // Pod to the secrets it references
+	podImages := make(map[string][]string)
+	var podNames []string
+
+	for _, pod := range pods {
+		podName := pod.GetNamespace() + "/" + pod.GetName()
+		refs, _, _ := unstructured.NestedSlice(pod.Object, "spec", "imagePullSecrets")
+		for _, r := range refs {
+			ref, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := ref["name"].(string)
+			if name == "" {
+				continue
+			}
+			key := pod.GetNamespace() + "/" + name
+			if _, exists := secrets[key]; !exists {
+				secrets[key] = &PullSecretStatus{Namespace: pod.GetNamespace(), Name: name}
+			}
+			secrets[key].Pods = appendUnique(secrets[key].Pods, podName)
+			podSecrets[podName] = appendUnique(podSecrets[podName], key)
+		}
+		if len(podSecrets[podName]) == 0 {
+			continue
+		}
+
+		podNames = append(podNames, podName)
+		for _, container := range podContainers(pod) {
+			if image, _ := container["image"].(string); image != "" {
+				podImages[podName] = appendUnique(podImages[podName], image)
+			}
+		}
+	}
+
+	for _, status := range secrets {
+		secret, err := getSecret(status.Namespace, status.Name)
+		switch {
+		case apierrors.IsNotFound(err):
+			status.Status = PullSecretMissing
+		case err != nil:
+			status.Status = PullSecretUnreadable
+			status.Problems = append(status.Problems, err.Error())
+		default:
+			auditPullSecret(status, secret, now)
+		}
+		report.Secrets = append(report.Secrets, *status)
+	}
+	sort.Slice(report.Secrets, func(i, j int) bool {
+		if report.Secrets[i].Namespace != report.Secrets[j].Namespace {
+			return report.Secrets[i].Namespace < report.Secrets[j].Namespace
+		}
+		return report.Secrets[i].Name < report.Secrets[j].Name
+	})
+
+	report.Registries = pullSecretCoverage(secrets, podNames, podSecrets, podImages)
+	report.Summary = summarizePullSecretAudit(report)
+	return report
+}
+
+// auditPullSecret parses the docker config of a secret and records its status, never its credentials
+func auditPullSecret(status *PullSecretStatus, secret *unstructured.Unstructured, now time.Time) {
+	status.Type, _, _ = unstructured.NestedString(secret.Object, "type")
+
+	var dataKey string
+	switch status.Type {
+	case secretTypeDockerConfigJSON:
+		dataKey = ".dockerconfigjson"
+	case secretTypeDockercfg:
+		dataKey = ".dockercfg"
+	default:
+		status.Status = PullSecretWrongType
+		status.Problems = append(status.Problems, fmt.Sprintf("type %q is not %s or %s", status.Type, secretTypeDockerConfigJSON, secretTypeDockercfg))
+		return
+	}
+
+	encoded, _, _ := unstructured.NestedString(secret.Object, "data", dataKey)
+	auths, err := parseDockerConfig(encoded, dataKey == ".dockerconfigjson")
+	if err != nil {
+		status.Status = PullSecretMalformed
+		status.Problems = append(status.Problems, fmt.Sprintf("%s: %v", dataKey, err))
+		return
+	}
+
+	usable, expired := 0, 0
+	for key, entry := range auths {
+		credential := auditDockerConfigEntry(key, entry, now)
+		status.Registries = append(status.Registries, credential)
+		switch {
+		case credential.usable():
+			usable++
+		case credential.Expired:
+			expired++
+		}
+	}
+	sort.Slice(status.Registries, func(i, j int) bool {
+		return status.Registries[i].Registry < status.Registries[j].Registry
+	})
+
+	switch {
+	case len(status.Registries) == 0:
+		status.Status = PullSecretMalformed
+		status.Problems = append(status.Problems, "no registries in docker config")
+	case usable > 0:
+		status.Status = PullSecretValid
+	case expired > 0:
+		status.Status = PullSecretExpired
+	default:
+		status.Status = PullSecretMalformed
+	}
+}
+
+// dockerConfigEntry is one registry entry of a docker config
+type dockerConfigEntry struct {
+	Auth          string `json:"auth,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// parseDockerConfig decodes the base64 secret data into its registry entries
+// .dockerconfigjson nests the entries under "auths", the legacy .dockercfg does not
+func parseDockerConfig(encoded string, nested bool) (map[string]dockerConfigEntry, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("missing")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64")
+	}
+
+	if !nested {
+		var auths map[string]dockerConfigEntry
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return nil, fmt.Errorf("invalid JSON")
+		}
+		return auths, nil
+	}
+
+	var config struct {
+		Auths map[string]dockerConfigEntry `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid JSON")
+	}
+	return config.Auths, nil
+}
+
+// auditDockerConfigEntry checks that a registry entry holds a credential and reads the expiry of its token
+// Parse errors are described without echoing any part of the value
+func auditDockerConfigEntry(key string, entry dockerConfigEntry, now time.Time) RegistryCredentialStatus {
+	status := RegistryCredentialStatus{Registry: registryFromConfigKey(key)}
+
+	password := entry.Password
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		username, authPassword, found := strings.Cut(string(decoded), ":")
+		switch {
+		case err != nil:
+			status.Problems = append(status.Problems, "auth is not valid base64")
+		case !found || username == "":
+			status.Problems = append(status.Problems, "auth is not in username:password form")
+		default:
+			password = authPassword
+		}
+	} else if entry.Username != "" && entry.Password == "" {
+		status.Problems = append(status.Problems, "username without a password")
+	}
+
+	var token string
+	switch {
+	case entry.IdentityToken != "":
+		status.AuthType, token = "identity-token", entry.IdentityToken
+	case entry.RegistryToken != "":
+		status.AuthType, token = "registry-token", entry.RegistryToken
+	case entry.Auth != "" || entry.Password != "":
+		status.AuthType, token = "basic", password
+	default:
+		status.Problems = append(status.Problems, "no credentials")
+	}
+
+	if expiresAt, ok := tokenExpiry(token); ok {
+		status.ExpiresAt = &expiresAt
+		status.Expired = !now.Before(expiresAt)
+	}
+	return status
+}
+
+// registryFromConfigKey reduces a docker config key such as https://index.docker.io/v1/ to its registry host
+func registryFromConfigKey(key string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	return normalizeRegistryHost(host)
+}
+
+// tokenExpiry reads the expiry of a JWT ("exp") or an ECR authorization token ("expiration")
+func tokenExpiry(token string) (time.Time, bool) {
+	if parts := strings.Split(token, "."); len(parts) == 3 {
+		payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+		if err != nil {
+			return time.Time{}, false
+		}
+		var claims struct {
+			Exp int64 `json:"exp"`
+		}
+		if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+			return time.Time{}, false
+		}
+		return time.Unix(claims.Exp, 0).UTC(), true
+	}
+
+	// ECR passwords are base64 JSON with the expiration in unix seconds
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var ecr struct {
+		Expiration int64 `json:"expiration"`
+	}
+	if err := json.Unmarshal(data, &ecr); err != nil || ecr.Expiration == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(ecr.Expiration, 0).UTC(), true
+}
+
+// pullSecretCoverage groups the images of pods with pull secrets by registry,
+// with the secrets that have an entry for each registry
+func pullSecretCoverage(secrets map[string]*PullSecretStatus, podNames []string, podSecrets, podImages map[string][]string) []RegistryCredentialCoverage {
+	coverage := make(map[string]*RegistryCredentialCoverage)
+	entry := func(registry string) *RegistryCredentialCoverage {
+		if _, exists := coverage[registry]; !exists {
+			coverage[registry] = &RegistryCredentialCoverage{Registry: registry}
+		}
+		return coverage[registry]
+	}
+
+	for _, podName := range podNames {
+		for _, image := range podImages[podName] {
+			c := entry(normalizeRegistryHost(GetRegistryFromImageRef(image)))
+			c.Images = appendUnique(c.Images, image)
+		}
+	}
+	for key, status := range secrets {
+		for _, credential := range status.Registries {
+			c := entry(credential.Registry)
+			c.Secrets = appendUnique(c.Secrets, key)
+			c.Covered = c.Covered || credential.usable()
+		}
+	}
+
+	registries := make([]RegistryCredentialCoverage, 0, len(coverage))
+	for _, c := range coverage {
+		sort.Strings(c.Images)
+		sort.Strings(c.Secrets)
+		registries = append(registries, *c)
+	}
+	sort.Slice(registries, func(i, j int) bool {
+		return registries[i].Registry < registries[j].Registry
+	})
+	return registries
+}
+
+func summarizePullSecretAudit(report *PullSecretAuditReport) PullSecretAuditSummary {
+	summary := PullSecretAuditSummary{TotalSecrets: len(report.Secrets)}
+	for _, secret := range report.Secrets {
+		switch secret.Status {
+		case PullSecretValid:
+			summary.ValidSecrets++
+		case PullSecretMissing:
+			summary.MissingSecrets++
+		case PullSecretMalformed, PullSecretWrongType:
+			summary.MalformedSecrets++
+		case PullSecretExpired:
+			summary.ExpiredSecrets++
+		case PullSecretUnreadable:
+			summary.UnreadableSecrets++
+		}
+	}
+	for _, registry := range report.Registries {
+		if len(registry.Images) > 0 && !registry.Covered {
+			summary.UncoveredRegistries++
+		}
+	}
+	return summary
+}
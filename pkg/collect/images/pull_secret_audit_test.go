@@ -0,0 +1,215 @@
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const testPullPassword = "s3cr3t-pull-password"
+
+func testPullSecret(namespace, name string, secretType corev1.SecretType, key, data string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:       secretType,
+		Data:       map[string][]byte{key: []byte(data)},
+	}
+}
+
+func testDockerConfigJSON(registry, username, password string) string {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, registry, auth)
+}
+
+func testPodWithPullSecrets(namespace, name, image string, secrets ...string) *corev1.Pod {
+	pod := testPod(namespace, name, "node-1", corev1.Container{Name: "app", Image: image})
+	for _, secret := range secrets {
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+	}
+	return pod
+}
+
+// testJWT returns an unsigned JWT expiring at exp
+func testJWT(exp time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return header + "." + payload + ".signature"
+}
+
+func TestAutoDiscoveryImageCollector_BuildPullSecretAudit(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+
+	expiredToken := testJWT(time.Now().Add(-time.Hour))
+	client := dynamicfake.NewSimpleDynamicClient(scheme,
+		testPullSecret("app", "registry-creds", corev1.SecretTypeDockerConfigJson, ".dockerconfigjson",
+			testDockerConfigJSON("https://registry.example.com/v1/", "deploy", testPullPassword)),
+		testPullSecret("app", "gcr-creds", corev1.SecretTypeDockerConfigJson, ".dockerconfigjson",
+			fmt.Sprintf(`{"auths":{"gcr.io":{"username":"oauth2accesstoken","password":%q}}}`, expiredToken)),
+		testPullSecret("app", "broken", corev1.SecretTypeDockerConfigJson, ".dockerconfigjson", `{"auths":`),
+		testPullSecret("app", "opaque", corev1.SecretTypeOpaque, "token", testPullPassword),
+		testPodWithPullSecrets("app", "web", "registry.example.com/team/web:v1", "registry-creds", "missing"),
+		testPodWithPullSecrets("app", "worker", "gcr.io/project/worker:v2", "gcr-creds", "broken", "opaque"),
+		testPodWithPullSecrets("app", "public", "nginx:1.25"),
+	)
+
+	report, err := NewAutoDiscoveryImageCollector(client).BuildPullSecretAudit(context.Background(), []string{"app"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	statuses := make(map[string]PullSecretStatus)
+	for _, secret := range report.Secrets {
+		statuses[secret.Name] = secret
+	}
+	expected := map[string]string{
+		"registry-creds": PullSecretValid,
+		"gcr-creds":      PullSecretExpired,
+		"broken":         PullSecretMalformed,
+		"opaque":         PullSecretWrongType,
+		"missing":        PullSecretMissing,
+	}
+	for name, status := range expected {
+		if statuses[name].Status != status {
+			t.Errorf("Expected %s to be %s, got %+v", name, status, statuses[name])
+		}
+	}
+
+	creds := statuses["registry-creds"]
+	if len(creds.Registries) != 1 || creds.Registries[0].Registry != "registry.example.com" || creds.Registries[0].AuthType != "basic" {
+		t.Errorf("Expected a basic credential for registry.example.com, got %+v", creds.Registries)
+	}
+	if len(creds.Pods) != 1 || creds.Pods[0] != "app/web" {
+		t.Errorf("Expected registry-creds to be used by app/web, got %v", creds.Pods)
+	}
+	if gcr := statuses["gcr-creds"].Registries[0]; !gcr.Expired || gcr.ExpiresAt == nil {
+		t.Errorf("Expected the gcr.io token to be expired, got %+v", gcr)
+	}
+
+	coverage := make(map[string]RegistryCredentialCoverage)
+	for _, registry := range report.Registries {
+		coverage[registry.Registry] = registry
+	}
+	if !coverage["registry.example.com"].Covered || coverage["gcr.io"].Covered {
+		t.Errorf("Expected registry.example.com to be covered and gcr.io not, got %+v", report.Registries)
+	}
+	if _, ok := coverage["index.docker.io"]; ok {
+		t.Errorf("Expected images of pods without pull secrets to be left out, got %+v", report.Registries)
+	}
+	if report.Summary.TotalSecrets != 5 || report.Summary.ValidSecrets != 1 || report.Summary.MalformedSecrets != 2 || report.Summary.UncoveredRegistries != 1 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Failed to marshal report: %v", err)
+	}
+	for _, secret := range []string{testPullPassword, expiredToken, base64.StdEncoding.EncodeToString([]byte("deploy:" + testPullPassword))} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected the report to leave out credentials, found %q", secret)
+		}
+	}
+}
+
+func TestAuditDockerConfigEntry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ecrToken := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"payload":"x","expiration":%d}`, now.Add(6*time.Hour).Unix())))
+
+	tests := []struct {
+		name             string
+		key              string
+		entry            dockerConfigEntry
+		expectedRegistry string
+		expectedAuthType string
+		expectProblems   bool
+		expectExpired    bool
+		expectExpiry     bool
+	}{
+		{
+			name:             "docker hub legacy key",
+			key:              "https://index.docker.io/v1/",
+			entry:            dockerConfigEntry{Auth: base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+			expectedRegistry: "index.docker.io",
+			expectedAuthType: "basic",
+		},
+		{
+			name:             "ecr token not yet expired",
+			key:              "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+			entry:            dockerConfigEntry{Username: "AWS", Password: ecrToken},
+			expectedRegistry: "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+			expectedAuthType: "basic",
+			expectExpiry:     true,
+		},
+		{
+			name:             "expired identity token",
+			key:              "myregistry.azurecr.io",
+			entry:            dockerConfigEntry{IdentityToken: testJWT(now.Add(-time.Minute))},
+			expectedRegistry: "myregistry.azurecr.io",
+			expectedAuthType: "identity-token",
+			expectExpired:    true,
+			expectExpiry:     true,
+		},
+		{
+			name:             "auth without a colon",
+			key:              "quay.io",
+			entry:            dockerConfigEntry{Auth: base64.StdEncoding.EncodeToString([]byte("token-only"))},
+			expectedRegistry: "quay.io",
+			expectedAuthType: "basic",
+			expectProblems:   true,
+		},
+		{
+			name:             "auth not base64",
+			key:              "quay.io",
+			entry:            dockerConfigEntry{Auth: "not base64!"},
+			expectedRegistry: "quay.io",
+			expectedAuthType: "basic",
+			expectProblems:   true,
+		},
+		{
+			name:             "empty entry",
+			key:              "docker.io",
+			expectedRegistry: "index.docker.io",
+			expectProblems:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := auditDockerConfigEntry(tt.key, tt.entry, now)
+			if status.Registry != tt.expectedRegistry || status.AuthType != tt.expectedAuthType {
+				t.Errorf("Expected %s with %q auth, got %+v", tt.expectedRegistry, tt.expectedAuthType, status)
+			}
+			if (len(status.Problems) > 0) != tt.expectProblems {
+				t.Errorf("Expected problems %v, got %v", tt.expectProblems, status.Problems)
+			}
+			if status.Expired != tt.expectExpired || (status.ExpiresAt != nil) != tt.expectExpiry {
+				t.Errorf("Expected expired %v with expiry %v, got %+v", tt.expectExpired, tt.expectExpiry, status)
+			}
+		})
+	}
+}
+
+func TestParseDockerConfig_Dockercfg(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"registry.example.com":{"auth":"dXNlcjpwYXNz"}}`))
+
+	auths, err := parseDockerConfig(encoded, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := auths["registry.example.com"]; !ok || len(auths) != 1 {
+		t.Errorf("Expected the legacy .dockercfg entry, got %v", auths)
+	}
+
+	if _, err := parseDockerConfig("", true); err == nil {
+		t.Errorf("Expected an error for missing data")
+	}
+}
@@ -0,0 +1,246 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RegistryCatalogFileName is the bundle file listing the registries the cluster pulls from
+const RegistryCatalogFileName = "registry-catalog.json"
+
+// registryProbeTimeout bounds each reachability probe so air-gapped registries do not stall collection
+const registryProbeTimeout = 5 * time.Second
+
+// Registry auth statuses, from the pull secrets of the pods pulling from a registry
+const (
+	RegistryAuthPullSecret         = "pull-secret"          // A pull secret has a usable credential for it
+	RegistryAuthPullSecretUnusable = "pull-secret-unusable" // Pull secrets have entries for it, none usable
+	RegistryAuthUnverified         = "unverified"           // Pulled from by pods with pull secrets that were not audited
+	RegistryAuthAnonymous          = "anonymous"            // No pod pulling from it has pull secrets, public or node credentials
+)
+
+// Registry reachability statuses, from the collection environment
+const (
+	RegistryReachable    = "reachable"     // GET /v2/ succeeded
+	RegistryAuthRequired = "auth-required" // GET /v2/ answered 401 or 403, the registry is up
+	RegistryUnreachable  = "unreachable"   // Transport error, timeout or server error
+)
+
+// RegistryCatalog aggregates the images pods pull by registry
+type RegistryCatalog struct {
+	GeneratedAt time.Time              `json:"generatedAt"`
+	Registries  []RegistryCatalogEntry `json:"registries"`
+	Summary     RegistryCatalogSummary `json:"summary"`
+}
+
+// RegistryCatalogSummary provides totals for the registry catalog
+type RegistryCatalogSummary struct {
+	TotalRegistries       int `json:"totalRegistries"`
+	TotalImages           int `json:"totalImages"`
+	UnreachableRegistries int `json:"unreachableRegistries"`
+	UnusableCredentials   int `json:"unusableCredentials"` // Registries whose pull secrets have no usable credential
+}
+
+// RegistryCatalogEntry is one registry the cluster depends on
+type RegistryCatalogEntry struct {
+	Registry     string                `json:"registry"`
+	ImageCount   int                   `json:"imageCount"`
+	Images       []string              `json:"images"`
+	Pods         int                   `json:"pods"`
+	Namespaces   []string              `json:"namespaces"`
+	Auth         string                `json:"auth"`
+	PullSecrets  []string              `json:"pullSecrets,omitempty"` // namespace/name of the secrets referenced by its pods
+	Reachability *RegistryReachability `json:"reachability,omitempty"`
+}
+
+// RegistryReachability is the result of probing a registry's /v2/ endpoint
+type RegistryReachability struct {
+	Status     string        `json:"status"`
+	Endpoint   string        `json:"endpoint,omitempty"` // The mirror or registry host that answered last
+	StatusCode int           `json:"statusCode,omitempty"`
+	Latency    time.Duration `json:"latency,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// BuildRegistryCatalog aggregates the images of the pods in the namespaces by registry and probes each registry
+// An empty namespace list covers all namespaces, audit refines auth statuses when pull secrets were audited
+func (adic *AutoDiscoveryImageCollector) BuildRegistryCatalog(ctx context.Context, namespaces []string, audit *PullSecretAuditReport) (*RegistryCatalog, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	pods, err := adic.discoverPods(ctx, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover pods: %w", err)
+	}
+
+	catalog := NewRegistryCatalog(pods, audit, time.Now())
+	if defaultClient, ok := adic.registryClient.(*DefaultRegistryClient); ok {
+		for i := range catalog.Registries {
+			reachability := defaultClient.ProbeRegistry(ctx, catalog.Registries[i].Registry)
+			catalog.Registries[i].Reachability = &reachability
+		}
+	}
+	catalog.Summary = summarizeRegistryCatalog(catalog)
+	return catalog, nil
+}
+
+// NewRegistryCatalog groups the images of the pods by registry, without probing the registries
+func NewRegistryCatalog(pods []unstructured.Unstructured, audit *PullSecretAuditReport, now time.Time) *RegistryCatalog {
+	entries := make(map[string]*RegistryCatalogEntry)
+	for _, pod := range pods {
+		var secrets []string
+		refs, _, _ := unstructured.NestedSlice(pod.Object, "spec", "imagePullSecrets")
+		for _, r := range refs {
+			if ref, ok := r.(map[string]interface{}); ok {
+				if name, _ := ref["name"].(string); name != "" {
+					secrets = appendUnique(secrets, pod.GetNamespace()+"/"+name)
+				}
+			}
+		}
+
+		counted := make(map[string]bool) // A pod counts once per registry
+		for _, container := range podContainers(pod) {
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+			registry := normalizeRegistryHost(GetRegistryFromImageRef(image))
+			entry, exists := entries[registry]
+			if !exists {
+				entry = &RegistryCatalogEntry{Registry: registry, Images: []string{}, Namespaces: []string{}}
+				entries[registry] = entry
+			}
+			if normalized, err := NormalizeImageReference(image); err == nil {
+				image = normalized
+			}
+			entry.Images = appendUnique(entry.Images, image)
+			if counted[registry] {
+				continue
+			}
+			counted[registry] = true
+			entry.Pods++
+			entry.Namespaces = appendUnique(entry.Namespaces, pod.GetNamespace())
+			for _, secret := range secrets {
+				entry.PullSecrets = appendUnique(entry.PullSecrets, secret)
+			}
+		}
+	}
+
+	coverage := make(map[string]RegistryCredentialCoverage)
+	if audit != nil {
+		for _, c := range audit.Registries {
+			coverage[c.Registry] = c
+		}
+	}
+
+	catalog := &RegistryCatalog{GeneratedAt: now, Registries: make([]RegistryCatalogEntry, 0, len(entries))}
+	for _, entry := range entries {
+		sort.Strings(entry.Images)
+		sort.Strings(entry.Namespaces)
+		sort.Strings(entry.PullSecrets)
+		entry.ImageCount = len(entry.Images)
+		entry.Auth = registryAuthStatus(*entry, coverage, audit != nil)
+		catalog.Registries = append(catalog.Registries, *entry)
+	}
+	sort.Slice(catalog.Registries, func(i, j int) bool {
+		if catalog.Registries[i].ImageCount != catalog.Registries[j].ImageCount {
+			return catalog.Registries[i].ImageCount > catalog.Registries[j].ImageCount
+		}
+		return catalog.Registries[i].Registry < catalog.Registries[j].Registry
+	})
+	catalog.Summary = summarizeRegistryCatalog(catalog)
+	return catalog
+}
+
+// registryAuthStatus derives the auth status of a registry from the pull secrets of its pods and, if audited, their coverage
+func registryAuthStatus(entry RegistryCatalogEntry, coverage map[string]RegistryCredentialCoverage, audited bool) string {
+	if len(entry.PullSecrets) == 0 {
+		return RegistryAuthAnonymous
+	}
+	if !audited {
+		return RegistryAuthUnverified
+	}
+	c, exists := coverage[entry.Registry]
+	switch {
+	case exists && c.Covered:
+		return RegistryAuthPullSecret
+	case exists && len(c.Secrets) > 0:
+		return RegistryAuthPullSecretUnusable
+	default:
+		return RegistryAuthAnonymous // None of the referenced secrets has an entry for it
+	}
+}
+
+func summarizeRegistryCatalog(catalog *RegistryCatalog) RegistryCatalogSummary {
+	summary := RegistryCatalogSummary{TotalRegistries: len(catalog.Registries)}
+	for _, entry := range catalog.Registries {
+		summary.TotalImages += entry.ImageCount
+		if entry.Auth == RegistryAuthPullSecretUnusable {
+			summary.UnusableCredentials++
+		}
+		if entry.Reachability != nil && entry.Reachability.Status == RegistryUnreachable {
+			summary.UnreachableRegistries++
+		}
+	}
+	return summary
+}
+
+// ProbeRegistry checks whether a registry answers GET /v2/ from here, trying its mirrors first
+// Credentials are sent when configured, so a 401 means they are missing or rejected
+func (rc *DefaultRegistryClient) ProbeRegistry(ctx context.Context, registry string) RegistryReachability {
+	ctx, cancel := context.WithTimeout(ctx, registryProbeTimeout)
+	defer cancel()
+
+	if err := rc.ensureAuthenticated(ctx, registry); err != nil {
+		fmt.Printf("Warning: failed to authenticate with %s, probing anonymously: %v\n", registry, err)
+	}
+
+	var result RegistryReachability
+	for _, endpoint := range rc.endpointsFor(registry) {
+		result = rc.probeEndpoint(ctx, registry, endpoint)
+		if result.Status != RegistryUnreachable {
+			return result
+		}
+	}
+	return result
+}
+
+func (rc *DefaultRegistryClient) probeEndpoint(ctx context.Context, registry string, endpoint registryEndpoint) RegistryReachability {
+	result := RegistryReachability{Endpoint: endpoint.Host}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.url("/v2/"), nil)
+	if err != nil {
+		result.Status = RegistryUnreachable
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", rc.userAgent)
+	rc.addAuthHeader(req, registry)
+
+	start := time.Now()
+	resp, err := rc.httpClient.Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Status = RegistryUnreachable
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		result.Status = RegistryAuthRequired
+	case resp.StatusCode < http.StatusBadRequest:
+		result.Status = RegistryReachable
+	default:
+		result.Status = RegistryUnreachable
+		result.Error = resp.Status
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package images
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewRegistryCatalog(t *testing.T) {
+	pods := []unstructured.Unstructured{
+		toUnstructured(t, testPod("app", "web", "node-1",
+			corev1.Container{Name: "web", Image: "registry.example.com/team/web:v1"},
+			corev1.Container{Name: "sidecar", Image: "registry.example.com/team/proxy:v2"})),
+		toUnstructured(t, testPodWithPullSecrets("app", "worker", "registry.example.com/team/web:v1", "registry-creds")),
+		toUnstructured(t, testPod("monitoring", "agent", "node-1", corev1.Container{Name: "agent", Image: "nginx:1.25"})),
+		toUnstructured(t, testPodWithPullSecrets("batch", "job", "gcr.io/project/job:v1", "gcr-creds")),
+	}
+
+	catalog := NewRegistryCatalog(pods, nil, time.Now())
+	if catalog.Summary.TotalRegistries != 3 || catalog.Summary.TotalImages != 4 {
+		t.Fatalf("Expected 3 registries and 4 images, got %+v", catalog.Summary)
+	}
+
+	first := catalog.Registries[0]
+	if first.Registry != "registry.example.com" || first.ImageCount != 2 || first.Pods != 2 {
+		t.Errorf("Expected registry.example.com first with 2 images and 2 pods, got %+v", first)
+	}
+	if !reflect.DeepEqual(first.Namespaces, []string{"app"}) || !reflect.DeepEqual(first.PullSecrets, []string{"app/registry-creds"}) {
+		t.Errorf("Expected namespace app and secret app/registry-creds, got %v and %v", first.Namespaces, first.PullSecrets)
+	}
+	if first.Auth != RegistryAuthUnverified {
+		t.Errorf("Expected pull secrets that were not audited to be %s, got %s", RegistryAuthUnverified, first.Auth)
+	}
+
+	for _, entry := range catalog.Registries[1:] {
+		if entry.Registry == "index.docker.io" {
+			if entry.Auth != RegistryAuthAnonymous || entry.Images[0] != "index.docker.io/library/nginx:1.25" {
+				t.Errorf("Expected anonymous Docker Hub pulls of nginx, got %+v", entry)
+			}
+		}
+		if entry.Reachability != nil {
+			t.Errorf("Expected no probe for %s, got %+v", entry.Registry, entry.Reachability)
+		}
+	}
+}
+
+func TestRegistryAuthStatus(t *testing.T) {
+	coverage := map[string]RegistryCredentialCoverage{
+		"registry.example.com": {Registry: "registry.example.com", Secrets: []string{"app/creds"}, Covered: true},
+		"gcr.io":               {Registry: "gcr.io", Secrets: []string{"app/expired"}},
+	}
+	tests := []struct {
+		name     string
+		entry    RegistryCatalogEntry
+		audited  bool
+		expected string
+	}{
+		{name: "no pull secrets", entry: RegistryCatalogEntry{Registry: "quay.io"}, audited: true, expected: RegistryAuthAnonymous},
+		{name: "not audited", entry: RegistryCatalogEntry{Registry: "gcr.io", PullSecrets: []string{"app/expired"}}, expected: RegistryAuthUnverified},
+		{name: "covered", entry: RegistryCatalogEntry{Registry: "registry.example.com", PullSecrets: []string{"app/creds"}}, audited: true, expected: RegistryAuthPullSecret},
+		{name: "unusable", entry: RegistryCatalogEntry{Registry: "gcr.io", PullSecrets: []string{"app/expired"}}, audited: true, expected: RegistryAuthPullSecretUnusable},
+		{name: "no entry for registry", entry: RegistryCatalogEntry{Registry: "ghcr.io", PullSecrets: []string{"app/creds"}}, audited: true, expected: RegistryAuthAnonymous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := registryAuthStatus(tt.entry, coverage, tt.audited); status != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestDefaultRegistryClient_ProbeRegistry(t *testing.T) {
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer open.Close()
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer private.Close()
+	missing := newTestMissingServer(t)
+
+	client := NewRegistryClient(5 * time.Second)
+	err := client.SetMirrors(map[string][]string{
+		"open.invalid":    {missing.URL, open.URL},
+		"private.invalid": {private.URL},
+		"down.invalid":    {missing.URL},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		registry string
+		status   string
+		endpoint string
+	}{
+		{registry: "open.invalid", status: RegistryReachable, endpoint: strings.TrimPrefix(open.URL, "http://")},
+		{registry: "private.invalid", status: RegistryAuthRequired, endpoint: strings.TrimPrefix(private.URL, "http://")},
+		{registry: "down.invalid", status: RegistryUnreachable, endpoint: "down.invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.registry, func(t *testing.T) {
+			result := client.ProbeRegistry(context.Background(), tt.registry)
+			if result.Status != tt.status || result.Endpoint != tt.endpoint {
+				t.Errorf("Expected %s from %s, got %+v", tt.status, tt.endpoint, result)
+			}
+		})
+	}
+}
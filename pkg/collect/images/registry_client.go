@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,7 +17,13 @@ type DefaultRegistryClient struct {
 	httpClient  *http.Client
 	credentials map[string]*RegistryCredentials
 	authTokens  map[string]string // registry -> auth token
+	authMu      sync.RWMutex      // guards credentials and authTokens, lookups run concurrently
+	keychain    Keychain          // resolves credentials for registries without static credentials
+	mirrors     map[string][]registryEndpoint // canonical registry -> mirrors queried before it
 	userAgent   string
+
+	captureHistory bool // keep config.history and map build instructions to layers
+	baseImageDigests map[string]string // layer digest -> base image, see IdentifyBaseImage
 }
 
 // NewRegistryClient creates a new registry client
@@ -33,10 +40,33 @@ func NewRegistryClient(timeout time.Duration) *DefaultRegistryClient {
 
 // SetCredentials sets authentication credentials for a registry
 func (rc *DefaultRegistryClient) SetCredentials(registry string, creds *RegistryCredentials) {
+	rc.authMu.Lock()
+	defer rc.authMu.Unlock()
 	rc.credentials[registry] = creds
 }
 
+// SetKeychain sets the keychain used to resolve credentials for registries without static credentials
+func (rc *DefaultRegistryClient) SetKeychain(keychain Keychain) {
+	rc.keychain = keychain
+}
+
+// SetCaptureHistory enables keeping config.history and mapping build instructions to layers
+func (rc *DefaultRegistryClient) SetCaptureHistory(enabled bool) {
+	rc.captureHistory = enabled
+}
+
+// SetBaseImageDigests sets the layer digests identifying known base images, see IdentifyBaseImage
+func (rc *DefaultRegistryClient) SetBaseImageDigests(digests map[string]string) {
+	rc.baseImageDigests = digests
+}
+
+// SetTransport sets the round tripper used for registry requests, e.g. one from NewRegistryTransport
+func (rc *DefaultRegistryClient) SetTransport(transport http.RoundTripper) {
+	rc.httpClient.Transport = transport
+}
+
 // GetImageFacts retrieves comprehensive metadata for an image
+// Mirrors of the image's registry are tried in order before the registry itself
 func (rc *DefaultRegistryClient) GetImageFacts(ctx context.Context, imageRef string) (*ImageFacts, error) {
 	// Parse image reference
 	imgRef, err := rc.parseImageReference(imageRef)
@@ -44,13 +74,22 @@ func (rc *DefaultRegistryClient) GetImageFacts(ctx context.Context, imageRef str
 		return nil, fmt.Errorf("failed to parse image reference: %w", err)
 	}
 
+	var facts *ImageFacts
+	err = rc.tryEndpoints(imgRef, func(endpoint registryEndpoint) error {
+		facts, err = rc.getImageFactsFrom(ctx, imgRef, endpoint)
+		return err
+	})
+	return facts, err
+}
+
+func (rc *DefaultRegistryClient) getImageFactsFrom(ctx context.Context, imgRef *ImageReference, endpoint registryEndpoint) (*ImageFacts, error) {
 	// Authenticate with registry
-	if err := rc.ensureAuthenticated(ctx, imgRef.Registry); err != nil {
-		return nil, fmt.Errorf("failed to authenticate with registry %s: %w", imgRef.Registry, err)
+	if err := rc.ensureAuthenticated(ctx, endpoint.Host); err != nil {
+		return nil, fmt.Errorf("failed to authenticate with registry %s: %w", endpoint.Host, err)
 	}
 
 	// Get manifest
-	manifest, err := rc.ParseManifest(ctx, imageRef)
+	manifest, err := rc.parseManifestFrom(ctx, imgRef, endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get manifest: %w", err)
 	}
@@ -58,7 +97,7 @@ func (rc *DefaultRegistryClient) GetImageFacts(ctx context.Context, imageRef str
 	// Get image configuration if available
 	var imageConfig *ImageConfig
 	if manifest.Config.Digest != "" {
-		imageConfig, err = rc.getImageConfig(ctx, imgRef, manifest.Config.Digest)
+		imageConfig, err = rc.getImageConfig(ctx, imgRef, endpoint, manifest.Config.Digest)
 		if err != nil {
 			// Log warning but continue - config is optional
 			fmt.Printf("Warning: failed to get image config: %v\n", err)
@@ -77,6 +116,9 @@ func (rc *DefaultRegistryClient) GetImageFacts(ctx context.Context, imageRef str
 		Platform:   Platform{Architecture: "amd64", OS: "linux"}, // Default, updated from manifest
 		Layers:     make([]LayerInfo, 0),
 	}
+	if endpoint.Mirror {
+		facts.ResolvedRegistry = endpoint.Host
+	}
 
 	// Add platform info if available
 	if manifest.Platform != nil {
@@ -98,6 +140,8 @@ func (rc *DefaultRegistryClient) GetImageFacts(ctx context.Context, imageRef str
 	// Add config information if available
 	if imageConfig != nil {
 		facts.Config = *imageConfig
+		applyBaseImage(facts, manifest.Annotations, rc.baseImageDigests)
+		applyImageHistory(facts, rc.captureHistory)
 		if len(imageConfig.Env) > 0 {
 			// Extract labels from environment variables if present
 			for _, env := range imageConfig.Env {
@@ -110,6 +154,8 @@ func (rc *DefaultRegistryClient) GetImageFacts(ctx context.Context, imageRef str
 				}
 			}
 		}
+	} else {
+		applyBaseImage(facts, manifest.Annotations, rc.baseImageDigests)
 	}
 
 	return facts, nil
@@ -122,12 +168,21 @@ func (rc *DefaultRegistryClient) ResolveDigest(ctx context.Context, imageRef str
 		return "", fmt.Errorf("failed to parse image reference: %w", err)
 	}
 
-	if err := rc.ensureAuthenticated(ctx, imgRef.Registry); err != nil {
+	var digest string
+	err = rc.tryEndpoints(imgRef, func(endpoint registryEndpoint) error {
+		digest, err = rc.resolveDigestFrom(ctx, imgRef, endpoint)
+		return err
+	})
+	return digest, err
+}
+
+func (rc *DefaultRegistryClient) resolveDigestFrom(ctx context.Context, imgRef *ImageReference, endpoint registryEndpoint) (string, error) {
+	if err := rc.ensureAuthenticated(ctx, endpoint.Host); err != nil {
 		return "", fmt.Errorf("failed to authenticate: %w", err)
 	}
 
 	// Build manifest URL
-	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", imgRef.Registry, imgRef.Repository, imgRef.Tag)
+	manifestURL := endpoint.url(fmt.Sprintf("/v2/%s/manifests/%s", imgRef.Repository, imgRef.Tag))
 
 	req, err := http.NewRequestWithContext(ctx, "HEAD", manifestURL, nil)
 	if err != nil {
@@ -135,7 +190,7 @@ func (rc *DefaultRegistryClient) ResolveDigest(ctx context.Context, imageRef str
 	}
 
 	// Add authentication
-	rc.addAuthHeader(req, imgRef.Registry)
+	rc.addAuthHeader(req, endpoint.Host)
 	
 	// Set accept headers for both Docker v2 and OCI formats
 	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json,application/vnd.oci.image.manifest.v1+json")
@@ -170,12 +225,20 @@ func (rc *DefaultRegistryClient) ParseManifest(ctx context.Context, imageRef str
 		return nil, fmt.Errorf("failed to parse image reference: %w", err)
 	}
 
-	if err := rc.ensureAuthenticated(ctx, imgRef.Registry); err != nil {
-		return nil, fmt.Errorf("failed to authenticate: %w", err)
-	}
+	var manifest *ManifestInfo
+	err = rc.tryEndpoints(imgRef, func(endpoint registryEndpoint) error {
+		if err := rc.ensureAuthenticated(ctx, endpoint.Host); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+		manifest, err = rc.parseManifestFrom(ctx, imgRef, endpoint)
+		return err
+	})
+	return manifest, err
+}
 
+func (rc *DefaultRegistryClient) parseManifestFrom(ctx context.Context, imgRef *ImageReference, endpoint registryEndpoint) (*ManifestInfo, error) {
 	// Build manifest URL
-	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", imgRef.Registry, imgRef.Repository, imgRef.Tag)
+	manifestURL := endpoint.url(fmt.Sprintf("/v2/%s/manifests/%s", imgRef.Repository, imgRef.Tag))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", manifestURL, nil)
 	if err != nil {
@@ -183,7 +246,7 @@ func (rc *DefaultRegistryClient) ParseManifest(ctx context.Context, imageRef str
 	}
 
 	// Add authentication
-	rc.addAuthHeader(req, imgRef.Registry)
+	rc.addAuthHeader(req, endpoint.Host)
 	
 	// Set accept headers for both Docker v2 and OCI formats
 	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json,application/vnd.oci.image.manifest.v1+json,application/vnd.docker.distribution.manifest.list.v2+json")
@@ -213,17 +276,34 @@ func (rc *DefaultRegistryClient) ParseManifest(ctx context.Context, imageRef str
 	return manifest, nil
 }
 
+// tryEndpoints calls fn with each mirror of the image's registry and then the registry itself until one succeeds
+// A failed mirror is reported and skipped, the error of the registry itself is returned when all fail
+func (rc *DefaultRegistryClient) tryEndpoints(imgRef *ImageReference, fn func(endpoint registryEndpoint) error) error {
+	var err error
+	for _, endpoint := range rc.endpointsFor(imgRef.Registry) {
+		if err = fn(endpoint); err == nil {
+			return nil
+		}
+		if endpoint.Mirror {
+			fmt.Printf("Warning: mirror %s failed for %s, trying the next endpoint: %v\n", endpoint.Host, imgRef.Original, err)
+		}
+	}
+	return err
+}
+
 // Authenticate authenticates with a registry using provided credentials
 func (rc *DefaultRegistryClient) Authenticate(ctx context.Context, registry string, credentials *RegistryCredentials) error {
 	if credentials == nil {
 		return fmt.Errorf("no credentials provided")
 	}
 
+	rc.authMu.Lock()
 	rc.credentials[registry] = credentials
+	rc.authMu.Unlock()
 
 	// For Docker Hub and other registries, we'll do token-based auth
 	if credentials.Token != "" {
-		rc.authTokens[registry] = credentials.Token
+		rc.setAuthToken(registry, credentials.Token)
 		return nil
 	}
 
@@ -235,9 +315,9 @@ func (rc *DefaultRegistryClient) Authenticate(ctx context.Context, registry stri
 			// Fall back to basic auth
 			fmt.Printf("Warning: failed to get auth token, using basic auth: %v\n", err)
 			basicAuth := base64.StdEncoding.EncodeToString([]byte(credentials.Username + ":" + credentials.Password))
-			rc.authTokens[registry] = "Basic " + basicAuth
+			rc.setAuthToken(registry, "Basic "+basicAuth)
 		} else {
-			rc.authTokens[registry] = "Bearer " + token
+			rc.setAuthToken(registry, "Bearer "+token)
 		}
 		return nil
 	}
@@ -343,23 +423,48 @@ func (rc *DefaultRegistryClient) parseImageReference(imageRef string) (*ImageRef
 }
 
 func (rc *DefaultRegistryClient) ensureAuthenticated(ctx context.Context, registry string) error {
+	rc.authMu.RLock()
+	_, authenticated := rc.authTokens[registry]
+	creds, exists := rc.credentials[registry]
+	rc.authMu.RUnlock()
+
 	// Check if we already have a token
-	if _, exists := rc.authTokens[registry]; exists {
+	if authenticated {
 		return nil
 	}
 
 	// Check if we have credentials for this registry
-	creds, exists := rc.credentials[registry]
 	if !exists {
-		// Try to authenticate with default/anonymous access
-		return nil
+		if rc.keychain == nil {
+			// Try to authenticate with default/anonymous access
+			return nil
+		}
+
+		resolved, err := rc.keychain.Resolve(ctx, registry)
+		if err != nil {
+			fmt.Printf("Warning: failed to resolve credentials for %s, using anonymous access: %v\n", registry, err)
+			return nil
+		}
+		if resolved == nil {
+			return nil
+		}
+		creds = resolved
 	}
 
 	return rc.Authenticate(ctx, registry, creds)
 }
 
+func (rc *DefaultRegistryClient) setAuthToken(registry, token string) {
+	rc.authMu.Lock()
+	defer rc.authMu.Unlock()
+	rc.authTokens[registry] = token
+}
+
 func (rc *DefaultRegistryClient) addAuthHeader(req *http.Request, registry string) {
-	if token, exists := rc.authTokens[registry]; exists {
+	rc.authMu.RLock()
+	token, exists := rc.authTokens[registry]
+	rc.authMu.RUnlock()
+	if exists {
 		if strings.HasPrefix(token, "Basic ") || strings.HasPrefix(token, "Bearer ") {
 			req.Header.Set("Authorization", token)
 		} else {
@@ -445,15 +550,15 @@ func (rc *DefaultRegistryClient) getDockerHubToken(ctx context.Context, creds *R
 	return tokenResp.Token, nil
 }
 
-func (rc *DefaultRegistryClient) getImageConfig(ctx context.Context, imgRef *ImageReference, configDigest string) (*ImageConfig, error) {
-	configURL := fmt.Sprintf("https://%s/v2/%s/blobs/%s", imgRef.Registry, imgRef.Repository, configDigest)
+func (rc *DefaultRegistryClient) getImageConfig(ctx context.Context, imgRef *ImageReference, endpoint registryEndpoint, configDigest string) (*ImageConfig, error) {
+	configURL := endpoint.url(fmt.Sprintf("/v2/%s/blobs/%s", imgRef.Repository, configDigest))
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", configURL, nil)
 	if err != nil {
 		return nil, err
 	}
 	
-	rc.addAuthHeader(req, imgRef.Registry)
+	rc.addAuthHeader(req, endpoint.Host)
 	req.Header.Set("Accept", "application/vnd.docker.container.image.v1+json")
 	
 	resp, err := rc.httpClient.Do(req)
@@ -473,13 +578,15 @@ func (rc *DefaultRegistryClient) getImageConfig(ctx context.Context, imgRef *Ima
 	
 	// Parse Docker image config format
 	var dockerConfig struct {
-		Config ImageConfig `json:"config"`
+		Config  ImageConfig    `json:"config"`
+		History []HistoryEntry `json:"history"`
 	}
 	
 	if err := json.Unmarshal(configData, &dockerConfig); err != nil {
 		return nil, fmt.Errorf("failed to parse image config: %w", err)
 	}
 	
+	dockerConfig.Config.History = dockerConfig.History
 	return &dockerConfig.Config, nil
 }
 
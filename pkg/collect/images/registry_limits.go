@@ -0,0 +1,117 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// registryLimiter bounds concurrent registry lookups, overall and per registry
+type registryLimiter struct {
+	global     chan struct{}
+	registries map[string]chan struct{}
+	limits     map[string]RegistryLimits
+	timeout    time.Duration
+	mu         sync.Mutex
+}
+
+// newRegistryLimiter creates a limiter from the collection options, MaxConcurrency below 1 runs lookups one at a time
+func newRegistryLimiter(options ImageCollectionOptions) *registryLimiter {
+	concurrency := options.MaxConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	limits := make(map[string]RegistryLimits, len(options.RegistryLimits))
+	for registry, limit := range options.RegistryLimits {
+		limits[normalizeRegistryHost(registry)] = limit
+	}
+
+	return &registryLimiter{
+		global:     make(chan struct{}, concurrency),
+		registries: make(map[string]chan struct{}),
+		limits:     limits,
+		timeout:    options.Timeout,
+	}
+}
+
+// Acquire waits for a slot for the registry and returns the function releasing it
+// The registry slot is taken first so lookups queued behind a slow registry don't hold global slots
+func (rl *registryLimiter) Acquire(ctx context.Context, registry string) (func(), error) {
+	registrySlots := rl.registrySlots(registry)
+	if registrySlots != nil {
+		select {
+		case registrySlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case rl.global <- struct{}{}:
+	case <-ctx.Done():
+		if registrySlots != nil {
+			<-registrySlots
+		}
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-rl.global
+		if registrySlots != nil {
+			<-registrySlots
+		}
+	}, nil
+}
+
+// Timeout returns the lookup timeout for the registry, 0 for none
+func (rl *registryLimiter) Timeout(registry string) time.Duration {
+	if limit, ok := rl.limits[normalizeRegistryHost(registry)]; ok && limit.Timeout > 0 {
+		return limit.Timeout
+	}
+	return rl.timeout
+}
+
+// registrySlots returns the semaphore of a registry with a concurrency override, nil otherwise
+func (rl *registryLimiter) registrySlots(registry string) chan struct{} {
+	registry = normalizeRegistryHost(registry)
+	limit, ok := rl.limits[registry]
+	if !ok || limit.MaxConcurrency < 1 {
+		return nil
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	slots, exists := rl.registries[registry]
+	if !exists {
+		slots = make(chan struct{}, limit.MaxConcurrency)
+		rl.registries[registry] = slots
+	}
+	return slots
+}
+
+// ValidateRegistryLimits checks per-registry overrides
+func ValidateRegistryLimits(limits map[string]RegistryLimits) error {
+	for registry, limit := range limits {
+		if registry == "" {
+			return fmt.Errorf("registry limits require a registry host")
+		}
+		if limit.MaxConcurrency < 0 {
+			return fmt.Errorf("max concurrency for %s cannot be negative", registry)
+		}
+		if limit.Timeout < 0 {
+			return fmt.Errorf("timeout for %s cannot be negative", registry)
+		}
+	}
+	return nil
+}
+
+// normalizeRegistryHost maps the Docker Hub aliases to the host image references resolve to
+func normalizeRegistryHost(registry string) string {
+	switch registry {
+	case "docker.io", "registry-1.docker.io":
+		return "index.docker.io"
+	}
+	return registry
+}
@@ -0,0 +1,139 @@
+package images
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyRecordingClient records the peak number of in-flight lookups per registry
+type concurrencyRecordingClient struct {
+	*MockRegistryClient
+	latency  time.Duration
+	mu       sync.Mutex
+	inFlight map[string]int
+	peak     map[string]int
+	total    int
+	peakAll  int
+}
+
+func (c *concurrencyRecordingClient) GetImageFacts(ctx context.Context, imageRef string) (*ImageFacts, error) {
+	registry := GetRegistryFromImageRef(imageRef)
+
+	c.mu.Lock()
+	c.inFlight[registry]++
+	c.total++
+	if c.inFlight[registry] > c.peak[registry] {
+		c.peak[registry] = c.inFlight[registry]
+	}
+	if c.total > c.peakAll {
+		c.peakAll = c.total
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-time.After(c.latency):
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	c.inFlight[registry]--
+	c.total--
+	c.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &ImageFacts{Registry: registry, Digest: "sha256:" + imageRef}, nil
+}
+
+func TestResilientImageCollector_RegistryLimits(t *testing.T) {
+	client := &concurrencyRecordingClient{
+		MockRegistryClient: &MockRegistryClient{},
+		latency:            20 * time.Millisecond,
+		inFlight:           make(map[string]int),
+		peak:               make(map[string]int),
+	}
+	collector := NewResilientImageCollector(client, NewErrorHandler(0, 0, FallbackNone), time.Minute)
+
+	var imageRefs []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		imageRefs = append(imageRefs, "harbor.internal/app/"+name+":v1", "library/"+name+":latest")
+	}
+
+	options := ImageCollectionOptions{
+		MaxConcurrency: 6,
+		RegistryLimits: map[string]RegistryLimits{
+			"docker.io": {MaxConcurrency: 2},
+		},
+	}
+	result, err := collector.CollectImageFacts(context.Background(), imageRefs, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Statistics.SuccessfulImages != len(imageRefs) {
+		t.Errorf("Expected %d successful images, got %d", len(imageRefs), result.Statistics.SuccessfulImages)
+	}
+	if peak := client.peak["index.docker.io"]; peak > 2 {
+		t.Errorf("Expected at most 2 concurrent Docker Hub lookups, got %d", peak)
+	}
+	if client.peakAll > 6 {
+		t.Errorf("Expected at most 6 concurrent lookups, got %d", client.peakAll)
+	}
+	if client.peak["harbor.internal"] < 2 {
+		t.Errorf("Expected parallel lookups against harbor.internal, got %d", client.peak["harbor.internal"])
+	}
+}
+
+func TestResilientImageCollector_RegistryTimeout(t *testing.T) {
+	client := &concurrencyRecordingClient{
+		MockRegistryClient: &MockRegistryClient{},
+		latency:            200 * time.Millisecond,
+		inFlight:           make(map[string]int),
+		peak:               make(map[string]int),
+	}
+	collector := NewResilientImageCollector(client, NewErrorHandler(0, 0, FallbackNone), time.Minute)
+
+	options := ImageCollectionOptions{
+		MaxConcurrency: 2,
+		RegistryLimits: map[string]RegistryLimits{
+			"slow.registry": {Timeout: 10 * time.Millisecond},
+		},
+	}
+	result, err := collector.CollectImageFacts(context.Background(), []string{"slow.registry/app:v1", "fast.registry/app:v1"}, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Errors["slow.registry/app:v1"] == nil {
+		t.Errorf("Expected slow.registry lookup to time out")
+	}
+	if result.Facts["fast.registry/app:v1"] == nil {
+		t.Errorf("Expected fast.registry lookup to use the global timeout and succeed")
+	}
+}
+
+func TestValidateRegistryLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    map[string]RegistryLimits
+		expectErr bool
+	}{
+		{name: "valid", limits: map[string]RegistryLimits{"docker.io": {MaxConcurrency: 2, Timeout: time.Second}}},
+		{name: "empty registry", limits: map[string]RegistryLimits{"": {MaxConcurrency: 2}}, expectErr: true},
+		{name: "negative concurrency", limits: map[string]RegistryLimits{"docker.io": {MaxConcurrency: -1}}, expectErr: true},
+		{name: "negative timeout", limits: map[string]RegistryLimits{"docker.io": {Timeout: -time.Second}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRegistryLimits(tt.limits)
+			if tt.expectErr && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package images
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// registryEndpoint is a host that serves a registry's images, either a mirror or the registry itself
+type registryEndpoint struct {
+	Scheme string
+	Host   string
+	Mirror bool
+}
+
+// url builds the URL of a registry API path on the endpoint, e.g. /v2/library/nginx/manifests/latest
+func (e registryEndpoint) url(path string) string {
+	return fmt.Sprintf("%s://%s%s", e.Scheme, e.Host, path)
+}
+
+// SetMirrors sets the mirror endpoints queried, in order, before each registry, like containerd's mirrors config
+// Endpoints are a host with an optional port, or an http:// or https:// URL for pull-through caches served over plain HTTP
+func (rc *DefaultRegistryClient) SetMirrors(mirrors map[string][]string) error {
+	parsed := make(map[string][]registryEndpoint, len(mirrors))
+	for registry, endpoints := range mirrors {
+		key := normalizeRegistryHost(registry) // docker.io in mirror configs matches images resolved to index.docker.io
+		for _, endpoint := range endpoints {
+			mirror, err := parseMirrorEndpoint(endpoint)
+			if err != nil {
+				return fmt.Errorf("invalid mirror for %s: %w", registry, err)
+			}
+			parsed[key] = append(parsed[key], mirror)
+		}
+	}
+	rc.mirrors = parsed
+	return nil
+}
+
+// endpointsFor returns the mirrors of a registry followed by the registry itself
+func (rc *DefaultRegistryClient) endpointsFor(registry string) []registryEndpoint {
+	mirrors := rc.mirrors[normalizeRegistryHost(registry)]
+	endpoints := make([]registryEndpoint, 0, len(mirrors)+1)
+	endpoints = append(endpoints, mirrors...)
+	return append(endpoints, registryEndpoint{Scheme: "https", Host: registry})
+}
+
+// ValidateRegistryMirrors checks that every mirror endpoint can be parsed
+func ValidateRegistryMirrors(mirrors map[string][]string) error {
+	for registry, endpoints := range mirrors {
+		if registry == "" {
+			return fmt.Errorf("mirror registry cannot be empty")
+		}
+		for _, endpoint := range endpoints {
+			if _, err := parseMirrorEndpoint(endpoint); err != nil {
+				return fmt.Errorf("invalid mirror for %s: %w", registry, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseMirrorEndpoint parses "host[:port]" or "scheme://host[:port][/v2]", https is assumed without a scheme
+func parseMirrorEndpoint(endpoint string) (registryEndpoint, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return registryEndpoint{}, fmt.Errorf("invalid mirror endpoint %q", endpoint)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return registryEndpoint{}, fmt.Errorf("unsupported scheme %q in mirror endpoint %q", u.Scheme, endpoint)
+	}
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" && path != "/v2" {
+		return registryEndpoint{}, fmt.Errorf("mirror endpoint %q cannot have a path", endpoint)
+	}
+
+	return registryEndpoint{Scheme: u.Scheme, Host: u.Host, Mirror: true}, nil
+}
@@ -0,0 +1,149 @@
+package images
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRegistryServer(t *testing.T, tls bool) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/team/app/manifests/v1"):
+			w.Header().Set("Docker-Content-Digest", "sha256:manifest")
+			w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config","size":10},"layers":[{"digest":"sha256:layer","size":20}]}`))
+		case strings.HasSuffix(r.URL.Path, "/team/app/blobs/sha256:config"):
+			w.Write([]byte(`{"config":{"User":"app"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var server *httptest.Server
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestMissingServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDefaultRegistryClient_Mirrors(t *testing.T) {
+	mirror := newTestRegistryServer(t, false)
+	missing := newTestMissingServer(t)
+
+	client := NewRegistryClient(5 * time.Second)
+	err := client.SetMirrors(map[string][]string{
+		"registry.invalid": {missing.URL, mirror.URL},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	facts, err := client.GetImageFacts(context.Background(), "registry.invalid/team/app:v1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if facts.Registry != "registry.invalid" {
+		t.Errorf("Expected the logical registry registry.invalid, got %s", facts.Registry)
+	}
+	if facts.ResolvedRegistry != strings.TrimPrefix(mirror.URL, "http://") {
+		t.Errorf("Expected the second mirror to serve the image, got %q", facts.ResolvedRegistry)
+	}
+	if facts.Config.User != "app" || len(facts.Layers) != 1 {
+		t.Errorf("Expected config and layers from the mirror, got %+v", facts)
+	}
+
+	digest, err := client.ResolveDigest(context.Background(), "registry.invalid/team/app:v1")
+	if err != nil || digest != "sha256:manifest" {
+		t.Errorf("Expected digest from the mirror, got %q, %v", digest, err)
+	}
+}
+
+func TestDefaultRegistryClient_MirrorFallback(t *testing.T) {
+	registry := newTestRegistryServer(t, true)
+	missing := newTestMissingServer(t)
+	registryHost := strings.TrimPrefix(registry.URL, "https://")
+
+	client := NewRegistryClient(5 * time.Second)
+	client.SetTransport(registry.Client().Transport)
+	if err := client.SetMirrors(map[string][]string{registryHost: {missing.URL}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	facts, err := client.GetImageFacts(context.Background(), registryHost+"/team/app:v1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if facts.Registry != registryHost || facts.ResolvedRegistry != "" {
+		t.Errorf("Expected the registry itself to serve the image, got registry %s resolved %q", facts.Registry, facts.ResolvedRegistry)
+	}
+
+	// The error of the registry itself is returned when every endpoint fails
+	if _, err := client.GetImageFacts(context.Background(), registryHost+"/team/other:v1"); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected a 404 from the registry, got %v", err)
+	}
+}
+
+func TestParseMirrorEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint       string
+		expectedScheme string
+		expectedHost   string
+		expectError    bool
+	}{
+		{endpoint: "mirror.internal:5000", expectedScheme: "https", expectedHost: "mirror.internal:5000"},
+		{endpoint: "http://cache.local", expectedScheme: "http", expectedHost: "cache.local"},
+		{endpoint: "https://mirror.gcr.io/v2/", expectedScheme: "https", expectedHost: "mirror.gcr.io"},
+		{endpoint: "https://mirror.internal/dockerhub", expectError: true},
+		{endpoint: "ftp://mirror.internal", expectError: true},
+		{endpoint: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			endpoint, err := parseMirrorEndpoint(tt.endpoint)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.endpoint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if endpoint.Scheme != tt.expectedScheme || endpoint.Host != tt.expectedHost || !endpoint.Mirror {
+				t.Errorf("Expected mirror %s://%s, got %+v", tt.expectedScheme, tt.expectedHost, endpoint)
+			}
+		})
+	}
+}
+
+func TestDefaultRegistryClient_EndpointsFor(t *testing.T) {
+	client := NewRegistryClient(0)
+	if err := client.SetMirrors(map[string][]string{"docker.io": {"mirror.gcr.io"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// docker.io in mirror configs applies to images that resolve to index.docker.io
+	endpoints := client.endpointsFor("index.docker.io")
+	if len(endpoints) != 2 || endpoints[0].Host != "mirror.gcr.io" || endpoints[1].Host != "index.docker.io" || endpoints[1].Mirror {
+		t.Errorf("Expected the mirror before Docker Hub, got %+v", endpoints)
+	}
+
+	if endpoints := client.endpointsFor("quay.io"); len(endpoints) != 1 || endpoints[0].Host != "quay.io" {
+		t.Errorf("Expected only quay.io itself, got %+v", endpoints)
+	}
+}
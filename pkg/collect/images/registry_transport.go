@@ -0,0 +1,121 @@
+package images
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// RegistryTransportConfig configures how registry requests reach registries behind proxies or private CAs
+type RegistryTransportConfig struct {
+	Proxy      string                       `json:"proxy,omitempty"`      // Proxy URL for all registry calls, empty uses HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+	CABundle   string                       `json:"caBundle,omitempty"`   // PEM file trusted for every registry in addition to the system roots
+	Registries map[string]RegistryTLSConfig `json:"registries,omitempty"` // Per-registry TLS settings keyed by the host that is contacted
+}
+
+// RegistryTLSConfig holds TLS settings for a single registry host
+type RegistryTLSConfig struct {
+	CABundle           string `json:"caBundle,omitempty"` // PEM file trusted for this registry in addition to the global bundle
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// registryTransport routes each request through the transport configured for its host
+type registryTransport struct {
+	defaultTransport *http.Transport
+	registries       map[string]*http.Transport
+}
+
+// NewRegistryTransport builds an http.RoundTripper from the transport config
+// A nil config returns a transport with the environment proxy and the system roots
+func NewRegistryTransport(config *RegistryTransportConfig) (http.RoundTripper, error) {
+	if config == nil {
+		config = &RegistryTransportConfig{}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", config.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	roots, err := loadCertPool(nil, config.CABundle)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := &registryTransport{
+		defaultTransport: newHTTPTransport(proxy, &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}),
+		registries:       make(map[string]*http.Transport),
+	}
+
+	for registry, tlsConfig := range config.Registries {
+		registryRoots, err := loadCertPool(roots, tlsConfig.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", registry, err)
+		}
+		rt.registries[registry] = newHTTPTransport(proxy, &tls.Config{
+			RootCAs:            registryRoots,
+			InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+			MinVersion:         tls.VersionTLS12,
+		})
+	}
+
+	return rt, nil
+}
+
+// RoundTrip sends the request with the registry's transport, matching host:port before the bare host
+func (rt *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.transportFor(req.URL.Host).RoundTrip(req)
+}
+
+func (rt *registryTransport) transportFor(host string) *http.Transport {
+	if transport, ok := rt.registries[host]; ok {
+		return transport
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if transport, ok := rt.registries[hostname]; ok {
+			return transport
+		}
+	}
+	return rt.defaultTransport
+}
+
+func newHTTPTransport(proxy func(*http.Request) (*url.URL, error), tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+// loadCertPool returns base (or the system roots when base is nil) plus the certificates in caBundle
+func loadCertPool(base *x509.CertPool, caBundle string) (*x509.CertPool, error) {
+	pool := base
+	if pool == nil {
+		systemRoots, err := x509.SystemCertPool()
+		if err != nil {
+			systemRoots = x509.NewCertPool()
+		}
+		pool = systemRoots
+	}
+	if caBundle == "" {
+		return pool, nil
+	}
+
+	data, err := os.ReadFile(caBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool = pool.Clone()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", caBundle)
+	}
+	return pool, nil
+}
@@ -0,0 +1,139 @@
+package images
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+	return path
+}
+
+func TestNewRegistryTransport_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	caBundle := writeServerCA(t, server)
+
+	tests := []struct {
+		name        string
+		config      *RegistryTransportConfig
+		expectError bool
+	}{
+		{
+			name:        "system roots only",
+			config:      nil,
+			expectError: true,
+		},
+		{
+			name:   "global CA bundle",
+			config: &RegistryTransportConfig{CABundle: caBundle},
+		},
+		{
+			name: "per-registry CA bundle",
+			config: &RegistryTransportConfig{Registries: map[string]RegistryTLSConfig{
+				serverURL.Host: {CABundle: caBundle},
+			}},
+		},
+		{
+			name: "per-registry skip verify by hostname",
+			config: &RegistryTransportConfig{Registries: map[string]RegistryTLSConfig{
+				serverURL.Hostname(): {InsecureSkipVerify: true},
+			}},
+		},
+		{
+			name: "skip verify for another registry",
+			config: &RegistryTransportConfig{Registries: map[string]RegistryTLSConfig{
+				"registry.example.com": {InsecureSkipVerify: true},
+			}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewRegistryTransport(tt.config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			client := &http.Client{Transport: transport}
+			resp, err := client.Get(server.URL + "/v2/")
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			if tt.expectError && err == nil {
+				t.Errorf("Expected TLS verification error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewRegistryTransport_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	transport, err := NewRegistryTransport(&RegistryTransportConfig{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get("http://registry.internal/v2/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if proxied != "http://registry.internal/v2/" {
+		t.Errorf("Expected request to go through the proxy, got %q", proxied)
+	}
+}
+
+func TestNewRegistryTransport_InvalidConfig(t *testing.T) {
+	emptyBundle := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(emptyBundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config *RegistryTransportConfig
+	}{
+		{"invalid proxy", &RegistryTransportConfig{Proxy: "proxy.local:3128"}},
+		{"missing CA bundle", &RegistryTransportConfig{CABundle: "/nonexistent/ca.pem"}},
+		{"CA bundle without certificates", &RegistryTransportConfig{CABundle: emptyBundle}},
+		{"invalid per-registry CA bundle", &RegistryTransportConfig{Registries: map[string]RegistryTLSConfig{
+			"registry.local": {CABundle: emptyBundle},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRegistryTransport(tt.config); err == nil {
+				t.Errorf("Expected error but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ImageRuntimeInfo holds what the cluster reports about an image that is already running
+type ImageRuntimeInfo struct {
+	Image     string     `json:"image"`
+	Digests   []string   `json:"digests,omitempty"`
+	Platforms []Platform `json:"platforms,omitempty"`
+	Nodes     []string   `json:"nodes,omitempty"`
+}
+
+// ImageRuntimeIndex maps canonical image names to their runtime info
+type ImageRuntimeIndex map[string]*ImageRuntimeInfo
+
+// Lookup returns the runtime info for an image reference, or nil when the image is not running
+func (idx ImageRuntimeIndex) Lookup(imageRef string) *ImageRuntimeInfo {
+	if idx == nil {
+		return nil
+	}
+	return idx[canonicalImageName(imageRef)]
+}
+
+// NewImageRuntimeIndex builds the index from pod .status.containerStatuses and node .status.nodeInfo
+func NewImageRuntimeIndex(nodes []unstructured.Unstructured, pods []unstructured.Unstructured) ImageRuntimeIndex {
+	nodePlatforms := make(map[string]Platform)
+	for _, node := range nodes {
+		if platform, ok := nodePlatform(node); ok {
+			nodePlatforms[node.GetName()] = platform
+		}
+	}
+
+	index := make(ImageRuntimeIndex)
+	for _, pod := range pods {
+		nodeName, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName")
+
+		// Status entries carry the runtime's view of the image name, so match them to the spec by container name
+		specImages := make(map[string]string)
+		for _, container := range podContainers(pod) {
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			specImages[name] = image
+		}
+
+		for _, field := range []string{"initContainerStatuses", "containerStatuses", "ephemeralContainerStatuses"} {
+			statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+			for _, s := range statuses {
+				status, ok := s.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := status["name"].(string)
+				image := specImages[name]
+				if image == "" {
+					image, _ = status["image"].(string)
+				}
+				if image == "" {
+					continue
+				}
+
+				canonical := canonicalImageName(image)
+				info, exists := index[canonical]
+				if !exists {
+					info = &ImageRuntimeInfo{Image: image}
+					index[canonical] = info
+				}
+
+				imageID, _ := status["imageID"].(string)
+				if digest := digestFromImageID(imageID); digest != "" {
+					info.Digests = appendUnique(info.Digests, digest)
+				}
+				if nodeName != "" {
+					info.Nodes = appendUnique(info.Nodes, nodeName)
+					if platform, ok := nodePlatforms[nodeName]; ok {
+						info.Platforms = appendUniquePlatform(info.Platforms, platform)
+					}
+				}
+			}
+		}
+	}
+
+	for _, info := range index {
+		sort.Strings(info.Digests)
+		sort.Strings(info.Nodes)
+	}
+
+	return index
+}
+
+// EnrichFacts fills blanks in fallback facts with the digest and platform observed in the cluster
+// Values are only applied when the cluster reports a single digest or platform for the image
+func (info *ImageRuntimeInfo) EnrichFacts(facts *ImageFacts) {
+	if info == nil || facts == nil {
+		return
+	}
+	if facts.Labels == nil {
+		facts.Labels = make(map[string]string)
+	}
+
+	switch {
+	case len(info.Digests) == 1:
+		if facts.Digest == "" {
+			facts.Digest = info.Digests[0]
+			facts.Labels["digest.source"] = "pod-status"
+		}
+	case len(info.Digests) > 1:
+		// Pods are running different builds of the same tag
+		facts.Labels["runtime.digests"] = strings.Join(info.Digests, ",")
+	}
+
+	switch {
+	case len(info.Platforms) == 1:
+		facts.Platform = info.Platforms[0]
+		facts.Labels["platform.source"] = "node-status"
+	case len(info.Platforms) > 1:
+		var platforms []string
+		for _, platform := range info.Platforms {
+			platforms = append(platforms, platform.OS+"/"+platform.Architecture)
+		}
+		facts.Labels["runtime.platforms"] = strings.Join(platforms, ",")
+	}
+
+	if len(info.Nodes) > 0 {
+		facts.Labels["runtime.nodes"] = strings.Join(info.Nodes, ",")
+	}
+}
+
+// BuildImageRuntimeIndex lists nodes and pods and indexes the images they are running
+// An empty namespace list covers all namespaces
+func (adic *AutoDiscoveryImageCollector) BuildImageRuntimeIndex(ctx context.Context, namespaces []string) ImageRuntimeIndex {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	pods, err := adic.discoverPods(ctx, namespaces)
+	if err != nil {
+		fmt.Printf("Warning: failed to discover pods for image enrichment: %v\n", err)
+		return nil
+	}
+	return adic.buildImageRuntimeIndex(ctx, pods)
+}
+
+func (adic *AutoDiscoveryImageCollector) buildImageRuntimeIndex(ctx context.Context, pods []unstructured.Unstructured) ImageRuntimeIndex {
+	nodeGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}
+
+	var nodes []unstructured.Unstructured
+	nodeList, err := adic.dynamicClient.Resource(nodeGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list nodes for image platform enrichment: %v\n", err)
+	} else {
+		nodes = nodeList.Items
+	}
+
+	return NewImageRuntimeIndex(nodes, pods)
+}
+
+// digestFromImageID extracts the repo digest from a container status imageID
+// such as docker-pullable://nginx@sha256:... or docker.io/library/nginx@sha256:...
+// A bare image ID (sha256:... without a repository) is a config digest, not a manifest digest, and is ignored
+func digestFromImageID(imageID string) string {
+	if _, rest, found := strings.Cut(imageID, "://"); found {
+		imageID = rest
+	}
+	at := strings.LastIndex(imageID, "@")
+	if at < 0 {
+		return ""
+	}
+	digest := imageID[at+1:]
+	if !strings.Contains(digest, ":") {
+		return ""
+	}
+	return digest
+}
+
+// nodePlatform reads the node OS and architecture from .status.nodeInfo, falling back to the well-known labels
+func nodePlatform(node unstructured.Unstructured) (Platform, bool) {
+	arch, _, _ := unstructured.NestedString(node.Object, "status", "nodeInfo", "architecture")
+	operatingSystem, _, _ := unstructured.NestedString(node.Object, "status", "nodeInfo", "operatingSystem")
+
+	labels := node.GetLabels()
+	if arch == "" {
+		arch = labels["kubernetes.io/arch"]
+	}
+	if operatingSystem == "" {
+		operatingSystem = labels["kubernetes.io/os"]
+	}
+
+	if arch == "" || operatingSystem == "" {
+		return Platform{}, false
+	}
+	return Platform{Architecture: arch, OS: operatingSystem}, true
+}
+
+func appendUniquePlatform(platforms []Platform, platform Platform) []Platform {
+	for _, p := range platforms {
+		if p == platform {
+			return platforms
+		}
+	}
+	return append(platforms, platform)
+}
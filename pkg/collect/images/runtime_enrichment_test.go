@@ -0,0 +1,199 @@
+package images
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testRunningPod(namespace, name, nodeName, container, image, imageID string) *corev1.Pod {
+	pod := testPod(namespace, name, nodeName, corev1.Container{Name: container, Image: image})
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: container, Image: "docker.io/library/" + image, ImageID: imageID},
+	}
+	return pod
+}
+
+func testPlatformNode(name, arch string) *corev1.Node {
+	node := testNode(name)
+	node.Status.NodeInfo = corev1.NodeSystemInfo{Architecture: arch, OperatingSystem: "linux"}
+	return node
+}
+
+func TestDigestFromImageID(t *testing.T) {
+	tests := []struct {
+		imageID  string
+		expected string
+	}{
+		{"docker-pullable://nginx@sha256:abc123", "sha256:abc123"},
+		{"docker.io/library/nginx@sha256:abc123", "sha256:abc123"},
+		{"sha256:def456", ""},
+		{"docker://sha256:def456", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.imageID, func(t *testing.T) {
+			if got := digestFromImageID(tt.imageID); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewImageRuntimeIndex(t *testing.T) {
+	nodes := []unstructured.Unstructured{
+		toUnstructured(t, testPlatformNode("amd-1", "amd64")),
+		toUnstructured(t, testPlatformNode("arm-1", "arm64")),
+	}
+	pods := []unstructured.Unstructured{
+		toUnstructured(t, testRunningPod("web", "nginx-a", "amd-1", "nginx", "nginx:1.25", "docker-pullable://nginx@sha256:aaa")),
+		toUnstructured(t, testRunningPod("web", "nginx-b", "amd-1", "nginx", "nginx:1.25", "docker.io/library/nginx@sha256:aaa")),
+		toUnstructured(t, testRunningPod("web", "redis-a", "amd-1", "redis", "redis:7", "docker.io/library/redis@sha256:r1")),
+		toUnstructured(t, testRunningPod("web", "redis-b", "arm-1", "redis", "redis:7", "docker.io/library/redis@sha256:r2")),
+	}
+
+	index := NewImageRuntimeIndex(nodes, pods)
+
+	nginx := index.Lookup("docker.io/library/nginx:1.25")
+	if nginx == nil {
+		t.Fatalf("Expected nginx:1.25 in the index")
+	}
+	if len(nginx.Digests) != 1 || nginx.Digests[0] != "sha256:aaa" {
+		t.Errorf("Expected a single nginx digest sha256:aaa, got %v", nginx.Digests)
+	}
+	if len(nginx.Platforms) != 1 || nginx.Platforms[0].Architecture != "amd64" {
+		t.Errorf("Expected a single amd64 platform for nginx, got %v", nginx.Platforms)
+	}
+
+	redis := index.Lookup("redis:7")
+	if redis == nil {
+		t.Fatalf("Expected redis:7 in the index")
+	}
+	if len(redis.Digests) != 2 || len(redis.Platforms) != 2 {
+		t.Errorf("Expected 2 digests and 2 platforms for redis, got %v and %v", redis.Digests, redis.Platforms)
+	}
+
+	if index.Lookup("postgres:16") != nil {
+		t.Errorf("Expected no runtime info for an image that is not running")
+	}
+}
+
+func TestImageRuntimeInfo_EnrichFacts(t *testing.T) {
+	tests := []struct {
+		name           string
+		info           *ImageRuntimeInfo
+		expectDigest   string
+		expectArch     string
+		expectLabel    string
+		expectLabelVal string
+	}{
+		{
+			name: "single digest and platform",
+			info: &ImageRuntimeInfo{
+				Digests:   []string{"sha256:aaa"},
+				Platforms: []Platform{{Architecture: "arm64", OS: "linux"}},
+			},
+			expectDigest:   "sha256:aaa",
+			expectArch:     "arm64",
+			expectLabel:    "digest.source",
+			expectLabelVal: "pod-status",
+		},
+		{
+			name: "conflicting digests",
+			info: &ImageRuntimeInfo{
+				Digests: []string{"sha256:aaa", "sha256:bbb"},
+			},
+			expectDigest:   "",
+			expectArch:     "amd64",
+			expectLabel:    "runtime.digests",
+			expectLabelVal: "sha256:aaa,sha256:bbb",
+		},
+		{
+			name: "multiple platforms",
+			info: &ImageRuntimeInfo{
+				Platforms: []Platform{{Architecture: "amd64", OS: "linux"}, {Architecture: "arm64", OS: "linux"}},
+			},
+			expectDigest:   "",
+			expectArch:     "amd64",
+			expectLabel:    "runtime.platforms",
+			expectLabelVal: "linux/amd64,linux/arm64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facts := &ImageFacts{Platform: Platform{Architecture: "amd64", OS: "linux"}}
+			tt.info.EnrichFacts(facts)
+
+			if facts.Digest != tt.expectDigest {
+				t.Errorf("Expected digest %q, got %q", tt.expectDigest, facts.Digest)
+			}
+			if facts.Platform.Architecture != tt.expectArch {
+				t.Errorf("Expected architecture %s, got %s", tt.expectArch, facts.Platform.Architecture)
+			}
+			if facts.Labels[tt.expectLabel] != tt.expectLabelVal {
+				t.Errorf("Expected label %s=%s, got %v", tt.expectLabel, tt.expectLabelVal, facts.Labels)
+			}
+		})
+	}
+}
+
+func TestResilientImageCollector_RuntimeEnrichment(t *testing.T) {
+	mockClient := &MockRegistryClient{digests: map[string]string{}}
+	errorHandler := NewErrorHandler(0, 0, FallbackBestEffort)
+	collector := NewResilientImageCollector(mockClient, errorHandler, 5*time.Minute)
+
+	imageRefs := []string{"private/app:v1", "private/other:v1"}
+
+	// Without runtime info the registry failure is reported as an error
+	result, err := collector.CollectImageFacts(context.Background(), imageRefs, ImageCollectionOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Statistics.FailedImages != 2 {
+		t.Errorf("Expected 2 failed images without runtime info, got %d", result.Statistics.FailedImages)
+	}
+
+	collector.SetRuntimeIndex(NewImageRuntimeIndex(
+		[]unstructured.Unstructured{toUnstructured(t, testPlatformNode("node-1", "arm64"))},
+		[]unstructured.Unstructured{toUnstructured(t, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-1",
+				Containers: []corev1.Container{{Name: "app", Image: "private/app:v1"}},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", Image: "docker.io/private/app:v1", ImageID: "docker.io/private/app@sha256:feed"},
+				},
+			},
+		})},
+	))
+
+	result, err = collector.CollectImageFacts(context.Background(), imageRefs, ImageCollectionOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Statistics.SuccessfulImages != 1 || result.Statistics.FailedImages != 1 {
+		t.Errorf("Expected 1 enriched and 1 failed image, got %+v", result.Statistics)
+	}
+
+	facts := result.Facts["private/app:v1"]
+	if facts == nil {
+		t.Fatalf("Expected fallback facts for private/app:v1")
+	}
+	if facts.Digest != "sha256:feed" {
+		t.Errorf("Expected digest sha256:feed from pod status, got %q", facts.Digest)
+	}
+	if facts.Platform.Architecture != "arm64" {
+		t.Errorf("Expected arm64 platform from node status, got %s", facts.Platform.Architecture)
+	}
+	if facts.Labels["collection.fallback"] != "best-effort" {
+		t.Errorf("Expected best-effort fallback label, got %v", facts.Labels)
+	}
+}
@@ -11,12 +11,15 @@ type ImageFacts struct {
 	Tag        string            `json:"tag"`
 	Digest     string            `json:"digest"`
 	Registry   string            `json:"registry"`
+	ResolvedRegistry string      `json:"resolvedRegistry,omitempty"` // Mirror endpoint that served the facts, empty when the registry itself did
 	Size       int64             `json:"size"`
 	Created    time.Time         `json:"created"`
 	Labels     map[string]string `json:"labels"`
 	Platform   Platform          `json:"platform"`
 	Layers     []LayerInfo       `json:"layers,omitempty"`
 	Config     ImageConfig       `json:"config,omitempty"`
+	BaseImage  string            `json:"baseImage,omitempty"`       // e.g. alpine:3.19 or distroless, see IdentifyBaseImage
+	BaseImageSource string       `json:"baseImageSource,omitempty"` // annotation, layer-digest or history
 }
 
 // Platform represents the target platform for an image
@@ -33,6 +36,8 @@ type LayerInfo struct {
 	MediaType   string    `json:"mediaType"`
 	URLs        []string  `json:"urls,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"` // Build instruction that produced the layer, from config.history
+	Created     string    `json:"created,omitempty"`
 }
 
 // ImageConfig contains image configuration details
@@ -44,6 +49,17 @@ type ImageConfig struct {
 	WorkingDir   string              `json:"workingDir,omitempty"`
 	User         string              `json:"user,omitempty"`
 	Volumes      map[string]struct{} `json:"volumes,omitempty"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	History      []HistoryEntry      `json:"history,omitempty"` // Only kept when history capture is enabled
+}
+
+// HistoryEntry is a config.history entry recording one build step of an image
+type HistoryEntry struct {
+	Created    string `json:"created,omitempty"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	EmptyLayer bool   `json:"empty_layer,omitempty"` // True for steps like ENV or CMD that add no layer
 }
 
 // RegistryClient defines the interface for interacting with container registries
@@ -95,11 +111,32 @@ type ImageCollectionOptions struct {
 	IncludeManifests bool                           `json:"includeManifests"`
 	IncludeLayers    bool                           `json:"includeLayers"`
 	IncludeConfig    bool                           `json:"includeConfig"`
+	IncludeHistory   bool                           `json:"includeHistory"` // Capture config.history and map build instructions to layers
 	Credentials      map[string]*RegistryCredentials `json:"credentials,omitempty"`
 	Timeout          time.Duration                  `json:"timeout"`
 	MaxConcurrency   int                            `json:"maxConcurrency"`
+	RegistryLimits   map[string]RegistryLimits      `json:"registryLimits,omitempty"` // Per-registry overrides of MaxConcurrency and Timeout
 	RetryCount       int                            `json:"retryCount"`
 	CacheEnabled     bool                           `json:"cacheEnabled"`
+	Transport        *RegistryTransportConfig       `json:"transport,omitempty"` // Proxy and CA settings for registry calls, nil uses the environment
+	Mirrors          map[string][]string            `json:"mirrors,omitempty"`   // Registry -> mirror endpoints queried in order before the registry itself
+	BaseImageDigests map[string]string              `json:"baseImageDigests,omitempty"` // Layer digest -> base image name, e.g. the top layer of your golden images
+	LabelFilter      *LabelFilter                   `json:"labelFilter,omitempty"`      // Labels kept in the facts, nil uses DefaultLabelFilter
+	Enrichers        []string                       `json:"enrichers,omitempty"`        // Registered enrichers run on each image's facts, in order, see RegisterEnricher
+}
+
+// labelFilter returns the configured label filter, or DefaultLabelFilter when none is set
+func (o ImageCollectionOptions) labelFilter() LabelFilter {
+	if o.LabelFilter == nil {
+		return DefaultLabelFilter()
+	}
+	return *o.LabelFilter
+}
+
+// RegistryLimits overrides the global concurrency and timeout for one registry, zero values keep the global setting
+type RegistryLimits struct {
+	MaxConcurrency int           `json:"maxConcurrency,omitempty"`
+	Timeout        time.Duration `json:"timeout,omitempty"`
 }
 
 // ImageCollectionResult represents the result of image metadata collection
@@ -119,6 +156,7 @@ type CollectionStatistics struct {
 	CacheHits         int `json:"cacheHits"`
 	CacheMisses       int `json:"cacheMisses"`
 	RegistriesAccessed int `json:"registriesAccessed"`
+	ResumedImages     int `json:"resumedImages,omitempty"` // Images already in the facts stream of an interrupted run
 }
 
 // FactsBuilder creates ImageFacts from registry data
@@ -166,6 +204,10 @@ type CollectionError struct {
 	Type     string `json:"type"`     // "auth", "network", "manifest", "config"
 	Message  string `json:"message"`
 	Retryable bool  `json:"retryable"`
+	Registry string `json:"registry,omitempty"`
+	Retries  int    `json:"retries,omitempty"`
+	Fallback string `json:"fallback,omitempty"` // Fallback facts returned instead: "partial" or "best-effort"
+	Time     time.Time `json:"time"`
 }
 
 // CacheEntry represents a cached image facts entry